@@ -0,0 +1,57 @@
+/**
+ * Bundles src/web into dist/web/tree-sitter-mcp.js for browsers and VS Code web. Node modules
+ * are replaced by the shims in src/web/shims and the native tree-sitter packages by empty
+ * modules; web-tree-sitter stays external for the host to provide with its grammars.
+ *
+ * esbuild is not a dependency of the package: npm install --no-save esbuild
+ */
+
+import { fileURLToPath } from 'url'
+import { dirname, join } from 'path'
+
+const root = join(dirname(fileURLToPath(import.meta.url)), '..')
+const shims = join(root, 'src/web/shims')
+const NODE_MODULES = /^(node:)?(fs|fs\/promises|module|os|crypto|child_process|util|url|net|zlib|chokidar)$/
+
+const NATIVE_PARSER = `export default class Parser {
+  setLanguage() {}
+  parse() { throw new Error('No grammar loaded for this language: see loadGrammars') }
+}`
+
+let esbuild
+try {
+  esbuild = await import('esbuild')
+}
+catch {
+  console.error('esbuild is required for the web build: npm install --no-save esbuild')
+  process.exit(1)
+}
+
+const shimPlugin = {
+  name: 'web-shims',
+  setup(build) {
+    build.onResolve({ filter: /^(node:)?path$/ }, () => ({ path: join(shims, 'path.ts') }))
+    build.onResolve({ filter: NODE_MODULES }, () => ({ path: join(shims, 'node.ts') }))
+    // Native parsers parse nothing; loadGrammars replaces them with web-tree-sitter parsers
+    build.onResolve({ filter: /^tree-sitter(-[a-z-]+)?$/ }, args => ({ path: args.path, namespace: 'native-tree-sitter' }))
+    build.onLoad({ filter: /.*/, namespace: 'native-tree-sitter' }, args => ({
+      contents: args.path === 'tree-sitter' ? NATIVE_PARSER : 'export default {}',
+      loader: 'js',
+    }))
+  },
+}
+
+await esbuild.build({
+  entryPoints: [join(root, 'src/web/index.ts')],
+  outfile: join(root, 'dist/web/tree-sitter-mcp.js'),
+  bundle: true,
+  format: 'esm',
+  platform: 'browser',
+  target: 'es2022',
+  sourcemap: true,
+  external: ['web-tree-sitter'],
+  plugins: [shimPlugin],
+  define: { 'process.env.NODE_ENV': '"production"' },
+  banner: { js: 'globalThis.process ??= { env: {}, cwd: () => \'/\', platform: \'browser\', argv: [] };' },
+  logLevel: 'info',
+})
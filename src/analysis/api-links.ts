@@ -0,0 +1,188 @@
+/**
+ * API client/server linking - matches outgoing HTTP calls in frontend code (fetch, axios and
+ * similar clients) to the backend routes serving them, whatever language the backend is in,
+ * and reports calls without a route and routes no client calls
+ */
+
+import { extname } from 'path'
+import { getAllNodes } from '../project/manager.js'
+import { LOGIC_EXTENSIONS, FRAMEWORK_EXTENSIONS, isTestFile } from '../constants/index.js'
+import { extractRoutes, normalizeRoutePath, routePathsMatch, HTTP_CLIENTS } from './routes.js'
+import { readCallArguments, splitTopLevel } from '../utils/helpers.js'
+import type { Project, TreeNode } from '../types/core.js'
+import type { RouteDefinition } from './routes.js'
+
+export interface HttpCall {
+  method: string // Upper-case HTTP method, or 'ANY' when it is chosen at runtime
+  url: string // As written, with template and concatenated expressions shown as {}
+  path?: string // Normalized route path; unset when the URL is built entirely at runtime
+  file: string
+  line: number
+  client: string
+}
+
+export interface ApiLink {
+  call: HttpCall
+  routes: RouteDefinition[]
+}
+
+export interface ApiLinkReport {
+  links: ApiLink[]
+  unmatchedCalls: HttpCall[]
+  unmatchedRoutes: RouteDefinition[]
+  dynamicCalls: HttpCall[]
+}
+
+const CLIENT_EXTENSIONS: string[] = [
+  ...LOGIC_EXTENSIONS.JAVASCRIPT,
+  ...LOGIC_EXTENSIONS.TYPESCRIPT,
+  ...FRAMEWORK_EXTENSIONS.REACT_JSX,
+  ...FRAMEWORK_EXTENSIONS.REACT_TSX,
+  ...FRAMEWORK_EXTENSIONS.VUE,
+  ...FRAMEWORK_EXTENSIONS.SVELTE,
+  ...FRAMEWORK_EXTENSIONS.ASTRO,
+]
+
+const FETCH_CALL = /(?<![\w$.])(fetch|\$fetch|ofetch|useFetch|useSWR)\s*(?:<[^>()]*>)?\s*\(/g
+// axios.get(...), api.post<User>(...), this.http.put(...), userClient.delete(...)
+const CLIENT_METHOD_CALL = new RegExp(`(?<![\\w$.])((?:this\\.)?(?:${HTTP_CLIENTS}|\\w*(?:Api|Client)))\\.(get|post|put|delete|patch|head|options)\\s*(?:<[^>()]*>)?\\s*\\(`, 'g')
+const CLIENT_CONFIG_CALL = /(?<![\w$.])(axios|request|\$http)(?:\.request)?\s*\(\s*\{/g
+
+/**
+ * Links the HTTP calls of a project's frontend code to its backend routes
+ */
+export function linkApiCalls(project: Project): ApiLinkReport {
+  return buildApiLinks(getAllNodes(project).filter(node => node.type === 'file'))
+}
+
+/**
+ * Cross-references HTTP calls and route registrations found in file nodes. Test files are
+ * ignored on both sides. Routes are only reported unmatched when the code makes calls at all.
+ */
+export function buildApiLinks(fileNodes: TreeNode[]): ApiLinkReport {
+  const sources = fileNodes.filter(node => !isTestFile(node.path))
+  const calls = extractHttpCalls(sources)
+  // A client call the route patterns also accepted (e.g. `users.get('/x')`) is not a route
+  const callSites = new Set(calls.map(call => `${call.file}:${call.line}`))
+  const routes = extractRoutes(sources).filter(route => !callSites.has(`${route.file}:${route.line}`))
+  const matchedRoutes = new Set<RouteDefinition>()
+
+  const links: ApiLink[] = []
+  const unmatchedCalls: HttpCall[] = []
+  const dynamicCalls: HttpCall[] = []
+
+  for (const call of calls) {
+    if (!call.path) {
+      dynamicCalls.push(call)
+      continue
+    }
+
+    const candidates = routes.filter(route => methodsMatch(route.method, call.method) && routePathsMatch(route.path, call.path!))
+    // Prefer exact paths over prefix matches so /api/users doesn't also link every /users route
+    const exact = candidates.filter(route => normalizeRoutePath(route.path) === call.path)
+    const matched = exact.length > 0 ? exact : candidates
+
+    if (matched.length === 0) {
+      unmatchedCalls.push(call)
+      continue
+    }
+
+    matched.forEach(route => matchedRoutes.add(route))
+    links.push({ call, routes: matched })
+  }
+
+  return {
+    links,
+    unmatchedCalls,
+    unmatchedRoutes: calls.length > 0 ? routes.filter(route => !matchedRoutes.has(route)) : [],
+    dynamicCalls,
+  }
+}
+
+/**
+ * Finds fetch, axios-style and framework client calls in JavaScript/TypeScript sources
+ */
+export function extractHttpCalls(fileNodes: TreeNode[]): HttpCall[] {
+  const calls: HttpCall[] = []
+
+  for (const fileNode of fileNodes) {
+    const content = fileNode.content
+    if (!content || !CLIENT_EXTENSIONS.includes(extname(fileNode.path))) continue
+
+    const add = (index: number, client: string, method: string, urlSource: string | undefined) => {
+      if (!urlSource) return
+      const url = readUrl(urlSource)
+      calls.push({
+        method,
+        url,
+        path: toRoutePath(url),
+        file: fileNode.path,
+        line: content.substring(0, index).split('\n').length,
+        client,
+      })
+    }
+
+    for (const match of content.matchAll(FETCH_CALL)) {
+      const call = readCallArguments(content, match.index + match[0].length - 1)
+      if (!call || call.args.length === 0) continue
+      add(match.index, match[1]!, readMethod(call.args[1]) ?? 'GET', call.args[0])
+    }
+
+    for (const match of content.matchAll(CLIENT_METHOD_CALL)) {
+      const call = readCallArguments(content, match.index + match[0].length - 1)
+      if (!call || call.args.length === 0) continue
+      add(match.index, match[1]!, match[2]!.toUpperCase(), call.args[0])
+    }
+
+    for (const match of content.matchAll(CLIENT_CONFIG_CALL)) {
+      const call = readCallArguments(content, content.indexOf('(', match.index))
+      const config = call?.args[0]
+      if (!config) continue
+      add(match.index, match[1]!, readMethod(config) ?? 'GET', readProperty(config, 'url'))
+    }
+  }
+
+  return calls.sort((a, b) => a.file.localeCompare(b.file) || a.line - b.line)
+}
+
+function methodsMatch(routeMethod: string, callMethod: string): boolean {
+  return routeMethod === callMethod || routeMethod === 'ANY' || routeMethod === 'ALL' || callMethod === 'ANY'
+}
+
+function readMethod(options: string | undefined): string | undefined {
+  if (!options || !/\bmethod\s*:/.test(options)) return undefined
+  const method = readProperty(options, 'method')
+  const literal = method?.match(/^['"`](\w+)['"`]$/)?.[1]
+  return literal ? literal.toUpperCase() : 'ANY'
+}
+
+function readProperty(objectSource: string, name: string): string | undefined {
+  const body = objectSource.trim().replace(/^\{|\}$/g, '')
+  for (const entry of splitTopLevel(body)) {
+    const property = entry.trim().match(/^['"]?(\w+)['"]?\s*:\s*([\s\S]+)$/)
+    if (property?.[1] === name) return property[2]!.trim()
+    // Shorthand `{ url, method }` is a runtime value
+    if (entry.trim() === name) return name
+  }
+  return undefined
+}
+
+function readUrl(source: string): string {
+  // '/users/' + id and `/users/${id}` both become /users/{}
+  return splitTopLevel(source, '+').map((part) => {
+    const literal = part.trim().match(/^(['"`])([\s\S]*)\1$/)
+    if (!literal) return '{}'
+    return literal[1] === '`' ? literal[2]!.replace(/\$\{[^}]*\}/g, '{}') : literal[2]!
+  }).join('')
+}
+
+function toRoutePath(url: string): string | undefined {
+  const path = url
+    .replace(/^(?:https?:)?\/\/[^/]+/i, '') // Absolute URLs: keep the path
+    .replace(/^\{\}(?=\/)/, '') // `${API_BASE}/users`: the base is configuration, not part of the route
+    .split(/[?#]/)[0]!
+    .replace(/([^/])\{\}$/, '$1') // `/users${query}` appends a query string
+
+  if (!path || path.startsWith('{}')) return undefined
+  return normalizeRoutePath(path)
+}
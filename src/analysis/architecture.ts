@@ -0,0 +1,225 @@
+/**
+ * Architecture summary - a machine-readable onboarding map of a project: its directory
+ * structure with languages and detected frameworks, entry points, the most referenced types
+ * and the import dependencies between directories
+ */
+
+import { dirname, join, relative, sep } from 'path'
+import { getAllNodes } from '../project/manager.js'
+import { getAliasIndex } from '../import/aliases.js'
+import { getLanguageForFile } from '../core/languages.js'
+import { symbolId } from '../core/symbol-ids.js'
+import { extractRoutes } from './routes.js'
+import { listEntryPoints, type EntryPoint } from './entry-points.js'
+import { isTestFile } from '../constants/index.js'
+import { detectFrameworks } from '../project/frameworks.js'
+import type { DetectedFramework, Project, TreeNode } from '../types/core.js'
+
+export interface ArchitectureSummary {
+  root: string
+  files: number
+  languages: { language: string, files: number }[]
+  frameworks: DetectedFramework[] // Project-relative directories, '.' for the root; `source` may also be `routes`
+  directories: DirectorySummary[]
+  entryPoints: EntryPoint[]
+  keyTypes: KeyType[]
+  dependencies: DirectoryDependency[]
+}
+
+export interface DirectorySummary {
+  path: string
+  files: number
+  languages: string[]
+  symbols: number
+  frameworks: string[]
+}
+
+export interface KeyType {
+  id: string
+  name: string
+  kind: string
+  path: string
+  line?: number
+  references: number // Occurrences of the name outside the declaring file
+}
+
+export interface DirectoryDependency {
+  from: string
+  to: string
+  imports: number // Files of `from` importing from `to`
+}
+
+export interface ArchitectureOptions {
+  depth?: number // Directory levels to group files by; default 2 (e.g. src/api)
+  maxKeyTypes?: number
+}
+
+// Route extraction reports frameworks by its own ids
+const ROUTE_FRAMEWORKS: Record<string, { id: string, name: string }> = {
+  express: { id: 'express', name: 'Express' },
+  nestjs: { id: 'nestjs', name: 'NestJS' },
+  fastapi: { id: 'fastapi', name: 'FastAPI' },
+  flask: { id: 'flask', name: 'Flask' },
+  django: { id: 'django', name: 'Django' },
+  spring: { id: 'spring-boot', name: 'Spring Boot' },
+  rails: { id: 'rails', name: 'Rails' },
+  phoenix: { id: 'phoenix', name: 'Phoenix' },
+  go: { id: 'go-net-http', name: 'Go net/http' },
+}
+
+const TYPE_KINDS = ['class', 'interface', 'struct', 'enum', 'trait', 'type']
+const DEFAULT_DEPTH = 2
+const DEFAULT_MAX_KEY_TYPES = 15
+const IDENTIFIER = /[A-Za-z_$][\w$]*/g
+
+/**
+ * Summarizes the architecture of a project. Test files count towards the structure but are
+ * not entry points and do not make types key.
+ */
+export function summarizeArchitecture(project: Project, options: ArchitectureOptions = {}): ArchitectureSummary {
+  const depth = Math.max(options.depth ?? DEFAULT_DEPTH, 1)
+  const root = project.config.directory
+  const nodes = uniqueNodes(project)
+  const files = nodes.filter(node => node.type === 'file')
+  const directoryOf = (path: string) => groupDirectory(root, path, depth)
+
+  const directories = new Map<string, DirectorySummary>()
+  const languages = new Map<string, number>()
+  for (const file of files) {
+    const language = getLanguageForFile(file.path)?.name ?? 'other'
+    languages.set(language, (languages.get(language) ?? 0) + 1)
+
+    const path = directoryOf(file.path)
+    const summary = directories.get(path) ?? { path, files: 0, languages: [], symbols: 0, frameworks: [] }
+    summary.files++
+    if (!summary.languages.includes(language)) summary.languages.push(language)
+    directories.set(path, summary)
+  }
+  for (const node of nodes) {
+    if (node.type !== 'file' && node.symbol) {
+      const summary = directories.get(directoryOf(node.path))
+      if (summary) summary.symbols++
+    }
+  }
+
+  const frameworks = detectFrameworks(root, files, ['.', ...directories.keys()].map(directory => join(root, directory)))
+    .map(framework => ({ ...framework, directory: groupPath(root, framework.directory, depth) }))
+  const entryPoints = [...listEntryPoints(project), ...findRouteFiles(files)]
+    .sort((a, b) => a.path.localeCompare(b.path) || (a.line ?? 0) - (b.line ?? 0))
+  for (const entry of entryPoints) {
+    if (entry.kind !== 'routes') continue
+    const directory = directoryOf(entry.path)
+    const framework = Object.values(ROUTE_FRAMEWORKS).find(candidate => candidate.name === entry.framework)
+      ?? { id: entry.framework!, name: entry.framework! }
+    if (!frameworks.some(found => found.id === framework.id && found.directory === directory)) {
+      frameworks.push({ ...framework, directory, source: 'routes' })
+    }
+  }
+  for (const framework of frameworks) {
+    const summary = directories.get(framework.directory)
+    if (summary && !summary.frameworks.includes(framework.name)) summary.frameworks.push(framework.name)
+  }
+
+  return {
+    root,
+    files: files.length,
+    languages: [...languages].map(([language, count]) => ({ language, files: count })).sort((a, b) => b.files - a.files || a.language.localeCompare(b.language)),
+    frameworks,
+    directories: [...directories.values()].sort((a, b) => a.path.localeCompare(b.path)),
+    entryPoints,
+    keyTypes: findKeyTypes(root, nodes, files, options.maxKeyTypes ?? DEFAULT_MAX_KEY_TYPES),
+    dependencies: directoryDependencies(project, directoryOf),
+  }
+}
+
+function uniqueNodes(project: Project): TreeNode[] {
+  const seen = new Set<string>()
+  return getAllNodes(project).filter((node) => {
+    const key = node.type === 'file' ? `file:${node.path}` : node.id
+    if (seen.has(key)) return false
+    seen.add(key)
+    return true
+  })
+}
+
+/**
+ * The first `depth` segments of a file's project-relative directory; '.' for root files
+ */
+function groupDirectory(root: string, path: string, depth: number): string {
+  return groupPath(root, dirname(path), depth)
+}
+
+function groupPath(root: string, directory: string, depth: number): string {
+  return relative(root, directory).split(sep).filter(Boolean).slice(0, depth).join('/') || '.'
+}
+
+// One entry point per file registering HTTP routes
+function findRouteFiles(files: TreeNode[]): EntryPoint[] {
+  const routesByFile = new Map<string, ReturnType<typeof extractRoutes>>()
+  for (const route of extractRoutes(files.filter(file => !isTestFile(file.path)))) {
+    routesByFile.set(route.file, [...(routesByFile.get(route.file) ?? []), route])
+  }
+  return [...routesByFile].map(([path, routes]): EntryPoint => {
+    const framework = routes[0]!.framework
+    return { kind: 'routes', path, line: routes[0]!.line, framework: ROUTE_FRAMEWORKS[framework]?.name ?? framework, routes: routes.length }
+  })
+}
+
+/**
+ * Types ranked by how often their name appears in other files
+ */
+function findKeyTypes(root: string, nodes: TreeNode[], files: TreeNode[], maxResults: number): KeyType[] {
+  const types = nodes.filter(node => node.name && TYPE_KINDS.includes(node.symbol?.kind ?? node.type) && !isTestFile(node.path))
+  if (types.length === 0) return []
+
+  const names = new Set(types.map(node => node.name!))
+  const totals = new Map<string, number>()
+  const perFile = new Map<string, Map<string, number>>()
+  for (const file of files) {
+    if (!file.content || isTestFile(file.path)) continue
+    const counts = new Map<string, number>()
+    for (const [identifier] of file.content.matchAll(IDENTIFIER)) {
+      if (names.has(identifier)) counts.set(identifier, (counts.get(identifier) ?? 0) + 1)
+    }
+    perFile.set(file.path, counts)
+    for (const [name, count] of counts) totals.set(name, (totals.get(name) ?? 0) + count)
+  }
+
+  return types
+    .map(node => ({
+      id: symbolId(root, node) ?? node.path,
+      name: node.name!,
+      kind: node.symbol?.kind ?? node.type,
+      path: node.path,
+      line: node.startLine,
+      references: (totals.get(node.name!) ?? 0) - (perFile.get(node.path)?.get(node.name!) ?? 0),
+    }))
+    .filter(type => type.references > 0)
+    .sort((a, b) => b.references - a.references || a.id.localeCompare(b.id))
+    .slice(0, maxResults)
+}
+
+/**
+ * Import edges between directory groups, from the alias index of JavaScript, TypeScript,
+ * Python and Go files. Imports within a group and of external packages are left out.
+ */
+function directoryDependencies(project: Project, directoryOf: (path: string) => string): DirectoryDependency[] {
+  const root = project.config.directory
+  const edges = new Map<string, DirectoryDependency>()
+
+  for (const [file, bindings] of getAliasIndex(project).imports) {
+    const from = directoryOf(file)
+    const modules = new Set(bindings.map(binding => binding.module).filter(module => module.startsWith(root + sep)))
+    for (const module of modules) {
+      // Go modules are package directories; group them like a file inside them
+      const to = directoryOf(getLanguageForFile(module) ? module : join(module, 'package'))
+      if (to === from) continue
+      const key = `${from}\0${to}`
+      const edge = edges.get(key) ?? { from, to, imports: 0 }
+      edge.imports++
+      edges.set(key, edge)
+    }
+  }
+
+  return [...edges.values()].sort((a, b) => a.from.localeCompare(b.from) || b.imports - a.imports || a.to.localeCompare(b.to))
+}
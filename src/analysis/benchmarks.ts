@@ -0,0 +1,412 @@
+/**
+ * Benchmarks - finds Go benchmarks and JavaScript benchmark suites (Vitest `bench`, Benchmark.js,
+ * tinybench, mitata), and runs a selection at two git refs in temporary worktrees to report how
+ * much faster or slower each got
+ */
+
+import { execFile } from 'child_process'
+import { existsSync, mkdtempSync, readFileSync, rmSync, symlinkSync } from 'fs'
+import { tmpdir } from 'os'
+import { dirname, join, posix, relative } from 'path'
+import { promisify } from 'util'
+import { maskGo, matchingBrace, matchingParen } from '../core/go-source.js'
+import { maskSource } from '../core/strings.js'
+import { findTestFiles } from '../project/test-files.js'
+import { BENCHMARK_CONFIG, PROJECT_FILES } from '../constants/index.js'
+import { isFile } from '../utils/helpers.js'
+
+const execFileAsync = promisify(execFile)
+
+export const BENCHMARK_FRAMEWORKS = ['go', 'vitest', 'benchmark.js', 'tinybench', 'mitata'] as const
+export type BenchmarkFramework = typeof BENCHMARK_FRAMEWORKS[number]
+
+export interface Benchmark {
+  name: string
+  file: string // Relative to the project root
+  line: number
+  framework: BenchmarkFramework
+  group?: string // Enclosing `describe` or `group`
+  cases?: string[] // Go sub-benchmarks with literal names
+  command: string // Runs it (JavaScript suites: the whole file)
+}
+
+export interface BenchmarkMeasurement {
+  nsPerOp: number // Median over the runs
+  bytesPerOp?: number
+  allocsPerOp?: number
+  runs: number
+}
+
+export type BenchmarkVerdict = 'faster' | 'slower' | 'unchanged' | 'added' | 'removed'
+
+export interface BenchmarkDelta {
+  name: string
+  file?: string
+  framework?: BenchmarkFramework
+  base?: BenchmarkMeasurement
+  head?: BenchmarkMeasurement
+  deltaPercent?: number // Change of the time per operation; negative is faster
+  allocsDeltaPercent?: number
+  verdict: BenchmarkVerdict
+}
+
+export interface BenchmarkComparison {
+  base: { ref: string, commit: string }
+  head: { ref: string, commit: string } // `WORKTREE` when comparing against uncommitted changes
+  count: number
+  thresholdPercent: number
+  results: BenchmarkDelta[]
+  errors: { ref: string, command: string, message: string }[]
+}
+
+export interface BenchmarkCompareOptions {
+  pattern?: string // Regular expression over benchmark names
+  count?: number
+  thresholdPercent?: number
+}
+
+interface Run {
+  framework: BenchmarkFramework
+  cwd: string // Relative to the project root
+  command: string
+  args: string[]
+  outputFile?: string // Where Vitest writes its results as JSON
+}
+
+const JS_BENCHMARK_FILE = /\.(?:bench|benchmark)\.[cm]?[jt]sx?$|(?:^|\/)(?:bench|benchmarks?|__benchmarks__)\/.*\.[cm]?[jt]sx?$/
+const GO_BENCHMARK = /^func\s+(Benchmark(?![a-z])\w*)\s*\(\s*(\w+)\s+\*testing\.B\s*\)\s*\{/gm
+const MAX_FILES = 2000
+const WORKTREE = 'WORKTREE'
+
+/**
+ * Benchmarks under the root, optionally only those whose name matches the pattern
+ */
+export function listBenchmarks(root: string, pattern?: string): Benchmark[] {
+  const matcher = pattern ? new RegExp(pattern) : undefined
+  const files = findTestFiles(root, path => path.endsWith('_test.go') || JS_BENCHMARK_FILE.test(path), MAX_FILES)
+  return files.flatMap((path) => {
+    const file = toRelative(root, path)
+    const content = readFileSync(path, 'utf-8')
+    if (file.endsWith('.go') && !content.includes('*testing.B')) return []
+    return readBenchmarks(file, content, moduleOf(root, file))
+  }).filter(benchmark => !matcher || matcher.test(benchmark.name) || (benchmark.group !== undefined && matcher.test(`${benchmark.group} ${benchmark.name}`)))
+}
+
+/**
+ * Benchmarks declared in one file; `moduleDir` is the directory holding its go.mod or
+ * package.json, relative to the root
+ */
+export function readBenchmarks(file: string, content: string, moduleDir = '.'): Benchmark[] {
+  if (file.endsWith('.go')) return readGoBenchmarks(file, content, moduleDir)
+  const framework = jsFramework(content)
+  return framework ? readJsBenchmarks(file, content, framework, moduleDir) : []
+}
+
+function readGoBenchmarks(file: string, content: string, moduleDir: string): Benchmark[] {
+  const code = maskGo(content)
+  const benchmarks: Benchmark[] = []
+  for (const match of code.matchAll(GO_BENCHMARK)) {
+    const [, name, receiver] = match as unknown as [string, string, string]
+    const open = match.index + match[0].length - 1
+    const body = content.substring(open, matchingBrace(code, open))
+    const cases = [...body.matchAll(new RegExp(`\\b${receiver}\\.Run\\(\\s*"([^"]+)"`, 'g'))].map(run => run[1]!)
+    benchmarks.push({
+      name,
+      file,
+      line: lineOf(content, match.index),
+      framework: 'go',
+      ...(cases.length > 0 ? { cases } : {}),
+      command: shellCommand(goRun(file, moduleDir, [name], 1)),
+    })
+  }
+  return benchmarks
+}
+
+function readJsBenchmarks(file: string, content: string, framework: BenchmarkFramework, moduleDir: string): Benchmark[] {
+  const code = maskSource(content, file)
+  const inCode = (index: number) => code[index] !== ' ' && code[index] !== undefined
+  const groups = [...content.matchAll(/\b(?:describe|group)\(\s*(['"`])([^'"`]+)\1/g)]
+    .filter(match => inCode(match.index))
+    .map(match => {
+      const open = match.index + match[0].indexOf('(')
+      return { name: match[2]!, start: open, end: matchingParen(code, open) }
+    })
+
+  const declaration = framework === 'benchmark.js' || framework === 'tinybench'
+    ? /\.add\(\s*(['"`])([^'"`]+)\1/g
+    : /\bbench\(\s*(['"`])([^'"`]+)\1/g
+  const run = jsRun(file, moduleDir, framework)
+  return [...content.matchAll(declaration)].filter(match => inCode(match.index)).map((match) => {
+    const group = groups.filter(candidate => candidate.start < match.index && match.index < candidate.end).at(-1)
+    return {
+      name: match[2]!,
+      file,
+      line: lineOf(content, match.index),
+      framework,
+      ...(group ? { group: group.name } : {}),
+      command: shellCommand(run),
+    }
+  })
+}
+
+function jsFramework(content: string): BenchmarkFramework | undefined {
+  const imports = (module: string) => new RegExp(`(?:from\\s+|require\\(\\s*)['"]${module}['"]`).test(content)
+  if (imports('vitest') && /\bbench\(/.test(content)) return 'vitest'
+  if (imports('benchmark')) return 'benchmark.js'
+  if (imports('tinybench')) return 'tinybench'
+  if (imports('mitata')) return 'mitata'
+  return undefined
+}
+
+function goRun(file: string, moduleDir: string, names: string[], count: number): Run {
+  const pkg = posix.relative(moduleDir, posix.dirname(file)) || '.'
+  const selector = names.length === 1 ? `^${names[0]}$` : `^(?:${names.join('|')})$`
+  return {
+    framework: 'go',
+    cwd: moduleDir,
+    command: 'go',
+    args: ['test', pkg === '.' ? '.' : `./${pkg}`, '-run', '^$', '-bench', selector, '-benchmem', ...(count > 1 ? ['-count', String(count)] : [])],
+  }
+}
+
+function jsRun(file: string, moduleDir: string, framework: BenchmarkFramework): Run {
+  const path = posix.relative(moduleDir, file)
+  if (framework === 'vitest') return { framework, cwd: moduleDir, command: 'npx', args: ['vitest', 'bench', '--run', path] }
+  return /\.[cm]?ts$|\.tsx$/.test(file)
+    ? { framework, cwd: moduleDir, command: 'npx', args: ['tsx', path] }
+    : { framework, cwd: moduleDir, command: 'node', args: [path] }
+}
+
+function shellCommand(run: Run): string {
+  const quoted = run.args.map(arg => /^[\w./=-]+$/.test(arg) ? arg : `'${arg}'`)
+  return `${run.cwd === '.' ? '' : `cd ${run.cwd} && `}${run.command} ${quoted.join(' ')}`
+}
+
+/**
+ * Runs the benchmarks matching the pattern at both refs, in detached worktrees (the working
+ * tree itself when no head ref is given), and compares the median time per operation
+ */
+export async function compareBenchmarks(root: string, baseRef: string, headRef: string | undefined, options: BenchmarkCompareOptions = {}): Promise<BenchmarkComparison> {
+  const { pattern, count = BENCHMARK_CONFIG.DEFAULT_COUNT, thresholdPercent = BENCHMARK_CONFIG.DEFAULT_THRESHOLD_PERCENT } = options
+  const benchmarks = listBenchmarks(root, pattern)
+  if (benchmarks.length === 0) {
+    throw new Error(pattern ? `No benchmarks match ${pattern}` : 'No benchmarks found')
+  }
+
+  const top = (await git(root, ['rev-parse', '--show-toplevel'])).trim()
+  const prefix = relative(top, root)
+  const runs = planRuns(root, benchmarks, count)
+  const errors: BenchmarkComparison['errors'] = []
+
+  const measure = async (ref: string | undefined) => {
+    const commit = ref ? await resolveCommit(root, ref) : WORKTREE
+    const worktree = ref ? mkdtempSync(join(tmpdir(), 'tree-sitter-mcp-bench-')) : undefined
+    try {
+      if (worktree) {
+        await git(root, ['worktree', 'add', '--detach', worktree, commit])
+      }
+      const directory = worktree ? join(worktree, prefix) : root
+      const results = new Map<string, BenchmarkMeasurement>()
+      for (const run of runs) {
+        if (worktree) linkNodeModules(join(root, run.cwd), join(directory, run.cwd))
+        const output = await execute(run, directory, ref ?? WORKTREE, errors)
+        for (const [name, measurement] of parseBenchmarkOutput(run.framework, output)) results.set(name, measurement)
+      }
+      return { ref: ref ?? WORKTREE, commit, results }
+    }
+    finally {
+      if (worktree) {
+        await git(root, ['worktree', 'remove', '--force', worktree]).catch(() => undefined)
+        rmSync(worktree, { recursive: true, force: true })
+      }
+    }
+  }
+
+  const base = await measure(baseRef)
+  const head = await measure(headRef)
+  return {
+    base: { ref: base.ref, commit: base.commit },
+    head: { ref: head.ref, commit: head.commit },
+    count,
+    thresholdPercent,
+    results: compareMeasurements(base.results, head.results, benchmarks, thresholdPercent),
+    errors,
+  }
+}
+
+/**
+ * One command per Go package and per JavaScript file; Go repeats with `-count`, the JavaScript
+ * runners `count` times
+ */
+function planRuns(root: string, benchmarks: Benchmark[], count: number): Run[] {
+  const groups = new Map<string, Benchmark[]>()
+  for (const benchmark of benchmarks) {
+    const key = benchmark.framework === 'go' ? posix.dirname(benchmark.file) : benchmark.file
+    groups.set(key, [...groups.get(key) ?? [], benchmark])
+  }
+
+  return [...groups.values()].flatMap((group) => {
+    const { file, framework } = group[0]!
+    const moduleDir = moduleOf(root, file)
+    if (framework === 'go') return [goRun(file, moduleDir, group.map(benchmark => benchmark.name), count)]
+    const run = jsRun(file, moduleDir, framework)
+    return Array.from({ length: count }, (_, index) => {
+      if (framework !== 'vitest') return run
+      const outputFile = join(tmpdir(), `tree-sitter-mcp-bench-${process.pid}-${index}.json`)
+      return { ...run, args: [...run.args, '--outputJson', outputFile], outputFile }
+    })
+  })
+}
+
+async function execute(run: Run, directory: string, ref: string, errors: BenchmarkComparison['errors']): Promise<string> {
+  const cwd = join(directory, run.cwd)
+  const command = shellCommand({ ...run, cwd: '.' })
+  let output = ''
+  try {
+    const { stdout } = await execFileAsync(run.command, run.args, { cwd, timeout: BENCHMARK_CONFIG.TIMEOUT_MS, maxBuffer: BENCHMARK_CONFIG.MAX_BUFFER_BYTES })
+    output = stdout
+  }
+  catch (error) {
+    // A failing benchmark in the package still leaves the others' results on stdout
+    const failed = error as { stdout?: string, stderr?: string, message: string }
+    output = failed.stdout ?? ''
+    errors.push({ ref, command, message: (failed.stderr || failed.message).trim().split('\n').slice(-5).join('\n') })
+  }
+
+  if (run.outputFile && existsSync(run.outputFile)) {
+    output = readFileSync(run.outputFile, 'utf-8')
+    rmSync(run.outputFile, { force: true })
+  }
+  return output
+}
+
+/**
+ * Medians per benchmark from Go `-bench` output, Vitest `--outputJson` results, or the text
+ * Benchmark.js (`x 1,234 ops/sec`) and mitata (`12.3 ns/iter`) print. Repeated runs of a
+ * benchmark, across a `-count` or concatenated outputs, are combined.
+ */
+export function parseBenchmarkOutput(framework: BenchmarkFramework, output: string): Map<string, BenchmarkMeasurement> {
+  const samples = new Map<string, { ns: number[], bytes: number[], allocs: number[] }>()
+  const add = (name: string, ns: number, bytes?: number, allocs?: number) => {
+    const entry = samples.get(name) ?? { ns: [], bytes: [], allocs: [] }
+    entry.ns.push(ns)
+    if (bytes !== undefined) entry.bytes.push(bytes)
+    if (allocs !== undefined) entry.allocs.push(allocs)
+    samples.set(name, entry)
+  }
+
+  if (framework === 'go') {
+    for (const line of output.matchAll(/^(Benchmark\S*?)(?:-\d+)?\s+\d+\s+([\d.]+) ns\/op(?:.*?\s([\d.]+) B\/op)?(?:.*?\s([\d.]+) allocs\/op)?/gm)) {
+      add(line[1]!, Number(line[2]), line[3] !== undefined ? Number(line[3]) : undefined, line[4] !== undefined ? Number(line[4]) : undefined)
+    }
+  }
+  else if (framework === 'vitest') {
+    for (const json of output.split(/\n(?=\{)/).filter(text => text.trim().startsWith('{'))) {
+      try {
+        const report = JSON.parse(json) as { files?: { groups?: { fullName?: string, benchmarks?: { name: string, mean: number }[] }[] }[] }
+        for (const group of report.files?.flatMap(file => file.groups ?? []) ?? []) {
+          const scope = (group.fullName ?? '').split(' > ').slice(1).join(' > ')
+          for (const benchmark of group.benchmarks ?? []) add(scope ? `${scope} > ${benchmark.name}` : benchmark.name, benchmark.mean * 1e6)
+        }
+      }
+      catch {
+        continue
+      }
+    }
+  }
+  else if (framework === 'benchmark.js') {
+    for (const line of output.matchAll(/^(.+?) x ([\d,.]+) ops\/sec\b/gm)) add(line[1]!.trim(), 1e9 / Number(line[2]!.replace(/,/g, '')))
+  }
+  else if (framework === 'mitata') {
+    const units: Record<string, number> = { ps: 1e-3, ns: 1, µs: 1e3, us: 1e3, ms: 1e6, s: 1e9 }
+    for (const line of output.matchAll(/^(\S.*?)\s+([\d.]+)\s*(ps|ns|µs|us|ms|s)\/iter\b/gm)) add(line[1]!.trim(), Number(line[2]) * units[line[3]!]!)
+  }
+
+  const measurements = new Map<string, BenchmarkMeasurement>()
+  for (const [name, entry] of samples) {
+    measurements.set(name, {
+      nsPerOp: round(median(entry.ns)),
+      ...(entry.bytes.length > 0 ? { bytesPerOp: median(entry.bytes) } : {}),
+      ...(entry.allocs.length > 0 ? { allocsPerOp: median(entry.allocs) } : {}),
+      runs: entry.ns.length,
+    })
+  }
+  return measurements
+}
+
+/**
+ * Pairs the measurements of both refs; benchmarks only one ref has are `added` or `removed`
+ */
+export function compareMeasurements(base: Map<string, BenchmarkMeasurement>, head: Map<string, BenchmarkMeasurement>, benchmarks: Benchmark[], thresholdPercent: number): BenchmarkDelta[] {
+  const names = [...new Set([...base.keys(), ...head.keys()])].sort()
+  return names.map((name) => {
+    // Sub-benchmarks and Vitest groups report under their benchmark's name
+    const source = benchmarks.find(benchmark => benchmark.name === name || name.startsWith(`${benchmark.name}/`) || name.endsWith(` > ${benchmark.name}`))
+    const before = base.get(name)
+    const after = head.get(name)
+    const described = { name, ...(source ? { file: source.file, framework: source.framework } : {}) }
+    if (!before) return { ...described, head: after, verdict: 'added' as const }
+    if (!after) return { ...described, base: before, verdict: 'removed' as const }
+
+    const deltaPercent = round((after.nsPerOp - before.nsPerOp) / before.nsPerOp * 100, 1)
+    const allocsDeltaPercent = before.allocsPerOp !== undefined && after.allocsPerOp !== undefined && before.allocsPerOp > 0
+      ? round((after.allocsPerOp - before.allocsPerOp) / before.allocsPerOp * 100, 1)
+      : undefined
+    const verdict: BenchmarkVerdict = Math.abs(deltaPercent) < thresholdPercent ? 'unchanged' : deltaPercent < 0 ? 'faster' : 'slower'
+    return { ...described, base: before, head: after, deltaPercent, ...(allocsDeltaPercent !== undefined ? { allocsDeltaPercent } : {}), verdict }
+  })
+}
+
+async function resolveCommit(root: string, ref: string): Promise<string> {
+  if (ref.startsWith('-')) throw new Error(`Invalid git ref: ${ref}`)
+  try {
+    return (await git(root, ['rev-parse', '--verify', '--quiet', `${ref}^{commit}`])).trim()
+  }
+  catch {
+    throw new Error(`Unknown git ref: ${ref} (in ${root})`)
+  }
+}
+
+/**
+ * Worktrees have no installed packages; JavaScript suites borrow the working tree's
+ */
+function linkNodeModules(from: string, to: string): void {
+  const source = join(from, 'node_modules')
+  const target = join(to, 'node_modules')
+  if (existsSync(source) && existsSync(to) && !existsSync(target)) symlinkSync(source, target, 'dir')
+}
+
+/**
+ * Directory of the go.mod or package.json nearest to a root-relative file, relative to the root
+ */
+function moduleOf(root: string, file: string): string {
+  const manifest = file.endsWith('.go') ? PROJECT_FILES.PACKAGE_MANAGERS.GO : PROJECT_FILES.PACKAGE_MANAGERS.NPM
+  for (let current = dirname(join(root, file)); ; current = dirname(current)) {
+    if (isFile(join(current, manifest))) return toRelative(root, current)
+    if (current === root || dirname(current) === current) return '.'
+  }
+}
+
+async function git(cwd: string, args: string[]): Promise<string> {
+  const { stdout } = await execFileAsync('git', args, { cwd, timeout: BENCHMARK_CONFIG.TIMEOUT_MS, maxBuffer: BENCHMARK_CONFIG.MAX_BUFFER_BYTES })
+  return stdout
+}
+
+function median(values: number[]): number {
+  const sorted = [...values].sort((a, b) => a - b)
+  const middle = Math.floor(sorted.length / 2)
+  return sorted.length % 2 === 1 ? sorted[middle]! : (sorted[middle - 1]! + sorted[middle]!) / 2
+}
+
+function round(value: number, digits = 2): number {
+  const factor = 10 ** digits
+  return Math.round(value * factor) / factor
+}
+
+function lineOf(content: string, index: number): number {
+  return content.substring(0, index).split('\n').length
+}
+
+function toRelative(root: string, path: string): string {
+  return relative(root, path).replace(/\\/g, '/') || '.'
+}
@@ -0,0 +1,434 @@
+/**
+ * Chunk export - splits indexed files into symbol-aligned chunks sized to a token budget, with
+ * the metadata embedding/RAG pipelines need (path, symbol, kind, language, imports, doc comment)
+ */
+
+import { getAllNodes } from '../project/manager.js'
+import { getLanguageForFile } from '../core/languages.js'
+import { cleanComment } from '../core/symbols.js'
+import { PARSER_NAMES, escapeRegExp } from '../constants/index.js'
+import type { Project, TreeNode } from '../types/core.js'
+
+export interface CodeChunk {
+  id: string
+  path: string
+  language?: string
+  symbol?: string // Unset for module-level code between symbols
+  kind: string // function, method, class, ... or 'module'
+  parent?: string // Enclosing symbol when a large one was split into its members
+  startLine: number
+  endLine: number
+  part?: number // Set with `parts` when a symbol had to be split at line boundaries
+  parts?: number
+  imports: string[] // Modules whose imported names the chunk uses
+  docComment?: string
+  tokens: number
+  content: string
+}
+
+export interface ChunkOptions {
+  maxTokens?: number
+  includeModuleCode?: boolean // Emit the code between symbols (imports, top-level statements); default true
+  pathPattern?: string
+}
+
+interface ChunkSymbol {
+  node: TreeNode
+  start: number // First line, including the doc comment and decorators above the node
+  end: number
+  docComment?: string
+}
+
+export interface ImportBinding {
+  module: string
+  names: string[]
+}
+
+interface FileContext {
+  path: string
+  language?: string
+  lines: string[]
+  imports: ImportBinding[]
+  maxTokens: number
+  includeModuleCode: boolean
+}
+
+const DEFAULT_MAX_TOKENS = 512
+const SYMBOL_TYPES = ['function', 'method', 'class', 'interface', 'struct', 'enum', 'trait', 'module']
+const CONTAINER_TYPES = ['class', 'interface', 'struct', 'enum', 'trait', 'module']
+
+/**
+ * Chunks every file of a project, in path order
+ */
+export function exportChunks(project: Project, options: ChunkOptions = {}): CodeChunk[] {
+  const files = getAllNodes(project)
+    .filter(node => node.type === 'file' && (!options.pathPattern || node.path.includes(options.pathPattern)))
+    .sort((a, b) => a.path.localeCompare(b.path))
+
+  return files.flatMap(file => chunkFile(file, options))
+}
+
+/**
+ * Serializes chunks as JSON Lines, one chunk per line
+ */
+export function formatChunksAsJsonl(chunks: CodeChunk[]): string {
+  return chunks.map(chunk => JSON.stringify(chunk)).join('\n') + (chunks.length > 0 ? '\n' : '')
+}
+
+/**
+ * Splits one file into chunks. Symbols that fit the budget become one chunk each; larger ones are
+ * split into their members, and symbols without members are split at line boundaries.
+ */
+export function chunkFile(fileNode: TreeNode, options: ChunkOptions = {}): CodeChunk[] {
+  const content = fileNode.content
+  if (!content?.trim()) return []
+
+  const lines = content.split('\n')
+  const language = getLanguageForFile(fileNode.path)?.name
+  const context: FileContext = {
+    path: fileNode.path,
+    language,
+    lines,
+    imports: readImports(content, language),
+    maxTokens: Math.max(options.maxTokens ?? DEFAULT_MAX_TOKENS, 16),
+    includeModuleCode: options.includeModuleCode ?? true,
+  }
+
+  const symbols = (fileNode.children ?? [])
+    .filter(node => SYMBOL_TYPES.includes(node.type) && node.startLine !== undefined && node.endLine !== undefined && node.cell === undefined)
+    .map(node => readSymbol(node, lines, language))
+    .sort((a, b) => a.start - b.start || b.end - a.end)
+
+  const chunks: CodeChunk[] = []
+  emitRange(context, 1, lines.length, symbols, undefined, chunks)
+  return chunks
+}
+
+function emitRange(context: FileContext, start: number, end: number, symbols: ChunkSymbol[], container: ChunkSymbol | undefined, chunks: CodeChunk[]): void {
+  // Only the outermost symbols of the range; nested ones are handled if their parent is split
+  const outermost: ChunkSymbol[] = []
+  for (const symbol of symbols) {
+    if (symbol === container || symbol.start < start || symbol.end > end) continue
+    if (outermost.some(outer => symbol.start >= outer.start && symbol.end <= outer.end)) continue
+    outermost.push(symbol)
+  }
+
+  let cursor = start
+  for (const symbol of outermost) {
+    if (symbol.start < cursor) continue
+    emitGap(context, cursor, symbol.start - 1, container, chunks)
+    emitSymbol(context, symbol, symbols, container, chunks)
+    cursor = symbol.end + 1
+  }
+  emitGap(context, cursor, end, container, chunks)
+}
+
+function emitSymbol(context: FileContext, symbol: ChunkSymbol, symbols: ChunkSymbol[], container: ChunkSymbol | undefined, chunks: CodeChunk[]): void {
+  const text = context.lines.slice(symbol.start - 1, symbol.end).join('\n')
+  const kind = symbol.node.symbol?.kind
+    ?? (symbol.node.type === 'function' && container && CONTAINER_TYPES.includes(container.node.type) ? 'method' : symbol.node.type)
+  const base = {
+    symbol: symbol.node.name,
+    kind,
+    parent: container?.node.name,
+    docComment: symbol.docComment,
+  }
+
+  if (estimateTokens(text) <= context.maxTokens) {
+    chunks.push(createChunk(context, symbol.start, symbol.end, text, base))
+    return
+  }
+
+  const members = symbols.filter(other => other !== symbol && other.start >= symbol.start && other.end <= symbol.end)
+  if (members.length > 0) {
+    emitRange(context, symbol.start, symbol.end, symbols, symbol, chunks)
+    return
+  }
+
+  emitParts(context, symbol.start, symbol.end, base, chunks)
+}
+
+function emitGap(context: FileContext, start: number, end: number, container: ChunkSymbol | undefined, chunks: CodeChunk[]): void {
+  if (!container && !context.includeModuleCode) return
+
+  // Trim blank lines, and skip gaps that are only closing braces of a split container
+  while (start <= end && !context.lines[start - 1]!.trim()) start++
+  while (end >= start && !context.lines[end - 1]!.trim()) end--
+  if (start > end) return
+  const text = context.lines.slice(start - 1, end).join('\n')
+  if (!/[\w$@]/.test(text)) return
+
+  const base = container
+    ? { symbol: container.node.name, kind: container.node.symbol?.kind ?? container.node.type, parent: undefined, docComment: container.docComment }
+    : { symbol: undefined, kind: 'module', parent: undefined, docComment: undefined }
+
+  if (estimateTokens(text) <= context.maxTokens) chunks.push(createChunk(context, start, end, text, base))
+  else emitParts(context, start, end, base, chunks)
+}
+
+function emitParts(context: FileContext, start: number, end: number, base: Pick<CodeChunk, 'symbol' | 'kind' | 'parent' | 'docComment'>, chunks: CodeChunk[]): void {
+  const parts: { start: number, end: number }[] = []
+  let partStart = start
+  let size = 0
+
+  for (let line = start; line <= end; line++) {
+    const lineTokens = estimateTokens(context.lines[line - 1]! + '\n')
+    if (size > 0 && size + lineTokens > context.maxTokens) {
+      parts.push({ start: partStart, end: line - 1 })
+      partStart = line
+      size = 0
+    }
+    size += lineTokens
+  }
+  parts.push({ start: partStart, end })
+
+  parts.forEach((part, index) => {
+    const text = context.lines.slice(part.start - 1, part.end).join('\n')
+    chunks.push(createChunk(context, part.start, part.end, text, { ...base, part: index + 1, parts: parts.length }))
+  })
+}
+
+function createChunk(context: FileContext, start: number, end: number, text: string, metadata: Partial<Pick<CodeChunk, 'symbol' | 'kind' | 'parent' | 'docComment' | 'part' | 'parts'>>): CodeChunk {
+  return {
+    id: `${context.path}:${start}-${end}`,
+    path: context.path,
+    language: context.language,
+    symbol: metadata.symbol,
+    kind: metadata.kind ?? 'module',
+    parent: metadata.parent,
+    startLine: start,
+    endLine: end,
+    part: metadata.part,
+    parts: metadata.parts,
+    imports: context.imports.filter(binding => binding.names.some(name => usesName(text, name))).map(binding => binding.module),
+    docComment: metadata.docComment,
+    tokens: estimateTokens(text),
+    content: text,
+  }
+}
+
+function readSymbol(node: TreeNode, lines: string[], language: string | undefined): ChunkSymbol {
+  let start = node.startLine!
+  // Decorators and attributes above the node belong to it
+  while (start > 1 && /^\s*(?:@[\w.]+|#\[)/.test(lines[start - 2]!)) start--
+
+  let docStart = start
+  const previous = lines[start - 2]?.trim() ?? ''
+  if (previous.endsWith('*/')) {
+    while (docStart > 1 && !lines[docStart - 2]!.includes('/*')) docStart--
+    docStart--
+  }
+  else {
+    while (docStart > 1 && /^\s*(?:\/\/|#(?!include|define|pragma|!|\[))/.test(lines[docStart - 2]!)) docStart--
+  }
+
+  const leading = lines.slice(docStart - 1, start - 1)
+  // Extractors that read the syntax tree have found the doc comment already
+  const docComment = node.symbol?.doc ?? (leading.length > 0
+    ? cleanComment(leading.join('\n'))
+    : language === PARSER_NAMES.PYTHON ? readDocstring(lines.slice(node.startLine! - 1, node.endLine!).join('\n')) : undefined)
+
+  return { node, start: docStart, end: node.endLine!, docComment: docComment || undefined }
+}
+
+function readDocstring(text: string): string | undefined {
+  // The first statement after the `def ...:` / `class ...:` header
+  const docstring = text.match(/:[ \t]*(?:#[^\n]*)?\n\s*[rRuU]?(\"\"\"|''')([\s\S]*?)\1/)
+  return docstring && docstring.index! < 500 ? docstring[2]!.trim() : undefined
+}
+
+/**
+ * The modules a file imports, each with the local names it binds
+ */
+export function readImports(content: string, language: string | undefined): ImportBinding[] {
+  const bindings: ImportBinding[] = []
+  const add = (module: string, names: string[]) => bindings.push({ module, names: names.filter(name => /^[A-Za-z_$][\w$]*$/.test(name)) })
+  const localName = (name: string) => name.trim().split(/\s+as\s+/).pop()!.trim()
+
+  if (language === PARSER_NAMES.PYTHON) {
+    readPythonImports(content, add, localName)
+  }
+  else if (language === PARSER_NAMES.GO) {
+    readGoImports(content, add)
+  }
+  else if (language === PARSER_NAMES.RUST) {
+    readRustImports(content, add, localName)
+  }
+  else if (language === PARSER_NAMES.JAVA || language === PARSER_NAMES.KOTLIN || language === PARSER_NAMES.SCALA || language === PARSER_NAMES.GROOVY || language === PARSER_NAMES.PHP) {
+    // The imported name is the last segment: import a.b.C / use App\Models\User
+    for (const match of content.matchAll(/^\s*(?:import(?:\s+static)?|use)\s+([\w.\\]+?)(?:\s+as\s+(\w+))?\s*;?\s*$/gm)) {
+      const segments = match[1]!.split(/[.\\]/)
+      add(match[1]!, [match[2] ?? segments[segments.length - 1]!])
+    }
+  }
+  else if (language === PARSER_NAMES.ELIXIR) {
+    // alias A.B.C binds C; alias A.B, as: D binds D; alias A.{B, C} binds B and C
+    for (const match of content.matchAll(/^\s*alias\s+([A-Z][\w.]*)(?:\.\{([^}]*)\}|\s*,\s*as:\s*([A-Z]\w*))?/gm)) {
+      const names = match[2]?.split(',').map(name => name.trim()).filter(Boolean)
+      if (names) names.forEach(name => add(`${match[1]}.${name}`, [name.split('.').pop()!]))
+      else add(match[1]!, [match[3] ?? match[1]!.split('.').pop()!])
+    }
+  }
+  else if (language === PARSER_NAMES.ZIG) {
+    // const std = @import("std")
+    for (const match of content.matchAll(/^\s*(?:pub\s+)?const\s+(\w+)\s*=\s*@import\(\s*"([^"]+)"\s*\)/gm)) {
+      add(match[2]!, [match[1]!])
+    }
+  }
+  else if (language === PARSER_NAMES.ODIN) {
+    // import "core:fmt" binds fmt; import str "core:strings" binds str
+    for (const match of content.matchAll(/^\s*import\s+(?:(\w+)\s+)?"([^"]+)"/gm)) {
+      add(match[2]!, [match[1] ?? match[2]!.split(/[:/]/).pop()!])
+    }
+  }
+  else if (language === PARSER_NAMES.DART) {
+    // import 'package:a/b.dart' as b; import 'package:a/b.dart' show C, D
+    for (const match of content.matchAll(/^\s*import\s+['"]([^'"]+)['"]\s*(?:deferred\s+)?(?:as\s+(\w+))?\s*(?:show\s+([\w\s,]+))?/gm)) {
+      const shown = match[3]?.split(',').map(name => name.trim()).filter(Boolean) ?? []
+      add(match[1]!, match[2] ? [match[2]] : shown)
+    }
+  }
+  else if (language === PARSER_NAMES.HASKELL) {
+    // import qualified Data.Map as M binds M; import Data.List (sortBy, nub) binds sortBy and nub
+    for (const match of content.matchAll(/^import\s+(?:safe\s+)?(?:qualified\s+)?([A-Z][\w.]*)(?:\s+qualified)?(?:\s+as\s+([A-Z][\w.]*))?(?:\s*\(([^)]*)\))?/gm)) {
+      const listed = match[3]?.split(',').map(name => name.trim()).filter(Boolean) ?? []
+      add(match[1]!, match[2] ? [match[2]] : listed.length > 0 ? listed : [match[1]!.split('.').pop()!])
+    }
+  }
+  else if (language === PARSER_NAMES.OCAML) {
+    // open Foo brings Foo's names into scope; module M = Foo.Bar binds M
+    for (const match of content.matchAll(/^\s*(?:open!?\s+([A-Z][\w.]*)|module\s+([A-Z]\w*)\s*=\s*([A-Z][\w.]*)\s*$)/gm)) {
+      if (match[1]) add(match[1], [match[1].split('.').pop()!])
+      else add(match[3]!, [match[2]!])
+    }
+  }
+  else if (language === PARSER_NAMES.R) {
+    // library(dplyr) / require("dplyr") attach the package, whose names are also reachable as dplyr::f
+    for (const match of content.matchAll(/^\s*(?:library|require|requireNamespace)\s*\(\s*['"]?([\w.]+)['"]?/gm)) {
+      add(match[1]!, [match[1]!])
+    }
+  }
+  else if (language === PARSER_NAMES.JULIA) {
+    // using A, B and import A bind A (and using attaches its exports); using A: f, g and import A: f bind f and g
+    for (const match of content.matchAll(/^\s*(using|import)\s+([^\n#]+)/gm)) {
+      const [modules, names] = match[2]!.split(':')
+      if (names !== undefined) {
+        add(modules!.trim(), names.split(',').map(name => name.trim().split(/\s+as\s+/).pop()!))
+        continue
+      }
+      for (const module of modules!.split(',').map(name => name.trim()).filter(Boolean)) {
+        add(module, [module.split('.').pop()!])
+      }
+    }
+  }
+  else if (language === PARSER_NAMES.OBJC) {
+    // #import "Foo.h" / #import <UIKit/UIKit.h> bind the header name; @import UIKit; binds the module
+    for (const match of content.matchAll(/^\s*#\s*(?:import|include)\s*[<"]([^>"]+)[>"]|^\s*@import\s+([\w.]+)\s*;/gm)) {
+      const module = match[1] ?? match[2]!
+      add(module, [module.split('/').pop()!.replace(/\.h$/, '').split('.')[0]!])
+    }
+  }
+  else if (language === PARSER_NAMES.SWIFT) {
+    // import UIKit binds UIKit; import class UIKit.UIView binds UIView
+    for (const match of content.matchAll(/^\s*(?:@\w+\s+)*import\s+(?:(?:typealias|struct|class|enum|protocol|let|var|func)\s+)?([\w.]+)/gm)) {
+      add(match[1]!, [match[1]!.split('.').pop()!])
+    }
+  }
+  else if (language === PARSER_NAMES.NIM) {
+    // import strutils, std/[os, times] binds each module; import a as b binds b; from m import x, y binds x and y
+    for (const match of content.matchAll(/^\s*(import|from)\s+([^\n#]+)/gm)) {
+      if (match[1] === 'from') {
+        const [module, names = ''] = match[2]!.split(/\s+import\s+/)
+        add(module!.trim(), names.split(',').map(name => name.trim()))
+        continue
+      }
+      const modules = match[2]!.replace(/([\w/]*)\[([^\]]*)\]/g, (_, prefix: string, list: string) => list.split(',').map(name => prefix + name.trim()).join(','))
+      for (const module of modules.split(',').map(name => name.trim()).filter(Boolean)) {
+        const [path, alias] = module.split(/\s+as\s+/)
+        add(path!, [alias ?? path!.split('/').pop()!])
+      }
+    }
+  }
+  else if (language === PARSER_NAMES.LUA) {
+    // local x = require('a.b') / local x = require "a.b"
+    for (const match of content.matchAll(/^\s*local\s+(\w+)\s*=\s*require\s*\(?\s*['"]([^'"]+)['"]/gm)) {
+      add(match[2]!, [match[1]!])
+    }
+  }
+  else {
+    readScriptImports(content, add, localName)
+  }
+
+  const seen = new Set<string>()
+  return bindings.filter((binding) => {
+    if (binding.names.length === 0 || seen.has(binding.module)) return false
+    seen.add(binding.module)
+    return true
+  })
+}
+
+type AddBinding = (module: string, names: string[]) => void
+
+function readScriptImports(content: string, add: AddBinding, localName: (name: string) => string): void {
+  // ES modules: import x, { a as b } from 'm' / import * as ns from 'm'
+  for (const match of content.matchAll(/^\s*import\s+(?:type\s+)?([\w$*{}\s,]+?)\s+from\s+['"]([^'"]+)['"]/gm)) {
+    const clause = match[1]!
+    const named = clause.match(/\{([^}]*)\}/)?.[1]?.split(',').map(name => localName(name.replace(/^\s*type\s+/, ''))) ?? []
+    const others = clause.replace(/\{[^}]*\}/, '').split(',').map(localName)
+    add(match[2]!, [...named, ...others])
+  }
+
+  // CommonJS: const x = require('m') / const { a, b: c } = require('m')
+  for (const match of content.matchAll(/\b(?:const|let|var)\s+(\{[^}]*\}|[\w$]+)\s*=\s*require\(\s*['"]([^'"]+)['"]\s*\)/g)) {
+    const target = match[1]!
+    const names = target.startsWith('{') ? target.slice(1, -1).split(',').map(name => name.split(':').pop()!.trim()) : [target]
+    add(match[2]!, names)
+  }
+}
+
+function readPythonImports(content: string, add: AddBinding, localName: (name: string) => string): void {
+  // from m import a, b as c / import a.b as c
+  for (const match of content.matchAll(/^from\s+([\w.]+)\s+import\s+(\([^)]*\)|[^\n]+)/gm)) {
+    add(match[1]!, match[2]!.replace(/[()]/g, '').split(',').map(localName))
+  }
+  for (const match of content.matchAll(/^import\s+([\w.]+(?:\s+as\s+\w+)?(?:\s*,\s*[\w.]+(?:\s+as\s+\w+)?)*)\s*$/gm)) {
+    for (const entry of match[1]!.split(',')) {
+      const [module, alias] = entry.trim().split(/\s+as\s+/)
+      add(module!, [alias ?? module!.split('.')[0]!])
+    }
+  }
+}
+
+function readGoImports(content: string, add: AddBinding): void {
+  // import "fmt" / import ( f "fmt"; "net/http" )
+  for (const match of content.matchAll(/^import\s+(?:\(([^)]*)\)|((?:[\w.]+\s+)?"[^"]+"))/gm)) {
+    for (const spec of (match[1] ?? match[2]!).matchAll(/(?:([\w.]+)\s+)?"([^"]+)"/g)) {
+      const segments = spec[2]!.split('/')
+      const name = /^v\d+$/.test(segments[segments.length - 1]!) ? segments[segments.length - 2] : segments[segments.length - 1]
+      add(spec[2]!, [spec[1] ?? name!.replace(/^go-|[.-]go$/g, '').replace(/[.-]/g, '')])
+    }
+  }
+}
+
+function readRustImports(content: string, add: AddBinding, localName: (name: string) => string): void {
+  // use a::b::C; / use a::b::{C, D as E};
+  for (const match of content.matchAll(/^\s*(?:pub\s+)?use\s+([\w:]+(?:::\{[^}]*\})?)\s*;/gm)) {
+    const [path, group] = match[1]!.split('::{')
+    const names = group
+      ? group.replace('}', '').split(',').map(localName).map(name => name === 'self' ? path!.split('::').pop()! : name)
+      : [localName(path!.split('::').pop()!)]
+    add(match[1]!, names)
+  }
+}
+
+function usesName(text: string, name: string): boolean {
+  return new RegExp(`(?<![\\w$])${escapeRegExp(name)}(?![\\w$])`).test(text)
+}
+
+/**
+ * Approximate token count of a text
+ */
+export function estimateTokens(text: string): number {
+  // Roughly four characters per token for code in common BPE vocabularies
+  return Math.ceil(text.length / 4)
+}
@@ -0,0 +1,210 @@
+/**
+ * Concurrency analysis - Go patterns that commonly hide races and deadlocks: goroutines
+ * capturing the loop variable before Go 1.22, locks left held on some return paths, channels
+ * sent to that nothing in the project receives from, and time.Sleep used to wait for goroutines
+ */
+
+import { readFileSync } from 'fs'
+import { dirname, join } from 'path'
+import { CONCURRENCY_CATEGORIES, escapeRegExp } from '../constants/index.js'
+import { blankGoClosures, lineAt, maskGo, matchingBrace, matchingParen, readGoFunctions, type GoFunction } from '../core/go-source.js'
+import { isFile } from '../utils/helpers.js'
+import type { TreeNode } from '../types/core.js'
+import type { ConcurrencyMetrics, Finding } from '../types/analysis.js'
+
+interface GoFile {
+  node: TreeNode
+  code: string // Comments blanked, string and rune contents blanked
+}
+
+const LOOP_HEADER = /\bfor\s+([^{;]*(?:;[^{;]*;[^{]*)?)\{/g
+const GOROUTINE_CLOSURE = /(?:\bgo\s+|\.Go\(\s*)func\s*\(/g
+const LOCK_CALL = /([A-Za-z_][\w.]*)\.(R?Lock)\(\)/g
+// The first Go release giving each loop iteration its own variable
+const PER_ITERATION_LOOP_VARS: [number, number] = [1, 22]
+
+/**
+ * Analyzes the Go files among the given nodes
+ */
+export function analyzeConcurrency(nodes: TreeNode[]): { findings: Finding[], metrics: ConcurrencyMetrics } {
+  const files: GoFile[] = nodes
+    .filter(node => node.type === 'file' && node.content && node.path.endsWith('.go'))
+    .map(node => ({ node, code: maskGo(node.content!) }))
+
+  const findings: Finding[] = []
+  let analyzedFunctions = 0
+  const versions = new Map<string, [number, number] | null>()
+
+  for (const file of files) {
+    const sharedLoopVars = !atLeast(goVersion(file.node.path, versions), PER_ITERATION_LOOP_VARS)
+    for (const fn of readGoFunctions(file.code)) {
+      analyzedFunctions++
+      const finding = (category: string, offset: number, description: string): Finding => ({
+        type: 'concurrency',
+        category,
+        severity: 'warning',
+        location: `${file.node.path}:${lineAt(file.code, fn.start + offset)}`,
+        description: `${fn.name}: ${description}`,
+        metrics: { function: fn.name },
+      })
+      if (sharedLoopVars) findings.push(...checkLoopCaptures(fn, finding))
+      findings.push(...checkLocks(fn, finding), ...checkSleeps(fn, finding))
+    }
+  }
+  findings.push(...checkChannels(files))
+
+  const issuesByCategory: Record<string, number> = {}
+  for (const finding of findings) {
+    issuesByCategory[finding.category] = (issuesByCategory[finding.category] ?? 0) + 1
+  }
+
+  return { findings, metrics: { analyzedFiles: files.length, analyzedFunctions, issuesByCategory } }
+}
+
+type FindingFactory = (category: string, offset: number, description: string) => Finding
+
+/**
+ * Goroutines started in a loop body that read the loop variable instead of a copy or argument
+ */
+function checkLoopCaptures(fn: GoFunction, finding: FindingFactory): Finding[] {
+  const findings: Finding[] = []
+  for (const loop of fn.body.matchAll(LOOP_HEADER)) {
+    const header = loop[1]!.trim()
+    const declared = /^(\w+)(?:\s*,\s*(\w+))?\s*:=\s*range\b/.exec(header) ?? /^(\w+)\s*:=/.exec(header)
+    const loopVars = (declared ? [declared[1], declared[2]] : []).filter((name): name is string => Boolean(name) && name !== '_')
+    if (loopVars.length === 0) continue
+
+    const open = loop.index + loop[0].length - 1
+    const body = fn.body.substring(open, matchingBrace(fn.body, open) + 1)
+    for (const closure of body.matchAll(GOROUTINE_CLOSURE)) {
+      const paramsOpen = closure.index + closure[0].length - 1
+      const paramsClose = matchingParen(body, paramsOpen)
+      const params = body.substring(paramsOpen + 1, paramsClose).split(',').map(param => param.trim().split(/\s+/)[0]!)
+      const bodyOpen = body.indexOf('{', paramsClose)
+      if (bodyOpen < 0) continue
+      const closureBody = body.substring(bodyOpen, matchingBrace(body, bodyOpen) + 1)
+
+      const before = body.substring(0, closure.index)
+      const captured = loopVars.filter(name => !params.includes(name)
+        && new RegExp(`(?<![\\w.])${name}\\b`).test(closureBody)
+        && !new RegExp(`\\b${name}\\s*:=\\s*${name}\\b`).test(before))
+      if (captured.length === 0) continue
+      findings.push(finding(CONCURRENCY_CATEGORIES.LOOP_VARIABLE_CAPTURE, open + closure.index,
+        `goroutine captures loop variable ${captured.join(', ')}, shared by all iterations before Go 1.22; pass it as an argument or copy it`))
+    }
+  }
+  return findings
+}
+
+/**
+ * Locks without a deferred unlock that a return path leaves held, or that are never unlocked
+ */
+function checkLocks(fn: GoFunction, finding: FindingFactory): Finding[] {
+  const findings: Finding[] = []
+  const body = blankGoClosures(fn.body)
+  for (const lock of body.matchAll(LOCK_CALL)) {
+    const [call, receiver, method] = lock as unknown as [string, string, string]
+    const unlock = `${receiver}.${method === 'RLock' ? 'RUnlock' : 'Unlock'}()`
+    const after = body.substring(lock.index + call.length)
+    // Deferred directly or from a deferred closure, which blanking hid from `after`
+    const deferred = new RegExp(`\\bdefer\\s+(?:func\\s*\\(\\)\\s*\\{[^}]*)?${escapeRegExp(unlock)}`)
+    if (deferred.test(fn.body.substring(lock.index + call.length))) continue
+
+    const released = after.indexOf(unlock)
+    if (released < 0) {
+      // Helpers such as lockShard() hand the held lock to their caller on purpose
+      if (/lock/i.test(fn.name)) continue
+      findings.push(finding(CONCURRENCY_CATEGORIES.UNRELEASED_LOCK, lock.index, `${receiver}.${method}() is never released; add defer ${unlock}`))
+    }
+    else if (/\breturn\b/.test(after.substring(0, released))) {
+      findings.push(finding(CONCURRENCY_CATEGORIES.UNRELEASED_LOCK, lock.index, `returns while holding ${receiver} locked with ${method}(); unlock before returning or defer ${unlock}`))
+    }
+  }
+  return findings
+}
+
+/**
+ * time.Sleep after starting goroutines in the same function, waiting on timing instead of them
+ */
+function checkSleeps(fn: GoFunction, finding: FindingFactory): Finding[] {
+  const body = blankGoClosures(fn.body)
+  const started = body.search(/\bgo\s+[\w.(]|\.Go\(/)
+  if (started < 0) return []
+  return [...body.substring(started).matchAll(/\btime\.Sleep\(/g)].map(sleep => finding(CONCURRENCY_CATEGORIES.SLEEP_SYNCHRONIZATION, started + sleep.index,
+    'waits for goroutines with time.Sleep; use a sync.WaitGroup, a channel or errgroup'))
+}
+
+/**
+ * Channels sent to that are received from nowhere in the project. Names are compared by their
+ * last selector segment, and a channel passed, returned or assigned anywhere is assumed read
+ * under another name.
+ */
+function checkChannels(files: GoFile[]): Finding[] {
+  const sends = new Map<string, { file: GoFile, index: number }>()
+  for (const file of files) {
+    for (const send of file.code.matchAll(/(?<![\w.])((?:[A-Za-z_]\w*\.)*([A-Za-z_]\w*))\s*<-(?!\s*chan\b)/g)) {
+      if (send[2] === 'chan' || sends.has(send[2]!)) continue
+      sends.set(send[2]!, { file, index: send.index })
+    }
+  }
+
+  const findings: Finding[] = []
+  for (const [name, send] of sends) {
+    const uses = files.flatMap(file => [...file.code.matchAll(new RegExp(`\\b${name}\\b`, 'g'))].map(use => channelUse(file.code, use.index, name)))
+    if (uses.some(use => use === 'receive' || use === 'other')) continue
+    findings.push({
+      type: 'concurrency',
+      category: CONCURRENCY_CATEGORIES.UNREAD_CHANNEL,
+      severity: 'warning',
+      location: `${send.file.node.path}:${lineAt(send.file.code, send.index)}`,
+      description: `Channel ${name} is sent to but never received from in the project; senders block once its buffer is full`,
+      metrics: { channel: name },
+    })
+  }
+  return findings
+}
+
+function channelUse(code: string, index: number, name: string): 'send' | 'receive' | 'declaration' | 'neutral' | 'other' {
+  let start = index
+  while (start > 0 && /[\w.]/.test(code[start - 1]!)) start--
+  const before = code.substring(Math.max(0, start - 20), start)
+  const after = code.substring(index + name.length, index + name.length + 40)
+
+  if (/<-\s*$/.test(before) || /\brange\s+$/.test(before)) return 'receive'
+  if (/^\s*(?::=|=|:)\s*make\(\s*chan\b/.test(after) || /^\s*(?:,\s*\w+\s*)*\s(?:chan\b|<-\s*chan\b)/.test(after)) return 'declaration'
+  if (/^\s*<-/.test(after)) return 'send'
+  if (/\b(?:close|len|cap)\(\s*$/.test(before)) return 'neutral'
+  return 'other'
+}
+
+/**
+ * The go directive of the go.mod nearest the file, if there is one
+ */
+function goVersion(path: string, cache: Map<string, [number, number] | null>): [number, number] | null {
+  const visited: string[] = []
+  let directory = dirname(path)
+  let version: [number, number] | null | undefined
+  for (;;) {
+    version = cache.get(directory)
+    if (version !== undefined) break
+    visited.push(directory)
+    const goMod = join(directory, 'go.mod')
+    if (isFile(goMod)) {
+      const directive = /^go\s+(\d+)\.(\d+)/m.exec(readFileSync(goMod, 'utf-8'))
+      version = directive ? [Number(directive[1]), Number(directive[2])] : null
+      break
+    }
+    const parent = dirname(directory)
+    if (parent === directory) {
+      version = null
+      break
+    }
+    directory = parent
+  }
+  for (const seen of visited) cache.set(seen, version)
+  return version
+}
+
+function atLeast(version: [number, number] | null, minimum: [number, number]): boolean {
+  return version !== null && (version[0] > minimum[0] || (version[0] === minimum[0] && version[1] >= minimum[1]))
+}
@@ -0,0 +1,539 @@
+/**
+ * Constant and enum values - resolves the literal value of constants and enum members (TS enums,
+ * Go iota sequences, Python Enum classes, Rust/C#/C discriminants, Java ordinals) and finds
+ * where each one is used
+ */
+
+import { getAllNodes } from '../project/manager.js'
+import { getLanguageForFile } from '../core/languages.js'
+import { PARSER_NAMES, escapeRegExp } from '../constants/index.js'
+import { readCallArguments, splitTopLevel } from '../utils/helpers.js'
+import type { Project, TreeNode } from '../types/core.js'
+
+export type ConstantValue = string | number | boolean
+
+export interface ConstantUsage {
+  file: string
+  line: number
+}
+
+export interface ConstantDefinition {
+  name: string
+  group?: string // Enum, `as const` object or typed Go const block the member belongs to
+  kind: 'enum' | 'constant'
+  value?: ConstantValue // Unset when the expression can't be evaluated statically
+  expression: string // Initializer as written; empty for implicit members
+  file: string
+  line: number
+  usages?: ConstantUsage[]
+}
+
+export interface ConstantQuery {
+  name?: string // Case-insensitive text in the constant or group name
+  value?: string // Resolved value, e.g. "3" matches 3 and 0x3
+}
+
+type Dialect = 'script' | 'go' | 'python' | 'rust' | 'java' | 'csharp' | 'c'
+
+type Scope = Map<string, ConstantValue>
+
+const DIALECTS: Record<string, Dialect> = {
+  [PARSER_NAMES.JAVASCRIPT]: 'script',
+  [PARSER_NAMES.TYPESCRIPT]: 'script',
+  [PARSER_NAMES.TSX]: 'script',
+  [PARSER_NAMES.GO]: 'go',
+  [PARSER_NAMES.PYTHON]: 'python',
+  [PARSER_NAMES.RUST]: 'rust',
+  [PARSER_NAMES.JAVA]: 'java',
+  [PARSER_NAMES.CSHARP]: 'csharp',
+  [PARSER_NAMES.C]: 'c',
+  [PARSER_NAMES.CPP]: 'c',
+}
+
+// Languages whose integer division truncates
+const INTEGER_DIALECTS = new Set<Dialect>(['go', 'rust', 'java', 'csharp', 'c'])
+
+const BINARY_PRECEDENCE: Record<string, number> = {
+  '|': 1, '^': 2, '&': 3, '<<': 4, '>>': 4, '+': 5, '-': 5, '*': 6, '/': 6, '%': 6, '**': 7,
+}
+
+const TOKEN = /\s*((?:0[xX][\da-fA-F_]+|0[bB][01_]+|0[oO][0-7_]+|\d[\d_]*(?:\.\d+)?(?:[eE][+-]?\d+)?)(?:[uUiI](?:8|16|32|64|128|size)?|[lLfFdDmMn])*|'(?:\\.|[^'\\])*'|"(?:\\.|[^"\\])*"|`[^`$]*`|[A-Za-z_$][\w$]*(?:(?:\.|::)[A-Za-z_$][\w$]*)*|<<|>>|\*\*|[-+*/%&|^~()])/y
+
+/**
+ * Lists the constants and enum members of a project matching a query, without usages
+ */
+export function listConstantValues(project: Project, query: ConstantQuery = {}): ConstantDefinition[] {
+  return findConstantValues(getAllNodes(project).filter(node => node.type === 'file'), query)
+}
+
+/**
+ * Extracts constants from file contents, ordered by file and line
+ */
+export function findConstantValues(fileNodes: TreeNode[], query: ConstantQuery = {}): ConstantDefinition[] {
+  return fileNodes
+    .flatMap(fileNode => fileNode.content ? extractConstants(fileNode.content, fileNode.path) : [])
+    .filter(constant => matchesQuery(constant, query))
+    .sort((a, b) => a.file.localeCompare(b.file) || a.line - b.line)
+}
+
+/**
+ * Reads the constants declared in one file. Only module-level scalar constants whose value
+ * evaluates are kept; enum members are kept even when their value can't be resolved.
+ */
+export function extractConstants(content: string, file: string): ConstantDefinition[] {
+  const dialect = dialectOf(file)
+  if (!dialect) return []
+
+  const source = blankComments(content, dialect === 'python' ? '#' : '//')
+  switch (dialect) {
+    case 'go':
+      return extractGoConstants(source, file)
+    case 'python':
+      return extractPythonConstants(source, file)
+    case 'java':
+      return [...extractJavaEnums(source, file), ...extractDeclared(source, file, dialect)]
+    default:
+      return [...extractBraceEnums(source, file, dialect), ...extractDeclared(source, file, dialect)]
+  }
+}
+
+/**
+ * Adds the places each constant is referenced, searching files of the same language. Enum
+ * members are matched qualified by their group (`Status.Active`, `Status::Active`); Go
+ * constants, C enum members and plain constants by name.
+ */
+export function withConstantUsages(constants: ConstantDefinition[], fileNodes: TreeNode[]): ConstantDefinition[] {
+  const matchers = constants.map(constant => ({ constant, dialect: dialectOf(constant.file), pattern: usagePattern(constant) }))
+  const usages = new Map<ConstantDefinition, ConstantUsage[]>(constants.map(constant => [constant, []]))
+
+  for (const fileNode of fileNodes) {
+    const dialect = dialectOf(fileNode.path)
+    const relevant = matchers.filter(matcher => matcher.dialect === dialect)
+    if (!fileNode.content || relevant.length === 0) continue
+
+    const source = blankComments(fileNode.content, dialect === 'python' ? '#' : '//')
+    for (const { constant, pattern } of relevant) {
+      const lines = new Set<number>()
+      for (const match of source.matchAll(pattern)) {
+        const line = lineOf(source, match.index!)
+        if (fileNode.path === constant.file && line === constant.line) continue
+        lines.add(line)
+      }
+      usages.get(constant)!.push(...[...lines].map(line => ({ file: fileNode.path, line })))
+    }
+  }
+
+  return constants.map(constant => ({ ...constant, usages: usages.get(constant) }))
+}
+
+/**
+ * Evaluates a constant expression: numeric, string and boolean literals, names already in
+ * scope, and arithmetic or bitwise operators. Conversions such as `Weekday(3)` or `3 as u8`
+ * yield their operand. Returns undefined for anything else.
+ */
+export function evaluateConstant(expression: string, scope: Scope = new Map(), integerDivision = false): ConstantValue | undefined {
+  const tokens = tokenize(expression.replace(/\s+as\s+[\w:]+/g, '').trim())
+  if (!tokens || tokens.length === 0) return undefined
+  let position = 0
+
+  const parseBinary = (minPrecedence: number): ConstantValue | undefined => {
+    let left = parseUnary()
+    for (;;) {
+      const operator = tokens[position]
+      const precedence = operator === undefined ? undefined : BINARY_PRECEDENCE[operator]
+      if (precedence === undefined || precedence < minPrecedence) return left
+      position++
+      // `**` is right-associative
+      const right = parseBinary(operator === '**' ? precedence : precedence + 1)
+      left = applyBinary(operator!, left, right, integerDivision)
+    }
+  }
+
+  const parseUnary = (): ConstantValue | undefined => {
+    const token = tokens[position++]
+    if (token === undefined) return undefined
+    if (token === '-' || token === '+' || token === '~' || token === '^') {
+      const operand = parseUnary()
+      if (typeof operand !== 'number') return undefined
+      return token === '-' ? -operand : token === '+' ? operand : -operand - 1
+    }
+    if (token === '(') {
+      const inner = parseBinary(0)
+      return tokens[position++] === ')' ? inner : undefined
+    }
+    if (/^[\d]/.test(token)) return parseNumber(token)
+    if (/^['"`]/.test(token)) return token.slice(1, -1).replace(/\\(.)/g, '$1')
+    if (token === 'true' || token === 'True') return true
+    if (token === 'false' || token === 'False') return false
+
+    if (tokens[position] === '(') {
+      // A type conversion of a single operand
+      position++
+      const inner = parseBinary(0)
+      return tokens[position++] === ')' ? inner : undefined
+    }
+    return scope.get(token) ?? scope.get(token.split(/\.|::/).pop()!)
+  }
+
+  const value = parseBinary(0)
+  return position === tokens.length ? value : undefined
+}
+
+function extractBraceEnums(source: string, file: string, dialect: Dialect): ConstantDefinition[] {
+  const constants: ConstantDefinition[] = []
+  const scope: Scope = new Map()
+
+  for (const match of source.matchAll(/\benum\s+(?:class\s+|struct\s+)?(\w+)?[^{};=()]*\{/g)) {
+    const open = match.index! + match[0].length - 1
+    const body = readCallArguments(source, open)
+    if (!body) continue
+    // C: `typedef enum { ... } Name;`
+    const group = match[1] ?? source.substring(body.end).match(/^\s*(\w+)\s*;/)?.[1]
+
+    let cursor = open
+    let next: number | undefined = 0
+    for (const member of body.args) {
+      const declaration = member.replace(/^(?:#\[[^\]]*\]\s*|\[[^\]]*\]\s*)*/, '')
+      const parsed = declaration.match(/^(\w+)\s*(?:=\s*([\s\S]+))?$/)
+      cursor = source.indexOf(member, cursor)
+      if (!parsed) {
+        // A Rust variant with fields has no discriminant, and neither do the ones after it
+        const name = declaration.match(/^\w+/)?.[0]
+        if (name && group) constants.push({ name, group, kind: 'enum', expression: '', file, line: lineOf(source, cursor) })
+        next = undefined
+        continue
+      }
+
+      const [, name = '', expression = ''] = parsed
+      const value = expression ? evaluateConstant(expression, scope, INTEGER_DIALECTS.has(dialect)) : next
+      constants.push({ name, group, kind: 'enum', value, expression: expression.trim(), file, line: lineOf(source, cursor) })
+      if (value !== undefined) addToScope(scope, name, group, value)
+      next = typeof value === 'number' ? value + 1 : undefined
+    }
+  }
+
+  return constants
+}
+
+function extractJavaEnums(source: string, file: string): ConstantDefinition[] {
+  const constants: ConstantDefinition[] = []
+
+  for (const match of source.matchAll(/\benum\s+(\w+)[^{};=()]*\{/g)) {
+    const open = match.index! + match[0].length - 1
+    const body = readCallArguments(source, open)
+    if (!body) continue
+
+    // Constants come before the first top-level `;`, which starts the enum's members
+    const text = source.substring(open + 1, body.end - 1)
+    const declarations = splitTopLevel(splitTopLevel(text, ';')[0] ?? '', ',')
+    let cursor = open
+    declarations.forEach((declaration, ordinal) => {
+      const parsed = declaration.trim().replace(/^(?:@\w+(?:\([^)]*\))?\s*)*/, '').match(/^(\w+)\s*(?:\(([\s\S]*?)\))?/)
+      if (!parsed) return
+      const [, name = '', args] = parsed
+      cursor = source.indexOf(name, cursor)
+      // `ACTIVE(3)` maps to its single argument; otherwise the ordinal stands for the member
+      const argument = args !== undefined && splitTopLevel(args).length === 1 ? evaluateConstant(args, new Map(), true) : undefined
+      constants.push({ name, group: match[1], kind: 'enum', value: argument ?? ordinal, expression: args ?? '', file, line: lineOf(source, cursor) })
+    })
+  }
+
+  return constants
+}
+
+/**
+ * Scalar constants declared with a keyword: `export const`, `static final`, C#/C++ `const`,
+ * Rust `const`/`static` and `#define`. Script `as const` objects become groups.
+ */
+function extractDeclared(source: string, file: string, dialect: Dialect): ConstantDefinition[] {
+  const constants: ConstantDefinition[] = []
+  const scope: Scope = new Map()
+  const add = (name: string, expression: string, index: number) => {
+    const value = evaluateConstant(expression, scope, INTEGER_DIALECTS.has(dialect))
+    if (value === undefined) return
+    constants.push({ name, kind: 'constant', value, expression, file, line: lineOf(source, index) })
+    scope.set(name, value)
+  }
+
+  const patterns: Record<Dialect, RegExp[]> = {
+    script: [/^(?:export\s+)?const\s+(\w+)\s*(?::\s*[^=\n]+)?=\s*([^\n;]+)/gm],
+    java: [/\b(?:static\s+final|final\s+static)\s+[\w<>[\]]+\s+(\w+)\s*=\s*([^;]+);/g],
+    csharp: [/\bconst\s+[\w.?]+\s+(\w+)\s*=\s*([^;]+);/g],
+    rust: [/^\s*(?:pub(?:\([^)]*\))?\s+)?(?:const|static)\s+(\w+)\s*:\s*[^=]+=\s*([^;]+);/gm],
+    c: [/\b(?:constexpr|const)\s+[\w:]+\s+(\w+)\s*=\s*([^;]+);/g, /^\s*#\s*define\s+(\w+)[ \t]+([^\n]+)/gm],
+    go: [],
+    python: [],
+  }
+
+  for (const pattern of patterns[dialect]) {
+    for (const match of source.matchAll(pattern)) {
+      const expression = match[2]!.trim()
+      if (dialect === 'script' && /^(?:Object\.freeze\(\s*)?\{/.test(expression)) {
+        constants.push(...readConstObject(source, match.index! + match[0].indexOf(expression), match[1]!, file))
+        continue
+      }
+      add(match[1]!, expression, match.index!)
+    }
+  }
+
+  return constants
+}
+
+/**
+ * `const Status = { Active: 1 } as const` and `Object.freeze({ ... })` - the usual TS enum substitutes
+ */
+function readConstObject(source: string, start: number, group: string, file: string): ConstantDefinition[] {
+  const open = source.indexOf('{', start)
+  const body = readCallArguments(source, open)
+  const frozen = source.substring(start, open).includes('freeze')
+  if (!body || (!frozen && !/^\s*as\s+const\b/.test(source.substring(body.end)))) return []
+
+  const constants: ConstantDefinition[] = []
+  const scope: Scope = new Map()
+  let cursor = open
+  for (const entry of body.args) {
+    const [key, ...rest] = splitTopLevel(entry, ':')
+    const name = key?.trim().replace(/^(['"`])(.*)\1$/, '$2')
+    if (!name || rest.length === 0 || !/^\w+$/.test(name)) continue
+    cursor = source.indexOf(entry, cursor)
+    const expression = rest.join(':').trim()
+    const value = evaluateConstant(expression, scope)
+    constants.push({ name, group, kind: 'enum', value, expression, file, line: lineOf(source, cursor) })
+    if (value !== undefined) addToScope(scope, name, group, value)
+  }
+  return constants
+}
+
+/**
+ * Go `const` declarations. Within a block `iota` counts specs, and a spec without a value
+ * repeats the previous expression and type, so `A Weekday = iota; B; C` is 0, 1, 2.
+ */
+function extractGoConstants(source: string, file: string): ConstantDefinition[] {
+  const constants: ConstantDefinition[] = []
+  const scope: Scope = new Map()
+
+  for (const match of source.matchAll(/^const\s*\(/gm)) {
+    const open = match.index! + match[0].length - 1
+    const body = readCallArguments(source, open)
+    if (!body) continue
+
+    let offset = open + 1
+    let iota = 0
+    let previous: { type?: string, expressions: string[] } = { expressions: [] }
+    for (const text of source.substring(open + 1, body.end - 1).split('\n')) {
+      const index = offset
+      offset += text.length + 1
+      const spec = text.trim()
+      if (!spec) continue
+
+      const [left = '', ...right] = spec.split('=')
+      const head = left.trim().match(/^(\w+(?:\s*,\s*\w+)*)\s*(.*)$/)
+      if (!head) continue
+      if (right.length > 0) previous = { type: head[2] || undefined, expressions: splitTopLevel(right.join('='), ',').map(part => part.trim()) }
+
+      scope.set('iota', iota)
+      head[1]!.split(',').map(name => name.trim()).forEach((name, position) => {
+        const expression = previous.expressions[position] ?? ''
+        if (name === '_') return
+        const value = evaluateConstant(expression, scope, true)
+        if (value !== undefined) scope.set(name, value)
+        const type = previous.type && /^[\w.]+$/.test(previous.type) ? previous.type : undefined
+        constants.push({ name, group: type, kind: type ? 'enum' : 'constant', value, expression: right.length > 0 ? expression : '', file, line: lineOf(source, index) })
+      })
+      iota++
+    }
+  }
+
+  for (const match of source.matchAll(/^const\s+(\w+)(?:\s+[\w.]+)?\s*=\s*([^\n]+)/gm)) {
+    const value = evaluateConstant(match[2]!, scope, true)
+    if (value === undefined) continue
+    constants.push({ name: match[1]!, kind: 'constant', value, expression: match[2]!.trim(), file, line: lineOf(source, match.index!) })
+    scope.set(match[1]!, value)
+  }
+
+  return constants
+}
+
+/**
+ * Python `Enum`/`IntEnum`/`StrEnum`/`Flag` members, resolving `auto()`, and module-level
+ * UPPER_CASE constants
+ */
+function extractPythonConstants(source: string, file: string): ConstantDefinition[] {
+  const constants: ConstantDefinition[] = []
+  const lines = source.split('\n')
+  const scope: Scope = new Map()
+
+  for (const match of source.matchAll(/^([ \t]*)class\s+(\w+)\s*\(([^)]*)\)\s*:/gm)) {
+    const bases = match[3]!
+    if (!/\b(?:Int|Str)?(?:Enum|Flag)\b/.test(bases)) continue
+
+    const group = match[2]!
+    const classLine = lineOf(source, match.index!)
+    let memberIndent: string | undefined
+    let last: ConstantValue | undefined = 0
+    for (let i = classLine; i < lines.length; i++) {
+      const text = lines[i]!
+      if (!text.trim()) continue
+      const indent = text.match(/^[ \t]*/)![0]
+      if (indent.length <= match[1]!.length) break
+      memberIndent ??= indent
+      if (indent !== memberIndent) continue
+
+      const member = text.match(/^\s+([A-Za-z]\w*)\s*(?::\s*[^=]+)?=\s*(.+)$/)
+      if (!member) continue
+      const [, name = '', raw = ''] = member
+      const expression = raw.trim()
+      let value: ConstantValue | undefined
+      if (/^(?:enum\.)?auto\(\)$/.test(expression)) {
+        value = /\bStrEnum\b/.test(bases)
+          ? name.toLowerCase()
+          : typeof last === 'number' ? (/\bFlag\b|IntFlag/.test(bases) ? nextFlag(last) : last + 1) : undefined
+      }
+      else {
+        value = evaluateConstant(expression, scope)
+      }
+      constants.push({ name, group, kind: 'enum', value, expression, file, line: i + 1 })
+      if (value !== undefined) addToScope(scope, name, group, value)
+      last = value
+    }
+  }
+
+  for (const match of source.matchAll(/^([A-Z][A-Z0-9_]*)\s*(?::\s*[^=\n]+)?=\s*([^\n]+)/gm)) {
+    const value = evaluateConstant(match[2]!, scope)
+    if (value === undefined) continue
+    constants.push({ name: match[1]!, kind: 'constant', value, expression: match[2]!.trim(), file, line: lineOf(source, match.index!) })
+    scope.set(match[1]!, value)
+  }
+
+  return constants
+}
+
+function usagePattern(constant: ConstantDefinition): RegExp {
+  const name = escapeRegExp(constant.name)
+  const dialect = dialectOf(constant.file)
+  if (!constant.group || dialect === 'go' || dialect === 'c') return new RegExp(`(?<![\\w$])${name}(?![\\w$])`, 'g')
+
+  const qualified = `(?<![\\w$])${escapeRegExp(constant.group)}\\s*(?:\\.|::)\\s*${name}(?![\\w$])`
+  // Java switch labels name the constant alone
+  return new RegExp(dialect === 'java' ? `${qualified}|\\bcase\\s+${name}\\b` : qualified, 'g')
+}
+
+function matchesQuery(constant: ConstantDefinition, query: ConstantQuery): boolean {
+  if (query.name) {
+    const text = query.name.toLowerCase()
+    if (!constant.name.toLowerCase().includes(text) && !constant.group?.toLowerCase().includes(text)) return false
+  }
+  if (query.value !== undefined) {
+    if (constant.value === undefined) return false
+    const numeric = parseNumber(query.value)
+    const matches = typeof constant.value === 'number' && numeric !== undefined
+      ? constant.value === numeric
+      : String(constant.value) === query.value.replace(/^(['"`])(.*)\1$/, '$2')
+    if (!matches) return false
+  }
+  return true
+}
+
+function applyBinary(operator: string, left: ConstantValue | undefined, right: ConstantValue | undefined, integerDivision: boolean): ConstantValue | undefined {
+  if (left === undefined || right === undefined) return undefined
+  if (operator === '+' && (typeof left === 'string' || typeof right === 'string')) return `${left}${right}`
+  if (typeof left !== 'number' || typeof right !== 'number') return undefined
+
+  const integers = Number.isInteger(left) && Number.isInteger(right)
+  switch (operator) {
+    case '+': return left + right
+    case '-': return left - right
+    case '*': return left * right
+    case '**': return left ** right
+    case '/':
+      if (right === 0) return undefined
+      return integerDivision && integers ? Math.trunc(left / right) : left / right
+    case '%': return right === 0 ? undefined : left % right
+  }
+
+  if (!integers) return undefined
+  // BigInt keeps `1 << 40` and wide masks exact
+  const a = BigInt(left)
+  const b = BigInt(right)
+  switch (operator) {
+    case '<<': return Number(a << b)
+    case '>>': return Number(a >> b)
+    case '&': return Number(a & b)
+    case '|': return Number(a | b)
+    case '^': return Number(a ^ b)
+  }
+  return undefined
+}
+
+function tokenize(expression: string): string[] | undefined {
+  const tokens: string[] = []
+  TOKEN.lastIndex = 0
+  while (TOKEN.lastIndex < expression.length) {
+    const match = TOKEN.exec(expression)
+    if (!match) return expression.substring(TOKEN.lastIndex).trim() ? undefined : tokens
+    tokens.push(match[1]!)
+  }
+  return tokens
+}
+
+function parseNumber(token: string): number | undefined {
+  const literal = token.trim().replace(/_/g, '').match(/^(0[xX][\da-fA-F]+|0[bB][01]+|0[oO][0-7]+|\d+(?:\.\d+)?(?:[eE][+-]?\d+)?)/)?.[1]
+  if (!literal) return undefined
+  const value = Number(literal)
+  return Number.isNaN(value) ? undefined : value
+}
+
+function nextFlag(last: number): number {
+  let flag = 1
+  while (flag <= last) flag *= 2
+  return flag
+}
+
+function addToScope(scope: Scope, name: string, group: string | undefined, value: ConstantValue) {
+  scope.set(name, value)
+  if (group) scope.set(`${group}.${name}`, value)
+}
+
+function dialectOf(file: string): Dialect | undefined {
+  const language = getLanguageForFile(file)?.name
+  return language ? DIALECTS[language] : undefined
+}
+
+/**
+ * Replaces comments with spaces, keeping offsets and line numbers intact
+ */
+function blankComments(content: string, lineComment: '//' | '#'): string {
+  let output = ''
+  for (let i = 0; i < content.length; i++) {
+    const char = content[i]!
+    let end = i
+    if (char === '"' || char === '\'' || char === '`') {
+      for (end = i + 1; end < content.length && content[end] !== char; end++) {
+        if (content[end] === '\\') end++
+        else if (content[end] === '\n' && char !== '`') break
+      }
+      output += content.substring(i, end + 1)
+      i = end
+      continue
+    }
+    if (content.startsWith(lineComment, i)) {
+      end = content.indexOf('\n', i)
+      if (end === -1) end = content.length
+    }
+    else if (lineComment === '//' && content.startsWith('/*', i)) {
+      end = content.indexOf('*/', i + 2)
+      end = end === -1 ? content.length : end + 2
+    }
+    if (end === i) {
+      output += char
+      continue
+    }
+    output += content.substring(i, end).replace(/[^\n]/g, ' ')
+    i = end - 1
+  }
+  return output
+}
+
+function lineOf(content: string, index: number): number {
+  return content.substring(0, index).split('\n').length
+}
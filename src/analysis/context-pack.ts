@@ -0,0 +1,234 @@
+/**
+ * Context packs - everything needed to change a symbol safely in one response: its definition,
+ * the imports it relies on, the project functions it calls, the types it references and its
+ * callers, fitted to a token budget
+ */
+
+import { dirname } from 'path'
+import { getAllNodes } from '../project/manager.js'
+import { findDependentFiles } from '../import/aliases.js'
+import { findSymbolCandidates, findSymbolsById, isSymbolId, symbolId } from '../core/symbol-ids.js'
+import { getLanguageForFile } from '../core/languages.js'
+import { escapeRegExp } from '../constants/index.js'
+import { estimateTokens, readImports, type ImportBinding } from './chunks.js'
+import type { Project, TreeNode } from '../types/core.js'
+
+export interface ContextEntry {
+  id: string
+  name: string
+  kind: string
+  path: string
+  startLine?: number
+  endLine?: number
+  signature: string
+  content?: string // Left out when the budget only allows the signature
+  truncated?: boolean // Set when the content was cut to fit the budget
+}
+
+export interface ContextPack {
+  target: ContextEntry
+  imports: ImportBinding[] // Only the imported names the definition uses
+  callees: ContextEntry[]
+  types: ContextEntry[]
+  callers: ContextEntry[]
+  omitted: number // Related symbols that did not fit even as a signature
+  tokens: number
+  maxTokens: number
+}
+
+export interface ContextPackOptions {
+  maxTokens?: number
+}
+
+const DEFAULT_MAX_TOKENS = 4000
+const CALLABLE_KINDS = ['function', 'method']
+const TYPE_KINDS = ['class', 'interface', 'struct', 'enum', 'trait', 'type']
+const CALL = /([A-Za-z_$][\w$]*)\s*\(/g
+const TYPE_NAME = /(?<![\w$])[A-Z][\w$]*/g
+const DECLARATION = /(?:function|def|func|fn)\s*\*?\s*$/
+
+/**
+ * Builds the context pack of a symbol, given by name or symbol id. The definition always comes
+ * first; related symbols are then added as signatures, and as much of their code as the budget
+ * leaves room for, in the order callees, types, callers.
+ */
+export function buildContextPack(project: Project, target: string, options: ContextPackOptions = {}): ContextPack {
+  const maxTokens = Math.max(options.maxTokens ?? DEFAULT_MAX_TOKENS, 100)
+  const definition = resolveTarget(project, target)
+  const root = project.config.directory
+  const content = definition.content ?? ''
+
+  const declarations = uniqueDeclarations(project)
+  const callees = closestByName(definition, calledNames(content).filter(name => name !== definition.name), declarations, CALLABLE_KINDS)
+  const types = closestByName(definition, typeNames(content).filter(name => name !== definition.name), declarations, TYPE_KINDS)
+  const callers = findCallers(project, definition, declarations)
+
+  const language = getLanguageForFile(definition.path)?.name
+  const fileContent = project.files.get(definition.path)?.content
+    ?? getAllNodes(project).find(node => node.type === 'file' && node.path === definition.path)?.content
+    ?? ''
+  const imports = readImports(fileContent, language)
+    .map(binding => ({ module: binding.module, names: binding.names.filter(name => usesName(content, name)) }))
+    .filter(binding => binding.names.length > 0)
+
+  let tokens = estimateTokens(JSON.stringify(imports))
+  const targetEntry = toEntry(root, definition)
+  const targetTokens = estimateTokens(content)
+  if (tokens + targetTokens > maxTokens) {
+    targetEntry.content = truncateToTokens(content, maxTokens - tokens)
+    targetEntry.truncated = true
+  }
+  else {
+    targetEntry.content = content
+  }
+  tokens += estimateTokens(targetEntry.content)
+
+  // Signatures first, so the budget covers as many related symbols as possible
+  const groups = [callees, types, callers].map(nodes => nodes.map(node => ({ node, entry: toEntry(root, node) })))
+  let omitted = 0
+  const kept = groups.map(group => group.filter(({ entry }) => {
+    const cost = summaryTokens(entry)
+    if (tokens + cost > maxTokens) {
+      omitted++
+      return false
+    }
+    tokens += cost
+    return true
+  }))
+
+  for (const { node, entry } of kept.flat()) {
+    const cost = estimateTokens(node.content!)
+    if (tokens + cost > maxTokens) continue
+    tokens += cost
+    entry.content = node.content
+  }
+
+  const [calleeEntries, typeEntries, callerEntries] = kept.map(group => group.map(({ entry }) => entry))
+  return {
+    target: targetEntry,
+    imports,
+    callees: calleeEntries!,
+    types: typeEntries!,
+    callers: callerEntries!,
+    omitted,
+    tokens,
+    maxTokens,
+  }
+}
+
+/**
+ * Names a piece of code calls, in order of first call
+ */
+export function calledNames(content: string): string[] {
+  const names = new Set<string>()
+  for (const match of content.matchAll(CALL)) {
+    if (DECLARATION.test(content.slice(Math.max(0, match.index - 10), match.index))) continue
+    names.add(match[1]!)
+  }
+  return [...names]
+}
+
+function resolveTarget(project: Project, target: string): TreeNode {
+  if (isSymbolId(target)) {
+    const [definition] = findSymbolsById(project, target)
+    if (!definition) {
+      throw new Error(`Unknown symbol id: ${target}`)
+    }
+    return definition
+  }
+
+  const candidates = findSymbolCandidates(project, target, [...CALLABLE_KINDS, ...TYPE_KINDS])
+  if (candidates.length === 0) {
+    throw new Error(`Unknown symbol: ${target}`)
+  }
+  if (candidates.length > 1) {
+    throw new Error(`Ambiguous symbol ${target}; pass one of these ids: ${candidates.map(candidate => candidate.id).join(', ')}`)
+  }
+  return findSymbolsById(project, candidates[0]!.id)[0]!
+}
+
+function uniqueDeclarations(project: Project): TreeNode[] {
+  const seen = new Set<string>()
+  return getAllNodes(project).filter((node) => {
+    if (!node.name || !node.content || node.type === 'file' || seen.has(node.id)) return false
+    seen.add(node.id)
+    return true
+  })
+}
+
+function typeNames(content: string): string[] {
+  return [...new Set(content.match(TYPE_NAME) ?? [])]
+}
+
+/**
+ * The declarations each name most likely refers to: those in the definition's file, else those
+ * in its directory, else any of that name
+ */
+function closestByName(definition: TreeNode, names: string[], declarations: TreeNode[], kinds: string[]): TreeNode[] {
+  const related: TreeNode[] = []
+  for (const name of names) {
+    const matches = declarations.filter(node => node.name === name && !isWithin(node, definition) && kinds.includes(kindOf(node)))
+    const sameFile = matches.filter(node => node.path === definition.path)
+    const sameDirectory = matches.filter(node => dirname(node.path) === dirname(definition.path))
+    related.push(...(sameFile.length > 0 ? sameFile : sameDirectory.length > 0 ? sameDirectory : matches))
+  }
+  return related
+}
+
+/**
+ * Functions whose body calls the definition, limited to files that can see it when imports
+ * are indexed for its language
+ */
+function findCallers(project: Project, definition: TreeNode, declarations: TreeNode[]): TreeNode[] {
+  const name = definition.name!
+  const dependents = findDependentFiles(project, definition)
+
+  return declarations
+    .filter(node => CALLABLE_KINDS.includes(kindOf(node)) && node.name !== name && !isWithin(node, definition))
+    .filter(node => !dependents || dependents.has(node.path))
+    .filter(node => calledNames(node.content!).includes(name))
+    .sort((a, b) => a.path.localeCompare(b.path) || (a.startLine ?? 0) - (b.startLine ?? 0))
+}
+
+// Members of a class target are part of its definition, not related symbols
+function isWithin(node: TreeNode, definition: TreeNode): boolean {
+  return node.path === definition.path
+    && (node.startLine ?? 0) >= (definition.startLine ?? 0)
+    && (node.endLine ?? 0) <= (definition.endLine ?? 0)
+}
+
+function toEntry(root: string, node: TreeNode): ContextEntry {
+  return {
+    id: symbolId(root, node) ?? node.path,
+    name: node.name ?? 'anonymous',
+    kind: kindOf(node),
+    path: node.path,
+    startLine: node.startLine,
+    endLine: node.endLine,
+    signature: node.symbol?.signature ?? node.content?.split('\n')[0]?.trim() ?? node.name ?? '',
+  }
+}
+
+function kindOf(node: TreeNode): string {
+  return node.symbol?.kind ?? node.type
+}
+
+function summaryTokens(entry: ContextEntry): number {
+  return estimateTokens(`${entry.id} ${entry.path}:${entry.startLine ?? ''} ${entry.signature}`)
+}
+
+function truncateToTokens(content: string, maxTokens: number): string {
+  const lines = content.split('\n')
+  const kept: string[] = []
+  let tokens = 0
+  for (const line of lines) {
+    tokens += estimateTokens(line + '\n')
+    if (tokens > maxTokens) break
+    kept.push(line)
+  }
+  return kept.join('\n')
+}
+
+function usesName(text: string, name: string): boolean {
+  return new RegExp(`(?<![\\w$])${escapeRegExp(name)}(?![\\w$])`).test(text)
+}
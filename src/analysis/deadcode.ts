@@ -5,6 +5,8 @@
 import { extractImports } from '../import/resolver.js'
 import { TEST_PATTERNS, isTestFile } from '../constants/index.js'
 import { escapeRegExp } from '../utils/string-analysis.js'
+import { expandPathAlias } from '../project/path-aliases.js'
+import { usesFramework } from '../project/frameworks.js'
 import type { Project, TreeNode } from '../types/core.js'
 import type { DeadcodeResult, DeadcodeMetrics, Finding } from '../types/analysis.js'
 
@@ -148,30 +150,38 @@ function traverseDependencies(entryPoints: string[], dependencyGraph: Map<string
 
 function detectFrameworkConventions(project: Project): Set<string> {
   const frameworkFiles = new Set<string>()
+  // Without detection results every convention applies, as before detection existed
+  const applies = (id: string) => usesFramework(project, id) !== false
 
-  for (const [filePath] of project.files) {
-    if (filePath.includes('/pages/')
-      || filePath.includes('/app/')
-      || TEST_PATTERNS.NEXT_JS_SPECIAL.some(pattern => filePath.includes(pattern))
-      || filePath.includes('/api/')) {
-      frameworkFiles.add(filePath)
+  if (applies('nextjs')) {
+    for (const [filePath] of project.files) {
+      if (filePath.includes('/pages/')
+        || filePath.includes('/app/')
+        || TEST_PATTERNS.NEXT_JS_SPECIAL.some(pattern => filePath.includes(pattern))
+        || filePath.includes('/api/')) {
+        frameworkFiles.add(filePath)
+      }
     }
   }
 
-  for (const [filePath] of project.files) {
-    if (filePath.includes('/pages/')
-      || filePath.includes('/layouts/')
-      || filePath.includes('/middleware/')
-      || filePath.includes('/plugins/')) {
-      frameworkFiles.add(filePath)
+  if (applies('nuxt')) {
+    for (const [filePath] of project.files) {
+      if (filePath.includes('/pages/')
+        || filePath.includes('/layouts/')
+        || filePath.includes('/middleware/')
+        || filePath.includes('/plugins/')) {
+        frameworkFiles.add(filePath)
+      }
     }
   }
 
-  for (const [filePath] of project.files) {
-    if (filePath.includes('/src/views/')
-      || filePath.includes('/src/components/')
-      || filePath.includes('/src/router/')) {
-      frameworkFiles.add(filePath)
+  if (applies('vue') || applies('nuxt')) {
+    for (const [filePath] of project.files) {
+      if (filePath.includes('/src/views/')
+        || filePath.includes('/src/components/')
+        || filePath.includes('/src/router/')) {
+        frameworkFiles.add(filePath)
+      }
     }
   }
 
@@ -221,13 +231,14 @@ function findUnreachableCode(
 }
 
 function resolveImportToFile(importPath: string, currentFile: string, project: Project): string | null {
-  if (importPath.startsWith('./') || importPath.startsWith('../')) {
-    const dir = currentFile.split('/').slice(0, -1).join('/')
-    const resolved = `${dir}/${importPath}`
+  const bases = importPath.startsWith('./') || importPath.startsWith('../')
+    ? [`${currentFile.split('/').slice(0, -1).join('/')}/${importPath}`]
+    : expandPathAlias(importPath, currentFile, project.pathAliases ?? [])
 
-    const extensions = ['', '.js', '.ts', '.jsx', '.tsx', '/index.js', '/index.ts']
+  const extensions = ['', '.js', '.ts', '.jsx', '.tsx', '/index.js', '/index.ts']
+  for (const base of bases) {
     for (const ext of extensions) {
-      const candidate = resolved + ext
+      const candidate = base + ext
       if (project.files.has(candidate)) {
         return candidate
       }
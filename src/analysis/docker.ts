@@ -0,0 +1,87 @@
+/**
+ * Container build checks - COPY/ADD sources that don't exist in the build context
+ */
+
+import { existsSync, readdirSync } from 'fs'
+import { basename, dirname, join, resolve } from 'path'
+import { parseDockerfile, parseComposeFile } from '../core/docker.js'
+import { INFRASTRUCTURE_FILE_PATTERNS } from '../constants/index.js'
+import { globToRegExp } from '../utils/helpers.js'
+import type { TreeNode } from '../types/core.js'
+import type { Finding } from '../types/analysis.js'
+
+/**
+ * Reports COPY/ADD instructions whose source paths are missing from the build context. The context
+ * is the Dockerfile's directory unless a compose service builds it with a different one.
+ */
+export function findMissingCopySources(fileNodes: TreeNode[]): Finding[] {
+  const contexts = collectBuildContexts(fileNodes)
+  const findings: Finding[] = []
+
+  for (const fileNode of fileNodes) {
+    if (!matches(fileNode.path, INFRASTRUCTURE_FILE_PATTERNS.DOCKERFILE) || !fileNode.content) continue
+
+    const context = contexts.get(resolve(fileNode.path)) ?? dirname(fileNode.path)
+    for (const copy of parseDockerfile(fileNode.content).copies) {
+      if (copy.from) continue
+
+      for (const source of copy.sources) {
+        if (isUncheckable(source) || sourceExists(context, source)) continue
+        findings.push({
+          type: 'structure',
+          category: 'missing_copy_source',
+          severity: 'warning',
+          location: `${fileNode.path}:${copy.line}`,
+          description: `${copy.instruction} source not found in build context: ${source}`,
+          metrics: { context },
+        })
+      }
+    }
+  }
+
+  return findings
+}
+
+function collectBuildContexts(fileNodes: TreeNode[]): Map<string, string> {
+  const contexts = new Map<string, string>()
+
+  for (const fileNode of fileNodes) {
+    if (!matches(fileNode.path, INFRASTRUCTURE_FILE_PATTERNS.COMPOSE) || !fileNode.content) continue
+
+    for (const service of parseComposeFile(fileNode.content)) {
+      if (!service.build || isUncheckable(service.build.context)) continue
+      const context = resolve(dirname(fileNode.path), service.build.context)
+      contexts.set(resolve(context, service.build.dockerfile ?? 'Dockerfile'), context)
+    }
+  }
+
+  return contexts
+}
+
+function sourceExists(context: string, source: string): boolean {
+  const relativeSource = source.replace(/^\/+/, '').replace(/\/+$/, '') || '.'
+  if (!/[*?[]/.test(relativeSource)) {
+    return existsSync(join(context, relativeSource))
+  }
+
+  // Wildcards only in the last segment are matched against the directory listing
+  const directory = join(context, dirname(relativeSource))
+  if (/[*?[]/.test(dirname(relativeSource))) return existsSync(context)
+  try {
+    const pattern = globToRegExp(basename(relativeSource))
+    return readdirSync(directory).some(entry => pattern.test(entry))
+  }
+  catch {
+    return false
+  }
+}
+
+function isUncheckable(source: string): boolean {
+  // Remote URLs, build args and heredocs can't be checked against the filesystem
+  return /^[a-z]+:\/\//i.test(source) || source.includes('$') || source.startsWith('<<') || source.startsWith('git@')
+}
+
+function matches(filePath: string, patterns: readonly RegExp[]): boolean {
+  const fileName = basename(filePath)
+  return patterns.some(pattern => pattern.test(fileName))
+}
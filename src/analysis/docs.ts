@@ -0,0 +1,137 @@
+/**
+ * Documentation lookup - the sections of Markdown and LaTeX documents that mention a symbol in
+ * their headings, code spans, examples or prose, and where the symbol is defined in code
+ */
+
+import { getAllNodes } from '../project/manager.js'
+import { isDocumentationFile } from '../constants/file-types.js'
+import { escapeRegExp } from '../utils/string-analysis.js'
+import type { Project, SymbolKind, TreeNode } from '../types/core.js'
+
+export type DocMentionKind = 'heading' | 'code' | 'example' | 'text'
+
+export interface DocMention {
+  line: number
+  kind: DocMentionKind // `code` is an inline code span, `example` a line of a code block
+  text: string
+}
+
+export interface DocSection {
+  path: string
+  heading?: string // Innermost heading; absent for text before the first heading
+  headings: string[] // Enclosing headings from the outermost, e.g. ['API', 'Parsing', 'parseFile']
+  line: number
+  endLine: number
+  mentions: DocMention[]
+  defines: boolean // An example in the section declares the symbol
+}
+
+export interface SymbolDefinition {
+  path: string
+  line: number
+  kind: SymbolKind
+  signature: string
+}
+
+export interface DocsLookup {
+  definitions: SymbolDefinition[]
+  sections: DocSection[]
+}
+
+const MENTION_WEIGHTS: Record<DocMentionKind, number> = { heading: 4, code: 3, example: 2, text: 1 }
+const MAX_MENTION_LENGTH = 200
+
+/**
+ * Finds the documentation of `symbol`: document sections mentioning it, those naming it in a
+ * heading or code span first, and its declarations outside documents. `Class.method` finds the
+ * qualified name in docs and the method of that class in code.
+ */
+export function findDocsFor(project: Project, symbol: string, maxResults = 20): DocsLookup {
+  const name = symbol.split(/\.|::|#/).pop()!
+  const container = symbol.length > name.length ? symbol.substring(0, symbol.length - name.length).replace(/(?:\.|::|#)$/, '') : undefined
+
+  const files = new Map<string, TreeNode>()
+  const elements = new Map<string, TreeNode[]>()
+  const definitions = new Map<string, SymbolDefinition>()
+  const seen = new Set<string>()
+
+  for (const node of getAllNodes(project)) {
+    if (seen.has(node.id)) continue
+    seen.add(node.id)
+    if (!isDocumentationFile(node.path)) {
+      if (node.symbol && node.name === name && (!container || node.symbol.container?.endsWith(container))) {
+        definitions.set(node.id, { path: node.path, line: node.startLine ?? 1, kind: node.symbol.kind, signature: node.symbol.signature })
+      }
+      continue
+    }
+    if (node.type === 'file') files.set(node.path, node)
+    else elements.set(node.path, [...(elements.get(node.path) ?? []), node])
+  }
+
+  const pattern = new RegExp(`(?<![\\w$])${escapeRegExp(symbol)}(?![\\w$])`)
+  const sections = [...files.values()].flatMap(file => findSections(file, elements.get(file.path) ?? [], pattern, name))
+
+  return {
+    definitions: [...definitions.values()].sort((a, b) => a.path.localeCompare(b.path) || a.line - b.line),
+    sections: sections
+      .sort((a, b) => sectionScore(b) - sectionScore(a) || b.mentions.length - a.mentions.length || a.path.localeCompare(b.path) || a.line - b.line)
+      .slice(0, maxResults),
+  }
+}
+
+/**
+ * The sections of one document mentioning the symbol, in document order
+ */
+function findSections(file: TreeNode, elements: TreeNode[], pattern: RegExp, name: string): DocSection[] {
+  const lines = (file.content ?? '').split('\n')
+  const headings = elements.filter(element => element.type === 'heading').sort((a, b) => a.startLine! - b.startLine!)
+  const blocks = elements.filter(element => element.type === 'code_block')
+  const declared = elements.filter(element => element.type !== 'heading' && element.type !== 'code_block' && element.symbol && element.name === name)
+  const latex = !/\.(?:md|mdx|markdown)$/.test(file.path)
+  const sections = new Map<string, DocSection>()
+
+  lines.forEach((text, index) => {
+    const line = index + 1
+    const match = pattern.exec(text)
+    if (!match) return
+
+    const enclosing = headings.filter(heading => heading.startLine! <= line && line <= heading.endLine!)
+    const heading = enclosing[enclosing.length - 1]
+    const key = heading?.id ?? `${file.path}-preamble`
+    const section = sections.get(key) ?? {
+      path: file.path,
+      ...(heading ? { heading: heading.name! } : {}),
+      headings: enclosing.map(candidate => candidate.name!),
+      line: heading?.startLine ?? 1,
+      endLine: heading?.endLine ?? (headings[0] ? headings[0].startLine! - 1 : lines.length),
+      mentions: [],
+      defines: false,
+    }
+    sections.set(key, section)
+
+    const kind: DocMentionKind = heading?.startLine === line
+      ? 'heading'
+      : blocks.some(block => block.startLine! < line && line < block.endLine!)
+        ? 'example'
+        : codeSpans(text, latex).some(([start, end]) => match.index >= start && match.index < end) ? 'code' : 'text'
+    section.mentions.push({ line, kind, text: text.trim().substring(0, MAX_MENTION_LENGTH) })
+    if (declared.some(element => element.startLine === line)) section.defines = true
+  })
+
+  return [...sections.values()]
+}
+
+/**
+ * Offsets of the inline code on a line: Markdown code spans, or LaTeX `\texttt`, `\verb` and
+ * `\lstinline` arguments
+ */
+function codeSpans(text: string, latex: boolean): [number, number][] {
+  const spans = latex
+    ? text.matchAll(/\\(?:texttt|code|lstinline|mintinline\{[^}]*\})\s*\{[^}]*\}|\\(?:verb|lstinline)\*?([^\w\s{])[^\n]*?\1/g)
+    : text.matchAll(/(`+)[^`][\s\S]*?\1/g)
+  return [...spans].map(span => [span.index!, span.index! + span[0].length])
+}
+
+function sectionScore(section: DocSection): number {
+  return Math.max(...section.mentions.map(mention => MENTION_WEIGHTS[mention.kind])) + (section.defines ? 1 : 0)
+}
@@ -0,0 +1,294 @@
+/**
+ * Entry point detection - where a project can be started: main functions and packages,
+ * scripts, npm bins and scripts, CLI command registrations, serverless handlers and
+ * scheduled jobs, from source files and the configuration next to them
+ */
+
+import { basename, dirname, join, relative, sep } from 'path'
+import { readdirSync, readFileSync } from 'fs'
+import { getAllNodes } from '../project/manager.js'
+import { getLanguageForFile } from '../core/languages.js'
+import { PARSER_NAMES, isTestFile } from '../constants/index.js'
+import { isDirectory, isFile } from '../utils/helpers.js'
+import type { Project, TreeNode } from '../types/core.js'
+
+export const ENTRY_POINT_KINDS = ['main', 'script', 'bin', 'npm-script', 'command', 'serverless', 'cron'] as const
+export type EntryPointKind = typeof ENTRY_POINT_KINDS[number]
+
+export interface EntryPoint {
+  kind: EntryPointKind | 'routes'
+  path: string
+  line?: number
+  name?: string // Function, command, bin, script or job name
+  command?: string // What runs: an npm script's command, a handler reference, a crontab command
+  schedule?: string // Cron expression or rate of a scheduled job
+  framework?: string // Library or platform registering it, e.g. cobra, click, serverless
+  routes?: number // Route registrations in the file; set by the architecture summary
+}
+
+interface SourcePattern {
+  kind: EntryPointKind
+  framework?: string
+  languages?: string[] // Any language when unset
+  pattern: RegExp // Global; group 1 is the name or schedule
+  requires?: RegExp // The file must also match this
+}
+
+const SCRIPT_LANGUAGES: string[] = [PARSER_NAMES.JAVASCRIPT, PARSER_NAMES.TYPESCRIPT, PARSER_NAMES.TSX]
+
+const SOURCE_PATTERNS: SourcePattern[] = [
+  { kind: 'main', languages: [PARSER_NAMES.GO], pattern: /^func\s+(main)\s*\(\s*\)/gm, requires: /^package\s+main\b/m },
+  { kind: 'main', languages: [PARSER_NAMES.RUST], pattern: /^\s*(?:pub\s+)?(?:async\s+)?fn\s+(main)\s*\(/gm },
+  { kind: 'main', languages: [PARSER_NAMES.C, PARSER_NAMES.CPP], pattern: /^\s*(?:int|void)\s+(main)\s*\(/gm },
+  { kind: 'main', languages: [PARSER_NAMES.JAVA, PARSER_NAMES.CSHARP], pattern: /\bstatic\s+(?:async\s+)?(?:void|int|Task(?:<int>)?)\s+([Mm]ain)\s*\(/g },
+  { kind: 'main', languages: [PARSER_NAMES.KOTLIN], pattern: /^fun\s+(main)\s*\(/gm },
+  { kind: 'main', languages: [PARSER_NAMES.PYTHON], pattern: /^if\s+__name__\s*==\s*['"](__main__)['"]\s*:/gm },
+  { kind: 'command', framework: 'cobra', languages: [PARSER_NAMES.GO], pattern: /&cobra\.Command\s*\{[^}]*?\bUse:\s*"([^"\s]+)/g },
+  { kind: 'command', framework: 'urfave/cli', languages: [PARSER_NAMES.GO], pattern: /&?cli\.(?:Command|App)\s*\{[^}]*?\bName:\s*"([^"]+)"/g },
+  { kind: 'command', framework: 'yargs', languages: SCRIPT_LANGUAGES, pattern: /\.command\(\s*['"`]([^'"`\s]+)/g, requires: /\byargs\b/ },
+  { kind: 'command', framework: 'commander', languages: SCRIPT_LANGUAGES, pattern: /\.command\(\s*['"`]([^'"`\s]+)/g, requires: /\bcommander\b/ },
+  { kind: 'command', framework: 'click', languages: [PARSER_NAMES.PYTHON], pattern: /^@\w+\.(?:command|group)\([^)]*\)\s*\n(?:@[^\n]*\n)*(?:async\s+)?def\s+(\w+)/gm, requires: /^\s*(?:import|from)\s+click\b/m },
+  { kind: 'command', framework: 'typer', languages: [PARSER_NAMES.PYTHON], pattern: /^@\w+\.command\([^)]*\)\s*\n(?:@[^\n]*\n)*(?:async\s+)?def\s+(\w+)/gm, requires: /^\s*(?:import|from)\s+typer\b/m },
+  { kind: 'command', framework: 'argparse', languages: [PARSER_NAMES.PYTHON], pattern: /\.add_parser\(\s*['"]([^'"]+)['"]/g },
+  { kind: 'command', framework: 'neovim', languages: [PARSER_NAMES.LUA], pattern: /\bnvim_create_user_command\(\s*['"]([^'"]+)['"]/g },
+  { kind: 'serverless', framework: 'aws-lambda', languages: [PARSER_NAMES.GO], pattern: /\blambda\.Start(?:WithContext)?\(\s*([\w.]+)/g },
+  { kind: 'serverless', framework: 'aws-lambda', languages: SCRIPT_LANGUAGES, pattern: /^\s*(?:(?:module\.)?exports\.(handler)\s*=|export\s+(?:const|let)\s+(handler)\s*=|export\s+(?:async\s+)?function\s+(handler)\s*\()/gm },
+  { kind: 'serverless', framework: 'aws-lambda', languages: [PARSER_NAMES.PYTHON], pattern: /^(?:async\s+)?def\s+(\w*handler)\s*\(\s*event\s*,\s*context\b/gm },
+  { kind: 'cron', framework: 'node-cron', languages: SCRIPT_LANGUAGES, pattern: /\bcron\.schedule\(\s*['"`]([^'"`]+)['"`]/g },
+  { kind: 'cron', framework: 'nestjs', languages: SCRIPT_LANGUAGES, pattern: /@Cron\(\s*(?:['"`]([^'"`]+)['"`]|(CronExpression\.\w+))/g },
+  { kind: 'cron', framework: 'robfig/cron', languages: [PARSER_NAMES.GO], pattern: /\.Add(?:Func|Job)\(\s*"([^"]+)"/g },
+  { kind: 'cron', framework: 'celery', languages: [PARSER_NAMES.PYTHON], pattern: /\b(crontab\([^)]*\))/g },
+  { kind: 'cron', framework: 'spring', languages: [PARSER_NAMES.JAVA, PARSER_NAMES.KOTLIN], pattern: /@Scheduled\(\s*cron\s*=\s*"([^"]+)"/g },
+]
+
+// Directories that hold deployment configuration but rarely any indexed source
+const CONFIG_DIRECTORIES = ['.github/workflows', 'k8s', 'kubernetes', 'deploy', 'manifests', 'cron.d']
+const AWS_SCHEDULE = /\b(?:schedule|Schedule|rate|ScheduleExpression):\s*['"]?((?:cron|rate)\([^)]*\))/
+const AWS_HANDLER = /^\s*(?:handler|Handler):\s*['"]?([^'"\s#]+)/
+const CRONTAB_LINE = /^\s*(@\w+|(?:\S+\s+){4}\S+)\s+(\S.*)$/
+
+/**
+ * Lists the entry points of a project, in path and line order, optionally only some kinds.
+ * Test files are skipped.
+ */
+export function listEntryPoints(project: Project, kinds: readonly EntryPointKind[] = ENTRY_POINT_KINDS): EntryPoint[] {
+  const root = project.config.directory
+  const seen = new Set<string>()
+  const files = getAllNodes(project).filter((node) => {
+    if (node.type !== 'file' || seen.has(node.path)) return false
+    seen.add(node.path)
+    return true
+  })
+
+  const directories = new Set(['.', ...CONFIG_DIRECTORIES])
+  for (const file of files) {
+    directories.add(relative(root, dirname(file.path)).split(sep).join('/') || '.')
+  }
+
+  return [...findSourceEntryPoints(files), ...findConfigEntryPoints(root, [...directories])]
+    .filter(entry => (kinds as readonly string[]).includes(entry.kind))
+    .sort((a, b) => a.path.localeCompare(b.path) || (a.line ?? 0) - (b.line ?? 0))
+}
+
+/**
+ * Entry points declared in source: main functions, shebang scripts, CLI commands, serverless
+ * handlers and scheduled jobs, in path and line order
+ */
+export function findSourceEntryPoints(fileNodes: TreeNode[]): EntryPoint[] {
+  const entryPoints: EntryPoint[] = []
+
+  for (const file of fileNodes) {
+    const content = file.content
+    if (!content || isTestFile(file.path)) continue
+    const language = getLanguageForFile(file.path)?.name ?? ''
+
+    if (content.startsWith('#!')) {
+      entryPoints.push({ kind: 'script', path: file.path, line: 1, command: content.split('\n', 1)[0]!.slice(2).trim() })
+    }
+
+    for (const source of SOURCE_PATTERNS) {
+      if (source.languages && !source.languages.includes(language)) continue
+      if (source.requires && !source.requires.test(content)) continue
+      for (const match of content.matchAll(source.pattern)) {
+        const value = match.slice(1).find(Boolean)!
+        const line = lineAt(content, match.index! + match[0].search(/\S/))
+        entryPoints.push(source.kind === 'cron'
+          ? { kind: 'cron', path: file.path, line, schedule: value, framework: source.framework }
+          : { kind: source.kind, path: file.path, line, name: value, framework: source.framework })
+      }
+    }
+  }
+
+  // commander's `.command()` is also matched for files mentioning yargs; keep one per call
+  return entryPoints
+    .filter((entry, index) => entry.kind !== 'command'
+      || entryPoints.findIndex(other => other.kind === 'command' && other.path === entry.path && other.line === entry.line && other.name === entry.name) === index)
+    .sort((a, b) => a.path.localeCompare(b.path) || (a.line ?? 0) - (b.line ?? 0))
+}
+
+/**
+ * Entry points declared in configuration: package.json bins and scripts, Serverless and SAM
+ * functions and schedules, GitHub Actions schedules, Kubernetes CronJobs, Vercel crons and
+ * crontab files. `directories` are project-relative.
+ */
+export function findConfigEntryPoints(root: string, directories: string[]): EntryPoint[] {
+  const entryPoints: EntryPoint[] = []
+
+  for (const directory of new Set(directories)) {
+    const absolute = join(root, directory)
+    if (!isDirectory(absolute)) continue
+
+    for (const name of listFiles(absolute)) {
+      const path = join(absolute, name)
+      const reader = configReader(name, directory)
+      if (!reader) continue
+      const content = readConfig(path)
+      if (content !== undefined) entryPoints.push(...reader(content, path))
+    }
+  }
+
+  return entryPoints
+}
+
+type ConfigReader = (content: string, path: string) => EntryPoint[]
+
+function configReader(name: string, directory: string): ConfigReader | undefined {
+  if (name === 'package.json') return readPackageJson
+  if (name === 'vercel.json') return readVercelCrons
+  if (name === 'crontab' || name.endsWith('.cron') || basename(directory) === 'cron.d') return readCrontab
+  if (!/\.ya?ml$/.test(name)) return undefined
+  if (/^serverless\.ya?ml$/.test(name)) return (content, path) => readFunctionConfig(content, path, 'functions', 'serverless')
+  if (directory.endsWith('.github/workflows')) return readWorkflowSchedules
+  return (content, path) => /AWS::Serverless::Function|AWS::Lambda::Function/.test(content)
+    ? readFunctionConfig(content, path, 'Resources', 'aws-sam')
+    : readCronJobs(content, path)
+}
+
+function readPackageJson(content: string, path: string): EntryPoint[] {
+  let json: { name?: unknown, bin?: unknown, scripts?: unknown }
+  try {
+    json = JSON.parse(content)
+  }
+  catch {
+    return []
+  }
+
+  const entryPoints: EntryPoint[] = []
+  const bins = typeof json.bin === 'string' ? { [String(json.name ?? basename(dirname(path)))]: json.bin } : json.bin
+  for (const [name, target] of Object.entries(bins && typeof bins === 'object' ? bins : {})) {
+    if (typeof target !== 'string') continue
+    entryPoints.push({ kind: 'bin', path: join(dirname(path), target), name, command: target })
+  }
+  for (const [name, command] of Object.entries(json.scripts && typeof json.scripts === 'object' ? json.scripts : {})) {
+    if (typeof command !== 'string') continue
+    entryPoints.push({ kind: 'npm-script', path, line: lineOfKey(content, name), name, command })
+  }
+  return entryPoints
+}
+
+function readVercelCrons(content: string, path: string): EntryPoint[] {
+  try {
+    const crons: unknown = JSON.parse(content).crons
+    if (!Array.isArray(crons)) return []
+    return crons
+      .filter(cron => typeof cron?.schedule === 'string')
+      .map((cron): EntryPoint => ({ kind: 'cron', path, line: lineOfKey(content, 'schedule', content.indexOf(`"${cron.path}"`)), name: cron.path, schedule: cron.schedule, framework: 'vercel' }))
+  }
+  catch {
+    return []
+  }
+}
+
+function readCrontab(content: string, path: string): EntryPoint[] {
+  const entryPoints: EntryPoint[] = []
+  content.split('\n').forEach((text, index) => {
+    if (/^\s*(?:#|$)|^\s*\w+\s*=/.test(text)) return
+    const match = text.match(CRONTAB_LINE)
+    if (match) entryPoints.push({ kind: 'cron', path, line: index + 1, schedule: match[1]!.replace(/\s+/g, ' '), command: match[2]!.trim(), framework: 'crontab' })
+  })
+  return entryPoints
+}
+
+/**
+ * Functions of a Serverless `functions:` or SAM `Resources:` section, each as a serverless
+ * entry point, and their schedule events as cron entry points
+ */
+function readFunctionConfig(content: string, path: string, section: string, framework: string): EntryPoint[] {
+  const entryPoints: EntryPoint[] = []
+  let inSection = false
+  let entryIndent: number | undefined
+  let current: string | undefined
+
+  content.split('\n').forEach((text, index) => {
+    if (!text.trim() || text.trim().startsWith('#')) return
+    const indent = text.search(/\S/)
+    if (indent === 0) {
+      inSection = text.startsWith(`${section}:`)
+      entryIndent = undefined
+      return
+    }
+    if (!inSection) return
+
+    entryIndent ??= indent
+    if (indent === entryIndent) {
+      current = text.trim().match(/^([\w-]+):/)?.[1]
+      return
+    }
+
+    const handler = text.match(AWS_HANDLER)
+    if (handler) entryPoints.push({ kind: 'serverless', path, line: index + 1, name: current, command: handler[1], framework })
+    const schedule = text.match(AWS_SCHEDULE)
+    if (schedule) entryPoints.push({ kind: 'cron', path, line: index + 1, name: current, schedule: schedule[1], framework })
+  })
+  return entryPoints
+}
+
+function readWorkflowSchedules(content: string, path: string): EntryPoint[] {
+  const name = content.match(/^name:\s*['"]?([^'"\n]+?)['"]?\s*$/m)?.[1] ?? basename(path)
+  return [...content.matchAll(/^\s*-\s*cron:\s*['"]?([^'"\n]+?)['"]?\s*$/gm)]
+    .map((match): EntryPoint => ({ kind: 'cron', path, line: lineAt(content, match.index!), name, schedule: match[1], framework: 'github-actions' }))
+}
+
+function readCronJobs(content: string, path: string): EntryPoint[] {
+  const entryPoints: EntryPoint[] = []
+  for (const kind of content.matchAll(/^kind:\s*CronJob\b/gm)) {
+    // The CronJob's document runs from the previous separator to the next
+    const start = content.lastIndexOf('\n---', kind.index!) + 1
+    const next = content.slice(kind.index!).search(/^---/m)
+    const document = content.slice(start, next === -1 ? undefined : kind.index! + next)
+    const schedule = document.match(/^\s*schedule:\s*['"]?([^'"\n]+?)['"]?\s*$/m)
+    if (!schedule) continue
+
+    const name = document.match(/^metadata:\s*\n(?:\s+.*\n)*?\s+name:\s*['"]?([\w.-]+)/m)?.[1]
+    entryPoints.push({ kind: 'cron', path, line: lineAt(content, start + schedule.index! + schedule[0].search(/\S/)), name, schedule: schedule[1], framework: 'kubernetes' })
+  }
+  return entryPoints
+}
+
+function listFiles(directory: string): string[] {
+  try {
+    return readdirSync(directory).filter(name => isFile(join(directory, name)))
+  }
+  catch {
+    return []
+  }
+}
+
+function readConfig(path: string): string | undefined {
+  try {
+    return readFileSync(path, 'utf-8')
+  }
+  catch {
+    // An unreadable file declares no entry points
+    return undefined
+  }
+}
+
+function lineOfKey(content: string, key: string, from = 0): number | undefined {
+  const index = content.indexOf(`"${key}"`, Math.max(from, 0))
+  return index === -1 ? undefined : lineAt(content, index)
+}
+
+function lineAt(content: string, index: number): number {
+  return content.slice(0, index).split('\n').length
+}
@@ -0,0 +1,221 @@
+/**
+ * Environment variable map - cross-references where variables are read in code with where they
+ * are defined (.env files, compose environment, Dockerfile ENV, Kubernetes container env, shell exports)
+ */
+
+import { readdirSync, readFileSync } from 'fs'
+import { basename, dirname, join, resolve } from 'path'
+import { getAllNodes } from '../project/manager.js'
+import { parseDockerfile, parseComposeFile } from '../core/docker.js'
+import { loadKubernetesResources } from '../core/kubernetes.js'
+import {
+  ENV_READ_PATTERNS,
+  ENV_WRITE_PATTERNS,
+  ENV_FILE_PATTERNS,
+  WELL_KNOWN_ENV_VARS,
+  WELL_KNOWN_ENV_PREFIXES,
+  INFRASTRUCTURE_FILE_PATTERNS,
+  LOGIC_EXTENSIONS,
+} from '../constants/index.js'
+import type { Project, TreeNode } from '../types/core.js'
+
+export type EnvSource = 'code' | 'dotenv' | 'compose' | 'dockerfile' | 'kubernetes' | 'shell'
+
+export interface EnvReference {
+  file: string
+  line: number
+  source: EnvSource
+}
+
+export interface EnvVariable {
+  name: string
+  status: 'defined' | 'undefined' | 'unused' | 'external'
+  reads: EnvReference[]
+  definitions: EnvReference[]
+}
+
+export interface EnvVarMap {
+  variables: EnvVariable[]
+  undefined: string[]
+  unused: string[]
+  envFiles: string[]
+}
+
+/**
+ * Builds the environment variable map for a project. A variable is `undefined` when code reads it
+ * but nothing in the project defines it, and `unused` when it is defined but never read.
+ */
+export function mapEnvironmentVariables(project: Project): EnvVarMap {
+  return buildEnvVarMap(getAllNodes(project).filter(node => node.type === 'file'), project.config.directory)
+}
+
+/**
+ * Builds the map from parsed file nodes, looking for .env files in the root directory and
+ * every directory that holds an indexed file
+ */
+export function buildEnvVarMap(fileNodes: TreeNode[], rootDirectory: string): EnvVarMap {
+  const reads: (EnvReference & { name: string })[] = []
+  const definitions: (EnvReference & { name: string })[] = []
+  const envFiles = new Set<string>()
+  const readEnvFiles: string[] = []
+
+  for (const fileNode of fileNodes) {
+    const content = fileNode.content
+    if (!content) continue
+    const fileName = basename(fileNode.path)
+
+    if (matchesAny(fileName, ENV_FILE_PATTERNS)) {
+      continue // Read below with the hidden .env files
+    }
+    else if (matchesAny(fileName, INFRASTRUCTURE_FILE_PATTERNS.DOCKERFILE)) {
+      definitions.push(...parseDockerfile(content).env.map(env => ({ ...env, file: fileNode.path, source: 'dockerfile' as const })))
+    }
+    else if (matchesAny(fileName, INFRASTRUCTURE_FILE_PATTERNS.COMPOSE)) {
+      for (const service of parseComposeFile(content)) {
+        definitions.push(...service.environment.map(env => ({ ...env, file: fileNode.path, source: 'compose' as const })))
+        service.envFiles.forEach(envFile => envFiles.add(resolve(dirname(fileNode.path), envFile)))
+      }
+    }
+    else if (isShellFile(fileNode.path)) {
+      definitions.push(...findShellExports(content).map(env => ({ ...env, file: fileNode.path, source: 'shell' as const })))
+    }
+    else {
+      reads.push(...findMatches(content, ENV_READ_PATTERNS).map(env => ({ ...env, file: fileNode.path, source: 'code' as const })))
+      reads.push(...findDestructuredReads(content).map(env => ({ ...env, file: fileNode.path, source: 'code' as const })))
+      definitions.push(...findMatches(content, ENV_WRITE_PATTERNS).map(env => ({ ...env, file: fileNode.path, source: 'code' as const })))
+    }
+  }
+
+  definitions.push(...findKubernetesDefinitions(fileNodes))
+
+  // Dotfiles aren't indexed, so .env files are looked up next to the indexed sources
+  for (const directory of new Set([rootDirectory, ...fileNodes.map(node => dirname(node.path))])) {
+    for (const envFile of findEnvFiles(directory)) envFiles.add(envFile)
+  }
+  for (const envFile of envFiles) {
+    const content = readText(envFile)
+    if (content === null) continue
+    readEnvFiles.push(envFile)
+    definitions.push(...parseDotenv(content).map(env => ({ ...env, file: envFile, source: 'dotenv' as const })))
+  }
+
+  const names = new Set([...reads.map(read => read.name), ...definitions.map(definition => definition.name)])
+  const variables: EnvVariable[] = Array.from(names).sort().map((name) => {
+    const variableReads = reads.filter(read => read.name === name).map(({ name: _name, ...reference }) => reference)
+    const variableDefinitions = definitions.filter(definition => definition.name === name).map(({ name: _name, ...reference }) => reference)
+
+    return {
+      name,
+      status: variableReads.length === 0
+        ? 'unused'
+        : variableDefinitions.length > 0 ? 'defined' : isWellKnown(name) ? 'external' : 'undefined',
+      reads: variableReads,
+      definitions: variableDefinitions,
+    }
+  })
+
+  return {
+    variables,
+    undefined: variables.filter(variable => variable.status === 'undefined').map(variable => variable.name),
+    unused: variables.filter(variable => variable.status === 'unused').map(variable => variable.name),
+    envFiles: readEnvFiles.sort(),
+  }
+}
+
+/**
+ * Variables set on Kubernetes containers, by `env` entries or by the keys of the ConfigMaps
+ * and Secrets their `envFrom` imports
+ */
+function findKubernetesDefinitions(fileNodes: TreeNode[]): (EnvReference & { name: string })[] {
+  const manifests = loadKubernetesResources(fileNodes)
+  const definitions: (EnvReference & { name: string })[] = []
+
+  for (const { file, resource } of manifests) {
+    for (const container of resource.containers) {
+      definitions.push(...container.env.map(env => ({ name: env.name, line: env.line, file, source: 'kubernetes' as const })))
+      for (const source of container.envFrom) {
+        const config = manifests.find(candidate => candidate.resource.kind === source.kind && candidate.resource.name === source.name)
+        if (!config) continue
+        definitions.push(...config.resource.keys.map(key => ({ name: key.name, line: key.line, file: config.file, source: 'kubernetes' as const })))
+      }
+    }
+  }
+  return definitions
+}
+
+/**
+ * Reads variable names from dotenv content (`KEY=value`, `export KEY=value`)
+ */
+export function parseDotenv(content: string): { name: string, line: number }[] {
+  return content.split('\n').flatMap((line, index) => {
+    const match = line.match(/^\s*(?:export\s+)?([A-Za-z_][\w.]*)\s*=/)
+    return match ? [{ name: match[1]!, line: index + 1 }] : []
+  })
+}
+
+function findMatches(content: string, patterns: readonly RegExp[]): { name: string, line: number }[] {
+  const matches: { name: string, line: number }[] = []
+
+  for (const pattern of patterns) {
+    for (const match of content.matchAll(pattern)) {
+      matches.push({ name: match[1]!, line: content.substring(0, match.index).split('\n').length })
+    }
+  }
+
+  return matches
+}
+
+function findDestructuredReads(content: string): { name: string, line: number }[] {
+  const reads: { name: string, line: number }[] = []
+
+  // const { DATABASE_URL, PORT = '3000' } = process.env
+  for (const match of content.matchAll(/\{([^{}]*)\}\s*=\s*process\.env\b/g)) {
+    const line = content.substring(0, match.index).split('\n').length
+    for (const property of match[1]!.split(',')) {
+      const name = property.trim().match(/^([A-Za-z_]\w*)/)?.[1]
+      if (name) reads.push({ name, line })
+    }
+  }
+
+  return reads
+}
+
+function findShellExports(content: string): { name: string, line: number }[] {
+  return content.split('\n').flatMap((line, index) => {
+    const match = line.match(/^\s*(?:[@+-]\s*)?export\s+([A-Za-z_]\w*)\s*(?::?=|\?=)/)
+    return match ? [{ name: match[1]!, line: index + 1 }] : []
+  })
+}
+
+function findEnvFiles(directory: string): string[] {
+  try {
+    return readdirSync(directory)
+      .filter(entry => matchesAny(entry, ENV_FILE_PATTERNS))
+      .map(entry => join(directory, entry))
+  }
+  catch {
+    return []
+  }
+}
+
+function isShellFile(filePath: string): boolean {
+  return [...LOGIC_EXTENSIONS.SHELL, ...LOGIC_EXTENSIONS.MAKE].some(extension => filePath.endsWith(extension))
+    || matchesAny(basename(filePath), INFRASTRUCTURE_FILE_PATTERNS.MAKEFILE)
+}
+
+function isWellKnown(name: string): boolean {
+  return WELL_KNOWN_ENV_VARS.has(name) || WELL_KNOWN_ENV_PREFIXES.some(prefix => name.startsWith(prefix))
+}
+
+function matchesAny(fileName: string, patterns: readonly RegExp[]): boolean {
+  return patterns.some(pattern => pattern.test(fileName))
+}
+
+function readText(filePath: string): string | null {
+  try {
+    return readFileSync(filePath, 'utf-8')
+  }
+  catch {
+    return null
+  }
+}
@@ -219,7 +219,10 @@ export function countErrorNodes(node: any): number {
   return count
 }
 
-function extractActionableErrors(node: any, filePath: string): ActionableError[] {
+/**
+ * Collects the actionable errors below a raw tree-sitter node, e.g. a file's root node
+ */
+export function extractActionableErrors(node: any, filePath: string): ActionableError[] {
   const errors: ActionableError[] = []
   collectErrorsRecursively(node, filePath, errors)
   return errors
@@ -0,0 +1,345 @@
+/**
+ * Enum exhaustiveness - switch and match statements over enum-like types (Go typed const
+ * blocks, TypeScript string unions and enums, Rust enums) that handle only some of the
+ * variants and have no default arm, with the variants they miss
+ */
+
+import { dirname } from 'path'
+import type { TreeNode } from '../types/core.js'
+import type { Finding } from '../types/analysis.js'
+
+export type EnumLanguage = 'go' | 'typescript' | 'rust'
+
+export interface EnumType {
+  name: string
+  language: EnumLanguage
+  kind: 'const' | 'union' | 'enum'
+  file: string
+  line: number
+  members: string[] // Constant or variant names; quoted literals for unions
+}
+
+export interface SwitchCheck {
+  enum: string
+  language: EnumLanguage
+  file: string
+  line: number
+  handled: string[]
+  missing: string[]
+  hasDefault: boolean
+}
+
+export interface ExhaustivenessReport {
+  enums: EnumType[]
+  switches: SwitchCheck[] // Every switch resolved to an enum, exhaustive or not
+  findings: Finding[]
+}
+
+interface SwitchStatement {
+  line: number
+  labels: string[]
+  hasDefault: boolean
+}
+
+const LANGUAGE_EXTENSIONS: [RegExp, EnumLanguage][] = [[/\.go$/, 'go'], [/\.(?:tsx?|mts|cts)$/, 'typescript'], [/\.rs$/, 'rust']]
+
+const GO_ENUM_TYPE = /^type\s+(\w+)\s+(?:u?int(?:8|16|32|64)?|string|byte|rune)\s*(?:\/\/.*)?$/gm
+const GO_SWITCH = /\bswitch\b([^{\n]*)\{/g
+const TS_UNION = /(?:^|\n)\s*(?:export\s+)?type\s+(\w+)\s*=\s*((?:\s*\|?\s*(?:'[^'\n]*'|"[^"\n]*"|-?\d+)\s*)+)(?=;|\n|$)/g
+const TS_ENUM = /(?:^|\n)\s*(?:export\s+)?(?:declare\s+)?(?:const\s+)?enum\s+(\w+)\s*\{/g
+const TS_SWITCH = /\bswitch\s*\(/g
+const RUST_ENUM = /(?:^|\n)\s*(?:pub(?:\([^)]*\))?\s+)?enum\s+(\w+)\s*(?:<[^>{]*>)?\s*(?:where[^{]*)?\{/g
+const RUST_MATCH = /\bmatch\s+[^{;]+?\{/g
+const CASE_LABELS = /\bcase\s+((?:'(?:\\.|[^'\\])*'|"(?:\\.|[^"\\])*"|`[^`]*`|[^:'"`])+?):/g
+
+/**
+ * Reads the enums of the project's files and checks every switch and match resolved to one
+ */
+export function analyzeExhaustiveness(fileNodes: TreeNode[]): ExhaustivenessReport {
+  const files = fileNodes
+    .filter(node => node.type === 'file' && node.content)
+    .map(node => ({ path: node.path, language: languageOf(node.path), content: node.content! }))
+    .filter((file): file is { path: string, language: EnumLanguage, content: string } => file.language !== undefined)
+    .map(file => ({ ...file, content: maskComments(file.content, file.language) }))
+
+  const enums = files.flatMap(file => extractEnums(file.content, file.path, file.language))
+  const switches: SwitchCheck[] = []
+  for (const file of files) {
+    const candidates = enums.filter(candidate => candidate.language === file.language)
+    for (const statement of extractSwitches(file.content, file.language)) {
+      const target = resolveEnum(candidates, statement.labels, file.path, file.language)
+      if (!target) continue
+      const handled = new Set(statement.labels.map(label => memberName(label, file.language)))
+      switches.push({
+        enum: target.name,
+        language: file.language,
+        file: file.path,
+        line: statement.line,
+        handled: target.members.filter(member => handled.has(member)),
+        missing: target.members.filter(member => !handled.has(member)),
+        hasDefault: statement.hasDefault,
+      })
+    }
+  }
+
+  const findings: Finding[] = switches
+    .filter(check => check.missing.length > 0 && !check.hasDefault)
+    .map(check => ({
+      type: 'quality',
+      category: 'non_exhaustive_switch',
+      severity: 'warning',
+      location: `${check.file}:${check.line}`,
+      description: `${check.language === 'rust' ? 'Match on' : 'Switch over'} ${check.enum} doesn't handle ${check.missing.join(', ')}`,
+      metrics: { enum: check.enum, missing: check.missing },
+    }))
+
+  return { enums, switches, findings }
+}
+
+/**
+ * Enum-like types declared in a file whose comments are masked
+ */
+export function extractEnums(content: string, file: string, language: EnumLanguage): EnumType[] {
+  if (language === 'go') return extractGoEnums(content, file)
+  if (language === 'typescript') return extractTypeScriptEnums(content, file)
+  return extractRustEnums(content, file)
+}
+
+/**
+ * Go types declared over an integer or string whose typed constants act as variants. A constant
+ * without a type or value repeats the one before it, as iota blocks do.
+ */
+function extractGoEnums(content: string, file: string): EnumType[] {
+  const types = new Map<string, number>()
+  for (const match of content.matchAll(GO_ENUM_TYPE)) types.set(match[1]!, lineAt(content, match.index))
+
+  const members = new Map<string, string[]>()
+  const addMember = (type: string | undefined, name: string) => {
+    if (!type || !types.has(type) || name === '_') return
+    members.set(type, [...(members.get(type) ?? []), name])
+  }
+
+  for (const block of content.matchAll(/^const\s*\(([\s\S]*?)^\)/gm)) {
+    let previous: string | undefined
+    for (const raw of block[1]!.split('\n')) {
+      const text = raw.trim()
+      if (!text) continue
+      // `Red Color = iota`, `Red = Color(1)`, or a bare `Green` repeating the line before
+      const typed = /^([A-Za-z_]\w*(?:\s*,\s*[A-Za-z_]\w*)*)\s+([A-Za-z_]\w*)\s*=/.exec(text)
+        ?? /^([A-Za-z_]\w*)\s*=\s*([A-Za-z_]\w*)\(/.exec(text)
+      const bare = /^([A-Za-z_]\w*)$/.exec(text)
+      if (typed) previous = typed[2]
+      else if (!bare) previous = undefined
+      if (typed || bare) (typed ? typed[1]! : bare![1]!).split(',').forEach(name => addMember(previous, name.trim()))
+    }
+  }
+  for (const single of content.matchAll(/^const\s+([A-Za-z_]\w*)\s+([A-Za-z_]\w*)\s*=/gm)) addMember(single[2], single[1]!)
+
+  return [...types]
+    .filter(([type]) => (members.get(type)?.length ?? 0) >= 2)
+    .map(([type, line]) => ({ name: type, language: 'go', kind: 'const', file, line, members: members.get(type)! }))
+}
+
+/**
+ * TypeScript unions of string or number literals, and enums
+ */
+function extractTypeScriptEnums(content: string, file: string): EnumType[] {
+  const enums: EnumType[] = []
+  for (const match of content.matchAll(TS_UNION)) {
+    // A union continuing past its literals (`| 'b'` then `| Other`) is not an enum
+    if (/^\s*\|/.test(content.substring(match.index + match[0].length))) continue
+    const literals = match[2]!.match(/'[^'\n]*'|"[^"\n]*"|-?\d+/g)!.map(literal => literal.replace(/^"(.*)"$/, '\'$1\''))
+    if (literals.length < 2) continue
+    enums.push({ name: match[1]!, language: 'typescript', kind: 'union', file, line: lineAt(content, match.index + match[0].search(/\S/)), members: [...new Set(literals)] })
+  }
+
+  for (const match of content.matchAll(TS_ENUM)) {
+    const open = match.index + match[0].length - 1
+    const { body } = block(content, open, 'typescript')
+    const members = splitTopLevelCommas(body).map(member => /^\s*['"]?([\w$]+)/.exec(member)?.[1]).filter(member => member !== undefined)
+    if (members.length < 2) continue
+    enums.push({ name: match[1]!, language: 'typescript', kind: 'enum', file, line: lineAt(content, match.index + match[0].search(/\S/)), members })
+  }
+  return enums
+}
+
+/**
+ * Rust enums with their unit, tuple and struct variants
+ */
+function extractRustEnums(content: string, file: string): EnumType[] {
+  const enums: EnumType[] = []
+  for (const match of content.matchAll(RUST_ENUM)) {
+    const { body } = block(content, match.index + match[0].length - 1, 'rust')
+    const members = splitTopLevelCommas(body, true)
+      .map(variant => variant.replace(/#\[[^\]]*\]/g, '').trim())
+      .map(variant => /^([A-Z]\w*)/.exec(variant)?.[1])
+      .filter(variant => variant !== undefined)
+    if (members.length < 2) continue
+    enums.push({ name: match[1]!, language: 'rust', kind: 'enum', file, line: lineAt(content, match.index + match[0].search(/\S/)), members })
+  }
+  return enums
+}
+
+/**
+ * The switch (match in Rust) statements of a file with the labels of their own arms
+ */
+export function extractSwitches(content: string, language: EnumLanguage): SwitchStatement[] {
+  const statements: SwitchStatement[] = []
+
+  if (language === 'rust') {
+    for (const match of content.matchAll(RUST_MATCH)) {
+      const { body } = block(content, match.index + match[0].length - 1, language)
+      const labels = [...body.matchAll(/\b((?:\w+::)+[A-Z]\w*)\s*(?:\([^)]*\)|\{[^}]*\})?\s*(?==>|\||\bif\b)/g)].map(arm => arm[1]!)
+      // `_ =>` and a lone binding such as `other =>` catch every remaining variant
+      const hasDefault = /(?:^|[,{}])\s*(?:_|[a-z_]\w*)\s*=>/.test(body)
+      statements.push({ line: lineAt(content, match.index), labels, hasDefault })
+    }
+    return statements
+  }
+
+  const heads = language === 'go'
+    ? [...content.matchAll(GO_SWITCH)].filter(match => !/\.\(type\)/.test(match[1]!) && match[1]!.trim() !== '').map(match => ({ index: match.index, open: match.index + match[0].length - 1 }))
+    : [...content.matchAll(TS_SWITCH)].map(match => ({ index: match.index, open: content.indexOf('{', closingParen(content, match.index + match[0].length - 1)) }))
+
+  for (const head of heads) {
+    if (head.open < 0) continue
+    const { body } = block(content, head.open, language)
+    const labels = [...body.matchAll(CASE_LABELS)].flatMap(label => language === 'go' ? splitTopLevelCommas(label[1]!) : [label[1]!]).map(label => label.trim())
+    statements.push({ line: lineAt(content, head.index), labels, hasDefault: /\bdefault\s*:/.test(body) })
+  }
+  return statements
+}
+
+/**
+ * The enum every label names a member of: qualified labels (`Status.Active`, `Shape::Circle`)
+ * by the qualifier, bare Go constants and literals by membership. Two or more literals are
+ * needed before a union is assumed, and the smallest fitting type wins, nearest file first.
+ */
+function resolveEnum(enums: EnumType[], labels: string[], file: string, language: EnumLanguage): EnumType | undefined {
+  if (labels.length === 0) return undefined
+  const qualifiers = new Set(labels.map(label => qualifier(label, language)))
+  if (qualifiers.size > 1) return undefined
+  const [owner] = qualifiers
+  const literal = language === 'typescript' && labels.every(label => /^['"\d-]/.test(label))
+  if (language === 'typescript' && !owner && (!literal || labels.length < 2)) return undefined
+  if (language === 'rust' && !owner) return undefined
+
+  const names = labels.map(label => memberName(label, language))
+  // A Go qualifier names the package, not the type
+  return enums
+    .filter(candidate => !owner || owner === 'Self' || language === 'go' || candidate.name === owner)
+    .filter(candidate => (candidate.kind === 'union') === literal)
+    .filter(candidate => names.every(name => candidate.members.includes(name)))
+    .sort((a, b) => Number(b.file === file) - Number(a.file === file)
+      || Number(dirname(b.file) === dirname(file)) - Number(dirname(a.file) === dirname(file))
+      || a.members.length - b.members.length)[0]
+}
+
+function qualifier(label: string, language: EnumLanguage): string | undefined {
+  if (language === 'rust') return /^(?:\w+::)*(\w+)::\w+$/.exec(label)?.[1]
+  if (language === 'typescript') return /^([\w$]+)\.[\w$]+$/.exec(label)?.[1]
+  return /^\w+\.\w+$/.test(label) ? label.split('.')[0] : undefined
+}
+
+function memberName(label: string, language: EnumLanguage): string {
+  if (language === 'rust') return label.split('::').pop()!
+  if (/^["']/.test(label)) return label.replace(/^"(.*)"$/, '\'$1\'')
+  return label.split('.').pop()!
+}
+
+/**
+ * The body of the brace block opening at `open`, with the content of nested blocks blanked so
+ * only the block's own statements remain; strings are kept
+ */
+function block(content: string, open: number, language: EnumLanguage): { body: string, end: number } {
+  let depth = 0
+  let body = ''
+  for (let i = open; i < content.length; i++) {
+    const literal = stringAt(content, i, language)
+    if (literal) {
+      body += depth === 1 ? literal : literal.replace(/[^\n]/g, ' ')
+      i += literal.length - 1
+      continue
+    }
+
+    // Nested blocks keep their braces so patterns like `Shape::Square { .. }` still read
+    const char = content[i]!
+    if (char === '{') depth++
+    if (char === '}' && --depth === 0) return { body, end: i }
+    if (depth === 1 && char === '{') continue
+    body += depth === 1 || char === '\n' || (depth === 2 && char === '{') || (depth === 1 && char === '}') ? char : ' '
+  }
+  return { body, end: content.length }
+}
+
+function closingParen(content: string, open: number): number {
+  let depth = 0
+  for (let i = open; i < content.length; i++) {
+    if (content[i] === '(') depth++
+    if (content[i] === ')' && --depth === 0) return i
+  }
+  return content.length
+}
+
+function stringAt(content: string, index: number, language: EnumLanguage): string | undefined {
+  const char = content[index]
+  if (char === '\'' && language !== 'typescript') return /^'(?:\\.|[^\\'\n])'/.exec(content.substring(index, index + 12))?.[0]
+  if (char !== '"' && char !== '\'' && char !== '`') return undefined
+  const pattern = char === '`' ? /^`[^`]*`/ : char === '"' ? /^"(?:\\.|[^"\\\n])*"/ : /^'(?:\\.|[^'\\\n])*'/
+  return pattern.exec(content.substring(index))?.[0]
+}
+
+/**
+ * Blanks comments, keeping line breaks so positions still map to lines
+ */
+function maskComments(content: string, language: EnumLanguage): string {
+  let masked = ''
+  for (let i = 0; i < content.length; i++) {
+    const literal = stringAt(content, i, language)
+    if (literal) {
+      masked += literal
+      i += literal.length - 1
+    }
+    else if (content.startsWith('//', i) || content.startsWith('/*', i)) {
+      const line = content.startsWith('//', i)
+      const end = line ? content.indexOf('\n', i) : content.indexOf('*/', i + 2)
+      const stop = end < 0 ? content.length : line ? end : end + 2
+      masked += content.substring(i, stop).replace(/[^\n]/g, ' ')
+      i = stop - 1
+    }
+    else {
+      masked += content[i]
+    }
+  }
+  return masked
+}
+
+/**
+ * Splits on commas outside brackets; angle brackets only count for Rust generics, since
+ * TypeScript enum initializers may shift (`1 << 2`)
+ */
+function splitTopLevelCommas(text: string, angles = false): string[] {
+  const parts: string[] = []
+  let depth = 0
+  let current = ''
+  for (const char of text) {
+    if ('([{'.includes(char) || (angles && char === '<')) depth++
+    if (')]}'.includes(char) || (angles && char === '>')) depth--
+    if (char === ',' && depth === 0) {
+      parts.push(current)
+      current = ''
+    }
+    else {
+      current += char
+    }
+  }
+  return [...parts, current].filter(part => part.trim())
+}
+
+function lineAt(content: string, index: number): number {
+  return content.substring(0, index).split('\n').length
+}
+
+function languageOf(path: string): EnumLanguage | undefined {
+  return LANGUAGE_EXTENSIONS.find(([pattern]) => pattern.test(path))?.[1]
+}
@@ -0,0 +1,109 @@
+/**
+ * Feature flag usage - lists every flag key evaluated in code with its call sites, matching
+ * built-in SDK calls (LaunchDarkly, Unleash, OpenFeature, ...) plus project-configured ones
+ */
+
+import { getAllNodes } from '../project/manager.js'
+import { loadProjectSettings } from '../project/settings.js'
+import { FEATURE_FLAG_SDK_FUNCTIONS, escapeRegExp } from '../constants/index.js'
+import { getLogger } from '../utils/logger.js'
+import type { Project, TreeNode } from '../types/core.js'
+import type { FeatureFlagSettings } from '../project/settings.js'
+
+export interface FlagReference {
+  file: string
+  line: number
+  call: string
+  source: string // SDK preset name, or 'custom' for configured functions and patterns
+}
+
+export interface FeatureFlag {
+  key: string
+  references: FlagReference[]
+  files: number
+}
+
+interface FlagMatcher {
+  pattern: RegExp
+  source: string
+}
+
+/**
+ * Lists the feature flags referenced in a project. Settings passed in are merged with the
+ * project's `.tree-sitter-mcp.json` `featureFlags` section.
+ */
+export function listFeatureFlags(project: Project, settings: FeatureFlagSettings = {}): FeatureFlag[] {
+  const configured = loadProjectSettings(project.config.directory).featureFlags ?? {}
+  const merged: FeatureFlagSettings = {
+    functions: [...(configured.functions ?? []), ...(settings.functions ?? [])],
+    patterns: [...(configured.patterns ?? []), ...(settings.patterns ?? [])],
+    sdks: settings.sdks ?? configured.sdks,
+  }
+
+  return findFeatureFlags(getAllNodes(project).filter(node => node.type === 'file'), merged)
+}
+
+/**
+ * Finds flag references in file contents using the given settings
+ */
+export function findFeatureFlags(fileNodes: TreeNode[], settings: FeatureFlagSettings = {}): FeatureFlag[] {
+  const matchers = buildMatchers(settings)
+  const flags = new Map<string, FlagReference[]>()
+
+  for (const fileNode of fileNodes) {
+    const content = fileNode.content
+    if (!content) continue
+
+    const seen = new Set<string>()
+    for (const matcher of matchers) {
+      for (const match of content.matchAll(matcher.pattern)) {
+        const key = match[1]
+        if (!key) continue
+
+        const line = content.substring(0, match.index).split('\n').length
+        // Overlapping matchers (a configured function that is also an SDK call) report a site once
+        if (seen.has(`${key}:${line}`)) continue
+        seen.add(`${key}:${line}`)
+
+        const references = flags.get(key) ?? []
+        references.push({ file: fileNode.path, line, call: match[0], source: matcher.source })
+        flags.set(key, references)
+      }
+    }
+  }
+
+  return Array.from(flags.entries())
+    .map(([key, references]) => ({ key, references, files: new Set(references.map(reference => reference.file)).size }))
+    .sort((a, b) => a.key.localeCompare(b.key))
+}
+
+function buildMatchers(settings: FeatureFlagSettings): FlagMatcher[] {
+  const matchers: FlagMatcher[] = []
+
+  for (const name of settings.functions ?? []) {
+    matchers.push({ pattern: callPattern([name]), source: 'custom' })
+  }
+
+  for (const pattern of settings.patterns ?? []) {
+    try {
+      matchers.push({ pattern: new RegExp(pattern, 'g'), source: 'custom' })
+    }
+    catch (error) {
+      getLogger().warn(`Ignoring invalid feature flag pattern ${pattern}: ${error}`)
+    }
+  }
+
+  const sdks = settings.sdks ?? Object.keys(FEATURE_FLAG_SDK_FUNCTIONS)
+  for (const sdk of sdks) {
+    const functions = FEATURE_FLAG_SDK_FUNCTIONS[sdk]
+    if (functions) matchers.push({ pattern: callPattern(functions), source: sdk })
+  }
+
+  return matchers
+}
+
+function callPattern(functions: string[]): RegExp {
+  // The key may follow one non-literal argument, as in getTreatment(userKey, 'split-name')
+  const names = functions.map(escapeRegExp).join('|')
+  return new RegExp(`(?<![\\w$])(?:${names})\\s*\\(\\s*(?:[\\w$.]+\\s*,\\s*)?['"\`]([\\w.:/-]+)['"\`]`, 'g')
+}
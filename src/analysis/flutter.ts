@@ -0,0 +1,163 @@
+/**
+ * Flutter widget map - the widget classes of Dart files with their State classes and build
+ * methods, and the project widgets each one builds, so widget hierarchies can be followed
+ * from a screen down to its leaves
+ */
+
+import { getAllNodes } from '../project/manager.js'
+import { lineAt, maskDart, readDart } from '../core/dart.js'
+import type { DartClass } from '../core/dart.js'
+import type { Project, TreeNode } from '../types/core.js'
+
+export type FlutterWidgetKind = 'stateless' | 'stateful' | 'inherited' | 'widget'
+
+export interface FlutterLocation {
+  path: string
+  line: number
+  endLine: number
+}
+
+export interface FlutterWidget {
+  name: string
+  kind: FlutterWidgetKind
+  base: string // Direct superclass, e.g. `StatelessWidget`, `ConsumerWidget` or a project widget
+  path: string
+  line: number
+  state?: FlutterLocation & { name: string }
+  build?: FlutterLocation // In the State class for stateful widgets
+  children: string[] // Project widgets created by the widget's (or its State's) methods, in first-use order
+  usedBy: string[] // Project widgets that create this one
+}
+
+interface ClassInFile {
+  cls: DartClass
+  file: TreeNode
+  code: string
+}
+
+// Framework base classes, including the Riverpod, flutter_hooks and provider variants
+const WIDGET_BASES: Record<string, FlutterWidgetKind> = {
+  StatelessWidget: 'stateless',
+  ConsumerWidget: 'stateless',
+  HookWidget: 'stateless',
+  HookConsumerWidget: 'stateless',
+  StatefulWidget: 'stateful',
+  ConsumerStatefulWidget: 'stateful',
+  StatefulHookWidget: 'stateful',
+  StatefulHookConsumerWidget: 'stateful',
+  InheritedWidget: 'inherited',
+  InheritedNotifier: 'inherited',
+  InheritedModel: 'inherited',
+  InheritedTheme: 'inherited',
+  Widget: 'widget',
+  ProxyWidget: 'widget',
+  ParentDataWidget: 'widget',
+  RenderObjectWidget: 'widget',
+  LeafRenderObjectWidget: 'widget',
+  SingleChildRenderObjectWidget: 'widget',
+  MultiChildRenderObjectWidget: 'widget',
+  AnimatedWidget: 'stateful',
+  ImplicitlyAnimatedWidget: 'stateful',
+}
+const STATE_BASES = new Set(['State', 'ConsumerState', 'AnimatedWidgetBaseState', 'ImplicitlyAnimatedWidgetState'])
+const CONSTRUCTOR_CALL = /(?<![\w$.])(_?[A-Z][\w$]*)(?:\.[\w$]+)?\s*(?:<[^()]*>)?\s*\(/g
+
+/**
+ * Maps the Flutter widgets of a project. `name` keeps only widgets whose name contains it.
+ */
+export function mapFlutterWidgets(project: Project, name?: string): FlutterWidget[] {
+  const files = new Map<string, TreeNode>()
+  for (const node of getAllNodes(project)) {
+    if (node.type === 'file' && node.path.endsWith('.dart') && !files.has(node.path)) files.set(node.path, node)
+  }
+
+  const filter = name?.toLowerCase()
+  return extractFlutterWidgets([...files.values()])
+    .filter(widget => !filter || widget.name.toLowerCase().includes(filter))
+}
+
+/**
+ * Finds the widget classes of Dart files, pairs stateful widgets with their State classes and
+ * reads which project widgets each one creates
+ */
+export function extractFlutterWidgets(fileNodes: TreeNode[]): FlutterWidget[] {
+  const classes = new Map<string, ClassInFile>()
+  for (const file of fileNodes) {
+    if (!file.content || !file.path.endsWith('.dart')) continue
+    const code = maskDart(file.content)
+    for (const cls of readDart(file.content).classes) {
+      if (cls.keyword === 'class' && !classes.has(cls.name)) classes.set(cls.name, { cls, file, code })
+    }
+  }
+
+  // Project widgets can extend other project widgets, so resolve kinds along the superclass chain
+  const kindOf = (className: string, seen = new Set<string>()): FlutterWidgetKind | undefined => {
+    if (WIDGET_BASES[className]) return WIDGET_BASES[className]
+    const superclass = classes.get(className)?.cls.superclass
+    if (!superclass || seen.has(className)) return undefined
+    seen.add(className)
+    return kindOf(superclass, seen)
+  }
+
+  const states = new Map<string, ClassInFile>()
+  for (const entry of classes.values()) {
+    const widget = entry.cls.superTypeArguments[0]
+    if (entry.cls.superclass && STATE_BASES.has(entry.cls.superclass) && widget) states.set(widget, entry)
+  }
+
+  const widgets: FlutterWidget[] = []
+  const builders = new Map<string, ClassInFile[]>()
+  for (const entry of classes.values()) {
+    const kind = entry.cls.superclass ? kindOf(entry.cls.superclass) : undefined
+    if (!kind || entry.cls.modifiers.includes('abstract')) continue
+
+    const state = states.get(entry.cls.name) ?? stateFromCreateState(entry, classes)
+    const owner = state ?? entry
+    const build = owner.cls.members.find(member => member.name === 'build' && member.kind === 'method')
+    builders.set(entry.cls.name, state ? [entry, state] : [entry])
+
+    widgets.push({
+      name: entry.cls.name,
+      kind,
+      base: entry.cls.superclass!,
+      path: entry.file.path,
+      line: lineAt(entry.file.content!, entry.cls.start),
+      ...(state ? { state: { name: state.cls.name, ...location(state.file, state.cls.start, state.cls.end) } } : {}),
+      ...(build ? { build: location(owner.file, build.start, build.end) } : {}),
+      children: [],
+      usedBy: [],
+    })
+  }
+
+  const byName = new Map(widgets.map(widget => [widget.name, widget]))
+  for (const widget of widgets) {
+    for (const { cls, code } of builders.get(widget.name)!) {
+      for (const member of cls.members) {
+        if (!member.body) continue
+        for (const match of code.substring(member.body.start, member.body.end).matchAll(CONSTRUCTOR_CALL)) {
+          const child = byName.get(match[1]!)
+          if (!child || child === widget || widget.children.includes(child.name)) continue
+          widget.children.push(child.name)
+          child.usedBy.push(widget.name)
+        }
+      }
+    }
+  }
+
+  return widgets.sort((a, b) => a.path.localeCompare(b.path) || a.line - b.line)
+}
+
+/**
+ * The State class `createState()` returns, for State classes declared without the widget as
+ * their type argument
+ */
+function stateFromCreateState(entry: ClassInFile, classes: Map<string, ClassInFile>): ClassInFile | undefined {
+  const createState = entry.cls.members.find(member => member.name === 'createState')
+  if (!createState?.body) return undefined
+  const created = entry.code.substring(createState.body.start, createState.body.end).match(/(?<![\w$.])(_?[A-Z][\w$]*)\s*\(/)?.[1]
+  return created ? classes.get(created) : undefined
+}
+
+function location(file: TreeNode, start: number, end: number): FlutterLocation {
+  return { path: file.path, line: lineAt(file.content!, start), endLine: lineAt(file.content!, end) }
+}
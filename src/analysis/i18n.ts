@@ -0,0 +1,298 @@
+/**
+ * Translation coverage - cross-references translation calls in code (t('key'), gettext, <Trans>)
+ * with the project's locale files (JSON, YAML, gettext PO) and lists hardcoded user-facing strings
+ */
+
+import { readFile } from 'fs/promises'
+import { basename, dirname, extname, relative } from 'path'
+import { getAllNodes } from '../project/manager.js'
+import { walkDirectory } from '../core/file-walker.js'
+import { parseYaml } from '../utils/yaml.js'
+import { getLogger } from '../utils/logger.js'
+import {
+  I18N_CALL_PATTERNS,
+  I18N_PLURAL_SUFFIXES,
+  LOCALE_CODE_PATTERN,
+  LOCALE_DIRECTORY_NAMES,
+  USER_FACING_ATTRIBUTES,
+  FRAMEWORK_EXTENSIONS,
+} from '../constants/index.js'
+import type { Project, TreeNode } from '../types/core.js'
+
+export interface TranslationUsage {
+  key: string
+  file: string
+  line: number
+}
+
+export interface LocaleCatalog {
+  locale: string
+  file: string
+  namespace?: string
+  entries: { key: string, translated: boolean }[]
+}
+
+export interface HardcodedString {
+  text: string
+  file: string
+  line: number
+  attribute?: string
+}
+
+export interface I18nReport {
+  locales: string[]
+  localeFiles: string[]
+  usedKeys: number
+  definedKeys: number
+  undefined: { key: string, usages: TranslationUsage[] }[]
+  unused: { key: string, locales: string[] }[]
+  missingTranslations: Record<string, string[]>
+  dynamicKeys: TranslationUsage[]
+  hardcodedStrings: HardcodedString[]
+}
+
+const LOCALE_EXTENSIONS = ['.json', '.yaml', '.yml', '.po']
+const MARKUP_TEXT = /<([A-Za-z][\w.:-]*)(?:\s[^<>]*?)?>([^<>{}]+)(?=[<{])/g
+const NON_TEXT_TAGS = new Set(['script', 'style', 'code', 'pre', 'kbd', 'samp'])
+
+/**
+ * Builds the translation report for a project. Locale files are found on disk: JSON/YAML under a
+ * locales-style directory or named after a locale (`en.json`, `fr-CA.yml`), and gettext `.po` files.
+ */
+export async function analyzeTranslations(project: Project): Promise<I18nReport> {
+  const root = project.config.directory
+  const files = await walkDirectory(root, { ignoreDirs: project.config.ignoreDirs, maxDepth: 10 })
+  const catalogs: LocaleCatalog[] = []
+
+  for (const file of files.filter(isLocaleFile)) {
+    try {
+      catalogs.push(...parseLocaleFile(await readFile(file, 'utf-8'), relative(root, file) || file))
+    }
+    catch (error) {
+      getLogger().debug(`Skipping unreadable locale file ${file}: ${error}`)
+    }
+  }
+
+  const fileNodes = getAllNodes(project).filter(node => node.type === 'file')
+  return buildI18nReport(fileNodes.map(node => ({ ...node, path: relative(root, node.path) || node.path })), catalogs)
+}
+
+/**
+ * Cross-references translation calls in the given files with parsed locale catalogs
+ */
+export function buildI18nReport(fileNodes: TreeNode[], catalogs: LocaleCatalog[]): I18nReport {
+  const usages: TranslationUsage[] = []
+  const dynamicKeys: TranslationUsage[] = []
+  const hardcodedStrings: HardcodedString[] = []
+
+  for (const fileNode of fileNodes) {
+    if (!fileNode.content || isLocaleFile(fileNode.path)) continue
+    for (const usage of findTranslationCalls(fileNode.content, fileNode.path)) {
+      // Template keys like `errors.${code}` can't be checked; their static prefix keeps matching keys in use
+      if (usage.key.includes('${')) dynamicKeys.push({ ...usage, key: usage.key.substring(0, usage.key.indexOf('${')) })
+      else usages.push(usage)
+    }
+    if (isMarkupSource(fileNode.path)) hardcodedStrings.push(...findHardcodedStrings(fileNode.content, fileNode.path))
+  }
+
+  const namespaces = new Set(catalogs.map(catalog => catalog.namespace).filter(Boolean))
+  const normalize = (key: string) => {
+    const separator = key.indexOf(':')
+    return separator > 0 && namespaces.has(key.substring(0, separator)) ? key.substring(separator + 1) : key
+  }
+
+  // Catalogs sharing a path apart from the locale (locales/{locale}/common.json) translate the same
+  // keys, so a key is only missing from the other locales of its own group
+  const known = new Map<string, Set<string>>()
+  const groups = new Map<string, { locales: Set<string>, keys: Map<string, Set<string>> }>()
+  for (const catalog of catalogs) {
+    const groupId = catalog.file.split(/([/\\.])/).map(part => part === catalog.locale ? '{locale}' : part).join('')
+    const group = groups.get(groupId) ?? { locales: new Set(), keys: new Map() }
+    groups.set(groupId, group)
+    group.locales.add(catalog.locale)
+
+    for (const entry of catalog.entries) {
+      const key = entry.key.replace(I18N_PLURAL_SUFFIXES, '')
+      known.set(key, (known.get(key) ?? new Set()).add(catalog.locale))
+      const translatedLocales = group.keys.get(key) ?? new Set()
+      group.keys.set(key, entry.translated ? translatedLocales.add(catalog.locale) : translatedLocales)
+    }
+  }
+
+  const locales = Array.from(new Set(catalogs.map(catalog => catalog.locale))).sort()
+  const usedKeys = new Map<string, TranslationUsage[]>()
+  for (const usage of usages) {
+    const key = normalize(usage.key)
+    usedKeys.set(key, [...(usedKeys.get(key) ?? []), usage])
+  }
+
+  const missing = new Map<string, Set<string>>()
+  for (const group of groups.values()) {
+    for (const [key, translatedLocales] of group.keys) {
+      if (!usedKeys.has(key)) continue
+      for (const locale of group.locales) {
+        if (!translatedLocales.has(locale)) missing.set(locale, (missing.get(locale) ?? new Set()).add(key))
+      }
+    }
+  }
+  const missingTranslations: Record<string, string[]> = {}
+  for (const locale of locales) {
+    if (missing.has(locale)) missingTranslations[locale] = Array.from(missing.get(locale)!).sort()
+  }
+
+  const dynamicPrefixes = dynamicKeys.map(usage => normalize(usage.key)).filter(Boolean)
+  return {
+    locales,
+    localeFiles: Array.from(new Set(catalogs.map(catalog => catalog.file))).sort(),
+    usedKeys: usedKeys.size,
+    definedKeys: known.size,
+    undefined: Array.from(usedKeys.entries())
+      .filter(([key]) => !known.has(key))
+      .map(([key, keyUsages]) => ({ key, usages: keyUsages }))
+      .sort((a, b) => a.key.localeCompare(b.key)),
+    unused: Array.from(known.entries())
+      .filter(([key]) => !usedKeys.has(key) && !dynamicPrefixes.some(prefix => key.startsWith(prefix)))
+      .map(([key, keyLocales]) => ({ key, locales: Array.from(keyLocales).sort() }))
+      .sort((a, b) => a.key.localeCompare(b.key)),
+    missingTranslations,
+    dynamicKeys,
+    hardcodedStrings,
+  }
+}
+
+/**
+ * Finds translation calls in source code
+ */
+export function findTranslationCalls(content: string, file: string): TranslationUsage[] {
+  const usages: TranslationUsage[] = []
+  const seen = new Set<number>()
+
+  for (const pattern of I18N_CALL_PATTERNS) {
+    for (const match of content.matchAll(pattern)) {
+      const key = match.groups?.key
+      // Overlapping patterns report a call once; relative Rails keys (t('.title')) depend on the view path
+      if (!key || key.startsWith('.') || seen.has(match.index)) continue
+      seen.add(match.index)
+      usages.push({ key: key.replace(/\\(['"`])/g, '$1'), file, line: content.substring(0, match.index).split('\n').length })
+    }
+  }
+
+  return usages.sort((a, b) => a.line - b.line)
+}
+
+/**
+ * Reads the catalogs of a locale file. JSON/YAML keys are flattened to dotted paths; a single
+ * top-level locale key (Rails' `en:`, vue-i18n's `{ "en": {...}, "fr": {...} }`) selects the locale.
+ */
+export function parseLocaleFile(content: string, file: string): LocaleCatalog[] {
+  const { locale, namespace } = readLocaleFromPath(file)
+
+  if (extname(file) === '.po') {
+    return [{ locale: locale ?? 'default', file, entries: parsePoEntries(content) }]
+  }
+
+  const document = extname(file) === '.json' ? JSON.parse(content) : parseYaml(content)
+  if (!document || typeof document !== 'object' || Array.isArray(document)) return []
+
+  const topKeys = Object.keys(document)
+  const values = document as Record<string, unknown>
+  const isLocaleMap = topKeys.length > 0
+    && topKeys.every(key => LOCALE_CODE_PATTERN.test(key) && values[key] !== null && typeof values[key] === 'object')
+  if (isLocaleMap && (!locale || topKeys.includes(locale))) {
+    return topKeys.map(key => ({
+      locale: key,
+      file,
+      namespace,
+      entries: flattenMessages(values[key]),
+    }))
+  }
+
+  return [{ locale: locale ?? 'default', file, namespace, entries: flattenMessages(document) }]
+}
+
+function parsePoEntries(content: string): { key: string, translated: boolean }[] {
+  const entries: { key: string, translated: boolean }[] = []
+
+  // Entries are blank-line separated; msgid/msgstr values may continue on following quoted lines
+  for (const block of content.split(/\n\s*\n/)) {
+    const msgid = readPoString(block, 'msgid')
+    if (!msgid || /^#~/m.test(block)) continue
+    const msgstrs = Array.from(block.matchAll(/^msgstr(?:\[\d+\])?\s+((?:"(?:\\.|[^"\\])*"\s*)+)/gm), match => unquotePo(match[1]!))
+    entries.push({ key: msgid, translated: msgstrs.some(Boolean) })
+  }
+
+  return entries
+}
+
+function readPoString(block: string, keyword: string): string | null {
+  const match = block.match(new RegExp(`^${keyword}\\s+((?:"(?:\\\\.|[^"\\\\])*"\\s*)+)`, 'm'))
+  return match ? unquotePo(match[1]!) : null
+}
+
+function unquotePo(value: string): string {
+  return Array.from(value.matchAll(/"((?:\\.|[^"\\])*)"/g), match => match[1]!)
+    .join('')
+    .replace(/\\n/g, '\n')
+    .replace(/\\(["\\])/g, '$1')
+}
+
+function flattenMessages(value: unknown, prefix = ''): { key: string, translated: boolean }[] {
+  if (value && typeof value === 'object' && !Array.isArray(value)) {
+    return Object.entries(value).flatMap(([key, child]) => flattenMessages(child, prefix ? `${prefix}.${key}` : key))
+  }
+  return prefix ? [{ key: prefix, translated: value !== '' && value !== null && value !== undefined }] : []
+}
+
+function readLocaleFromPath(file: string): { locale?: string, namespace?: string } {
+  const name = basename(file, extname(file))
+  const nameLocale = name.split('.').reverse().find(part => LOCALE_CODE_PATTERN.test(part))
+  if (nameLocale) return { locale: nameLocale }
+
+  // locales/en/common.json -> locale en, namespace common; locale/fr/LC_MESSAGES/django.po -> fr
+  const segments = dirname(file).split(/[/\\]/)
+  const localeRoot = segments.findLastIndex(part => LOCALE_DIRECTORY_NAMES.has(part))
+  const directoryLocale = segments.slice(localeRoot + 1).find(part => LOCALE_CODE_PATTERN.test(part))
+  return { locale: directoryLocale, namespace: directoryLocale && extname(file) !== '.po' ? name : undefined }
+}
+
+function isLocaleFile(file: string): boolean {
+  const extension = extname(file)
+  if (!LOCALE_EXTENSIONS.includes(extension)) return false
+
+  return extension === '.po'
+    || dirname(file).split(/[/\\]/).some(part => LOCALE_DIRECTORY_NAMES.has(part))
+    || basename(file, extension).split('.').some(part => LOCALE_CODE_PATTERN.test(part))
+}
+
+function isMarkupSource(file: string): boolean {
+  return [...FRAMEWORK_EXTENSIONS.REACT_JSX, ...FRAMEWORK_EXTENSIONS.REACT_TSX, ...FRAMEWORK_EXTENSIONS.VUE, ...FRAMEWORK_EXTENSIONS.SVELTE, ...FRAMEWORK_EXTENSIONS.ASTRO]
+    .some(extension => file.endsWith(extension))
+}
+
+/**
+ * Finds text content and user-facing attributes (placeholder, title, alt, ...) written as literals
+ * in JSX and component templates
+ */
+export function findHardcodedStrings(content: string, file: string): HardcodedString[] {
+  const strings: HardcodedString[] = []
+  const lineOf = (index: number) => content.substring(0, index).split('\n').length
+
+  for (const match of content.matchAll(MARKUP_TEXT)) {
+    const text = match[2]!.replace(/\s+/g, ' ').trim()
+    if (NON_TEXT_TAGS.has(match[1]!.toLowerCase()) || !isUserFacingText(text)) continue
+    strings.push({ text, file, line: lineOf(match.index + match[0].indexOf(match[2]!)) })
+  }
+
+  const attributes = new RegExp(`\\s(${USER_FACING_ATTRIBUTES.join('|')})=(["'])([^"'{}<>]+)\\2`, 'g')
+  for (const match of content.matchAll(attributes)) {
+    const text = match[3]!.trim()
+    if (isUserFacingText(text)) strings.push({ text, file, line: lineOf(match.index), attribute: match[1] })
+  }
+
+  return strings.sort((a, b) => a.line - b.line)
+}
+
+function isUserFacingText(text: string): boolean {
+  // Words, not code: generics (useState<string>(...)), comparisons and entities don't count
+  return /[A-Za-z]{2,}/.test(text) && !/[=;()[\]|&$]|=>|^&\w+;$/.test(text)
+}
@@ -6,9 +6,14 @@ import { analyzeQuality } from './quality.js'
 import { analyzeDeadcode } from './deadcode.js'
 import { analyzeStructure } from './structure.js'
 import { analyzeSyntaxErrors } from './syntax.js'
+import { analyzeSecurity } from './security.js'
+import { analyzeConcurrency } from './concurrency.js'
+import { analyzeResources } from './resources.js'
+import { analyzeNullability } from './nullability.js'
 import { createProject, parseProject } from '../project/manager.js'
 import { handleError } from '../utils/errors.js'
 import { getLogger } from '../utils/logger.js'
+import { isDocumentationFile } from '../constants/file-types.js'
 import {
   ANALYSIS_TEMPLATES,
   populateTemplateWithSections,
@@ -19,7 +24,7 @@ import {
   buildCriticalIssuesSection,
   buildWarningsSection,
 } from '../constants/templates.js'
-import type { AnalysisOptions, AnalysisResult } from '../types/analysis.js'
+import type { AnalysisOptions, AnalysisResult, Finding, FindingsDiff } from '../types/analysis.js'
 import type { ProjectConfig, Project } from '../types/core.js'
 
 /**
@@ -96,7 +101,9 @@ export async function analyzeProject(
     }
 
     if (options.includeQuality !== false) {
-      const qualityResult = analyzeQuality(allNodes)
+      // Examples in documentation illustrate an API, and fallback guesses may not be code at
+      // all; neither is held to the code's rules
+      const qualityResult = analyzeQuality(allNodes.filter(node => !isDocumentationFile(node.path) && node.symbol?.confidence !== 'low'))
       result.metrics.quality = qualityResult.metrics
       result.findings.push(...qualityResult.findings)
     }
@@ -108,7 +115,7 @@ export async function analyzeProject(
     }
 
     if (options.includeStructure) {
-      const structureResult = analyzeStructure(allNodes)
+      const structureResult = analyzeStructure(allNodes, project.pathAliases)
       result.metrics.structure = structureResult.metrics
       result.findings.push(...structureResult.findings)
     }
@@ -119,6 +126,30 @@ export async function analyzeProject(
       result.findings.push(...syntaxResult.findings)
     }
 
+    if (options.includeSecurity) {
+      const securityResult = analyzeSecurity(nodes)
+      result.metrics.security = securityResult.metrics
+      result.findings.push(...securityResult.findings)
+    }
+
+    if (options.includeConcurrency) {
+      const concurrencyResult = analyzeConcurrency(nodes)
+      result.metrics.concurrency = concurrencyResult.metrics
+      result.findings.push(...concurrencyResult.findings)
+    }
+
+    if (options.includeResources) {
+      const resourceResult = analyzeResources(nodes)
+      result.metrics.resources = resourceResult.metrics
+      result.findings.push(...resourceResult.findings)
+    }
+
+    if (options.includeNullability) {
+      const nullabilityResult = analyzeNullability(nodes, options.minConfidence)
+      result.metrics.nullability = nullabilityResult.metrics
+      result.findings.push(...nullabilityResult.findings)
+    }
+
     result.summary = calculateSummary(result.findings)
 
     logger.info(`Analysis complete: ${result.findings.length} findings`)
@@ -141,6 +172,45 @@ export function calculateSummary(findings: AnalysisResult['findings']): Analysis
   }
 }
 
+/**
+ * Compares two sets of findings. Findings are matched by file rather than line and ignore
+ * the measured values in their description, so code shifting down a few lines or a
+ * complexity going from 16 to 15 does not read as a resolved issue plus a new one.
+ */
+export function diffFindings(previous: Finding[], current: Finding[]): FindingsDiff {
+  const remaining = new Map<string, number>()
+  for (const finding of previous) {
+    const key = findingKey(finding)
+    remaining.set(key, (remaining.get(key) ?? 0) + 1)
+  }
+
+  const added: Finding[] = []
+  for (const finding of current) {
+    const key = findingKey(finding)
+    const count = remaining.get(key) ?? 0
+    if (count > 0) remaining.set(key, count - 1)
+    else added.push(finding)
+  }
+
+  const resolved: Finding[] = []
+  for (const finding of previous) {
+    const key = findingKey(finding)
+    const count = remaining.get(key) ?? 0
+    if (count > 0) {
+      remaining.set(key, count - 1)
+      resolved.push(finding)
+    }
+  }
+
+  return { added, resolved }
+}
+
+function findingKey(finding: Finding): string {
+  const file = finding.location.replace(/:\d+(?::\d+)?$/, '')
+  const description = finding.description.replace(/\s*\([^)]*\d[^)]*\)/g, '')
+  return [finding.type, finding.category, finding.severity, file, description].join('\0')
+}
+
 /**
  * Formats analysis results as a markdown report using templates
  */
@@ -0,0 +1,257 @@
+/**
+ * Kubernetes map - links the workloads of manifests and Helm charts to the code they run: the
+ * Dockerfiles building their images, the code reading their environment, the Services routing
+ * to them and the ConfigMaps and Secrets they mount
+ */
+
+import { basename, dirname, join, resolve, sep } from 'path'
+import { getAllNodes } from '../project/manager.js'
+import { parseComposeFile } from '../core/docker.js'
+import { imageRepository, loadKubernetesResources, WORKLOAD_KINDS, type KubernetesResource, type ManifestResource } from '../core/kubernetes.js'
+import { INFRASTRUCTURE_FILE_PATTERNS } from '../constants/index.js'
+import { buildEnvVarMap, type EnvReference } from './env-vars.js'
+import type { Project, TreeNode } from '../types/core.js'
+
+export interface ImageBuild {
+  dockerfile: string
+  context: string
+  via: 'compose' | 'build-command' | 'directory'
+}
+
+export interface KubernetesEnvLink {
+  name: string
+  line: number
+  from?: string // `ConfigMap/<name>` or `Secret/<name>` the value comes from
+  reads: EnvReference[] // Code reading it, within the image's build context when one is known
+}
+
+export interface KubernetesContainerLink {
+  name: string
+  image?: string
+  line: number
+  builds: ImageBuild[]
+  env: KubernetesEnvLink[]
+}
+
+export interface KubernetesWorkload {
+  kind: string
+  name: string
+  namespace?: string
+  path: string
+  line: number
+  chart?: string
+  containers: KubernetesContainerLink[]
+}
+
+export interface KubernetesService {
+  name: string
+  namespace?: string
+  path: string
+  line: number
+  selector: Record<string, string>
+  workloads: string[]
+}
+
+export interface KubernetesConfig {
+  kind: 'ConfigMap' | 'Secret'
+  name: string
+  namespace?: string
+  path: string
+  line: number
+  keys: string[]
+  usedBy: string[] // Workloads importing it with envFrom or reading a key of it
+}
+
+export interface KubernetesMap {
+  workloads: KubernetesWorkload[]
+  services: KubernetesService[]
+  configs: KubernetesConfig[]
+}
+
+const BUILD_COMMAND = /\bdocker\s+(?:buildx\s+)?build\b[^\n]*/g
+
+/**
+ * Maps the Kubernetes objects of a project to its code. `name` keeps only objects whose name
+ * contains it.
+ */
+export function mapKubernetes(project: Project, name?: string): KubernetesMap {
+  const root = project.config.directory
+  const files = uniqueFiles(getAllNodes(project))
+  const manifests = loadKubernetesResources(files)
+  const reads = new Map(buildEnvVarMap(files, root).variables.map(variable => [variable.name, variable.reads]))
+  const builders = findImageBuilders(root, files)
+  const matches = (resource: KubernetesResource) => !name || resource.name.includes(name)
+
+  const workloads = manifests.filter(({ resource }) => WORKLOAD_KINDS.includes(resource.kind))
+  const configs = manifests.filter(({ resource }) => resource.kind === 'ConfigMap' || resource.kind === 'Secret')
+
+  return {
+    workloads: workloads.filter(({ resource }) => matches(resource)).map(manifest => linkWorkload(manifest, configs, builders, reads)),
+    services: manifests
+      .filter(({ resource }) => resource.kind === 'Service' && matches(resource))
+      .map(({ file, resource }) => ({
+        name: resource.name,
+        namespace: resource.namespace,
+        path: file,
+        line: resource.line,
+        selector: resource.selector ?? {},
+        workloads: workloads
+          .filter(workload => workload.resource.namespace === resource.namespace && selects(resource.selector, podLabels(workload)))
+          .map(workload => workload.resource.name),
+      })),
+    configs: configs
+      .filter(({ resource }) => matches(resource))
+      .map(({ file, resource }) => ({
+        kind: resource.kind as KubernetesConfig['kind'],
+        name: resource.name,
+        namespace: resource.namespace,
+        path: file,
+        line: resource.line,
+        keys: resource.keys.map(key => key.name),
+        usedBy: workloads
+          .filter(workload => workload.resource.containers.some(container =>
+            container.envFrom.some(source => source.kind === resource.kind && source.name === resource.name)
+            || container.env.some(env => env.from?.kind === resource.kind && env.from.name === resource.name)))
+          .map(workload => workload.resource.name),
+      })),
+  }
+}
+
+function linkWorkload(
+  { file, chart, resource }: ManifestResource,
+  configs: ManifestResource[],
+  builders: Map<string, ImageBuild[]>,
+  reads: Map<string, EnvReference[]>,
+): KubernetesWorkload {
+  return {
+    kind: resource.kind,
+    name: resource.name,
+    namespace: resource.namespace,
+    path: file,
+    line: resource.line,
+    chart,
+    containers: resource.containers.map((container) => {
+      const builds = container.image ? findBuilds(container.image, builders) : []
+      const readsOf = (variable: string) => (reads.get(variable) ?? [])
+        .filter(read => builds.length === 0 || builds.some(build => read.file.startsWith(build.context + sep)))
+
+      const imported = container.envFrom.flatMap((source) => {
+        const config = configs.find(candidate => candidate.resource.kind === source.kind && candidate.resource.name === source.name)
+        return (config?.resource.keys ?? []).map(key => ({ name: key.name, line: container.line, from: `${source.kind}/${source.name}` }))
+      })
+      const env = [
+        ...container.env.map(entry => ({ name: entry.name, line: entry.line, from: entry.from ? `${entry.from.kind}/${entry.from.name}` : undefined })),
+        ...imported,
+      ]
+
+      return {
+        name: container.name,
+        image: container.image,
+        line: container.line,
+        builds,
+        env: env.map(entry => ({ ...entry, reads: readsOf(entry.name) })),
+      }
+    }),
+  }
+}
+
+/**
+ * Dockerfiles that build each image repository, found through compose services and
+ * `docker build -t` commands, keyed by repository
+ */
+function findImageBuilders(root: string, files: TreeNode[]): Map<string, ImageBuild[]> {
+  const builders = new Map<string, ImageBuild[]>()
+  const add = (image: string, build: ImageBuild) => {
+    const repository = imageRepository(image)
+    builders.set(repository, [...(builders.get(repository) ?? []), build])
+  }
+
+  for (const file of files) {
+    const content = file.content
+    if (!content) continue
+    const fileName = basename(file.path)
+
+    if (INFRASTRUCTURE_FILE_PATTERNS.COMPOSE.some(pattern => pattern.test(fileName))) {
+      for (const service of parseComposeFile(content)) {
+        if (!service.image || !service.build) continue
+        const context = resolve(dirname(file.path), service.build.context)
+        add(service.image, { dockerfile: resolve(context, service.build.dockerfile ?? 'Dockerfile'), context, via: 'compose' })
+      }
+      continue
+    }
+
+    // CI workflows run from the repository root, scripts and Makefiles from their directory
+    const base = file.path.includes(`${sep}.github${sep}`) ? root : dirname(file.path)
+    for (const match of content.matchAll(BUILD_COMMAND)) {
+      const command = readBuildCommand(match[0])
+      if (!command) continue
+      const context = resolve(base, command.context)
+      const dockerfile = command.dockerfile ? resolve(base, command.dockerfile) : join(context, 'Dockerfile')
+      for (const tag of command.tags) add(tag, { dockerfile, context, via: 'build-command' })
+    }
+  }
+
+  // Otherwise a Dockerfile in a directory named like the image, e.g. services/api for org/api
+  for (const file of files) {
+    if (!INFRASTRUCTURE_FILE_PATTERNS.DOCKERFILE.some(pattern => pattern.test(basename(file.path)))) continue
+    const directory = dirname(file.path)
+    const key = `directory:${basename(directory)}`
+    builders.set(key, [...(builders.get(key) ?? []), { dockerfile: file.path, context: directory, via: 'directory' }])
+  }
+  return builders
+}
+
+/**
+ * The builds of an image: by its repository, by its last path segment when the build tags it
+ * through variables (`$REGISTRY/api`), else by directory name
+ */
+function findBuilds(image: string, builders: Map<string, ImageBuild[]>): ImageBuild[] {
+  const repository = imageRepository(image)
+  const shortName = repository.split('/').pop()!
+  const exact = builders.get(repository)
+    ?? [...builders].filter(([key]) => key.includes('$') && key.split('/').pop() === shortName).flatMap(([, builds]) => builds)
+  const builds = exact.length > 0 ? exact : builders.get(`directory:${shortName}`) ?? []
+
+  const seen = new Set<string>()
+  return builds.filter(build => !seen.has(build.dockerfile) && Boolean(seen.add(build.dockerfile)))
+}
+
+function readBuildCommand(command: string): { tags: string[], dockerfile?: string, context: string } | undefined {
+  const tokens = command.replace(/\s+(?:&&|\||;).*$/, '').split(/\s+/).slice(2).map(token => token.replace(/^["']|["']$/g, ''))
+  const tags: string[] = []
+  let dockerfile: string | undefined
+  let context: string | undefined
+
+  for (let i = 0; i < tokens.length; i++) {
+    const token = tokens[i]!
+    const [flag, inline] = token.split('=', 2) as [string, string | undefined]
+    if (flag === '-t' || flag === '--tag') tags.push(inline ?? tokens[++i] ?? '')
+    else if (flag === '-f' || flag === '--file') dockerfile = inline ?? tokens[++i]
+    else if (token === 'build') continue
+    else if (token.startsWith('-')) {
+      // Flags with a value; boolean flags such as --push or --no-cache take none
+      if (!inline && /^--(?:build-arg|platform|target|label|cache-from|cache-to|secret|ssh|output|progress|network)$|^-o$/.test(token)) i++
+    }
+    else context = token
+  }
+
+  const validTags = tags.filter(Boolean)
+  return validTags.length > 0 ? { tags: validTags, dockerfile, context: context ?? '.' } : undefined
+}
+
+function podLabels({ resource }: ManifestResource): Record<string, string> {
+  return resource.selector ?? resource.labels
+}
+
+function selects(selector: Record<string, string> | undefined, labels: Record<string, string>): boolean {
+  const entries = Object.entries(selector ?? {})
+  return entries.length > 0 && entries.every(([key, value]) => labels[key] === value)
+}
+
+function uniqueFiles(nodes: TreeNode[]): TreeNode[] {
+  const files = new Map<string, TreeNode>()
+  for (const node of nodes) {
+    if (node.type === 'file' && !files.has(node.path)) files.set(node.path, node)
+  }
+  return [...files.values()]
+}
@@ -0,0 +1,409 @@
+/**
+ * Logging audit - extracts logging calls with their level, message template and structured
+ * fields, and flags inconsistent patterns (print statements in production code, error-level
+ * logs in error handlers that leave out the error)
+ */
+
+import { extname } from 'path'
+import {
+  LOGIC_EXTENSIONS,
+  FRAMEWORK_EXTENSIONS,
+  LOG_METHOD_LEVELS,
+  LOG_RECEIVER_PATTERN,
+  PRINT_CALL_PATTERNS,
+  isTestFile,
+} from '../constants/index.js'
+import { readCallArguments, splitTopLevel } from '../utils/helpers.js'
+import type { CallArguments } from '../utils/helpers.js'
+import type { TreeNode } from '../types/core.js'
+import type { Finding } from '../types/analysis.js'
+
+export type LogLevel = 'trace' | 'debug' | 'info' | 'warn' | 'error' | 'fatal' | 'print'
+
+export interface LogStatement {
+  file: string
+  line: number
+  language: string
+  logger: string // Receiver or print function as written: 'slog', 'this.logger', 'fmt.Println'
+  level: LogLevel
+  message?: string
+  fields: string[]
+  includesError: boolean
+  call: string
+}
+
+export interface LoggingAnalysis {
+  statements: LogStatement[]
+  findings: Finding[]
+}
+
+interface ScannedStatement extends LogStatement {
+  handler?: { error: string | null } // Set when the call sits in a catch/except/`if err != nil` block
+}
+
+const METHOD_CALL = /(?<![\w$.])(zap\.[LS]\(\)|(?:[\w$]+\.)*[\w$]+)((?:\.With\w*\((?:[^()]|\([^()]*\))*\))*)\.(\w+)\s*\(/g
+const RUST_LOG_MACRO = /(?<![\w$.])((?:log|tracing)::)?(trace|debug|info|warn|error)!\s*\(/g
+const COMMON_ERROR_NAMES = ['err', 'error', 'e', 'ex', 'exc', 'exception', 'cause']
+const NON_FIELD_KEYWORDS = new Set(['exc_info', 'stack_info', 'stacklevel', 'extra'])
+const NON_PRODUCTION_DIRECTORY = /(?:^|[/\\])(?:scripts?|examples?|bin|tools)[/\\]/
+const HANDLER_LOOKBACK = 200
+
+/**
+ * Extracts the logging calls of every source file and checks them for inconsistent patterns
+ */
+export function analyzeLogging(fileNodes: TreeNode[]): LoggingAnalysis {
+  const statements = fileNodes
+    .filter(node => node.type === 'file' && node.content && getLanguage(node.path))
+    .flatMap(node => scanLogStatements(node.content!, node.path))
+
+  return {
+    statements: statements.map(({ handler: _handler, ...statement }) => statement),
+    findings: findLoggingIssues(statements),
+  }
+}
+
+/**
+ * Extracts the logging calls of one file
+ */
+export function extractLogStatements(content: string, filePath: string): LogStatement[] {
+  return scanLogStatements(content, filePath).map(({ handler: _handler, ...statement }) => statement)
+}
+
+function scanLogStatements(content: string, filePath: string): ScannedStatement[] {
+  const language = getLanguage(filePath)
+  if (!language) return []
+
+  const lines = content.split('\n')
+  const statements: ScannedStatement[] = []
+  const add = (index: number, logger: string, level: LogLevel, args: string[], end: number, chain: CallArguments[] = []) => {
+    const lineStart = content.lastIndexOf('\n', index - 1) + 1
+    if (isCommentedOut(content.substring(lineStart, index), language)) return
+
+    const line = content.substring(0, index).split('\n').length
+    const handler = findEnclosingHandler(lines, line - 1, index - lineStart, language)
+    const details = describeArguments(args, chain, language, level === 'print' || /f$/.test(logger))
+    const errorNames = handler?.error ? [handler.error] : COMMON_ERROR_NAMES
+    const allArgs = [...args, ...chain.flatMap(method => method.args)]
+
+    statements.push({
+      file: filePath,
+      line,
+      language,
+      logger,
+      level,
+      message: details.message,
+      fields: details.fields,
+      includesError: details.fields.some(field => /^err(or)?$/i.test(field))
+        || /\.exception$/.test(logger)
+        || allArgs.some(arg => /^exc_info\s*=\s*(?!False\b|None\b)/.test(arg) || mentionsAny(arg, errorNames)),
+      call: collapse(content.substring(index, end)),
+      handler,
+    })
+  }
+
+  const printPattern = PRINT_CALL_PATTERNS[language]
+  for (const match of printPattern ? content.matchAll(printPattern) : []) {
+    const call = readCallArguments(content, match.index + match[0].length - 1)
+    if (call) add(match.index, match[0].replace(/\s*\($/, ''), 'print', call.args, call.end)
+  }
+
+  for (const match of content.matchAll(METHOD_CALL)) {
+    const [, receiver = '', withChain = '', method = ''] = match
+    if (!LOG_RECEIVER_PATTERN.test(receiver) || (receiver === 'console' && method === 'log')) continue
+    let level = getLevel(method)
+    if (!level) continue
+
+    const call = readCallArguments(content, match.index + match[0].length - 1)
+    if (!call) continue
+
+    // winston-style logger.log('error', message, meta)
+    let args = call.args
+    const levelArgument = method === 'log' ? readStringLiteral(args[0] ?? '') : null
+    if (levelArgument && LOG_METHOD_LEVELS[levelArgument.toLowerCase()]) {
+      level = LOG_METHOD_LEVELS[levelArgument.toLowerCase()]!
+      args = args.slice(1)
+    }
+
+    const messageChain = readMessageChain(content, call)
+    const end = messageChain[messageChain.length - 1]?.end ?? call.end
+    add(match.index, `${receiver}.${method}`, level, args, end, [...readWithChain(withChain), ...messageChain])
+  }
+
+  if (language === 'RUST') {
+    for (const match of content.matchAll(RUST_LOG_MACRO)) {
+      const call = readCallArguments(content, match.index + match[0].length - 1)
+      if (call) add(match.index, `${match[1] ?? ''}${match[2]}!`, LOG_METHOD_LEVELS[match[2]!]!, call.args, call.end)
+    }
+  }
+
+  return statements.sort((a, b) => a.line - b.line)
+}
+
+function findLoggingIssues(statements: ScannedStatement[]): Finding[] {
+  const findings: Finding[] = []
+  const loggersByLanguage = new Map<string, Map<string, number>>()
+
+  for (const statement of statements) {
+    if (statement.level === 'print' || statement.logger.startsWith('console.')) continue
+    // Rust log macros count as the log (or tracing) crate
+    const receiver = statement.logger.endsWith('!')
+      ? statement.logger.includes('::') ? statement.logger.split('::')[0]! : 'log'
+      : statement.logger.replace(/\.[^.]+$/, '')
+    const counts = loggersByLanguage.get(statement.language) ?? new Map<string, number>()
+    counts.set(receiver, (counts.get(receiver) ?? 0) + 1)
+    loggersByLanguage.set(statement.language, counts)
+  }
+
+  for (const statement of statements) {
+    const location = `${statement.file}:${statement.line}`
+
+    if (statement.level === 'print' && !isTestFile(statement.file) && !NON_PRODUCTION_DIRECTORY.test(statement.file)) {
+      const loggers = loggersByLanguage.get(statement.language)
+      const preferred = loggers && Array.from(loggers.entries()).sort((a, b) => b[1] - a[1])[0]![0]
+      findings.push({
+        type: 'quality',
+        category: 'print_logging',
+        severity: preferred ? 'warning' : 'info',
+        location,
+        description: preferred
+          ? `${statement.logger} in production code; other ${statement.language.toLowerCase()} code logs through ${preferred}`
+          : `${statement.logger} in production code instead of a logger`,
+      })
+    }
+
+    if ((statement.level === 'error' || statement.level === 'fatal') && statement.handler && !statement.includesError) {
+      findings.push({
+        type: 'quality',
+        category: 'error_log_without_error',
+        severity: 'warning',
+        location,
+        description: statement.handler.error
+          ? `Error-level log doesn't include the handled error ${statement.handler.error}`
+          : 'Error-level log in a handler that discards the error',
+        metrics: { logger: statement.logger },
+      })
+    }
+  }
+
+  return findings
+}
+
+function getLanguage(filePath: string): string | null {
+  const extension = extname(filePath)
+  if (Object.values(FRAMEWORK_EXTENSIONS).flat().some(ext => filePath.endsWith(ext))) return 'JAVASCRIPT'
+
+  for (const [language, extensions] of Object.entries(LOGIC_EXTENSIONS)) {
+    if (!(extensions as readonly string[]).includes(extension)) continue
+    if (language === 'SHELL' || language === 'MAKE' || language === 'PROTO') return null
+    return language === 'TYPESCRIPT' ? 'JAVASCRIPT' : language
+  }
+
+  return null
+}
+
+function getLevel(method: string): LogLevel | undefined {
+  const name = method.toLowerCase()
+  return LOG_METHOD_LEVELS[name] ?? LOG_METHOD_LEVELS[name.replace(/(?:ln|f|w|context|ctx)$/, '')]
+}
+
+/**
+ * Reads the arguments of the call whose opening parenthesis is at `open`, split at top-level commas
+ */
+function readWithChain(withChain: string): CallArguments[] {
+  // logrus/slog context: log.WithField("user", id).WithError(err).Error(...)
+  const calls: CallArguments[] = []
+  for (const match of withChain.matchAll(/\.(With\w*)\(/g)) {
+    const call = readCallArguments(withChain, match.index + match[0].length - 1)
+    if (call) calls.push({ ...call, args: match[1] === 'WithError' ? ['__error__', ...call.args] : [match[1]!, ...call.args] })
+  }
+  return calls
+}
+
+function readMessageChain(content: string, call: CallArguments): CallArguments[] {
+  // zerolog: log.Error().Err(err).Str("user", id).Msg("failed")
+  if (call.args.length > 0) return []
+
+  const calls: CallArguments[] = []
+  let position = call.end
+  for (let steps = 0; steps < 20; steps++) {
+    const next = content.substring(position).match(/^\s*\.(\w+)\s*\(/)
+    if (!next) break
+    const chained = readCallArguments(content, position + next[0].length - 1)
+    if (!chained) break
+    calls.push({ ...chained, args: [next[1]!, ...chained.args] })
+    position = chained.end
+    if (/^(Msg|Msgf|Send)$/.test(next[1]!)) break
+  }
+  return calls
+}
+
+function describeArguments(args: string[], chain: CallArguments[], language: string, formatted: boolean): { message?: string, fields: string[] } {
+  const fields: string[] = []
+  let message: string | undefined
+  let messageIndex = -1
+
+  for (const [index, arg] of args.entries()) {
+    const literal = readStringLiteral(arg)
+    if (literal !== null && message === undefined) {
+      message = literal
+      messageIndex = index
+      continue
+    }
+    fields.push(...readFieldNames(arg))
+  }
+
+  // slog, zap sugared (*w) and logr loggers take alternating key/value arguments after the message
+  if (language === 'GO' && !formatted && messageIndex >= 0) {
+    const rest = args.slice(messageIndex + 1)
+    for (let i = 0; i + 1 < rest.length; i++) {
+      const key = readStringLiteral(rest[i]!)
+      if (key !== null && /^[\w.-]+$/.test(key)) {
+        fields.push(key)
+        i++
+      }
+    }
+  }
+
+  for (const { args: [method = '', ...methodArgs] } of chain) {
+    if (method === '__error__' || method === 'Err') {
+      fields.push('error')
+    }
+    else if (method === 'Msg' || method === 'Msgf') {
+      message = readStringLiteral(methodArgs[0] ?? '') ?? message
+    }
+    else if (method === 'With' || method === 'WithFields') {
+      methodArgs.forEach((arg, index) => {
+        const key = readStringLiteral(arg)
+        if (key !== null && index % 2 === 0) fields.push(key)
+        else fields.push(...readFieldNames(arg))
+      })
+    }
+    else {
+      const key = readStringLiteral(methodArgs[0] ?? '')
+      if (key !== null) fields.push(key)
+    }
+  }
+
+  return { message, fields: Array.from(new Set(fields)) }
+}
+
+function readFieldNames(arg: string): string[] {
+  // Object and map literals: { userId, orderId: id }, logrus.Fields{"user": id}
+  const object = arg.match(/^[\w.]*\{([\s\S]*)\}$/)
+  if (object) {
+    return splitTopLevel(object[1]!).flatMap((entry) => {
+      const key = entry.match(/^\s*(?:['"]([\w.-]+)['"]|([A-Za-z_$][\w$]*))\s*(?::|$)/)
+      return key ? [key[1] ?? key[2]!] : []
+    })
+  }
+
+  // Python keyword arguments (structlog, loguru) and extra={...}
+  const keyword = arg.match(/^([A-Za-z_]\w*)\s*=(?!=)\s*([\s\S]*)$/)
+  if (keyword) {
+    if (keyword[1] === 'extra') return readFieldNames(keyword[2]!.trim())
+    return NON_FIELD_KEYWORDS.has(keyword[1]!) ? [] : [keyword[1]!]
+  }
+
+  // Typed attribute constructors: zap.String("user", id), slog.Int("count", n), kv("user", id)
+  if (/^zap\.(?:Error|NamedError)\(/.test(arg)) return ['error']
+  const attribute = arg.match(/^(?:(?:zap|slog|StructuredArguments)\.\w+|kv|keyValue)\(\s*["']([\w.-]+)["']/)
+  return attribute ? [attribute[1]!] : []
+}
+
+function readStringLiteral(arg: string): string | null {
+  const match = arg.match(/^[fFrRbBuU]{0,2}(["'`])((?:\\.|(?!\1)[^\\])*)\1/)
+  return match ? match[2]! : null
+}
+
+/**
+ * Finds the catch/except/rescue clause, `if err != nil` or `Err(e) =>` arm enclosing a line.
+ * Returns the bound error name, `null` when the handler doesn't bind one, or undefined outside handlers.
+ */
+function findEnclosingHandler(lines: string[], index: number, column: number, language: string): { error: string | null } | undefined {
+  // Brace-less handlers on the same line: `except ValueError as e: log.error(...)`, `Err(e) => error!(...)`
+  const prefix = lines[index]!.substring(0, column)
+  const sameLine = prefix.includes('{') ? undefined : matchHandler(prefix, language)
+  if (sameLine) return sameLine
+
+  if (language === 'PYTHON' || language === 'RUBY') {
+    let indent = lines[index]!.search(/\S/)
+    for (let i = index - 1; i >= Math.max(0, index - HANDLER_LOOKBACK) && indent > 0; i--) {
+      const lineIndent = lines[i]!.search(/\S/)
+      if (lineIndent < 0 || lineIndent >= indent || lines[i]!.trim().startsWith('#')) continue
+      indent = lineIndent
+      const handler = matchHandler(lines[i]!, language)
+      if (handler) return handler
+    }
+    return undefined
+  }
+
+  // Walk back over balanced braces; each unmatched `{` opens an enclosing block
+  let depth = 0
+  for (let i = index; i >= Math.max(0, index - HANDLER_LOOKBACK); i--) {
+    const text = stripStringsAndComments(i === index ? prefix : lines[i]!)
+    for (let j = text.length - 1; j >= 0; j--) {
+      if (text[j] === '}') {
+        depth++
+      }
+      else if (text[j] === '{') {
+        if (depth > 0) {
+          depth--
+          continue
+        }
+        const header = text.substring(0, j).trim() || stripStringsAndComments(lines[i - 1] ?? '')
+        const handler = matchHandler(header.replace(/^.*\{/, ''), language)
+        if (handler) return handler
+      }
+    }
+  }
+
+  return undefined
+}
+
+function matchHandler(header: string, language: string): { error: string | null } | undefined {
+  if (language === 'PYTHON') {
+    const except = header.match(/^\s*except\b(?:[^:]*?\bas\s+(\w+))?\s*:/)
+    return except ? { error: except[1] ?? null } : undefined
+  }
+  if (language === 'RUBY') {
+    const rescue = header.match(/^\s*rescue\b(?:[^=]*=>\s*(\w+))?/)
+    return rescue ? { error: rescue[1] ?? null } : undefined
+  }
+  if (language === 'GO') {
+    const check = header.match(/\bif\s+(?:[^;{]*;\s*)?(\w*[eE]rr\w*)\s*!=\s*nil\b/)
+    return check ? { error: check[1]! } : undefined
+  }
+  if (language === 'RUST') {
+    const arm = header.match(/\bErr\s*\(\s*(\w+)\s*\)\s*(?:=>|=[^=>])/)
+    return arm ? { error: arm[1] === '_' ? null : arm[1]! } : undefined
+  }
+
+  const catchClause = header.match(/\bcatch\b\s*(?:\(([^)]*)\)?)?[^{]*$/)
+  if (!catchClause) return undefined
+  const names = catchClause[1]?.split(':')[0]!.match(/[\w$]+/g)
+  return { error: names ? names[names.length - 1]! : null }
+}
+
+function stripStringsAndComments(line: string): string {
+  return line
+    .replace(/(["'`])(?:\\.|(?!\1)[^\\])*\1/g, '""')
+    .replace(/\/\/.*$/, '')
+}
+
+function mentionsAny(arg: string, names: string[]): boolean {
+  // Plain string literals are ignored so "failed to parse e" doesn't count as passing `e`
+  const code = arg.replace(/(?<![fF`])(["'])(?:\\.|(?!\1)[^\\])*\1/g, '""')
+  return names.some(name => new RegExp(`(?<![\\w$.])${name.replace(/\$/g, '\\$')}(?![\\w$])`).test(code))
+}
+
+function isCommentedOut(before: string, language: string): boolean {
+  const code = stripStringsAndComments(before)
+  return /^\s*(?:\*|\/\*)/.test(code)
+    || code.length < before.replace(/(["'`])(?:\\.|(?!\1)[^\\])*\1/g, '""').length
+    || ((language === 'PYTHON' || language === 'RUBY') && code.includes('#'))
+}
+
+function collapse(text: string): string {
+  const collapsed = text.replace(/\s+/g, ' ')
+  return collapsed.length > 200 ? collapsed.substring(0, 197) + '...' : collapsed
+}
@@ -0,0 +1,560 @@
+/**
+ * Database migration analysis - reads golang-migrate, goose, Prisma and Alembic migrations, extracts
+ * the schema changes of each one and replays them to give the current shape of every table
+ */
+
+import { basename, dirname } from 'path'
+import { getAllNodes } from '../project/manager.js'
+import { readCallArguments, splitTopLevel } from '../utils/helpers.js'
+import type { Project, TreeNode } from '../types/core.js'
+
+export type MigrationTool = 'golang-migrate' | 'goose' | 'prisma' | 'alembic'
+
+export interface ColumnDefinition {
+  name: string
+  type?: string
+  nullable?: boolean
+  primaryKey?: boolean
+  default?: string
+  references?: string
+}
+
+export interface SchemaChange {
+  kind: 'create_table' | 'drop_table' | 'rename_table' | 'add_column' | 'drop_column' | 'rename_column' | 'alter_column'
+  table: string
+  column?: string
+  to?: string // New name of a renamed table or column
+  columns?: ColumnDefinition[] // create_table
+  definition?: Partial<ColumnDefinition> // add_column, and the attributes alter_column changes
+  line: number
+}
+
+export interface Migration {
+  id: string
+  name: string
+  tool: MigrationTool
+  file: string
+  changes: SchemaChange[]
+  downRevisions?: string[] // Alembic revisions this one follows
+}
+
+export interface TableColumn extends ColumnDefinition {
+  addedIn: string
+  changedIn?: string
+}
+
+export interface TableShape {
+  name: string
+  columns: TableColumn[]
+  createdIn?: string // Unset when the table predates the migrations and is first seen in an ALTER
+  lastTouchedIn: string
+  droppedIn?: string
+  history: { migration: string, kind: SchemaChange['kind'], column?: string, to?: string, file: string, line: number }[]
+}
+
+const IDENTIFIER = String.raw`(?:"[^"]+"|` + '`[^`]+`' + String.raw`|\[[^\]]+\]|[\w$]+)`
+const QUALIFIED = `(${IDENTIFIER}(?:\\.${IDENTIFIER})?)`
+const COLUMN_CONSTRAINT = /\b(?:not\s+null|null|primary\s+key|references|default|unique|check|constraint|generated|collate|auto_increment|autoincrement|identity|on\s+update|comment)\b/i
+const TABLE_CONSTRAINT = /^(?:constraint|primary\s+key|foreign\s+key|unique|check|index|key|exclude|fulltext|spatial)\b/i
+const ALEMBIC_OPERATION = /\b(op|batch_op)\.(create_table|drop_table|rename_table|add_column|drop_column|alter_column)\s*\(/g
+
+/**
+ * Finds and orders the migrations of a project
+ */
+export function listMigrations(project: Project): Migration[] {
+  return findMigrations(getAllNodes(project).filter(node => node.type === 'file'))
+}
+
+/**
+ * Finds migrations among file nodes. SQL migrations are ordered by their version prefix, Alembic
+ * revisions by their down_revision chain.
+ */
+export function findMigrations(fileNodes: TreeNode[]): Migration[] {
+  const sqlMigrations: Migration[] = []
+  const revisions: Migration[] = []
+
+  for (const fileNode of fileNodes) {
+    const content = fileNode.content
+    if (!content) continue
+    const fileName = basename(fileNode.path)
+
+    const prisma = fileName === 'migration.sql' ? basename(dirname(fileNode.path)).match(/^(\d+)_(.+)$/) : null
+    const migrate = fileName.match(/^(\d+)_(.+)\.up\.sql$/)
+    const goose = !migrate && !fileName.endsWith('.down.sql') ? fileName.match(/^(\d+)_(.+)\.sql$/) : null
+
+    if (prisma) {
+      sqlMigrations.push({ id: `${prisma[1]}_${prisma[2]}`, name: prisma[2]!, tool: 'prisma', file: fileNode.path, changes: parseSqlMigration(content) })
+    }
+    else if (migrate) {
+      sqlMigrations.push({ id: migrate[1]!, name: migrate[2]!, tool: 'golang-migrate', file: fileNode.path, changes: parseSqlMigration(content) })
+    }
+    else if (goose && /^--\s*\+goose\s+Up\b/im.test(content)) {
+      sqlMigrations.push({ id: goose[1]!, name: goose[2]!, tool: 'goose', file: fileNode.path, changes: parseSqlMigration(readGooseUp(content)) })
+    }
+    else if (fileName.endsWith('.py') && /^\s*def\s+upgrade\s*\(/m.test(content) && /\bfrom\s+alembic\s+import\s+op\b|\bimport\s+alembic\b/.test(content)) {
+      revisions.push(parseAlembicMigration(content, fileNode.path))
+    }
+  }
+
+  sqlMigrations.sort((a, b) => a.tool.localeCompare(b.tool) || compareVersions(a.id, b.id))
+  return [...sqlMigrations, ...orderRevisions(revisions)]
+}
+
+/**
+ * Replays migrations in order and returns every table they create or alter. Dropped tables are
+ * kept with `droppedIn` so their history can still be looked up.
+ */
+export function replayMigrations(migrations: Migration[]): TableShape[] {
+  const tables = new Map<string, TableShape>()
+
+  for (const migration of migrations) {
+    for (const change of migration.changes) {
+      const key = change.table.toLowerCase()
+      let table = tables.get(key)
+      if (!table || (table.droppedIn && change.kind === 'create_table')) {
+        table = { name: change.table, columns: [], lastTouchedIn: migration.id, history: table?.history ?? [] }
+        tables.set(key, table)
+      }
+
+      table.lastTouchedIn = migration.id
+      table.history.push({ migration: migration.id, kind: change.kind, column: change.column, to: change.to, file: migration.file, line: change.line })
+      applyChange(tables, table, change, migration.id)
+    }
+  }
+
+  return Array.from(tables.values()).sort((a, b) => a.name.localeCompare(b.name))
+}
+
+/**
+ * Current shape of one table, matched case-insensitively
+ */
+export function describeTable(migrations: Migration[], name: string): TableShape | undefined {
+  return replayMigrations(migrations).find(table => table.name.toLowerCase() === name.toLowerCase())
+}
+
+function applyChange(tables: Map<string, TableShape>, table: TableShape, change: SchemaChange, migration: string): void {
+  const findColumn = (name?: string) => table.columns.find(column => column.name.toLowerCase() === name?.toLowerCase())
+
+  switch (change.kind) {
+    case 'create_table':
+      table.createdIn = migration
+      table.droppedIn = undefined
+      table.columns = (change.columns ?? []).map(column => ({ ...column, addedIn: migration }))
+      break
+    case 'drop_table':
+      table.droppedIn = migration
+      break
+    case 'rename_table': {
+      // SQLite rebuilds (Prisma): CREATE new_t, DROP t, ALTER new_t RENAME TO t keeps t's history
+      const previous = tables.get(change.to!.toLowerCase())
+      tables.delete(change.table.toLowerCase())
+      table.name = change.to!
+      if (previous?.droppedIn) {
+        table.createdIn = previous.createdIn
+        const history = [...previous.history, ...table.history]
+        const order = new Map<string, number>()
+        history.forEach(entry => order.has(entry.migration) || order.set(entry.migration, order.size))
+        table.history = history.sort((a, b) => order.get(a.migration)! - order.get(b.migration)! || a.line - b.line)
+        for (const column of table.columns) {
+          const before = previous.columns.find(old => old.name.toLowerCase() === column.name.toLowerCase())
+          if (before) column.addedIn = before.addedIn
+        }
+      }
+      tables.set(change.to!.toLowerCase(), table)
+      break
+    }
+    case 'add_column':
+      table.columns = table.columns.filter(column => column.name.toLowerCase() !== change.column!.toLowerCase())
+      table.columns.push({ name: change.column!, ...change.definition, addedIn: migration })
+      break
+    case 'drop_column':
+      table.columns = table.columns.filter(column => column.name.toLowerCase() !== change.column!.toLowerCase())
+      break
+    case 'rename_column': {
+      const column = findColumn(change.column)
+      if (column) Object.assign(column, { name: change.to!, changedIn: migration })
+      break
+    }
+    case 'alter_column': {
+      const column = findColumn(change.column)
+      if (column) Object.assign(column, change.definition, { changedIn: migration })
+      else table.columns.push({ name: change.column!, ...change.definition, addedIn: migration })
+      break
+    }
+  }
+}
+
+/**
+ * Extracts table and column changes from SQL DDL (PostgreSQL, MySQL and SQLite dialects)
+ */
+export function parseSqlMigration(sql: string): SchemaChange[] {
+  return splitStatements(sql).flatMap(({ text, line }) => parseStatement(text.replace(/\s+/g, ' ').trim(), line))
+}
+
+function parseStatement(statement: string, line: number): SchemaChange[] {
+  const create = statement.match(new RegExp(`^create\\s+(?:(?:global\\s+|local\\s+)?(?:temporary|temp)\\s+|unlogged\\s+)?table\\s+(?:if\\s+not\\s+exists\\s+)?${QUALIFIED}\\s*\\(([\\s\\S]*)\\)[^)]*$`, 'i'))
+  if (create) {
+    return [{ kind: 'create_table', table: unquote(create[1]!), columns: parseTableBody(create[2]!), line }]
+  }
+
+  const drop = statement.match(/^drop\s+table\s+(?:if\s+exists\s+)?(.+?)(?:\s+(?:cascade|restrict))?$/i)
+  if (drop) {
+    return splitTopLevel(drop[1]!).map(table => ({ kind: 'drop_table' as const, table: unquote(table.trim()), line }))
+  }
+
+  const renameTables = statement.match(/^rename\s+table\s+(.+)$/i)
+  if (renameTables) {
+    return splitTopLevel(renameTables[1]!).flatMap((pair) => {
+      const [from, to] = pair.trim().split(/\s+to\s+/i)
+      return from && to ? [{ kind: 'rename_table' as const, table: unquote(from), to: unquote(to), line }] : []
+    })
+  }
+
+  const alter = statement.match(new RegExp(`^alter\\s+table\\s+(?:if\\s+exists\\s+)?(?:only\\s+)?${QUALIFIED}\\s+([\\s\\S]+)$`, 'i'))
+  if (alter) {
+    const table = unquote(alter[1]!)
+    return splitTopLevel(alter[2]!).flatMap(action => parseAlterAction(table, action.trim(), line))
+  }
+
+  return []
+}
+
+function parseAlterAction(table: string, action: string, line: number): SchemaChange[] {
+  const renameTable = action.match(new RegExp(`^rename\\s+to\\s+${QUALIFIED}$`, 'i'))
+  if (renameTable) return [{ kind: 'rename_table', table, to: unquote(renameTable[1]!), line }]
+
+  const renameColumn = action.match(new RegExp(`^rename\\s+(?:column\\s+)?(${IDENTIFIER})\\s+to\\s+(${IDENTIFIER})$`, 'i'))
+  if (renameColumn) return [{ kind: 'rename_column', table, column: unquote(renameColumn[1]!), to: unquote(renameColumn[2]!), line }]
+
+  const add = action.match(/^add\s+(?:column\s+)?(?:if\s+not\s+exists\s+)?([\s\S]+)$/i)
+  if (add) {
+    const constraint = parseTableConstraint(add[1]!)
+    if (constraint) return constraint.map(({ name, ...definition }) => ({ kind: 'alter_column', table, column: name, definition, line }))
+    const column = parseColumnDefinition(add[1]!)
+    if (!column) return []
+    const { name, ...definition } = column
+    return [{ kind: 'add_column', table, column: name, definition, line }]
+  }
+
+  const alterColumn = action.match(new RegExp(`^alter\\s+(?:column\\s+)?(${IDENTIFIER})\\s+([\\s\\S]+)$`, 'i'))
+  if (alterColumn) {
+    const definition = parseColumnAlteration(alterColumn[2]!)
+    return definition ? [{ kind: 'alter_column', table, column: unquote(alterColumn[1]!), definition, line }] : []
+  }
+
+  const modify = action.match(/^modify\s+(?:column\s+)?([\s\S]+)$/i)
+  if (modify) {
+    const column = parseColumnDefinition(modify[1]!)
+    if (!column) return []
+    const { name, ...definition } = column
+    return [{ kind: 'alter_column', table, column: name, definition, line }]
+  }
+
+  const change = action.match(new RegExp(`^change\\s+(?:column\\s+)?(${IDENTIFIER})\\s+([\\s\\S]+)$`, 'i'))
+  if (change) {
+    const column = parseColumnDefinition(change[2]!)
+    if (!column) return []
+    const { name, ...definition } = column
+    const from = unquote(change[1]!)
+    return [
+      ...(from.toLowerCase() !== name.toLowerCase() ? [{ kind: 'rename_column' as const, table, column: from, to: name, line }] : []),
+      { kind: 'alter_column', table, column: name, definition, line },
+    ]
+  }
+
+  const dropColumn = action.match(new RegExp(`^drop\\s+(?!constraint\\b|index\\b|key\\b|primary\\b|foreign\\b|check\\b)(?:column\\s+)?(?:if\\s+exists\\s+)?(${IDENTIFIER})(?:\\s+(?:cascade|restrict))?$`, 'i'))
+  if (dropColumn) return [{ kind: 'drop_column', table, column: unquote(dropColumn[1]!), line }]
+
+  return []
+}
+
+function parseTableBody(body: string): ColumnDefinition[] {
+  const columns: ColumnDefinition[] = []
+  const constraints: ColumnDefinition[] = []
+
+  for (const part of splitTopLevel(body).map(entry => entry.trim())) {
+    const constraint = parseTableConstraint(part)
+    if (constraint) constraints.push(...constraint)
+    else if (!TABLE_CONSTRAINT.test(part)) {
+      const column = parseColumnDefinition(part)
+      if (column) columns.push(column)
+    }
+  }
+
+  // PRIMARY KEY (id) and FOREIGN KEY (a) REFERENCES t (b) apply to columns defined above them
+  for (const { name, ...attributes } of constraints) {
+    const column = columns.find(candidate => candidate.name.toLowerCase() === name.toLowerCase())
+    if (column) Object.assign(column, attributes)
+  }
+
+  return columns
+}
+
+function parseTableConstraint(text: string): ColumnDefinition[] | null {
+  const body = text.replace(new RegExp(`^constraint\\s+${IDENTIFIER}\\s+`, 'i'), '')
+
+  const primary = body.match(/^primary\s+key\s*\(([^)]*)\)/i)
+  if (primary) return splitTopLevel(primary[1]!).map(name => ({ name: unquote(name.trim()), primaryKey: true, nullable: false }))
+
+  const foreign = body.match(new RegExp(`^foreign\\s+key\\s*\\(([^)]*)\\)\\s*references\\s+${QUALIFIED}\\s*(?:\\(([^)]*)\\))?`, 'i'))
+  if (foreign) {
+    const targets = foreign[3] ? splitTopLevel(foreign[3]).map(name => unquote(name.trim())) : []
+    return splitTopLevel(foreign[1]!).map((name, index) => ({
+      name: unquote(name.trim()),
+      references: `${unquote(foreign[2]!)}.${targets[index] ?? 'id'}`,
+    }))
+  }
+
+  return TABLE_CONSTRAINT.test(body) ? [] : null
+}
+
+function parseColumnDefinition(text: string): ColumnDefinition | null {
+  const match = text.trim().match(new RegExp(`^(${IDENTIFIER})\\s+([\\s\\S]+)$`))
+  if (!match) return null
+
+  const rest = match[2]!
+  const typeEnd = rest.search(COLUMN_CONSTRAINT)
+  const type = (typeEnd < 0 ? rest : rest.substring(0, typeEnd)).trim()
+  const options = typeEnd < 0 ? '' : rest.substring(typeEnd)
+  const references = options.match(new RegExp(`\\breferences\\s+${QUALIFIED}\\s*(?:\\(\\s*(${IDENTIFIER})\\s*\\))?`, 'i'))
+  const primaryKey = /\bprimary\s+key\b/i.test(options)
+
+  return {
+    name: unquote(match[1]!),
+    type: type || undefined,
+    nullable: primaryKey || /\bnot\s+null\b/i.test(options) ? false : /\bnull\b/i.test(options) ? true : undefined,
+    primaryKey: primaryKey || undefined,
+    default: options.match(/\bdefault\s+('(?:[^']|'')*'|\((?:[^()]|\([^()]*\))*\)|[^\s,]+)/i)?.[1],
+    references: references ? `${unquote(references[1]!)}.${references[2] ? unquote(references[2]) : 'id'}` : undefined,
+  }
+}
+
+function parseColumnAlteration(text: string): Partial<ColumnDefinition> | null {
+  const type = text.match(/^(?:set\s+data\s+)?type\s+(.+?)(?:\s+using\s+[\s\S]*)?$/i)
+  if (type) return { type: type[1]!.trim() }
+  if (/^set\s+not\s+null$/i.test(text)) return { nullable: false }
+  if (/^drop\s+not\s+null$/i.test(text)) return { nullable: true }
+  const defaultValue = text.match(/^set\s+default\s+([\s\S]+)$/i)
+  if (defaultValue) return { default: defaultValue[1]!.trim() }
+  if (/^drop\s+default$/i.test(text)) return { default: undefined }
+  return null
+}
+
+/**
+ * Reads an Alembic revision: its id, the revisions it follows and the `op` calls of `upgrade()`
+ */
+export function parseAlembicMigration(content: string, file: string): Migration {
+  const revision = content.match(/^revision\s*(?::\s*\w+\s*)?=\s*['"]([^'"]+)['"]/m)?.[1] ?? basename(file, '.py')
+  const downRevision = content.match(/^down_revision\s*(?::[^=\n]+)?=\s*(.+)$/m)?.[1] ?? ''
+  const name = basename(file, '.py').replace(new RegExp(`^${revision}_?`), '') || revision
+
+  const upgradeStart = content.search(/^def\s+upgrade\s*\(/m)
+  const upgradeEnd = content.slice(upgradeStart + 1).search(/^def\s+\w+\s*\(/m)
+  const upgrade = content.substring(0, upgradeEnd < 0 ? content.length : upgradeStart + 1 + upgradeEnd)
+  const changes: SchemaChange[] = []
+
+  for (const match of upgrade.matchAll(ALEMBIC_OPERATION)) {
+    if (match.index < upgradeStart) continue
+    const args = readCallArguments(upgrade, match.index + match[0].length - 1)?.args.filter(Boolean)
+    if (!args) continue
+
+    const line = upgrade.substring(0, match.index).split('\n').length
+    // batch_op calls take their table from the enclosing `with op.batch_alter_table('t') as batch_op:`
+    const batchTable = match[1] === 'batch_op'
+      ? Array.from(upgrade.substring(0, match.index).matchAll(/batch_alter_table\(\s*['"]([^'"]+)['"]/g)).pop()?.[1]
+      : undefined
+    const [table, ...rest] = batchTable ? [batchTable, ...args] : [readString(args[0] ?? ''), ...args.slice(1)]
+    if (!table) continue
+
+    changes.push(...readAlembicOperation(match[2]!, table, rest, line))
+  }
+
+  return {
+    id: revision,
+    name,
+    tool: 'alembic',
+    file,
+    changes,
+    downRevisions: Array.from(downRevision.matchAll(/['"]([^'"]+)['"]/g), match => match[1]!),
+  }
+}
+
+function readAlembicOperation(operation: string, table: string, args: string[], line: number): SchemaChange[] {
+  const keyword = (name: string) => args.find(arg => new RegExp(`^${name}\\s*=`).test(arg))?.replace(/^\w+\s*=\s*/, '')
+
+  switch (operation) {
+    case 'create_table': {
+      const columns = args.filter(arg => /^(?:sa\.|sqlalchemy\.)?Column\(/.test(arg)).map(readAlembicColumn).filter((column): column is ColumnDefinition => column !== null)
+      for (const arg of args) {
+        const primary = arg.match(/PrimaryKeyConstraint\(([^)]*)\)/)
+        primary?.[1]!.match(/['"][^'"]+['"]/g)?.forEach((name) => {
+          const column = columns.find(candidate => candidate.name === readString(name))
+          if (column) Object.assign(column, { primaryKey: true, nullable: false })
+        })
+        const foreign = arg.match(/ForeignKeyConstraint\(\s*\[([^\]]*)\]\s*,\s*\[([^\]]*)\]/)
+        if (foreign) {
+          const targets = foreign[2]!.match(/['"][^'"]+['"]/g)?.map(readString) ?? []
+          foreign[1]!.match(/['"][^'"]+['"]/g)?.forEach((name, index) => {
+            const column = columns.find(candidate => candidate.name === readString(name))
+            if (column && targets[index]) column.references = targets[index]
+          })
+        }
+      }
+      return [{ kind: 'create_table', table, columns, line }]
+    }
+    case 'drop_table':
+      return [{ kind: 'drop_table', table, line }]
+    case 'rename_table':
+      return [{ kind: 'rename_table', table, to: readString(args[0] ?? '') ?? '', line }]
+    case 'add_column': {
+      const column = readAlembicColumn(args[0] ?? '')
+      if (!column) return []
+      const { name, ...definition } = column
+      return [{ kind: 'add_column', table, column: name, definition, line }]
+    }
+    case 'drop_column': {
+      const column = readString(args[0] ?? '')
+      return column ? [{ kind: 'drop_column', table, column, line }] : []
+    }
+    case 'alter_column': {
+      const column = readString(args[0] ?? '')
+      if (!column) return []
+      const definition: Partial<ColumnDefinition> = {}
+      const type = keyword('type_')
+      const nullable = keyword('nullable')
+      const serverDefault = keyword('server_default')
+      if (type) definition.type = readAlembicType(type)
+      if (nullable) definition.nullable = nullable === 'True'
+      if (serverDefault) definition.default = serverDefault
+      const newName = readString(keyword('new_column_name') ?? '')
+      return [
+        { kind: 'alter_column', table, column, definition, line },
+        ...(newName ? [{ kind: 'rename_column' as const, table, column, to: newName, line }] : []),
+      ]
+    }
+  }
+
+  return []
+}
+
+function readAlembicColumn(expression: string): ColumnDefinition | null {
+  const open = expression.indexOf('(')
+  const args = open < 0 ? null : readCallArguments(expression, open)?.args.filter(Boolean)
+  const name = readString(args?.[0] ?? '')
+  if (!args || !name) return null
+
+  const keyword = (key: string) => args.find(arg => new RegExp(`^${key}\\s*=`).test(arg))?.replace(/^\w+\s*=\s*/, '')
+  const type = args.slice(1).find(arg => !/^\w+\s*=/.test(arg) && !/ForeignKey\(/.test(arg))
+  const primaryKey = keyword('primary_key') === 'True'
+  const nullable = keyword('nullable')
+
+  return {
+    name,
+    type: type ? readAlembicType(type) : undefined,
+    nullable: primaryKey ? false : nullable === undefined ? undefined : nullable === 'True',
+    primaryKey: primaryKey || undefined,
+    default: keyword('server_default'),
+    references: args.map(arg => arg.match(/ForeignKey\(\s*['"]([^'"]+)['"]/)?.[1]).find(Boolean),
+  }
+}
+
+function readAlembicType(expression: string): string {
+  // sa.Integer() -> Integer, postgresql.JSONB(astext_type=...) keeps its arguments
+  return expression.replace(/^(?:sa|sqlalchemy|postgresql|mysql|sqlite)\./, '').replace(/\(\)$/, '')
+}
+
+function orderRevisions(revisions: Migration[]): Migration[] {
+  const ordered: Migration[] = []
+  const placed = new Set<string>()
+
+  // Repeatedly take revisions whose parents are all placed; leftovers (missing parents) go last
+  let remaining = [...revisions].sort((a, b) => a.file.localeCompare(b.file))
+  while (remaining.length > 0) {
+    const ready = remaining.filter(revision => (revision.downRevisions ?? []).every(parent => placed.has(parent) || !revisions.some(other => other.id === parent)))
+    const next = ready.length > 0 ? ready : remaining
+    next.forEach((revision) => {
+      ordered.push(revision)
+      placed.add(revision.id)
+    })
+    remaining = remaining.filter(revision => !placed.has(revision.id))
+  }
+
+  return ordered
+}
+
+function readGooseUp(content: string): string {
+  // Lines outside the Up section are blanked so statement line numbers match the file
+  let inUp = false
+  return content.split('\n').map((line) => {
+    const annotation = line.match(/^--\s*\+goose\s+(Up|Down)\b/i)
+    if (annotation) inUp = annotation[1]!.toLowerCase() === 'up'
+    return inUp && !annotation ? line : ''
+  }).join('\n')
+}
+
+function splitStatements(sql: string): { text: string, line: number }[] {
+  const statements: { text: string, line: number }[] = []
+  let current = ''
+  let line = 1
+  let startLine = 0
+
+  for (let i = 0; i < sql.length; i++) {
+    const char = sql[i]!
+
+    if (char === '-' && sql[i + 1] === '-') {
+      while (i < sql.length && sql[i] !== '\n') i++
+      i--
+      continue
+    }
+    if (char === '/' && sql[i + 1] === '*') {
+      const end = sql.indexOf('*/', i + 2)
+      const comment = sql.substring(i, end < 0 ? sql.length : end + 2)
+      line += comment.split('\n').length - 1
+      current += ' '
+      i += comment.length - 1
+      continue
+    }
+
+    if (char === '\'' || char === '"' || char === '`' || (char === '$' && /^\$\w*\$/.test(sql.substring(i)))) {
+      const quote = char === '$' ? sql.substring(i).match(/^\$\w*\$/)![0] : char
+      const end = sql.indexOf(quote, i + quote.length)
+      const literal = sql.substring(i, end < 0 ? sql.length : end + quote.length)
+      if (!current.trim()) startLine = line
+      line += literal.split('\n').length - 1
+      current += literal
+      i += literal.length - 1
+      continue
+    }
+
+    if (char === ';') {
+      if (current.trim()) statements.push({ text: current, line: startLine })
+      current = ''
+      continue
+    }
+
+    if (char === '\n') line++
+    else if (!current.trim() && /\S/.test(char)) startLine = line
+    current += char
+  }
+
+  if (current.trim()) statements.push({ text: current, line: startLine })
+  return statements
+}
+
+function readString(text: string): string | undefined {
+  return text.trim().match(/^['"]([^'"]*)['"]$/)?.[1]
+}
+
+function unquote(identifier: string): string {
+  // Schema prefixes are dropped: public.users -> users
+  const parts = identifier.trim().match(new RegExp(IDENTIFIER, 'g')) ?? [identifier]
+  return parts[parts.length - 1]!.replace(/^["`[]|["`\]]$/g, '')
+}
+
+function compareVersions(a: string, b: string): number {
+  const versionA = a.match(/^\d+/)?.[0] ?? ''
+  const versionB = b.match(/^\d+/)?.[0] ?? ''
+  return versionA && versionB && versionA !== versionB
+    ? (BigInt(versionA) < BigInt(versionB) ? -1 : 1)
+    : a.localeCompare(b)
+}
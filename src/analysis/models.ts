@@ -0,0 +1,422 @@
+/**
+ * Data model extraction - ORM entities with their fields and relations from GORM structs,
+ * Prisma schemas, SQLAlchemy/SQLModel classes and TypeORM (or MikroORM) entities
+ */
+
+import { getAllNodes } from '../project/manager.js'
+import { splitTopLevel } from '../utils/helpers.js'
+import type { Project, TreeNode } from '../types/core.js'
+
+export type OrmName = 'gorm' | 'prisma' | 'sqlalchemy' | 'typeorm'
+
+export interface ModelField {
+  name: string
+  type: string
+  line: number
+  column?: string
+  primaryKey?: boolean
+  nullable?: boolean
+  references?: string // Foreign key target, e.g. users.id
+}
+
+export interface ModelRelation {
+  name: string
+  kind: 'belongsTo' | 'hasOne' | 'hasMany' | 'manyToMany'
+  target: string
+  line: number
+}
+
+export interface ModelDefinition {
+  name: string
+  orm: OrmName
+  file: string
+  line: number
+  endLine: number
+  table?: string // Only when declared explicitly (@@map, TableName, __tablename__, @Entity('name'))
+  fields: ModelField[]
+  relations: ModelRelation[]
+}
+
+interface GormStruct extends ModelDefinition {
+  fields: (ModelField & { many2many?: boolean })[]
+}
+
+interface LogicalLine {
+  text: string
+  line: number
+}
+
+const GORM_MODEL_FIELDS: Omit<ModelField, 'line'>[] = [
+  { name: 'ID', type: 'uint', primaryKey: true },
+  { name: 'CreatedAt', type: 'time.Time' },
+  { name: 'UpdatedAt', type: 'time.Time' },
+  { name: 'DeletedAt', type: 'gorm.DeletedAt', nullable: true },
+]
+
+/**
+ * Lists the ORM models of a project
+ */
+export function listModels(project: Project): ModelDefinition[] {
+  return extractModels(getAllNodes(project).filter(node => node.type === 'file'))
+}
+
+/**
+ * Extracts models from file contents. Relations are resolved against all models found, so a GORM
+ * struct may reference one declared in another file.
+ */
+export function extractModels(fileNodes: TreeNode[]): ModelDefinition[] {
+  const models: ModelDefinition[] = []
+  const goStructs: GormStruct[] = []
+  const goTableNames = new Map<string, string>()
+
+  for (const fileNode of fileNodes) {
+    const content = fileNode.content
+    if (!content) continue
+
+    if (fileNode.path.endsWith('.prisma')) {
+      models.push(...parsePrismaSchema(content, fileNode.path))
+    }
+    else if (fileNode.path.endsWith('.go')) {
+      goStructs.push(...parseGormStructs(content, fileNode.path))
+      for (const match of content.matchAll(/func\s*\(\s*(?:\w+\s+)?\*?(\w+)\s*\)\s*TableName\(\)\s*string\s*\{\s*return\s+"([^"]+)"/g)) {
+        goTableNames.set(match[1]!, match[2]!)
+      }
+    }
+    else if (fileNode.path.endsWith('.py')) {
+      models.push(...parseSqlAlchemyModels(content, fileNode.path))
+    }
+    else if (/\.[cm]?[jt]s$/.test(fileNode.path) && content.includes('@Entity')) {
+      models.push(...parseTypeOrmEntities(content, fileNode.path))
+    }
+  }
+
+  models.push(...resolveGormModels(goStructs, goTableNames))
+  return models.sort((a, b) => a.name.localeCompare(b.name))
+}
+
+/**
+ * Reads `model` blocks of a Prisma schema. Fields typed with another model are relations: lists are
+ * hasMany (manyToMany when both sides are lists), the side declaring `@relation(fields: ...)` is belongsTo.
+ */
+export function parsePrismaSchema(content: string, file: string): ModelDefinition[] {
+  const lines = content.split('\n')
+  const blocks: { name: string, line: number, endLine: number, body: LogicalLine[] }[] = []
+
+  for (let i = 0; i < lines.length; i++) {
+    const model = lines[i]!.match(/^\s*model\s+(\w+)\s*\{/)
+    if (!model) continue
+    const body: LogicalLine[] = []
+    let end = i + 1
+    while (end < lines.length && !/^\s*\}/.test(lines[end]!)) {
+      body.push({ text: lines[end]!.replace(/\/\/.*$/, '').trim(), line: end + 1 })
+      end++
+    }
+    blocks.push({ name: model[1]!, line: i + 1, endLine: end + 1, body })
+    i = end
+  }
+
+  const modelNames = new Set(blocks.map(block => block.name))
+  const models = blocks.map((block): ModelDefinition => {
+    const model: ModelDefinition = { name: block.name, orm: 'prisma', file, line: block.line, endLine: block.endLine, fields: [], relations: [] }
+
+    for (const { text, line } of block.body) {
+      const table = text.match(/^@@map\(\s*"([^"]+)"/)
+      if (table) model.table = table[1]
+      const field = text.match(/^(\w+)\s+([\w.]+)(\[\])?(\?)?(.*)$/)
+      if (!field) continue
+
+      const [, name = '', type = '', list, optional, attributes = ''] = field
+      if (modelNames.has(type)) {
+        model.relations.push({ name, kind: list ? 'hasMany' : /@relation\([^)]*\bfields\s*:/.test(attributes) ? 'belongsTo' : 'hasOne', target: type, line })
+        continue
+      }
+      model.fields.push({
+        name,
+        type: list ? `${type}[]` : type,
+        line,
+        column: attributes.match(/@map\(\s*"([^"]+)"/)?.[1],
+        primaryKey: /@id\b/.test(attributes) || undefined,
+        nullable: optional ? true : undefined,
+      })
+    }
+
+    return model
+  })
+
+  return markManyToMany(models)
+}
+
+function parseGormStructs(content: string, file: string): GormStruct[] {
+  const lines = content.split('\n')
+  const structs: GormStruct[] = []
+
+  for (let i = 0; i < lines.length; i++) {
+    const struct = lines[i]!.match(/^type\s+(\w+)\s+struct\s*\{/)
+    if (!struct) continue
+
+    const model: GormStruct = { name: struct[1]!, orm: 'gorm', file, line: i + 1, endLine: i + 1, fields: [], relations: [] }
+    let isModel = false
+    let depth = 1
+    let end = i + 1
+    for (; end < lines.length && depth > 0; end++) {
+      const text = lines[end]!.replace(/\/\/.*$/, '').trim()
+      depth += (text.match(/\{/g)?.length ?? 0) - (text.match(/\}/g)?.length ?? 0)
+      if (depth !== 1 || !text) continue
+
+      const embedded = text.match(/^(\*?[\w.]+)\s*(`[^`]*`)?$/)
+      if (embedded) {
+        if (embedded[1] === 'gorm.Model') {
+          isModel = true
+          model.fields.push(...GORM_MODEL_FIELDS.map(field => ({ ...field, line: end + 1 })))
+        }
+        continue
+      }
+
+      const field = text.match(/^([A-Z]\w*)\s+([^\s`]+)\s*(`[^`]*`)?/)
+      if (!field) continue
+      const tag = field[3]?.match(/gorm:"([^"]*)"/)?.[1]
+      if (tag !== undefined) isModel = true
+      if (tag === '-') continue
+
+      const options = new Map((tag ?? '').split(';').filter(Boolean).map((option) => {
+        const [key = '', ...value] = option.split(':')
+        return [key.trim().toLowerCase(), value.join(':').trim()] as const
+      }))
+      model.fields.push({
+        name: field[1]!,
+        type: field[2]!,
+        line: end + 1,
+        column: options.get('column') || undefined,
+        primaryKey: options.has('primarykey') || options.has('primary_key') || undefined,
+        nullable: field[2]!.startsWith('*') || field[2]!.startsWith('sql.Null') || undefined,
+        many2many: options.has('many2many') || undefined,
+      })
+    }
+
+    model.endLine = end
+    if (isModel) structs.push(model)
+    i = end - 1
+  }
+
+  return structs
+}
+
+function resolveGormModels(structs: GormStruct[], tableNames: Map<string, string>): ModelDefinition[] {
+  const modelNames = new Set(structs.map(struct => struct.name))
+
+  return markManyToMany(structs.map((struct) => {
+    const fields: ModelField[] = []
+    const relations: ModelRelation[] = []
+    const fieldNames = new Set(struct.fields.map(field => field.name))
+
+    for (const field of struct.fields) {
+      const isList = field.type.startsWith('[]')
+      const target = field.type.replace(/^\[\]/, '').replace(/^\*/, '')
+      const { many2many, ...plainField } = field
+
+      if (!modelNames.has(target)) {
+        fields.push(plainField)
+        continue
+      }
+
+      const kind = many2many
+        ? 'manyToMany'
+        : isList ? 'hasMany' : fieldNames.has(`${field.name}ID`) ? 'belongsTo' : 'hasOne'
+      relations.push({ name: field.name, kind, target, line: field.line })
+    }
+
+    return { ...struct, table: tableNames.get(struct.name), fields, relations }
+  }))
+}
+
+/**
+ * Reads SQLAlchemy declarative models (Column, mapped_column, relationship) and SQLModel table classes
+ */
+export function parseSqlAlchemyModels(content: string, file: string): ModelDefinition[] {
+  const lines = joinParenthesized(content.split('\n'))
+  const models: ModelDefinition[] = []
+
+  for (let i = 0; i < lines.length; i++) {
+    const header = lines[i]!.text.match(/^(\s*)class\s+(\w+)\s*\(([^)]*)\)\s*:/)
+    if (!header) continue
+
+    const indent = header[1]!.length
+    const isTable = /\btable\s*=\s*True\b/.test(header[3]!)
+    const model: ModelDefinition = { name: header[2]!, orm: 'sqlalchemy', file, line: lines[i]!.line, endLine: lines[i]!.line, fields: [], relations: [] }
+    const pendingRelations: { name: string, annotation: string, args: string, line: number }[] = []
+    let isModel = isTable
+
+    let end = i + 1
+    for (; end < lines.length; end++) {
+      const { text, line } = lines[end]!
+      if (!text.trim()) continue
+      if (text.search(/\S/) <= indent) break
+      model.endLine = line
+      if (text.search(/\S/) !== indent + 4 && text.search(/\S/) !== indent + 2) continue
+
+      const table = text.match(/^\s*__tablename__\s*=\s*['"]([^'"]+)['"]/)
+      if (table) {
+        model.table = table[1]
+        isModel = true
+        continue
+      }
+
+      const attribute = text.match(/^\s*(\w+)\s*(?::\s*([^=]+?))?\s*(?:=\s*(?:[\w.]+\.)?(\w+)\s*\(([\s\S]*)\))?\s*$/)
+      if (!attribute || attribute[1]!.startsWith('__')) continue
+      const [, name = '', annotation = '', call = '', args = ''] = attribute
+
+      if (/^[rR]elationship$/.test(call)) {
+        pendingRelations.push({ name, annotation, args, line })
+      }
+      else if (call === 'Column' || call === 'mapped_column' || (call === 'Field' && isTable) || (!call && annotation && isTable)) {
+        isModel = true
+        model.fields.push(readSqlAlchemyField(name, annotation, call === 'Column' ? args : '', args, line))
+      }
+    }
+
+    for (const relation of pendingRelations) {
+      const target = relation.args.match(/^\s*['"](\w+)['"]/)?.[1]
+        ?? relation.annotation.match(/(\w+)['"]?\s*\]+\s*$/)?.[1]
+      if (!target) continue
+      const referencesTarget = model.fields.some(field => field.references && field.references.split('.')[0]!.startsWith(target.toLowerCase()))
+      const kind = /\bsecondary\s*=/.test(relation.args)
+        ? 'manyToMany'
+        : /\buselist\s*=\s*False\b/.test(relation.args)
+          ? 'hasOne'
+          : /\b(?:List|list)\[/.test(relation.annotation)
+            ? 'hasMany'
+            : referencesTarget ? 'belongsTo' : relation.annotation ? 'hasOne' : 'hasMany'
+      model.relations.push({ name: relation.name, kind, target, line: relation.line })
+    }
+
+    if (isModel) models.push(model)
+    i = end - 1
+  }
+
+  return models
+}
+
+function readSqlAlchemyField(name: string, annotation: string, columnArgs: string, args: string, line: number): ModelField {
+  // Column("user_name", String(50), ...) names the column first; Mapped[Optional[str]] carries the type
+  const [first = '', second = ''] = splitTopLevel(columnArgs).map(part => part.trim())
+  const column = first.match(/^['"]([^'"]+)['"]$/)?.[1]
+  const columnType = (column ? second : first).match(/^(?:[\w]+\.)*([A-Z]\w*)/)?.[1]
+  const mapped = annotation.match(/^Mapped\[(.*)\]$/)?.[1] ?? annotation
+  const optional = /^Optional\[|\|\s*None\b/.test(mapped) || /\bnullable\s*=\s*True\b/.test(args)
+
+  return {
+    name,
+    type: columnType ?? (mapped.replace(/^Optional\[(.*)\]$/, '$1').replace(/\s*\|\s*None\b/, '') || 'unknown'),
+    line,
+    column,
+    primaryKey: /\bprimary_key\s*=\s*True\b/.test(args) || undefined,
+    nullable: optional || undefined,
+    references: args.match(/ForeignKey\(\s*['"]([^'"]+)['"]/)?.[1] ?? args.match(/foreign_key\s*=\s*['"]([^'"]+)['"]/)?.[1],
+  }
+}
+
+/**
+ * Reads classes decorated with `@Entity` and their column and relation decorators
+ */
+export function parseTypeOrmEntities(content: string, file: string): ModelDefinition[] {
+  const lines = content.split('\n')
+  const models: ModelDefinition[] = []
+
+  for (let i = 0; i < lines.length; i++) {
+    const entity = lines[i]!.match(/^\s*@Entity\s*\((.*)\)?\s*$/)
+    if (!entity) continue
+
+    const classLine = lines.findIndex((line, index) => index > i && /\bclass\s+\w+/.test(line))
+    if (classLine < 0) break
+    const name = lines[classLine]!.match(/\bclass\s+(\w+)/)![1]!
+    const table = entity[1]?.match(/^\s*['"]([^'"]+)['"]/)?.[1] ?? entity[1]?.match(/\bname\s*:\s*['"]([^'"]+)['"]/)?.[1]
+    const model: ModelDefinition = { name, orm: 'typeorm', file, line: classLine + 1, endLine: classLine + 1, table, fields: [], relations: [] }
+
+    let depth = 0
+    let decorators: string[] = []
+    let end = classLine
+    for (; end < lines.length; end++) {
+      const text = lines[end]!.replace(/\/\/.*$/, '').trim()
+      const opened = depth
+      depth += (text.match(/\{/g)?.length ?? 0) - (text.match(/\}/g)?.length ?? 0)
+      if (end > classLine && depth <= 0) break
+      if (opened !== 1) continue
+
+      // Decorators may share the line with the property: @Column() name: string
+      let rest = text
+      let decorator = rest.match(/^@\w+(?:\((?:[^()]|\([^()]*\))*\)?)?/)
+      while (decorator) {
+        decorators.push(decorator[0])
+        rest = rest.substring(decorator[0].length).trim()
+        decorator = rest.match(/^@\w+(?:\((?:[^()]|\([^()]*\))*\)?)?/)
+      }
+
+      const property = rest.match(/^(?:(?:public|private|protected|readonly)\s+)*(\w+)([?!])?\s*:\s*([^;=]+)/)
+      if (!property) continue
+      model.fields.push(...readTypeOrmMember(property[1]!, property[3]!.trim(), property[2] === '?', decorators, end + 1, model.relations))
+      decorators = []
+    }
+
+    model.endLine = end + 1
+    models.push(model)
+    i = end
+  }
+
+  return models
+}
+
+function readTypeOrmMember(name: string, type: string, optional: boolean, decorators: string[], line: number, relations: ModelRelation[]): ModelField[] {
+  for (const decorator of decorators) {
+    const relation = decorator.match(/^@(ManyToOne|OneToMany|OneToOne|ManyToMany)\s*\(\s*(?:\(\)\s*=>\s*)?(\w+)/)
+    if (relation) {
+      const kinds = { ManyToOne: 'belongsTo', OneToMany: 'hasMany', OneToOne: 'hasOne', ManyToMany: 'manyToMany' } as const
+      const ownsForeignKey = relation[1] === 'OneToOne' && decorators.some(other => other.startsWith('@JoinColumn'))
+      relations.push({ name, kind: ownsForeignKey ? 'belongsTo' : kinds[relation[1] as keyof typeof kinds], target: relation[2]!, line })
+      return []
+    }
+  }
+
+  const column = decorators.find(decorator => /^@(?:Primary\w*Column|\w*Column|Property|PrimaryKey)\b/.test(decorator))
+  if (!column) return []
+
+  return [{
+    name,
+    type,
+    line,
+    column: column.match(/\bname\s*:\s*['"]([^'"]+)['"]/)?.[1],
+    primaryKey: /^@Primary/.test(column) || undefined,
+    nullable: optional || /\bnullable\s*:\s*true\b/.test(column) || undefined,
+  }]
+}
+
+function markManyToMany(models: ModelDefinition[]): ModelDefinition[] {
+  // Two list sides pointing at each other (Prisma implicit m:n) form a many-to-many relation
+  const byName = new Map(models.map(model => [model.name, model]))
+  for (const model of models) {
+    for (const relation of model.relations) {
+      if (relation.kind !== 'hasMany') continue
+      const inverse = byName.get(relation.target)?.relations.some(other => other.target === model.name && (other.kind === 'hasMany' || other.kind === 'manyToMany'))
+      if (inverse) relation.kind = 'manyToMany'
+    }
+  }
+  return models
+}
+
+function joinParenthesized(lines: string[]): LogicalLine[] {
+  const logical: LogicalLine[] = []
+
+  for (let i = 0; i < lines.length; i++) {
+    const start = i
+    let text = lines[i]!.replace(/#.*$/, '')
+    let depth = (text.match(/[([{]/g)?.length ?? 0) - (text.match(/[)\]}]/g)?.length ?? 0)
+    while (depth > 0 && i + 1 < lines.length) {
+      i++
+      const next = lines[i]!.replace(/#.*$/, '')
+      text += ' ' + next.trim()
+      depth += (next.match(/[([{]/g)?.length ?? 0) - (next.match(/[)\]}]/g)?.length ?? 0)
+    }
+    logical.push({ text: text.trimEnd(), line: start + 1 })
+  }
+
+  return logical
+}
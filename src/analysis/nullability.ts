@@ -0,0 +1,222 @@
+/**
+ * Nullability analysis - dereferences of values that can be nil or None on some path through a
+ * function: values used after a nil check that lets execution continue, or after the block that
+ * guards them, Go results used before their error is checked, and Python optionals such as
+ * `re.match()` and `.get()` results used unchecked. Findings carry a confidence so the less
+ * certain ones can be left out.
+ */
+
+import { CONFIDENCE_LEVELS, NULLABILITY_CATEGORIES, escapeRegExp } from '../constants/index.js'
+import { blankGoClosures, lineAt, maskGo, matchingBrace, readGoFunctions, type GoFunction } from '../core/go-source.js'
+import { maskCommentsAndStrings } from '../core/python-dynamic.js'
+import { blockEnd, readPythonFunctions, type PythonLine } from '../core/python-source.js'
+import type { TreeNode } from '../types/core.js'
+import type { Confidence, Finding, NullabilityMetrics } from '../types/analysis.js'
+
+interface Dereference {
+  category: string
+  confidence: Confidence
+  variable: string
+  description: string
+  at: number // Offset in the Go body, or line in the Python file
+}
+
+const GO_EXITS = /\b(?:return|panic\(|continue|break|goto)\b|\bos\.Exit\(|\blog\.Fatal/
+const GO_NIL_CHECK = /\bif\s+(\w+)\s*(==|!=)\s*nil\s*(?:&&[^{]*)?\{/g
+const GO_ERROR_RESULT = /(?<![\w.])(\w+)\s*,\s*(err|\w+Err)\s*:?=(?!=)/g
+const PY_EXITS = /^(?:return|raise|continue|break)\b/
+// Optionals by how often they are None in practice
+const PY_OPTIONALS: [Confidence, RegExp][] = [
+  ['high', /^re\.(?:match|search|fullmatch)\(/],
+  ['medium', /^\w+\.(?:match|search|fullmatch)\(/],
+  ['low', /\.get\((?:[^,()]*|[^()]*,\s*None\s*)\)$/],
+]
+const PY_CHAINED_OPTIONALS: [Confidence, RegExp][] = [
+  ['high', /\b(re\.(?:match|search|fullmatch))\((?:[^()]|\([^()]*\))*\)\.\w/],
+  ['low', /\b((?:\w+\.)*\w+\.get)\(([^()]*)\)\.\w/], // Unless given a default other than None
+]
+
+/**
+ * Analyzes the Go and Python files among the given nodes, keeping findings of at least the
+ * given confidence
+ */
+export function analyzeNullability(nodes: TreeNode[], minConfidence: Confidence = 'medium'): { findings: Finding[], metrics: NullabilityMetrics } {
+  const findings: Finding[] = []
+  let analyzedFiles = 0
+  let analyzedFunctions = 0
+  const threshold = CONFIDENCE_LEVELS.indexOf(minConfidence)
+
+  const report = (file: string, fn: string, line: number, dereference: Dereference) => {
+    if (CONFIDENCE_LEVELS.indexOf(dereference.confidence) < threshold) return
+    findings.push({
+      type: 'nullability',
+      category: dereference.category,
+      severity: dereference.confidence === 'high' ? 'warning' : 'info',
+      location: `${file}:${line}`,
+      description: `${fn}: ${dereference.description}`,
+      metrics: { function: fn, variable: dereference.variable, confidence: dereference.confidence },
+    })
+  }
+
+  for (const node of nodes) {
+    if (node.type !== 'file' || !node.content) continue
+    if (node.path.endsWith('.go')) {
+      analyzedFiles++
+      const code = maskGo(node.content)
+      for (const fn of readGoFunctions(code)) {
+        analyzedFunctions++
+        checkGoFunction(fn).forEach(dereference => report(node.path, fn.name, lineAt(code, fn.start + dereference.at), dereference))
+      }
+    }
+    else if (node.path.endsWith('.py')) {
+      analyzedFiles++
+      for (const fn of readPythonFunctions(maskCommentsAndStrings(node.content))) {
+        analyzedFunctions++
+        checkPythonFunction(fn.body).forEach(dereference => report(node.path, fn.name, dereference.at, dereference))
+      }
+    }
+  }
+
+  const issuesByCategory: Record<string, number> = {}
+  for (const finding of findings) {
+    issuesByCategory[finding.category] = (issuesByCategory[finding.category] ?? 0) + 1
+  }
+
+  return { findings, metrics: { analyzedFiles, analyzedFunctions, issuesByCategory } }
+}
+
+function checkGoFunction(fn: GoFunction): Dereference[] {
+  const body = blankGoClosures(fn.body)
+  const found = new Map<number, Dereference>()
+  const add = (dereference: Dereference) => {
+    if (!found.has(dereference.at)) found.set(dereference.at, dereference)
+  }
+
+  for (const check of body.matchAll(GO_NIL_CHECK)) {
+    const [, variable, operator] = check as unknown as [string, string, string]
+    const open = check.index + check[0].length - 1
+    const close = matchingBrace(body, open)
+    const block = body.substring(open, close + 1)
+
+    if (operator === '==') {
+      // Handling nil without leaving or assigning the value carries it on to the code below
+      if (GO_EXITS.test(block) || goAssigns(block, variable)) continue
+      const at = goDereference(body.substring(open), variable)
+      if (at === undefined) continue
+      add({ category: NULLABILITY_CATEGORIES.NIL_DEREFERENCE, confidence: 'high', variable, at: open + at,
+        description: `${variable} is dereferenced after \`if ${variable} == nil\` lets execution continue with it nil` })
+    }
+    else {
+      if (/^\s*else\b/.test(body.substring(close + 1))) continue
+      const at = goDereference(body.substring(close + 1), variable)
+      if (at === undefined) continue
+      add({ category: NULLABILITY_CATEGORIES.NIL_DEREFERENCE, confidence: 'medium', variable, at: close + 1 + at,
+        description: `${variable} is dereferenced after the \`if ${variable} != nil\` block, where it can still be nil` })
+    }
+  }
+
+  for (const result of body.matchAll(GO_ERROR_RESULT)) {
+    const [assignment, variable, error] = result as unknown as [string, string, string]
+    if (variable === '_') continue
+    const after = result.index + assignment.length
+    const statementEnd = body.indexOf('\n', after)
+    const rest = body.substring(statementEnd < 0 ? body.length : statementEnd)
+    const checked = rest.search(new RegExp(`(?<![\\w.])${escapeRegExp(error)}\\b`))
+    const at = goDereference(checked < 0 ? rest : rest.substring(0, checked), variable)
+    if (at === undefined) continue
+    add({ category: NULLABILITY_CATEGORIES.UNCHECKED_RESULT, confidence: 'low', variable, at: body.length - rest.length + at,
+      description: `${variable} is dereferenced before ${error} is checked; it is usually nil when ${error} is set` })
+  }
+
+  return [...found.values()].sort((a, b) => a.at - b.at)
+}
+
+/**
+ * Offset of the first selector on the variable, unless it is reassigned or checked for nil
+ * again first
+ */
+function goDereference(code: string, variable: string): number | undefined {
+  const name = escapeRegExp(variable)
+  const stop = code.search(new RegExp(`(?<![\\w.])${name}\\s*(?:,\\s*\\w+\\s*)*:?=(?!=)|,\\s*${name}\\s*:?=(?!=)|(?<![\\w.])${name}\\s*[!=]=\\s*nil\\b`))
+  const use = code.search(new RegExp(`(?<![\\w.&*])${name}\\.\\w`))
+  return use >= 0 && (stop < 0 || use < stop) ? use : undefined
+}
+
+function goAssigns(code: string, variable: string): boolean {
+  const name = escapeRegExp(variable)
+  return new RegExp(`(?<![\\w.])${name}\\s*(?:,\\s*\\w+\\s*)*:?=(?!=)|,\\s*${name}\\s*:?=(?!=)`).test(code)
+}
+
+function checkPythonFunction(lines: PythonLine[]): Dereference[] {
+  const found = new Map<number, Dereference>()
+  const add = (dereference: Dereference) => {
+    if (!found.has(dereference.at)) found.set(dereference.at, dereference)
+  }
+
+  lines.forEach((line, index) => {
+    // Chained on the call itself, as in re.match(...).group(1)
+    for (const [confidence, chain] of PY_CHAINED_OPTIONALS) {
+      const chained = chain.exec(line.text)
+      if (!chained || /,\s*(?!None\s*$)\S/.test(chained[2] ?? '')) continue
+      add({ category: NULLABILITY_CATEGORIES.UNCHECKED_RESULT, confidence, variable: chained[1]!, at: line.line,
+        description: `the result of ${chained[1]}() can be None and is used without a check` })
+    }
+
+    const assignment = /^(\w+)\s*=(?!=)\s*(.+)$/.exec(line.text)
+    if (assignment) {
+      const [, variable, value] = assignment as unknown as [string, string, string]
+      const optional = PY_OPTIONALS.find(([, pattern]) => pattern.test(value))
+      const at = optional && pythonDereference(lines, index + 1, variable)
+      if (optional && at !== undefined) {
+        add({ category: NULLABILITY_CATEGORIES.UNCHECKED_RESULT, confidence: optional[0], variable, at,
+          description: `${variable} from ${value.split('(')[0]}() can be None and is used without a check` })
+      }
+      return
+    }
+
+    // Only explicit None checks; `if items:` is as often about emptiness
+    const guard = /^(?:el)?if\s+(\w+)\s+is\s+not\s+None\s*:/.exec(line.text)
+    const none = /^if\s+(\w+)\s+is\s+None\s*:/.exec(line.text)
+    if (!guard && !none) return
+    const end = blockEnd(lines, index)
+    const variable = (guard ?? none)![1]!
+
+    if (guard) {
+      if (lines[end] && lines[end]!.indent === line.indent && /^(?:elif|else)\b/.test(lines[end]!.text)) return
+      const at = pythonDereference(lines, end, variable)
+      if (at === undefined) return
+      add({ category: NULLABILITY_CATEGORIES.NIL_DEREFERENCE, confidence: 'medium', variable, at,
+        description: `${variable} is used after the block checking it for None, where it can still be None` })
+      return
+    }
+
+    const block = lines.slice(index + 1, end)
+    if (block.some(inner => PY_EXITS.test(inner.text) || pythonAssigns(inner.text, variable))) return
+    const at = pythonDereference(lines, index + 1, variable)
+    if (at === undefined) return
+    add({ category: NULLABILITY_CATEGORIES.NIL_DEREFERENCE, confidence: 'high', variable, at,
+      description: `${variable} is used after \`${line.text.replace(/:$/, '')}\` lets execution continue with it None` })
+  })
+
+  return [...found.values()].sort((a, b) => a.at - b.at)
+}
+
+/**
+ * Line of the first attribute access, subscript or call on the variable from the given index,
+ * unless it is reassigned or checked first
+ */
+function pythonDereference(lines: PythonLine[], from: number, variable: string): number | undefined {
+  const name = escapeRegExp(variable)
+  const checks = new RegExp(`^(?:if|elif|while|assert)\\b.*(?<![\\w.])${name}\\b|(?<![\\w.])${name}\\s+(?:and|or|is)\\b|\\b(?:and|or|if|not)\\s+${name}\\b`)
+  const uses = new RegExp(`(?<![\\w.])${name}(?:\\.\\w|\\[|\\()`)
+  for (const line of lines.slice(from)) {
+    if (pythonAssigns(line.text, variable) || checks.test(line.text)) return undefined
+    if (uses.test(line.text)) return line.line
+  }
+  return undefined
+}
+
+function pythonAssigns(text: string, variable: string): boolean {
+  const name = escapeRegExp(variable)
+  return new RegExp(`^(?:${name}\\s*(?:,[^=]*)?=(?!=)|for\\s+(?:[\\w, ]*,\\s*)?${name}\\b.*\\bin\\b|with\\b.*\\bas\\s+${name}\\b)`).test(text)
+}
@@ -0,0 +1,146 @@
+/**
+ * OpenAPI cross-referencing - matches spec operations to the route handlers implementing them
+ * and reports operations without handlers and routes missing from the spec
+ */
+
+import { readFile } from 'fs/promises'
+import { basename, extname, relative } from 'path'
+import { walkDirectory } from '../core/file-walker.js'
+import { getAllNodes } from '../project/manager.js'
+import { extractRoutes, routePathsMatch } from './routes.js'
+import { parseYaml, getYamlKeyLine } from '../utils/yaml.js'
+import { getLogger } from '../utils/logger.js'
+import type { Project } from '../types/core.js'
+import type { RouteDefinition } from './routes.js'
+
+export interface OpenApiOperation {
+  method: string
+  path: string
+  operationId?: string
+  summary?: string
+  specFile: string
+  line?: number
+}
+
+export interface LinkedOperation extends OpenApiOperation {
+  handlers: { file: string, line: number, handler?: string, matchedBy: 'route' | 'operationId' }[]
+}
+
+export interface OpenApiReport {
+  specFiles: string[]
+  operations: LinkedOperation[]
+  unimplemented: OpenApiOperation[]
+  undocumented: RouteDefinition[]
+}
+
+const SPEC_EXTENSIONS = ['.yaml', '.yml', '.json']
+const SPEC_NAME_PATTERN = /(openapi|swagger|api-?spec)/i
+const OPERATION_METHODS = ['get', 'put', 'post', 'delete', 'options', 'head', 'patch', 'trace']
+
+/**
+ * Finds OpenAPI/Swagger documents in the project and parses their operations
+ */
+export async function loadOpenApiOperations(project: Project, specFile?: string): Promise<{ specFiles: string[], operations: OpenApiOperation[] }> {
+  const logger = getLogger()
+  const candidates = specFile
+    ? [specFile]
+    : (await walkDirectory(project.config.directory, { ignoreDirs: project.config.ignoreDirs, maxDepth: 8 }))
+        .filter(file => SPEC_EXTENSIONS.includes(extname(file)))
+
+  const specFiles: string[] = []
+  const operations: OpenApiOperation[] = []
+
+  for (const file of candidates) {
+    // Only read likely specs unless the caller named one; every YAML file in a repo is too many
+    if (!specFile && !SPEC_NAME_PATTERN.test(basename(file))) continue
+
+    let document: unknown
+    try {
+      const content = await readFile(file, 'utf-8')
+      document = extname(file) === '.json' ? JSON.parse(content) : parseYaml(content)
+    }
+    catch (error) {
+      logger.debug(`Skipping unreadable spec ${file}: ${error}`)
+      continue
+    }
+
+    const paths = isOpenApiDocument(document) ? (document as { paths?: unknown }).paths : undefined
+    if (!paths || typeof paths !== 'object') continue
+
+    const displayPath = relative(project.config.directory, file) || file
+    specFiles.push(displayPath)
+    operations.push(...collectOperations(paths as Record<string, unknown>, displayPath))
+  }
+
+  return { specFiles, operations }
+}
+
+/**
+ * Cross-references a project's OpenAPI operations with the routes registered in its code
+ */
+export async function checkOpenApi(project: Project, options: { specFile?: string } = {}): Promise<OpenApiReport> {
+  const { specFiles, operations } = await loadOpenApiOperations(project, options.specFile)
+  const nodes = getAllNodes(project)
+  const routes = extractRoutes(nodes.filter(node => node.type === 'file'))
+  const functions = nodes.filter(node => node.type === 'function' || node.type === 'method')
+  const matchedRoutes = new Set<RouteDefinition>()
+
+  const linked: LinkedOperation[] = operations.map((operation) => {
+    const handlers: LinkedOperation['handlers'] = []
+
+    for (const route of routes) {
+      if ((route.method === operation.method || route.method === 'ANY' || route.method === 'ALL') && routePathsMatch(route.path, operation.path)) {
+        handlers.push({ file: route.file, line: route.line, handler: route.handler, matchedBy: 'route' })
+        matchedRoutes.add(route)
+      }
+    }
+
+    // Code-first generators and frameworks like Connexion bind handlers by operationId
+    if (operation.operationId) {
+      const operationName = operation.operationId.split('.').pop()!
+      for (const fn of functions) {
+        if (fn.name === operationName && !handlers.some(h => h.file === fn.path && h.handler === fn.name)) {
+          handlers.push({ file: fn.path, line: fn.startLine ?? 1, handler: fn.name, matchedBy: 'operationId' })
+        }
+      }
+    }
+
+    return { ...operation, handlers }
+  })
+
+  return {
+    specFiles,
+    operations: linked,
+    unimplemented: linked.filter(operation => operation.handlers.length === 0).map(({ handlers: _handlers, ...operation }) => operation),
+    undocumented: specFiles.length > 0 ? routes.filter(route => !matchedRoutes.has(route)) : [],
+  }
+}
+
+function isOpenApiDocument(document: unknown): boolean {
+  return Boolean(document) && typeof document === 'object'
+    && ('openapi' in (document as object) || 'swagger' in (document as object))
+}
+
+function collectOperations(paths: Record<string, unknown>, specFile: string): OpenApiOperation[] {
+  const operations: OpenApiOperation[] = []
+
+  for (const [path, item] of Object.entries(paths)) {
+    if (!item || typeof item !== 'object') continue
+
+    for (const [method, operation] of Object.entries(item as Record<string, unknown>)) {
+      if (!OPERATION_METHODS.includes(method.toLowerCase())) continue
+      const details = (operation && typeof operation === 'object' ? operation : {}) as { operationId?: string, summary?: string }
+
+      operations.push({
+        method: method.toUpperCase(),
+        path,
+        operationId: details.operationId,
+        summary: details.summary,
+        specFile,
+        line: getYamlKeyLine(item, method) ?? getYamlKeyLine(paths, path),
+      })
+    }
+  }
+
+  return operations
+}
@@ -0,0 +1,254 @@
+/**
+ * Panic paths - where functions can panic, throw or exit (explicit panics, unchecked Go type
+ * assertions, throw and raise statements, Rust unwraps), and which functions reach such a site
+ * through the calls they make, up to a given depth
+ */
+
+import { getLanguageForFile } from '../core/languages.js'
+import { maskSource } from '../core/strings.js'
+import { lineAt, matchingBrace } from '../core/go-source.js'
+import { PARSER_NAMES } from '../constants/index.js'
+import type { TreeNode } from '../types/core.js'
+
+export type PanicKind = 'panic' | 'type_assertion' | 'throw' | 'raise' | 'assert' | 'unwrap' | 'exit'
+
+export interface PanicSite {
+  kind: PanicKind
+  function: string
+  file: string
+  line: number
+  code: string
+  handled?: boolean // Inside a try block with a catch, or a Go function that recovers
+}
+
+export interface PanicFunction {
+  name: string
+  file: string
+  line: number
+  depth: number // 0 when it panics itself, else the number of calls to a function that does
+  sites: number // Unhandled sites in its own body
+  via?: string[] // Call chain to a function that panics itself
+}
+
+export interface PanicReport {
+  sites: PanicSite[]
+  functions: PanicFunction[]
+  depth: number
+}
+
+export interface PanicOptions {
+  symbol?: string
+  depth?: number
+}
+
+interface Callable {
+  node: TreeNode
+  family: string
+  sites: PanicSite[]
+  calls: Set<string> // Names called outside handled ranges
+}
+
+const DEFAULT_DEPTH = 3
+const CALLABLE_KINDS = ['function', 'method']
+const CALL = /([A-Za-z_$][\w$]*)\s*\(/g
+const DECLARATION = /(?:function|def|func|fn)\s*\*?\s*$/
+const THROW_LANGUAGES = new Set<string>([
+  PARSER_NAMES.JAVASCRIPT, PARSER_NAMES.TYPESCRIPT, PARSER_NAMES.TSX, PARSER_NAMES.JAVA, PARSER_NAMES.KOTLIN, PARSER_NAMES.CSHARP,
+  PARSER_NAMES.PHP, PARSER_NAMES.DART, PARSER_NAMES.SWIFT, PARSER_NAMES.SCALA, PARSER_NAMES.CPP, PARSER_NAMES.GROOVY,
+])
+const RAISE_LANGUAGES = new Set<string>([PARSER_NAMES.PYTHON, PARSER_NAMES.RUBY, PARSER_NAMES.ELIXIR, PARSER_NAMES.CRYSTAL])
+
+const GO_SITES: [PanicKind, RegExp][] = [
+  ['panic', /(?<![\w.])panic\(|\blog\.Panic(?:f|ln)?\(|\b[a-z]\w*\.Must(?:[A-Z]\w*)?\(/g],
+  ['exit', /\blog\.Fatal(?:f|ln)?\(|\bos\.Exit\(/g],
+  // Single-result assertions; `v, ok := x.(T)` reports failure instead of panicking
+  ['type_assertion', /[\w\])]\.\((?!type\))[*\w.[\]]+\)/g],
+]
+// The two-value form before an assertion, as in `if v, ok := x.(T); ok`
+const COMMA_OK = /(?:^|[;{]|\b(?:if|switch|var))\s*\w+\s*,\s*\w+\s*:?=\s*[\w.[\]()]*$/
+const RUST_SITES: [PanicKind, RegExp][] = [
+  ['panic', /\b(?:panic|unreachable|todo|unimplemented)!\s*[([{]/g],
+  ['assert', /\bassert(?:_eq|_ne)?!\s*[([{]/g],
+  ['unwrap', /\.(?:unwrap|expect)\(/g],
+]
+const THROW_SITES: [PanicKind, RegExp][] = [
+  ['throw', /(?<![\w$.])throw\b/g],
+  ['exit', /\b(?:process|System)\.exit\(|(?<![\w.])fatalError\(/g],
+]
+const RAISE_SITES: [PanicKind, RegExp][] = [
+  ['raise', /^[ \t]*raise\b/gm],
+  ['assert', /^[ \t]*assert\b/gm],
+  ['exit', /\bsys\.exit\(/g],
+]
+
+/**
+ * Lists the panic sites of the given function and method nodes and marks the functions that
+ * can reach one through unhandled calls. With a symbol, only that function is reported, with
+ * the sites it can reach.
+ */
+export function findPanicPaths(nodes: TreeNode[], options: PanicOptions = {}): PanicReport {
+  const depth = Math.max(0, options.depth ?? DEFAULT_DEPTH)
+  const declarations = uniqueCallables(nodes)
+  const callables = declarations.map(node => readCallable(node, declarations))
+    .filter((callable): callable is Callable => callable !== undefined)
+
+  const byName = new Map<string, Callable[]>()
+  for (const callable of callables) {
+    byName.set(callable.node.name!, [...byName.get(callable.node.name!) ?? [], callable])
+  }
+  const callees = (callable: Callable) => [...callable.calls]
+    .filter(name => name !== callable.node.name)
+    .flatMap(name => byName.get(name) ?? [])
+    .filter(callee => callee.family === callable.family)
+
+  // Spread the marker from functions that panic themselves to their callers, one call per round
+  const marked = new Map<Callable, PanicFunction>()
+  for (const callable of callables) {
+    const sites = callable.sites.filter(site => !site.handled).length
+    if (sites > 0) marked.set(callable, toFunction(callable, 0, sites))
+  }
+  for (let round = 1; round <= depth; round++) {
+    const reached = [...marked.values()].filter(fn => fn.depth === round - 1)
+    if (reached.length === 0) break
+    for (const callable of callables) {
+      if (marked.has(callable)) continue
+      const callee = callees(callable).find(candidate => reached.includes(marked.get(candidate)!))
+      if (!callee) continue
+      const through = marked.get(callee)!
+      marked.set(callable, { ...toFunction(callable, round, 0), via: [through.name, ...through.via ?? []] })
+    }
+  }
+
+  if (!options.symbol) {
+    return {
+      sites: callables.flatMap(callable => callable.sites),
+      functions: [...marked.values()].sort((a, b) => a.depth - b.depth || a.file.localeCompare(b.file) || a.line - b.line),
+      depth,
+    }
+  }
+
+  const targets = callables.filter(callable => matchesSymbol(callable.node, options.symbol!))
+  if (targets.length === 0) {
+    throw new Error(`Unknown function: ${options.symbol}`)
+  }
+  // Sites the targets can reach: their own, then those of their callees up to the depth
+  const reachable = new Set<Callable>(targets)
+  let frontier = targets
+  for (let round = 1; round <= depth && frontier.length > 0; round++) {
+    frontier = frontier.flatMap(callees).filter(callee => !reachable.has(callee))
+    frontier.forEach(callee => reachable.add(callee))
+  }
+  return {
+    sites: [...reachable].flatMap(callable => callable.sites.filter(site => !site.handled || targets.includes(callable))),
+    functions: targets.flatMap(target => marked.get(target) ?? []),
+    depth,
+  }
+}
+
+function readCallable(node: TreeNode, declarations: TreeNode[]): Callable | undefined {
+  const language = getLanguageForFile(node.path)?.name
+  const patterns = language === PARSER_NAMES.GO
+    ? GO_SITES
+    : language === PARSER_NAMES.RUST ? RUST_SITES : language && THROW_LANGUAGES.has(language) ? THROW_SITES : language && RAISE_LANGUAGES.has(language) ? RAISE_SITES : undefined
+  if (!patterns) return undefined
+
+  const code = maskSource(node.content!, node.path)
+  const startLine = node.startLine ?? 1
+  // Lines of nested functions belong to them, not to this body
+  const nested = declarations.filter(other => other !== node && other.path === node.path && contains(node, other))
+  const own = (offset: number) => {
+    const line = startLine + lineAt(code, offset) - 1
+    return !nested.some(other => other.startLine! <= line && line <= other.endLine!)
+  }
+  const handled = handledRanges(code, language!)
+  const isHandled = (offset: number) => handled.some(([start, end]) => start <= offset && offset < end)
+
+  const sites: PanicSite[] = []
+  for (const [kind, pattern] of patterns) {
+    for (const match of code.matchAll(pattern)) {
+      if (!own(match.index)) continue
+      const line = lineAt(code, match.index)
+      if (kind === 'type_assertion' && COMMA_OK.test(code.substring(code.lastIndexOf('\n', match.index) + 1, match.index + 1))) continue
+      sites.push({
+        kind,
+        function: node.name!,
+        file: node.path,
+        line: startLine + line - 1,
+        code: node.content!.split('\n')[line - 1]!.trim(),
+        ...(isHandled(match.index) ? { handled: true } : {}),
+      })
+    }
+  }
+  sites.sort((a, b) => a.line - b.line)
+
+  const calls = new Set<string>()
+  for (const call of code.matchAll(CALL)) {
+    if (call.index === 0 || DECLARATION.test(code.slice(Math.max(0, call.index - 10), call.index))) continue
+    if (own(call.index) && !isHandled(call.index)) calls.add(call[1]!)
+  }
+
+  const family = language === PARSER_NAMES.TSX || language === PARSER_NAMES.JAVASCRIPT ? PARSER_NAMES.TYPESCRIPT : language!
+  return { node, family, sites, calls }
+}
+
+/**
+ * Offsets `[start, end)` where a panic does not leave the function: try blocks followed by a
+ * catch, and in Go the whole body when it defers a recover()
+ */
+function handledRanges(code: string, language: string): [number, number][] {
+  if (language === PARSER_NAMES.GO) {
+    return /\brecover\(\)/.test(code) ? [[0, code.length]] : []
+  }
+  if (language === PARSER_NAMES.PYTHON) {
+    return pythonTryBlocks(code)
+  }
+
+  const ranges: [number, number][] = []
+  for (const block of code.matchAll(/\btry\s*\{/g)) {
+    const open = block.index + block[0].length - 1
+    const close = matchingBrace(code, open)
+    if (/^\s*catch\b/.test(code.substring(close + 1))) ranges.push([open, close])
+  }
+  return ranges
+}
+
+function pythonTryBlocks(code: string): [number, number][] {
+  const offsets: number[] = []
+  let offset = 0
+  for (const line of lines) {
+    offsets.push(offset)
+    offset += line.length + 1
+  }
+  const indent = (line: string) => line.length - line.trimStart().length
+
+  const ranges: [number, number][] = []
+  lines.forEach((line, index) => {
+    if (!/^\s*try\s*:/.test(line)) return
+    let end = index + 1
+    while (end < lines.length && (!lines[end]!.trim() || indent(lines[end]!) > indent(line))) end++
+    if (end < lines.length && /^\s*except\b/.test(lines[end]!)) ranges.push([offsets[index + 1] ?? code.length, offsets[end]!])
+  })
+  return ranges
+}
+
+function uniqueCallables(nodes: TreeNode[]): TreeNode[] {
+  const seen = new Set<string>()
+  return nodes.filter((node) => {
+    if (!node.name || !node.content || !CALLABLE_KINDS.includes(node.symbol?.kind ?? node.type) || seen.has(node.id)) return false
+    seen.add(node.id)
+    return true
+  })
+}
+
+function matchesSymbol(node: TreeNode, symbol: string): boolean {
+  return node.name === symbol || (node.symbol?.container !== undefined && `${node.symbol.container}.${node.name}` === symbol)
+}
+
+function contains(outer: TreeNode, inner: TreeNode): boolean {
+  return (inner.startLine ?? 0) >= (outer.startLine ?? 0) && (inner.endLine ?? 0) <= (outer.endLine ?? 0)
+    && (inner.startLine !== outer.startLine || inner.endLine !== outer.endLine)
+}
+
+function toFunction(callable: Callable, depth: number, sites: number): PanicFunction {
+  return { name: callable.node.name!, file: callable.node.path, line: callable.node.startLine ?? 1, depth, sites }
+}
@@ -0,0 +1,405 @@
+/**
+ * Payload-to-type matching - the Go structs, TypeScript interfaces and Python dataclasses whose
+ * serialized field names best match a JSON/YAML payload or a dotted key path, scored so the
+ * type a payload decodes into can be found when debugging serialization
+ */
+
+import { getAllNodes } from '../project/manager.js'
+import { parseGoStructs } from './struct-tags.js'
+import { parseYaml } from '../utils/yaml.js'
+import type { Project, TreeNode } from '../types/core.js'
+
+export type ShapeLanguage = 'go' | 'typescript' | 'python'
+
+export interface ShapeField {
+  name: string
+  keys: string[] // Names the field is serialized under: tag, alias, or the field name
+  type: string
+  line: number
+  optional?: boolean // omitempty, pointer, `?`, Optional or defaulted; not counted missing
+}
+
+export interface DataShape {
+  name: string
+  language: ShapeLanguage
+  kind: 'struct' | 'interface' | 'type' | 'class' | 'dataclass' | 'model'
+  file: string
+  line: number
+  fields: ShapeField[]
+  bases: string[] // Embedded structs, extended interfaces and base classes, promoted into fields
+}
+
+export interface KeyMatch {
+  key: string
+  field: string
+  line: number
+  exact: boolean // False when only the case or `_`/`-` separators differ
+}
+
+export interface ShapeCandidate {
+  name: string
+  language: ShapeLanguage
+  kind: DataShape['kind']
+  file: string
+  line: number
+  score: number // 0-1: matched keys weighed against payload keys and required fields
+  matched: KeyMatch[]
+  unknownKeys: string[] // In the payload, not in the type
+  missingFields: string[] // Required by the type, absent from the payload
+  nested: { key: string, type: string, score: number }[] // Object values scored against the field's type
+}
+
+export interface PathStep {
+  type: string
+  field: string
+  key: string
+  file: string
+  line: number
+  fieldType: string
+  exact: boolean
+}
+
+export interface PathCandidate {
+  name: string // Type the walk starts at
+  language: ShapeLanguage
+  file: string
+  line: number
+  score: number // Share of path segments resolved, loose matches counting less
+  steps: PathStep[]
+  unresolved: string[] // Segments before the start type or after the walk stopped
+}
+
+export interface MatchOptions {
+  language?: ShapeLanguage
+  maxResults?: number
+}
+
+// A key matching only after dropping case and separators is a likely but unconfirmed match
+const LOOSE_WEIGHT = 0.5
+const MAX_NESTING = 3
+
+const TS_SHAPE_START = /^\s*(?:export\s+)?(?:declare\s+)?(?:(interface)\s+(\w+)(?:<[^>]*>)?(?:\s+extends\s+([^{]+))?|(type)\s+(\w+)(?:<[^>]*>)?\s*=\s*(?:([\w\s&<>,.]+?)\s*&\s*)?|(?:abstract\s+)?(class)\s+(\w+)(?:<[^>]*>)?(?:\s+extends\s+([\w.]+))?(?:\s+implements\s+[^{]+)?)\s*\{\s*(?:\/\/.*)?$/
+const TS_PROPERTY = /^(?:(?:public|private|protected|readonly|declare)\s+)*(?:(['"])([^'"]+)\1|([\w$]+))([?!])?\s*:\s*([^;=]+?)\s*(?:=.*)?[;,]?$/
+const PY_CLASS = /^(\s*)class\s+(\w+)\s*(?:\((.*)\))?\s*:/
+const PY_FIELD = /^(\w+)\s*:\s*([^=]+?)\s*(?:=\s*(.*))?$/
+
+// Bases that make a Python class a serialized data type
+const PY_MODEL_BASES = /\b(?:BaseModel|BaseSettings|TypedDict|Schema|Struct|SQLModel)\b/
+
+/**
+ * The data types of a project's Go, TypeScript and Python files
+ */
+export function listDataShapes(project: Project): DataShape[] {
+  return extractDataShapes(getAllNodes(project).filter(node => node.type === 'file'))
+}
+
+/**
+ * Reads data types from file contents. Fields of embedded structs, extended interfaces and base
+ * classes found among them are promoted into the types using them.
+ */
+export function extractDataShapes(fileNodes: TreeNode[]): DataShape[] {
+  const shapes: DataShape[] = []
+  for (const fileNode of fileNodes) {
+    const content = fileNode.content
+    if (!content) continue
+    if (fileNode.path.endsWith('.go')) shapes.push(...readGoShapes(content, fileNode.path))
+    else if (/\.(?:tsx?|mts|cts)$/.test(fileNode.path) && !fileNode.path.endsWith('.d.ts')) shapes.push(...parseTypeScriptShapes(content, fileNode.path))
+    else if (fileNode.path.endsWith('.py')) shapes.push(...parsePythonShapes(content, fileNode.path))
+  }
+  return promoteBases(shapes)
+}
+
+/**
+ * Types whose fields match the keys of a payload, best first. An array payload is matched by the
+ * keys of its objects; object values are scored against the type of the field holding them.
+ */
+export function matchPayload(shapes: DataShape[], payload: unknown, options: MatchOptions = {}): ShapeCandidate[] {
+  const { language, maxResults = 10 } = options
+  const object = payloadObject(payload)
+  if (!object || Object.keys(object).length === 0) return []
+
+  return shapes
+    .filter(shape => !language || shape.language === language)
+    .map(shape => scoreShape(shapes, shape, object, 0))
+    .filter(candidate => candidate.matched.length > 0)
+    .sort((a, b) => b.score - a.score || b.matched.length - a.matched.length || a.name.localeCompare(b.name))
+    .slice(0, maxResults)
+}
+
+/**
+ * Reads a payload given as JSON or YAML text
+ */
+export function parsePayload(text: string): unknown {
+  try {
+    return JSON.parse(text)
+  }
+  catch {
+    return parseYaml(text)
+  }
+}
+
+/**
+ * Types a dotted key path such as `user.address.street` (or `items[0].id`) resolves through,
+ * following each field to its type. A walk may start below the first segment when the outer
+ * keys belong to an envelope no type declares.
+ */
+export function matchKeyPath(shapes: DataShape[], path: string, options: MatchOptions = {}): PathCandidate[] {
+  const { language, maxResults = 10 } = options
+  const segments = path.split('.').map(segment => segment.replace(/\[\d*\]/g, '')).filter(segment => segment && !/^\d+$/.test(segment))
+  if (segments.length === 0) return []
+
+  const candidates: { candidate: PathCandidate, complete: boolean }[] = []
+  for (const shape of shapes) {
+    if (language && shape.language !== language) continue
+
+    let best: { candidate: PathCandidate, complete: boolean } | undefined
+    for (let start = 0; start < segments.length; start++) {
+      const steps = walkPath(shapes, shape, segments.slice(start))
+      if (steps.length === 0) continue
+      const weight = steps.reduce((sum, step) => sum + (step.exact ? 1 : LOOSE_WEIGHT), 0)
+      const complete = start + steps.length === segments.length
+      const candidate = {
+        name: shape.name,
+        language: shape.language,
+        file: shape.file,
+        line: shape.line,
+        score: round(weight / segments.length),
+        steps,
+        unresolved: [...segments.slice(0, start), ...segments.slice(start + steps.length)],
+      }
+      // A walk reaching the last segment beats a longer one that stops short
+      if (!best || (complete && !best.complete) || (complete === best.complete && candidate.score > best.candidate.score)) best = { candidate, complete }
+    }
+    if (best) candidates.push(best)
+  }
+
+  return candidates
+    .sort((a, b) => Number(b.complete) - Number(a.complete) || b.candidate.score - a.candidate.score || a.candidate.name.localeCompare(b.candidate.name))
+    .slice(0, maxResults)
+    .map(({ candidate }) => candidate)
+}
+
+/**
+ * TypeScript interfaces, object type aliases and classes with their properties
+ */
+export function parseTypeScriptShapes(content: string, file: string): DataShape[] {
+  const lines = content.split('\n')
+  const shapes: DataShape[] = []
+
+  for (let i = 0; i < lines.length; i++) {
+    const start = TS_SHAPE_START.exec(lines[i]!)
+    if (!start) continue
+
+    const kind = start[1] ? 'interface' : start[4] ? 'type' : 'class'
+    const bases = (start[3] ?? start[6] ?? start[9] ?? '').split(/[,&]/).map(base => base.trim().replace(/<.*$/, '')).filter(Boolean)
+    const shape: DataShape = { name: (start[2] ?? start[5] ?? start[8])!, language: 'typescript', kind, file, line: i + 1, fields: [], bases }
+
+    let depth = 1
+    let end = i + 1
+    for (; end < lines.length; end++) {
+      const text = lines[end]!.replace(/\/\/.*$/, '').trim()
+      const before = depth
+      depth += (text.match(/\{/g)?.length ?? 0) - (text.match(/\}/g)?.length ?? 0)
+      if (depth <= 0 || before !== 1) {
+        if (depth <= 0) break
+        continue
+      }
+
+      const property = TS_PROPERTY.exec(text.replace(/^@\w+(?:\([^)]*\))?\s*/, ''))
+      if (!property || /^\s*\(/.test(property[5]!)) continue
+      const name = (property[2] ?? property[3])!
+      const type = property[5]!.replace(/\{$/, '').trim()
+      shape.fields.push({ name, keys: [name], type, line: end + 1, ...(property[4] === '?' || /\|\s*(?:undefined|null)\b/.test(type) ? { optional: true } : {}) })
+    }
+
+    shapes.push(shape)
+    i = end
+  }
+
+  return shapes
+}
+
+/**
+ * Dataclasses, attrs classes, pydantic models, TypedDicts and marshmallow/msgspec schemas with
+ * their annotated attributes; a pydantic `Field(alias=...)` gives the serialized name
+ */
+export function parsePythonShapes(content: string, file: string): DataShape[] {
+  const lines = content.split('\n')
+  const shapes: DataShape[] = []
+
+  for (let i = 0; i < lines.length; i++) {
+    const start = PY_CLASS.exec(lines[i]!)
+    if (!start) continue
+
+    const bases = (start[3] ?? '').split(',').map(base => base.trim().split(/[.[]/).pop()!).filter(base => base && !base.includes('='))
+    const decorated = lines.slice(Math.max(0, i - 3), i).some(line => /^\s*@(?:dataclasses\.)?(?:dataclass|attr\.s|attrs\.define|define|frozen)\b/.test(line))
+    const isModel = PY_MODEL_BASES.test(start[3] ?? '')
+    const shape: DataShape = { name: start[2]!, language: 'python', kind: isModel ? 'model' : decorated ? 'dataclass' : 'class', file, line: i + 1, fields: [], bases }
+
+    const indent = start[1]!.length
+    let bodyIndent: number | undefined
+    let docstring = false
+    let end = i + 1
+    for (; end < lines.length; end++) {
+      const raw = lines[end]!.replace(/#.*$/, '')
+      if (!raw.trim()) continue
+      const lineIndent = raw.length - raw.trimStart().length
+      if (lineIndent <= indent && !docstring) break
+      bodyIndent ??= lineIndent
+      const quotes = raw.match(/"""|'''/g)?.length ?? 0
+      if (docstring || quotes > 0) {
+        if (quotes % 2 === 1) docstring = !docstring
+        continue
+      }
+      if (lineIndent !== bodyIndent) continue
+
+      const field = PY_FIELD.exec(raw.trim())
+      if (!field || field[1]!.startsWith('_') || /^ClassVar\b/.test(field[2]!)) continue
+      const alias = /\b(?:alias|serialization_alias|data_key)\s*=\s*['"]([^'"]+)['"]/.exec(field[3] ?? '')?.[1]
+      const optional = (field[3] !== undefined && !/^Field\(\s*\.\.\./.test(field[3])) || /^Optional\[|\|\s*None\b|^NotRequired\[/.test(field[2]!)
+      shape.fields.push({ name: field[1]!, keys: alias ? [alias, field[1]!] : [field[1]!], type: field[2]!.trim(), line: end + 1, ...(optional ? { optional: true } : {}) })
+    }
+
+    // Plain classes only count when an annotated attribute makes them look like records
+    if (isModel || decorated || shape.fields.length > 0) shapes.push(shape)
+    i = end - 1
+  }
+
+  return shapes
+}
+
+/**
+ * Go structs with the names their fields are serialized under by their json or yaml tags, and
+ * untagged exported fields under their Go name
+ */
+function readGoShapes(content: string, file: string): DataShape[] {
+  return parseGoStructs(content, file).map(struct => {
+    const fields: ShapeField[] = []
+    const bases: string[] = []
+    for (const field of struct.fields) {
+      const tagged = [field.tags.json, field.tags.yaml].filter(tag => tag !== undefined)
+      if (tagged.some(tag => tag.skipped)) continue
+      if (field.embedded && tagged.length === 0) {
+        bases.push(field.name)
+        continue
+      }
+      const keys = tagged.length > 0 ? [...new Set(tagged.map(tag => tag.name))] : /^[A-Z]/.test(field.name) ? [field.name] : []
+      if (keys.length === 0) continue
+      const optional = tagged.some(tag => tag.options.includes('omitempty')) || /^[*[]|^map\[/.test(field.type)
+      fields.push({ name: field.name, keys, type: field.type, line: field.line, ...(optional ? { optional: true } : {}) })
+    }
+    return { name: struct.name, language: 'go' as const, kind: 'struct' as const, file, line: struct.line, fields, bases }
+  })
+}
+
+function promoteBases(shapes: DataShape[]): DataShape[] {
+  const promoted = (shape: DataShape, seen: Set<DataShape>): ShapeField[] => {
+    seen.add(shape)
+    const inherited = shape.bases.flatMap(base => {
+      const target = findShape(shapes, base, shape.language)
+      return target && !seen.has(target) ? promoted(target, seen) : []
+    })
+    const own = new Set(shape.fields.map(field => field.name))
+    return [...inherited.filter(field => !own.has(field.name)), ...shape.fields]
+  }
+  return shapes.map(shape => shape.bases.length > 0 ? { ...shape, fields: promoted(shape, new Set()) } : shape)
+}
+
+function scoreShape(shapes: DataShape[], shape: DataShape, object: Record<string, unknown>, depth: number): ShapeCandidate {
+  const keys = Object.keys(object)
+  const matched: KeyMatch[] = []
+  const nested: ShapeCandidate['nested'] = []
+  const used = new Set<ShapeField>()
+
+  for (const key of keys) {
+    const found = fieldFor(shape, key)
+    if (!found || used.has(found.field)) continue
+    used.add(found.field)
+    matched.push({ key, field: found.field.name, line: found.field.line, exact: found.exact })
+
+    const value = payloadObject(object[key])
+    const target = value && depth < MAX_NESTING ? referencedShape(shapes, found.field.type, shape.language) : undefined
+    if (value && target) nested.push({ key, type: target.name, score: scoreShape(shapes, target, value, depth + 1).score })
+  }
+
+  const weight = matched.reduce((sum, match) => sum + (match.exact ? 1 : LOOSE_WEIGHT), 0)
+  const missing = shape.fields.filter(field => !used.has(field) && !field.optional)
+  const precision = weight / keys.length
+  const recall = weight / Math.max(used.size + missing.length, 1)
+  const own = precision + recall > 0 ? (2 * precision * recall) / (precision + recall) : 0
+  const score = (own + nested.reduce((sum, child) => sum + child.score, 0)) / (1 + nested.length)
+
+  return {
+    name: shape.name,
+    language: shape.language,
+    kind: shape.kind,
+    file: shape.file,
+    line: shape.line,
+    score: round(score),
+    matched,
+    unknownKeys: keys.filter(key => !matched.some(match => match.key === key)),
+    missingFields: missing.map(field => field.name),
+    nested,
+  }
+}
+
+function walkPath(shapes: DataShape[], shape: DataShape, segments: string[]): PathStep[] {
+  const steps: PathStep[] = []
+  let current: DataShape | undefined = shape
+  for (const segment of segments) {
+    const found = current && fieldFor(current, segment)
+    if (!current || !found) break
+    steps.push({ type: current.name, field: found.field.name, key: segment, file: current.file, line: found.field.line, fieldType: found.field.type, exact: found.exact })
+    current = referencedShape(shapes, found.field.type, current.language)
+  }
+  return steps
+}
+
+/**
+ * The field a key decodes into: an exact serialized name first, then one differing only in case
+ * (which encoding/json accepts for Go), then one differing in case and separators
+ */
+function fieldFor(shape: DataShape, key: string): { field: ShapeField, exact: boolean } | undefined {
+  const exact = shape.fields.find(field => field.keys.includes(key))
+  if (exact) return { field: exact, exact: true }
+
+  if (shape.language === 'go') {
+    const folded = shape.fields.find(field => field.keys.some(name => name.toLowerCase() === key.toLowerCase()))
+    if (folded) return { field: folded, exact: true }
+  }
+  const loose = shape.fields.find(field => [...field.keys, field.name].some(name => normalizeKey(name) === normalizeKey(key)))
+  return loose ? { field: loose, exact: false } : undefined
+}
+
+/**
+ * The declared type a field's type names, seen through pointers, slices, maps, optionals and
+ * generic containers
+ */
+function referencedShape(shapes: DataShape[], type: string, language: ShapeLanguage): DataShape | undefined {
+  const names = type.match(/[A-Za-z_][\w.]*/g) ?? []
+  for (const name of names.reverse()) {
+    const shape = findShape(shapes, name.split('.').pop()!, language)
+    if (shape) return shape
+  }
+  return undefined
+}
+
+function findShape(shapes: DataShape[], name: string, language: ShapeLanguage): DataShape | undefined {
+  return shapes.find(shape => shape.name === name && shape.language === language)
+}
+
+function payloadObject(value: unknown): Record<string, unknown> | undefined {
+  if (Array.isArray(value)) {
+    const objects = value.map(payloadObject).filter(item => item !== undefined)
+    return objects.length > 0 ? Object.assign({}, ...objects) : undefined
+  }
+  return value && typeof value === 'object' ? value as Record<string, unknown> : undefined
+}
+
+function normalizeKey(key: string): string {
+  return key.toLowerCase().replace(/[_-]/g, '')
+}
+
+function round(score: number): number {
+  return Math.round(score * 100) / 100
+}
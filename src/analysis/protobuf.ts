@@ -0,0 +1,98 @@
+/**
+ * Protobuf/gRPC linking - lists .proto services and types and ties generated code back to them
+ */
+
+import { findUsage } from '../core/search.js'
+import { parseProtoDefinitions, getGeneratedServiceIdentifiers } from '../core/proto.js'
+import { getAllNodes } from '../project/manager.js'
+import { isGeneratedProtoFile } from '../constants/patterns.js'
+import { createLightweightTreeNode } from '../types/core.js'
+import type { Project, TreeNode } from '../types/core.js'
+import type { ProtoRpc } from '../core/proto.js'
+
+export interface ProtoUsageLinks {
+  generatedFiles: string[]
+  usages: { path: string, line: number }[]
+  totalUsages: number
+}
+
+export interface LinkedProtoFile {
+  path: string
+  package?: string
+  services: {
+    name: string
+    fullName: string
+    startLine: number
+    rpcs: ProtoRpc[]
+    links?: ProtoUsageLinks
+  }[]
+  types: {
+    kind: 'message' | 'enum'
+    name: string
+    fullName: string
+    startLine: number
+    links?: ProtoUsageLinks
+  }[]
+}
+
+/**
+ * Lists the definitions of every .proto file in a project. With `includeUsages`, each service
+ * and type is linked to the generated files that declare it and the handwritten code using it.
+ */
+export function listProtoDefinitions(
+  project: Project,
+  options: { pathPattern?: string, includeUsages?: boolean, maxUsages?: number } = {},
+): LinkedProtoFile[] {
+  const { pathPattern, includeUsages = true, maxUsages = 5 } = options
+  const fileNodes = getAllNodes(project).filter(node => node.type === 'file')
+  const protoFiles = fileNodes.filter(node => node.path.endsWith('.proto') && (!pathPattern || node.path.includes(pathPattern)))
+  // Children are dropped so each occurrence is counted once, at file level
+  const codeFiles = fileNodes.filter(node => !node.path.endsWith('.proto')).map(createLightweightTreeNode)
+
+  const link = (identifiers: string[]) => includeUsages ? linkIdentifiers(identifiers, codeFiles, maxUsages) : undefined
+
+  return protoFiles.map((fileNode) => {
+    const definitions = parseProtoDefinitions(fileNode.content ?? '')
+
+    return {
+      path: fileNode.path,
+      package: definitions.package,
+      services: definitions.services.map(service => ({
+        name: service.name,
+        fullName: service.fullName,
+        startLine: service.startLine,
+        rpcs: service.rpcs,
+        links: link(getGeneratedServiceIdentifiers(service.name)),
+      })),
+      types: definitions.types.map(type => ({
+        kind: type.kind,
+        name: type.name,
+        fullName: type.fullName,
+        startLine: type.startLine,
+        links: link([type.name]),
+      })),
+    }
+  })
+}
+
+function linkIdentifiers(identifiers: string[], codeFiles: TreeNode[], maxUsages: number): ProtoUsageLinks {
+  const generatedFiles = new Set<string>()
+  const usages: { path: string, line: number }[] = []
+
+  for (const identifier of identifiers) {
+    for (const usage of findUsage(identifier, codeFiles, { caseSensitive: true, exactMatch: true })) {
+      if (isGeneratedProtoFile(usage.node.path)) {
+        generatedFiles.add(usage.node.path)
+      }
+      else {
+        usages.push({ path: usage.node.path, line: usage.startLine })
+      }
+    }
+  }
+
+  return {
+    generatedFiles: Array.from(generatedFiles),
+    usages: usages.slice(0, maxUsages),
+    totalUsages: usages.length,
+  }
+}
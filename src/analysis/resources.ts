@@ -0,0 +1,187 @@
+/**
+ * Resource analysis - files, connections and HTTP response bodies opened in a function and not
+ * closed on every path out of it: Go resources without a deferred Close, and Python handles
+ * opened outside a `with` block without a close in `finally`
+ */
+
+import { RESOURCE_CATEGORIES, escapeRegExp } from '../constants/index.js'
+import { blankGoClosures, lineAt, maskGo, matchingBrace, readGoFunctions, type GoFunction } from '../core/go-source.js'
+import { maskCommentsAndStrings } from '../core/python-dynamic.js'
+import { readPythonFunctions, type PythonLine } from '../core/python-source.js'
+import type { TreeNode } from '../types/core.js'
+import type { Finding, ResourceMetrics } from '../types/analysis.js'
+
+interface Acquisition {
+  variable: string
+  closer: string // The call that releases it, e.g. `f.Close()` or `resp.Body.Close()`
+  opener: string
+  offset: number
+  end: number // Offset after the acquiring statement
+}
+
+// Openers returning an io.Closer as their first result
+const GO_OPENERS = /\b(os\.(?:Open|Create|OpenFile|CreateTemp)|ioutil\.TempFile|net\.Dial(?:Timeout)?|tls\.Dial|zip\.OpenReader|gzip\.NewReader|http\.(?:Get|Post|PostForm|Head)|(?:http\.DefaultClient|[\w.]*[cC]lient)\.Do)\(/
+const GO_ACQUISITION = new RegExp(`\\b(\\w+)\\s*(?:,\\s*(\\w+)\\s*)?:?=\\s*${GO_OPENERS.source}`, 'g')
+const PY_ACQUISITION = /^(\w+)\s*=\s*((?:io\.|codecs\.|gzip\.|bz2\.)?open|tempfile\.(?:NamedTemporaryFile|TemporaryFile)|socket\.socket|sqlite3\.connect|urllib\.request\.urlopen|urlopen)\(/
+
+/**
+ * Analyzes the Go and Python files among the given nodes
+ */
+export function analyzeResources(nodes: TreeNode[]): { findings: Finding[], metrics: ResourceMetrics } {
+  const findings: Finding[] = []
+  let analyzedFiles = 0
+  let analyzedFunctions = 0
+
+  for (const node of nodes) {
+    if (node.type !== 'file' || !node.content) continue
+    if (node.path.endsWith('.go')) {
+      analyzedFiles++
+      const code = maskGo(node.content)
+      for (const fn of readGoFunctions(code)) {
+        analyzedFunctions++
+        findings.push(...checkGoFunction(fn).map(({ offset, ...finding }) => ({ ...finding, location: `${node.path}:${lineAt(code, fn.start + offset)}` })))
+      }
+    }
+    else if (node.path.endsWith('.py')) {
+      analyzedFiles++
+      for (const fn of readPythonFunctions(maskCommentsAndStrings(node.content))) {
+        analyzedFunctions++
+        findings.push(...checkPythonFunction(fn.name, fn.body).map(({ line, ...finding }) => ({ ...finding, location: `${node.path}:${line}` })))
+      }
+    }
+  }
+
+  const issuesByCategory: Record<string, number> = {}
+  for (const finding of findings) {
+    issuesByCategory[finding.category] = (issuesByCategory[finding.category] ?? 0) + 1
+  }
+
+  return { findings, metrics: { analyzedFiles, analyzedFunctions, issuesByCategory } }
+}
+
+type PendingFinding<T> = Omit<Finding, 'location'> & T
+
+/**
+ * Follows each resource from where it is opened to the function's exits. A deferred close
+ * covers every path; otherwise each return before the explicit close leaks it, except the
+ * error check right after opening, where there is nothing to close, and returns handing the
+ * resource to the caller.
+ */
+function checkGoFunction(fn: GoFunction): PendingFinding<{ offset: number }>[] {
+  const body = blankGoClosures(fn.body)
+  const findings: PendingFinding<{ offset: number }>[] = []
+
+  for (const acquisition of goAcquisitions(body)) {
+    const rest = fn.body.substring(acquisition.end)
+    const deferred = new RegExp(`\\bdefer\\s+(?:func\\s*\\([^)]*\\)\\s*\\{[^}]*)?${escapeRegExp(acquisition.closer)}`)
+    if (deferred.test(rest) || escapes(body.substring(acquisition.end), acquisition.variable)) continue
+
+    const finding = (category: string, offset: number, description: string) => ({
+      type: 'resources' as const,
+      category,
+      severity: 'warning' as const,
+      offset,
+      description: `${fn.name}: ${description}`,
+      metrics: { function: fn.name, resource: acquisition.variable, opener: acquisition.opener },
+    })
+
+    const scope = body.substring(acquisition.end)
+    const closed = scope.indexOf(acquisition.closer)
+    if (closed < 0) {
+      findings.push(finding(RESOURCE_CATEGORIES.UNCLOSED_RESOURCE, acquisition.offset,
+        `${acquisition.variable} from ${acquisition.opener} is never closed; add defer ${acquisition.closer}`))
+      continue
+    }
+
+    const errorCheck = /^\s*if\s+\w+\s*!=\s*nil\b[^{]*\{/.exec(scope)
+    const checked = errorCheck ? matchingBrace(scope, errorCheck[0].length - 1) : -1
+    for (const exit of scope.substring(0, closed).matchAll(/\breturn\b[^\n]*/g)) {
+      if (exit.index < checked) continue
+      findings.push(finding(RESOURCE_CATEGORIES.LEAK_ON_RETURN, acquisition.end + exit.index,
+        `returns without closing ${acquisition.variable} from ${acquisition.opener}; defer ${acquisition.closer} after opening it`))
+    }
+  }
+  return findings
+}
+
+function goAcquisitions(body: string): Acquisition[] {
+  return [...body.matchAll(GO_ACQUISITION)]
+    .filter(match => match[1] !== '_')
+    .map((match) => {
+      const opener = match[3]!
+      const response = /^http\.|\.Do$/.test(opener)
+      const lineEnd = body.indexOf('\n', match.index)
+      return {
+        variable: match[1]!,
+        closer: `${match[1]}.${response ? 'Body.' : ''}Close()`,
+        opener,
+        offset: match.index,
+        end: lineEnd < 0 ? body.length : lineEnd,
+      }
+    })
+}
+
+/**
+ * Whether code after opening hands the resource on: returns it, stores it in a field, map or
+ * composite literal, or sends it on a channel
+ */
+function escapes(code: string, variable: string): boolean {
+  const name = escapeRegExp(variable)
+  return new RegExp(`\\breturn\\b[^\\n]*(?<![\\w.])${name}\\b(?!\\.)`).test(code)
+    || new RegExp(`[\\w\\]]\\s*=\\s*${name}\\s*$|[:{,]\\s*${name}\\s*[,}]|<-\\s*${name}\\b`, 'm').test(code)
+}
+
+/**
+ * Follows each handle opened outside a `with` block. A close in a `finally` block covers every
+ * path; otherwise each return or raise before the close leaks it.
+ */
+function checkPythonFunction(name: string, body: PythonLine[]): PendingFinding<{ line: number }>[] {
+  const findings: PendingFinding<{ line: number }>[] = []
+
+  body.forEach((opened, index) => {
+    const acquisition = PY_ACQUISITION.exec(opened.text)
+    if (!acquisition) return
+    const [, variable, opener] = acquisition as unknown as [string, string, string]
+    const after = body.slice(index + 1)
+    const finding = (category: string, line: number, description: string) => ({
+      type: 'resources' as const,
+      category,
+      severity: 'warning' as const,
+      line,
+      description: `${name}: ${description}`,
+      metrics: { function: name, resource: variable, opener },
+    })
+
+    const uses = (pattern: string) => new RegExp(pattern.replace('VAR', escapeRegExp(variable)))
+    if (after.some(line => uses('^(?:async\\s+)?with\\b[^:]*\\bVAR\\b').test(line.text)
+      || uses('^(?:return|yield)\\b.*\\bVAR\\b(?!\\.)').test(line.text)
+      || uses('^[\\w.[\\]\'"]+\\s*=\\s*VAR\\s*$').test(line.text))) return
+
+    const closeIndex = after.findIndex(line => uses('\\bVAR\\.close\\(\\)').test(line.text))
+    if (closeIndex < 0) {
+      findings.push(finding(RESOURCE_CATEGORIES.UNCLOSED_RESOURCE, opened.line, `${variable} from ${opener}() is never closed; open it in a with block`))
+      return
+    }
+    if (inFinally(after, closeIndex)) return
+
+    for (const exit of after.slice(0, closeIndex)) {
+      if (!/^(?:return|raise)\b/.test(exit.text)) continue
+      findings.push(finding(RESOURCE_CATEGORIES.LEAK_ON_RETURN, exit.line, `${exit.text.startsWith('raise') ? 'raises' : 'returns'} without closing ${variable} from ${opener}(); open it in a with block`))
+    }
+  })
+  return findings
+}
+
+/**
+ * Whether the line at `index` sits in a `finally:` block
+ */
+function inFinally(lines: PythonLine[], index: number): boolean {
+  let indent = lines[index]!.indent
+  for (let i = index - 1; i >= 0; i--) {
+    const line = lines[i]!
+    if (line.indent >= indent) continue
+    if (/^finally\s*:/.test(line.text)) return true
+    indent = line.indent
+  }
+  return false
+}
@@ -0,0 +1,134 @@
+/**
+ * Analysis rollups - finding counts per CODEOWNERS team or top-level directory, with trends
+ * against an earlier report, so tech-debt work can be split up from a single analysis
+ */
+
+import { relative, sep } from 'path'
+import { findOwners, type CodeOwners } from '../project/codeowners.js'
+import type { AnalysisRollup, Finding, FindingRollup } from '../types/analysis.js'
+
+export const ROLLUP_GROUPINGS = ['owner', 'directory'] as const
+export type RollupGrouping = typeof ROLLUP_GROUPINGS[number]
+
+export interface RollupOptions {
+  groupBy: RollupGrouping
+  directory: string // Project root the finding locations are relative to
+  codeOwners?: CodeOwners // Required to group by owner
+}
+
+const UNOWNED_GROUP = '(unowned)'
+const ROOT_GROUP = '(root)'
+
+/**
+ * Counts findings per group, most critical groups first. A finding owned by several teams
+ * counts towards each of them.
+ */
+export function rollupFindings(findings: Finding[], options: RollupOptions): FindingRollup[] {
+  if (options.groupBy === 'owner' && !options.codeOwners) {
+    throw new Error('Grouping by owner needs a CODEOWNERS file')
+  }
+
+  const groups = new Map<string, FindingRollup>()
+  for (const finding of findings) {
+    for (const name of groupsOf(finding, options)) {
+      let group = groups.get(name)
+      if (!group) {
+        group = { group: name, total: 0, critical: 0, warning: 0, info: 0, categories: {} }
+        groups.set(name, group)
+      }
+      group.total++
+      group[finding.severity]++
+      group.categories[finding.category] = (group.categories[finding.category] ?? 0) + 1
+    }
+  }
+
+  return sortGroups([...groups.values()])
+}
+
+/**
+ * Adds the change since `baseline` to every group. Groups only in the baseline are kept with
+ * zero counts, so teams that cleared all their findings still show up.
+ */
+export function applyRollupTrends(current: FindingRollup[], baseline: FindingRollup[]): FindingRollup[] {
+  const previous = new Map(baseline.map(group => [group.group, group]))
+  const groups = current.map(group => ({ ...group, trend: trendBetween(group, previous.get(group.group)) }))
+
+  for (const group of baseline) {
+    if (!current.some(candidate => candidate.group === group.group)) {
+      const cleared = { group: group.group, total: 0, critical: 0, warning: 0, info: 0, categories: {} }
+      groups.push({ ...cleared, trend: trendBetween(cleared, group) })
+    }
+  }
+
+  return sortGroups(groups)
+}
+
+/**
+ * Reads the rollup out of a previous `analyze` JSON report, for use as a baseline
+ */
+export function readRollupBaseline(report: unknown, groupBy: RollupGrouping): AnalysisRollup {
+  const rollup = (report as { rollup?: Partial<AnalysisRollup> } | null)?.rollup
+  if (!rollup || !Array.isArray(rollup.groups)) {
+    throw new Error('Baseline report has no rollup; produce it with --group-by')
+  }
+  if (rollup.groupBy !== groupBy) {
+    throw new Error(`Baseline report is grouped by ${rollup.groupBy}, not ${groupBy}`)
+  }
+  return rollup as AnalysisRollup
+}
+
+/**
+ * Builds the rollup of a report, with trends when a baseline rollup is given
+ */
+export function buildRollup(findings: Finding[], options: RollupOptions, baseline?: AnalysisRollup): AnalysisRollup {
+  const groups = rollupFindings(findings, options)
+  return {
+    groupBy: options.groupBy,
+    generatedAt: new Date().toISOString(),
+    groups: baseline ? applyRollupTrends(groups, baseline.groups) : groups,
+    ...(baseline ? { baselineAt: baseline.generatedAt } : {}),
+  }
+}
+
+/**
+ * Renders a rollup as a markdown table
+ */
+export function formatRollupTable(rollup: AnalysisRollup): string {
+  const withTrend = rollup.groups.some(group => group.trend)
+  const heading = rollup.groupBy === 'owner' ? 'Owner' : 'Directory'
+  const delta = (value: number) => withTrend ? ` (${value > 0 ? '+' : ''}${value})` : ''
+
+  const lines = [
+    `## Findings by ${heading.toLowerCase()}${rollup.baselineAt ? ` (trend since ${rollup.baselineAt})` : ''}`,
+    '',
+    `| ${heading} | Total | Critical | Warnings | Info |`,
+    '|---|---|---|---|---|',
+    ...rollup.groups.map(group => `| ${group.group} | ${group.total}${delta(group.trend?.total ?? 0)} | ${group.critical}${delta(group.trend?.critical ?? 0)} | ${group.warning}${delta(group.trend?.warning ?? 0)} | ${group.info}${delta(group.trend?.info ?? 0)} |`),
+  ]
+  return `${lines.join('\n')}\n`
+}
+
+function groupsOf(finding: Finding, options: RollupOptions): string[] {
+  const path = finding.location.replace(/:\d+(?::\d+)?$/, '')
+
+  if (options.groupBy === 'owner') {
+    const owners = findOwners(options.codeOwners!, path).owners
+    return owners.length > 0 ? owners : [UNOWNED_GROUP]
+  }
+
+  const segments = relative(options.directory, path).split(sep)
+  return [segments.length > 1 && segments[0] !== '..' ? segments[0]! : ROOT_GROUP]
+}
+
+function trendBetween(current: FindingRollup, previous?: FindingRollup): FindingRollup['trend'] {
+  return {
+    total: current.total - (previous?.total ?? 0),
+    critical: current.critical - (previous?.critical ?? 0),
+    warning: current.warning - (previous?.warning ?? 0),
+    info: current.info - (previous?.info ?? 0),
+  }
+}
+
+function sortGroups(groups: FindingRollup[]): FindingRollup[] {
+  return groups.sort((a, b) => b.critical - a.critical || b.total - a.total || a.group.localeCompare(b.group))
+}
@@ -0,0 +1,185 @@
+/**
+ * HTTP route extraction - finds route registrations in common web frameworks
+ * (Express/Koa/Fastify/Hono, NestJS, Flask, FastAPI, Django, Go net/http/gin/echo/chi, Spring, Rails, Phoenix)
+ */
+
+import { extractPhoenixRoutes, isPhoenixRouter, maskElixir } from '../core/elixir.js'
+import type { TreeNode } from '../types/core.js'
+
+export interface RouteDefinition {
+  method: string // Upper-case HTTP method, or 'ANY' when the registration accepts every method
+  path: string
+  file: string
+  line: number
+  handler?: string
+  framework: string
+}
+
+interface RoutePattern {
+  framework: string
+  pattern: RegExp
+  methods: (match: RegExpMatchArray) => string[]
+  path: (match: RegExpMatchArray) => string
+  handler?: (match: RegExpMatchArray) => string | undefined
+  decorator?: boolean // Handler is the function declared right after the match
+}
+
+const HTTP_METHODS = 'get|post|put|delete|patch|options|head|all'
+// Receivers whose `.get('/path')` is an outgoing request rather than a route registration
+export const HTTP_CLIENTS = 'axios|http|https|client|request|api|fetch|superagent|got|ky|\\$http'
+
+const ROUTE_PATTERNS: RoutePattern[] = [
+  {
+    framework: 'express',
+    pattern: new RegExp(`\\b(?!(?:${HTTP_CLIENTS})\\.)\\w+\\.(${HTTP_METHODS})\\(\\s*['"\`](\\/[^'"\`]*)['"\`]([^)\\n]*)`, 'g'),
+    methods: match => [match[1]!.toUpperCase()],
+    path: match => match[2]!,
+    handler: match => match[3]!.match(/([A-Za-z_$][\w$.]*)\s*\)?\s*$/)?.[1],
+  },
+  {
+    framework: 'go',
+    pattern: /\b\w+\.(GET|POST|PUT|DELETE|PATCH|OPTIONS|HEAD|Get|Post|Put|Delete|Patch|Options|Head)\(\s*"([^"]+)"\s*,\s*([\w.]+)/g,
+    methods: match => [match[1]!.toUpperCase()],
+    path: match => match[2]!,
+    handler: match => match[3],
+  },
+  {
+    framework: 'go',
+    pattern: /\b(?:http|\w+)\.(?:HandleFunc|Handle)\(\s*"(?:(GET|POST|PUT|DELETE|PATCH) )?([^"]+)"\s*,\s*([\w.]+)/g,
+    methods: match => [match[1] ?? 'ANY'],
+    path: match => match[2]!,
+    handler: match => match[3],
+  },
+  {
+    framework: 'nestjs',
+    pattern: /@(Get|Post|Put|Delete|Patch|Options|Head|All)\(\s*(?:['"`]([^'"`]*)['"`])?\s*\)/g,
+    methods: match => [match[1]!.toUpperCase()],
+    path: match => match[2] ?? '',
+    decorator: true,
+  },
+  {
+    framework: 'fastapi',
+    pattern: /@\w+\.(get|post|put|delete|patch|options|head)\(\s*['"]([^'"]+)['"]/g,
+    methods: match => [match[1]!.toUpperCase()],
+    path: match => match[2]!,
+    decorator: true,
+  },
+  {
+    framework: 'flask',
+    pattern: /@\w+\.route\(\s*['"]([^'"]+)['"](?:[^)]*methods\s*=\s*\[([^\]]*)\])?/g,
+    methods: match => match[2] ? (match[2].match(/\w+/g) ?? []).map(method => method.toUpperCase()) : ['GET'],
+    path: match => match[1]!,
+    decorator: true,
+  },
+  {
+    framework: 'django',
+    pattern: /\b(?:re_)?path\(\s*r?['"]([^'"]*)['"]\s*,\s*([\w.]+)/g,
+    methods: () => ['ANY'],
+    path: match => '/' + match[1]!.replace(/^\^|\$$/g, ''),
+    handler: match => match[2],
+  },
+  {
+    framework: 'spring',
+    pattern: /@(Get|Post|Put|Delete|Patch|Request)Mapping\b(?:\(\s*(?:(?:value|path)\s*=\s*)?\{?\s*"([^"]*)")?/g,
+    methods: match => [match[1] === 'Request' ? 'ANY' : match[1]!.toUpperCase()],
+    path: match => match[2] ?? '',
+    decorator: true,
+  },
+  {
+    framework: 'rails',
+    pattern: /^\s*(get|post|put|patch|delete)\s+['"]([^'"]+)['"](?:\s*,\s*to:\s*['"]([^'"]+)['"])?/gm,
+    methods: match => [match[1]!.toUpperCase()],
+    path: match => match[2]!,
+    handler: match => match[3],
+  },
+]
+
+const CLASS_PREFIX_PATTERNS = [
+  /@Controller\(\s*['"`]([^'"`]*)['"`]\s*\)/,
+  /@RequestMapping\(\s*(?:(?:value|path)\s*=\s*)?\{?\s*"([^"]*)"/,
+]
+
+/**
+ * Extracts route registrations from parsed files. File nodes supply the source text and
+ * their function children supply handler names for decorator-style frameworks.
+ */
+export function extractRoutes(fileNodes: TreeNode[]): RouteDefinition[] {
+  const routes: RouteDefinition[] = []
+
+  for (const fileNode of fileNodes) {
+    const content = fileNode.content
+    if (!content) continue
+
+    // Phoenix verb macros read like Rails routes but are scoped and name a controller action
+    if (/\.exs?$/.test(fileNode.path)) {
+      if (!isPhoenixRouter(maskElixir(content))) continue
+      for (const route of extractPhoenixRoutes(content)) {
+        const handler = route.action ? `${route.controller}.${route.action}` : route.controller
+        routes.push({ method: route.method, path: route.path, file: fileNode.path, line: route.line, handler, framework: 'phoenix' })
+      }
+      continue
+    }
+
+    const functions = (fileNode.children ?? []).filter(child => child.type === 'function')
+    const prefix = CLASS_PREFIX_PATTERNS.map(pattern => content.match(pattern)?.[1]).find(Boolean) ?? ''
+
+    for (const routePattern of ROUTE_PATTERNS) {
+      routePattern.pattern.lastIndex = 0
+      for (const match of content.matchAll(routePattern.pattern)) {
+        const line = content.substring(0, match.index).split('\n').length
+        // Class-level prefixes only apply to decorator routes; a Spring class mapping is not a route itself
+        if (routePattern.decorator && isClassPrefix(content, match.index!)) continue
+
+        const path = routePattern.decorator ? joinRoutePath(prefix, routePattern.path(match)) : routePattern.path(match)
+        const handler = routePattern.decorator
+          ? functions.find(fn => (fn.startLine ?? 0) >= line && (fn.startLine ?? 0) <= line + 5)?.name
+          : routePattern.handler?.(match)
+
+        for (const method of routePattern.methods(match)) {
+          routes.push({ method, path, file: fileNode.path, line, handler, framework: routePattern.framework })
+        }
+      }
+    }
+  }
+
+  return routes
+}
+
+/**
+ * Normalizes path parameters (`:id`, `{id}`, `<int:id>`, `(?P<id>...)`) to `{}` and strips
+ * trailing slashes, so routes from different frameworks and specs can be compared
+ */
+export function normalizeRoutePath(path: string): string {
+  const normalized = path
+    .replace(/\(\?P<\w+>[^)]*\)/g, '{}')
+    .replace(/<(?:\w+:)?\w+>/g, '{}')
+    .replace(/\{[^}]*\}/g, '{}')
+    .replace(/:\w+/g, '{}')
+    .replace(/\/+/g, '/')
+    .replace(/\/$/, '')
+
+  return normalized.startsWith('/') ? normalized : `/${normalized}`
+}
+
+/**
+ * Checks whether two routes refer to the same endpoint, allowing one side to carry a mount
+ * prefix (e.g. `/api/v1`) the other omits
+ */
+export function routePathsMatch(a: string, b: string): boolean {
+  const left = normalizeRoutePath(a)
+  const right = normalizeRoutePath(b)
+  if (left === right) return true
+
+  const [longer, shorter] = left.length > right.length ? [left, right] : [right, left]
+  return /[^/{}]/.test(shorter) && longer.endsWith(shorter)
+}
+
+function joinRoutePath(prefix: string, path: string): string {
+  if (!prefix) return path.startsWith('/') ? path : `/${path}`
+  return `/${[prefix, path].map(part => part.replace(/^\/|\/$/g, '')).filter(Boolean).join('/')}`
+}
+
+function isClassPrefix(content: string, index: number): boolean {
+  const following = content.substring(index).split('\n').slice(1, 4).join('\n')
+  return /^\s*(?:@\w+.*\n\s*)*(?:public\s+|export\s+)?(?:abstract\s+)?class\s/m.test(following)
+}
@@ -0,0 +1,105 @@
+/**
+ * Security analysis - patterns that commonly lead to exploits. Covers Solidity contracts:
+ * authorization through tx.origin, state written after an external call (reentrancy),
+ * unchecked low-level calls, delegatecall and selfdestruct.
+ */
+
+import { getLanguageForFile } from '../core/languages.js'
+import { lineAt, maskSolidity, readSolidity } from '../core/solidity.js'
+import { PARSER_NAMES, SECURITY_CATEGORIES, escapeRegExp } from '../constants/index.js'
+import type { SolidityContract, SolidityFunction } from '../core/solidity.js'
+import type { TreeNode } from '../types/core.js'
+import type { Finding, SecurityMetrics } from '../types/analysis.js'
+
+const LOW_LEVEL_CALL = /\.(call|delegatecall|staticcall|send)\s*(?:\{[^}]*\}\s*)?\(/g
+const EXTERNAL_CALL = /\.(?:call|delegatecall)\s*(?:\{[^}]*\}\s*)?\(|\.call\.value\s*\(/g
+const REENTRANCY_GUARD = /^(?:nonReentrant|noReentrancy|noReentrant|lock|mutex)$/i
+
+/**
+ * Analyzes the Solidity files among the given nodes
+ */
+export function analyzeSecurity(nodes: TreeNode[]): { findings: Finding[], metrics: SecurityMetrics } {
+  const findings: Finding[] = []
+  let analyzedFiles = 0
+  let analyzedFunctions = 0
+
+  for (const node of nodes) {
+    if (node.type !== 'file' || !node.content || getLanguageForFile(node.path)?.name !== PARSER_NAMES.SOLIDITY) continue
+    analyzedFiles++
+
+    const { contracts, functions } = readSolidity(node.content)
+    const code = maskSolidity(node.content)
+    for (const fn of functions) {
+      if (!fn.body) continue
+      analyzedFunctions++
+      const contract = contracts.find(candidate => candidate.name === fn.contract)
+      findings.push(...checkFunction(node, code, fn, contract))
+    }
+  }
+
+  const issuesByCategory: Record<string, number> = {}
+  for (const finding of findings) {
+    issuesByCategory[finding.category] = (issuesByCategory[finding.category] ?? 0) + 1
+  }
+
+  return { findings, metrics: { analyzedFiles, analyzedFunctions, issuesByCategory } }
+}
+
+function checkFunction(file: TreeNode, code: string, fn: SolidityFunction, contract?: SolidityContract): Finding[] {
+  const findings: Finding[] = []
+  const bodyStart = fn.body!.start
+  const body = code.substring(bodyStart, fn.body!.end)
+  const finding = (category: string, severity: Finding['severity'], offset: number, description: string): Finding => ({
+    type: 'security',
+    category,
+    severity,
+    location: `${file.path}:${lineAt(file.content!, bodyStart + offset)}`,
+    description,
+    metrics: { function: fn.contract ? `${fn.contract}.${fn.name}` : fn.name },
+  })
+
+  for (const match of body.matchAll(/\btx\.origin\b/g)) {
+    // Comparing against tx.origin authorizes whoever started the transaction, not the caller
+    const compared = /(?:==|!=)\s*$/.test(body.substring(0, match.index!)) || /^\s*(?:==|!=)/.test(body.substring(match.index! + match[0].length))
+    findings.push(compared
+      ? finding(SECURITY_CATEGORIES.TX_ORIGIN, 'critical', match.index!, `${fn.name} authorizes with tx.origin; a contract the owner calls can act as the owner. Use msg.sender`)
+      : finding(SECURITY_CATEGORIES.TX_ORIGIN, 'info', match.index!, `${fn.name} reads tx.origin; make sure it is not used for authorization`))
+  }
+
+  for (const match of body.matchAll(LOW_LEVEL_CALL)) {
+    const statement = body.substring(0, match.index!).split(/[;{}]/).pop()!
+    if (!/=|\b(?:require|assert|if|return)\b/.test(statement)) {
+      findings.push(finding(SECURITY_CATEGORIES.UNCHECKED_CALL, 'warning', match.index!, `${fn.name} ignores whether a low-level .${match[1]} succeeded; check the returned bool`))
+    }
+    if (match[1] === 'delegatecall') {
+      findings.push(finding(SECURITY_CATEGORIES.DELEGATECALL, 'warning', match.index!, `${fn.name} uses delegatecall, which runs the target's code against this contract's storage; make sure the target is trusted`))
+    }
+  }
+
+  for (const match of body.matchAll(/\b(?:selfdestruct|suicide)\s*\(/g)) {
+    findings.push(finding(SECURITY_CATEGORIES.SELFDESTRUCT, 'warning', match.index!, `${fn.name} can selfdestruct the contract; make sure it is restricted to trusted callers`))
+  }
+
+  const reentrancy = findStateWriteAfterCall(body, contract?.stateVariables ?? [])
+  if (reentrancy && !fn.modifiers.some(modifier => REENTRANCY_GUARD.test(modifier))) {
+    findings.push(finding(SECURITY_CATEGORIES.REENTRANCY, 'critical', reentrancy.offset,
+      `${fn.name} writes ${reentrancy.variable} after an external call; a reentrant call sees the old value. Update state before the call or add a reentrancy guard`))
+  }
+
+  return findings
+}
+
+/**
+ * The first write to a state variable following the first external call of a function body
+ */
+function findStateWriteAfterCall(body: string, stateVariables: string[]): { variable: string, offset: number } | undefined {
+  const call = EXTERNAL_CALL.exec(body)
+  EXTERNAL_CALL.lastIndex = 0
+  if (!call || stateVariables.length === 0) return undefined
+
+  const names = stateVariables.map(escapeRegExp).join('|')
+  const write = new RegExp(String.raw`(?<![\w.])(${names})\b(?:\s*\[[^\]]*\])*(?:\.\w+)*\s*(?:[-+*/%|&^]?=(?!=)|\+\+|--)|\bdelete\s+(${names})\b|(?:\+\+|--)\s*(${names})\b`, 'g')
+  write.lastIndex = call.index + call[0].length
+  const match = write.exec(body)
+  return match ? { variable: match[1] ?? match[2] ?? match[3]!, offset: match.index } : undefined
+}
@@ -0,0 +1,183 @@
+/**
+ * Similar-code search - normalizes a snippet to the shape of its syntax tree and ranks the
+ * project's functions by how closely their structure matches it, ignoring names and literals
+ */
+
+import type Parser from 'tree-sitter'
+import { parseContent } from '../core/parser.js'
+import { getLanguageForFile, resolveLanguage } from '../core/languages.js'
+import { getAllNodes } from '../project/manager.js'
+import { MEMORY_LIMITS } from '../constants/persistence.js'
+import type { LanguageConfig, Project, TreeNode } from '../types/core.js'
+
+export interface SimilarCode {
+  node: TreeNode
+  similarity: number // 0-100
+}
+
+export interface SimilarityOptions {
+  language?: string // Name, alias or extension; without one, snippets are compared token by token
+  maxResults?: number
+  minSimilarity?: number
+  types?: string[]
+}
+
+const SHINGLE_SIZE = 4
+const DEFAULT_TYPES = ['function', 'method']
+
+// Kept as-is by the token fallback; every other identifier becomes `id`
+const KEYWORDS = new Set([
+  'if', 'else', 'elif', 'for', 'while', 'do', 'switch', 'case', 'default', 'match', 'break', 'continue',
+  'return', 'yield', 'await', 'async', 'try', 'catch', 'except', 'finally', 'throw', 'raise', 'new',
+  'function', 'def', 'func', 'fn', 'lambda', 'class', 'struct', 'let', 'const', 'var', 'in', 'of',
+  'with', 'defer', 'go', 'select', 'range', 'not', 'and', 'or', 'is', 'null', 'nil', 'None', 'true',
+  'false', 'True', 'False', 'this', 'self', 'super',
+])
+
+const TOKEN = /[A-Za-z_$][\w$]*|\d[\w.]*|"(?:\\.|[^"\\])*"|'(?:\\.|[^'\\])*'|`[^`]*`|\S/g
+
+/**
+ * Finds the functions most structurally similar to a snippet. With a language, the snippet is
+ * parsed and trees are compared by node types; functions of files without a syntax tree, and
+ * every function when no language is given or the snippet doesn't parse, are compared by
+ * normalized tokens.
+ */
+export function findSimilarCode(project: Project, snippet: string, options: SimilarityOptions = {}): SimilarCode[] {
+  const { maxResults = 10, minSimilarity = 50, types = DEFAULT_TYPES } = options
+  if (!snippet.trim()) {
+    throw new Error('Snippet must not be empty')
+  }
+  if (Buffer.byteLength(snippet) > MEMORY_LIMITS.MAX_FILE_SIZE_BYTES) {
+    throw new Error(`Snippet exceeds ${MEMORY_LIMITS.MAX_FILE_SIZE_BYTES} bytes`)
+  }
+
+  const language = options.language ? resolveLanguage(options.language) : undefined
+  if (options.language && !language) {
+    throw new Error(`Unknown language: ${options.language}`)
+  }
+
+  const tokenShape = shingles(normalizedTokens(snippet))
+  const syntaxTree = language ? parseSnippet(snippet, language) : undefined
+  const treeShape = syntaxTree ? shingles(syntaxShape(syntaxTree)) : undefined
+
+  const nodes = getAllNodes(project)
+  const trees = new Map(nodes.filter(node => node.type === 'file' && node.rawNode).map(node => [node.path, node.rawNode as Parser.SyntaxNode]))
+  const seen = new Set<string>()
+  const results: SimilarCode[] = []
+
+  for (const node of nodes) {
+    if (!types.includes(node.type) || !node.content || seen.has(node.id)) continue
+    if (language && getLanguageForFile(node.path)?.name !== language.name) continue
+    seen.add(node.id)
+
+    // Trees are only comparable with trees of the same grammar
+    const shape = treeShape ? node.syntaxShape ?? elementShape(trees.get(node.path), node) : undefined
+    const similarity = shape
+      ? diceSimilarity(treeShape!, shingles(shape), minSimilarity)
+      : diceSimilarity(tokenShape, shingles(normalizedTokens(node.content)), minSimilarity)
+    if (similarity >= minSimilarity) results.push({ node, similarity })
+  }
+
+  return results
+    .sort((a, b) => b.similarity - a.similarity || a.node.path.localeCompare(b.node.path) || (a.node.startLine ?? 0) - (b.node.startLine ?? 0))
+    .slice(0, maxResults)
+}
+
+/**
+ * The named node types of a syntax tree in pre-order. Identifiers and literals keep only their
+ * type, so renamed copies have the same shape; comments are dropped.
+ */
+export function syntaxShape(node: Parser.SyntaxNode): string[] {
+  const shape: string[] = []
+  const visit = (current: Parser.SyntaxNode) => {
+    if (current.type === 'comment' || current.type.endsWith('_comment')) return
+    if (current.isNamed) shape.push(current.type)
+    // A string's contents are part of its literal
+    if (/string|template/.test(current.type) && current.isNamed) return
+    for (const child of current.children) visit(child)
+  }
+  visit(node)
+  return shape
+}
+
+/**
+ * Tokens with identifiers, numbers and strings replaced by placeholders, for code without a
+ * syntax tree
+ */
+export function normalizedTokens(code: string): string[] {
+  const withoutComments = code.replace(/\/\*[\s\S]*?\*\/|\/\/[^\n]*|^\s*#[^\n]*/gm, '')
+  return (withoutComments.match(TOKEN) ?? []).map((token) => {
+    if (/^[A-Za-z_$]/.test(token)) return KEYWORDS.has(token) ? token : 'id'
+    if (/^\d/.test(token)) return 'num'
+    if (/^["'`]/.test(token)) return 'str'
+    return token
+  })
+}
+
+/**
+ * The syntax shape of an element, read from its file's tree. Index archives store it, as
+ * restored nodes have no tree to read it from.
+ */
+export function elementShape(root: Parser.SyntaxNode | undefined, node: TreeNode): string[] | undefined {
+  const syntaxNode = root ? findSyntaxNode(root, node) : undefined
+  return syntaxNode ? syntaxShape(syntaxNode) : undefined
+}
+
+// A snippet that doesn't parse is still compared by its tokens
+function parseSnippet(snippet: string, language: LanguageConfig): Parser.SyntaxNode | undefined {
+  try {
+    return parseContent(snippet, `snippet${language.extensions[0] ?? ''}`, language).rawNode
+  }
+  catch {
+    return undefined
+  }
+}
+
+function shingles(sequence: string[]): Map<string, number> {
+  const counts = new Map<string, number>()
+  const size = Math.min(SHINGLE_SIZE, sequence.length)
+  for (let i = 0; i + size <= sequence.length && size > 0; i++) {
+    const shingle = sequence.slice(i, i + size).join(' ')
+    counts.set(shingle, (counts.get(shingle) ?? 0) + 1)
+  }
+  return counts
+}
+
+/**
+ * Dice coefficient of two shingle multisets, as a percentage. Returns 0 early when the sizes
+ * alone rule out reaching `minimum`.
+ */
+function diceSimilarity(a: Map<string, number>, b: Map<string, number>, minimum: number): number {
+  const sizeA = total(a)
+  const sizeB = total(b)
+  if (sizeA === 0 || sizeB === 0) return 0
+  if (200 * Math.min(sizeA, sizeB) / (sizeA + sizeB) < minimum) return 0
+
+  let shared = 0
+  for (const [shingle, count] of a) shared += Math.min(count, b.get(shingle) ?? 0)
+  return Math.round(200 * shared / (sizeA + sizeB))
+}
+
+function total(counts: Map<string, number>): number {
+  let sum = 0
+  for (const count of counts.values()) sum += count
+  return sum
+}
+
+/**
+ * The syntax node a function element was extracted from, located by its position in the file
+ */
+function findSyntaxNode(root: Parser.SyntaxNode, node: TreeNode): Parser.SyntaxNode | undefined {
+  if (node.startLine === undefined || node.endLine === undefined) return undefined
+  const start = { row: node.startLine - 1, column: node.startColumn ?? 0 }
+  const end = { row: node.endLine - 1, column: node.endColumn ?? 0 }
+
+  for (let current: Parser.SyntaxNode | null = root.descendantForPosition(start, end); current; current = current.parent) {
+    if (current.startPosition.row === start.row && current.endPosition.row === end.row
+      && current.startPosition.column === start.column && current.endPosition.column === end.column) {
+      return current
+    }
+    if (current.startPosition.row < start.row) return undefined
+  }
+  return undefined
+}
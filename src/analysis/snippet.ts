@@ -0,0 +1,123 @@
+/**
+ * Snippet analysis - parses source text held in memory (piped on stdin or just generated by
+ * an agent) and reports its outline, symbols, syntax errors and complexity without a project
+ */
+
+import { extname } from 'path'
+import { parseContent } from '../core/parser.js'
+import { resolveLanguage } from '../core/languages.js'
+import { extractActionableErrors, type ActionableError } from './errors.js'
+import { analyzeQuality } from './quality.js'
+import { calculateComplexity, calculateMethodLength, getParameterCount } from './quality-metrics.js'
+import { MEMORY_LIMITS } from '../constants/persistence.js'
+import type { SymbolKind, SymbolVisibility, TreeNode } from '../types/core.js'
+import type { Finding, QualityMetrics } from '../types/analysis.js'
+
+export interface SnippetSymbol {
+  name: string
+  type: string
+  kind?: SymbolKind
+  visibility?: SymbolVisibility
+  signature?: string
+  startLine?: number
+  endLine?: number
+  parent?: string // Enclosing class, when the language extracts methods as separate symbols
+  complexity?: number // Functions and methods only
+  length?: number
+  parameters?: number
+}
+
+export interface SnippetAnalysis {
+  language: string
+  path: string
+  lines: number
+  outline: string
+  symbols: SnippetSymbol[]
+  syntaxErrors: ActionableError[]
+  quality: QualityMetrics
+  findings: Finding[]
+}
+
+export interface SnippetOptions {
+  language?: string // Name, alias, extension or file name; inferred from `filename` when omitted
+  filename?: string // Used in reported locations and for test-file thresholds
+}
+
+const FUNCTION_TYPES = new Set(['function', 'method'])
+
+/**
+ * Parses and analyzes a snippet of source text
+ */
+export function analyzeSnippet(content: string, options: SnippetOptions = {}): SnippetAnalysis {
+  const hint = options.language || options.filename
+  if (!hint) {
+    throw new Error('A language is required to parse a snippet (e.g. typescript, py, .go)')
+  }
+
+  const language = resolveLanguage(hint)
+  if (!language) {
+    throw new Error(`Unknown language: ${hint}`)
+  }
+
+  if (Buffer.byteLength(content) > MEMORY_LIMITS.MAX_FILE_SIZE_BYTES) {
+    throw new Error(`Snippet exceeds ${MEMORY_LIMITS.MAX_FILE_SIZE_BYTES} bytes`)
+  }
+
+  const filename = options.filename && extname(options.filename) ? options.filename : `snippet${language.extensions[0] ?? ''}`
+  const fileNode = parseContent(content, filename, language)
+  const children = [...(fileNode.children ?? [])].sort((a, b) => (a.startLine ?? 0) - (b.startLine ?? 0))
+  const { metrics, findings } = analyzeQuality(children)
+
+  return {
+    language: language.name,
+    path: filename,
+    lines: content.split('\n').length,
+    outline: formatOutline(children),
+    symbols: children.map(child => toSymbol(child, children)),
+    syntaxErrors: fileNode.rawNode ? extractActionableErrors(fileNode.rawNode, filename) : [],
+    quality: metrics,
+    findings,
+  }
+}
+
+function toSymbol(node: TreeNode, siblings: TreeNode[]): SnippetSymbol {
+  const parent = node.symbol?.container ?? findEnclosingClass(node, siblings)?.name
+  return {
+    name: node.name ?? 'anonymous',
+    type: node.type,
+    ...(node.symbol ? { kind: node.symbol.kind, visibility: node.symbol.visibility, signature: node.symbol.signature } : {}),
+    startLine: node.startLine,
+    endLine: node.endLine,
+    ...(parent ? { parent } : {}),
+    ...(FUNCTION_TYPES.has(node.type)
+      ? { complexity: calculateComplexity(node), length: calculateMethodLength(node), parameters: getParameterCount(node) }
+      : {}),
+  }
+}
+
+function findEnclosingClass(node: TreeNode, siblings: TreeNode[]): TreeNode | undefined {
+  if (node.startLine === undefined || node.endLine === undefined) return undefined
+  const start = node.startLine
+  const end = node.endLine
+
+  // Strict containment, so two symbols spanning the same lines never nest in each other
+  return siblings
+    .filter(candidate => candidate.type === 'class'
+      && (candidate.startLine ?? 0) <= start && (candidate.endLine ?? 0) >= end
+      && ((candidate.startLine ?? 0) < start || (candidate.endLine ?? 0) > end))
+    .sort((a, b) => (b.startLine ?? 0) - (a.startLine ?? 0))[0]
+}
+
+/**
+ * Renders symbols as an indented outline, nesting members under their class
+ */
+function formatOutline(children: TreeNode[]): string {
+  const depth = (node: TreeNode): number => {
+    const parent = findEnclosingClass(node, children)
+    return parent ? depth(parent) + 1 : 0
+  }
+
+  return children
+    .map(node => `${'  '.repeat(depth(node))}${node.type} ${node.name ?? 'anonymous'} (${node.startLine ?? '?'}-${node.endLine ?? '?'})`)
+    .join('\n')
+}
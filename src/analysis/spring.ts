@@ -0,0 +1,436 @@
+/**
+ * Spring bean map - the stereotype-annotated classes and `@Bean` methods of Java services, the
+ * dependencies each one has injected through fields, constructors and setters, the beans
+ * satisfying them, and the endpoints of controllers
+ */
+
+import { getAllNodes } from '../project/manager.js'
+import { extractRoutes } from './routes.js'
+import type { Project, TreeNode } from '../types/core.js'
+
+export type SpringStereotype = 'SpringBootApplication' | 'Configuration' | 'RestController' | 'Controller' | 'Service' | 'Repository' | 'Component' | 'Bean'
+
+export interface SpringDependency {
+  type: string // Declared type, e.g. `PaymentGateway` or `List<Notifier>`
+  name: string // Field or parameter name
+  via: 'field' | 'constructor' | 'setter' | 'parameter'
+  line: number
+  qualifier?: string
+  beans: string[] // Names of the beans injected; empty when none is found in the project
+}
+
+export interface SpringEndpoint {
+  method: string
+  path: string
+  line: number
+  handler?: string
+}
+
+export interface SpringBean {
+  name: string
+  type: string // Class, or the return type of a `@Bean` method
+  stereotype: SpringStereotype
+  path: string
+  line: number
+  primary?: boolean
+  declaredBy?: string // Configuration class of a `@Bean` method
+  dependencies: SpringDependency[]
+  endpoints?: SpringEndpoint[]
+}
+
+interface TypeDeclaration {
+  name: string
+  keyword: string
+  annotations: Annotation[]
+  supertypes: string[]
+  start: number
+  header: number // Offset of the text between the name and the body
+  open: number
+  close: number
+}
+
+interface Annotation {
+  name: string
+  args: string // Unmasked text between the parentheses
+}
+
+interface Parameter {
+  type: string
+  name: string
+  offset: number
+  annotations: Annotation[]
+}
+
+const STEREOTYPES: SpringStereotype[] = ['SpringBootApplication', 'Configuration', 'RestController', 'Controller', 'Service', 'Repository', 'Component']
+const INJECTION_ANNOTATIONS = new Set(['Autowired', 'Inject', 'Resource'])
+// Spring Data interfaces whose extensions are repository beans without an annotation
+const DATA_REPOSITORIES = /^(?:Jpa|Crud|ListCrud|PagingAndSorting|ListPagingAndSorting|Mongo|Reactive(?:Crud|Mongo|Sorting)|Elasticsearch|R2dbc|Neo4j|Cassandra)?Repository$/
+// Wrappers whose type argument is the injected bean type; collections take every match
+const SINGLE_WRAPPERS = new Set(['Optional', 'ObjectProvider', 'Provider', 'Lazy'])
+const COLLECTION_WRAPPERS = new Set(['List', 'Set', 'Collection', 'Iterable', 'Map'])
+
+const ANNOTATIONS = String.raw`((?:@[\w.]+\s*(?:\((?:[^()]|\([^()]*\))*\))?\s*)*)`
+const MODIFIERS = String.raw`((?:(?:public|protected|private|abstract|final|static|sealed|non-sealed|strictfp|default|synchronized|transient|volatile)\s+)*)`
+const TYPE_DECLARATION = new RegExp(String.raw`${ANNOTATIONS}${MODIFIERS}(class|interface|record|enum)\s+(\w+)([^{;]*)\{`, 'g')
+
+/**
+ * Maps the Spring beans of a project. `name` keeps only beans whose name or type contains it.
+ */
+export function mapSpringBeans(project: Project, name?: string): SpringBean[] {
+  const files = new Map<string, TreeNode>()
+  for (const node of getAllNodes(project)) {
+    if (node.type === 'file' && node.path.endsWith('.java') && !files.has(node.path)) files.set(node.path, node)
+  }
+
+  const filter = name?.toLowerCase()
+  return extractSpringBeans([...files.values()])
+    .filter(bean => !filter || bean.name.toLowerCase().includes(filter) || bean.type.toLowerCase().includes(filter))
+}
+
+/**
+ * Extracts beans from Java files and resolves their dependencies against each other, by type
+ * and the interfaces and superclasses each bean's class extends
+ */
+export function extractSpringBeans(fileNodes: TreeNode[]): SpringBean[] {
+  const beans: SpringBean[] = []
+  const supertypes = new Map<string, string[]>()
+
+  for (const fileNode of fileNodes) {
+    const content = fileNode.content
+    if (!content || !fileNode.path.endsWith('.java')) continue
+
+    const code = maskCommentsAndStrings(content)
+    const lineAt = (index: number) => content.substring(0, index).split('\n').length
+    const routes = extractRoutes([fileNode])
+
+    for (const declaration of readTypeDeclarations(content, code)) {
+      supertypes.set(declaration.name, declaration.supertypes)
+
+      const stereotype = declaration.annotations.map(annotation => annotation.name).find((annotation): annotation is SpringStereotype => STEREOTYPES.includes(annotation as SpringStereotype))
+        ?? (declaration.keyword === 'interface' && declaration.supertypes.some(type => DATA_REPOSITORIES.test(type)) ? 'Repository' : undefined)
+      const members = readMembers(content, code, declaration)
+
+      if (stereotype) {
+        const value = declaration.annotations.find(annotation => annotation.name === stereotype)?.args
+        const endpoints = routes
+          .filter(route => route.line > lineAt(declaration.start) && route.line <= lineAt(declaration.close))
+          .map(route => ({ method: route.method, path: route.path, line: route.line, handler: route.handler }))
+
+        beans.push({
+          name: annotationString(value ?? '', 'value') ?? decapitalize(declaration.name),
+          type: declaration.name,
+          stereotype,
+          path: fileNode.path,
+          line: lineAt(declaration.start),
+          ...(hasAnnotation(declaration.annotations, 'Primary') ? { primary: true } : {}),
+          dependencies: members.dependencies.map(({ offset, ...dependency }) => ({ ...dependency, line: lineAt(offset), beans: [] })),
+          ...(endpoints.length > 0 ? { endpoints } : {}),
+        })
+      }
+
+      for (const method of members.beanMethods) {
+        beans.push({
+          name: method.name,
+          type: method.type,
+          stereotype: 'Bean',
+          path: fileNode.path,
+          line: lineAt(method.offset),
+          ...(method.primary ? { primary: true } : {}),
+          declaredBy: declaration.name,
+          dependencies: method.parameters.map(parameter => ({
+            type: parameter.type,
+            name: parameter.name,
+            via: 'parameter' as const,
+            line: lineAt(parameter.offset),
+            ...qualifierOf(parameter.annotations),
+            beans: [],
+          })),
+        })
+      }
+    }
+  }
+
+  for (const bean of beans) {
+    for (const dependency of bean.dependencies) dependency.beans = resolveDependency(dependency, beans, supertypes)
+  }
+  return beans
+}
+
+/**
+ * Type declarations at any nesting level, with their annotations and direct supertypes
+ */
+function readTypeDeclarations(content: string, code: string): TypeDeclaration[] {
+  const declarations: TypeDeclaration[] = []
+  for (const match of code.matchAll(TYPE_DECLARATION)) {
+    const start = match.index! + match[0].search(/\S/)
+    const open = match.index! + match[0].length - 1
+    const close = findClosing(code, open)
+    if (close === -1) continue
+
+    const annotationsStart = match.index!
+    const header = match[5]!.replace(/<(?:[^<>]|<[^<>]*>)*>/g, '')
+    const supertypes = [...header.matchAll(/\b(?:extends|implements)\s+([\w.\s,]+?)(?=\s+(?:extends|implements|permits)\b|$)/g)]
+      .flatMap(clause => clause[1]!.split(',').map(type => type.trim().replace(/^.*\./, '')).filter(Boolean))
+
+    declarations.push({
+      name: match[4]!,
+      keyword: match[3]!,
+      annotations: readAnnotations(content.substring(annotationsStart, annotationsStart + match[1]!.length), code.substring(annotationsStart, annotationsStart + match[1]!.length)),
+      supertypes,
+      start,
+      header: open - match[5]!.length,
+      open,
+      close,
+    })
+  }
+  return declarations
+}
+
+/**
+ * The injection points and `@Bean` methods declared directly in a type's body
+ */
+function readMembers(content: string, code: string, declaration: TypeDeclaration) {
+  const dependencies: (Omit<SpringDependency, 'line' | 'beans'> & { offset: number })[] = []
+  const beanMethods: { name: string, type: string, offset: number, primary: boolean, parameters: Parameter[] }[] = []
+  const constructors: { annotations: Annotation[], parameters: Parameter[] }[] = []
+  const fields: { annotations: Annotation[], modifiers: string, type: string, name: string, offset: number, initialized: boolean }[] = []
+  const classAnnotations = new Set(declaration.annotations.map(annotation => annotation.name))
+
+  if (declaration.keyword === 'interface') return { dependencies, beanMethods }
+
+  for (const member of splitMembers(code, declaration.open + 1, declaration.close)) {
+    const text = code.substring(member.start, member.end)
+    const match = text.match(new RegExp(String.raw`^${ANNOTATIONS}${MODIFIERS}`))!
+    const annotations = readAnnotations(content.substring(member.start, member.start + match[1]!.length), match[1]!)
+    const modifiers = match[2]!
+    const rest = text.substring(match[0].length)
+    const restOffset = member.start + match[0].length
+
+    const constructor = rest.match(new RegExp(String.raw`^(?:<[^>]*>\s*)?${declaration.name}\s*\(`))
+    const method = rest.match(/^(?:<[^>]*>\s*)?([\w.]+(?:\s*<(?:[^<>]|<[^<>]*>)*>)?(?:\s*\[\s*\])*)\s+(\w+)\s*\(/)
+    const field = rest.match(/^([\w.]+(?:\s*<(?:[^<>]|<[^<>]*>)*>)?(?:\s*\[\s*\])*)\s+(\w+)\s*(=|;)/)
+
+    if (constructor) {
+      const open = restOffset + constructor[0].length - 1
+      constructors.push({ annotations, parameters: readParameters(content, code, open) })
+    }
+    else if (method) {
+      const open = restOffset + method[0].length - 1
+      const parameters = readParameters(content, code, open)
+      const bean = annotations.find(annotation => annotation.name === 'Bean')
+      if (bean) {
+        const names = annotationStrings(bean.args, 'name').concat(annotationStrings(bean.args, 'value'))
+        beanMethods.push({
+          name: names[0] ?? method[2]!,
+          type: method[1]!.replace(/\s+/g, ''),
+          offset: member.start,
+          primary: hasAnnotation(annotations, 'Primary'),
+          parameters,
+        })
+      }
+      else if (annotations.some(annotation => INJECTION_ANNOTATIONS.has(annotation.name)) && parameters.length === 1) {
+        const [parameter] = parameters
+        dependencies.push({ type: parameter!.type, name: parameter!.name, via: 'setter', offset: member.start, ...qualifierOf([...annotations, ...parameter!.annotations]) })
+      }
+    }
+    else if (field && !/\bstatic\b/.test(modifiers)) {
+      fields.push({ annotations, modifiers, type: field[1]!.replace(/\s+/g, ''), name: field[2]!, offset: member.start, initialized: field[3] === '=' })
+    }
+  }
+
+  for (const field of fields) {
+    if (field.annotations.some(annotation => INJECTION_ANNOTATIONS.has(annotation.name))) {
+      dependencies.push({ type: field.type, name: field.name, via: 'field', offset: field.offset, ...qualifierOf(field.annotations) })
+    }
+  }
+
+  // Spring injects through the constructor marked @Autowired, or the only one; Lombok generates it
+  const injecting = constructors.find(constructor => constructor.annotations.some(annotation => INJECTION_ANNOTATIONS.has(annotation.name)))
+    ?? (constructors.length === 1 ? constructors[0] : undefined)
+  if (injecting) {
+    for (const parameter of injecting.parameters) {
+      dependencies.push({ type: parameter.type, name: parameter.name, via: 'constructor', offset: parameter.offset, ...qualifierOf(parameter.annotations) })
+    }
+  }
+  else if (declaration.keyword === 'record') {
+    const open = code.indexOf('(', declaration.header)
+    if (open !== -1 && open < declaration.open) {
+      for (const parameter of readParameters(content, code, open)) {
+        dependencies.push({ type: parameter.type, name: parameter.name, via: 'constructor', offset: parameter.offset, ...qualifierOf(parameter.annotations) })
+      }
+    }
+  }
+  else if (classAnnotations.has('RequiredArgsConstructor') || classAnnotations.has('AllArgsConstructor')) {
+    const all = classAnnotations.has('AllArgsConstructor')
+    for (const field of fields) {
+      if (field.initialized || (!all && !/\bfinal\b/.test(field.modifiers))) continue
+      if (dependencies.some(dependency => dependency.name === field.name)) continue
+      dependencies.push({ type: field.type, name: field.name, via: 'constructor', offset: field.offset, ...qualifierOf(field.annotations) })
+    }
+  }
+
+  return { dependencies, beanMethods }
+}
+
+function resolveDependency(dependency: SpringDependency, beans: SpringBean[], supertypes: Map<string, string[]>): string[] {
+  const { type, collection } = injectedType(dependency.type)
+  const candidates = beans.filter(bean => assignableTo(bean.type.replace(/<.*$/, '').replace(/^.*\./, ''), type, supertypes))
+
+  if (dependency.qualifier) return candidates.filter(bean => bean.name === dependency.qualifier).map(bean => bean.name)
+  if (collection || candidates.length <= 1) return candidates.map(bean => bean.name)
+
+  // Several candidates for one bean: @Primary wins, then the one named like the field or parameter
+  const chosen = candidates.find(bean => bean.primary) ?? candidates.find(bean => bean.name === dependency.name)
+  return (chosen ? [chosen] : candidates).map(bean => bean.name)
+}
+
+function injectedType(declared: string): { type: string, collection: boolean } {
+  const match = declared.match(/^([\w.]+)<(.*)>$/)
+  const wrapper = match?.[1]!.replace(/^.*\./, '')
+  if (match && wrapper && (SINGLE_WRAPPERS.has(wrapper) || COLLECTION_WRAPPERS.has(wrapper))) {
+    // Map<String, T> injects beans by name; the value type is the bean type
+    const argument = wrapper === 'Map' ? match[2]!.substring(match[2]!.indexOf(',') + 1) : match[2]!
+    return { type: injectedType(argument.trim()).type, collection: COLLECTION_WRAPPERS.has(wrapper) }
+  }
+  if (declared.endsWith('[]')) return { type: injectedType(declared.slice(0, -2)).type, collection: true }
+  return { type: declared.replace(/<.*$/, '').replace(/^.*\./, ''), collection: false }
+}
+
+function assignableTo(type: string, target: string, supertypes: Map<string, string[]>, seen = new Set<string>()): boolean {
+  if (type === target) return true
+  if (seen.has(type)) return false
+  seen.add(type)
+  return (supertypes.get(type) ?? []).some(supertype => assignableTo(supertype, target, supertypes, seen))
+}
+
+/**
+ * Parameters of the list whose `(` is at `open`
+ */
+function readParameters(content: string, code: string, open: number): Parameter[] {
+  const close = findClosing(code, open)
+  if (close === -1) return []
+
+  const parameters: Parameter[] = []
+  let start = open + 1
+  for (const end of [...topLevelCommas(code, open + 1, close), close]) {
+    const text = code.substring(start, end)
+    const match = text.match(new RegExp(String.raw`^\s*${ANNOTATIONS}(?:final\s+)?([\w.]+(?:\s*<(?:[^<>]|<[^<>]*>)*>)?(?:\s*\[\s*\]|\.\.\.)*)\s+(\w+)\s*$`))
+    if (match) {
+      const annotationsStart = start + text.search(/\S/)
+      const annotations = readAnnotations(content.substring(annotationsStart, annotationsStart + match[1]!.length), match[1]!)
+      // @Value parameters are configuration values, not beans
+      if (!hasAnnotation(annotations, 'Value')) {
+        parameters.push({ type: match[2]!.replace(/\s+/g, '').replace(/\.\.\.$/, '[]'), name: match[3]!, offset: annotationsStart, annotations })
+      }
+    }
+    start = end + 1
+  }
+  return parameters
+}
+
+/**
+ * Member declarations of a type body between `start` and `end`: each runs to its `;` or
+ * through its braced body. Nested types are read by readTypeDeclarations on their own.
+ */
+function splitMembers(code: string, start: number, end: number): { start: number, end: number }[] {
+  const members: { start: number, end: number }[] = []
+  let memberStart = -1
+  for (let i = start; i < end; i++) {
+    const char = code[i]!
+    if (memberStart === -1) {
+      if (/\s|;/.test(char)) continue
+      memberStart = i
+    }
+    if (char === '(' || char === '[') {
+      i = findClosing(code, i)
+      if (i === -1) break
+    }
+    else if (char === '{') {
+      const close = findClosing(code, i)
+      if (close === -1) break
+      members.push({ start: memberStart, end: close + 1 })
+      memberStart = -1
+      i = close
+    }
+    else if (char === ';') {
+      members.push({ start: memberStart, end: i + 1 })
+      memberStart = -1
+    }
+  }
+  return members
+}
+
+function readAnnotations(content: string, code: string): Annotation[] {
+  return [...code.matchAll(/@([\w.]+)\s*(\((?:[^()]|\([^()]*\))*\))?/g)].map(match => ({
+    name: match[1]!.replace(/^.*\./, ''),
+    args: match[2] ? content.substring(match.index! + match[0].length - match[2].length + 1, match.index! + match[0].length - 1) : '',
+  }))
+}
+
+function qualifierOf(annotations: Annotation[]): { qualifier?: string } {
+  const qualifier = annotations.find(annotation => annotation.name === 'Qualifier' || annotation.name === 'Named')
+  const resource = annotations.find(annotation => annotation.name === 'Resource')
+  const name = qualifier ? annotationString(qualifier.args, 'value') : resource ? annotationString(resource.args, 'name') : undefined
+  return name ? { qualifier: name } : {}
+}
+
+function hasAnnotation(annotations: Annotation[], name: string): boolean {
+  return annotations.some(annotation => annotation.name === name)
+}
+
+/**
+ * A string attribute of an annotation; `value` may also be given positionally
+ */
+function annotationString(args: string, attribute: string): string | undefined {
+  return annotationStrings(args, attribute)[0]
+}
+
+function annotationStrings(args: string, attribute: string): string[] {
+  const named = args.match(new RegExp(String.raw`\b${attribute}\s*=\s*(\{[^}]*\}|"[^"]*")`))?.[1]
+  const positional = attribute === 'value' && !/^\s*\w+\s*=/.test(args) ? args.match(/^\s*(\{[^}]*\}|"[^"]*")/)?.[1] : undefined
+  const values = named ?? positional
+  return values ? [...values.matchAll(/"([^"]*)"/g)].map(match => match[1]!) : []
+}
+
+/**
+ * The default bean name: the class name with its first letter lower-cased, unless the first two
+ * letters are both upper case, as `java.beans.Introspector.decapitalize` does
+ */
+function decapitalize(name: string): string {
+  return /^[A-Z]{2}/.test(name) ? name : name.charAt(0).toLowerCase() + name.substring(1)
+}
+
+function topLevelCommas(code: string, start: number, end: number): number[] {
+  const commas: number[] = []
+  let depth = 0
+  for (let i = start; i < end; i++) {
+    const char = code[i]!
+    if ('([{<'.includes(char)) depth++
+    else if (')]}>'.includes(char)) depth--
+    else if (char === ',' && depth === 0) commas.push(i)
+  }
+  return commas
+}
+
+function findClosing(code: string, open: number): number {
+  let depth = 0
+  for (let i = open; i < code.length; i++) {
+    if ('([{'.includes(code[i]!)) depth++
+    else if (')]}'.includes(code[i]!) && --depth === 0) return i
+  }
+  return -1
+}
+
+/**
+ * Blanks comments and the inside of string and character literals, keeping offsets
+ */
+function maskCommentsAndStrings(content: string): string {
+  return content.replace(
+    /\/\/[^\n]*|\/\*[\s\S]*?\*\/|"""[\s\S]*?"""|"(?:\\.|[^"\\\n])*"|'(?:\\.|[^'\\\n])+'/g,
+    (match) => {
+      if (match.startsWith('/')) return match.replace(/[^\n]/g, ' ')
+      const quote = match.startsWith('"""') ? '"""' : match[0]!
+      return quote + match.substring(quote.length, match.length - quote.length).replace(/[^\n]/g, ' ') + quote
+    },
+  )
+}
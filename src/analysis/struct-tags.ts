@@ -0,0 +1,276 @@
+/**
+ * Go struct tags - the fields of Go structs with their tags parsed the way reflect.StructTag
+ * reads them, to find structs tagged with a key, the field serialized under a name, and tags
+ * that are malformed or give two fields the same name
+ */
+
+import { getAllNodes } from '../project/manager.js'
+import type { Project, TreeNode } from '../types/core.js'
+
+export interface TagValue {
+  name: string // Serialized name; the field's default when the tag leaves it empty
+  options: string[] // After the name, e.g. `omitempty`, `string`
+  skipped?: boolean // `-`: the field is not serialized under this key
+}
+
+export interface StructField {
+  name: string
+  type: string
+  line: number
+  embedded?: boolean
+  tag?: string // Raw tag text, without quotes
+  tags: Record<string, TagValue> // By key, e.g. `json`, `db`, `yaml`
+  tagError?: string // Why the tag does not parse as key:"value" pairs
+}
+
+export interface GoStruct {
+  name: string
+  file: string
+  line: number
+  endLine: number
+  fields: StructField[]
+}
+
+export interface TagProblem {
+  kind: 'malformed' | 'duplicate'
+  struct: string
+  file: string
+  line: number
+  fields: string[]
+  key?: string
+  name?: string // Serialized name two fields share
+  message: string
+}
+
+export interface FieldMatch {
+  struct: string
+  file: string
+  field: string
+  type: string
+  line: number
+  key: string
+  name: string
+  tagged: boolean // False when the field is serialized under its Go name for lack of a tag
+  exact: boolean // False for a case-insensitive match, which encoding/json also decodes
+}
+
+// Keys whose libraries default an untagged field to its lower-cased name
+const LOWERCASE_DEFAULT_KEYS = new Set(['yaml', 'db', 'bson'])
+// Keys whose libraries serialize untagged exported fields under the field name
+const FIELD_NAME_DEFAULT_KEYS = new Set(['json', 'xml', 'toml', 'yaml', 'db', 'bson', 'mapstructure', 'msgpack'])
+
+const STRUCT_START = /^\s*(?:type\s+)?([A-Za-z_]\w*)(?:\[[^\]]*\])?\s+struct\s*\{\s*(?:\/\/.*)?$/
+
+/**
+ * The structs of every Go file in the project
+ */
+export function listGoStructs(project: Project): GoStruct[] {
+  return getAllNodes(project)
+    .filter((node): node is TreeNode & { content: string } => node.type === 'file' && node.path.endsWith('.go') && Boolean(node.content))
+    .flatMap(node => parseGoStructs(node.content, node.path))
+}
+
+/**
+ * Reads the struct declarations of a Go file. Fields of nested anonymous structs are not
+ * listed; the field holding one is, with the tag after its closing brace.
+ */
+export function parseGoStructs(content: string, file: string): GoStruct[] {
+  const lines = content.split('\n')
+  const structs: GoStruct[] = []
+
+  for (let i = 0; i < lines.length; i++) {
+    const start = STRUCT_START.exec(lines[i]!)
+    if (!start) continue
+
+    const struct: GoStruct = { name: start[1]!, file, line: i + 1, endLine: i + 1, fields: [] }
+    let depth = 1
+    let nested: StructField | undefined
+    let end = i + 1
+    for (; end < lines.length; end++) {
+      const text = stripComment(lines[end]!).trim()
+      const before = depth
+      depth += countOutsideTags(text, '{') - countOutsideTags(text, '}')
+      if (depth <= 0) break
+
+      if (before > 1) {
+        // The closing brace of a nested struct carries the tag of the field holding it
+        if (depth === 1 && nested) {
+          const tag = /^\}\s*(`[^`]*`|"(?:[^"\\]|\\.)*")?/.exec(text)?.[1]
+          if (tag) Object.assign(nested, tagFields(tag))
+          nested = undefined
+        }
+        continue
+      }
+      if (!text) continue
+
+      const field = parseFieldLine(text, end + 1)
+      struct.fields.push(...field)
+      if (depth > 1) nested = field[field.length - 1]
+    }
+
+    struct.endLine = Math.min(end + 1, lines.length)
+    structs.push(struct)
+    i = end
+  }
+
+  return structs
+}
+
+/**
+ * Structs with at least one field tagged with `key`, or with any tag when no key is given
+ */
+export function structsTaggedWith(structs: GoStruct[], key?: string): GoStruct[] {
+  return structs.filter(struct => struct.fields.some(field => key ? field.tags[key] : field.tag !== undefined))
+}
+
+/**
+ * The fields serialized under `name` for `key`: by their tag, or by their Go name when they have
+ * none. encoding/json also matches names case-insensitively when decoding, so those are listed
+ * after the exact matches.
+ */
+export function findSerializedField(structs: GoStruct[], name: string, key = 'json'): FieldMatch[] {
+  const matches: FieldMatch[] = []
+  for (const struct of structs) {
+    for (const field of struct.fields) {
+      const tag = field.tags[key]
+      if (tag?.skipped) continue
+      if (!tag && (!FIELD_NAME_DEFAULT_KEYS.has(key) || field.embedded || !/^[A-Z]/.test(field.name))) continue
+
+      const serialized = tag?.name ?? defaultName(field.name, key)
+      const exact = serialized === name
+      if (!exact && !(key === 'json' && serialized.toLowerCase() === name.toLowerCase())) continue
+      matches.push({ struct: struct.name, file: struct.file, field: field.name, type: field.type, line: field.line, key, name: serialized, tagged: Boolean(tag), exact })
+    }
+  }
+  return matches.sort((a, b) => Number(b.exact) - Number(a.exact) || Number(b.tagged) - Number(a.tagged))
+}
+
+/**
+ * Tags that do not parse, repeat a key, or give two fields of a struct the same name under a key
+ */
+export function findTagProblems(structs: GoStruct[]): TagProblem[] {
+  const problems: TagProblem[] = []
+  for (const struct of structs) {
+    const names = new Map<string, StructField[]>()
+    for (const field of struct.fields) {
+      if (field.tagError) {
+        problems.push({ kind: 'malformed', struct: struct.name, file: struct.file, line: field.line, fields: [field.name], message: field.tagError })
+      }
+      for (const [key, tag] of Object.entries(field.tags)) {
+        if (tag.skipped) continue
+        const id = `${key}\0${tag.name}`
+        names.set(id, [...(names.get(id) ?? []), field])
+      }
+    }
+
+    for (const [id, fields] of names) {
+      if (fields.length < 2) continue
+      const [key, name] = id.split('\0') as [string, string]
+      problems.push({
+        kind: 'duplicate',
+        struct: struct.name,
+        file: struct.file,
+        line: fields[1]!.line,
+        fields: fields.map(field => field.name),
+        key,
+        name,
+        message: `${fields.map(field => field.name).join(' and ')} are both serialized as ${key} "${name}"`,
+      })
+    }
+  }
+  return problems.sort((a, b) => a.file.localeCompare(b.file) || a.line - b.line)
+}
+
+/**
+ * The fields a struct body line declares: `A, B int`, `Name string \`json:"name"\`` or an
+ * embedded `*pkg.Type`
+ */
+function parseFieldLine(text: string, line: number): StructField[] {
+  const tagMatch = /(`[^`]*`|"(?:[^"\\]|\\.)*")\s*$/.exec(text)
+  const declaration = (tagMatch ? text.substring(0, tagMatch.index) : text).trim()
+  const tag = tagMatch ? tagFields(tagMatch[1]!) : { tags: {} }
+
+  const embedded = /^\*?([\w.]+)(?:\[[^\]]*\])?$/.exec(declaration)
+  if (embedded) {
+    const name = embedded[1]!.split('.').pop()!
+    return [{ name, type: declaration, line, embedded: true, ...tag, tags: withDefaultNames(tag.tags, name) }]
+  }
+
+  const field = /^([A-Za-z_]\w*(?:\s*,\s*[A-Za-z_]\w*)*)\s+(.+)$/.exec(declaration)
+  if (!field) return []
+  const type = field[2]!.replace(/\{$/, '').trim()
+  return field[1]!.split(',').map(name => name.trim()).map(name => ({ name, type, line, ...tag, tags: withDefaultNames(tag.tags, name) }))
+}
+
+/**
+ * The tags of one field, an empty name replaced by the field's default
+ */
+function withDefaultNames(tags: Record<string, TagValue>, field: string): Record<string, TagValue> {
+  return Object.fromEntries(Object.entries(tags).map(([key, tag]) => [key, tag.name === '' ? { ...tag, name: defaultName(field, key) } : tag]))
+}
+
+/**
+ * A tag literal read into its key:"value" pairs as reflect.StructTag does, with an error for
+ * text that breaks the convention (go vet's structtag check)
+ */
+function tagFields(literal: string): Pick<StructField, 'tag' | 'tags' | 'tagError'> {
+  const tag = literal.startsWith('`') ? literal.slice(1, -1) : unquote(literal)
+  const tags: Record<string, TagValue> = {}
+  if (tag === undefined) return { tag: literal, tags, tagError: 'tag is not a valid string literal' }
+
+  let rest = tag
+  while (rest !== '') {
+    const spaces = /^ */.exec(rest)![0].length
+    rest = rest.substring(spaces)
+    if (rest === '') break
+
+    const key = /^[^\s:"\x00-\x1f\x7f]+/.exec(rest)?.[0]
+    if (!key || rest[key.length] !== ':' || rest[key.length + 1] !== '"') {
+      return { tag, tags, tagError: `bad syntax for struct tag pair at "${rest.substring(0, 20)}"` }
+    }
+    rest = rest.substring(key.length + 1)
+
+    const value = /^"(?:[^"\\]|\\.)*"/.exec(rest)?.[0]
+    const unquoted = value === undefined ? undefined : unquote(value)
+    if (unquoted === undefined) {
+      return { tag, tags, tagError: `bad syntax for struct tag value of ${key}` }
+    }
+    rest = rest.substring(value!.length)
+    if (rest !== '' && !rest.startsWith(' ')) {
+      return { tag, tags, tagError: `key:"value" pairs not separated by spaces after ${key}` }
+    }
+    if (tags[key]) {
+      return { tag, tags, tagError: `tag repeats the ${key} key` }
+    }
+
+    const [name = '', ...options] = unquoted.split(',')
+    tags[key] = name === '-' && options.length === 0 ? { name, options, skipped: true } : { name, options }
+  }
+  return { tag, tags }
+}
+
+/**
+ * The name a field is serialized under when its tag does not give one
+ */
+function defaultName(field: string, key: string): string {
+  return LOWERCASE_DEFAULT_KEYS.has(key) ? field.toLowerCase() : field
+}
+
+function unquote(literal: string): string | undefined {
+  try {
+    const value = JSON.parse(literal)
+    return typeof value === 'string' ? value : undefined
+  }
+  catch {
+    return undefined
+  }
+}
+
+function stripComment(line: string): string {
+  const comment = /\/\/(?=[^`"]*$)/.exec(line)
+  return comment ? line.substring(0, comment.index) : line
+}
+
+function countOutsideTags(text: string, char: string): number {
+  return text.replace(/`[^`]*`|"(?:[^"\\]|\\.)*"/g, '').split(char).length - 1
+}
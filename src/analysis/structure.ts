@@ -1,10 +1,12 @@
 /**
- * Structure analysis - analyzes dependencies, coupling, and HTML nesting
+ * Structure analysis - analyzes dependencies, coupling, HTML nesting, and container build inputs
  */
 
 import { extractImports } from '../import/resolver.js'
+import { findMissingCopySources } from './docker.js'
+import { expandPathAlias } from '../project/path-aliases.js'
 import { MARKUP_EXTENSIONS, FRAMEWORK_EXTENSIONS, HTML_TAGS, NESTING_THRESHOLD, TEMPLATE_PATTERNS } from '../constants/index.js'
-import type { TreeNode } from '../types/core.js'
+import type { PathAlias, TreeNode } from '../types/core.js'
 import type { Finding, StructureMetrics } from '../types/analysis.js'
 
 export interface StructureAnalysisResult {
@@ -12,7 +14,7 @@ export interface StructureAnalysisResult {
   findings: Finding[]
 }
 
-export function analyzeStructure(nodes: TreeNode[]): StructureAnalysisResult {
+export function analyzeStructure(nodes: TreeNode[], pathAliases: PathAlias[] = []): StructureAnalysisResult {
   const fileNodes = nodes.filter(node => node.type === 'file')
 
   if (fileNodes.length === 0) {
@@ -29,7 +31,7 @@ export function analyzeStructure(nodes: TreeNode[]): StructureAnalysisResult {
     }
   }
 
-  const dependencyGraph = buildDependencyGraph(fileNodes)
+  const dependencyGraph = buildDependencyGraph(fileNodes, pathAliases)
   const circularDeps = findCircularDependencies(dependencyGraph)
   const highCouplingFiles = findHighCouplingFiles(dependencyGraph)
   const htmlAnalysis = analyzeHtmlStructure(fileNodes)
@@ -43,12 +45,15 @@ export function analyzeStructure(nodes: TreeNode[]): StructureAnalysisResult {
     maxNestingDepth: htmlAnalysis.maxNestingDepth,
   }
 
-  const findings = generateStructureFindings(circularDeps, highCouplingFiles, htmlAnalysis)
+  const findings = [
+    ...generateStructureFindings(circularDeps, highCouplingFiles, htmlAnalysis),
+    ...findMissingCopySources(fileNodes),
+  ]
 
   return { metrics, findings }
 }
 
-function buildDependencyGraph(fileNodes: TreeNode[]): Map<string, string[]> {
+function buildDependencyGraph(fileNodes: TreeNode[], pathAliases: PathAlias[]): Map<string, string[]> {
   const dependencies = new Map<string, string[]>()
 
   for (const fileNode of fileNodes) {
@@ -59,7 +64,7 @@ function buildDependencyGraph(fileNodes: TreeNode[]): Map<string, string[]> {
       const imports = extractImports(fileNode.content)
 
       for (const importPath of imports) {
-        const resolved = resolveImportPath(importPath, filePath, fileNodes)
+        const resolved = resolveImportPath(importPath, filePath, fileNodes, pathAliases)
         if (resolved) {
           deps.push(resolved)
         }
@@ -72,8 +77,12 @@ function buildDependencyGraph(fileNodes: TreeNode[]): Map<string, string[]> {
   return dependencies
 }
 
-function resolveImportPath(importPath: string, currentFile: string, fileNodes: TreeNode[]): string | null {
+function resolveImportPath(importPath: string, currentFile: string, fileNodes: TreeNode[], pathAliases: PathAlias[]): string | null {
   if (!importPath.startsWith('.') && !importPath.startsWith('/')) {
+    for (const base of expandPathAlias(importPath, currentFile, pathAliases)) {
+      const resolved = findFileNode(base, fileNodes)
+      if (resolved) return resolved
+    }
     return null
   }
 
@@ -99,10 +108,14 @@ function resolveImportPath(importPath: string, currentFile: string, fileNodes: T
     resolved = importPath
   }
 
+  return findFileNode(resolved, fileNodes)
+}
+
+function findFileNode(base: string, fileNodes: TreeNode[]): string | null {
   const extensions = ['', '.js', '.ts', '.jsx', '.tsx', '.mjs', '.cjs', '/index.js', '/index.ts']
 
   for (const ext of extensions) {
-    const candidate = resolved + ext
+    const candidate = base + ext
     if (fileNodes.some(node => node.path === candidate)) {
       return candidate
     }
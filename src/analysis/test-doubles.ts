@@ -0,0 +1,470 @@
+/**
+ * Test doubles - the interfaces a type is used through and the mocks and fakes the project
+ * already has for them: gomock and testify mocks, Jest and Vitest module mocks and typed mock
+ * factories, unittest.mock specs and patches, and hand-written fakes and stubs, so new tests
+ * can reuse a double instead of writing another
+ */
+
+import { readFileSync } from 'fs'
+import { basename, dirname, extname, relative, resolve } from 'path'
+import { getLanguageForFile } from '../core/languages.js'
+import { lineAt, maskGo, matchingBrace, matchingParen } from '../core/go-source.js'
+import { maskCommentsAndStrings } from '../core/python-dynamic.js'
+import { maskSource } from '../core/strings.js'
+import { findTestFiles, isTestPath } from '../project/test-files.js'
+import { PARSER_NAMES, escapeRegExp } from '../constants/index.js'
+import type { Project } from '../types/core.js'
+
+export type DoubleKind = 'gomock' | 'testify' | 'jest' | 'vitest' | 'unittest.mock' | 'fake'
+export type InterfaceLink = 'self' | 'declared' | 'assertion' | 'structural'
+
+export interface SourceFile {
+  path: string
+  content: string
+}
+
+export interface BoundaryConsumer {
+  function: string
+  file: string
+  line: number
+}
+
+export interface BoundaryInterface {
+  name: string
+  file: string
+  line: number
+  via: InterfaceLink // The type itself, `implements`/a base class, `var _ I = (*T)(nil)`, or by method set
+  methods: string[]
+  consumers: BoundaryConsumer[] // Functions taking the interface as a parameter
+  consumerCount: number
+}
+
+export interface TestDouble {
+  kind: DoubleKind
+  of: string // The type or interface it stands in for
+  name?: string // Declared double, or the variable holding it
+  usage?: string // How a framework double is created, e.g. `mock<UserStore>()`
+  constructor?: string
+  file: string
+  line: number
+}
+
+export interface TestBoundaries {
+  type: { name: string, kind: Declaration['kind'], file: string, line: number, methods: string[] }
+  interfaces: BoundaryInterface[]
+  doubles: TestDouble[]
+}
+
+type Family = 'go' | 'js' | 'python'
+
+interface Declaration {
+  name: string
+  kind: 'interface' | 'struct' | 'class'
+  family: Family
+  file: string
+  line: number
+  methods: Set<string>
+  bases: string[] // Implemented and extended types, Python base classes, Go embedded fields
+  gomockOf?: string // From the `MockX is a mock of X interface` comment mockgen writes
+}
+
+interface ScannedFile extends SourceFile {
+  family: Family
+  code: string // Comments and strings blanked
+}
+
+const FAKE_NAME = /^(?:Fake|Stub|Mock|InMemory|Dummy|Spy|Noop|Nop)(?=[A-Z_]|$)|^(?:fake|stub|mock|inMemory|dummy|spy|noop)(?=[A-Z_])|(?:Fake|Stub|Mock|Double|Spy)$/
+const PROTOCOL_BASES = /^(?:typing\.)?Protocol\b|^(?:abc\.)?ABC$/
+const KEYWORDS = new Set(['func', 'function', 'if', 'for', 'while', 'switch', 'catch', 'return', 'def', 'with', 'elif', 'lambda'])
+const MAX_SCANNED_TESTS = 500
+const MAX_CONSUMERS = 10
+
+/**
+ * Finds the interface boundaries and test doubles of a type, by name or `path#name`, in the
+ * indexed files and the project's tests
+ */
+export function findTestDoubles(project: Project, target: string): TestBoundaries {
+  const root = project.config.directory
+  const files: SourceFile[] = []
+  for (const [path, node] of project.files) {
+    if (familyOf(path)) files.push({ path, content: node.content ?? readFileSync(path, 'utf-8') })
+  }
+  const indexed = new Set(project.files.keys())
+  for (const path of findTestFiles(root, path => familyOf(path) !== undefined && isTestPath(path), MAX_SCANNED_TESTS)) {
+    if (!indexed.has(path)) files.push({ path, content: readFileSync(path, 'utf-8') })
+  }
+  return findTestBoundaries(files, root, target)
+}
+
+export function findTestBoundaries(sources: SourceFile[], root: string, target: string): TestBoundaries {
+  const files = sources.flatMap((file): ScannedFile[] => {
+    const family = familyOf(file.path)
+    if (!family) return []
+    const code = family === 'go' ? maskGo(file.content) : family === 'python' ? maskCommentsAndStrings(file.content) : maskSource(file.content, file.path)
+    return [{ ...file, family, code }]
+  })
+  const declarations = files.flatMap(readDeclarations)
+  attachGoMethods(declarations, files)
+  const type = resolveType(declarations, root, target)
+  const family = files.filter(file => file.family === type.family)
+  const candidates = declarations.filter(declaration => declaration.family === type.family)
+  const interfaces = interfacesOf(type, candidates, family)
+
+  for (const boundary of interfaces) {
+    const consumers = findConsumers(boundary.name, family)
+    boundary.consumerCount = consumers.length
+    boundary.consumers = consumers.slice(0, MAX_CONSUMERS).map(consumer => ({ ...consumer, file: toRelative(root, consumer.file) }))
+  }
+
+  const doubles = findDoubles(type, interfaces, candidates, family, root)
+    .map(double => ({ ...double, file: toRelative(root, double.file) }))
+    .sort((a, b) => a.file.localeCompare(b.file) || a.line - b.line)
+
+  return {
+    type: { name: type.name, kind: type.kind, file: toRelative(root, type.file), line: type.line, methods: [...type.methods].sort() },
+    interfaces: interfaces.map(boundary => ({ ...boundary, file: toRelative(root, boundary.file) })),
+    doubles,
+  }
+}
+
+function resolveType(declarations: Declaration[], root: string, target: string): Declaration {
+  const [path, name] = target.includes('#') ? target.split('#') as [string, string] : [undefined, target]
+  const matches = declarations.filter(declaration => declaration.name === name && (!path || toRelative(root, declaration.file) === path))
+  if (matches.length === 0) throw new Error(`Unknown type: ${target}`)
+
+  // Doubles in tests can reuse the name of what they replace
+  const production = matches.filter(declaration => !isTestPath(toRelative(root, declaration.file)))
+  const chosen = production.length > 0 ? production : matches
+  if (chosen.length > 1) {
+    throw new Error(`Ambiguous type ${target}; pass one of: ${chosen.map(declaration => `${toRelative(root, declaration.file)}#${declaration.name}`).join(', ')}`)
+  }
+  return chosen[0]!
+}
+
+function interfacesOf(type: Declaration, declarations: Declaration[], files: ScannedFile[]): BoundaryInterface[] {
+  const found = new Map<string, BoundaryInterface>()
+  const add = (declaration: Declaration, via: InterfaceLink) => {
+    if (found.has(declaration.name)) return
+    found.set(declaration.name, { name: declaration.name, file: declaration.file, line: declaration.line, via, methods: [...declaration.methods].sort(), consumers: [], consumerCount: 0 })
+  }
+  const interfaces = declarations.filter(declaration => declaration.kind === 'interface' && declaration !== type)
+
+  if (type.kind === 'interface') {
+    add(type, 'self')
+    return [...found.values()]
+  }
+
+  for (const base of type.bases) {
+    const declared = interfaces.find(declaration => declaration.name === unqualified(base))
+    if (declared) add(declared, 'declared')
+  }
+  if (type.family === 'go') {
+    for (const assertion of goAssertions(files)) {
+      if (assertion.type !== type.name) continue
+      const declared = interfaces.find(declaration => declaration.name === assertion.interface)
+      if (declared) add(declared, 'assertion')
+    }
+  }
+  for (const declaration of interfaces) {
+    // Python only matches protocols structurally; an ABC has to be subclassed
+    if (type.family === 'python' && !declaration.bases.some(base => /Protocol\b/.test(base))) continue
+    if (satisfies(type, declaration)) add(declaration, 'structural')
+  }
+  return [...found.values()]
+}
+
+function satisfies(type: Declaration, contract: Declaration): boolean {
+  return contract.methods.size > 0 && [...contract.methods].every(method => type.methods.has(method))
+}
+
+/**
+ * Mocks and fakes standing in for the type or one of its interfaces
+ */
+function findDoubles(type: Declaration, interfaces: BoundaryInterface[], declarations: Declaration[], files: ScannedFile[], root: string): TestDouble[] {
+  const targets = new Set([type.name, ...interfaces.map(boundary => boundary.name)])
+  const contracts = [type, ...declarations.filter(declaration => interfaces.some(boundary => boundary.name === declaration.name && boundary.file === declaration.file))]
+  const constructors = type.family === 'go' ? goConstructors(files) : new Map<string, string>()
+  const assertions = type.family === 'go' ? goAssertions(files) : []
+  const doubles: TestDouble[] = []
+
+  // What a declared double stands in for: what it says it mocks, extends or implements, or whose
+  // methods it has
+  const standsFor = (declaration: Declaration): string | undefined => {
+    if (declaration.gomockOf) return targets.has(declaration.gomockOf) ? declaration.gomockOf : undefined
+    const named = declaration.name.replace(FAKE_NAME, '')
+    const declared = [...declaration.bases.map(unqualified), ...assertions.filter(assertion => assertion.type === declaration.name).map(assertion => assertion.interface)]
+    return declared.find(name => targets.has(name))
+      ?? (targets.has(named) ? named : undefined)
+      ?? contracts.find(contract => contract.kind === 'interface' && satisfies(declaration, contract))?.name
+  }
+
+  for (const declaration of declarations) {
+    if (declaration === type || declaration.kind === 'interface') continue
+    const kind: DoubleKind | undefined = declaration.gomockOf
+      ? 'gomock'
+      : declaration.bases.includes('mock.Mock')
+        ? 'testify'
+        : FAKE_NAME.test(declaration.name) ? 'fake' : undefined
+    if (!kind) continue
+    const of = standsFor(declaration)
+    if (!of) continue
+    const constructor = constructors.get(declaration.name)
+    doubles.push({ kind, of, name: declaration.name, ...(constructor ? { constructor } : {}), file: declaration.file, line: declaration.line })
+  }
+
+  if (type.family === 'js') doubles.push(...jsDoubles(type, targets, files, root))
+  if (type.family === 'python') doubles.push(...pythonDoubles(targets, files))
+  return doubles
+}
+
+function jsDoubles(type: Declaration, targets: Set<string>, files: ScannedFile[], root: string): TestDouble[] {
+  const doubles: TestDouble[] = []
+  const typeModule = withoutExtension(type.file)
+  const names = [...targets].map(escapeRegExp).join('|')
+  const patterns: RegExp[] = [
+    new RegExp(`\\b(?:mock|mockDeep|createMock|vi\\.mocked|jest\\.mocked)\\s*(?:<\\s*(?:typeof\\s+)?(${names})\\s*>\\s*\\(|\\(\\s*(${names})\\b)`, 'g'),
+    new RegExp(`\\b(?:jest\\.)?(?:Mocked|MockedObject|MockProxy|DeepMockProxy|DeepMocked|MockedClass)\\s*<\\s*(?:typeof\\s+)?(${names})\\s*>`, 'g'),
+    new RegExp(`\\b(?:jest|vi)\\.spyOn\\(\\s*(${names})\\.prototype\\b`, 'g'),
+  ]
+
+  for (const file of files) {
+    const kind: DoubleKind = /\bvi\.|from\s+['"]vitest['"]/.test(file.content) ? 'vitest' : 'jest'
+    const at = (index: number) => lineAt(file.code, index)
+
+    if (basename(dirname(file.path)) === '__mocks__' && withoutExtension(resolve(dirname(file.path), '..', basename(file.path))) === typeModule) {
+      doubles.push({ kind, of: type.name, usage: `manual mock ${toRelative(root, file.path)}`, file: file.path, line: 1 })
+    }
+
+    for (const call of file.content.matchAll(/\b(jest|vi)\.(?:mock|doMock)\(\s*(['"`])([^'"`]+)\2/g)) {
+      if (file.code[call.index] === ' ') continue // In a comment
+      const specifier = call[3]!
+      if (!specifier.startsWith('.') || withoutExtension(resolve(dirname(file.path), specifier)) !== typeModule) continue
+      doubles.push({ kind: call[1] === 'vi' ? 'vitest' : 'jest', of: type.name, usage: `${call[1]}.mock('${specifier}')`, file: file.path, line: at(call.index) })
+    }
+
+    for (const pattern of patterns) {
+      for (const match of file.code.matchAll(pattern)) {
+        const held = /\b(?:const|let|var|private|readonly)\s+(\w+)\s*(?::[^=;\n]*)?=?\s*$/.exec(file.code.substring(file.code.lastIndexOf('\n', match.index) + 1, match.index))?.[1]
+        doubles.push({ kind, of: (match[1] ?? match[2])!, ...(held ? { name: held } : {}), usage: closeCall(match[0]), file: file.path, line: at(match.index) })
+      }
+    }
+
+    // Hand-written object literals typed as the interface
+    for (const literal of file.code.matchAll(new RegExp(`\\b(?:const|let|var)\\s+(\\w+)\\s*:\\s*(${names})\\s*=\\s*\\{`, 'g'))) {
+      if (FAKE_NAME.test(literal[1]!)) doubles.push({ kind: 'fake', of: literal[2]!, name: literal[1], file: file.path, line: at(literal.index) })
+    }
+  }
+  return doubles
+}
+
+function pythonDoubles(targets: Set<string>, files: ScannedFile[]): TestDouble[] {
+  const doubles: TestDouble[] = []
+  const patterns = [
+    /\bcreate_autospec\(\s*([\w.]+)/g,
+    /\b(?:Magic|Async|NonCallable)?Mock\((?:[^()]|\([^()]*\))*?\bspec(?:_set)?\s*=\s*([\w.]+)/g,
+    /\bpatch\(\s*['"]([\w.]+)['"]/g,
+    /\bpatch\.object\(\s*([\w.]+)/g,
+  ]
+  for (const file of files) {
+    for (const pattern of patterns) {
+      for (const match of file.content.matchAll(pattern)) {
+        if (file.code[match.index] === ' ') continue // In a comment or string
+        // A patch target can name a method of the type: `app.store.UserStore.save`
+        const of = match[1]!.split('.').reverse().find(segment => targets.has(segment))
+        if (!of) continue
+        const statement = file.content.substring(file.content.lastIndexOf('\n', match.index) + 1, match.index)
+        const held = /^\s*(\w+)\s*=\s*$/.exec(statement)?.[1]
+        doubles.push({ kind: 'unittest.mock', of, ...(held ? { name: held } : {}), usage: closeCall(match[0]), file: file.path, line: lineAt(file.content, match.index) })
+      }
+    }
+  }
+  return doubles
+}
+
+function readDeclarations(file: ScannedFile): Declaration[] {
+  if (file.family === 'go') return readGoDeclarations(file)
+  if (file.family === 'python') return readPythonDeclarations(file)
+  return readJsDeclarations(file)
+}
+
+function readGoDeclarations(file: ScannedFile): Declaration[] {
+  const { code } = file
+  const declarations: Declaration[] = []
+  for (const match of code.matchAll(/^type\s+(\w+)(?:\[[^\]\n]*\])?\s+(struct|interface)\s*\{/gm)) {
+    const open = match.index + match[0].length - 1
+    const body = code.substring(open + 1, matchingBrace(code, open))
+    const methods = new Set<string>()
+    const bases: string[] = []
+    for (const line of body.split('\n').map(text => text.trim()).filter(Boolean)) {
+      const method = match[2] === 'interface' && /^(\w+)\s*\(/.exec(line)
+      if (method) methods.add(method[1]!)
+      else if (/^\*?[\w.]+$/.test(line)) bases.push(line.replace(/^\*/, ''))
+    }
+    const gomockOf = /\*gomock\.Controller\b/.test(body)
+      ? new RegExp(`//\\s*${escapeRegExp(match[1]!)} is a mock of (\\w+) interface`).exec(file.content)?.[1] ?? match[1]!.replace(/^Mock/, '')
+      : undefined
+    declarations.push({ name: match[1]!, kind: match[2] === 'interface' ? 'interface' : 'struct', family: 'go', file: file.path, line: lineAt(code, match.index), methods, bases, ...(gomockOf ? { gomockOf } : {}) })
+  }
+
+  return declarations
+}
+
+/**
+ * Go methods are declared outside their type, in any file of its package
+ */
+function attachGoMethods(declarations: Declaration[], files: ScannedFile[]): void {
+  for (const file of files) {
+    if (file.family !== 'go') continue
+    for (const method of file.code.matchAll(/^func\s*\(\s*(?:\w+\s+)?\*?\s*(\w+)(?:\[[^\]]*\])?\s*\)\s*(\w+)\s*[[(]/gm)) {
+      const receiver = declarations.find(declaration => declaration.family === 'go' && declaration.name === method[1] && dirname(declaration.file) === dirname(file.path))
+      receiver?.methods.add(method[2]!)
+    }
+  }
+}
+
+function readJsDeclarations(file: ScannedFile): Declaration[] {
+  const { code } = file
+  const declarations: Declaration[] = []
+  const declaration = /\b(?:(class)\s+(\w+)(?:\s*<[^{]*?>)?(?:\s+extends\s+([\w.]+)(?:\s*<[^{]*?>)?)?(?:\s+implements\s+([^{]+?))?|(interface)\s+(\w+)(?:\s*<[^{]*?>)?(?:\s+extends\s+([^{]+?))?)\s*\{/g
+  for (const match of code.matchAll(declaration)) {
+    const open = match.index + match[0].length - 1
+    const members = topLevel(code.substring(open + 1, matchingBrace(code, open)))
+    const isClass = match[1] === 'class'
+    const methods = new Set<string>()
+    for (const line of members.split('\n')) {
+      const member = isClass
+        ? /^\s*(?:(?:public|private|protected|static|readonly|async|override|abstract|get|set)\s+)*#?(\w+)\s*(?:<[^>(]*>)?\s*(?:\(|(?::[^=]+)?=\s*(?:async\s+)?(?:\([^)]*\)|\w+)\s*(?::[^=]+)?=>)/.exec(line)
+        : /^\s*(?:readonly\s+)?(\w+)\??\s*(?:(?:<[^>(]*>)?\s*\(|:\s*(?:<[^>]*>\s*)?\()/.exec(line)
+      if (member && member[1] !== 'constructor' && !KEYWORDS.has(member[1]!)) methods.add(member[1]!)
+    }
+    const bases = (isClass ? [match[3], ...splitTypes(match[4])] : splitTypes(match[7])).filter((base): base is string => Boolean(base))
+    declarations.push({ name: (match[2] ?? match[6])!, kind: isClass ? 'class' : 'interface', family: 'js', file: file.path, line: lineAt(code, match.index), methods, bases })
+  }
+  return declarations
+}
+
+function readPythonDeclarations(file: ScannedFile): Declaration[] {
+  const lines = file.code.split('\n')
+  const declarations: Declaration[] = []
+  lines.forEach((text, index) => {
+    const match = /^(\s*)class\s+(\w+)\s*(?:\(([^)]*)\))?\s*:/.exec(text)
+    if (!match) return
+    const indent = match[1]!.length
+    const bases = (match[3] ?? '').split(',').map(base => base.trim()).filter(Boolean)
+    const methods = new Set<string>()
+    let bodyIndent: number | undefined
+    for (const line of lines.slice(index + 1)) {
+      if (!line.trim()) continue
+      const lineIndent = line.length - line.trimStart().length
+      if (lineIndent <= indent) break
+      bodyIndent ??= lineIndent
+      const method = lineIndent === bodyIndent && /^\s*(?:async\s+)?def\s+(\w+)/.exec(line)
+      if (method && !/^__\w+__$/.test(method[1]!)) methods.add(method[1]!)
+    }
+    const isInterface = bases.some(base => PROTOCOL_BASES.test(base) || /metaclass\s*=\s*(?:abc\.)?ABCMeta/.test(base))
+    declarations.push({ name: match[2]!, kind: isInterface ? 'interface' : 'class', family: 'python', file: file.path, line: index + 1, methods, bases: bases.filter(base => !base.includes('=')) })
+  })
+  return declarations
+}
+
+/**
+ * `var _ Interface = (*Type)(nil)` and `var _ Interface = &Type{}` compile-time assertions
+ */
+function goAssertions(files: ScannedFile[]): { interface: string, type: string }[] {
+  return files.flatMap(file => [...file.code.matchAll(/^\s*(?:var\s+)?_\s+\*?([\w.]+)\s*=\s*(?:\(\s*\*?\s*(\w+)\s*\)\s*\(\s*nil\s*\)|&?(\w+)\s*\{)/gm)]
+    .map(match => ({ interface: unqualified(match[1]!), type: (match[2] ?? match[3])! })))
+}
+
+/**
+ * Constructors by the type they return, such as mockgen's `NewMockStore`
+ */
+function goConstructors(files: ScannedFile[]): Map<string, string> {
+  const constructors = new Map<string, string>()
+  for (const file of files) {
+    for (const match of file.code.matchAll(/^func\s+(New\w+)\s*\([^)]*\)\s*\*?(\w+)\s*\{/gm)) {
+      if (!constructors.has(match[2]!)) constructors.set(match[2]!, match[1]!)
+    }
+  }
+  return constructors
+}
+
+/**
+ * Functions with a parameter of the interface's type
+ */
+function findConsumers(name: string, files: ScannedFile[]): BoundaryConsumer[] {
+  const type = escapeRegExp(name)
+  const consumers: BoundaryConsumer[] = []
+  for (const file of files) {
+    const parameter = file.family === 'go'
+      ? new RegExp(`[(,]\\s*\\w+(?:\\s*,\\s*\\w+)*\\s+(?:\\*|\\[\\])?(?:\\w+\\.)?${type}\\b(?!\\s*[{.])`, 'g')
+      : new RegExp(`[(,]\\s*(?:(?:readonly|private|public|protected)\\s+)*\\w+\\??\\s*:\\s*(?:\\w+\\.)?${type}\\b(?!\\s*\\()`, 'g')
+    const seen = new Set<number>()
+    for (const match of file.code.matchAll(parameter)) {
+      const open = unmatchedParen(file.code, match.index + 1)
+      if (open < 0 || seen.has(open) || matchingParen(file.code, open) < match.index) continue
+      seen.add(open)
+      const before = file.code.substring(Math.max(0, open - 200), open)
+      const owner = /(\w+)\s*(?::[^=(]*)?=\s*(?:async\s*)?$/.exec(before)?.[1] ?? /(\w+)\s*(?:\[[^\]]*\]|<[^<>]*>)?\s*$/.exec(before)?.[1]
+      if (!owner || KEYWORDS.has(owner)) continue
+      consumers.push({ function: owner, file: file.path, line: lineAt(file.code, open) })
+    }
+  }
+  return consumers
+}
+
+function unmatchedParen(code: string, from: number): number {
+  let depth = 0
+  for (let i = from; i >= 0; i--) {
+    if (code[i] === ')') depth++
+    else if (code[i] === '(' && depth-- === 0) return i
+    else if ((code[i] === '{' || code[i] === ';') && depth === 0) return -1
+  }
+  return -1
+}
+
+/**
+ * The body with nested blocks blanked, so member patterns only see the type's own members
+ */
+function topLevel(body: string): string {
+  let depth = 0
+  let result = ''
+  for (const char of body) {
+    if (char === '}') depth--
+    result += depth > 0 && char !== '\n' ? ' ' : char
+    if (char === '{') depth++
+  }
+  return result
+}
+
+/**
+ * A matched call prefix as a short, balanced expression: `mock<Store>()`, `create_autospec(Store)`
+ */
+function closeCall(text: string): string {
+  const open = (text.match(/\(/g) ?? []).length - (text.match(/\)/g) ?? []).length
+  return text.replace(/\s+/g, ' ') + ')'.repeat(Math.max(0, open))
+}
+
+function splitTypes(list: string | undefined): string[] {
+  return (list ?? '').split(',').map(part => part.replace(/<.*$/, '').trim()).filter(Boolean)
+}
+
+function unqualified(name: string): string {
+  return name.substring(name.lastIndexOf('.') + 1)
+}
+
+function familyOf(path: string): Family | undefined {
+  const language = getLanguageForFile(path)?.name
+  if (language === PARSER_NAMES.GO) return 'go'
+  if (language === PARSER_NAMES.PYTHON) return 'python'
+  if (language === PARSER_NAMES.TYPESCRIPT || language === PARSER_NAMES.TSX || language === PARSER_NAMES.JAVASCRIPT) return 'js'
+  return undefined
+}
+
+function withoutExtension(path: string): string {
+  const stripped = path.substring(0, path.length - extname(path).length)
+  return basename(stripped) === 'index' ? dirname(stripped) : stripped
+}
+
+function toRelative(root: string, path: string): string {
+  return relative(root, path).replace(/\\/g, '/')
+}
@@ -0,0 +1,301 @@
+/**
+ * Test fixtures - maps each test to the data files it reads, from the literal paths in its code:
+ * path joins, Python `Path` chains and plain path strings, resolved against the test's
+ * directory, the project root and the directories the test already refers to. Golden files and
+ * snapshots are marked, with the command that regenerates them, so a change to an output format
+ * can be followed by refreshing exactly the goldens it affects.
+ */
+
+import { readFileSync } from 'fs'
+import { basename, dirname, extname, posix } from 'path'
+import { getLanguageForFile } from '../core/languages.js'
+import { matchingParen } from '../core/go-source.js'
+import { extractStringLiterals, maskSource } from '../core/strings.js'
+import { findTestFiles, isTestPath } from '../project/test-files.js'
+import { globToRegExp, splitTopLevel } from '../utils/helpers.js'
+
+export type FixtureKind = 'file' | 'directory' | 'pattern'
+
+export interface FixtureReference {
+  path: string // Relative to the project root; a glob for a `pattern`
+  kind: FixtureKind
+  line: number
+  golden: boolean // Expected output the test compares against, rather than input
+  exists: boolean
+  matches?: string[] // Files a `pattern` matches
+}
+
+export interface TestFixtures {
+  test: string
+  fixtures: FixtureReference[]
+  update?: string // Command regenerating the test's golden files or snapshots
+}
+
+export interface FixtureDirectory {
+  path: string
+  files: number
+  tests: number // Tests referring to files in it
+}
+
+export interface FixtureMap {
+  fixtureDirectories: FixtureDirectory[]
+  tests: TestFixtures[]
+}
+
+interface Reference {
+  segments: string[] // `*` for parts only known at run time
+  line: number
+  anchored: boolean // Relative to a directory the test did not spell out
+  golden?: boolean
+}
+
+const FIXTURE_DIRECTORIES = new Set(['testdata', 'fixtures', '__fixtures__', 'fixture', 'test-fixtures', 'test_fixtures', 'test-data', 'test_data', 'golden', 'goldens', 'goldenfiles', '__snapshots__', 'snapshots', '__snapshot__', 'expected'])
+const GOLDEN_DIRECTORIES = new Set(['golden', 'goldens', 'goldenfiles', '__snapshots__', 'snapshots', '__snapshot__', 'expected'])
+const GOLDEN_EXTENSIONS = new Set(['.golden', '.snap', '.ambr', '.approved', '.expected'])
+const DATA_EXTENSIONS = new Set([
+  '.json', '.jsonl', '.ndjson', '.yaml', '.yml', '.toml', '.ini', '.xml', '.csv', '.tsv', '.txt', '.md', '.html', '.sql', '.graphql', '.gql',
+  '.proto', '.har', '.eml', '.log', '.bin', '.dat', '.gz', '.zip', '.tar', '.png', '.jpg', '.jpeg', '.gif', '.svg', '.pdf', '.wasm',
+  '.in', '.out', '.input', '.output', '.env', '.pem', '.crt', '.key', ...GOLDEN_EXTENSIONS,
+])
+const JOIN_CALL = /\b(?:filepath\.Join|path\.(?:Join|join|resolve)|os\.path\.join|posixpath\.join|File\.(?:join|expand_path)|Paths\.get|Path\.of)\s*\(/g
+const BASE = /__dirname|__file__|import\.meta|\bDir\(\)|getcwd|process\.cwd\(\)/
+const TEMPORARY = /TempDir|mkdtemp|tmp_path|tmpdir|os\.tmpdir|tempfile|gettempdir/
+const IMPORT_LINE = /^\s*(?:import|from|export|package|use|require|include)\b|\brequire\(|\b(?:jest|vi)\.(?:do)?mock\(|\bpatch(?:\.object)?\(|\bimport\(/
+const PATH_LIKE = /^(?:\.{1,2}\/)?[\w.@*-]+(?:\/[\w.@*-]+)*\/?$/
+const MAX_FILES = 20000
+
+/**
+ * Maps the project's tests to their fixtures, optionally only the tests that are, or refer
+ * to files, under the given path
+ */
+export function findTestFixtures(root: string, path?: string): FixtureMap {
+  const files = findTestFiles(root, () => true, MAX_FILES).map(file => posix.relative(root.replace(/\\/g, '/'), file.replace(/\\/g, '/')))
+  const tests = files.filter(isTestSource).map(file => ({ path: file, content: readFileSync(`${root}/${file}`, 'utf-8') }))
+  return mapTestFixtures(tests, files, path)
+}
+
+/**
+ * The pure mapping: test sources and every file of the project, by root-relative path
+ */
+export function mapTestFixtures(tests: { path: string, content: string }[], files: string[], path?: string): FixtureMap {
+  const fileSet = new Set(files)
+  const directories = new Map<string, number>()
+  for (const file of files) {
+    for (let directory = posix.dirname(file); directory !== '.'; directory = posix.dirname(directory)) {
+      directories.set(directory, (directories.get(directory) ?? 0) + 1)
+    }
+  }
+
+  const mapped: TestFixtures[] = []
+  for (const test of tests) {
+    const references = readReferences(test.path, test.content)
+    const fixtures = resolveReferences(test.path, references, fileSet, directories, files)
+    if (fixtures.length === 0) continue
+    const update = updateCommand(test.path, test.content, fixtures, tests)
+    mapped.push({ test: test.path, fixtures, ...(update ? { update } : {}) })
+  }
+
+  const within = (file: string) => !path || file === path || file.startsWith(`${path.replace(/\/$/, '')}/`)
+  const selected = mapped.filter(test => within(test.test) || test.fixtures.some(fixture => within(fixture.path) || fixture.matches?.some(within)))
+    .sort((a, b) => a.test.localeCompare(b.test))
+
+  const fixtureDirectories = [...directories.keys()]
+    .filter(directory => FIXTURE_DIRECTORIES.has(posix.basename(directory)) && !FIXTURE_DIRECTORIES.has(posix.basename(posix.dirname(directory))))
+    .filter(directory => !path || within(directory) || directory.startsWith(path) || path.startsWith(`${directory}/`))
+    .sort()
+    .map(directory => ({
+      path: directory,
+      files: directories.get(directory)!,
+      tests: mapped.filter(test => test.fixtures.some(fixture => [fixture.path, ...fixture.matches ?? []].some(file => file === directory || file.startsWith(`${directory}/`)))).length,
+    }))
+
+  return { fixtureDirectories, tests: selected }
+}
+
+function isTestSource(path: string): boolean {
+  if (!isTestPath(path) || !getLanguageForFile(path) || DATA_EXTENSIONS.has(extname(path)) || path.split('/').includes('__mocks__')) return false
+  // Code kept as fixture data is not a test
+  return !path.split('/').slice(0, -1).some(segment => FIXTURE_DIRECTORIES.has(segment))
+}
+
+/**
+ * Literal paths in a test: joins first, then strings not already part of one
+ */
+function readReferences(file: string, content: string): Reference[] {
+  const code = maskSource(content, file)
+  const lineStarts = [0, ...[...content.matchAll(/\n/g)].map(match => match.index + 1)]
+  const lineOf = (index: number) => {
+    let line = 0
+    while (line + 1 < lineStarts.length && lineStarts[line + 1]! <= index) line++
+    return line + 1
+  }
+  const inCode = (index: number) => code[index] !== ' ' && code[index] !== undefined
+  const consumed: [number, number][] = []
+  const references: Reference[] = []
+
+  for (const call of code.matchAll(JOIN_CALL)) {
+    const open = call.index + call[0].length - 1
+    const close = matchingParen(code, open)
+    const args = splitTopLevel(content.substring(open + 1, close)).map(arg => arg.trim()).filter(Boolean)
+    const reference = joinReference(args, lineOf(call.index))
+    consumed.push([open, close])
+    if (reference) references.push(reference)
+  }
+
+  // `Path(__file__).parent / "testdata" / f"{name}.golden"`
+  if (getLanguageForFile(file)?.name === 'python') {
+    const chain = /(Path\([^)\n]*\)(?:\.\w+(?:\(\))?)*|[A-Za-z_]\w*)((?:[ \t]*\/[ \t]*(?:[rbfRBF]{0,2}"[^"\n]*"|[rbfRBF]{0,2}'[^'\n]*'|[A-Za-z_]\w*))+)/g
+    for (const match of content.matchAll(chain)) {
+      if (!inCode(match.index) || !/["']/.test(match[2]!)) continue
+      const parts = [...match[2]!.matchAll(/\/[ \t]*([rbfRBF]{0,2}"[^"\n]*"|[rbfRBF]{0,2}'[^'\n]*'|[A-Za-z_]\w*)/g)].map(part => part[1]!)
+      const reference = joinReference([match[1]!, ...parts], lineOf(match.index))
+      consumed.push([match.index, match.index + match[0].length])
+      if (reference) references.push(reference)
+    }
+  }
+
+  const lines = content.split('\n')
+  for (const literal of extractStringLiterals(content, file)) {
+    const offset = lineStarts[literal.line - 1]! + (literal.column ?? 1) - 1
+    if (consumed.some(([start, end]) => offset > start && offset < end) || IMPORT_LINE.test(lines[literal.line - 1] ?? '')) continue
+    const text = literalText(literal.text, content[offset - 1])
+    if (!PATH_LIKE.test(text) || /^\*+$/.test(text) || (!text.includes('/') && !DATA_EXTENSIONS.has(extname(text)))) continue
+    references.push({ segments: text.split('/').filter(segment => segment && segment !== '.'), line: literal.line, anchored: false })
+  }
+
+  // Snapshot and golden helpers whose paths follow from the test's name
+  const snapshots = /\.(?:toMatchSnapshot|toThrowErrorMatchingSnapshot)\(/.exec(code)
+  if (snapshots) references.push({ segments: ['__snapshots__', `${basename(file)}.snap`], line: lineOf(snapshots.index), anchored: false, golden: true })
+  const syrupy = /^[ \t]*(?:async\s+)?def\s+test\w*\([^)]*\bsnapshot\b/m.exec(code)
+  if (syrupy) references.push({ segments: ['__snapshots__', `${basename(file, '.py')}.ambr`], line: lineOf(syrupy.index), anchored: false, golden: true })
+  const goldie = /\bgoldie\.New\(/.exec(code)
+  if (goldie) references.push({ segments: ['testdata', '*.golden'], line: lineOf(goldie.index), anchored: false, golden: true })
+
+  return references
+}
+
+/**
+ * A path from join arguments: literals as written, run-time parts as `*`. Leading bases such as
+ * `__dirname` are the test's directory; a leading variable is some directory the test set up.
+ */
+function joinReference(args: string[], line: number): Reference | undefined {
+  // Output written to a temporary directory is not a fixture
+  if (args.some(arg => TEMPORARY.test(arg))) return undefined
+  const segments: string[] = []
+  let anchored = false
+  args.forEach((arg, index) => {
+    const literal = /^([rbfRBF]{0,2})(["'`])([\s\S]*)\2$/.exec(arg)
+    if (literal) {
+      segments.push(...literalText(literal[3]!, literal[1]!.toLowerCase().includes('f') ? 'f' : literal[2]).split('/').filter(segment => segment && segment !== '.'))
+      return
+    }
+    if (BASE.test(arg) && index === 0) return
+    if (index === 0 && segments.length === 0 && /^[\w.]+$/.test(arg)) {
+      anchored = true
+      return
+    }
+    // `name + ".golden"`: the literal parts of a concatenation
+    const parts = splitTopLevel(arg, '+').map(part => part.trim())
+    const joined = parts.map(part => /^(["'`])(.*)\1$/.exec(part)?.[2] ?? '*').join('').replace(/\*+/g, '*')
+    segments.push(joined || '*')
+  })
+  if (!segments.some(segment => segment !== '*')) return undefined
+  return { segments, line, anchored }
+}
+
+/**
+ * The text of a literal with interpolations (`${name}`, Python f-string `{name}`) as `*`
+ */
+function literalText(text: string, quote?: string): string {
+  const interpolated = quote === 'f' || quote === 'F' ? text.replace(/\{[^{}]*\}/g, '*') : text
+  return interpolated.replace(/\$\{[^}]*\}/g, '*').replace(/%[sdvq]/g, '*')
+}
+
+function resolveReferences(test: string, references: Reference[], files: Set<string>, directories: Map<string, number>, allFiles: string[]): FixtureReference[] {
+  const testDirectory = posix.dirname(test)
+  const resolved = new Map<string, FixtureReference>()
+  const referencedDirectories: string[] = []
+
+  const lookup = (relative: string, bases: string[]): { path: string, kind: FixtureKind, matches?: string[] } | undefined => {
+    for (const base of bases) {
+      const candidate = posix.normalize(base === '.' ? relative : `${base}/${relative}`).replace(/\/$/, '')
+      if (candidate.startsWith('..')) continue
+      if (candidate.includes('*')) {
+        const pattern = globToRegExp(candidate)
+        const matches = allFiles.filter(file => pattern.test(file))
+        if (matches.length > 0) return { path: candidate, kind: 'pattern', matches }
+        continue
+      }
+      if (files.has(candidate)) return { path: candidate, kind: 'file' }
+      if (directories.has(candidate)) return { path: candidate, kind: 'directory' }
+    }
+    return undefined
+  }
+
+  // Directories resolve first, so names used under them can be found there
+  const ordered = [...references].sort((a, b) => Number(DATA_EXTENSIONS.has(extname(a.segments.at(-1)!))) - Number(DATA_EXTENSIONS.has(extname(b.segments.at(-1)!))) || a.line - b.line)
+  for (const reference of ordered) {
+    const relative = reference.segments.join('/')
+    const bases = reference.anchored
+      ? [...referencedDirectories, testDirectory, '.']
+      : [testDirectory, '.', ...referencedDirectories]
+    let found = lookup(relative, bases)
+    // A file under a fixture directory only the variable it is joined to names
+    if (!found && (reference.anchored || reference.segments.length === 1)) {
+      const fixtureDirectories = [...directories.keys()].filter(directory => FIXTURE_DIRECTORIES.has(posix.basename(directory)) && (directory.startsWith(`${testDirectory}/`) || posix.dirname(directory) === posix.dirname(testDirectory)))
+      found = lookup(relative, fixtureDirectories)
+    }
+
+    const golden = reference.golden === true || isGolden(found?.path ?? relative)
+    if (found) {
+      if (found.kind === 'directory') referencedDirectories.push(found.path)
+      if (!resolved.has(found.path)) resolved.set(found.path, { path: found.path, kind: found.kind, line: reference.line, golden, exists: true, ...(found.matches ? { matches: found.matches } : {}) })
+      continue
+    }
+
+    // Missing files still matter when they look like data, such as goldens an update writes
+    if (!reference.golden && !reference.segments.some(segment => FIXTURE_DIRECTORIES.has(segment)) && !DATA_EXTENSIONS.has(extname(relative))) continue
+    if (!reference.golden && !reference.segments.some(segment => FIXTURE_DIRECTORIES.has(segment)) && !relative.includes('/')) continue
+    // Paths starting at a top-level directory are spelled from the root
+    const base = reference.anchored && referencedDirectories.length > 0
+      ? referencedDirectories[0]!
+      : directories.has(reference.segments[0]!) ? '.' : testDirectory
+    const path = posix.normalize(base === '.' ? relative : `${base}/${relative}`)
+    if (path.startsWith('..') || resolved.has(path)) continue
+    const kind: FixtureKind = path.includes('*') ? 'pattern' : extname(path) ? 'file' : 'directory'
+    resolved.set(path, { path, kind, line: reference.line, golden, exists: false, ...(kind === 'pattern' ? { matches: [] } : {}) })
+  }
+
+  return [...resolved.values()].sort((a, b) => a.line - b.line || a.path.localeCompare(b.path))
+}
+
+function isGolden(path: string): boolean {
+  const segments = path.split('/')
+  const name = segments.at(-1)!
+  return GOLDEN_EXTENSIONS.has(extname(name)) || /golden|\.expected\.|\.approved\./i.test(name)
+    || segments.slice(0, -1).some(segment => GOLDEN_DIRECTORIES.has(segment))
+}
+
+/**
+ * How the project's tooling rewrites goldens: Go `-update` flags, Jest and Vitest `-u`, and the
+ * snapshot and regression plugins of pytest
+ */
+function updateCommand(test: string, content: string, fixtures: FixtureReference[], tests: { path: string, content: string }[]): string | undefined {
+  if (!fixtures.some(fixture => fixture.golden)) return undefined
+  const directory = dirname(test)
+  if (test.endsWith('_test.go')) {
+    // The flag can be declared in any test file of the package
+    const siblings = tests.filter(other => other.path.endsWith('_test.go') && dirname(other.path) === directory).map(other => other.content)
+    const flag = siblings.map(source => /\bflag\.Bool\(\s*"([\w-]+)"/.exec(source)?.[1]).find(name => name !== undefined && /update|golden|regen|record/i.test(name))
+    const name = flag ?? (siblings.some(source => source.includes('goldie')) ? 'update' : undefined)
+    return name ? `go test ${directory === '.' ? '.' : `./${directory}`} -${name}` : undefined
+  }
+  if (/\.[cm]?[jt]sx?$/.test(test) && fixtures.some(fixture => fixture.path.endsWith('.snap') || /Snapshot\(/.test(content))) {
+    return /from\s+['"]vitest['"]|\bvi\./.test(content) ? `npx vitest run ${test} -u` : `npx jest ${test} -u`
+  }
+  if (test.endsWith('.py')) {
+    if (/\bsnapshot\b/.test(content)) return `pytest ${test} --snapshot-update`
+    if (/\b(?:data|file|num|dataframe|image)_regression\b/.test(content)) return `pytest ${test} --force-regen`
+  }
+  return undefined
+}
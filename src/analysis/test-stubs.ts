@@ -0,0 +1,508 @@
+/**
+ * Test stubs - test file skeletons for a function in the style of the project's existing tests:
+ * table-driven Go tests, Jest or Vitest describe blocks with `it.each`, and pytest parametrized
+ * tests, placed and named like the tests already there and importing the function under test
+ */
+
+import { existsSync, readFileSync } from 'fs'
+import { basename, dirname, extname, join, relative } from 'path'
+import { getLanguageForFile } from '../core/languages.js'
+import { findSymbolCandidates, findSymbolsById, isSymbolId, type SymbolCandidate } from '../core/symbol-ids.js'
+import { matchingParen } from '../core/go-source.js'
+import { getAllNodes } from '../project/manager.js'
+import { findTestFiles } from '../project/test-files.js'
+import { PARSER_NAMES, PROJECT_FILES } from '../constants/index.js'
+import { isFile, splitTopLevel } from '../utils/helpers.js'
+import type { Project, TreeNode } from '../types/core.js'
+
+export type TestFramework = 'go' | 'vitest' | 'jest' | 'pytest'
+export type TestPlacement = 'colocated' | '__tests__' | 'mirrored'
+
+export interface ExistingTest {
+  path: string // Relative to the project root, `/`-separated
+  content: string
+}
+
+export interface TestConventions {
+  framework: TestFramework
+  placement: TestPlacement
+  testRoot?: string // Mirrored placement: tests for `<sourceRoot>/a/b.ts` go in `<testRoot>/a/`
+  sourceRoot?: string // Unset when tests sit directly in the test root
+  examined: number // Existing tests of the language the conventions were read from
+}
+
+export interface TestStub {
+  function: { name: string, file: string, line?: number }
+  testFile: string // Relative to the project root
+  exists: boolean // The test file is already there; `content` is then the block to add to it
+  conventions: TestConventions
+  imports: string[] // Import lines the stub needs
+  content: string
+}
+
+type StubLanguage = 'go' | 'typescript' | 'python'
+
+interface Style {
+  semicolons: boolean
+  quote: string
+  testCall: 'it' | 'test'
+  importGlobals: boolean // Test functions are imported rather than ambient
+  jsExtension: boolean // Relative imports end in `.js`, as ESM TypeScript needs
+  external: boolean // Go tests in the `_test` package
+  testify: boolean
+  suffix: string // `.test`, `.spec`, or for Python `test_` as a prefix or `_test` as a suffix
+}
+
+interface Parameter {
+  name: string
+  type?: string
+  variadic?: boolean
+}
+
+const CALLABLE_KINDS = ['function', 'method']
+const MAX_EXAMINED_TESTS = 40
+
+/**
+ * Generates a test skeleton for a function or method, given by name or symbol id
+ */
+export function generateTestStub(project: Project, target: string): TestStub {
+  const definition = resolveFunction(project, target)
+  const root = project.config.directory
+  const language = stubLanguage(definition.path)
+  if (!language) {
+    throw new Error(`No test template for ${getLanguageForFile(definition.path)?.name ?? extname(definition.path)} files; supported are Go, JavaScript/TypeScript and Python`)
+  }
+
+  const tests = findExistingTests(root, language)
+  const sources = [...project.files.keys()].map(path => toRelative(root, path))
+  const fileContent = project.files.get(definition.path)?.content ?? readFileSync(definition.path, 'utf-8')
+  return buildTestStub(definition, fileContent, root, tests, sources)
+}
+
+/**
+ * Builds the stub from the function, the content of its file, and the project's existing tests
+ * and source files (paths relative to the root)
+ */
+export function buildTestStub(definition: TreeNode, fileContent: string, root: string, tests: ExistingTest[], sources: string[]): TestStub {
+  const language = stubLanguage(definition.path)!
+  const source = toRelative(root, definition.path)
+  const relevant = tests.filter(test => stubLanguage(test.path) === language).slice(0, MAX_EXAMINED_TESTS)
+  const style = readStyle(language, relevant)
+  const conventions = readConventions(language, relevant, sources, root)
+  const testFile = testPathFor(language, source, conventions, style)
+  const exists = isFile(join(root, testFile))
+
+  const { imports, body } = language === 'go'
+    ? goStub(definition, fileContent, root, style)
+    : language === 'python'
+      ? pythonStub(definition, source, conventions, style)
+      : typescriptStub(definition, fileContent, source, testFile, conventions, style)
+
+  const header = language === 'go' ? goHeader(fileContent, style, imports) : imports.join('\n')
+  const existing = exists ? readFileSync(join(root, testFile), 'utf-8') : ''
+  return {
+    function: { name: definition.name!, file: source, line: definition.startLine },
+    testFile,
+    exists,
+    conventions,
+    // Those the test file doesn't have yet when adding to it
+    imports: exists ? imports.filter(line => line && !existing.includes(line)) : imports,
+    content: exists ? body : `${header}\n\n${language === 'python' ? '\n' : ''}${body}`,
+  }
+}
+
+function resolveFunction(project: Project, target: string): TreeNode {
+  if (isSymbolId(target)) {
+    const [definition] = findSymbolsById(project, target)
+    if (!definition) throw new Error(`Unknown symbol id: ${target}`)
+    return definition
+  }
+
+  const candidates = findSymbolCandidates(project, target, CALLABLE_KINDS)
+  if (candidates.length === 0) {
+    throw new Error(`Unknown function: ${target}`)
+  }
+  if (candidates.length > 1) {
+    throw new Error(`Ambiguous function ${target}; pass one of these ids: ${candidates.map(candidate => candidate.id).join(', ')}`)
+  }
+  const [candidate] = candidates as [SymbolCandidate]
+  return findSymbolsById(project, candidate.id)[0]
+    ?? getAllNodes(project).find(node => node.path === candidate.path && node.startLine === candidate.startLine && node.name === candidate.name)!
+}
+
+function stubLanguage(path: string): StubLanguage | undefined {
+  const language = getLanguageForFile(path)?.name
+  if (language === PARSER_NAMES.GO) return 'go'
+  if (language === PARSER_NAMES.PYTHON) return 'python'
+  if (language === PARSER_NAMES.TYPESCRIPT || language === PARSER_NAMES.TSX || language === PARSER_NAMES.JAVASCRIPT) return 'typescript'
+  return undefined
+}
+
+/**
+ * Test files of the language under the root, including the test directories the index skips
+ */
+function findExistingTests(root: string, language: StubLanguage): ExistingTest[] {
+  return findTestFiles(root, path => stubLanguage(path) === language && isTestName(language, path), MAX_EXAMINED_TESTS)
+    .map(path => ({ path: toRelative(root, path), content: readFileSync(path, 'utf-8') }))
+}
+
+function isTestName(language: StubLanguage, path: string): boolean {
+  const name = basename(path)
+  if (language === 'go') return name.endsWith('_test.go')
+  if (language === 'python') return /^test_.*\.py$|_test\.py$/.test(name)
+  return /\.(?:test|spec)\.[cm]?[jt]sx?$/.test(name) || path.split('/').includes('__tests__')
+}
+
+/**
+ * The name of the source file a test file covers
+ */
+function testedStem(language: StubLanguage, path: string): string {
+  const name = basename(path)
+  if (language === 'go') return name.replace(/_test\.go$/, '')
+  if (language === 'python') return name.replace(/^test_/, '').replace(/_test\.py$/, '').replace(/\.py$/, '')
+  return name.replace(/(?:\.(?:test|spec))?\.[cm]?[jt]sx?$/, '')
+}
+
+function majority<T>(values: T[], fallback: T): T {
+  const counts = new Map<T, number>()
+  for (const value of values) counts.set(value, (counts.get(value) ?? 0) + 1)
+  let best = fallback
+  let bestCount = 0
+  for (const [value, count] of counts) {
+    if (count > bestCount) {
+      best = value
+      bestCount = count
+    }
+  }
+  return best
+}
+
+function readStyle(language: StubLanguage, tests: ExistingTest[]): Style {
+  const contents = tests.map(test => test.content)
+  const names = tests.map(test => basename(test.path))
+  const importLines = contents.flatMap(content => content.split('\n').filter(line => /^\s*(?:import|from)\b/.test(line)))
+  const relativeImports = importLines.map(line => /from\s+['"](\.{1,2}\/[^'"]+)['"]/.exec(line)?.[1]).filter((path): path is string => path !== undefined)
+  const statementLines = contents.flatMap(content => content.split('\n').map(line => line.trimEnd()).filter(line => /[\w)\]'"`]$|;$/.test(line)))
+
+  return {
+    semicolons: majority(statementLines.map(line => line.endsWith(';')), false),
+    quote: majority(importLines.map(line => /['"]/.exec(line)?.[0]).filter((quote): quote is string => quote !== undefined), language === 'python' ? '"' : '\''),
+    testCall: majority(contents.flatMap(content => [...content.matchAll(/^\s*(it|test)(?:\.each)?\s*[(`]/gm)].map(match => match[1] as 'it' | 'test')), 'it'),
+    importGlobals: contents.length === 0 || majority(contents.map(content => /from\s+['"](?:vitest|@jest\/globals)['"]/.test(content)), true),
+    jsExtension: majority(relativeImports.map(path => path.endsWith('.js')), false),
+    external: majority(contents.map(content => /^package\s+\w+_test\b/m.test(content)), false),
+    testify: contents.some(content => content.includes('github.com/stretchr/testify/')),
+    suffix: language === 'python'
+      ? majority(names.map(name => name.startsWith('test_') ? 'test_' : '_test'), 'test_')
+      : majority(names.map(name => /\.spec\./.test(name) ? '.spec' : '.test'), '.test'),
+  }
+}
+
+/**
+ * Where tests live relative to their sources, learned from existing tests whose stem matches a
+ * source file: next to it, in a sibling `__tests__` directory, or under a separate tree that
+ * mirrors the source directories
+ */
+function readConventions(language: StubLanguage, tests: ExistingTest[], sources: string[], root: string): TestConventions {
+  const framework: TestFramework = language === 'go'
+    ? 'go'
+    : language === 'python' ? 'pytest' : detectJsFramework(tests, root)
+  if (language === 'go') return { framework, placement: 'colocated', examined: tests.length }
+
+  const votes: string[] = []
+  for (const test of tests) {
+    const stem = testedStem(language, test.path)
+    const testDirectory = dirname(test.path)
+    const matches = sources.filter(source => stubLanguage(source) === language && !isTestName(language, source) && basename(source).replace(/\.[^.]+$/, '') === stem)
+    let best: string | undefined
+    let bestShared = -1
+    for (const match of matches) {
+      const sourceDirectory = dirname(match)
+      if (sourceDirectory === testDirectory) {
+        best = 'colocated'
+        break
+      }
+      if (testDirectory === join(sourceDirectory, '__tests__').replace(/\\/g, '/')) {
+        best = '__tests__'
+        break
+      }
+      const testSegments = segments(testDirectory)
+      const sourceSegments = segments(sourceDirectory)
+      let shared = 0
+      while (shared < testSegments.length && shared < sourceSegments.length
+        && testSegments[testSegments.length - 1 - shared] === sourceSegments[sourceSegments.length - 1 - shared]) shared++
+      if (shared > bestShared) {
+        bestShared = shared
+        best = `${testSegments.slice(0, testSegments.length - shared).join('/')}|${sourceSegments.slice(0, sourceSegments.length - shared).join('/')}`
+      }
+    }
+    if (best) votes.push(best)
+  }
+
+  if (votes.length === 0 && language === 'python') {
+    // Nothing to go by: a flat tests directory, as pytest projects usually start with
+    return { framework, placement: 'mirrored', testRoot: 'tests', examined: tests.length }
+  }
+  const placement = majority(votes, tests.some(test => test.path.split('/').includes('__tests__')) ? '__tests__' : 'colocated')
+  if (placement === 'colocated' || placement === '__tests__') {
+    return { framework, placement, examined: tests.length }
+  }
+  const [testRoot, sourceRoot] = placement.split('|') as [string, string]
+  return { framework, placement: 'mirrored', testRoot, sourceRoot, examined: tests.length }
+}
+
+function detectJsFramework(tests: ExistingTest[], root: string): TestFramework {
+  const imports = tests.map(test => /from\s+['"]vitest['"]/.test(test.content) ? 'vitest' : /@jest\/globals|\bjest\.\w+\(/.test(test.content) ? 'jest' : undefined)
+    .filter((framework): framework is 'vitest' | 'jest' => framework !== undefined)
+  if (imports.length > 0) return majority(imports, 'jest')
+
+  const manifest = join(root, PROJECT_FILES.PACKAGE_MANAGERS.NPM)
+  if (existsSync(manifest)) {
+    const text = readFileSync(manifest, 'utf-8')
+    if (/"vitest"\s*:/.test(text)) return 'vitest'
+  }
+  return 'jest'
+}
+
+function testPathFor(language: StubLanguage, source: string, conventions: TestConventions, style: Style): string {
+  const directory = dirname(source) === '.' ? '' : dirname(source)
+  const stem = basename(source).replace(/\.[^.]+$/, '')
+  const extension = extname(source)
+  const name = language === 'go'
+    ? `${stem}_test.go`
+    : language === 'python'
+      ? (style.suffix === '_test' ? `${stem}_test.py` : `test_${stem}.py`)
+      : `${stem}${style.suffix}${extension}`
+
+  if (conventions.placement === 'colocated') return join(directory, name)
+  if (conventions.placement === '__tests__') return join(directory, '__tests__', name)
+  if (conventions.sourceRoot === undefined) return join(conventions.testRoot ?? '', name)
+
+  const sourceSegments = segments(directory)
+  const rootSegments = segments(conventions.sourceRoot)
+  const inside = rootSegments.every((segment, index) => sourceSegments[index] === segment)
+  const rest = inside ? sourceSegments.slice(rootSegments.length) : sourceSegments
+  return join(conventions.testRoot ?? '', ...rest, name)
+}
+
+function goHeader(fileContent: string, style: Style, imports: string[]): string {
+  const pkg = /^package\s+(\w+)/m.exec(fileContent)?.[1] ?? 'main'
+  const lines = [`package ${style.external ? `${pkg}_test` : pkg}`, '']
+  if (imports.length === 1) lines.push(`import ${imports[0]}`)
+  else lines.push('import (', ...imports.map(line => `\t${line}`), ')')
+  return lines.join('\n')
+}
+
+/**
+ * A gotests-style table-driven test
+ */
+function goStub(definition: TreeNode, fileContent: string, root: string, style: Style): { imports: string[], body: string } {
+  const signature = goSignature(definition.content ?? '')
+  const name = definition.name!
+  const pkg = /^package\s+(\w+)/m.exec(fileContent)?.[1] ?? 'main'
+  const qualify = (type: string) => style.external ? type.replace(/(?<![\w.])([A-Z]\w*)/g, `${pkg}.$1`) : type
+  const fields = signature.parameters.map(parameter => ({ ...parameter, type: parameter.variadic ? `[]${parameter.type}` : parameter.type }))
+  const results = signature.results
+  const returnsError = results.at(-1) === 'error'
+  const values = returnsError ? results.slice(0, -1) : results
+  const wants = values.map((type, index) => ({ name: index === 0 ? 'want' : `want${index}`, type: qualify(type) }))
+  const gots = values.map((_, index) => index === 0 ? 'got' : `got${index}`)
+
+  const callee = signature.receiver
+    ? `${signature.receiver.name}.${name}`
+    : style.external ? `${pkg}.${name}` : name
+  const args = fields.map(field => `tt.args.${field.name}${field.variadic ? '...' : ''}`).join(', ')
+  const call = `${callee}(${args})`
+  const label = signature.receiver ? `${signature.receiver.type}.${name}` : name
+
+  const body: string[] = [`func Test${signature.receiver ? `${signature.receiver.type}_` : ''}${name}(t *testing.T) {`]
+  if (fields.length > 0) {
+    body.push('\ttype args struct {', ...aligned(fields.map(field => [field.name, qualify(field.type!)])).map(line => `\t\t${line}`), '\t}')
+  }
+  const columns: [string, string][] = [['name', 'string']]
+  if (fields.length > 0) columns.push(['args', 'args'])
+  wants.forEach(want => columns.push([want.name, want.type]))
+  if (returnsError) columns.push(['wantErr', 'bool'])
+  body.push('\ttests := []struct {', ...aligned(columns).map(line => `\t\t${line}`), '\t}{', '\t\t// TODO: Add test cases.', '\t}')
+  body.push('\tfor _, tt := range tests {', '\t\tt.Run(tt.name, func(t *testing.T) {')
+  if (signature.receiver) {
+    const receiverType = qualify(signature.receiver.type)
+    body.push(`\t\t\t${signature.receiver.name} := ${signature.receiver.pointer ? '&' : ''}${receiverType}{}`)
+  }
+
+  const assigned = [...gots, ...(returnsError ? ['err'] : [])]
+  body.push(assigned.length > 0 ? `\t\t\t${assigned.join(', ')} := ${call}` : `\t\t\t${call}`)
+  if (returnsError) {
+    if (style.testify) {
+      body.push('\t\t\tif tt.wantErr {', '\t\t\t\tassert.Error(t, err)', '\t\t\t\treturn', '\t\t\t}', '\t\t\tassert.NoError(t, err)')
+    }
+    else {
+      body.push('\t\t\tif (err != nil) != tt.wantErr {', `\t\t\t\tt.Errorf("${label}() error = %v, wantErr %v", err, tt.wantErr)`, '\t\t\t\treturn', '\t\t\t}')
+    }
+  }
+  wants.forEach((want, index) => {
+    if (style.testify) {
+      body.push(`\t\t\tassert.Equal(t, tt.${want.name}, ${gots[index]})`)
+      return
+    }
+    body.push(`\t\t\tif !reflect.DeepEqual(${gots[index]}, tt.${want.name}) {`, `\t\t\t\tt.Errorf("${label}() ${gots[index]} = %v, want %v", ${gots[index]}, tt.${want.name})`, '\t\t\t}')
+  })
+  body.push('\t\t})', '\t}', '}')
+
+  const imports = [
+    ...(wants.length > 0 && !style.testify ? ['"reflect"'] : []),
+    '"testing"',
+    ...(style.external ? [`"${goImportPath(definition.path, root)}"`] : []),
+    ...(style.testify && (wants.length > 0 || returnsError) ? ['"github.com/stretchr/testify/assert"'] : []),
+  ]
+  return { imports, body: body.join('\n') + '\n' }
+}
+
+function goSignature(content: string): { receiver?: { name: string, type: string, pointer: boolean }, parameters: Parameter[], results: string[] } {
+  const header = /^func\s*(?:\(\s*(?:(\w+)\s+)?(\*?)\s*([\w.]+)(?:\[[^\]]*\])?\s*\)\s*)?\w+\s*(?:\[[^\]]*\]\s*)?\(/.exec(content)
+  if (!header) return { parameters: [], results: [] }
+  const open = header[0].length - 1
+  const close = matchingParen(content, open)
+  const receiver = header[3] ? { name: header[1] ?? header[3][0]!.toLowerCase(), type: header[3], pointer: header[2] === '*' } : undefined
+
+  const parameters: Parameter[] = []
+  let pending: string[] = []
+  for (const part of splitTopLevel(content.substring(open + 1, close)).map(part => part.trim()).filter(Boolean)) {
+    const named = /^(\w+)\s+(.+)$/.exec(part)
+    if (!named) {
+      pending.push(part)
+      continue
+    }
+    const variadic = named[2]!.startsWith('...')
+    const type = variadic ? named[2]!.substring(3) : named[2]!
+    for (const name of [...pending, named[1]!]) parameters.push({ name, type, variadic })
+    pending = []
+  }
+  // Unnamed parameters
+  pending.forEach((type, index) => parameters.push({ name: `arg${index}`, type }))
+
+  const rest = content.substring(close + 1, content.indexOf('{', close + 1)).trim()
+  const results = rest.startsWith('(')
+    ? splitTopLevel(rest.slice(1, -1)).map(part => part.trim()).filter(Boolean).map(part => /^\w+\s+(.+)$/.exec(part)?.[1] ?? part)
+    : rest ? [rest] : []
+  return { receiver, parameters, results }
+}
+
+function goImportPath(file: string, root: string): string {
+  for (let directory = dirname(file); ; directory = dirname(directory)) {
+    const goMod = join(directory, PROJECT_FILES.PACKAGE_MANAGERS.GO)
+    if (isFile(goMod)) {
+      const module = /^module\s+(\S+)/m.exec(readFileSync(goMod, 'utf-8'))?.[1] ?? basename(directory)
+      const rest = toRelative(directory, dirname(file))
+      return rest === '.' || rest === '' ? module : `${module}/${rest}`
+    }
+    if (directory === root || dirname(directory) === directory) return toRelative(root, dirname(file))
+  }
+}
+
+/**
+ * Struct field lines with the types lined up, as gofmt formats them
+ */
+function aligned(fields: [string, string][]): string[] {
+  const width = Math.max(...fields.map(([name]) => name.length))
+  return fields.map(([name, type]) => `${name.padEnd(width)} ${type}`)
+}
+
+function pythonStub(definition: TreeNode, source: string, conventions: TestConventions, style: Style): { imports: string[], body: string } {
+  const q = style.quote
+  const name = definition.name!
+  const container = definition.symbol?.container
+  const header = /def\s+\w+\s*\(/.exec(definition.content ?? '')
+  const open = header ? header.index + header[0].length - 1 : -1
+  const parameters = open < 0
+    ? []
+    : splitTopLevel((definition.content ?? '').substring(open + 1, matchingParen(definition.content!, open)))
+      .map(part => part.trim().split(/[:=]/)[0]!.trim())
+      .filter(part => part && !part.startsWith('*') && part !== '/' && part !== 'self' && part !== 'cls')
+  const isAsync = /^\s*async\s+def\b/.test(definition.content ?? '')
+
+  const modulePath = pythonModule(source, conventions)
+  const imported = container ?? name
+  const subject = container ? `${container}().${name}` : name
+  const columns = [...parameters, 'expected']
+  const call = `${isAsync ? 'await ' : ''}${subject}(${parameters.join(', ')})`
+  const body = [
+    ...(isAsync ? ['@pytest.mark.asyncio'] : []),
+    '@pytest.mark.parametrize(',
+    `    (${columns.map(column => `${q}${column}${q}`).join(', ')}${columns.length === 1 ? ',' : ''}),`,
+    '    [',
+    '        # TODO: add cases',
+    '    ],',
+    ')',
+    `${isAsync ? 'async ' : ''}def test_${container ? `${toSnakeCase(container)}_` : ''}${name}(${columns.join(', ')}):`,
+    `    assert ${call} == expected`,
+  ]
+  return { imports: ['import pytest', '', `from ${modulePath} import ${imported}`], body: body.join('\n') + '\n' }
+}
+
+/**
+ * Dotted module path of a source file, relative to the source root tests import from
+ */
+function pythonModule(source: string, conventions: TestConventions): string {
+  let parts = segments(source.replace(/\.py$/, ''))
+  const sourceRoot = segments(conventions.sourceRoot ?? '')
+  // A src layout installs the packages below src, not src itself
+  if (parts[0] === 'src' && (sourceRoot.length === 0 || sourceRoot[0] === 'src')) parts = parts.slice(1)
+  if (parts.at(-1) === '__init__') parts = parts.slice(0, -1)
+  return parts.join('.')
+}
+
+function typescriptStub(definition: TreeNode, fileContent: string, source: string, testFile: string, conventions: TestConventions, style: Style): { imports: string[], body: string } {
+  const q = style.quote
+  const semi = style.semicolons ? ';' : ''
+  const name = definition.name!
+  const container = definition.symbol?.container
+  const imported = container ?? name
+  const isDefault = new RegExp(`export\\s+default\\s+(?:async\\s+)?(?:function|class)\\s+${imported}\\b`).test(fileContent)
+    || new RegExp(`export\\s+default\\s+${imported}\\b`).test(fileContent)
+
+  const content = definition.content ?? ''
+  const open = content.indexOf('(')
+  const parameters = open < 0
+    ? []
+    : splitTopLevel(content.substring(open + 1, matchingParen(content, open)))
+      .map(part => part.trim().replace(/^(?:public|private|protected|readonly)\s+/, '').split(/[?:=]/)[0]!.trim().replace(/^\.\.\./, ''))
+      .filter(part => /^[A-Za-z_$][\w$]*$/.test(part) && part !== 'this')
+  const isAsync = open >= 0 && /\basync\b/.test(content.substring(0, open))
+
+  let specifier = relative(dirname(testFile), source.replace(/\.[cm]?[jt]sx?$/, '')).replace(/\\/g, '/')
+  if (!specifier.startsWith('.')) specifier = `./${specifier}`
+  if (style.jsExtension) specifier += '.js'
+
+  const testCall = style.testCall
+  const imports = [
+    ...(style.importGlobals ? [`import { describe, ${testCall}, expect } from ${q}${conventions.framework === 'vitest' ? 'vitest' : '@jest/globals'}${q}${semi}`] : []),
+    `import ${isDefault ? imported : `{ ${imported} }`} from ${q}${specifier}${q}${semi}`,
+  ]
+
+  const subject = container ? `new ${container}().${name}` : name
+  const columns = [...parameters, 'expected']
+  const call = `${subject}(${parameters.join(', ')})`
+  const body = [
+    `describe(${q}${container ? `${container}.${name}` : name}${q}, () => {`,
+    `  ${testCall}.each([`,
+    `    // TODO: add cases as [${columns.join(', ')}]`,
+    `  ])(${q}${name}(${parameters.map(() => '%o').join(', ')}) returns %o${q}, ${isAsync ? 'async ' : ''}(${columns.join(', ')}) => {`,
+    `    expect(${isAsync ? `await ${call}` : call}).toEqual(expected)${semi}`,
+    `  })${semi}`,
+    `})${semi}`,
+  ]
+  return { imports, body: body.join('\n') + '\n' }
+}
+
+function segments(path: string): string[] {
+  return path.split(/[\\/]/).filter(segment => segment && segment !== '.')
+}
+
+function toRelative(root: string, path: string): string {
+  return relative(root, path).replace(/\\/g, '/')
+}
+
+function toSnakeCase(name: string): string {
+  return name.replace(/([a-z0-9])([A-Z])/g, '$1_$2').toLowerCase()
+}
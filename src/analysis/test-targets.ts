@@ -0,0 +1,254 @@
+/**
+ * Test targets - ranks functions by how much they would gain from more tests, combining line
+ * and branch coverage from an lcov, Go cover profile or Cobertura report with complexity and
+ * recent churn, and points at the branches and lines no test reaches
+ */
+
+import { readFileSync } from 'fs'
+import { join, resolve } from 'path'
+import { calculateComplexity } from './quality-metrics.js'
+import { getLanguageForFile } from '../core/languages.js'
+import { PROJECT_FILES, isTestFile } from '../constants/index.js'
+import { isFile } from '../utils/helpers.js'
+import type { TreeNode } from '../types/core.js'
+
+export type CoverageFormat = 'lcov' | 'go' | 'cobertura'
+
+export interface FileCoverage {
+  lines: Map<number, number> // Hits per instrumented line
+  branches: Map<number, { taken: number, total: number }>
+}
+
+export interface CoverageReport {
+  file: string
+  format: CoverageFormat
+  files: Map<string, FileCoverage> // By name as written in the report
+}
+
+export interface AssertionTarget {
+  line: number
+  code: string
+  reason: 'branch not taken' | 'branch not reached' | 'not run' | 'branch'
+}
+
+export interface TestTarget {
+  name: string
+  file: string
+  line: number
+  endLine?: number
+  score: number
+  complexity: number
+  coverage?: number // Share of instrumented lines run, unset without coverage for the file
+  commits: number // In the churn window
+  targets: AssertionTarget[]
+}
+
+export interface TestTargetOptions {
+  coverage?: CoverageReport
+  churn?: Map<string, number>
+  maxTargets?: number
+}
+
+const CALLABLE_KINDS = ['function', 'method']
+const BRANCH = /\b(?:if|else|elif|case|catch|except|switch|match|when)\b|&&|\|\||\?\?|\?[^.:]/
+const DEFAULT_MAX_TARGETS = 5
+
+/**
+ * Reads the given coverage report, or the first of the usual report paths in the directory
+ */
+export function readCoverageReport(directory: string, coverageFile?: string): CoverageReport | undefined {
+  const candidates = coverageFile ? [resolve(directory, coverageFile)] : PROJECT_FILES.COVERAGE_REPORTS.map(path => join(directory, path))
+  const file = candidates.find(isFile)
+  if (!file) {
+    if (coverageFile) throw new Error(`Coverage report not found: ${coverageFile}`)
+    return undefined
+  }
+  return parseCoverageReport(readFileSync(file, 'utf-8'), file)
+}
+
+export function parseCoverageReport(content: string, file: string): CoverageReport {
+  if (/^mode:\s*\w+/.test(content)) return { file, format: 'go', files: parseGoProfile(content) }
+  if (/<coverage\b/.test(content)) return { file, format: 'cobertura', files: parseCobertura(content) }
+  if (/^(?:TN|SF):/m.test(content)) return { file, format: 'lcov', files: parseLcov(content) }
+  throw new Error(`Unrecognized coverage report format: ${file}`)
+}
+
+/**
+ * Ranks the functions among the given nodes. A function's score is its complexity, scaled by
+ * the share of it no test runs (all of it when the report covers its language but not its file)
+ * and by the log of the recent commits to its file. Fully covered functions are left out.
+ */
+export function suggestTestTargets(nodes: TreeNode[], root: string, options: TestTargetOptions = {}): TestTarget[] {
+  const { coverage, churn = new Map<string, number>(), maxTargets = DEFAULT_MAX_TARGETS } = options
+  const functions = uniqueFunctions(nodes)
+  const covered = coverage ? matchCoverage(coverage, [...new Set(functions.map(node => node.path))], root) : new Map<string, FileCoverage>()
+  const reportedLanguages = new Set([...covered.keys()].map(path => getLanguageForFile(path)?.name))
+
+  const suggestions: TestTarget[] = []
+  for (const node of functions) {
+    const complexity = calculateComplexity(node)
+    const fileCoverage = covered.get(node.path)
+    const unreported = coverage !== undefined && !fileCoverage && reportedLanguages.has(getLanguageForFile(node.path)?.name)
+    const { ratio, targets } = assessFunction(node, fileCoverage, unreported, maxTargets)
+    const missed = ratio === undefined ? 1 : 1 - ratio
+    if (missed <= 0) continue
+
+    const commits = churn.get(node.path) ?? 0
+    suggestions.push({
+      name: node.name!,
+      file: node.path,
+      line: node.startLine ?? 1,
+      endLine: node.endLine,
+      score: round(complexity * missed * (1 + Math.log2(1 + commits))),
+      complexity,
+      ...(ratio !== undefined ? { coverage: round(ratio) } : {}),
+      commits,
+      targets,
+    })
+  }
+
+  return suggestions.sort((a, b) => b.score - a.score || a.file.localeCompare(b.file) || a.line - b.line)
+}
+
+/**
+ * The covered share of a function, blending line and branch coverage when the report has both,
+ * and the code no test reaches
+ */
+function assessFunction(node: TreeNode, coverage: FileCoverage | undefined, unreported: boolean, maxTargets: number): { ratio?: number, targets: AssertionTarget[] } {
+  const start = node.startLine ?? 1
+  const lines = node.content!.split('\n')
+  const codeAt = (line: number) => lines[line - start]?.trim() ?? ''
+  const isBranch = (line: number) => BRANCH.test(codeAt(line).replace(/(["'`]).*?\1/g, '""'))
+  // The declaration line is run by any call into the function; the body lines are what count
+  const bodyLines = lines.map((_, index) => start + index).slice(1)
+
+  if (!coverage) {
+    const targets = bodyLines.filter(isBranch).slice(0, maxTargets)
+      .map(line => ({ line, code: codeAt(line), reason: unreported ? 'branch not reached' as const : 'branch' as const }))
+    return { ratio: unreported ? 0 : undefined, targets }
+  }
+
+  const instrumented = bodyLines.filter(line => coverage.lines.has(line))
+  const branches = bodyLines.filter(line => coverage.branches.has(line)).map(line => ({ line, ...coverage.branches.get(line)! }))
+  if (instrumented.length === 0 && branches.length === 0) return { ratio: undefined, targets: [] }
+
+  const lineRatio = instrumented.length > 0 ? instrumented.filter(line => coverage.lines.get(line)! > 0).length / instrumented.length : 1
+  const totalBranches = branches.reduce((sum, branch) => sum + branch.total, 0)
+  const branchRatio = totalBranches > 0 ? branches.reduce((sum, branch) => sum + branch.taken, 0) / totalBranches : undefined
+  const ratio = branchRatio === undefined ? lineRatio : (lineRatio + branchRatio) / 2
+
+  const targets: AssertionTarget[] = []
+  const add = (line: number, reason: AssertionTarget['reason']) => {
+    if (!targets.some(target => target.line === line)) targets.push({ line, code: codeAt(line), reason })
+  }
+  for (const branch of branches) {
+    if (branch.taken < branch.total) add(branch.line, coverage.lines.get(branch.line) === 0 ? 'branch not reached' : 'branch not taken')
+  }
+  // The first line of each run of unexecuted lines, branches before plain statements
+  const unrun = instrumented.filter((line, index) => coverage.lines.get(line) === 0 && (index === 0 || coverage.lines.get(instrumented[index - 1]!)! > 0))
+  unrun.filter(isBranch).forEach(line => add(line, 'branch not reached'))
+  unrun.forEach(line => add(line, 'not run'))
+
+  return { ratio, targets: targets.slice(0, maxTargets).sort((a, b) => a.line - b.line) }
+}
+
+/**
+ * Keys the report's files by indexed path. Reports name files absolutely, relative to the
+ * project, or by Go import path, so names are matched on their longest path suffix.
+ */
+function matchCoverage(coverage: CoverageReport, paths: string[], root: string): Map<string, FileCoverage> {
+  const matched = new Map<string, FileCoverage>()
+  for (const [name, fileCoverage] of coverage.files) {
+    const normalized = name.replace(/\\/g, '/')
+    let path = paths.find(candidate => candidate === normalized || candidate === resolve(root, normalized))
+    if (!path) {
+      const segments = normalized.split('/')
+      for (let i = 1; i < segments.length && !path; i++) {
+        const suffix = `/${segments.slice(i).join('/')}`
+        const found = paths.filter(candidate => candidate.endsWith(suffix))
+        if (found.length === 1) path = found[0]
+      }
+    }
+    if (path) matched.set(path, fileCoverage)
+  }
+  return matched
+}
+
+function parseLcov(content: string): Map<string, FileCoverage> {
+  const files = new Map<string, FileCoverage>()
+  let current: FileCoverage | undefined
+  for (const raw of content.split('\n')) {
+    const line = raw.trim()
+    if (line.startsWith('SF:')) {
+      current = fileEntry(files, line.substring(3))
+    }
+    else if (line.startsWith('DA:') && current) {
+      const [number, hits] = line.substring(3).split(',').map(Number) as [number, number]
+      current.lines.set(number, Math.max(current.lines.get(number) ?? 0, hits))
+    }
+    else if (line.startsWith('BRDA:') && current) {
+      const [number, , , taken] = line.substring(5).split(',')
+      const branch = current.branches.get(Number(number)) ?? { taken: 0, total: 0 }
+      branch.total++
+      if (taken !== '-' && Number(taken) > 0) branch.taken++
+      current.branches.set(Number(number), branch)
+    }
+    else if (line === 'end_of_record') {
+      current = undefined
+    }
+  }
+  return files
+}
+
+/**
+ * `go test -coverprofile` output: `path/file.go:startLine.col,endLine.col statements count`
+ */
+function parseGoProfile(content: string): Map<string, FileCoverage> {
+  const files = new Map<string, FileCoverage>()
+  for (const block of content.matchAll(/^(.+):(\d+)\.\d+,(\d+)\.\d+ \d+ (\d+)$/gm)) {
+    const coverage = fileEntry(files, block[1]!)
+    const count = Number(block[4])
+    for (let line = Number(block[2]); line <= Number(block[3]); line++) {
+      coverage.lines.set(line, Math.max(coverage.lines.get(line) ?? 0, count))
+    }
+  }
+  return files
+}
+
+function parseCobertura(content: string): Map<string, FileCoverage> {
+  const files = new Map<string, FileCoverage>()
+  for (const record of content.matchAll(/<class\b[^>]*\bfilename="([^"]+)"[^>]*>([\s\S]*?)<\/class>/g)) {
+    const coverage = fileEntry(files, record[1]!)
+    for (const line of record[2]!.matchAll(/<line\b([^>]*)\/?>/g)) {
+      const number = Number(/\bnumber="(\d+)"/.exec(line[1]!)?.[1])
+      const hits = Number(/\bhits="(\d+)"/.exec(line[1]!)?.[1] ?? 0)
+      if (!number) continue
+      coverage.lines.set(number, Math.max(coverage.lines.get(number) ?? 0, hits))
+      const conditions = /\bcondition-coverage="[^"(]*\((\d+)\/(\d+)\)"/.exec(line[1]!)
+      if (conditions) coverage.branches.set(number, { taken: Number(conditions[1]), total: Number(conditions[2]) })
+    }
+  }
+  return files
+}
+
+function fileEntry(files: Map<string, FileCoverage>, name: string): FileCoverage {
+  let entry = files.get(name)
+  if (!entry) {
+    entry = { lines: new Map(), branches: new Map() }
+    files.set(name, entry)
+  }
+  return entry
+}
+
+function uniqueFunctions(nodes: TreeNode[]): TreeNode[] {
+  const seen = new Set<string>()
+  return nodes.filter((node) => {
+    if (!node.name || !node.content || !CALLABLE_KINDS.includes(node.symbol?.kind ?? node.type) || isTestFile(node.path) || seen.has(node.id)) return false
+    seen.add(node.id)
+    return true
+  })
+}
+
+function round(value: number): number {
+  return Math.round(value * 100) / 100
+}
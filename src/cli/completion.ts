@@ -0,0 +1,271 @@
+/**
+ * Shell completion - small bash/zsh/fish scripts that ask the CLI itself for candidates,
+ * so completions follow the commander definitions as commands and options are added
+ */
+
+import { basename } from 'path'
+import type { Command, Option } from 'commander'
+import { MCP_TOOLS } from '../mcp/schemas.js'
+import { LANGUAGE_CONFIGS } from '../core/languages.js'
+import { listCachedCheckouts } from '../project/remote.js'
+import { sanitizeProjectId } from '../project/persistent-manager.js'
+import { loadProjectSettings } from '../project/settings.js'
+
+export const COMPLETION_SHELLS = ['bash', 'zsh', 'fish'] as const
+export type CompletionShell = typeof COMPLETION_SHELLS[number]
+
+export interface CompletionCandidate {
+  value: string
+  description?: string
+}
+
+/**
+ * Candidates for the word being completed, or a request to fall back to the shell's own
+ * file or directory completion
+ */
+export interface CompletionResult {
+  candidates: CompletionCandidate[]
+  fallback?: 'files' | 'dirs'
+}
+
+type ValueSource = 'files' | 'dirs' | 'project-ids' | 'scopes' | 'queries' | 'tools' | 'languages' | readonly string[]
+
+// Keyed by long flag; options missing here take free-form values
+const OPTION_VALUES: Record<string, ValueSource> = {
+  '--directory': 'dirs',
+  '--ignore-dirs': 'dirs',
+  '--output-file': 'files',
+  '--project-id': 'project-ids',
+  '--scope': 'scopes',
+  '--output': ['json', 'text', 'markdown'],
+  '--analysis-types': ['quality', 'deadcode', 'structure', 'syntax', 'security', 'concurrency', 'resources', 'nullability'],
+  '--min-confidence': ['low', 'medium', 'high'],
+  '--type': ['function', 'method', 'class', 'interface', 'struct', 'enum', 'variable', 'constant'],
+  '--language': 'languages',
+  '--group-by': ['owner', 'directory'],
+  '--mode': ['symbols', 'strings', 'ui', 'comments'],
+  '--comments': ['include', 'exclude', 'only'],
+  '--baseline': 'files',
+}
+
+// Keyed by command path and argument name
+const ARGUMENT_VALUES: Record<string, ValueSource> = {
+  'completion shell': COMPLETION_SHELLS,
+  'tools name': 'tools',
+  'search query': 'queries',
+  'index export file': 'files',
+  'index import file': 'files',
+  'parse-snippet file': 'files',
+}
+
+const COMMAND_NAME = 'tree-sitter-mcp'
+
+/**
+ * Completes the last of `words` (the arguments after the program name, the last one
+ * possibly empty) against the program's commands and options
+ */
+export function completeWords(program: Command, words: string[]): CompletionResult {
+  const current = words.at(-1) ?? ''
+  let command = program
+  let pendingOption: Option | undefined
+  let positional = 0
+  let directory: string | undefined
+
+  for (const word of words.slice(0, -1)) {
+    if (pendingOption && !(pendingOption.variadic && word.startsWith('-'))) {
+      if (pendingOption.long === '--directory') directory = word
+      if (!pendingOption.variadic) pendingOption = undefined
+      continue
+    }
+    pendingOption = undefined
+
+    if (word.startsWith('-')) {
+      const option = findOption(command, word.split('=')[0]!)
+      if (option && (option.required || option.optional) && !word.includes('=')) pendingOption = option
+      continue
+    }
+
+    const subcommand = command.commands.find(sub => sub.name() === word || sub.aliases().includes(word))
+    if (subcommand && positional === 0) {
+      command = subcommand
+      continue
+    }
+    positional++
+  }
+
+  if (pendingOption && !current.startsWith('-')) {
+    return resolveValues(OPTION_VALUES[pendingOption.long ?? ''], current, directory)
+  }
+
+  const help = command.createHelp()
+  if (current.startsWith('-')) {
+    const options = [...help.visibleOptions(command), ...visibleAncestorOptions(command)]
+    const candidates = options.flatMap(option => [option.long, option.short]
+      .filter((flag): flag is string => Boolean(flag))
+      .map(flag => ({ value: flag, description: option.description })))
+    return { candidates: filterCandidates(candidates, current) }
+  }
+
+  const subcommands = help.visibleCommands(command).filter(sub => sub.name() !== 'help')
+  if (subcommands.length > 0 && positional === 0) {
+    return {
+      candidates: filterCandidates(subcommands.map(sub => ({ value: sub.name(), description: sub.description() })), current),
+    }
+  }
+
+  const args = command.registeredArguments
+  const argument = args[Math.min(positional, args.length - 1)]
+  if (!argument || (positional >= args.length && !argument.variadic)) return { candidates: [] }
+  return resolveValues(ARGUMENT_VALUES[`${commandPath(command)} ${argument.name()}`], current, directory)
+}
+
+/**
+ * Returns the completion script for a shell. Each script calls the hidden `__complete`
+ * command with the words typed so far.
+ */
+export function generateCompletionScript(shell: CompletionShell): string {
+  switch (shell) {
+    case 'bash':
+      return `# ${COMMAND_NAME} bash completion
+# Add to ~/.bashrc: source <(${COMMAND_NAME} completion bash)
+_tree_sitter_mcp_completion() {
+  local cur=\${COMP_WORDS[COMP_CWORD]}
+  local candidates
+  candidates=$(${COMMAND_NAME} __complete -- "\${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null)
+  local IFS=$'\\n'
+  case "$candidates" in
+    :files) COMPREPLY=($(compgen -f -- "$cur")) ;;
+    :dirs) COMPREPLY=($(compgen -d -- "$cur")) ;;
+    *) COMPREPLY=($(compgen -W "$(printf '%s\\n' "$candidates" | cut -f1)" -- "$cur")) ;;
+  esac
+}
+complete -o filenames -F _tree_sitter_mcp_completion ${COMMAND_NAME}
+`
+
+    case 'zsh':
+      return `#compdef ${COMMAND_NAME}
+# Add to ~/.zshrc after compinit: source <(${COMMAND_NAME} completion zsh)
+_tree_sitter_mcp() {
+  local -a lines candidates
+  local line value description
+  lines=("\${(@f)$(${COMMAND_NAME} __complete -- "\${(@)words[2,CURRENT]}" 2>/dev/null)}")
+  case "\${lines[1]}" in
+    :files) _files; return ;;
+    :dirs) _files -/; return ;;
+  esac
+  for line in "\${lines[@]}"; do
+    [[ -z "$line" ]] && continue
+    value=\${line%%$'\\t'*}
+    description=\${line#*$'\\t'}
+    candidates+=("\${value//:/\\\\:}\${description:+:$description}")
+  done
+  _describe '${COMMAND_NAME}' candidates
+}
+compdef _tree_sitter_mcp ${COMMAND_NAME}
+`
+
+    case 'fish':
+      return `# ${COMMAND_NAME} fish completion
+# Save to ~/.config/fish/completions/${COMMAND_NAME}.fish: ${COMMAND_NAME} completion fish > ~/.config/fish/completions/${COMMAND_NAME}.fish
+function __tree_sitter_mcp_complete
+  set -l tokens (commandline -opc)
+  set -e tokens[1]
+  set -l current (commandline -ct | string collect --allow-empty)
+  set -l candidates (${COMMAND_NAME} __complete -- $tokens "$current" 2>/dev/null)
+  switch "$candidates[1]"
+    case ':files'
+      __fish_complete_path "$current"
+    case ':dirs'
+      __fish_complete_directories "$current"
+    case '*'
+      printf '%s\\n' $candidates
+  end
+end
+complete -c ${COMMAND_NAME} -f -a '(__tree_sitter_mcp_complete)'
+`
+  }
+}
+
+/**
+ * Renders a completion result in the line format the scripts read: `value<TAB>description`
+ * per candidate, or a single `:files`/`:dirs` line
+ */
+export function formatCompletionResult(result: CompletionResult): string {
+  if (result.fallback) return `:${result.fallback}\n`
+  return result.candidates
+    .map(candidate => `${candidate.value}\t${(candidate.description ?? '').replace(/\s+/g, ' ')}\n`)
+    .join('')
+}
+
+/**
+ * Space-separated names of a command and its parents below the program, e.g. `index export`
+ */
+export function commandPath(command: Command): string {
+  const names: string[] = []
+  for (let current: Command | null = command; current?.parent; current = current.parent) {
+    names.unshift(current.name())
+  }
+  return names.join(' ')
+}
+
+function resolveValues(source: ValueSource | undefined, current: string, directory?: string): CompletionResult {
+  if (source === 'files' || source === 'dirs') return { candidates: [], fallback: source }
+  if (!source) return { candidates: [] }
+
+  let candidates: CompletionCandidate[]
+  if (source === 'project-ids') {
+    // The CLI keeps no registry between runs, so offer the IDs projects get by default
+    const defaultId = sanitizeProjectId(basename(directory ?? process.cwd()))
+    const cached = listCachedCheckouts().map(checkout => ({ value: basename(checkout), description: 'Cached remote checkout' }))
+    candidates = [{ value: defaultId, description: 'Default ID for the project directory' }, ...cached]
+  }
+  else if (source === 'scopes') {
+    const scopes = loadProjectSettings(directory ?? process.cwd()).scopes ?? {}
+    candidates = Object.entries(scopes).map(([value, globs]) => ({ value, description: [globs].flat().join(' ') }))
+  }
+  else if (source === 'queries') {
+    const queries = loadProjectSettings(directory ?? process.cwd()).queries ?? {}
+    candidates = Object.entries(queries).map(([name, query]) => ({ value: `@${name}`, description: query }))
+  }
+  else if (source === 'languages') {
+    candidates = LANGUAGE_CONFIGS.map(language => ({ value: language.name, description: language.extensions.join(' ') }))
+  }
+  else if (source === 'tools') {
+    candidates = MCP_TOOLS.map(tool => ({ value: tool.name, description: summarize(tool.description) }))
+  }
+  else {
+    candidates = source.map(value => ({ value }))
+  }
+
+  return { candidates: filterCandidates(candidates, current) }
+}
+
+function findOption(command: Command, flag: string): Option | undefined {
+  for (let current: Command | null = command; current; current = current.parent) {
+    const option = current.options.find(candidate => candidate.long === flag || candidate.short === flag)
+    if (option) return option
+  }
+  return undefined
+}
+
+function visibleAncestorOptions(command: Command): Option[] {
+  const options: Option[] = []
+  for (let current = command.parent; current; current = current.parent) {
+    options.push(...current.options.filter(option => !option.hidden))
+  }
+  return options
+}
+
+function filterCandidates(candidates: CompletionCandidate[], current: string): CompletionCandidate[] {
+  const seen = new Set<string>()
+  return candidates.filter((candidate) => {
+    if (!candidate.value.startsWith(current) || seen.has(candidate.value)) return false
+    seen.add(candidate.value)
+    return true
+  })
+}
+
+function summarize(description: string): string {
+  if (description.length <= 80) return description
+  return `${description.substring(0, 77).replace(/\s+\S*$/, '')}...`
+}
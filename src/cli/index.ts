@@ -5,16 +5,46 @@
 import { Command } from 'commander'
 import chalk from 'chalk'
 import { execSync } from 'child_process'
-import { analyzeProject, formatAnalysisReport } from '../analysis/index.js'
+import { readFileSync, writeFileSync } from 'fs'
+import { basename, relative, resolve } from 'path'
+import { analyzeProject, diffFindings, formatAnalysisReport } from '../analysis/index.js'
 import { analyzeErrors, partitionErrors } from '../analysis/errors.js'
+import { exportChunks, formatChunksAsJsonl } from '../analysis/chunks.js'
+import { analyzeSnippet } from '../analysis/snippet.js'
+import { compareBenchmarks, listBenchmarks, type BenchmarkMeasurement } from '../analysis/benchmarks.js'
+import { buildRollup, formatRollupTable, readRollupBaseline, ROLLUP_GROUPINGS, type RollupGrouping } from '../analysis/rollup.js'
 import { findDependencyModuleDirs } from '../project/monorepo.js'
-import { searchCode, findUsage } from '../core/search.js'
-import { createPersistentManager, getOrCreateProject } from '../project/persistent-manager.js'
+import { searchCode, findUsage, findConfigKeyUsage } from '../core/search.js'
+import { applySearchQuery } from '../core/query.js'
+import { expandSavedQueries } from '../project/saved-queries.js'
+import { isKeyPath } from '../core/config-keys.js'
+import { createGoBuildIndex } from '../core/go-build.js'
+import { createPreprocessorIndex, parseDefines } from '../core/preprocessor.js'
+import { findAliasedDefinitions, findAliasExpressions } from '../import/aliases.js'
+import { symbolId } from '../core/symbol-ids.js'
+import { createPersistentManager, getOrCreateProject, loadProjectFromIndex } from '../project/persistent-manager.js'
+import { scopeProject } from '../project/scopes.js'
+import { loadProjectAtRef } from '../project/git-history.js'
+import { loadCodeOwners, ownersOf } from '../project/codeowners.js'
+import { exportIndex } from '../project/index-archive.js'
+import { updateProject } from '../project/manager.js'
+import { createFileWatcher } from '../core/watcher.js'
+import { COMMENT_FILTERS, searchStrings, STRING_SEARCH_MODES, type CommentFilter, type StringSearchMode } from '../core/strings.js'
 import { startMCPServer } from '../mcp/server.js'
+import { startLSPServer } from '../lsp/server.js'
+import { startGRPCServer } from '../grpc/server.js'
+import { loadTenants } from '../mcp/tenants.js'
+import type { CallLimits } from '../mcp/limits.js'
+import { MCP_TOOLS } from '../mcp/schemas.js'
+import { COMPLETION_SHELLS, commandPath, completeWords, formatCompletionResult, generateCompletionScript, type CompletionShell } from './completion.js'
+import { CLI_EXAMPLES, type CliExample } from '../constants/cli-examples.js'
+import { BENCHMARK_CONFIG } from '../constants/persistence.js'
+import { CONFIDENCE_LEVELS } from '../constants/patterns.js'
 import { renderAnalysis, type AnalysisData, SETUP_TEMPLATE, SETUP_AUTO_SUCCESS_TEMPLATE, SETUP_AUTO_EXISTS_TEMPLATE, SETUP_AUTO_FAILED_TEMPLATE, SETUP_CLAUDE_NOT_FOUND_TEMPLATE } from '../constants/templates.js'
 import { initializeLogger, getLogger } from '../utils/logger.js'
 import { getVersion } from '../utils/version.js'
-import type { AnalysisOptions as CoreAnalysisOptions } from '../types/analysis.js'
+import type { AnalysisOptions as CoreAnalysisOptions, AnalysisRollup, Confidence, Finding, FindingsDiff } from '../types/analysis.js'
+import type { FileChange, Project } from '../types/core.js'
 
 const persistentManager = createPersistentManager(10)
 
@@ -24,6 +54,19 @@ export function createCLI(): Command {
     .description('Tree-sitter MCP server for code analysis and search')
     .version(getVersion())
     .option('--mcp', 'Run as MCP server')
+    .option('--read-only', 'With --mcp or --grpc-port: refuse tool calls that write files')
+    .option('--lsp', 'Run as a language server on stdio instead of the MCP server')
+    .option('--lsp-port <port>', 'With --mcp: also serve LSP on this local TCP port, sharing the index')
+    .option('--grpc-port <port>', 'Serve the gRPC API on this TCP port; with --mcp, alongside the MCP server')
+    .option('--grpc-host <host>', 'With --grpc-port: address to listen on (default: 127.0.0.1)')
+    .option('--tenants <file>', 'With --grpc-port: require a token per call, restricted to the projects and quotas this file grants it')
+    .option('--max-concurrent-calls <n>', 'With --mcp or --grpc-port: tool calls a client may run at once')
+    .option('--max-calls-per-minute <n>', 'With --mcp or --grpc-port: tool calls a client may make per minute; each call of a batch counts')
+    .option('--max-files-per-call <n>', 'With --mcp or --grpc-port: refuse calls that would index a project with more files')
+    .option('--no-result-cache', 'With --mcp or --grpc-port: recompute repeated tool calls instead of answering them from cache')
+    .option('--language-server <server=command>', 'With --mcp or --grpc-port: command line of a language server (gopls, tsserver, pyright), e.g. "gopls=/opt/go/bin/gopls serve"; repeatable', (value: string, previous: string[]) => [...previous, value], [])
+    .option('--allow-file-remotes', 'With --mcp or --grpc-port: let register_project clone file:// URLs, which reach any repository on this machine')
+    .option('--format-edits', 'With --mcp or --grpc-port: run edited files through their formatter unless a call passes format: false')
     .option('--debug', 'Enable debug logging')
     .option('--quiet', 'Suppress non-error output')
 
@@ -33,10 +76,15 @@ export function createCLI(): Command {
     .option('-d, --directory <dir>', 'Directory to search (default: current directory)')
     .option('-p, --project-id <id>', 'Optional: Project ID for persistent AST caching')
     .option('--path-pattern <pattern>', 'Optional: Filter results to files containing this text in their path')
+    .option('--scope <name>', 'Optional: Named scope from .tree-sitter-mcp.json to restrict the command to')
     .option('-t, --type <types...>', 'Filter by element types (function, class, etc.)')
+    .option('--ref <ref>', 'Optional: Search the code as it was at a git ref (branch, tag or commit) instead of the working tree')
+    .option('--build-tags <tags>', 'Optional: Only Go files that build with these tags (e.g. linux, windows/amd64, linux,integration)')
+    .option('--defines <defines>', 'Optional: Only C/C++ declarations compiled with these defines (e.g. _WIN32,DEBUG or "-DVERSION=2")')
     .option('-m, --max-results <num>', 'Maximum number of results', '10')
     .option('--fuzzy-threshold <num>', 'Minimum fuzzy match score (0-100)', '30')
     .option('--exact', 'Exact match only')
+    .option('--mode <mode>', 'What to search: symbols, strings (string literal text), ui (user-facing text only) or comments', 'symbols')
     .option('--force-content-inclusion', 'Force content inclusion even with 4+ results')
     .option('--max-content-lines <num>', 'Maximum lines for content truncation', '150')
     .option('--disable-content-inclusion', 'Disable content inclusion entirely')
@@ -50,10 +98,15 @@ export function createCLI(): Command {
     .option('-d, --directory <dir>', 'Directory to analyze (default: current directory)')
     .option('-p, --project-id <id>', 'Optional: Project ID for persistent AST caching')
     .option('--path-pattern <pattern>', 'Optional: Filter results to files containing this text in their path')
-    .option('-a, --analysis-types <types...>', 'Analysis types to run: quality, deadcode, structure (default: quality)', ['quality'])
+    .option('--scope <name>', 'Optional: Named scope from .tree-sitter-mcp.json to restrict the command to')
+    .option('-a, --analysis-types <types...>', 'Analysis types to run: quality, deadcode, structure, security, concurrency, resources, nullability (default: quality)', ['quality'])
+    .option('--min-confidence <level>', 'Optional: Lowest confidence of nullability findings to report (low, medium, high)', 'medium')
     .option('--ignore-dirs <dirs...>', 'Additional directories to ignore (beyond default ignore list)')
     .option('--max-results <num>', 'Maximum number of findings to return', '15')
     .option('--output <format>', 'Output format (json, text, markdown)', 'json')
+    .option('--group-by <key>', 'Optional: Roll findings up per CODEOWNERS team or top-level directory (owner, directory)')
+    .option('--baseline <file>', 'Optional: Previous JSON report with a rollup to show per-group trends against')
+    .option('-w, --watch', 'Keep running and re-analyze on save, printing only new and resolved findings')
     .action(handleAnalysis)
 
   program
@@ -62,6 +115,7 @@ export function createCLI(): Command {
     .option('-d, --directory <dir>', 'Directory to analyze (default: current directory)')
     .option('-p, --project-id <id>', 'Optional: Project ID for persistent AST caching')
     .option('--path-pattern <pattern>', 'Optional: Filter results to files containing this text in their path')
+    .option('--scope <name>', 'Optional: Named scope from .tree-sitter-mcp.json to restrict the command to')
     .option('--ignore-dirs <dirs...>', 'Additional directories to ignore (beyond default ignore list)')
     .option('--max-results <num>', 'Maximum number of errors to return', '50')
     .option('--output <format>', 'Output format (json, text)', 'json')
@@ -73,13 +127,95 @@ export function createCLI(): Command {
     .option('-d, --directory <dir>', 'Directory to search (default: current directory)')
     .option('-p, --project-id <id>', 'Optional: Project ID for persistent AST caching')
     .option('--path-pattern <pattern>', 'Optional: Filter results to files containing this text in their path')
+    .option('--scope <name>', 'Optional: Named scope from .tree-sitter-mcp.json to restrict the command to')
     .option('--case-sensitive', 'Case sensitive search')
     .option('--exact', 'Exact match only')
+    .option('--comments <filter>', 'Matches inside comments and docstrings: include, exclude or only', 'include')
     .option('--ignore-dirs <dirs...>', 'Additional directories to ignore (beyond default ignore list)')
     .option('-m, --max-results <num>', 'Maximum number of results', '50')
     .option('--output <format>', 'Output format (json, text)', 'json')
     .action(handleFindUsage)
 
+  program
+    .command('export-chunks')
+    .description('Export symbol-aligned code chunks with metadata as JSONL for embedding/RAG pipelines')
+    .option('-d, --directory <dir>', 'Directory to export (default: current directory)')
+    .option('-p, --project-id <id>', 'Optional: Project ID for persistent AST caching')
+    .option('--path-pattern <pattern>', 'Optional: Filter results to files containing this text in their path')
+    .option('--scope <name>', 'Optional: Named scope from .tree-sitter-mcp.json to restrict the command to')
+    .option('--max-tokens <num>', 'Approximate token budget per chunk', '512')
+    .option('--no-module-code', 'Leave out code between symbols (imports, top-level statements)')
+    .option('--ignore-dirs <dirs...>', 'Additional directories to ignore (beyond default ignore list)')
+    .option('-o, --output-file <file>', 'Write the JSONL to this file instead of stdout')
+    .action(handleExportChunks)
+
+  program
+    .command('parse-snippet [file]')
+    .description('Parse source text from stdin (or a single file) and report its outline, symbols, syntax errors and complexity')
+    .option('-l, --language <lang>', 'Language name, alias or extension (e.g. typescript, py, .go); inferred from the file name when given')
+    .option('--filename <name>', 'File name to report locations under (default: snippet.<ext>)')
+    .option('--output <format>', 'Output format (json, text)', 'json')
+    .action(handleParseSnippet)
+
+  const index = program
+    .command('index')
+    .description('Export or import a prebuilt index archive, e.g. to share the index built in CI')
+
+  index
+    .command('export <file>')
+    .description('Parse the project and write its index to a gzip archive')
+    .option('-d, --directory <dir>', 'Directory to index (default: current directory)')
+    .option('-p, --project-id <id>', 'Optional: Project ID for persistent AST caching')
+    .option('--ignore-dirs <dirs...>', 'Additional directories to ignore (beyond default ignore list)')
+    .action(handleIndexExport)
+
+  index
+    .command('import <file>')
+    .description('Load an index archive, re-parsing files changed since it was exported')
+    .option('-d, --directory <dir>', 'Directory the archive was built from (default: current directory)')
+    .option('-p, --project-id <id>', 'Optional: Project ID for persistent AST caching')
+    .option('--ignore-dirs <dirs...>', 'Override the ignore list recorded in the archive')
+    .option('--update', 'Write the refreshed index back to the archive')
+    .action(handleIndexImport)
+
+  const bench = program
+    .command('bench')
+    .description('List Go and JavaScript benchmarks, or compare them between two git refs')
+
+  bench
+    .command('list')
+    .description('List benchmarks with the command running each')
+    .option('-d, --directory <dir>', 'Project directory (default: current directory)')
+    .option('--bench <pattern>', 'Regular expression over benchmark names')
+    .action(handleBenchList)
+
+  bench
+    .command('compare <base> [head]')
+    .description('Run benchmarks in worktrees of two refs (head defaults to the working tree) and report the change of each')
+    .option('-d, --directory <dir>', 'Project directory (default: current directory)')
+    .option('--bench <pattern>', 'Regular expression over benchmark names')
+    .option('--count <n>', 'Runs per benchmark and ref; the median is compared', String(BENCHMARK_CONFIG.DEFAULT_COUNT))
+    .option('--threshold <percent>', 'Smaller changes are reported as unchanged', String(BENCHMARK_CONFIG.DEFAULT_THRESHOLD_PERCENT))
+    .option('--output <format>', 'Output format (json, text)', 'json')
+    .action(handleBenchCompare)
+
+  program
+    .command('tools [name]')
+    .description('List the MCP server tools, or show the parameters of one tool')
+    .action(handleTools)
+
+  program
+    .command('completion <shell>')
+    .description(`Print the shell completion script (${COMPLETION_SHELLS.join(', ')})`)
+    .action(handleCompletion)
+
+  program
+    .command('__complete [words...]', { hidden: true })
+    .description('Print completion candidates for the words typed so far (used by the completion scripts)')
+    .action((words: string[] = []) => {
+      process.stdout.write(formatCompletionResult(completeWords(program, words.length > 0 ? words : [''])))
+    })
+
   program
     .command('setup')
     .description('Setup MCP integration')
@@ -88,6 +224,8 @@ export function createCLI(): Command {
 
   program.action(handleDefaultAction)
 
+  addExplainOptions(program)
+
   return program
 }
 
@@ -95,10 +233,15 @@ interface SearchOptions {
   directory?: string
   projectId?: string
   pathPattern?: string
+  scope?: string
   type?: string[]
+  ref?: string
+  buildTags?: string
+  defines?: string
   maxResults: string
   fuzzyThreshold: string
   exact?: boolean
+  mode: string
   ignoreDirs?: string[]
   output: string
   debug?: boolean
@@ -116,12 +259,19 @@ async function handleSearch(query: string, options: SearchOptions): Promise<void
   try {
     logger.info(`Searching for: ${query}`)
 
-    const project = await getOrCreateProject(persistentManager, {
+    if (options.mode !== 'symbols' && !STRING_SEARCH_MODES.includes(options.mode as StringSearchMode)) {
+      throw new Error(`Invalid mode value: ${options.mode}. Must be one of: symbols, ${STRING_SEARCH_MODES.join(', ')}.`)
+    }
+
+    const snapshot = options.ref
+      ? await loadProjectAtRef(resolve(options.directory || process.cwd()), options.ref, options.projectId)
+      : undefined
+    const project = scopeProject(snapshot?.project ?? await getOrCreateProject(persistentManager, {
       directory: options.directory || process.cwd(),
       languages: [],
       ignoreDirs: options.ignoreDirs || [],
       autoWatch: false,
-    }, options.projectId)
+    }, options.projectId), options.scope)
 
     const allNodes = Array.from(project.files.values())
     const elementNodes = Array.from(project.nodes.values()).flat()
@@ -133,7 +283,11 @@ async function handleSearch(query: string, options: SearchOptions): Promise<void
       }
     }
 
-    const searchNodes = [...allNodes, ...elementNodes]
+    const goBuild = createGoBuildIndex([...allNodes, ...elementNodes])
+    const preprocessor = createPreprocessorIndex([...allNodes, ...elementNodes])
+    const compiledWith = options.defines !== undefined ? parseDefines(options.defines) : undefined
+    const searchNodes = [...allNodes, ...elementNodes].filter(node => (!options.buildTags || goBuild.matches(node.path, options.buildTags))
+      && (!compiledWith || preprocessor.matches(node, compiledWith)))
 
     let maxResults = 10
     if (options.maxResults) {
@@ -162,12 +316,51 @@ async function handleSearch(query: string, options: SearchOptions): Promise<void
       maxContentLines = parsed
     }
 
-    const results = searchCode(query, searchNodes, {
+    const codeOwners = loadCodeOwners(project.config.directory)
+
+    if (options.mode !== 'symbols') {
+      const matches = searchStrings(query, allNodes, {
+        mode: options.mode as StringSearchMode,
+        maxResults,
+        pathPattern: options.pathPattern,
+      })
+
+      if (options.output === 'json') {
+        logger.output(JSON.stringify({
+          ref: snapshot?.ref,
+          commit: snapshot?.commit,
+          query,
+          mode: options.mode,
+          results: matches.map(match => ({ ...match, owners: ownersOf(codeOwners, match.file) })),
+          totalResults: matches.length,
+        }, null, 2))
+        return
+      }
+
+      if (matches.length === 0) {
+        logger.output(chalk.yellow('No results found'))
+        return
+      }
+
+      logger.output(chalk.cyan(`Found ${matches.length} strings${snapshot ? ` at ${snapshot.ref} (${snapshot.commit.substring(0, 12)})` : ''}:\n`))
+      for (const match of matches) {
+        logger.output(`${chalk.green('●')} ${chalk.bold(JSON.stringify(match.text))} ${chalk.dim(`(${match.attribute ?? match.kind})`)}`)
+        logger.output(`  ${chalk.dim(match.file)}:${match.line}${match.column ? `:${match.column}` : ''}${match.symbol ? chalk.dim(` in ${match.symbol}`) : ''}`)
+        logger.output(`  ${chalk.dim('Score:')} ${match.score}`)
+        logger.output('')
+      }
+      return
+    }
+
+    const expandedQuery = expandSavedQueries(project.config.directory, query)
+    const structured = applySearchQuery(expandedQuery, searchNodes, project.config.directory)
+    const results = searchCode(structured.query, structured.nodes, {
       maxResults,
       fuzzyThreshold,
       exactMatch: options.exact,
       types: options.type,
       pathPattern: options.pathPattern,
+      aliasMatches: structured.query === expandedQuery ? findAliasedDefinitions(project, expandedQuery) : [],
       // New content inclusion options
       forceContentInclusion: options.forceContentInclusion,
       maxContentLines,
@@ -176,10 +369,15 @@ async function handleSearch(query: string, options: SearchOptions): Promise<void
 
     if (options.output === 'json') {
       logger.output(JSON.stringify({
+        ref: snapshot?.ref,
+        commit: snapshot?.commit,
         query,
+        expandedQuery: expandedQuery !== query ? expandedQuery : undefined,
         results: results.map(r => ({
+          id: symbolId(project.config.directory, r.node),
           name: r.node.name,
           type: r.node.type,
+          symbol: r.node.symbol,
           path: r.node.path,
           startLine: r.node.startLine,
           endLine: r.node.endLine,
@@ -187,11 +385,15 @@ async function handleSearch(query: string, options: SearchOptions): Promise<void
           endColumn: r.node.endColumn,
           score: r.score,
           matches: r.matches,
+          reExports: r.reExports,
           // New content inclusion fields
           contentIncluded: r.contentIncluded,
           content: r.content,
           contentTruncated: r.contentTruncated,
           contentLines: r.contentLines,
+          build: goBuild.describe(r.node),
+          preprocessor: preprocessor.describe(r.node),
+          owners: ownersOf(codeOwners, r.node.path),
         })),
         totalResults: results.length,
       }, null, 2))
@@ -203,13 +405,31 @@ async function handleSearch(query: string, options: SearchOptions): Promise<void
       return
     }
 
-    logger.output(chalk.cyan(`Found ${results.length} results:\n`))
+    logger.output(chalk.cyan(`Found ${results.length} results${snapshot ? ` at ${snapshot.ref} (${snapshot.commit.substring(0, 12)})` : ''}:\n`))
 
     for (const result of results) {
       const { node, score } = result
       logger.output(`${chalk.green('●')} ${chalk.bold(node.name || 'unnamed')} ${chalk.dim(`(${node.type})`)}`)
       logger.output(`  ${chalk.dim(node.path)}${node.startLine ? ':' + node.startLine : ''}`)
       logger.output(`  ${chalk.dim('Score:')} ${score}`)
+      const owners = ownersOf(codeOwners, node.path)
+      if (owners && owners.length > 0) {
+        logger.output(`  ${chalk.dim('Owners:')} ${owners.join(' ')}`)
+      }
+      const build = goBuild.describe(node)
+      if (build) {
+        const variants = build.variants.map(variant => `${relative(project.config.directory, variant.path)}${variant.constraint ? ` (${variant.constraint})` : ''}`)
+        logger.output(`  ${chalk.dim('Build:')} ${build.constraint ?? 'all platforms'}${variants.length > 0 ? chalk.dim(`, also in ${variants.join(', ')}`) : ''}`)
+      }
+      const conditional = preprocessor.describe(node)
+      if (conditional) {
+        const variants = conditional.variants.map(variant => `${relative(project.config.directory, variant.path)}:${variant.line ?? 1}${variant.guard ? ` (${variant.guard})` : ''}`)
+        logger.output(`  ${chalk.dim('Compiled if:')} ${conditional.guard ?? 'always'}${variants.length > 0 ? chalk.dim(`, also at ${variants.join(', ')}`) : ''}`)
+      }
+      if (result.reExports) {
+        const chain = result.reExports.map(hop => `${hop.name} (${relative(project.config.directory, hop.path)})`)
+        logger.output(`  ${chalk.dim('Re-exported via:')} ${chain.join(' → ')}`)
+      }
 
       // Show content inclusion status and content if available
       if (result.contentIncluded && result.content) {
@@ -259,10 +479,15 @@ interface AnalysisOptions {
   directory?: string
   projectId?: string
   pathPattern?: string
+  scope?: string
   analysisTypes?: string[]
   ignoreDirs?: string[]
   maxResults?: string
   output?: string
+  groupBy?: string
+  baseline?: string
+  minConfidence?: string
+  watch?: boolean
   debug?: boolean
   quiet?: boolean
 }
@@ -271,6 +496,7 @@ interface ErrorsOptions {
   directory?: string
   projectId?: string
   pathPattern?: string
+  scope?: string
   ignoreDirs?: string[]
   maxResults?: string
   output?: string
@@ -284,6 +510,16 @@ async function handleAnalysis(options: AnalysisOptions): Promise<void> {
   try {
     const analysisTypes = options.analysisTypes || ['quality']
 
+    if (options.groupBy !== undefined && !ROLLUP_GROUPINGS.includes(options.groupBy as RollupGrouping)) {
+      throw new Error(`Invalid group-by value: ${options.groupBy}. Must be one of: ${ROLLUP_GROUPINGS.join(', ')}`)
+    }
+    if (options.baseline && !options.groupBy) {
+      throw new Error('--baseline needs --group-by')
+    }
+    if (options.minConfidence !== undefined && !CONFIDENCE_LEVELS.includes(options.minConfidence as Confidence)) {
+      throw new Error(`Invalid min-confidence value: ${options.minConfidence}. Must be one of: ${CONFIDENCE_LEVELS.join(', ')}`)
+    }
+
     const project = await getOrCreateProject(persistentManager, {
       directory: options.directory || process.cwd(),
       ignoreDirs: options.ignoreDirs || [],
@@ -296,26 +532,19 @@ async function handleAnalysis(options: AnalysisOptions): Promise<void> {
       includeDeadcode: analysisTypes.includes('deadcode'),
       includeStructure: analysisTypes.includes('structure'),
       includeSyntax: analysisTypes.includes('syntax'),
+      includeSecurity: analysisTypes.includes('security'),
+      includeConcurrency: analysisTypes.includes('concurrency'),
+      includeResources: analysisTypes.includes('resources'),
+      includeNullability: analysisTypes.includes('nullability'),
+      minConfidence: options.minConfidence as Confidence | undefined,
       excludePaths: depDirs,
     }
 
     logger.info(`Analyzing ${project.config.directory} (project: ${project.id})...`)
 
-    const result = await analyzeProject(project, analysisOptions)
+    const result = await analyzeProject(scopeProject(project, options.scope), analysisOptions)
 
-    let filteredFindings = result.findings
-    if (options.pathPattern) {
-      filteredFindings = result.findings.filter(finding =>
-        finding.location.includes(options.pathPattern!),
-      )
-    }
-
-    const severityOrder = { critical: 0, warning: 1, info: 2 }
-    filteredFindings.sort((a, b) => {
-      const aOrder = severityOrder[a.severity] ?? 3
-      const bOrder = severityOrder[b.severity] ?? 3
-      return aOrder - bOrder
-    })
+    const filteredFindings = selectFindings(result.findings, options.pathPattern)
 
     let maxResults = 15
     if (options.maxResults) {
@@ -325,11 +554,22 @@ async function handleAnalysis(options: AnalysisOptions): Promise<void> {
       }
       maxResults = parsed
     }
+    const codeOwners = loadCodeOwners(project.config.directory)
     const limitedFindings = filteredFindings.slice(0, maxResults)
+      .map(finding => codeOwners ? { ...finding, owners: ownersOf(codeOwners, finding.location) } : finding)
+
+    const rollup = options.groupBy
+      ? buildRollup(filteredFindings, {
+          groupBy: options.groupBy as RollupGrouping,
+          directory: project.config.directory,
+          codeOwners,
+        }, options.baseline ? readBaselineReport(options.baseline, options.groupBy as RollupGrouping) : undefined)
+      : undefined
 
     const filteredResult = {
       ...result,
       findings: limitedFindings,
+      rollup,
     }
 
     const { metrics, summary } = filteredResult
@@ -354,10 +594,15 @@ async function handleAnalysis(options: AnalysisOptions): Promise<void> {
       logger.output(JSON.stringify(filteredResult, null, 2))
     }
     else if (options.output === 'markdown') {
-      logger.output(formatAnalysisReport(filteredResult))
+      logger.output(formatAnalysisReport(filteredResult) + (rollup ? `\n${formatRollupTable(rollup)}` : ''))
     }
     else {
       logger.output('\n' + renderAnalysis(analysisData, 'console'))
+      if (rollup) printRollup(rollup)
+    }
+
+    if (options.watch) {
+      await watchAnalysis(project, analysisOptions, options, filteredFindings)
     }
   }
   catch (error) {
@@ -380,15 +625,124 @@ async function handleAnalysis(options: AnalysisOptions): Promise<void> {
   }
 }
 
+function selectFindings(findings: Finding[], pathPattern?: string): Finding[] {
+  const selected = pathPattern ? findings.filter(finding => finding.location.includes(pathPattern)) : [...findings]
+
+  const severityOrder = { critical: 0, warning: 1, info: 2 }
+  return selected.sort((a, b) => {
+    const aOrder = severityOrder[a.severity] ?? 3
+    const bOrder = severityOrder[b.severity] ?? 3
+    return aOrder - bOrder
+  })
+}
+
+function readBaselineReport(file: string, groupBy: RollupGrouping): AnalysisRollup {
+  let report: unknown
+  try {
+    report = JSON.parse(readFileSync(resolve(file), 'utf-8'))
+  }
+  catch (error) {
+    throw new Error(`Cannot read baseline report ${file}: ${error instanceof Error ? error.message : String(error)}`)
+  }
+  return readRollupBaseline(report, groupBy)
+}
+
+function printRollup(rollup: AnalysisRollup): void {
+  const logger = getLogger()
+  const width = Math.max(...rollup.groups.map(group => group.group.length), 5)
+  const delta = (value?: number) => value === undefined || value === 0
+    ? ''
+    : value > 0 ? chalk.red(` (+${value})`) : chalk.green(` (${value})`)
+
+  logger.output(chalk.bold(`Findings by ${rollup.groupBy}${rollup.baselineAt ? ` (trend since ${rollup.baselineAt})` : ''}:`))
+  for (const group of rollup.groups) {
+    logger.output(`  ${group.group.padEnd(width)}  ${group.total} total${delta(group.trend?.total)}, `
+      + `${group.critical} critical${delta(group.trend?.critical)}, ${group.warning} warnings${delta(group.trend?.warning)}`)
+  }
+}
+
+/**
+ * Re-runs the analysis after every burst of saves until interrupted. Bursts arriving while
+ * an analysis is running are queued and applied together afterwards.
+ */
+async function watchAnalysis(
+  project: Project,
+  analysisOptions: CoreAnalysisOptions,
+  options: AnalysisOptions,
+  initialFindings: Finding[],
+): Promise<void> {
+  const logger = getLogger()
+  let findings = initialFindings
+  let pending: FileChange[] = []
+  let running = false
+
+  const rerun = async () => {
+    running = true
+    while (pending.length > 0) {
+      const changes = pending
+      pending = []
+      try {
+        await updateProject(project, changes)
+        const result = await analyzeProject(scopeProject(project, options.scope), analysisOptions)
+        const current = selectFindings(result.findings, options.pathPattern)
+        printFindingsDiff(project, changes, diffFindings(findings, current), current.length, options.output)
+        findings = current
+      }
+      catch (error) {
+        logger.error('Re-analysis failed:', error)
+      }
+    }
+    running = false
+  }
+
+  const watcher = createFileWatcher(project.config.directory, (changes) => {
+    pending.push(...changes)
+    if (!running) void rerun()
+  })
+  watcher.start()
+  logger.info('Watching for changes (Ctrl+C to stop)')
+
+  await new Promise<void>((resolveWatch) => {
+    process.once('SIGINT', () => {
+      watcher.stop()
+      resolveWatch()
+    })
+  })
+}
+
+function printFindingsDiff(project: Project, changes: FileChange[], diff: FindingsDiff, totalFindings: number, output?: string): void {
+  const logger = getLogger()
+  const changedFiles = Array.from(new Set(changes.map(change => relative(project.config.directory, change.path))))
+
+  if (output === 'json') {
+    // One line per update so the stream can be consumed as JSON Lines
+    logger.output(JSON.stringify({ changedFiles, newFindings: diff.added, resolvedFindings: diff.resolved, totalFindings }))
+    return
+  }
+
+  const time = new Date().toLocaleTimeString()
+  const files = changedFiles.length === 1 ? changedFiles[0] : `${changedFiles.length} files`
+  logger.output(chalk.dim(`[${time}] ${files} changed: ${diff.added.length} new, ${diff.resolved.length} resolved (${totalFindings} findings)`))
+
+  const describe = (finding: Finding) =>
+    `${finding.severity.padEnd(8)} ${relative(project.config.directory, finding.location)}  ${finding.description}`
+  for (const finding of diff.added) {
+    logger.output(chalk.red(`  + ${describe(finding)}`))
+  }
+  for (const finding of diff.resolved) {
+    logger.output(chalk.green(`  - ${describe(finding)}`))
+  }
+}
+
 async function handleErrors(options: ErrorsOptions): Promise<void> {
   const logger = initializeLogger(options.debug ? 'debug' : 'info', options.quiet)
 
   try {
-    const project = await getOrCreateProject(persistentManager, {
+    const project = scopeProject(await getOrCreateProject(persistentManager, {
       directory: options.directory || process.cwd(),
       ignoreDirs: options.ignoreDirs || [],
       autoWatch: false,
-    }, options.projectId)
+    }, options.projectId), options.scope)
 
     logger.info(`Finding errors in ${project.config.directory} (project: ${project.id})...`)
     const result = analyzeErrors(project)
@@ -544,8 +898,10 @@ interface FindUsageOptions {
   directory?: string
   projectId?: string
   pathPattern?: string
+  scope?: string
   caseSensitive?: boolean
   exact?: boolean
+  comments: string
   ignoreDirs?: string[]
   maxResults: string
   output: string
@@ -575,12 +931,16 @@ async function handleFindUsage(identifier: string, options: FindUsageOptions): P
 
     logger.info(`Finding usage of: ${identifier}`)
 
-    const project = await getOrCreateProject(persistentManager, {
+    if (!COMMENT_FILTERS.includes(options.comments as CommentFilter)) {
+      throw new Error(`Invalid comments value: ${options.comments}. Must be one of: ${COMMENT_FILTERS.join(', ')}.`)
+    }
+
+    const project = scopeProject(await getOrCreateProject(persistentManager, {
       directory: options.directory || process.cwd(),
       languages: [],
       ignoreDirs: options.ignoreDirs || [],
       autoWatch: false,
-    }, options.projectId)
+    }, options.projectId), options.scope)
 
     const allNodes = Array.from(project.files.values())
     const elementNodes = Array.from(project.nodes.values()).flat()
@@ -594,11 +954,15 @@ async function handleFindUsage(identifier: string, options: FindUsageOptions): P
 
     const searchNodes = [...allNodes, ...elementNodes]
 
-    const results = findUsage(identifier, searchNodes, {
+    const filters = {
       caseSensitive: options.caseSensitive,
-      exactMatch: options.exact,
       pathPattern: options.pathPattern,
-    })
+      comments: options.comments as CommentFilter,
+    }
+    const results = [
+      ...(isKeyPath(identifier) ? findConfigKeyUsage(identifier, searchNodes, filters) : []),
+      ...findUsage(identifier, searchNodes, { ...filters, exactMatch: options.exact, aliases: findAliasExpressions(project, identifier) }),
+    ]
 
     let maxResults = 50
     if (options.maxResults) {
@@ -622,6 +986,7 @@ async function handleFindUsage(identifier: string, options: FindUsageOptions): P
           type: result.node.type,
           name: result.node.name || '',
           context: result.context,
+          via: result.via,
         })),
         totalUsages: results.length,
         displayedUsages: limitedResults.length,
@@ -641,7 +1006,7 @@ async function handleFindUsage(identifier: string, options: FindUsageOptions): P
 
     for (const result of limitedResults) {
       const position = `${result.startLine}:${result.startColumn}-${result.endLine}:${result.endColumn}`
-      logger.output(`${chalk.green('●')} ${chalk.bold(result.node.path)}:${position}`)
+      logger.output(`${chalk.green('●')} ${chalk.bold(result.node.path)}:${position}${result.via ? chalk.dim(` (via ${result.via})`) : ''}`)
       if (result.context) {
         const lines = result.context.split('\n')
         const previewLine = lines.find(line => line.startsWith('→ '))?.trim() || lines[0]?.trim() || ''
@@ -672,6 +1037,301 @@ async function handleFindUsage(identifier: string, options: FindUsageOptions): P
   }
 }
 
+interface ExportChunksOptions {
+  directory?: string
+  projectId?: string
+  pathPattern?: string
+  scope?: string
+  maxTokens: string
+  moduleCode: boolean
+  ignoreDirs?: string[]
+  outputFile?: string
+  debug?: boolean
+  quiet?: boolean
+}
+
+async function handleExportChunks(options: ExportChunksOptions): Promise<void> {
+  const logger = initializeLogger(options.debug ? 'debug' : 'info', options.quiet)
+
+  try {
+    const maxTokens = parseInt(options.maxTokens)
+    if (isNaN(maxTokens) || maxTokens < 16) {
+      throw new Error(`Invalid max-tokens value: ${options.maxTokens}. Must be a number of at least 16.`)
+    }
+
+    const project = scopeProject(await getOrCreateProject(persistentManager, {
+      directory: options.directory || process.cwd(),
+      ignoreDirs: options.ignoreDirs || [],
+      autoWatch: false,
+    }, options.projectId), options.scope)
+
+    const chunks = exportChunks(project, {
+      maxTokens,
+      includeModuleCode: options.moduleCode,
+      pathPattern: options.pathPattern,
+    })
+    const jsonl = formatChunksAsJsonl(chunks)
+
+    if (options.outputFile) {
+      writeFileSync(resolve(options.outputFile), jsonl)
+      const files = new Set(chunks.map(chunk => chunk.path)).size
+      logger.info(`Wrote ${chunks.length} chunks from ${files} files to ${options.outputFile}`)
+      return
+    }
+
+    // Nothing else goes to stdout so the output can be piped straight into a pipeline
+    process.stdout.write(jsonl)
+  }
+  catch (error) {
+    const errorMessage = error instanceof Error ? error.message : 'Unknown error'
+    logger.output(chalk.red(`Chunk export failed: ${errorMessage}`))
+    process.exit(1)
+  }
+}
+
+interface ParseSnippetOptions {
+  language?: string
+  filename?: string
+  output: string
+  debug?: boolean
+  quiet?: boolean
+}
+
+async function handleParseSnippet(file: string | undefined, options: ParseSnippetOptions): Promise<void> {
+  const logger = initializeLogger(options.debug ? 'debug' : 'info', options.quiet)
+
+  try {
+    const fromStdin = !file || file === '-'
+    if (fromStdin && process.stdin.isTTY) {
+      throw new Error('Pipe source text on stdin or pass a file')
+    }
+
+    const content = fromStdin ? await readStdin() : readFileSync(resolve(file), 'utf-8')
+    const analysis = analyzeSnippet(content, {
+      language: options.language,
+      filename: options.filename ?? (fromStdin ? undefined : basename(file)),
+    })
+
+    if (options.output === 'json') {
+      logger.output(JSON.stringify(analysis, null, 2))
+      return
+    }
+
+    logger.output(chalk.cyan(`${analysis.language} snippet, ${analysis.lines} ${analysis.lines === 1 ? 'line' : 'lines'}\n`))
+    logger.output(analysis.outline ? `${chalk.bold('Outline:')}\n${analysis.outline.replace(/^/gm, '  ')}\n` : chalk.dim('No symbols found\n'))
+
+    if (analysis.syntaxErrors.length > 0) {
+      logger.output(chalk.red(chalk.bold(`Syntax errors (${analysis.syntaxErrors.length}):`)))
+      for (const error of analysis.syntaxErrors) {
+        logger.output(`  ${error.file}:${error.line}:${error.column} ${error.type} ${chalk.dim(error.nodeType)}`)
+        logger.output(`    Fix: ${error.suggestion}`)
+      }
+      logger.output('')
+    }
+
+    for (const finding of analysis.findings) {
+      logger.output(`${chalk.yellow(finding.severity.padEnd(8))} ${finding.location}  ${finding.description}`)
+    }
+    logger.output(chalk.dim(`Quality score ${analysis.quality.codeQualityScore}/10, average complexity ${analysis.quality.avgComplexity}`))
+  }
+  catch (error) {
+    const errorMessage = error instanceof Error ? error.message : 'Unknown error'
+    logger.output(chalk.red(`Snippet parsing failed: ${errorMessage}`))
+    process.exit(1)
+  }
+}
+
+async function readStdin(): Promise<string> {
+  const chunks: Buffer[] = []
+  for await (const chunk of process.stdin) {
+    chunks.push(typeof chunk === 'string' ? Buffer.from(chunk) : chunk)
+  }
+  return Buffer.concat(chunks).toString('utf-8')
+}
+
+interface IndexOptions {
+  directory?: string
+  projectId?: string
+  ignoreDirs?: string[]
+  update?: boolean
+  debug?: boolean
+  quiet?: boolean
+}
+
+async function handleIndexExport(file: string, options: IndexOptions): Promise<void> {
+  const logger = initializeLogger(options.debug ? 'debug' : 'info', options.quiet)
+
+  try {
+    const project = await getOrCreateProject(persistentManager, {
+      directory: options.directory || process.cwd(),
+      ignoreDirs: options.ignoreDirs || [],
+      autoWatch: false,
+    }, options.projectId)
+
+    const summary = await exportIndex(project, file)
+    logger.output(JSON.stringify(summary, null, 2))
+  }
+  catch (error) {
+    const errorMessage = error instanceof Error ? error.message : 'Unknown error'
+    logger.output(chalk.red(`Index export failed: ${errorMessage}`))
+    process.exit(1)
+  }
+}
+
+async function handleIndexImport(file: string, options: IndexOptions): Promise<void> {
+  const logger = initializeLogger(options.debug ? 'debug' : 'info', options.quiet)
+
+  try {
+    const { project, report } = await loadProjectFromIndex(persistentManager, file, {
+      directory: options.directory || process.cwd(),
+      ignoreDirs: options.ignoreDirs || [],
+      autoWatch: false,
+    }, options.projectId)
+
+    if (!report.toolVersionMatched) {
+      logger.warn(`Index was written by version ${report.toolVersion}, all files were re-parsed`)
+    }
+
+    const updated = options.update ? await exportIndex(project, file) : undefined
+    logger.output(JSON.stringify({ projectId: project.id, ...report, ...(updated ? { updated } : {}) }, null, 2))
+  }
+  catch (error) {
+    const errorMessage = error instanceof Error ? error.message : 'Unknown error'
+    logger.output(chalk.red(`Index import failed: ${errorMessage}`))
+    process.exit(1)
+  }
+}
+
+interface BenchOptions {
+  directory?: string
+  bench?: string
+  count?: string
+  threshold?: string
+  output?: string
+  debug?: boolean
+  quiet?: boolean
+}
+
+async function handleBenchList(options: BenchOptions): Promise<void> {
+  const logger = initializeLogger(options.debug ? 'debug' : 'info', options.quiet)
+
+  try {
+    const benchmarks = listBenchmarks(resolve(options.directory || process.cwd()), options.bench)
+    logger.output(JSON.stringify(benchmarks, null, 2))
+  }
+  catch (error) {
+    const errorMessage = error instanceof Error ? error.message : 'Unknown error'
+    logger.output(chalk.red(`Benchmark listing failed: ${errorMessage}`))
+    process.exit(1)
+  }
+}
+
+async function handleBenchCompare(base: string, head: string | undefined, options: BenchOptions): Promise<void> {
+  const logger = initializeLogger(options.debug ? 'debug' : 'info', options.quiet)
+
+  try {
+    const comparison = await compareBenchmarks(resolve(options.directory || process.cwd()), base, head, {
+      pattern: options.bench,
+      count: Math.max(1, parseInt(options.count ?? '', 10) || BENCHMARK_CONFIG.DEFAULT_COUNT),
+      thresholdPercent: Number(options.threshold ?? BENCHMARK_CONFIG.DEFAULT_THRESHOLD_PERCENT),
+    })
+
+    if (options.output !== 'text') {
+      logger.output(JSON.stringify(comparison, null, 2))
+      return
+    }
+
+    const time = (measurement?: BenchmarkMeasurement) => measurement ? `${measurement.nsPerOp} ns/op` : '-'
+    const width = Math.max(...comparison.results.map(result => result.name.length), 9)
+    logger.output(chalk.cyan(`${comparison.base.ref} (${comparison.base.commit.substring(0, 10)}) -> ${comparison.head.ref}, median of ${comparison.count}\n`))
+    for (const result of comparison.results) {
+      const color = result.verdict === 'faster' ? chalk.green : result.verdict === 'slower' ? chalk.red : chalk.dim
+      const delta = result.deltaPercent === undefined ? '' : `${result.deltaPercent > 0 ? '+' : ''}${result.deltaPercent}%`
+      logger.output(`${result.name.padEnd(width)}  ${time(result.base).padStart(16)}  ${time(result.head).padStart(16)}  ${delta.padStart(8)}  ${color(result.verdict)}`)
+    }
+    for (const failure of comparison.errors) {
+      logger.output(chalk.red(`\n${failure.ref}: ${failure.command}\n${failure.message}`))
+    }
+  }
+  catch (error) {
+    const errorMessage = error instanceof Error ? error.message : 'Unknown error'
+    logger.output(chalk.red(`Benchmark comparison failed: ${errorMessage}`))
+    process.exit(1)
+  }
+}
+
+/**
+ * Adds `--explain` to every command with examples. It is handled as soon as the option is
+ * parsed, so it works without the command's required arguments.
+ */
+function addExplainOptions(command: Command): void {
+  for (const subcommand of command.commands) {
+    const examples = CLI_EXAMPLES[commandPath(subcommand)]
+    if (examples) {
+      subcommand
+        .option('--explain', 'Show example invocations with sample output')
+        .on('option:explain', () => {
+          getLogger().output(formatExamples(subcommand, examples))
+          process.exit(0)
+        })
+    }
+    addExplainOptions(subcommand)
+  }
+}
+
+function formatExamples(command: Command, examples: CliExample[]): string {
+  let output = `${chalk.bold(`tree-sitter-mcp ${commandPath(command)}`)} - ${command.description()}\n`
+
+  for (const example of examples) {
+    output += `\n${chalk.cyan(example.description)}\n`
+    output += `  $ ${example.command}\n`
+    if (example.output) {
+      output += example.output.split('\n').map(line => chalk.dim(`    ${line}`)).join('\n') + '\n'
+    }
+  }
+
+  return output
+}
+
+function handleTools(name: string | undefined): void {
+  const logger = getLogger()
+
+  if (!name) {
+    const width = Math.max(...MCP_TOOLS.map(tool => tool.name.length))
+    for (const tool of MCP_TOOLS) {
+      const writes = tool.annotations.readOnlyHint ? '' : chalk.dim(' (writes files)')
+      logger.output(`${chalk.bold(tool.name.padEnd(width))}  ${tool.description}${writes}`)
+    }
+    return
+  }
+
+  const tool = MCP_TOOLS.find(candidate => candidate.name === name)
+  if (!tool) {
+    logger.output(chalk.red(`Unknown tool: ${name}. Run \`tree-sitter-mcp tools\` to list them.`))
+    process.exit(1)
+  }
+
+  const required: readonly string[] = tool.inputSchema.required
+  const properties = Object.entries(tool.inputSchema.properties as Record<string, { type?: string, description?: string }>)
+  const width = Math.max(0, ...properties.map(([property]) => property.length))
+
+  logger.output(`${chalk.bold(tool.name)}\n${tool.description}\n`)
+  logger.output(properties.length > 0 ? 'Parameters:' : 'No parameters')
+  for (const [property, schema] of properties) {
+    const type = `${schema.type ?? 'any'}${required.includes(property) ? ' (required)' : ''}`
+    logger.output(`  ${property.padEnd(width)}  ${chalk.dim(type.padEnd(18))}  ${schema.description ?? ''}`)
+  }
+}
+
+function handleCompletion(shell: string): void {
+  if (!(COMPLETION_SHELLS as readonly string[]).includes(shell)) {
+    getLogger().output(chalk.red(`Unsupported shell: ${shell}. Use one of: ${COMPLETION_SHELLS.join(', ')}`))
+    process.exit(1)
+  }
+
+  process.stdout.write(generateCompletionScript(shell as CompletionShell))
+}
+
 interface SetupOptions {
   auto?: boolean
 }
@@ -720,13 +1380,87 @@ async function runAutoSetup(logger: any): Promise<void> {
 
 interface DefaultOptions {
   mcp?: boolean
+  readOnly?: boolean
+  lsp?: boolean
+  lspPort?: string
+  grpcPort?: string
+  grpcHost?: string
+  tenants?: string
+  maxConcurrentCalls?: string
+  maxCallsPerMinute?: string
+  maxFilesPerCall?: string
+  resultCache?: boolean
+  languageServer?: string[]
+  formatEdits?: boolean
+  allowFileRemotes?: boolean
 }
 
 function handleDefaultAction(options: DefaultOptions): void {
-  if (options.mcp || !process.stdin.isTTY) {
-    startMCPServer()
+  if (options.lsp) {
+    startLSPServer()
+  }
+  else if (options.grpcPort && !options.mcp) {
+    serveGRPC(options)
+  }
+  else if (options.mcp || !process.stdin.isTTY) {
+    startMCPServer({ readOnly: options.readOnly, limits: callLimits(options), resultCache: options.resultCache, languageServers: languageServers(options), formatEdits: options.formatEdits, allowFileRemotes: options.allowFileRemotes })
+    if (options.lspPort) startLSPServer({ port: parseInt(options.lspPort) })
+    if (options.grpcPort) serveGRPC(options)
   }
   else {
     console.info('Use --help to see available commands')
   }
 }
+
+function callLimits(options: DefaultOptions): CallLimits | undefined {
+  const limit = (value: string | undefined, flag: string): number | undefined => {
+    if (value === undefined) return undefined
+    const parsed = parseInt(value)
+    if (isNaN(parsed) || parsed < 1) {
+      getLogger().output(chalk.red(`${flag} must be a positive number`))
+      process.exit(1)
+    }
+    return parsed
+  }
+  const limits: CallLimits = {
+    maxConcurrentCalls: limit(options.maxConcurrentCalls, '--max-concurrent-calls'),
+    maxCallsPerMinute: limit(options.maxCallsPerMinute, '--max-calls-per-minute'),
+    maxFilesPerCall: limit(options.maxFilesPerCall, '--max-files-per-call'),
+  }
+  return Object.values(limits).some(value => value !== undefined) ? limits : undefined
+}
+
+function languageServers(options: DefaultOptions): Record<string, string[]> | undefined {
+  if (!options.languageServer?.length) return undefined
+  const commands: Record<string, string[]> = {}
+  for (const value of options.languageServer) {
+    const separator = value.indexOf('=')
+    const command = value.slice(separator + 1).trim().split(/\s+/).filter(Boolean)
+    if (separator < 1 || command.length === 0) {
+      getLogger().output(chalk.red(`--language-server must be <server>=<command>, e.g. gopls=/opt/go/bin/gopls: ${value}`))
+      process.exit(1)
+    }
+    commands[value.slice(0, separator)] = command
+  }
+  return commands
+}
+
+function serveGRPC(options: DefaultOptions): void {
+  Promise.resolve()
+    .then(() => startGRPCServer({
+      port: parseInt(options.grpcPort!),
+      host: options.grpcHost,
+      readOnly: options.readOnly ?? false,
+      tenants: options.tenants ? loadTenants(options.tenants) : undefined,
+      limits: callLimits(options),
+      resultCache: options.resultCache,
+      languageServers: languageServers(options),
+      formatEdits: options.formatEdits,
+      allowFileRemotes: options.allowFileRemotes,
+    }))
+    .catch((error) => {
+      const errorMessage = error instanceof Error ? error.message : String(error)
+      getLogger().output(chalk.red(`gRPC server failed: ${errorMessage}`))
+      process.exit(1)
+    })
+}
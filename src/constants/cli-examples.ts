@@ -0,0 +1,231 @@
+/**
+ * Example invocations shown by `--explain`, keyed by command path. Outputs are shortened
+ * samples of what each command prints.
+ */
+
+export interface CliExample {
+  description: string
+  command: string
+  output?: string
+}
+
+export const CLI_EXAMPLES: Record<string, CliExample[]> = {
+  'search': [
+    {
+      description: 'Find a function by name, with its content when there are few matches',
+      command: 'tree-sitter-mcp search handleRequest --type function --output text',
+      output: `Found 1 results:
+
+● handleRequest (function)
+  /app/src/server.ts:42
+  Score: 100
+  Content: 18 lines`,
+    },
+    {
+      description: 'Fuzzy discovery across a sub-directory, as JSON',
+      command: 'tree-sitter-mcp search User --path-pattern src/models --max-results 5',
+      output: `{
+  "query": "User",
+  "results": [
+    { "name": "User", "type": "class", "path": "/app/src/models/user.ts", "startLine": 3, "score": 100, ... },
+    { "name": "UserRole", "type": "interface", "path": "/app/src/models/user.ts", "startLine": 28, "score": 78, ... }
+  ],
+  "totalResults": 2
+}`,
+    },
+    {
+      description: 'Check whether a function existed at a release tag',
+      command: 'tree-sitter-mcp search legacyLogin --exact --ref v2.1 --output text',
+      output: `Found 1 results at v2.1 (3f9c2a71b0de):
+
+● legacyLogin (function)
+  /app/src/auth.ts:12
+  Score: 100`,
+    },
+    {
+      description: 'Trace an error message seen in production back to the code that builds it',
+      command: 'tree-sitter-mcp search "Order 1234 could not be shipped" --mode strings --output text',
+      output: `Found 1 strings:
+
+● "Order \${id} could not be shipped" (template)
+  /app/src/orders/ship.ts:48:22 in shipOrder
+  Score: 50`,
+    },
+  ],
+  'find-usage': [
+    {
+      description: 'Every reference to an identifier before renaming it',
+      command: 'tree-sitter-mcp find-usage validateEmail --exact --output text',
+      output: `Found 3 usages:
+
+● /app/src/utils/validation.ts:12:17-12:30
+    export function validateEmail(email: string): boolean {
+● /app/src/services/userService.ts:31:9-31:22
+    if (!validateEmail(input.email)) {`,
+    },
+  ],
+  'analyze': [
+    {
+      description: 'Quality findings, most severe first',
+      command: 'tree-sitter-mcp analyze --output text',
+      output: `Analysis Summary
+Total findings: 4
+Critical: 1
+Warnings: 3
+Info: 0
+
+Quality Metrics
+Code Quality Score: 8.4/10
+...`,
+    },
+    {
+      description: 'Dead code and structure as JSON, for scripts',
+      command: 'tree-sitter-mcp analyze --analysis-types deadcode structure --max-results 50',
+    },
+    {
+      description: 'Re-analyze on every save and print only what changed',
+      command: 'tree-sitter-mcp analyze --watch --output text',
+      output: `[10:42:07] src/server.ts changed: 1 new, 1 resolved (4 findings)
+  + warning  src/server.ts:88  route: shorten method (64 lines)
+  - critical src/server.ts:42  handleRequest: reduce complexity (21)`,
+    },
+    {
+      description: 'Findings per CODEOWNERS team, with the change since last week\'s report',
+      command: 'tree-sitter-mcp analyze --group-by owner --baseline last-week.json --output markdown',
+      output: `## Findings by owner (trend since 2026-10-07T09:00:00.000Z)
+
+| Owner | Total | Critical | Warnings | Info |
+|---|---|---|---|---|
+| @acme/backend | 9 (-3) | 2 (-1) | 7 (-2) | 0 (0) |
+| @acme/web | 4 (+1) | 0 (0) | 4 (+1) | 0 (0) |`,
+    },
+  ],
+  'errors': [
+    {
+      description: 'Syntax errors with the enclosing function and a suggested fix',
+      command: 'tree-sitter-mcp errors --output text --max-results 10',
+      output: `=== Syntax Errors Analysis ===
+Total errors: 1
+Files with errors: 1
+...
+
+=== Missing Syntax (1) ===
+  /app/src/parser.ts:57:1
+    Missing: }
+    Context: if (header.length > 0) {
+    Fix: Add the missing "}"
+    In: parseHeader (function, lines 49-57)`,
+    },
+  ],
+  'export-chunks': [
+    {
+      description: 'Symbol-aligned chunks for an embedding pipeline',
+      command: 'tree-sitter-mcp export-chunks --max-tokens 256 --path-pattern src/',
+      output: '{"id":"src/server.ts:42-59","path":"src/server.ts","language":"typescript","symbol":"handleRequest","kind":"function",...}',
+    },
+    {
+      description: 'Write the chunks to a file',
+      command: 'tree-sitter-mcp export-chunks --output-file chunks.jsonl',
+      output: 'Wrote 412 chunks from 58 files to chunks.jsonl',
+    },
+  ],
+  'parse-snippet': [
+    {
+      description: 'Check generated code before writing it to disk',
+      command: "echo 'export function add(a: number, b: number) { return a + b }' | tree-sitter-mcp parse-snippet -l ts --output text",
+      output: `typescript snippet, 1 line
+
+Outline:
+  function add (1-1)
+
+Quality score 10/10, average complexity 1`,
+    },
+    {
+      description: 'Outline, symbols and syntax errors of a single file as JSON',
+      command: 'tree-sitter-mcp parse-snippet scripts/migrate.py',
+    },
+  ],
+  'index export': [
+    {
+      description: 'Build the index once in CI and keep it as an artifact',
+      command: 'tree-sitter-mcp index export index.tsz',
+      output: `{
+  "file": "/ci/workspace/index.tsz",
+  "files": 58,
+  "bytes": 183204
+}`,
+    },
+  ],
+  'index import': [
+    {
+      description: 'Load the CI index, re-parsing only files changed locally',
+      command: 'tree-sitter-mcp index import index.tsz --update',
+      output: `{
+  "projectId": "app",
+  "restored": 55,
+  "reparsed": 2,
+  "added": 1,
+  "removed": 0,
+  "toolVersion": "2.8.2",
+  "toolVersionMatched": true,
+  "updated": { "file": "/home/dev/app/index.tsz", "files": 59, "bytes": 185933 }
+}`,
+    },
+  ],
+  'bench list': [
+    {
+      description: 'Find the parser benchmarks and how to run them',
+      command: 'tree-sitter-mcp bench list --bench Parse',
+      output: `[
+  {
+    "name": "BenchmarkParse",
+    "file": "parser/parse_test.go",
+    "line": 12,
+    "framework": "go",
+    "cases": ["small", "large"],
+    "command": "go test ./parser -run '^$' -bench '^BenchmarkParse$' -benchmem"
+  }
+]`,
+    },
+  ],
+  'bench compare': [
+    {
+      description: 'Check whether uncommitted changes made the parser faster than main',
+      command: 'tree-sitter-mcp bench compare main --bench Parse --output text',
+      output: `main (3f9c2a1b7e) -> WORKTREE, median of 5
+
+BenchmarkParse/large       48211 ns/op       39012 ns/op    -19.1%  faster
+BenchmarkParse/small        1204 ns/op        1187 ns/op     -1.4%  unchanged`,
+    },
+  ],
+  'tools': [
+    {
+      description: 'List the tools the MCP server offers',
+      command: 'tree-sitter-mcp tools',
+      output: `search_code    Search for functions, classes, variables, and other code elements with fuzzy matching
+find_usage     Find all usages of a function, variable, class, or identifier
+...`,
+    },
+    {
+      description: 'Show the parameters of one tool',
+      command: 'tree-sitter-mcp tools check_errors',
+    },
+  ],
+  'completion': [
+    {
+      description: 'Enable completion for the current bash session',
+      command: 'source <(tree-sitter-mcp completion bash)',
+    },
+    {
+      description: 'Install fish completions permanently',
+      command: 'tree-sitter-mcp completion fish > ~/.config/fish/completions/tree-sitter-mcp.fish',
+    },
+  ],
+  'setup': [
+    {
+      description: 'Register the MCP server with Claude Code',
+      command: 'tree-sitter-mcp setup --auto',
+      output: 'SUCCESS: Successfully installed tree-sitter-mcp!',
+    },
+  ],
+}
@@ -15,6 +15,27 @@ export const LOGIC_EXTENSIONS = {
   CSHARP: ['.cs'],
   PHP: ['.php'],
   KOTLIN: ['.kt', '.kts'],
+  SCALA: ['.scala', '.sc'],
+  ELIXIR: ['.ex', '.exs'],
+  LUA: ['.lua'],
+  ZIG: ['.zig'],
+  ODIN: ['.odin'],
+  SOLIDITY: ['.sol'],
+  DART: ['.dart'],
+  HASKELL: ['.hs'],
+  OCAML: ['.ml', '.mli'],
+  R: ['.r', '.R'],
+  JULIA: ['.jl'],
+  OBJC: ['.m', '.mm'],
+  SWIFT: ['.swift'],
+  GROOVY: ['.groovy', '.gvy', '.gradle'],
+  NIM: ['.nim', '.nims', '.nimble'],
+  CRYSTAL: ['.cr'],
+  SHELL: ['.sh', '.bash'],
+  MAKE: ['.mk'],
+  PROTO: ['.proto'],
+  ASSEMBLY: ['.s', '.asm', '.nasm'],
+  LINKER_SCRIPT: ['.ld', '.lds'],
 } as const
 
 export const FRAMEWORK_EXTENSIONS = {
@@ -37,10 +58,46 @@ export const MARKUP_EXTENSIONS = {
   JSON: ['.json'],
   YAML: ['.yml', '.yaml'],
   XML: ['.xml'],
-  MARKDOWN: ['.md', '.mdx'],
+  MARKDOWN: ['.md', '.mdx', '.markdown'],
+  LATEX: ['.tex', '.ltx', '.sty', '.cls'],
   TOML: ['.toml'],
 } as const
 
+export const NOTEBOOK_EXTENSIONS = ['.ipynb'] as const
+
+export const DOCUMENTATION_EXTENSIONS = [...MARKUP_EXTENSIONS.MARKDOWN, ...MARKUP_EXTENSIONS.LATEX]
+
+/**
+ * Data, prose and generated files that fallback indexing leaves as plain text
+ */
+export const FALLBACK_EXCLUDED_EXTENSIONS = [
+  '.csv', '.tsv', '.txt', '.log', '.lock', '.sum', '.svg', '.xml', '.html', '.htm',
+  '.css', '.scss', '.sass', '.less', '.map', '.min.js', '.ini', '.cfg', '.conf', '.env',
+  '.properties', '.rst', '.adoc', '.org', '.patch', '.diff', '.pem', '.crt', '.key',
+] as const
+
+/**
+ * Files identified by base name rather than extension
+ */
+export const INFRASTRUCTURE_FILE_PATTERNS = {
+  DOCKERFILE: [/^(Docker|Container)file(\.[\w.-]+)?$/i, /\.dockerfile$/i],
+  COMPOSE: [/^(docker-)?compose(\.[\w.-]+)?\.ya?ml$/i],
+  MAKEFILE: [/^(GNU)?makefile$/i],
+  TASKFILE: [/^taskfile(\.dist)?\.ya?ml$/i],
+  JUSTFILE: [/^\.?justfile$/i],
+  GRADLE: [/^(build|settings)\.gradle(\.kts)?$/],
+  JENKINSFILE: [/^Jenkinsfile(\.[\w.-]+)?$/, /\.jenkinsfile$/i],
+  SBT: [/^build\.sbt$/],
+} as const
+
+/**
+ * CI pipeline definitions, matched against the full path
+ */
+export const CI_PIPELINE_PATTERNS = {
+  GITHUB_ACTIONS: [/[\\/]\.github[\\/]workflows[\\/][^\\/]+\.ya?ml$/],
+  GITLAB_CI: [/(?:^|[\\/])\.gitlab-ci\.ya?ml$/],
+} as const
+
 export const ALL_FRAMEWORK_EXTENSIONS = Object.values(FRAMEWORK_EXTENSIONS).flat()
 
 export const ALL_LOGIC_EXTENSIONS = Object.values(LOGIC_EXTENSIONS).flat()
@@ -59,6 +116,19 @@ export function isLogicFile(filePath: string): boolean {
   return ALL_LOGIC_EXTENSIONS.some(ext => filePath.endsWith(ext))
 }
 
+export function isNotebookFile(filePath: string): boolean {
+  return NOTEBOOK_EXTENSIONS.some(ext => filePath.endsWith(ext))
+}
+
+export function isDocumentationFile(filePath: string): boolean {
+  return DOCUMENTATION_EXTENSIONS.some(ext => filePath.endsWith(ext))
+}
+
+export function isFallbackIndexable(filePath: string): boolean {
+  const lower = filePath.toLowerCase()
+  return !FALLBACK_EXCLUDED_EXTENSIONS.some(ext => lower.endsWith(ext))
+}
+
 export const TEST_PATTERNS = {
   FILE_PATTERNS: ['.test.', '.spec.'],
   DIRECTORY_PATTERNS: ['/test/', '/tests/', '__tests__', '/fixtures/'],
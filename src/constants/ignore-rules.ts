@@ -31,6 +31,9 @@ export const GLOBAL_IGNORE_DIRS = new Set([
   'third_party', 'external', 'libs', 'lib', 'include', 'headers',
 ])
 
+// Hidden entries still indexed because they hold CI pipelines
+export const INDEXED_HIDDEN_ENTRIES = new Set(['.github', '.gitlab-ci.yml', '.gitlab-ci.yaml'])
+
 export const GLOBAL_IGNORE_FILES = new Set([
   // Lock files
   'package-lock.json', 'yarn.lock', 'pnpm-lock.yaml', 'composer.lock',
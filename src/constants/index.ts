@@ -10,4 +10,5 @@ export * from './templates.js'
 export * from './project-files.js'
 export * from './patterns.js'
 export * from './persistence.js'
-export * from './ignore-rules.js'
\ No newline at end of file
+export * from './ignore-rules.js'
+export * from './cli-examples.js'
\ No newline at end of file
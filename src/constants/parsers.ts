@@ -17,15 +17,68 @@ export const PARSER_NAMES = {
   PHP: 'php',
   HTML: 'html',
   KOTLIN: 'kotlin',
+  SCALA: 'scala',
+  ELIXIR: 'elixir',
+  LUA: 'lua',
+  ZIG: 'zig',
+  ODIN: 'odin',
+  SOLIDITY: 'solidity',
+  DART: 'dart',
+  HASKELL: 'haskell',
+  OCAML: 'ocaml',
+  R: 'r',
+  JULIA: 'julia',
+  OBJC: 'objc',
+  SWIFT: 'swift',
+  GROOVY: 'groovy',
+  NIM: 'nim',
+  CRYSTAL: 'crystal',
+  BASH: 'bash',
+  MAKE: 'make',
+  PROTO: 'proto',
+  ASSEMBLY: 'assembly',
+  LINKER_SCRIPT: 'linker_script',
+  MARKDOWN: 'markdown',
+  LATEX: 'latex',
+  DOCKERFILE: 'dockerfile',
+  COMPOSE: 'compose',
+  JSON: 'json',
+  YAML: 'yaml',
+  TOML: 'toml',
+  GRADLE: 'gradle',
+  SBT: 'sbt',
 } as const
 
+/**
+ * Grammars loaded only if their package is installed, keyed by parser name
+ */
+export const OPTIONAL_GRAMMAR_PACKAGES: Record<string, string> = {
+  [PARSER_NAMES.PROTO]: 'tree-sitter-proto',
+  [PARSER_NAMES.SCALA]: 'tree-sitter-scala',
+  [PARSER_NAMES.ELIXIR]: 'tree-sitter-elixir',
+  [PARSER_NAMES.LUA]: 'tree-sitter-lua',
+  [PARSER_NAMES.ZIG]: 'tree-sitter-zig',
+  [PARSER_NAMES.ODIN]: 'tree-sitter-odin',
+  [PARSER_NAMES.SOLIDITY]: 'tree-sitter-solidity',
+  [PARSER_NAMES.DART]: 'tree-sitter-dart',
+  [PARSER_NAMES.HASKELL]: 'tree-sitter-haskell',
+  [PARSER_NAMES.OCAML]: 'tree-sitter-ocaml',
+  [PARSER_NAMES.R]: 'tree-sitter-r',
+  [PARSER_NAMES.JULIA]: 'tree-sitter-julia',
+  [PARSER_NAMES.OBJC]: 'tree-sitter-objc',
+  [PARSER_NAMES.SWIFT]: 'tree-sitter-swift',
+  [PARSER_NAMES.GROOVY]: 'tree-sitter-groovy',
+  [PARSER_NAMES.NIM]: 'tree-sitter-nim',
+  [PARSER_NAMES.CRYSTAL]: 'tree-sitter-crystal',
+}
+
 export const FUNCTION_TYPES = {
   JAVASCRIPT: ['function_declaration', 'arrow_function', 'method_definition'],
   TYPESCRIPT: ['function_declaration', 'arrow_function', 'method_definition'],
   PYTHON: ['function_definition'],
   GO: ['function_declaration', 'method_declaration'],
   RUST: ['function_item'],
-  JAVA: ['method_declaration'],
+  JAVA: ['method_declaration', 'constructor_declaration'],
   C: ['function_definition', 'function_declarator'],
   CPP: ['function_definition', 'function_declarator'],
   RUBY: ['method'],
@@ -33,6 +86,35 @@ export const FUNCTION_TYPES = {
   PHP: ['function_definition', 'method_declaration'],
   HTML: [],
   KOTLIN: ['function_declaration'],
+  SCALA: ['function_definition', 'function_declaration'],
+  ELIXIR: [],
+  LUA: [],
+  ZIG: [],
+  ODIN: [],
+  SOLIDITY: [],
+  DART: [],
+  HASKELL: [],
+  OCAML: [],
+  R: [],
+  JULIA: [],
+  OBJC: [],
+  SWIFT: [],
+  GROOVY: [],
+  NIM: [],
+  CRYSTAL: [],
+  BASH: ['function_definition'],
+  PROTO: ['rpc'],
+  ASSEMBLY: [],
+  LINKER_SCRIPT: [],
+  MARKDOWN: [],
+  LATEX: [],
+  DOCKERFILE: [],
+  COMPOSE: [],
+  JSON: [],
+  YAML: [],
+  TOML: [],
+  GRADLE: [],
+  SBT: [],
 } as const
 
 export const CLASS_TYPES = {
@@ -41,7 +123,7 @@ export const CLASS_TYPES = {
   PYTHON: ['class_definition'],
   GO: ['type_declaration'],
   RUST: ['struct_item', 'enum_item', 'trait_item'],
-  JAVA: ['class_declaration', 'interface_declaration'],
+  JAVA: ['class_declaration', 'interface_declaration', 'enum_declaration', 'record_declaration', 'annotation_type_declaration'],
   C: ['struct_specifier'],
   CPP: ['class_specifier', 'struct_specifier'],
   RUBY: ['class', 'module'],
@@ -49,8 +131,77 @@ export const CLASS_TYPES = {
   PHP: ['class_declaration'],
   HTML: [],
   KOTLIN: ['class_declaration', 'object_declaration'],
+  SCALA: ['class_definition', 'object_definition', 'trait_definition', 'enum_definition'],
+  ELIXIR: [],
+  LUA: [],
+  ZIG: [],
+  ODIN: [],
+  SOLIDITY: [],
+  DART: [],
+  HASKELL: [],
+  OCAML: [],
+  R: [],
+  JULIA: [],
+  OBJC: [],
+  SWIFT: [],
+  GROOVY: [],
+  NIM: [],
+  CRYSTAL: [],
+  BASH: [],
+  PROTO: ['service', 'message', 'enum'],
+  ASSEMBLY: [],
+  LINKER_SCRIPT: [],
+  MARKDOWN: [],
+  LATEX: [],
+  DOCKERFILE: ['stage'],
+  COMPOSE: ['service'],
+  JSON: [],
+  YAML: [],
+  TOML: [],
+  GRADLE: ['project'],
+  SBT: ['project'],
+} as const
+
+export const VARIABLE_TYPES = {
+  BASH: ['variable_assignment'],
 } as const
 
+/**
+ * Common names for languages whose parser name differs, accepted as language hints
+ */
+export const LANGUAGE_ALIASES: Record<string, string> = {
+  'js': PARSER_NAMES.JAVASCRIPT,
+  'node': PARSER_NAMES.JAVASCRIPT,
+  'ts': PARSER_NAMES.TYPESCRIPT,
+  'py': PARSER_NAMES.PYTHON,
+  'golang': PARSER_NAMES.GO,
+  'rs': PARSER_NAMES.RUST,
+  'c++': PARSER_NAMES.CPP,
+  'rb': PARSER_NAMES.RUBY,
+  'c#': PARSER_NAMES.CSHARP,
+  'csharp': PARSER_NAMES.CSHARP,
+  'cs': PARSER_NAMES.CSHARP,
+  'kt': PARSER_NAMES.KOTLIN,
+  'sc': PARSER_NAMES.SCALA,
+  'ex': PARSER_NAMES.ELIXIR,
+  'exs': PARSER_NAMES.ELIXIR,
+  'sol': PARSER_NAMES.SOLIDITY,
+  'hs': PARSER_NAMES.HASKELL,
+  'ml': PARSER_NAMES.OCAML,
+  'jl': PARSER_NAMES.JULIA,
+  'cr': PARSER_NAMES.CRYSTAL,
+  'rlang': PARSER_NAMES.R,
+  'sh': PARSER_NAMES.BASH,
+  'shell': PARSER_NAMES.BASH,
+  'makefile': PARSER_NAMES.MAKE,
+  'protobuf': PARSER_NAMES.PROTO,
+  'asm': PARSER_NAMES.ASSEMBLY,
+  'ld': PARSER_NAMES.LINKER_SCRIPT,
+  'docker': PARSER_NAMES.DOCKERFILE,
+  'yml': PARSER_NAMES.YAML,
+  'jsonc': PARSER_NAMES.JSON,
+}
+
 export const PARSER_LIMITS = {
   KOTLIN_MAX_FILE_SIZE: 32767,
 } as const
\ No newline at end of file
@@ -20,6 +20,34 @@ export const QUALITY_CATEGORIES = {
   GOD_CLASS: 'god_class',
 } as const
 
+export const SECURITY_CATEGORIES = {
+  TX_ORIGIN: 'tx_origin',
+  REENTRANCY: 'reentrancy',
+  UNCHECKED_CALL: 'unchecked_call',
+  DELEGATECALL: 'delegatecall',
+  SELFDESTRUCT: 'selfdestruct',
+} as const
+
+export const CONCURRENCY_CATEGORIES = {
+  LOOP_VARIABLE_CAPTURE: 'loop_variable_capture',
+  UNRELEASED_LOCK: 'unreleased_lock',
+  UNREAD_CHANNEL: 'unread_channel',
+  SLEEP_SYNCHRONIZATION: 'sleep_synchronization',
+} as const
+
+export const NULLABILITY_CATEGORIES = {
+  NIL_DEREFERENCE: 'nil_dereference',
+  UNCHECKED_RESULT: 'unchecked_result',
+} as const
+
+// Least to most certain
+export const CONFIDENCE_LEVELS = ['low', 'medium', 'high'] as const
+
+export const RESOURCE_CATEGORIES = {
+  UNCLOSED_RESOURCE: 'unclosed_resource',
+  LEAK_ON_RETURN: 'leak_on_return',
+} as const
+
 export const IMPORT_PATTERNS = {
   ANALYSIS_SCHEME: 'analysis://',
   PATH_JOIN_PATTERN: ').slice(0, -1).join(',
@@ -27,4 +55,129 @@ export const IMPORT_PATTERNS = {
 
 export function escapeRegExp(string: string): string {
   return string.replace(/[.*+?^${}()|[\]\\]/g, REGEX_PATTERNS.ESCAPE_SPECIAL_CHARS)
-}
\ No newline at end of file
+}
+export const GENERATED_PROTO_PATTERNS = [
+  /\.pb\.go$/, /_grpc\.pb\.go$/, /\.pb\.gw\.go$/,
+  /_pb2(_grpc)?\.pyi?$/,
+  /_pb\.(js|d\.ts|ts)$/, /_grpc_pb\.(js|d\.ts)$/, /_connect\.ts$/,
+  /Grpc\.(java|cs|kt)$/, /\.pb\.(h|cc)$/,
+] as const
+
+export function isGeneratedProtoFile(filePath: string): boolean {
+  return GENERATED_PROTO_PATTERNS.some(pattern => pattern.test(filePath))
+}
+
+/**
+ * Environment variable reads by language; the first capture group is the variable name
+ */
+export const ENV_READ_PATTERNS = [
+  /\bprocess\.env\.([A-Za-z_]\w*)/g,
+  /\bprocess\.env\[\s*['"`]([A-Za-z_]\w*)['"`]\s*\]/g,
+  /\bimport\.meta\.env\.([A-Za-z_]\w*)/g,
+  /\bos\.(?:Getenv|LookupEnv)\(\s*"([A-Za-z_]\w*)"/g,
+  /\bos\.environ(?:\.get)?\s*[[(]\s*['"]([A-Za-z_]\w*)['"]/g,
+  /\bos\.getenv\(\s*['"]([A-Za-z_]\w*)['"]/g,
+  /\bENV(?:\.fetch)?\s*[[(]\s*['"]([A-Za-z_]\w*)['"]/g,
+  /\benv::var(?:_os)?\(\s*"([A-Za-z_]\w*)"/g,
+  /\bSystem\.getenv\(\s*"([A-Za-z_]\w*)"/g,
+  /\bEnvironment\.GetEnvironmentVariable\(\s*"([A-Za-z_]\w*)"/g,
+  /(?<![.\w$])getenv\(\s*['"]([A-Za-z_]\w*)['"]/g,
+  /\$_ENV\[\s*['"]([A-Za-z_]\w*)['"]/g,
+] as const
+
+/**
+ * Environment variable writes from code, counted as definitions
+ */
+export const ENV_WRITE_PATTERNS = [
+  /\bos\.Setenv\(\s*"([A-Za-z_]\w*)"/g,
+  /\bos\.environ\.setdefault\(\s*['"]([A-Za-z_]\w*)['"]/g,
+  /\benv::set_var\(\s*"([A-Za-z_]\w*)"/g,
+  /(?<![.\w])putenv\(\s*['"]([A-Za-z_]\w*)=/g,
+] as const
+
+/**
+ * Variables provided by the OS, shells, CI systems or runtimes. Reading them without a
+ * definition in the project is expected, so they are reported as external rather than undefined.
+ */
+export const WELL_KNOWN_ENV_VARS = new Set([
+  'PATH', 'HOME', 'USER', 'USERNAME', 'SHELL', 'PWD', 'TERM', 'LANG', 'LC_ALL', 'TZ',
+  'TMPDIR', 'TEMP', 'TMP', 'HOSTNAME', 'EDITOR', 'APPDATA', 'LOCALAPPDATA', 'USERPROFILE',
+  'NODE_ENV', 'DEBUG', 'CI', 'PORT', 'GOPATH', 'GOOS', 'GOARCH', 'VIRTUAL_ENV', 'PYTHONPATH',
+  'XDG_CONFIG_HOME', 'XDG_CACHE_HOME', 'XDG_DATA_HOME', 'HTTP_PROXY', 'HTTPS_PROXY', 'NO_PROXY',
+])
+
+export const WELL_KNOWN_ENV_PREFIXES = ['GITHUB_', 'RUNNER_', 'CI_', 'GITLAB_', 'BUILDKITE_', 'CIRCLE_', 'npm_', 'VERCEL_', 'AWS_LAMBDA_'] as const
+
+export const ENV_FILE_PATTERNS = [/^\.env(\.[\w.-]+)?$/, /\.env$/, /^\.envrc$/] as const
+
+/**
+ * Flag evaluation functions of common feature flag SDKs. The flag key is the first string argument.
+ */
+export const FEATURE_FLAG_SDK_FUNCTIONS: Record<string, string[]> = {
+  launchdarkly: ['variation', 'variationDetail', 'boolVariation', 'stringVariation', 'numberVariation', 'intVariation', 'floatVariation', 'jsonVariation', 'BoolVariation', 'StringVariation', 'IntVariation', 'Float64Variation', 'JSONVariation'],
+  unleash: ['isEnabled', 'getVariant', 'useFlag', 'useVariant', 'IsEnabled', 'GetVariant', 'is_enabled', 'get_variant'],
+  openfeature: ['getBooleanValue', 'getStringValue', 'getNumberValue', 'getObjectValue', 'BooleanValue', 'StringValue', 'get_boolean_value', 'get_string_value'],
+  growthbook: ['isOn', 'isOff', 'getFeatureValue', 'useFeature', 'useFeatureIsOn', 'useFeatureValue', 'is_on', 'get_feature_value'],
+  split: ['getTreatment', 'getTreatmentWithConfig', 'get_treatment'],
+  flagsmith: ['hasFeature', 'is_feature_enabled', 'get_feature_value'],
+}
+
+/**
+ * Logging method names by level, lowercased. Go-style suffixes (`Errorf`, `Infow`, `ErrorContext`)
+ * are stripped before lookup.
+ */
+export const LOG_METHOD_LEVELS: Record<string, 'trace' | 'debug' | 'info' | 'warn' | 'error' | 'fatal'> = {
+  trace: 'trace',
+  verbose: 'debug',
+  debug: 'debug',
+  fine: 'debug',
+  info: 'info',
+  notice: 'info',
+  print: 'info',
+  log: 'info',
+  warn: 'warn',
+  warning: 'warn',
+  error: 'error',
+  exception: 'error',
+  severe: 'error',
+  critical: 'fatal',
+  fatal: 'fatal',
+  panic: 'fatal',
+}
+
+export const LOG_RECEIVER_PATTERN = /(?:^|\.)(?:console|logging|slog|sugar|log|_log|[\w$]*[lL]ogger|[\w$]*Log)$|^(?:LOGGER|LOG)$|^zap\.[LS]\(\)$/
+
+/**
+ * Print-style output used as logging, by LOGIC_EXTENSIONS language
+ */
+export const PRINT_CALL_PATTERNS: Record<string, RegExp> = {
+  GO: /(?<![\w$.])fmt\.(?:Print(?:ln|f)?|Fprint(?:ln|f)?(?=\(\s*os\.Std(?:out|err)\b))\s*\(/g,
+  JAVASCRIPT: /(?<![\w$.])console\.log\s*\(/g,
+  PYTHON: /(?<![\w$.])print\s*\(/g,
+  RUST: /(?<![\w$.])(?:e?println|e?print|dbg)!\s*\(/g,
+  JAVA: /(?<![\w$.])System\.(?:out|err)\.print(?:ln|f)?\s*\(/g,
+  KOTLIN: /(?<![\w$.])(?:println|print)\s*\(/g,
+  CSHARP: /(?<![\w$.])Console\.Write(?:Line)?\s*\(/g,
+}
+
+/**
+ * Translation function calls; the `key` group is the message key or gettext msgid
+ */
+export const I18N_CALL_PATTERNS = [
+  /(?<![\w$.])(?:(?:this|vm|props|ctx|i18n|i18next|I18n|\$i18n)\.)*\$?tc?\s*\(\s*(?<q>['"`])(?<key>(?:\\.|(?!\k<q>)[^\\\n])+)\k<q>/g,
+  /(?<![\w$.])(?:translate|trans|__|_|u?gettext(?:_lazy|_noop)?|l?ngettext(?:_lazy)?)\s*\(\s*(?<q>['"`])(?<key>(?:\\.|(?!\k<q>)[^\\\n])+)\k<q>/g,
+  /(?<![\w$.])n?pgettext(?:_lazy)?\s*\(\s*(['"])[^'"]*\1\s*,\s*(?<q>['"])(?<key>(?:\\.|(?!\k<q>)[^\\\n])+)\k<q>/g,
+  /\bformatMessage\(\s*\{[^}]*?\bid:\s*(?<q>['"`])(?<key>[^'"`]+)\k<q>/g,
+  /<(?:FormattedMessage|FormattedHTMLMessage)\b[^>]*?\bid=(?<q>['"])(?<key>[^'"]+)\k<q>/g,
+  /\bi18nKey=(?<q>['"])(?<key>[^'"]+)\k<q>/g,
+  /\bMessageID:\s*(?<q>["`])(?<key>[^"`]+)\k<q>/g,
+  /\{%\s*trans(?:late)?\s+(?<q>['"])(?<key>(?:\\.|(?!\k<q>)[^\\\n])+)\k<q>/g,
+] as const
+
+export const LOCALE_DIRECTORY_NAMES = new Set(['locales', 'locale', 'i18n', 'lang', 'langs', 'languages', 'translations', 'messages', 'l10n', 'po'])
+
+export const LOCALE_CODE_PATTERN = /^[a-z]{2}(?:[-_](?:[A-Za-z]{2}|[A-Z][a-z]{3}|\d{3}))?$/
+
+export const I18N_PLURAL_SUFFIXES = /_(?:zero|one|two|few|many|other|plural)$/
+
+export const USER_FACING_ATTRIBUTES = ['placeholder', 'title', 'alt', 'aria-label', 'label'] as const
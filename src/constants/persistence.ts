@@ -36,4 +36,35 @@ export const MEMORY_LIMITS = {
   MAX_NODES_PER_FILE: 50000,
   ESTIMATED_BYTES_PER_NODE: 100,
   ESTIMATED_BYTES_PER_FILE: 1000,
-} as const
\ No newline at end of file
+  MAX_DIAGNOSTICS_PER_PROJECT: 500,
+} as const
+export const REMOTE_REPO_CONFIG = {
+  CACHE_DIR_ENV: 'TREE_SITTER_MCP_CACHE_DIR',
+  DEFAULT_CACHE_SUBDIR: '.cache/tree-sitter-mcp/repos',
+  CLONE_TIMEOUT_MS: 120000,
+  DEFAULT_REF: 'HEAD',
+} as const
+
+export const GIT_HISTORY_CONFIG = {
+  CACHED_SNAPSHOTS: 4, // Parsed commits kept in memory; a commit never changes, so entries never go stale
+  TIMEOUT_MS: 60000,
+  MAX_BUFFER_BYTES: 64 * 1024 * 1024,
+} as const
+
+export const BENCHMARK_CONFIG = {
+  TIMEOUT_MS: 10 * 60 * 1000, // Per benchmark command and ref
+  MAX_BUFFER_BYTES: 64 * 1024 * 1024,
+  DEFAULT_COUNT: 5, // Runs per benchmark, so noise can be told from change
+  DEFAULT_THRESHOLD_PERCENT: 5, // Smaller deltas are reported as unchanged
+} as const
+
+export const INDEX_ARCHIVE_CONFIG = {
+  FORMAT: 'tree-sitter-mcp-index',
+  FORMAT_VERSION: 2, // Bump when the archive layout or serialized node shape changes
+} as const
+
+export const EDIT_JOURNAL_CONFIG = {
+  DIR_ENV: 'TREE_SITTER_MCP_EDIT_JOURNAL_DIR',
+  DEFAULT_SUBDIR: '.cache/tree-sitter-mcp/edits',
+  MAX_ENTRIES: 100, // Applied edits kept per project; the oldest can no longer be undone
+} as const
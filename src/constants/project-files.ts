@@ -14,6 +14,14 @@ export const PROJECT_FILES = {
   VERSION_CONTROL: {
     GIT: '.git',
   },
+  SETTINGS: '.tree-sitter-mcp.json', // Per-project settings for analyses that need user-supplied patterns
+  CODEOWNERS: ['.github/CODEOWNERS', 'CODEOWNERS', 'docs/CODEOWNERS', '.gitlab/CODEOWNERS'], // In lookup order
+  COVERAGE_REPORTS: ['coverage/lcov.info', 'lcov.info', 'coverage.out', 'cover.out', 'coverage.xml', 'coverage/cobertura-coverage.xml'], // In lookup order
+  PATH_ALIAS_CONFIGS: {
+    TSCONFIG: ['tsconfig.json', 'jsconfig.json'],
+    JEST: ['jest.config.json', 'jest.config.js', 'jest.config.ts', 'jest.config.cjs', 'jest.config.mjs'],
+    BUNDLER: ['webpack.config.js', 'webpack.config.ts', 'webpack.config.cjs', 'webpack.config.mjs', 'vite.config.js', 'vite.config.ts', 'vite.config.mjs'],
+  },
 } as const
 
 export const WORKSPACE_FILES = [
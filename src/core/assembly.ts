@@ -0,0 +1,277 @@
+/**
+ * Assembly sources and linker scripts - the labels of assembly files and the regions, sections
+ * and symbols of linker scripts, read from the source text without parsing instructions
+ */
+
+import type { SymbolKind, SymbolVisibility, TreeNode } from '../types/core.js'
+
+interface Definition {
+  type: string
+  kind: SymbolKind
+  name: string
+  startLine: number
+  endLine: number
+  signature: string
+  visibility: SymbolVisibility
+  container?: string
+}
+
+const MAX_SIGNATURE_LENGTH = 200
+
+const LABEL = /^\s*([A-Za-z_.$][\w.$@]*):(?!:)/
+const GLOBAL = /^\s*\.?(?:globl|global|globals|weak|export|public)\s+([^;#\n]+)/i
+const TYPE_DIRECTIVE = /^\s*\.type\s+([\w.$]+)\s*,\s*[@%#]?(function|gnu_indirect_function|object|tls_object|common)\b/
+const SECTION = /^\s*(?:\.section\s+([\w.$]+)|\.(text|data|bss|rodata)\b|section\s+([\w.$]+))/i
+const MACRO = /^\s*(?:\.macro\s+([\w.$]+)|%i?macro\s+([\w.$]+)|([\w.$]+)\s+macro\b)/i
+const MACRO_END = /^\s*(?:\.endm\b|%endmacro\b|endm\b)/i
+const PROC = /^\s*([\w.$@?]+)\s+proc\b(?:\s+(?:near|far|public|private|export|uses|frame|c|stdcall|pascal|syscall)\b[^;\n]*)?\s*(?:;.*)?$/i
+const PROC_END = /^\s*([\w.$@?]+)\s+endp\b/i
+const CONSTANT = /^\s*(?:\.(?:equ|set|equiv)\s+([\w.$]+)\s*,|([A-Za-z_$][\w.$]*)\s+equ\b|([A-Za-z_$][\w.$]*)\s*=(?!=))/i
+// Entry macros of kernels and libcs: ENTRY(memcpy), SYM_FUNC_START(memcpy), LEAF(start)
+const ENTRY_MACRO = /^\s*(ENTRY|GLOBAL|SYM_FUNC_START|SYM_FUNC_START_LOCAL|SYM_FUNC_START_WEAK|SYM_CODE_START|SYM_CODE_START_LOCAL|SYM_DATA_START|SYM_DATA|FUNC|ASM_FUNC|LEAF|NESTED)\s*\(\s*([\w.$]+)/
+const END_MARKER = /^\s*(?:\.size\s+([\w.$]+)\s*,|(?:END|ENDPROC|ENDFUNC|SYM_FUNC_END|SYM_CODE_END|SYM_DATA_END)\s*\(\s*([\w.$]+))/
+// Directives between a label and the comment documenting it
+const LABEL_DIRECTIVE = /^\s*(?:\.(?:globl|global|weak|hidden|type|p2align|align|balign|func|ent|thumb_func)\b|global\b|align\b)/i
+const COMMENT_LINE = /^\s*(?:;|#(?!\s*(?:include|define|if|ifdef|ifndef|elif|else|endif|undef)\b)|\/\/|@|\/\*|\*(?:\s|\/|$))/
+const DATA_SECTION = /^\.?(?:data|bss|rodata|sdata|sbss|tdata|tbss)\b/
+const IDENTIFIER = /^[A-Za-z_.$][\w.$@]*$/
+
+/**
+ * Text-based extraction of assembly: `label` nodes for labels, of `function` kind in code
+ * sections and `variable` kind in data sections unless a `.type` directive says otherwise. A
+ * label runs to its `.size` or `END` marker, or else to the next label that is not local;
+ * local labels (`.Lloop`, NASM's `.loop`) take the label before them as `symbol.container`.
+ * Labels named by `.globl`, `global` or an entry macro such as `ENTRY(name)` are public, the
+ * rest private to the file. `.macro` and `%macro` definitions become `macro` nodes, MASM
+ * procedures `function` nodes and `.equ` or `equ` constants `constant` nodes.
+ */
+export function extractAssemblyDefinitions(content: string, filePath: string): TreeNode[] {
+  const lines = content.split('\n')
+  const code = maskBlockComments(content).split('\n')
+  const globals = new Set<string>()
+  const types = new Map<string, SymbolKind>()
+
+  for (const line of code) {
+    const global = line.match(GLOBAL)
+    if (global) global[1]!.split(/[\s,]+/).map(name => name.replace(/:.*$/, '')).filter(name => IDENTIFIER.test(name)).forEach(name => globals.add(name))
+    const type = line.match(TYPE_DIRECTIVE)
+    if (type) types.set(type[1]!, /object|common/.test(type[2]!) ? 'variable' : 'function')
+    const entry = line.match(ENTRY_MACRO)
+    if (entry && !entry[1]!.endsWith('_LOCAL')) globals.add(entry[2]!)
+  }
+
+  const definitions: Definition[] = []
+  let current: Definition | undefined // The top-level label being read, until its end is found
+  let section = '.text'
+
+  const close = (last: number) => {
+    if (!current) return
+    let end = last
+    while (end >= current.startLine && !code[end]!.trim()) end--
+    current.endLine = Math.max(current.startLine, end + 1)
+    current = undefined
+  }
+
+  for (let index = 0; index < code.length; index++) {
+    const line = code[index]!
+
+    const sectionMatch = line.match(SECTION)
+    if (sectionMatch) {
+      // A label never runs into the next section
+      close(index - 1)
+      section = sectionMatch[1] ?? sectionMatch[2] ?? sectionMatch[3]!
+    }
+
+    const end = line.match(END_MARKER)
+    if (end && current?.name === (end[1] ?? end[2])) {
+      close(index)
+      continue
+    }
+
+    const macro = line.match(MACRO)
+    if (macro && !LABEL.test(line)) {
+      close(index - 1)
+      const last = findFrom(code, index, candidate => MACRO_END.test(candidate))
+      definitions.push(definition('macro', 'macro', macro[1] ?? macro[2] ?? macro[3]!, index, last, lines, 'public'))
+      index = last
+      continue
+    }
+
+    const proc = line.match(PROC)
+    if (proc) {
+      close(index - 1)
+      const last = findFrom(code, index, candidate => PROC_END.exec(candidate)?.[1] === proc[1])
+      definitions.push(definition('function', 'function', proc[1]!, index, last, lines, globals.has(proc[1]!) ? 'public' : 'private'))
+      index = last
+      continue
+    }
+
+    const entry = line.match(ENTRY_MACRO)
+    const label = entry ? entry[2]! : line.match(LABEL)?.[1]
+    if (label) {
+      const local = !entry && label.startsWith('.') && current !== undefined
+      const owner = local ? current!.name : undefined
+      if (!local) close(index - 1)
+      const kind = types.get(label) ?? (DATA_SECTION.test(section) ? 'variable' : 'function')
+      const node = definition('label', kind, label, index, index, lines, globals.has(label) ? 'public' : 'private', owner)
+      definitions.push(node)
+      if (local) node.endLine = localLabelEnd(code, index) + 1
+      else current = node
+      continue
+    }
+
+    const constant = line.match(CONSTANT)
+    if (constant) {
+      const name = constant[1] ?? constant[2] ?? constant[3]!
+      definitions.push(definition('constant', 'variable', name, index, index, lines, globals.has(name) ? 'public' : 'private'))
+    }
+  }
+  close(code.length - 1)
+
+  return definitions.sort((a, b) => a.startLine - b.startLine).map(toNode('asm', filePath, lines))
+}
+
+/**
+ * Text-based extraction of GNU ld linker scripts: `region` nodes for the regions of `MEMORY`,
+ * `section` nodes for the output sections of `SECTIONS`, and `symbol` nodes for the symbols a
+ * script assigns, such as `_estack = ORIGIN(RAM) + LENGTH(RAM);` or `PROVIDE(end = .);`, which
+ * C and assembly code refer to by name. A symbol assigned inside an output section has it as
+ * `symbol.container`; symbols in `HIDDEN` or `PROVIDE_HIDDEN` are `internal`.
+ */
+export function extractLinkerScriptDefinitions(content: string, filePath: string): TreeNode[] {
+  const lines = content.split('\n')
+  const code = maskBlockComments(content)
+  const definitions: Definition[] = []
+  const blocks = [...code.matchAll(/\b(MEMORY|SECTIONS)\s*\{/g)].map(match => ({
+    keyword: match[1]!,
+    start: match.index! + match[0].length,
+    end: closingBrace(code, match.index! + match[0].length - 1),
+  }))
+  const memory = blocks.filter(block => block.keyword === 'MEMORY')
+
+  for (const block of memory) {
+    const body = code.substring(block.start, block.end)
+    for (const match of body.matchAll(/^[ \t]*([A-Za-z_][\w.]*)[ \t]*(?:\([^)]*\))?[ \t]*:[ \t]*(?:ORIGIN|org|o)\b/gim)) {
+      const line = lineAt(code, block.start + match.index!) - 1
+      definitions.push(definition('region', 'variable', match[1]!, line, line, lines, 'public'))
+    }
+  }
+
+  const sections: Definition[] = []
+  const OUTPUT_SECTION = /([.\w$/][\w.$/-]*)[ \t]*[^:;{}=\n]*?:(?!:)[^{;\n]*?\s*\{/y
+  for (const block of blocks.filter(candidate => candidate.keyword === 'SECTIONS')) {
+    // Output sections sit at the top level of SECTIONS: `.text : { ... } > FLASH`
+    let depth = 0
+    for (let index = block.start; index < block.end; index++) {
+      const char = code[index]!
+      if (char === '{') depth++
+      else if (char === '}') depth--
+      if (depth !== 0 || /[\w.$/]/.test(code[index - 1]!)) continue
+      OUTPUT_SECTION.lastIndex = index
+      const match = OUTPUT_SECTION.exec(code)
+      if (!match) continue
+      const last = closingBrace(code, index + match[0].length - 1)
+      sections.push(definition('section', 'module', match[1]!, lineAt(code, index) - 1, lineAt(code, last) - 1, lines, 'public'))
+      index = last - 1
+    }
+  }
+  definitions.push(...sections)
+
+  for (const match of code.matchAll(/(?:\b(PROVIDE|PROVIDE_HIDDEN|HIDDEN)\s*\(\s*)?(?<![\w.$])([A-Za-z_$][\w$.]*)\s*=(?!=)[^;{}]*;/g)) {
+    if (memory.some(block => match.index! >= block.start && match.index! < block.end)) continue
+    const line = lineAt(code, match.index! + match[0].search(/[A-Za-z_$]/)) - 1
+    const owner = sections.find(candidate => candidate.startLine <= line + 1 && line + 1 <= candidate.endLine)
+    definitions.push(definition('symbol', 'variable', match[2]!, line, line, lines, match[1]?.includes('HIDDEN') ? 'internal' : 'public', owner?.name))
+  }
+
+  return definitions.sort((a, b) => a.startLine - b.startLine).map(toNode('ld', filePath, lines))
+}
+
+/**
+ * The last non-blank line before the label after the one on line `first`
+ */
+function localLabelEnd(code: string[], first: number): number {
+  let end = first
+  for (let index = first + 1; index < code.length && !LABEL.test(code[index]!) && !ENTRY_MACRO.test(code[index]!) && !END_MARKER.test(code[index]!); index++) {
+    if (code[index]!.trim()) end = index
+  }
+  return end
+}
+
+/**
+ * The first line after `first` matching `test`, or `first` when none does
+ */
+function findFrom(code: string[], first: number, test: (line: string) => boolean): number {
+  const index = code.findIndex((line, lineIndex) => lineIndex > first && test(line))
+  return index === -1 ? first : index
+}
+
+function definition(type: string, kind: SymbolKind, name: string, first: number, last: number, lines: string[], visibility: SymbolVisibility, container?: string): Definition {
+  return {
+    type,
+    kind,
+    name,
+    startLine: first + 1,
+    endLine: last + 1,
+    signature: lines[first]!.trim(),
+    visibility,
+    ...(container ? { container } : {}),
+  }
+}
+
+function toNode(prefix: string, filePath: string, lines: string[]): (definition: Definition) => TreeNode {
+  return (definition) => {
+    const doc = readDocComment(lines, definition.startLine)
+    return {
+      id: `${prefix}-${definition.type}-${filePath}-${definition.startLine}-${definition.name}`,
+      type: definition.type,
+      name: definition.name,
+      path: filePath,
+      startLine: definition.startLine,
+      endLine: definition.endLine,
+      content: lines.slice(definition.startLine - 1, definition.endLine).join('\n'),
+      symbol: {
+        kind: definition.kind,
+        visibility: definition.visibility,
+        signature: definition.signature.substring(0, MAX_SIGNATURE_LENGTH),
+        ...(definition.container ? { container: definition.container } : {}),
+        ...(doc ? { doc } : {}),
+      },
+    }
+  }
+}
+
+/**
+ * The comment lines above a declaration and its `.globl` and `.type` directives, in any of the
+ * `;`, `#`, `//`, `@` and `/* ... *\/` styles assemblers accept
+ */
+function readDocComment(lines: string[], startLine: number): string | undefined {
+  let index = startLine - 2
+  while (index >= 0 && LABEL_DIRECTIVE.test(lines[index]!)) index--
+  const comments: string[] = []
+  for (; index >= 0 && COMMENT_LINE.test(lines[index]!); index--) {
+    comments.unshift(lines[index]!.replace(/^\s*(?:;+|#+|\/\/+|@|\/\*+|\*+(?!\/))\s?/, '').replace(/\s*\*+\/\s*$/, '').trimEnd())
+  }
+  return comments.join('\n').trim() || undefined
+}
+
+function closingBrace(code: string, open: number): number {
+  let depth = 0
+  for (let index = open; index < code.length; index++) {
+    if (code[index] === '{') depth++
+    else if (code[index] === '}' && --depth === 0) return index + 1
+  }
+  return code.length
+}
+
+/**
+ * Blanks `/* ... *\/` comments, keeping offsets and line numbers. Line comments are kept: their
+ * markers differ between assemblers, and none of them starts a label.
+ */
+function maskBlockComments(content: string): string {
+  return content.replace(/\/\*[\s\S]*?\*\//g, match => match.replace(/[^\n]/g, ' '))
+}
+
+function lineAt(content: string, index: number): number {
+  return content.substring(0, index).split('\n').length
+}
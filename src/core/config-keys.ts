@@ -0,0 +1,294 @@
+/**
+ * Configuration file keys - every key of a JSON, YAML or TOML file under its full path
+ * (`database.pool_size`, `servers[0].host`), so a setting can be searched for by the same
+ * path the code reads it with
+ */
+
+import { parseYamlDocuments, getYamlKeyLine } from '../utils/yaml.js'
+import type { TreeNode } from '../types/core.js'
+
+export interface ConfigKey {
+  path: string
+  line: number
+}
+
+export type ConfigFormat = 'json' | 'yaml' | 'toml'
+
+// Data files can hold far more keys than any setting lookup needs
+const MAX_KEYS_PER_FILE = 5000
+
+const KEY_PATH = /^[A-Za-z_$][\w$-]*(?:\.[A-Za-z_$][\w$-]*|\[\d+\])+$/
+
+/**
+ * Reads the keys of a configuration file in declaration order. Mapping keys holding a
+ * section are listed as well as the leaves below them.
+ */
+export function readConfigKeys(content: string, format: ConfigFormat): ConfigKey[] {
+  const keys = format === 'json' ? readJsonKeys(content) : format === 'yaml' ? readYamlKeys(content) : readTomlKeys(content)
+  return keys.slice(0, MAX_KEYS_PER_FILE)
+}
+
+/**
+ * Converts the keys of a configuration file into `key` nodes named by their path
+ */
+export function configKeysToNodes(format: ConfigFormat): (content: string, filePath: string) => TreeNode[] {
+  return (content, filePath) => {
+    const lines = content.split('\n')
+    return readConfigKeys(content, format).map(key => ({
+      id: `config-key-${filePath}-${key.line}-${key.path}`,
+      type: 'key',
+      name: key.path,
+      path: filePath,
+      startLine: key.line,
+      endLine: key.line,
+      content: lines[key.line - 1] ?? '',
+    }))
+  }
+}
+
+/**
+ * Whether a search term looks like a configuration key path rather than an identifier
+ */
+export function isKeyPath(text: string): boolean {
+  return KEY_PATH.test(text)
+}
+
+/**
+ * Subscript spellings code reads a key path with besides the dotted one, e.g.
+ * `['database']['pool_size']` or Ruby's `[:database][:pool_size]`
+ */
+export function keyPathAccessors(path: string): string[] {
+  const segments = splitKeyPath(path)
+  const subscript = (format: (segment: string) => string) =>
+    segments.map(segment => /^\d+$/.test(segment) ? `[${segment}]` : `[${format(segment)}]`).join('')
+  return [
+    subscript(segment => `'${segment}'`),
+    subscript(segment => `"${segment}"`),
+    subscript(segment => `:${segment}`),
+  ]
+}
+
+function splitKeyPath(path: string): string[] {
+  return path.split(/\.|(?=\[)/).map(segment => segment.replace(/^\[(\d+)\]$/, '$1'))
+}
+
+function childPath(parent: string, key: string): string {
+  return parent ? `${parent}.${key}` : key
+}
+
+function indexPath(parent: string, index: number): string {
+  return `${parent}[${index}]`
+}
+
+interface JsonFrame {
+  kind: 'object' | 'array'
+  path: string
+  key?: string // Object key whose value comes next
+  index: number // Array element being read
+}
+
+/**
+ * Scans JSON text for keys, tolerating the comments and trailing commas of JSONC files such
+ * as tsconfig.json
+ */
+function readJsonKeys(content: string): ConfigKey[] {
+  const keys: ConfigKey[] = []
+  const stack: JsonFrame[] = []
+  let line = 1
+
+  const valuePath = () => {
+    const top = stack[stack.length - 1]
+    if (!top) return ''
+    return top.kind === 'array' ? indexPath(top.path, top.index) : childPath(top.path, top.key ?? '')
+  }
+
+  for (let i = 0; i < content.length; i++) {
+    const char = content[i]!
+    if (char === '\n') {
+      line++
+    }
+    else if (content.startsWith('//', i)) {
+      i = content.indexOf('\n', i) - 1
+      if (i < 0) break
+    }
+    else if (content.startsWith('/*', i)) {
+      const end = content.indexOf('*/', i + 2)
+      const comment = content.substring(i, end === -1 ? content.length : end)
+      line += comment.split('\n').length - 1
+      if (end === -1) break
+      i = end + 1
+    }
+    else if (char === '{' || char === '[') {
+      stack.push({ kind: char === '{' ? 'object' : 'array', path: valuePath(), index: 0 })
+    }
+    else if (char === '}' || char === ']') {
+      stack.pop()
+    }
+    else if (char === ',') {
+      const top = stack[stack.length - 1]
+      if (top?.kind === 'array') top.index++
+      else if (top) top.key = undefined
+    }
+    else if (char === '"') {
+      let end = i + 1
+      while (end < content.length && content[end] !== '"' && content[end] !== '\n') {
+        end += content[end] === '\\' ? 2 : 1
+      }
+      const top = stack[stack.length - 1]
+      const isKey = top?.kind === 'object' && top.key === undefined && /^\s*:/.test(content.substring(end + 1, end + 64))
+      if (isKey) {
+        const key = decodeJsonString(content.substring(i, end + 1))
+        top.key = key
+        keys.push({ path: childPath(top.path, key), line })
+      }
+      i = end
+    }
+  }
+
+  return keys
+}
+
+function decodeJsonString(literal: string): string {
+  try {
+    return JSON.parse(literal)
+  }
+  catch {
+    return literal.slice(1, -1)
+  }
+}
+
+function readYamlKeys(content: string): ConfigKey[] {
+  const keys: ConfigKey[] = []
+  const seen = new Set<string>()
+
+  const visit = (value: unknown, path: string, depth: number) => {
+    // Aliases share their anchor's object, so nesting is bounded to stay finite on odd documents
+    if (!value || typeof value !== 'object' || depth > 32) return
+    if (Array.isArray(value)) {
+      value.forEach((item, index) => visit(item, indexPath(path, index), depth + 1))
+      return
+    }
+    for (const [key, child] of Object.entries(value)) {
+      const keyPath = childPath(path, key)
+      const line = getYamlKeyLine(value, key)
+      // Merged keys belong to the anchor they were declared under
+      if (line !== undefined && !seen.has(`${keyPath}:${line}`)) {
+        seen.add(`${keyPath}:${line}`)
+        keys.push({ path: keyPath, line })
+      }
+      visit(child, keyPath, depth + 1)
+    }
+  }
+
+  for (const document of parseYamlDocuments(content)) {
+    visit(document, '', 0)
+  }
+  return keys.sort((a, b) => a.line - b.line)
+}
+
+/**
+ * Reads TOML tables, arrays of tables, dotted keys and one level of inline tables line by line
+ */
+function readTomlKeys(content: string): ConfigKey[] {
+  const keys: ConfigKey[] = []
+  const tableCounts = new Map<string, number>()
+  const lines = content.split('\n')
+  let table = ''
+
+  for (let index = 0; index < lines.length; index++) {
+    const line = index + 1
+    const text = stripTomlComment(lines[index]!).trim()
+    if (!text) continue
+
+    const header = text.match(/^\[(\[)?\s*([^\]]+?)\s*\]\]?$/)
+    if (header) {
+      const name = splitTomlKey(header[2]!).join('.')
+      if (header[1]) {
+        const count = tableCounts.get(name) ?? 0
+        tableCounts.set(name, count + 1)
+        table = indexPath(name, count)
+      }
+      else {
+        table = name
+      }
+      keys.push({ path: table, line })
+      continue
+    }
+
+    const assignment = findTomlAssignment(text)
+    if (assignment === -1) continue
+    const keyPath = splitTomlKey(text.substring(0, assignment)).reduce(childPath, table)
+    keys.push({ path: keyPath, line })
+
+    const value = text.substring(assignment + 1).trim()
+    const inline = value.match(/^\{([^{}]*)\}$/)
+    if (inline) {
+      for (const match of inline[1]!.matchAll(/(?:^|,)\s*("[^"]*"|'[^']*'|[\w.-]+)\s*=/g)) {
+        keys.push({ path: splitTomlKey(match[1]!).reduce(childPath, keyPath), line })
+      }
+    }
+
+    // Multi-line strings and arrays continue on the following lines
+    const delimiter = value.startsWith('"""') ? '"""' : value.startsWith('\'\'\'') ? '\'\'\'' : undefined
+    if (delimiter && !value.substring(3).includes(delimiter)) {
+      while (index + 1 < lines.length) {
+        if (lines[++index]!.includes(delimiter)) break
+      }
+    }
+    else if (value.startsWith('[')) {
+      let depth = bracketDepth(value)
+      while (depth > 0 && index + 1 < lines.length) depth += bracketDepth(stripTomlComment(lines[++index]!))
+    }
+  }
+
+  return keys
+}
+
+function splitTomlKey(key: string): string[] {
+  return (key.match(/"[^"]*"|'[^']*'|[^.]+/g) ?? [])
+    .map(segment => segment.trim().replace(/^(["'])(.*)\1$/, '$2'))
+    .filter(Boolean)
+}
+
+function findTomlAssignment(text: string): number {
+  let quote: string | undefined
+  for (let i = 0; i < text.length; i++) {
+    const char = text[i]!
+    if (quote) {
+      if (char === quote) quote = undefined
+    }
+    else if (char === '"' || char === '\'') quote = char
+    else if (char === '=') return i
+  }
+  return -1
+}
+
+function stripTomlComment(text: string): string {
+  let quote: string | undefined
+  for (let i = 0; i < text.length; i++) {
+    const char = text[i]!
+    if (quote) {
+      if (char === '\\' && quote === '"') i++
+      else if (char === quote) quote = undefined
+    }
+    else if (char === '"' || char === '\'') quote = char
+    else if (char === '#') return text.substring(0, i)
+  }
+  return text
+}
+
+function bracketDepth(text: string): number {
+  let depth = 0
+  let quote: string | undefined
+  for (let i = 0; i < text.length; i++) {
+    const char = text[i]!
+    if (quote) {
+      if (char === '\\' && quote === '"') i++
+      else if (char === quote) quote = undefined
+    }
+    else if (char === '"' || char === '\'') quote = char
+    else if (char === '[') depth++
+    else if (char === ']') depth--
+  }
+  return depth
+}
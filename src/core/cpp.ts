@@ -0,0 +1,402 @@
+/**
+ * C and C++ support - names behind nested declarators, template parameter lists, and the
+ * declarations macros generate. A `DEFINE_HANDLER(login)` invocation is expanded with the
+ * macro's definition, from the file itself or the headers it includes, and the declarations
+ * of the expansion are indexed at the invocation, marked with the macro that produced them.
+ */
+
+import type Parser from 'tree-sitter'
+import { readFileSync } from 'fs'
+import { dirname, resolve } from 'path'
+import { isFile } from '../utils/helpers.js'
+import type { SymbolKind, TreeNode, TypeParameter } from '../types/core.js'
+
+export interface CMacro {
+  name: string
+  parameters?: string[] // Function-like macros only; `...` for variadic ones
+  body: string
+  line: number
+  endLine: number
+}
+
+export interface MacroDeclaration {
+  kind: SymbolKind
+  name: string
+  signature: string
+}
+
+const MAX_SIGNATURE_LENGTH = 200
+const MAX_EXPANSION_DEPTH = 8
+const MAX_INCLUDE_DEPTH = 2
+
+const NOT_DECLARED_NAMES = new Set([
+  'if', 'for', 'while', 'switch', 'return', 'sizeof', 'do', 'else', 'case', 'defined', 'decltype',
+  'static_assert', 'alignof', 'typeof', '__attribute__', '__declspec',
+])
+
+const TYPE_DEFINITION = /^(?:template\s*<[^{;]*>\s*)?(struct|class|union|enum(?:\s+(?:class|struct))?)\s+(?:\[\[[^\]]*\]\]\s*)?([A-Za-z_]\w*)\b[^;(=]*$/
+const FUNCTION_HEAD = /^(?:template\s*<[^{;]*>\s*)?([\w\s:<>,*&~]*?)(~?[A-Za-z_][\w:]*|operator\s*[^\s(]+)\s*\(([\s\S]*)\)[\s\w]*(?:->[^{;]*)?$/
+const VARIABLE_HEAD = /^(?:[\w:<>,]+\s*[*&]*\s+)+[*&]*\s*([A-Za-z_]\w*)\s*(?:\[[^\]]*\])?\s*(?:=[\s\S]*)?$/
+
+/**
+ * The name a C or C++ function node declares through its (possibly nested) declarator, split
+ * from the scope of qualified names such as `Cache::get`
+ */
+export function declaratorName(node: Parser.SyntaxNode, source: string): { name: string, scope?: string } | undefined {
+  let declarator = node.childForFieldName('declarator')
+  for (let depth = 0; declarator && depth < 8; depth++) {
+    const inner = declarator.childForFieldName('declarator')
+    if (!inner) break
+    declarator = inner
+  }
+  if (!declarator) return undefined
+
+  const text = source.substring(declarator.startIndex, declarator.endIndex).replace(/\s+/g, '')
+  const separator = text.lastIndexOf('::')
+  const name = separator === -1 ? text : text.substring(separator + 2)
+  // Specializations such as `max<int>` declare `max`; `operator<` keeps its bracket
+  const bare = name.startsWith('operator') ? name : name.replace(/<.*$/, '')
+  return separator === -1 ? { name: bare } : { name: bare, scope: text.substring(0, separator).replace(/<.*$/, '') }
+}
+
+/**
+ * Parses the inside of a C++ template parameter list: `typename T, int N = 4, std::integral U`
+ * gives T, N and U with `typename`, `int` and `std::integral` as their constraints
+ */
+export function parseTemplateParameters(list: string): TypeParameter[] {
+  return splitTopLevel(list, ',', '([{<').flatMap((parameter) => {
+    const [declaration = ''] = splitTopLevel(parameter, '=', '([{<')
+    const match = declaration.trim().match(/^([\s\S]*?[\s.>&*])([A-Za-z_]\w*)$/)
+    return match?.[1]?.trim() ? [{ name: match[2]!, constraint: match[1]!.replace(/\s+/g, ' ').trim() }] : []
+  })
+}
+
+/**
+ * Reads the `#define` directives of a file, joining `\` continuations
+ */
+export function readMacroDefinitions(content: string): CMacro[] {
+  const lines = content.split('\n')
+  const macros: CMacro[] = []
+
+  for (let i = 0; i < lines.length; i++) {
+    const start = i
+    let text = lines[i]!
+    while (text.trimEnd().endsWith('\\') && i + 1 < lines.length) text = `${text.trimEnd().slice(0, -1)} ${lines[++i]}`
+
+    const match = text.match(/^\s*#\s*define\s+([A-Za-z_]\w*)(\(([^)]*)\))?(.*)$/)
+    if (!match) continue
+    macros.push({
+      name: match[1]!,
+      parameters: match[2] ? match[3]!.split(',').map(parameter => parameter.trim()).filter(Boolean) : undefined,
+      body: stripComments(match[4]!).trim(),
+      line: start + 1,
+      endLine: i + 1,
+    })
+  }
+  return macros
+}
+
+/**
+ * Expands one macro invocation: arguments are substituted, `#param` is stringized and `##`
+ * pastes tokens. Other macros in the result are left for the caller to expand.
+ */
+export function expandMacro(macro: CMacro, args: string[] = []): string {
+  const parameters = macro.parameters ?? []
+  const variadic = parameters.indexOf('...')
+  const valueOf = (name: string) => {
+    if (name === '__VA_ARGS__' && variadic !== -1) return args.slice(variadic).map(arg => arg.trim()).join(', ')
+    const index = parameters.indexOf(name)
+    return index === -1 ? undefined : (args[index] ?? '').trim()
+  }
+
+  return macro.body
+    .replace(/(^|[^#])#\s*([A-Za-z_]\w*)/g, (whole, before: string, name: string) => {
+      const value = valueOf(name)
+      return value === undefined ? whole : `${before}"${value}"`
+    })
+    .replace(/"(?:[^"\\]|\\.)*"|[A-Za-z_]\w*/g, word => word.startsWith('"') ? word : valueOf(word) ?? word)
+    .replace(/\s*##\s*/g, '')
+}
+
+/**
+ * Expands every invocation of the given macros in a piece of code, and the invocations their
+ * expansions contain in turn. A macro is not expanded inside its own expansion.
+ */
+export function expandMacros(text: string, macros: Map<string, CMacro>, active = new Set<string>(), depth = 0): string {
+  if (depth >= MAX_EXPANSION_DEPTH) return text
+
+  let output = ''
+  let index = 0
+  for (const match of text.matchAll(/"(?:[^"\\\n]|\\.)*"|[A-Za-z_]\w*/g)) {
+    const macro = macros.get(match[0])
+    if (match.index! < index || !macro || active.has(macro.name)) continue
+
+    let end = match.index! + match[0].length
+    let args: string[] = []
+    if (macro.parameters) {
+      const open = text.substring(end).search(/\S/)
+      if (open === -1 || text[end + open] !== '(') continue
+      const close = findClosing(text, end + open, '(', ')')
+      if (close === -1) continue
+      args = splitTopLevel(text.substring(end + open + 1, close), ',')
+      end = close + 1
+    }
+
+    const expansion = expandMacros(expandMacro(macro, args), macros, new Set([...active, macro.name]), depth + 1)
+    output += text.substring(index, match.index) + expansion
+    index = end
+  }
+  return output + text.substring(index)
+}
+
+/**
+ * The declarations in a piece of expanded code: functions, types, typedefs and variables at
+ * its top level. Function bodies are skipped.
+ */
+export function readDeclarations(code: string): MacroDeclaration[] {
+  const declarations: MacroDeclaration[] = []
+
+  for (const { head, definesBody } of splitStatements(code)) {
+    const normalized = head.replace(/\s+/g, ' ').trim()
+    const signature = normalized.substring(0, MAX_SIGNATURE_LENGTH)
+    if (!normalized) continue
+
+    if (/^typedef\b/.test(normalized)) {
+      const name = normalized.match(/([A-Za-z_]\w*)\s*(?:\[[^\]]*\])?\s*\)?\s*(?:\([^()]*\))?$/)?.[1]
+      if (name) declarations.push({ kind: 'type', name, signature })
+      continue
+    }
+
+    const type = definesBody ? normalized.match(TYPE_DEFINITION) : null
+    if (type) {
+      const keyword = type[1]!
+      const kind: SymbolKind = keyword.startsWith('enum') ? 'enum' : keyword === 'class' ? 'class' : 'struct'
+      declarations.push({ kind, name: type[2]!, signature })
+      continue
+    }
+
+    const fn = normalized.match(FUNCTION_HEAD)
+    if (fn) {
+      const name = fn[2]!.split('::').pop()!
+      const returnType = fn[1]!.trim()
+      if (!NOT_DECLARED_NAMES.has(name) && (returnType || fn[2]!.includes('::') || name.startsWith('~'))) {
+        declarations.push({ kind: 'function', name, signature })
+      }
+      continue
+    }
+
+    const variable = normalized.match(VARIABLE_HEAD)
+    if (variable && !/^(?:return|goto|case|delete|throw)\b/.test(normalized)) {
+      declarations.push({ kind: 'variable', name: variable[1]!, signature })
+    }
+  }
+  return declarations
+}
+
+/**
+ * Text-based extraction added to the syntax tree's nodes for C and C++ files: a `macro` node
+ * per `#define`, and the declarations of each macro invocation standing as a declaration of its
+ * own, with `symbol.macro` naming the macro
+ */
+export function extractMacroDeclarations(content: string, filePath: string): TreeNode[] {
+  const own = readMacroDefinitions(content)
+  const macros = new Map([...collectIncludedMacros(content, filePath), ...own].map(macro => [macro.name, macro]))
+  const lines = content.split('\n')
+  const nodes: TreeNode[] = own.map(macro => ({
+    id: `macro-${filePath}-${macro.line}-${macro.name}`,
+    type: 'macro',
+    name: macro.name,
+    path: filePath,
+    startLine: macro.line,
+    endLine: macro.endLine,
+    content: lines.slice(macro.line - 1, macro.endLine).join('\n'),
+    symbol: {
+      kind: 'macro',
+      visibility: 'public',
+      signature: `#define ${macro.name}${macro.parameters ? `(${macro.parameters.join(', ')})` : ''}`,
+    },
+  }))
+
+  // Only macros whose expansion declares something are looked for as statements
+  const declaring = [...macros.values()].filter((macro) => {
+    const invocation = macro.parameters ? `${macro.name}(${macro.parameters.filter(parameter => parameter !== '...').join(', ')})` : macro.name
+    return readDeclarations(expandMacros(invocation, macros)).length > 0
+  })
+  if (declaring.length === 0) return nodes
+
+  const code = maskDirectivesAndComments(content)
+  const lineStarts = [0, ...[...content.matchAll(/\n/g)].map(match => match.index! + 1)]
+  const lineAt = (index: number) => lineStarts.filter(start => start <= index).length
+  const names = declaring.map(macro => macro.name).join('|')
+
+  for (const match of code.matchAll(new RegExp(`^[ \\t]*(${names})\\b`, 'gm'))) {
+    const macro = macros.get(match[1]!)!
+    const start = match.index! + match[0].length - macro.name.length
+    let end = start + macro.name.length
+    if (macro.parameters) {
+      const open = code.substring(end).search(/\S/)
+      if (open === -1 || code[end + open] !== '(') continue
+      const close = findClosing(code, end + open, '(', ')')
+      if (close === -1) continue
+      end = close + 1
+    }
+
+    // A macro producing a function header is often followed by the body
+    const next = end + code.substring(end).search(/\S|$/)
+    const bodyEnd = code[next] === '{' ? findClosing(code, next, '{', '}') : -1
+    const declarations = readDeclarations(expandMacros(content.substring(start, end), macros))
+    const invocationEnd = bodyEnd === -1 ? end : bodyEnd + 1
+
+    for (const declaration of declarations) {
+      nodes.push({
+        id: `macro-decl-${filePath}-${lineAt(start)}-${declaration.name}`,
+        type: declaration.kind === 'function' ? 'function' : declaration.kind === 'variable' ? 'variable' : 'class',
+        name: declaration.name,
+        path: filePath,
+        startLine: lineAt(start),
+        endLine: lineAt(invocationEnd - 1),
+        content: content.substring(start, invocationEnd),
+        symbol: {
+          kind: declaration.kind,
+          visibility: /^static\b/.test(declaration.signature) ? 'internal' : 'public',
+          signature: declaration.signature,
+          macro: macro.name,
+        },
+      })
+    }
+  }
+  return nodes
+}
+
+/**
+ * Macros of the headers a file includes with quotes, resolved next to the file. Angle-bracket
+ * includes and include paths are not followed.
+ */
+function collectIncludedMacros(content: string, filePath: string, depth = 0, seen = new Set<string>()): CMacro[] {
+  if (depth >= MAX_INCLUDE_DEPTH) return []
+
+  const macros: CMacro[] = []
+  for (const include of content.matchAll(/^\s*#\s*include\s+"([^"]+)"/gm)) {
+    const path = resolve(dirname(filePath), include[1]!)
+    if (seen.has(path) || !isFile(path)) continue
+    seen.add(path)
+    const header = readText(path)
+    if (header === undefined) continue
+    macros.push(...collectIncludedMacros(header, path, depth + 1, seen), ...readMacroDefinitions(header))
+  }
+  return macros
+}
+
+/**
+ * Splits code into top-level statements, each with its text before any `{ ... }` body. Type
+ * definitions run to their `;`, so `typedef struct { ... } name;` stays one statement.
+ */
+function splitStatements(code: string): { head: string, definesBody: boolean }[] {
+  const statements: { head: string, definesBody: boolean }[] = []
+  let head = ''
+  let tail = ''
+  let definesBody = false
+
+  const flush = () => {
+    statements.push({ head: (head + tail).trim(), definesBody })
+    head = ''
+    tail = ''
+    definesBody = false
+  }
+
+  for (let i = 0; i < code.length; i++) {
+    const char = code[i]!
+    if (char === '"' || char === '\'') {
+      const end = skipLiteral(code, i)
+      const literal = code.substring(i, end + 1)
+      if (definesBody) tail += literal
+      else head += literal
+      i = end
+    }
+    else if (char === '{') {
+      const close = findClosing(code, i, '{', '}')
+      const typeDefinition = /^\s*(?:template\s*<[^{;]*>\s*)?(?:typedef|struct|class|union|enum)\b/.test(head)
+      definesBody = true
+      i = close === -1 ? code.length : close
+      if (!typeDefinition) flush()
+    }
+    else if (char === ';') {
+      flush()
+    }
+    else if (definesBody) {
+      tail += char
+    }
+    else {
+      head += char
+    }
+  }
+  if ((head + tail).trim()) flush()
+  return statements.filter(statement => statement.head)
+}
+
+function findClosing(text: string, open: number, opening: string, closing: string): number {
+  let depth = 0
+  for (let i = open; i < text.length; i++) {
+    const char = text[i]
+    if (char === '"' || char === '\'') i = skipLiteral(text, i)
+    else if (char === opening) depth++
+    else if (char === closing && --depth === 0) return i
+  }
+  return -1
+}
+
+function skipLiteral(text: string, start: number): number {
+  const quote = text[start]
+  for (let i = start + 1; i < text.length; i++) {
+    if (text[i] === '\\') i++
+    else if (text[i] === quote || text[i] === '\n') return i
+  }
+  return text.length - 1
+}
+
+/**
+ * Splits on a separator outside brackets. Macro arguments only group by parentheses and braces;
+ * template parameters by angle brackets as well.
+ */
+function splitTopLevel(text: string, separator: ',' | '=', brackets = '([{'): string[] {
+  const closing = brackets.replace('(', ')').replace('[', ']').replace('{', '}').replace('<', '>')
+  const parts: string[] = []
+  let depth = 0
+  let start = 0
+  for (let i = 0; i < text.length; i++) {
+    const char = text[i]!
+    if (brackets.includes(char)) depth++
+    else if (closing.includes(char)) depth--
+    else if (char === separator && depth === 0) {
+      parts.push(text.substring(start, i).trim())
+      start = i + 1
+    }
+  }
+  parts.push(text.substring(start).trim())
+  return parts.filter(Boolean)
+}
+
+/**
+ * Blanks preprocessor directives and comments, keeping offsets and line breaks, so macro
+ * invocations are only found in code
+ */
+function maskDirectivesAndComments(content: string): string {
+  const blank = (text: string) => text.replace(/[^\n]/g, ' ')
+  return content
+    .replace(/\/\*[\s\S]*?\*\/|\/\/[^\n]*/g, blank)
+    .replace(/^[ \t]*#(?:[^\n]*\\\n)*[^\n]*/gm, blank)
+}
+
+function stripComments(text: string): string {
+  return text.replace(/\/\*[\s\S]*?\*\//g, ' ').replace(/\/\/.*$/, '')
+}
+
+function readText(path: string): string | undefined {
+  try {
+    return readFileSync(path, 'utf-8')
+  }
+  catch {
+    // An unreadable header contributes no macros
+    return undefined
+  }
+}
@@ -0,0 +1,298 @@
+/**
+ * Crystal - modules, classes, structs, enums, libs and their methods and macros read from the
+ * source text. Blocks are matched on their `end`, telling block `if` and `unless` apart from
+ * their modifier form (`return unless ready`), which has none.
+ */
+
+import type { SymbolKind, SymbolVisibility, TreeNode } from '../types/core.js'
+
+interface Definition {
+  type: string
+  kind: SymbolKind
+  name: string
+  start: number
+  end: number
+  signature: string
+  visibility: SymbolVisibility
+  container?: string
+}
+
+interface Block {
+  keyword: string
+  start: number
+  end: number
+  parent?: Block
+  name?: string // Modules, classes, structs, enums and libs
+}
+
+const MAX_SIGNATURE_LENGTH = 200
+
+const BLOCK_KEYWORD = /(?<![\w.:@$?!])(?:(class|struct|module|enum|lib|annotation|union|def|macro|fun|if|unless|while|until|case|begin|do|select)|(end))(?![\w?!:])/g
+const CONDITIONAL = new Set(['if', 'unless', 'while', 'until'])
+const TYPE_KEYWORDS = new Set(['class', 'struct', 'module', 'enum', 'lib', 'annotation', 'union'])
+const TYPE_HEADER = /^(class|struct|module|enum|lib|annotation|union)\s+((?:::)?[A-Z][\w:]*)/
+const DEF_HEADER = /^(def|macro|fun)\s+(?:self\.)?([A-Za-z_]\w*[?!=]?|\[\][?=]?|[+\-*/%<>=!~^&|]+)/
+const ABSTRACT_DEF = /^[ \t]*(?:(private|protected)\s+)?abstract\s+def\s+(?:self\.)?([A-Za-z_]\w*[?!=]?|\[\][?=]?|[+\-*/%<>=!~^&|]+)[^\n]*/gm
+const TYPE_KINDS: Record<string, { type: string, kind: SymbolKind }> = {
+  class: { type: 'class', kind: 'class' },
+  struct: { type: 'class', kind: 'struct' },
+  union: { type: 'class', kind: 'struct' },
+  enum: { type: 'class', kind: 'enum' },
+  annotation: { type: 'class', kind: 'type' },
+  module: { type: 'module', kind: 'module' },
+  lib: { type: 'module', kind: 'module' },
+}
+
+/**
+ * `module` nodes for modules and C bindings (`lib`), `class` nodes for classes, structs, enums
+ * and annotations, `method` nodes for their `def`s (including `abstract def`), `variant` nodes
+ * for enum members, `macro` nodes, and `function` nodes for top-level `def`s and `lib`
+ * functions. Types nest as `A::B` containers; `private` and `protected` set the visibility.
+ */
+export function extractCrystalDefinitions(content: string, filePath: string): TreeNode[] {
+  const code = maskCrystal(content)
+  const blocks = readBlocks(code)
+  const innermost = (offset: number) => blocks
+    .filter(block => block.start < offset && offset < block.end)
+    .sort((a, b) => b.start - a.start)[0]
+  const definitions: Definition[] = []
+
+  for (const block of blocks) {
+    const text = code.substring(block.start, block.end)
+    if (TYPE_KEYWORDS.has(block.keyword)) {
+      const match = text.match(TYPE_HEADER)
+      if (!match) continue
+      block.name = match[2]!.replace(/^::/, '')
+      const start = modifiedStart(code, block.start)
+      definitions.push({
+        ...TYPE_KINDS[block.keyword]!,
+        name: block.name.split('::').pop()!,
+        start,
+        end: block.end,
+        signature: oneLine(content.substring(start, lineEnd(code, block.start))),
+        visibility: visibilityAt(code, block.start),
+        container: containerOf(block.parent, block.name),
+      })
+      if (block.keyword === 'enum') definitions.push(...readEnumMembers(content, code, block, blocks))
+    }
+    else if (block.keyword === 'def' || block.keyword === 'macro' || block.keyword === 'fun') {
+      const match = text.match(DEF_HEADER)
+      if (!match || (block.parent && !isDeclarationScope(block.parent))) continue
+      const start = modifiedStart(code, block.start)
+      definitions.push({
+        ...routineKind(block.keyword, block.parent),
+        name: match[2]!,
+        start,
+        end: block.end,
+        signature: oneLine(content.substring(start, signatureEnd(code, block.start + match[0].length))),
+        visibility: visibilityAt(code, block.start),
+        container: containerOf(block.parent),
+      })
+    }
+  }
+
+  // abstract def and the functions a lib declares have no body, and no `end`
+  for (const match of code.matchAll(ABSTRACT_DEF)) {
+    const start = match.index! + match[0].search(/\S/)
+    const parent = innermost(start)
+    definitions.push({
+      ...routineKind('def', parent),
+      name: match[2]!,
+      start,
+      end: match.index! + match[0].length,
+      signature: oneLine(content.substring(start, match.index! + match[0].length)),
+      visibility: match[1] === 'private' ? 'private' : match[1] === 'protected' ? 'protected' : 'public',
+      container: containerOf(parent),
+    })
+  }
+  for (const block of blocks.filter(candidate => candidate.keyword === 'lib')) {
+    const body = code.substring(block.start, block.end)
+    for (const match of body.matchAll(/^[ \t]*fun\s+(\w+)[^\n]*/gm)) {
+      const start = block.start + match.index! + match[0].search(/\S/)
+      if (innermost(start) !== block) continue
+      definitions.push({
+        type: 'function',
+        kind: 'function',
+        name: match[1]!,
+        start,
+        end: block.start + match.index! + match[0].length,
+        signature: oneLine(content.substring(start, block.start + match.index! + match[0].length)),
+        visibility: 'public',
+        container: containerOf(block),
+      })
+    }
+  }
+
+  const lines = content.split('\n')
+  return definitions
+    .sort((a, b) => a.start - b.start)
+    .map((definition) => {
+      const startLine = lineAt(content, definition.start)
+      const endLine = lineAt(content, definition.end)
+      const doc = readDocComment(lines, startLine)
+      return {
+        id: `crystal-${definition.type}-${filePath}-${startLine}-${definition.name}`,
+        type: definition.type,
+        name: definition.name,
+        path: filePath,
+        startLine,
+        endLine,
+        content: lines.slice(startLine - 1, endLine).join('\n'),
+        symbol: {
+          kind: definition.kind,
+          visibility: definition.visibility,
+          signature: definition.signature.substring(0, MAX_SIGNATURE_LENGTH),
+          ...(definition.container ? { container: definition.container } : {}),
+          ...(doc ? { doc } : {}),
+        },
+      }
+    })
+}
+
+/**
+ * Matches every block keyword with its `end`. `if`, `unless`, `while` and `until` only open a
+ * block at the start of a statement or expression; `fun` only has a body outside a `lib`.
+ */
+function readBlocks(code: string): Block[] {
+  const blocks: Block[] = []
+  const stack: Block[] = []
+
+  for (const match of code.matchAll(BLOCK_KEYWORD)) {
+    const top = stack[stack.length - 1]
+    const before = code.substring(code.lastIndexOf('\n', match.index! - 1) + 1, match.index!)
+    // Methods may be named after keywords: `def select`
+    if (/\b(?:def|macro|fun)\s+(?:self\.)?$/.test(before)) continue
+    if (match[2]) {
+      if (top) {
+        stack.pop()
+        top.end = match.index! + match[0].length
+        blocks.push(top)
+      }
+      continue
+    }
+
+    const keyword = match[1]!
+    if (CONDITIONAL.has(keyword) && !/(?:^|[;=(,[{]|\|\||&&)\s*$/.test(before)) continue
+    if (keyword === 'def' && /\babstract\s+$/.test(before)) continue
+    if (keyword === 'fun' && top?.keyword === 'lib') continue
+    stack.push({ keyword, start: match.index!, end: code.length, parent: top })
+  }
+  return blocks.sort((a, b) => a.start - b.start)
+}
+
+/**
+ * Enum members: the constant names written directly in the enum body
+ */
+function readEnumMembers(content: string, code: string, block: Block, blocks: Block[]): Definition[] {
+  const members: Definition[] = []
+  const headerEnd = lineEnd(code, block.start)
+  const body = code.substring(headerEnd, block.end)
+  for (const match of body.matchAll(/^[ \t]*([A-Z]\w*)[ \t]*(?:=[^\n]*)?$/gm)) {
+    const start = headerEnd + match.index! + match[0].search(/\S/)
+    if (blocks.some(other => other !== block && other.start < start && start < other.end && other.start > block.start)) continue
+    members.push({
+      type: 'variant',
+      kind: 'variant',
+      name: match[1]!,
+      start,
+      end: headerEnd + match.index! + match[0].length,
+      signature: oneLine(content.substring(start, headerEnd + match.index! + match[0].length)),
+      visibility: 'public',
+      container: containerOf(block),
+    })
+  }
+  return members
+}
+
+function routineKind(keyword: string, parent: Block | undefined): { type: string, kind: SymbolKind } {
+  if (keyword === 'macro') return { type: 'macro', kind: 'macro' }
+  return parent && TYPE_KEYWORDS.has(parent.keyword) && parent.keyword !== 'lib'
+    ? { type: 'method', kind: 'method' }
+    : { type: 'function', kind: 'function' }
+}
+
+/**
+ * Methods and macros are declarations at the top level and in types; elsewhere, as in a
+ * `macro` body, they are generated code
+ */
+function isDeclarationScope(block: Block): boolean {
+  return TYPE_KEYWORDS.has(block.keyword)
+}
+
+function containerOf(block: Block | undefined, ownName?: string): string | undefined {
+  const names: string[] = []
+  for (let current = block; current; current = current.parent) {
+    if (current.name) names.unshift(current.name)
+  }
+  // `class A::B` nests B in A without an enclosing block
+  if (ownName?.includes('::')) names.push(ownName.split('::').slice(0, -1).join('::'))
+  return names.length > 0 ? names.join('::') : undefined
+}
+
+/**
+ * The start of a declaration including its `private`, `protected` or `abstract` modifiers
+ */
+function modifiedStart(code: string, start: number): number {
+  const before = code.substring(code.lastIndexOf('\n', start - 1) + 1, start)
+  const modifiers = before.match(/(?:(?:private|protected|abstract)\s+)+$/)
+  return modifiers ? start - modifiers[0].length : start
+}
+
+function visibilityAt(code: string, start: number): SymbolVisibility {
+  const before = code.substring(code.lastIndexOf('\n', start - 1) + 1, start)
+  if (/\bprivate\s+(?:abstract\s+)?$/.test(before)) return 'private'
+  if (/\bprotected\s+(?:abstract\s+)?$/.test(before)) return 'protected'
+  return 'public'
+}
+
+/**
+ * The end of a `def` header: its parameters, when given, and a return type or `forall` clause
+ */
+function signatureEnd(code: string, nameEnd: number): number {
+  let index = nameEnd
+  if (code[index] === '(') {
+    let depth = 0
+    for (; index < code.length; index++) {
+      if (code[index] === '(') depth++
+      else if (code[index] === ')' && --depth === 0) break
+    }
+    index++
+  }
+  const tail = code.substring(index).match(/^[^\n;]*/)![0]
+  return index + tail.replace(/\s+$/, '').length
+}
+
+/**
+ * The `#` comment lines directly above a declaration, which Crystal takes as its documentation
+ */
+function readDocComment(lines: string[], startLine: number): string | undefined {
+  const comments: string[] = []
+  for (let index = startLine - 2; index >= 0 && /^\s*#(?!\[)/.test(lines[index]!); index--) {
+    comments.unshift(lines[index]!.replace(/^\s*#\s?/, '').trimEnd())
+  }
+  return comments.join('\n').trim() || undefined
+}
+
+/**
+ * Blanks comments and the contents of strings and character literals, keeping offsets and
+ * line numbers. Interpolated `#{...}` code is blanked with its string.
+ */
+export function maskCrystal(content: string): string {
+  return content.replace(
+    /#[^\n]*|"(?:\\.|#\{[^}]*\}|[^"\\])*"|'(?:\\.|[^'\\\n])'/g,
+    match => match.startsWith('#') ? match.replace(/[^\n]/g, ' ') : match[0] + match.slice(1, -1).replace(/[^\n]/g, ' ') + match[0],
+  )
+}
+
+function lineEnd(code: string, index: number): number {
+  const newline = code.indexOf('\n', index)
+  return newline === -1 ? code.length : newline
+}
+
+function oneLine(text: string): string {
+  return text.replace(/\s+/g, ' ').trim()
+}
+
+function lineAt(content: string, index: number): number {
+  return content.substring(0, index).split('\n').length
+}
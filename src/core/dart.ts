@@ -0,0 +1,332 @@
+/**
+ * Dart - classes, mixins, enums, extensions and their members read from the source text,
+ * with the supertypes Flutter widget detection needs
+ */
+
+import type { SymbolKind, TreeNode } from '../types/core.js'
+
+export interface DartMember {
+  name: string // Constructors keep their class prefix: `Counter` or `Counter.initial`
+  kind: 'method' | 'getter' | 'setter' | 'constructor' | 'operator'
+  start: number
+  end: number
+  signature: string
+  body?: { start: number, end: number } // Block or arrow body; unset for abstract members
+}
+
+export interface DartClass {
+  name: string
+  keyword: 'class' | 'mixin' | 'enum' | 'extension'
+  modifiers: string[] // `abstract`, `sealed`, `interface`, ...
+  superclass?: string // Without type arguments
+  superTypeArguments: string[] // `State<Counter>` gives `['Counter']`
+  interfaces: string[]
+  mixins: string[]
+  start: number
+  end: number
+  signature: string
+  members: DartMember[]
+}
+
+export interface DartSource {
+  classes: DartClass[]
+  functions: DartMember[]
+}
+
+interface Segment {
+  start: number
+  end: number
+  headerEnd: number // Start of the body: `{`, `=>`, or the end of a bodiless declaration
+  open?: number // Offset of a block body's `{`
+  close?: number
+}
+
+const MAX_SIGNATURE_LENGTH = 200
+
+const CLASS_HEADER = /^((?:(?:abstract|base|final|sealed|interface|macro)\s+)*)(class|mixin(?:\s+class)?|enum|extension(?:\s+type)?)\b\s*(?!on\b)(\w+)?/
+const LEADING_ANNOTATIONS = /^(?:\s*@[\w.]+(?:\s*\((?:[^()]|\([^()]*\))*\))?)*\s*/
+const MEMBER_NAME = /(?:^|[\s>?\]])(get\s+|set\s+)?([A-Za-z_$][\w$]*(?:\.[A-Za-z_$][\w$]*)?)\s*(?:<[^()]*>)?\s*$/
+const GETTER = /(?:^|\s)get\s+([A-Za-z_$][\w$]*)\s*$/
+const OPERATOR = /\boperator\s*([^\s(]+)\s*$/
+const CLASS_KINDS: Record<DartClass['keyword'], SymbolKind> = { class: 'class', mixin: 'trait', enum: 'enum', extension: 'class' }
+
+/**
+ * Reads the classes of a library with their members, and its top-level functions
+ */
+export function readDart(content: string): DartSource {
+  const code = maskDart(content)
+  const classes: DartClass[] = []
+  const functions: DartMember[] = []
+
+  for (const segment of readSegments(code, 0, code.length)) {
+    const { start, header } = stripAnnotations(code, segment)
+    const classMatch = header.match(CLASS_HEADER)
+    if (classMatch && segment.open !== undefined) {
+      classes.push(readClass(content, code, segment, start, classMatch))
+      continue
+    }
+    const member = readMember(content, code, segment, start, header)
+    if (member) functions.push(member)
+  }
+
+  return { classes, functions }
+}
+
+/**
+ * `class` nodes for classes, mixins, enums and extensions, `method` nodes for their members
+ * with the class as `symbol.container`, and `function` nodes for top-level functions. Names
+ * starting with `_` are library-private.
+ */
+export function extractDartDefinitions(content: string, filePath: string): TreeNode[] {
+  const { classes, functions } = readDart(content)
+  const lines = content.split('\n')
+  const node = (type: string, name: string, start: number, end: number, kind: SymbolKind, signature: string, container?: string): TreeNode => {
+    const startLine = lineAt(content, start)
+    const endLine = lineAt(content, end)
+    const doc = readDocComment(content, start)
+    const ownName = name.split('.').pop()!
+    return {
+      id: `dart-${type}-${filePath}-${startLine}-${name}`,
+      type,
+      name,
+      path: filePath,
+      startLine,
+      endLine,
+      content: lines.slice(startLine - 1, endLine).join('\n'),
+      symbol: {
+        kind,
+        visibility: ownName.startsWith('_') ? 'private' : 'public',
+        signature: signature.substring(0, MAX_SIGNATURE_LENGTH),
+        ...(container ? { container } : {}),
+        ...(doc ? { doc } : {}),
+      },
+    }
+  }
+
+  return [
+    ...classes.flatMap(cls => [
+      node('class', cls.name, cls.start, cls.end, CLASS_KINDS[cls.keyword], cls.signature),
+      ...cls.members.map(member => node('method', member.name, member.start, member.end, 'method', member.signature, cls.name)),
+    ]),
+    ...functions.map(fn => node('function', fn.name, fn.start, fn.end, 'function', fn.signature)),
+  ].sort((a, b) => (a.startLine ?? 0) - (b.startLine ?? 0))
+}
+
+function readClass(content: string, code: string, segment: Segment, start: number, match: RegExpMatchArray): DartClass {
+  const keyword = match[2]!.split(/\s+/)[0] as DartClass['keyword']
+  const headerText = code.substring(start, segment.headerEnd)
+  const clause = (word: string) => headerText.match(new RegExp(String.raw`\b${word}\s+([\s\S]*?)(?=\b(?:extends|with|implements|on)\b|$)`))?.[1]
+  const superclass = clause(keyword === 'extension' ? 'on' : 'extends')?.trim()
+
+  const members: DartMember[] = []
+  const segments = readSegments(code, segment.open! + 1, segment.close!)
+  // An enum's values come before its members
+  for (const memberSegment of keyword === 'enum' ? segments.slice(1) : segments) {
+    const stripped = stripAnnotations(code, memberSegment)
+    const member = readMember(content, code, memberSegment, stripped.start, stripped.header, match[3])
+    if (member) members.push(member)
+  }
+
+  return {
+    name: match[3] ?? superclass ?? 'extension',
+    keyword,
+    modifiers: match[1]!.trim().split(/\s+/).filter(Boolean),
+    ...(superclass ? { superclass: superclass.replace(/<[\s\S]*$/, '').trim() } : {}),
+    superTypeArguments: splitTypes(superclass?.match(/<([\s\S]*)>/)?.[1] ?? ''),
+    interfaces: splitTypes(clause('implements') ?? '').map(type => type.replace(/<[\s\S]*$/, '')),
+    mixins: splitTypes(clause('with') ?? '').map(type => type.replace(/<[\s\S]*$/, '')),
+    start,
+    end: segment.close! + 1,
+    signature: oneLine(content.substring(start, segment.headerEnd)),
+    members,
+  }
+}
+
+function readMember(content: string, code: string, segment: Segment, start: number, header: string, className?: string): DartMember | undefined {
+  const paren = topLevelParen(header)
+  // Fields and variables are assigned before any parameter list
+  const assignment = header.search(/(?<![=!<>])=(?![=>])/)
+  if (assignment !== -1 && (paren === -1 || assignment < paren)) return undefined
+
+  let name: string
+  let kind: DartMember['kind'] = 'method'
+  if (paren === -1) {
+    const getter = header.match(GETTER)
+    if (!getter) return undefined
+    name = getter[1]!
+    kind = 'getter'
+  }
+  else {
+    // After the parameters only modifiers or an initializer list may follow; anything else is a
+    // field of function type like `final void Function(int) onTap`
+    const rest = header.substring(closingParen(header, paren)).trim()
+    if (rest && !/^(?:async\*?|sync\*|:)/.test(rest)) return undefined
+
+    const before = header.substring(0, paren)
+    const operator = before.match(OPERATOR)
+    const named = operator ? undefined : before.match(MEMBER_NAME)
+    if (!operator && !named) return undefined
+    name = operator ? `operator ${operator[1]}` : named![2]!
+    if (operator) kind = 'operator'
+    else if (named![1]?.startsWith('set')) kind = 'setter'
+    else if (named![1]?.startsWith('get')) kind = 'getter'
+    else if (className && (name === className || name.startsWith(`${className}.`))) kind = 'constructor'
+    else if (/[^\w$.]/.test(name) || ['if', 'for', 'while', 'switch', 'return'].includes(name)) return undefined
+  }
+
+  const hasBody = segment.open !== undefined || code.startsWith('=>', segment.headerEnd)
+  return {
+    name,
+    kind,
+    start,
+    end: segment.end,
+    signature: oneLine(content.substring(start, segment.headerEnd)),
+    ...(hasBody ? { body: { start: segment.headerEnd, end: segment.end } } : {}),
+  }
+}
+
+/**
+ * Splits a library or class body into its declarations. A declaration ends at a `;` outside
+ * brackets, or at the `}` closing its block body.
+ */
+function readSegments(code: string, from: number, to: number): Segment[] {
+  const segments: Segment[] = []
+  let start = from
+  let headerEnd: number | undefined
+  let open: number | undefined
+  let braces = 0
+  let parens = 0
+  let assigned = false // A top-level `=` makes a following `{` a literal rather than a body
+
+  for (let index = from; index < to; index++) {
+    const char = code[index]!
+    if (/\s/.test(char) && start === index && braces === 0 && parens === 0) {
+      start++
+      continue
+    }
+
+    if (char === '(' || char === '[') {
+      parens++
+    }
+    else if (char === ')' || char === ']') {
+      parens--
+    }
+    else if (char === '=' && code[index + 1] === '>' && braces === 0 && parens === 0 && headerEnd === undefined) {
+      headerEnd = index
+    }
+    else if (char === '=' && braces === 0 && parens === 0 && !/[=!<>]/.test(code[index - 1] ?? '') && !/[=>]/.test(code[index + 1] ?? '')) {
+      assigned = true
+    }
+    else if (char === '{') {
+      if (braces === 0 && parens === 0 && headerEnd === undefined && !assigned) {
+        headerEnd = index
+        open = index
+      }
+      braces++
+    }
+    else if (char === '}') {
+      braces--
+      if (braces === 0 && parens === 0 && open !== undefined) {
+        segments.push({ start, end: index + 1, headerEnd: headerEnd!, open, close: index })
+        start = index + 1
+        headerEnd = open = undefined
+        assigned = false
+      }
+    }
+    else if (char === ';' && braces === 0 && parens === 0) {
+      segments.push({ start, end: index + 1, headerEnd: headerEnd ?? index })
+      start = index + 1
+      headerEnd = open = undefined
+      assigned = false
+    }
+  }
+
+  return segments
+}
+
+function stripAnnotations(code: string, segment: Segment): { start: number, header: string } {
+  const header = code.substring(segment.start, segment.headerEnd)
+  const annotations = header.match(LEADING_ANNOTATIONS)![0]
+  return { start: segment.start + annotations.length, header: header.substring(annotations.length).trimEnd() }
+}
+
+function closingParen(text: string, open: number): number {
+  let depth = 0
+  for (let index = open; index < text.length; index++) {
+    if (text[index] === '(') depth++
+    else if (text[index] === ')' && --depth === 0) return index + 1
+  }
+  return text.length
+}
+
+function topLevelParen(header: string): number {
+  let angles = 0
+  for (let index = 0; index < header.length; index++) {
+    const char = header[index]!
+    if (char === '<') angles++
+    else if (char === '>' && header[index - 1] !== '=') angles--
+    else if (char === '(' && angles <= 0) return index
+  }
+  return -1
+}
+
+function splitTypes(text: string): string[] {
+  const types: string[] = []
+  let depth = 0
+  let current = ''
+  for (const char of text) {
+    if (char === '<') depth++
+    else if (char === '>') depth--
+    if (char === ',' && depth === 0) {
+      types.push(current.trim())
+      current = ''
+    }
+    else {
+      current += char
+    }
+  }
+  if (current.trim()) types.push(current.trim())
+  return types
+}
+
+/**
+ * The `///` lines or doc block directly above a declaration and its annotations
+ */
+function readDocComment(content: string, start: number): string | undefined {
+  const before = content.substring(0, start).replace(/\s+$/, '')
+  const block = before.match(/\/\*\*((?:(?!\*\/)[\s\S])*)\*\/$/)
+  if (block) {
+    return block[1]!.split('\n').map(line => line.replace(/^\s*\*?\s?/, '').trimEnd()).join('\n').trim() || undefined
+  }
+
+  const lines = before.split('\n')
+  const comments: string[] = []
+  for (let index = lines.length - 1; index >= 0 && /^\s*\/\/\//.test(lines[index]!); index--) {
+    comments.unshift(lines[index]!.replace(/^\s*\/\/\/\s?/, '').trimEnd())
+  }
+  return comments.join('\n').trim() || undefined
+}
+
+/**
+ * Blanks comments and string contents, including raw and triple-quoted strings, keeping
+ * offsets and line numbers
+ */
+export function maskDart(content: string): string {
+  return content.replace(
+    /\/\/[^\n]*|\/\*[\s\S]*?\*\/|r?'''[\s\S]*?'''|r?"""[\s\S]*?"""|r?'(?:\\.|[^'\\\n])*'|r?"(?:\\.|[^"\\\n])*"/g,
+    (match) => {
+      if (match.startsWith('/')) return match.replace(/[^\n]/g, ' ')
+      const quote = match.match(/^r?('''|"""|'|")/)![1]!
+      const open = match.indexOf(quote) + quote.length
+      return match.substring(0, open) + match.substring(open, match.length - quote.length).replace(/[^\n]/g, ' ') + quote
+    },
+  )
+}
+
+function oneLine(text: string): string {
+  return text.replace(/\s+/g, ' ').trim()
+}
+
+export function lineAt(content: string, index: number): number {
+  return content.substring(0, index).split('\n').length
+}
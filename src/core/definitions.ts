@@ -0,0 +1,122 @@
+/**
+ * Go-to-definition - the declaration an identifier at a position refers to, from the
+ * project's language server when one is installed and otherwise by matching its name against
+ * the index. Each definition names the resolver that produced it.
+ */
+
+import { readFileSync } from 'fs'
+import { relative } from 'path'
+import { definitionsAt, type LanguageServerOptions, type ServerStatus, type SourceLocation } from '../lsp/client.js'
+import { getAllNodes } from '../project/manager.js'
+import { findSymbolCandidates, symbolCandidate, type SymbolCandidate } from './symbol-ids.js'
+import { isFile } from '../utils/helpers.js'
+import type { Project, TreeNode } from '../types/core.js'
+
+export const TREE_SITTER_RESOLVER = 'tree-sitter'
+
+export type ResolvedDefinition = Partial<SymbolCandidate> & {
+  name: string
+  path: string
+  startLine: number
+  column?: number // 1-based, where the language server located the name
+  external?: boolean // Outside the project, e.g. in the standard library or a dependency
+  resolvedBy: string // Language server id, or tree-sitter for a name match in the index
+}
+
+export interface DefinitionLookup {
+  name?: string // Identifier at the position
+  resolvedBy: string // Resolver that produced `definitions`
+  definitions: ResolvedDefinition[]
+  otherCandidates: ResolvedDefinition[] // Declarations of the same name the language server ruled out
+  languageServer: { server?: string, status: ServerStatus, error?: string }
+}
+
+export interface DefinitionOptions {
+  precise?: boolean // Ask the language server; the index alone when false
+  settings?: LanguageServerOptions
+}
+
+const IDENTIFIER_CHAR = /[\w$]/
+
+/**
+ * The declarations the identifier at a 1-based line and column of `filePath` refers to. A
+ * language server's answer replaces the name matches of the index, which stay available as
+ * `otherCandidates`; without a server, or when it fails or finds nothing, the index answers.
+ */
+export async function findDefinitions(project: Project, filePath: string, line: number, column: number, options: DefinitionOptions = {}): Promise<DefinitionLookup> {
+  const { precise = true, settings = {} } = options
+  const text = isFile(filePath) ? readFileSync(filePath, 'utf-8') : ''
+  const name = identifierAt(text.split('\n')[line - 1] ?? '', column - 1)
+
+  const indexed = name ? nameMatches(project, name, filePath) : []
+  const answer = precise
+    ? await definitionsAt(project.config.directory, filePath, line, column, settings)
+    : { status: 'disabled' as const }
+  const languageServer = { server: answer.server, status: answer.status, ...(answer.error ? { error: answer.error } : {}) }
+
+  const located = (answer.result ?? []).map(location => locatedDefinition(project, location, answer.server!))
+  if (located.length === 0) {
+    return { name, resolvedBy: TREE_SITTER_RESOLVER, definitions: indexed, otherCandidates: [], languageServer }
+  }
+
+  const confirmed = new Set(located.map(definition => `${definition.path}:${definition.startLine}`))
+  return {
+    name,
+    resolvedBy: answer.server!,
+    definitions: located,
+    otherCandidates: indexed.filter(candidate => !confirmed.has(`${candidate.path}:${candidate.startLine}`)),
+    languageServer,
+  }
+}
+
+/**
+ * The identifier around a 0-based index of a line, if there is one
+ */
+export function identifierAt(line: string, index: number): string | undefined {
+  let start = Math.min(Math.max(index, 0), line.length)
+  let end = start
+  while (start > 0 && IDENTIFIER_CHAR.test(line[start - 1]!)) start--
+  while (end < line.length && IDENTIFIER_CHAR.test(line[end]!)) end++
+  return end > start ? line.substring(start, end) : undefined
+}
+
+/**
+ * Declarations of the name in the index, those of the file asking first
+ */
+function nameMatches(project: Project, name: string, filePath: string): ResolvedDefinition[] {
+  return findSymbolCandidates(project, name)
+    .sort((a, b) => Number(b.path === filePath) - Number(a.path === filePath))
+    .map(candidate => ({ ...candidate, startLine: candidate.startLine ?? 1, resolvedBy: TREE_SITTER_RESOLVER }))
+}
+
+/**
+ * A language server location, described by the indexed declaration it points at when there is one
+ */
+export function locatedDefinition(project: Project, location: SourceLocation, server: string): ResolvedDefinition {
+  const text = isFile(location.path) ? readFileSync(location.path, 'utf-8') : ''
+  const name = identifierAt(text.split('\n')[location.line - 1] ?? '', location.column - 1) ?? ''
+  const external = relative(project.config.directory, location.path).startsWith('..')
+
+  const node = external ? undefined : declarationAt(project, location.path, location.line, name)
+  if (node) {
+    return { ...symbolCandidate(project, node), startLine: node.startLine!, column: location.column, resolvedBy: server }
+  }
+  return {
+    name,
+    path: location.path,
+    startLine: location.line,
+    column: location.column,
+    ...(external ? { external: true } : {}),
+    resolvedBy: server,
+  }
+}
+
+/**
+ * The innermost indexed declaration of `name` spanning the line
+ */
+function declarationAt(project: Project, path: string, line: number, name: string): TreeNode | undefined {
+  return getAllNodes(project)
+    .filter(node => node.path === path && node.name === name && node.type !== 'file' && node.type !== 'parameter')
+    .filter(node => node.startLine! <= line && line <= (node.endLine ?? node.startLine!))
+    .sort((a, b) => b.startLine! - a.startLine!)[0]
+}
@@ -0,0 +1,259 @@
+/**
+ * Container build file extraction - stages, base images and COPY/ADD paths from Dockerfiles,
+ * and service definitions from compose files
+ */
+
+import { parseYaml, getYamlKeyLine } from '../utils/yaml.js'
+import type { TreeNode } from '../types/core.js'
+
+export interface DockerStage {
+  index: number
+  name?: string
+  baseImage: string
+  line: number
+}
+
+export interface DockerCopy {
+  instruction: 'COPY' | 'ADD'
+  sources: string[]
+  destination: string
+  from?: string // --from=<stage|image>; sources then come from that stage, not the build context
+  stage: number
+  line: number
+}
+
+export interface DockerfileDefinitions {
+  stages: DockerStage[]
+  copies: DockerCopy[]
+  env: { name: string, line: number }[]
+}
+
+export interface ComposeService {
+  name: string
+  image?: string
+  build?: { context: string, dockerfile?: string }
+  line: number
+  dependsOn: string[]
+  environment: { name: string, line: number }[]
+  envFiles: string[]
+}
+
+interface Instruction {
+  keyword: string
+  args: string
+  line: number
+}
+
+/**
+ * Extracts build stages, ENV variables and COPY/ADD instructions from a Dockerfile
+ */
+export function parseDockerfile(content: string): DockerfileDefinitions {
+  const definitions: DockerfileDefinitions = { stages: [], copies: [], env: [] }
+
+  for (const instruction of readInstructions(content)) {
+    if (instruction.keyword === 'FROM') {
+      const [baseImage = '', as, name] = instruction.args.replace(/--platform=\S+\s*/, '').split(/\s+/)
+      definitions.stages.push({
+        index: definitions.stages.length,
+        name: as?.toUpperCase() === 'AS' ? name : undefined,
+        baseImage,
+        line: instruction.line,
+      })
+    }
+    else if (instruction.keyword === 'ENV') {
+      // ENV KEY=value [KEY2=value ...], or the legacy single-pair form ENV KEY value
+      const names = instruction.args.includes('=')
+        ? Array.from(instruction.args.matchAll(/(?:^|\s)([A-Za-z_][\w]*)=/g), match => match[1]!)
+        : [instruction.args.split(/\s+/)[0]!]
+      definitions.env.push(...names.filter(Boolean).map(name => ({ name, line: instruction.line })))
+    }
+    else if (instruction.keyword === 'COPY' || instruction.keyword === 'ADD') {
+      const copy = readCopyArguments(instruction.args)
+      if (!copy) continue
+      definitions.copies.push({
+        instruction: instruction.keyword,
+        ...copy,
+        stage: Math.max(0, definitions.stages.length - 1),
+        line: instruction.line,
+      })
+    }
+  }
+
+  return definitions
+}
+
+/**
+ * Extracts the services of a compose file with their image, build context, dependencies and environment
+ */
+export function parseComposeFile(content: string): ComposeService[] {
+  const document = parseYaml(content) as { services?: Record<string, unknown> } | null
+  const services = document?.services
+  if (!services || typeof services !== 'object') return []
+
+  return Object.entries(services).map(([name, definition]) => {
+    const service = (definition ?? {}) as {
+      image?: string
+      build?: string | { context?: string, dockerfile?: string }
+      depends_on?: string[] | Record<string, unknown>
+      environment?: string[] | Record<string, unknown>
+      env_file?: string | (string | { path?: string })[]
+    }
+    const line = getYamlKeyLine(services, name) ?? 1
+    const environment = Array.isArray(service.environment)
+      ? service.environment.map(entry => ({ name: String(entry).split('=')[0]!, line }))
+      : Object.keys(service.environment ?? {}).map(key => ({ name: key, line: getYamlKeyLine(service.environment, key) ?? line }))
+    const envFiles = (Array.isArray(service.env_file) ? service.env_file : service.env_file ? [service.env_file] : [])
+      .map(entry => typeof entry === 'string' ? entry : entry.path)
+      .filter((path): path is string => Boolean(path))
+    const build = typeof service.build === 'string'
+      ? { context: service.build }
+      : service.build ? { context: service.build.context ?? '.', dockerfile: service.build.dockerfile } : undefined
+
+    return {
+      name,
+      image: service.image,
+      build,
+      line,
+      dependsOn: Array.isArray(service.depends_on) ? service.depends_on : Object.keys(service.depends_on ?? {}),
+      environment,
+      envFiles,
+    }
+  })
+}
+
+/**
+ * Converts Dockerfile definitions into tree nodes: each stage becomes a `stage` node and its
+ * base image and copied paths become `image` and `copy` nodes, so searches for either hit the Dockerfile
+ */
+export function dockerfileToNodes(content: string, filePath: string): TreeNode[] {
+  const { stages, copies } = parseDockerfile(content)
+  const lines = content.split('\n')
+  const nodes: TreeNode[] = []
+
+  for (const [position, stage] of stages.entries()) {
+    const endLine = (stages[position + 1]?.line ?? lines.length + 1) - 1
+    nodes.push({
+      id: `docker-stage-${filePath}-${stage.index}`,
+      type: 'stage',
+      name: stage.name ?? stage.baseImage,
+      path: filePath,
+      startLine: stage.line,
+      endLine,
+      content: lines.slice(stage.line - 1, endLine).join('\n'),
+    })
+    nodes.push({
+      id: `docker-image-${filePath}-${stage.index}`,
+      type: 'image',
+      name: stage.baseImage,
+      path: filePath,
+      startLine: stage.line,
+      endLine: stage.line,
+      content: lines[stage.line - 1] ?? '',
+    })
+  }
+
+  for (const copy of copies) {
+    for (const source of copy.sources) {
+      nodes.push({
+        id: `docker-copy-${filePath}-${copy.line}-${source}`,
+        type: 'copy',
+        name: source,
+        path: filePath,
+        startLine: copy.line,
+        endLine: copy.line,
+        content: lines[copy.line - 1] ?? '',
+      })
+    }
+  }
+
+  return nodes.sort((a, b) => (a.startLine ?? 0) - (b.startLine ?? 0))
+}
+
+/**
+ * Converts compose services into `service` nodes, with an `image` node for each referenced image
+ */
+export function composeToNodes(content: string, filePath: string): TreeNode[] {
+  const lines = content.split('\n')
+  const services = parseComposeFile(content)
+  const nodes: TreeNode[] = []
+
+  for (const [position, service] of services.entries()) {
+    const endLine = (services[position + 1]?.line ?? lines.length + 1) - 1
+    nodes.push({
+      id: `compose-service-${filePath}-${service.name}`,
+      type: 'service',
+      name: service.name,
+      path: filePath,
+      startLine: service.line,
+      endLine,
+      content: lines.slice(service.line - 1, endLine).join('\n'),
+    })
+
+    if (service.image) {
+      nodes.push({
+        id: `compose-image-${filePath}-${service.name}`,
+        type: 'image',
+        name: service.image,
+        path: filePath,
+        startLine: service.line,
+        endLine,
+      })
+    }
+  }
+
+  return nodes
+}
+
+function readInstructions(content: string): Instruction[] {
+  const instructions: Instruction[] = []
+  const lines = content.split('\n')
+
+  for (let i = 0; i < lines.length; i++) {
+    const line = lines[i]!.trim()
+    if (!line || line.startsWith('#')) continue
+
+    const startLine = i + 1
+    let text = line
+    // Backslash continues an instruction; comment lines inside a continuation are skipped
+    while (text.endsWith('\\') && i + 1 < lines.length) {
+      i++
+      const next = lines[i]!.trim()
+      text = text.slice(0, -1).trimEnd() + (next.startsWith('#') ? '' : ' ' + next)
+    }
+
+    const match = text.match(/^(\w+)\s*(.*)$/)
+    if (match) {
+      instructions.push({ keyword: match[1]!.toUpperCase(), args: match[2]!.trim(), line: startLine })
+    }
+  }
+
+  return instructions
+}
+
+function readCopyArguments(args: string): { sources: string[], destination: string, from?: string } | null {
+  let rest = args
+  let from: string | undefined
+
+  // --from, --chown, --chmod, --link ... precede the paths
+  while (rest.startsWith('--')) {
+    const flag = rest.match(/^--([\w-]+)(?:=(\S+))?\s*/)!
+    if (flag[1] === 'from') from = flag[2]
+    rest = rest.substring(flag[0].length)
+  }
+
+  let paths: string[]
+  if (rest.startsWith('[')) {
+    try {
+      paths = JSON.parse(rest)
+    }
+    catch {
+      return null
+    }
+  }
+  else {
+    paths = rest.split(/\s+/).filter(Boolean)
+  }
+
+  if (paths.length < 2) return null
+  return { sources: paths.slice(0, -1), destination: paths[paths.length - 1]!, from }
+}
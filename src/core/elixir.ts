@@ -0,0 +1,448 @@
+/**
+ * Elixir - modules, functions and macros read from the source text, since the Elixir grammar
+ * represents every definition as a generic `call` node, and Phoenix router routes resolved to
+ * the controller actions that handle them
+ */
+
+import type { SymbolKind, TreeNode } from '../types/core.js'
+
+export interface PhoenixRoute {
+  method: string
+  path: string
+  line: number
+  controller: string // Controller or LiveView module, qualified with the aliases of its scopes
+  action?: string
+}
+
+interface Definition {
+  type: string
+  kind: SymbolKind
+  name: string
+  container?: string
+  visibility: 'public' | 'private'
+  start: number
+  end: number
+  signature: string
+  doc?: string
+}
+
+interface Scope {
+  end: number
+  path: string
+  alias?: string
+}
+
+const MAX_SIGNATURE_LENGTH = 200
+
+const DEFINITION = /^[ \t]*(defmodule|defprotocol|defimpl|defmacrop?|defguardp?|defdelegate|defp?)[ \t]+/gm
+const MODULE_NAME = /^[A-Z][\w.]*/
+const FUNCTION_NAME = /^([a-z_][\w]*[?!]?)/
+const BLOCK_TOKEN = /(?<![\w.:?!@])(do|fn|end)\b(?![?!:])/g
+const DOC_ATTRIBUTE = /^[ \t]*@(doc|moduledoc)\s+(?:~[sS])?("""|'''|")/gm
+const ROUTER_STATEMENT = /^[ \t]*(scope|resources|live|get|post|put|patch|delete|options|head)\b/gm
+const RESOURCE_ACTIONS: { action: string, methods: string[], suffix: string, member: boolean }[] = [
+  { action: 'index', methods: ['GET'], suffix: '', member: false },
+  { action: 'edit', methods: ['GET'], suffix: '/edit', member: true },
+  { action: 'new', methods: ['GET'], suffix: '/new', member: false },
+  { action: 'show', methods: ['GET'], suffix: '', member: true },
+  { action: 'create', methods: ['POST'], suffix: '', member: false },
+  { action: 'update', methods: ['PATCH', 'PUT'], suffix: '', member: true },
+  { action: 'delete', methods: ['DELETE'], suffix: '', member: true },
+]
+
+/**
+ * Modules (`defmodule`, `defprotocol`, `defimpl`) become `class` nodes named by their full
+ * dotted name, `def`/`defp` clauses `function` nodes with the module as container, consecutive
+ * clauses of one function forming one node, and `defmacro` `macro` nodes. Router modules also
+ * get a `route` node per route, named by its path, with the controller action in
+ * `symbol.handler`.
+ */
+export function extractElixirDefinitions(content: string, filePath: string): TreeNode[] {
+  const code = maskElixir(content)
+  const blocks = matchBlocks(code)
+  const definitions = readDefinitions(content, code, blocks)
+  const lines = content.split('\n')
+  const nodes = definitions.map(definition => toNode(content, lines, filePath, definition))
+
+  for (const route of isPhoenixRouter(code) ? extractPhoenixRoutes(content) : []) {
+    const handler = route.action ? `${route.controller}.${route.action}` : route.controller
+    nodes.push({
+      id: `route-${filePath}-${route.line}-${route.method}-${route.path}`,
+      type: 'route',
+      name: route.path,
+      path: filePath,
+      startLine: route.line,
+      endLine: route.line,
+      content: lines[route.line - 1] ?? '',
+      symbol: { kind: 'function', visibility: 'public', signature: `${route.method} ${route.path}`, handler },
+    })
+  }
+
+  return nodes.sort((a, b) => (a.startLine ?? 0) - (b.startLine ?? 0))
+}
+
+/**
+ * Reads the routes of a Phoenix router: verb macros (`get "/", PageController, :home`), `live`
+ * routes and `resources`, expanded into their standard actions with `only:`/`except:` applied.
+ * Scope paths prefix the routes inside them and scope aliases qualify their controllers;
+ * nested resources add the parent's `:user_id` parameter.
+ */
+export function extractPhoenixRoutes(content: string): PhoenixRoute[] {
+  const code = maskElixir(content)
+  const blocks = matchBlocks(code)
+  const routes: PhoenixRoute[] = []
+  const scopes: Scope[] = []
+
+  for (const match of code.matchAll(ROUTER_STATEMENT)) {
+    const start = match.index! + match[0].length - match[1]!.length
+    while (scopes.length > 0 && scopes.at(-1)!.end < start) scopes.pop()
+
+    const end = statementEnd(code, start, blocks)
+    const text = content.substring(start, end.header).replace(/\s+/g, ' ')
+    const line = lineAt(content, start)
+    const prefix = scopes.map(scope => scope.path).join('/')
+    const alias = scopes.map(scope => scope.alias).filter(Boolean).join('.')
+    const qualify = (module: string) => alias ? `${alias}.${module}` : module
+
+    if (match[1] === 'scope') {
+      const path = text.match(/^scope\s+"([^"]*)"/)?.[1] ?? keywordString(text, 'path') ?? ''
+      const scopeAlias = text.match(/^scope\s+(?:"[^"]*"\s*,\s*)?([A-Z][\w.]*)/)?.[1] ?? text.match(/\balias:\s*([A-Z][\w.]*)/)?.[1]
+      if (end.block !== undefined) scopes.push({ end: end.block, path: trimSlashes(path), alias: scopeAlias })
+      continue
+    }
+
+    if (match[1] === 'resources') {
+      const resource = text.match(/^resources\s+"([^"]*)"\s*,\s*([A-Z][\w.]*)(.*)$/)
+      if (!resource) continue
+      const options = resource[3]!
+      const singleton = /\bsingleton:\s*true\b/.test(options)
+      const param = keywordString(options, 'param') ?? 'id'
+      const only = atomList(options, 'only')
+      const except = atomList(options, 'except')
+      const base = joinPath(prefix, resource[1]!)
+
+      for (const action of RESOURCE_ACTIONS) {
+        if (singleton && action.action === 'index') continue
+        if ((only && !only.includes(action.action)) || except?.includes(action.action)) continue
+        const path = base + (action.member && !singleton ? `/:${param}` : '') + action.suffix
+        for (const method of action.methods) {
+          routes.push({ method, path, line, controller: qualify(resource[2]!), action: action.action })
+        }
+      }
+
+      // Resources nested in this one are mounted under the parent's member path
+      if (end.block !== undefined) {
+        const name = keywordString(options, 'name') ?? resourceName(resource[2]!)
+        scopes.push({ end: end.block, path: trimSlashes(joinPath(resource[1]!, singleton ? '' : `:${name}_${param}`)) })
+      }
+      continue
+    }
+
+    if (match[1] === 'live') {
+      const live = text.match(/^live\s+"([^"]*)"\s*,\s*([A-Z][\w.]*)(?:\s*,\s*:(\w+))?/)
+      if (live) routes.push({ method: 'GET', path: joinPath(prefix, live[1]!), line, controller: qualify(live[2]!), action: live[3] })
+      continue
+    }
+
+    const verb = text.match(/^\w+\s+"([^"]*)"\s*,\s*([A-Z][\w.]*)\s*,\s*:(\w+[?!]?)/)
+    if (verb) {
+      routes.push({ method: match[1]!.toUpperCase(), path: joinPath(prefix, verb[1]!), line, controller: qualify(verb[2]!), action: verb[3] })
+    }
+  }
+
+  return routes
+}
+
+/**
+ * Whether masked Elixir source defines a Phoenix router
+ */
+export function isPhoenixRouter(code: string): boolean {
+  return /\buse\s+(?:Phoenix\.Router\b|[A-Z][\w.]*\s*,\s*:router\b)/.test(code)
+}
+
+function readDefinitions(content: string, code: string, blocks: Map<number, number>): Definition[] {
+  const definitions: Definition[] = []
+  const modules: Definition[] = []
+  const docs = readDocAttributes(content, code)
+  let previousStart = -1
+
+  for (const match of code.matchAll(DEFINITION)) {
+    const keyword = match[1]!
+    const start = match.index! + match[0].search(/\S/)
+    const nameStart = match.index! + match[0].length
+    const end = statementEnd(code, start, blocks)
+    const container = [...modules].reverse().find(module => module.start < start && module.end >= start)
+    const signature = content.substring(start, end.header).replace(/\s+/g, ' ').trim().substring(0, MAX_SIGNATURE_LENGTH)
+    const doc = docs.filter(attribute => attribute.start > previousStart && attribute.start < start).at(-1)
+    previousStart = start
+
+    if (keyword === 'defmodule' || keyword === 'defprotocol' || keyword === 'defimpl') {
+      let name = code.substring(nameStart).match(MODULE_NAME)?.[0]
+      if (!name) continue
+      if (keyword === 'defimpl') {
+        // An implementation module is named after the protocol and the implementing type
+        const target = content.substring(nameStart, end.header).match(/\bfor:\s*([A-Z][\w.]*)/)?.[1] ?? container?.name
+        if (target) name = `${name}.${target}`
+      }
+      const module: Definition = {
+        type: 'class',
+        kind: keyword === 'defprotocol' ? 'interface' : 'module',
+        name: container && keyword !== 'defimpl' ? `${container.name}.${name}` : name,
+        ...(container ? { container: container.name } : {}),
+        visibility: 'public',
+        start,
+        end: end.block ?? end.statement,
+        signature,
+      }
+      modules.push(module)
+      definitions.push(module)
+      continue
+    }
+
+    const name = code.substring(nameStart).match(FUNCTION_NAME)?.[1]
+    if (!name || name === 'unquote') continue
+
+    const macro = keyword.startsWith('defmacro')
+    const previous = definitions.at(-1)
+    // Further clauses of the function just defined extend its node
+    if (previous && previous.name === name && previous.container === container?.name && previous.type !== 'class' && previous.end < start) {
+      previous.end = end.block ?? end.statement
+      continue
+    }
+
+    definitions.push({
+      type: macro ? 'macro' : 'function',
+      kind: macro ? 'macro' : 'function',
+      name,
+      ...(container ? { container: container.name } : {}),
+      visibility: keyword.endsWith('p') ? 'private' : 'public',
+      start,
+      end: end.block ?? end.statement,
+      signature,
+      ...(doc?.kind === 'doc' && doc.text ? { doc: doc.text } : {}),
+    })
+  }
+
+  // Module docs come from the @moduledoc inside the module rather than before it
+  for (const module of modules) {
+    const moduledoc = docs.find(attribute => attribute.kind === 'moduledoc' && attribute.start > module.start && attribute.start < module.end
+      && !modules.some(nested => nested !== module && nested.start > module.start && nested.start < attribute.start && nested.end > attribute.start))
+    if (moduledoc?.text) module.doc = moduledoc.text
+  }
+
+  return definitions
+}
+
+function toNode(content: string, lines: string[], filePath: string, definition: Definition): TreeNode {
+  const startLine = lineAt(content, definition.start)
+  const endLine = lineAt(content, definition.end)
+  return {
+    id: `elixir-${definition.type}-${filePath}-${startLine}-${definition.name}`,
+    type: definition.type,
+    name: definition.name,
+    path: filePath,
+    startLine,
+    endLine,
+    content: lines.slice(startLine - 1, endLine).join('\n'),
+    symbol: {
+      kind: definition.kind,
+      visibility: definition.visibility,
+      signature: definition.signature,
+      ...(definition.container ? { container: definition.container } : {}),
+      ...(definition.doc ? { doc: definition.doc } : {}),
+    },
+  }
+}
+
+/**
+ * `@doc` and `@moduledoc` strings, with `@doc false` left out
+ */
+function readDocAttributes(content: string, code: string): { kind: string, start: number, text?: string }[] {
+  const attributes: { kind: string, start: number, text?: string }[] = []
+  for (const match of code.matchAll(DOC_ATTRIBUTE)) {
+    const quote = match[2]!
+    const open = match.index! + match[0].length
+    const close = content.indexOf(quote, open)
+    const text = close === -1 ? undefined : content.substring(open, close).split('\n').map(part => part.trim()).join('\n').trim()
+    attributes.push({ kind: match[1]!, start: match.index!, text: text || undefined })
+  }
+  return attributes
+}
+
+/**
+ * Finds where a definition or macro call starting at `start` ends. `header` is where its
+ * `do` keyword or block begins, `block` the end of its `do ... end` block if it has one.
+ */
+function statementEnd(code: string, start: number, blocks: Map<number, number>): { header: number, statement: number, block?: number } {
+  let depth = 0
+  let header = -1
+
+  for (let index = start; index < code.length; index++) {
+    const char = code[index]!
+    if ('([{'.includes(char)) depth++
+    else if (')]}'.includes(char)) depth--
+    else if (depth === 0 && blocks.has(index)) {
+      // A `fn ... end` in a `do:` body belongs to the body rather than opening the definition's block
+      if (header !== -1) {
+        index = blocks.get(index)! - 1
+        continue
+      }
+      return { header: index, statement: blocks.get(index)!, block: blocks.get(index)! }
+    }
+    else if (depth === 0 && header === -1 && /^,?\s*do:/.test(code.substring(index, index + 8)) && /[\s,]/.test(char)) {
+      header = index
+    }
+    else if (char === '\n' && depth <= 0) {
+      const before = code.substring(start, index).trimEnd()
+      const after = code.substring(index + 1).trimStart()
+      if (!/[,\\|=>(]$/.test(before) && !/^(?:do\b|when\b|\|>)/.test(after)) {
+        return { header: header === -1 ? index : header, statement: index }
+      }
+    }
+  }
+
+  return { header: header === -1 ? code.length : header, statement: code.length }
+}
+
+/**
+ * Pairs each block-opening `do` and `fn` with the end of its `end` keyword
+ */
+function matchBlocks(code: string): Map<number, number> {
+  const blocks = new Map<number, number>()
+  const open: number[] = []
+
+  for (const match of code.matchAll(BLOCK_TOKEN)) {
+    if (match[1] === 'end') {
+      const opening = open.pop()
+      if (opening !== undefined) blocks.set(opening, match.index! + 3)
+    }
+    else {
+      open.push(match.index!)
+    }
+  }
+
+  return blocks
+}
+
+/**
+ * Blanks comments and the contents of strings, charlists, heredocs and sigils, keeping their
+ * delimiters, offsets and line numbers
+ */
+export function maskElixir(content: string): string {
+  const chars = content.split('')
+  const blank = (from: number, to: number) => {
+    for (let index = from; index < to; index++) {
+      if (chars[index] !== '\n') chars[index] = ' '
+    }
+  }
+  const closeOf = (open: string) => ({ '(': ')', '[': ']', '{': '}', '<': '>' } as Record<string, string>)[open] ?? open
+
+  let index = 0
+  while (index < content.length) {
+    const char = content[index]!
+
+    if (char === '#') {
+      const end = content.indexOf('\n', index)
+      blank(index, end === -1 ? content.length : end)
+      index = end === -1 ? content.length : end
+    }
+    else if (char === '?' && !/[\w?!)\]}]/.test(content[index - 1] ?? '')) {
+      // Character literal such as ?# or ?"
+      const length = content[index + 1] === '\\' ? 3 : 2
+      blank(index + 1, index + length)
+      index += length
+    }
+    else if ((char === '"' || char === '\'') && content.startsWith(char.repeat(3), index)) {
+      const end = content.indexOf(char.repeat(3), index + 3)
+      const stop = end === -1 ? content.length : end
+      blank(index + 3, stop)
+      index = stop + 3
+    }
+    else if (char === '"' || char === '\'') {
+      const stop = stringEnd(content, index + 1, char)
+      blank(index + 1, stop)
+      index = stop + 1
+    }
+    else if (char === '~' && /[a-zA-Z]/.test(content[index + 1] ?? '')) {
+      let open = index + 1
+      while (/[a-zA-Z]/.test(content[open] ?? '')) open++
+      const delimiter = content[open] ?? ''
+      if (content.startsWith('"""', open) || content.startsWith('\'\'\'', open)) {
+        const end = content.indexOf(delimiter.repeat(3), open + 3)
+        const stop = end === -1 ? content.length : end
+        blank(open + 3, stop)
+        index = stop + 3
+      }
+      else if (/[([{<"'/|]/.test(delimiter)) {
+        const close = closeOf(delimiter)
+        let stop = open + 1
+        while (stop < content.length && content[stop] !== close) stop += content[stop] === '\\' ? 2 : 1
+        blank(open + 1, stop)
+        index = stop + 1
+      }
+      else {
+        index = open
+      }
+    }
+    else {
+      index++
+    }
+  }
+
+  return chars.join('')
+}
+
+/**
+ * Finds the closing quote of a string, stepping over escapes and `#{...}` interpolations
+ */
+function stringEnd(content: string, from: number, quote: string): number {
+  let index = from
+  while (index < content.length && content[index] !== quote) {
+    if (content[index] === '\\') {
+      index += 2
+    }
+    else if (content.startsWith('#{', index)) {
+      let depth = 1
+      index += 2
+      while (index < content.length && depth > 0) {
+        if (content[index] === '{') depth++
+        else if (content[index] === '}') depth--
+        index++
+      }
+    }
+    else {
+      index++
+    }
+  }
+  return index
+}
+
+/**
+ * `UserController` and `Admin.UserController` are the `user` resource
+ */
+function resourceName(controller: string): string {
+  const base = controller.split('.').pop()!.replace(/Controller$/, '')
+  return base.replace(/([a-z\d])([A-Z])/g, '$1_$2').toLowerCase()
+}
+
+function atomList(options: string, keyword: string): string[] | undefined {
+  const list = options.match(new RegExp(String.raw`\b${keyword}:\s*\[([^\]]*)\]`))?.[1]
+  return list === undefined ? undefined : [...list.matchAll(/:(\w+)/g)].map(match => match[1]!)
+}
+
+function keywordString(text: string, keyword: string): string | undefined {
+  return text.match(new RegExp(String.raw`\b${keyword}:\s*"([^"]*)"`))?.[1]
+}
+
+function joinPath(prefix: string, path: string): string {
+  const joined = [prefix, path].map(trimSlashes).filter(Boolean).join('/')
+  return `/${joined}`
+}
+
+function trimSlashes(path: string): string {
+  return path.replace(/^\/+|\/+$/g, '')
+}
+
+function lineAt(content: string, index: number): number {
+  return content.substring(0, index).split('\n').length
+}
@@ -0,0 +1,177 @@
+/**
+ * Fallback indexing for files in languages with neither a grammar nor an extractor - declarations
+ * guessed from keyword-led and C-like header lines, with blocks delimited by indentation. Every
+ * element is marked `confidence: 'low'` so callers can tell guesses from parsed code.
+ */
+
+import type { SymbolKind, SymbolVisibility, TreeNode } from '../types/core.js'
+
+interface Guess {
+  kind: SymbolKind
+  name: string
+  container?: string // Qualifier written in the name, e.g. `Parser` in `Parser.read`
+}
+
+const MAX_SIGNATURE_LENGTH = 200
+const MAX_LINE_LENGTH = 500
+
+const MODIFIERS = '(?:(?:export|public|private|protected|internal|static|async|pub|local|global|inline|extern|override|final|abstract|sealed|open|virtual|partial)\\s+)*'
+const NAME = '([A-Za-z_$][\\w$]*(?:(?:\\.|::|:)[A-Za-z_$][\\w$]*)*[!?]?)'
+const FUNCTION_KEYWORD = new RegExp(`^\\s*${MODIFIERS}(?:function|func|fun|fn|def|defp|sub|proc|procedure|method|subroutine|routine)\\s+${NAME}`, 'i')
+const TYPE_KEYWORD = new RegExp(`^\\s*${MODIFIERS}(class|struct|union|interface|protocol|trait|enum|record|object|module|namespace)\\s+${NAME}`, 'i')
+const LISP_FORM = /^\s*\((defun|defn-?|defmacro|defmethod|defgeneric|define|defclass|defstruct|defrecord|deftype|defprotocol)\s+\(?([^\s()]+)/
+// `int parse(char *s) {`, `void Parser::read()` followed by `{` on the next line
+const C_LIKE = /^\s*(?:[\w$:<>,*&[\]]+\s+)+[*&]*([A-Za-z_$][\w$]*(?:::[A-Za-z_$~][\w$]*)*)\s*\([^;]*$/
+const CONTROL = /^\s*(?:if|else|elif|elsif|for|foreach|while|until|do|switch|case|when|match|return|throw|raise|catch|try|new|delete|yield|await|goto|unless|with|select|assert|print|echo)\b/i
+// Lines at a block's own indentation that still belong to it
+const BLOCK_CLOSE = /^\s*(?:[}\])]+|end\b|fi\b|done\b|esac\b|end\w+\b|next\b|loop\b|wend\b)/i
+const COMMENT = /^\s*(?:\/\/+|#+|--+|;+|%+|'|\*|\/\*+|rem\b)\s?/i
+
+const TYPE_KINDS: Record<string, SymbolKind> = {
+  class: 'class',
+  object: 'class',
+  record: 'class',
+  struct: 'struct',
+  union: 'struct',
+  interface: 'interface',
+  protocol: 'interface',
+  trait: 'trait',
+  enum: 'enum',
+  module: 'module',
+  namespace: 'module',
+}
+
+const LISP_KINDS: Record<string, SymbolKind> = {
+  defmacro: 'macro',
+  defclass: 'class',
+  defstruct: 'struct',
+  defrecord: 'struct',
+  deftype: 'type',
+  defprotocol: 'interface',
+}
+
+/**
+ * Heuristic extraction for a file no language claims: `function`, `method` and type nodes for
+ * lines that look like declarations - `function`/`def`/`fn`/`sub`/`proc` routines, `class`,
+ * `struct`, `interface`, `enum` and `module` types, C-like headers opening a `{` block, and
+ * Lisp `defun`/`define` forms. A declaration runs to the last line indented below it, or to a
+ * closing `}`/`end` at its own indentation; routines inside a type are its methods. Binary
+ * content yields nothing.
+ */
+export function extractFallbackDefinitions(content: string, filePath: string): TreeNode[] {
+  if (content.includes('\0')) return []
+
+  const lines = content.split('\n')
+  const nodes: TreeNode[] = []
+  const types: { name: string, startLine: number, endLine: number }[] = []
+
+  lines.forEach((line, index) => {
+    if (line.length > MAX_LINE_LENGTH || COMMENT.test(line)) return
+    const guess = guessDeclaration(lines, index)
+    if (!guess) return
+
+    const end = blockEnd(lines, index)
+    const enclosing = [...types].reverse().find(type => type.startLine < index && index <= type.endLine)
+    const isType = !['function', 'method', 'macro'].includes(guess.kind)
+    if (isType) types.push({ name: guess.name, startLine: index, endLine: end })
+
+    const kind = guess.kind === 'function' && enclosing ? 'method' : guess.kind
+    const container = guess.container ?? enclosing?.name
+    const doc = docAbove(lines, index)
+    nodes.push({
+      id: `fallback-${kind}-${filePath}-${index + 1}-${guess.name}`,
+      type: kind,
+      name: guess.name,
+      path: filePath,
+      startLine: index + 1,
+      endLine: end + 1,
+      content: lines.slice(index, end + 1).join('\n'),
+      symbol: {
+        kind,
+        visibility: visibilityOf(line),
+        signature: line.trim().replace(/\s*\{\s*$/, '').substring(0, MAX_SIGNATURE_LENGTH),
+        confidence: 'low',
+        ...(container ? { container } : {}),
+        ...(doc ? { doc } : {}),
+      },
+    })
+  })
+
+  return nodes
+}
+
+function guessDeclaration(lines: string[], index: number): Guess | undefined {
+  const line = lines[index]!
+
+  const lisp = line.match(LISP_FORM)
+  if (lisp) return qualified(LISP_KINDS[lisp[1]!] ?? 'function', lisp[2]!.replace(/[)]+$/, ''))
+
+  const type = line.match(TYPE_KEYWORD)
+  if (type) return qualified(TYPE_KINDS[type[1]!.toLowerCase()]!, type[2]!)
+
+  const routine = line.match(FUNCTION_KEYWORD)
+  if (routine) return qualified('function', routine[1]!)
+
+  if (CONTROL.test(line) || /=\s*[^=>]/.test(line.split('(')[0]!)) return undefined
+  const header = line.match(C_LIKE)
+  // Without an opening brace this is as likely a call or a prototype as a definition
+  const opens = /\{\s*$/.test(line) || /^\s*\{/.test(lines[index + 1] ?? '')
+  if (header && opens) return qualified('function', header[1]!)
+  return undefined
+}
+
+/**
+ * Splits `Parser.read` or `Parser::read` into the name and the container it is qualified by
+ */
+function qualified(kind: SymbolKind, written: string): Guess {
+  const parts = written.split(/\.|::|:/)
+  const name = parts.pop()!
+  return parts.length > 0 ? { kind, name, container: parts.join('.') } : { kind, name }
+}
+
+/**
+ * The last line of the block a header on line `index` opens: lines indented further than the
+ * header, and an opening brace or a closing token at its own indentation
+ */
+function blockEnd(lines: string[], index: number): number {
+  const indent = indentOf(lines[index]!)
+  let end = index
+  for (let next = index + 1; next < lines.length; next++) {
+    const line = lines[next]!
+    if (!line.trim()) continue
+    if (indentOf(line) > indent) {
+      end = next
+      continue
+    }
+    // A brace on its own line opens the block of the header above it
+    if (indentOf(line) === indent && end === index && /^\s*\{\s*$/.test(line)) {
+      end = next
+      continue
+    }
+    if (indentOf(line) === indent && BLOCK_CLOSE.test(line)) end = next
+    break
+  }
+  return end
+}
+
+function indentOf(line: string): number {
+  return line.replace(/\t/g, '    ').match(/^ */)![0].length
+}
+
+function visibilityOf(line: string): SymbolVisibility {
+  if (/\b(?:private|local|defp)\b/.test(line)) return 'private'
+  if (/\bprotected\b/.test(line)) return 'protected'
+  if (/\binternal\b/.test(line)) return 'internal'
+  return 'public'
+}
+
+/**
+ * The comment lines directly above a declaration, without their markers
+ */
+function docAbove(lines: string[], index: number): string | undefined {
+  const doc: string[] = []
+  for (let above = index - 1; above >= 0 && COMMENT.test(lines[above]!) && lines[above]!.trim(); above--) {
+    doc.unshift(lines[above]!.replace(COMMENT, '').replace(/\*\/\s*$/, '').trim())
+  }
+  return doc.filter(Boolean).join(' ') || undefined
+}
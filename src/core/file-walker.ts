@@ -3,10 +3,10 @@
  */
 
 import { readdir, stat } from 'fs/promises'
-import { join, resolve, extname } from 'path'
-import { getLanguageByExtension } from './languages.js'
+import { join, resolve } from 'path'
+import { getLanguageForFile, getLanguageByName } from './languages.js'
 import { getLogger } from '../utils/logger.js'
-import { isTestFile, GLOBAL_IGNORE_DIRS } from '../constants/index.js'
+import { isTestFile, isNotebookFile, GLOBAL_IGNORE_DIRS, INDEXED_HIDDEN_ENTRIES, PARSER_NAMES } from '../constants/index.js'
 
 export interface WalkOptions {
   maxDepth?: number
@@ -37,29 +37,24 @@ export async function walkDirectory(
       const entries = await readdir(dir)
 
       for (const entry of entries) {
-        if (!includeHidden && entry.startsWith('.')) continue
+        if (!includeHidden && entry.startsWith('.') && !INDEXED_HIDDEN_ENTRIES.has(entry)) continue
 
         const fullPath = join(dir, entry)
         const stats = await stat(fullPath)
 
         if (stats.isDirectory()) {
-          if (!includeHidden && entry.startsWith('.')) {
-            continue
-          }
           if (!ignoreDirSet.has(entry)) {
             await walk(fullPath, depth + 1)
           }
         }
         else if (stats.isFile()) {
-          if (!includeHidden && entry.startsWith('.')) {
-            continue
-          }
-
           if (isTestFile(entry)) {
             continue
           }
 
-          const language = getLanguageByExtension(extname(fullPath))
+          // Notebooks are indexed with their kernel's parser; filter them as Python, the common case
+          const language = getLanguageForFile(fullPath)
+            ?? (isNotebookFile(entry) ? getLanguageByName(PARSER_NAMES.PYTHON) : undefined)
 
           if (languages.length === 0 || (language && languages.includes(language.name))) {
             files.push(resolve(fullPath))
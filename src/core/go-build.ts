@@ -0,0 +1,220 @@
+/**
+ * Go build constraints - `//go:build` lines (and legacy `// +build` lines) and `_GOOS`/`_GOARCH`
+ * file name suffixes, so symbols declared once per platform file can be told apart and
+ * searches limited to one build configuration
+ */
+
+import { dirname } from 'path'
+import type { TreeNode } from '../types/core.js'
+
+// The values `go tool dist list` knows, as in go/build/syslist.go
+export const GO_OPERATING_SYSTEMS = [
+  'aix', 'android', 'darwin', 'dragonfly', 'freebsd', 'hurd', 'illumos', 'ios', 'js', 'linux', 'nacl',
+  'netbsd', 'openbsd', 'plan9', 'solaris', 'wasip1', 'windows', 'zos',
+] as const
+export const GO_ARCHITECTURES = [
+  '386', 'amd64', 'amd64p32', 'arm', 'armbe', 'arm64', 'arm64be', 'loong64', 'mips', 'mipsle', 'mips64',
+  'mips64le', 'mips64p32', 'mips64p32le', 'ppc', 'ppc64', 'ppc64le', 'riscv', 'riscv64', 's390', 's390x',
+  'sparc', 'sparc64', 'wasm',
+] as const
+
+// Operating systems the `unix` constraint matches
+const UNIX = new Set(['aix', 'android', 'darwin', 'dragonfly', 'freebsd', 'hurd', 'illumos', 'ios', 'linux', 'netbsd', 'openbsd', 'solaris'])
+const OPERATING_SYSTEMS = new Set<string>(GO_OPERATING_SYSTEMS)
+const ARCHITECTURES = new Set<string>(GO_ARCHITECTURES)
+
+export interface BuildConstraint {
+  expression: string // File name suffix and directive combined, in `//go:build` syntax
+  directive?: string // The file's own `//go:build` expression
+  suffix?: string // File name suffix such as `_linux_amd64`
+}
+
+export interface BuildVariant {
+  path: string
+  constraint?: string
+}
+
+export interface GoBuildInfo {
+  constraint?: string
+  variants: BuildVariant[] // Declarations of the same symbol in the package's other build files
+}
+
+type Expression =
+  | { tag: string }
+  | { not: Expression }
+  | { and: [Expression, Expression] }
+  | { or: [Expression, Expression] }
+
+/**
+ * Build constraint of a Go file, or undefined when it builds everywhere
+ */
+export function readBuildConstraint(path: string, content: string): BuildConstraint | undefined {
+  const directive = readDirective(content)
+  const suffix = fileSuffix(path)
+  const terms = [
+    ...(suffix ? suffix.substring(1).split('_') : []),
+    ...(directive ? [/^[\w.!]+$/.test(directive) ? directive : `(${directive})`] : []),
+  ]
+  if (terms.length === 0) return undefined
+  return {
+    expression: terms.length === 1 && directive ? directive : terms.join(' && '),
+    ...(directive ? { directive } : {}),
+    ...(suffix ? { suffix } : {}),
+  }
+}
+
+/**
+ * The constraint lines only count in the header, before the package clause
+ */
+function readDirective(content: string): string | undefined {
+  const legacy: string[] = []
+  for (const line of content.split('\n')) {
+    const text = line.trim()
+    if (text.startsWith('package ') || text === 'package') break
+    const build = text.match(/^\/\/go:build\s+(.+)$/)
+    if (build) return build[1]!.trim()
+    const plus = text.match(/^\/\/\s*\+build\s+(.+)$/)
+    if (plus) legacy.push(fromLegacy(plus[1]!))
+  }
+  if (legacy.length === 0) return undefined
+  return legacy.length === 1 ? legacy[0] : legacy.map(line => line.includes('||') ? `(${line})` : line).join(' && ')
+}
+
+// `// +build linux,386 darwin,!cgo` is `(linux && 386) || (darwin && !cgo)`
+function fromLegacy(line: string): string {
+  const options = line.trim().split(/\s+/).map(option => option.split(',').join(' && '))
+  return options.length === 1 ? options[0]! : options.map(option => option.includes('&&') ? `(${option})` : option).join(' || ')
+}
+
+/**
+ * `_GOOS`, `_GOARCH` or `_GOOS_GOARCH` before `.go` (and `_test`), as `go build` reads it
+ */
+function fileSuffix(path: string): string | undefined {
+  const name = path.substring(path.lastIndexOf('/') + 1).replace(/\.go$/, '').replace(/_test$/, '')
+  const parts = name.split('_').slice(1)
+  const last = parts.at(-1)
+  const previous = parts.at(-2)
+  if (last !== undefined && previous !== undefined && OPERATING_SYSTEMS.has(previous) && ARCHITECTURES.has(last)) return `_${previous}_${last}`
+  if (last !== undefined && (OPERATING_SYSTEMS.has(last) || ARCHITECTURES.has(last))) return `_${last}`
+  return undefined
+}
+
+/**
+ * Whether a file with this constraint builds with the given tags, e.g. `linux`, `linux/arm64`
+ * or `windows,integration`. An operating system or architecture left out matches any; custom
+ * tags left out are unset, and Go release tags (`go1.21`) and `gc` are always set.
+ */
+export function matchesBuildTags(constraint: BuildConstraint | undefined, tags: string): boolean {
+  if (!constraint) return true
+  const expression = parseBuildExpression(constraint.expression)
+  if (!expression) return true
+
+  const given = tags.split(/[\s,/]+/).filter(Boolean)
+  const goos = given.filter(tag => OPERATING_SYSTEMS.has(tag))
+  const goarch = given.filter(tag => ARCHITECTURES.has(tag))
+  const custom = given.filter(tag => !OPERATING_SYSTEMS.has(tag) && !ARCHITECTURES.has(tag))
+
+  return (goos.length > 0 ? goos : GO_OPERATING_SYSTEMS).some(os =>
+    (goarch.length > 0 ? goarch : GO_ARCHITECTURES).some((arch) => {
+      const set = new Set([os, arch, ...custom, 'gc', ...(UNIX.has(os) ? ['unix'] : [])])
+      return evaluate(expression, tag => set.has(tag) || /^go1\.\d+$/.test(tag))
+    }))
+}
+
+/**
+ * Parses a `//go:build` expression of tags, `!`, `&&`, `||` and parentheses; undefined when
+ * it is malformed
+ */
+export function parseBuildExpression(text: string): Expression | undefined {
+  const tokens = text.match(/[\w.]+|&&|\|\||[!()]/g) ?? []
+  let position = 0
+
+  const primary = (): Expression | undefined => {
+    const token = tokens[position++]
+    if (token === '!') {
+      const operand = primary()
+      return operand && { not: operand }
+    }
+    if (token === '(') {
+      const inner = or()
+      return tokens[position++] === ')' ? inner : undefined
+    }
+    return token !== undefined && /^[\w.]+$/.test(token) ? { tag: token } : undefined
+  }
+  const and = (): Expression | undefined => {
+    let left = primary()
+    while (left && tokens[position] === '&&') {
+      position++
+      const right = primary()
+      left = right && { and: [left, right] }
+    }
+    return left
+  }
+  const or = (): Expression | undefined => {
+    let left = and()
+    while (left && tokens[position] === '||') {
+      position++
+      const right = and()
+      left = right && { or: [left, right] }
+    }
+    return left
+  }
+
+  const expression = or()
+  return position === tokens.length ? expression : undefined
+}
+
+function evaluate(expression: Expression, isSet: (tag: string) => boolean): boolean {
+  if ('tag' in expression) return isSet(expression.tag)
+  if ('not' in expression) return !evaluate(expression.not, isSet)
+  if ('and' in expression) return evaluate(expression.and[0], isSet) && evaluate(expression.and[1], isSet)
+  return evaluate(expression.or[0], isSet) || evaluate(expression.or[1], isSet)
+}
+
+/**
+ * Reads the constraints of the Go files among the nodes once, for annotating and filtering
+ * search results
+ */
+export function createGoBuildIndex(nodes: TreeNode[]) {
+  const constraints = new Map<string, BuildConstraint | undefined>()
+  for (const node of nodes) {
+    if (node.type === 'file' && node.path.endsWith('.go')) constraints.set(node.path, readBuildConstraint(node.path, node.content ?? ''))
+  }
+
+  // Declarations by package directory, kind and name
+  let declarations: Map<string, TreeNode[]> | undefined
+  const key = (node: TreeNode) => `${dirname(node.path)}\0${node.type}\0${node.name}`
+
+  return {
+    constraintOf: (path: string) => constraints.get(path),
+
+    matches: (path: string, tags: string) => !path.endsWith('.go') || matchesBuildTags(constraints.get(path), tags),
+
+    /**
+     * The node's constraint and the other build files of its package declaring the same
+     * symbol; undefined for nodes outside Go files or built on every platform without variants
+     */
+    describe: (node: TreeNode): GoBuildInfo | undefined => {
+      if (!node.path.endsWith('.go') || !node.name || node.type === 'file') return undefined
+      if (!declarations) {
+        declarations = new Map()
+        for (const candidate of nodes) {
+          if (!candidate.name || candidate.type === 'file' || !constraints.has(candidate.path)) continue
+          declarations.set(key(candidate), [...declarations.get(key(candidate)) ?? [], candidate])
+        }
+      }
+
+      const variants = (declarations.get(key(node)) ?? [])
+        .filter(candidate => candidate.path !== node.path)
+        .map(candidate => ({ path: candidate.path, ...describeConstraint(constraints.get(candidate.path)) }))
+        .filter((variant, index, all) => all.findIndex(other => other.path === variant.path) === index)
+      const constraint = constraints.get(node.path)
+      if (!constraint && variants.length === 0) return undefined
+      return { ...describeConstraint(constraint), variants }
+    },
+  }
+}
+
+function describeConstraint(constraint: BuildConstraint | undefined): { constraint?: string } {
+  return constraint ? { constraint: constraint.expression } : {}
+}
@@ -0,0 +1,95 @@
+/**
+ * Go generics - type parameter lists with their constraints, read from declaration headers,
+ * and the type arguments of instantiations such as `Map[string, int]` or `Max[float64](a, b)`
+ */
+
+import type { TypeParameter } from '../types/core.js'
+
+const IDENTIFIER = /^[A-Za-z_]\w*$/
+
+// Type arguments are types, never index or slice expressions (`xs[i+1]`, `s[1:]`, `m["key"]`)
+const TYPE_ARGUMENT = /^[\w.*()[\]{}, ]+$/
+
+/**
+ * Reads the type parameters a Go declaration header declares after `name`, as in
+ * `func Map[T, U any](...)` or `type Set[K comparable] map[K]struct{}`. Array types such as
+ * `type Block [32]byte` have no constraints and yield none.
+ */
+export function readGoTypeParameters(signature: string, name: string): TypeParameter[] {
+  const start = signature.search(new RegExp(`\\b${name}\\[`))
+  if (start === -1) return []
+  const list = readBracketed(signature, start + name.length)
+  return list === undefined ? [] : parseTypeParameterList(list)
+}
+
+/**
+ * Parses the inside of a Go type parameter list. Names sharing a constraint (`K, V any`)
+ * each get it; a group without a constraint means the brackets were not a parameter list.
+ */
+export function parseTypeParameterList(list: string): TypeParameter[] {
+  const parameters: TypeParameter[] = []
+  let pending: string[] = []
+
+  for (const part of splitTopLevel(list)) {
+    const match = part.match(/^([A-Za-z_]\w*)\s+(\S.*)$/)
+    if (match) {
+      for (const name of [...pending, match[1]!]) parameters.push({ name, constraint: match[2]!.trim() })
+      pending = []
+    }
+    else if (IDENTIFIER.test(part)) {
+      pending.push(part)
+    }
+    else {
+      return []
+    }
+  }
+  return pending.length > 0 ? [] : parameters
+}
+
+/**
+ * The explicit type arguments of an instantiation whose `[` starts at `index`, or undefined
+ * when the brackets hold an index or slice expression
+ */
+export function readGoTypeArguments(text: string, index: number): string[] | undefined {
+  if (text[index] !== '[') return undefined
+  const list = readBracketed(text, index)
+  if (!list?.trim() || !TYPE_ARGUMENT.test(list)) return undefined
+
+  const typeArguments = splitTopLevel(list)
+  return typeArguments.every(argument => /^[*[(]|^[A-Za-z_]/.test(argument) && !/\w\s*\*/.test(argument))
+    ? typeArguments.map(argument => argument.replace(/\s+/g, ' '))
+    : undefined
+}
+
+/**
+ * The text between the `[` at `index` and its matching `]`
+ */
+function readBracketed(text: string, index: number): string | undefined {
+  let depth = 0
+  for (let i = index; i < text.length; i++) {
+    const char = text[i]
+    if (char === '[' || char === '(' || char === '{') depth++
+    else if (char === ']' || char === ')' || char === '}') {
+      depth--
+      if (depth === 0) return char === ']' ? text.substring(index + 1, i) : undefined
+    }
+  }
+  return undefined
+}
+
+function splitTopLevel(list: string): string[] {
+  const parts: string[] = []
+  let depth = 0
+  let start = 0
+  for (let i = 0; i < list.length; i++) {
+    const char = list[i]
+    if (char === '[' || char === '(' || char === '{') depth++
+    else if (char === ']' || char === ')' || char === '}') depth--
+    else if (char === ',' && depth === 0) {
+      parts.push(list.substring(start, i).trim())
+      start = i + 1
+    }
+  }
+  parts.push(list.substring(start).trim())
+  return parts.filter(Boolean)
+}
@@ -0,0 +1,83 @@
+/**
+ * Go source scanning - functions and their bodies read from source text with comments and
+ * literal contents masked, for analyses that check statement order within a function
+ */
+
+export interface GoFunction {
+  name: string
+  start: number // Offset of the body's opening brace
+  body: string
+}
+
+const FUNC_DECLARATION = /^func\s+(?:\([^)]*\)\s*)?(\w+)/gm
+
+/**
+ * Top-level functions and methods with their bodies
+ */
+export function readGoFunctions(code: string): GoFunction[] {
+  const functions: GoFunction[] = []
+  for (const declaration of code.matchAll(FUNC_DECLARATION)) {
+    const paramsOpen = code.indexOf('(', declaration.index + declaration[0].length)
+    if (paramsOpen < 0) continue
+    // The body opens at the first brace outside the parameter and result lists
+    let open = matchingParen(code, paramsOpen) + 1
+    let depth = 0
+    for (; open < code.length; open++) {
+      const char = code[open]!
+      if (char === '(' || char === '[') depth++
+      if (char === ')' || char === ']') depth--
+      if ((char === '{' && depth === 0) || char === '\n') break
+    }
+    if (code[open] !== '{') continue
+    functions.push({ name: declaration[1]!, start: open, body: code.substring(open, matchingBrace(code, open) + 1) })
+  }
+  return functions
+}
+
+/**
+ * Blanks the bodies of function literals so statements inside goroutines and callbacks are not
+ * read as the enclosing function's
+ */
+export function blankGoClosures(body: string): string {
+  let result = body
+  for (const literal of body.matchAll(/\bfunc\s*\(/g)) {
+    if (literal.index === 0) continue
+    const open = result.indexOf('{', matchingParen(result, literal.index + literal[0].length - 1))
+    if (open < 0) continue
+    const close = matchingBrace(result, open)
+    result = result.substring(0, open + 1) + result.substring(open + 1, close).replace(/[^\n]/g, ' ') + result.substring(close)
+  }
+  return result
+}
+
+/**
+ * Blanks comments and the contents of string and rune literals, keeping line breaks
+ */
+export function maskGo(content: string): string {
+  return content.replace(
+    /\/\/[^\n]*|\/\*[\s\S]*?\*\/|"(?:\\.|[^"\\\n])*"|`[^`]*`|'(?:\\.|[^'\\\n])+'/g,
+    match => match.startsWith('/') ? match.replace(/[^\n]/g, ' ') : match[0] + match.slice(1, -1).replace(/[^\n]/g, ' ') + match[0],
+  )
+}
+
+export function matchingBrace(code: string, open: number): number {
+  let depth = 0
+  for (let i = open; i < code.length; i++) {
+    if (code[i] === '{') depth++
+    if (code[i] === '}' && --depth === 0) return i
+  }
+  return code.length - 1
+}
+
+export function matchingParen(code: string, open: number): number {
+  let depth = 0
+  for (let i = open; i < code.length; i++) {
+    if (code[i] === '(') depth++
+    if (code[i] === ')' && --depth === 0) return i
+  }
+  return code.length - 1
+}
+
+export function lineAt(content: string, index: number): number {
+  return content.substring(0, index).split('\n').length
+}
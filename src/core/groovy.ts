@@ -0,0 +1,206 @@
+/**
+ * Groovy - classes and methods of Groovy sources, the stages of Jenkins pipelines and the
+ * tasks of Gradle Groovy DSL builds, read from the source text
+ */
+
+import type { SymbolKind, SymbolVisibility, TreeNode } from '../types/core.js'
+
+interface Definition {
+  type: string
+  kind: SymbolKind
+  name: string
+  start: number
+  end: number
+  signature: string
+  visibility: SymbolVisibility
+  container?: string
+}
+
+const MAX_SIGNATURE_LENGTH = 200
+
+const CLASS = /^[ \t]*((?:(?:public|private|protected|abstract|final|static|sealed)\s+)*)(class|interface|trait|enum|@interface)\s+(\w+)[^{]*\{/gm
+const METHOD = /^[ \t]*((?:(?:public|private|protected|static|final|abstract|synchronized|def)\s+)*)([\w.]+(?:<[\w\s<>,.?]*>)?(?:\[\])*\s+)?(\w+)\s*\(([^()]*(?:\([^()]*\)[^()]*)*)\)\s*(?:throws\s+[\w.,\s]+)?\{/gm
+const STAGE = /\bstage\s*\(\s*(?:name\s*:\s*)?(['"])/g
+const TASK = /^[ \t]*(?:task\s+(\w+)|(?:task|tasks\.(?:register|create))\s*\(\s*(['"]))/gm
+const KEYWORDS = new Set(['if', 'for', 'while', 'switch', 'catch', 'synchronized', 'return', 'new', 'else', 'throw', 'assert'])
+const CLASS_KINDS: Record<string, SymbolKind> = { 'class': 'class', 'interface': 'interface', 'trait': 'trait', 'enum': 'enum', '@interface': 'interface' }
+
+/**
+ * `class` nodes for classes, interfaces, traits and enums, `method` nodes for their methods
+ * and `function` nodes for script-level `def` functions, `stage` nodes named after each
+ * Jenkins pipeline stage (nested and parallel stages have their enclosing stage as
+ * `symbol.container`), and `task` nodes for Gradle tasks declared with `task` or
+ * `tasks.register`
+ */
+export function extractGroovyDefinitions(content: string, filePath: string): TreeNode[] {
+  const code = maskGroovy(content)
+  const definitions: Definition[] = []
+
+  for (const match of code.matchAll(CLASS)) {
+    const start = match.index! + match[0].search(/\S/)
+    const open = match.index! + match[0].length - 1
+    definitions.push({
+      type: 'class',
+      kind: CLASS_KINDS[match[2]!]!,
+      name: match[3]!,
+      start,
+      end: closingBrace(code, open),
+      signature: oneLine(content.substring(start, open)),
+      visibility: toVisibility(match[1]!),
+    })
+  }
+  const classes = [...definitions]
+
+  for (const match of code.matchAll(METHOD)) {
+    const [, modifiers, returnType, name] = match
+    // A call followed by a closure, like `stage('x') {` or `node {`, is not a declaration
+    if (!modifiers && !returnType) continue
+    // `task copyDocs(type: Copy) {` declares a Gradle task, read below
+    if (KEYWORDS.has(name!) || KEYWORDS.has(returnType?.trim() ?? '') || returnType?.trim() === 'task') continue
+    const start = match.index! + match[0].search(/\S/)
+    const open = match.index! + match[0].length - 1
+    const owner = innermost(classes, start)
+    definitions.push({
+      type: owner ? 'method' : 'function',
+      kind: owner ? 'method' : 'function',
+      name: name!,
+      start,
+      end: closingBrace(code, open),
+      signature: oneLine(content.substring(start, open)),
+      visibility: toVisibility(modifiers!),
+      ...(owner ? { container: owner.name } : {}),
+    })
+  }
+
+  const stages: Definition[] = []
+  for (const match of code.matchAll(STAGE)) {
+    const nameStart = match.index! + match[0].length
+    const nameEnd = code.indexOf(match[1]!, nameStart)
+    if (nameEnd === -1) continue
+    const close = closingParen(code, code.indexOf('(', match.index!))
+    const body = code.substring(close).match(/^\s*\{/)
+    const end = body ? closingBrace(code, close + body[0].length - 1) : close
+    const parent = innermost(stages, match.index!)
+    const stage: Definition = {
+      type: 'stage',
+      kind: 'function',
+      name: content.substring(nameStart, nameEnd),
+      start: match.index!,
+      end,
+      signature: oneLine(content.substring(match.index!, close)),
+      visibility: 'public',
+      ...(parent ? { container: parent.name } : {}),
+    }
+    stages.push(stage)
+  }
+  definitions.push(...stages)
+
+  for (const match of code.matchAll(TASK)) {
+    const start = match.index! + match[0].search(/\S/)
+    const nameStart = match.index! + match[0].length
+    // Names are read from the source, since masking blanks the string naming the task
+    const nameEnd = match[1] ? nameStart : code.indexOf(match[2]!, nameStart)
+    if (nameEnd === -1) continue
+    const name = match[1] ?? content.substring(nameStart, nameEnd)
+    const header = code.substring(nameEnd).match(/^[^{\n]*/)![0]
+    const open = nameEnd + header.length
+    definitions.push({
+      type: 'task',
+      kind: 'function',
+      name,
+      start,
+      end: code[open] === '{' ? closingBrace(code, open) : open,
+      signature: oneLine(content.substring(start, open)),
+      visibility: 'public',
+    })
+  }
+
+  const lines = content.split('\n')
+  return definitions
+    .sort((a, b) => a.start - b.start)
+    .map((definition) => {
+      const startLine = lineAt(content, definition.start)
+      const endLine = lineAt(content, definition.end)
+      const doc = readDocComment(content, definition.start)
+      return {
+        id: `groovy-${definition.type}-${filePath}-${startLine}-${definition.name}`,
+        type: definition.type,
+        name: definition.name,
+        path: filePath,
+        startLine,
+        endLine,
+        content: lines.slice(startLine - 1, endLine).join('\n'),
+        symbol: {
+          kind: definition.kind,
+          visibility: definition.visibility,
+          signature: definition.signature.substring(0, MAX_SIGNATURE_LENGTH),
+          ...(definition.container ? { container: definition.container } : {}),
+          ...(doc ? { doc } : {}),
+        },
+      }
+    })
+}
+
+function innermost(definitions: Definition[], offset: number): Definition | undefined {
+  let found: Definition | undefined
+  for (const definition of definitions) {
+    if (definition.start < offset && offset < definition.end && (!found || definition.start > found.start)) found = definition
+  }
+  return found
+}
+
+function toVisibility(modifiers: string): SymbolVisibility {
+  if (/\bprivate\b/.test(modifiers)) return 'private'
+  if (/\bprotected\b/.test(modifiers)) return 'protected'
+  return 'public'
+}
+
+/**
+ * The `/** ... *\/` Groovydoc block directly above a declaration
+ */
+function readDocComment(content: string, start: number): string | undefined {
+  const before = content.substring(0, start).replace(/\s+$/, '')
+  const block = before.match(/\/\*\*((?:(?!\*\/)[\s\S])*)\*\/$/)
+  return block?.[1]!.split('\n').map(line => line.replace(/^\s*\*?\s?/, '').trimEnd()).join('\n').trim() || undefined
+}
+
+function closingBrace(code: string, open: number): number {
+  let depth = 0
+  for (let index = open; index < code.length; index++) {
+    if (code[index] === '{') depth++
+    else if (code[index] === '}' && --depth === 0) return index + 1
+  }
+  return code.length
+}
+
+function closingParen(code: string, open: number): number {
+  let depth = 0
+  for (let index = open; index < code.length; index++) {
+    if (code[index] === '(') depth++
+    else if (code[index] === ')' && --depth === 0) return index + 1
+  }
+  return code.length
+}
+
+/**
+ * Blanks comments and the contents of single, double and triple-quoted strings, keeping
+ * offsets and line numbers. GString `${...}` expressions are blanked with their string.
+ */
+export function maskGroovy(content: string): string {
+  return content.replace(
+    /\/\/[^\n]*|\/\*[\s\S]*?\*\/|'''[\s\S]*?'''|"""[\s\S]*?"""|'(?:\\.|[^'\\\n])*'|"(?:\\.|[^"\\\n])*"/g,
+    (match) => {
+      if (match.startsWith('/')) return match.replace(/[^\n]/g, ' ')
+      const quote = /^('''|""")/.test(match) ? match.substring(0, 3) : match[0]!
+      return quote + match.slice(quote.length, -quote.length).replace(/[^\n]/g, ' ') + quote
+    },
+  )
+}
+
+function oneLine(text: string): string {
+  return text.replace(/\s+/g, ' ').trim()
+}
+
+function lineAt(content: string, index: number): number {
+  return content.substring(0, index).split('\n').length
+}
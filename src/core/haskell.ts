@@ -0,0 +1,393 @@
+/**
+ * Haskell - the module, its functions, data types, type classes and constructors read from the
+ * source text. Declarations follow the layout rule: a top-level declaration runs until the
+ * next line starting in the first column.
+ */
+
+import { escapeRegExp } from '../constants/index.js'
+import type { SymbolKind, SymbolVisibility, TreeNode } from '../types/core.js'
+
+interface Definition {
+  type: string
+  kind: SymbolKind
+  name: string
+  start: number
+  end: number
+  signature: string
+  container?: string
+}
+
+interface Block {
+  start: number
+  end: number // Before the trailing blank lines
+  text: string // Masked
+}
+
+const MAX_SIGNATURE_LENGTH = 200
+
+const MODULE = /^module\s+([A-Z][\w.']*)/m
+const TYPE_DECLARATION = /^(data|newtype|type|class)(?:\s+(?:family|instance))?\s+(?:[^=]*?=>\s*)?\(?([A-Z][\w']*|:[!#$%&*+./<=>?@\\^|~:-]*)/
+const TYPE_SIGNATURE = /^((?:[a-z_][\w']*|\([!#$%&*+./<=>?@\\^|~:-]+\))(?:\s*,\s*(?:[a-z_][\w']*|\([!#$%&*+./<=>?@\\^|~:-]+\)))*)\s*::/
+const FOREIGN_IMPORT = /^foreign\s+import\s+\w+(?:\s+(?:safe|unsafe|interruptible))?(?:\s+"[^"]*")?\s+([a-z_][\w']*)\s*::/
+const EQUATION = /^([a-z_][\w']*)(?![\w'])|^\(([!#$%&*+./<=>?@\\^|~:-]+)\)/
+const INFIX_EQUATION = /^[a-z_][\w']*\s+(`[a-z_][\w']*`|[!#$%&*+./<>?@\\^|~:-][!#$%&*+./<=>?@\\^|~:-]*)\s/
+const KEYWORDS = new Set(['module', 'import', 'where', 'infix', 'infixl', 'infixr', 'deriving', 'default', 'foreign', 'pattern', 'let', 'in', 'instance', 'type', 'data', 'newtype', 'class'])
+const PATTERN_PREFIXES = new Set(['|', '~', '!', '@'])
+const TYPE_KINDS: Record<string, SymbolKind> = { data: 'type', newtype: 'type', type: 'type', class: 'trait' }
+
+/**
+ * A `module` node, `function` nodes spanning a type signature and its equations (`variable`
+ * for values without arguments), `class` nodes for data types, newtypes, type synonyms and
+ * type classes, `variant` nodes for data constructors and `method` nodes for class methods.
+ * With an export list, declarations it leaves out are private.
+ */
+export function extractHaskellDefinitions(content: string, filePath: string): TreeNode[] {
+  const code = maskHaskell(content)
+  const moduleMatch = code.match(MODULE)
+  const exports = moduleMatch ? readExportList(code, moduleMatch.index! + moduleMatch[0].length) : undefined
+  const isExported = (name: string) => !exports || exports.has(name.replace(/^\((.*)\)$/, '$1'))
+
+  const definitions: Array<Definition & { visibility: SymbolVisibility }> = []
+  const add = (definition: Definition, exported: boolean) => definitions.push({ ...definition, visibility: exported ? 'public' : 'private' })
+
+  if (moduleMatch) {
+    const end = moduleEnd(code, moduleMatch.index! + moduleMatch[0].length)
+    add({ type: 'module', kind: 'module', name: moduleMatch[1]!, start: moduleMatch.index!, end, signature: `module ${moduleMatch[1]}` }, true)
+  }
+
+  let pending: { names: string[], definition: Definition, hasArrow: boolean } | undefined
+  const flush = () => {
+    if (!pending) return
+    for (const name of pending.names) {
+      const { definition } = pending
+      const isFunction = pending.hasArrow
+      add({ ...definition, name, type: isFunction ? 'function' : 'variable', kind: isFunction ? 'function' : 'variable' }, isExported(name))
+    }
+    pending = undefined
+  }
+
+  for (const block of topLevelBlocks(code)) {
+    const signature = oneLine(content.substring(block.start, block.end))
+    const typeMatch = block.text.match(TYPE_DECLARATION)
+    if (typeMatch && !/^(?:type|data)\s+instance\b/.test(block.text)) {
+      flush()
+      const [, keyword, name] = typeMatch
+      const typeName = name!.startsWith(':') ? `(${name})` : name!
+      // A synonym is best described by what it stands for
+      const header = keyword === 'type' && !/^type\s+family\b/.test(block.text) ? signature : oneLine(content.substring(block.start, block.start + headerLength(block.text)))
+      add({ type: 'class', kind: TYPE_KINDS[keyword!]!, name: typeName, start: block.start, end: block.end, signature: header }, isExported(typeName))
+      const exportedMembers = (member: string) => !exports || exports.has(member) || exports.has(`${typeName}(..)`)
+      const members = keyword === 'class' ? readClassMethods(content, block) : keyword === 'type' ? [] : readConstructors(content, block)
+      for (const member of members) add({ ...member, container: typeName }, isExported(typeName) && exportedMembers(member.name))
+      continue
+    }
+
+    const foreign = block.text.match(FOREIGN_IMPORT)
+    if (foreign) {
+      flush()
+      add({ type: 'function', kind: 'function', name: foreign[1]!, start: block.start, end: block.end, signature }, isExported(foreign[1]!))
+      continue
+    }
+
+    const typeSignature = block.text.match(TYPE_SIGNATURE)
+    if (typeSignature) {
+      flush()
+      const names = typeSignature[1]!.split(',').map(name => name.trim())
+      pending = { names, definition: { type: 'function', kind: 'function', name: names[0]!, start: block.start, end: block.end, signature }, hasArrow: /->/.test(block.text) }
+      continue
+    }
+
+    const name = pending && definesOperator(block.text, pending.names) ? pending.names[0]! : equationName(block.text)
+    if (!name) {
+      flush()
+      continue
+    }
+    // Further equations of the same function, or the equations following its signature
+    if (pending && pending.names.length === 1 && pending.names[0] === name) {
+      pending.definition.end = block.end
+      pending.hasArrow ||= hasArguments(block.text, name)
+      continue
+    }
+    flush()
+    const withArguments = hasArguments(block.text, name)
+    pending = { names: [name], definition: { type: 'function', kind: 'function', name, start: block.start, end: block.end, signature: oneLine(content.substring(block.start, block.start + equationHeaderLength(block.text))) }, hasArrow: withArguments }
+  }
+  flush()
+
+  const lines = content.split('\n')
+  return definitions
+    .sort((a, b) => a.start - b.start)
+    .map(definition => toNode(content, lines, filePath, definition))
+}
+
+/**
+ * The module a file declares, for symbol ids and imports
+ */
+export function haskellModuleName(content: string): string | undefined {
+  return maskHaskell(content).match(MODULE)?.[1]
+}
+
+/**
+ * Splits the file into top-level declarations, each running until the next line that starts
+ * in the first column
+ */
+function topLevelBlocks(code: string): Block[] {
+  const blocks: Block[] = []
+  const starts: number[] = []
+  for (const match of code.matchAll(/^(?=\S)/gm)) starts.push(match.index!)
+
+  for (let index = 0; index < starts.length; index++) {
+    const start = starts[index]!
+    const next = starts[index + 1] ?? code.length
+    const end = start + code.substring(start, next).trimEnd().length
+    blocks.push({ start, end, text: code.substring(start, end) })
+  }
+  return blocks
+}
+
+function equationName(text: string): string | undefined {
+  // `x <+> y = ...` and ``a `on` b = ...`` define the operator; guards and bang or lazy patterns do not
+  const infix = text.match(INFIX_EQUATION)
+  if (infix && !PATTERN_PREFIXES.has(infix[1]!) && /^[^=]*=(?!=)/.test(text)) {
+    return infix[1]!.startsWith('`') ? infix[1]!.slice(1, -1) : `(${infix[1]})`
+  }
+  const match = text.match(EQUATION)
+  if (!match) return undefined
+  if (match[2]) return `(${match[2]})`
+  return KEYWORDS.has(match[1]!) ? undefined : match[1]
+}
+
+/**
+ * Whether an equation defines the operator named by the signature before it, as in
+ * `(x1, y1) <+> (x2, y2) = ...` where the left operand is not a plain name
+ */
+function definesOperator(text: string, names: string[]): boolean {
+  const operator = names.length === 1 ? names[0]!.match(/^\((.+)\)$/)?.[1] : undefined
+  if (!operator) return false
+  const header = text.substring(0, equationHeaderLength(text))
+  return new RegExp(String.raw`(?<![!#$%&*+./<=>?@\\^|~:-])${escapeRegExp(operator)}(?![!#$%&*+./<=>?@\\^|~:-])`).test(header)
+}
+
+function hasArguments(text: string, name: string): boolean {
+  const header = text.substring(name.length, equationHeaderLength(text))
+  return /\S/.test(header.replace(/[=|][\s\S]*$/, '')) || /=\s*\\/.test(text)
+}
+
+/**
+ * The length of an equation up to its `=` or first guard
+ */
+function equationHeaderLength(text: string): number {
+  const match = text.match(/^[^=|]*?(?:\s=(?!=)|\s\|(?!\|))/)
+  return match ? match[0].length - 1 : text.split('\n')[0]!.length
+}
+
+/**
+ * The length of a type declaration header: up to `=`, `where` or the end of the line
+ */
+function headerLength(text: string): number {
+  const match = text.match(/^[\s\S]*?(?=\s=(?!>)|\swhere\b|\n|$)/)
+  return match![0].length
+}
+
+/**
+ * The data constructors of a `data`/`newtype` declaration, from `= A | B {..}` or the
+ * signatures of a GADT `where` block
+ */
+function readConstructors(content: string, block: Block): Definition[] {
+  const constructors: Definition[] = []
+  const gadt = block.text.match(/\bwhere\b/)
+  if (gadt && !/\s=(?!>)/.test(block.text.substring(0, gadt.index))) {
+    for (const match of block.text.substring(gadt.index! + 5).matchAll(/^([ \t]+)([A-Z][\w']*(?:\s*,\s*[A-Z][\w']*)*)\s*::/gm)) {
+      const offset = gadt.index! + 5 + match.index!
+      const start = block.start + offset + match[1]!.length
+      const end = block.start + memberEnd(block.text, offset, match[1]!.length)
+      for (const name of match[2]!.split(',').map(part => part.trim())) {
+        constructors.push({ type: 'variant', kind: 'variant', name, start, end, signature: oneLine(content.substring(start, end)) })
+      }
+    }
+    return constructors
+  }
+
+  const equals = block.text.search(/\s=(?!>)/)
+  if (equals === -1) return constructors
+  const body = block.text.substring(equals + 2).replace(/\bderiving\b[\s\S]*$/, '')
+  let depth = 0
+  let partStart = 0
+  const parts: Array<{ offset: number, text: string }> = []
+  for (let index = 0; index <= body.length; index++) {
+    const char = body[index]
+    if (char === '(' || char === '{' || char === '[') depth++
+    else if (char === ')' || char === '}' || char === ']') depth--
+    else if ((char === '|' && depth === 0) || index === body.length) {
+      parts.push({ offset: partStart, text: body.substring(partStart, index) })
+      partStart = index + 1
+    }
+  }
+
+  for (const part of parts) {
+    // `forall a. Show a => C a` and strictness annotations come before the name
+    const match = part.text.match(/^(\s*(?:forall[^.]*\.\s*)?(?:[^=]*=>\s*)?)([A-Z][\w']*|\(:[!#$%&*+./<=>?@\\^|~:-]*\))/)
+    if (!match) continue
+    const start = block.start + equals + 2 + part.offset + match[1]!.length
+    const end = block.start + equals + 2 + part.offset + part.text.trimEnd().length
+    constructors.push({ type: 'variant', kind: 'variant', name: match[2]!, start, end, signature: oneLine(content.substring(start, end)) })
+  }
+  return constructors
+}
+
+/**
+ * The method signatures of a type class body
+ */
+function readClassMethods(content: string, block: Block): Definition[] {
+  const where = block.text.match(/\bwhere\b/)
+  if (!where) return []
+  const bodyOffset = where.index! + 5
+  const methods: Definition[] = []
+  let indent: number | undefined
+
+  for (const match of block.text.substring(bodyOffset).matchAll(/^([ \t]+)((?:[a-z_][\w']*|\([!#$%&*+./<=>?@\\^|~:-]+\))(?:\s*,\s*(?:[a-z_][\w']*|\([!#$%&*+./<=>?@\\^|~:-]+\)))*)\s*::/gm)) {
+    // Only the class's own layout column; deeper lines belong to a signature spanning lines
+    indent ??= match[1]!.length
+    if (match[1]!.length !== indent) continue
+    const offset = bodyOffset + match.index!
+    const start = block.start + offset + indent
+    const end = block.start + memberEnd(block.text, offset, indent)
+    for (const name of match[2]!.split(',').map(part => part.trim())) {
+      methods.push({ type: 'method', kind: 'method', name, start, end, signature: oneLine(content.substring(start, end)) })
+    }
+  }
+  return methods
+}
+
+/**
+ * The end of an indented member: the last line before one indented no deeper than it
+ */
+function memberEnd(text: string, lineOffset: number, indent: number): number {
+  const lineEnd = text.indexOf('\n', lineOffset)
+  if (lineEnd === -1) return text.length
+  let end = lineEnd
+  for (const match of text.substring(lineEnd + 1).matchAll(/^([ \t]*)(\S?)[^\n]*/gm)) {
+    if (!match[2]) continue
+    if (match[1]!.length <= indent) break
+    end = lineEnd + 1 + match.index! + match[0].length
+  }
+  return end
+}
+
+/**
+ * The names of a module's export list, or undefined for a module exporting everything.
+ * `T(..)` exports a type with all its constructors and methods and is kept as written.
+ */
+function readExportList(code: string, offset: number): Set<string> | undefined {
+  const rest = code.substring(offset)
+  const open = rest.match(/^\s*\(/)
+  if (!open) return undefined
+
+  const start = offset + open[0].length
+  let depth = 1
+  let index = start
+  for (; index < code.length && depth > 0; index++) {
+    if (code[index] === '(') depth++
+    else if (code[index] === ')') depth--
+  }
+  const list = code.substring(start, index - 1)
+
+  const names = new Set<string>()
+  for (const item of splitTopLevel(list)) {
+    const text = item.trim().replace(/^(?:type|pattern)\s+/, '')
+    if (!text || /^module\b/.test(text)) continue
+    const withMembers = text.match(/^([A-Z][\w']*)\s*\(([\s\S]*)\)$/)
+    if (withMembers) {
+      names.add(withMembers[1]!)
+      if (withMembers[2]!.trim() === '..') names.add(`${withMembers[1]}(..)`)
+      else withMembers[2]!.split(',').forEach(member => names.add(member.trim().replace(/^\((.*)\)$/, '$1')))
+      continue
+    }
+    names.add(text.replace(/^\((.*)\)$/, '$1'))
+  }
+  return names
+}
+
+function splitTopLevel(text: string): string[] {
+  const items: string[] = []
+  let depth = 0
+  let start = 0
+  for (let index = 0; index < text.length; index++) {
+    if (text[index] === '(') depth++
+    else if (text[index] === ')') depth--
+    else if (text[index] === ',' && depth === 0) {
+      items.push(text.substring(start, index))
+      start = index + 1
+    }
+  }
+  items.push(text.substring(start))
+  return items
+}
+
+/**
+ * The module node spans the header up to `where`
+ */
+function moduleEnd(code: string, offset: number): number {
+  const where = code.substring(offset).match(/\bwhere\b/)
+  return where ? offset + where.index! + 5 : offset
+}
+
+function toNode(content: string, lines: string[], filePath: string, definition: Definition & { visibility: SymbolVisibility }): TreeNode {
+  const startLine = lineAt(content, definition.start)
+  const endLine = lineAt(content, definition.end)
+  const doc = readHaddock(content, definition.start)
+  return {
+    id: `haskell-${definition.type}-${filePath}-${startLine}-${definition.name}`,
+    type: definition.type,
+    name: definition.name,
+    path: filePath,
+    startLine,
+    endLine,
+    content: lines.slice(startLine - 1, endLine).join('\n'),
+    symbol: {
+      kind: definition.kind,
+      visibility: definition.visibility,
+      signature: definition.signature,
+      ...(definition.container ? { container: definition.container } : {}),
+      ...(doc ? { doc } : {}),
+    },
+  }
+}
+
+/**
+ * The Haddock comment above a declaration: `-- |` lines, or a `{-| ... -}` block
+ */
+function readHaddock(content: string, start: number): string | undefined {
+  const before = content.substring(0, start).replace(/[ \t]+$/, '')
+  const block = before.match(/\{-\s*\|((?:(?!-\})[\s\S])*)-\}\s*$/)
+  if (block) return block[1]!.trim() || undefined
+
+  const lines = before.split('\n').slice(0, -1)
+  const comments: string[] = []
+  let index = lines.length - 1
+  for (; index >= 0 && /^\s*--(?![!#$%&*+./<=>?@\\^|~:])/.test(lines[index]!); index--) comments.unshift(lines[index]!)
+  if (comments.length === 0 || !/^\s*--\s*\|/.test(comments[0]!)) return undefined
+  return comments
+    .map(line => line.replace(/^\s*--\s*\|?\s?/, '').trimEnd())
+    .join('\n')
+    .trim() || undefined
+}
+
+/**
+ * Blanks comments, pragmas, strings and character literals, keeping offsets and line numbers.
+ * A `'` after an identifier character is a prime (`foldl'`), not a character literal.
+ */
+export function maskHaskell(content: string): string {
+  return content.replace(
+    /--+(?![!#$%&*+./<=>?@\\^|~:])[^\n]*|\{-[\s\S]*?-\}|"(?:\\.|[^"\\\n])*"|(?<![\w'])'(?:\\[^'\n]{1,10}|[^'\\\n])'/g,
+    match => match.startsWith('"') ? `"${match.slice(1, -1).replace(/[^\n]/g, ' ')}"` : match.replace(/[^\n]/g, ' '),
+  )
+}
+
+function oneLine(text: string): string {
+  return text.replace(/\s+/g, ' ').trim().substring(0, MAX_SIGNATURE_LENGTH)
+}
+
+function lineAt(content: string, index: number): number {
+  return content.substring(0, index).split('\n').length
+}
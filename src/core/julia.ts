@@ -0,0 +1,326 @@
+/**
+ * Julia - modules, functions, macros and types read from the source text, with the names each
+ * module exports. Blocks are matched on their `end`, telling it apart from the `end` of an
+ * index like `a[end]`.
+ */
+
+import type { SymbolKind, SymbolVisibility, TreeNode } from '../types/core.js'
+
+interface Definition {
+  type: string
+  kind: SymbolKind
+  name: string
+  start: number
+  end: number
+  signature: string
+  module?: Block
+  container?: string
+}
+
+interface Block {
+  keyword: string
+  start: number
+  end: number
+  parent?: Block
+  name?: string // Modules and structs
+}
+
+const MAX_SIGNATURE_LENGTH = 200
+
+const BLOCK_KEYWORD = /(?<![\w.!@:])(?:(function|macro|module|baremodule|struct|quote|begin|let|do|try|if|for|while)|(abstract|primitive)\s+type|(end))(?![\w!])|[()[\]{}]/g
+const FUNCTION = /^function\s+(?:([\w.]+)\.)?(@?[\w!]+|\(\s*[^)\s]+\s*\)|:\S+?)\s*(?:\{[^}]*\}\s*)?\(/
+const SHORT_FUNCTION = /^[ \t]*(?:@\w+\s+)*(?:([\w.]+)\.)?([A-Za-z_][\w!]*|\([^)\s]+\)|:\([^)\s]+\)|:[^\s(]+)\s*(?:\{[^}]*\}\s*)?\(/gm
+const TYPE = /^(?:(mutable\s+)?struct|(abstract|primitive)\s+type)\s+([A-Za-z_]\w*)/
+const EXPORT = /(?<![\w.])(export|public)\s+((?:[^\n,]+,[ \t]*\n?)*[^\n,]+)/g
+
+/**
+ * `module` nodes, `function` nodes for long and short-form functions (`method` nodes for inner
+ * constructors), `macro` nodes named `@name`, and `class` nodes for structs and abstract and
+ * primitive types. Inside a module that exports names, the ones it leaves out are `internal`.
+ */
+export function extractJuliaDefinitions(content: string, filePath: string): TreeNode[] {
+  const code = maskJulia(content)
+  const blocks = readBlocks(code)
+  const blocksByStart = new Map(blocks.map(block => [block.start, block]))
+  const innermost = (offset: number) => blocks
+    .filter(block => block.start < offset && offset < block.end)
+    .sort((a, b) => b.start - a.start)[0]
+  const definitions: Definition[] = []
+
+  for (const block of blocks) {
+    const text = code.substring(block.start, block.end)
+    if (block.keyword === 'module' || block.keyword === 'baremodule') {
+      const name = text.match(/^(?:bare)?module\s+(\w+)/)?.[1]
+      if (!name) continue
+      block.name = name
+      definitions.push({ type: 'module', kind: 'module', name, start: block.start, end: block.end, signature: `${block.keyword} ${name}`, module: block.parent })
+    }
+    else if (block.keyword === 'struct' || block.keyword === 'abstract' || block.keyword === 'primitive') {
+      const start = /mutable\s+$/.test(code.substring(block.start - 12, block.start)) ? code.lastIndexOf('mutable', block.start) : block.start
+      const match = code.substring(start, block.end).match(TYPE)
+      if (!match) continue
+      block.name = match[3]
+      const header = code.substring(start).match(/^[^\n;]*/)![0].replace(/\s+end\s*$/, '')
+      definitions.push({ type: 'class', kind: match[2] ? 'type' : 'struct', name: match[3]!, start, end: block.end, signature: oneLine(content.substring(start, start + header.length)) })
+    }
+    else if (block.keyword === 'function' || block.keyword === 'macro') {
+      const match = text.match(FUNCTION) ?? text.match(/^macro\s+(?:()?)(\w+)\s*\(/)
+      if (!match || (block.parent && !isDeclarationScope(block.parent))) continue
+      const name = block.keyword === 'macro' ? `@${match[2]}` : match[2]!.replace(/^\(\s*|\s*\)$/g, '')
+      const paramsOpen = block.start + match[0].length - 1
+      definitions.push({
+        type: block.keyword === 'macro' ? 'macro' : block.parent?.keyword === 'struct' ? 'method' : 'function',
+        kind: block.keyword === 'macro' ? 'macro' : block.parent?.keyword === 'struct' ? 'method' : 'function',
+        name,
+        start: block.start,
+        end: block.end,
+        signature: oneLine(content.substring(block.start, signatureEnd(code, paramsOpen))),
+        module: block.parent,
+      })
+    }
+  }
+
+  // name(args) = body, at module level or as an inner constructor
+  for (const match of code.matchAll(SHORT_FUNCTION)) {
+    const start = match.index! + match[0].search(/\S/)
+    const parent = innermost(start)
+    if (parent && !isDeclarationScope(parent)) continue
+    if (blocksByStart.has(start) || /^(?:function|macro|return|if|elseif|while|for|using|import|export|public|const|global|local)\b/.test(code.substring(start))) continue
+    const paramsOpen = match.index! + match[0].length - 1
+    const headerEnd = signatureEnd(code, paramsOpen)
+    const assignment = code.substring(headerEnd).match(/^\s*=(?![=>])/)
+    if (!assignment) continue
+    const bodyStart = headerEnd + assignment[0].length
+    definitions.push({
+      type: parent?.keyword === 'struct' ? 'method' : 'function',
+      kind: parent?.keyword === 'struct' ? 'method' : 'function',
+      name: match[2]!.replace(/^:?\(|\)$/g, '').replace(/^:/, ''),
+      start,
+      end: expressionEnd(code, bodyStart, blocksByStart),
+      signature: oneLine(content.substring(start, headerEnd)),
+      module: parent,
+    })
+  }
+
+  const exportsOf = new Map<Block | undefined, Set<string>>()
+  for (const match of code.matchAll(EXPORT)) {
+    const scope = moduleOf(innermost(match.index!))
+    const names = exportsOf.get(scope) ?? new Set<string>()
+    match[2]!.split(',').map(name => name.trim()).filter(Boolean).forEach(name => names.add(name))
+    exportsOf.set(scope, names)
+  }
+
+  const lines = content.split('\n')
+  return definitions
+    .sort((a, b) => a.start - b.start)
+    .map(definition => {
+      const scope = definition.type === 'module' ? definition.module : moduleOf(innermost(definition.start))
+      const exported = exportsOf.get(moduleOf(scope))
+      let visibility: SymbolVisibility = 'public'
+      if (definition.name.startsWith('_')) visibility = 'private'
+      else if (exported && !exported.has(definition.name)) visibility = 'internal'
+      return toNode(content, lines, filePath, { ...definition, container: containerOf(innermost(definition.start)) }, visibility)
+    })
+}
+
+/**
+ * Matches every block keyword with its `end`. An `end` inside brackets is an index, and `for`
+ * and `if` inside brackets are comprehension clauses.
+ */
+function readBlocks(code: string): Block[] {
+  const blocks: Block[] = []
+  const stack: Array<{ bracket: string } | Block> = []
+  const parentBlock = () => {
+    for (let index = stack.length - 1; index >= 0; index--) {
+      const entry = stack[index]!
+      if (!('bracket' in entry)) return entry
+    }
+    return undefined
+  }
+
+  for (const match of code.matchAll(BLOCK_KEYWORD)) {
+    const token = match[0]
+    const top = stack[stack.length - 1]
+    if ('([{'.includes(token)) {
+      stack.push({ bracket: token })
+    }
+    else if (')]}'.includes(token)) {
+      // Close the bracket along with any block left open inside it
+      while (stack.length > 0 && !('bracket' in stack[stack.length - 1]!)) stack.pop()
+      stack.pop()
+    }
+    else if (match[3]) {
+      if (top && !('bracket' in top)) {
+        stack.pop()
+        top.end = match.index! + token.length
+        blocks.push(top)
+      }
+    }
+    else {
+      const keyword = match[1] ?? match[2]!
+      if (top && 'bracket' in top && (keyword === 'for' || keyword === 'if')) continue
+      // A `function` without a body (`function area end`) or a one-line type still has its `end`
+      stack.push({ keyword, start: match.index!, end: code.length, parent: parentBlock() })
+    }
+  }
+  return blocks.sort((a, b) => a.start - b.start)
+}
+
+/**
+ * Functions are declarations at the top level, in modules, and as inner constructors of
+ * structs; elsewhere they are local
+ */
+function isDeclarationScope(block: Block): boolean {
+  return block.keyword === 'module' || block.keyword === 'baremodule' || block.keyword === 'struct'
+}
+
+function moduleOf(block: Block | undefined): Block | undefined {
+  for (let current = block; current; current = current.parent) {
+    if (current.keyword === 'module' || current.keyword === 'baremodule') return current
+  }
+  return undefined
+}
+
+function containerOf(block: Block | undefined): string | undefined {
+  const names: string[] = []
+  for (let current = block; current; current = current.parent) {
+    if (current.name) names.unshift(current.name)
+  }
+  return names.length > 0 ? names.join('.') : undefined
+}
+
+/**
+ * The end of a signature: its parameters plus any return type and `where` clause
+ */
+function signatureEnd(code: string, paramsOpen: number): number {
+  let depth = 0
+  let index = paramsOpen
+  for (; index < code.length; index++) {
+    if (code[index] === '(') depth++
+    else if (code[index] === ')' && --depth === 0) break
+  }
+  const tail = code.substring(index + 1).match(/^(?:\s*::\s*[\w.{}, ]+?)?(?:\s+where\s+(?:\{[^}]*\}|[\w<: ]+?))?(?=\s*(?:=(?!=)|\n|;|$))/)
+  return index + 1 + (tail?.[0].length ?? 0)
+}
+
+/**
+ * The end of a short-form body: its line, continued while brackets or blocks are open
+ */
+function expressionEnd(code: string, from: number, blocksByStart: Map<number, Block>): number {
+  let depth = 0
+  for (let index = from; index < code.length; index++) {
+    const char = code[index]
+    const block = blocksByStart.get(index)
+    if (block) {
+      index = block.end - 1
+      continue
+    }
+    if (char === '(' || char === '[' || char === '{') depth++
+    else if (char === ')' || char === ']' || char === '}') depth--
+    else if (char === '\n' && depth <= 0 && !/[=+\-*/|&,]\s*$/.test(code.substring(from, index))) return index
+  }
+  return code.length
+}
+
+function toNode(content: string, lines: string[], filePath: string, definition: Definition, visibility: SymbolVisibility): TreeNode {
+  const startLine = lineAt(content, definition.start)
+  const endLine = lineAt(content, definition.end)
+  const doc = readDocstring(content, definition.start)
+  return {
+    id: `julia-${definition.type}-${filePath}-${startLine}-${definition.name}`,
+    type: definition.type,
+    name: definition.name,
+    path: filePath,
+    startLine,
+    endLine,
+    content: lines.slice(startLine - 1, endLine).join('\n'),
+    symbol: {
+      kind: definition.kind,
+      visibility,
+      signature: definition.signature.substring(0, MAX_SIGNATURE_LENGTH),
+      ...(definition.container ? { container: definition.container } : {}),
+      ...(doc ? { doc } : {}),
+    },
+  }
+}
+
+/**
+ * The docstring directly above a definition, a `"""..."""` or `"..."` literal
+ */
+function readDocstring(content: string, start: number): string | undefined {
+  const before = content.substring(0, start).replace(/\s+$/, '')
+  const triple = before.match(/"""((?:(?!""")[\s\S])*)"""$/)
+  const single = triple ? undefined : before.match(/(?:^|\n)[ \t]*"((?:\\.|[^"\\\n])*)"$/)
+  const text = triple?.[1] ?? single?.[1]
+  if (!text) return undefined
+
+  // Docstrings conventionally open with the indented signature; keep the description
+  const lines = text.split('\n')
+  const indent = Math.min(...lines.filter(line => line.trim()).map(line => line.match(/^\s*/)![0].length))
+  const description = lines.map(line => line.substring(indent)).join('\n').replace(/^\s*\n/, '').replace(/^ {4}[^\n]*\n+/, '')
+  return description.trim() || undefined
+}
+
+/**
+ * Blanks comments (`#=` blocks nest), strings, command literals and character literals,
+ * keeping offsets and line numbers. A `'` after a name or bracket is the adjoint operator.
+ */
+export function maskJulia(content: string): string {
+  const chars = content.split('')
+  const blank = (from: number, to: number) => {
+    for (let index = from; index < to; index++) if (chars[index] !== '\n') chars[index] = ' '
+  }
+
+  let index = 0
+  while (index < content.length) {
+    if (content.startsWith('#=', index)) {
+      let depth = 0
+      let end = index
+      while (end < content.length) {
+        if (content.startsWith('#=', end)) {
+          depth++
+          end += 2
+        }
+        else if (content.startsWith('=#', end)) {
+          end += 2
+          if (--depth === 0) break
+        }
+        else {
+          end++
+        }
+      }
+      blank(index, end)
+      index = end
+    }
+    else if (content[index] === '#') {
+      const end = content.indexOf('\n', index)
+      blank(index, end === -1 ? content.length : end)
+      index = end === -1 ? content.length : end
+    }
+    else if (content[index] === '"' || content[index] === '`') {
+      const quote = content.startsWith('"""', index) ? '"""' : content.startsWith('```', index) ? '```' : content[index]!
+      let end = index + quote.length
+      while (end < content.length && !content.startsWith(quote, end)) end += content[end] === '\\' ? 2 : 1
+      end = Math.min(content.length, end + quote.length)
+      blank(index + quote.length, end - quote.length)
+      index = end
+    }
+    else if (content[index] === "'" && !/[\w)\]}'.]/.test(content[index - 1] ?? '')) {
+      const literal = content.substring(index).match(/^'(?:\\[^'\n]{1,10}|[^\\'\n])'/)
+      if (literal) blank(index + 1, index + literal[0].length - 1)
+      index += literal ? literal[0].length : 1
+    }
+    else {
+      index++
+    }
+  }
+  return chars.join('')
+}
+
+function oneLine(text: string): string {
+  return text.replace(/\s+/g, ' ').trim()
+}
+
+function lineAt(content: string, index: number): number {
+  return content.substring(0, index).split('\n').length
+}
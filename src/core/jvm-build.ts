@@ -0,0 +1,273 @@
+/**
+ * Gradle and sbt build files - the subprojects a build includes and the dependencies each
+ * declares, read from settings.gradle, build.gradle(.kts) and build.sbt without running the build
+ */
+
+import { basename } from 'path'
+import type { JvmDependency, TreeNode } from '../types/core.js'
+
+export interface GradleInclude {
+  path: string // Project path such as `:core:api`
+  line: number
+}
+
+export interface GradleSettings {
+  rootName?: string
+  includes: GradleInclude[]
+  projectDirs: Map<string, string> // Project path to the directory set by `projectDir = file(...)`
+}
+
+export interface GradleDependencies {
+  dependencies: JvmDependency[]
+  projects: JvmDependency[] // `project(":core")` dependencies, with the project path as coordinate
+}
+
+export interface SbtProject {
+  name: string
+  directory: string // Relative to the build root, `.` for the root project
+  line: number
+  endLine: number
+  dependsOn: string[]
+  dependencies: JvmDependency[]
+}
+
+export interface SbtBuild {
+  projects: SbtProject[]
+  dependencies: JvmDependency[] // Set outside any project definition, so they belong to the root project
+}
+
+const GRADLE_SETTINGS_FILE = /^settings\.gradle(\.kts)?$/
+const GRADLE_BUILD_FILE = /^build\.gradle(\.kts)?$/
+
+const GRADLE_INCLUDE = /^[ \t]*include[ \t]*\(?((?:\s*(['"])[^'"\n]+\2\s*,?)+)\)?/gm
+const GRADLE_PROJECT_DIR = /project\s*\(\s*(['"])([^'"]+)\1\s*\)\s*\.projectDir\s*=\s*(?:new\s+File\s*\([^,]+,|file\s*\()\s*(['"])([^'"]+)\3/g
+const GRADLE_ROOT_NAME = /rootProject\.name\s*=\s*(['"])([^'"]+)\1/
+const GRADLE_DECLARATION = /^\s*(\w+)\s*\(?\s*(?:(?:platform|enforcedPlatform|testFixtures)\s*\(\s*)?(.*)$/
+const GRADLE_PROJECT_REFERENCE = /^project\s*\(\s*(?:path\s*[:=]\s*)?(['"])([^'"]+)\1/
+const GRADLE_STRING_NOTATION = /^(['"])([^'"\s]+)\1/
+const GRADLE_MAP_NOTATION = /^group\s*[:=]\s*(['"])([^'"]+)\1\s*,\s*name\s*[:=]\s*(['"])([^'"]+)\3(?:\s*,\s*version\s*[:=]\s*(['"])([^'"]+)\5)?/
+
+const SBT_PROJECT = /^(?:lazy\s+)?val\s+(\w+)\s*(?::\s*Project\s*)?=\s*\(?\s*(?:project\b|Project\s*\()/
+const SBT_DIRECTORY = /\bfile\s*\(\s*"([^"]*)"\s*\)/
+const SBT_DEPENDS_ON = /\.dependsOn\s*\(([^)]*)\)/g
+const SBT_MODULE = /"([^"\s]+)"\s*%{1,3}\s*"([^"\s]+)"\s*%\s*(?:"([^"\s]+)"|([\w.]+))(?:\s*%\s*(?:"([^"\s]+)"|(\w+)))?/g
+
+/**
+ * Checks whether a file name is a Gradle settings or build script, or an sbt build definition
+ */
+export function isJvmBuildFile(fileName: string): boolean {
+  return GRADLE_SETTINGS_FILE.test(fileName) || GRADLE_BUILD_FILE.test(fileName) || fileName === 'build.sbt'
+}
+
+/**
+ * Reads the projects a Gradle settings script includes. `include 'core'` and `include(":core")`
+ * both name `:core`; a nested path like `:core:api` lives in `core/api` unless `projectDir` moves it.
+ */
+export function parseGradleSettings(content: string): GradleSettings {
+  const code = stripComments(content)
+  const includes: GradleInclude[] = []
+  const projectDirs = new Map<string, string>()
+
+  for (const match of code.matchAll(GRADLE_INCLUDE)) {
+    const line = lineAt(code, match.index!)
+    for (const value of match[1]!.matchAll(/(['"])([^'"\n]+)\1/g)) {
+      includes.push({ path: projectPath(value[2]!), line })
+    }
+  }
+  for (const match of code.matchAll(GRADLE_PROJECT_DIR)) {
+    projectDirs.set(projectPath(match[2]!), match[4]!)
+  }
+
+  return { rootName: code.match(GRADLE_ROOT_NAME)?.[2], includes, projectDirs }
+}
+
+/**
+ * Reads the dependencies declared in the `dependencies` blocks of a Gradle build script, in string
+ * (`"group:artifact:version"`), map (`group: 'g', name: 'a'`) and `project(":core")` notation.
+ * Version catalog accessors like `libs.guava` are not resolved, and `buildscript` classpath
+ * entries are plugins rather than dependencies of the project.
+ */
+export function parseGradleDependencies(content: string): GradleDependencies {
+  const code = stripComments(content)
+  const result: GradleDependencies = { dependencies: [], projects: [] }
+  const blocks: string[] = []
+
+  code.split('\n').forEach((text, index) => {
+    if (blocks.at(-1) === 'dependencies' && !blocks.includes('buildscript')) {
+      const declaration = text.match(GRADLE_DECLARATION)
+      const configuration = declaration?.[1]
+      const notation = declaration?.[2] ?? ''
+      const line = index + 1
+
+      const project = notation.match(GRADLE_PROJECT_REFERENCE)
+      const coordinate = notation.match(GRADLE_STRING_NOTATION)
+      const map = notation.match(GRADLE_MAP_NOTATION)
+      if (configuration && project) {
+        result.projects.push({ coordinate: projectPath(project[2]!), configuration, line })
+      }
+      else if (configuration && coordinate && coordinate[2]!.includes(':')) {
+        const [group, artifact, version] = coordinate[2]!.replace(/@\w+$/, '').split(':')
+        result.dependencies.push({ coordinate: `${group}:${artifact}`, ...(version ? { version } : {}), configuration, line })
+      }
+      else if (configuration && map) {
+        result.dependencies.push({ coordinate: `${map[2]}:${map[4]}`, ...(map[6] ? { version: map[6] } : {}), configuration, line })
+      }
+    }
+
+    // Track the enclosing blocks by the identifier before each brace, e.g. `dependencies {`
+    for (const brace of text.matchAll(/(\w*)\s*(?:\([^(){}]*\))?\s*\{|\}/g)) {
+      if (brace[0] === '}') blocks.pop()
+      else blocks.push(brace[1]!)
+    }
+  })
+
+  return result
+}
+
+/**
+ * Reads the projects of an sbt build definition, each with its directory, the projects it
+ * `dependsOn` and the library dependencies set in its settings. A project defined as
+ * `lazy val core = project` lives in `core`, as sbt does.
+ */
+export function parseSbtBuild(content: string): SbtBuild {
+  const code = stripComments(content)
+  const build: SbtBuild = { projects: [], dependencies: [] }
+
+  for (const statement of readTopLevelStatements(code)) {
+    const text = code.substring(statement.start, statement.end)
+    const dependencies = readSbtModules(text, statement.line)
+    const project = text.match(SBT_PROJECT)
+
+    if (!project) {
+      // A shared `val deps = Seq(...)` is not the root project's until it is added to its settings
+      if (/\blibraryDependencies\b/.test(text)) build.dependencies.push(...dependencies)
+      continue
+    }
+
+    const dependsOn: string[] = []
+    for (const match of text.matchAll(SBT_DEPENDS_ON)) {
+      for (const argument of match[1]!.split(',')) {
+        const name = argument.trim().match(/^\w+/)?.[0]
+        if (name && !dependsOn.includes(name)) dependsOn.push(name)
+      }
+    }
+
+    build.projects.push({
+      name: project[1]!,
+      directory: text.match(SBT_DIRECTORY)?.[1]?.replace(/^\.\/|\/$/g, '') || project[1]!,
+      line: statement.line,
+      endLine: statement.line + text.trimEnd().split('\n').length - 1,
+      dependsOn,
+      dependencies,
+    })
+  }
+
+  return build
+}
+
+/**
+ * Converts a build file into `project` nodes for the subprojects it defines and `dependency`
+ * nodes named by `group:artifact` (or the project path) for what it depends on
+ */
+export function jvmBuildToNodes(content: string, filePath: string): TreeNode[] {
+  const fileName = basename(filePath)
+  const lines = content.split('\n')
+  const nodes: TreeNode[] = []
+
+  const projectNode = (name: string, line: number, endLine = line): TreeNode => ({
+    id: `jvm-project-${filePath}-${line}-${name}`,
+    type: 'project',
+    name,
+    path: filePath,
+    startLine: line,
+    endLine,
+    content: lines.slice(line - 1, endLine).join('\n'),
+    symbol: { kind: 'module', visibility: 'public', signature: lines[line - 1]?.trim() ?? '' },
+  })
+  const dependencyNode = (dependency: JvmDependency, container?: string): TreeNode => ({
+    id: `jvm-dependency-${filePath}-${dependency.line}-${dependency.coordinate}`,
+    type: 'dependency',
+    name: dependency.coordinate,
+    path: filePath,
+    startLine: dependency.line,
+    endLine: dependency.line,
+    content: lines[dependency.line - 1] ?? '',
+    symbol: {
+      kind: 'module',
+      visibility: 'public',
+      signature: `${dependency.configuration} ${dependency.coordinate}${dependency.version ? `:${dependency.version}` : ''}`,
+      ...(container ? { container } : {}),
+    },
+  })
+
+  if (GRADLE_SETTINGS_FILE.test(fileName)) {
+    nodes.push(...parseGradleSettings(content).includes.map(include => projectNode(include.path, include.line)))
+  }
+  else if (GRADLE_BUILD_FILE.test(fileName)) {
+    const { dependencies, projects } = parseGradleDependencies(content)
+    nodes.push(...[...dependencies, ...projects].map(dependency => dependencyNode(dependency)))
+  }
+  else if (fileName === 'build.sbt') {
+    const build = parseSbtBuild(content)
+    nodes.push(...build.dependencies.map(dependency => dependencyNode(dependency)))
+    for (const project of build.projects) {
+      nodes.push(projectNode(project.name, project.line, project.endLine))
+      nodes.push(...project.dependencies.map(dependency => dependencyNode(dependency, project.name)))
+    }
+  }
+
+  return nodes.sort((a, b) => (a.startLine ?? 0) - (b.startLine ?? 0))
+}
+
+/**
+ * `core:api` and `:core:api` are the same Gradle project
+ */
+function projectPath(value: string): string {
+  return value.startsWith(':') ? value : `:${value}`
+}
+
+function readSbtModules(text: string, firstLine: number): JvmDependency[] {
+  const dependencies: JvmDependency[] = []
+  for (const match of text.matchAll(SBT_MODULE)) {
+    const version = match[3] ?? match[4]
+    dependencies.push({
+      coordinate: `${match[1]}:${match[2]}`,
+      ...(version ? { version } : {}),
+      configuration: (match[5] ?? match[6] ?? 'compile').toLowerCase(),
+      line: firstLine + lineAt(text, match.index!) - 1,
+    })
+  }
+  return dependencies
+}
+
+/**
+ * Splits sbt source into statements that start in the first column; continuation lines such as
+ * `.settings(...)` or a closing `)` stay with the statement above them
+ */
+function readTopLevelStatements(code: string): { start: number, end: number, line: number }[] {
+  const statements: { start: number, end: number, line: number }[] = []
+  let offset = 0
+
+  code.split('\n').forEach((text, index) => {
+    if (/^[^\s.)}\]]/.test(text)) {
+      if (statements.length > 0) statements.at(-1)!.end = offset
+      statements.push({ start: offset, end: code.length, line: index + 1 })
+    }
+    offset += text.length + 1
+  })
+
+  return statements
+}
+
+/**
+ * Blanks line and block comments outside strings, keeping offsets and line numbers
+ */
+function stripComments(content: string): string {
+  return content.replace(/("(?:[^"\\\n]|\\.)*"|'(?:[^'\\\n]|\\.)*')|\/\/[^\n]*|\/\*[\s\S]*?\*\//g, (match, string: string | undefined) =>
+    string ?? match.replace(/[^\n]/g, ' '),
+  )
+}
+
+function lineAt(text: string, index: number): number {
+  return text.substring(0, index).split('\n').length
+}
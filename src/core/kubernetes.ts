@@ -0,0 +1,277 @@
+/**
+ * Kubernetes manifest extraction - workloads, services, ConfigMaps and Secrets with their
+ * containers, images and environment, from plain manifests and Helm chart templates
+ */
+
+import { readFileSync } from 'fs'
+import { basename, dirname, join } from 'path'
+import { parseYaml, parseYamlDocuments, getYamlKeyLine } from '../utils/yaml.js'
+import { isFile } from '../utils/helpers.js'
+import type { TreeNode } from '../types/core.js'
+
+export interface KubernetesEnv {
+  name: string
+  line: number
+  from?: { kind: 'ConfigMap' | 'Secret', name: string, key: string } // valueFrom key references
+}
+
+export interface KubernetesContainer {
+  name: string
+  image?: string
+  line: number
+  env: KubernetesEnv[]
+  envFrom: { kind: 'ConfigMap' | 'Secret', name: string }[]
+}
+
+export interface KubernetesResource {
+  kind: string
+  name: string
+  namespace?: string
+  line: number
+  endLine: number
+  labels: Record<string, string>
+  selector?: Record<string, string> // Pod labels a Service routes to, or a workload manages
+  containers: KubernetesContainer[]
+  keys: { name: string, line: number }[] // data and stringData keys of ConfigMaps and Secrets
+}
+
+export interface ManifestResource {
+  file: string
+  chart?: string // Name of the Helm chart the template belongs to
+  resource: KubernetesResource
+}
+
+// Where each workload kind keeps its pod template
+const POD_SPEC_PATHS: Record<string, string[]> = {
+  Pod: ['spec'],
+  Deployment: ['spec', 'template', 'spec'],
+  StatefulSet: ['spec', 'template', 'spec'],
+  DaemonSet: ['spec', 'template', 'spec'],
+  ReplicaSet: ['spec', 'template', 'spec'],
+  Job: ['spec', 'template', 'spec'],
+  CronJob: ['spec', 'jobTemplate', 'spec', 'template', 'spec'],
+}
+
+export const WORKLOAD_KINDS = Object.keys(POD_SPEC_PATHS)
+
+const HELM_DIRECTIVE = /\{\{-?([\s\S]*?)-?\}\}/g
+
+/**
+ * Whether YAML content declares Kubernetes objects
+ */
+export function isKubernetesManifest(content: string): boolean {
+  return /^apiVersion:\s*\S/m.test(content) && /^kind:\s*\w/m.test(content)
+}
+
+/**
+ * Whether a file is a template of a Helm chart
+ */
+export function isHelmTemplate(filePath: string): boolean {
+  return /\.ya?ml$/.test(filePath) && basename(dirname(filePath)) === 'templates'
+}
+
+/**
+ * Replaces the `{{ }}` directives of a Helm template so the result parses as YAML, keeping line
+ * numbers. Lines holding only directives (`{{- if }}`, `{{- include ... | nindent 4 }}`) are
+ * blanked; `.Values` lookups found in `values` are substituted, and other inline directives
+ * become `fallback`.
+ */
+export function renderHelmTemplate(content: string, values: unknown = null, fallback = 'release'): string {
+  return content.split('\n').map((line) => {
+    if (!line.replace(HELM_DIRECTIVE, '').trim()) return ''
+    return line.replace(HELM_DIRECTIVE, (_directive, body: string) => {
+      const lookup = body.trim().match(/^\.Values\.([\w.]+)/)
+      const value = lookup ? lookupValue(values, lookup[1]!) : undefined
+      return typeof value === 'string' || typeof value === 'number' || typeof value === 'boolean' ? String(value) : fallback
+    })
+  }).join('\n')
+}
+
+/**
+ * Extracts the objects of a manifest, one per YAML document
+ */
+export function parseKubernetesManifests(content: string): KubernetesResource[] {
+  const lineCount = content.split('\n').length
+  const resources: KubernetesResource[] = []
+
+  for (const document of parseYamlDocuments(content)) {
+    if (!isMapping(document)) continue
+    // `kind: List` wraps its objects in `items`
+    const objects = document.kind === 'List' && Array.isArray(document.items) ? document.items : [document]
+    for (const object of objects) {
+      const resource = isMapping(object) ? readResource(object) : undefined
+      if (resource) resources.push(resource)
+    }
+  }
+
+  resources.sort((a, b) => a.line - b.line)
+  resources.forEach((resource, index) => {
+    resource.endLine = (resources[index + 1]?.line ?? lineCount + 1) - 1
+  })
+  return resources
+}
+
+/**
+ * Reads the Kubernetes objects of every manifest among the given files. Helm templates are
+ * rendered with their chart's values.yaml, and unresolved directives with the chart name.
+ */
+export function loadKubernetesResources(fileNodes: TreeNode[]): ManifestResource[] {
+  const contents = new Map(fileNodes.map(node => [node.path, node.content]))
+  const readYamlFile = (path: string) => parseYaml(contents.get(path) ?? (isFile(path) ? readText(path) : '') ?? '')
+
+  const resources: ManifestResource[] = []
+  for (const fileNode of fileNodes) {
+    if (!fileNode.content || !/\.ya?ml$/.test(fileNode.path)) continue
+
+    let content = fileNode.content
+    let chart: string | undefined
+    if (isHelmTemplate(fileNode.path)) {
+      const chartDirectory = dirname(dirname(fileNode.path))
+      const metadata = readYamlFile(join(chartDirectory, 'Chart.yaml'))
+      chart = isMapping(metadata) && typeof metadata.name === 'string' ? metadata.name : basename(chartDirectory)
+      content = renderHelmTemplate(content, readYamlFile(join(chartDirectory, 'values.yaml')), chart)
+    }
+    if (!isKubernetesManifest(content)) continue
+
+    for (const resource of parseKubernetesManifests(content)) {
+      resources.push({ file: fileNode.path, chart, resource })
+    }
+  }
+  return resources
+}
+
+/**
+ * Converts the objects of a manifest or Helm template into nodes typed by their lowercased kind
+ * (`deployment`, `service`, `configmap`), with an `image` node per container image so searching
+ * an image finds the workloads running it
+ */
+export function kubernetesToNodes(content: string, filePath: string): TreeNode[] {
+  const source = isHelmTemplate(filePath) ? renderHelmTemplate(content) : content
+  if (!isKubernetesManifest(source)) return []
+
+  const lines = content.split('\n')
+  const nodes: TreeNode[] = []
+  for (const resource of parseKubernetesManifests(source)) {
+    nodes.push({
+      id: `k8s-${filePath}-${resource.line}-${resource.kind}-${resource.name}`,
+      type: resource.kind.toLowerCase(),
+      name: resource.name,
+      path: filePath,
+      startLine: resource.line,
+      endLine: resource.endLine,
+      content: lines.slice(resource.line - 1, resource.endLine).join('\n'),
+    })
+    for (const container of resource.containers) {
+      if (!container.image) continue
+      nodes.push({
+        id: `k8s-image-${filePath}-${container.line}-${container.name}`,
+        type: 'image',
+        name: container.image,
+        path: filePath,
+        startLine: container.line,
+        endLine: container.line,
+        content: lines[container.line - 1] ?? '',
+      })
+    }
+  }
+  return nodes
+}
+
+/**
+ * The repository of an image reference, without its tag or digest
+ */
+export function imageRepository(image: string): string {
+  const withoutDigest = image.split('@')[0]!
+  const tag = withoutDigest.lastIndexOf(':')
+  return tag > withoutDigest.lastIndexOf('/') ? withoutDigest.substring(0, tag) : withoutDigest
+}
+
+function readResource(object: Record<string, unknown>): KubernetesResource | undefined {
+  const metadata = isMapping(object.metadata) ? object.metadata : {}
+  if (typeof object.kind !== 'string' || typeof object.apiVersion !== 'string') return undefined
+
+  const line = Math.min(...['apiVersion', 'kind', 'metadata'].map(key => getYamlKeyLine(object, key) ?? Infinity))
+  const spec = isMapping(object.spec) ? object.spec : {}
+  const selector = object.kind === 'Service'
+    ? stringMap(spec.selector)
+    : isMapping(spec.selector) ? stringMap(spec.selector.matchLabels) : undefined
+
+  return {
+    kind: object.kind,
+    name: String(metadata.name ?? metadata.generateName ?? ''),
+    namespace: typeof metadata.namespace === 'string' ? metadata.namespace : undefined,
+    line: Number.isFinite(line) ? line : 1,
+    endLine: 0,
+    labels: stringMap(metadata.labels) ?? {},
+    selector,
+    containers: readContainers(object),
+    keys: ['data', 'stringData', 'binaryData'].flatMap((field) => {
+      const data = object[field]
+      return isMapping(data) ? Object.keys(data).map(name => ({ name, line: getYamlKeyLine(data, name) ?? line })) : []
+    }),
+  }
+}
+
+function readContainers(object: Record<string, unknown>): KubernetesContainer[] {
+  const path = POD_SPEC_PATHS[object.kind as string]
+  if (!path) return []
+
+  let podSpec: unknown = object
+  for (const key of path) podSpec = isMapping(podSpec) ? podSpec[key] : undefined
+  if (!isMapping(podSpec)) return []
+
+  return [podSpec.initContainers, podSpec.containers]
+    .flatMap(list => Array.isArray(list) ? list.filter(isMapping) : [])
+    .map(container => ({
+      name: String(container.name ?? ''),
+      image: typeof container.image === 'string' ? container.image : undefined,
+      line: getYamlKeyLine(container, 'image') ?? getYamlKeyLine(container, 'name') ?? 1,
+      env: (Array.isArray(container.env) ? container.env.filter(isMapping) : [])
+        .filter(env => typeof env.name === 'string')
+        .map(env => ({ name: env.name as string, line: getYamlKeyLine(env, 'name') ?? 1, from: readValueFrom(env.valueFrom) })),
+      envFrom: (Array.isArray(container.envFrom) ? container.envFrom.filter(isMapping) : []).flatMap((source) => {
+        const configMap = isMapping(source.configMapRef) ? source.configMapRef.name : undefined
+        const secret = isMapping(source.secretRef) ? source.secretRef.name : undefined
+        return [
+          ...(typeof configMap === 'string' ? [{ kind: 'ConfigMap' as const, name: configMap }] : []),
+          ...(typeof secret === 'string' ? [{ kind: 'Secret' as const, name: secret }] : []),
+        ]
+      }),
+    }))
+}
+
+function readValueFrom(valueFrom: unknown): KubernetesEnv['from'] {
+  if (!isMapping(valueFrom)) return undefined
+  for (const [field, kind] of [['configMapKeyRef', 'ConfigMap'], ['secretKeyRef', 'Secret']] as const) {
+    const ref = valueFrom[field]
+    if (isMapping(ref) && typeof ref.name === 'string' && typeof ref.key === 'string') {
+      return { kind, name: ref.name, key: ref.key }
+    }
+  }
+  return undefined
+}
+
+function readText(path: string): string | undefined {
+  try {
+    return readFileSync(path, 'utf-8')
+  }
+  catch {
+    // A missing chart file leaves the template's directives unresolved
+    return undefined
+  }
+}
+
+function lookupValue(values: unknown, path: string): unknown {
+  let current = values
+  for (const key of path.split('.')) current = isMapping(current) ? current[key] : undefined
+  return current
+}
+
+function stringMap(value: unknown): Record<string, string> | undefined {
+  if (!isMapping(value)) return undefined
+  return Object.fromEntries(Object.entries(value).map(([key, entry]) => [key, String(entry)]))
+}
+
+function isMapping(value: unknown): value is Record<string, unknown> {
+  return Boolean(value) && typeof value === 'object' && !Array.isArray(value)
+}
@@ -16,10 +16,47 @@ import CSharp from 'tree-sitter-c-sharp'
 import PHP from 'tree-sitter-php'
 import HTML from 'tree-sitter-html'
 import Kotlin from 'tree-sitter-kotlin'
+import Bash from 'tree-sitter-bash'
+import { createRequire } from 'module'
 
-import { LOGIC_EXTENSIONS, PARSER_NAMES, FUNCTION_TYPES, CLASS_TYPES } from '../constants/index.js'
+import { basename, extname } from 'path'
+import { LOGIC_EXTENSIONS, MARKUP_EXTENSIONS, PARSER_NAMES, FUNCTION_TYPES, CLASS_TYPES, VARIABLE_TYPES, OPTIONAL_GRAMMAR_PACKAGES, INFRASTRUCTURE_FILE_PATTERNS, LANGUAGE_ALIASES } from '../constants/index.js'
+import { parseProtoDefinitions, protoDefinitionsToNodes } from './proto.js'
+import { dockerfileToNodes, composeToNodes } from './docker.js'
+import { makefileToShell } from './shell.js'
+import { configKeysToNodes } from './config-keys.js'
+import { isHelmTemplate, kubernetesToNodes, renderHelmTemplate } from './kubernetes.js'
+import { extractMacroDeclarations } from './cpp.js'
+import { extractRustMacroDeclarations } from './rust-macros.js'
+import { extractDynamicAttributes } from './python-dynamic.js'
+import { extractDecoratedRegistrations } from './python-decorators.js'
+import { jvmBuildToNodes } from './jvm-build.js'
+import { extractScalaDefinitions } from './scala.js'
+import { extractElixirDefinitions } from './elixir.js'
+import { extractLuaDefinitions } from './lua.js'
+import { extractZigDefinitions } from './zig.js'
+import { extractOdinDefinitions } from './odin.js'
+import { extractSolidityDefinitions } from './solidity.js'
+import { extractDartDefinitions } from './dart.js'
+import { extractHaskellDefinitions } from './haskell.js'
+import { extractOCamlDefinitions } from './ocaml.js'
+import { extractRDefinitions } from './r.js'
+import { extractJuliaDefinitions } from './julia.js'
+import { extractObjCDefinitions, extractObjCHeaderDefinitions } from './objc.js'
+import { extractSwiftDefinitions } from './swift.js'
+import { extractGroovyDefinitions } from './groovy.js'
+import { extractNimDefinitions } from './nim.js'
+import { extractCrystalDefinitions } from './crystal.js'
+import { extractAssemblyDefinitions, extractLinkerScriptDefinitions } from './assembly.js'
+import { extractMarkdownStructure } from './markdown.js'
+import { extractLatexStructure } from './latex.js'
 import type { LanguageConfig, TreeSitterLanguage } from '../types/core.js'
 
+const require = createRequire(import.meta.url)
+
+// Languages with `extractElements` read their declarations from the source text instead of
+// walking the syntax tree. Those with an optional grammar are indexed without it, and the
+// grammar then only adds syntax error checks.
 export const LANGUAGE_CONFIGS: LanguageConfig[] = [
   {
     name: PARSER_NAMES.JAVASCRIPT,
@@ -41,6 +78,7 @@ export const LANGUAGE_CONFIGS: LanguageConfig[] = [
     parserName: PARSER_NAMES.PYTHON,
     functionTypes: [...FUNCTION_TYPES.PYTHON],
     classTypes: [...CLASS_TYPES.PYTHON],
+    extraElements: (content, filePath) => [...extractDynamicAttributes(content, filePath), ...extractDecoratedRegistrations(content, filePath)],
   },
   {
     name: PARSER_NAMES.GO,
@@ -55,6 +93,7 @@ export const LANGUAGE_CONFIGS: LanguageConfig[] = [
     parserName: PARSER_NAMES.RUST,
     functionTypes: [...FUNCTION_TYPES.RUST],
     classTypes: [...CLASS_TYPES.RUST],
+    extraElements: extractRustMacroDeclarations,
   },
   {
     name: PARSER_NAMES.JAVA,
@@ -69,6 +108,8 @@ export const LANGUAGE_CONFIGS: LanguageConfig[] = [
     parserName: PARSER_NAMES.C,
     functionTypes: [...FUNCTION_TYPES.C],
     classTypes: [...CLASS_TYPES.C],
+    // Objective-C headers share the `.h` extension with C
+    extraElements: (content, filePath) => [...extractMacroDeclarations(content, filePath), ...extractObjCHeaderDefinitions(content, filePath)],
   },
   {
     name: PARSER_NAMES.CPP,
@@ -76,6 +117,7 @@ export const LANGUAGE_CONFIGS: LanguageConfig[] = [
     parserName: PARSER_NAMES.CPP,
     functionTypes: [...FUNCTION_TYPES.CPP],
     classTypes: [...CLASS_TYPES.CPP],
+    extraElements: extractMacroDeclarations,
   },
   {
     name: PARSER_NAMES.RUBY,
@@ -112,6 +154,288 @@ export const LANGUAGE_CONFIGS: LanguageConfig[] = [
     functionTypes: [...FUNCTION_TYPES.KOTLIN],
     classTypes: [...CLASS_TYPES.KOTLIN],
   },
+  {
+    name: PARSER_NAMES.SCALA,
+    extensions: [...LOGIC_EXTENSIONS.SCALA],
+    parserName: PARSER_NAMES.SCALA,
+    functionTypes: [...FUNCTION_TYPES.SCALA],
+    classTypes: [...CLASS_TYPES.SCALA],
+    optional: true,
+    extractElements: extractScalaDefinitions,
+  },
+  {
+    name: PARSER_NAMES.ELIXIR,
+    extensions: [...LOGIC_EXTENSIONS.ELIXIR],
+    parserName: PARSER_NAMES.ELIXIR,
+    functionTypes: [...FUNCTION_TYPES.ELIXIR],
+    classTypes: [...CLASS_TYPES.ELIXIR],
+    optional: true,
+    extractElements: extractElixirDefinitions,
+  },
+  {
+    name: PARSER_NAMES.LUA,
+    extensions: [...LOGIC_EXTENSIONS.LUA],
+    parserName: PARSER_NAMES.LUA,
+    functionTypes: [...FUNCTION_TYPES.LUA],
+    classTypes: [...CLASS_TYPES.LUA],
+    optional: true,
+    extractElements: extractLuaDefinitions,
+  },
+  {
+    name: PARSER_NAMES.ZIG,
+    extensions: [...LOGIC_EXTENSIONS.ZIG],
+    parserName: PARSER_NAMES.ZIG,
+    functionTypes: [...FUNCTION_TYPES.ZIG],
+    classTypes: [...CLASS_TYPES.ZIG],
+    optional: true,
+    extractElements: extractZigDefinitions,
+  },
+  {
+    name: PARSER_NAMES.ODIN,
+    extensions: [...LOGIC_EXTENSIONS.ODIN],
+    parserName: PARSER_NAMES.ODIN,
+    functionTypes: [...FUNCTION_TYPES.ODIN],
+    classTypes: [...CLASS_TYPES.ODIN],
+    optional: true,
+    extractElements: extractOdinDefinitions,
+  },
+  {
+    name: PARSER_NAMES.SOLIDITY,
+    extensions: [...LOGIC_EXTENSIONS.SOLIDITY],
+    parserName: PARSER_NAMES.SOLIDITY,
+    functionTypes: [...FUNCTION_TYPES.SOLIDITY],
+    classTypes: [...CLASS_TYPES.SOLIDITY],
+    optional: true,
+    extractElements: extractSolidityDefinitions,
+  },
+  {
+    name: PARSER_NAMES.DART,
+    extensions: [...LOGIC_EXTENSIONS.DART],
+    parserName: PARSER_NAMES.DART,
+    functionTypes: [...FUNCTION_TYPES.DART],
+    classTypes: [...CLASS_TYPES.DART],
+    optional: true,
+    extractElements: extractDartDefinitions,
+  },
+  {
+    name: PARSER_NAMES.HASKELL,
+    extensions: [...LOGIC_EXTENSIONS.HASKELL],
+    parserName: PARSER_NAMES.HASKELL,
+    functionTypes: [...FUNCTION_TYPES.HASKELL],
+    classTypes: [...CLASS_TYPES.HASKELL],
+    optional: true,
+    extractElements: extractHaskellDefinitions,
+  },
+  {
+    name: PARSER_NAMES.OCAML,
+    extensions: [...LOGIC_EXTENSIONS.OCAML],
+    parserName: PARSER_NAMES.OCAML,
+    functionTypes: [...FUNCTION_TYPES.OCAML],
+    classTypes: [...CLASS_TYPES.OCAML],
+    optional: true,
+    extractElements: extractOCamlDefinitions,
+  },
+  {
+    name: PARSER_NAMES.R,
+    extensions: [...LOGIC_EXTENSIONS.R],
+    parserName: PARSER_NAMES.R,
+    functionTypes: [...FUNCTION_TYPES.R],
+    classTypes: [...CLASS_TYPES.R],
+    optional: true,
+    extractElements: extractRDefinitions,
+  },
+  {
+    name: PARSER_NAMES.JULIA,
+    extensions: [...LOGIC_EXTENSIONS.JULIA],
+    parserName: PARSER_NAMES.JULIA,
+    functionTypes: [...FUNCTION_TYPES.JULIA],
+    classTypes: [...CLASS_TYPES.JULIA],
+    optional: true,
+    extractElements: extractJuliaDefinitions,
+  },
+  {
+    name: PARSER_NAMES.OBJC,
+    extensions: [...LOGIC_EXTENSIONS.OBJC],
+    parserName: PARSER_NAMES.OBJC,
+    functionTypes: [...FUNCTION_TYPES.OBJC],
+    classTypes: [...CLASS_TYPES.OBJC],
+    optional: true,
+    extractElements: extractObjCDefinitions,
+  },
+  {
+    name: PARSER_NAMES.SWIFT,
+    extensions: [...LOGIC_EXTENSIONS.SWIFT],
+    parserName: PARSER_NAMES.SWIFT,
+    functionTypes: [...FUNCTION_TYPES.SWIFT],
+    classTypes: [...CLASS_TYPES.SWIFT],
+    optional: true,
+    extractElements: extractSwiftDefinitions,
+  },
+  {
+    name: PARSER_NAMES.GROOVY,
+    extensions: [...LOGIC_EXTENSIONS.GROOVY],
+    filePatterns: INFRASTRUCTURE_FILE_PATTERNS.JENKINSFILE,
+    parserName: PARSER_NAMES.GROOVY,
+    functionTypes: [...FUNCTION_TYPES.GROOVY],
+    classTypes: [...CLASS_TYPES.GROOVY],
+    optional: true,
+    extractElements: extractGroovyDefinitions,
+  },
+  {
+    name: PARSER_NAMES.NIM,
+    extensions: [...LOGIC_EXTENSIONS.NIM],
+    parserName: PARSER_NAMES.NIM,
+    functionTypes: [...FUNCTION_TYPES.NIM],
+    classTypes: [...CLASS_TYPES.NIM],
+    optional: true,
+    extractElements: extractNimDefinitions,
+  },
+  {
+    name: PARSER_NAMES.CRYSTAL,
+    extensions: [...LOGIC_EXTENSIONS.CRYSTAL],
+    parserName: PARSER_NAMES.CRYSTAL,
+    functionTypes: [...FUNCTION_TYPES.CRYSTAL],
+    classTypes: [...CLASS_TYPES.CRYSTAL],
+    optional: true,
+    extractElements: extractCrystalDefinitions,
+  },
+  {
+    name: PARSER_NAMES.BASH,
+    extensions: [...LOGIC_EXTENSIONS.SHELL],
+    parserName: PARSER_NAMES.BASH,
+    functionTypes: [...FUNCTION_TYPES.BASH],
+    classTypes: [...CLASS_TYPES.BASH],
+    variableTypes: [...VARIABLE_TYPES.BASH],
+  },
+  {
+    name: PARSER_NAMES.MAKE,
+    extensions: [...LOGIC_EXTENSIONS.MAKE],
+    filePatterns: INFRASTRUCTURE_FILE_PATTERNS.MAKEFILE,
+    parserName: PARSER_NAMES.BASH,
+    functionTypes: [...FUNCTION_TYPES.BASH],
+    classTypes: [...CLASS_TYPES.BASH],
+    variableTypes: [...VARIABLE_TYPES.BASH],
+    preprocess: makefileToShell,
+  },
+  {
+    name: PARSER_NAMES.PROTO,
+    extensions: [...LOGIC_EXTENSIONS.PROTO],
+    parserName: PARSER_NAMES.PROTO,
+    functionTypes: [...FUNCTION_TYPES.PROTO],
+    classTypes: [...CLASS_TYPES.PROTO],
+    optional: true,
+    extractElements: (content, filePath) => protoDefinitionsToNodes(parseProtoDefinitions(content), content, filePath),
+  },
+  {
+    name: PARSER_NAMES.ASSEMBLY,
+    extensions: [...LOGIC_EXTENSIONS.ASSEMBLY],
+    parserName: PARSER_NAMES.ASSEMBLY,
+    functionTypes: [...FUNCTION_TYPES.ASSEMBLY],
+    classTypes: [...CLASS_TYPES.ASSEMBLY],
+    optional: true,
+    extractElements: extractAssemblyDefinitions,
+  },
+  {
+    name: PARSER_NAMES.LINKER_SCRIPT,
+    extensions: [...LOGIC_EXTENSIONS.LINKER_SCRIPT],
+    parserName: PARSER_NAMES.LINKER_SCRIPT,
+    functionTypes: [...FUNCTION_TYPES.LINKER_SCRIPT],
+    classTypes: [...CLASS_TYPES.LINKER_SCRIPT],
+    optional: true,
+    extractElements: extractLinkerScriptDefinitions,
+  },
+  {
+    name: PARSER_NAMES.MARKDOWN,
+    extensions: [...MARKUP_EXTENSIONS.MARKDOWN],
+    parserName: PARSER_NAMES.MARKDOWN,
+    functionTypes: [...FUNCTION_TYPES.MARKDOWN],
+    classTypes: [...CLASS_TYPES.MARKDOWN],
+    optional: true,
+    extractElements: extractMarkdownStructure,
+  },
+  {
+    name: PARSER_NAMES.LATEX,
+    extensions: [...MARKUP_EXTENSIONS.LATEX],
+    parserName: PARSER_NAMES.LATEX,
+    functionTypes: [...FUNCTION_TYPES.LATEX],
+    classTypes: [...CLASS_TYPES.LATEX],
+    optional: true,
+    extractElements: extractLatexStructure,
+  },
+  {
+    name: PARSER_NAMES.DOCKERFILE,
+    extensions: [],
+    filePatterns: INFRASTRUCTURE_FILE_PATTERNS.DOCKERFILE,
+    parserName: PARSER_NAMES.DOCKERFILE,
+    functionTypes: [...FUNCTION_TYPES.DOCKERFILE],
+    classTypes: [...CLASS_TYPES.DOCKERFILE],
+    optional: true,
+    extractElements: dockerfileToNodes,
+  },
+  {
+    name: PARSER_NAMES.COMPOSE,
+    extensions: [],
+    filePatterns: INFRASTRUCTURE_FILE_PATTERNS.COMPOSE,
+    parserName: PARSER_NAMES.COMPOSE,
+    functionTypes: [...FUNCTION_TYPES.COMPOSE],
+    classTypes: [...CLASS_TYPES.COMPOSE],
+    optional: true,
+    extractElements: composeToNodes,
+  },
+  {
+    name: PARSER_NAMES.JSON,
+    extensions: [...MARKUP_EXTENSIONS.JSON, '.jsonc'],
+    parserName: PARSER_NAMES.JSON,
+    functionTypes: [...FUNCTION_TYPES.JSON],
+    classTypes: [...CLASS_TYPES.JSON],
+    optional: true,
+    extractElements: configKeysToNodes('json'),
+  },
+  {
+    name: PARSER_NAMES.YAML,
+    extensions: [...MARKUP_EXTENSIONS.YAML],
+    parserName: PARSER_NAMES.YAML,
+    functionTypes: [...FUNCTION_TYPES.YAML],
+    classTypes: [...CLASS_TYPES.YAML],
+    optional: true,
+    extractElements: (content, filePath) => [
+      ...configKeysToNodes('yaml')(isHelmTemplate(filePath) ? renderHelmTemplate(content) : content, filePath),
+      ...kubernetesToNodes(content, filePath),
+    ],
+  },
+  {
+    name: PARSER_NAMES.TOML,
+    extensions: [...MARKUP_EXTENSIONS.TOML],
+    parserName: PARSER_NAMES.TOML,
+    functionTypes: [...FUNCTION_TYPES.TOML],
+    classTypes: [...CLASS_TYPES.TOML],
+    optional: true,
+    extractElements: configKeysToNodes('toml'),
+  },
+  {
+    name: PARSER_NAMES.GRADLE,
+    extensions: [],
+    filePatterns: INFRASTRUCTURE_FILE_PATTERNS.GRADLE,
+    parserName: PARSER_NAMES.GRADLE,
+    functionTypes: [...FUNCTION_TYPES.GRADLE],
+    classTypes: [...CLASS_TYPES.GRADLE],
+    optional: true,
+    // Groovy DSL builds also declare tasks and helper functions
+    extractElements: (content, filePath) => [
+      ...jvmBuildToNodes(content, filePath),
+      ...(filePath.endsWith('.gradle') ? extractGroovyDefinitions(content, filePath) : []),
+    ],
+  },
+  {
+    name: PARSER_NAMES.SBT,
+    extensions: [],
+    filePatterns: INFRASTRUCTURE_FILE_PATTERNS.SBT,
+    parserName: PARSER_NAMES.SBT,
+    functionTypes: [...FUNCTION_TYPES.SBT],
+    classTypes: [...CLASS_TYPES.SBT],
+    optional: true,
+    extractElements: jvmBuildToNodes,
+  },
 ]
 
 const GRAMMARS: Record<string, TreeSitterLanguage> = {
@@ -129,6 +453,28 @@ const GRAMMARS: Record<string, TreeSitterLanguage> = {
   [PARSER_NAMES.PHP]: PHP.php,
   [PARSER_NAMES.HTML]: HTML,
   [PARSER_NAMES.KOTLIN]: Kotlin,
+  [PARSER_NAMES.BASH]: Bash,
+  ...loadOptionalGrammars(),
+}
+
+/**
+ * Loads the optional grammar packages that are installed, skipping the rest silently
+ */
+function loadOptionalGrammars(): Record<string, TreeSitterLanguage> {
+  const grammars: Record<string, TreeSitterLanguage> = {}
+
+  for (const [parserName, packageName] of Object.entries(OPTIONAL_GRAMMAR_PACKAGES)) {
+    try {
+      // Packages shipping several grammars export them by name, like tree-sitter-ocaml's `ocaml`
+      const grammar = require(packageName)
+      grammars[parserName] = grammar[parserName] ?? grammar
+    }
+    catch {
+      /* optional grammar not installed */
+    }
+  }
+
+  return grammars
 }
 
 const parsers = new Map<string, Parser>()
@@ -153,14 +499,42 @@ export function getParser(language: string): Parser | undefined {
   return parsers.get(language)
 }
 
+/**
+ * Installs the parser used for a language, replacing the native one. Browser builds register
+ * web-tree-sitter parsers here, whose API matches the native binding's.
+ */
+export function registerParser(language: string, parser: Parser): void {
+  parsers.set(language, parser)
+}
+
 export function getLanguageByExtension(extension: string): LanguageConfig | undefined {
   return LANGUAGE_CONFIGS.find(config =>
     config.extensions.includes(extension.toLowerCase()),
   )
 }
 
+/**
+ * Finds the language for a file by base name (Dockerfile, compose.yaml) first, then by extension
+ */
+export function getLanguageForFile(filePath: string): LanguageConfig | undefined {
+  const fileName = basename(filePath)
+  return LANGUAGE_CONFIGS.find(config => config.filePatterns?.some(pattern => pattern.test(fileName)))
+    ?? getLanguageByExtension(extname(filePath))
+}
+
 export function getLanguageByName(name: string): LanguageConfig | undefined {
   return LANGUAGE_CONFIGS.find(config => config.name === name)
 }
 
+/**
+ * Resolves a user-supplied language hint: a language name or common alias (`ts`, `golang`),
+ * an extension (`.py`), or a file name (`Dockerfile`, `main.rs`)
+ */
+export function resolveLanguage(hint: string): LanguageConfig | undefined {
+  const normalized = hint.trim().toLowerCase()
+  return getLanguageByName(LANGUAGE_ALIASES[normalized] ?? normalized)
+    ?? getLanguageByExtension(normalized.startsWith('.') ? normalized : `.${normalized}`)
+    ?? getLanguageForFile(hint.trim())
+}
+
 initializeParsers()
\ No newline at end of file
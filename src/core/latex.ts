@@ -0,0 +1,144 @@
+/**
+ * LaTeX structure - sectioning commands, labels, macro definitions and code listings, whose
+ * code is parsed by the language the listing declares
+ */
+
+import { codeBlockNode, headingNodes, parseEmbeddedCode } from './markdown.js'
+import type { DocumentHeading } from './markdown.js'
+import type { TreeNode } from '../types/core.js'
+
+const SECTION_LEVELS: Record<string, number> = {
+  part: 0,
+  chapter: 1,
+  section: 2,
+  subsection: 3,
+  subsubsection: 4,
+  paragraph: 5,
+  subparagraph: 6,
+}
+
+const SECTION = /\\(part|chapter|section|subsection|subsubsection|paragraph|subparagraph)\*?\s*(?:\[[^\]]*\])?\s*\{/g
+const LABEL = /\\label\s*\{([^}]+)\}/g
+const MACRO = /\\(?:(?:re)?newcommand\*?|providecommand\*?|DeclareMathOperator\*?|(?:New|Renew|Provide|Declare)DocumentCommand)\s*\{?\s*\\([A-Za-z@]+)|\\(?:[gex]?def|let)\s*\\([A-Za-z@]+)|\\(?:re)?newenvironment\s*\{([A-Za-z@*]+)\}/g
+// Environments whose contents are code, with the language given in their options or argument
+const LISTING = /\\begin\{(lstlisting|minted|verbatim|Verbatim|pycode|luacode)\}(\[[^\]\n]*\])?(?:\{([^}\n]*)\})?/g
+
+/**
+ * Text-based extraction of LaTeX: `heading` nodes for `\part` through `\subparagraph`, each
+ * running to the next one of the same or a higher level, `label` nodes for `\label` with the
+ * heading they are under as `symbol.container`, `macro` nodes for `\newcommand`, `\def`,
+ * `\DeclareMathOperator` and `\newenvironment` definitions, and `code_block` nodes for
+ * `lstlisting`, `minted` and `verbatim` environments. The code of a listing whose language is
+ * given (`[language=Python]`, `\begin{minted}{rust}`) is parsed with that language.
+ */
+export function extractLatexStructure(content: string, filePath: string): TreeNode[] {
+  const lines = content.split('\n')
+  const nodes: TreeNode[] = []
+
+  // Listings are read from the original text: `%` in code is not a comment
+  const listings: { open: number, close: number }[] = []
+  for (const match of content.matchAll(LISTING)) {
+    const open = lineAt(content, match.index!) - 1
+    const closeIndex = content.indexOf(`\\end{${match[1]}}`, match.index! + match[0].length)
+    const close = closeIndex === -1 ? lines.length - 1 : lineAt(content, closeIndex) - 1
+    if (listings.some(listing => open <= listing.close && listing.open <= open)) continue
+    listings.push({ open, close })
+
+    const code = lines.slice(open + 1, close)
+    const language = match[1] === 'minted' ? match[3] : match[2]?.match(/\blanguage\s*=\s*\{?([\w+#-]+)/)?.[1]
+    nodes.push(codeBlockNode(language?.toLowerCase() ?? '', code, open, close, filePath, 'tex'))
+    if (language) nodes.push(...parseEmbeddedCode(code, open + 1, language, filePath))
+  }
+
+  const code = maskLatex(content, listings)
+  const end = code.search(/\\end\s*\{document\}/)
+  const last = end === -1 ? lines.length - 1 : lineAt(code, end) - 1
+
+  const headings: DocumentHeading[] = []
+  for (const match of code.matchAll(SECTION)) {
+    const line = lineAt(code, match.index!) - 1
+    const title = readGroup(content, match.index! + match[0].length - 1)
+    headings.push({ level: SECTION_LEVELS[match[1]!]!, title: plainText(title), line, bodyLine: line + 1 })
+  }
+  const headingElements = headingNodes(headings, lines, filePath, 'tex', last)
+  nodes.push(...headingElements)
+
+  for (const match of code.matchAll(LABEL)) {
+    const line = lineAt(code, match.index!) - 1
+    const heading = [...headingElements].reverse().find(candidate => candidate.startLine! <= line + 1 && line + 1 <= candidate.endLine!)
+    nodes.push(element('label', 'variable', match[1]!.trim(), line, line, lines, filePath, heading?.name))
+  }
+
+  for (const match of code.matchAll(MACRO)) {
+    const line = lineAt(code, match.index!) - 1
+    const name = match[1] ?? match[2] ?? match[3]!
+    // The body follows the name after any closing brace, `[n]` argument count and `#1` parameters
+    const after = code.substring(match.index! + match[0].length).match(/^[\s}]*(?:\[[^\]]*\]\s*)*(?:#\d\s*)*\{/)
+    const body = after ? match.index! + match[0].length + after[0].length - 1 : -1
+    const close = body === -1 ? line : lineAt(code, body + readGroup(code, body).length + 1) - 1
+    nodes.push(element('macro', 'macro', name, line, close, lines, filePath))
+  }
+
+  return nodes.sort((a, b) => (a.startLine ?? 0) - (b.startLine ?? 0))
+}
+
+function element(type: string, kind: 'variable' | 'macro', name: string, first: number, last: number, lines: string[], filePath: string, container?: string): TreeNode {
+  return {
+    id: `tex-${type}-${filePath}-${first + 1}-${name}`,
+    type,
+    name,
+    path: filePath,
+    startLine: first + 1,
+    endLine: last + 1,
+    content: lines.slice(first, last + 1).join('\n'),
+    symbol: {
+      kind,
+      visibility: 'public',
+      signature: lines[first]!.trim().substring(0, 200),
+      ...(container ? { container } : {}),
+    },
+  }
+}
+
+/**
+ * The text of the brace group opening at `open`, without its braces
+ */
+function readGroup(content: string, open: number): string {
+  let depth = 0
+  for (let index = open; index < content.length; index++) {
+    if (content[index] === '\\') index++
+    else if (content[index] === '{') depth++
+    else if (content[index] === '}' && --depth === 0) return content.substring(open + 1, index)
+  }
+  return content.substring(open + 1)
+}
+
+/**
+ * A title without commands such as `\emph` and `\texttt`, keeping their text
+ */
+function plainText(title: string): string {
+  return title
+    .replace(/\\(?:label|footnote|index)\s*\{[^}]*\}/g, '')
+    .replace(/\\[A-Za-z]+\*?\s*/g, '')
+    .replace(/\\(.)/g, '$1')
+    .replace(/[{}~]/g, ' ')
+    .replace(/\s+/g, ' ')
+    .trim()
+}
+
+/**
+ * Blanks `%` comments and the code of `listings`, keeping offsets and line numbers
+ */
+function maskLatex(content: string, listings: { open: number, close: number }[]): string {
+  return content.split('\n').map((line, index) => {
+    if (listings.some(listing => index > listing.open && index < listing.close)) return ' '.repeat(line.length)
+    const comment = line.search(/(?<!\\)(?:\\\\)*%/)
+    if (comment === -1) return line
+    const start = line.indexOf('%', comment)
+    return line.substring(0, start) + ' '.repeat(line.length - start)
+  }).join('\n')
+}
+
+function lineAt(content: string, index: number): number {
+  return content.substring(0, index).split('\n').length
+}
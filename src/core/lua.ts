@@ -0,0 +1,360 @@
+/**
+ * Lua - functions read from the source text, with Neovim plugin conventions: functions on a
+ * module's returned table are contained by its `require` name, and the keymaps, autocommands
+ * and user commands a config or plugin registers are indexed under their key, event and name
+ */
+
+import type { SymbolVisibility, TreeNode } from '../types/core.js'
+
+interface Argument {
+  text: string
+  start: number
+}
+
+interface Call {
+  start: number
+  end: number
+  args: Argument[]
+}
+
+const MAX_SIGNATURE_LENGTH = 200
+
+const BLOCK_TOKEN = /(?<![\w.:])(function|if|do|repeat|end|until)\b/g
+const NAMED_FUNCTION = /(?<![\w.:])(local\s+)?(function)\s+([A-Za-z_]\w*(?:\.[A-Za-z_]\w*)*(?::[A-Za-z_]\w*)?)\s*\(/g
+const ASSIGNED_FUNCTION = /(?<![\w.:])(local\s+)?([A-Za-z_]\w*(?:\.[A-Za-z_]\w*)*)\s*=\s*(function)\s*\(/g
+const LOCAL_DECLARATION = /(?<![\w.:])local\s+([A-Za-z_]\w*)\s*=/g
+const RETURNED_TABLE = /^return\s+([A-Za-z_]\w*)\s*$/m
+const KEYMAP_CALL = /(?<![\w.:])(vim\.keymap\.set|vim\.api\.nvim_set_keymap|vim\.api\.nvim_buf_set_keymap)\s*\(/g
+const AUTOCMD_CALL = /(?<![\w.:])vim\.api\.nvim_create_autocmd\s*\(/g
+const COMMAND_CALL = /(?<![\w.:])vim\.api\.nvim_(buf_)?create_user_command\s*\(/g
+const AUGROUP_VARIABLE = /(?<![\w.:])(?:local\s+)?([A-Za-z_]\w*)\s*=\s*vim\.api\.nvim_create_augroup\s*\(\s*(['"])([^'"]+)\2/g
+
+/**
+ * The module name a file is loaded by with `require`, from its path below a `lua/` directory:
+ * `lua/telescope/init.lua` is `telescope` and `lua/telescope/builtin.lua` `telescope.builtin`
+ */
+export function luaModuleName(filePath: string): string | undefined {
+  const parts = filePath.split(/[\\/]/)
+  const root = parts.lastIndexOf('lua')
+  if (root === -1 || root === parts.length - 1) return undefined
+  const segments = parts.slice(root + 1)
+  segments[segments.length - 1] = segments.at(-1)!.replace(/\.lua$/, '')
+  if (segments.at(-1) === 'init') segments.pop()
+  return segments.length > 0 ? segments.join('.') : undefined
+}
+
+/**
+ * `function` nodes for named and assigned functions, with the table they are defined on as
+ * container, plus `keymap`, `autocmd` and `command` nodes for Neovim registrations, named by
+ * the key sequence, event and command
+ */
+export function extractLuaDefinitions(content: string, filePath: string): TreeNode[] {
+  const code = maskLua(content)
+  const blocks = matchBlocks(code)
+  const lines = content.split('\n')
+  const nodes: TreeNode[] = [
+    ...readFunctions(content, code, blocks, filePath),
+    ...readKeymaps(content, code),
+    ...readAutocmds(content, code),
+    ...readUserCommands(content, code),
+  ].map(node => ({ ...node, path: filePath, content: lines.slice(node.startLine! - 1, node.endLine).join('\n') }))
+
+  return nodes.sort((a, b) => (a.startLine ?? 0) - (b.startLine ?? 0))
+}
+
+function readFunctions(content: string, code: string, blocks: Map<number, number>, filePath: string): TreeNode[] {
+  const moduleTable = code.match(RETURNED_TABLE)?.[1]
+  const moduleName = luaModuleName(filePath)
+  const locals = new Set([...code.matchAll(LOCAL_DECLARATION)].map(match => match[1]!))
+  const nodes: TreeNode[] = []
+
+  for (const match of [...code.matchAll(NAMED_FUNCTION), ...code.matchAll(ASSIGNED_FUNCTION)]) {
+    const named = match[2] === 'function'
+    // Callbacks in a table passed to a call, like nvim_create_autocmd's `callback = function`
+    if (!named && !match[1] && !match[2]!.includes('.') && /[(,]\s*$/.test(code.substring(0, enclosingTable(code, match.index!)))) continue
+    const path = named ? match[3]! : match[2]!
+    const keyword = match.index! + match[0].lastIndexOf('function')
+    const end = blocks.get(keyword) ?? code.indexOf('\n', keyword)
+    const start = match.index!
+    const paramsEnd = code.indexOf(')', match.index! + match[0].length)
+
+    const segments = path.split(/[.:]/)
+    const name = segments.pop()!
+    let container = segments.length > 0 ? path.substring(0, path.length - name.length - 1) : undefined
+    // The returned table is the module itself, so its functions belong to the require name
+    if (container && moduleName && segments[0] === moduleTable) {
+      container = [moduleName, ...segments.slice(1)].join('.')
+    }
+
+    let visibility: SymbolVisibility = 'public'
+    if (match[1] || (segments.length > 0 && locals.has(segments[0]!) && segments[0] !== moduleTable)) visibility = 'private'
+    else if (segments.length === 0 && locals.has(name)) visibility = 'private'
+
+    const doc = readDocComment(content, start)
+    nodes.push({
+      id: `lua-function-${filePath}-${lineAt(content, start)}-${path}`,
+      type: 'function',
+      name,
+      path: filePath,
+      startLine: lineAt(content, start),
+      endLine: lineAt(content, end === -1 ? content.length : end),
+      symbol: {
+        kind: path.includes(':') ? 'method' : 'function',
+        visibility,
+        signature: content.substring(start, paramsEnd === -1 ? end : paramsEnd + 1).replace(/\s+/g, ' ').substring(0, MAX_SIGNATURE_LENGTH),
+        ...(container ? { container } : {}),
+        ...(doc ? { doc } : {}),
+      },
+    })
+  }
+
+  return nodes
+}
+
+/**
+ * `vim.keymap.set(mode, lhs, rhs, opts)` and the `nvim_set_keymap` API, including calls through
+ * a local alias such as `local map = vim.keymap.set`
+ */
+function readKeymaps(content: string, code: string): TreeNode[] {
+  const aliases = [...code.matchAll(/(?<![\w.:])local\s+([A-Za-z_]\w*)\s*=\s*vim\.keymap\.set\b(?!\s*\()/g)].map(match => match[1]!)
+  const pattern = aliases.length > 0
+    ? new RegExp(`${KEYMAP_CALL.source.replace('(vim\\.keymap\\.set|', `(${aliases.join('|')}|vim\\.keymap\\.set|`)}`, 'g')
+    : KEYMAP_CALL
+  const nodes: TreeNode[] = []
+
+  for (const match of code.matchAll(pattern)) {
+    const call = readCall(content, code, match.index!, match.index! + match[0].length - 1)
+    const args = match[1] === 'vim.api.nvim_buf_set_keymap' ? call.args.slice(1) : call.args
+    const lhs = args[1] && readString(args[1].text)
+    if (!lhs) continue
+
+    const modes = readStrings(args[0]?.text ?? '')
+    const options = args[3]?.text ?? ''
+    const desc = tableString(options, 'desc')
+    const handler = args[2] && readHandler(args[2].text)
+    nodes.push(registrationNode('keymap', lhs, content, call, `${modes.join(',') || 'n'} ${lhs}`, desc, handler))
+  }
+
+  return nodes
+}
+
+/**
+ * `vim.api.nvim_create_autocmd(events, opts)`, one node per event, contained by its augroup
+ */
+function readAutocmds(content: string, code: string): TreeNode[] {
+  const groups = new Map([...code.matchAll(AUGROUP_VARIABLE)].map(match => [match[1]!, content.substring(match.index!).match(/(['"])([^'"]+)\1/)![2]!]))
+  const nodes: TreeNode[] = []
+
+  for (const match of code.matchAll(AUTOCMD_CALL)) {
+    const call = readCall(content, code, match.index!, match.index! + match[0].length - 1)
+    const options = call.args[1]?.text ?? ''
+    const pattern = tableString(options, 'pattern') ?? readStrings(tableValue(options, 'pattern') ?? '').join(',')
+    const group = options.match(/\bgroup\s*=\s*(?:vim\.api\.nvim_create_augroup\s*\(\s*)?(['"])([^'"]+)\1/)?.[2]
+      ?? groups.get(options.match(/\bgroup\s*=\s*([A-Za-z_]\w*)/)?.[1] ?? '')
+    const desc = tableString(options, 'desc')
+    const handler = tableString(options, 'command') ?? readHandler(tableValue(options, 'callback') ?? '')
+
+    for (const event of readStrings(call.args[0]?.text ?? '')) {
+      const node = registrationNode('autocmd', event, content, call, `autocmd ${event}${pattern ? ` ${pattern}` : ''}`, desc, handler)
+      if (group) node.symbol!.container = group
+      nodes.push(node)
+    }
+  }
+
+  return nodes
+}
+
+/**
+ * `vim.api.nvim_create_user_command(name, command, opts)` and its buffer-local form
+ */
+function readUserCommands(content: string, code: string): TreeNode[] {
+  const nodes: TreeNode[] = []
+
+  for (const match of code.matchAll(COMMAND_CALL)) {
+    const call = readCall(content, code, match.index!, match.index! + match[0].length - 1)
+    const args = match[1] ? call.args.slice(1) : call.args
+    const name = args[0] && readString(args[0].text)
+    if (!name) continue
+
+    const desc = tableString(args[2]?.text ?? '', 'desc')
+    const handler = args[1] && readHandler(args[1].text)
+    nodes.push(registrationNode('command', name, content, call, `:${name}`, desc, handler))
+  }
+
+  return nodes
+}
+
+function registrationNode(type: string, name: string, content: string, call: Call, signature: string, doc?: string, handler?: string): TreeNode {
+  const startLine = lineAt(content, call.start)
+  return {
+    id: `lua-${type}-${startLine}-${name}`,
+    type,
+    name,
+    path: '',
+    startLine,
+    endLine: lineAt(content, call.end),
+    symbol: {
+      kind: 'function',
+      visibility: 'public',
+      signature: signature.substring(0, MAX_SIGNATURE_LENGTH),
+      ...(doc ? { doc } : {}),
+      ...(handler ? { handler } : {}),
+    },
+  }
+}
+
+/**
+ * Splits the arguments of the call whose `(` is at `open`, at top-level commas
+ */
+function readCall(content: string, code: string, start: number, open: number): Call {
+  const args: Argument[] = []
+  let depth = 0
+  let argumentStart = open + 1
+  let index = open
+
+  for (; index < code.length; index++) {
+    const char = code[index]!
+    if ('([{'.includes(char)) {
+      depth++
+    }
+    else if (')]}'.includes(char)) {
+      depth--
+      if (depth === 0) break
+    }
+    else if (char === ',' && depth === 1) {
+      args.push({ text: content.substring(argumentStart, index).trim(), start: argumentStart })
+      argumentStart = index + 1
+    }
+  }
+
+  const last = content.substring(argumentStart, index).trim()
+  if (last) args.push({ text: last, start: argumentStart })
+  return { start, end: index, args }
+}
+
+/**
+ * What a keymap, autocommand or command runs, when it is a named function or a command string
+ * rather than an inline function
+ */
+function readHandler(text: string): string | undefined {
+  const string = readString(text)
+  if (string !== undefined) return string || undefined
+  return /^[A-Za-z_][\w.:]*$/.test(text) ? text : undefined
+}
+
+function readString(text: string): string | undefined {
+  const match = text.match(/^(['"])([\s\S]*)\1$/) ?? text.match(/^\[(=*)\[([\s\S]*)\]\1\]$/)
+  return match ? match[2] : undefined
+}
+
+/**
+ * A string or a table of strings, as used for modes and events
+ */
+function readStrings(text: string): string[] {
+  const single = readString(text)
+  if (single !== undefined) return [single]
+  return text.startsWith('{') ? [...text.matchAll(/(['"])([^'"]*)\1/g)].map(match => match[2]!) : []
+}
+
+function tableString(table: string, key: string): string | undefined {
+  return table.match(new RegExp(String.raw`(?:^|[{,\s])${key}\s*=\s*(['"])((?:\\.|(?!\1).)*)\1`))?.[2]
+}
+
+function tableValue(table: string, key: string): string | undefined {
+  return table.match(new RegExp(String.raw`(?:^|[{,\s])${key}\s*=\s*(\{[^}]*\}|[^,}\s]+)`))?.[1]
+}
+
+/**
+ * The `---` or `--` comment lines directly above a definition, without LuaLS annotations
+ */
+function readDocComment(content: string, start: number): string | undefined {
+  const lines = content.substring(0, start).split('\n').slice(0, -1)
+  const comments: string[] = []
+  for (let index = lines.length - 1; index >= 0 && /^\s*--(?!\[)/.test(lines[index]!); index--) {
+    comments.unshift(lines[index]!.replace(/^\s*-+\s?/, '').trimEnd())
+  }
+  const doc = comments.filter(line => !line.startsWith('@')).join('\n').trim()
+  return doc || undefined
+}
+
+/**
+ * Pairs each block-opening keyword with the end of the `end` (or `until`) closing it. `for` and
+ * `while` open their block with `do`, so only the `do` is counted.
+ */
+function matchBlocks(code: string): Map<number, number> {
+  const blocks = new Map<number, number>()
+  const open: number[] = []
+
+  for (const match of code.matchAll(BLOCK_TOKEN)) {
+    if (match[1] === 'end' || match[1] === 'until') {
+      const opening = open.pop()
+      if (opening !== undefined) blocks.set(opening, match.index! + match[1].length)
+    }
+    else {
+      open.push(match.index!)
+    }
+  }
+
+  return blocks
+}
+
+/**
+ * Blanks comments and the contents of strings, including long brackets like `[==[ ... ]==]`,
+ * keeping offsets and line numbers
+ */
+export function maskLua(content: string): string {
+  const chars = content.split('')
+  const blank = (from: number, to: number) => {
+    for (let index = from; index < to; index++) {
+      if (chars[index] !== '\n') chars[index] = ' '
+    }
+  }
+  const longBracket = (index: number) => content.substring(index).match(/^\[(=*)\[/)?.[1]
+
+  let index = 0
+  while (index < content.length) {
+    const char = content[index]!
+
+    if (content.startsWith('--', index)) {
+      const level = longBracket(index + 2)
+      const close = level === undefined ? content.indexOf('\n', index) : content.indexOf(`]${level}]`, index)
+      const stop = close === -1 ? content.length : level === undefined ? close : close + level.length + 2
+      blank(index, stop)
+      index = stop
+    }
+    else if (char === '[' && longBracket(index) !== undefined) {
+      const level = longBracket(index)!
+      const close = content.indexOf(`]${level}]`, index)
+      const stop = close === -1 ? content.length : close
+      blank(index + level.length + 2, stop)
+      index = stop + level.length + 2
+    }
+    else if (char === '"' || char === '\'') {
+      let stop = index + 1
+      while (stop < content.length && content[stop] !== char && content[stop] !== '\n') stop += content[stop] === '\\' ? 2 : 1
+      blank(index + 1, stop)
+      index = stop + 1
+    }
+    else {
+      index++
+    }
+  }
+
+  return chars.join('')
+}
+
+/**
+ * The offset of the innermost unclosed `{` before an offset, or 0 outside any table
+ */
+function enclosingTable(code: string, offset: number): number {
+  let depth = 0
+  for (let index = offset - 1; index >= 0; index--) {
+    if (code[index] === '}') depth++
+    else if (code[index] === '{' && depth-- === 0) return index
+  }
+  return 0
+}
+
+function lineAt(content: string, index: number): number {
+  return content.substring(0, index).split('\n').length
+}
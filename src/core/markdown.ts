@@ -0,0 +1,171 @@
+/**
+ * Markdown structure - headings and fenced code blocks, with each block's code parsed by the
+ * grammar its fence declares so documentation examples are indexed as code
+ */
+
+import { parseContent } from './parser.js'
+import { resolveLanguage } from './languages.js'
+import { PARSER_NAMES } from '../constants/parsers.js'
+import type { TreeNode } from '../types/core.js'
+
+// Formats whose fenced examples are not code: their keys and headings would read as if the
+// docs declared them
+const NON_CODE_LANGUAGES = new Set<string>([PARSER_NAMES.MARKDOWN, PARSER_NAMES.LATEX, PARSER_NAMES.JSON, PARSER_NAMES.YAML, PARSER_NAMES.TOML])
+
+const ATX_HEADING = /^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$/
+const SETEXT_UNDERLINE = /^ {0,3}(=+|-+)[ \t]*$/
+const FENCE = /^(\s*)(`{3,}|~{3,})\s*([^`\s]*)/
+
+export interface DocumentHeading {
+  level: number
+  title: string
+  line: number // Where the heading is written
+  bodyLine: number // First line after the heading
+}
+
+/**
+ * Text-based extraction of Markdown: `heading` nodes running to the next heading of the same
+ * or a higher level, with the heading above as `symbol.container` and the first paragraph as
+ * `symbol.doc`, and `code_block` nodes for fenced blocks, named by their info string. The code
+ * of a block in a programming language is parsed with that language, and what it declares is
+ * indexed at its lines in the document.
+ */
+export function extractMarkdownStructure(content: string, filePath: string): TreeNode[] {
+  const lines = content.split('\n')
+  const headings: DocumentHeading[] = []
+  const nodes: TreeNode[] = []
+  let index = frontMatterEnd(lines)
+
+  for (; index < lines.length; index++) {
+    const line = lines[index]!
+
+    const fence = line.match(FENCE)
+    if (fence) {
+      const indent = fence[1]!.length
+      const marker = fence[2]!
+      let close = index + 1
+      while (close < lines.length && !new RegExp(`^\\s*${marker[0]}{${marker.length},}\\s*$`).test(lines[close]!)) close++
+      const code = lines.slice(index + 1, close).map(codeLine => codeLine.substring(Math.min(indent, codeLine.match(/^\s*/)![0].length)))
+      const info = fence[3]!.replace(/^\{?\.?/, '').replace(/[},].*$/, '')
+      nodes.push(codeBlockNode(info, code, index, Math.min(close, lines.length - 1), filePath, 'md'))
+      if (info) nodes.push(...parseEmbeddedCode(code, index + 1, info, filePath))
+      index = close
+      continue
+    }
+
+    const atx = line.match(ATX_HEADING)
+    if (atx) {
+      headings.push({ level: atx[1]!.length, title: headingTitle(atx[2] ?? ''), line: index, bodyLine: index + 1 })
+      continue
+    }
+
+    const underline = lines[index + 1]?.match(SETEXT_UNDERLINE)
+    if (underline && line.trim() && !/^\s*(?:[-*+>|]|\d+[.)])/.test(line) && !lines[index + 1]!.includes('|')) {
+      headings.push({ level: underline[1]!.startsWith('=') ? 1 : 2, title: headingTitle(line), line: index, bodyLine: index + 2 })
+      index++
+    }
+  }
+
+  return [...headingNodes(headings, lines, filePath, 'md'), ...nodes].sort((a, b) => (a.startLine ?? 0) - (b.startLine ?? 0))
+}
+
+/**
+ * Parses the code of a documentation example whose first line is line `first` (0-based) of
+ * the document, with the language `hint` names. Elements keep the document's line numbers;
+ * examples in unknown languages, data formats, or that fail to parse yield none.
+ */
+export function parseEmbeddedCode(code: string[], first: number, hint: string, filePath: string): TreeNode[] {
+  const language = resolveLanguage(hint)
+  if (!language || NON_CODE_LANGUAGES.has(language.name)) return []
+
+  try {
+    // Padding with blank lines places every element at its line in the document
+    return parseContent('\n'.repeat(first) + code.join('\n'), filePath, language).children ?? []
+  }
+  catch {
+    return []
+  }
+}
+
+/**
+ * `heading` nodes for headings in document order. A heading covers the lines up to the next
+ * heading of the same or a higher level, or the end of `lines`.
+ */
+export function headingNodes(headings: DocumentHeading[], lines: string[], filePath: string, prefix: string, last = lines.length - 1): TreeNode[] {
+  const parents: DocumentHeading[] = []
+  return headings.map((heading, position) => {
+    while (parents.length > 0 && parents[parents.length - 1]!.level >= heading.level) parents.pop()
+    const container = parents[parents.length - 1]?.title
+    parents.push(heading)
+
+    const next = headings.slice(position + 1).find(candidate => candidate.level <= heading.level)
+    let end = next ? next.line - 1 : last
+    while (end > heading.line && !lines[end]!.trim()) end--
+    const doc = firstParagraph(lines, heading.bodyLine, end)
+
+    return {
+      id: `${prefix}-heading-${filePath}-${heading.line + 1}-${heading.title}`,
+      type: 'heading',
+      name: heading.title,
+      path: filePath,
+      startLine: heading.line + 1,
+      endLine: end + 1,
+      content: lines.slice(heading.line, end + 1).join('\n'),
+      symbol: {
+        kind: 'module',
+        visibility: 'public',
+        signature: lines[heading.line]!.trim().substring(0, 200),
+        ...(container ? { container } : {}),
+        ...(doc ? { doc } : {}),
+      },
+    }
+  })
+}
+
+/**
+ * A `code_block` node for an example running from line `open` to line `close` (0-based)
+ */
+export function codeBlockNode(language: string, code: string[], open: number, close: number, filePath: string, prefix: string): TreeNode {
+  return {
+    id: `${prefix}-code_block-${filePath}-${open + 1}`,
+    type: 'code_block',
+    ...(language ? { name: language } : {}),
+    path: filePath,
+    startLine: open + 1,
+    endLine: close + 1,
+    content: code.join('\n'),
+  }
+}
+
+/**
+ * The heading text without emphasis, code spans, link targets and `{#id}` attributes
+ */
+function headingTitle(text: string): string {
+  return text
+    .replace(/\s*\{#[^}]*\}\s*$/, '')
+    .replace(/!?\[([^\]]*)\]\([^)]*\)/g, '$1')
+    .replace(/[`*]/g, '')
+    .trim()
+}
+
+/**
+ * The first paragraph between lines `first` and `last`, if it comes before any other block
+ */
+function firstParagraph(lines: string[], first: number, last: number): string | undefined {
+  let index = first
+  while (index <= last && !lines[index]!.trim()) index++
+  const paragraph: string[] = []
+  for (; index <= last && lines[index]!.trim() && !FENCE.test(lines[index]!) && !ATX_HEADING.test(lines[index]!); index++) {
+    paragraph.push(lines[index]!.trim())
+  }
+  return paragraph.join(' ') || undefined
+}
+
+/**
+ * The first line after a YAML front matter block, or 0 without one
+ */
+function frontMatterEnd(lines: string[]): number {
+  if (lines[0]?.trim() !== '---') return 0
+  const close = lines.findIndex((line, index) => index > 0 && /^(?:---|\.\.\.)\s*$/.test(line))
+  return close === -1 ? 0 : close + 1
+}
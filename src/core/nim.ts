@@ -0,0 +1,287 @@
+/**
+ * Nim - procedures, iterators, templates, macros and the types of `type` sections read from
+ * the source text. Blocks are delimited by indentation; a `*` after a name exports it.
+ */
+
+import type { SymbolKind, SymbolVisibility, TreeNode } from '../types/core.js'
+
+interface Definition {
+  type: string
+  kind: SymbolKind
+  name: string
+  startLine: number
+  endLine: number
+  signature: string
+  visibility: SymbolVisibility
+  container?: string
+  bodyLine: number // First line after the header, where `##` documentation begins
+}
+
+const MAX_SIGNATURE_LENGTH = 200
+
+const ROUTINE = /^(\s*)(proc|func|method|iterator|converter|template|macro)\s+(`[^`]+`|[A-Za-z_]\w*)(\*)?/
+const TYPE_SECTION = /^(\s*)type\b\s*(.*)$/
+const TYPE_ENTRY = /^(\s*)([A-Za-z_]\w*)(\*)?\s*(?:\[[^\]]*\])?\s*(?:\{\.[^}]*\.?\})?\s*=\s*(.*)$/
+const LITERAL = /#[^\n]*|[rR]?"""[\s\S]*?"""(?!")|[rR]"(?:""|[^"\n])*"|"(?:\\.|[^"\\\n])*"|'(?:\\.|[^'\\\n])'/y
+const ROUTINE_KINDS: Record<string, { type: string, kind: SymbolKind }> = {
+  proc: { type: 'function', kind: 'function' },
+  func: { type: 'function', kind: 'function' },
+  iterator: { type: 'function', kind: 'function' },
+  converter: { type: 'function', kind: 'function' },
+  method: { type: 'method', kind: 'method' },
+  template: { type: 'macro', kind: 'macro' },
+  macro: { type: 'macro', kind: 'macro' },
+}
+
+/**
+ * `function` nodes for procs, funcs, iterators and converters, `method` nodes for methods
+ * (with the type of their first parameter as `symbol.container`, the type they dispatch on),
+ * `macro` nodes for templates and macros, and `class` nodes for object, enum, concept and
+ * other types, with `variant` nodes for enum values. Names exported with `*` are public, the
+ * rest are `internal` to their module. Routines declared inside other routines are skipped.
+ */
+export function extractNimDefinitions(content: string, filePath: string): TreeNode[] {
+  const lines = content.split('\n')
+  const code = maskNim(content).split('\n')
+  const definitions: Definition[] = []
+  let routineEnd = -1 // Last line of the routine being read, whose body is not searched
+
+  for (let index = 0; index < code.length; index++) {
+    const line = code[index]!
+    if (index <= routineEnd) continue
+
+    const routine = line.match(ROUTINE)
+    if (routine) {
+      const indent = routine[1]!.length
+      const headerEnd = routineHeaderEnd(code, index)
+      const header = code.slice(index, headerEnd + 1).join('\n')
+      const assignment = topLevelAssignment(header)
+      const end = assignment === -1 ? headerEnd : blockEnd(code, headerEnd, indent)
+      const headerText = lines.slice(index, headerEnd + 1).join('\n')
+      definitions.push({
+        ...ROUTINE_KINDS[routine[2]!]!,
+        name: routine[3]!.replace(/`/g, ''),
+        startLine: index + 1,
+        endLine: end + 1,
+        signature: oneLine(assignment === -1 ? headerText : headerText.substring(0, assignment)),
+        visibility: routine[4] ? 'public' : 'internal',
+        bodyLine: headerEnd + 2,
+        ...(routine[2] === 'method' ? { container: firstParameterType(header) } : {}),
+      })
+      routineEnd = end
+      continue
+    }
+
+    const section = line.match(TYPE_SECTION)
+    if (section) {
+      const end = blockEnd(code, index, section[1]!.length)
+      // `type Shape = object` declares its one type on the `type` line
+      definitions.push(...readTypeSection(lines, code, index, end, section[2]!.trim() ? line.length - section[2]!.length : undefined))
+      index = end
+    }
+  }
+
+  return definitions.map((definition) => {
+    const doc = readDocComment(lines, definition)
+    return {
+      id: `nim-${definition.type}-${filePath}-${definition.startLine}-${definition.name}`,
+      type: definition.type,
+      name: definition.name,
+      path: filePath,
+      startLine: definition.startLine,
+      endLine: definition.endLine,
+      content: lines.slice(definition.startLine - 1, definition.endLine).join('\n'),
+      symbol: {
+        kind: definition.kind,
+        visibility: definition.visibility,
+        signature: definition.signature.substring(0, MAX_SIGNATURE_LENGTH),
+        ...(definition.container ? { container: definition.container } : {}),
+        ...(doc ? { doc } : {}),
+      },
+    }
+  })
+}
+
+/**
+ * The types of a `type` section from `first` to `last`. Entries sit at the indentation of the
+ * first one; an entry on the `type` line itself starts at column `inline`.
+ */
+function readTypeSection(lines: string[], code: string[], first: number, last: number, inline?: number): Definition[] {
+  const definitions: Definition[] = []
+  let entryIndent: number | undefined
+
+  for (let index = first; index <= last; index++) {
+    const text = index === first ? (inline === undefined ? '' : ' '.repeat(inline) + code[index]!.substring(inline)) : code[index]!
+    const entry = text.match(TYPE_ENTRY)
+    if (!entry) continue
+    const indent = entry[1]!.length
+    entryIndent ??= indent
+    if (indent !== entryIndent) continue
+
+    // A type on the `type` line is the only one, and its body is indented from the section
+    const end = index === first && inline !== undefined ? last : blockEnd(code, index, indent)
+    const body = entry[4]!.trim()
+    const { kind, type } = typeKind(body)
+    const name = entry[2]!
+    definitions.push({
+      type,
+      kind,
+      name,
+      startLine: index + 1,
+      endLine: Math.min(end, last) + 1,
+      signature: oneLine(lines[index]!.substring(indent).replace(/^type\s+/, '')),
+      visibility: entry[3] ? 'public' : 'internal',
+      bodyLine: index + 2,
+    })
+    if (kind === 'enum') definitions.push(...readEnumValues(lines, code, index, Math.min(end, last), name, entry[3] ? 'public' : 'internal'))
+    index = Math.min(end, last)
+  }
+  return definitions
+}
+
+function typeKind(body: string): { type: string, kind: SymbolKind } {
+  if (/^(?:ref\s+|ptr\s+)?object\b.*\bof\b/.test(body)) return { type: 'class', kind: 'class' }
+  if (/^(?:ref\s+|ptr\s+)?object\b/.test(body)) return { type: 'class', kind: 'struct' }
+  if (/^enum\b/.test(body)) return { type: 'class', kind: 'enum' }
+  if (/^concept\b/.test(body)) return { type: 'class', kind: 'interface' }
+  return { type: 'type', kind: 'type' }
+}
+
+/**
+ * The values of an enum, listed after `enum` on its line and on the lines below, separated by
+ * commas or newlines
+ */
+function readEnumValues(lines: string[], code: string[], first: number, last: number, container: string, visibility: SymbolVisibility): Definition[] {
+  const values: Definition[] = []
+  for (let index = first; index <= last; index++) {
+    const text = index === first ? code[index]!.replace(/^[\s\S]*?\benum\b/, '') : code[index]!
+    for (const part of text.split(',')) {
+      const name = part.match(/^\s*`?([A-Za-z_]\w*)`?\s*(?:=.*)?$/)?.[1]
+      if (!name) continue
+      values.push({ type: 'variant', kind: 'variant', name, startLine: index + 1, endLine: index + 1, signature: oneLine(lines[index]!.replace(/,\s*$/, '')), visibility, container, bodyLine: index + 2 })
+    }
+  }
+  return values
+}
+
+/**
+ * The last line of a routine header, which runs over lines while its parameter list is open
+ */
+function routineHeaderEnd(code: string[], first: number): number {
+  let depth = 0
+  for (let index = first; index < code.length; index++) {
+    for (const char of code[index]!) {
+      if (char === '(' || char === '[') depth++
+      else if (char === ')' || char === ']') depth--
+    }
+    if (depth <= 0) return index
+  }
+  return code.length - 1
+}
+
+/**
+ * Offset of the `=` opening a routine body in its header, or -1 for a forward declaration
+ */
+function topLevelAssignment(header: string): number {
+  let depth = 0
+  for (let index = 0; index < header.length; index++) {
+    const char = header[index]
+    if (char === '(' || char === '[' || char === '{') depth++
+    else if (char === ')' || char === ']' || char === '}') depth--
+    else if (char === '=' && depth === 0 && !/[=<>!:+\-*/]/.test(header[index - 1] ?? '') && header[index + 1] !== '=') return index
+  }
+  return -1
+}
+
+/**
+ * The last line of the block opened on line `first`: the lines after it that are blank or
+ * indented deeper than `indent`, without trailing blank lines
+ */
+function blockEnd(code: string[], first: number, indent: number): number {
+  let end = first
+  for (let index = first + 1; index < code.length; index++) {
+    const line = code[index]!
+    if (!line.trim()) continue
+    if (line.match(/^\s*/)![0].length <= indent) break
+    end = index
+  }
+  return end
+}
+
+/**
+ * The type a method dispatches on: its first parameter's, without `var`, `ref` or `ptr`
+ */
+function firstParameterType(header: string): string | undefined {
+  const parameters = header.match(/\(([^)]*)/)?.[1] ?? ''
+  return parameters.split(/[,;]/)[0]?.split(':')[1]?.trim().replace(/^(?:var|ref|ptr|sink|lent)\s+/, '').match(/^\w+/)?.[0]
+}
+
+/**
+ * The `##` lines opening a routine or type body, or directly above it
+ */
+function readDocComment(lines: string[], definition: Definition): string | undefined {
+  const comments: string[] = []
+  for (let index = definition.bodyLine - 1; index < definition.endLine && /^\s*##(?!\[)/.test(lines[index]!); index++) {
+    comments.push(lines[index]!.replace(/^\s*##\s?/, '').trimEnd())
+  }
+  if (comments.length === 0 && definition.type !== 'variant') {
+    for (let index = definition.startLine - 2; index >= 0 && /^\s*##(?!\[)/.test(lines[index]!); index--) {
+      comments.unshift(lines[index]!.replace(/^\s*##\s?/, '').trimEnd())
+    }
+  }
+  return comments.join('\n').trim() || undefined
+}
+
+/**
+ * Blanks comments (`#[ ]#` blocks nest), strings and character literals, keeping offsets and
+ * line numbers
+ */
+export function maskNim(content: string): string {
+  let output = ''
+  let index = 0
+  while (index < content.length) {
+    if (content.startsWith('#[', index) || content.startsWith('##[', index)) {
+      let depth = 0
+      let end = index
+      while (end < content.length) {
+        if (content.startsWith('#[', end)) {
+          depth++
+          end += 2
+        }
+        else if (content.startsWith(']#', end)) {
+          end += 2
+          if (--depth === 0) break
+        }
+        else {
+          end++
+        }
+      }
+      output += content.substring(index, end).replace(/[^\n]/g, ' ')
+      index = end
+      continue
+    }
+
+    LITERAL.lastIndex = index
+    const literal = '#"\'rR'.includes(content[index]!) ? LITERAL.exec(content) : null
+    if (literal && !(literal[0].startsWith("'") && /\w/.test(content[index - 1] ?? ''))) {
+      const text = literal[0]
+      if (text.startsWith('#')) output += text.replace(/[^\n]/g, ' ')
+      else {
+        const open = text.match(/^[rR]?(?:"""|"|')/)![0]
+        const close = open.replace(/^[rR]/, '')
+        output += open + text.slice(open.length, text.length - close.length).replace(/[^\n]/g, ' ') + close
+      }
+      index += text.length
+      continue
+    }
+
+    output += content[index]
+    index++
+  }
+  return output
+}
+
+
+function oneLine(text: string): string {
+  return text.replace(/\s+/g, ' ').trim()
+}
@@ -0,0 +1,109 @@
+/**
+ * Jupyter notebook support - indexes code cells with cell-aware locations
+ */
+
+import { parseContent } from './parser.js'
+import { getLanguageByName } from './languages.js'
+import { PARSER_NAMES } from '../constants/parsers.js'
+import type { TreeNode } from '../types/core.js'
+
+export interface NotebookCell {
+  index: number
+  source: string
+  scriptLine: number // First line of the cell's source in the generated script
+  lineCount: number
+}
+
+export interface NotebookScript {
+  language: string
+  script: string
+  cells: NotebookCell[]
+}
+
+/**
+ * Converts notebook JSON into a script of its code cells, in the same `# In[n]:` layout
+ * `jupyter nbconvert --to script` produces. IPython magics and shell escapes are commented
+ * out so the script parses as plain source.
+ */
+export function extractNotebookScript(raw: string): NotebookScript {
+  const notebook = JSON.parse(raw) as {
+    cells?: { cell_type?: string, source?: string | string[] }[]
+    metadata?: { kernelspec?: { language?: string }, language_info?: { name?: string } }
+  }
+
+  const language = (notebook.metadata?.kernelspec?.language || notebook.metadata?.language_info?.name || PARSER_NAMES.PYTHON).toLowerCase()
+  const lines: string[] = []
+  const cells: NotebookCell[] = []
+
+  for (const [index, cell] of (notebook.cells ?? []).entries()) {
+    if (cell.cell_type !== 'code') continue
+
+    const source = Array.isArray(cell.source) ? cell.source.join('') : cell.source ?? ''
+    const sourceLines = source.split('\n')
+
+    lines.push(`# In[${index}]:`, '')
+    cells.push({ index, source, scriptLine: lines.length + 1, lineCount: sourceLines.length })
+    lines.push(...sourceLines.map(line => /^\s*[%!]/.test(line) ? `# ${line}` : line), '')
+  }
+
+  return { language, script: lines.join('\n'), cells }
+}
+
+/**
+ * Parses a notebook into a file node whose children are one `cell` node per code cell plus
+ * the functions and classes defined in them. Element lines are relative to their cell.
+ */
+export function parseNotebook(raw: string, filePath: string): TreeNode {
+  const { language, script, cells } = extractNotebookScript(raw)
+  const languageConfig = getLanguageByName(language)
+
+  const fileNode: TreeNode = languageConfig
+    ? parseContent(script, filePath, languageConfig)
+    : { id: `file-${Date.now()}`, type: 'file', path: filePath, content: script, children: [] }
+
+  const elements = fileNode.children ?? []
+  for (const element of elements) {
+    const cell = findCellForLine(cells, element.startLine ?? 1)
+    if (!cell) continue
+    element.cell = cell.index
+    element.startLine = (element.startLine ?? cell.scriptLine) - cell.scriptLine + 1
+    element.endLine = (element.endLine ?? cell.scriptLine) - cell.scriptLine + 1
+  }
+
+  const cellNodes: TreeNode[] = cells.map(cell => ({
+    id: `cell-${Date.now()}-${cell.index}`,
+    type: 'cell',
+    name: `cell ${cell.index}`,
+    path: filePath,
+    startLine: 1,
+    endLine: cell.lineCount,
+    content: cell.source,
+    cell: cell.index,
+  }))
+
+  fileNode.children = [...cellNodes, ...elements]
+  return fileNode
+}
+
+/**
+ * Lists the functions and classes defined in each code cell of a parsed notebook
+ */
+export function getNotebookOutline(fileNode: TreeNode): { cell: number, lines: number, functions: string[], classes: string[] }[] {
+  const children = fileNode.children ?? []
+
+  return children
+    .filter(child => child.type === 'cell')
+    .map((cellNode) => {
+      const defined = children.filter(child => child.type !== 'cell' && child.cell === cellNode.cell)
+      return {
+        cell: cellNode.cell!,
+        lines: cellNode.endLine ?? 0,
+        functions: defined.filter(child => child.type === 'function').map(child => child.name ?? 'anonymous'),
+        classes: defined.filter(child => child.type === 'class').map(child => child.name ?? 'anonymous'),
+      }
+    })
+}
+
+function findCellForLine(cells: NotebookCell[], line: number): NotebookCell | undefined {
+  return cells.find(cell => line >= cell.scriptLine && line < cell.scriptLine + cell.lineCount)
+}
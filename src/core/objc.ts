@@ -0,0 +1,225 @@
+/**
+ * Objective-C - interfaces, implementations, categories, protocols and their methods and
+ * properties read from the source text. Methods are named by the first piece of their
+ * selector (`initWithName` for `initWithName:age:`), which is what message sends spell out.
+ */
+
+import type { SymbolKind, TreeNode } from '../types/core.js'
+
+interface Definition {
+  type: string
+  kind: SymbolKind
+  name: string
+  start: number
+  end: number
+  signature: string
+  container?: string
+}
+
+const MAX_SIGNATURE_LENGTH = 200
+
+const CONTAINER = /^[ \t]*@(interface|implementation|protocol)\s+(\w+)(?:\s*\(\s*\w*\s*\))?(?:\s*:\s*\w+)?(?:\s*<[^>]*>)?/gm
+const METHOD = /^[ \t]*([-+])\s*\(([^)]*(?:\([^)]*\)[^)]*)*)\)\s*(\w+)/gm
+const PROPERTY = /^[ \t]*@property\b[^;]*;/gm
+const ENUM = /^[ \t]*typedef\s+(?:NS_ENUM|NS_OPTIONS|NS_CLOSED_ENUM|NS_ERROR_ENUM|CF_ENUM|CF_OPTIONS)\s*\(\s*[\w\s]+,\s*(\w+)\s*\)/gm
+const FUNCTION = /^(?!\s)(?:(?:static|inline|extern|FOUNDATION_EXPORT|NS_INLINE)\s+)*[A-Za-z_][\w\s*]*?[\s*]([A-Za-z_]\w*)\s*\(([^;{}()]*)\)\s*\{/gm
+const KEYWORDS = new Set(['if', 'while', 'for', 'switch', 'return', 'sizeof', 'else'])
+const OBJC_DECLARATION = /^[ \t]*@(?:interface|protocol|implementation)\b/m
+
+/**
+ * `class` nodes for interfaces, class extensions, categories (named by the class they extend),
+ * implementations and `NS_ENUM` types, `interface` kind for protocols, `method` nodes with the
+ * class as `symbol.container`, `property` nodes, and `function` nodes for C functions. The
+ * selector is kept in the signature, with macros like `NS_SWIFT_NAME(...)`.
+ */
+export function extractObjCDefinitions(content: string, filePath: string): TreeNode[] {
+  const code = maskObjC(content)
+  const definitions = readDefinitions(content, code)
+  const inContainer = (offset: number) => definitions.some(definition => definition.type === 'class' && definition.kind !== 'enum' && definition.start < offset && offset < definition.end)
+
+  for (const match of code.matchAll(FUNCTION)) {
+    if (KEYWORDS.has(match[1]!) || inContainer(match.index!)) continue
+    const open = match.index! + match[0].length - 1
+    definitions.push({ type: 'function', kind: 'function', name: match[1]!, start: match.index!, end: closingBrace(code, open), signature: oneLine(content.substring(match.index!, open)) })
+  }
+
+  return toNodes(content, filePath, definitions)
+}
+
+/**
+ * The Objective-C declarations of a `.h` header, which is otherwise parsed as C: nothing for
+ * plain C headers, and no C functions, which the C grammar finds already
+ */
+export function extractObjCHeaderDefinitions(content: string, filePath: string): TreeNode[] {
+  if (!OBJC_DECLARATION.test(content)) return []
+  return toNodes(content, filePath, readDefinitions(content, maskObjC(content)))
+}
+
+function readDefinitions(content: string, code: string): Definition[] {
+  const definitions: Definition[] = []
+
+  for (const match of code.matchAll(CONTAINER)) {
+    // Categories and extensions keep the class name; the category shows in the signature
+    const [, keyword, name] = match
+    const headerEnd = match.index! + match[0].length
+    // `@protocol Name;` and `@class`-like forward declarations have no body
+    if (keyword === 'protocol' && /^\s*;/.test(code.substring(headerEnd))) continue
+    const endMatch = /^[ \t]*@end\b/m.exec(code.substring(headerEnd))
+    const end = endMatch ? headerEnd + endMatch.index + endMatch[0].length : code.length
+    const start = match.index! + match[0].search(/@/)
+    definitions.push({
+      type: 'class',
+      kind: keyword === 'protocol' ? 'interface' : 'class',
+      name: name!,
+      start,
+      end,
+      signature: oneLine(content.substring(start, headerEnd)),
+    })
+
+    const bodyStart = skipInstanceVariables(code, headerEnd)
+    const body = code.substring(bodyStart, end)
+    for (const method of body.matchAll(METHOD)) {
+      definitions.push(readMethod(content, code, bodyStart + method.index!, bodyStart + method.index! + method[0].length - method[3]!.length, name!))
+    }
+    for (const property of body.matchAll(PROPERTY)) {
+      const propertyStart = bodyStart + property.index! + property[0].search(/@/)
+      const propertyName = readPropertyName(property[0])
+      if (!propertyName) continue
+      definitions.push({
+        type: 'property',
+        kind: 'variable',
+        name: propertyName,
+        start: propertyStart,
+        end: bodyStart + property.index! + property[0].length,
+        signature: oneLine(content.substring(propertyStart, bodyStart + property.index! + property[0].length - 1)),
+        container: name,
+      })
+    }
+  }
+
+  for (const match of code.matchAll(ENUM)) {
+    const start = match.index! + match[0].search(/\S/)
+    const open = code.indexOf('{', match.index! + match[0].length)
+    const semicolon = code.indexOf(';', match.index! + match[0].length)
+    const end = open !== -1 && (semicolon === -1 || open < semicolon) ? closingBrace(code, open) : semicolon + 1
+    definitions.push({ type: 'class', kind: 'enum', name: match[1]!, start, end, signature: oneLine(content.substring(start, match.index! + match[0].length)) })
+  }
+
+  return definitions
+}
+
+/**
+ * A method declaration (`- (void)load:(id)a with:(id)b;`) or definition with its body
+ */
+function readMethod(content: string, code: string, start: number, selectorStart: number, container: string): Definition {
+  const offset = start + code.substring(start).search(/[-+]/)
+  const rest = code.substring(selectorStart)
+  const terminator = rest.search(/[;{]/)
+  const headerEnd = selectorStart + (terminator === -1 ? rest.length : terminator)
+  const end = code[headerEnd] === '{' ? closingBrace(code, headerEnd) : headerEnd + 1
+  const name = rest.match(/^\w+/)![0]
+  return { type: 'method', kind: 'method', name, start: offset, end, signature: oneLine(content.substring(offset, headerEnd)), container }
+}
+
+/**
+ * The selector of a method signature: `initWithName:age:` for
+ * `- (instancetype)initWithName:(NSString *)name age:(NSInteger)age`
+ */
+export function objcSelector(signature: string): string {
+  const selector = signature
+    .replace(/^[-+]\s*\((?:[^()]|\([^()]*\))*\)\s*/, '')
+    .replace(/\s+(?:NS_|API_|__attribute__|UI_|CF_)[\s\S]*$/, '')
+  const pieces = [...selector.matchAll(/(\w+)\s*:\s*(?:\((?:[^()]|\([^()]*\))*\))?\s*\w+/g)].map(match => `${match[1]}:`)
+  return pieces.length > 0 ? pieces.join('') : selector.match(/^\w+/)?.[0] ?? ''
+}
+
+function readPropertyName(declaration: string): string | undefined {
+  const text = declaration
+    .replace(/;\s*$/, '')
+    .replace(/^[\s\S]*?@property\s*(?:\([^)]*\))?/, '')
+    .replace(/\b(?:NS_|API_|UI_|__attribute__)\w*(?:\s*\((?:[^()]|\([^()]*\))*\))?/g, '')
+  // A block property names itself inside the type: void (^handler)(int)
+  return text.match(/\(\s*\^\s*(\w+)\s*\)/)?.[1] ?? text.match(/(\w+)\s*$/)?.[1]
+}
+
+/**
+ * Skips the `{ ... }` instance variable block that can follow an interface or implementation
+ * header
+ */
+function skipInstanceVariables(code: string, headerEnd: number): number {
+  const rest = code.substring(headerEnd).match(/^\s*\{/)
+  return rest ? closingBrace(code, headerEnd + rest[0].length - 1) : headerEnd
+}
+
+function toNodes(content: string, filePath: string, definitions: Definition[]): TreeNode[] {
+  const lines = content.split('\n')
+  return definitions
+    .sort((a, b) => a.start - b.start)
+    .map(definition => {
+      const startLine = lineAt(content, definition.start)
+      const endLine = lineAt(content, definition.end)
+      const doc = readDocComment(content, definition.start)
+      return {
+        id: `objc-${definition.type}-${filePath}-${startLine}-${definition.name}`,
+        type: definition.type,
+        name: definition.name,
+        path: filePath,
+        startLine,
+        endLine,
+        content: lines.slice(startLine - 1, endLine).join('\n'),
+        symbol: {
+          kind: definition.kind,
+          visibility: 'public',
+          signature: definition.signature.substring(0, MAX_SIGNATURE_LENGTH),
+          ...(definition.container ? { container: definition.container } : {}),
+          ...(doc ? { doc } : {}),
+        },
+      }
+    })
+}
+
+/**
+ * The `/** ... *\/` block or `///` lines directly above a declaration
+ */
+function readDocComment(content: string, start: number): string | undefined {
+  const before = content.substring(0, start).replace(/\s+$/, '')
+  const block = before.match(/\/\*\*((?:(?!\*\/)[\s\S])*)\*\/$/)
+  if (block) {
+    return block[1]!.split('\n').map(line => line.replace(/^\s*\*?\s?/, '').trimEnd()).join('\n').trim() || undefined
+  }
+
+  const lines = before.split('\n')
+  const comments: string[] = []
+  for (let index = lines.length - 1; index >= 0 && /^\s*\/\/\//.test(lines[index]!); index--) {
+    comments.unshift(lines[index]!.replace(/^\s*\/\/\/\s?/, '').trimEnd())
+  }
+  return comments.join('\n').trim() || undefined
+}
+
+function closingBrace(code: string, open: number): number {
+  let depth = 0
+  for (let index = open; index < code.length; index++) {
+    if (code[index] === '{') depth++
+    else if (code[index] === '}' && --depth === 0) return index + 1
+  }
+  return code.length
+}
+
+/**
+ * Blanks comments and the contents of C and `@"..."` strings and character literals, keeping
+ * offsets and line numbers
+ */
+export function maskObjC(content: string): string {
+  return content.replace(
+    /\/\/[^\n]*|\/\*[\s\S]*?\*\/|"(?:\\.|[^"\\\n])*"|'(?:\\.|[^'\\\n])*'/g,
+    match => match.startsWith('/') ? match.replace(/[^\n]/g, ' ') : match[0] + match.slice(1, -1).replace(/[^\n]/g, ' ') + match[0],
+  )
+}
+
+function oneLine(text: string): string {
+  return text.replace(/\s+/g, ' ').trim()
+}
+
+function lineAt(content: string, index: number): number {
+  return content.substring(0, index).split('\n').length
+}
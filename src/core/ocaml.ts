@@ -0,0 +1,351 @@
+/**
+ * OCaml - modules, module types, functions, values, types and classes read from the source
+ * text of implementations (.ml) and interfaces (.mli). Items are the `let`, `type`, `module`,
+ * `val`, ... declarations starting a line at the indentation of their structure; deeper ones
+ * are local bindings.
+ */
+
+import type { SymbolKind, TreeNode } from '../types/core.js'
+
+interface Definition {
+  type: string
+  kind: SymbolKind
+  name: string
+  start: number
+  end: number
+  signature: string
+  container?: string
+}
+
+interface Scope {
+  keyword: string
+  start: number // After the opening keyword
+  end: number // At `end`
+  indent?: number // Indentation of the scope's items, from its first one
+}
+
+interface Item {
+  keyword: string
+  start: number
+  end: number
+  scope?: Scope
+}
+
+const MAX_SIGNATURE_LENGTH = 200
+
+const ITEM = /^[ \t]*(let|and|type|module|class|exception|external|val|method)(?![\w'])/gm
+const SCOPE_KEYWORD = /(?<![\w'.])(struct|sig|object|begin|end)(?![\w'])/g
+const NAME = String.raw`(?:[a-z_][\w']*|\(\s*[!$%&*+./:<=>?@^|~-][!$%&*+./:<=>?@^|~-]*\s*\))`
+const LET = new RegExp(String.raw`^let(?:%[\w.]+)?(?:\s+rec)?(?:\s*\[@[^\]]*\])?\s+(${NAME})`)
+const AND = new RegExp(String.raw`^and(?:\s*\[@[^\]]*\])?\s+(${NAME})`)
+const TYPE = /^(?:type|and)(?:\s+nonrec)?\s+(?:'[\w']+\s+|[+-]?_\s+|\([^)]*\)\s*)?([a-z_][\w']*)/
+const MODULE = /^module(?:\s+rec)?\s+(type\s+)?([A-Z][\w']*)/
+const CLASS = /^(?:class|and)(?:\s+type)?(?:\s+virtual)?\s*(?:\[[^\]]*\]\s*)?([a-z_][\w']*)/
+const EXCEPTION = /^exception\s+([A-Z][\w']*)/
+const VALUE_DECLARATION = new RegExp(String.raw`^(?:val|external)\s+(${NAME})\s*:`)
+const METHOD = /^method!?(?:\s+private)?(?:\s+virtual)?\s+([a-z_][\w']*)/
+
+/**
+ * `module` nodes for modules, functors and module types, `function` nodes for functions, `val`
+ * declarations of function type and externals, `variable` nodes for other values, `class`
+ * nodes for types, exceptions and classes, `variant` nodes for constructors and `method` nodes
+ * for class methods. Nested declarations have their modules and classes as `symbol.container`,
+ * like `Map.Make`.
+ */
+export function extractOCamlDefinitions(content: string, filePath: string): TreeNode[] {
+  const code = maskOCaml(content)
+  const items = readItems(code)
+  const definitions: Definition[] = []
+
+  let previous: string | undefined
+  for (const item of items) {
+    const text = code.substring(item.start, item.end)
+    // `and` continues the declaration before it: `let rec f ... and g`, `type a ... and b`
+    const keyword = item.keyword === 'and' ? previous : item.keyword
+    if (item.keyword !== 'and') previous = item.keyword
+    const definition = keyword ? readItem(content, code, item, keyword, text) : []
+    definitions.push(...definition)
+  }
+
+  // Modules and classes contain the items within their extent
+  const containers = definitions.filter(definition => definition.type === 'module' || (definition.type === 'class' && definition.kind === 'class'))
+  for (const definition of definitions) {
+    const enclosing = containers.filter(other => other !== definition && other.start < definition.start && definition.start < other.end)
+    if (enclosing.length > 0 && !definition.container) definition.container = enclosing.map(other => other.name).join('.')
+    else if (enclosing.length > 0 && definition.type === 'variant') definition.container = `${enclosing.map(other => other.name).join('.')}.${definition.container}`
+  }
+
+  const lines = content.split('\n')
+  return definitions
+    .sort((a, b) => a.start - b.start)
+    .map(definition => toNode(content, lines, filePath, definition))
+}
+
+/**
+ * The module name OCaml gives a file: its base name, capitalized
+ */
+export function ocamlModuleName(filePath: string): string {
+  const base = filePath.split(/[/\\]/).pop()!.replace(/\.mli?$/, '')
+  return base.charAt(0).toUpperCase() + base.slice(1)
+}
+
+/**
+ * The structure items of a file: declarations starting a line at the indentation of the
+ * first item of their `struct`, `sig` or `object`, each running to the next item of the same
+ * structure or its `end`
+ */
+function readItems(code: string): Item[] {
+  const scopes: Scope[] = []
+  const open: Array<{ keyword: string, start: number }> = []
+  for (const match of code.matchAll(SCOPE_KEYWORD)) {
+    if (match[1] !== 'end') {
+      open.push({ keyword: match[1]!, start: match.index! + match[0].length })
+      continue
+    }
+    const opening = open.pop()
+    if (opening) scopes.push({ ...opening, end: match.index! })
+  }
+  // Innermost first, so the first scope containing an offset is the one it is in
+  scopes.sort((a, b) => (a.end - a.start) - (b.end - b.start))
+  const scopeAt = (offset: number) => scopes.find(scope => scope.start <= offset && offset < scope.end)
+
+  let fileIndent: number | undefined
+  const items: Item[] = []
+  for (const match of code.matchAll(ITEM)) {
+    const start = match.index! + match[0].length - match[1]!.length
+    const indent = start - match.index!
+    const scope = scopeAt(start)
+    // Bindings in `begin ... end` are expressions, as are those indented past the structure's items
+    if (scope?.keyword === 'begin') continue
+    if (match[1] === 'method' && scope?.keyword !== 'object') continue
+    if (scope) scope.indent ??= indent
+    else fileIndent ??= indent
+    if (indent !== (scope ? scope.indent : fileIndent)) continue
+    items.push({ keyword: match[1]!, start, end: scope ? scope.end : code.length, scope })
+  }
+
+  // Each item runs until the next one in the same structure
+  for (let index = 0; index < items.length; index++) {
+    const item = items[index]!
+    const next = items.slice(index + 1).find(other => other.scope === item.scope)
+    if (next) item.end = next.start
+    item.end = item.start + code.substring(item.start, item.end).replace(/(?:\s|;;)+$/, '').length
+  }
+  return items
+}
+
+function readItem(content: string, code: string, item: Item, keyword: string, text: string): Definition[] {
+  const definition = (type: string, kind: SymbolKind, name: string, signatureEnd = item.end): Definition => ({
+    type, kind, name, start: item.start, end: item.end, signature: oneLine(content.substring(item.start, signatureEnd)),
+  })
+
+  switch (keyword) {
+    case 'let': {
+      const match = text.match(item.keyword === 'and' ? AND : LET)
+      if (!match) return []
+      const name = match[1]!.replace(/\s+/g, '')
+      const equals = bindingEquals(text, match[0].length)
+      const params = text.substring(match[0].length, equals === -1 ? undefined : equals).replace(/:[\s\S]*$/, '')
+      const body = equals === -1 ? '' : text.substring(equals + 1)
+      const isFunction = /\S/.test(params) || /^\s*(?:fun|function)\b/.test(body)
+      return [definition(isFunction ? 'function' : 'variable', isFunction ? 'function' : 'variable', name, equals === -1 ? item.end : item.start + equals)]
+    }
+
+    case 'type': {
+      const match = text.match(TYPE)
+      if (!match) return []
+      const equals = bindingEquals(text, match[0].length)
+      const body = equals === -1 ? '' : text.substring(equals + 1).replace(/^\s*(?:private\s+)?/, '')
+      const constructors = readConstructors(content, item.start + equals + 1 + (text.length - equals - 1 - body.length), body, match[1]!)
+      const kind: SymbolKind = body.startsWith('{') ? 'struct' : constructors.length > 0 ? 'enum' : 'type'
+      return [definition('class', kind, match[1]!, constructors.length > 0 || body.startsWith('{') ? item.start + equals : item.end), ...constructors]
+    }
+
+    case 'module': {
+      const match = text.match(MODULE)
+      if (!match) return []
+      // The header runs to `=` (or `:` for a signature), before `struct`/`sig`
+      const body = text.search(/(?<![\w'])(?:struct|sig)(?![\w'])|=/)
+      return [definition('module', match[1] ? 'interface' : 'module', match[2]!, body === -1 ? item.end : item.start + body)]
+    }
+
+    case 'class': {
+      const match = text.match(CLASS)
+      if (!match) return []
+      const equals = bindingEquals(text, match[0].length)
+      return [definition('class', 'class', match[1]!, equals === -1 ? item.end : item.start + equals)]
+    }
+
+    case 'exception': {
+      const match = text.match(EXCEPTION)
+      return match ? [definition('class', 'type', match[1]!)] : []
+    }
+
+    case 'val':
+    case 'external': {
+      // In objects, `val` declares an instance variable
+      if (keyword === 'val' && item.scope?.keyword === 'object') return []
+      const match = text.match(VALUE_DECLARATION)
+      if (!match) return []
+      const isFunction = keyword === 'external' || /->/.test(text)
+      return [definition(isFunction ? 'function' : 'variable', isFunction ? 'function' : 'variable', match[1]!.replace(/\s+/g, ''))]
+    }
+
+    case 'method': {
+      const match = text.match(METHOD)
+      if (!match) return []
+      const equals = bindingEquals(text, match[0].length)
+      return [definition('method', 'method', match[1]!, equals === -1 ? item.end : item.start + equals)]
+    }
+
+    default:
+      return []
+  }
+}
+
+/**
+ * The constructors of a variant type body (`A | B of int`, optionally starting with `|`)
+ */
+function readConstructors(content: string, offset: number, body: string, typeName: string): Definition[] {
+  if (!/^\|?\s*[A-Z]/.test(body)) return []
+  const constructors: Definition[] = []
+  let depth = 0
+  let partStart = 0
+  for (let index = 0; index <= body.length; index++) {
+    const char = body[index]
+    if (char === '(' || char === '{' || char === '[') depth++
+    else if (char === ')' || char === '}' || char === ']') depth--
+    else if ((char === '|' && depth === 0) || index === body.length) {
+      const part = body.substring(partStart, index)
+      const match = part.match(/^(\s*)([A-Z][\w']*)/)
+      if (match) {
+        const start = offset + partStart + match[1]!.length
+        const end = offset + partStart + part.trimEnd().length
+        constructors.push({ type: 'variant', kind: 'variant', name: match[2]!, start, end, signature: oneLine(content.substring(start, end)), container: typeName })
+      }
+      partStart = index + 1
+    }
+  }
+  return constructors
+}
+
+/**
+ * The offset of a binding's `=`, skipping those inside parentheses (`?(x = 1)`) and
+ * comparison operators
+ */
+function bindingEquals(text: string, from: number): number {
+  let depth = 0
+  for (let index = from; index < text.length; index++) {
+    const char = text[index]
+    if (char === '(' || char === '[' || char === '{') depth++
+    else if (char === ')' || char === ']' || char === '}') depth--
+    else if (char === '=' && depth === 0 && !/[!$%&*+./:<=>?@^|~-]/.test(text[index - 1] ?? '') && !/[!$%&*+./:<=>?@^|~-]/.test(text[index + 1] ?? '')) return index
+  }
+  return -1
+}
+
+function toNode(content: string, lines: string[], filePath: string, definition: Definition): TreeNode {
+  const startLine = lineAt(content, definition.start)
+  const endLine = lineAt(content, definition.end)
+  const doc = readDocComment(content, definition.start)
+  return {
+    id: `ocaml-${definition.type}-${filePath}-${startLine}-${definition.name}`,
+    type: definition.type,
+    name: definition.name,
+    path: filePath,
+    startLine,
+    endLine,
+    content: lines.slice(startLine - 1, endLine).join('\n'),
+    symbol: {
+      kind: definition.kind,
+      visibility: 'public',
+      signature: definition.signature,
+      ...(definition.container ? { container: definition.container } : {}),
+      ...(doc ? { doc } : {}),
+    },
+  }
+}
+
+/**
+ * The `(** ... *)` comment directly above a declaration
+ */
+function readDocComment(content: string, start: number): string | undefined {
+  const before = content.substring(0, start).replace(/\s+$/, '')
+  const block = before.match(/\(\*\*(?!\*)((?:(?!\*\))[\s\S])*)\*\)$/)
+  if (!block) return undefined
+  return block[1]!.split('\n').map(line => line.trim()).join('\n').trim() || undefined
+}
+
+/**
+ * Blanks comments (which nest), strings, quoted strings (`{id|...|id}`) and character
+ * literals, keeping offsets and line numbers. A `'` starting a type variable (`'a`) is kept.
+ */
+export function maskOCaml(content: string): string {
+  const chars = content.split('')
+  const blank = (from: number, to: number) => {
+    for (let index = from; index < to; index++) if (chars[index] !== '\n') chars[index] = ' '
+  }
+
+  let index = 0
+  while (index < content.length) {
+    if (content.startsWith('(*', index) && content[index + 2] !== ')') {
+      let depth = 0
+      let end = index
+      while (end < content.length) {
+        if (content.startsWith('(*', end)) {
+          depth++
+          end += 2
+        }
+        else if (content.startsWith('*)', end)) {
+          end += 2
+          if (--depth === 0) break
+        }
+        else if (content[end] === '"') {
+          end = stringEnd(content, end)
+        }
+        else {
+          end++
+        }
+      }
+      blank(index, end)
+      index = end
+    }
+    else if (content[index] === '"') {
+      const end = stringEnd(content, index)
+      blank(index + 1, end - 1)
+      index = end
+    }
+    else if (content[index] === '{' && /^\{[a-z_]*\|/.test(content.substring(index, index + 20))) {
+      const id = content.substring(index + 1, content.indexOf('|', index))
+      const close = content.indexOf(`|${id}}`, index)
+      const end = close === -1 ? content.length : close + id.length + 2
+      blank(index + 1, end - 1)
+      index = end
+    }
+    else if (content[index] === "'" && !/[\w']/.test(content[index - 1] ?? '')) {
+      const literal = content.substring(index).match(/^'(?:\\(?:[\\"'ntbr ]|\d{3}|x[0-9a-fA-F]{2}|o[0-7]{3})|[^\\'\n])'/)
+      if (literal) blank(index + 1, index + literal[0].length - 1)
+      index += literal ? literal[0].length : 1
+    }
+    else {
+      index++
+    }
+  }
+  return chars.join('')
+}
+
+function stringEnd(content: string, open: number): number {
+  for (let index = open + 1; index < content.length; index++) {
+    if (content[index] === '\\') index++
+    else if (content[index] === '"') return index + 1
+  }
+  return content.length
+}
+
+function oneLine(text: string): string {
+  return text.replace(/\s+/g, ' ').trim().substring(0, MAX_SIGNATURE_LENGTH)
+}
+
+function lineAt(content: string, index: number): number {
+  return content.substring(0, index).split('\n').length
+}
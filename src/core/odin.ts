@@ -0,0 +1,218 @@
+/**
+ * Odin - procedures and types read from the source text. Every declaration is a constant
+ * binding (`name :: proc(...)`, `Name :: struct {...}`), so nodes are named by the binding.
+ */
+
+import type { SymbolKind, SymbolVisibility, TreeNode } from '../types/core.js'
+
+interface Definition {
+  type: string
+  kind: SymbolKind
+  name: string
+  visibility: SymbolVisibility
+  start: number
+  end: number
+  signature: string
+  doc?: string
+  container?: string
+  scope?: { start: number, end: number } // Body whose declarations this one contains
+}
+
+const MAX_SIGNATURE_LENGTH = 200
+
+const DECLARATION = /^([ \t]*(?:@(?:\([^)]*\)|\w+)[ \t]*)*)(\w+)[ \t]*::[ \t]*(#type[ \t]+)?(?:#\w+[ \t]+)*(proc|struct|union|enum|bit_field|bit_set|distinct)\b/gm
+const FOREIGN_BLOCK = /^[ \t]*(?:@\([^)]*\)\s*)*foreign[ \t]+(\w+)?[ \t]*\{/gm
+const ATTRIBUTE = /@\(([^)]*)\)|@(\w+)/g
+const FILE_PRIVATE = /^\s*(?:#\+|\/\/\+)private\b/m
+const TYPE_KINDS: Record<string, SymbolKind> = { struct: 'struct', union: 'type', enum: 'enum', bit_field: 'struct', bit_set: 'type', distinct: 'type' }
+
+/**
+ * `function` nodes for procedures and procedure groups, and `class` nodes for struct, union,
+ * enum, bit_set and distinct types. `@(private)` declarations, and all of a file tagged
+ * `#+private`, are private. Procedures in a `foreign` block are contained by the foreign
+ * library.
+ */
+export function extractOdinDefinitions(content: string, filePath: string): TreeNode[] {
+  const code = maskOdin(content)
+  const braces = matchBraces(code)
+  const filePrivate = FILE_PRIVATE.test(content)
+  const definitions: Definition[] = []
+
+  for (const match of code.matchAll(FOREIGN_BLOCK)) {
+    const open = match.index! + match[0].length - 1
+    const close = braces.get(open)
+    if (close !== undefined && match[1]) {
+      definitions.push({ type: 'foreign', kind: 'module', name: match[1], visibility: 'private', start: match.index!, end: close + 1, signature: '', scope: { start: open, end: close } })
+    }
+  }
+
+  for (const match of code.matchAll(DECLARATION)) {
+    const start = match.index! + match[1]!.length
+    const keywordEnd = match.index! + match[0].length
+    // `#type proc(...)` declares a procedure type rather than a procedure
+    const definition = match[4] === 'proc' && !match[3]
+      ? readProcedure(content, code, braces, start, keywordEnd)
+      : readType(content, code, braces, start, keywordEnd, match[3] ? 'distinct' : match[4]!)
+
+    const attributes = readAttributes(content, start)
+    const isPrivate = filePrivate || attributes.some(attribute => /^private\b/.test(attribute))
+    definitions.push({
+      ...definition,
+      name: match[2]!,
+      visibility: isPrivate ? 'private' : 'public',
+      doc: readDocComment(content, start),
+    })
+  }
+
+  definitions.sort((a, b) => a.start - b.start)
+  for (const definition of definitions) {
+    const enclosing = definitions.filter(other => other.scope && other !== definition
+      && other.scope.start < definition.start && definition.start < other.scope.end)
+    if (enclosing.length > 0) definition.container = enclosing.map(other => other.name).join('.')
+  }
+
+  const lines = content.split('\n')
+  return definitions
+    .filter(definition => definition.type !== 'foreign')
+    .map(definition => toNode(content, lines, filePath, definition))
+}
+
+/**
+ * `proc(params) -> result { ... }`, a bodiless foreign `proc(...) ---`, or a procedure group
+ * `proc{a, b}`
+ */
+function readProcedure(content: string, code: string, braces: Map<number, number>, start: number, keywordEnd: number): Omit<Definition, 'name' | 'visibility'> {
+  const rest = code.substring(keywordEnd)
+  if (/^\s*\{/.test(rest)) {
+    const open = keywordEnd + rest.indexOf('{')
+    const end = (braces.get(open) ?? open) + 1
+    return { type: 'function', kind: 'function', start, end, signature: oneLine(content.substring(start, end)) }
+  }
+
+  // Skip the calling convention string (`proc "c" (...)`), then the parameters
+  const paramsOpen = code.indexOf('(', keywordEnd)
+  const paramsEnd = paramsOpen === -1 ? keywordEnd : closingParen(code, paramsOpen)
+  const header = code.substring(paramsEnd).match(/^[^{\n]*?(?=\{|---|\n|$)/)![0]
+  const open = paramsEnd + header.length
+  const close = code[open] === '{' ? braces.get(open) : undefined
+
+  return {
+    type: 'function',
+    kind: 'function',
+    start,
+    end: close === undefined ? open : close + 1,
+    signature: oneLine(content.substring(start, open)),
+    ...(close !== undefined ? { scope: { start: open, end: close } } : {}),
+  }
+}
+
+function readType(content: string, code: string, braces: Map<number, number>, start: number, keywordEnd: number, keyword: string): Omit<Definition, 'name' | 'visibility'> {
+  // Everything up to the body brace is the header: polymorphic parameters, backing types, tags
+  const header = code.substring(keywordEnd).match(/^[^{\n]*/)![0]
+  const open = keywordEnd + header.length
+  const close = code[open] === '{' && keyword !== 'distinct' && keyword !== 'bit_set' ? braces.get(open) : undefined
+
+  return {
+    type: 'class',
+    kind: TYPE_KINDS[keyword]!,
+    start,
+    end: close === undefined ? open : close + 1,
+    signature: oneLine(content.substring(start, open)),
+  }
+}
+
+function toNode(content: string, lines: string[], filePath: string, definition: Definition): TreeNode {
+  const startLine = lineAt(content, definition.start)
+  const endLine = lineAt(content, definition.end)
+  return {
+    id: `odin-${definition.type}-${filePath}-${startLine}-${definition.name}`,
+    type: definition.type,
+    name: definition.name,
+    path: filePath,
+    startLine,
+    endLine,
+    content: lines.slice(startLine - 1, endLine).join('\n'),
+    symbol: {
+      kind: definition.kind,
+      visibility: definition.visibility,
+      signature: definition.signature,
+      ...(definition.container ? { container: definition.container } : {}),
+      ...(definition.doc ? { doc: definition.doc } : {}),
+    },
+  }
+}
+
+/**
+ * The attributes on the lines directly above a declaration, like `@(private="file")` or
+ * `@(require_results)`, one entry per comma-separated attribute
+ */
+function readAttributes(content: string, start: number): string[] {
+  const lines = content.substring(0, start).split('\n')
+  const attributes: string[] = []
+  const read = (line: string) => {
+    for (const match of line.matchAll(ATTRIBUTE)) {
+      attributes.push(...(match[1] ?? match[2]!).split(',').map(attribute => attribute.trim()))
+    }
+  }
+
+  read(lines.pop()!)
+  for (let index = lines.length - 1; index >= 0 && /^\s*@/.test(lines[index]!); index--) read(lines[index]!)
+  return attributes
+}
+
+/**
+ * The `//` comment lines directly above a declaration and its attributes
+ */
+function readDocComment(content: string, start: number): string | undefined {
+  const lines = content.substring(0, start).split('\n').slice(0, -1)
+  let index = lines.length - 1
+  while (index >= 0 && /^\s*@/.test(lines[index]!)) index--
+
+  const comments: string[] = []
+  for (; index >= 0 && /^\s*\/\/(?!\+)/.test(lines[index]!); index--) {
+    comments.unshift(lines[index]!.replace(/^\s*\/\/+\s?/, '').trimEnd())
+  }
+  return comments.join('\n').trim() || undefined
+}
+
+function closingParen(code: string, open: number): number {
+  let depth = 0
+  for (let index = open; index < code.length; index++) {
+    if (code[index] === '(') depth++
+    else if (code[index] === ')' && --depth === 0) return index + 1
+  }
+  return code.length
+}
+
+function matchBraces(code: string): Map<number, number> {
+  const braces = new Map<number, number>()
+  const open: number[] = []
+  for (let index = 0; index < code.length; index++) {
+    if (code[index] === '{') {
+      open.push(index)
+    }
+    else if (code[index] === '}') {
+      const opening = open.pop()
+      if (opening !== undefined) braces.set(opening, index)
+    }
+  }
+  return braces
+}
+
+/**
+ * Blanks comments, strings, raw strings and rune literals, keeping offsets and line numbers
+ */
+export function maskOdin(content: string): string {
+  return content.replace(
+    /\/\/[^\n]*|\/\*[\s\S]*?\*\/|"(?:\\.|[^"\\\n])*"|`[^`]*`|'(?:\\[^'\n]{1,10}|[^'\\\n])'/g,
+    match => match.replace(/[^\n]/g, ' '),
+  )
+}
+
+function oneLine(text: string): string {
+  return text.replace(/\s+/g, ' ').trim().substring(0, MAX_SIGNATURE_LENGTH)
+}
+
+function lineAt(content: string, index: number): number {
+  return content.substring(0, index).split('\n').length
+}
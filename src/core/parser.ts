@@ -4,11 +4,15 @@
 
 import Parser from 'tree-sitter'
 import { readFileSync, statSync } from 'fs'
-import { extname } from 'path'
 import { createError } from '../utils/errors.js'
 import { getLogger } from '../utils/logger.js'
-import { getParser, getLanguageByExtension } from './languages.js'
+import { getParser, getLanguageByExtension, getLanguageForFile } from './languages.js'
 import { PARSER_LIMITS, PARSER_NAMES } from '../constants/parsers.js'
+import { isFallbackIndexable, isNotebookFile } from '../constants/file-types.js'
+import { parseNotebook } from './notebook.js'
+import { extractFallbackDefinitions } from './fallback.js'
+import { describeSyntaxSymbol } from './symbols.js'
+import { declaratorName } from './cpp.js'
 import type { TreeNode, LanguageConfig } from '../types/core.js'
 
 /**
@@ -18,8 +22,7 @@ export async function parseFile(filePath: string): Promise<TreeNode> {
   const logger = getLogger()
 
   try {
-    const extension = extname(filePath)
-    const languageConfig = getLanguageByExtension(extension)
+    const languageConfig = getLanguageForFile(filePath)
 
     if (languageConfig?.name === PARSER_NAMES.KOTLIN) {
       const fileSize = statSync(filePath).size
@@ -39,15 +42,16 @@ export async function parseFile(filePath: string): Promise<TreeNode> {
     }
 
     const rawContent = readFileSync(filePath, 'utf-8')
+
+    // Notebook JSON is parsed whole; truncating embedded outputs would corrupt it
+    if (isNotebookFile(filePath)) {
+      return parseNotebook(rawContent, filePath)
+    }
+
     const content = truncateLongLines(rawContent, 1000)
 
     if (!languageConfig) {
-      return {
-        id: `file-${Date.now()}`,
-        type: 'file',
-        path: filePath,
-        content,
-      }
+      return fallbackFileNode(content, filePath)
     }
 
     return parseContent(content, filePath, languageConfig)
@@ -62,26 +66,20 @@ export async function parseFile(filePath: string): Promise<TreeNode> {
  * Parses content string and extracts tree elements
  */
 export function parseContent(content: string, filePath: string, language?: LanguageConfig): TreeNode {
-  const extension = extname(filePath)
-  const languageConfig = language || getLanguageByExtension(extension)
+  const languageConfig = language || getLanguageForFile(filePath)
 
   if (!languageConfig) {
-    return {
-      id: `file-${Date.now()}`,
-      type: 'file',
-      path: filePath,
-      content,
-    }
+    return fallbackFileNode(content, filePath)
   }
 
   try {
     const parser = getParser(languageConfig.name)
-    if (!parser) {
+    if (!parser && !languageConfig.optional) {
       throw new Error(`Parser not available for ${languageConfig.name}`)
     }
 
-    const tree = parser.parse(content)
-    const rootNode = tree.rootNode
+    const source = languageConfig.preprocess ? languageConfig.preprocess(content) : content
+    const rootNode = parser?.parse(source).rootNode
 
     const fileNode: TreeNode = {
       id: `file-${Date.now()}`,
@@ -92,7 +90,22 @@ export function parseContent(content: string, filePath: string, language?: Langu
       rawNode: rootNode, // Preserve raw tree-sitter node for error detection
     }
 
-    extractElements(rootNode, content, filePath, languageConfig, fileNode)
+    if (languageConfig.extractElements) {
+      fileNode.children = languageConfig.extractElements(content, filePath)
+    }
+    else if (rootNode) {
+      extractElements(rootNode, source, filePath, languageConfig, fileNode)
+    }
+
+    if (languageConfig.extraElements) {
+      const extra = languageConfig.extraElements(content, filePath)
+      // A macro invocation the grammar took for a declaration is replaced by what it declares
+      const invocations = new Set(extra.filter(node => node.symbol?.macro).map(node => `${node.symbol!.macro}:${node.startLine}`))
+      fileNode.children = [
+        ...(fileNode.children ?? []).filter(node => !invocations.has(`${node.name}:${node.startLine}`)),
+        ...extra,
+      ]
+    }
 
     return fileNode
   }
@@ -101,6 +114,21 @@ export function parseContent(content: string, filePath: string, language?: Langu
   }
 }
 
+/**
+ * A file no language claims, with low-confidence elements guessed from its lines unless it is
+ * data or prose
+ */
+function fallbackFileNode(content: string, filePath: string): TreeNode {
+  const children = isFallbackIndexable(filePath) ? extractFallbackDefinitions(content, filePath) : []
+  return {
+    id: `file-${Date.now()}`,
+    type: 'file',
+    path: filePath,
+    content,
+    ...(children.length > 0 ? { children } : {}),
+  }
+}
+
 function extractElements(
   node: Parser.SyntaxNode,
   content: string,
@@ -109,37 +137,49 @@ function extractElements(
   parent: TreeNode,
 ): void {
   if (language.functionTypes.includes(node.type)) {
-    const functionNode = extractFunction(node, content, filePath)
+    const functionNode = extractFunction(node, content, filePath, language)
     if (functionNode) {
       parent.children?.push(functionNode)
     }
   }
 
   if (language.classTypes.includes(node.type)) {
-    const classNode = extractClass(node, content, filePath)
+    const classNode = extractClass(node, content, filePath, language)
     if (classNode) {
       parent.children?.push(classNode)
     }
   }
 
+  if (language.variableTypes?.includes(node.type)) {
+    const variableNode = extractVariable(node, content, filePath, language)
+    if (variableNode) {
+      parent.children?.push(variableNode)
+    }
+  }
+
   for (const child of node.children) {
     extractElements(child, content, filePath, language, parent)
   }
 }
 
-function extractFunction(node: Parser.SyntaxNode, content: string, filePath: string): TreeNode | null {
+function extractFunction(node: Parser.SyntaxNode, content: string, filePath: string, language: LanguageConfig): TreeNode | null {
   try {
     if (node.type === 'arrow_function' && isCallbackArrowFunction(node, content)) {
       return null
     }
 
-    const name = getFunctionName(node, content)
+    // A C/C++ definition's declarator, and function pointers inside it, belong to the definition
+    if (node.type === 'function_declarator' && hasAncestor(node, 'function_definition')) {
+      return null
+    }
+
+    const name = getFunctionName(node, content) || 'anonymous'
     const parameters = extractParameters(node, content)
 
     return {
       id: `func-${Date.now()}-${Math.random().toString(36).substr(2, 9)}`,
       type: 'function',
-      name: name || 'anonymous',
+      name,
       path: filePath,
       startLine: node.startPosition.row + 1,
       endLine: node.endPosition.row + 1,
@@ -147,6 +187,7 @@ function extractFunction(node: Parser.SyntaxNode, content: string, filePath: str
       endColumn: node.endPosition.column,
       content: content.substring(node.startIndex, node.endIndex),
       parameters,
+      symbol: describeSyntaxSymbol(node, content, language, 'function', name),
     }
   }
   catch {
@@ -154,14 +195,14 @@ function extractFunction(node: Parser.SyntaxNode, content: string, filePath: str
   }
 }
 
-function extractClass(node: Parser.SyntaxNode, content: string, filePath: string): TreeNode | null {
+function extractClass(node: Parser.SyntaxNode, content: string, filePath: string, language: LanguageConfig): TreeNode | null {
   try {
-    const name = getClassName(node, content)
+    const name = getClassName(node, content) || 'anonymous'
 
     return {
       id: `class-${Date.now()}-${Math.random().toString(36).substr(2, 9)}`,
       type: 'class',
-      name: name || 'anonymous',
+      name,
       path: filePath,
       startLine: node.startPosition.row + 1,
       endLine: node.endPosition.row + 1,
@@ -169,6 +210,7 @@ function extractClass(node: Parser.SyntaxNode, content: string, filePath: string
       endColumn: node.endPosition.column,
       content: content.substring(node.startIndex, node.endIndex),
       children: [],
+      symbol: describeSyntaxSymbol(node, content, language, 'class', name),
     }
   }
   catch {
@@ -176,12 +218,37 @@ function extractClass(node: Parser.SyntaxNode, content: string, filePath: string
   }
 }
 
+function extractVariable(node: Parser.SyntaxNode, content: string, filePath: string, language: LanguageConfig): TreeNode | null {
+  const nameNode = node.childForFieldName('name')
+  if (!nameNode) return null
+  const name = content.substring(nameNode.startIndex, nameNode.endIndex)
+
+  return {
+    id: `var-${Date.now()}-${Math.random().toString(36).substr(2, 9)}`,
+    type: 'variable',
+    name,
+    path: filePath,
+    startLine: node.startPosition.row + 1,
+    endLine: node.endPosition.row + 1,
+    startColumn: node.startPosition.column,
+    endColumn: node.endPosition.column,
+    content: content.substring(node.startIndex, node.endIndex),
+    symbol: describeSyntaxSymbol(node, content, language, 'variable', name),
+  }
+}
+
 function getFunctionName(node: Parser.SyntaxNode, content: string): string | null {
   const nameNode = node.childForFieldName('name')
   if (nameNode) {
     return content.substring(nameNode.startIndex, nameNode.endIndex)
   }
 
+  // C and C++ name functions through nested declarators, e.g. `int *Cache::get(int key)`
+  const declared = declaratorName(node, content)
+  if (declared) {
+    return declared.name
+  }
+
   for (const child of node.children) {
     if (child.type === 'identifier' || child.type === 'simple_identifier') {
       return content.substring(child.startIndex, child.endIndex)
@@ -226,6 +293,13 @@ function getClassName(node: Parser.SyntaxNode, content: string): string | null {
   return null
 }
 
+function hasAncestor(node: Parser.SyntaxNode, type: string): boolean {
+  for (let ancestor = node.parent; ancestor; ancestor = ancestor.parent) {
+    if (ancestor.type === type) return true
+  }
+  return false
+}
+
 function isCallbackArrowFunction(node: Parser.SyntaxNode, content: string): boolean {
   if (!node.parent) return false
 
@@ -0,0 +1,327 @@
+/**
+ * C preprocessor conditions - the `#if`/`#ifdef`/`#elif`/`#else` regions of C, C++ and
+ * Objective-C files, so declarations compiled only under some defines carry their guard and
+ * searches can be limited to what a build with given defines would see
+ */
+
+import { LOGIC_EXTENSIONS } from '../constants/file-types.js'
+import { maskSource } from './strings.js'
+import type { TreeNode } from '../types/core.js'
+
+const EXTENSIONS: readonly string[] = [...LOGIC_EXTENSIONS.C, ...LOGIC_EXTENSIONS.CPP, ...LOGIC_EXTENSIONS.OBJC, '.hh', '.inl', '.ipp', '.tpp']
+
+export interface ConditionalRegion {
+  condition: string // Condition of this branch, earlier branches of the chain negated
+  line: number // The `#if`, `#elif` or `#else` line
+  endLine: number // Last line of the branch, before the next branch or `#endif`
+}
+
+export interface PreprocessorVariant {
+  path: string
+  line?: number
+  guard?: string
+}
+
+export interface PreprocessorInfo {
+  guard?: string
+  variants: PreprocessorVariant[] // Other declarations of the same name and kind
+}
+
+// Binary operators from the loosest binding to the tightest
+const PRECEDENCE = [['||'], ['&&'], ['|'], ['^'], ['&'], ['==', '!='], ['<', '<=', '>', '>='], ['<<', '>>'], ['+', '-'], ['*', '/', '%']]
+
+interface OpenChain {
+  conditions: string[] // Branch conditions seen so far, un-negated
+  region?: ConditionalRegion
+  includeGuard?: boolean
+}
+
+/**
+ * Whether a path is a C, C++ or Objective-C source or header
+ */
+export function isPreprocessedFile(path: string): boolean {
+  return EXTENSIONS.some(extension => path.endsWith(extension))
+}
+
+/**
+ * Conditional regions of a file, innermost last. A header's include guard
+ * (`#ifndef X_H` / `#define X_H` around the whole file) is not a condition and is skipped.
+ */
+export function readConditionalRegions(content: string, file = 'source.c'): ConditionalRegion[] {
+  const lines = maskSource(content, file).split('\n')
+  const directives: { line: number, keyword: string, argument: string }[] = []
+  for (let index = 0; index < lines.length; index++) {
+    const start = index
+    let text = lines[index]!
+    while (text.endsWith('\\') && index + 1 < lines.length) text = text.slice(0, -1) + ' ' + lines[++index]!
+    const match = text.match(/^\s*#\s*(\w+)\s*(.*)$/)
+    if (match) directives.push({ line: start + 1, keyword: match[1]!, argument: match[2]!.trim().replace(/\s+/g, ' ') })
+  }
+
+  const regions: ConditionalRegion[] = []
+  const stack: OpenChain[] = []
+  const close = (chain: OpenChain | undefined, line: number) => {
+    if (chain?.region) {
+      chain.region.endLine = line - 1
+      regions.push(chain.region)
+    }
+  }
+
+  directives.forEach((directive, index) => {
+    const { keyword, argument, line } = directive
+    if (keyword === 'if' || keyword === 'ifdef' || keyword === 'ifndef') {
+      const condition = keyword === 'if' ? argument : `${keyword === 'ifndef' ? '!' : ''}defined(${argument})`
+      const next = directives[index + 1]
+      const includeGuard = index === 0 && keyword === 'ifndef' && next?.keyword === 'define' && next.argument === argument
+        && isClosedLast(directives, index)
+      stack.push({ conditions: [condition], includeGuard, ...(includeGuard ? {} : { region: { condition, line, endLine: line } }) })
+    }
+    else if (keyword === 'elif' || keyword === 'elifdef' || keyword === 'elifndef' || keyword === 'else') {
+      const chain = stack.at(-1)
+      if (!chain || chain.includeGuard) return
+      close(chain, line)
+      const branch = keyword === 'elif' ? argument : keyword === 'else' ? undefined : `${keyword === 'elifndef' ? '!' : ''}defined(${argument})`
+      const condition = joinConditions([...chain.conditions.map(negate), ...(branch ? [branch] : [])])
+      if (branch) chain.conditions.push(branch)
+      chain.region = { condition, line, endLine: line }
+    }
+    else if (keyword === 'endif') {
+      close(stack.pop(), line)
+    }
+  })
+  while (stack.length > 0) close(stack.pop(), lines.length + 1)
+
+  return regions.sort((a, b) => a.line - b.line)
+}
+
+// The include guard's `#endif` is the file's last directive
+function isClosedLast(directives: { keyword: string }[], opening: number): boolean {
+  let depth = 0
+  for (let index = opening; index < directives.length; index++) {
+    const { keyword } = directives[index]!
+    if (keyword === 'if' || keyword === 'ifdef' || keyword === 'ifndef') depth++
+    else if (keyword === 'endif' && --depth === 0) return index === directives.length - 1
+  }
+  return false
+}
+
+/**
+ * Combined guard of the regions enclosing a line, or undefined outside any
+ */
+export function guardAt(regions: ConditionalRegion[], line: number): string | undefined {
+  const enclosing = regions.filter(region => region.line < line && line <= region.endLine)
+  return enclosing.length > 0 ? joinConditions(enclosing.map(region => region.condition)) : undefined
+}
+
+function negate(condition: string): string {
+  if (condition.startsWith('!defined(') && isSimple(condition.substring(1))) return condition.substring(1)
+  return isSimple(condition) ? `!${condition}` : `!(${condition})`
+}
+
+function joinConditions(conditions: string[]): string {
+  if (conditions.length === 1) return conditions[0]!
+  return conditions.map(condition => /\|\||\?/.test(condition) && !isParenthesized(condition) ? `(${condition})` : condition).join(' && ')
+}
+
+function isSimple(condition: string): boolean {
+  return /^!?(?:defined\(\w+\)|\w+)$/.test(condition)
+}
+
+function isParenthesized(condition: string): boolean {
+  if (!condition.startsWith('(') || !condition.endsWith(')')) return false
+  let depth = 0
+  for (let index = 0; index < condition.length; index++) {
+    if (condition[index] === '(') depth++
+    else if (condition[index] === ')' && --depth === 0 && index < condition.length - 1) return false
+  }
+  return true
+}
+
+/**
+ * Reads defines as given to a compiler: `DEBUG,VERSION=2`, `-DDEBUG -DVERSION=2` or
+ * `DEBUG VERSION=2`. A define without a value is 1.
+ */
+export function parseDefines(text: string): Map<string, string> {
+  const defines = new Map<string, string>()
+  for (const part of text.split(/[\s,]+/).filter(Boolean)) {
+    const [name, ...value] = part.replace(/^-D/, '').split('=')
+    if (name) defines.set(name, value.length > 0 ? value.join('=') : '1')
+  }
+  return defines
+}
+
+/**
+ * Evaluates a condition as the preprocessor would with the defines; names not defined are 0.
+ * Undefined when it can't be decided, e.g. for `__has_include(...)` or function-like macros.
+ */
+export function evaluateCondition(condition: string, defines: Map<string, string>): boolean | undefined {
+  const tokens = condition.match(/\d[\w.]*|[A-Za-z_]\w*|<<|>>|<=|>=|==|!=|&&|\|\||[-+*/%<>!~&|^?:()]/g) ?? []
+  let position = 0
+  let undecidable = false
+
+  const value = (name: string): number => {
+    const text = defines.get(name)
+    if (text === undefined) return 0
+    const number = literal(text)
+    if (number === undefined) undecidable = true
+    return number ?? 1
+  }
+
+  const primary = (): number => {
+    const token = tokens[position++]
+    if (token === undefined) {
+      undecidable = true
+      return 0
+    }
+    if (token === '(') {
+      const inner = conditional()
+      if (tokens[position++] !== ')') undecidable = true
+      return inner
+    }
+    if (token === '!') return primary() ? 0 : 1
+    if (token === '-') return -primary()
+    if (token === '+') return primary()
+    if (token === '~') return ~primary()
+    if (token === 'defined') {
+      const parenthesized = tokens[position] === '('
+      if (parenthesized) position++
+      const name = tokens[position++] ?? ''
+      if (parenthesized && tokens[position++] !== ')') undecidable = true
+      return defines.has(name) ? 1 : 0
+    }
+    if (/^\d/.test(token)) {
+      const number = literal(token)
+      if (number === undefined) undecidable = true
+      return number ?? 0
+    }
+    if (/^[A-Za-z_]/.test(token)) {
+      if (tokens[position] === '(') {
+        // A function-like macro such as __has_include(<x>) or __GNUC_PREREQ(4, 2)
+        undecidable = true
+        let depth = 0
+        do {
+          if (tokens[position] === '(') depth++
+          else if (tokens[position] === ')') depth--
+          position++
+        } while (depth > 0 && position < tokens.length)
+        return 0
+      }
+      return value(token)
+    }
+    undecidable = true
+    return 0
+  }
+
+  const apply = (operator: string, a: number, b: number): number => {
+    switch (operator) {
+      case '||': return a || b ? 1 : 0
+      case '&&': return a && b ? 1 : 0
+      case '|': return a | b
+      case '^': return a ^ b
+      case '&': return a & b
+      case '==': return a === b ? 1 : 0
+      case '!=': return a !== b ? 1 : 0
+      case '<': return a < b ? 1 : 0
+      case '<=': return a <= b ? 1 : 0
+      case '>': return a > b ? 1 : 0
+      case '>=': return a >= b ? 1 : 0
+      case '<<': return a << b
+      case '>>': return a >> b
+      case '+': return a + b
+      case '-': return a - b
+      case '*': return a * b
+      case '/': return b === 0 ? 0 : Math.trunc(a / b)
+      default: return b === 0 ? 0 : a % b
+    }
+  }
+  const binary = (level: number): number => {
+    if (level === PRECEDENCE.length) return primary()
+    let left = binary(level + 1)
+    while (tokens[position] !== undefined && PRECEDENCE[level]!.includes(tokens[position]!)) {
+      const operator = tokens[position++]!
+      left = apply(operator, left, binary(level + 1))
+    }
+    return left
+  }
+  const conditional = (): number => {
+    const test = binary(0)
+    if (tokens[position] !== '?') return test
+    position++
+    const whenTrue = conditional()
+    if (tokens[position++] !== ':') undecidable = true
+    const whenFalse = conditional()
+    return test ? whenTrue : whenFalse
+  }
+
+  const result = conditional()
+  if (position < tokens.length) undecidable = true
+  return undecidable ? undefined : result !== 0
+}
+
+function literal(text: string): number | undefined {
+  const match = text.trim().match(/^(0x[\da-f]+|0b[01]+|0[0-7]*|[1-9]\d*)(?:[ul]{0,3})$/i)
+  if (!match) return undefined
+  const digits = match[1]!.toLowerCase()
+  if (digits.startsWith('0x')) return parseInt(digits.substring(2), 16)
+  if (digits.startsWith('0b')) return parseInt(digits.substring(2), 2)
+  return digits.length > 1 && digits.startsWith('0') ? parseInt(digits, 8) : parseInt(digits, 10)
+}
+
+/**
+ * Reads the conditional regions of the C-family files among the nodes once, for annotating
+ * and filtering search results
+ */
+export function createPreprocessorIndex(nodes: TreeNode[]) {
+  const regions = new Map<string, ConditionalRegion[]>()
+  for (const node of nodes) {
+    if (node.type === 'file' && isPreprocessedFile(node.path) && node.content?.includes('#')) {
+      const found = readConditionalRegions(node.content, node.path)
+      if (found.length > 0) regions.set(node.path, found)
+    }
+  }
+
+  const guardOf = (node: TreeNode) => {
+    const fileRegions = regions.get(node.path)
+    return fileRegions && node.startLine !== undefined && node.type !== 'file' ? guardAt(fileRegions, node.startLine) : undefined
+  }
+
+  let declarations: Map<string, TreeNode[]> | undefined
+  const key = (node: TreeNode) => `${node.type}\0${node.name}`
+
+  return {
+    guardOf,
+
+    /**
+     * Whether a declaration is compiled with the defines; undecidable guards keep it
+     */
+    matches: (node: TreeNode, defines: Map<string, string>) => {
+      const guard = guardOf(node)
+      return guard === undefined || evaluateCondition(guard, defines) !== false
+    },
+
+    /**
+     * The node's guard and the other declarations of the same name and kind in C-family
+     * files; undefined when neither it nor any of them is guarded
+     */
+    describe: (node: TreeNode): PreprocessorInfo | undefined => {
+      if (!isPreprocessedFile(node.path) || !node.name || node.type === 'file') return undefined
+      if (!declarations) {
+        declarations = new Map()
+        for (const candidate of nodes) {
+          if (!candidate.name || candidate.type === 'file' || !isPreprocessedFile(candidate.path)) continue
+          declarations.set(key(candidate), [...declarations.get(key(candidate)) ?? [], candidate])
+        }
+      }
+
+      const guard = guardOf(node)
+      const variants = (declarations.get(key(node)) ?? [])
+        .filter(candidate => candidate.path !== node.path || candidate.startLine !== node.startLine)
+        .map((candidate) => {
+          const variantGuard = guardOf(candidate)
+          return { path: candidate.path, line: candidate.startLine, ...(variantGuard ? { guard: variantGuard } : {}) }
+        })
+      if (!guard && !variants.some(variant => variant.guard)) return undefined
+      return { ...(guard ? { guard } : {}), variants }
+    },
+  }
+}
@@ -0,0 +1,243 @@
+/**
+ * Protobuf definition extraction - services, RPC methods, messages and enums from .proto files.
+ * Works on the source text so definitions are available whether or not tree-sitter-proto is installed.
+ */
+
+import type { TreeNode } from '../types/core.js'
+
+export interface ProtoRpc {
+  name: string
+  requestType: string
+  responseType: string
+  clientStreaming: boolean
+  serverStreaming: boolean
+  line: number
+}
+
+export interface ProtoService {
+  name: string
+  fullName: string
+  startLine: number
+  endLine: number
+  rpcs: ProtoRpc[]
+}
+
+export interface ProtoType {
+  kind: 'message' | 'enum'
+  name: string
+  fullName: string
+  startLine: number
+  endLine: number
+}
+
+export interface ProtoDefinitions {
+  package?: string
+  services: ProtoService[]
+  types: ProtoType[]
+}
+
+interface Token {
+  value: string
+  line: number
+}
+
+interface Frame {
+  kind: 'message' | 'enum' | 'service' | 'block'
+  name: string
+  startLine: number
+  service?: ProtoService
+}
+
+/**
+ * Extracts the package, services (with their RPCs) and message/enum types declared in a .proto file
+ */
+export function parseProtoDefinitions(content: string): ProtoDefinitions {
+  const tokens = tokenize(content)
+  const definitions: ProtoDefinitions = { services: [], types: [] }
+  const stack: Frame[] = []
+
+  const qualify = (name: string) => {
+    const scope = stack.filter(frame => frame.kind === 'message').map(frame => frame.name)
+    return [definitions.package, ...scope, name].filter(Boolean).join('.')
+  }
+
+  for (let i = 0; i < tokens.length; i++) {
+    const token = tokens[i]!
+    const next = tokens[i + 1]
+    const current = stack[stack.length - 1]
+
+    if (token.value === 'package' && next && stack.length === 0) {
+      definitions.package = next.value
+      i++
+    }
+    else if ((token.value === 'message' || token.value === 'enum' || token.value === 'service') && next && tokens[i + 2]?.value === '{') {
+      const kind = token.value
+      const frame: Frame = { kind, name: next.value, startLine: token.line }
+      if (kind === 'service') {
+        frame.service = { name: next.value, fullName: qualify(next.value), startLine: token.line, endLine: token.line, rpcs: [] }
+      }
+      else {
+        definitions.types.push({ kind, name: next.value, fullName: qualify(next.value), startLine: token.line, endLine: token.line })
+      }
+      stack.push(frame)
+      i += 2
+    }
+    else if (token.value === 'rpc' && current?.kind === 'service' && next) {
+      const rpc = readRpc(tokens, i)
+      if (rpc) {
+        current.service!.rpcs.push(rpc.rpc)
+        i = rpc.end
+      }
+    }
+    else if (token.value === '{') {
+      stack.push({ kind: 'block', name: '', startLine: token.line })
+    }
+    else if (token.value === '}') {
+      const frame = stack.pop()
+      if (frame?.kind === 'service') {
+        frame.service!.endLine = token.line
+        definitions.services.push(frame.service!)
+      }
+      else if (frame && frame.kind !== 'block') {
+        const type = [...definitions.types].reverse().find(t => t.name === frame.name && t.startLine === frame.startLine)
+        if (type) type.endLine = token.line
+      }
+    }
+  }
+
+  return definitions
+}
+
+/**
+ * Converts proto definitions into tree nodes: services, messages and enums become `class`
+ * elements and RPCs become `function` elements, matching how code elements are indexed. Their
+ * symbols keep the proto meaning: messages are structs, services interfaces, RPCs methods.
+ */
+export function protoDefinitionsToNodes(definitions: ProtoDefinitions, content: string, filePath: string): TreeNode[] {
+  const lines = content.split('\n')
+  const slice = (start: number, end: number) => lines.slice(start - 1, end).join('\n')
+  const header = (line: number) => (lines[line - 1] ?? '').replace(/\s*\{.*$/, '').replace(/;\s*$/, '').trim()
+  const packagePrefix = definitions.package ? `${definitions.package}.` : ''
+  const nodes: TreeNode[] = []
+
+  for (const type of definitions.types) {
+    // Nested types are named after their enclosing message
+    const container = type.fullName.substring(packagePrefix.length).split('.').slice(0, -1).pop()
+    nodes.push({
+      id: `proto-${type.kind}-${type.fullName}`,
+      type: 'class',
+      name: type.name,
+      path: filePath,
+      startLine: type.startLine,
+      endLine: type.endLine,
+      content: slice(type.startLine, type.endLine),
+      symbol: {
+        kind: type.kind === 'enum' ? 'enum' : 'struct',
+        visibility: 'public',
+        signature: header(type.startLine),
+        ...(container ? { container } : {}),
+      },
+    })
+  }
+
+  for (const service of definitions.services) {
+    nodes.push({
+      id: `proto-service-${service.fullName}`,
+      type: 'class',
+      name: service.name,
+      path: filePath,
+      startLine: service.startLine,
+      endLine: service.endLine,
+      content: slice(service.startLine, service.endLine),
+      symbol: { kind: 'interface', visibility: 'public', signature: header(service.startLine) },
+    })
+
+    for (const rpc of service.rpcs) {
+      nodes.push({
+        id: `proto-rpc-${service.fullName}.${rpc.name}`,
+        type: 'function',
+        name: rpc.name,
+        path: filePath,
+        startLine: rpc.line,
+        endLine: rpc.line,
+        content: lines[rpc.line - 1] ?? '',
+        symbol: {
+          kind: 'method',
+          visibility: 'public',
+          signature: `rpc ${rpc.name}(${rpc.clientStreaming ? 'stream ' : ''}${rpc.requestType}) returns (${rpc.serverStreaming ? 'stream ' : ''}${rpc.responseType})`,
+          container: service.name,
+        },
+      })
+    }
+  }
+
+  return nodes.sort((a, b) => (a.startLine ?? 0) - (b.startLine ?? 0))
+}
+
+/**
+ * Identifiers that protoc plugins generate for a service in the common target languages
+ * (Go, Python, Java, TypeScript/JavaScript, C#)
+ */
+export function getGeneratedServiceIdentifiers(serviceName: string): string[] {
+  return [
+    `${serviceName}Client`,
+    `${serviceName}Server`,
+    `New${serviceName}Client`,
+    `Register${serviceName}Server`,
+    `Unimplemented${serviceName}Server`,
+    `${serviceName}Stub`,
+    `${serviceName}Servicer`,
+    `add_${serviceName}Servicer_to_server`,
+    `${serviceName}Grpc`,
+    `${serviceName}ImplBase`,
+  ]
+}
+
+function readRpc(tokens: Token[], start: number): { rpc: ProtoRpc, end: number } | null {
+  // rpc Name ( [stream] Request ) returns ( [stream] Response )
+  let i = start + 1
+  const name = tokens[i]?.value
+  if (!name || tokens[i + 1]?.value !== '(') return null
+  i += 2
+
+  const clientStreaming = tokens[i]?.value === 'stream'
+  if (clientStreaming) i++
+  const requestType = tokens[i]?.value ?? ''
+  i++
+  if (tokens[i]?.value !== ')' || tokens[i + 1]?.value !== 'returns' || tokens[i + 2]?.value !== '(') return null
+  i += 3
+
+  const serverStreaming = tokens[i]?.value === 'stream'
+  if (serverStreaming) i++
+  const responseType = tokens[i]?.value ?? ''
+  i++
+  if (tokens[i]?.value !== ')') return null
+
+  return {
+    rpc: { name, requestType, responseType, clientStreaming, serverStreaming, line: tokens[start]!.line },
+    end: i,
+  }
+}
+
+function tokenize(content: string): Token[] {
+  const tokens: Token[] = []
+  const pattern = /\/\/[^\n]*|\/\*[\s\S]*?\*\/|"(?:[^"\\\n]|\\.)*"|'(?:[^'\\\n]|\\.)*'|[A-Za-z_.][\w.]*|\d[\w.]*|[{}()\[\];=<>,]|\n/g
+  let line = 1
+  let match
+
+  while ((match = pattern.exec(content)) !== null) {
+    const value = match[0]
+    if (value === '\n') {
+      line++
+      continue
+    }
+    if (value.startsWith('//')) continue
+    if (value.startsWith('/*')) {
+      line += value.split('\n').length - 1
+      continue
+    }
+    tokens.push({ value, line })
+  }
+
+  return tokens
+}
@@ -0,0 +1,141 @@
+/**
+ * Python decorator registrations - Flask and FastAPI routes, Celery tasks and click or typer
+ * commands, indexed under the URL path, task name or command name they register, so a search
+ * for `/users/{id}` or `billing.tasks.charge` lands on the handler
+ */
+
+import { basename, dirname, join } from 'path'
+import { isFile } from '../utils/helpers.js'
+import type { TreeNode } from '../types/core.js'
+import { maskCommentsAndStrings, readDocstring, readLogicalLines, type LogicalLine } from './python-dynamic.js'
+
+interface Registration {
+  type: 'route' | 'task' | 'command'
+  name: string
+  container?: string
+}
+
+const MAX_SIGNATURE_LENGTH = 200
+
+const ROUTE_DECORATOR = /^@([A-Za-z_][\w.]*)\.(route|get|post|put|delete|patch|options|head|api_route|websocket)\(\s*(?:path\s*=\s*)?(['"])(\/[^'"]*)\3/
+const TASK_DECORATOR = /^@(?:(?:[A-Za-z_][\w.]*\.)?shared_task|[A-Za-z_][\w.]*\.task)(?:\(([\s\S]*)\))?$/
+const COMMAND_DECORATOR = /^@([A-Za-z_][\w.]*)\.(command|group)(?:\(([\s\S]*)\))?$/
+const ROUTER_PREFIX = /^([A-Za-z_]\w*)\s*=\s*(?:[\w.]+\.)?(?:APIRouter|Blueprint)\(([\s\S]*)\)$/
+const CLI_MODULES = new Set(['click', 'typer'])
+
+/**
+ * Text-based extraction added to the syntax tree's nodes for Python files: one node per route,
+ * task or command registration, named by what it registers and spanning the decorated function,
+ * with `symbol.handler` naming the function
+ */
+export function extractDecoratedRegistrations(content: string, filePath: string): TreeNode[] {
+  const code = maskCommentsAndStrings(content)
+  const lines = readLogicalLines(code)
+  const textOf = (line: LogicalLine) => content.substring(line.start, line.end).replace(/\s+/g, ' ')
+
+  const usesCelery = /^\s*(?:from|import)\s+celery\b/m.test(code)
+  const usesCli = /^\s*(?:from|import)\s+(?:click|typer)\b/m.test(code)
+  const prefixes = readRouterPrefixes(lines.map(textOf))
+  const nodes: TreeNode[] = []
+
+  lines.forEach((line, index) => {
+    if (!code.startsWith('@', line.start) || (index > 0 && code.startsWith('@', lines[index - 1]!.start))) return
+
+    let defIndex = index
+    while (defIndex < lines.length && code.startsWith('@', lines[defIndex]!.start)) defIndex++
+    const definition = lines[defIndex]
+    const handler = definition && code.substring(definition.start, definition.end).match(/^(?:async\s+)?def\s+([A-Za-z_]\w*)/)?.[1]
+    if (!handler) return
+
+    let endIndex = defIndex
+    while (endIndex + 1 < lines.length && lines[endIndex + 1]!.indent > definition.indent) endIndex++
+    const docLine = lines[defIndex + 1]
+    const doc = docLine && docLine.indent > definition.indent ? readDocstring(content, docLine) : undefined
+    const signature = textOf(definition).replace(/:$/, '')
+
+    for (const decorator of lines.slice(index, defIndex)) {
+      const registration = readRegistration(textOf(decorator), handler, filePath, prefixes, usesCelery, usesCli)
+      if (!registration) continue
+
+      nodes.push({
+        id: `${registration.type}-${filePath}-${decorator.line}-${registration.name}`,
+        type: registration.type,
+        name: registration.name,
+        path: filePath,
+        startLine: decorator.line,
+        endLine: lines[endIndex]!.line + code.substring(lines[endIndex]!.start, lines[endIndex]!.end).split('\n').length - 1,
+        content: content.substring(decorator.start, lines[endIndex]!.end),
+        symbol: {
+          kind: 'function',
+          visibility: 'public',
+          signature: `${textOf(decorator)} ${signature}`.substring(0, MAX_SIGNATURE_LENGTH),
+          ...(registration.container ? { container: registration.container } : {}),
+          ...(doc ? { doc } : {}),
+          handler,
+        },
+      })
+    }
+  })
+  return nodes
+}
+
+/**
+ * The default Celery task name, `module.function`, where the module path runs up through the
+ * package directories (those with an `__init__.py`) around the file
+ */
+export function celeryTaskName(filePath: string, handler: string): string {
+  const modules = [basename(filePath).replace(/\.py$/, '')].filter(name => name !== '__init__')
+  for (let directory = dirname(filePath); dirname(directory) !== directory && isFile(join(directory, '__init__.py')); directory = dirname(directory)) {
+    modules.unshift(basename(directory))
+  }
+  return [...modules, handler].join('.')
+}
+
+function readRegistration(
+  decorator: string,
+  handler: string,
+  filePath: string,
+  prefixes: Map<string, string>,
+  usesCelery: boolean,
+  usesCli: boolean,
+): Registration | undefined {
+  const route = decorator.match(ROUTE_DECORATOR)
+  if (route) {
+    const prefix = prefixes.get(route[1]!) ?? ''
+    return { type: 'route', name: `${prefix.replace(/\/$/, '')}${route[4]}` || '/' }
+  }
+
+  const task = decorator.match(TASK_DECORATOR)
+  if (task && (usesCelery || decorator.includes('shared_task'))) {
+    return { type: 'task', name: keywordString(task[1] ?? '', 'name') ?? celeryTaskName(filePath, handler) }
+  }
+
+  const command = decorator.match(COMMAND_DECORATOR)
+  if (command && usesCli) {
+    // click and typer default to the function name with underscores replaced by dashes
+    const name = keywordString(command[3] ?? '', 'name') ?? positionalString(command[3] ?? '') ?? handler.toLowerCase().replace(/_/g, '-')
+    return CLI_MODULES.has(command[1]!) ? { type: 'command', name } : { type: 'command', name, container: command[1] }
+  }
+  return undefined
+}
+
+/**
+ * URL prefixes of routers and blueprints declared in the file, by variable
+ */
+function readRouterPrefixes(statements: string[]): Map<string, string> {
+  const prefixes = new Map<string, string>()
+  for (const statement of statements) {
+    const match = statement.match(ROUTER_PREFIX)
+    const prefix = match && (keywordString(match[2]!, 'prefix') ?? keywordString(match[2]!, 'url_prefix'))
+    if (prefix) prefixes.set(match[1]!, prefix)
+  }
+  return prefixes
+}
+
+function keywordString(args: string, keyword: string): string | undefined {
+  return args.match(new RegExp(String.raw`(?:^|[(,\s])${keyword}\s*=\s*(['"])(.*?)\1`))?.[2]
+}
+
+function positionalString(args: string): string | undefined {
+  return args.match(/^\s*(['"])(.*?)\1/)?.[2]
+}
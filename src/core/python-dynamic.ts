@@ -0,0 +1,344 @@
+/**
+ * Python dynamic definitions - attributes that are not plain module-level assignments: the
+ * fields of dataclasses, attrs classes and pydantic-style models, instance attributes assigned
+ * in `__init__`, and names set with `setattr` and a literal name
+ */
+
+import type { SymbolVisibility, TreeNode } from '../types/core.js'
+
+interface PythonSource {
+  content: string
+  code: string // Content with comments and strings blanked out, offsets unchanged
+  filePath: string
+  lineStarts: number[]
+}
+
+export interface LogicalLine {
+  start: number // Offset of the first non-blank character
+  end: number
+  indent: number
+  line: number
+}
+
+interface Block {
+  kind: 'class' | 'def'
+  name: string
+  indent: number
+  bodyIndent?: number
+  model?: boolean // Class whose annotated class attributes are fields
+  self?: string // First parameter of a method
+}
+
+interface Attribute {
+  name: string
+  container?: string
+  start: number
+  end: number
+  line: number
+  signature: string
+  doc?: string
+}
+
+const MAX_SIGNATURE_LENGTH = 200
+
+const MODEL_DECORATORS = /^@(?:[\w.]+\.)?dataclass\b|^@attrs?\.(?:s|attrs|define|frozen|mutable)\b|^@(?:define|frozen|mutable)\b/
+const MODEL_BASES = new Set(['BaseModel', 'BaseSettings', 'SQLModel', 'TypedDict', 'NamedTuple', 'Struct', 'Document', 'EmbeddedDocument'])
+const INIT_METHODS = new Set(['__init__', '__post_init__', '__attrs_post_init__'])
+
+/**
+ * Text-based extraction added to the syntax tree's nodes for Python files: a `variable` node
+ * per model field, `__init__` attribute and `setattr` target, contained by its class. The first
+ * definition of each name in a class wins.
+ */
+export function extractDynamicAttributes(content: string, filePath: string): TreeNode[] {
+  const source: PythonSource = {
+    content,
+    code: maskCommentsAndStrings(content),
+    filePath,
+    lineStarts: [0, ...[...content.matchAll(/\n/g)].map(match => match.index! + 1)],
+  }
+
+  const lines = readLogicalLines(source.code)
+  const models = new Set<string>()
+  const attributes: Attribute[] = []
+  const stack: Block[] = []
+  let decorators: string[] = []
+
+  lines.forEach((line, index) => {
+    while (stack.length > 0 && stack[stack.length - 1]!.indent >= line.indent) stack.pop()
+    const parent = stack[stack.length - 1]
+    if (parent && parent.bodyIndent === undefined) parent.bodyIndent = line.indent
+
+    const code = source.code.substring(line.start, line.end)
+    const klass = code.match(/^class\s+([A-Za-z_]\w*)\s*(?:\(([\s\S]*)\))?\s*:/)
+    const def = code.match(/^(?:async\s+)?def\s+([A-Za-z_]\w*)\s*\(\s*(\*{0,2}[A-Za-z_]\w*)?/)
+
+    if (code.startsWith('@')) {
+      decorators.push(code.replace(/\s+/g, ''))
+      return
+    }
+    if (klass) {
+      const bases = (klass[2] ?? '').split(',').map(base => base.trim().replace(/^.*\./, '').replace(/\[.*$/, ''))
+      const model = decorators.some(decorator => MODEL_DECORATORS.test(decorator)) || bases.some(base => MODEL_BASES.has(base) || models.has(base))
+      if (model) models.add(klass[1]!)
+      stack.push({ kind: 'class', name: klass[1]!, indent: line.indent, model })
+    }
+    else if (def) {
+      stack.push({ kind: 'def', name: def[1]!, indent: line.indent, self: def[2] })
+    }
+    decorators = []
+    if (klass || def) return
+
+    const owner = [...stack].reverse().find(block => block.kind === 'class')
+    if (parent?.kind === 'class' && parent.model && line.indent === parent.bodyIndent) {
+      const field = readField(source, line, lines[index + 1])
+      if (field) attributes.push({ ...field, container: parent.name })
+    }
+
+    const method = owner ? stack[stack.indexOf(owner) + 1] : undefined
+    if (owner && method?.self && INIT_METHODS.has(method.name)) {
+      attributes.push(...readSelfAssignments(source, line, method.self).map(attribute => ({ ...attribute, container: owner.name })))
+    }
+
+    attributes.push(...readSetattrCalls(source, line, owner, method?.self))
+  })
+
+  const seen = new Set<string>()
+  return attributes
+    .filter((attribute) => {
+      const key = `${attribute.container ?? ''}.${attribute.name}`
+      if (seen.has(key)) return false
+      seen.add(key)
+      return true
+    })
+    .map(attribute => toNode(source, attribute))
+}
+
+/**
+ * An annotated class attribute, `name: type` with an optional default. `ClassVar` attributes
+ * are not fields. The doc is a pydantic `Field(description=...)` or the string on the next line.
+ */
+function readField(source: PythonSource, line: LogicalLine, next: LogicalLine | undefined): Omit<Attribute, 'container'> | undefined {
+  const code = source.code.substring(line.start, line.end)
+  const match = code.match(/^([A-Za-z_]\w*)\s*:(?!=)/)
+  if (!match) return undefined
+
+  const [target, value] = splitAssignment(code)
+  const annotation = source.content.substring(line.start + match[0].length, line.start + target.length).trim()
+  if (!annotation || /^(?:typing\.)?ClassVar\b/.test(annotation)) return undefined
+
+  const original = value === undefined ? '' : source.content.substring(line.end - value.length, line.end)
+  const description = original.match(/\bField\s*\([\s\S]*?\bdescription\s*=\s*(?:"([^"]*)"|'([^']*)')/)
+  const docstring = next && next.indent === line.indent ? readDocstring(source.content, next) : undefined
+
+  return {
+    name: match[1]!,
+    start: line.start,
+    end: line.end,
+    line: line.line,
+    signature: signatureOf(`${match[1]}: ${annotation}`),
+    doc: description?.[1] ?? description?.[2] ?? docstring,
+  }
+}
+
+/**
+ * `self.name = ...` targets, including annotated, chained and tuple assignments
+ */
+function readSelfAssignments(source: PythonSource, line: LogicalLine, self: string): Omit<Attribute, 'container'>[] {
+  const code = source.code.substring(line.start, line.end)
+  const parts = splitTopLevel(code, '=')
+  if (parts.length < 2) return []
+
+  const pattern = new RegExp(String.raw`^${self}\.([A-Za-z_]\w*)\s*(?::([\s\S]+))?$`)
+  const attributes: Omit<Attribute, 'container'>[] = []
+  let offset = 0
+  for (const part of parts.slice(0, -1)) {
+    let targetOffset = offset
+    for (const target of splitTopLevel(part, ',')) {
+      const match = target.trim().match(pattern)
+      const targetStart = line.start + targetOffset + target.search(/\S|$/)
+      if (match) {
+        const annotation = match[2] ? source.content.substring(targetStart + target.trim().indexOf(':') + 1, targetStart + target.trim().length).trim() : ''
+        attributes.push({
+          name: match[1]!,
+          start: line.start,
+          end: line.end,
+          line: line.line,
+          signature: signatureOf(`${self}.${match[1]}${annotation ? `: ${annotation}` : ''}`),
+        })
+      }
+      targetOffset += target.length + 1
+    }
+    offset += part.length + 1
+  }
+  return attributes
+}
+
+/**
+ * `setattr(target, "name", value)` calls with a literal name. `self` and `cls` set attributes of
+ * the enclosing class, `sys.modules[__name__]` defines a module-level name, and other targets
+ * are named as the container.
+ */
+function readSetattrCalls(source: PythonSource, line: LogicalLine, owner: Block | undefined, self: string | undefined): Attribute[] {
+  const code = source.code.substring(line.start, line.end)
+  const attributes: Attribute[] = []
+
+  for (const call of code.matchAll(/(?<![\w.])setattr\s*\(/g)) {
+    const open = call.index! + call[0].length - 1
+    const close = findClosing(code, open)
+    if (close === -1) continue
+
+    const args = splitTopLevel(code.substring(open + 1, close), ',')
+    if (args.length !== 3) continue
+    const nameStart = line.start + open + 1 + args[0]!.length + 1
+    const name = source.content.substring(nameStart, nameStart + args[1]!.length).trim().match(/^(['"])([A-Za-z_]\w*)\1$/)?.[2]
+    if (!name) continue
+
+    const target = args[0]!.trim()
+    const container = target === self || target === 'self' || target === 'cls'
+      ? owner?.name
+      : /^sys\.modules\[\s*__name__\s*\]$/.test(target) ? undefined : /^[A-Za-z_][\w.]*$/.test(target) ? target : null
+    if (container === null) continue
+
+    attributes.push({
+      name,
+      container,
+      start: line.start + call.index!,
+      end: line.start + close + 1,
+      line: line.line,
+      signature: signatureOf(source.content.substring(line.start + call.index!, line.start + close + 1)),
+    })
+  }
+  return attributes
+}
+
+function toNode(source: PythonSource, attribute: Attribute): TreeNode {
+  return {
+    id: `attr-${source.filePath}-${attribute.line}-${attribute.container ?? ''}.${attribute.name}`,
+    type: 'variable',
+    name: attribute.name,
+    path: source.filePath,
+    startLine: attribute.line,
+    endLine: source.lineStarts.filter(start => start < attribute.end).length,
+    content: source.content.substring(attribute.start, attribute.end),
+    symbol: {
+      kind: 'variable',
+      visibility: visibilityOf(attribute.name),
+      signature: attribute.signature,
+      ...(attribute.container ? { container: attribute.container } : {}),
+      ...(attribute.doc ? { doc: attribute.doc } : {}),
+    },
+  }
+}
+
+function visibilityOf(name: string): SymbolVisibility {
+  if (name.startsWith('__') && !name.endsWith('__')) return 'private'
+  return name.startsWith('_') ? 'internal' : 'public'
+}
+
+/**
+ * The text of a statement that is only a string literal, such as a docstring
+ */
+export function readDocstring(content: string, line: LogicalLine): string | undefined {
+  const text = content.substring(line.start, line.end)
+  const match = text.match(/^[rRuU]?("""|'''|"|')([\s\S]*)\1$/)
+  return match ? match[2]!.split('\n').map(part => part.trim()).join('\n').trim() || undefined : undefined
+}
+
+/**
+ * Statements with their indentation, joining lines continued inside brackets or with `\`
+ */
+export function readLogicalLines(code: string): LogicalLine[] {
+  const lines: LogicalLine[] = []
+  let start = -1
+  let indent = 0
+  let line = 0
+  let depth = 0
+  let offset = 0
+
+  code.split('\n').forEach((text, index) => {
+    if (start === -1) {
+      const first = text.search(/\S/)
+      if (first !== -1) {
+        start = offset + first
+        indent = text.substring(0, first).replace(/\t/g, '        ').length
+        line = index + 1
+      }
+    }
+    if (start !== -1) {
+      for (const char of text) {
+        if ('([{'.includes(char)) depth++
+        else if (')]}'.includes(char)) depth = Math.max(0, depth - 1)
+      }
+      if (depth === 0 && !text.trimEnd().endsWith('\\')) {
+        lines.push({ start, end: offset + text.trimEnd().length, indent, line })
+        start = -1
+      }
+    }
+    offset += text.length + 1
+  })
+  return lines
+}
+
+/**
+ * Splits an assignment statement at its first top-level `=`
+ */
+function splitAssignment(code: string): [string, string | undefined] {
+  const parts = splitTopLevel(code, '=')
+  return parts.length < 2 ? [code, undefined] : [parts[0]!, code.substring(parts[0]!.length + 1)]
+}
+
+/**
+ * Splits at top-level separators; `=` is only an assignment, not `==`, `<=`, `:=` or `+=`
+ */
+function splitTopLevel(text: string, separator: ',' | '='): string[] {
+  const parts: string[] = []
+  let depth = 0
+  let start = 0
+  for (let i = 0; i < text.length; i++) {
+    const char = text[i]!
+    if ('([{'.includes(char)) depth++
+    else if (')]}'.includes(char)) depth--
+    else if (char === separator && depth === 0) {
+      if (separator === '=' && (text[i + 1] === '=' || /[=<>!:+\-*/%&|^@]/.test(text[i - 1] ?? ''))) {
+        if (text[i + 1] === '=') i++
+        continue
+      }
+      parts.push(text.substring(start, i))
+      start = i + 1
+    }
+  }
+  parts.push(text.substring(start))
+  return parts
+}
+
+function findClosing(text: string, open: number): number {
+  let depth = 0
+  for (let i = open; i < text.length; i++) {
+    if ('([{'.includes(text[i]!)) depth++
+    else if (')]}'.includes(text[i]!) && --depth === 0) return i
+  }
+  return -1
+}
+
+function signatureOf(text: string): string {
+  return text.replace(/\s+/g, ' ').trim().substring(0, MAX_SIGNATURE_LENGTH)
+}
+
+/**
+ * Blanks comments and the inside of string literals, keeping the quotes so a string statement
+ * is still recognizable, and newlines so offsets and lines are unchanged
+ */
+export function maskCommentsAndStrings(content: string): string {
+  return content.replace(
+    /#[^\n]*|[rRbBuUfF]{0,2}("""|''')[\s\S]*?\1|[rRbBuUfF]{0,2}(["'])(?:\\[\s\S]|(?!\2)[^\\\n])*\2/g,
+    (match, triple?: string, single?: string) => {
+      if (match.startsWith('#')) return match.replace(/[^\n]/g, ' ')
+      const quote = triple ?? single!
+      const prefix = match.indexOf(quote)
+      return match.substring(0, prefix + quote.length)
+        + match.substring(prefix + quote.length, match.length - quote.length).replace(/[^\n]/g, ' ')
+        + quote
+    },
+  )
+}
@@ -0,0 +1,53 @@
+/**
+ * Python source scanning - functions and the lines of their bodies read by indentation from
+ * source text with comments and string contents masked, for analyses that check statement
+ * order within a function
+ */
+
+export interface PythonLine {
+  text: string // Trimmed
+  indent: number
+  line: number
+}
+
+export interface PythonFunction {
+  name: string
+  body: PythonLine[]
+}
+
+const FUNCTION_DECLARATION = /^(\s*)(?:async\s+)?def\s+(\w+)\s*\(/
+
+/**
+ * Functions and methods with the non-blank lines of their own bodies; nested functions are
+ * listed separately and left out of the body enclosing them
+ */
+export function readPythonFunctions(content: string): PythonFunction[] {
+  const lines: PythonLine[] = content.split('\n')
+    .map((text, index) => ({ text: text.trim(), indent: text.length - text.trimStart().length, line: index + 1 }))
+    .filter(line => line.text)
+
+  const functions: PythonFunction[] = []
+  lines.forEach((line, index) => {
+    const declaration = FUNCTION_DECLARATION.exec(' '.repeat(line.indent) + line.text)
+    if (!declaration) return
+    const body: PythonLine[] = []
+    let nested: number | undefined
+    for (const next of lines.slice(index + 1)) {
+      if (next.indent <= line.indent) break
+      if (nested !== undefined && next.indent > nested) continue
+      nested = FUNCTION_DECLARATION.test(' '.repeat(next.indent) + next.text) ? next.indent : undefined
+      if (nested === undefined) body.push(next)
+    }
+    functions.push({ name: declaration[2]!, body })
+  })
+  return functions
+}
+
+/**
+ * Index after the last line of the block opened by the line at `index`
+ */
+export function blockEnd(lines: PythonLine[], index: number): number {
+  let end = index + 1
+  while (end < lines.length && lines[end]!.indent > lines[index]!.indent) end++
+  return end
+}
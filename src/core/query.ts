@@ -0,0 +1,217 @@
+/**
+ * Search query language - filters composed in the query string itself, e.g.
+ * `kind:function lang:go name:~Handler -path:vendor caller:main`. Terms are ANDed; `OR`,
+ * `-` (or `NOT`) and parentheses combine them. Words without a field match names, and the longest
+ * ranks the results. A query with no `field:` term is an ordinary name search.
+ */
+
+import { extname, relative, sep } from 'path'
+import { getLanguageForFile } from './languages.js'
+import { calledNames } from '../analysis/context-pack.js'
+import type { TreeNode } from '../types/core.js'
+
+export const QUERY_FIELDS = ['kind', 'lang', 'name', 'path', 'caller'] as const
+
+export type QueryField = typeof QUERY_FIELDS[number]
+
+export type QueryExpression =
+  | { op: 'and' | 'or', terms: QueryExpression[] }
+  | { op: 'not', term: QueryExpression }
+  | { op: 'term', field: QueryField | 'text', value: string }
+
+export interface ParsedQuery {
+  expression: QueryExpression
+  text: string // The longest word without a field outside negations, to rank results by; '' for none
+}
+
+const FIELD_TERM = new RegExp(`^-?(?:${QUERY_FIELDS.join('|')}):`)
+const CALLABLE_KINDS = ['function', 'method']
+
+/**
+ * Whether a query uses the query language rather than being a plain name
+ */
+export function isStructuredQuery(query: string): boolean {
+  return query.split(/[\s()]+/).some(token => FIELD_TERM.test(token))
+}
+
+export function parseSearchQuery(query: string): ParsedQuery {
+  const tokens = tokenize(query)
+  let position = 0
+  const words: string[] = []
+
+  const parseOr = (negated: boolean): QueryExpression => {
+    const terms = [parseAnd(negated)]
+    while (tokens[position] === 'OR') {
+      position++
+      terms.push(parseAnd(negated))
+    }
+    return terms.length === 1 ? terms[0]! : { op: 'or', terms }
+  }
+
+  const parseAnd = (negated: boolean): QueryExpression => {
+    const terms: QueryExpression[] = []
+    while (position < tokens.length && tokens[position] !== ')' && tokens[position] !== 'OR') {
+      terms.push(parseUnary(negated))
+    }
+    if (terms.length === 0) {
+      throw new Error(`Expected a search term ${position < tokens.length ? `before "${tokens[position]}"` : 'at the end of the query'}`)
+    }
+    return terms.length === 1 ? terms[0]! : { op: 'and', terms }
+  }
+
+  const parseUnary = (negated: boolean): QueryExpression => {
+    const token = tokens[position++]!
+    if (token === '-' || token === 'NOT') return { op: 'not', term: parseUnary(!negated) }
+    if (token === '(') {
+      const inner = parseOr(negated)
+      if (tokens[position++] !== ')') throw new Error('Unbalanced parentheses in query')
+      return inner
+    }
+    if (token.startsWith('-') && token.length > 1) {
+      tokens[--position] = token.slice(1)
+      return { op: 'not', term: parseUnary(!negated) }
+    }
+    return parseTerm(token, negated)
+  }
+
+  const parseTerm = (token: string, negated: boolean): QueryExpression => {
+    const separator = token.indexOf(':')
+    const field = separator > 0 ? token.slice(0, separator) : ''
+    if ((QUERY_FIELDS as readonly string[]).includes(field)) {
+      const value = unquote(token.slice(separator + 1))
+      if (!value) throw new Error(`${field}: needs a value`)
+      return { op: 'term', field: field as QueryField, value }
+    }
+    const value = unquote(token)
+    if (!negated) words.push(value)
+    return { op: 'term', field: 'text', value }
+  }
+
+  if (tokens.length === 0) throw new Error('Query is empty')
+  const expression = parseOr(false)
+  if (position < tokens.length) throw new Error('Unbalanced parentheses in query')
+  return { expression, text: words.reduce((longest, word) => word.length > longest.length ? word : longest, '') }
+}
+
+/**
+ * A predicate for declarations matching the expression. `path:` terms match paths relative to
+ * `root`, the project directory; `caller:` terms look up the functions of that name among
+ * `declarations` and match what they call.
+ */
+export function compileQuery(expression: QueryExpression, declarations: TreeNode[], root: string): (node: TreeNode) => boolean {
+  const calleesByCaller = new Map<string, Set<string>>()
+  const calleesOf = (caller: string): Set<string> => {
+    let callees = calleesByCaller.get(caller)
+    if (!callees) {
+      callees = new Set(declarations
+        .filter(node => node.name === caller && node.content && CALLABLE_KINDS.includes(node.symbol?.kind ?? node.type))
+        .flatMap(node => calledNames(node.content!)))
+      calleesByCaller.set(caller, callees)
+    }
+    return callees
+  }
+
+  const matches = (current: QueryExpression, node: TreeNode): boolean => {
+    switch (current.op) {
+      case 'and': return current.terms.every(term => matches(term, node))
+      case 'or': return current.terms.some(term => matches(term, node))
+      case 'not': return !matches(current.term, node)
+      case 'term': return matchesTerm(current.field, current.value, node, root, calleesOf)
+    }
+  }
+  return node => matches(expression, node)
+}
+
+/**
+ * Narrows nodes to the declarations a structured query selects, and gives the word to rank
+ * them by. Plain queries come back unchanged.
+ */
+export function applySearchQuery(query: string, nodes: TreeNode[], root: string): { query: string, nodes: TreeNode[] } {
+  if (!isStructuredQuery(query)) return { query, nodes }
+
+  const { expression, text } = parseSearchQuery(query)
+  const declarations = flatten(nodes).filter(node => node.name && node.type !== 'file')
+  const predicate = compileQuery(expression, declarations, root)
+  // Without children, so the search does not reach members the query left out
+  return { query: text, nodes: declarations.filter(predicate).map(node => ({ ...node, children: undefined })) }
+}
+
+function matchesTerm(field: QueryField | 'text', value: string, node: TreeNode, root: string, calleesOf: (caller: string) => Set<string>): boolean {
+  const name = node.name ?? ''
+  switch (field) {
+    case 'kind':
+      return value.toLowerCase().split(',').some(kind => kind === node.type.toLowerCase() || kind === node.symbol?.kind?.toLowerCase())
+    case 'lang': {
+      const wanted = value.toLowerCase()
+      return getLanguageForFile(node.path)?.name.toLowerCase() === wanted || extname(node.path).slice(1).toLowerCase() === wanted
+    }
+    case 'name':
+      return matchesPattern(name, value, false)
+    case 'path':
+      // Relative, so the directories above the project do not match
+      return matchesPattern(relative(root, node.path).split(sep).join('/'), value.startsWith('~') ? value : `~${value}`, true)
+    case 'caller':
+      return calleesOf(value).has(name)
+    case 'text':
+      return name.toLowerCase().includes(value.toLowerCase())
+  }
+}
+
+// `~text` matches anywhere, `*` is a wildcard, anything else is the whole value ignoring case
+function matchesPattern(subject: string, pattern: string, caseSensitive: boolean): boolean {
+  const contains = pattern.startsWith('~')
+  const body = contains ? pattern.slice(1) : pattern
+  const source = body.split('*').map(part => part.replace(/[.*+?^${}()|[\]\\]/g, '\\$&')).join('.*')
+  return new RegExp(contains ? source : `^${source}$`, caseSensitive ? '' : 'i').test(subject)
+}
+
+function tokenize(query: string): string[] {
+  const tokens: string[] = []
+  let current = ''
+  let quoted = false
+  const flush = () => {
+    if (current) tokens.push(current)
+    current = ''
+  }
+  for (const char of query) {
+    if (char === '"') {
+      quoted = !quoted
+      current += char
+    }
+    else if (quoted) {
+      current += char
+    }
+    else if (/\s/.test(char)) {
+      flush()
+    }
+    else if (char === '(' || char === ')') {
+      flush()
+      tokens.push(char)
+    }
+    else {
+      current += char
+    }
+  }
+  if (quoted) throw new Error('Unterminated quote in query')
+  flush()
+  return tokens
+}
+
+function unquote(value: string): string {
+  return value.replace(/"/g, '')
+}
+
+function flatten(nodes: TreeNode[]): TreeNode[] {
+  const seen = new Set<string>()
+  const all: TreeNode[] = []
+  const visit = (current: TreeNode[]) => {
+    for (const node of current) {
+      if (seen.has(node.id)) continue
+      seen.add(node.id)
+      all.push(node)
+      if (node.children) visit(node.children)
+    }
+  }
+  visit(nodes)
+  return all
+}
@@ -0,0 +1,312 @@
+/**
+ * R - functions, R6/Reference/S4 classes and their methods read from the source text, with
+ * the names a package exports. A package's NAMESPACE file decides what is exported, or the
+ * roxygen `@export` tags it is generated from.
+ */
+
+import { readFileSync } from 'fs'
+import { basename, dirname, join } from 'path'
+import type { SymbolKind, SymbolVisibility, TreeNode } from '../types/core.js'
+
+interface Definition {
+  type: string
+  kind: SymbolKind
+  name: string
+  start: number
+  end: number
+  signature: string
+  container?: string
+  visibility?: SymbolVisibility // Set for class members; top-level names follow the exports
+}
+
+export interface NamespaceExports {
+  names: Set<string>
+  patterns: RegExp[]
+}
+
+const MAX_SIGNATURE_LENGTH = 200
+
+const NAME = String.raw`([A-Za-z.][\w.]*|\x60[^\x60\n]+\x60)`
+const FUNCTION_ASSIGNMENT = new RegExp(String.raw`(?<![\w.$@])${NAME}\s*(?:<<?-|=(?!=))\s*(?:function\b|\\\()`, 'g')
+const CLASS_ASSIGNMENT = new RegExp(String.raw`(?<![\w.$@])${NAME}\s*(?:<<?-|=(?!=))\s*(?:R6::)?(R6Class|setRefClass)\s*\(`, 'g')
+const S4_CALL = /(?<![\w.$@])(setClass|setGeneric|setMethod)\s*\(\s*"/g
+const MEMBER_LISTS: Record<string, SymbolVisibility> = { public: 'public', active: 'public', private: 'private', methods: 'public' }
+
+/**
+ * `function` nodes for functions assigned at the top level and S4 generics, `class` nodes for
+ * R6, Reference and S4 classes, and `method` nodes for their methods with the class as
+ * `symbol.container`. In a package with a NAMESPACE file, names it does not export are
+ * `internal`.
+ */
+export function extractRDefinitions(content: string, filePath: string): TreeNode[] {
+  const code = maskR(content)
+  const depths = nestingDepths(code)
+  const definitions: Definition[] = []
+
+  for (const match of code.matchAll(CLASS_ASSIGNMENT)) {
+    if (depths[match.index!]! > 0) continue
+    const open = match.index! + match[0].length - 1
+    const close = closingParen(code, open)
+    const name = unquote(match[1]!)
+    const className = stringArguments(content, code, open, close)[0]
+    const signature = `${oneLine(content.substring(match.index!, open + 1))}${className ? `"${className}"` : ''})`
+    definitions.push({ type: 'class', kind: 'class', name, start: match.index!, end: close, signature })
+    definitions.push(...readMembers(content, code, open, close, name))
+  }
+
+  // Functions inside a class definition are its methods, found above
+  const inClass = (offset: number) => definitions.some(definition => definition.type === 'class' && definition.start < offset && offset < definition.end)
+  for (const match of code.matchAll(FUNCTION_ASSIGNMENT)) {
+    if (depths[match.index!]! > 0 || inClass(match.index!)) continue
+    const fn = readFunction(content, code, match.index!, match.index! + match[0].length)
+    definitions.push({ type: 'function', kind: 'function', name: unquote(match[1]!), ...fn })
+  }
+
+  for (const match of code.matchAll(S4_CALL)) {
+    if (depths[match.index!]! > 0) continue
+    const open = code.indexOf('(', match.index!)
+    const close = closingParen(code, open)
+    const [name, className] = stringArguments(content, code, open, close)
+    if (!name) continue
+    const firstLineEnd = code.indexOf('\n', match.index!)
+    const signature = oneLine(content.substring(match.index!, firstLineEnd === -1 ? close : Math.min(close, firstLineEnd)))
+    if (match[1] === 'setClass') definitions.push({ type: 'class', kind: 'class', name, start: match.index!, end: close, signature })
+    else if (match[1] === 'setGeneric') definitions.push({ type: 'function', kind: 'function', name, start: match.index!, end: close, signature })
+    else definitions.push({ type: 'method', kind: 'method', name, start: match.index!, end: close, signature, ...(className ? { container: className } : {}) })
+  }
+
+  const namespace = findNamespace(filePath)
+  const roxygenExports = !namespace && /^\s*#'\s*@export\b/m.test(content)
+  const lines = content.split('\n')
+  return definitions
+    .sort((a, b) => a.start - b.start)
+    .map(definition => {
+      const doc = readRoxygen(content, definition.start)
+      let visibility = definition.visibility
+      if (!visibility) {
+        const exported = namespace ? isExported(namespace, definition.name) : !roxygenExports || Boolean(doc?.exported)
+        visibility = exported ? 'public' : 'internal'
+      }
+      return toNode(content, lines, filePath, { ...definition, visibility }, doc?.text)
+    })
+}
+
+function readFunction(content: string, code: string, start: number, keywordEnd: number): Pick<Definition, 'start' | 'end' | 'signature'> {
+  const paramsOpen = code.indexOf('(', keywordEnd - 1)
+  const paramsEnd = paramsOpen === -1 ? keywordEnd : closingParen(code, paramsOpen)
+  const body = code.substring(paramsEnd).match(/^\s*/)![0].length + paramsEnd
+  // A braced body, or a single expression running to the end of its line
+  const end = code[body] === '{' ? closingBrace(code, body) : lineEnd(code, body)
+  return { start, end, signature: oneLine(content.substring(start, paramsEnd)) }
+}
+
+/**
+ * The methods an R6 class lists in `public`, `private` and `active`, or a Reference class in
+ * `methods`
+ */
+function readMembers(content: string, code: string, open: number, close: number, className: string): Definition[] {
+  const members: Definition[] = []
+  for (const list of code.substring(open, close).matchAll(/(?<![\w.])(public|private|active|methods)\s*=\s*list\s*\(/g)) {
+    const listOpen = open + list.index! + list[0].length - 1
+    const listClose = closingParen(code, listOpen)
+    const listText = code.substring(listOpen, listClose)
+    for (const match of listText.matchAll(FUNCTION_ASSIGNMENT)) {
+      const offset = listOpen + match.index!
+      // Entries of the list itself, not functions defined inside its methods
+      if (parenDepth(code, listOpen, offset) !== 1) continue
+      const fn = readFunction(content, code, offset, offset + match[0].length)
+      members.push({ type: 'method', kind: 'method', name: unquote(match[1]!), container: className, visibility: MEMBER_LISTS[list[1]!], ...fn })
+    }
+  }
+  return members
+}
+
+/**
+ * The exports of the package a file belongs to: its NAMESPACE file, next to the `R/`
+ * directory holding the sources
+ */
+function findNamespace(filePath: string): NamespaceExports | undefined {
+  const directory = dirname(filePath)
+  if (basename(directory) !== 'R') return undefined
+  try {
+    return readNamespaceExports(readFileSync(join(dirname(directory), 'NAMESPACE'), 'utf-8'))
+  }
+  catch {
+    // Not a package, or its NAMESPACE cannot be read; every name is public
+    return undefined
+  }
+}
+
+/**
+ * Reads `export`, `exportPattern`, `exportClasses`, `exportMethods` and `S3method` directives
+ */
+export function readNamespaceExports(text: string): NamespaceExports {
+  const names = new Set<string>()
+  const patterns: RegExp[] = []
+  const code = text.replace(/#[^\n]*/g, '')
+  for (const match of code.matchAll(/\b(export|exportPattern|exportClasses|exportClass|exportMethods|S3method)\s*\(([^)]*)\)/g)) {
+    const args = match[2]!.split(',').map(arg => arg.trim().replace(/^["'`]|["'`]$/g, '')).filter(Boolean)
+    if (match[1] === 'exportPattern') {
+      for (const pattern of args) {
+        try {
+          patterns.push(new RegExp(pattern.replace(/\\\\/g, '\\')))
+        }
+        catch {
+          // An invalid pattern exports nothing
+        }
+      }
+    }
+    else if (match[1] === 'S3method' && args.length >= 2) {
+      names.add(`${args[0]}.${args[1]}`)
+    }
+    else {
+      args.forEach(name => names.add(name))
+    }
+  }
+  return { names, patterns }
+}
+
+function isExported(namespace: NamespaceExports, name: string): boolean {
+  return namespace.names.has(name) || namespace.patterns.some(pattern => pattern.test(name))
+}
+
+/**
+ * The string literals among a call's arguments, in order
+ */
+function stringArguments(content: string, code: string, open: number, close: number): string[] {
+  const strings: string[] = []
+  for (const match of code.substring(open, close).matchAll(/"[^"\n]*"/g)) {
+    if (parenDepth(code, open, open + match.index!) !== 1) continue
+    strings.push(content.substring(open + match.index! + 1, open + match.index! + match[0].length - 1))
+  }
+  return strings
+}
+
+function toNode(content: string, lines: string[], filePath: string, definition: Definition & { visibility: SymbolVisibility }, doc?: string): TreeNode {
+  const startLine = lineAt(content, definition.start)
+  const endLine = lineAt(content, definition.end)
+  return {
+    id: `r-${definition.type}-${filePath}-${startLine}-${definition.name}`,
+    type: definition.type,
+    name: definition.name,
+    path: filePath,
+    startLine,
+    endLine,
+    content: lines.slice(startLine - 1, endLine).join('\n'),
+    symbol: {
+      kind: definition.kind,
+      visibility: definition.visibility,
+      signature: definition.signature.substring(0, MAX_SIGNATURE_LENGTH),
+      ...(definition.container ? { container: definition.container } : {}),
+      ...(doc ? { doc } : {}),
+    },
+  }
+}
+
+/**
+ * The roxygen `#'` block above a definition: its text without the tags, and whether it has
+ * an `@export` tag
+ */
+function readRoxygen(content: string, start: number): { text?: string, exported: boolean } | undefined {
+  const lines = content.substring(0, start).split('\n').slice(0, -1)
+  const block: string[] = []
+  for (let index = lines.length - 1; index >= 0 && /^\s*#'/.test(lines[index]!); index--) {
+    block.unshift(lines[index]!.replace(/^\s*#'\s?/, '').trimEnd())
+  }
+  if (block.length === 0) return undefined
+
+  const text: string[] = []
+  let inTag = false
+  for (const line of block) {
+    // Tags run to the next tag; @description and @details continue the text
+    if (/^@/.test(line)) inTag = !/^@(?:description|details)\b/.test(line)
+    if (!inTag) text.push(line.replace(/^@(?:description|details)\s*/, ''))
+  }
+  return { text: text.join('\n').trim() || undefined, exported: block.some(line => /^@export\b/.test(line)) }
+}
+
+/**
+ * The brace and parenthesis depth at every offset, so top-level assignments can be told from
+ * local ones and from named arguments
+ */
+function nestingDepths(code: string): number[] {
+  const depths: number[] = new Array(code.length + 1)
+  let depth = 0
+  for (let index = 0; index < code.length; index++) {
+    if (code[index] === '}' || code[index] === ')') depth = Math.max(0, depth - 1)
+    depths[index] = depth
+    if (code[index] === '{' || code[index] === '(') depth++
+  }
+  depths[code.length] = depth
+  return depths
+}
+
+function parenDepth(code: string, from: number, to: number): number {
+  let depth = 0
+  for (let index = from; index < to; index++) {
+    if (code[index] === '(') depth++
+    else if (code[index] === ')') depth--
+  }
+  return depth
+}
+
+function closingParen(code: string, open: number): number {
+  let depth = 0
+  for (let index = open; index < code.length; index++) {
+    if (code[index] === '(') depth++
+    else if (code[index] === ')' && --depth === 0) return index + 1
+  }
+  return code.length
+}
+
+function closingBrace(code: string, open: number): number {
+  let depth = 0
+  for (let index = open; index < code.length; index++) {
+    if (code[index] === '{') depth++
+    else if (code[index] === '}' && --depth === 0) return index + 1
+  }
+  return code.length
+}
+
+/**
+ * The end of an unbraced body: its line, continued while brackets are open
+ */
+function lineEnd(code: string, from: number): number {
+  let depth = 0
+  for (let index = from; index < code.length; index++) {
+    const char = code[index]
+    if (char === '(' || char === '[' || char === '{') depth++
+    else if (char === ')' || char === ']' || char === '}') {
+      if (--depth < 0) return index
+    }
+    else if ((char === '\n' || char === ',') && depth === 0) return index
+  }
+  return code.length
+}
+
+function unquote(name: string): string {
+  return name.replace(/^`|`$/g, '')
+}
+
+/**
+ * Blanks comments and string contents, keeping offsets, line numbers and the quotes, and raw
+ * strings like `r"(...)"`
+ */
+export function maskR(content: string): string {
+  return content.replace(
+    /#[^\n]*|[rR]"(-*)[([{][\s\S]*?[)\]}]\1"|[rR]'(-*)[([{][\s\S]*?[)\]}]\2'|"(?:\\.|[^"\\])*"|'(?:\\.|[^'\\])*'/g,
+    match => match.startsWith('#')
+      ? match.replace(/[^\n]/g, ' ')
+      : /^[rR]/.test(match)
+        ? ` ${match[1]}${match.slice(2, -1).replace(/[^\n]/g, ' ')}${match[1]}`
+        : match[0] + match.slice(1, -1).replace(/[^\n]/g, ' ') + match[0],
+  )
+}
+
+function oneLine(text: string): string {
+  return text.replace(/\s+/g, ' ').trim()
+}
+
+function lineAt(content: string, index: number): number {
+  return content.substring(0, index).split('\n').length
+}
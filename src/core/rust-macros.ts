@@ -0,0 +1,323 @@
+/**
+ * Rust macro heuristics - symbols that common macros declare inside token trees the grammar
+ * leaves unparsed: `macro_rules!` definitions, the statics of `lazy_static!` and
+ * `thread_local!`, `bitflags!` types with their flags, and what the `derive_builder::Builder`
+ * and `thiserror::Error` derives generate
+ */
+
+import type { SymbolKind, SymbolVisibility, TreeNode } from '../types/core.js'
+
+interface RustSource {
+  content: string
+  code: string // Content with comments and literals blanked out, offsets unchanged
+  filePath: string
+  lineStarts: number[]
+}
+
+interface MacroSymbol {
+  type: string
+  kind: SymbolKind
+  name: string
+  start: number
+  end: number
+  signature: string
+  visibility: SymbolVisibility
+  container?: string
+  macro?: string
+}
+
+const MAX_SIGNATURE_LENGTH = 200
+
+const ATTRIBUTES = String.raw`((?:#\[[^\]]*\]\s*)*)`
+const VISIBILITY = String.raw`(?:(pub\b(?:\s*\([^)]*\))?)\s+)?`
+const IDENTIFIER = String.raw`([A-Za-z_]\w*)`
+
+const MACRO_RULES = new RegExp(String.raw`^[ \t]*${ATTRIBUTES}macro_rules!\s*${IDENTIFIER}\s*[{([]`, 'gm')
+const STATIC_BLOCK = /\b(lazy_static|thread_local)!\s*\{/g
+const STATIC_ITEM = new RegExp(String.raw`${ATTRIBUTES}${VISIBILITY}static\s+(?:ref\s+)?${IDENTIFIER}\s*:[^=;]+=`, 'g')
+const BITFLAGS_BLOCK = /\bbitflags!\s*\{/g
+const BITFLAGS_STRUCT = new RegExp(String.raw`${ATTRIBUTES}${VISIBILITY}struct\s+${IDENTIFIER}\s*:\s*[\w:]+\s*\{`, 'g')
+const FLAG = new RegExp(String.raw`${ATTRIBUTES}const\s+${IDENTIFIER}\s*=`, 'g')
+const DERIVING_ITEM = new RegExp(String.raw`^[ \t]*((?:#\[[^\]]*\]\s*)+)${VISIBILITY}(struct|enum)\s+${IDENTIFIER}`, 'gm')
+
+/**
+ * Text-based extraction added to the syntax tree's nodes for Rust files. Declarations inside a
+ * macro invocation carry `symbol.macro` naming the macro; `macro_rules!` definitions become
+ * `macro` nodes.
+ */
+export function extractRustMacroDeclarations(content: string, filePath: string): TreeNode[] {
+  const source: RustSource = {
+    content,
+    code: maskCommentsAndLiterals(content),
+    filePath,
+    lineStarts: [0, ...[...content.matchAll(/\n/g)].map(match => match.index! + 1)],
+  }
+
+  return [
+    ...readMacroRules(source),
+    ...readStaticBlocks(source),
+    ...readBitflags(source),
+    ...readDerives(source),
+  ].map(symbol => toNode(source, symbol))
+}
+
+function readMacroRules(source: RustSource): MacroSymbol[] {
+  return [...source.code.matchAll(MACRO_RULES)].map((match) => {
+    const start = match.index! + match[0].search(/\S/)
+    const close = findClosing(source.code, match.index! + match[0].length - 1)
+    return {
+      type: 'macro',
+      kind: 'macro',
+      name: match[2]!,
+      start,
+      end: close === -1 ? source.code.length : close + 1,
+      signature: `macro_rules! ${match[2]}`,
+      // Without #[macro_export] a macro is only usable below its definition in the crate
+      visibility: /#\[\s*macro_export\b/.test(match[1]!) ? 'public' : 'private',
+    }
+  })
+}
+
+function readStaticBlocks(source: RustSource): MacroSymbol[] {
+  const symbols: MacroSymbol[] = []
+  for (const block of source.code.matchAll(STATIC_BLOCK)) {
+    const open = block.index! + block[0].length - 1
+    const close = findClosing(source.code, open)
+    if (close === -1) continue
+
+    for (const item of source.code.substring(open + 1, close).matchAll(STATIC_ITEM)) {
+      const start = open + 1 + item.index!
+      const declaration = start + item[1]!.length
+      symbols.push({
+        type: 'variable',
+        kind: 'variable',
+        name: item[3]!,
+        start: declaration,
+        end: findItemEnd(source.code, start + item[0].length, close),
+        signature: signatureOf(source.code.substring(declaration, start + item[0].length - 1)),
+        visibility: visibilityOf(item[2]),
+        macro: block[1],
+      })
+    }
+  }
+  return symbols
+}
+
+function readBitflags(source: RustSource): MacroSymbol[] {
+  const symbols: MacroSymbol[] = []
+  for (const block of source.code.matchAll(BITFLAGS_BLOCK)) {
+    const open = block.index! + block[0].length - 1
+    const close = findClosing(source.code, open)
+    if (close === -1) continue
+
+    for (const item of source.code.substring(open + 1, close).matchAll(BITFLAGS_STRUCT)) {
+      const start = open + 1 + item.index! + item[1]!.length
+      const structOpen = open + item.index! + item[0].length
+      const structClose = findClosing(source.code, structOpen)
+      if (structClose === -1) continue
+      const name = item[3]!
+      const visibility = visibilityOf(item[2])
+
+      symbols.push({
+        type: 'class',
+        kind: 'struct',
+        name,
+        start,
+        end: structClose + 1,
+        signature: signatureOf(source.code.substring(start, structOpen)),
+        visibility,
+        macro: 'bitflags',
+      })
+      for (const flag of source.code.substring(structOpen + 1, structClose).matchAll(FLAG)) {
+        const flagStart = structOpen + 1 + flag.index! + flag[1]!.length
+        symbols.push({
+          type: 'variable',
+          kind: 'variable',
+          name: flag[2]!,
+          start: flagStart,
+          end: findItemEnd(source.code, structOpen + 1 + flag.index! + flag[0].length, structClose),
+          signature: `${item[2] ? `${item[2]} ` : ''}const ${flag[2]}: ${name}`,
+          visibility,
+          container: name,
+          macro: 'bitflags',
+        })
+      }
+    }
+  }
+  return symbols
+}
+
+/**
+ * Symbols generated by derives: the builder type of `#[derive(Builder)]`, and the variants of
+ * a thiserror error enum, whose `#[error("...")]` messages are what logs and callers show
+ */
+function readDerives(source: RustSource): MacroSymbol[] {
+  const symbols: MacroSymbol[] = []
+  const importsThiserror = /\bthiserror\b/.test(source.code)
+
+  for (const item of source.code.matchAll(DERIVING_ITEM)) {
+    const derives = [...item[1]!.matchAll(/#\[\s*derive\s*\(([^)]*)\)/g)]
+      .flatMap(derive => derive[1]!.split(',').map(path => path.trim()).filter(Boolean))
+    if (derives.length === 0) continue
+
+    const start = item.index! + item[0].search(/\S/)
+    const nameEnd = item.index! + item[0].length
+    const end = findItemEnd(source.code, nameEnd)
+    const [attributes, modifier, keyword, name] = [item[1]!, item[2], item[3], item[4]!]
+    const visibility = visibilityOf(modifier)
+
+    const builder = derives.find(path => path.replace(/^.*::/, '') === 'Builder')
+    if (builder && keyword === 'struct') {
+      // #[builder(name = "...")] renames the builder; the string is read from the unmasked text
+      const attributeText = source.content.substring(start, start + attributes.length)
+      const builderName = attributeText.match(/#\[\s*builder\s*\([^\]]*\bname\s*=\s*"(\w+)"/)?.[1] ?? `${name}Builder`
+      symbols.push({
+        type: 'class',
+        kind: 'struct',
+        name: builderName,
+        start,
+        end,
+        signature: `${modifier ? `${modifier} ` : ''}struct ${builderName}`,
+        visibility,
+        macro: builder,
+      })
+    }
+
+    const error = derives.find(path => path === 'thiserror::Error' || (path === 'Error' && importsThiserror))
+    const open = source.code.indexOf('{', nameEnd)
+    if (error && keyword === 'enum' && open !== -1 && open < end) {
+      for (const variant of splitVariants(source.code, open, end - 1)) {
+        symbols.push({
+          type: 'variant',
+          kind: 'variant',
+          name: variant.name,
+          start: variant.start,
+          end: variant.end,
+          signature: signatureOf(source.code.substring(variant.nameStart, variant.end)),
+          visibility,
+          container: name,
+          macro: 'thiserror::Error',
+        })
+      }
+    }
+  }
+  return symbols
+}
+
+/**
+ * The variants between an enum's braces, each starting at its first attribute
+ */
+function splitVariants(code: string, open: number, close: number): { name: string, start: number, nameStart: number, end: number }[] {
+  const variants: { name: string, start: number, nameStart: number, end: number }[] = []
+  const push = (from: number, to: number) => {
+    const text = code.substring(from, to)
+    const match = text.match(new RegExp(String.raw`^\s*${ATTRIBUTES}${IDENTIFIER}`))
+    if (!match) return
+    const start = from + text.search(/\S/)
+    variants.push({ name: match[2]!, start, nameStart: from + match[0].length - match[2]!.length, end: from + text.trimEnd().length })
+  }
+
+  let depth = 0
+  let from = open + 1
+  for (let i = open + 1; i < close; i++) {
+    const char = code[i]!
+    if ('([{'.includes(char)) depth++
+    else if (')]}'.includes(char)) depth--
+    else if (char === ',' && depth === 0) {
+      push(from, i)
+      from = i + 1
+    }
+  }
+  push(from, close)
+  return variants
+}
+
+function toNode(source: RustSource, symbol: MacroSymbol): TreeNode {
+  const startLine = lineAt(source, symbol.start)
+  const doc = docAbove(source.content.split('\n'), startLine)
+  return {
+    id: `${symbol.type === 'macro' ? 'macro' : 'macro-decl'}-${source.filePath}-${startLine}-${symbol.name}`,
+    type: symbol.type,
+    name: symbol.name,
+    path: source.filePath,
+    startLine,
+    endLine: lineAt(source, Math.max(symbol.start, symbol.end - 1)),
+    content: source.content.substring(symbol.start, symbol.end),
+    symbol: {
+      kind: symbol.kind,
+      visibility: symbol.visibility,
+      signature: symbol.signature,
+      ...(symbol.container ? { container: symbol.container } : {}),
+      ...(doc ? { doc } : {}),
+      ...(symbol.macro ? { macro: symbol.macro } : {}),
+    },
+  }
+}
+
+/**
+ * The `///` doc comment on the lines above `line`, skipping attributes between them
+ */
+function docAbove(lines: string[], line: number): string | undefined {
+  const doc: string[] = []
+  for (let index = line - 2; index >= 0; index--) {
+    const text = lines[index]!.trim()
+    if (text.startsWith('///')) doc.unshift(text.replace(/^\/\/\/\s?/, ''))
+    else if (!text.startsWith('#[')) break
+  }
+  return doc.length > 0 ? doc.join('\n').trim() : undefined
+}
+
+function visibilityOf(modifier: string | undefined): SymbolVisibility {
+  if (!modifier) return 'private'
+  return modifier.includes('(') ? 'internal' : 'public'
+}
+
+function signatureOf(text: string): string {
+  return text.replace(/\s+/g, ' ').trim().substring(0, MAX_SIGNATURE_LENGTH)
+}
+
+function lineAt(source: RustSource, index: number): number {
+  return source.lineStarts.filter(start => start <= index).length
+}
+
+/**
+ * Index of the bracket closing the one at `open`, or -1
+ */
+function findClosing(code: string, open: number): number {
+  let depth = 0
+  for (let i = open; i < code.length; i++) {
+    if ('([{'.includes(code[i]!)) depth++
+    else if (')]}'.includes(code[i]!) && --depth === 0) return i
+  }
+  return -1
+}
+
+/**
+ * End of the item continuing at `from`: past its top-level `;`, or its braced body unless an
+ * expression goes on after it (`Foo { .. }.into();`), stopping at `limit`
+ */
+function findItemEnd(code: string, from: number, limit = code.length): number {
+  let depth = 0
+  for (let i = from; i < limit; i++) {
+    const char = code[i]!
+    if ('([{'.includes(char)) depth++
+    else if (')]}'.includes(char)) {
+      depth--
+      if (depth < 0) return i
+      if (depth === 0 && char === '}' && !/^\s*[;.?]/.test(code.substring(i + 1, i + 40))) return i + 1
+    }
+    else if (char === ';' && depth === 0) return i + 1
+  }
+  return limit
+}
+
+/**
+ * Blanks comments, strings and character literals so brackets and keywords inside them are
+ * not read as code. Lifetimes (`'a`) are left alone.
+ */
+function maskCommentsAndLiterals(content: string): string {
+  return content.replace(
+    /\/\/[^\n]*|\/\*[\s\S]*?\*\/|b?r(#*)"[\s\S]*?"\1|b?"(?:\\[\s\S]|[^"\\])*"|b?'(?:\\[^']{1,10}|[^'\\\n])'/g,
+    match => match.replace(/[^\n]/g, ' '),
+  )
+}
@@ -0,0 +1,211 @@
+/**
+ * Scala - classes, objects, traits, enums and their methods read from the source text, for
+ * brace-delimited bodies and Scala 3's indented ones alike
+ */
+
+import type { SymbolKind, SymbolVisibility, TreeNode } from '../types/core.js'
+
+interface Definition {
+  type: string
+  kind: SymbolKind
+  name: string
+  start: number
+  end: number
+  signature: string
+  visibility: SymbolVisibility
+  container?: string
+}
+
+const MAX_SIGNATURE_LENGTH = 200
+
+const MODIFIERS = String.raw`(?:(?:private|protected)(?:\[\w+\])?|final|sealed|abstract|implicit|override|lazy|inline|transparent|open|case)\s+`
+const TEMPLATE = new RegExp(String.raw`^[ \t]*((?:${MODIFIERS})*)(class|object|trait|enum)\s+([\w$]+|\x60[^\x60\n]+\x60)`, 'gm')
+const METHOD = new RegExp(String.raw`^[ \t]*((?:${MODIFIERS})*)def\s+([\w$]+|\x60[^\x60\n]+\x60|[!#%&*+\-/:<=>?@\\^|~]+)`, 'gm')
+const TEMPLATE_KINDS: Record<string, SymbolKind> = { class: 'class', object: 'class', trait: 'trait', enum: 'enum' }
+
+/**
+ * `class` nodes for classes, case classes, objects, traits and enums, `method` nodes for the
+ * `def`s in their bodies with the innermost one as `symbol.container`, and `function` nodes for
+ * top-level `def`s
+ */
+export function extractScalaDefinitions(content: string, filePath: string): TreeNode[] {
+  const code = maskScala(content)
+  const templates: Definition[] = []
+
+  for (const match of code.matchAll(TEMPLATE)) {
+    const start = match.index! + match[0].search(/\S/)
+    const headerEnd = readHeader(code, match.index! + match[0].length, false)
+    templates.push({
+      type: 'class',
+      kind: TEMPLATE_KINDS[match[2]!]!,
+      name: match[3]!.replace(/`/g, ''),
+      start,
+      end: readBody(code, start, headerEnd),
+      signature: oneLine(content.substring(start, headerEnd)),
+      visibility: toVisibility(match[1]!),
+    })
+  }
+
+  const methods: Definition[] = []
+  for (const match of code.matchAll(METHOD)) {
+    const start = match.index! + match[0].search(/\S/)
+    const headerEnd = readHeader(code, match.index! + match[0].length, true)
+    const owner = innermost(templates, start)
+    methods.push({
+      type: owner ? 'method' : 'function',
+      kind: owner ? 'method' : 'function',
+      name: match[2]!.replace(/`/g, ''),
+      start,
+      end: readBody(code, start, headerEnd),
+      signature: oneLine(content.substring(start, headerEnd)),
+      visibility: toVisibility(match[1]!),
+      ...(owner ? { container: owner.name } : {}),
+    })
+  }
+  for (const template of templates) {
+    const owner = innermost(templates, template.start)
+    if (owner) template.container = owner.name
+  }
+
+  const lines = content.split('\n')
+  return [...templates, ...methods]
+    .sort((a, b) => a.start - b.start)
+    .map((definition) => {
+      const startLine = lineAt(content, definition.start)
+      const endLine = lineAt(content, definition.end)
+      const doc = readDocComment(content, definition.start)
+      return {
+        id: `scala-${definition.type}-${filePath}-${startLine}-${definition.name}`,
+        type: definition.type,
+        name: definition.name,
+        path: filePath,
+        startLine,
+        endLine,
+        content: lines.slice(startLine - 1, endLine).join('\n'),
+        symbol: {
+          kind: definition.kind,
+          visibility: definition.visibility,
+          signature: definition.signature.substring(0, MAX_SIGNATURE_LENGTH),
+          ...(definition.container ? { container: definition.container } : {}),
+          ...(doc ? { doc } : {}),
+        },
+      }
+    })
+}
+
+/**
+ * Where a declaration's header ends: at the `{` or Scala 3 `:` opening its body, at the `=` of
+ * a method, or at the end of the line outside brackets. `extends` and `with` clauses continue
+ * onto the next line.
+ */
+function readHeader(code: string, from: number, isMethod: boolean): number {
+  let depth = 0
+  for (let index = from; index < code.length; index++) {
+    const char = code[index]!
+    if (char === '(' || char === '[') depth++
+    else if (char === ')' || char === ']') depth--
+    if (depth > 0) continue
+
+    if (char === '{') return index
+    if (isMethod && char === '=' && !/[=>]/.test(code[index + 1] ?? '') && !/[=!<>]/.test(code[index - 1] ?? '')) return index
+    if (!isMethod && char === ':' && /^[ \t]*(?:\n|$)/.test(code.substring(index + 1))) return index
+    if (char === '\n' && !/^\s*(?:extends|with|derives)\b/.test(code.substring(index + 1))) return index
+  }
+  return code.length
+}
+
+/**
+ * The end of a body opened at `headerEnd`: its closing brace, or for bodies that are indented
+ * rather than braced, the last line indented deeper than the declaration
+ */
+function readBody(code: string, start: number, headerEnd: number): number {
+  const rest = code.substring(headerEnd).match(/^[=\s]*\{/)
+  if (code[headerEnd] === '{' || (code[headerEnd] === '=' && rest)) {
+    return closingBrace(code, headerEnd + rest![0].length - 1)
+  }
+  if (code[headerEnd] !== ':' && code[headerEnd] !== '=') return headerEnd
+
+  const indent = code.substring(code.lastIndexOf('\n', start - 1) + 1, start).length
+  let end = code.indexOf('\n', headerEnd)
+  if (end === -1) return code.length
+  // An expression body that starts on the declaration's line may end there
+  if (code[headerEnd] === '=' && code.substring(headerEnd + 1, end).trim()) {
+    if (!/^\n[ \t]+/.test(code.substring(end)) || lineIndent(code, end + 1) <= indent) return end
+  }
+  while (end < code.length) {
+    const next = code.indexOf('\n', end + 1)
+    const line = code.substring(end + 1, next === -1 ? code.length : next)
+    if (line.trim() && lineIndent(code, end + 1) <= indent) break
+    if (line.trim()) end = next === -1 ? code.length : next
+    else if (next === -1) break
+    else {
+      // Blank lines belong to the body only if it continues after them
+      const following = code.substring(next + 1).match(/^(?:[ \t]*\n)*([ \t]*)\S/)
+      if (!following || following[1]!.length <= indent) break
+      end = next
+    }
+  }
+  return end
+}
+
+function lineIndent(code: string, lineStart: number): number {
+  return code.substring(lineStart).match(/^[ \t]*/)![0].length
+}
+
+function innermost(definitions: Definition[], offset: number): Definition | undefined {
+  let found: Definition | undefined
+  for (const definition of definitions) {
+    if (definition.start < offset && offset < definition.end && (!found || definition.start > found.start)) found = definition
+  }
+  return found
+}
+
+function toVisibility(modifiers: string): SymbolVisibility {
+  // `private[pkg]` is visible throughout the package; `private[this]` is private
+  const qualified = modifiers.match(/\b(?:private|protected)\[(\w+)\]/)
+  if (qualified && qualified[1] !== 'this') return 'internal'
+  if (/\bprivate\b/.test(modifiers)) return 'private'
+  if (/\bprotected\b/.test(modifiers)) return 'protected'
+  return 'public'
+}
+
+/**
+ * The `/** ... *\/` Scaladoc block directly above a declaration
+ */
+function readDocComment(content: string, start: number): string | undefined {
+  const before = content.substring(0, start).replace(/\s+$/, '')
+  const block = before.match(/\/\*\*((?:(?!\*\/)[\s\S])*)\*\/$/)
+  return block?.[1]!.split('\n').map(line => line.replace(/^\s*\*?\s?/, '').trimEnd()).join('\n').trim() || undefined
+}
+
+function closingBrace(code: string, open: number): number {
+  let depth = 0
+  for (let index = open; index < code.length; index++) {
+    if (code[index] === '{') depth++
+    else if (code[index] === '}' && --depth === 0) return index + 1
+  }
+  return code.length
+}
+
+/**
+ * Blanks comments and the contents of strings, triple-quoted and interpolated ones included,
+ * and character literals, keeping offsets and line numbers
+ */
+export function maskScala(content: string): string {
+  return content.replace(
+    /\/\/[^\n]*|\/\*[\s\S]*?\*\/|"""[\s\S]*?"""|"(?:\\.|[^"\\\n])*"|'(?:\\.|[^'\\\n])'/g,
+    (match) => {
+      if (match.startsWith('/')) return match.replace(/[^\n]/g, ' ')
+      const quote = match.startsWith('"""') ? '"""' : match[0]!
+      return quote + match.slice(quote.length, -quote.length).replace(/[^\n]/g, ' ') + quote
+    },
+  )
+}
+
+function oneLine(text: string): string {
+  return text.replace(/\s+/g, ' ').trim()
+}
+
+function lineAt(content: string, index: number): number {
+  return content.substring(0, index).split('\n').length
+}
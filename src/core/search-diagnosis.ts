@@ -0,0 +1,195 @@
+/**
+ * Empty search diagnosis - when a search finds nothing, says where the files went (indexing,
+ * scope, filters) and whether the name exists in another spelling, so a misconfigured
+ * project root or filter shows up in the response instead of as a silent empty list. Also
+ * the did-you-mean names symbol queries with few results return.
+ */
+
+import { getLanguageForFile } from './languages.js'
+import type { TreeNode } from '../types/core.js'
+
+export interface SearchDiagnosis {
+  filesIndexed: number // Files of the project within the call's scope
+  filesSearched: number // After target, includeProjects, buildTags and defines
+  filesMatchingPath: number // Of those, files whose path contains pathPattern
+  languages: Record<string, number> // Files matching the filters, by language
+  caseInsensitiveMatches: Array<{ name: string, type: string, path: string }> // Anywhere in the index, ignoring filters
+  suggestions: string[] // Nearest symbol names, closest first
+  hints: string[]
+}
+
+export interface DiagnosisOptions {
+  indexed: TreeNode[] // Every node of the project within scope
+  searched: TreeNode[] // The nodes the search ran on
+  pathPattern?: string
+  types?: string[]
+  symbols?: boolean // Whether the query names a symbol; string searches skip name matching
+}
+
+const MAX_MATCHES = 5
+const MAX_SUGGESTIONS = 5
+
+export function diagnoseEmptySearch(query: string, options: DiagnosisOptions): SearchDiagnosis {
+  const { indexed, searched, pathPattern, types = [], symbols = true } = options
+  const searchedFiles = filesOf(searched)
+  const matchingFiles = pathPattern ? [...searchedFiles].filter(path => path.includes(pathPattern)) : [...searchedFiles]
+
+  const languages: Record<string, number> = {}
+  for (const path of matchingFiles) {
+    const language = getLanguageForFile(path)?.name ?? 'other'
+    languages[language] = (languages[language] ?? 0) + 1
+  }
+
+  const diagnosis: SearchDiagnosis = {
+    filesIndexed: filesOf(indexed).size,
+    filesSearched: searchedFiles.size,
+    filesMatchingPath: matchingFiles.length,
+    languages,
+    caseInsensitiveMatches: [],
+    suggestions: [],
+    hints: [],
+  }
+
+  if (symbols && query) {
+    const lowered = query.toLowerCase()
+    walk(indexed, (node) => {
+      if (node.name?.toLowerCase() === lowered && node.type !== 'file' && diagnosis.caseInsensitiveMatches.length < MAX_MATCHES) {
+        diagnosis.caseInsensitiveMatches.push({ name: node.name, type: node.type, path: node.path })
+      }
+    })
+    diagnosis.suggestions = nearestNames(query, symbolNames(indexed))
+  }
+
+  diagnosis.hints = hintsFor(diagnosis, { pathPattern, types })
+  return diagnosis
+}
+
+function hintsFor(diagnosis: SearchDiagnosis, filters: { pathPattern?: string, types: string[] }): string[] {
+  const hints: string[] = []
+  if (diagnosis.filesIndexed === 0) {
+    hints.push('No files are indexed for this project: check that the directory is the project root, and its ignoreDirs and scope')
+  }
+  else if (diagnosis.filesSearched === 0) {
+    hints.push('target, includeProjects, buildTags or defines excluded every indexed file')
+  }
+  else if (diagnosis.filesMatchingPath === 0 && filters.pathPattern) {
+    hints.push(`No searched file has "${filters.pathPattern}" in its path`)
+  }
+
+  const [match] = diagnosis.caseInsensitiveMatches
+  if (match) {
+    const excludedBy = filters.types.length > 0 && !filters.types.includes(match.type) ? ` of type ${match.type}, which types leaves out,` : ''
+    hints.push(`${match.name}${excludedBy} is declared in ${match.path}; the filters or exactMatch may exclude it`)
+  }
+  else if (diagnosis.suggestions.length > 0) {
+    hints.push(`No symbol has this name; did you mean ${diagnosis.suggestions.slice(0, 3).join(', ')}?`)
+  }
+  return hints
+}
+
+/**
+ * Symbol names close to the query, best first: within a small edit distance (`ChatProvidor`
+ * and `ChatProvider`), or sharing most of its words (`user_service` and `UserServiceImpl`)
+ */
+export function nearestNames(query: string, names: string[], limit = MAX_SUGGESTIONS): string[] {
+  const lowered = query.toLowerCase()
+  const queryWords = nameWords(query)
+  const maxDistance = Math.max(2, Math.floor(query.length / 3))
+  const scored: Array<{ name: string, score: number }> = []
+  for (const name of names) {
+    const candidate = name.toLowerCase()
+    if (candidate === lowered) continue
+    let score = 0
+    if (Math.abs(name.length - query.length) <= maxDistance) {
+      const distance = editDistance(lowered, candidate, maxDistance)
+      if (distance <= maxDistance) score = 1 - distance / Math.max(query.length, name.length)
+    }
+    // Word overlap ranks below a close spelling, so it mostly finds reordered or extended names
+    score = Math.max(score, wordOverlap(queryWords, nameWords(name)) * 0.9)
+    if (score > 0) scored.push({ name, score })
+  }
+  return scored
+    .sort((a, b) => b.score - a.score || a.name.localeCompare(b.name))
+    .slice(0, limit)
+    .map(candidate => candidate.name)
+}
+
+/**
+ * Lowercase words of an identifier, split at case changes, digits and separators
+ */
+export function nameWords(name: string): string[] {
+  return name
+    .replace(/([a-z\d])([A-Z])/g, '$1 $2')
+    .replace(/([A-Z]+)([A-Z][a-z])/g, '$1 $2')
+    .split(/[^A-Za-z\d]+/)
+    .filter(Boolean)
+    .map(word => word.toLowerCase())
+}
+
+// Share of words the names have in common; a misspelled word counts half. Zero below half,
+// or when no word matches exactly.
+function wordOverlap(queryWords: string[], nameWords: string[]): number {
+  let exact = 0
+  let matched = 0
+  const remaining = [...nameWords]
+  for (const word of queryWords) {
+    const index = remaining.indexOf(word)
+    if (index >= 0) {
+      exact++
+      matched++
+      remaining.splice(index, 1)
+      continue
+    }
+    const near = word.length >= 4 ? remaining.findIndex(other => editDistance(word, other, 1) <= 1) : -1
+    if (near >= 0) {
+      matched += 0.5
+      remaining.splice(near, 1)
+    }
+  }
+  const overlap = matched / Math.max(queryWords.length, nameWords.length)
+  return exact > 0 && overlap >= 0.5 ? overlap : 0
+}
+
+// Levenshtein distance, giving up once every alignment exceeds `max`
+function editDistance(a: string, b: string, max: number): number {
+  let previous = Array.from({ length: b.length + 1 }, (_, index) => index)
+  for (let i = 1; i <= a.length; i++) {
+    const current = [i]
+    let best = i
+    for (let j = 1; j <= b.length; j++) {
+      current[j] = Math.min(previous[j]! + 1, current[j - 1]! + 1, previous[j - 1]! + (a[i - 1] === b[j - 1] ? 0 : 1))
+      best = Math.min(best, current[j]!)
+    }
+    if (best > max) return max + 1
+    previous = current
+  }
+  return previous[b.length]!
+}
+
+/**
+ * Distinct names of the declarations among nodes and their children
+ */
+export function symbolNames(nodes: TreeNode[]): string[] {
+  const names = new Set<string>()
+  walk(nodes, (node) => {
+    if (node.name && node.type !== 'file') names.add(node.name)
+  })
+  return [...names]
+}
+
+function filesOf(nodes: TreeNode[]): Set<string> {
+  return new Set(nodes.map(node => node.path))
+}
+
+function walk(nodes: TreeNode[], visit: (node: TreeNode) => void): void {
+  const seen = new Set<string>()
+  const visitAll = (current: TreeNode[]) => {
+    for (const node of current) {
+      if (seen.has(node.id)) continue
+      seen.add(node.id)
+      visit(node)
+      if (node.children) visitAll(node.children)
+    }
+  }
+  visitAll(nodes)
+}
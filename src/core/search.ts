@@ -6,6 +6,9 @@ import type { TreeNode, SearchOptions, SearchResult, FindUsageResult } from '../
 import { createLightweightTreeNode } from '../types/core.js'
 import { escapeRegExp } from '../utils/string-analysis.js'
 import { getUsageContext, extractContent } from '../utils/content-extraction.js'
+import { commentRanges, type CommentFilter } from './strings.js'
+import { keyPathAccessors } from './config-keys.js'
+import { readGoTypeArguments } from './go-generics.js'
 
 /**
  * Searches for code elements matching the query with progressive content inclusion
@@ -24,7 +27,9 @@ export function searchCode(
     forceContentInclusion = false,
     maxContentLines = 150,
     disableContentInclusion = false,
+    aliasMatches = [],
   } = options
+  const aliasChains = new Map(aliasMatches.map(match => [match.node.id, match.reExports]))
 
   // First pass: collect all matching results without content
   const initialResults: Omit<SearchResult, 'contentIncluded' | 'content' | 'contentTruncated' | 'contentLines'>[] = []
@@ -34,12 +39,14 @@ export function searchCode(
       if (types.length > 0 && !types.includes(node.type)) continue
       if (pathPattern && !node.path.includes(pathPattern)) continue
 
-      const score = calculateScore(query, node, exactMatch, fuzzyThreshold)
+      const reExports = aliasChains.get(node.id)
+      const score = reExports ? 100 : Math.round(calculateScore(query, node, exactMatch, fuzzyThreshold) * confidenceWeight(node))
       if (score > 0) {
         initialResults.push({
           node: createLightweightTreeNode(node),
           score,
-          matches: getMatches(query, node),
+          matches: reExports ? ['alias', ...getMatches(query, node)] : getMatches(query, node),
+          reExports: reExports?.length ? reExports : undefined,
         })
       }
 
@@ -204,6 +211,13 @@ function calculateScore(query: string, node: TreeNode, exactMatch: boolean, fuzz
   return fuzzyScore >= fuzzyThreshold ? fuzzyScore : 0
 }
 
+// Elements guessed by fallback indexing rank below parsed ones matching as well
+const LOW_CONFIDENCE_WEIGHT = 0.8
+
+function confidenceWeight(node: TreeNode): number {
+  return node.symbol?.confidence === 'low' ? LOW_CONFIDENCE_WEIGHT : 1
+}
+
 function calculateFuzzyScore(query: string, target: string): number {
   if (query.length === 0) return 100
   if (target.length === 0) return 0
@@ -244,32 +258,55 @@ function getMatches(query: string, node: TreeNode): string[] {
 }
 
 /**
- * Finds usage of an identifier across nodes with enhanced context
+ * Finds usage of an identifier across nodes with enhanced context. `aliases` lists, per file,
+ * other expressions the identifier's symbol is used by there; their matches carry `via`.
+ * `comments` keeps or drops matches inside comments and docstrings, or keeps only those.
+ * With `generic`, Go matches followed by explicit type arguments carry them as `typeArguments`.
  */
 export function findUsage(
   identifier: string,
   nodes: TreeNode[],
-  options: { caseSensitive?: boolean, exactMatch?: boolean, pathPattern?: string } = {},
+  options: { caseSensitive?: boolean, exactMatch?: boolean, pathPattern?: string, aliases?: Map<string, string[]>, comments?: CommentFilter, generic?: boolean } = {},
 ): FindUsageResult[] {
-  const { caseSensitive = false, exactMatch = true, pathPattern } = options
+  const { caseSensitive = false, exactMatch = true, pathPattern, aliases, comments = 'include', generic = false } = options
   const results: FindUsageResult[] = []
 
+  const createRegex = (term: string) => {
+    const searchId = caseSensitive ? term : term.toLowerCase()
+    return exactMatch
+      ? new RegExp(`\\b${escapeRegExp(searchId)}\\b`, caseSensitive ? 'g' : 'gi')
+      : new RegExp(escapeRegExp(searchId), caseSensitive ? 'g' : 'gi')
+  }
+  // Aliases containing the identifier (`format.Date` for `Date`) are found by the identifier itself
+  const coversAlias = (alias: string) => createRegex(identifier).test(alias)
+
   function searchInNode(node: TreeNode) {
     if (!node.content) return
 
     if (pathPattern && !node.path.includes(pathPattern)) return
 
-    const searchText = caseSensitive ? node.content : node.content.toLowerCase()
-    const searchId = caseSensitive ? identifier : identifier.toLowerCase()
+    searchTerm(node, identifier)
+    for (const alias of aliases?.get(node.path) ?? []) {
+      if (!coversAlias(alias)) searchTerm(node, alias, alias)
+    }
 
-    const regex = exactMatch
-      ? new RegExp(`\\b${escapeRegExp(searchId)}\\b`, caseSensitive ? 'g' : 'gi')
-      : new RegExp(escapeRegExp(searchId), caseSensitive ? 'g' : 'gi')
+    if (node.children) {
+      node.children.forEach(searchInNode)
+    }
+  }
+
+  function searchTerm(node: TreeNode, term: string, via?: string) {
+    const content = node.content!
+    const searchText = caseSensitive ? content : content.toLowerCase()
+    const regex = createRegex(term)
+    const ranges = comments === 'include' ? [] : commentRanges(content, node.path)
 
     let match
     while ((match = regex.exec(searchText)) !== null) {
       const matchIndex = match.index
-      const lines = node.content.split('\n')
+      const inComment = ranges.some(([start, end]) => matchIndex >= start && matchIndex < end)
+      if (comments !== 'include' && inComment !== (comments === 'only')) continue
+      const lines = content.split('\n')
 
       let currentIndex = 0
       let lineNumber = 0
@@ -286,6 +323,9 @@ export function findUsage(
       }
 
       const context = getUsageContext(node, lineNumber, lines)
+      const typeArguments = generic && node.path.endsWith('.go')
+        ? readGoTypeArguments(content, matchIndex + match[0].length)
+        : undefined
 
       results.push({
         node: createLightweightTreeNode(node),
@@ -293,15 +333,55 @@ export function findUsage(
         startLine: (node.startLine || 1) + lineNumber,
         endLine: (node.startLine || 1) + lineNumber,
         startColumn: columnNumber,
-        endColumn: columnNumber + identifier.length,
+        endColumn: columnNumber + term.length,
+        ...(via ? { via } : {}),
+        ...(typeArguments ? { typeArguments } : {}),
       })
     }
+  }
 
-    if (node.children) {
-      node.children.forEach(searchInNode)
+  nodes.forEach(searchInNode)
+  return results
+}
+
+/**
+ * Finds a configuration key path (`database.pool_size`) where config files declare it and
+ * where code reads it by subscripts (`['database']['pool_size']`). Reads spelled with dots
+ * are found by `findUsage` itself.
+ */
+export function findConfigKeyUsage(
+  path: string,
+  nodes: TreeNode[],
+  options: { caseSensitive?: boolean, pathPattern?: string, comments?: CommentFilter } = {},
+): FindUsageResult[] {
+  const results: FindUsageResult[] = []
+  const seen = new Set<string>()
+  const lastSegment = path.split(/\.|\[/).pop()!
+
+  const collectDeclarations = (currentNodes: TreeNode[]) => {
+    for (const node of currentNodes) {
+      if (options.pathPattern && !node.path.includes(options.pathPattern)) continue
+      const matches = options.caseSensitive ? node.name === path : node.name?.toLowerCase() === path.toLowerCase()
+      if (node.type === 'key' && matches && !seen.has(node.id)) {
+        seen.add(node.id)
+        const column = Math.max(0, (node.content ?? '').indexOf(lastSegment))
+        results.push({
+          node: createLightweightTreeNode(node),
+          context: node.content ?? '',
+          startLine: node.startLine ?? 1,
+          endLine: node.endLine ?? node.startLine ?? 1,
+          startColumn: column,
+          endColumn: column + lastSegment.length,
+        })
+      }
+      if (node.children) collectDeclarations(node.children)
     }
   }
+  collectDeclarations(nodes)
 
-  nodes.forEach(searchInNode)
+  for (const accessor of keyPathAccessors(path)) {
+    const reads = findUsage(accessor, nodes, { ...options, exactMatch: false })
+    results.push(...reads.map(read => ({ ...read, via: accessor })))
+  }
   return results
 }
@@ -0,0 +1,75 @@
+/**
+ * Makefile support - rewrites a Makefile into a shell script with the same line numbering, so
+ * recipes and variable definitions are indexed with the bash grammar
+ */
+
+const MAKE_ASSIGNMENT = /^(?:(export|override)\s+)?([A-Za-z_][\w.-]*)\s*(?::::=|::=|:=|\?=|\+=|!=|=)\s*(.*)$/
+
+/**
+ * Recipe lines become shell commands (with `$$` unescaped and `$(VAR)` turned into `${VAR}`),
+ * Make variable assignments become shell assignments, and everything else becomes a blank line.
+ */
+export function makefileToShell(content: string): string {
+  const lines = content.split('\n')
+  const output: string[] = []
+  let inRecipe = false
+  let defineName: string | null = null
+
+  for (const line of lines) {
+    if (defineName) {
+      output.push('')
+      if (/^\s*endef\b/.test(line)) defineName = null
+      continue
+    }
+
+    if (line.startsWith('\t') && inRecipe) {
+      output.push(toShellCommand(line.substring(1)))
+      continue
+    }
+
+    const trimmed = line.trim()
+    const define = trimmed.match(/^(?:(?:export|override)\s+)?define\s+([A-Za-z_][\w]*)/)
+    if (define) {
+      defineName = define[1]!
+      output.push(`${define[1]}=''`)
+      continue
+    }
+
+    const assignment = trimmed.match(MAKE_ASSIGNMENT)
+    if (assignment && !trimmed.startsWith('#')) {
+      inRecipe = false
+      const value = toShellWords(assignment[3]!).replace(/'/g, `'\\''`)
+      output.push(`${assignment[1] === 'export' ? 'export ' : ''}${assignment[2]}='${value}'`)
+      continue
+    }
+
+    if (/^export\s+[A-Za-z_]\w*(\s|$)/.test(trimmed)) {
+      output.push(trimmed)
+      continue
+    }
+
+    // A rule line starts a recipe; blank lines and comments don't end it
+    if (/^[^\s#][^=]*:(?!=)/.test(line)) {
+      inRecipe = true
+    }
+    else if (trimmed && !trimmed.startsWith('#')) {
+      inRecipe = false
+    }
+    output.push('')
+  }
+
+  return output.join('\n')
+}
+
+function toShellCommand(recipeLine: string): string {
+  // @ (silent), - (ignore errors) and + (always run) prefixes aren't shell syntax
+  return toShellWords(recipeLine.replace(/^\s*[@+-]+/, ''))
+}
+
+function toShellWords(text: string): string {
+  return text
+    .replace(/\$\$/g, '\u0000')
+    .replace(/\$\(([A-Za-z_][\w]*)\)/g, '${$1}')
+    .replace(/\$\((?:[^()]|\([^()]*\))*\)/g, '')
+    .replace(/\u0000/g, '$')
+}
@@ -0,0 +1,282 @@
+/**
+ * Solidity - contracts, interfaces, libraries and their functions, modifiers, events, structs
+ * and enums read from the source text, along with the state variables and header modifiers
+ * the security analysis needs
+ */
+
+import type { SymbolKind, SymbolVisibility, TreeNode } from '../types/core.js'
+
+export interface SolidityContract {
+  name: string
+  kind: 'contract' | 'interface' | 'library'
+  abstract: boolean
+  start: number
+  end: number
+  header: string
+  stateVariables: string[] // Mutable state; constants and immutables are left out
+}
+
+export interface SolidityFunction {
+  name: string // `constructor`, `fallback` and `receive` are named by their keyword
+  kind: 'function' | 'constructor' | 'fallback' | 'receive' | 'modifier'
+  contract?: string // Unset for free functions
+  visibility: SymbolVisibility
+  start: number
+  end: number
+  header: string
+  body?: { start: number, end: number } // Offsets of the body's braces; unset for declarations
+  modifiers: string[] // Modifiers invoked in the header, like `onlyOwner` or `nonReentrant`
+}
+
+export interface SolidityDeclaration {
+  type: 'event' | 'struct' | 'enum'
+  name: string
+  contract?: string
+  start: number
+  end: number
+  header: string
+}
+
+export interface SoliditySource {
+  contracts: SolidityContract[]
+  functions: SolidityFunction[]
+  declarations: SolidityDeclaration[]
+}
+
+const MAX_SIGNATURE_LENGTH = 200
+
+const CONTRACT = /(?<![\w.])(abstract\s+)?(contract|interface|library)\s+(\w+)[^{;]*\{/g
+const FUNCTION = /(?<![\w.])(?:function\s+(\w+)|(constructor|fallback|receive))\s*\(/g
+const MODIFIER = /(?<![\w.])modifier\s+(\w+)\s*[({]/g
+const DECLARATION = /(?<![\w.])(event|struct|enum)\s+(\w+)\s*[({]/g
+const HEADER_KEYWORDS = new Set([
+  'public', 'external', 'internal', 'private', 'view', 'pure', 'payable', 'nonpayable',
+  'virtual', 'override', 'returns', 'constant', 'memory', 'calldata', 'storage',
+])
+const STATE_EXCLUDED = /^(?:using|event|error|function|modifier|constructor|fallback|receive|struct|enum|type)\b/
+const CONTRACT_KINDS: Record<SolidityContract['kind'], SymbolKind> = { contract: 'class', interface: 'interface', library: 'module' }
+
+/**
+ * Reads the contracts of a source file with their functions, modifiers and declarations
+ */
+export function readSolidity(content: string): SoliditySource {
+  const code = maskSolidity(content)
+  const braces = matchBraces(code)
+
+  const contracts: SolidityContract[] = []
+  for (const match of code.matchAll(CONTRACT)) {
+    const open = match.index! + match[0].length - 1
+    const close = braces.get(open)
+    if (close === undefined) continue
+    contracts.push({
+      name: match[3]!,
+      kind: match[2] as SolidityContract['kind'],
+      abstract: Boolean(match[1]),
+      start: match.index!,
+      end: close + 1,
+      header: oneLine(content.substring(match.index!, open)),
+      stateVariables: readStateVariables(code, open, close),
+    })
+  }
+  const contractAt = (offset: number) => contracts.find(contract => contract.start < offset && offset < contract.end)
+
+  const functions: SolidityFunction[] = []
+  for (const match of code.matchAll(FUNCTION)) {
+    const paramsEnd = closingParen(code, match.index! + match[0].length - 1)
+    functions.push(readFunction(content, code, braces, match.index!, paramsEnd, match[1] ?? match[2]!, match[2] ? match[2] as SolidityFunction['kind'] : 'function', contractAt(match.index!)))
+  }
+  for (const match of code.matchAll(MODIFIER)) {
+    const paramsEnd = match[0].endsWith('(') ? closingParen(code, match.index! + match[0].length - 1) : match.index! + match[0].length - 1
+    functions.push(readFunction(content, code, braces, match.index!, paramsEnd, match[1]!, 'modifier', contractAt(match.index!)))
+  }
+
+  const declarations: SolidityDeclaration[] = []
+  for (const match of code.matchAll(DECLARATION)) {
+    const open = match.index! + match[0].length - 1
+    const semicolon = code.indexOf(';', open)
+    const headerEnd = match[1] !== 'event' ? open : semicolon === -1 ? code.length : semicolon
+    declarations.push({
+      type: match[1] as SolidityDeclaration['type'],
+      name: match[2]!,
+      contract: contractAt(match.index!)?.name,
+      start: match.index!,
+      end: match[1] === 'event' ? headerEnd + 1 : (braces.get(open) ?? open) + 1,
+      header: oneLine(content.substring(match.index!, headerEnd)),
+    })
+  }
+
+  return { contracts, functions: functions.sort((a, b) => a.start - b.start), declarations }
+}
+
+/**
+ * `class` nodes for contracts, interfaces, libraries, structs and enums, `function` nodes for
+ * functions (methods inside a contract), and `modifier` and `event` nodes, with the contract
+ * as `symbol.container`
+ */
+export function extractSolidityDefinitions(content: string, filePath: string): TreeNode[] {
+  const source = readSolidity(content)
+  const lines = content.split('\n')
+  const node = (type: string, name: string, start: number, end: number, symbol: NonNullable<TreeNode['symbol']>): TreeNode => {
+    const startLine = lineAt(content, start)
+    const endLine = lineAt(content, end)
+    return {
+      id: `solidity-${type}-${filePath}-${startLine}-${name}`,
+      type,
+      name,
+      path: filePath,
+      startLine,
+      endLine,
+      content: lines.slice(startLine - 1, endLine).join('\n'),
+      symbol: { ...symbol, signature: symbol.signature.substring(0, MAX_SIGNATURE_LENGTH) },
+    }
+  }
+  const withDoc = (start: number, container?: string) => {
+    const doc = readNatSpec(content, start)
+    return { ...(container ? { container } : {}), ...(doc ? { doc } : {}) }
+  }
+
+  return [
+    ...source.contracts.map(contract => node('class', contract.name, contract.start, contract.end, {
+      kind: CONTRACT_KINDS[contract.kind],
+      visibility: 'public',
+      signature: contract.header,
+      ...withDoc(contract.start),
+    })),
+    ...source.functions.map(fn => node(fn.kind === 'modifier' ? 'modifier' : 'function', fn.name, fn.start, fn.end, {
+      kind: fn.kind === 'modifier' || !fn.contract ? 'function' : 'method',
+      visibility: fn.visibility,
+      signature: fn.header,
+      ...withDoc(fn.start, fn.contract),
+    })),
+    ...source.declarations.map(declaration => node(declaration.type === 'event' ? 'event' : 'class', declaration.name, declaration.start, declaration.end, {
+      kind: declaration.type === 'event' ? 'type' : declaration.type,
+      visibility: 'public',
+      signature: declaration.header,
+      ...withDoc(declaration.start, declaration.contract),
+    })),
+  ].sort((a, b) => (a.startLine ?? 0) - (b.startLine ?? 0))
+}
+
+function readFunction(content: string, code: string, braces: Map<number, number>, start: number, paramsEnd: number, name: string, kind: SolidityFunction['kind'], contract?: SolidityContract): SolidityFunction {
+  // The header runs to the body, or to the `;` of a declaration without one
+  const tail = code.substring(paramsEnd).match(/^[^{;]*/)![0]
+  const open = paramsEnd + tail.length
+  const close = code[open] === '{' ? braces.get(open) : undefined
+  const words = tail.replace(/\breturns\b[\s\S]*$/, '')
+  const visibility = words.match(/\b(public|external|internal|private)\b/)?.[1]
+
+  let modifierText = words
+  while (/\([^()]*\)/.test(modifierText)) modifierText = modifierText.replace(/\([^()]*\)/g, ' ')
+  const modifiers = [...modifierText.matchAll(/\b[A-Za-z_]\w*\b/g)]
+    .map(match => match[0])
+    .filter(word => !HEADER_KEYWORDS.has(word))
+
+  return {
+    name,
+    kind,
+    ...(contract ? { contract: contract.name } : {}),
+    visibility: visibility === 'external' ? 'public' : (visibility as SymbolVisibility | undefined) ?? (kind === 'modifier' ? 'internal' : 'public'),
+    start,
+    end: close === undefined ? open + 1 : close + 1,
+    header: oneLine(content.substring(start, open)),
+    ...(close !== undefined ? { body: { start: open, end: close } } : {}),
+    modifiers,
+  }
+}
+
+/**
+ * Names of the mutable state variables declared directly in a contract body
+ */
+function readStateVariables(code: string, open: number, close: number): string[] {
+  const names: string[] = []
+  let depth = 0
+  let statementStart = open + 1
+
+  for (let index = open + 1; index < close; index++) {
+    const char = code[index]!
+    if (char === '{') {
+      depth++
+    }
+    else if (char === '}') {
+      if (--depth === 0) statementStart = index + 1
+    }
+    else if (char === ';' && depth === 0) {
+      const statement = code.substring(statementStart, index).trim()
+      statementStart = index + 1
+      if (STATE_EXCLUDED.test(statement) || /\b(?:constant|immutable)\b/.test(statement)) continue
+      const name = statement.replace(/(?<![=!<>])=(?![=>])[\s\S]*$/, '').trim().match(/(\w+)$/)?.[1]
+      if (name) names.push(name)
+    }
+  }
+
+  return names
+}
+
+/**
+ * The NatSpec comment above a declaration, from `///` lines or a doc block, without the `@`
+ * tags other than `@notice`
+ */
+function readNatSpec(content: string, start: number): string | undefined {
+  const before = content.substring(0, start).replace(/[ \t]+$/, '')
+  const block = before.match(/\/\*\*((?:(?!\*\/)[\s\S])*)\*\/\s*$/)
+  let text: string[]
+  if (block) {
+    text = block[1]!.split('\n').map(line => line.replace(/^\s*\*?\s?/, ''))
+  }
+  else {
+    const lines = before.split('\n').slice(0, -1)
+    text = []
+    for (let index = lines.length - 1; index >= 0 && /^\s*\/\/\//.test(lines[index]!); index--) {
+      text.unshift(lines[index]!.replace(/^\s*\/\/\/\s?/, ''))
+    }
+  }
+
+  const doc = text
+    .map(line => line.replace(/^@notice\s+/, '').trimEnd())
+    .filter(line => !line.startsWith('@'))
+    .join('\n')
+    .trim()
+  return doc || undefined
+}
+
+function closingParen(code: string, open: number): number {
+  let depth = 0
+  for (let index = open; index < code.length; index++) {
+    if (code[index] === '(') depth++
+    else if (code[index] === ')' && --depth === 0) return index + 1
+  }
+  return code.length
+}
+
+function matchBraces(code: string): Map<number, number> {
+  const braces = new Map<number, number>()
+  const open: number[] = []
+  for (let index = 0; index < code.length; index++) {
+    if (code[index] === '{') {
+      open.push(index)
+    }
+    else if (code[index] === '}') {
+      const opening = open.pop()
+      if (opening !== undefined) braces.set(opening, index)
+    }
+  }
+  return braces
+}
+
+/**
+ * Blanks comments and string contents, keeping offsets and line numbers
+ */
+export function maskSolidity(content: string): string {
+  return content.replace(
+    /\/\/[^\n]*|\/\*[\s\S]*?\*\/|"(?:\\.|[^"\\\n])*"|'(?:\\.|[^'\\\n])*'/g,
+    match => match.startsWith('/') ? match.replace(/[^\n]/g, ' ') : match[0] + match.slice(1, -1).replace(/[^\n]/g, ' ') + match[0],
+  )
+}
+
+function oneLine(text: string): string {
+  return text.replace(/\s+/g, ' ').trim()
+}
+
+export function lineAt(content: string, index: number): number {
+  return content.substring(0, index).split('\n').length
+}
@@ -0,0 +1,301 @@
+/**
+ * String literal and comment search - finds the code that produces a message or UI label seen at
+ * runtime by searching inside string literals, template strings, JSX text and user-facing
+ * attributes, or searches comments and docstrings only. Literals with placeholders (`${id}`,
+ * `%s`, `{}`) match the rendered text they produce.
+ */
+
+import { findHardcodedStrings } from '../analysis/i18n.js'
+import { getLanguageForFile } from './languages.js'
+import { FRAMEWORK_EXTENSIONS, PARSER_NAMES, escapeRegExp } from '../constants/index.js'
+import type { TreeNode } from '../types/core.js'
+
+export const STRING_SEARCH_MODES = ['strings', 'ui', 'comments'] as const
+export type StringSearchMode = typeof STRING_SEARCH_MODES[number]
+
+export type StringKind = 'string' | 'template' | 'markup' | 'attribute' | 'comment' | 'docstring'
+
+export const COMMENT_FILTERS = ['include', 'exclude', 'only'] as const
+export type CommentFilter = typeof COMMENT_FILTERS[number]
+
+export interface StringLiteral {
+  text: string // Literal body as written, without quotes
+  kind: StringKind
+  file: string
+  line: number
+  endLine?: number // For comments spanning several lines
+  column?: number // Unset for markup text
+  attribute?: string // For user-facing attributes such as placeholder or title
+}
+
+export interface StringMatch extends StringLiteral {
+  score: number
+  symbol?: string // Innermost declaration containing the literal
+}
+
+export interface StringSearchOptions {
+  mode?: StringSearchMode
+  maxResults?: number
+  pathPattern?: string
+}
+
+// Kinds rendered to users; `ui` mode only searches these
+const UI_KINDS = new Set<StringKind>(['template', 'markup', 'attribute'])
+
+const HASH_COMMENT_LANGUAGES = new Set<string>([PARSER_NAMES.PYTHON, PARSER_NAMES.RUBY, PARSER_NAMES.BASH, PARSER_NAMES.MAKE, PARSER_NAMES.DOCKERFILE, PARSER_NAMES.COMPOSE, PARSER_NAMES.YAML, PARSER_NAMES.TOML])
+
+// Single quotes delimit characters (or Rust lifetimes) rather than strings
+const CHAR_QUOTE_LANGUAGES = new Set<string>([PARSER_NAMES.GO, PARSER_NAMES.RUST, PARSER_NAMES.JAVA, PARSER_NAMES.C, PARSER_NAMES.CPP, PARSER_NAMES.CSHARP, PARSER_NAMES.KOTLIN])
+
+const MARKUP_EXTENSIONS: string[] = [...FRAMEWORK_EXTENSIONS.REACT_JSX, ...FRAMEWORK_EXTENSIONS.REACT_TSX, ...FRAMEWORK_EXTENSIONS.VUE, ...FRAMEWORK_EXTENSIONS.SVELTE, ...FRAMEWORK_EXTENSIONS.ASTRO]
+
+// `${expr}`, `#{expr}`, `{name}`/`{0}`/`{}`, printf verbs (`%s`, `%-5d`, `%.2f`, `%+v`)
+const PLACEHOLDER = /\$\{[^}]*\}|#\{[^}]*\}|\{[\w.:!]*\}|%[-+ #0]*\d*(?:\.\d+)?[sdifvqxXeEgGuoc]/g
+
+// A template matches rendered text only when enough of it is fixed
+const MIN_FIXED_CHARACTERS = 4
+
+/**
+ * Searches string literals (or, in `comments` mode, comments) of the given files for text. A
+ * literal matches when it contains the query or when its placeholders can be filled in to
+ * produce it, so `User ${id} not found` is found by `User 42 not found`. Best matches come first.
+ */
+export function searchStrings(query: string, fileNodes: TreeNode[], options: StringSearchOptions = {}): StringMatch[] {
+  const { mode = 'strings', maxResults = 20, pathPattern } = options
+  const normalizedQuery = normalize(query)
+  if (!normalizedQuery) return []
+
+  const matches: StringMatch[] = []
+  const seen = new Set<string>()
+  for (const fileNode of fileNodes) {
+    if (fileNode.type !== 'file' || !fileNode.content || seen.has(fileNode.path)) continue
+    if (pathPattern && !fileNode.path.includes(pathPattern)) continue
+    seen.add(fileNode.path)
+
+    const texts = mode === 'comments' ? extractComments(fileNode.content, fileNode.path) : extractStringLiterals(fileNode.content, fileNode.path)
+    for (const literal of texts) {
+      if (mode === 'ui' && !UI_KINDS.has(literal.kind)) continue
+      const score = scoreLiteral(normalizedQuery, literal.text)
+      if (score === 0) continue
+      matches.push({ ...literal, score, symbol: enclosingSymbol(fileNode, literal.line) })
+    }
+  }
+
+  return matches
+    .sort((a, b) => b.score - a.score || a.file.localeCompare(b.file) || a.line - b.line)
+    .slice(0, maxResults)
+}
+
+/**
+ * Lists the string literals of a file, skipping comments and docstrings. In JSX and component
+ * templates, text content and user-facing attributes are reported as `markup` and `attribute`.
+ */
+export function extractStringLiterals(content: string, file: string): StringLiteral[] {
+  const { spans, position, markup } = scanSource(content, file)
+  const literals: StringLiteral[] = spans
+    .filter(span => (span.kind === 'string' || span.kind === 'template') && span.text.trim())
+    .map(span => ({ text: span.text, kind: span.kind, file, ...position(span.start) }))
+
+  if (markup) {
+    // Attributes also lexed as plain strings are reported once, as attributes
+    const hardcoded = findHardcodedStrings(content, file)
+    const attributes = new Set(hardcoded.filter(string => string.attribute).map(string => `${string.line}:${string.text}`))
+    for (const string of hardcoded) {
+      literals.push({ text: string.text, kind: string.attribute ? 'attribute' : 'markup', file, line: string.line, attribute: string.attribute })
+    }
+    return literals
+      .filter(literal => literal.kind === 'attribute' || !attributes.has(`${literal.line}:${literal.text.trim()}`))
+      .sort((a, b) => a.line - b.line)
+  }
+
+  return literals
+}
+
+/**
+ * Lists the comments of a file with their markers stripped. Doc comments (`/**` blocks, `///`) and
+ * Python docstrings are `docstring`; a run of line comments on consecutive lines is one comment.
+ */
+export function extractComments(content: string, file: string): StringLiteral[] {
+  const { spans, position } = scanSource(content, file)
+  const comments: (StringLiteral & { standalone?: boolean })[] = []
+
+  for (const span of spans) {
+    if (span.kind !== 'comment' && span.kind !== 'docstring') continue
+    const { line, column } = position(span.start)
+    const endLine = position(span.end - 1).line
+    const previous = comments[comments.length - 1]
+    if (span.standalone && previous?.standalone && previous.kind === span.kind && previous.endLine === line - 1) {
+      previous.text += `\n${span.text}`
+      previous.endLine = endLine
+      continue
+    }
+    comments.push({ text: span.text, kind: span.kind, file, line, column, endLine, standalone: span.standalone })
+  }
+
+  return comments
+    .filter(comment => comment.text.trim())
+    .map(({ standalone: _standalone, ...comment }) => comment)
+}
+
+/**
+ * Offsets `[start, end)` of the comments and docstrings in a file, for telling matches inside
+ * them apart from code
+ */
+export function commentRanges(content: string, file: string): [number, number][] {
+  return scanSource(content, file).spans
+    .filter(span => span.kind === 'comment' || span.kind === 'docstring')
+    .map(span => [span.start, span.end])
+}
+
+/**
+ * Blanks the comments and string literals of a piece of code, keeping line breaks so offsets
+ * and lines still point at the original
+ */
+export function maskSource(content: string, file: string): string {
+  let masked = ''
+  let last = 0
+  for (const span of scanSource(content, file).spans) {
+    masked += content.substring(last, span.start) + content.substring(span.start, span.end).replace(/[^\n]/g, ' ')
+    last = span.end
+  }
+  return masked + content.substring(last)
+}
+
+interface SourceSpan {
+  kind: 'string' | 'template' | 'comment' | 'docstring'
+  start: number
+  end: number
+  text: string // Body without quotes or comment markers
+  standalone?: boolean // A line comment with nothing but whitespace before it
+}
+
+interface ScannedSource {
+  spans: SourceSpan[]
+  markup: boolean
+  position: (index: number) => { line: number, column: number }
+}
+
+/**
+ * Splits a file into its string literals and comments, following the quoting and comment
+ * rules of its language
+ */
+function scanSource(content: string, file: string): ScannedSource {
+  const language = getLanguageForFile(file)?.name
+  const markup = language === PARSER_NAMES.HTML || MARKUP_EXTENSIONS.some(extension => file.endsWith(extension))
+  const hashComments = language !== undefined && HASH_COMMENT_LANGUAGES.has(language)
+  const charQuotes = language !== undefined && CHAR_QUOTE_LANGUAGES.has(language)
+
+  // Apostrophes in markup text ("Don't") would otherwise open string literals. Arrows and
+  // generics (`=> f('x') {`, `Array<T>('x')`) aren't tags, so their text is left alone.
+  const source = markup ? content.replace(/(?<![=-])>([^<>{}()=;]+)(?=[<{])/g, (text: string) => text.replace(/[^\n]/g, ' ')) : content
+  const spans: SourceSpan[] = []
+  const standaloneAt = (index: number) => /^[ \t]*$/.test(source.substring(source.lastIndexOf('\n', index - 1) + 1, index))
+
+  for (let i = 0; i < source.length; i++) {
+    const char = source[i]!
+    const lineMarker = hashComments ? (char === '#' ? '#' : undefined) : source.startsWith('//', i) ? '//' : undefined
+    if (lineMarker) {
+      const end = lineEnd(source, i)
+      const doc = !hashComments && /^\/\/[/!](?!\/)/.test(source.substring(i, i + 4))
+      const text = source.substring(i + (doc ? 3 : lineMarker.length), end).replace(/^ /, '').trimEnd()
+      spans.push({ kind: doc ? 'docstring' : 'comment', start: i, end, text, standalone: standaloneAt(i) })
+      i = end
+      continue
+    }
+    const block = !hashComments && source.startsWith('/*', i) ? ['/*', '*/'] : markup && source.startsWith('<!--', i) ? ['<!--', '-->'] : undefined
+    if (block) {
+      const close = source.indexOf(block[1]!, i + block[0]!.length)
+      const end = close === -1 ? source.length : close + block[1]!.length
+      const doc = source.startsWith('/**', i) && !source.startsWith('/**/', i)
+      const body = source.substring(i + block[0]!.length + (doc ? 1 : 0), close === -1 ? source.length : close)
+      const text = body.split('\n').map(line => line.replace(/^\s*\* ?/, '').trimEnd()).join('\n').trim()
+      spans.push({ kind: doc ? 'docstring' : 'comment', start: i, end, text })
+      i = end - 1
+      continue
+    }
+    if (char !== '"' && char !== '\'' && char !== '`') continue
+
+    // A string prefix (f"", $"", r"") right before the quote marks interpolation or raw text
+    const prefix = source.substring(Math.max(0, i - 2), i).match(/[A-Za-z$@]*$/)![0]
+    if (char === '\'' && charQuotes) {
+      i = skipCharLiteral(source, i)
+      continue
+    }
+
+    const triple = source.startsWith(char.repeat(3), i)
+    const delimiter = triple ? char.repeat(3) : char
+    const start = i + delimiter.length
+    const close = findClosingQuote(source, start, delimiter, char === '`' || triple)
+    const text = source.substring(start, close)
+    // A triple-quoted string standing alone as a statement documents the code around it
+    const kind = triple && language === PARSER_NAMES.PYTHON && standaloneAt(i - prefix.length)
+      ? 'docstring'
+      : char === '`' && language !== PARSER_NAMES.GO ? 'template' : /[fF$]/.test(prefix) && text.includes('{') ? 'template' : 'string'
+    spans.push({ kind, start: i, end: Math.min(source.length, close + delimiter.length), text: kind === 'docstring' ? text.trim() : text })
+    i = close + delimiter.length - 1
+  }
+
+  const lineStarts = [0]
+  for (let i = 0; i < source.length; i++) {
+    if (source[i] === '\n') lineStarts.push(i + 1)
+  }
+  const position = (index: number) => {
+    let low = 0
+    let high = lineStarts.length - 1
+    while (low < high) {
+      const middle = Math.ceil((low + high) / 2)
+      if (lineStarts[middle]! <= index) low = middle
+      else high = middle - 1
+    }
+    return { line: low + 1, column: index - lineStarts[low]! + 1 }
+  }
+
+  return { spans, markup, position }
+}
+
+function scoreLiteral(query: string, text: string): number {
+  const literal = normalize(text)
+  if (!literal) return 0
+  if (literal === query) return 100
+  // Containing the query: the less surrounding text, the better the match
+  if (literal.includes(query)) return 60 + Math.round(30 * query.length / literal.length)
+
+  const fixed = literal.split(PLACEHOLDER)
+  if (fixed.length < 2 || fixed.join('').replace(/\s/g, '').length < MIN_FIXED_CHARACTERS) return 0
+  const pattern = new RegExp(fixed.map(part => escapeRegExp(part)).join('.+?'))
+  return pattern.test(query) ? 50 : 0
+}
+
+function normalize(text: string): string {
+  return text.replace(/\\[nrt]/g, ' ').replace(/\s+/g, ' ').trim().toLowerCase()
+}
+
+function enclosingSymbol(fileNode: TreeNode, line: number): string | undefined {
+  let symbol: string | undefined
+  let children = fileNode.children ?? []
+  for (;;) {
+    const container = children.find(node => node.name && node.startLine !== undefined && node.endLine !== undefined && node.startLine <= line && line <= node.endLine)
+    if (!container) return symbol
+    symbol = container.name
+    children = container.children ?? []
+  }
+}
+
+function findClosingQuote(source: string, start: number, delimiter: string, multiline: boolean): number {
+  for (let i = start; i < source.length; i++) {
+    if (source[i] === '\\') i++
+    else if (source.startsWith(delimiter, i)) return i
+    else if (source[i] === '\n' && !multiline) return i
+  }
+  return source.length
+}
+
+function skipCharLiteral(source: string, start: number): number {
+  const close = source.slice(start + 1, start + 12).search(/(?<!\\)'/)
+  return close === -1 ? start : start + 1 + close
+}
+
+function lineEnd(source: string, start: number): number {
+  const end = source.indexOf('\n', start)
+  return end === -1 ? source.length : end
+}
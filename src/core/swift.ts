@@ -0,0 +1,280 @@
+/**
+ * Swift - types, extensions, protocols and their members read from the source text, with
+ * the attributes (`@objc`, `@objcMembers`) Objective-C bridging looks at
+ */
+
+import type { SymbolKind, SymbolVisibility, TreeNode } from '../types/core.js'
+
+interface Declaration {
+  keyword: string
+  name: string
+  start: number
+  end: number
+  signature: string
+  attributes: string[]
+  visibility?: SymbolVisibility // Unset when no access modifier is written
+  body?: { open: number, close: number }
+}
+
+const MAX_SIGNATURE_LENGTH = 200
+
+const DECLARATION = /^[ \t]*((?:@\w+(?:\((?:[^()]|\([^()]*\))*\))?\s+)*)((?:(?:public|private|fileprivate|internal|open|package|final|static|class|override|mutating|nonmutating|convenience|required|lazy|weak|unowned|dynamic|nonisolated|indirect|optional)(?:\(\w+\))?\s+)*)(class|struct|enum|protocol|extension|actor|func|init|deinit|subscript|typealias|var|let|case)\b/gm
+const TYPE_KEYWORDS = new Set(['class', 'struct', 'enum', 'protocol', 'extension', 'actor'])
+const TYPE_KINDS: Record<string, SymbolKind> = { class: 'class', struct: 'struct', enum: 'enum', protocol: 'interface', extension: 'class', actor: 'class' }
+const LITERAL = /\/\/[^\n]*|(#*)"""[\s\S]*?"""\1|(#*)"(?:\\.|[^"\\\n])*"\2/y
+const CONTINUATION = /^\s*(?:->|throws|rethrows|async|where|\.\.\.)/
+
+/**
+ * `class` nodes for classes, structs, enums, actors, protocols (kind `interface`) and
+ * extensions (named by the type they extend), `method` nodes for initializers, functions and
+ * subscripts inside them with the type as `symbol.container`, `variant` nodes for enum cases,
+ * `variable` nodes for properties and top-level `var`/`let`, `type` nodes for typealiases and
+ * `function` nodes for top-level functions. Attributes are kept in `symbol.annotations`;
+ * declarations inside function bodies are skipped.
+ */
+export function extractSwiftDefinitions(content: string, filePath: string): TreeNode[] {
+  const code = maskSwift(content)
+  const braces = bracePairs(code)
+  const declarations = readDeclarations(content, code)
+  const types = declarations.filter(declaration => TYPE_KEYWORDS.has(declaration.keyword) && declaration.body)
+  const lines = content.split('\n')
+  const nodes: TreeNode[] = []
+
+  for (const declaration of declarations) {
+    const enclosing = innermostBrace(braces, declaration.start)
+    const owner = enclosing && types.find(type => type.body!.open === enclosing.open)
+    // Declarations inside function bodies, closures and accessors are locals
+    if (enclosing && !owner) continue
+    if (declaration.keyword === 'case' && owner?.keyword !== 'enum') continue
+
+    const containers = types.filter(type => type.body!.open < declaration.start && declaration.start < type.body!.close)
+    const container = containers.map(type => type.name).join('.') || undefined
+    // Members written without a modifier take the access of a protocol, enum or extension
+    const visibility = declaration.visibility
+      ?? ((owner && ['protocol', 'extension'].includes(owner.keyword)) || declaration.keyword === 'case' ? owner!.visibility : undefined)
+      ?? 'internal'
+    const { type, kind } = nodeKind(declaration.keyword, Boolean(owner))
+
+    for (const name of declaration.keyword === 'case' ? caseNames(code, declaration) : [declaration.name]) {
+      const startLine = lineAt(content, declaration.start)
+      const endLine = lineAt(content, declaration.end)
+      const doc = readDocComment(content, declaration.start)
+      nodes.push({
+        id: `swift-${type}-${filePath}-${startLine}-${name}`,
+        type,
+        name,
+        path: filePath,
+        startLine,
+        endLine,
+        content: lines.slice(startLine - 1, endLine).join('\n'),
+        symbol: {
+          kind,
+          visibility,
+          signature: declaration.signature.substring(0, MAX_SIGNATURE_LENGTH),
+          ...(container ? { container } : {}),
+          ...(doc ? { doc } : {}),
+          ...(declaration.attributes.length > 0 ? { annotations: declaration.attributes } : {}),
+        },
+      })
+    }
+  }
+
+  return nodes
+}
+
+function nodeKind(keyword: string, member: boolean): { type: string, kind: SymbolKind } {
+  if (TYPE_KEYWORDS.has(keyword)) return { type: 'class', kind: TYPE_KINDS[keyword]! }
+  if (keyword === 'case') return { type: 'variant', kind: 'variant' }
+  if (keyword === 'typealias') return { type: 'type', kind: 'type' }
+  if (keyword === 'var' || keyword === 'let') return { type: member ? 'property' : 'variable', kind: 'variable' }
+  return member ? { type: 'method', kind: 'method' } : { type: 'function', kind: 'function' }
+}
+
+function readDeclarations(content: string, code: string): Declaration[] {
+  const declarations: Declaration[] = []
+
+  for (const match of code.matchAll(DECLARATION)) {
+    const [, attributeText, modifierText, keyword] = match
+    const start = match.index! + match[0].search(/\S/)
+    const keywordEnd = match.index! + match[0].length
+    const name = readName(code.substring(keywordEnd), keyword!)
+    if (!name) continue
+
+    const { headerEnd, open } = readHeader(code, keywordEnd, keyword!)
+    const close = open === undefined ? undefined : closingBrace(code, open)
+    const access = modifierText!.match(/\b(open|public|package|internal|fileprivate|private)\b(?!\s*\()/)?.[1]
+    declarations.push({
+      keyword: keyword!,
+      name,
+      start,
+      end: close ?? headerEnd,
+      signature: oneLine(content.substring(start, headerEnd)),
+      attributes: [...attributeText!.matchAll(/@\w+(?:\((?:[^()]|\([^()]*\))*\))?/g)].map(attribute => attribute[0]),
+      ...(access ? { visibility: toVisibility(access) } : {}),
+      ...(open !== undefined ? { body: { open, close: close! - 1 } } : {}),
+    })
+  }
+
+  return declarations
+}
+
+function readName(rest: string, keyword: string): string | undefined {
+  if (keyword === 'init' || keyword === 'deinit' || keyword === 'subscript') return keyword
+  if (keyword === 'extension') return rest.match(/^\s+([\w.]+)/)?.[1]
+  if (keyword === 'func') return rest.match(/^\s+(`?(\w+)`?|[^\s(<\w]+)/)?.[2] ?? rest.match(/^\s+([^\s(<]+)/)?.[1]
+  // `var (a, b) = ...` destructures and has no single name
+  return rest.match(/^\s+`?(\w+)`?/)?.[1]
+}
+
+/**
+ * Finds the end of a declaration header and the `{` of its body. Headers run over lines while
+ * brackets are open or the next line continues them (`-> T`, `where ...`); a `var` or `let`
+ * only has a body when no `=` comes first.
+ */
+function readHeader(code: string, from: number, keyword: string): { headerEnd: number, open?: number } {
+  let depth = 0
+  for (let index = from; index < code.length; index++) {
+    const char = code[index]!
+    // `<` only opens generic arguments when it follows a name directly: `Array<Int>`, not `a < b`
+    if (char === '(' || char === '[' || (char === '<' && /\w/.test(code[index - 1] ?? ''))) depth++
+    else if ((char === ')' || char === ']' || char === '>') && code[index - 1] !== '-') depth = Math.max(0, depth - 1)
+    else if (depth > 0) continue
+    else if (char === '{') {
+      const header = code.substring(from, index)
+      if ((keyword === 'var' || keyword === 'let') && /(?<![=!<>])=(?!=)/.test(header)) return { headerEnd: lineEnd(code, index) }
+      if (keyword === 'case') return { headerEnd: index }
+      return { headerEnd: index, open: index }
+    }
+    else if (char === '}' || char === ';') return { headerEnd: index }
+    else if (char === '\n' && !CONTINUATION.test(code.substring(index + 1, index + 40)) && !/^\s*\{/.test(code.substring(index + 1, index + 40))) {
+      return { headerEnd: index }
+    }
+  }
+  return { headerEnd: code.length }
+}
+
+/**
+ * The cases of `case a, b(Int), c = 3`: commas inside associated values don't split
+ */
+function caseNames(code: string, declaration: Declaration): string[] {
+  const text = code.substring(declaration.start, declaration.end).replace(/^[\s\S]*?\bcase\b/, '')
+  const names: string[] = []
+  let depth = 0
+  let part = ''
+  for (const char of `${text},`) {
+    if (char === '(') depth++
+    else if (char === ')') depth--
+    if (char === ',' && depth === 0) {
+      const name = part.match(/^\s*`?(\w+)/)?.[1]
+      if (name) names.push(name)
+      part = ''
+    }
+    else part += char
+  }
+  return names
+}
+
+function toVisibility(access: string): SymbolVisibility {
+  if (access === 'open' || access === 'public') return 'public'
+  if (access === 'private' || access === 'fileprivate') return 'private'
+  return 'internal'
+}
+
+function bracePairs(code: string): Array<{ open: number, close: number }> {
+  const pairs: Array<{ open: number, close: number }> = []
+  const stack: number[] = []
+  for (let index = 0; index < code.length; index++) {
+    if (code[index] === '{') stack.push(index)
+    else if (code[index] === '}' && stack.length > 0) pairs.push({ open: stack.pop()!, close: index })
+  }
+  for (const open of stack) pairs.push({ open, close: code.length })
+  return pairs
+}
+
+function innermostBrace(pairs: Array<{ open: number, close: number }>, offset: number): { open: number, close: number } | undefined {
+  let innermost: { open: number, close: number } | undefined
+  for (const pair of pairs) {
+    if (pair.open < offset && offset < pair.close && (!innermost || pair.open > innermost.open)) innermost = pair
+  }
+  return innermost
+}
+
+/**
+ * The `///` lines or `/** ... *\/` block directly above a declaration
+ */
+function readDocComment(content: string, start: number): string | undefined {
+  const before = content.substring(0, start).replace(/\s+$/, '')
+  const block = before.match(/\/\*\*((?:(?!\*\/)[\s\S])*)\*\/$/)
+  if (block) {
+    return block[1]!.split('\n').map(line => line.replace(/^\s*\*?\s?/, '').trimEnd()).join('\n').trim() || undefined
+  }
+
+  const lines = before.split('\n')
+  const comments: string[] = []
+  for (let index = lines.length - 1; index >= 0 && /^\s*\/\/\//.test(lines[index]!); index--) {
+    comments.unshift(lines[index]!.replace(/^\s*\/\/\/\s?/, '').trimEnd())
+  }
+  return comments.join('\n').trim() || undefined
+}
+
+function closingBrace(code: string, open: number): number {
+  let depth = 0
+  for (let index = open; index < code.length; index++) {
+    if (code[index] === '{') depth++
+    else if (code[index] === '}' && --depth === 0) return index + 1
+  }
+  return code.length
+}
+
+/**
+ * Blanks comments (which nest in Swift) and the contents of string literals, including
+ * multi-line `"""` and raw `#"..."#` strings, keeping offsets and line numbers
+ */
+export function maskSwift(content: string): string {
+  let output = ''
+  let index = 0
+  while (index < content.length) {
+    if (content.startsWith('/*', index)) {
+      let depth = 0
+      let end = index
+      while (end < content.length) {
+        if (content.startsWith('/*', end)) { depth++; end += 2 }
+        else if (content.startsWith('*/', end)) { end += 2; if (--depth === 0) break }
+        else end++
+      }
+      output += content.substring(index, end).replace(/[^\n]/g, ' ')
+      index = end
+      continue
+    }
+
+    LITERAL.lastIndex = index
+    const literal = '/"#'.includes(content[index]!) ? LITERAL.exec(content) : null
+    if (literal) {
+      const text = literal[0]
+      if (text.startsWith('//')) output += text.replace(/[^\n]/g, ' ')
+      else {
+        const quote = text.match(/^#*(?:"""|")/)![0]
+        output += quote + text.slice(quote.length, text.length - quote.length).replace(/[^\n]/g, ' ') + text.slice(text.length - quote.length)
+      }
+      index += text.length
+      continue
+    }
+
+    output += content[index]
+    index++
+  }
+  return output
+}
+
+function lineEnd(code: string, index: number): number {
+  const newline = code.indexOf('\n', index)
+  return newline === -1 ? code.length : newline
+}
+
+function oneLine(text: string): string {
+  return text.replace(/\s+/g, ' ').trim()
+}
+
+function lineAt(content: string, index: number): number {
+  return content.substring(0, index).split('\n').length
+}
@@ -0,0 +1,168 @@
+/**
+ * Qualified symbol ids - a stable handle per declaration (`src/api/users.ts#UserService.load`)
+ * so a name defined in several packages can be picked out explicitly in follow-up calls
+ */
+
+import { dirname, join, relative, resolve, sep } from 'path'
+import { readFileSync } from 'fs'
+import { getAllNodes } from '../project/manager.js'
+import { findOwningGoModule } from '../project/go-workspace.js'
+import { getLanguageForFile } from './languages.js'
+import { PARSER_NAMES } from '../constants/parsers.js'
+import { PROJECT_FILES } from '../constants/project-files.js'
+import { isFile } from '../utils/helpers.js'
+import type { Project, TreeNode } from '../types/core.js'
+
+const SYMBOL_ID = /^([^\s#]*[./][^\s#]*)#([\w$.]+)$/
+const DECLARED_PACKAGE = /^\s*(?:package|namespace)\s+([\w.]+)/m
+
+export interface SymbolCandidate {
+  id: string
+  name: string
+  kind: string
+  package: string
+  container?: string
+  signature?: string
+  visibility?: string
+  path: string
+  startLine?: number
+  endLine?: number
+}
+
+/**
+ * The id of a declaration: its project-relative file path and its name, qualified by its
+ * container. Files are identified by their path alone.
+ */
+export function symbolId(root: string, node: TreeNode): string | undefined {
+  const path = relative(root, node.path).split(sep).join('/')
+  if (node.type === 'file') return path
+  if (!node.name) return undefined
+
+  const container = node.symbol?.container
+  return `${path}#${container ? `${container}.` : ''}${node.name}`
+}
+
+export function isSymbolId(value: string): boolean {
+  return SYMBOL_ID.test(value)
+}
+
+/**
+ * Declarations with the given id. Normally one; overloads share an id.
+ */
+export function findSymbolsById(project: Project, id: string): TreeNode[] {
+  const match = id.match(SYMBOL_ID)
+  if (!match) return []
+
+  const path = resolve(project.config.directory, match[1]!)
+  const seen = new Set<string>()
+  return getAllNodes(project).filter((node) => {
+    if (node.path !== path || node.type === 'file' || seen.has(node.id)) return false
+    seen.add(node.id)
+    return symbolId(project.config.directory, node) === id
+  })
+}
+
+/**
+ * Every declaration a name may refer to, described well enough to tell them apart. A
+ * qualified name (`UserService.load`) only matches members of that container.
+ */
+export function findSymbolCandidates(project: Project, name: string, types: string[] = []): SymbolCandidate[] {
+  const segments = name.split('.')
+  const member = segments.pop()!
+  const container = segments.join('.')
+  const packages = new Map<string, string>()
+  const seen = new Set<string>()
+  const candidates: SymbolCandidate[] = []
+
+  for (const node of getAllNodes(project)) {
+    if (node.name !== member || node.type === 'file' || node.type === 'parameter' || seen.has(node.id)) continue
+    if (container && node.symbol?.container !== container) continue
+    if (types.length > 0 && !types.includes(node.symbol?.kind ?? node.type) && !types.includes(node.type)) continue
+    seen.add(node.id)
+
+    const directory = dirname(node.path)
+    if (!packages.has(directory)) packages.set(directory, packageOf(project, node))
+    candidates.push(symbolCandidate(project, node, packages.get(directory)))
+  }
+
+  return candidates.sort((a, b) => a.id.localeCompare(b.id))
+}
+
+/**
+ * Describes one declaration for picking it among others of the same name
+ */
+export function symbolCandidate(project: Project, node: TreeNode, packageName = packageOf(project, node)): SymbolCandidate {
+  return {
+    id: symbolId(project.config.directory, node)!,
+    name: node.name ?? '',
+    kind: node.symbol?.kind ?? node.type,
+    package: packageName,
+    container: node.symbol?.container,
+    signature: node.symbol?.signature,
+    visibility: node.symbol?.visibility,
+    path: node.path,
+    startLine: node.startLine,
+    endLine: node.endLine,
+  }
+}
+
+/**
+ * The package a declaration belongs to, as its language names it: the Go import path, the
+ * Python dotted module, a declared Java/Kotlin/C#/Odin package, or the nearest package.json name.
+ * Falls back to the project-relative directory.
+ */
+export function packageOf(project: Project, node: TreeNode): string {
+  const root = project.config.directory
+  const directory = dirname(node.path)
+  const relativeDir = relative(root, directory).split(sep).join('/') || '.'
+
+  switch (getLanguageForFile(node.path)?.name) {
+    case PARSER_NAMES.GO: {
+      const module = project.goModules ? findOwningGoModule(node.path, project.goModules) : null
+      if (!module) return relativeDir
+      const subpath = relative(module.directory, directory).split(sep).join('/')
+      return subpath ? `${module.path}/${subpath}` : module.path
+    }
+
+    case PARSER_NAMES.PYTHON: {
+      const modulePath = relative(root, node.path).replace(/\.py$/, '').split(sep)
+      if (modulePath[modulePath.length - 1] === '__init__') modulePath.pop()
+      return modulePath.join('.') || relativeDir
+    }
+
+    case PARSER_NAMES.JAVA:
+    case PARSER_NAMES.KOTLIN:
+    case PARSER_NAMES.SCALA:
+    case PARSER_NAMES.ODIN:
+    case PARSER_NAMES.CSHARP: {
+      const declared = fileContent(project, node.path).match(DECLARED_PACKAGE)?.[1]
+      return declared ?? relativeDir
+    }
+
+    default:
+      return nearestPackageName(root, directory) ?? relativeDir
+  }
+}
+
+function nearestPackageName(root: string, directory: string): string | undefined {
+  for (let dir = directory; dir === root || dir.startsWith(root + sep); dir = dirname(dir)) {
+    const manifest = join(dir, PROJECT_FILES.PACKAGE_MANAGERS.NPM)
+    if (isFile(manifest)) {
+      try {
+        const name: unknown = JSON.parse(readFileSync(manifest, 'utf-8')).name
+        if (typeof name === 'string') return name
+      }
+      catch {
+        // An unreadable manifest names no package; keep looking further up
+      }
+    }
+    if (dir === root) break
+  }
+  return undefined
+}
+
+function fileContent(project: Project, path: string): string {
+  return project.files.get(path)?.content
+    ?? project.subProjects?.map(subProject => subProject.files.get(path)?.content).find(Boolean)
+    ?? ''
+}
@@ -0,0 +1,290 @@
+/**
+ * Symbol normalization - maps the declarations each language extractor finds onto one model
+ * (kind, visibility, signature, container, doc), so tools can treat symbols alike whatever
+ * the grammar calls them
+ */
+
+import type Parser from 'tree-sitter'
+import { PARSER_NAMES } from '../constants/parsers.js'
+import { readGoTypeParameters } from './go-generics.js'
+import { declaratorName, parseTemplateParameters } from './cpp.js'
+import type { LanguageConfig, SymbolInfo, SymbolKind, SymbolVisibility } from '../types/core.js'
+
+const MAX_SIGNATURE_LENGTH = 200
+
+const CLASS_KINDS: Record<string, SymbolKind> = {
+  interface_declaration: 'interface',
+  trait_item: 'trait',
+  trait_definition: 'trait',
+  struct_item: 'struct',
+  struct_specifier: 'struct',
+  enum_item: 'enum',
+  enum_declaration: 'enum',
+  enum_definition: 'enum',
+  annotation_type_declaration: 'interface',
+  module: 'module',
+}
+
+// Declarations nested in a class-like node of these types are methods
+const IMPL_TYPES = ['impl_item']
+
+// Nodes wrapping a declaration; its doc comment sits above the outermost one
+const DECLARATION_WRAPPERS = new Set([
+  'export_statement',
+  'variable_declarator',
+  'lexical_declaration',
+  'variable_declaration',
+  'decorated_definition',
+  'public_field_definition',
+  'template_declaration',
+])
+
+const MODIFIER_PATTERN = /\b(public|protected|internal|private)\b/
+
+/**
+ * Describes a declaration found in a syntax tree. `extracted` is what the generic extractor
+ * took the node for; the grammar's node types refine it.
+ */
+export function describeSyntaxSymbol(
+  node: Parser.SyntaxNode,
+  source: string,
+  language: LanguageConfig,
+  extracted: 'function' | 'class' | 'variable',
+  name: string,
+): SymbolInfo {
+  const containerNode = findContainer(node, language)
+  const container = containerNode ? containerName(containerNode, source) : receiverType(node, source) ?? qualifiedScope(node, source, language)
+
+  let kind: SymbolKind = extracted
+  if (extracted === 'function' && (container || node.type === 'method_declaration')) kind = 'method'
+  if (extracted === 'class') kind = classKind(node, source, language)
+
+  const symbol: SymbolInfo = {
+    kind,
+    visibility: visibilityOf(node, source, language, name, containerNode),
+    signature: signatureOf(node, source),
+  }
+  if (container) symbol.container = container
+
+  const doc = docOf(node, source, language)
+  if (doc) symbol.doc = doc
+
+  if (language.name === PARSER_NAMES.GO && extracted !== 'variable') {
+    const typeParameters = readGoTypeParameters(symbol.signature, name)
+    if (typeParameters.length > 0) symbol.typeParameters = typeParameters
+  }
+  if (language.name === PARSER_NAMES.JAVA) {
+    const annotations = javaAnnotations(node, source)
+    if (annotations.length > 0) symbol.annotations = annotations
+  }
+  const template = node.parent?.type === 'template_declaration' ? node.parent.childForFieldName('parameters') : null
+  if (template) {
+    const typeParameters = parseTemplateParameters(textOf(template, source).slice(1, -1))
+    if (typeParameters.length > 0) symbol.typeParameters = typeParameters
+  }
+  return symbol
+}
+
+/**
+ * Strips comment markers (`/** *\/`, `//`, `///`, `#`) from a comment block
+ */
+export function cleanComment(comment: string): string {
+  return comment
+    .replace(/^\s*\/\*\*?|\*\/\s*$/g, '')
+    .split('\n')
+    .map(line => line.replace(/^\s*(?:\*(?!\/)|\/\/\/?|#)\s?/, '').trimEnd())
+    .join('\n')
+    .trim()
+}
+
+function findContainer(node: Parser.SyntaxNode, language: LanguageConfig): Parser.SyntaxNode | undefined {
+  for (let ancestor = node.parent; ancestor; ancestor = ancestor.parent) {
+    if (language.classTypes.includes(ancestor.type) || IMPL_TYPES.includes(ancestor.type)) return ancestor
+
+    // A function declared inside another function's body is local to it, not a member
+    const body = ancestor.childForFieldName('body')
+    if (language.functionTypes.includes(ancestor.type) && body && body.startIndex <= node.startIndex && node.endIndex <= body.endIndex) {
+      return undefined
+    }
+  }
+  return undefined
+}
+
+function containerName(node: Parser.SyntaxNode, source: string): string | undefined {
+  const typeNode = IMPL_TYPES.includes(node.type) ? node.childForFieldName('type') : undefined
+  if (typeNode) return textOf(typeNode, source).replace(/<.*$/s, '')
+  return nodeName(node, source)
+}
+
+/**
+ * Go methods are declared outside their type; the receiver names it
+ */
+function receiverType(node: Parser.SyntaxNode, source: string): string | undefined {
+  const receiver = node.type === 'method_declaration' ? node.childForFieldName('receiver') : null
+  return receiver ? textOf(receiver, source).match(/\*?\s*([A-Za-z_]\w*)\s*(?:\[[^\]]*\])?\s*\)\s*$/)?.[1] : undefined
+}
+
+/**
+ * C++ members defined outside their class are qualified by it, as in `Cache::get`
+ */
+function qualifiedScope(node: Parser.SyntaxNode, source: string, language: LanguageConfig): string | undefined {
+  return language.name === PARSER_NAMES.CPP && node.type === 'function_definition' ? declaratorName(node, source)?.scope : undefined
+}
+
+function nodeName(node: Parser.SyntaxNode, source: string): string | undefined {
+  const nameNode = node.childForFieldName('name')
+    ?? node.namedChildren.find(child => child.type === 'type_spec')?.childForFieldName('name')
+    ?? node.namedChildren.find(child => ['identifier', 'type_identifier', 'simple_identifier', 'constant'].includes(child.type))
+  return nameNode ? textOf(nameNode, source) : undefined
+}
+
+function classKind(node: Parser.SyntaxNode, source: string, language: LanguageConfig): SymbolKind {
+  if (node.type === 'type_declaration') {
+    const type = node.namedChildren.find(child => child.type === 'type_spec')?.childForFieldName('type')?.type
+    return type === 'struct_type' ? 'struct' : type === 'interface_type' ? 'interface' : 'type'
+  }
+
+  // Kotlin declares interfaces and enums as class_declaration
+  if (language.name === PARSER_NAMES.KOTLIN && node.type === 'class_declaration') {
+    const header = textOf(node, source).split('{')[0] ?? ''
+    if (/\binterface\b/.test(header)) return 'interface'
+    if (/\benum\s+class\b/.test(header)) return 'enum'
+  }
+
+  return CLASS_KINDS[node.type] ?? 'class'
+}
+
+function visibilityOf(node: Parser.SyntaxNode, source: string, language: LanguageConfig, name: string, containerNode?: Parser.SyntaxNode): SymbolVisibility {
+  const text = textOf(node, source)
+  const nameIndex = text.indexOf(name)
+  const header = nameIndex > 0 ? text.substring(0, nameIndex) : ''
+  const modifier = header.match(MODIFIER_PATTERN)?.[1] as SymbolVisibility | undefined
+
+  switch (language.name) {
+    case PARSER_NAMES.GO:
+      return /^[A-Z]/.test(name) ? 'public' : 'internal'
+
+    case PARSER_NAMES.PYTHON:
+      if (name.startsWith('__') && !name.endsWith('__')) return 'private'
+      return name.startsWith('_') ? 'internal' : 'public'
+
+    case PARSER_NAMES.RUST: {
+      const visibility = node.namedChildren.find(child => child.type === 'visibility_modifier')
+      if (visibility) return textOf(visibility, source) === 'pub' ? 'public' : 'internal'
+      // Trait items and trait implementations are as visible as the trait
+      return containerNode?.type === 'trait_item' || containerNode?.childForFieldName('trait') ? 'public' : 'private'
+    }
+
+    case PARSER_NAMES.JAVASCRIPT:
+    case PARSER_NAMES.TYPESCRIPT:
+    case PARSER_NAMES.TSX:
+      if (name.startsWith('#')) return 'private'
+      if (modifier) return modifier
+      return containerNode || isExported(node) ? 'public' : 'internal'
+
+    case PARSER_NAMES.C:
+      return /\bstatic\b/.test(header) ? 'internal' : 'public'
+
+    case PARSER_NAMES.CPP:
+      return sectionVisibility(node, source) ?? (containerNode?.type === 'class_specifier' ? 'private' : 'public')
+
+    case PARSER_NAMES.RUBY:
+      return sectionVisibility(node, source) ?? 'public'
+
+    case PARSER_NAMES.JAVA:
+      return modifier ?? (containerNode?.type === 'interface_declaration' ? 'public' : 'internal')
+
+    case PARSER_NAMES.CSHARP:
+      if (modifier) return modifier
+      if (containerNode?.type === 'interface_declaration') return 'public'
+      return containerNode ? 'private' : 'internal'
+
+    default:
+      return modifier ?? 'public'
+  }
+}
+
+function isExported(node: Parser.SyntaxNode): boolean {
+  for (let ancestor = node.parent; ancestor && ancestor.type !== 'program'; ancestor = ancestor.parent) {
+    if (ancestor.type === 'export_statement') return true
+  }
+  return false
+}
+
+/**
+ * C++ `private:` labels and Ruby's bare `private` apply to the members that follow them
+ */
+function sectionVisibility(node: Parser.SyntaxNode, source: string): SymbolVisibility | undefined {
+  for (let sibling = node.previousNamedSibling; sibling; sibling = sibling.previousNamedSibling) {
+    if (sibling.type !== 'access_specifier' && sibling.type !== 'identifier') continue
+    const word = textOf(sibling, source).replace(/:$/, '').trim()
+    if (word === 'public' || word === 'protected' || word === 'private') return word
+  }
+  return undefined
+}
+
+function signatureOf(node: Parser.SyntaxNode, source: string): string {
+  const body = node.childForFieldName('body')
+    ?? node.namedChildren.find(child => /(?:body|block|declaration_list)$/.test(child.type))
+
+  // `const handler = (req) => ...` reads better with the name it is bound to
+  const start = node.parent && DECLARATION_WRAPPERS.has(node.parent.type) && node.parent.type !== 'export_statement'
+    ? node.parent.startIndex
+    : node.startIndex
+  const header = body && body.startIndex > start
+    ? source.substring(start, body.startIndex)
+    : source.substring(start, node.endIndex).split('\n')[0] ?? ''
+
+  return header.replace(/\s+/g, ' ').replace(/\s*[{:;]?\s*$/, '').trim().substring(0, MAX_SIGNATURE_LENGTH)
+}
+
+function docOf(node: Parser.SyntaxNode, source: string, language: LanguageConfig): string | undefined {
+  if (language.name === PARSER_NAMES.PYTHON) {
+    const docstring = pythonDocstring(node, source)
+    if (docstring) return docstring
+  }
+
+  let anchor = node
+  while (anchor.parent && DECLARATION_WRAPPERS.has(anchor.parent.type)) anchor = anchor.parent
+
+  const comments: string[] = []
+  let line = anchor.startPosition.row
+  for (let sibling = anchor.previousNamedSibling; sibling && sibling.endPosition.row >= line - 1; sibling = sibling.previousNamedSibling) {
+    // Attributes and decorators sit between a declaration and its doc comment
+    if (sibling.type !== 'attribute_item' && sibling.type !== 'decorator') {
+      if (!sibling.type.includes('comment')) break
+      comments.unshift(textOf(sibling, source))
+    }
+    line = sibling.startPosition.row
+  }
+
+  return comments.length > 0 ? cleanComment(comments.join('\n')) || undefined : undefined
+}
+
+/**
+ * Annotations on a Java declaration, as written: `@Service`, `@GetMapping("/{id}")`
+ */
+function javaAnnotations(node: Parser.SyntaxNode, source: string): string[] {
+  const modifiers = node.namedChildren.find(child => child.type === 'modifiers')
+  return (modifiers?.namedChildren ?? [])
+    .filter(child => child.type === 'marker_annotation' || child.type === 'annotation')
+    .map(child => textOf(child, source).replace(/\s+/g, ' '))
+}
+
+function pythonDocstring(node: Parser.SyntaxNode, source: string): string | undefined {
+  const first = node.childForFieldName('body')?.namedChildren[0]
+  const string = first?.type === 'expression_statement' ? first.namedChildren[0] : undefined
+  if (string?.type !== 'string') return undefined
+
+  return textOf(string, source)
+    .replace(/^[rRuUbBfF]*("""|'''|"|')/, '')
+    .replace(/("""|'''|"|')$/, '')
+    .split('\n')
+    .map(line => line.trim())
+    .join('\n')
+    .trim() || undefined
+}
+
+function textOf(node: Parser.SyntaxNode, source: string): string {
+  return source.substring(node.startIndex, node.endIndex)
+}
@@ -0,0 +1,420 @@
+/**
+ * Type information - the declared type of a variable, field or parameter and the declaration
+ * of that type. The index stores no types, so they are read from the declaring source; a
+ * language server, when installed, answers precisely, including for inferred types.
+ */
+
+import { readFileSync } from 'fs'
+import { hoverAt, typeDefinitionsAt, type ServerStatus } from '../lsp/client.js'
+import { getAllNodes } from '../project/manager.js'
+import { getLanguageForFile } from './languages.js'
+import { findSymbolCandidates, findSymbolsById } from './symbol-ids.js'
+import { identifierAt, locatedDefinition, TREE_SITTER_RESOLVER, type DefinitionOptions, type ResolvedDefinition } from './definitions.js'
+import { isFile } from '../utils/helpers.js'
+import type { Project, TreeNode } from '../types/core.js'
+
+export const TYPE_ROLES = ['parameter', 'variable', 'field'] as const
+export type TypeRole = typeof TYPE_ROLES[number]
+
+export interface DeclaredType {
+  type: string // As written, e.g. `*User` or `Optional[list[Order]]`
+  role?: TypeRole
+  path?: string // Where it is declared; unset for a type the language server inferred
+  line?: number
+  resolvedBy: string
+}
+
+export type TypeDefinition = ResolvedDefinition & {
+  content?: string // Source of the declaration, the shape of the type
+  contentTruncated?: boolean
+}
+
+export interface TypeInfo {
+  name?: string // Identifier at the position
+  declaredType?: DeclaredType
+  resolvedBy: string // Resolver that produced `typeDefinitions`
+  typeDefinitions: TypeDefinition[]
+  hover?: string // The language server's description, with documentation
+  languageServer: { server?: string, status: ServerStatus, error?: string }
+}
+
+// How declarations spell types: `name: Type` (Python also annotating bare names), Go's
+// `name Type` or C's `Type name`
+type Syntax = 'colon' | 'python' | 'go' | 'c'
+
+const SYNTAX_BY_LANGUAGE: Record<string, Syntax> = {
+  typescript: 'colon',
+  tsx: 'colon',
+  javascript: 'colon',
+  python: 'python',
+  rust: 'colon',
+  kotlin: 'colon',
+  swift: 'colon',
+  scala: 'colon',
+  go: 'go',
+  java: 'c',
+  c_sharp: 'c',
+  c: 'c',
+  cpp: 'c',
+  dart: 'c',
+}
+
+const TYPE_KINDS = ['class', 'interface', 'struct', 'enum', 'trait', 'type']
+const CALLABLE_KINDS = ['function', 'method']
+const MAX_DEFINITION_LINES = 40
+const MAX_DEFINITIONS_PER_NAME = 3
+
+// Keywords that read like a type before a name in C-like code, e.g. `return x;`
+const NOT_TYPES = new Set(['return', 'new', 'else', 'case', 'throw', 'goto', 'delete', 'yield', 'await', 'typeof', 'sizeof', 'in', 'of', 'is', 'as'])
+const INFERRED_TYPES = new Set(['var', 'val', 'auto', 'let', 'const', 'final', 'dynamic'])
+const COLON_MODIFIERS = '(?:(?:pub(?:\\([\\w:]+\\))?|private|protected|public|readonly|static|declare|override|abstract|val|var|let|const|mut|lateinit|open|internal)\\s+)*'
+
+/**
+ * The declared type of the identifier at a 1-based line and column, and where that type is
+ * declared. A language server's hover and type definition take precedence; the source is read
+ * for declarations and parameters, then for fields of the enclosing or receiver type.
+ */
+export async function getTypeInfo(project: Project, filePath: string, line: number, column: number, options: DefinitionOptions = {}): Promise<TypeInfo> {
+  const { precise = true, settings = {} } = options
+  const lines = isFile(filePath) ? readFileSync(filePath, 'utf-8').split('\n') : []
+  const lineText = lines[line - 1] ?? ''
+  const name = identifierAt(lineText, column - 1)
+
+  const inferred = name ? inferDeclaredType(project, filePath, lines, line, column - 1, name) : undefined
+  const fromSource = inferred ? { ...inferred, resolvedBy: TREE_SITTER_RESOLVER } : undefined
+  const indexed = fromSource ? typeDeclarations(project, fromSource.type, filePath) : []
+
+  if (!precise) {
+    return { name, declaredType: fromSource, resolvedBy: TREE_SITTER_RESOLVER, typeDefinitions: indexed, languageServer: { status: 'disabled' } }
+  }
+
+  const directory = project.config.directory
+  const [hover, located] = await Promise.all([
+    hoverAt(directory, filePath, line, column, settings),
+    typeDefinitionsAt(directory, filePath, line, column, settings),
+  ])
+  const failed = [hover, located].find(answer => answer.status === 'failed')
+  const languageServer = { server: located.server, status: failed?.status ?? located.status, ...(failed?.error ? { error: failed.error } : {}) }
+
+  const hoverType = name && hover.result ? typeFromHover(hover.result, name) : undefined
+  const declaredType = hoverType
+    ? { ...(fromSource ?? {}), type: hoverType, ...(fromSource ? {} : { role: roleFromHover(hover.result!) }), resolvedBy: hover.server! }
+    : fromSource
+
+  const definitions = (located.result ?? []).map(location => withContent(project, locatedDefinition(project, location, located.server!)))
+  if (definitions.length > 0) {
+    return { name, declaredType, resolvedBy: located.server!, typeDefinitions: definitions, ...(hover.result ? { hover: hover.result } : {}), languageServer }
+  }
+
+  // A type the server named is looked up in the index when it gave no location
+  const typeDefinitions = hoverType && hoverType !== fromSource?.type ? typeDeclarations(project, hoverType, filePath) : indexed
+  return { name, declaredType, resolvedBy: TREE_SITTER_RESOLVER, typeDefinitions, ...(hover.result ? { hover: hover.result } : {}), languageServer }
+}
+
+/**
+ * The declared type of `name`, used at a 0-based index of a line: a member access resolves
+ * the receiver's type first, and a bare name is looked up in local declarations, parameters,
+ * fields of the enclosing type and module-level declarations, innermost first
+ */
+function inferDeclaredType(project: Project, filePath: string, lines: string[], line: number, index: number, name: string): Omit<DeclaredType, 'resolvedBy'> | undefined {
+  const syntax = SYNTAX_BY_LANGUAGE[getLanguageForFile(filePath)?.name ?? '']
+  if (!syntax) return undefined
+
+  const lineText = lines[line - 1] ?? ''
+  let start = Math.min(index, lineText.length)
+  while (start > 0 && /[\w$]/.test(lineText[start - 1]!)) start--
+
+  const enclosing = getAllNodes(project)
+    .filter(node => node.path === filePath && node.type !== 'file' && node.type !== 'parameter' && node.startLine !== undefined)
+    .filter(node => node.startLine! <= line && line <= (node.endLine ?? node.startLine!))
+    .sort((a, b) => b.startLine! - a.startLine!)
+  const callables = enclosing.filter(node => CALLABLE_KINDS.includes(kindOf(node)))
+
+  if (lineText[start - 1] === '.') {
+    const receiver = identifierAt(lineText, start - 2)
+    if (!receiver) return undefined
+    const own = receiver === 'this' || receiver === 'self'
+    const receiverType = own ? undefined : inferDeclaredType(project, filePath, lines, line, start - 2, receiver)?.type
+    const types = own ? enclosingTypes(project, enclosing) : receiverType ? typeNodes(project, receiverType) : []
+    if (types.length > 0) return fieldOf(types, name, syntax)
+    // Without the receiver's type, a field only one type declares
+    const declaring = typeNodes(project, undefined).filter(node => fieldOf([node], name, syntax))
+    return declaring.length === 1 ? fieldOf(declaring, name, syntax) : undefined
+  }
+
+  // Local declarations from the line back to the start of the outermost enclosing function
+  const scopeStart = callables[callables.length - 1]?.startLine ?? 1
+  for (let current = line; current >= scopeStart; current--) {
+    const type = variableType(lines[current - 1] ?? '', name, syntax)
+    if (type) return { type, role: 'variable', path: filePath, line: current }
+  }
+
+  for (const callable of callables) {
+    const type = parameterType(signatureOf(callable), name, syntax)
+    if (type) return { type, role: 'parameter', path: filePath, line: callable.startLine }
+  }
+
+  const field = fieldOf(enclosingTypes(project, enclosing), name, syntax)
+  if (field) return field
+
+  for (let current = 1; current <= lines.length; current++) {
+    const type = variableType(lines[current - 1]!, name, syntax)
+    if (type) return { type, role: 'variable', path: filePath, line: current }
+  }
+  return undefined
+}
+
+/**
+ * The type a declaration on the line gives `name`, spelled out or read from its initializer
+ */
+function variableType(line: string, name: string, syntax: Syntax): string | undefined {
+  const id = escape(name)
+  let match: RegExpExecArray | null
+
+  if (syntax === 'colon') {
+    match = new RegExp(`\\b(?:const|let|var|val|mut)\\s+(?:mut\\s+)?${id}[?!]?\\s*:\\s*(?!:)`).exec(line)
+  }
+  else if (syntax === 'python') {
+    match = new RegExp(`^\\s*${id}\\s*:\\s*(?!:)`).exec(line)
+  }
+  else if (syntax === 'go') {
+    match = new RegExp(`\\bvar\\s+${id}(?:\\s*,\\s*\\w+)*\\s+(?![=\\s])`).exec(line)
+  }
+  else {
+    const declaration = new RegExp(`(?:^|[;{(,]\\s*|\\s)((?:[A-Za-z_][\\w.:]*)(?:<[^;=()]*>)?(?:\\[\\])*[*&]*)\\s+[*&]*${id}\\s*(?:=|;|,|\\)|:|$)`).exec(line)
+    const type = declaration?.[1]
+    if (type && !NOT_TYPES.has(type) && !INFERRED_TYPES.has(type)) return type
+    match = null
+  }
+
+  if (match) {
+    const type = readType(line, match.index + match[0].length, syntax)
+    if (type) return type
+  }
+  return initializerType(line, name)
+}
+
+/**
+ * The type a constructor call or composite literal assigned to `name` creates, such as
+ * `new User(`, `User{`, `&User{`, `User::new(` or Python's `User(`
+ */
+function initializerType(line: string, name: string): string | undefined {
+  const match = new RegExp(`(?:^|[^\\w.$])${escape(name)}\\s*(?::=|=)\\s*(new\\s+|&)?([A-Za-z_][\\w.]*)(?:<[^>]*>)?(::\\w+)?\\s*[({]`).exec(line)
+  if (!match) return undefined
+  const [, prefix, type, associated] = match
+  const last = type!.split('.').pop()!
+  // A lower-case call is a function whose return type is unknown here
+  if (!prefix && !associated && !/^[A-Z]/.test(last)) return undefined
+  return prefix?.trim() === '&' ? `*${type}` : type
+}
+
+/**
+ * The type of the parameter `name` in a function header
+ */
+function parameterType(signature: string, name: string, syntax: Syntax): string | undefined {
+  for (const group of parameterGroups(signature)) {
+    const parameters = splitTopLevel(group)
+    for (let index = 0; index < parameters.length; index++) {
+      const parameter = parameters[index]!.replace(/@\w+(?:\([^)]*\))?/g, '').trim()
+
+      if (isColon(syntax)) {
+        const match = new RegExp(`^(?:\\w+\\s+)*[*&]*${escape(name)}[?!]?\\s*:\\s*`).exec(parameter)
+        if (match) return stripDefault(parameter.substring(match[0].length))
+      }
+      else if (syntax === 'go') {
+        const [first, ...rest] = parameter.split(/\s+/)
+        if (first !== name) continue
+        // `a, b int` gives both names the type of the last one
+        if (rest.length > 0) return rest.join(' ')
+        const typed = parameters.slice(index + 1).map(next => next.trim().split(/\s+/)).find(tokens => tokens.length > 1)
+        if (typed) return typed.slice(1).join(' ')
+      }
+      else {
+        const match = new RegExp(`^(.*?)\\s*[*&]*\\b${escape(name)}(\\[\\])?\\s*(?:=.*)?$`).exec(parameter)
+        const type = match?.[1]?.replace(/\b(?:final|const|in|out|ref|params|this)\s+/g, '').trim()
+        if (type) return `${type}${match![2] ?? ''}`
+      }
+    }
+  }
+  return undefined
+}
+
+/**
+ * The first field named `name` declared in one of the type declarations
+ */
+function fieldOf(types: TreeNode[], name: string, syntax: Syntax): Omit<DeclaredType, 'resolvedBy'> | undefined {
+  const id = escape(name)
+  for (const node of types) {
+    const lines = (node.content ?? '').split('\n')
+    for (let index = 0; index < lines.length; index++) {
+      const line = lines[index]!
+      const type = isColon(syntax)
+        ? readAfter(line, new RegExp(`^\\s*${COLON_MODIFIERS}(?:self\\.|this\\.)?${id}[?!]?\\s*:\\s*(?!:)`), syntax)
+          ?? readAfter(line, new RegExp(`\\b(?:self|this)\\.${id}\\s*:\\s*(?!:)`), syntax)
+          ?? initializerType(line.replace(/\b(?:self|this)\./, ''), name)
+        : syntax === 'go'
+          ? readAfter(line, new RegExp(`^\\s*${id}(?:\\s*,\\s*\\w+)*\\s+(?=[\\w*\\[.]|map\\b|func\\b|chan\\b)`), syntax)
+          : index > 0 ? variableType(line, name, syntax) : undefined
+      if (type) return { type, role: 'field', path: node.path, line: (node.startLine ?? 1) + index }
+    }
+  }
+  return undefined
+}
+
+function readAfter(line: string, pattern: RegExp, syntax: Syntax): string | undefined {
+  const match = pattern.exec(line)
+  return match ? readType(line, match.index + match[0].length, syntax) : undefined
+}
+
+/**
+ * The type expression starting at `start`, up to the first delimiter outside brackets
+ */
+function readType(line: string, start: number, syntax: Syntax): string | undefined {
+  let depth = 0
+  let end = start
+  for (; end < line.length; end++) {
+    const char = line[end]!
+    if ('<([{'.includes(char)) {
+      // A Go or Rust body or struct literal follows the type
+      if (char === '{' && depth === 0 && end > start && !isColon(syntax)) break
+      depth++
+    }
+    else if ('>)]}'.includes(char)) {
+      if (char === '>' && line[end - 1] === '-') continue
+      if (depth === 0) break
+      depth--
+    }
+    else if (depth === 0 && (char === '=' || char === ',' || char === ';' || char === '`')) break
+    else if (depth === 0 && line.startsWith('//', end)) break
+    else if (depth === 0 && isColon(syntax) && char === ':' && line[end + 1] !== ':' && line[end - 1] !== ':') break
+  }
+  const type = line.substring(start, end).trim().replace(/\?$/, '').trim()
+  return type || undefined
+}
+
+/**
+ * The parenthesized lists of a function header: Go's receiver and parameters, or the parameters
+ */
+function parameterGroups(signature: string): string[] {
+  const groups: string[] = []
+  let depth = 0
+  let start = 0
+  for (let index = 0; index < signature.length; index++) {
+    const char = signature[index]!
+    if (char === '(') {
+      if (depth === 0) start = index + 1
+      depth++
+    }
+    else if (char === ')' && depth > 0) {
+      depth--
+      if (depth === 0) groups.push(signature.substring(start, index))
+    }
+    else if ((char === '{' || char === '=') && depth === 0 && groups.length > 0) break
+  }
+  return groups
+}
+
+function splitTopLevel(text: string): string[] {
+  const parts: string[] = []
+  let depth = 0
+  let start = 0
+  for (let index = 0; index < text.length; index++) {
+    const char = text[index]!
+    if ('<([{'.includes(char)) depth++
+    else if ('>)]}'.includes(char) && !(char === '>' && text[index - 1] === '=')) depth--
+    else if (char === ',' && depth === 0) {
+      parts.push(text.substring(start, index))
+      start = index + 1
+    }
+  }
+  parts.push(text.substring(start))
+  return parts.filter(part => part.trim())
+}
+
+function stripDefault(type: string): string | undefined {
+  let depth = 0
+  for (let index = 0; index < type.length; index++) {
+    const char = type[index]!
+    if ('<([{'.includes(char)) depth++
+    else if ('>)]}'.includes(char)) depth--
+    else if (char === '=' && depth === 0 && type[index + 1] !== '>') return type.substring(0, index).trim() || undefined
+  }
+  return type.trim() || undefined
+}
+
+/**
+ * The declarations of the named types a type expression mentions, such as `User` and `Order`
+ * in `Map<User, Order[]>`, closest to the file first; built-in types have none
+ */
+function typeDeclarations(project: Project, type: string, filePath: string): TypeDefinition[] {
+  const names = [...new Set((type.match(/[A-Za-z_][\w]*(?:\.[A-Za-z_]\w*)*/g) ?? []).map(name => name.split('.').pop()!))]
+  return names.flatMap(name => findSymbolCandidates(project, name, TYPE_KINDS)
+    .sort((a, b) => Number(b.path === filePath) - Number(a.path === filePath))
+    .slice(0, MAX_DEFINITIONS_PER_NAME)
+    .map(candidate => withContent(project, { ...candidate, startLine: candidate.startLine ?? 1, resolvedBy: TREE_SITTER_RESOLVER })))
+}
+
+function withContent(project: Project, definition: ResolvedDefinition): TypeDefinition {
+  const node = definition.id ? findSymbolsById(project, definition.id)[0] : undefined
+  if (!node?.content) return definition
+  const lines = node.content.split('\n')
+  return {
+    ...definition,
+    content: lines.slice(0, MAX_DEFINITION_LINES).join('\n'),
+    ...(lines.length > MAX_DEFINITION_LINES ? { contentTruncated: true } : {}),
+  }
+}
+
+/**
+ * The type declarations a type expression names, or every type declaration without one
+ */
+function typeNodes(project: Project, type: string | undefined): TreeNode[] {
+  const declarations = getAllNodes(project).filter(node => TYPE_KINDS.includes(kindOf(node)))
+  if (type === undefined) return declarations
+  const names = new Set((type.match(/[A-Za-z_]\w*/g) ?? []))
+  return declarations.filter(node => names.has(node.name ?? ''))
+}
+
+/**
+ * The type a method belongs to: an enclosing class, or the container it was declared for
+ */
+function enclosingTypes(project: Project, enclosing: TreeNode[]): TreeNode[] {
+  const types = enclosing.filter(node => TYPE_KINDS.includes(kindOf(node)))
+  if (types.length > 0) return types
+  const container = enclosing.find(node => node.symbol?.container)?.symbol?.container
+  return container ? typeNodes(project, container) : []
+}
+
+function isColon(syntax: Syntax): boolean {
+  return syntax === 'colon' || syntax === 'python'
+}
+
+function signatureOf(node: TreeNode): string {
+  return node.symbol?.signature ?? (node.content ?? '').split('\n').slice(0, 5).join('\n')
+}
+
+function kindOf(node: TreeNode): string {
+  return node.symbol?.kind ?? node.type
+}
+
+/**
+ * The type in the first line of a language server's hover, e.g. `var u *User`, `const u: User`
+ * or pyright's `(variable) u: User`
+ */
+function typeFromHover(hover: string, name: string): string | undefined {
+  const code = /```\w*\n([^\n]*)/.exec(hover)?.[1] ?? hover.split('\n')[0]!
+  const id = escape(name)
+  const colon = new RegExp(`\\b${id}[?!]?\\s*:\\s*(.+)$`).exec(code)?.[1]
+  const spaced = new RegExp(`\\b${id}\\s+([^\\s=].*)$`).exec(code)?.[1]
+  return (colon ?? spaced)?.replace(/\s*=.*$/, '').trim() || undefined
+}
+
+function roleFromHover(hover: string): TypeRole {
+  if (/\(parameter\)|\bparam\b/.test(hover)) return 'parameter'
+  if (/\((?:property)\)|\bfield\b/.test(hover)) return 'field'
+  return 'variable'
+}
+
+function escape(name: string): string {
+  return name.replace(/[$]/g, '\\$')
+}
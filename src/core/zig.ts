@@ -0,0 +1,178 @@
+/**
+ * Zig - structs, enums, unions and functions read from the source text. Types are `const`
+ * declarations whose value is a container expression, so they are named by the declaration,
+ * and functions returning `type` contain the declarations of the struct they build.
+ */
+
+import type { SymbolKind, SymbolVisibility, TreeNode } from '../types/core.js'
+
+interface Definition {
+  type: string
+  kind: SymbolKind
+  name: string
+  visibility: SymbolVisibility
+  start: number
+  end: number
+  signature: string
+  doc?: string
+  container?: string
+  scope?: { start: number, end: number } // Body whose declarations this one contains
+}
+
+const MAX_SIGNATURE_LENGTH = 200
+
+const CONTAINER_DECLARATION = /(?<![\w.])(pub\s+)?const\s+(\w+)\s*(?::\s*type\s*)?=\s*(?:extern\s+|packed\s+)?(struct|enum|union|opaque|error)\b/g
+const FUNCTION_DECLARATION = /(?<![\w.])(pub\s+)?(?:(?:export|extern(?:\s+"[^"]*")?)\s+)?(?:inline\s+|noinline\s+)?fn\s+(\w+)\s*\(/g
+const CONTAINER_KINDS: Record<string, SymbolKind> = { struct: 'struct', enum: 'enum', union: 'type', opaque: 'type', error: 'enum' }
+
+/**
+ * `class` nodes for container declarations (`const Point = struct { ... }`) and `function`
+ * nodes for `fn` declarations, with the enclosing types as `symbol.container`. Functions
+ * taking `self` are methods.
+ */
+export function extractZigDefinitions(content: string, filePath: string): TreeNode[] {
+  const code = maskZig(content)
+  const braces = matchBraces(code)
+  const definitions = [...readContainers(content, code, braces), ...readFunctions(content, code, braces)]
+    .sort((a, b) => a.start - b.start)
+
+  for (const definition of definitions) {
+    const enclosing = definitions.filter(other => other.scope && other !== definition
+      && other.scope.start < definition.start && definition.start < other.scope.end)
+    if (enclosing.length > 0) definition.container = enclosing.map(other => other.name).join('.')
+    if (definition.kind === 'function' && definition.container && /^\s*\w+\s*\(\s*self\s*:/.test(definition.signature.replace(/^.*?\bfn\s+/, ''))) {
+      definition.kind = 'method'
+    }
+  }
+
+  const lines = content.split('\n')
+  return definitions.map(definition => toNode(content, lines, filePath, definition))
+}
+
+function readContainers(content: string, code: string, braces: Map<number, number>): Definition[] {
+  const definitions: Definition[] = []
+
+  for (const match of code.matchAll(CONTAINER_DECLARATION)) {
+    // Only the container body may follow, after an optional tag type: `enum(u8) {`, `union(enum) {`
+    const header = code.substring(match.index! + match[0].length).match(/^\s*(?:\([^)]*\)\s*)?\{/)
+    const open = header ? match.index! + match[0].length + header[0].length - 1 : -1
+    const close = braces.get(open)
+    if (close === undefined) continue
+
+    const start = match.index!
+    definitions.push({
+      type: 'class',
+      kind: CONTAINER_KINDS[match[3]!]!,
+      name: match[2]!,
+      visibility: match[1] ? 'public' : 'private',
+      start,
+      end: close + 1,
+      signature: content.substring(start, open).replace(/\s+/g, ' ').trim(),
+      doc: readDocComment(content, start),
+      scope: { start: open, end: close },
+    })
+  }
+
+  return definitions
+}
+
+function readFunctions(content: string, code: string, braces: Map<number, number>): Definition[] {
+  const definitions: Definition[] = []
+
+  for (const match of code.matchAll(FUNCTION_DECLARATION)) {
+    const start = match.index!
+    const paramsEnd = closingParen(code, start + match[0].length - 1)
+    // The body is the first brace after the return type; extern declarations end at `;`
+    const header = code.substring(paramsEnd).match(/^[^{;]*/)![0]
+    const open = paramsEnd + header.length
+    const close = code[open] === '{' ? braces.get(open) : undefined
+    const returnType = header.trim()
+
+    definitions.push({
+      type: 'function',
+      kind: 'function',
+      name: match[2]!,
+      visibility: match[1] || /\bexport\s/.test(match[0]) ? 'public' : 'private',
+      start,
+      end: close === undefined ? open + 1 : close + 1,
+      signature: content.substring(start, open).replace(/\s+/g, ' ').trim().substring(0, MAX_SIGNATURE_LENGTH),
+      doc: readDocComment(content, start),
+      // A function returning `type` builds a generic type, so it contains that type's members
+      ...(returnType === 'type' && close !== undefined ? { scope: { start: open, end: close } } : {}),
+    })
+  }
+
+  return definitions
+}
+
+function toNode(content: string, lines: string[], filePath: string, definition: Definition): TreeNode {
+  const startLine = lineAt(content, definition.start)
+  const endLine = lineAt(content, definition.end)
+  return {
+    id: `zig-${definition.type}-${filePath}-${startLine}-${definition.name}`,
+    type: definition.type,
+    name: definition.name,
+    path: filePath,
+    startLine,
+    endLine,
+    content: lines.slice(startLine - 1, endLine).join('\n'),
+    symbol: {
+      kind: definition.kind,
+      visibility: definition.visibility,
+      signature: definition.signature,
+      ...(definition.container ? { container: definition.container } : {}),
+      ...(definition.doc ? { doc: definition.doc } : {}),
+    },
+  }
+}
+
+/**
+ * The `///` doc comment lines directly above a declaration
+ */
+function readDocComment(content: string, start: number): string | undefined {
+  const lines = content.substring(0, start).split('\n').slice(0, -1)
+  const comments: string[] = []
+  for (let index = lines.length - 1; index >= 0 && /^\s*\/\/\/(?!\/)/.test(lines[index]!); index--) {
+    comments.unshift(lines[index]!.replace(/^\s*\/\/\/\s?/, '').trimEnd())
+  }
+  return comments.join('\n').trim() || undefined
+}
+
+function closingParen(code: string, open: number): number {
+  let depth = 0
+  for (let index = open; index < code.length; index++) {
+    if (code[index] === '(') depth++
+    else if (code[index] === ')' && --depth === 0) return index + 1
+  }
+  return code.length
+}
+
+function matchBraces(code: string): Map<number, number> {
+  const braces = new Map<number, number>()
+  const open: number[] = []
+  for (let index = 0; index < code.length; index++) {
+    if (code[index] === '{') {
+      open.push(index)
+    }
+    else if (code[index] === '}') {
+      const opening = open.pop()
+      if (opening !== undefined) braces.set(opening, index)
+    }
+  }
+  return braces
+}
+
+/**
+ * Blanks comments, strings, character literals and `\\` multiline string lines, keeping
+ * offsets and line numbers
+ */
+export function maskZig(content: string): string {
+  return content.replace(
+    /\/\/[^\n]*|\\\\[^\n]*|"(?:\\.|[^"\\\n])*"|'(?:\\[^'\n]{1,10}|[^'\\\n])'/g,
+    match => match.replace(/[^\n]/g, ' '),
+  )
+}
+
+function lineAt(content: string, index: number): number {
+  return content.substring(0, index).split('\n').length
+}
@@ -0,0 +1,253 @@
+/**
+ * gRPC API - the MCP tools for services without an MCP client (bots, CI jobs), described by
+ * proto/tree_sitter_mcp.proto. Every RPC runs through the MCP tool handlers, so both APIs
+ * answer the same. Uses the optional packages @grpc/grpc-js and @grpc/proto-loader.
+ */
+
+import { createRequire } from 'module'
+import { fileURLToPath } from 'url'
+import { handleToolRequest, setFormatEdits, setIndexFileLimit, setLanguageServerCommands, setReadOnlyMode, setResultCache } from '../mcp/handlers.js'
+import { createCallSession, isIdleSession, isThrottled, runLimitedCall, type CallLimits, type CallSession } from '../mcp/limits.js'
+import { MCP_TOOLS } from '../mcp/schemas.js'
+import { authenticate, runTenantCall, type TenantRegistry } from '../mcp/tenants.js'
+import { setFileRemotesAllowed } from '../project/remote.js'
+import { getLogger } from '../utils/logger.js'
+import { TreeSitterError } from '../utils/errors.js'
+import type { JsonObject, JsonValue } from '../types/core.js'
+
+const require = createRequire(import.meta.url)
+
+export const PROTO_PATH = fileURLToPath(new URL('../../proto/tree_sitter_mcp.proto', import.meta.url))
+export const GRPC_SERVICE = 'treesittermcp.v1.TreeSitterMcp'
+
+export interface GRPCServerOptions {
+  port: number // 0 picks a free port
+  host?: string // Defaults to 127.0.0.1; use 0.0.0.0 to serve other machines
+  readOnly?: boolean // Refuse tool calls that write files
+  tenants?: TenantRegistry // Require a token per call and restrict it to the projects it grants
+  limits?: CallLimits // Caps per client: per tenant with tenants, else per connection
+  resultCache?: boolean // false stops answering repeated calls from the result cache
+  languageServers?: Record<string, string[]> // Command line per language server id; tenants' calls never start one
+  formatEdits?: boolean // Format edited files when a call doesn't pass `format`; never for tenants
+  allowFileRemotes?: boolean // Let register_project clone file:// URLs
+}
+
+export interface GRPCServer {
+  port: number
+  close(): Promise<void>
+}
+
+export type ToolCaller = (name: string, args: JsonObject) => Promise<JsonObject>
+export type GRPCMethod = (request: JsonObject) => Promise<Record<string, unknown>>
+
+// google.protobuf.Value as @grpc/proto-loader represents it
+interface StructValue {
+  nullValue?: unknown
+  numberValue?: number
+  stringValue?: string
+  boolValue?: boolean
+  structValue?: { fields?: Record<string, StructValue> }
+  listValue?: { values?: StructValue[] }
+}
+
+// The parts of @grpc/grpc-js and @grpc/proto-loader used here
+interface GRPCModule {
+  Server: new () => {
+    addService(service: unknown, implementation: Record<string, unknown>): void
+    bindAsync(address: string, credentials: unknown, callback: (error: Error | null, port: number) => void): void
+    tryShutdown(callback: (error?: Error) => void): void
+  }
+  ServerCredentials: { createInsecure(): unknown }
+  loadPackageDefinition(definition: unknown): Record<string, unknown>
+  status: Record<string, number>
+  Metadata: new () => { set(key: string, value: string): void }
+}
+
+interface ProtoLoaderModule {
+  loadSync(path: string, options: object): unknown
+}
+
+interface UnaryCall {
+  request: JsonObject
+  metadata: { get(key: string): Array<string | { toString(): string }> }
+  getPeer(): string
+}
+
+type UnaryCallback = (error: { code: number, message: string, metadata?: unknown } | null, response?: Record<string, unknown>) => void
+
+/**
+ * Serves the gRPC API on a TCP port, sharing the MCP tools' project index
+ */
+export async function startGRPCServer(options: GRPCServerOptions): Promise<GRPCServer> {
+  const { grpc, protoLoader } = loadGRPC()
+  if (options.readOnly !== undefined) setReadOnlyMode(options.readOnly)
+  if (options.limits) setIndexFileLimit(options.limits.maxFilesPerCall)
+  if (options.resultCache === false) setResultCache(undefined)
+  if (options.languageServers) setLanguageServerCommands(options.languageServers)
+  if (options.formatEdits !== undefined) setFormatEdits(options.formatEdits)
+  if (options.allowFileRemotes !== undefined) setFileRemotesAllowed(options.allowFileRemotes)
+
+  const definition = protoLoader.loadSync(PROTO_PATH, { keepCase: false, longs: Number, enums: String, defaults: false, oneofs: true })
+  const service = GRPC_SERVICE.split('.').reduce<unknown>(
+    (scope, name) => (scope as Record<string, unknown> | undefined)?.[name],
+    grpc.loadPackageDefinition(definition),
+  ) as { service: unknown }
+
+  const { tenants, limits } = options
+  const sessions = new Map<string, CallSession>()
+  const sessionFor = (key: string): CallSession => {
+    for (const [other, session] of sessions) {
+      if (isIdleSession(session)) sessions.delete(other)
+    }
+    let session = sessions.get(key)
+    if (!session) sessions.set(key, session = createCallSession(limits!))
+    return session
+  }
+
+  const callerFor = (call: UnaryCall): ToolCaller => {
+    const tenant = tenants && authenticate(tenants, call.metadata.get('authorization')[0]?.toString())
+    const caller: ToolCaller = tenant ? (tool, args) => runTenantCall(tenants!, tenant, tool, args, callMCPTool) : callMCPTool
+    if (!limits) return caller
+    const session = sessionFor(tenant ? `tenant:${tenant.name}` : call.getPeer())
+    return (tool, args) => runLimitedCall(session, tool, args, () => caller(tool, args))
+  }
+
+  const implementation: Record<string, unknown> = {}
+  for (const name of Object.keys(createGRPCMethods(callMCPTool))) {
+    implementation[name] = (call: UnaryCall, callback: UnaryCallback) => {
+      Promise.resolve().then(() => createGRPCMethods(callerFor(call))[name]!(call.request)).then(
+        response => callback(null, response),
+        error => callback({ code: statusOf(grpc, error), message: error instanceof Error ? error.message : String(error), metadata: retryMetadata(grpc, error) }),
+      )
+    }
+  }
+
+  const server = new grpc.Server()
+  server.addService(service.service, implementation)
+  const host = options.host ?? '127.0.0.1'
+  const port = await new Promise<number>((resolveBind, rejectBind) => {
+    server.bindAsync(`${host}:${options.port}`, grpc.ServerCredentials.createInsecure(), (error, boundPort) => {
+      if (error) rejectBind(error)
+      else resolveBind(boundPort)
+    })
+  })
+  getLogger().info(`gRPC server listening on ${host}:${port}${tenants ? ` for ${tenants.tenants.length} tenants` : ''}`)
+
+  return {
+    port,
+    close: () => new Promise<void>((resolveClose, rejectClose) => {
+      server.tryShutdown(error => error ? rejectClose(error) : resolveClose())
+    }),
+  }
+}
+
+/**
+ * The service's RPCs on top of a tool caller. Typed requests carry the tool's argument names
+ * (proto-loader turns `project_id` into `projectId`), and responses keep the fields the
+ * messages declare.
+ */
+export function createGRPCMethods(callTool: ToolCaller): Record<string, GRPCMethod> {
+  return {
+    ListTools: async () => ({
+      tools: MCP_TOOLS.map(tool => ({
+        name: tool.name,
+        description: tool.description,
+        inputSchema: toStruct(tool.inputSchema as JsonObject),
+        readOnly: tool.annotations.readOnlyHint,
+      })),
+    }),
+    CallTool: async request => ({
+      result: toStruct(await callTool(String(request.name ?? ''), fromStruct(request.arguments as StructValue['structValue']))),
+    }),
+    SearchCode: async request => callTool('search_code', { ...request, mode: 'symbols' }),
+    FindUsage: async request => callTool('find_usage', request),
+    AnalyzeCode: async (request) => {
+      const { analysis } = await callTool('analyze_code', request) as { analysis: JsonObject }
+      const findings = (analysis.findings ?? []) as JsonObject[]
+      return {
+        projectId: analysis.projectId ?? null,
+        findings: findings.map(({ metrics, ...finding }) => ({ ...finding, ...(metrics ? { metrics: toStruct(metrics as JsonObject) } : {}) })),
+        summary: analysis.summary ?? null,
+        totalFindings: analysis.totalFindings ?? findings.length,
+      }
+    },
+  }
+}
+
+/**
+ * Converts JSON to a google.protobuf.Struct
+ */
+export function toStruct(object: JsonObject): { fields: Record<string, StructValue> } {
+  const fields: Record<string, StructValue> = {}
+  for (const [key, value] of Object.entries(object)) {
+    if (value !== undefined) fields[key] = toValue(value)
+  }
+  return { fields }
+}
+
+/**
+ * Converts a google.protobuf.Struct back to JSON
+ */
+export function fromStruct(struct: { fields?: Record<string, StructValue> } | undefined): JsonObject {
+  const object: JsonObject = {}
+  for (const [key, value] of Object.entries(struct?.fields ?? {})) {
+    object[key] = fromValue(value)
+  }
+  return object
+}
+
+function toValue(value: JsonValue): StructValue {
+  if (value === null) return { nullValue: 'NULL_VALUE' }
+  if (typeof value === 'number') return { numberValue: value }
+  if (typeof value === 'string') return { stringValue: value }
+  if (typeof value === 'boolean') return { boolValue: value }
+  if (Array.isArray(value)) return { listValue: { values: value.map(toValue) } }
+  return { structValue: toStruct(value) }
+}
+
+function fromValue(value: StructValue): JsonValue {
+  if (value.numberValue !== undefined) return value.numberValue
+  if (value.stringValue !== undefined) return value.stringValue
+  if (value.boolValue !== undefined) return value.boolValue
+  if (value.listValue !== undefined) return (value.listValue.values ?? []).map(fromValue)
+  if (value.structValue !== undefined) return fromStruct(value.structValue)
+  return null
+}
+
+async function callMCPTool(name: string, args: JsonObject): Promise<JsonObject> {
+  const result = await handleToolRequest({ params: { name, arguments: args } })
+  const text = result.content[0]?.text ?? '{}'
+  try {
+    return JSON.parse(text)
+  }
+  catch {
+    return { text }
+  }
+}
+
+function statusOf(grpc: GRPCModule, error: unknown): number {
+  if (isThrottled(error)) return grpc.status.RESOURCE_EXHAUSTED!
+  if (error instanceof TreeSitterError && grpc.status[error.code] !== undefined) return grpc.status[error.code]!
+  const message = error instanceof Error ? error.message : String(error)
+  if (message.startsWith('Unknown tool')) return grpc.status.NOT_FOUND!
+  if (message.includes('read-only mode')) return grpc.status.PERMISSION_DENIED!
+  return grpc.status.INTERNAL!
+}
+
+// Throttled calls carry `retry-after-ms` when the limit says when the call may succeed
+function retryMetadata(grpc: GRPCModule, error: unknown): unknown {
+  const retryAfterMs = isThrottled(error) ? error.context?.retryAfterMs : undefined
+  if (retryAfterMs === undefined) return undefined
+  const metadata = new grpc.Metadata()
+  metadata.set('retry-after-ms', String(retryAfterMs))
+  return metadata
+}
+
+function loadGRPC(): { grpc: GRPCModule, protoLoader: ProtoLoaderModule } {
+  try {
+    return { grpc: require('@grpc/grpc-js'), protoLoader: require('@grpc/proto-loader') }
+  }
+  catch {
+    throw new Error('The gRPC API needs the optional packages @grpc/grpc-js and @grpc/proto-loader: npm install @grpc/grpc-js @grpc/proto-loader')
+  }
+}
@@ -0,0 +1,613 @@
+/**
+ * Import alias awareness - follows aliased imports, namespace imports and re-exports so a
+ * symbol is found under every name it is reachable by, e.g. `utils.FormatDate` for a
+ * `format.Date` that utils re-exports
+ */
+
+import { basename, dirname, extname, join, relative, resolve, sep } from 'path'
+import { getAllNodes } from '../project/manager.js'
+import { detectGoModules, detectGoReplaces, resolveGoImport } from '../project/go-workspace.js'
+import { expandPathAlias } from '../project/path-aliases.js'
+import { resolvePackageSpecifier, type ImportKind } from '../project/package-exports.js'
+import { getLanguageForFile } from '../core/languages.js'
+import { findBridgedExpressions, findBridgedExpressionsOf } from './bridging.js'
+import { PARSER_NAMES } from '../constants/parsers.js'
+import type { AliasMatch, GoModule, Project, TreeNode } from '../types/core.js'
+
+export interface SymbolRef {
+  module: string // Defining file, or package directory for Go
+  name: string
+}
+
+export interface ImportBinding {
+  local: string // Name bound in the importing file; dotted for Python `import a.b`
+  module: string
+  imported?: string // Undefined for namespace imports, which bind the whole module
+}
+
+export interface ModuleAliases {
+  module: string
+  files: string[]
+  qualifiers: Set<string> // Names the module is referred to by without an import: package or file name
+  definitions: Set<string>
+  exports: Map<string, SymbolRef> // Names the module exports on behalf of another symbol
+  starExports: string[] // Modules re-exported wholesale (`export * from`)
+}
+
+export interface AliasIndex {
+  modules: Map<string, ModuleAliases>
+  imports: Map<string, ImportBinding[]> // Keyed by importing file
+}
+
+interface FileAliases {
+  imports: ImportBinding[]
+  exports: [string, string][] // Exported name and the expression it stands for
+  directExports: [string, SymbolRef][] // `export { a as b } from 'm'`
+  starExports: string[]
+  qualifiers: string[]
+}
+
+interface ResolveContext {
+  project: Project
+  files: Set<string>
+  pythonFiles: string[]
+  goModules: GoModule[]
+}
+
+const SCRIPT_LANGUAGES: string[] = [PARSER_NAMES.JAVASCRIPT, PARSER_NAMES.TYPESCRIPT, PARSER_NAMES.TSX]
+const INDEXED_LANGUAGES = [...SCRIPT_LANGUAGES, PARSER_NAMES.PYTHON, PARSER_NAMES.GO]
+const SCRIPT_EXTENSIONS = ['.ts', '.tsx', '.js', '.jsx', '.mjs', '.cjs']
+const QUALIFIED_NAME = /^[\w$]+(?:\.[\w$]+)*$/
+
+const indexCache = new WeakMap<Project, { generation: number, index: AliasIndex }>()
+
+/**
+ * Builds (or reuses) the alias index of a project. It is rebuilt whenever the project has
+ * published a new index generation.
+ */
+export function getAliasIndex(project: Project): AliasIndex {
+  const cached = indexCache.get(project)
+  if (cached && cached.generation === (project.generation ?? 0)) return cached.index
+
+  const index = buildAliasIndex(project)
+  indexCache.set(project, { generation: project.generation ?? 0, index })
+  return index
+}
+
+/**
+ * Indexes the imports, re-exports and aliasing assignments of every JavaScript, TypeScript,
+ * Python and Go file of a project
+ */
+export function buildAliasIndex(project: Project): AliasIndex {
+  const fileNodes = new Map<string, TreeNode>()
+  for (const node of getAllNodes(project)) {
+    if (node.type === 'file') fileNodes.set(node.path, node)
+  }
+
+  const paths = [...fileNodes.keys()]
+  const context: ResolveContext = {
+    project,
+    files: new Set(paths),
+    pythonFiles: paths.filter(path => path.endsWith('.py')),
+    goModules: project.goModules
+      ?? (paths.some(path => path.endsWith('.go')) ? detectWorkspaceModules(project.config.directory) : []),
+  }
+
+  const index: AliasIndex = { modules: new Map(), imports: new Map() }
+  const pending: [ModuleAliases, FileAliases][] = []
+
+  for (const fileNode of fileNodes.values()) {
+    const language = getLanguageForFile(fileNode.path)?.name
+    const content = fileNode.content ?? ''
+    const aliases = SCRIPT_LANGUAGES.includes(language ?? '')
+      ? readScriptAliases(content, fileNode.path, context)
+      : language === PARSER_NAMES.PYTHON
+        ? readPythonAliases(content, fileNode.path, context)
+        : language === PARSER_NAMES.GO ? readGoAliases(content, context) : undefined
+
+    const moduleId = moduleIdOf(fileNode.path)
+    let module = index.modules.get(moduleId)
+    if (!module) {
+      module = { module: moduleId, files: [], qualifiers: new Set(), definitions: new Set(), exports: new Map(), starExports: [] }
+      index.modules.set(moduleId, module)
+    }
+    module.files.push(fileNode.path)
+    for (const child of fileNode.children ?? []) {
+      if (child.name) module.definitions.add(child.name)
+    }
+
+    if (aliases) {
+      index.imports.set(fileNode.path, aliases.imports)
+      aliases.qualifiers.forEach(qualifier => module.qualifiers.add(qualifier))
+      module.starExports.push(...aliases.starExports)
+      pending.push([module, aliases])
+    }
+  }
+
+  // Local names resolve through the file's imports, so exports are read once all imports are known
+  for (const [module, aliases] of pending) {
+    for (const [exported, ref] of aliases.directExports) {
+      module.exports.set(exported, ref)
+    }
+    for (const [exported, expression] of aliases.exports) {
+      const ref = resolveExpression(expression, aliases.imports, module.module)
+      if (ref && (ref.module !== module.module || ref.name !== exported)) module.exports.set(exported, ref)
+    }
+  }
+
+  return index
+}
+
+/**
+ * Resolves a name, plain (`FormatDate`) or qualified (`utils.FormatDate`), to the symbols
+ * it ultimately refers to. Only names reached through an import or export alias, or
+ * qualified by a module, resolve.
+ */
+export function resolveQualifiedName(index: AliasIndex, query: string): SymbolRef[] {
+  return uniqueRefs(resolveReExportChains(index, query).map(chain => chain[chain.length - 1]!))
+}
+
+/**
+ * Like resolveQualifiedName, but keeps the path to each symbol: the module-qualified names
+ * followed through re-exports, ending at the definition. A barrel that re-exports a name
+ * under itself starts a chain for the plain name too.
+ */
+export function resolveReExportChains(index: AliasIndex, query: string): SymbolRef[][] {
+  if (!QUALIFIED_NAME.test(query)) return []
+
+  const segments = query.split('.')
+  const name = segments.pop()!
+  const qualifier = segments.join('.')
+  const refs: SymbolRef[] = []
+
+  if (qualifier) {
+    const modules = new Set<string>()
+    for (const bindings of index.imports.values()) {
+      for (const binding of bindings) {
+        if (binding.imported === undefined && binding.local === qualifier) modules.add(binding.module)
+      }
+    }
+    for (const module of index.modules.values()) {
+      if (module.qualifiers.has(qualifier)) modules.add(module.module)
+    }
+    modules.forEach(module => refs.push({ module, name }))
+  }
+  else {
+    for (const module of index.modules.values()) {
+      if (module.exports.has(name) || (module.starExports.length > 0 && !module.definitions.has(name))) {
+        refs.push({ module: module.module, name })
+      }
+    }
+    for (const bindings of index.imports.values()) {
+      for (const binding of bindings) {
+        if (binding.local === name && binding.imported !== undefined && binding.imported !== name) {
+          refs.push({ module: binding.module, name: binding.imported })
+        }
+      }
+    }
+  }
+
+  const chains = new Map<string, SymbolRef[]>()
+  for (const ref of uniqueRefs(refs)) {
+    const chain = traceReExports(index, ref)
+    const definition = chain[chain.length - 1]!
+    if (!index.modules.get(definition.module)?.definitions.has(definition.name)) continue
+    // A plain name that is simply defined where it is looked up is not an alias
+    if (!qualifier && chain.length === 1) continue
+    chains.set(chain.map(refKey).join('\n'), chain)
+  }
+  return [...chains.values()]
+}
+
+/**
+ * Declarations a name resolves to through aliases, for ranking them as exact matches, each
+ * with the re-exports it was reached through. Where several chains reach one declaration
+ * the shortest is kept.
+ */
+export function findAliasedDefinitions(project: Project, query: string): AliasMatch[] {
+  const index = getAliasIndex(project)
+  const chains = resolveReExportChains(index, query).sort((a, b) => a.length - b.length)
+  if (chains.length === 0) return []
+
+  const fileNodes = new Map(getAllNodes(project).filter(node => node.type === 'file').map(node => [node.path, node]))
+  const matches = new Map<string, AliasMatch>()
+  for (const chain of chains) {
+    const definition = chain[chain.length - 1]!
+    const reExports = chain.slice(0, -1).map(ref => ({ path: ref.module, name: ref.name }))
+    for (const file of index.modules.get(definition.module)?.files ?? []) {
+      for (const node of fileNodes.get(file)?.children ?? []) {
+        if (node.name === definition.name && !matches.has(node.id)) matches.set(node.id, { node, reExports })
+      }
+    }
+  }
+  return [...matches.values()]
+}
+
+/**
+ * The other expressions, per file, by which the symbols an identifier names are used:
+ * aliased imports (`fd`), namespace members (`utils.FormatDate`), re-exported names and
+ * the names Objective-C and Swift see each other's declarations under
+ */
+export function findAliasExpressions(project: Project, identifier: string): Map<string, string[]> {
+  const index = getAliasIndex(project)
+  const targets = resolveQualifiedName(index, identifier)
+  if (!identifier.includes('.')) {
+    for (const module of index.modules.values()) {
+      if (module.definitions.has(identifier)) targets.push({ module: module.module, name: identifier })
+    }
+  }
+
+  return mergeExpressions(aliasExpressions(index, targets, identifier), findBridgedExpressions(project, identifier))
+}
+
+/**
+ * Like findAliasExpressions, for one definition rather than every symbol of a name
+ */
+export function findAliasExpressionsOf(project: Project, definition: TreeNode): Map<string, string[]> {
+  const expressions = aliasExpressions(getAliasIndex(project), [{ module: moduleIdOf(definition.path), name: definition.name ?? '' }], definition.name ?? '')
+  return mergeExpressions(expressions, findBridgedExpressionsOf(project, definition))
+}
+
+/**
+ * Files that can refer to a definition: those of its module and those importing the module
+ * or a module re-exporting it. Undefined for languages whose imports are not indexed.
+ */
+export function findDependentFiles(project: Project, definition: TreeNode): Set<string> | undefined {
+  const language = getLanguageForFile(definition.path)?.name ?? ''
+  if (!INDEXED_LANGUAGES.includes(language)) return undefined
+
+  const index = getAliasIndex(project)
+  const reachable = aliasClosure(index, [{ module: moduleIdOf(definition.path), name: definition.name ?? '' }])
+  const modules = new Set([...reachable.values()].map(ref => ref.module))
+
+  const files = new Set<string>()
+  for (const module of modules) {
+    index.modules.get(module)?.files.forEach(file => files.add(file))
+  }
+  for (const [file, bindings] of index.imports) {
+    if (bindings.some(binding => modules.has(binding.module))) files.add(file)
+  }
+  return files
+}
+
+/**
+ * The module a file belongs to in the alias index: its package directory for Go, the file
+ * itself otherwise
+ */
+export function moduleIdOf(filePath: string): string {
+  return getLanguageForFile(filePath)?.name === PARSER_NAMES.GO ? dirname(filePath) : filePath
+}
+
+/**
+ * Follows export aliases and star re-exports to the symbol a module-qualified name stands for
+ */
+export function canonicalRef(index: AliasIndex, ref: SymbolRef): SymbolRef {
+  const chain = traceReExports(index, ref)
+  return chain[chain.length - 1]!
+}
+
+/**
+ * The module-qualified names a ref passes through on its way to its definition, starting
+ * with the ref itself. Ends at the last name reached when there is no definition.
+ */
+export function traceReExports(index: AliasIndex, ref: SymbolRef, seen = new Set<string>()): SymbolRef[] {
+  const key = refKey(ref)
+  if (seen.has(key)) return [ref]
+  seen.add(key)
+
+  const module = index.modules.get(ref.module)
+  if (!module) return [ref]
+
+  const alias = module.exports.get(ref.name)
+  if (alias) return [ref, ...traceReExports(index, alias, seen)]
+
+  if (!module.definitions.has(ref.name)) {
+    for (const star of module.starExports) {
+      const chain = traceReExports(index, { module: star, name: ref.name }, seen)
+      const target = chain[chain.length - 1]!
+      if (index.modules.get(target.module)?.definitions.has(target.name)) return [ref, ...chain]
+    }
+  }
+  return [ref]
+}
+
+/**
+ * Every module-qualified name that resolves to one of `targets`, including the targets
+ */
+function aliasClosure(index: AliasIndex, targets: SymbolRef[]): Map<string, SymbolRef> {
+  const reachable = new Map(targets.map(ref => [refKey(ref), ref]))
+
+  let grown = true
+  while (grown) {
+    grown = false
+    for (const module of index.modules.values()) {
+      const candidates: SymbolRef[] = []
+      for (const [exported, ref] of module.exports) {
+        if (reachable.has(refKey(ref))) candidates.push({ module: module.module, name: exported })
+      }
+      for (const star of module.starExports) {
+        for (const ref of reachable.values()) {
+          if (ref.module === star && !module.definitions.has(ref.name)) candidates.push({ module: module.module, name: ref.name })
+        }
+      }
+
+      for (const candidate of candidates) {
+        if (!reachable.has(refKey(candidate))) {
+          reachable.set(refKey(candidate), candidate)
+          grown = true
+        }
+      }
+    }
+  }
+
+  return reachable
+}
+
+function aliasExpressions(index: AliasIndex, targets: SymbolRef[], identifier: string): Map<string, string[]> {
+  const reachable = aliasClosure(index, targets)
+  const expressions = new Map<string, string[]>()
+  const add = (file: string, expression: string) => {
+    if (expression === identifier) return
+    const list = expressions.get(file) ?? []
+    if (!list.includes(expression)) list.push(expression)
+    expressions.set(file, list)
+  }
+
+  for (const ref of reachable.values()) {
+    for (const file of index.modules.get(ref.module)?.files ?? []) add(file, ref.name)
+  }
+  for (const [file, bindings] of index.imports) {
+    for (const binding of bindings) {
+      for (const ref of reachable.values()) {
+        if (ref.module !== binding.module) continue
+        if (binding.imported === undefined) add(file, `${binding.local}.${ref.name}`)
+        else if (binding.imported === ref.name) add(file, binding.local)
+      }
+    }
+  }
+
+  return expressions
+}
+
+function mergeExpressions(expressions: Map<string, string[]>, more: Map<string, string[]>): Map<string, string[]> {
+  for (const [file, list] of more) {
+    expressions.set(file, [...new Set([...(expressions.get(file) ?? []), ...list])])
+  }
+  return expressions
+}
+
+function readScriptAliases(content: string, filePath: string, context: ResolveContext): FileAliases {
+  const aliases: FileAliases = { imports: [], exports: [], directExports: [], starExports: [], qualifiers: [] }
+  const resolveModule = (specifier: string, kind: ImportKind = 'import') => resolveScriptModule(specifier, filePath, context, kind)
+
+  const stem = basename(filePath, extname(filePath))
+  aliases.qualifiers.push(stem === 'index' ? basename(dirname(filePath)) : stem)
+
+  // import x, { a as b } from 'm' / import * as ns from 'm'
+  for (const match of content.matchAll(/^\s*import\s+(?:type\s+)?([\w$*{}\s,]+?)\s+from\s+['"]([^'"]+)['"]/gm)) {
+    const module = resolveModule(match[2]!)
+    if (!module) continue
+
+    const clause = match[1]!
+    const namespace = clause.match(/\*\s+as\s+([\w$]+)/)?.[1]
+    if (namespace) aliases.imports.push({ local: namespace, module })
+
+    for (const [imported, local] of readNamedList(clause.match(/\{([^}]*)\}/)?.[1])) {
+      aliases.imports.push({ local, module, imported })
+    }
+
+    const defaultName = clause.replace(/\{[^}]*\}/, '').replace(/\*\s+as\s+[\w$]+/, '').split(',')[0]?.trim()
+    if (defaultName) aliases.imports.push({ local: defaultName, module, imported: 'default' })
+  }
+
+  // const x = require('m') / const { a, b: c } = require('m')
+  for (const match of content.matchAll(/\b(?:const|let|var)\s+(\{[^}]*\}|[\w$]+)\s*=\s*require\(\s*['"]([^'"]+)['"]\s*\)/g)) {
+    const module = resolveModule(match[2]!, 'require')
+    if (!module) continue
+
+    if (match[1]!.startsWith('{')) {
+      for (const entry of match[1]!.slice(1, -1).split(',')) {
+        const [imported, local = imported] = entry.split(':').map(part => part.trim())
+        if (imported) aliases.imports.push({ local: local!, module, imported })
+      }
+    }
+    else {
+      aliases.imports.push({ local: match[1]!, module })
+    }
+  }
+
+  // export { a as b } / export { a as b } from 'm'
+  for (const match of content.matchAll(/^\s*export\s+(?:type\s+)?\{([^}]*)\}\s*(?:from\s+['"]([^'"]+)['"])?/gm)) {
+    const module = match[2] ? resolveModule(match[2]) : undefined
+    if (match[2] && !module) continue
+
+    for (const [name, exported] of readNamedList(match[1])) {
+      if (module) aliases.directExports.push([exported, { module, name }])
+      else aliases.exports.push([exported, name])
+    }
+  }
+
+  for (const match of content.matchAll(/^\s*export\s+\*\s+from\s+['"]([^'"]+)['"]/gm)) {
+    const module = resolveModule(match[1]!)
+    if (module) aliases.starExports.push(module)
+  }
+
+  // export const FormatDate = format.Date / export default Date
+  for (const match of content.matchAll(/^\s*export\s+(?:const|let|var)\s+([\w$]+)\s*(?::[^=\n]+)?=\s*([\w$]+(?:\.[\w$]+)?)\s*;?\s*$/gm)) {
+    aliases.exports.push([match[1]!, match[2]!])
+  }
+  for (const match of content.matchAll(/^\s*export\s+default\s+(?:(?:async\s+)?function\*?\s+|class\s+)?([\w$]+(?:\.[\w$]+)?)\b/gm)) {
+    aliases.exports.push(['default', match[1]!])
+  }
+
+  return aliases
+}
+
+function readPythonAliases(content: string, filePath: string, context: ResolveContext): FileAliases {
+  const aliases: FileAliases = { imports: [], exports: [], directExports: [], starExports: [], qualifiers: [] }
+
+  const stem = basename(filePath, '.py')
+  const moduleName = stem === '__init__' ? basename(dirname(filePath)) : stem
+  const dotted = relative(context.project.config.directory, stem === '__init__' ? dirname(filePath) : join(dirname(filePath), stem))
+  aliases.qualifiers.push(moduleName)
+  if (dotted && !dotted.startsWith('..')) aliases.qualifiers.push(dotted.split(sep).join('.'))
+
+  // from m import a, b as c - names imported into a module are importable from it too. Only
+  // packages and renames are taken for re-exports; any module imports plenty of plain names.
+  for (const match of content.matchAll(/^from\s+([\w.]+)\s+import\s+(\([^)]*\)|[^\n]+)/gm)) {
+    const module = resolvePythonModule(match[1]!, filePath, context)
+    for (const entry of match[2]!.replace(/[()]/g, '').replace(/#.*$/gm, '').split(',')) {
+      const [imported, local = imported] = entry.trim().split(/\s+as\s+/)
+      if (imported === '*' && module) aliases.starExports.push(module)
+      if (!imported || imported === '*') continue
+
+      // `from pkg import module` binds a submodule
+      const submodule = resolvePythonModule(`${match[1]!.endsWith('.') ? match[1] : `${match[1]}.`}${imported}`, filePath, context)
+      if (submodule) {
+        aliases.imports.push({ local: local!, module: submodule })
+      }
+      else if (module) {
+        aliases.imports.push({ local: local!, module, imported })
+        if (stem === '__init__' || local !== imported) aliases.exports.push([local!, local!])
+      }
+    }
+  }
+
+  // import a.b as c
+  for (const match of content.matchAll(/^import\s+([\w.]+(?:\s+as\s+\w+)?(?:\s*,\s*[\w.]+(?:\s+as\s+\w+)?)*)\s*$/gm)) {
+    for (const entry of match[1]!.split(',')) {
+      const [specifier, local] = entry.trim().split(/\s+as\s+/)
+      const module = resolvePythonModule(specifier!, filePath, context)
+      if (module) aliases.imports.push({ local: local ?? specifier!, module })
+    }
+  }
+
+  // FormatDate = format.Date at module level
+  for (const match of content.matchAll(/^([A-Za-z_]\w*)\s*=\s*([A-Za-z_][\w.]*)\s*$/gm)) {
+    aliases.exports.push([match[1]!, match[2]!])
+  }
+
+  return aliases
+}
+
+function readGoAliases(content: string, context: ResolveContext): FileAliases {
+  const aliases: FileAliases = { imports: [], exports: [], directExports: [], starExports: [], qualifiers: [] }
+
+  const packageName = content.match(/^package\s+(\w+)/m)?.[1]
+  if (packageName) aliases.qualifiers.push(packageName)
+
+  // import "fmt" / import ( f "fmt"; "net/http" )
+  for (const match of content.matchAll(/^import\s+(?:\(([^)]*)\)|((?:[\w.]+\s+)?"[^"]+"))/gm)) {
+    for (const spec of (match[1] ?? match[2]!).matchAll(/(?:([\w.]+)\s+)?"([^"]+)"/g)) {
+      const resolution = resolveGoImport(spec[2]!, context.goModules)
+      if (!resolution || spec[1] === '_' || spec[1] === '.') continue
+
+      const segments = spec[2]!.split('/')
+      const name = /^v\d+$/.test(segments[segments.length - 1]!) ? segments[segments.length - 2]! : segments[segments.length - 1]!
+      aliases.imports.push({ local: spec[1] ?? name.replace(/^go-|[.-]go$/g, '').replace(/[.-]/g, ''), module: resolution.directory })
+    }
+  }
+
+  // var FormatDate = format.Date, also inside var ( ... ) blocks, and type Formatter = format.Formatter
+  let inBlock = false
+  for (const line of content.split('\n')) {
+    if (/^(?:var|const|type)\s*\($/.test(line.trim()) && !/^\s/.test(line)) {
+      inBlock = true
+      continue
+    }
+    if (inBlock && line.startsWith(')')) {
+      inBlock = false
+      continue
+    }
+
+    const declaration = inBlock
+      ? line.match(/^\s+([A-Z]\w*)(?:\s+[\w.*[\]]+)?\s*=\s*(\w+\.[A-Z]\w*|[A-Z]\w*)\s*$/)
+      : line.match(/^(?:var|const|type)\s+([A-Z]\w*)(?:\s+[\w.*[\]]+)?\s*=\s*(\w+\.[A-Z]\w*|[A-Z]\w*)\s*$/)
+    if (declaration) aliases.exports.push([declaration[1]!, declaration[2]!])
+  }
+
+  return aliases
+}
+
+function resolveExpression(expression: string, imports: ImportBinding[], module: string): SymbolRef | undefined {
+  const dot = expression.lastIndexOf('.')
+  if (dot > 0) {
+    const qualifier = expression.substring(0, dot)
+    const binding = imports.find(candidate => candidate.imported === undefined && candidate.local === qualifier)
+    return binding ? { module: binding.module, name: expression.substring(dot + 1) } : undefined
+  }
+
+  const binding = imports.find(candidate => candidate.imported !== undefined && candidate.local === expression)
+  return binding ? { module: binding.module, name: binding.imported! } : { module, name: expression }
+}
+
+function resolveScriptModule(specifier: string, fromFile: string, context: ResolveContext, kind: ImportKind): string | undefined {
+  // Bare specifiers are packages outside the indexed sources unless a path alias maps them or
+  // they name a package of the project, whose entry point depends on the kind of import
+  const bases = specifier.startsWith('.')
+    ? [resolve(dirname(fromFile), specifier)]
+    : [
+        ...expandPathAlias(specifier, fromFile, context.project.pathAliases ?? []),
+        ...resolvePackageSpecifier(specifier, fromFile, context.project.packages ?? [], kind),
+      ]
+
+  for (const base of bases) {
+    // TypeScript sources import the compiled `.js` names of each other
+    const stem = base.replace(/\.(?:[cm]?js|jsx)$/, '')
+    const candidates = [
+      base,
+      ...SCRIPT_EXTENSIONS.map(extension => stem + extension),
+      ...SCRIPT_EXTENSIONS.map(extension => join(base, `index${extension}`)),
+    ]
+    const found = candidates.find(candidate => context.files.has(candidate))
+    if (found) return found
+  }
+  return undefined
+}
+
+function resolvePythonModule(specifier: string, fromFile: string, context: ResolveContext): string | undefined {
+  const dots = specifier.match(/^\.*/)![0].length
+  const segments = specifier.substring(dots).split('.').filter(Boolean)
+  const candidates = (base: string) => [`${base}.py`, join(base, '__init__.py')]
+
+  if (dots > 0) {
+    let directory = dirname(fromFile)
+    for (let level = 1; level < dots; level++) directory = dirname(directory)
+    return candidates(join(directory, ...segments)).find(candidate => context.files.has(candidate))
+  }
+
+  // Absolute imports are relative to a source root; any file whose path ends with the module path matches
+  if (segments.length === 0) return undefined
+  const suffixes = candidates(join(...segments)).map(candidate => `${sep}${candidate}`)
+  return context.pythonFiles.find(file => suffixes.some(suffix => file.endsWith(suffix)))
+}
+
+/**
+ * Reads `a, b as c, type d` into [name, alias] pairs
+ */
+function readNamedList(list: string | undefined): [string, string][] {
+  if (!list) return []
+  return list.split(',')
+    .map(entry => entry.trim().replace(/^type\s+/, '').split(/\s+as\s+/))
+    .filter(parts => /^[\w$]+$/.test(parts[0] ?? ''))
+    .map(([name, alias]) => [name!, alias?.trim() || name!])
+}
+
+function uniqueRefs(refs: SymbolRef[]): SymbolRef[] {
+  const seen = new Set<string>()
+  return refs.filter((ref) => {
+    const key = refKey(ref)
+    if (seen.has(key)) return false
+    seen.add(key)
+    return true
+  })
+}
+
+function refKey(ref: SymbolRef): string {
+  return `${ref.module}\0${ref.name}`
+}
+
+function detectWorkspaceModules(directory: string): GoModule[] {
+  const modules = detectGoModules(directory)
+  return [...modules, ...detectGoReplaces(modules)]
+}
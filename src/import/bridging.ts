@@ -0,0 +1,224 @@
+/**
+ * Objective-C and Swift bridging - follows bridging headers and generated `-Swift.h` imports
+ * so a symbol is found under the name it has on the other side of a mixed iOS codebase:
+ * `loadData(url:)` in Swift for `loadDataWithURL:` in Objective-C, and back
+ */
+
+import { basename, dirname, join, resolve } from 'path'
+import { readFileSync, readdirSync } from 'fs'
+import { getAllNodes } from '../project/manager.js'
+import { isFile } from '../utils/helpers.js'
+import type { Project, TreeNode } from '../types/core.js'
+
+interface BridgeContext {
+  files: Map<string, string> // Path to content
+  swiftVisible: Set<string> // Headers reachable from a bridging header
+  swiftImporters: string[] // Objective-C files importing a generated `-Swift.h` header
+  swiftFiles: string[]
+}
+
+const OBJC_EXTENSIONS = ['.h', '.m', '.mm']
+const BRIDGING_HEADER = /-Bridging-Header\.h$/
+const BRIDGING_SETTING = /SWIFT_OBJC_BRIDGING_HEADER\s*=\s*"?([^";\n]+)"?\s*;/g
+const HEADER_IMPORT = /^[ \t]*#\s*(?:import|include)\s*[<"]([^>"]+)[>"]/gm
+const GENERATED_HEADER = /^[ \t]*#\s*import\s*[<"](?:[^>"]*\/)?[\w-]+-Swift\.h[>"]/m
+// Attributes exposing a Swift declaration to the Objective-C runtime
+const OBJC_ATTRIBUTES = /^@(?:objc|IBAction|IBOutlet|IBInspectable|NSManaged|GKInspectable)\b/
+
+const contextCache = new WeakMap<Project, { generation: number, context: BridgeContext }>()
+
+/**
+ * The bridged expressions, per file, by which the symbols an identifier names are used on
+ * the other side of the Objective-C/Swift boundary. Empty for projects without a bridging
+ * header or generated Swift header import.
+ */
+export function findBridgedExpressions(project: Project, identifier: string): Map<string, string[]> {
+  const definitions = getAllNodes(project).filter(node => node.name === identifier && node.type !== 'file')
+  return bridgedExpressions(project, definitions, identifier)
+}
+
+/**
+ * Like findBridgedExpressions, for one definition rather than every symbol of a name
+ */
+export function findBridgedExpressionsOf(project: Project, definition: TreeNode): Map<string, string[]> {
+  return bridgedExpressions(project, [definition], definition.name ?? '')
+}
+
+/**
+ * Bridging headers of a project: files named `<Target>-Bridging-Header.h`, and those the
+ * `SWIFT_OBJC_BRIDGING_HEADER` build setting of an Xcode project names
+ */
+export function findBridgingHeaders(directory: string, files: string[]): string[] {
+  const headers = new Set(files.filter(file => BRIDGING_HEADER.test(file)))
+
+  for (const projectFile of findXcodeProjects(directory)) {
+    let content: string
+    try {
+      content = readFileSync(projectFile, 'utf-8')
+    }
+    catch {
+      continue
+    }
+    // Paths are relative to the directory holding the .xcodeproj bundle
+    const root = dirname(dirname(projectFile))
+    for (const match of content.matchAll(BRIDGING_SETTING)) {
+      const path = resolve(root, match[1]!.trim().replace(/^\$\((?:SRCROOT|PROJECT_DIR)\)\/?/, ''))
+      if (files.includes(path) || isFile(path)) headers.add(path)
+    }
+  }
+
+  return [...headers]
+}
+
+/**
+ * Every header a set of headers imports, directly or through other headers, themselves
+ * included. Imports resolve relative to the importing header first, then by file name
+ * anywhere in the project, as header search paths usually allow.
+ */
+export function resolveHeaderClosure(headers: string[], files: Map<string, string>): Set<string> {
+  const byName = new Map<string, string[]>()
+  for (const file of files.keys()) {
+    byName.set(basename(file), [...(byName.get(basename(file)) ?? []), file])
+  }
+
+  const visible = new Set<string>()
+  const pending = [...headers]
+  while (pending.length > 0) {
+    const header = pending.pop()!
+    if (visible.has(header)) continue
+    visible.add(header)
+
+    for (const match of (files.get(header) ?? '').matchAll(HEADER_IMPORT)) {
+      const relativePath = resolve(dirname(header), match[1]!)
+      const found = files.has(relativePath) ? [relativePath] : byName.get(basename(match[1]!)) ?? []
+      pending.push(...found.filter(file => !visible.has(file)))
+    }
+  }
+  return visible
+}
+
+/**
+ * The name Swift imports an Objective-C declaration under: the `NS_SWIFT_NAME` base name,
+ * or the first selector piece without its `With...` preposition. Initializers become
+ * `init(...)` calls Swift code spells as the type name, so they have none.
+ */
+export function swiftNameOf(definition: TreeNode): string | undefined {
+  const name = definition.name ?? ''
+  const signature = definition.symbol?.signature ?? ''
+  const swiftName = signature.match(/\bNS_SWIFT_NAME\s*\(\s*([\w.]+)/)?.[1]
+  if (swiftName) return swiftName.split('.').pop()
+  if (definition.type !== 'method') return name
+  if (/^init(?:[A-Z]|$)/.test(name)) return undefined
+  // Only a selector taking arguments moves its preposition into the first label
+  return signature.includes(':') ? name.replace(/(?<=[a-z0-9])With[A-Z]\w*$/, '') : name
+}
+
+/**
+ * The name Objective-C sees a Swift declaration under: an explicit `@objc(name)`, otherwise
+ * the function name joined with its first argument label (`loadWithURL` for
+ * `load(URL:)`, `load` for `load(_:)`) and `initWithName` for `init(name:)`
+ */
+export function objcNameOf(definition: TreeNode): string | undefined {
+  const name = definition.name ?? ''
+  const explicit = definition.symbol?.annotations?.map(annotation => annotation.match(/^@objc\(\s*([\w:]+)\s*\)/)?.[1]).find(Boolean)
+  if (explicit) return explicit.split(':')[0]
+  if (definition.type !== 'method' && definition.type !== 'function') return name
+
+  const parameters = (definition.symbol?.signature ?? '').match(/\(([^)]*)/)?.[1] ?? ''
+  const label = parameters.split(',')[0]!.trim().match(/^(\w+)(?:\s+\w+)?\s*:/)?.[1]
+  if (name === 'init') return label && label !== '_' ? `initWith${capitalize(label)}` : 'init'
+  return label && label !== '_' ? `${name}With${capitalize(label)}` : name
+}
+
+function bridgedExpressions(project: Project, definitions: TreeNode[], identifier: string): Map<string, string[]> {
+  const expressions = new Map<string, string[]>()
+  const add = (files: Iterable<string>, expression: string | undefined) => {
+    if (!expression || expression === identifier) return
+    for (const file of files) {
+      const list = expressions.get(file) ?? []
+      if (!list.includes(expression)) list.push(expression)
+      expressions.set(file, list)
+    }
+  }
+
+  const objcDefinitions = definitions.filter(definition => definition.id.startsWith('objc-'))
+  const swiftDefinitions = definitions.filter(definition => definition.id.startsWith('swift-') && isExposedToObjC(project, definition))
+  if (objcDefinitions.length === 0 && swiftDefinitions.length === 0) return expressions
+
+  const context = getBridgeContext(project)
+  for (const definition of objcDefinitions) {
+    // An implementation file is visible through the header declaring its interface
+    const header = definition.path.replace(/\.mm?$/, '.h')
+    if (context.swiftVisible.has(definition.path) || context.swiftVisible.has(header)) {
+      add(context.swiftFiles, swiftNameOf(definition))
+    }
+  }
+  for (const definition of swiftDefinitions) {
+    add(context.swiftImporters, objcNameOf(definition))
+  }
+
+  return expressions
+}
+
+/**
+ * Whether a Swift declaration is visible to Objective-C: marked `@objc` (or an attribute
+ * implying it), or a member of an `@objcMembers` class
+ */
+function isExposedToObjC(project: Project, definition: TreeNode): boolean {
+  if (definition.symbol?.visibility === 'private') return false
+  if (definition.symbol?.annotations?.some(annotation => OBJC_ATTRIBUTES.test(annotation))) return true
+
+  const container = definition.symbol?.container?.split('.').pop()
+  if (!container) return false
+  return getAllNodes(project).some(node => node.path === definition.path && node.type === 'class' && node.name === container
+    && node.symbol?.annotations?.some(annotation => /^@objcMembers\b/.test(annotation)))
+}
+
+function getBridgeContext(project: Project): BridgeContext {
+  const cached = contextCache.get(project)
+  if (cached && cached.generation === (project.generation ?? 0)) return cached.context
+
+  const files = new Map<string, string>()
+  for (const node of getAllNodes(project)) {
+    if (node.type === 'file' && (OBJC_EXTENSIONS.some(extension => node.path.endsWith(extension)) || node.path.endsWith('.swift'))) {
+      files.set(node.path, node.content ?? '')
+    }
+  }
+
+  const paths = [...files.keys()]
+  const headers = findBridgingHeaders(project.config.directory, paths)
+  const context: BridgeContext = {
+    files,
+    swiftVisible: resolveHeaderClosure(headers, files),
+    swiftImporters: paths.filter(path => !path.endsWith('.swift') && GENERATED_HEADER.test(files.get(path)!)),
+    swiftFiles: paths.filter(path => path.endsWith('.swift')),
+  }
+  contextCache.set(project, { generation: project.generation ?? 0, context })
+  return context
+}
+
+/**
+ * The `project.pbxproj` files of Xcode projects at the root or one directory down
+ */
+function findXcodeProjects(directory: string): string[] {
+  const projects: string[] = []
+  const search = (dir: string, depth: number) => {
+    let entries: string[]
+    try {
+      entries = readdirSync(dir)
+    }
+    catch {
+      return
+    }
+    for (const entry of entries) {
+      if (entry.endsWith('.xcodeproj') && isFile(join(dir, entry, 'project.pbxproj'))) projects.push(join(dir, entry, 'project.pbxproj'))
+      else if (depth > 0 && !entry.startsWith('.') && entry !== 'node_modules' && entry !== 'Pods') search(join(dir, entry), depth - 1)
+    }
+  }
+  search(directory, 1)
+  return projects
+}
+
+function capitalize(text: string): string {
+  return text.charAt(0).toUpperCase() + text.slice(1)
+}
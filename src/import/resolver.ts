@@ -3,8 +3,10 @@
  */
 
 import { resolve, dirname, join } from 'path'
-import { isFile } from '../utils/helpers.js'
-import type { ImportContext, ResolutionResult } from '../types/core.js'
+import { isFile, isDirectory } from '../utils/helpers.js'
+import { resolveGoImport } from '../project/go-workspace.js'
+import { expandPathAlias } from '../project/path-aliases.js'
+import type { ImportContext, ResolutionResult, GoModule, PathAlias } from '../types/core.js'
 
 /**
  * Resolves an import path using multiple resolution strategies
@@ -17,7 +19,9 @@ export function resolveImport(
   return (
     tryRelativeResolution(importPath, currentFile)
     || tryAliasResolution(importPath, context.aliases)
+    || tryPathAliasResolution(importPath, currentFile, context.pathAliases)
     || tryFrameworkResolution(importPath, context.framework)
+    || tryGoWorkspaceResolution(importPath, context.goModules)
     || tryAbsoluteResolution(importPath, context.basePath)
     || { resolved: null, isExternal: true }
   )
@@ -68,6 +72,26 @@ export function tryAliasResolution(importPath: string, aliases?: Record<string,
   return null
 }
 
+/**
+ * Attempts to resolve imports through tsconfig `paths`, jest `moduleNameMapper` or bundler
+ * aliases read from the project's config files
+ */
+export function tryPathAliasResolution(importPath: string, currentFile: string, pathAliases?: PathAlias[]): ResolutionResult | null {
+  if (!pathAliases || importPath.startsWith('.')) return null
+
+  const extensions = ['', '.js', '.ts', '.jsx', '.tsx', '/index.js', '/index.ts']
+  for (const base of expandPathAlias(importPath, currentFile, pathAliases)) {
+    for (const ext of extensions) {
+      const candidate = base + ext
+      if (isFile(candidate)) {
+        return { resolved: candidate, isExternal: false }
+      }
+    }
+  }
+
+  return null
+}
+
 /**
  * Attempts to resolve imports using framework-specific resolution rules
  */
@@ -105,6 +129,20 @@ export function tryAbsoluteResolution(importPath: string, basePath?: string): Re
   return null
 }
 
+/**
+ * Attempts to resolve Go imports to a package directory in another workspace module
+ */
+export function tryGoWorkspaceResolution(importPath: string, goModules?: GoModule[]): ResolutionResult | null {
+  if (!goModules || goModules.length === 0) return null
+
+  const resolution = resolveGoImport(importPath, goModules)
+  if (resolution && isDirectory(resolution.directory)) {
+    return { resolved: resolution.directory, isExternal: false }
+  }
+
+  return null
+}
+
 /**
  * Resolves Next.js specific import paths
  */
@@ -0,0 +1,90 @@
+/**
+ * Library API - the indexer, search and analysis for programs that embed them instead of
+ * running the CLI or the MCP server. What this module exports is the package's stable surface;
+ * the modules behind it may change between minor versions.
+ */
+
+import { resolve } from 'path'
+import { analyzeProject } from './analysis/index.js'
+import { findUsage, searchCode } from './core/search.js'
+import {
+  createProject,
+  getAllNodes,
+  getProjectDiagnostics,
+  getProjectStats,
+  parseProject,
+  updateProject,
+  watchProject,
+} from './project/manager.js'
+import { isFile } from './utils/helpers.js'
+import type { AnalysisOptions, AnalysisResult } from './types/analysis.js'
+import type { FileChange, FindUsageResult, IndexDiagnostic, Project, ProjectConfig, SearchOptions, SearchResult } from './types/core.js'
+
+export type { AnalysisOptions, AnalysisResult, Finding, FindingsDiff } from './types/analysis.js'
+export type {
+  FileChange,
+  FindUsageResult,
+  IndexDiagnostic,
+  Project,
+  ProjectConfig,
+  SearchOptions,
+  SearchResult,
+  TreeNode,
+} from './types/core.js'
+export type { LogLevel } from './utils/logger.js'
+export { initializeLogger } from './utils/logger.js'
+export { diffFindings, formatAnalysisReport } from './analysis/index.js'
+export { TreeSitterError } from './utils/errors.js'
+
+export type FindUsageOptions = NonNullable<Parameters<typeof findUsage>[2]>
+
+export interface IndexStats {
+  totalFiles: number
+  totalNodes: number
+  languages: string[] // File extensions seen
+  frameworks: string[]
+}
+
+export interface Index {
+  readonly project: Project
+  search(query: string, options?: SearchOptions): SearchResult[]
+  findUsage(identifier: string, options?: FindUsageOptions): FindUsageResult[]
+  analyze(options?: AnalysisOptions): Promise<AnalysisResult>
+  update(changes: Array<FileChange | string>): Promise<void> // Bare paths count as modified, or deleted once gone
+  watch(onUpdate?: (changes: FileChange[]) => void): () => void // Returns the function stopping the watcher
+  stats(): IndexStats
+  diagnostics(): IndexDiagnostic[]
+}
+
+/**
+ * Indexes a directory and returns the index. The project is parsed once up front; keep it
+ * current with `update` for changes the host knows about, or with `watch`.
+ */
+export async function openIndex(config: ProjectConfig | string): Promise<Index> {
+  const projectConfig = typeof config === 'string' ? { directory: config } : config
+  const project = createProject({ ...projectConfig, directory: resolve(projectConfig.directory) })
+  await parseProject(project)
+
+  return {
+    project,
+    search: (query, options) => searchCode(query, getAllNodes(project), options),
+    findUsage: (identifier, options) => findUsage(identifier, getAllNodes(project), options),
+    analyze: (options = { includeQuality: true }) => analyzeProject(project, options),
+    update: changes => updateProject(project, changes.map(change => toFileChange(project, change))),
+    watch: onUpdate => watchProject(project, onUpdate),
+    stats: () => {
+      const { totalFiles, totalNodes, languages, frameworks } = getProjectStats(project)
+      return { totalFiles, totalNodes, languages, frameworks }
+    },
+    diagnostics: () => getProjectDiagnostics(project),
+  }
+}
+
+/**
+ * A change for a path, relative to the project directory unless absolute
+ */
+function toFileChange(project: Project, change: FileChange | string): FileChange {
+  if (typeof change !== 'string') return change
+  const path = resolve(project.config.directory, change)
+  return { type: isFile(path) ? 'modified' : 'deleted', path, timestamp: Date.now() }
+}
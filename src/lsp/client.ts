@@ -0,0 +1,338 @@
+/**
+ * Language server client - asks an installed language server (gopls, tsserver through
+ * typescript-language-server, pyright) for definitions and types where the index can only
+ * match names. Servers are started on first use, one per project and server, and kept running.
+ */
+
+import { spawn, type ChildProcess } from 'child_process'
+import { readFileSync } from 'fs'
+import { basename, delimiter, extname, isAbsolute, join } from 'path'
+import { fileURLToPath, pathToFileURL } from 'url'
+import { readMessages, writeMessage, type LSPMessage } from './protocol.js'
+import { isFile } from '../utils/helpers.js'
+import { getLogger } from '../utils/logger.js'
+import type { LanguageServerSettings } from '../project/settings.js'
+import type { JsonObject, JsonValue } from '../types/core.js'
+
+export type ServerStatus = 'resolved' | 'unavailable' | 'disabled' | 'failed'
+
+export interface ServerAnswer<T> {
+  server?: string // Language server that was asked
+  status: ServerStatus
+  result?: T // Set when resolved
+  error?: string // Why a server that was asked did not answer
+}
+
+export interface SourceLocation {
+  path: string
+  line: number // 1-based
+  column: number // 1-based
+  endLine: number
+  endColumn: number
+}
+
+export interface LanguageServerOptions extends LanguageServerSettings {
+  commands?: Record<string, string[]> // Command line per server from the server's own options, e.g. { "gopls": ["/opt/go/bin/gopls", "serve"] }
+}
+
+interface ServerSpec {
+  id: string
+  extensions: readonly string[]
+  command: readonly string[]
+  languageId: (extension: string) => string
+}
+
+interface RunningServer {
+  id: string
+  child: ChildProcess
+  nextId: number
+  pending: Map<number, { resolve: (result: JsonValue) => void, reject: (error: Error) => void }>
+  documents: Map<string, { version: number, text: string }> // Synced documents by URI
+  exited?: string
+}
+
+const TS_LANGUAGE_IDS: Record<string, string> = {
+  '.ts': 'typescript',
+  '.mts': 'typescript',
+  '.cts': 'typescript',
+  '.tsx': 'typescriptreact',
+  '.jsx': 'javascriptreact',
+}
+
+const LANGUAGE_SERVERS: ServerSpec[] = [
+  {
+    id: 'gopls',
+    extensions: ['.go'],
+    command: ['gopls'],
+    languageId: () => 'go',
+  },
+  {
+    id: 'tsserver',
+    extensions: ['.ts', '.tsx', '.mts', '.cts', '.js', '.jsx', '.mjs', '.cjs'],
+    command: ['typescript-language-server', '--stdio'],
+    languageId: extension => TS_LANGUAGE_IDS[extension] ?? 'javascript',
+  },
+  {
+    id: 'pyright',
+    extensions: ['.py', '.pyi'],
+    command: ['pyright-langserver', '--stdio'],
+    languageId: () => 'python',
+  },
+]
+
+const DEFAULT_TIMEOUT_MS = 10000
+// Servers load the whole workspace before they answer the first request
+const STARTUP_TIMEOUT_MS = 60000
+
+const servers = new Map<string, Promise<RunningServer>>()
+// Started servers, kept apart from their promises so they can be stopped synchronously on exit
+const running = new Set<RunningServer>()
+let stopOnExit = false
+
+/**
+ * Where the language server for `filePath` says the symbol at a 1-based position is declared
+ */
+export async function definitionsAt(directory: string, filePath: string, line: number, column: number, settings: LanguageServerOptions = {}): Promise<ServerAnswer<SourceLocation[]>> {
+  return askAt(directory, filePath, line, column, settings, 'textDocument/definition', result => toLocations(result))
+}
+
+/**
+ * Where the language server says the type of the symbol at a 1-based position is declared
+ */
+export async function typeDefinitionsAt(directory: string, filePath: string, line: number, column: number, settings: LanguageServerOptions = {}): Promise<ServerAnswer<SourceLocation[]>> {
+  return askAt(directory, filePath, line, column, settings, 'textDocument/typeDefinition', result => toLocations(result))
+}
+
+/**
+ * The language server's hover text for the symbol at a 1-based position: its type or signature,
+ * usually followed by its documentation
+ */
+export async function hoverAt(directory: string, filePath: string, line: number, column: number, settings: LanguageServerOptions = {}): Promise<ServerAnswer<string>> {
+  return askAt(directory, filePath, line, column, settings, 'textDocument/hover', result => hoverText(result))
+}
+
+/**
+ * Stops every language server that was started
+ */
+export function stopLanguageServers(): void {
+  for (const server of running) stopServer(server)
+  running.clear()
+  servers.clear()
+}
+
+async function askAt<T>(
+  directory: string,
+  filePath: string,
+  line: number,
+  column: number,
+  settings: LanguageServerOptions,
+  method: string,
+  convert: (result: JsonValue) => T,
+): Promise<ServerAnswer<T>> {
+  const extension = extname(filePath).toLowerCase()
+  const spec = LANGUAGE_SERVERS.find(candidate => candidate.extensions.includes(extension))
+  if (!spec) return { status: 'unavailable' }
+  if (settings.enabled === false) return { server: spec.id, status: 'disabled' }
+
+  const command = settings.commands?.[spec.id] ?? spec.command
+  const executable = findExecutable(command[0]!)
+  if (!executable) return { server: spec.id, status: 'unavailable' }
+
+  try {
+    const server = await getServer(spec, [executable, ...command.slice(1)], directory)
+    const uri = pathToFileURL(filePath).href
+    syncDocument(server, uri, spec.languageId(extension), readFileSync(filePath, 'utf-8'))
+    const result = await request(server, method, {
+      textDocument: { uri },
+      position: { line: line - 1, character: Math.max(0, column - 1) },
+    }, settings.timeoutMs ?? DEFAULT_TIMEOUT_MS)
+    return { server: spec.id, status: 'resolved', result: convert(result) }
+  }
+  catch (error) {
+    getLogger().debug(`${spec.id} failed on ${method}:`, error)
+    return { server: spec.id, status: 'failed', error: error instanceof Error ? error.message : String(error) }
+  }
+}
+
+function getServer(spec: ServerSpec, command: string[], directory: string): Promise<RunningServer> {
+  const key = `${spec.id}\0${directory}`
+  let server = servers.get(key)
+  if (!server) {
+    server = startServer(spec, command, directory)
+    servers.set(key, server)
+    // A server that failed to start is tried again on the next request
+    server.catch(() => servers.delete(key))
+  }
+  return server.then((running) => {
+    if (running.exited) {
+      servers.delete(key)
+      throw new Error(`${spec.id} exited: ${running.exited}`)
+    }
+    return running
+  })
+}
+
+async function startServer(spec: ServerSpec, command: string[], directory: string): Promise<RunningServer> {
+  if (!stopOnExit) {
+    stopOnExit = true
+    process.once('exit', stopLanguageServers)
+  }
+
+  const child = spawn(command[0]!, command.slice(1), { cwd: directory, stdio: ['pipe', 'pipe', 'ignore'] })
+  const server: RunningServer = { id: spec.id, child, nextId: 1, pending: new Map(), documents: new Map() }
+  running.add(server)
+
+  const fail = (reason: string) => {
+    server.exited = reason
+    running.delete(server)
+    for (const { reject } of server.pending.values()) reject(new Error(`${spec.id} exited: ${reason}`))
+    server.pending.clear()
+  }
+  child.on('error', error => fail(error.message))
+  child.on('exit', code => fail(`exit code ${code}`))
+  child.stdin!.on('error', () => undefined)
+
+  readMessages(child.stdout!, (message) => {
+    if (message) handleServerMessage(server, message)
+  })
+
+  const rootUri = pathToFileURL(directory).href
+  try {
+    await request(server, 'initialize', {
+      processId: process.pid,
+      rootUri,
+      workspaceFolders: [{ uri: rootUri, name: basename(directory) }],
+      capabilities: {
+        textDocument: {
+          synchronization: { dynamicRegistration: false },
+          definition: { linkSupport: true },
+        typeDefinition: { linkSupport: true },
+          hover: { contentFormat: ['markdown', 'plaintext'] },
+        },
+        workspace: { configuration: true, workspaceFolders: true },
+      },
+    }, STARTUP_TIMEOUT_MS)
+  }
+  catch (error) {
+    stopServer(server)
+    throw error
+  }
+  notify(server, 'initialized', {})
+  return server
+}
+
+function stopServer(server: RunningServer): void {
+  if (server.exited) return
+  notify(server, 'exit', {})
+  server.child.kill()
+}
+
+/**
+ * Settles the request a response answers, and answers the server's own requests with defaults
+ */
+function handleServerMessage(server: RunningServer, message: LSPMessage): void {
+  if (message.method === undefined) {
+    const pending = server.pending.get(Number(message.id))
+    if (!pending) return
+    server.pending.delete(Number(message.id))
+    if (message.error) pending.reject(new Error(message.error.message))
+    else pending.resolve(message.result ?? null)
+    return
+  }
+
+  if (message.id === undefined || message.id === null) return
+  // workspace/configuration asks for one setting per item; defaults are used for all
+  const items = (message.params as JsonObject | undefined)?.items
+  const result = message.method === 'workspace/configuration' && Array.isArray(items) ? items.map(() => null) : null
+  writeMessage(server.child.stdin!, { jsonrpc: '2.0', id: message.id, result })
+}
+
+function request(server: RunningServer, method: string, params: JsonObject, timeoutMs: number): Promise<JsonValue> {
+  if (server.exited) return Promise.reject(new Error(`${server.id} exited: ${server.exited}`))
+
+  const id = server.nextId++
+  return new Promise((resolve, reject) => {
+    const timer = setTimeout(() => {
+      server.pending.delete(id)
+      reject(new Error(`${server.id} did not answer ${method} within ${timeoutMs}ms`))
+    }, timeoutMs)
+    server.pending.set(id, {
+      resolve: (result) => {
+        clearTimeout(timer)
+        resolve(result)
+      },
+      reject: (error) => {
+        clearTimeout(timer)
+        reject(error)
+      },
+    })
+    writeMessage(server.child.stdin!, { jsonrpc: '2.0', id, method, params })
+  })
+}
+
+function notify(server: RunningServer, method: string, params: JsonObject): void {
+  writeMessage(server.child.stdin!, { jsonrpc: '2.0', method, params })
+}
+
+/**
+ * Opens the document, or sends its new text if it changed since it was last synced
+ */
+function syncDocument(server: RunningServer, uri: string, languageId: string, text: string): void {
+  const synced = server.documents.get(uri)
+  if (!synced) {
+    server.documents.set(uri, { version: 1, text })
+    notify(server, 'textDocument/didOpen', { textDocument: { uri, languageId, version: 1, text } })
+  }
+  else if (synced.text !== text) {
+    const version = synced.version + 1
+    server.documents.set(uri, { version, text })
+    notify(server, 'textDocument/didChange', { textDocument: { uri, version }, contentChanges: [{ text }] })
+  }
+}
+
+/**
+ * The command itself when it is a path, else its first match on the PATH. The project's own
+ * node_modules/.bin is not searched: it holds whatever the checkout commits.
+ */
+function findExecutable(command: string): string | undefined {
+  if (isAbsolute(command) || command.includes('/')) return isFile(command) ? command : undefined
+
+  const dirs = (process.env.PATH ?? '').split(delimiter).filter(Boolean)
+  const names = process.platform === 'win32' ? [`${command}.cmd`, `${command}.exe`, command] : [command]
+  return dirs.flatMap(dir => names.map(name => join(dir, name))).find(isFile)
+}
+
+/**
+ * Locations from a definition result, which is a Location, a list of them or a list of LocationLinks
+ */
+function toLocations(result: JsonValue): SourceLocation[] {
+  const entries = Array.isArray(result) ? result : result ? [result] : []
+  return entries.flatMap((entry) => {
+    const location = entry as JsonObject
+    const uri = location.targetUri ?? location.uri
+    const range = (location.targetSelectionRange ?? location.range) as JsonObject | undefined
+    if (typeof uri !== 'string' || !uri.startsWith('file:') || !range) return []
+    const start = range.start as JsonObject
+    const end = range.end as JsonObject
+    return [{
+      path: fileURLToPath(uri),
+      line: Number(start.line) + 1,
+      column: Number(start.character) + 1,
+      endLine: Number(end.line) + 1,
+      endColumn: Number(end.character) + 1,
+    }]
+  })
+}
+
+/**
+ * The text of a hover result, whose contents are a string, a MarkupContent, a MarkedString
+ * or a list of them
+ */
+function hoverText(result: JsonValue): string {
+  const contents = (result as JsonObject | null)?.contents
+  const parts = Array.isArray(contents) ? contents : contents ? [contents] : []
+  return parts
+    .map(part => typeof part === 'string' ? part : String((part as JsonObject).value ?? ''))
+    .filter(Boolean)
+    .join('\n\n')
+}
@@ -0,0 +1,57 @@
+/**
+ * LSP wire protocol - JSON-RPC messages framed by a Content-Length header, shared by the
+ * bridge that serves LSP and the client that asks installed language servers
+ */
+
+import type { Readable, Writable } from 'stream'
+import type { JsonObject, JsonValue } from '../types/core.js'
+
+export interface LSPMessage {
+  jsonrpc: '2.0'
+  id?: number | string | null
+  method?: string
+  params?: JsonObject | JsonValue[]
+  result?: JsonValue
+  error?: { code: number, message: string }
+}
+
+export function writeMessage(output: Writable, message: LSPMessage): void {
+  const body = Buffer.from(JSON.stringify(message), 'utf-8')
+  output.write(`Content-Length: ${body.length}\r\n\r\n`)
+  output.write(body)
+}
+
+/**
+ * Calls `onMessage` for every message read from `input`, with undefined for a body that is
+ * not JSON
+ */
+export function readMessages(input: Readable, onMessage: (message: LSPMessage | undefined) => void): void {
+  let buffer = Buffer.alloc(0)
+
+  input.on('data', (chunk: Buffer) => {
+    buffer = Buffer.concat([buffer, chunk])
+    for (;;) {
+      const headerEnd = buffer.indexOf('\r\n\r\n')
+      if (headerEnd < 0) return
+      const length = Number(/Content-Length:\s*(\d+)/i.exec(buffer.subarray(0, headerEnd).toString('ascii'))?.[1])
+      if (!Number.isFinite(length)) {
+        // A header without a length cannot be framed; drop it and resynchronize
+        buffer = buffer.subarray(headerEnd + 4)
+        continue
+      }
+      if (buffer.length < headerEnd + 4 + length) return
+
+      const body = buffer.subarray(headerEnd + 4, headerEnd + 4 + length).toString('utf-8')
+      buffer = buffer.subarray(headerEnd + 4 + length)
+
+      let message: LSPMessage | undefined
+      try {
+        message = JSON.parse(body) as LSPMessage
+      }
+      catch {
+        message = undefined
+      }
+      onMessage(message)
+    }
+  })
+}
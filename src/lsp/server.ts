@@ -0,0 +1,291 @@
+/**
+ * LSP bridge - a minimal language server (definitions, references, document and workspace
+ * symbols) answering from the same project index as the MCP tools, for editors without MCP
+ * support. Speaks JSON-RPC with Content-Length framing over stdio or a TCP port.
+ */
+
+import { createServer, type Server } from 'net'
+import { readFileSync } from 'fs'
+import { resolve } from 'path'
+import { fileURLToPath, pathToFileURL } from 'url'
+import type { Readable, Writable } from 'stream'
+import { readMessages, writeMessage, type LSPMessage } from './protocol.js'
+import { getOrCreateMCPProject } from '../mcp/handlers.js'
+import { findUsage, searchCode } from '../core/search.js'
+import { identifierAt } from '../core/definitions.js'
+import { getAllNodes } from '../project/manager.js'
+import { getLogger, initializeLogger } from '../utils/logger.js'
+import { isFile } from '../utils/helpers.js'
+import type { JsonObject, JsonValue, Project, TreeNode } from '../types/core.js'
+
+export interface LSPServerOptions {
+  port?: number // Listen on this TCP port instead of stdio
+}
+
+export interface LSPSession {
+  root?: string // Workspace directory from initialize
+  documents: Map<string, string> // Text of open documents by URI
+  initialized: boolean
+  shuttingDown: boolean
+}
+
+interface Position {
+  line: number // 0-based
+  character: number
+}
+
+const ERROR_CODES = {
+  INVALID_REQUEST: -32600,
+  METHOD_NOT_FOUND: -32601,
+  INTERNAL_ERROR: -32603,
+  SERVER_NOT_INITIALIZED: -32002,
+} as const
+
+// LSP SymbolKind for each element kind; anything else is reported as a variable
+const SYMBOL_KINDS: Record<string, number> = {
+  module: 2,
+  namespace: 3,
+  package: 4,
+  class: 5,
+  type: 5,
+  method: 6,
+  property: 7,
+  field: 8,
+  constructor: 9,
+  enum: 10,
+  interface: 11,
+  trait: 11,
+  function: 12,
+  macro: 12,
+  variable: 13,
+  constant: 14,
+  variant: 22,
+  struct: 23,
+}
+
+const MAX_WORKSPACE_SYMBOLS = 100
+
+export function createLSPSession(): LSPSession {
+  return { documents: new Map(), initialized: false, shuttingDown: false }
+}
+
+/**
+ * Starts the language server on stdio, or on a TCP port where each connection is its own
+ * session. On stdio only errors are logged, as stdout carries the protocol.
+ */
+export async function startLSPServer(options: LSPServerOptions = {}): Promise<Server | undefined> {
+  if (options.port === undefined) {
+    initializeLogger('error', true)
+    serveLSP(process.stdin, process.stdout, () => process.exit(0))
+    return undefined
+  }
+
+  const server = createServer(socket => serveLSP(socket, socket, () => socket.end()))
+  await new Promise<void>((resolveListen, rejectListen) => {
+    server.once('error', rejectListen)
+    server.listen(options.port, '127.0.0.1', () => resolveListen())
+  })
+  getLogger().info(`LSP server listening on 127.0.0.1:${options.port}`)
+  return server
+}
+
+/**
+ * Reads framed messages from `input` and writes the responses to `output`, calling `onExit`
+ * when the client sends exit
+ */
+export function serveLSP(input: Readable, output: Writable, onExit: () => void): LSPSession {
+  const session = createLSPSession()
+  readMessages(input, (message) => {
+    if (!message) {
+      writeMessage(output, { jsonrpc: '2.0', id: null, error: { code: ERROR_CODES.INVALID_REQUEST, message: 'Invalid JSON' } })
+      return
+    }
+    if (message.method === 'exit') {
+      onExit()
+      return
+    }
+    handleLSPMessage(session, message).then((response) => {
+      if (response) writeMessage(output, response)
+    })
+  })
+  return session
+}
+
+/**
+ * The response to one message; undefined for notifications
+ */
+export async function handleLSPMessage(session: LSPSession, message: LSPMessage): Promise<LSPMessage | undefined> {
+  const { id, method } = message
+  const params = (message.params ?? {}) as JsonObject
+  const isRequest = id !== undefined && id !== null
+  if (!method) return undefined
+
+  try {
+    if (!session.initialized && method !== 'initialize') {
+      if (!isRequest) return undefined
+      return { jsonrpc: '2.0', id, error: { code: ERROR_CODES.SERVER_NOT_INITIALIZED, message: 'Server not initialized' } }
+    }
+
+    const result = await dispatch(session, method, params)
+    if (!isRequest) return undefined
+    if (result === undefined) {
+      return { jsonrpc: '2.0', id, error: { code: ERROR_CODES.METHOD_NOT_FOUND, message: `Unsupported method: ${method}` } }
+    }
+    return { jsonrpc: '2.0', id, result }
+  }
+  catch (error) {
+    getLogger().error(`LSP request failed: ${method}`, error)
+    if (!isRequest) return undefined
+    return { jsonrpc: '2.0', id, error: { code: ERROR_CODES.INTERNAL_ERROR, message: error instanceof Error ? error.message : String(error) } }
+  }
+}
+
+/**
+ * The result of a method; undefined for methods the server does not implement
+ */
+async function dispatch(session: LSPSession, method: string, params: JsonObject): Promise<JsonValue | undefined> {
+  switch (method) {
+    case 'initialize':
+      session.initialized = true
+      session.root = workspaceRoot(params)
+      return {
+        capabilities: {
+          textDocumentSync: 1, // Full text on every change
+          definitionProvider: true,
+          referencesProvider: true,
+          documentSymbolProvider: true,
+          workspaceSymbolProvider: true,
+        },
+        serverInfo: { name: 'tree-sitter-mcp' },
+      }
+
+    case 'initialized':
+      return null
+
+    case 'shutdown':
+      session.shuttingDown = true
+      return null
+
+    case 'textDocument/didOpen': {
+      const document = params.textDocument as JsonObject
+      session.documents.set(String(document.uri), String(document.text ?? ''))
+      return null
+    }
+
+    case 'textDocument/didChange': {
+      const changes = params.contentChanges as JsonObject[]
+      const last = changes[changes.length - 1]
+      if (last) session.documents.set(String((params.textDocument as JsonObject).uri), String(last.text ?? ''))
+      return null
+    }
+
+    case 'textDocument/didClose':
+      session.documents.delete(String((params.textDocument as JsonObject).uri))
+      return null
+
+    case 'textDocument/definition': {
+      const word = wordAt(session, params)
+      if (!word) return []
+      const project = await projectFor(session)
+      return declarations(project)
+        .filter(node => node.name === word)
+        .map(node => location(node.path, node.startLine!, node.startColumn ?? 0, node.endLine ?? node.startLine!, node.endColumn ?? 0))
+    }
+
+    case 'textDocument/references': {
+      const word = wordAt(session, params)
+      if (!word) return []
+      const project = await projectFor(session)
+      const includeDeclaration = (params.context as JsonObject | undefined)?.includeDeclaration !== false
+      const declared = new Set(declarations(project)
+        .filter(node => node.name === word)
+        .map(node => `${node.path}:${node.startLine}`))
+      // Usages are found again in nested declarations; the file's own match has the true column
+      const files = new Map(getAllNodes(project).filter(node => node.type === 'file').map(node => [node.path, node]))
+      return findUsage(word, [...files.values()], { caseSensitive: true })
+        .filter(usage => usage.node.type === 'file')
+        .filter(usage => includeDeclaration || !declared.has(`${usage.node.path}:${usage.startLine}`))
+        .map(usage => location(usage.node.path, usage.startLine, usage.startColumn, usage.endLine, usage.endColumn))
+    }
+
+    case 'textDocument/documentSymbol': {
+      const path = uriToPath(String((params.textDocument as JsonObject).uri))
+      const project = await projectFor(session)
+      return declarations(project)
+        .filter(node => node.path === path)
+        .sort((a, b) => (a.startLine ?? 0) - (b.startLine ?? 0))
+        .map(symbolInformation)
+    }
+
+    case 'workspace/symbol': {
+      const query = typeof params.query === 'string' ? params.query : ''
+      if (!query) return []
+      const project = await projectFor(session)
+      return searchCode(query, declarations(project), { maxResults: MAX_WORKSPACE_SYMBOLS, disableContentInclusion: true })
+        .map(result => result.node)
+        .filter(node => node.startLine !== undefined)
+        .map(symbolInformation)
+    }
+
+    default:
+      // Notifications such as $/cancelRequest need no answer
+      return method.startsWith('$/') ? null : undefined
+  }
+}
+
+function workspaceRoot(params: JsonObject): string | undefined {
+  const folders = params.workspaceFolders as JsonObject[] | null | undefined
+  const uri = params.rootUri ?? folders?.[0]?.uri
+  if (typeof uri === 'string') return uriToPath(uri)
+  return typeof params.rootPath === 'string' ? resolve(params.rootPath) : undefined
+}
+
+/**
+ * The MCP server's project for the workspace, so both protocols share one index
+ */
+function projectFor(session: LSPSession): Promise<Project> {
+  return getOrCreateMCPProject(undefined, session.root)
+}
+
+/**
+ * The identifier under the cursor, from the open document or else the file on disk
+ */
+function wordAt(session: LSPSession, params: JsonObject): string | undefined {
+  const uri = String((params.textDocument as JsonObject).uri)
+  const position = params.position as unknown as Position
+  const path = uriToPath(uri)
+  const text = session.documents.get(uri) ?? (isFile(path) ? readFileSync(path, 'utf-8') : undefined)
+  const line = text?.split('\n')[position.line]
+  return line === undefined ? undefined : identifierAt(line, position.character)
+}
+
+function declarations(project: Project): TreeNode[] {
+  return getAllNodes(project).filter(node => node.name && node.type !== 'file' && node.type !== 'parameter')
+}
+
+function symbolInformation(node: TreeNode): JsonObject {
+  const kind = node.symbol?.kind ?? node.type
+  return {
+    name: node.name ?? '',
+    kind: SYMBOL_KINDS[kind] ?? SYMBOL_KINDS.variable!,
+    location: location(node.path, node.startLine!, node.startColumn ?? 0, node.endLine ?? node.startLine!, node.endColumn ?? 0),
+    ...(node.symbol?.container ? { containerName: node.symbol.container } : {}),
+  }
+}
+
+/**
+ * An LSP location from 1-based lines and 0-based columns
+ */
+function location(path: string, startLine: number, startColumn: number, endLine: number, endColumn: number): JsonObject {
+  return {
+    uri: pathToFileURL(path).href,
+    range: {
+      start: { line: startLine - 1, character: startColumn },
+      end: { line: endLine - 1, character: endColumn },
+    },
+  }
+}
+
+function uriToPath(uri: string): string {
+  return uri.startsWith('file:') ? fileURLToPath(uri) : resolve(uri)
+}
@@ -2,18 +2,159 @@
  * MCP tool request handlers - simplified from complex handler system
  */
 
+import { writeFile } from 'fs/promises'
+import { relative, resolve } from 'path'
 import { analyzeProject } from '../analysis/index.js'
 import { analyzeErrors, partitionErrors } from '../analysis/errors.js'
-import { searchCode, findUsage } from '../core/search.js'
-import { createPersistentManager, getOrCreateProject } from '../project/persistent-manager.js'
+import { listProtoDefinitions } from '../analysis/protobuf.js'
+import { checkOpenApi } from '../analysis/openapi.js'
+import { mapEnvironmentVariables } from '../analysis/env-vars.js'
+import { listFeatureFlags } from '../analysis/feature-flags.js'
+import { analyzeLogging } from '../analysis/logging.js'
+import { analyzeExhaustiveness } from '../analysis/exhaustiveness.js'
+import { findPanicPaths } from '../analysis/panics.js'
+import { readCoverageReport, suggestTestTargets } from '../analysis/test-targets.js'
+import { generateTestStub } from '../analysis/test-stubs.js'
+import { findTestDoubles } from '../analysis/test-doubles.js'
+import { findTestFixtures } from '../analysis/test-fixtures.js'
+import { listBenchmarks } from '../analysis/benchmarks.js'
+import { analyzeTranslations } from '../analysis/i18n.js'
+import { listModels } from '../analysis/models.js'
+import { listDataShapes, matchKeyPath, matchPayload, parsePayload, type ShapeLanguage } from '../analysis/payload-match.js'
+import { findSerializedField, findTagProblems, listGoStructs, structsTaggedWith } from '../analysis/struct-tags.js'
+import { listMigrations, replayMigrations } from '../analysis/migrations.js'
+import { linkApiCalls } from '../analysis/api-links.js'
+import { exportChunks, formatChunksAsJsonl } from '../analysis/chunks.js'
+import { analyzeSnippet } from '../analysis/snippet.js'
+import { listConstantValues, withConstantUsages } from '../analysis/constants.js'
+import { findSimilarCode } from '../analysis/similarity.js'
+import { buildContextPack } from '../analysis/context-pack.js'
+import { summarizeArchitecture } from '../analysis/architecture.js'
+import { ENTRY_POINT_KINDS, listEntryPoints, type EntryPointKind } from '../analysis/entry-points.js'
+import { mapKubernetes } from '../analysis/kubernetes.js'
+import { mapSpringBeans } from '../analysis/spring.js'
+import { mapFlutterWidgets } from '../analysis/flutter.js'
+import { findDocsFor } from '../analysis/docs.js'
+import { applyRollupTrends, rollupFindings, ROLLUP_GROUPINGS, type RollupGrouping } from '../analysis/rollup.js'
+import { searchCode, findUsage, findConfigKeyUsage } from '../core/search.js'
+import { isKeyPath } from '../core/config-keys.js'
+import { createGoBuildIndex } from '../core/go-build.js'
+import { createPreprocessorIndex, parseDefines } from '../core/preprocessor.js'
+import { COMMENT_FILTERS, searchStrings, STRING_SEARCH_MODES, type CommentFilter, type StringSearchMode } from '../core/strings.js'
+import { findAliasedDefinitions, findAliasExpressions, findAliasExpressionsOf, findDependentFiles } from '../import/aliases.js'
+import { findSymbolCandidates, findSymbolsById, isSymbolId, symbolCandidate, symbolId } from '../core/symbol-ids.js'
+import { findDefinitions } from '../core/definitions.js'
+import { getTypeInfo } from '../core/type-info.js'
+import { getNotebookOutline } from '../core/notebook.js'
+import { isNotebookFile } from '../constants/file-types.js'
+import { PROJECT_FILES } from '../constants/project-files.js'
+import { CONFIDENCE_LEVELS } from '../constants/patterns.js'
+import { createPersistentManager, getOrCreateProject, loadProjectFromIndex } from '../project/persistent-manager.js'
+import { exportIndex } from '../project/index-archive.js'
+import { applyEditPlan, describeEditPlan, EDIT_POSITIONS, editAtSymbol, getEditPlan, planEdits, planUndo, resolveEditTarget, type EditPlan, type EditPosition } from '../project/edits.js'
+import { listEdits } from '../project/edit-journal.js'
+import { getProject } from '../project/memory.js'
+import { checkoutRemoteRepository, isGitUrl, type RemoteCheckout } from '../project/remote.js'
+import { resolveDependencySource, type DependencyEcosystem } from '../project/dependencies.js'
 import { findDependencyModuleDirs } from '../project/monorepo.js'
+import { getAllNodes, getProjectDiagnostics, parseProject, updateProject } from '../project/manager.js'
+import { scopeProject } from '../project/scopes.js'
+import { loadProjectSettings } from '../project/settings.js'
+import { listProjectFrameworks } from '../project/frameworks.js'
+import { findOwners, loadCodeOwners, ownersOf } from '../project/codeowners.js'
+import { getFileChurn, getSymbolHistory, loadProjectAtRef, type RefSnapshot } from '../project/git-history.js'
+import { findOwningGoModule } from '../project/go-workspace.js'
+import { findOwningJvmModule } from '../project/jvm-modules.js'
+import { findBazelTarget, targetContains, targetsFor } from '../project/bazel.js'
+import { extractTasks } from '../project/tasks.js'
+import { extractCIJobs } from '../project/ci.js'
+import { MCP_TOOLS } from './schemas.js'
+import { cachedToolCall, createResultCache, isCacheableCall, type ResultCache } from './result-cache.js'
+import { diagnoseEmptySearch, nearestNames, symbolNames } from '../core/search-diagnosis.js'
+import { applySearchQuery } from '../core/query.js'
+import { expandSavedQueries } from '../project/saved-queries.js'
+import { appliedFilters, collectStats, recordScanned, type QueryStats } from './stats.js'
 import { getLogger } from '../utils/logger.js'
-import { handleError } from '../utils/errors.js'
-import type { AnalysisOptions } from '../types/analysis.js'
-import type { JsonObject, Project } from '../types/core.js'
+import { createError, handleError } from '../utils/errors.js'
+import type { LanguageServerOptions } from '../lsp/client.js'
+import type { AnalysisOptions, AnalysisRollup, Confidence } from '../types/analysis.js'
+import type { JsonObject, JsonValue, Project, TreeNode } from '../types/core.js'
 
 const mcpPersistentManager = createPersistentManager(10)
 
+// Calls in a parallel batch share one parse of a project that isn't loaded yet
+const pendingProjects = new Map<string, Promise<Project>>()
+
+const MAX_BATCH_CALLS = 20
+
+// Latest rollup per analyze_code request shape, the baseline for the next request's trends
+const rollupBaselines = new Map<string, AnalysisRollup>()
+
+const HISTORY_SYMBOL_TYPES = new Set(['function', 'method', 'class', 'interface', 'struct', 'enum'])
+
+// Set by `--read-only`: calls that write files are refused
+let readOnlyMode = false
+
+// Set by `--max-files-per-call`: calls that would index a larger project are refused
+let indexFileLimit: number | undefined
+
+// Set by `--language-server`: the command line per language server. Never read from a project's
+// settings, which would let an indexed checkout pick the programs its lookups run
+let languageServerCommands: Record<string, string[]> = {}
+
+// Set by `--format-edits`: whether edits run through the project's formatter when the call
+// doesn't say. Not a project setting, as formatters run the checkout's configuration
+let formatEdits = false
+
+// Answers repeated read-only calls while the index is unchanged; `--no-result-cache` clears it
+let resultCache: ResultCache | undefined = createResultCache()
+
+// Tools annotated as writing that only do so when an argument asks them to
+const CONDITIONAL_WRITES: Record<string, (args: JsonObject) => boolean> = {
+  export_chunks: args => typeof args.outputFile === 'string',
+  edit_at_symbol: args => args.dryRun !== true,
+  undo_last_edit: args => args.dryRun !== true,
+  batch: () => false, // Each of its calls is checked on its own
+  register_project: args => typeof args.url === 'string' || (typeof args.directory === 'string' && isGitUrl(args.directory)), // Clones into the cache
+}
+
+/**
+ * Enables or disables read-only mode, in which mutating tool calls fail
+ */
+export function setReadOnlyMode(enabled: boolean): void {
+  readOnlyMode = enabled
+}
+
+/**
+ * Sets the most files a tool call may index when its project is not indexed yet
+ */
+export function setIndexFileLimit(limit: number | undefined): void {
+  indexFileLimit = limit
+}
+
+/**
+ * Sets the command lines language servers are started with, by server id
+ */
+export function setLanguageServerCommands(commands: Record<string, string[]>): void {
+  languageServerCommands = commands
+}
+
+/**
+ * Sets whether edits are formatted when a call doesn't pass `format`
+ */
+export function setFormatEdits(enabled: boolean): void {
+  formatEdits = enabled
+}
+
+/**
+ * Whether a call to `name` with `args` writes files, by the tool's `readOnlyHint` annotation
+ */
+export function isMutatingCall(name: string, args: JsonObject = {}): boolean {
+  const conditional = CONDITIONAL_WRITES[name]
+  if (conditional) return conditional(args)
+  return MCP_TOOLS.find(tool => tool.name === name)?.annotations.readOnlyHint === false
+}
+
 // Export function for test cleanup
 export function clearMCPMemory(): void {
   // Stop all watchers first
@@ -42,7 +183,7 @@ interface MCPToolRequest {
   params: MCPToolParams
 }
 
-interface MCPToolResult {
+export interface MCPToolResult {
   content: Array<{
     type: 'text'
     text: string
@@ -50,21 +191,80 @@ interface MCPToolResult {
   [key: string]: unknown
 }
 
-async function getOrCreateMCPProject(projectId?: string, directory?: string, ignoreDirs?: string[]): Promise<Project> {
-  const actualDirectory = directory || (projectId && projectId.startsWith('/') ? projectId : process.cwd())
+function resolveMCPLocation(projectId?: string, directory?: string): { actualProjectId?: string, actualDirectory: string } {
   const actualProjectId = projectId && !projectId.startsWith('/') ? projectId : undefined
+  // A registered project (e.g. a cloned remote repository) is addressable by ID alone
+  const registeredDirectory = actualProjectId ? mcpPersistentManager.projectToDirectory.get(actualProjectId) : undefined
+  const actualDirectory = directory || registeredDirectory || (projectId && projectId.startsWith('/') ? projectId : process.cwd())
+  return { actualProjectId, actualDirectory }
+}
 
-  return getOrCreateProject(mcpPersistentManager, {
-    directory: actualDirectory,
-    ignoreDirs: ignoreDirs || [],
-    autoWatch: process.env.NODE_ENV !== 'test',
-  }, actualProjectId)
+/**
+ * The project the MCP tools share for a location; the LSP bridge answers from it too
+ */
+export async function getOrCreateMCPProject(projectId?: string, directory?: string, ignoreDirs?: string[], scope?: unknown): Promise<Project> {
+  const { actualProjectId, actualDirectory } = resolveMCPLocation(projectId, directory)
+
+  const key = JSON.stringify([actualProjectId, resolve(actualDirectory), ignoreDirs || []])
+  let request = pendingProjects.get(key)
+  if (!request) {
+    request = getOrCreateProject(mcpPersistentManager, {
+      directory: actualDirectory,
+      ignoreDirs: ignoreDirs || [],
+      autoWatch: process.env.NODE_ENV !== 'test',
+      maxFiles: indexFileLimit,
+    }, actualProjectId).finally(() => pendingProjects.delete(key))
+    pendingProjects.set(key, request)
+  }
+
+  return scopeProject(await request, typeof scope === 'string' ? scope : undefined)
+}
+
+/**
+ * The project already indexed for a directory, without indexing it
+ */
+export function findLoadedMCPProject(directory: string): Project | undefined {
+  const projectId = mcpPersistentManager.directoryToProject.get(resolve(directory))
+  return projectId ? mcpPersistentManager.memory.projects.get(projectId) : undefined
+}
+
+// Below this many results a symbol query also gets the names it may have meant
+const DID_YOU_MEAN_BELOW = 3
+
+/**
+ * Names close to a symbol query that found few results, unless one of them has the queried
+ * name; undefined when there are none
+ */
+function didYouMean(query: string, nodes: TreeNode[], resultNames: Array<string | undefined>): string[] | undefined {
+  const lowered = query.toLowerCase()
+  if (!query || resultNames.length >= DID_YOU_MEAN_BELOW || resultNames.some(name => name?.toLowerCase() === lowered)) return undefined
+  const found = new Set(resultNames)
+  const names = nearestNames(query, symbolNames(nodes).filter(name => !found.has(name)))
+  return names.length > 0 ? names : undefined
 }
 
-function getSearchNodes(project: Project) {
-  const allNodes = Array.from(project.files.values())
-  const elementNodes = Array.from(project.nodes.values()).flat()
-  return [...allNodes, ...elementNodes]
+function getSearchNodes(project: Project, target?: unknown, includeProjects?: unknown) {
+  // Includes sub-projects so workspace modules are searched as one codebase
+  const nodes = getAllNodes(project)
+
+  if (Array.isArray(includeProjects)) {
+    for (const includedId of includeProjects) {
+      const included = typeof includedId === 'string' ? getProject(mcpPersistentManager.memory, includedId) : null
+      if (!included) {
+        throw new Error(`Unknown project: ${String(includedId)}. Register it with register_project first`)
+      }
+      if (included !== project) nodes.push(...getAllNodes(included))
+    }
+  }
+
+  if (typeof target !== 'string' || !target) return nodes
+
+  const bazelTarget = findBazelTarget(target, project.bazelTargets ?? [])
+  if (!bazelTarget) {
+    throw new Error(`Unknown Bazel target: ${target}`)
+  }
+
+  return nodes.filter(node => targetContains(bazelTarget, node.path))
 }
 
 export async function handleToolRequest(request: MCPToolRequest): Promise<MCPToolResult> {
@@ -73,6 +273,75 @@ export async function handleToolRequest(request: MCPToolRequest): Promise<MCPToo
 
   logger.debug(`Handling tool request: ${name}`)
 
+  if (readOnlyMode && isMutatingCall(name, args)) {
+    throw new Error(`${name} writes files and is disabled: the server runs in read-only mode`)
+  }
+
+  if (args.includeStats === true) {
+    return handleWithStats(name, args)
+  }
+  return runToolRequest(name, args)
+}
+
+function runToolRequest(name: string, args: JsonObject): Promise<MCPToolResult> {
+  if (resultCache && isCacheableCall(name, args)) {
+    return cachedToolCall(resultCache, () => cacheDependencies(args), name, args, () => dispatchToolRequest(name, args))
+  }
+  return dispatchToolRequest(name, args)
+}
+
+/**
+ * Runs a call without its `includeStats` flag, so it shares cached results with calls that
+ * leave the flag out, and adds a `stats` object to its JSON response
+ */
+async function handleWithStats(name: string, args: JsonObject): Promise<MCPToolResult> {
+  const callArgs = { ...args }
+  delete callArgs.includeStats
+  const { value: result, durationMs, filesScanned, cacheHit } = await collectStats(() => runToolRequest(name, callArgs))
+
+  const lookups = resultCache ? resultCache.hits + resultCache.misses : 0
+  const stats: QueryStats = {
+    durationMs,
+    filesIndexed: cacheDependencies(callArgs).reduce((total, project) => total + countIndexedFiles(project), 0),
+    filesScanned: filesScanned ?? null,
+    cache: { hit: cacheHit, hitRatio: lookups > 0 ? Math.round(resultCache!.hits / lookups * 1000) / 1000 : null },
+    filters: appliedFilters(callArgs),
+  }
+
+  const [first, ...rest] = result.content
+  const response = first && rest.length === 0 ? parseToolText(first.text) : undefined
+  if (!response || typeof response !== 'object' || Array.isArray(response)) return result
+  return { ...result, content: [{ type: 'text', text: JSON.stringify({ ...response, stats }) }] }
+}
+
+function countIndexedFiles(project: Project): number {
+  return (project.subProjects ?? []).reduce((total, subProject) => total + countIndexedFiles(subProject), project.files.size)
+}
+
+/**
+ * Sets the cache repeated read-only calls are answered from, or disables caching with undefined
+ */
+export function setResultCache(cache: ResultCache | undefined): void {
+  resultCache = cache
+}
+
+// The loaded projects a call's result is computed from: its own, then those it includes
+function cacheDependencies(args: JsonObject): Project[] {
+  const { actualDirectory } = resolveMCPLocation(
+    typeof args.projectId === 'string' ? args.projectId : undefined,
+    typeof args.directory === 'string' ? args.directory : undefined,
+  )
+  const project = findLoadedMCPProject(actualDirectory)
+  if (!project) return []
+
+  const included = Array.isArray(args.includeProjects) ? args.includeProjects : []
+  return [project, ...included.flatMap((id) => {
+    const includedProject = typeof id === 'string' ? getProject(mcpPersistentManager.memory, id) : null
+    return includedProject ? [includedProject] : []
+  })]
+}
+
+async function dispatchToolRequest(name: string, args: JsonObject): Promise<MCPToolResult> {
   switch (name) {
     case 'search_code':
       return handleSearchCode(args)
@@ -86,6 +355,147 @@ export async function handleToolRequest(request: MCPToolRequest): Promise<MCPToo
     case 'check_errors':
       return handleCheckErrors(args)
 
+    case 'get_diagnostics':
+      return handleGetDiagnostics(args)
+
+    case 'register_project':
+      return handleRegisterProject(args)
+
+    case 'index_dependency':
+      return handleIndexDependency(args)
+
+    case 'get_notebook_outline':
+      return handleGetNotebookOutline(args)
+
+    case 'list_proto_definitions':
+      return handleListProtoDefinitions(args)
+
+    case 'check_openapi':
+      return handleCheckOpenApi(args)
+
+    case 'list_tasks':
+      return handleListTasks(args)
+
+    case 'list_ci_jobs':
+      return handleListCIJobs(args)
+
+    case 'map_env_vars':
+      return handleMapEnvVars(args)
+
+    case 'list_feature_flags':
+      return handleListFeatureFlags(args)
+
+    case 'list_log_statements':
+      return handleListLogStatements(args)
+
+    case 'check_exhaustiveness':
+      return handleCheckExhaustiveness(args)
+
+    case 'list_panic_paths':
+      return handleListPanicPaths(args)
+
+    case 'suggest_test_targets':
+      return handleSuggestTestTargets(args)
+
+    case 'generate_test_stub':
+      return handleGenerateTestStub(args)
+
+    case 'find_test_doubles':
+      return handleFindTestDoubles(args)
+
+    case 'map_test_fixtures':
+      return handleMapTestFixtures(args)
+
+    case 'list_benchmarks':
+      return handleListBenchmarks(args)
+
+    case 'check_translations':
+      return handleCheckTranslations(args)
+
+    case 'list_models':
+      return handleListModels(args)
+
+    case 'query_struct_tags':
+      return handleQueryStructTags(args)
+
+    case 'match_payload_type':
+      return handleMatchPayloadType(args)
+
+    case 'analyze_migrations':
+      return handleAnalyzeMigrations(args)
+
+    case 'link_api_calls':
+      return handleLinkApiCalls(args)
+
+    case 'export_chunks':
+      return handleExportChunks(args)
+
+    case 'export_index':
+      return handleExportIndex(args)
+
+    case 'import_index':
+      return handleImportIndex(args)
+
+    case 'parse_snippet':
+      return handleParseSnippet(args)
+
+    case 'get_symbol_history':
+      return handleGetSymbolHistory(args)
+
+    case 'find_owner':
+      return handleFindOwner(args)
+
+    case 'resolve_symbol':
+      return handleResolveSymbol(args)
+
+    case 'find_definition':
+      return handleFindDefinition(args)
+
+    case 'get_type_info':
+      return handleGetTypeInfo(args)
+
+    case 'get_constant_values':
+      return handleGetConstantValues(args)
+
+    case 'find_similar':
+      return handleFindSimilar(args)
+
+    case 'get_context_pack':
+      return handleGetContextPack(args)
+
+    case 'summarize_architecture':
+      return handleSummarizeArchitecture(args)
+
+    case 'list_entry_points':
+      return handleListEntryPoints(args)
+
+    case 'map_kubernetes':
+      return handleMapKubernetes(args)
+
+    case 'map_spring_beans':
+      return handleMapSpringBeans(args)
+
+    case 'map_flutter_widgets':
+      return handleMapFlutterWidgets(args)
+
+    case 'docs_for':
+      return handleDocsFor(args)
+
+    case 'edit_at_symbol':
+      return handleEditAtSymbol(args)
+
+    case 'apply_edit':
+      return handleApplyEdit(args)
+
+    case 'list_edits':
+      return handleListEdits(args)
+
+    case 'undo_last_edit':
+      return handleUndoLastEdit(args)
+
+    case 'batch':
+      return handleBatch(args)
+
     default:
       throw new Error(`Unknown tool: ${name}`)
   }
@@ -96,11 +506,17 @@ async function handleSearchCode(args: JsonObject): Promise<MCPToolResult> {
     projectId,
     directory,
     query,
+    mode = 'symbols',
     maxResults = 10,
     fuzzyThreshold = 30,
     exactMatch = false,
     types = [],
     pathPattern,
+    target,
+    includeProjects,
+    searchAtRef,
+    buildTags,
+    defines,
     // New content inclusion options
     forceContentInclusion = false,
     maxContentLines = 150,
@@ -110,21 +526,84 @@ async function handleSearchCode(args: JsonObject): Promise<MCPToolResult> {
   if (typeof query !== 'string') {
     throw new Error('Query must be a string')
   }
+  if (mode !== 'symbols' && !STRING_SEARCH_MODES.includes(mode as StringSearchMode)) {
+    throw new Error(`Unknown search mode: ${String(mode)}. Use one of: symbols, ${STRING_SEARCH_MODES.join(', ')}`)
+  }
 
   try {
-    const project = await getOrCreateMCPProject(
-      typeof projectId === 'string' ? projectId : undefined,
-      typeof directory === 'string' ? directory : undefined,
-      [],
-    )
-    const searchNodes = getSearchNodes(project)
+    let snapshot: RefSnapshot | undefined
+    if (typeof searchAtRef === 'string' && searchAtRef) {
+      const { actualProjectId, actualDirectory } = resolveMCPLocation(
+        typeof projectId === 'string' ? projectId : undefined,
+        typeof directory === 'string' ? directory : undefined,
+      )
+      snapshot = await loadProjectAtRef(resolve(actualDirectory), searchAtRef, actualProjectId, indexFileLimit)
+    }
+
+    const project = snapshot
+      ? scopeProject(snapshot.project, typeof args.scope === 'string' ? args.scope : undefined)
+      : await getOrCreateMCPProject(
+        typeof projectId === 'string' ? projectId : undefined,
+        typeof directory === 'string' ? directory : undefined,
+        [],
+        args.scope,
+      )
+    const codeOwners = loadCodeOwners(project.config.directory)
+
+    if (mode !== 'symbols') {
+      const stringNodes = getSearchNodes(project, target, includeProjects)
+      recordScanned(stringNodes)
+      const matches = searchStrings(query, stringNodes, {
+        mode: mode as StringSearchMode,
+        maxResults: Number(maxResults),
+        pathPattern: typeof pathPattern === 'string' ? pathPattern : undefined,
+      })
+
+      return {
+        content: [{
+          type: 'text',
+          text: JSON.stringify({
+            projectId: project.id,
+            ref: snapshot?.ref,
+            commit: snapshot?.commit,
+            query,
+            mode,
+            results: matches.map(match => ({ ...match, owners: ownersOf(codeOwners, match.file) })),
+            totalResults: matches.length,
+            diagnosis: matches.length === 0
+              ? diagnoseEmptySearch(query, { indexed: getAllNodes(project), searched: stringNodes, pathPattern: typeof pathPattern === 'string' ? pathPattern : undefined, symbols: false })
+              : undefined,
+          }),
+        }],
+      }
+    }
+
+    // A symbol id from an earlier result names exactly one declaration
+    const idMatches = isSymbolId(query) ? findSymbolsById(project, query) : undefined
+    if (idMatches && idMatches.length === 0) {
+      throw new Error(`Unknown symbol id: ${query}`)
+    }
+    const projectNodes = idMatches ?? getSearchNodes(project, target, includeProjects)
+    const indexedNodes = idMatches ? getAllNodes(project) : projectNodes
+    const goBuild = createGoBuildIndex(indexedNodes)
+    const preprocessor = createPreprocessorIndex(indexedNodes)
+    const compiledWith = typeof defines === 'string' ? parseDefines(defines) : undefined
+    const filteredNodes = projectNodes.filter(node => (typeof buildTags !== 'string' || !buildTags || goBuild.matches(node.path, buildTags))
+      && (!compiledWith || preprocessor.matches(node, compiledWith)))
+    recordScanned(filteredNodes)
+    // @name stands for a query saved in the project settings
+    const expandedQuery = idMatches ? query : expandSavedQueries(project.config.directory, query)
+    // kind:, lang:, name:, path: and caller: terms narrow the nodes; the remaining words rank them
+    const structured = idMatches ? { query, nodes: filteredNodes } : applySearchQuery(expandedQuery, filteredNodes, project.config.directory)
+    const searchNodes = structured.nodes
 
-    const results = searchCode(query as string, searchNodes, {
+    const results = searchCode(idMatches?.[0]?.name ?? structured.query, searchNodes, {
       maxResults: Number(maxResults),
       fuzzyThreshold: Number(fuzzyThreshold),
-      exactMatch: Boolean(exactMatch),
+      exactMatch: Boolean(exactMatch) || idMatches !== undefined,
       types: Array.isArray(types) ? types as string[] : [],
       pathPattern: typeof pathPattern === 'string' ? pathPattern : undefined,
+      aliasMatches: idMatches || structured.query !== expandedQuery ? [] : findAliasedDefinitions(project, expandedQuery),
       // New content inclusion options
       forceContentInclusion: Boolean(forceContentInclusion),
       maxContentLines: Number(maxContentLines),
@@ -136,23 +615,44 @@ async function handleSearchCode(args: JsonObject): Promise<MCPToolResult> {
         type: 'text',
         text: JSON.stringify({
           projectId: project.id,
+          ref: snapshot?.ref,
+          commit: snapshot?.commit,
           query,
+          expandedQuery: expandedQuery !== query ? expandedQuery : undefined,
           results: results.map(r => ({
+            id: symbolId(project.config.directory, r.node),
             name: r.node.name,
             type: r.node.type,
+            symbol: r.node.symbol,
             path: r.node.path,
             startLine: r.node.startLine,
             endLine: r.node.endLine,
             startColumn: r.node.startColumn,
             endColumn: r.node.endColumn,
+            cell: r.node.cell,
             score: r.score,
             matches: r.matches,
+            reExports: r.reExports,
             contentIncluded: r.contentIncluded,
             content: r.content,
             contentTruncated: r.contentTruncated,
             contentLines: r.contentLines,
+            targets: project.bazelTargets ? targetsFor(r.node.path, project.bazelTargets).map(t => t.label) : undefined,
+            subproject: project.jvmModules ? findOwningJvmModule(r.node.path, project.jvmModules)?.name : undefined,
+            build: goBuild.describe(r.node),
+            preprocessor: preprocessor.describe(r.node),
+            owners: ownersOf(codeOwners, r.node.path),
           })),
           totalResults: results.length,
+          didYouMean: idMatches || !structured.query ? undefined : didYouMean(structured.query, getAllNodes(project), results.map(r => r.node.name)),
+          diagnosis: results.length === 0
+            ? diagnoseEmptySearch(structured.query, {
+              indexed: getAllNodes(project),
+              searched: searchNodes,
+              pathPattern: typeof pathPattern === 'string' ? pathPattern : undefined,
+              types: Array.isArray(types) ? types as string[] : [],
+            })
+            : undefined,
         }),
       }],
     }
@@ -169,27 +669,72 @@ async function handleFindUsage(args: JsonObject): Promise<MCPToolResult> {
     identifier,
     caseSensitive = false,
     exactMatch = true,
+    comments = 'include',
     maxResults = 50,
     pathPattern,
+    target,
+    includeProjects,
+    typeArguments,
   } = args
 
   if (typeof identifier !== 'string') {
     throw new Error('Identifier must be a string')
   }
+  if (!COMMENT_FILTERS.includes(comments as CommentFilter)) {
+    throw new Error(`Unknown comments filter: ${String(comments)}. Use one of: ${COMMENT_FILTERS.join(', ')}`)
+  }
 
   try {
     const project = await getOrCreateMCPProject(
       typeof projectId === 'string' ? projectId : undefined,
       typeof directory === 'string' ? directory : undefined,
       [],
+      args.scope,
     )
-    const searchNodes = getSearchNodes(project)
+    let searchNodes = getSearchNodes(project, target, includeProjects)
+    let name = identifier
+    let aliases = findAliasExpressions(project, identifier)
+
+    // For a symbol id, only files that can see that declaration are searched
+    if (isSymbolId(identifier)) {
+      const definitions = findSymbolsById(project, identifier)
+      if (definitions.length === 0) {
+        throw new Error(`Unknown symbol id: ${identifier}`)
+      }
+      const dependents = definitions.map(definition => findDependentFiles(project, definition))
+      if (dependents.every(Boolean)) {
+        const files = new Set(dependents.flatMap(files => [...files!]))
+        searchNodes = searchNodes.filter(node => files.has(node.path))
+      }
+      name = definitions[0]!.name!
+      aliases = new Map()
+      for (const definition of definitions) {
+        for (const [file, expressions] of findAliasExpressionsOf(project, definition)) {
+          aliases.set(file, [...new Set([...(aliases.get(file) ?? []), ...expressions])])
+        }
+      }
+    }
 
-    const results = findUsage(identifier, searchNodes, {
+    const filters = {
       caseSensitive: Boolean(caseSensitive),
-      exactMatch: Boolean(exactMatch),
       pathPattern: typeof pathPattern === 'string' ? pathPattern : undefined,
-    })
+      comments: comments as CommentFilter,
+    }
+    recordScanned(searchNodes)
+    // Instantiations are only told apart from index expressions for declared generics
+    const generic = getAllNodes(project).some(node => node.name === name && node.symbol?.typeParameters)
+    // A config key path is also found where config files declare it and code subscripts it
+    const results = [
+      ...(isKeyPath(name) ? findConfigKeyUsage(name, searchNodes, filters) : []),
+      ...findUsage(name, searchNodes, { ...filters, exactMatch: Boolean(exactMatch), aliases, generic }),
+    ].filter(result => typeof typeArguments !== 'string'
+      || result.typeArguments?.join(',').replace(/\s+/g, '') === typeArguments.replace(/\s+/g, ''))
+    const instantiations = new Map<string, { typeArguments: string[], count: number }>()
+    for (const { typeArguments: found } of results) {
+      if (!found) continue
+      const key = found.join(', ')
+      instantiations.set(key, { typeArguments: found, count: (instantiations.get(key)?.count ?? 0) + 1 })
+    }
 
     return {
       content: [{
@@ -206,8 +751,14 @@ async function handleFindUsage(args: JsonObject): Promise<MCPToolResult> {
             type: result.node.type,
             name: result.node.name,
             context: result.context,
+            via: result.via,
+            typeArguments: result.typeArguments,
+            cell: result.node.cell,
+            module: project.goModules ? findOwningGoModule(result.node.path, project.goModules)?.path : undefined,
           })),
           totalUsages: results.length,
+          instantiations: generic ? [...instantiations.values()] : undefined,
+          didYouMean: results.length === 0 && !isSymbolId(identifier) ? didYouMean(identifier, getAllNodes(project), []) : undefined,
         }),
       }],
     }
@@ -225,16 +776,26 @@ async function handleAnalyzeCode(args: JsonObject): Promise<MCPToolResult> {
     pathPattern,
     ignoreDirs = [],
     maxResults = 15,
+    groupBy,
+    minConfidence = 'medium',
   } = args
 
   const analysisTypesArray = Array.isArray(analysisTypes) ? analysisTypes as string[] : ['quality']
+  if (groupBy !== undefined && !ROLLUP_GROUPINGS.includes(groupBy as RollupGrouping)) {
+    throw new Error(`groupBy must be one of: ${ROLLUP_GROUPINGS.join(', ')}`)
+  }
+  if (!CONFIDENCE_LEVELS.includes(minConfidence as Confidence)) {
+    throw new Error(`minConfidence must be one of: ${CONFIDENCE_LEVELS.join(', ')}`)
+  }
 
   try {
     const project = await getOrCreateMCPProject(
       typeof projectId === 'string' ? projectId : undefined,
       typeof directory === 'string' ? directory : undefined,
       Array.isArray(ignoreDirs) ? ignoreDirs as string[] : [],
+      args.scope,
     )
+    recordScanned(getAllNodes(project))
 
     const depDirs = findDependencyModuleDirs(project.config.directory, project.nodes)
 
@@ -243,6 +804,11 @@ async function handleAnalyzeCode(args: JsonObject): Promise<MCPToolResult> {
       includeDeadcode: analysisTypesArray.includes('deadcode'),
       includeStructure: analysisTypesArray.includes('structure'),
       includeSyntax: analysisTypesArray.includes('syntax'),
+      includeSecurity: analysisTypesArray.includes('security'),
+      includeConcurrency: analysisTypesArray.includes('concurrency'),
+      includeResources: analysisTypesArray.includes('resources'),
+      includeNullability: analysisTypesArray.includes('nullability'),
+      minConfidence: minConfidence as Confidence,
       excludePaths: depDirs,
     }
 
@@ -262,7 +828,23 @@ async function handleAnalyzeCode(args: JsonObject): Promise<MCPToolResult> {
       return aOrder - bOrder
     })
 
+    const codeOwners = loadCodeOwners(project.config.directory)
     const limitedFindings = filteredFindings.slice(0, Number(maxResults))
+      .map(finding => codeOwners ? { ...finding, owners: ownersOf(codeOwners, finding.location) } : finding)
+
+    let rollup: AnalysisRollup | undefined
+    if (groupBy) {
+      const grouping = groupBy as RollupGrouping
+      const groups = rollupFindings(filteredFindings, { groupBy: grouping, directory: project.config.directory, codeOwners })
+      // Trends compare against the previous identical request of this server session
+      const key = JSON.stringify([project.id, grouping, [...analysisTypesArray].sort(), pathPattern ?? null, args.scope ?? null])
+      const baseline = rollupBaselines.get(key)
+      const generatedAt = new Date().toISOString()
+      rollup = baseline
+        ? { groupBy: grouping, generatedAt, groups: applyRollupTrends(groups, baseline.groups), baselineAt: baseline.generatedAt }
+        : { groupBy: grouping, generatedAt, groups }
+      rollupBaselines.set(key, { groupBy: grouping, generatedAt, groups })
+    }
 
     return {
       content: [{
@@ -271,6 +853,7 @@ async function handleAnalyzeCode(args: JsonObject): Promise<MCPToolResult> {
           analysis: {
             ...result,
             findings: limitedFindings,
+            rollup,
             timestamp: new Date().toISOString(),
             projectId: project.id,
             directory: project.config.directory,
@@ -303,6 +886,7 @@ async function handleCheckErrors(args: JsonObject): Promise<MCPToolResult> {
       typeof projectId === 'string' ? projectId : undefined,
       typeof directory === 'string' ? directory : undefined,
       Array.isArray(ignoreDirs) ? ignoreDirs as string[] : [],
+      args.scope,
     )
 
     const result = analyzeErrors(project)
@@ -354,4 +938,1780 @@ async function handleCheckErrors(args: JsonObject): Promise<MCPToolResult> {
   catch (error) {
     throw handleError(error, 'Error analysis failed')
   }
-}
\ No newline at end of file
+}
+
+async function handleGetDiagnostics(args: JsonObject): Promise<MCPToolResult> {
+  const {
+    projectId,
+    directory,
+    pathPattern,
+    maxResults = 50,
+  } = args
+
+  try {
+    const project = await getOrCreateMCPProject(
+      typeof projectId === 'string' ? projectId : undefined,
+      typeof directory === 'string' ? directory : undefined,
+      [],
+      args.scope,
+    )
+
+    const matchesPath = (path: string) => typeof pathPattern !== 'string' || path.includes(pathPattern)
+
+    const diagnostics = getProjectDiagnostics(project)
+      .filter(diagnostic => matchesPath(diagnostic.path))
+      .sort((a, b) => b.timestamp - a.timestamp)
+
+    const skippedFiles = getAllNodes(project)
+      .filter(node => node.type === 'file' && node.skipped && matchesPath(node.path))
+      .map(node => ({ path: node.path, reason: node.skipReason }))
+
+    const limit = Number(maxResults)
+
+    return {
+      content: [{
+        type: 'text',
+        text: JSON.stringify({
+          projectId: project.id,
+          diagnostics: diagnostics.slice(0, limit).map(diagnostic => ({
+            ...diagnostic,
+            timestamp: new Date(diagnostic.timestamp).toISOString(),
+          })),
+          skippedFiles: skippedFiles.slice(0, limit),
+          totalDiagnostics: diagnostics.length,
+          totalSkippedFiles: skippedFiles.length,
+        }),
+      }],
+    }
+  }
+  catch (error) {
+    throw handleError(error, 'Diagnostics lookup failed')
+  }
+}
+
+async function handleRegisterProject(args: JsonObject): Promise<MCPToolResult> {
+  const {
+    projectId,
+    directory,
+    url,
+    ref,
+    refresh = false,
+    ignoreDirs = [],
+  } = args
+
+  const source = typeof url === 'string' ? url : typeof directory === 'string' && isGitUrl(directory) ? directory : undefined
+  if (!source && typeof directory !== 'string') {
+    throw new Error('Either directory or url is required')
+  }
+
+  try {
+    let remote: RemoteCheckout | undefined
+    if (source) {
+      if (!isGitUrl(source)) {
+        throw new Error(source.startsWith('file://')
+          ? `file:// URLs are refused unless the server runs with --allow-file-remotes: ${source}`
+          : `Not a git URL: ${source}`)
+      }
+      remote = await checkoutRemoteRepository(source, typeof ref === 'string' ? ref : undefined, Boolean(refresh))
+    }
+
+    const project = await getOrCreateProject(mcpPersistentManager, {
+      directory: remote ? remote.directory : directory as string,
+      ignoreDirs: Array.isArray(ignoreDirs) ? ignoreDirs as string[] : [],
+      // Cached checkouts only change when refreshed, so there is nothing to watch
+      autoWatch: !remote && process.env.NODE_ENV !== 'test',
+      maxFiles: indexFileLimit,
+    }, typeof projectId === 'string' ? projectId : undefined)
+
+    if (remote && refresh) {
+      await parseProject(project)
+    }
+
+    return {
+      content: [{
+        type: 'text',
+        text: JSON.stringify({
+          projectId: project.id,
+          directory: project.config.directory,
+          remote: remote
+            ? { url: remote.url, ref: remote.ref, commit: remote.commit }
+            : undefined,
+          totalFiles: getAllNodes(project).filter(node => node.type === 'file').length,
+          frameworks: listProjectFrameworks(project).map(framework => ({
+            ...framework,
+            directory: relative(project.config.directory, framework.directory) || '.',
+          })),
+        }),
+      }],
+    }
+  }
+  catch (error) {
+    throw handleError(error, 'Project registration failed')
+  }
+}
+
+async function handleIndexDependency(args: JsonObject): Promise<MCPToolResult> {
+  const {
+    projectId,
+    directory,
+    name,
+    ecosystem,
+  } = args
+
+  if (typeof name !== 'string' || !name) {
+    throw new Error('Dependency name must be a string')
+  }
+
+  try {
+    const projectDirectory = typeof directory === 'string'
+      ? directory
+      : (typeof projectId === 'string' && mcpPersistentManager.projectToDirectory.get(projectId)) || process.cwd()
+
+    const source = await resolveDependencySource(
+      projectDirectory,
+      name,
+      typeof ecosystem === 'string' ? ecosystem as DependencyEcosystem : undefined,
+    )
+    if (!source) {
+      throw new Error(`Could not locate installed sources for ${name} from ${projectDirectory}`)
+    }
+
+    const project = await getOrCreateProject(mcpPersistentManager, {
+      directory: source.directory,
+      ignoreDirs: [],
+      autoWatch: false,
+      maxFiles: indexFileLimit,
+      ...(source.file ? { files: [source.file] } : {}),
+    }, `${source.ecosystem}-${source.name}${source.version ? `-${source.version}` : ''}`)
+
+    return {
+      content: [{
+        type: 'text',
+        text: JSON.stringify({
+          projectId: project.id,
+          dependency: source,
+          totalFiles: getAllNodes(project).filter(node => node.type === 'file').length,
+        }),
+      }],
+    }
+  }
+  catch (error) {
+    throw handleError(error, 'Dependency indexing failed')
+  }
+}
+
+async function handleGetNotebookOutline(args: JsonObject): Promise<MCPToolResult> {
+  const {
+    projectId,
+    directory,
+    pathPattern,
+  } = args
+
+  try {
+    const project = await getOrCreateMCPProject(
+      typeof projectId === 'string' ? projectId : undefined,
+      typeof directory === 'string' ? directory : undefined,
+      [],
+      args.scope,
+    )
+
+    const notebooks = getAllNodes(project)
+      .filter(node => node.type === 'file' && isNotebookFile(node.path))
+      .filter(node => typeof pathPattern !== 'string' || node.path.includes(pathPattern))
+      .map(node => ({ path: node.path, cells: getNotebookOutline(node) }))
+
+    return {
+      content: [{
+        type: 'text',
+        text: JSON.stringify({
+          projectId: project.id,
+          notebooks,
+          totalNotebooks: notebooks.length,
+        }),
+      }],
+    }
+  }
+  catch (error) {
+    throw handleError(error, 'Notebook outline failed')
+  }
+}
+
+async function handleListProtoDefinitions(args: JsonObject): Promise<MCPToolResult> {
+  const {
+    projectId,
+    directory,
+    pathPattern,
+    includeUsages = true,
+    maxUsages = 5,
+  } = args
+
+  try {
+    const project = await getOrCreateMCPProject(
+      typeof projectId === 'string' ? projectId : undefined,
+      typeof directory === 'string' ? directory : undefined,
+      [],
+      args.scope,
+    )
+
+    const protoFiles = listProtoDefinitions(project, {
+      pathPattern: typeof pathPattern === 'string' ? pathPattern : undefined,
+      includeUsages: Boolean(includeUsages),
+      maxUsages: Number(maxUsages),
+    })
+
+    return {
+      content: [{
+        type: 'text',
+        text: JSON.stringify({
+          projectId: project.id,
+          protoFiles,
+          totalServices: protoFiles.reduce((sum, file) => sum + file.services.length, 0),
+          totalTypes: protoFiles.reduce((sum, file) => sum + file.types.length, 0),
+        }),
+      }],
+    }
+  }
+  catch (error) {
+    throw handleError(error, 'Proto definition listing failed')
+  }
+}
+
+async function handleCheckOpenApi(args: JsonObject): Promise<MCPToolResult> {
+  const { projectId, directory, specFile } = args
+
+  try {
+    const project = await getOrCreateMCPProject(
+      typeof projectId === 'string' ? projectId : undefined,
+      typeof directory === 'string' ? directory : undefined,
+      [],
+      args.scope,
+    )
+
+    const report = await checkOpenApi(project, {
+      specFile: typeof specFile === 'string' ? resolve(project.config.directory, specFile) : undefined,
+    })
+
+    return {
+      content: [{
+        type: 'text',
+        text: JSON.stringify({
+          projectId: project.id,
+          ...report,
+          summary: {
+            operations: report.operations.length,
+            implemented: report.operations.length - report.unimplemented.length,
+            unimplemented: report.unimplemented.length,
+            undocumented: report.undocumented.length,
+          },
+        }),
+      }],
+    }
+  }
+  catch (error) {
+    throw handleError(error, 'OpenAPI check failed')
+  }
+}
+
+async function handleListTasks(args: JsonObject): Promise<MCPToolResult> {
+  const { projectId, directory, runner, pattern } = args
+
+  try {
+    const project = await getOrCreateMCPProject(
+      typeof projectId === 'string' ? projectId : undefined,
+      typeof directory === 'string' ? directory : undefined,
+      [],
+      args.scope,
+    )
+
+    const tasks = extractTasks(getAllNodes(project).filter(node => node.type === 'file'))
+      .filter(task => typeof runner !== 'string' || task.runner === runner)
+      .filter(task => typeof pattern !== 'string' || task.name.includes(pattern))
+
+    return {
+      content: [{
+        type: 'text',
+        text: JSON.stringify({
+          projectId: project.id,
+          tasks,
+          totalTasks: tasks.length,
+          taskFiles: Array.from(new Set(tasks.map(task => task.file))),
+        }),
+      }],
+    }
+  }
+  catch (error) {
+    throw handleError(error, 'Task listing failed')
+  }
+}
+
+async function handleListCIJobs(args: JsonObject): Promise<MCPToolResult> {
+  const { projectId, directory, provider, pattern } = args
+
+  try {
+    const project = await getOrCreateMCPProject(
+      typeof projectId === 'string' ? projectId : undefined,
+      typeof directory === 'string' ? directory : undefined,
+      [],
+      args.scope,
+    )
+
+    const jobs = extractCIJobs(getAllNodes(project).filter(node => node.type === 'file'), project.config.directory)
+      .filter(job => typeof provider !== 'string' || job.provider === provider)
+      .filter(job => typeof pattern !== 'string' || job.id.includes(pattern) || job.name.includes(pattern))
+
+    return {
+      content: [{
+        type: 'text',
+        text: JSON.stringify({
+          projectId: project.id,
+          jobs,
+          totalJobs: jobs.length,
+          pipelineFiles: Array.from(new Set(jobs.map(job => job.file))),
+        }),
+      }],
+    }
+  }
+  catch (error) {
+    throw handleError(error, 'CI job listing failed')
+  }
+}
+
+async function handleMapEnvVars(args: JsonObject): Promise<MCPToolResult> {
+  const { projectId, directory, name, status } = args
+
+  try {
+    const project = await getOrCreateMCPProject(
+      typeof projectId === 'string' ? projectId : undefined,
+      typeof directory === 'string' ? directory : undefined,
+      [],
+      args.scope,
+    )
+
+    const envMap = mapEnvironmentVariables(project)
+    const variables = envMap.variables
+      .filter(variable => typeof name !== 'string' || variable.name.includes(name))
+      .filter(variable => typeof status !== 'string' || variable.status === status)
+
+    return {
+      content: [{
+        type: 'text',
+        text: JSON.stringify({
+          projectId: project.id,
+          variables,
+          undefined: envMap.undefined,
+          unused: envMap.unused,
+          envFiles: envMap.envFiles,
+          totalVariables: envMap.variables.length,
+        }),
+      }],
+    }
+  }
+  catch (error) {
+    throw handleError(error, 'Environment variable mapping failed')
+  }
+}
+
+async function handleListFeatureFlags(args: JsonObject): Promise<MCPToolResult> {
+  const { projectId, directory, functions, patterns, sdks, key } = args
+
+  try {
+    const project = await getOrCreateMCPProject(
+      typeof projectId === 'string' ? projectId : undefined,
+      typeof directory === 'string' ? directory : undefined,
+      [],
+      args.scope,
+    )
+
+    const flags = listFeatureFlags(project, {
+      functions: Array.isArray(functions) ? functions as string[] : undefined,
+      patterns: Array.isArray(patterns) ? patterns as string[] : undefined,
+      sdks: Array.isArray(sdks) ? sdks as string[] : undefined,
+    }).filter(flag => typeof key !== 'string' || flag.key.includes(key))
+
+    return {
+      content: [{
+        type: 'text',
+        text: JSON.stringify({
+          projectId: project.id,
+          flags,
+          totalFlags: flags.length,
+          totalReferences: flags.reduce((sum, flag) => sum + flag.references.length, 0),
+        }),
+      }],
+    }
+  }
+  catch (error) {
+    throw handleError(error, 'Feature flag listing failed')
+  }
+}
+
+async function handleListLogStatements(args: JsonObject): Promise<MCPToolResult> {
+  const { projectId, directory, level, issuesOnly = false, maxResults = 100 } = args
+
+  try {
+    const project = await getOrCreateMCPProject(
+      typeof projectId === 'string' ? projectId : undefined,
+      typeof directory === 'string' ? directory : undefined,
+      [],
+      args.scope,
+    )
+
+    const { statements, findings } = analyzeLogging(getAllNodes(project))
+    const filteredStatements = statements.filter(statement => typeof level !== 'string' || statement.level === level)
+    const levels: Record<string, number> = {}
+    for (const statement of statements) {
+      levels[statement.level] = (levels[statement.level] ?? 0) + 1
+    }
+
+    return {
+      content: [{
+        type: 'text',
+        text: JSON.stringify({
+          projectId: project.id,
+          ...(issuesOnly ? {} : { statements: filteredStatements.slice(0, Number(maxResults)) }),
+          findings,
+          levels,
+          totalStatements: filteredStatements.length,
+        }),
+      }],
+    }
+  }
+  catch (error) {
+    throw handleError(error, 'Log statement extraction failed')
+  }
+}
+
+async function handleCheckExhaustiveness(args: JsonObject): Promise<MCPToolResult> {
+  const { projectId, directory, enum: enumName, maxResults = 100 } = args
+
+  try {
+    const project = await getOrCreateMCPProject(
+      typeof projectId === 'string' ? projectId : undefined,
+      typeof directory === 'string' ? directory : undefined,
+      [],
+      args.scope,
+    )
+
+    const { enums, switches, findings } = analyzeExhaustiveness(getAllNodes(project))
+    const wanted = (name: unknown) => typeof enumName !== 'string' || name === enumName
+    const reported = findings.filter(finding => wanted(finding.metrics?.enum))
+
+    return {
+      content: [{
+        type: 'text',
+        text: JSON.stringify({
+          projectId: project.id,
+          findings: reported.slice(0, Number(maxResults)),
+          enums: enums.filter(type => wanted(type.name)).map(({ name, language, kind, file, line, members }) => ({ name, language, kind, file, line, variants: members.length })),
+          checkedSwitches: switches.filter(check => wanted(check.enum)).length,
+          totalFindings: reported.length,
+        }),
+      }],
+    }
+  }
+  catch (error) {
+    throw handleError(error, 'Exhaustiveness check failed')
+  }
+}
+
+async function handleListPanicPaths(args: JsonObject): Promise<MCPToolResult> {
+  const { projectId, directory, symbol, depth = 3, maxResults = 100 } = args
+
+  try {
+    const project = await getOrCreateMCPProject(
+      typeof projectId === 'string' ? projectId : undefined,
+      typeof directory === 'string' ? directory : undefined,
+      [],
+      args.scope,
+    )
+
+    const report = findPanicPaths(getAllNodes(project), {
+      symbol: typeof symbol === 'string' && symbol.trim() ? symbol.trim() : undefined,
+      depth: Number(depth),
+    })
+    const limit = Number(maxResults)
+
+    return {
+      content: [{
+        type: 'text',
+        text: JSON.stringify({
+          projectId: project.id,
+          depth: report.depth,
+          functions: report.functions.slice(0, limit),
+          sites: report.sites.slice(0, limit),
+          totalFunctions: report.functions.length,
+          totalSites: report.sites.length,
+        }),
+      }],
+    }
+  }
+  catch (error) {
+    throw handleError(error, 'Panic path listing failed')
+  }
+}
+
+async function handleSuggestTestTargets(args: JsonObject): Promise<MCPToolResult> {
+  const { projectId, directory, coverageFile, days = 90, maxResults = 20 } = args
+
+  try {
+    const project = await getOrCreateMCPProject(
+      typeof projectId === 'string' ? projectId : undefined,
+      typeof directory === 'string' ? directory : undefined,
+      [],
+      args.scope,
+    )
+
+    const root = project.config.directory
+    const coverage = readCoverageReport(root, typeof coverageFile === 'string' ? coverageFile : undefined)
+    const churn = await getFileChurn(root, Number(days))
+    const targets = suggestTestTargets(getAllNodes(project), root, { coverage, churn })
+
+    return {
+      content: [{
+        type: 'text',
+        text: JSON.stringify({
+          projectId: project.id,
+          coverage: coverage ? { file: relative(root, coverage.file), format: coverage.format, files: coverage.files.size } : null,
+          churnDays: Number(days),
+          targets: targets.slice(0, Number(maxResults)),
+          totalTargets: targets.length,
+        }),
+      }],
+    }
+  }
+  catch (error) {
+    throw handleError(error, 'Test target suggestion failed')
+  }
+}
+
+async function handleGenerateTestStub(args: JsonObject): Promise<MCPToolResult> {
+  const { projectId, directory, symbol } = args
+
+  if (typeof symbol !== 'string') {
+    throw new Error('Symbol must be a string')
+  }
+
+  try {
+    const project = await getOrCreateMCPProject(
+      typeof projectId === 'string' ? projectId : undefined,
+      typeof directory === 'string' ? directory : undefined,
+      [],
+      args.scope,
+    )
+
+    const stub = generateTestStub(project, symbol)
+
+    return {
+      content: [{
+        type: 'text',
+        text: JSON.stringify({
+          projectId: project.id,
+          ...stub,
+        }),
+      }],
+    }
+  }
+  catch (error) {
+    throw handleError(error, 'Test stub generation failed')
+  }
+}
+
+async function handleFindTestDoubles(args: JsonObject): Promise<MCPToolResult> {
+  const { projectId, directory, type } = args
+
+  if (typeof type !== 'string') {
+    throw new Error('Type must be a string')
+  }
+
+  try {
+    const project = await getOrCreateMCPProject(
+      typeof projectId === 'string' ? projectId : undefined,
+      typeof directory === 'string' ? directory : undefined,
+      [],
+      args.scope,
+    )
+
+    const boundaries = findTestDoubles(project, type)
+
+    return {
+      content: [{
+        type: 'text',
+        text: JSON.stringify({
+          projectId: project.id,
+          ...boundaries,
+        }),
+      }],
+    }
+  }
+  catch (error) {
+    throw handleError(error, 'Test double lookup failed')
+  }
+}
+
+async function handleMapTestFixtures(args: JsonObject): Promise<MCPToolResult> {
+  const { projectId, directory, path, maxResults = 100 } = args
+
+  try {
+    const project = await getOrCreateMCPProject(
+      typeof projectId === 'string' ? projectId : undefined,
+      typeof directory === 'string' ? directory : undefined,
+      [],
+      args.scope,
+    )
+
+    const { fixtureDirectories, tests } = findTestFixtures(project.config.directory, typeof path === 'string' ? path : undefined)
+
+    return {
+      content: [{
+        type: 'text',
+        text: JSON.stringify({
+          projectId: project.id,
+          fixtureDirectories,
+          tests: tests.slice(0, Number(maxResults)),
+          totalTests: tests.length,
+        }),
+      }],
+    }
+  }
+  catch (error) {
+    throw handleError(error, 'Test fixture mapping failed')
+  }
+}
+
+async function handleListBenchmarks(args: JsonObject): Promise<MCPToolResult> {
+  const { projectId, directory, pattern, maxResults = 100 } = args
+
+  try {
+    const project = await getOrCreateMCPProject(
+      typeof projectId === 'string' ? projectId : undefined,
+      typeof directory === 'string' ? directory : undefined,
+      [],
+      args.scope,
+    )
+
+    const benchmarks = listBenchmarks(project.config.directory, typeof pattern === 'string' ? pattern : undefined)
+
+    return {
+      content: [{
+        type: 'text',
+        text: JSON.stringify({
+          projectId: project.id,
+          benchmarks: benchmarks.slice(0, Number(maxResults)),
+          totalBenchmarks: benchmarks.length,
+        }),
+      }],
+    }
+  }
+  catch (error) {
+    throw handleError(error, 'Benchmark listing failed')
+  }
+}
+
+async function handleCheckTranslations(args: JsonObject): Promise<MCPToolResult> {
+  const { projectId, directory, locale, includeHardcoded = true, maxResults = 50 } = args
+
+  try {
+    const project = await getOrCreateMCPProject(
+      typeof projectId === 'string' ? projectId : undefined,
+      typeof directory === 'string' ? directory : undefined,
+      [],
+      args.scope,
+    )
+
+    const report = await analyzeTranslations(project)
+    const limit = Number(maxResults)
+    const missingTranslations = typeof locale === 'string'
+      ? { [locale]: report.missingTranslations[locale] ?? [] }
+      : report.missingTranslations
+
+    return {
+      content: [{
+        type: 'text',
+        text: JSON.stringify({
+          projectId: project.id,
+          locales: report.locales,
+          localeFiles: report.localeFiles,
+          usedKeys: report.usedKeys,
+          definedKeys: report.definedKeys,
+          undefined: report.undefined.slice(0, limit),
+          unused: report.unused.slice(0, limit),
+          missingTranslations: Object.fromEntries(Object.entries(missingTranslations).map(([name, keys]) => [name, keys.slice(0, limit)])),
+          dynamicKeys: report.dynamicKeys.slice(0, limit),
+          ...(includeHardcoded ? { hardcodedStrings: report.hardcodedStrings.slice(0, limit) } : {}),
+          totals: {
+            undefined: report.undefined.length,
+            unused: report.unused.length,
+            missing: Object.values(missingTranslations).reduce((sum, keys) => sum + keys.length, 0),
+            hardcoded: report.hardcodedStrings.length,
+          },
+        }),
+      }],
+    }
+  }
+  catch (error) {
+    throw handleError(error, 'Translation check failed')
+  }
+}
+
+async function handleListModels(args: JsonObject): Promise<MCPToolResult> {
+  const { projectId, directory, name, orm } = args
+
+  try {
+    const project = await getOrCreateMCPProject(
+      typeof projectId === 'string' ? projectId : undefined,
+      typeof directory === 'string' ? directory : undefined,
+      [],
+      args.scope,
+    )
+
+    const filter = typeof name === 'string' ? name.toLowerCase() : undefined
+    const models = listModels(project)
+      .filter(model => typeof orm !== 'string' || model.orm === orm)
+      .filter(model => !filter || model.name.toLowerCase().includes(filter) || model.table?.toLowerCase().includes(filter))
+
+    return {
+      content: [{
+        type: 'text',
+        text: JSON.stringify({
+          projectId: project.id,
+          models,
+          totalModels: models.length,
+        }),
+      }],
+    }
+  }
+  catch (error) {
+    throw handleError(error, 'Model listing failed')
+  }
+}
+
+async function handleQueryStructTags(args: JsonObject): Promise<MCPToolResult> {
+  const { projectId, directory, key, name, problems = true, maxResults = 100 } = args
+
+  try {
+    const project = await getOrCreateMCPProject(
+      typeof projectId === 'string' ? projectId : undefined,
+      typeof directory === 'string' ? directory : undefined,
+      [],
+      args.scope,
+    )
+
+    const tagKey = typeof key === 'string' && key ? key : undefined
+    const limit = typeof maxResults === 'number' ? maxResults : 100
+    const structs = listGoStructs(project)
+
+    // A name looks up the fields serialized under it; otherwise the structs using the key
+    const result = typeof name === 'string' && name
+      ? { fields: findSerializedField(structs, name, tagKey).slice(0, limit) }
+      : {
+          structs: structsTaggedWith(structs, tagKey).slice(0, limit).map(struct => ({
+            ...struct,
+            fields: struct.fields.filter(field => tagKey ? field.tags[tagKey] : field.tag !== undefined),
+          })),
+        }
+    const tagProblems = problems === false ? undefined : findTagProblems(structs)
+
+    return {
+      content: [{
+        type: 'text',
+        text: JSON.stringify({
+          projectId: project.id,
+          ...result,
+          ...(tagProblems ? { problems: tagProblems.slice(0, limit), totalProblems: tagProblems.length } : {}),
+          totalStructs: structs.length,
+        }),
+      }],
+    }
+  }
+  catch (error) {
+    throw handleError(error, 'Struct tag query failed')
+  }
+}
+
+async function handleMatchPayloadType(args: JsonObject): Promise<MCPToolResult> {
+  const { projectId, directory, payload, keyPath, language, maxResults = 10 } = args
+
+  const hasPayload = typeof payload === 'string' ? payload.trim() !== '' : payload !== undefined && payload !== null
+  if (!hasPayload && (typeof keyPath !== 'string' || !keyPath.trim())) {
+    throw new Error('Either payload or keyPath is required')
+  }
+
+  try {
+    const project = await getOrCreateMCPProject(
+      typeof projectId === 'string' ? projectId : undefined,
+      typeof directory === 'string' ? directory : undefined,
+      [],
+      args.scope,
+    )
+
+    const shapes = listDataShapes(project)
+    const options = {
+      language: typeof language === 'string' ? language as ShapeLanguage : undefined,
+      maxResults: typeof maxResults === 'number' ? maxResults : 10,
+    }
+
+    return {
+      content: [{
+        type: 'text',
+        text: JSON.stringify({
+          projectId: project.id,
+          ...(hasPayload ? { candidates: matchPayload(shapes, typeof payload === 'string' ? parsePayload(payload) : payload, options) } : {}),
+          ...(typeof keyPath === 'string' && keyPath.trim() ? { paths: matchKeyPath(shapes, keyPath.trim(), options) } : {}),
+          totalTypes: shapes.length,
+        }),
+      }],
+    }
+  }
+  catch (error) {
+    throw handleError(error, 'Payload type matching failed')
+  }
+}
+
+async function handleAnalyzeMigrations(args: JsonObject): Promise<MCPToolResult> {
+  const { projectId, directory, table } = args
+
+  try {
+    const project = await getOrCreateMCPProject(
+      typeof projectId === 'string' ? projectId : undefined,
+      typeof directory === 'string' ? directory : undefined,
+      [],
+      args.scope,
+    )
+
+    const migrations = listMigrations(project)
+    const tables = replayMigrations(migrations)
+
+    if (typeof table === 'string') {
+      const shape = tables.find(candidate => candidate.name.toLowerCase() === table.toLowerCase())
+      return {
+        content: [{
+          type: 'text',
+          text: JSON.stringify({
+            projectId: project.id,
+            table: shape ?? null,
+            ...(shape ? {} : { knownTables: tables.map(candidate => candidate.name) }),
+          }),
+        }],
+      }
+    }
+
+    return {
+      content: [{
+        type: 'text',
+        text: JSON.stringify({
+          projectId: project.id,
+          migrations: migrations.map(({ changes, ...migration }) => ({
+            ...migration,
+            changes: changes.map(change => ({ kind: change.kind, table: change.table, column: change.column, to: change.to, line: change.line })),
+          })),
+          tables: tables.map(shape => ({
+            name: shape.name,
+            columns: shape.columns.length,
+            createdIn: shape.createdIn,
+            lastTouchedIn: shape.lastTouchedIn,
+            droppedIn: shape.droppedIn,
+          })),
+          totalMigrations: migrations.length,
+        }),
+      }],
+    }
+  }
+  catch (error) {
+    throw handleError(error, 'Migration analysis failed')
+  }
+}
+
+async function handleLinkApiCalls(args: JsonObject): Promise<MCPToolResult> {
+  const { projectId, directory, unmatchedOnly = false } = args
+
+  try {
+    const project = await getOrCreateMCPProject(
+      typeof projectId === 'string' ? projectId : undefined,
+      typeof directory === 'string' ? directory : undefined,
+      [],
+      args.scope,
+    )
+
+    const report = linkApiCalls(project)
+
+    return {
+      content: [{
+        type: 'text',
+        text: JSON.stringify({
+          projectId: project.id,
+          ...(unmatchedOnly === true ? {} : { links: report.links }),
+          unmatchedCalls: report.unmatchedCalls,
+          unmatchedRoutes: report.unmatchedRoutes,
+          dynamicCalls: report.dynamicCalls,
+          summary: {
+            linkedCalls: report.links.length,
+            unmatchedCalls: report.unmatchedCalls.length,
+            unmatchedRoutes: report.unmatchedRoutes.length,
+            dynamicCalls: report.dynamicCalls.length,
+          },
+        }),
+      }],
+    }
+  }
+  catch (error) {
+    throw handleError(error, 'API call linking failed')
+  }
+}
+
+async function handleExportChunks(args: JsonObject): Promise<MCPToolResult> {
+  const { projectId, directory, pathPattern, maxTokens = 512, includeModuleCode = true, outputFile, maxChunks = 200 } = args
+
+  try {
+    const project = await getOrCreateMCPProject(
+      typeof projectId === 'string' ? projectId : undefined,
+      typeof directory === 'string' ? directory : undefined,
+      [],
+      args.scope,
+    )
+
+    const chunks = exportChunks(project, {
+      maxTokens: typeof maxTokens === 'number' ? maxTokens : 512,
+      includeModuleCode: includeModuleCode !== false,
+      pathPattern: typeof pathPattern === 'string' ? pathPattern : undefined,
+    })
+    const summary = {
+      projectId: project.id,
+      totalChunks: chunks.length,
+      files: new Set(chunks.map(chunk => chunk.path)).size,
+    }
+
+    if (typeof outputFile === 'string') {
+      const target = resolve(project.config.directory, outputFile)
+      await writeFile(target, formatChunksAsJsonl(chunks))
+      return { content: [{ type: 'text', text: JSON.stringify({ ...summary, outputFile: target }) }] }
+    }
+
+    // The summary first, then the chunks themselves as JSONL
+    const limit = typeof maxChunks === 'number' ? maxChunks : 200
+    return {
+      content: [
+        { type: 'text', text: JSON.stringify({ ...summary, returnedChunks: Math.min(limit, chunks.length) }) },
+        { type: 'text', text: formatChunksAsJsonl(chunks.slice(0, limit)) },
+      ],
+    }
+  }
+  catch (error) {
+    throw handleError(error, 'Chunk export failed')
+  }
+}
+
+async function handleExportIndex(args: JsonObject): Promise<MCPToolResult> {
+  const { projectId, directory, file } = args
+
+  if (typeof file !== 'string') {
+    throw new Error('File must be a string')
+  }
+
+  try {
+    const project = await getOrCreateMCPProject(
+      typeof projectId === 'string' ? projectId : undefined,
+      typeof directory === 'string' ? directory : undefined,
+      [],
+    )
+
+    const summary = await exportIndex(project, resolve(project.config.directory, file))
+    return { content: [{ type: 'text', text: JSON.stringify({ projectId: project.id, ...summary }) }] }
+  }
+  catch (error) {
+    throw handleError(error, 'Index export failed')
+  }
+}
+
+async function handleImportIndex(args: JsonObject): Promise<MCPToolResult> {
+  const { projectId, directory, file } = args
+
+  if (typeof file !== 'string') {
+    throw new Error('File must be a string')
+  }
+
+  try {
+    const actualDirectory = resolve(typeof directory === 'string' ? directory : process.cwd())
+    const { project, report } = await loadProjectFromIndex(mcpPersistentManager, resolve(actualDirectory, file), {
+      directory: actualDirectory,
+      autoWatch: process.env.NODE_ENV !== 'test',
+    }, typeof projectId === 'string' ? projectId : undefined)
+
+    return { content: [{ type: 'text', text: JSON.stringify({ projectId: project.id, ...report }) }] }
+  }
+  catch (error) {
+    throw handleError(error, 'Index import failed')
+  }
+}
+
+async function handleParseSnippet(args: JsonObject): Promise<MCPToolResult> {
+  const { content, language, filename } = args
+
+  if (typeof content !== 'string') {
+    throw new Error('Content must be a string')
+  }
+
+  try {
+    const analysis = analyzeSnippet(content, {
+      language: typeof language === 'string' ? language : undefined,
+      filename: typeof filename === 'string' ? filename : undefined,
+    })
+
+    return { content: [{ type: 'text', text: JSON.stringify(analysis) }] }
+  }
+  catch (error) {
+    throw handleError(error, 'Snippet parsing failed')
+  }
+}
+
+async function handleGetSymbolHistory(args: JsonObject): Promise<MCPToolResult> {
+  const { projectId, directory, name, file, maxCommits = 10, includeDiffs = true, maxDiffLines = 80 } = args
+
+  if (typeof name !== 'string') {
+    throw new Error('Name must be a string')
+  }
+
+  try {
+    const project = await getOrCreateMCPProject(
+      typeof projectId === 'string' ? projectId : undefined,
+      typeof directory === 'string' ? directory : undefined,
+      [],
+      args.scope,
+    )
+
+    const candidates = getAllNodes(project).filter(node => node.name === name
+      && HISTORY_SYMBOL_TYPES.has(node.type)
+      && (typeof file !== 'string' || node.path.includes(file)))
+
+    if (candidates.length === 0) {
+      throw new Error(`Symbol not found: ${name}`)
+    }
+    if (candidates.length > 1) {
+      const locations = candidates.map(node => `${relative(project.config.directory, node.path)}:${node.startLine}`)
+      throw new Error(`${name} is defined ${candidates.length} times (${locations.join(', ')}); pass file to pick one`)
+    }
+
+    const symbol = candidates[0]!
+    const commits = await getSymbolHistory(symbol, {
+      maxCommits: Number(maxCommits),
+      includeDiffs: Boolean(includeDiffs),
+      maxDiffLines: Number(maxDiffLines),
+    })
+
+    return {
+      content: [{
+        type: 'text',
+        text: JSON.stringify({
+          projectId: project.id,
+          symbol: {
+            name: symbol.name,
+            type: symbol.type,
+            path: symbol.path,
+            startLine: symbol.startLine,
+            endLine: symbol.endLine,
+          },
+          commits,
+          returnedCommits: commits.length,
+        }),
+      }],
+    }
+  }
+  catch (error) {
+    throw handleError(error, 'Symbol history failed')
+  }
+}
+
+async function handleFindOwner(args: JsonObject): Promise<MCPToolResult> {
+  const { projectId, directory, path, symbol } = args
+
+  if (typeof path !== 'string' && typeof symbol !== 'string') {
+    throw new Error('Either path or symbol must be a string')
+  }
+
+  try {
+    const project = typeof symbol === 'string'
+      ? await getOrCreateMCPProject(
+        typeof projectId === 'string' ? projectId : undefined,
+        typeof directory === 'string' ? directory : undefined,
+        [],
+        args.scope,
+      )
+      : undefined
+    const root = project?.config.directory ?? resolve(resolveMCPLocation(
+      typeof projectId === 'string' ? projectId : undefined,
+      typeof directory === 'string' ? directory : undefined,
+    ).actualDirectory)
+
+    const codeOwners = loadCodeOwners(root)
+    if (!codeOwners) {
+      throw new Error(`No CODEOWNERS file in ${root} (looked for ${PROJECT_FILES.CODEOWNERS.join(', ')})`)
+    }
+
+    const results: JsonObject[] = []
+    if (typeof path === 'string') {
+      const { owners, rule } = findOwners(codeOwners, path)
+      results.push({ path: relative(root, resolve(root, path)), owners, rule: rule ? { pattern: rule.pattern, line: rule.line } : null })
+    }
+    if (project && typeof symbol === 'string') {
+      const matches = getAllNodes(project).filter(node => node.name === symbol && node.type !== 'file' && node.type !== 'parameter')
+      if (matches.length === 0) {
+        throw new Error(`Symbol not found: ${symbol}`)
+      }
+      for (const node of matches) {
+        const { owners, rule } = findOwners(codeOwners, node.path)
+        results.push({
+          symbol: { name: symbol, type: node.type, startLine: node.startLine ?? null },
+          path: relative(root, node.path),
+          owners,
+          rule: rule ? { pattern: rule.pattern, line: rule.line } : null,
+        })
+      }
+    }
+
+    return {
+      content: [{
+        type: 'text',
+        text: JSON.stringify({ codeOwnersFile: relative(root, codeOwners.file), results }),
+      }],
+    }
+  }
+  catch (error) {
+    throw handleError(error, 'Find owner failed')
+  }
+}
+
+async function handleResolveSymbol(args: JsonObject): Promise<MCPToolResult> {
+  const { projectId, directory, name, types, maxResults = 50 } = args
+
+  if (typeof name !== 'string' || !name) {
+    throw new Error('Name must be a non-empty string')
+  }
+
+  try {
+    const project = await getOrCreateMCPProject(
+      typeof projectId === 'string' ? projectId : undefined,
+      typeof directory === 'string' ? directory : undefined,
+      [],
+      args.scope,
+    )
+
+    const candidates = findSymbolCandidates(project, name, Array.isArray(types) ? types as string[] : [])
+    // `utils.FormatDate` names a module member rather than a container member
+    for (const { node } of findAliasedDefinitions(project, name)) {
+      const candidate = symbolCandidate(project, node)
+      if (!candidates.some(existing => existing.id === candidate.id)) candidates.push(candidate)
+    }
+
+    return {
+      content: [{
+        type: 'text',
+        text: JSON.stringify({
+          projectId: project.id,
+          name,
+          ambiguous: candidates.length > 1,
+          candidates: candidates.slice(0, Number(maxResults)),
+          totalCandidates: candidates.length,
+          didYouMean: candidates.length === 0 ? didYouMean(name, getAllNodes(project), []) : undefined,
+        }),
+      }],
+    }
+  }
+  catch (error) {
+    throw handleError(error, 'Symbol resolution failed')
+  }
+}
+
+async function handleFindDefinition(args: JsonObject): Promise<MCPToolResult> {
+  const { projectId, directory, file, line, column, precise = true } = args
+
+  if (typeof file !== 'string' || !file) {
+    throw new Error('File must be a non-empty string')
+  }
+  if (typeof line !== 'number' || typeof column !== 'number' || line < 1 || column < 1) {
+    throw new Error('Line and column must be 1-based numbers')
+  }
+
+  try {
+    const project = await getOrCreateMCPProject(
+      typeof projectId === 'string' ? projectId : undefined,
+      typeof directory === 'string' ? directory : undefined,
+      [],
+      args.scope,
+    )
+    const root = project.config.directory
+    const lookup = await findDefinitions(project, resolve(root, file), line, column, {
+      // A read-only server runs no programs on behalf of the projects it serves
+      precise: Boolean(precise) && !readOnlyMode,
+      settings: languageServerOptions(root),
+    })
+    if (!lookup.name) {
+      throw new Error(`No identifier at ${file}:${line}:${column}`)
+    }
+
+    return {
+      content: [{
+        type: 'text',
+        text: JSON.stringify({
+          projectId: project.id,
+          ...lookup,
+          definitions: lookup.definitions.map(definition => ({ ...definition, path: relative(root, definition.path) })),
+          otherCandidates: lookup.otherCandidates.map(definition => ({ ...definition, path: relative(root, definition.path) })),
+        }),
+      }],
+    }
+  }
+  catch (error) {
+    throw handleError(error, 'Definition lookup failed')
+  }
+}
+
+// The project may turn servers off or give them longer; what runs is the server's choice
+function languageServerOptions(root: string): LanguageServerOptions {
+  const { enabled, timeoutMs } = loadProjectSettings(root).languageServers ?? {}
+  return { enabled, timeoutMs, commands: languageServerCommands }
+}
+
+async function handleGetTypeInfo(args: JsonObject): Promise<MCPToolResult> {
+  const { projectId, directory, file, line, column, precise = true } = args
+
+  if (typeof file !== 'string' || !file) {
+    throw new Error('File must be a non-empty string')
+  }
+  if (typeof line !== 'number' || typeof column !== 'number' || line < 1 || column < 1) {
+    throw new Error('Line and column must be 1-based numbers')
+  }
+
+  try {
+    const project = await getOrCreateMCPProject(
+      typeof projectId === 'string' ? projectId : undefined,
+      typeof directory === 'string' ? directory : undefined,
+      [],
+      args.scope,
+    )
+    const root = project.config.directory
+    const info = await getTypeInfo(project, resolve(root, file), line, column, {
+      // A read-only server runs no programs on behalf of the projects it serves
+      precise: Boolean(precise) && !readOnlyMode,
+      settings: languageServerOptions(root),
+    })
+    if (!info.name) {
+      throw new Error(`No identifier at ${file}:${line}:${column}`)
+    }
+
+    return {
+      content: [{
+        type: 'text',
+        text: JSON.stringify({
+          projectId: project.id,
+          ...info,
+          ...(info.declaredType?.path ? { declaredType: { ...info.declaredType, path: relative(root, info.declaredType.path) } } : {}),
+          typeDefinitions: info.typeDefinitions.map(definition => ({ ...definition, path: relative(root, definition.path) })),
+        }),
+      }],
+    }
+  }
+  catch (error) {
+    throw handleError(error, 'Type lookup failed')
+  }
+}
+
+async function handleGetConstantValues(args: JsonObject): Promise<MCPToolResult> {
+  const { projectId, directory, name, value, includeUsages = true, maxResults = 100 } = args
+
+  try {
+    const project = await getOrCreateMCPProject(
+      typeof projectId === 'string' ? projectId : undefined,
+      typeof directory === 'string' ? directory : undefined,
+      [],
+      args.scope,
+    )
+
+    const all = listConstantValues(project, {
+      name: typeof name === 'string' ? name : undefined,
+      // A numeric value such as 3 is matched like "3"
+      value: typeof value === 'string' || typeof value === 'number' ? String(value) : undefined,
+    })
+    const selected = all.slice(0, Number(maxResults))
+    const constants = includeUsages
+      ? withConstantUsages(selected, getAllNodes(project).filter(node => node.type === 'file'))
+      : selected
+
+    return {
+      content: [{
+        type: 'text',
+        text: JSON.stringify({
+          projectId: project.id,
+          constants,
+          totalConstants: all.length,
+        }),
+      }],
+    }
+  }
+  catch (error) {
+    throw handleError(error, 'Constant value lookup failed')
+  }
+}
+
+async function handleFindSimilar(args: JsonObject): Promise<MCPToolResult> {
+  const { projectId, directory, code, language, maxResults = 10, minSimilarity = 50 } = args
+
+  if (typeof code !== 'string' || !code.trim()) {
+    throw new Error('Code is required and must be a non-empty string')
+  }
+
+  try {
+    const project = await getOrCreateMCPProject(
+      typeof projectId === 'string' ? projectId : undefined,
+      typeof directory === 'string' ? directory : undefined,
+      [],
+      args.scope,
+    )
+
+    const results = findSimilarCode(project, code, {
+      language: typeof language === 'string' ? language : undefined,
+      maxResults: Number(maxResults),
+      minSimilarity: Number(minSimilarity),
+    })
+
+    return {
+      content: [{
+        type: 'text',
+        text: JSON.stringify({
+          projectId: project.id,
+          results: results.map(({ node, similarity }) => ({
+            id: symbolId(project.config.directory, node),
+            name: node.name,
+            type: node.type,
+            path: node.path,
+            startLine: node.startLine,
+            endLine: node.endLine,
+            signature: node.symbol?.signature,
+            similarity,
+          })),
+          totalResults: results.length,
+        }),
+      }],
+    }
+  }
+  catch (error) {
+    throw handleError(error, 'Similar code search failed')
+  }
+}
+
+async function handleGetContextPack(args: JsonObject): Promise<MCPToolResult> {
+  const { projectId, directory, symbol, maxTokens = 4000 } = args
+
+  if (typeof symbol !== 'string' || !symbol.trim()) {
+    throw new Error('Symbol is required and must be a non-empty string')
+  }
+
+  try {
+    const project = await getOrCreateMCPProject(
+      typeof projectId === 'string' ? projectId : undefined,
+      typeof directory === 'string' ? directory : undefined,
+      [],
+      args.scope,
+    )
+
+    const pack = buildContextPack(project, symbol, { maxTokens: Number(maxTokens) })
+
+    return {
+      content: [{
+        type: 'text',
+        text: JSON.stringify({
+          projectId: project.id,
+          ...pack,
+        }),
+      }],
+    }
+  }
+  catch (error) {
+    throw handleError(error, 'Context pack failed')
+  }
+}
+
+async function handleSummarizeArchitecture(args: JsonObject): Promise<MCPToolResult> {
+  const { projectId, directory, depth = 2, maxKeyTypes = 15 } = args
+
+  try {
+    const project = await getOrCreateMCPProject(
+      typeof projectId === 'string' ? projectId : undefined,
+      typeof directory === 'string' ? directory : undefined,
+      [],
+      args.scope,
+    )
+
+    const summary = summarizeArchitecture(project, { depth: Number(depth), maxKeyTypes: Number(maxKeyTypes) })
+
+    return {
+      content: [{
+        type: 'text',
+        text: JSON.stringify({
+          projectId: project.id,
+          ...summary,
+        }),
+      }],
+    }
+  }
+  catch (error) {
+    throw handleError(error, 'Architecture summary failed')
+  }
+}
+
+async function handleListEntryPoints(args: JsonObject): Promise<MCPToolResult> {
+  const { projectId, directory, kinds = [] } = args
+
+  if (!Array.isArray(kinds)) {
+    throw new Error('Kinds must be an array')
+  }
+  const unknown = kinds.find(kind => !ENTRY_POINT_KINDS.includes(kind as EntryPointKind))
+  if (unknown !== undefined) {
+    throw new Error(`Unknown entry point kind: ${String(unknown)}. Use any of: ${ENTRY_POINT_KINDS.join(', ')}`)
+  }
+
+  try {
+    const project = await getOrCreateMCPProject(
+      typeof projectId === 'string' ? projectId : undefined,
+      typeof directory === 'string' ? directory : undefined,
+      [],
+      args.scope,
+    )
+
+    const entryPoints = listEntryPoints(project, kinds.length > 0 ? kinds as EntryPointKind[] : undefined)
+
+    return {
+      content: [{
+        type: 'text',
+        text: JSON.stringify({
+          projectId: project.id,
+          entryPoints,
+          totalEntryPoints: entryPoints.length,
+        }),
+      }],
+    }
+  }
+  catch (error) {
+    throw handleError(error, 'Entry point detection failed')
+  }
+}
+
+async function handleMapKubernetes(args: JsonObject): Promise<MCPToolResult> {
+  const { projectId, directory, name } = args
+
+  try {
+    const project = await getOrCreateMCPProject(
+      typeof projectId === 'string' ? projectId : undefined,
+      typeof directory === 'string' ? directory : undefined,
+      [],
+      args.scope,
+    )
+
+    const map = mapKubernetes(project, typeof name === 'string' ? name : undefined)
+
+    return {
+      content: [{
+        type: 'text',
+        text: JSON.stringify({
+          projectId: project.id,
+          ...map,
+          totalObjects: map.workloads.length + map.services.length + map.configs.length,
+        }),
+      }],
+    }
+  }
+  catch (error) {
+    throw handleError(error, 'Kubernetes mapping failed')
+  }
+}
+
+async function handleMapSpringBeans(args: JsonObject): Promise<MCPToolResult> {
+  const { projectId, directory, name } = args
+
+  try {
+    const project = await getOrCreateMCPProject(
+      typeof projectId === 'string' ? projectId : undefined,
+      typeof directory === 'string' ? directory : undefined,
+      [],
+      args.scope,
+    )
+
+    const beans = mapSpringBeans(project, typeof name === 'string' ? name : undefined)
+
+    return {
+      content: [{
+        type: 'text',
+        text: JSON.stringify({
+          projectId: project.id,
+          beans,
+          totalBeans: beans.length,
+          unresolved: beans.flatMap(bean => bean.dependencies.filter(dependency => dependency.beans.length === 0).map(dependency => ({ bean: bean.name, type: dependency.type, name: dependency.name, line: dependency.line, path: bean.path }))),
+        }),
+      }],
+    }
+  }
+  catch (error) {
+    throw handleError(error, 'Spring bean mapping failed')
+  }
+}
+
+async function handleMapFlutterWidgets(args: JsonObject): Promise<MCPToolResult> {
+  const { projectId, directory, name } = args
+
+  try {
+    const project = await getOrCreateMCPProject(
+      typeof projectId === 'string' ? projectId : undefined,
+      typeof directory === 'string' ? directory : undefined,
+      [],
+      args.scope,
+    )
+
+    const widgets = mapFlutterWidgets(project, typeof name === 'string' ? name : undefined)
+
+    return {
+      content: [{
+        type: 'text',
+        text: JSON.stringify({
+          projectId: project.id,
+          widgets,
+          totalWidgets: widgets.length,
+          // Widgets no other project widget creates: screens, routes and the app itself
+          roots: widgets.filter(widget => widget.usedBy.length === 0).map(widget => widget.name),
+        }),
+      }],
+    }
+  }
+  catch (error) {
+    throw handleError(error, 'Flutter widget mapping failed')
+  }
+}
+
+async function handleDocsFor(args: JsonObject): Promise<MCPToolResult> {
+  const { projectId, directory, symbol, maxResults = 20 } = args
+
+  if (typeof symbol !== 'string' || !symbol.trim()) {
+    throw new Error('Symbol must be a non-empty string')
+  }
+
+  try {
+    const project = await getOrCreateMCPProject(
+      typeof projectId === 'string' ? projectId : undefined,
+      typeof directory === 'string' ? directory : undefined,
+      [],
+      args.scope,
+    )
+
+    const { definitions, sections } = findDocsFor(project, symbol.trim(), typeof maxResults === 'number' ? maxResults : 20)
+
+    return {
+      content: [{
+        type: 'text',
+        text: JSON.stringify({
+          projectId: project.id,
+          symbol: symbol.trim(),
+          definitions,
+          sections,
+          totalSections: sections.length,
+        }),
+      }],
+    }
+  }
+  catch (error) {
+    throw handleError(error, 'Documentation lookup failed')
+  }
+}
+
+async function handleEditAtSymbol(args: JsonObject): Promise<MCPToolResult> {
+  const { projectId, directory, symbol, text, position = 'replace', dryRun = false, allowSyntaxErrors, format } = args
+
+  if (typeof symbol !== 'string' || !symbol) {
+    throw new Error('Symbol must be a non-empty string')
+  }
+  if (typeof text !== 'string') {
+    throw new Error('Text must be a string')
+  }
+  if (!EDIT_POSITIONS.includes(position as EditPosition)) {
+    throw new Error(`Unknown position: ${String(position)}. Use one of: ${EDIT_POSITIONS.join(', ')}`)
+  }
+
+  try {
+    const project = await getOrCreateMCPProject(
+      typeof projectId === 'string' ? projectId : undefined,
+      typeof directory === 'string' ? directory : undefined,
+    )
+
+    const node = resolveEditTarget(project, symbol)
+    const plan = planEdits('edit_at_symbol', project.id, project.config.directory, [editAtSymbol(node, text, position as EditPosition)], {
+      // Formatters are programs too, which a read-only server doesn't run
+      format: !readOnlyMode && (typeof format === 'boolean' ? format : formatEdits),
+    })
+    return await editResult(project, plan, dryRun === true, allowSyntaxErrors)
+  }
+  catch (error) {
+    throw handleError(error, 'Symbol edit failed')
+  }
+}
+
+async function handleApplyEdit(args: JsonObject): Promise<MCPToolResult> {
+  const { planId, allowSyntaxErrors, directory } = args
+
+  if (typeof planId !== 'string' || !planId) {
+    throw new Error('Plan ID must be a non-empty string')
+  }
+
+  try {
+    const plan = getEditPlan(planId)
+    // A tenant's call names the project it was granted, and only that project's plans apply
+    if (typeof directory === 'string' && resolve(directory) !== resolve(plan.directory)) {
+      throw createError('PERMISSION_DENIED', `Edit plan ${planId} was made for another project`)
+    }
+    const project = await getOrCreateMCPProject(plan.projectId, plan.directory)
+    return await editResult(project, plan, false, allowSyntaxErrors)
+  }
+  catch (error) {
+    throw handleError(error, 'Applying edit failed')
+  }
+}
+
+async function handleListEdits(args: JsonObject): Promise<MCPToolResult> {
+  const { projectId, directory, maxResults = 20 } = args
+
+  try {
+    const project = await getOrCreateMCPProject(
+      typeof projectId === 'string' ? projectId : undefined,
+      typeof directory === 'string' ? directory : undefined,
+    )
+
+    const edits = listEdits(project.config.directory)
+    return {
+      content: [{
+        type: 'text',
+        text: JSON.stringify({ projectId: project.id, edits: edits.slice(0, Number(maxResults)), totalEdits: edits.length }),
+      }],
+    }
+  }
+  catch (error) {
+    throw handleError(error, 'Listing edits failed')
+  }
+}
+
+async function handleUndoLastEdit(args: JsonObject): Promise<MCPToolResult> {
+  const { projectId, directory, force = false, dryRun = false } = args
+
+  try {
+    const project = await getOrCreateMCPProject(
+      typeof projectId === 'string' ? projectId : undefined,
+      typeof directory === 'string' ? directory : undefined,
+    )
+
+    const plan = planUndo(project.id, project.config.directory, force === true)
+    return await editResult(project, plan, dryRun === true)
+  }
+  catch (error) {
+    throw handleError(error, 'Undo failed')
+  }
+}
+
+/**
+ * Applies a plan unless this is a dry run, re-indexing the files it wrote, and reports its diff
+ * with the syntax errors it introduces. Whether those block the edit comes from the call, or
+ * else from `edits.allowSyntaxErrors` in the project settings.
+ */
+async function editResult(project: Project, plan: EditPlan, dryRun: boolean, allowSyntaxErrors?: JsonValue): Promise<MCPToolResult> {
+  if (!dryRun) {
+    const allow = typeof allowSyntaxErrors === 'boolean'
+      ? allowSyntaxErrors
+      : loadProjectSettings(project.config.directory).edits?.allowSyntaxErrors === true
+    await updateProject(project, applyEditPlan(plan, { allowSyntaxErrors: allow }))
+  }
+
+  return {
+    content: [{
+      type: 'text',
+      text: JSON.stringify({ ...describeEditPlan(plan), dryRun, applied: !dryRun }),
+    }],
+  }
+}
+
+interface BatchCall {
+  id: string
+  tool: string
+  arguments: JsonObject
+}
+
+async function handleBatch(args: JsonObject): Promise<MCPToolResult> {
+  const { calls, parallel = false } = args
+
+  if (!Array.isArray(calls) || calls.length === 0) {
+    throw new Error('Calls must be a non-empty array')
+  }
+  if (calls.length > MAX_BATCH_CALLS) {
+    throw new Error(`A batch accepts at most ${MAX_BATCH_CALLS} calls, got ${calls.length}`)
+  }
+
+  const batch = calls.map(toBatchCall)
+  const ids = new Set<string>()
+  for (const call of batch) {
+    if (ids.has(call.id)) throw new Error(`Duplicate call id: ${call.id}`)
+    ids.add(call.id)
+  }
+
+  const outcomes: JsonObject[] = []
+  if (parallel === true) {
+    outcomes.push(...await Promise.all(batch.map(runBatchCall)))
+  }
+  else {
+    for (const call of batch) {
+      outcomes.push(await runBatchCall(call))
+    }
+  }
+
+  const results: JsonObject = {}
+  batch.forEach((call, index) => {
+    results[call.id] = outcomes[index]!
+  })
+  const failed = outcomes.filter(outcome => outcome.ok === false).length
+
+  return {
+    content: [{
+      type: 'text',
+      text: JSON.stringify({ results, succeeded: outcomes.length - failed, failed }),
+    }],
+  }
+}
+
+function toBatchCall(call: JsonValue, index: number): BatchCall {
+  if (!call || typeof call !== 'object' || Array.isArray(call) || typeof call.tool !== 'string') {
+    throw new Error(`Call ${index} must be an object with a tool name`)
+  }
+  if (call.tool === 'batch') {
+    throw new Error('Batches cannot be nested')
+  }
+  if (call.arguments !== undefined && (!call.arguments || typeof call.arguments !== 'object' || Array.isArray(call.arguments))) {
+    throw new Error(`Arguments of call ${index} must be an object`)
+  }
+
+  return {
+    id: call.id === undefined ? String(index) : String(call.id),
+    tool: call.tool,
+    arguments: call.arguments ?? {},
+  }
+}
+
+async function runBatchCall(call: BatchCall): Promise<JsonObject> {
+  try {
+    const result = await handleToolRequest({ params: { name: call.tool, arguments: call.arguments } })
+    const values = result.content.map(item => parseToolText(item.text))
+    return { tool: call.tool, ok: true, result: values.length === 1 ? values[0]! : values }
+  }
+  catch (error) {
+    return { tool: call.tool, ok: false, error: error instanceof Error ? error.message : String(error) }
+  }
+}
+
+function parseToolText(text: string): JsonValue {
+  try {
+    return JSON.parse(text) as JsonValue
+  }
+  catch {
+    return text
+  }
+}
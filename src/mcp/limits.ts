@@ -0,0 +1,90 @@
+/**
+ * Per-session call limits - caps on concurrent tool calls and on calls per minute, so a client
+ * stuck in a loop cannot take over the host. A refused call fails with a THROTTLED error that
+ * says which limit it hit and when to retry.
+ */
+
+import { createError, TreeSitterError } from '../utils/errors.js'
+import type { JsonObject } from '../types/core.js'
+
+export interface CallLimits {
+  maxConcurrentCalls?: number
+  maxCallsPerMinute?: number // Each call of a batch counts
+  maxFilesPerCall?: number // Files a call may index when it has to index a project first
+}
+
+export interface CallSession {
+  limits: CallLimits
+  active: number
+  recent: number[] // Start times of the calls of the last minute, one entry per counted call
+}
+
+export type ThrottleReason = 'concurrency' | 'rate' | 'files'
+
+const WINDOW_MS = 60_000
+
+export function createCallSession(limits: CallLimits): CallSession {
+  return { limits, active: 0, recent: [] }
+}
+
+/**
+ * Runs a tool call within the session's limits, or throws THROTTLED without running it
+ */
+export async function runLimitedCall<T>(session: CallSession, name: string, args: JsonObject, call: () => Promise<T>, now = Date.now()): Promise<T> {
+  const { maxConcurrentCalls, maxCallsPerMinute } = session.limits
+  if (maxConcurrentCalls !== undefined && session.active >= maxConcurrentCalls) {
+    throw throttled('concurrency', maxConcurrentCalls, `${session.active} tool calls are already running, the most this session allows`)
+  }
+
+  session.recent = session.recent.filter(start => start > now - WINDOW_MS)
+  const weight = name === 'batch' && Array.isArray(args.calls) ? Math.max(args.calls.length, 1) : 1
+  const excess = maxCallsPerMinute === undefined ? 0 : session.recent.length + weight - maxCallsPerMinute
+  if (excess > 0) {
+    // The call fits once the oldest `excess` calls leave the window; a batch larger than the budget never does
+    const freedAt = session.recent[excess - 1]
+    throw throttled('rate', maxCallsPerMinute!, `The session made ${session.recent.length} tool calls in the last minute; it allows ${maxCallsPerMinute}`,
+      freedAt === undefined ? undefined : freedAt + WINDOW_MS - now)
+  }
+
+  session.recent.push(...Array<number>(weight).fill(now))
+  session.active++
+  try {
+    return await call()
+  }
+  finally {
+    session.active--
+  }
+}
+
+/**
+ * Whether a session has no running calls and none left in its window, so it can be dropped
+ */
+export function isIdleSession(session: CallSession, now = Date.now()): boolean {
+  return session.active === 0 && session.recent.every(start => start <= now - WINDOW_MS)
+}
+
+/**
+ * A THROTTLED error; `retryAfterMs` is when the call may succeed, when that is known
+ */
+export function throttled(reason: ThrottleReason, limit: number, message: string, retryAfterMs?: number): TreeSitterError {
+  return createError('THROTTLED', `Throttled: ${message}`, {
+    reason,
+    limit,
+    ...(retryAfterMs !== undefined ? { retryAfterMs: Math.max(0, Math.ceil(retryAfterMs)) } : {}),
+  })
+}
+
+export function isThrottled(error: unknown): error is TreeSitterError {
+  return error instanceof TreeSitterError && error.code === 'THROTTLED'
+}
+
+/**
+ * How a throttled call is reported to MCP clients: a tool error whose text is JSON, so a
+ * client can read the reason and back off
+ */
+export function throttledResult(error: TreeSitterError): { isError: true, content: Array<{ type: 'text', text: string }> } {
+  return {
+    isError: true,
+    content: [{ type: 'text', text: JSON.stringify({ error: 'throttled', message: error.message, ...error.context }) }],
+  }
+}
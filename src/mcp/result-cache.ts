@@ -0,0 +1,123 @@
+/**
+ * Tool result cache - answers a repeated read-only call from the result of the identical
+ * earlier one while the index it was computed from is unchanged. Entries are kept per
+ * project and keyed by tool and arguments; any new index generation of the project, its
+ * sub-projects or the projects a call includes makes them stale.
+ */
+
+import { recordCacheHit } from './stats.js'
+import type { MCPToolResult } from './handlers.js'
+import type { JsonObject, JsonValue, Project } from '../types/core.js'
+
+export interface ResultCacheOptions {
+  maxEntries?: number // Per project; the least recently used entry is dropped first
+  maxAgeMs?: number // Bounds staleness from inputs outside the index, such as CODEOWNERS
+}
+
+export interface ResultCache {
+  maxEntries: number
+  maxAgeMs: number
+  projects: WeakMap<Project, Map<string, CachedResult>>
+  hits: number
+  misses: number
+}
+
+interface CachedResult {
+  fingerprint: string
+  storedAt: number
+  result: MCPToolResult
+}
+
+// Tools whose results follow from the index and the arguments alone. analyze_code is left out:
+// its reports carry the time they were made, and rollups compare against the previous request.
+const CACHEABLE_TOOLS = new Set(['search_code', 'find_usage', 'resolve_symbol', 'find_definition', 'get_type_info'])
+
+const DEFAULT_MAX_ENTRIES = 200
+const DEFAULT_MAX_AGE_MS = 5 * 60_000
+
+export function createResultCache(options: ResultCacheOptions = {}): ResultCache {
+  return {
+    maxEntries: options.maxEntries ?? DEFAULT_MAX_ENTRIES,
+    maxAgeMs: options.maxAgeMs ?? DEFAULT_MAX_AGE_MS,
+    projects: new WeakMap(),
+    hits: 0,
+    misses: 0,
+  }
+}
+
+/**
+ * Whether a call may be answered from the cache. Searches at a git ref depend on more than
+ * the index.
+ */
+export function isCacheableCall(name: string, args: JsonObject): boolean {
+  if (!CACHEABLE_TOOLS.has(name)) return false
+  if (name === 'search_code' && typeof args.searchAtRef === 'string' && args.searchAtRef) return false
+  return true
+}
+
+/**
+ * The index generations a result depends on
+ */
+export function indexFingerprint(projects: Project[]): string {
+  const generations: number[] = []
+  const collect = (project: Project) => {
+    generations.push(project.generation ?? 0)
+    for (const subProject of project.subProjects ?? []) collect(subProject)
+  }
+  projects.forEach(collect)
+  return generations.join('.')
+}
+
+/**
+ * Runs a call through the cache. `dependencies` are the loaded projects the result is
+ * computed from, the call's project first, or none when it is not indexed yet. They are read
+ * before and after the call; a result computed while the index changed is not stored.
+ */
+export async function cachedToolCall(
+  cache: ResultCache,
+  dependencies: () => Project[],
+  name: string,
+  args: JsonObject,
+  call: () => Promise<MCPToolResult>,
+  now = Date.now(),
+): Promise<MCPToolResult> {
+  const key = `${name}:${stableStringify(args)}`
+  const before = dependencies()
+  const fingerprint = indexFingerprint(before)
+  const cached = before[0] && cache.projects.get(before[0])?.get(key)
+  if (cached && cached.fingerprint === fingerprint && now - cached.storedAt <= cache.maxAgeMs) {
+    // Re-inserting keeps the map in least recently used order
+    const entries = cache.projects.get(before[0]!)!
+    entries.delete(key)
+    entries.set(key, cached)
+    cache.hits++
+    recordCacheHit()
+    return copyResult(cached.result)
+  }
+
+  cache.misses++
+  const result = await call()
+  // A call that indexed its project is stored against the index it built
+  const after = dependencies()
+  if (!after[0] || result.isError || (before.length > 0 && (after[0] !== before[0] || indexFingerprint(after) !== fingerprint))) return result
+
+  let entries = cache.projects.get(after[0])
+  if (!entries) cache.projects.set(after[0], entries = new Map())
+  entries.delete(key)
+  entries.set(key, { fingerprint: indexFingerprint(after), storedAt: now, result: copyResult(result) })
+  while (entries.size > cache.maxEntries) entries.delete(entries.keys().next().value!)
+  return result
+}
+
+function copyResult(result: MCPToolResult): MCPToolResult {
+  return { ...result, content: result.content.map(item => ({ ...item })) }
+}
+
+// JSON with object keys sorted, so argument order does not matter
+function stableStringify(value: JsonValue): string {
+  if (Array.isArray(value)) return `[${value.map(stableStringify).join(',')}]`
+  if (value && typeof value === 'object') {
+    return `{${Object.keys(value).sort().filter(key => value[key] !== undefined).map(key => `${JSON.stringify(key)}:${stableStringify(value[key]!)}`).join(',')}}`
+  }
+  return JSON.stringify(value)
+}
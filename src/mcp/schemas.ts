@@ -2,16 +2,26 @@
  * MCP tool and resource schemas - consistent parameter support across all tools
  */
 
+// Every tool carries MCP tool annotations: `readOnlyHint: false` marks the ones that write
+// files, which a server started with `--read-only` refuses
+
 export const MCP_TOOLS = [
   {
     name: 'search_code',
     description: 'Search for functions, classes, variables, and other code elements with fuzzy matching',
+    annotations: { readOnlyHint: true, openWorldHint: false },
     inputSchema: {
       type: 'object',
       properties: {
         query: {
           type: 'string',
-          description: 'Search query (name of element)',
+          description: 'Search query (name of element), or a symbol id from an earlier result to get exactly that declaration. In symbols mode the query may combine kind:, lang:, name:, path: and caller: terms with OR, - (NOT) and parentheses, e.g. "kind:function lang:go name:~Handler -path:vendor"; name: matches the whole name, name:~ part of it, and * is a wildcard. @name refers to a query saved under queries in .tree-sitter-mcp.json',
+        },
+        mode: {
+          type: 'string',
+          enum: ['symbols', 'strings', 'ui', 'comments'],
+          description: 'What to search: code element names (symbols), the text of string literals (strings), only user-facing text such as JSX text, template strings and attributes like placeholder (ui), or comments and docstrings (comments). Literals with placeholders (`User ${id} not found`, "%s failed") match the rendered text',
+          default: 'symbols',
         },
         projectId: {
           type: 'string',
@@ -21,10 +31,35 @@ export const MCP_TOOLS = [
           type: 'string',
           description: 'Optional: Directory to search (default: current working directory)',
         },
+        scope: {
+          type: 'string',
+          description: 'Optional: Named scope from .tree-sitter-mcp.json restricting the tool to its files (e.g., "backend")',
+        },
         pathPattern: {
           type: 'string',
           description: 'Optional: Filter results to files containing this text in their path (e.g., "server", "client", "components")',
         },
+        target: {
+          type: 'string',
+          description: 'Optional: Restrict results to the sources of a Bazel/Buck target (e.g., "//services/api:server")',
+        },
+        searchAtRef: {
+          type: 'string',
+          description: 'Optional: Search the code as it was at this git ref (branch, tag or commit, e.g., "v2.1") instead of the working tree',
+        },
+        buildTags: {
+          type: 'string',
+          description: 'Optional: Only Go files that build with these tags, from //go:build lines and _GOOS/_GOARCH file suffixes (e.g., "linux", "windows/amd64", "linux,integration"). An OS or architecture left out matches any',
+        },
+        defines: {
+          type: 'string',
+          description: 'Optional: Only C/C++ declarations compiled with these defines, from their #if/#ifdef/#elif/#else guards (e.g., "_WIN32,DEBUG", "-DVERSION=2"). Other names count as undefined; guards that can\'t be decided are kept',
+        },
+        includeProjects: {
+          type: 'array',
+          items: { type: 'string' },
+          description: 'Optional: IDs of other registered projects (e.g., remote repositories) to search alongside this one',
+        },
         maxResults: {
           type: 'number',
           description: 'Maximum number of results',
@@ -45,6 +80,11 @@ export const MCP_TOOLS = [
           items: { type: 'string' },
           description: 'Filter by element types (function, class, variable, etc.)',
         },
+        includeStats: {
+          type: 'boolean',
+          description: 'Optional: Add a stats object to the response with timing, files scanned, result cache hits and the filters applied',
+          default: false,
+        },
       },
       required: ['query'],
     },
@@ -52,12 +92,13 @@ export const MCP_TOOLS = [
   {
     name: 'find_usage',
     description: 'Find all usages of a function, variable, class, or identifier',
+    annotations: { readOnlyHint: true, openWorldHint: false },
     inputSchema: {
       type: 'object',
       properties: {
         identifier: {
           type: 'string',
-          description: 'Function, variable, class, or identifier name to find usage of',
+          description: 'Function, variable, class, or identifier name to find usage of, or a symbol id (from search_code or resolve_symbol) to find usages of that declaration only',
         },
         projectId: {
           type: 'string',
@@ -67,10 +108,23 @@ export const MCP_TOOLS = [
           type: 'string',
           description: 'Optional: Directory to search (default: current working directory)',
         },
+        scope: {
+          type: 'string',
+          description: 'Optional: Named scope from .tree-sitter-mcp.json restricting the tool to its files (e.g., "backend")',
+        },
         pathPattern: {
           type: 'string',
           description: 'Optional: Filter results to files containing this text in their path (e.g., "server", "client", "components")',
         },
+        target: {
+          type: 'string',
+          description: 'Optional: Restrict results to the sources of a Bazel/Buck target (e.g., "//services/api:server")',
+        },
+        includeProjects: {
+          type: 'array',
+          items: { type: 'string' },
+          description: 'Optional: IDs of other registered projects (e.g., remote repositories) to search alongside this one',
+        },
         caseSensitive: {
           type: 'boolean',
           description: 'Case sensitive search',
@@ -81,11 +135,26 @@ export const MCP_TOOLS = [
           description: 'Require exact identifier match (word boundaries)',
           default: true,
         },
+        comments: {
+          type: 'string',
+          enum: ['include', 'exclude', 'only'],
+          description: 'Matches inside comments and docstrings: include them, exclude them to get code references only, or return only those',
+          default: 'include',
+        },
+        typeArguments: {
+          type: 'string',
+          description: 'Optional: For a Go generic, only return instantiations with these explicit type arguments (e.g., "string, int")',
+        },
         maxResults: {
           type: 'number',
           description: 'Maximum number of results',
           default: 50,
         },
+        includeStats: {
+          type: 'boolean',
+          description: 'Optional: Add a stats object to the response with timing, files scanned, result cache hits and the filters applied',
+          default: false,
+        },
       },
       required: ['identifier'],
     },
@@ -93,6 +162,7 @@ export const MCP_TOOLS = [
   {
     name: 'analyze_code',
     description: 'Analyze code quality, structure, dead code, and configuration issues',
+    annotations: { readOnlyHint: true, openWorldHint: false },
     inputSchema: {
       type: 'object',
       properties: {
@@ -104,6 +174,10 @@ export const MCP_TOOLS = [
           type: 'string',
           description: 'Optional: Directory to analyze (default: current working directory)',
         },
+        scope: {
+          type: 'string',
+          description: 'Optional: Named scope from .tree-sitter-mcp.json restricting the tool to its files (e.g., "backend")',
+        },
         pathPattern: {
           type: 'string',
           description: 'Optional: Filter results to files containing this text in their path (e.g., "server", "client", "components")',
@@ -117,9 +191,9 @@ export const MCP_TOOLS = [
           type: 'array',
           items: {
             type: 'string',
-            enum: ['quality', 'structure', 'deadcode'],
+            enum: ['quality', 'structure', 'deadcode', 'security', 'concurrency', 'resources', 'nullability'],
           },
-          description: 'Analysis types to run: quality, deadcode, structure',
+          description: 'Analysis types to run: quality, deadcode, structure, security, concurrency, resources, nullability',
           default: ['quality'],
         },
         maxResults: {
@@ -127,6 +201,22 @@ export const MCP_TOOLS = [
           description: 'Maximum number of findings to return',
           default: 15,
         },
+        groupBy: {
+          type: 'string',
+          enum: ['owner', 'directory'],
+          description: 'Optional: Add a rollup of finding counts per CODEOWNERS team or top-level directory, with trends since the previous identical request',
+        },
+        minConfidence: {
+          type: 'string',
+          enum: ['low', 'medium', 'high'],
+          description: 'Optional: Lowest confidence of nullability findings to report',
+          default: 'medium',
+        },
+        includeStats: {
+          type: 'boolean',
+          description: 'Optional: Add a stats object to the response with timing, files scanned, result cache hits and the filters applied',
+          default: false,
+        },
       },
       required: ['analysisTypes'],
     },
@@ -134,6 +224,7 @@ export const MCP_TOOLS = [
   {
     name: 'check_errors',
     description: 'Find actionable syntax errors with detailed context and fix suggestions',
+    annotations: { readOnlyHint: true, openWorldHint: false },
     inputSchema: {
       type: 'object',
       properties: {
@@ -145,6 +236,10 @@ export const MCP_TOOLS = [
           type: 'string',
           description: 'Optional: Directory to check for errors (default: current working directory)',
         },
+        scope: {
+          type: 'string',
+          description: 'Optional: Named scope from .tree-sitter-mcp.json restricting the tool to its files (e.g., "backend")',
+        },
         pathPattern: {
           type: 'string',
           description: 'Optional: Filter results to files containing this text in their path (e.g., "server", "client", "components")',
@@ -163,6 +258,1557 @@ export const MCP_TOOLS = [
       required: [],
     },
   },
+  {
+    name: 'get_diagnostics',
+    description: 'List files that failed to parse or were skipped during indexing, with the recorded failure reason',
+    annotations: { readOnlyHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID for targeting specific cached project',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project (default: current working directory)',
+        },
+        scope: {
+          type: 'string',
+          description: 'Optional: Named scope from .tree-sitter-mcp.json restricting the tool to its files (e.g., "backend")',
+        },
+        pathPattern: {
+          type: 'string',
+          description: 'Optional: Filter results to files containing this text in their path (e.g., "server", "client", "components")',
+        },
+        maxResults: {
+          type: 'number',
+          description: 'Maximum number of diagnostics to return',
+          default: 50,
+        },
+      },
+      required: [],
+    },
+  },
+  {
+    name: 'register_project',
+    description: 'Register and index a project from a local directory or a git URL (shallow-cloned into a local cache) so it can be searched by projectId',
+    annotations: { readOnlyHint: false, openWorldHint: true },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        directory: {
+          type: 'string',
+          description: 'Optional: Local directory of the project',
+        },
+        url: {
+          type: 'string',
+          description: 'Optional: Git URL to clone instead of a local directory (e.g., "https://github.com/org/repo.git")',
+        },
+        ref: {
+          type: 'string',
+          description: 'Optional: Branch, tag, or commit to check out when cloning a git URL (default: remote HEAD)',
+        },
+        refresh: {
+          type: 'boolean',
+          description: 'Re-fetch the ref even if a cached checkout already exists',
+          default: false,
+        },
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID to register under (default: derived from the directory or repository name)',
+        },
+        ignoreDirs: {
+          type: 'array',
+          items: { type: 'string' },
+          description: 'Optional: Directories to ignore while indexing',
+        },
+      },
+      required: [],
+    },
+  },
+  {
+    name: 'index_dependency',
+    description: 'Index the installed sources of a third-party dependency (node_modules, Go module cache, or site-packages) as its own project so library internals can be searched',
+    annotations: { readOnlyHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        name: {
+          type: 'string',
+          description: 'Package or module name (e.g., "express", "github.com/spf13/cobra", "requests")',
+        },
+        ecosystem: {
+          type: 'string',
+          enum: ['npm', 'go', 'python'],
+          description: 'Optional: Where to look for the dependency (default: try each in turn)',
+        },
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID of the project that depends on it',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project that depends on it (default: current working directory)',
+        },
+      },
+      required: ['name'],
+    },
+  },
+  {
+    name: 'get_notebook_outline',
+    description: 'Outline Jupyter notebooks: the functions and classes defined in each code cell',
+    annotations: { readOnlyHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID for targeting specific cached project',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project (default: current working directory)',
+        },
+        scope: {
+          type: 'string',
+          description: 'Optional: Named scope from .tree-sitter-mcp.json restricting the tool to its files (e.g., "backend")',
+        },
+        pathPattern: {
+          type: 'string',
+          description: 'Optional: Only outline notebooks whose path contains this text',
+        },
+      },
+      required: [],
+    },
+  },
+  {
+    name: 'list_proto_definitions',
+    description: 'List protobuf services, RPC methods, and message/enum types, linked to the generated code that declares them and the code that uses them',
+    annotations: { readOnlyHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID for targeting specific cached project',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project (default: current working directory)',
+        },
+        scope: {
+          type: 'string',
+          description: 'Optional: Named scope from .tree-sitter-mcp.json restricting the tool to its files (e.g., "backend")',
+        },
+        pathPattern: {
+          type: 'string',
+          description: 'Optional: Only include .proto files whose path contains this text',
+        },
+        includeUsages: {
+          type: 'boolean',
+          description: 'Link each service and type to generated files and usages in other code',
+          default: true,
+        },
+        maxUsages: {
+          type: 'number',
+          description: 'Maximum usages listed per service or type',
+          default: 5,
+        },
+      },
+      required: [],
+    },
+  },
+  {
+    name: 'check_openapi',
+    description: 'Cross-reference OpenAPI/Swagger specs with route handlers: link each operation to its implementation and list unimplemented operations and undocumented routes',
+    annotations: { readOnlyHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID for targeting specific cached project',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project (default: current working directory)',
+        },
+        scope: {
+          type: 'string',
+          description: 'Optional: Named scope from .tree-sitter-mcp.json restricting the tool to its files (e.g., "backend")',
+        },
+        specFile: {
+          type: 'string',
+          description: 'Optional: Path to a specific spec file (default: discover openapi/swagger files in the project)',
+        },
+      },
+      required: [],
+    },
+  },
+  {
+    name: 'list_tasks',
+    description: 'List the tasks defined in Makefiles, Taskfiles and justfiles with their dependencies and the commands they run, to discover how the project is built and tested',
+    annotations: { readOnlyHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID for targeting specific cached project',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project (default: current working directory)',
+        },
+        scope: {
+          type: 'string',
+          description: 'Optional: Named scope from .tree-sitter-mcp.json restricting the tool to its files (e.g., "backend")',
+        },
+        runner: {
+          type: 'string',
+          enum: ['make', 'task', 'just'],
+          description: 'Optional: Only list tasks for this runner',
+        },
+        pattern: {
+          type: 'string',
+          description: 'Optional: Only list tasks whose name contains this text',
+        },
+      },
+      required: [],
+    },
+  },
+  {
+    name: 'list_ci_jobs',
+    description: 'List the jobs of GitHub Actions workflows and GitLab CI pipelines with the commands each step runs and the package.json scripts, Make/task/just targets and local scripts they invoke, to reproduce a CI failure locally',
+    annotations: { readOnlyHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID for targeting specific cached project',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project (default: current working directory)',
+        },
+        scope: {
+          type: 'string',
+          description: 'Optional: Named scope from .tree-sitter-mcp.json restricting the tool to its files (e.g., "backend")',
+        },
+        provider: {
+          type: 'string',
+          enum: ['github-actions', 'gitlab-ci'],
+          description: 'Optional: Only list jobs of this CI provider',
+        },
+        pattern: {
+          type: 'string',
+          description: 'Optional: Only list jobs whose id or name contains this text',
+        },
+      },
+      required: [],
+    },
+  },
+  {
+    name: 'map_env_vars',
+    description: 'Map environment variables: where code reads them and where they are defined (.env files, compose environment, Dockerfile ENV, Kubernetes container env, shell exports), flagging undefined and unused variables',
+    annotations: { readOnlyHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID for targeting specific cached project',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project (default: current working directory)',
+        },
+        scope: {
+          type: 'string',
+          description: 'Optional: Named scope from .tree-sitter-mcp.json restricting the tool to its files (e.g., "backend")',
+        },
+        name: {
+          type: 'string',
+          description: 'Optional: Only include variables whose name contains this text',
+        },
+        status: {
+          type: 'string',
+          enum: ['defined', 'undefined', 'unused', 'external'],
+          description: 'Optional: Only include variables with this status',
+        },
+      },
+      required: [],
+    },
+  },
+  {
+    name: 'list_feature_flags',
+    description: 'List every feature flag key evaluated in code with its call sites. Matches common flag SDKs (LaunchDarkly, Unleash, OpenFeature, GrowthBook, Split, Flagsmith) plus functions or patterns configured in .tree-sitter-mcp.json',
+    annotations: { readOnlyHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID for targeting specific cached project',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project (default: current working directory)',
+        },
+        scope: {
+          type: 'string',
+          description: 'Optional: Named scope from .tree-sitter-mcp.json restricting the tool to its files (e.g., "backend")',
+        },
+        functions: {
+          type: 'array',
+          items: { type: 'string' },
+          description: 'Optional: Extra flag functions whose first string argument is the key (e.g. "isFeatureOn", "flags.enabled")',
+        },
+        patterns: {
+          type: 'array',
+          items: { type: 'string' },
+          description: 'Optional: Extra regular expressions whose first capture group is the flag key',
+        },
+        sdks: {
+          type: 'array',
+          items: { type: 'string' },
+          description: 'Optional: Built-in SDK presets to match (default: all)',
+        },
+        key: {
+          type: 'string',
+          description: 'Optional: Only include flags whose key contains this text',
+        },
+      },
+      required: [],
+    },
+  },
+  {
+    name: 'list_log_statements',
+    description: 'Extract logging calls with their level, message template and structured fields, and flag inconsistent logging: print statements (fmt.Println, console.log, print) in production code and error-level logs in error handlers that leave out the error',
+    annotations: { readOnlyHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID for targeting specific cached project',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project (default: current working directory)',
+        },
+        scope: {
+          type: 'string',
+          description: 'Optional: Named scope from .tree-sitter-mcp.json restricting the tool to its files (e.g., "backend")',
+        },
+        level: {
+          type: 'string',
+          enum: ['trace', 'debug', 'info', 'warn', 'error', 'fatal', 'print'],
+          description: 'Optional: Only include statements at this level',
+        },
+        issuesOnly: {
+          type: 'boolean',
+          description: 'Only return the consistency findings, not the statements',
+          default: false,
+        },
+        maxResults: {
+          type: 'number',
+          description: 'Maximum number of statements',
+          default: 100,
+        },
+      },
+      required: [],
+    },
+  },
+  {
+    name: 'check_exhaustiveness',
+    description: 'Find switch and match statements over enum-like types (Go typed const blocks, TypeScript literal unions and enums, Rust enums) that miss variants and have no default arm, listing the missing cases',
+    annotations: { readOnlyHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID for targeting specific cached project',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project (default: current working directory)',
+        },
+        scope: {
+          type: 'string',
+          description: 'Optional: Named scope from .tree-sitter-mcp.json restricting the tool to its files (e.g., "backend")',
+        },
+        enum: {
+          type: 'string',
+          description: 'Optional: Only check switches over the enum of this name',
+        },
+        maxResults: {
+          type: 'number',
+          description: 'Maximum number of findings',
+          default: 100,
+        },
+      },
+      required: [],
+    },
+  },
+  {
+    name: 'list_panic_paths',
+    description: 'List where functions can panic, throw or exit: explicit panics and raise/throw statements, unchecked Go type assertions, Rust unwrap/expect, assertions and process exits. Functions calling one of these, directly or through other calls up to a depth, are marked as able to panic with the call chain, so the failure surface of a function can be assessed',
+    annotations: { readOnlyHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID for targeting specific cached project',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project (default: current working directory)',
+        },
+        scope: {
+          type: 'string',
+          description: 'Optional: Named scope from .tree-sitter-mcp.json restricting the tool to its files (e.g., "backend")',
+        },
+        symbol: {
+          type: 'string',
+          description: 'Optional: Only report this function (e.g., "load" or "UserService.load") and the sites it can reach',
+        },
+        depth: {
+          type: 'number',
+          description: 'How many calls away from a panic site a function is still marked as able to panic',
+          default: 3,
+        },
+        maxResults: {
+          type: 'number',
+          description: 'Maximum number of functions and of sites',
+          default: 100,
+        },
+      },
+      required: [],
+    },
+  },
+  {
+    name: 'suggest_test_targets',
+    description: 'Rank functions by how much they would benefit from more tests, combining coverage (lcov, Go cover profile or Cobertura report), complexity and recent git churn, with the uncovered branches and lines to write assertions for',
+    annotations: { readOnlyHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID for targeting specific cached project',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project (default: current working directory)',
+        },
+        scope: {
+          type: 'string',
+          description: 'Optional: Named scope from .tree-sitter-mcp.json restricting the tool to its files (e.g., "backend")',
+        },
+        coverageFile: {
+          type: 'string',
+          description: 'Optional: Coverage report relative to the project (default: coverage/lcov.info, lcov.info, coverage.out, cover.out, coverage.xml or coverage/cobertura-coverage.xml, whichever exists)',
+        },
+        days: {
+          type: 'number',
+          description: 'Churn window: commits from this many days back count',
+          default: 90,
+        },
+        maxResults: {
+          type: 'number',
+          description: 'Maximum number of functions',
+          default: 20,
+        },
+      },
+      required: [],
+    },
+  },
+  {
+    name: 'generate_test_stub',
+    description: 'Generate a test file skeleton for a function: a table-driven Go test, a Jest or Vitest describe block with it.each, or a pytest parametrized test, named, placed and importing the function the way the project\'s existing tests do',
+    annotations: { readOnlyHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        symbol: {
+          type: 'string',
+          description: 'Function or method to test, by name, `Container.name` or symbol id (e.g., "ParseConfig", "UserService.load", "src/api/users.ts#UserService.load")',
+        },
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID for targeting specific cached project',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project (default: current working directory)',
+        },
+        scope: {
+          type: 'string',
+          description: 'Optional: Named scope from .tree-sitter-mcp.json restricting the tool to its files (e.g., "backend")',
+        },
+      },
+      required: ['symbol'],
+    },
+  },
+  {
+    name: 'find_test_doubles',
+    description: 'List the interfaces a Go, TypeScript/JavaScript or Python type is used through (declared, asserted or matched by method set), the functions that take them, and the mocks and fakes the project already has for them: gomock and testify mocks, Jest/Vitest module mocks and mock<T>() factories, unittest.mock specs and patches, and hand-written fakes and stubs',
+    annotations: { readOnlyHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        type: {
+          type: 'string',
+          description: 'Type or interface, by name or `path#Name` (e.g., "PostgresStore", "src/billing/gateway.ts#StripeGateway")',
+        },
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID for targeting specific cached project',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project (default: current working directory)',
+        },
+        scope: {
+          type: 'string',
+          description: 'Optional: Named scope from .tree-sitter-mcp.json restricting the tool to its files (e.g., "backend")',
+        },
+      },
+      required: ['type'],
+    },
+  },
+  {
+    name: 'map_test_fixtures',
+    description: 'Map each test to the fixture and golden files it reads, from literal paths in its code (path joins, Path chains, path strings) and snapshot helpers, with fixture directories and the command that regenerates the test\'s goldens (go test -update, jest/vitest -u, pytest --snapshot-update)',
+    annotations: { readOnlyHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID for targeting specific cached project',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project (default: current working directory)',
+        },
+        scope: {
+          type: 'string',
+          description: 'Optional: Named scope from .tree-sitter-mcp.json restricting the tool to its files (e.g., "backend")',
+        },
+        path: {
+          type: 'string',
+          description: 'Optional: Only tests under this path or depending on files under it, relative to the project (e.g., "pkg/render/testdata")',
+        },
+        maxResults: {
+          type: 'number',
+          description: 'Maximum number of tests',
+          default: 100,
+        },
+      },
+      required: [],
+    },
+  },
+  {
+    name: 'list_benchmarks',
+    description: 'List Go benchmarks (with literal b.Run sub-benchmarks) and JavaScript benchmark suites (Vitest bench, Benchmark.js, tinybench, mitata) with the command running each. The CLI\'s bench compare runs them at two git refs and reports the deltas',
+    annotations: { readOnlyHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID for targeting specific cached project',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project (default: current working directory)',
+        },
+        scope: {
+          type: 'string',
+          description: 'Optional: Named scope from .tree-sitter-mcp.json restricting the tool to its files (e.g., "backend")',
+        },
+        pattern: {
+          type: 'string',
+          description: 'Optional: Regular expression over benchmark names (e.g., "^BenchmarkParse")',
+        },
+        maxResults: {
+          type: 'number',
+          description: 'Maximum number of benchmarks',
+          default: 100,
+        },
+      },
+      required: [],
+    },
+  },
+  {
+    name: 'check_translations',
+    description: 'Cross-reference translation calls (t(\'key\'), $t, i18n.t, gettext, _(), <Trans i18nKey>, formatMessage) with locale JSON/YAML/PO files. Reports keys used but not defined, keys defined but unused, keys missing per locale, and hardcoded user-facing strings in JSX and component templates',
+    annotations: { readOnlyHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID for targeting specific cached project',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project (default: current working directory)',
+        },
+        scope: {
+          type: 'string',
+          description: 'Optional: Named scope from .tree-sitter-mcp.json restricting the tool to its files (e.g., "backend")',
+        },
+        locale: {
+          type: 'string',
+          description: 'Optional: Only report missing translations for this locale',
+        },
+        includeHardcoded: {
+          type: 'boolean',
+          description: 'Include hardcoded user-facing strings',
+          default: true,
+        },
+        maxResults: {
+          type: 'number',
+          description: 'Maximum entries per list',
+          default: 50,
+        },
+      },
+      required: [],
+    },
+  },
+  {
+    name: 'list_models',
+    description: 'List ORM models (GORM structs, Prisma models, SQLAlchemy/SQLModel classes, TypeORM entities) with their table, fields, relations and code locations, to reason about the data model without reading every file',
+    annotations: { readOnlyHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID for targeting specific cached project',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project (default: current working directory)',
+        },
+        scope: {
+          type: 'string',
+          description: 'Optional: Named scope from .tree-sitter-mcp.json restricting the tool to its files (e.g., "backend")',
+        },
+        name: {
+          type: 'string',
+          description: 'Optional: Only include models whose name or table contains this text (case-insensitive)',
+        },
+        orm: {
+          type: 'string',
+          enum: ['gorm', 'prisma', 'sqlalchemy', 'typeorm'],
+          description: 'Optional: Only include models of this ORM',
+        },
+      },
+      required: [],
+    },
+  },
+  {
+    name: 'query_struct_tags',
+    description: 'Query Go struct tags: list the structs using a tag key (json, db, yaml, ...), find the field serialized under a given name, and report malformed tags and fields sharing a serialized name',
+    annotations: { readOnlyHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID for targeting specific cached project',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project (default: current working directory)',
+        },
+        scope: {
+          type: 'string',
+          description: 'Optional: Named scope from .tree-sitter-mcp.json restricting the tool to its files (e.g., "backend")',
+        },
+        key: {
+          type: 'string',
+          description: 'Optional: Tag key to query, e.g. "json", "db", "yaml" (default: any key when listing structs, "json" when looking up a name)',
+        },
+        name: {
+          type: 'string',
+          description: 'Optional: Serialized name to find the fields for, e.g. "user_id"',
+        },
+        problems: {
+          type: 'boolean',
+          description: 'Optional: Report malformed tags and fields serialized under the same name',
+          default: true,
+        },
+        maxResults: {
+          type: 'number',
+          description: 'Maximum structs, fields and problems to return',
+          default: 100,
+        },
+      },
+      required: [],
+    },
+  },
+  {
+    name: 'match_payload_type',
+    description: 'Find the Go struct, TypeScript interface or Python dataclass/model a JSON or YAML payload (or a dotted key path like "user.address.street") decodes into, matching serialized field names and tags, with a score per candidate and the keys that matched, are unknown or missing',
+    annotations: { readOnlyHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID for targeting specific cached project',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project (default: current working directory)',
+        },
+        scope: {
+          type: 'string',
+          description: 'Optional: Named scope from .tree-sitter-mcp.json restricting the tool to its files (e.g., "backend")',
+        },
+        payload: {
+          type: 'string',
+          description: 'Optional: Payload as JSON or YAML text, e.g. a request body or config file',
+        },
+        keyPath: {
+          type: 'string',
+          description: 'Optional: Dotted key path to resolve through field types, e.g. "data.items[0].sku"',
+        },
+        language: {
+          type: 'string',
+          enum: ['go', 'typescript', 'python'],
+          description: 'Optional: Only match types of this language',
+        },
+        maxResults: {
+          type: 'number',
+          description: 'Maximum candidates to return',
+          default: 10,
+        },
+      },
+      required: [],
+    },
+  },
+  {
+    name: 'analyze_migrations',
+    description: 'Read database migrations (golang-migrate, goose, Prisma, Alembic), list the schema changes of each one and replay them to answer what a table currently looks like and which migration last touched it',
+    annotations: { readOnlyHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID for targeting specific cached project',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project (default: current working directory)',
+        },
+        scope: {
+          type: 'string',
+          description: 'Optional: Named scope from .tree-sitter-mcp.json restricting the tool to its files (e.g., "backend")',
+        },
+        table: {
+          type: 'string',
+          description: 'Optional: Table to describe (case-insensitive). Returns its current columns and the migrations that changed it instead of the full migration list',
+        },
+      },
+      required: [],
+    },
+  },
+  {
+    name: 'link_api_calls',
+    description: 'Match frontend HTTP calls (fetch, axios and similar clients) to the backend route definitions serving them, across languages and frameworks, and report calls with no route and routes no client calls',
+    annotations: { readOnlyHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID for targeting specific cached project',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project (default: current working directory)',
+        },
+        scope: {
+          type: 'string',
+          description: 'Optional: Named scope from .tree-sitter-mcp.json restricting the tool to its files (e.g., "backend")',
+        },
+        unmatchedOnly: {
+          type: 'boolean',
+          description: 'Optional: Only return unmatched calls and routes, not the linked pairs (default: false)',
+          default: false,
+        },
+      },
+      required: [],
+    },
+  },
+  {
+    name: 'export_chunks',
+    description: 'Export symbol-aligned code chunks (one per function, method or class, split to fit a token budget) with path, symbol, kind, language, used imports and doc comment as JSONL, to feed embedding/RAG pipelines',
+    annotations: { readOnlyHint: false, destructiveHint: true, idempotentHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID for targeting specific cached project',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project (default: current working directory)',
+        },
+        scope: {
+          type: 'string',
+          description: 'Optional: Named scope from .tree-sitter-mcp.json restricting the tool to its files (e.g., "backend")',
+        },
+        pathPattern: {
+          type: 'string',
+          description: 'Optional: Only chunk files containing this text in their path',
+        },
+        maxTokens: {
+          type: 'number',
+          description: 'Optional: Approximate token budget per chunk (default: 512)',
+          default: 512,
+        },
+        includeModuleCode: {
+          type: 'boolean',
+          description: 'Optional: Also emit code between symbols such as imports and top-level statements (default: true)',
+          default: true,
+        },
+        outputFile: {
+          type: 'string',
+          description: 'Optional: Write the JSONL to this file (relative to the project directory) and return only a summary',
+        },
+        maxChunks: {
+          type: 'number',
+          description: 'Optional: Maximum chunks returned inline when no outputFile is given (default: 200)',
+          default: 200,
+        },
+      },
+      required: [],
+    },
+  },
+  {
+    name: 'export_index',
+    description: 'Write the parsed index of a project to a gzip archive that can be committed, cached in CI or shared, and later loaded with import_index instead of re-parsing',
+    annotations: { readOnlyHint: false, destructiveHint: true, idempotentHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID for targeting specific cached project',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project (default: current working directory)',
+        },
+        file: {
+          type: 'string',
+          description: 'Archive path, relative to the project directory (e.g. index.tsz)',
+        },
+      },
+      required: ['file'],
+    },
+  },
+  {
+    name: 'import_index',
+    description: 'Load a project from an index archive written by export_index or `tree-sitter-mcp index export`. Files whose content changed since the export, or that were written by a different tool version, are re-parsed',
+    annotations: { readOnlyHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID to register the loaded project under',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory the archive describes (default: current working directory)',
+        },
+        file: {
+          type: 'string',
+          description: 'Archive path, relative to the project directory',
+        },
+      },
+      required: ['file'],
+    },
+  },
+  {
+    name: 'parse_snippet',
+    description: 'Parse source text directly, without a project on disk, and return its outline, symbols with complexity, syntax errors and quality findings. Useful for checking code you just generated before writing it',
+    annotations: { readOnlyHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        content: {
+          type: 'string',
+          description: 'Source text to analyze',
+        },
+        language: {
+          type: 'string',
+          description: 'Language name, alias or extension (e.g. typescript, py, .go). Optional when filename has a known extension',
+        },
+        filename: {
+          type: 'string',
+          description: 'Optional: File name used in reported locations; a test file name applies the relaxed test thresholds',
+        },
+      },
+      required: ['content'],
+    },
+  },
+  {
+    name: 'get_symbol_history',
+    description: 'List the commits that modified a function, method or class, newest first, with messages and the diff of each change to its body (git log -L on the symbol\'s range). Use it to explain why code is the way it is',
+    annotations: { readOnlyHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        name: {
+          type: 'string',
+          description: 'Name of the symbol',
+        },
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID for targeting specific cached project',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project (default: current working directory)',
+        },
+        scope: {
+          type: 'string',
+          description: 'Optional: Named scope from .tree-sitter-mcp.json restricting the tool to its files (e.g., "backend")',
+        },
+        file: {
+          type: 'string',
+          description: 'Optional: Only consider symbols in files containing this text in their path; needed when the name is defined more than once',
+        },
+        maxCommits: {
+          type: 'number',
+          description: 'Maximum number of commits to return',
+          default: 10,
+        },
+        includeDiffs: {
+          type: 'boolean',
+          description: 'Include the diff of each commit within the symbol',
+          default: true,
+        },
+        maxDiffLines: {
+          type: 'number',
+          description: 'Maximum diff lines per commit',
+          default: 80,
+        },
+      },
+      required: ['name'],
+    },
+  },
+  {
+    name: 'find_owner',
+    description: 'Look up the owning team of a file or symbol from CODEOWNERS (.github/, root, docs/ or .gitlab/), with the rule that matched. Search and analysis results also carry owners when the project has a CODEOWNERS file',
+    annotations: { readOnlyHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        path: {
+          type: 'string',
+          description: 'Optional: File or directory path, relative to the project directory',
+        },
+        symbol: {
+          type: 'string',
+          description: 'Optional: Name of a function, class or other symbol; every file defining it is reported',
+        },
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID for targeting specific cached project',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project (default: current working directory)',
+        },
+        scope: {
+          type: 'string',
+          description: 'Optional: Named scope from .tree-sitter-mcp.json restricting the tool to its files (e.g., "backend")',
+        },
+      },
+      required: [],
+    },
+  },
+  {
+    name: 'resolve_symbol',
+    description: 'List every declaration a name may refer to, with its package, signature and path, when several packages define it. Pass the id of the chosen candidate as query to search_code or identifier to find_usage to target only that declaration',
+    annotations: { readOnlyHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        name: {
+          type: 'string',
+          description: 'Symbol name, optionally qualified by its container or module (e.g., "UserService.load", "utils.FormatDate")',
+        },
+        types: {
+          type: 'array',
+          items: { type: 'string' },
+          description: 'Optional: Only candidates of these kinds or types (e.g., ["class", "struct"])',
+        },
+        maxResults: {
+          type: 'number',
+          description: 'Maximum number of candidates to return',
+          default: 50,
+        },
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID for targeting specific cached project',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project (default: current working directory)',
+        },
+        scope: {
+          type: 'string',
+          description: 'Optional: Named scope from .tree-sitter-mcp.json restricting the tool to its files (e.g., "backend")',
+        },
+      },
+      required: ['name'],
+    },
+  },
+  {
+    name: 'find_definition',
+    description: 'Go to the declaration of the identifier at a file position. Uses the project\'s language server (gopls, tsserver, pyright) when installed, which tells apart same-named symbols, interface methods and overloads, and otherwise matches the name against the index; each definition says which resolver produced it',
+    annotations: { readOnlyHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        file: {
+          type: 'string',
+          description: 'File containing the identifier, relative to the project directory or absolute',
+        },
+        line: {
+          type: 'number',
+          description: 'Line of the identifier (1-based)',
+        },
+        column: {
+          type: 'number',
+          description: 'Column of any character of the identifier (1-based)',
+        },
+        precise: {
+          type: 'boolean',
+          description: 'Ask the language server when one is installed; false to answer from the index alone',
+          default: true,
+        },
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID for targeting specific cached project',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project (default: current working directory)',
+        },
+        scope: {
+          type: 'string',
+          description: 'Optional: Named scope from .tree-sitter-mcp.json restricting the tool to its files (e.g., "backend")',
+        },
+      },
+      required: ['file', 'line', 'column'],
+    },
+  },
+  {
+    name: 'get_type_info',
+    description: 'The declared type of the variable, field or parameter at a file position and the declaration of that type, with its source. Read from the declaring code (parameters, annotations, constructor calls, struct fields), or from the project\'s language server (gopls, tsserver, pyright) when installed, which also knows inferred types',
+    annotations: { readOnlyHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        file: {
+          type: 'string',
+          description: 'File containing the identifier, relative to the project directory or absolute',
+        },
+        line: {
+          type: 'number',
+          description: 'Line of the identifier (1-based)',
+        },
+        column: {
+          type: 'number',
+          description: 'Column of any character of the identifier (1-based)',
+        },
+        precise: {
+          type: 'boolean',
+          description: 'Ask the language server when one is installed; false to read the source alone',
+          default: true,
+        },
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID for targeting specific cached project',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project (default: current working directory)',
+        },
+        scope: {
+          type: 'string',
+          description: 'Optional: Named scope from .tree-sitter-mcp.json restricting the tool to its files (e.g., "backend")',
+        },
+      },
+      required: ['file', 'line', 'column'],
+    },
+  },
+  {
+    name: 'get_constant_values',
+    description: 'Resolve the literal values of constants and enum members (TS enums and as-const objects, Go iota sequences, Python Enum classes, Rust/C#/C discriminants, Java enums) and list where each is used. Answers questions like "what does status 3 mean"',
+    annotations: { readOnlyHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        name: {
+          type: 'string',
+          description: 'Optional: Only constants whose name or enum contains this text (case-insensitive)',
+        },
+        value: {
+          type: 'string',
+          description: 'Optional: Only constants resolving to this value (e.g., "3", "0x3", "active")',
+        },
+        includeUsages: {
+          type: 'boolean',
+          description: 'Include the places each constant is referenced',
+          default: true,
+        },
+        maxResults: {
+          type: 'number',
+          description: 'Maximum number of constants to return',
+          default: 100,
+        },
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID for targeting specific cached project',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project (default: current working directory)',
+        },
+        scope: {
+          type: 'string',
+          description: 'Optional: Named scope from .tree-sitter-mcp.json restricting the tool to its files (e.g., "backend")',
+        },
+      },
+      required: [],
+    },
+  },
+  {
+    name: 'find_similar',
+    description: 'Find functions and methods structurally similar to a code snippet, ignoring names, literals and comments. Useful for spotting duplicated logic before writing a helper or when refactoring copy-pasted code',
+    annotations: { readOnlyHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        code: {
+          type: 'string',
+          description: 'Code snippet to compare against the project',
+        },
+        language: {
+          type: 'string',
+          description: 'Optional: Language of the snippet (name, alias or extension, e.g., "typescript", "py"). Compares syntax trees and only searches files of that language; without it, normalized tokens are compared across all languages',
+        },
+        maxResults: {
+          type: 'number',
+          description: 'Maximum number of functions to return',
+          default: 10,
+        },
+        minSimilarity: {
+          type: 'number',
+          description: 'Minimum similarity score from 0 to 100',
+          default: 50,
+        },
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID for targeting specific cached project',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project (default: current working directory)',
+        },
+        scope: {
+          type: 'string',
+          description: 'Optional: Named scope from .tree-sitter-mcp.json restricting the tool to its files (e.g., "backend")',
+        },
+      },
+      required: ['code'],
+    },
+  },
+  {
+    name: 'get_context_pack',
+    description: 'Everything needed to modify a function or type safely, in one token-budgeted response: its definition, the imports it uses, the project functions it calls, the types it references and its callers. Related symbols are given with their code when the budget allows, otherwise as signatures',
+    annotations: { readOnlyHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        symbol: {
+          type: 'string',
+          description: 'Name of the function, method or type, optionally qualified (e.g., "UserService.load"), or a symbol id from resolve_symbol',
+        },
+        maxTokens: {
+          type: 'number',
+          description: 'Approximate token budget for the whole pack',
+          default: 4000,
+        },
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID for targeting specific cached project',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project (default: current working directory)',
+        },
+        scope: {
+          type: 'string',
+          description: 'Optional: Named scope from .tree-sitter-mcp.json restricting the tool to its files (e.g., "backend")',
+        },
+      },
+      required: ['symbol'],
+    },
+  },
+  {
+    name: 'summarize_architecture',
+    description: 'Onboarding map of a project: directories with their languages and frameworks, detected frameworks (from manifests and route registrations), entry points (main functions, scripts, package bins, route files), the most referenced types and the import dependencies between directories',
+    annotations: { readOnlyHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        depth: {
+          type: 'number',
+          description: 'Directory levels to group files by (e.g., 2 groups src/api/users.ts under src/api)',
+          default: 2,
+        },
+        maxKeyTypes: {
+          type: 'number',
+          description: 'Maximum number of key types to list',
+          default: 15,
+        },
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID for targeting specific cached project',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project (default: current working directory)',
+        },
+        scope: {
+          type: 'string',
+          description: 'Optional: Named scope from .tree-sitter-mcp.json restricting the tool to its files (e.g., "backend")',
+        },
+      },
+      required: [],
+    },
+  },
+  {
+    name: 'list_entry_points',
+    description: 'List where a project can be started, with file and line: main functions and packages, scripts, npm bins and scripts, CLI command registrations (cobra, urfave/cli, commander, yargs, click, typer, argparse), serverless handlers, and scheduled jobs (crontab, GitHub Actions, Kubernetes CronJobs, Serverless/SAM schedules, cron libraries). Use it first when asked to run the project',
+    annotations: { readOnlyHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        kinds: {
+          type: 'array',
+          items: {
+            type: 'string',
+            enum: ['main', 'script', 'bin', 'npm-script', 'command', 'serverless', 'cron'],
+          },
+          description: 'Optional: Only entry points of these kinds',
+          default: [],
+        },
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID for targeting specific cached project',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project (default: current working directory)',
+        },
+        scope: {
+          type: 'string',
+          description: 'Optional: Named scope from .tree-sitter-mcp.json restricting the tool to its files (e.g., "backend")',
+        },
+      },
+      required: [],
+    },
+  },
+  {
+    name: 'map_kubernetes',
+    description: 'Map Kubernetes manifests and Helm chart templates to the code: Deployments, StatefulSets, Jobs and CronJobs with the Dockerfiles building their container images and the code reading their env vars, Services with the workloads they select, and ConfigMaps/Secrets with the workloads using them',
+    annotations: { readOnlyHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        name: {
+          type: 'string',
+          description: 'Optional: Only include objects whose name contains this text',
+        },
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID for targeting specific cached project',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project (default: current working directory)',
+        },
+        scope: {
+          type: 'string',
+          description: 'Optional: Named scope from .tree-sitter-mcp.json restricting the tool to its files (e.g., "backend")',
+        },
+      },
+      required: [],
+    },
+  },
+  {
+    name: 'map_spring_beans',
+    description: 'Map the Spring beans of Java services: @Component, @Service, @Repository, @Controller/@RestController and @Configuration classes, @Bean methods and Spring Data repositories, with the dependencies each gets injected (@Autowired fields and setters, constructors, Lombok constructors) resolved to the beans satisfying them, and the endpoints of controllers',
+    annotations: { readOnlyHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        name: {
+          type: 'string',
+          description: 'Optional: Only include beans whose name or type contains this text',
+        },
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID for targeting specific cached project',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project (default: current working directory)',
+        },
+        scope: {
+          type: 'string',
+          description: 'Optional: Named scope from .tree-sitter-mcp.json restricting the tool to its files (e.g., "backend")',
+        },
+      },
+      required: [],
+    },
+  },
+  {
+    name: 'map_flutter_widgets',
+    description: 'Map the Flutter widgets of Dart files: StatelessWidget, StatefulWidget, InheritedWidget and Riverpod/hooks widget classes with their State classes, build methods, and the project widgets each one creates and is created by, to navigate widget hierarchies',
+    annotations: { readOnlyHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        name: {
+          type: 'string',
+          description: 'Optional: Only include widgets whose name contains this text',
+        },
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID for targeting specific cached project',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project (default: current working directory)',
+        },
+        scope: {
+          type: 'string',
+          description: 'Optional: Named scope from .tree-sitter-mcp.json restricting the tool to its files (e.g., "backend")',
+        },
+      },
+      required: [],
+    },
+  },
+  {
+    name: 'docs_for',
+    description: 'Find the documentation of a symbol: the sections of Markdown and LaTeX documents that name it in a heading, a code span, an example or prose, best matches first, together with where it is defined in code',
+    annotations: { readOnlyHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        symbol: {
+          type: 'string',
+          description: 'Name of the function, class or other symbol, optionally qualified (e.g., "parseFile", "Parser.parse")',
+        },
+        maxResults: {
+          type: 'number',
+          description: 'Maximum number of sections to return (default: 20)',
+          default: 20,
+        },
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID for targeting specific cached project',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project (default: current working directory)',
+        },
+        scope: {
+          type: 'string',
+          description: 'Optional: Named scope from .tree-sitter-mcp.json restricting the tool to its files (e.g., "backend")',
+        },
+      },
+      required: ['symbol'],
+    },
+  },
+  {
+    name: 'edit_at_symbol',
+    description: 'Replace a declaration with new text, or insert text before or after it, addressing it by symbol name or id instead of line numbers. With dryRun, returns the unified diff, affected files and any syntax errors the edit introduces without writing, plus a planId for apply_edit. Edits that introduce syntax errors are refused unless allowed',
+    annotations: { readOnlyHint: false, destructiveHint: true, idempotentHint: false, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID for targeting specific cached project',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project (default: current working directory)',
+        },
+        symbol: {
+          type: 'string',
+          description: 'Name (e.g. "UserService.load") or symbol id from resolve_symbol (e.g. "src/users.ts#UserService.load") of the declaration; must name exactly one',
+        },
+        text: {
+          type: 'string',
+          description: 'Code to write. Replacing with an empty string deletes the declaration',
+        },
+        position: {
+          type: 'string',
+          enum: ['replace', 'before', 'after'],
+          description: 'Replace the declaration\'s lines, or insert the text on the lines before or after them',
+          default: 'replace',
+        },
+        dryRun: {
+          type: 'boolean',
+          description: 'Return the diff without writing; apply it later with apply_edit',
+          default: false,
+        },
+        allowSyntaxErrors: {
+          type: 'boolean',
+          description: 'Apply the edit even if it introduces parse errors (default: edits.allowSyntaxErrors in .tree-sitter-mcp.json, else false)',
+        },
+        format: {
+          type: 'boolean',
+          description: 'Run the edited file through the formatter the project uses - gofmt, prettier, black or rustfmt - before diffing and writing (default: false unless the server runs with --format-edits; never on a read-only server)',
+        },
+      },
+      required: ['symbol', 'text'],
+    },
+  },
+  {
+    name: 'apply_edit',
+    description: 'Apply an edit planned by a dry run of a mutating tool. Refused if any affected file changed since the dry run, so concurrent edits are not overwritten',
+    annotations: { readOnlyHint: false, destructiveHint: true, idempotentHint: false, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        planId: {
+          type: 'string',
+          description: 'planId returned by the dry run',
+        },
+        allowSyntaxErrors: {
+          type: 'boolean',
+          description: 'Apply the edit even if it introduces parse errors (default: edits.allowSyntaxErrors in .tree-sitter-mcp.json, else false)',
+        },
+      },
+      required: ['planId'],
+    },
+  },
+  {
+    name: 'list_edits',
+    description: 'List the edits applied to a project by mutating tools, most recent first, with the files each one changed. These are the edits undo_last_edit can roll back',
+    annotations: { readOnlyHint: true, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID for targeting specific cached project',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project (default: current working directory)',
+        },
+        maxResults: {
+          type: 'number',
+          description: 'Maximum edits returned',
+          default: 20,
+        },
+      },
+      required: [],
+    },
+  },
+  {
+    name: 'undo_last_edit',
+    description: 'Roll back the most recent applied edit by restoring the content its files had before, from the edit journal rather than git. Refused if those files changed since the edit, unless forced. With dryRun, returns the diff and a planId for apply_edit',
+    annotations: { readOnlyHint: false, destructiveHint: true, idempotentHint: false, openWorldHint: false },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        projectId: {
+          type: 'string',
+          description: 'Optional: Project ID for targeting specific cached project',
+        },
+        directory: {
+          type: 'string',
+          description: 'Optional: Directory of the project (default: current working directory)',
+        },
+        force: {
+          type: 'boolean',
+          description: 'Undo even if the files changed since the edit, discarding those changes',
+          default: false,
+        },
+        dryRun: {
+          type: 'boolean',
+          description: 'Return the diff without writing; apply it later with apply_edit',
+          default: false,
+        },
+      },
+      required: [],
+    },
+  },
+  {
+    name: 'batch',
+    description: 'Run several tool calls in one request and get their results keyed by id. Saves a round trip per call, e.g. for a series of searches. A failing call is reported in its result and does not stop the others',
+    annotations: { readOnlyHint: false, openWorldHint: true },
+    inputSchema: {
+      type: 'object',
+      properties: {
+        calls: {
+          type: 'array',
+          items: {
+            type: 'object',
+            properties: {
+              id: {
+                type: 'string',
+                description: 'Key for this call in the results (default: its index in the array)',
+              },
+              tool: {
+                type: 'string',
+                description: 'Name of the tool to call, e.g. search_code',
+              },
+              arguments: {
+                type: 'object',
+                description: 'Arguments for the tool',
+              },
+            },
+            required: ['tool'],
+          },
+          description: 'Tool calls to run, at most 20',
+        },
+        parallel: {
+          type: 'boolean',
+          description: 'Run the calls concurrently instead of in order',
+          default: false,
+        },
+      },
+      required: ['calls'],
+    },
+  },
 ]
 
 export const MCP_RESOURCES = [
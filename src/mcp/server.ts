@@ -12,20 +12,41 @@ import {
 } from '@modelcontextprotocol/sdk/types.js'
 
 import { analyzeProject } from '../analysis/index.js'
-import { handleToolRequest } from './handlers.js'
+import { handleToolRequest, setFormatEdits, setIndexFileLimit, setLanguageServerCommands, setReadOnlyMode, setResultCache } from './handlers.js'
+import { createResultCache } from './result-cache.js'
+import { createCallSession, isThrottled, runLimitedCall, throttledResult, type CallLimits } from './limits.js'
 import { MCP_TOOLS, MCP_RESOURCES } from './schemas.js'
 import { getLogger } from '../utils/logger.js'
+import { setFileRemotesAllowed } from '../project/remote.js'
 import { handleError } from '../utils/errors.js'
 import { getVersion } from '../utils/version.js'
 import type { JsonObject } from '../types/core.js'
 
+export interface MCPServerOptions {
+  readOnly?: boolean // Refuse tool calls that write files
+  limits?: CallLimits // Caps on the client's tool calls; refused calls return a throttled error
+  resultCache?: boolean // Answer repeated read-only calls from cache while the index is unchanged; default true
+  languageServers?: Record<string, string[]> // Command line per language server id, instead of gopls, typescript-language-server or pyright-langserver on the PATH
+  formatEdits?: boolean // Format edited files when a call doesn't pass `format`; default false
+  allowFileRemotes?: boolean // Let register_project clone file:// URLs; default false
+}
+
 /**
  * Starts the MCP server with stdio transport
  */
-export async function startMCPServer(): Promise<void> {
+export async function startMCPServer(options: MCPServerOptions = {}): Promise<void> {
   const logger = getLogger()
 
   try {
+    setReadOnlyMode(options.readOnly ?? false)
+    setIndexFileLimit(options.limits?.maxFilesPerCall)
+    setResultCache(options.resultCache === false ? undefined : createResultCache())
+    setLanguageServerCommands(options.languageServers ?? {})
+    setFormatEdits(options.formatEdits ?? false)
+    setFileRemotesAllowed(options.allowFileRemotes ?? false)
+    // A stdio server has one client, so the server's limits are its session's
+    const session = createCallSession(options.limits ?? {})
+
     const server = new Server(
       {
         name: 'tree-sitter-mcp',
@@ -52,9 +73,13 @@ export async function startMCPServer(): Promise<void> {
             arguments: request.params.arguments as JsonObject,
           },
         }
-        return await handleToolRequest(toolRequest)
+        return await runLimitedCall(session, toolRequest.params.name, toolRequest.params.arguments ?? {}, () => handleToolRequest(toolRequest))
       }
       catch (error) {
+        if (isThrottled(error)) {
+          logger.warn(`Tool request throttled: ${request.params.name}`)
+          return throttledResult(error)
+        }
         logger.error('Tool request failed:', error)
         throw handleError(error, `Tool request failed: ${request.params.name}`)
       }
@@ -94,10 +119,16 @@ export async function startMCPServer(): Promise<void> {
       }
     })
 
+    // A long-running server must survive stray failures from background work such as
+    // file watcher re-parses; per-file failures are surfaced through get_diagnostics
+    process.on('unhandledRejection', (reason) => {
+      logger.error('Unhandled rejection in MCP server:', reason)
+    })
+
     const transport = new StdioServerTransport()
     await server.connect(transport)
 
-    logger.info('MCP server started successfully')
+    logger.info(`MCP server started successfully${options.readOnly ? ' (read-only)' : ''}`)
   }
   catch (error) {
     logger.error('Failed to start MCP server:', error)
@@ -0,0 +1,75 @@
+/**
+ * Query stats - what a tool call did, attached to its response when it passes
+ * `includeStats: true`: how long it took, how many files it looked at, whether the result
+ * cache answered it, and the filters it ran with. Meant for telling why a search is slow or
+ * comes back empty.
+ */
+
+import { AsyncLocalStorage } from 'async_hooks'
+import type { JsonObject, TreeNode } from '../types/core.js'
+
+export interface QueryStats {
+  durationMs: number
+  filesIndexed: number // Files of the project and sub-projects the call ran on
+  filesScanned: number | null // Files the call searched, after scope and target; null for cache hits and tools that do not report it
+  cache: {
+    hit: boolean
+    hitRatio: number | null // Over the server session; null while nothing was cacheable
+  }
+  filters: JsonObject // Arguments that narrow the call, as given
+}
+
+interface StatsCollector {
+  files?: Set<string> // Set once the call reports what it searches
+  cacheHit: boolean
+}
+
+// Arguments reported as filters when a call gives them
+const FILTER_ARGUMENTS = [
+  'mode', 'scope', 'pathPattern', 'target', 'includeProjects', 'searchAtRef', 'buildTags', 'defines',
+  'types', 'exactMatch', 'caseSensitive', 'comments', 'fuzzyThreshold', 'typeArguments', 'analysisTypes',
+  'ignoreDirs', 'minConfidence', 'maxResults',
+] as const
+
+const collectors = new AsyncLocalStorage<StatsCollector>()
+
+/**
+ * Runs a call, collecting what recordScanned and recordCacheHit note during it
+ */
+export async function collectStats<T>(call: () => Promise<T>): Promise<{ value: T, filesScanned: number | undefined, durationMs: number, cacheHit: boolean }> {
+  const collector: StatsCollector = { cacheHit: false }
+  const started = performance.now()
+  const value = await collectors.run(collector, call)
+  return {
+    value,
+    filesScanned: collector.files?.size,
+    durationMs: Math.round((performance.now() - started) * 10) / 10,
+    cacheHit: collector.cacheHit,
+  }
+}
+
+/**
+ * Notes the nodes a call is about to search; a no-op outside collectStats
+ */
+export function recordScanned(nodes: TreeNode[]): void {
+  const collector = collectors.getStore()
+  if (!collector) return
+  const files = collector.files ?? (collector.files = new Set())
+  for (const node of nodes) files.add(node.path)
+}
+
+/**
+ * Notes that the result cache answered the call
+ */
+export function recordCacheHit(): void {
+  const collector = collectors.getStore()
+  if (collector) collector.cacheHit = true
+}
+
+export function appliedFilters(args: JsonObject): JsonObject {
+  const filters: JsonObject = {}
+  for (const key of FILTER_ARGUMENTS) {
+    if (args[key] !== undefined) filters[key] = args[key]
+  }
+  return filters
+}
@@ -0,0 +1,224 @@
+/**
+ * Tenants of a shared server - a tenants file lists the projects the server indexes and the
+ * tokens clients present. Each token grants a set of those projects and carries quotas; every
+ * tool call is checked against them and pinned to a granted project before it runs.
+ */
+
+import { createHash, timingSafeEqual } from 'crypto'
+import { readFileSync } from 'fs'
+import { dirname, isAbsolute, relative, resolve } from 'path'
+import { findLoadedMCPProject, getOrCreateMCPProject, isMutatingCall } from './handlers.js'
+import { estimateProjectMemory } from '../project/memory.js'
+import { sanitizeProjectId } from '../project/persistent-manager.js'
+import { createError } from '../utils/errors.js'
+import type { JsonObject } from '../types/core.js'
+
+export interface TenantQuota {
+  maxMemoryMb?: number // Estimated size of the loaded indexes of the tenant's projects
+  maxConcurrentCalls?: number // Tool calls (searches, analyses, ...) running at once
+}
+
+export interface Tenant {
+  name: string
+  tokenSha256: string // Hex; a plain `token` in the file is hashed on load
+  projects: string[] // Ids of the projects the token grants
+  quota: TenantQuota
+  readOnly: boolean // Refuse tool calls that write files
+}
+
+export interface TenantProject {
+  id: string
+  directory: string
+}
+
+export interface TenantRegistry {
+  projects: Map<string, TenantProject>
+  tenants: Tenant[]
+  activeCalls: Map<string, number> // By tenant name
+}
+
+// Tools that would reach outside the projects the file lists: both register new projects
+const SERVER_ONLY_TOOLS = new Set(['register_project', 'index_dependency'])
+
+// Tools that would start a language server, a program running on the tenant's code
+const LANGUAGE_SERVER_TOOLS = new Set(['find_definition', 'get_type_info'])
+
+// Tools that would run the project's formatter on the edited files
+const FORMATTING_TOOLS = new Set(['edit_at_symbol'])
+
+// Arguments naming a file or directory relative to the project
+const PATH_ARGUMENTS = ['file', 'path', 'specFile', 'outputFile', 'coverageFile'] as const
+
+/**
+ * Reads a tenants file. Project directories are relative to the file.
+ */
+export function loadTenants(file: string): TenantRegistry {
+  let content: unknown
+  try {
+    content = JSON.parse(readFileSync(file, 'utf-8'))
+  }
+  catch (error) {
+    throw new Error(`Cannot read tenants file ${file}: ${error instanceof Error ? error.message : String(error)}`)
+  }
+  return parseTenants(content, dirname(resolve(file)))
+}
+
+export function parseTenants(content: unknown, baseDirectory: string): TenantRegistry {
+  const { projects = [], tenants = [] } = (content ?? {}) as { projects?: unknown[], tenants?: unknown[] }
+  const registry: TenantRegistry = { projects: new Map(), tenants: [], activeCalls: new Map() }
+
+  for (const entry of projects) {
+    const { id, directory } = entry as Record<string, unknown>
+    if (typeof id !== 'string' || typeof directory !== 'string') {
+      throw new Error('Every project needs a string id and directory')
+    }
+    if (sanitizeProjectId(id) !== id) {
+      throw new Error(`Invalid project id: ${id}. Use letters, digits, dots, dashes and underscores`)
+    }
+    registry.projects.set(id, { id, directory: resolve(baseDirectory, directory) })
+  }
+
+  for (const entry of tenants) {
+    const { name, token, tokenSha256, projects: granted = [], quota = {}, readOnly = false } = entry as Record<string, unknown>
+    if (typeof name !== 'string' || !name) throw new Error('Every tenant needs a name')
+    const hash = typeof tokenSha256 === 'string' ? tokenSha256.toLowerCase() : typeof token === 'string' && token ? sha256(token) : undefined
+    if (!hash || !/^[0-9a-f]{64}$/.test(hash)) throw new Error(`Tenant ${name} needs a token or a tokenSha256`)
+    if (!Array.isArray(granted) || granted.some(id => typeof id !== 'string' || !registry.projects.has(id))) {
+      throw new Error(`Tenant ${name} grants a project the file does not list`)
+    }
+    registry.tenants.push({ name, tokenSha256: hash, projects: granted as string[], quota: quota as TenantQuota, readOnly: readOnly === true })
+  }
+
+  return registry
+}
+
+/**
+ * The tenant a bearer token belongs to
+ */
+export function authenticate(registry: TenantRegistry, token: string | undefined): Tenant {
+  const hash = token ? Buffer.from(sha256(token.replace(/^Bearer\s+/i, '')), 'hex') : undefined
+  const tenant = hash && registry.tenants.find(candidate => timingSafeEqual(Buffer.from(candidate.tokenSha256, 'hex'), hash))
+  if (!tenant) throw createError('UNAUTHENTICATED', 'A valid token is required: send it as "authorization: Bearer <token>"')
+  return tenant
+}
+
+/**
+ * The arguments a tenant's call runs with: the project it names (by id, or by the directory of
+ * a granted project) must be granted, and is set to the registered one. With a single granted
+ * project, naming none means that one. Paths must stay inside the project.
+ */
+export function authorizeCall(registry: TenantRegistry, tenant: Tenant, name: string, args: JsonObject): JsonObject {
+  if (SERVER_ONLY_TOOLS.has(name)) {
+    throw createError('PERMISSION_DENIED', `${name} is not available on a shared server: projects are listed in its tenants file`)
+  }
+  if (tenant.readOnly && isMutatingCall(name, args)) {
+    throw createError('PERMISSION_DENIED', `${name} writes files and tenant ${tenant.name} is read-only`)
+  }
+  if (name === 'batch' && Array.isArray(args.calls)) {
+    return {
+      ...args,
+      calls: args.calls.map((call) => {
+        const { tool, arguments: callArgs = {} } = call as JsonObject
+        return { ...call as JsonObject, arguments: authorizeCall(registry, tenant, String(tool), callArgs as JsonObject) }
+      }),
+    }
+  }
+
+  const project = grantedProject(registry, tenant, args)
+  if (Array.isArray(args.includeProjects)) {
+    for (const id of args.includeProjects) {
+      if (typeof id !== 'string' || !tenant.projects.includes(id)) {
+        throw createError('PERMISSION_DENIED', `Tenant ${tenant.name} has no access to project ${String(id)}`)
+      }
+    }
+  }
+  for (const key of PATH_ARGUMENTS) {
+    const value = args[key]
+    if (typeof value !== 'string') continue
+    const inside = relative(project.directory, resolve(project.directory, value))
+    if (inside.startsWith('..') || isAbsolute(inside)) {
+      throw createError('PERMISSION_DENIED', `${key} must be inside project ${project.id}: ${value}`)
+    }
+  }
+
+  const authorized: JsonObject = { ...args, projectId: project.id, directory: project.directory }
+  if (LANGUAGE_SERVER_TOOLS.has(name)) authorized.precise = false
+  if (FORMATTING_TOOLS.has(name)) authorized.format = false
+  return authorized
+}
+
+/**
+ * Runs a tenant's tool call within its quotas
+ */
+export async function runTenantCall<T>(
+  registry: TenantRegistry,
+  tenant: Tenant,
+  name: string,
+  args: JsonObject,
+  call: (name: string, args: JsonObject) => Promise<T>,
+): Promise<T> {
+  const authorized = authorizeCall(registry, tenant, name, args)
+
+  const active = registry.activeCalls.get(tenant.name) ?? 0
+  const { maxConcurrentCalls, maxMemoryMb } = tenant.quota
+  if (maxConcurrentCalls !== undefined && active >= maxConcurrentCalls) {
+    throw createError('RESOURCE_EXHAUSTED', `Tenant ${tenant.name} already runs ${active} calls, its limit`)
+  }
+  if (maxMemoryMb !== undefined) {
+    const used = tenantMemory(registry, tenant)
+    if (used > maxMemoryMb * 1024 * 1024) {
+      throw createError('RESOURCE_EXHAUSTED', `Tenant ${tenant.name} uses ${(used / 1024 / 1024).toFixed(1)}MB of index memory, over its ${maxMemoryMb}MB quota`)
+    }
+  }
+
+  registry.activeCalls.set(tenant.name, active + 1)
+  try {
+    // Included projects are searched only once indexed, and tenants cannot register them
+    for (const id of Array.isArray(authorized.includeProjects) ? authorized.includeProjects : []) {
+      const included = registry.projects.get(String(id))!
+      await getOrCreateMCPProject(included.id, included.directory)
+    }
+    return await call(name, authorized)
+  }
+  finally {
+    registry.activeCalls.set(tenant.name, (registry.activeCalls.get(tenant.name) ?? 1) - 1)
+  }
+}
+
+/**
+ * Estimated bytes of the indexes loaded for a tenant's projects
+ */
+export function tenantMemory(registry: TenantRegistry, tenant: Tenant): number {
+  let bytes = 0
+  for (const id of tenant.projects) {
+    const project = findLoadedMCPProject(registry.projects.get(id)!.directory)
+    if (project) bytes += estimateProjectMemory(project)
+  }
+  return bytes
+}
+
+function grantedProject(registry: TenantRegistry, tenant: Tenant, args: JsonObject): TenantProject {
+  const { projectId, directory } = args
+  let id: string | undefined
+  if (typeof projectId === 'string' && projectId) {
+    id = projectId
+  }
+  else if (typeof directory === 'string' && directory) {
+    id = tenant.projects.find(candidate => registry.projects.get(candidate)!.directory === resolve(directory)) ?? directory
+  }
+  else if (tenant.projects.length === 1) {
+    id = tenant.projects[0]
+  }
+  else {
+    throw createError('PERMISSION_DENIED', `projectId is required: tenant ${tenant.name} has access to ${tenant.projects.join(', ')}`)
+  }
+
+  if (!id || !tenant.projects.includes(id)) {
+    throw createError('PERMISSION_DENIED', `Tenant ${tenant.name} has no access to project ${id}`)
+  }
+  return registry.projects.get(id)!
+}
+
+function sha256(text: string): string {
+  return createHash('sha256').update(text).digest('hex')
+}
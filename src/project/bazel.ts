@@ -0,0 +1,252 @@
+/**
+ * Bazel/Buck build graph awareness - reads BUILD files to learn target boundaries and visibility
+ */
+
+import { join, relative, resolve, sep } from 'path'
+import { readFileSync, readdirSync } from 'fs'
+import { isDirectory, isFile, globToRegExp, splitTopLevel } from '../utils/helpers.js'
+import { GLOBAL_IGNORE_DIRS } from '../constants/index.js'
+import type { BazelTarget } from '../types/core.js'
+
+const BUILD_FILE_NAMES = ['BUILD.bazel', 'BUILD', 'BUCK']
+const WORKSPACE_MARKERS = ['WORKSPACE', 'WORKSPACE.bazel', 'MODULE.bazel', '.buckconfig']
+const SOURCE_ATTRIBUTES = new Set(['srcs', 'hdrs', 'data', 'resources'])
+
+interface RuleCall {
+  rule: string
+  args: Map<string, string>
+}
+
+/**
+ * Checks whether a directory is the root of a Bazel or Buck workspace
+ */
+export function isBazelWorkspace(directory: string): boolean {
+  return WORKSPACE_MARKERS.some(marker => isFile(join(directory, marker)))
+}
+
+/**
+ * Loads every target declared by BUILD files under a workspace root
+ */
+export function loadBazelTargets(rootDirectory: string): BazelTarget[] {
+  const root = resolve(rootDirectory)
+  const targets: BazelTarget[] = []
+
+  for (const buildFile of findBuildFiles(root)) {
+    const packageDir = resolve(buildFile, '..')
+    const packageName = relative(root, packageDir).split(sep).join('/')
+    targets.push(...parseBuildFile(readText(buildFile), packageDir, packageName, buildFile))
+  }
+
+  return targets
+}
+
+/**
+ * Extracts targets from BUILD file content. Only literal attribute values are understood;
+ * macros and computed lists are skipped rather than guessed at.
+ */
+export function parseBuildFile(content: string, packageDir: string, packageName: string, buildFile = join(packageDir, 'BUILD')): BazelTarget[] {
+  const calls = extractRuleCalls(stripComments(content))
+  const packageCall = calls.find(call => call.rule === 'package')
+  const defaultVisibility = packageCall ? extractStrings(packageCall.args.get('default_visibility') ?? '') : ['//visibility:private']
+  const targets: BazelTarget[] = []
+
+  for (const call of calls) {
+    const nameValue = call.args.get('name')
+    if (!nameValue) continue
+    const [name] = extractStrings(nameValue)
+    if (!name) continue
+
+    const srcs: string[] = []
+    const globs: { include: string[], exclude: string[] }[] = []
+
+    for (const [attribute, value] of call.args) {
+      if (!SOURCE_ATTRIBUTES.has(attribute)) continue
+      const { literals, globCalls } = splitSourceValue(value)
+      for (const literal of literals) {
+        if (!literal.startsWith(':') && !literal.startsWith('//') && !literal.startsWith('@')) {
+          srcs.push(join(packageDir, ...literal.split('/')))
+        }
+      }
+      globs.push(...globCalls)
+    }
+
+    const visibilityValue = call.args.get('visibility')
+    targets.push({
+      label: `//${packageName}:${name}`,
+      name,
+      rule: call.rule,
+      packageDir,
+      buildFile,
+      srcs,
+      globs,
+      visibility: visibilityValue ? extractStrings(visibilityValue) : defaultVisibility,
+    })
+  }
+
+  return targets
+}
+
+/**
+ * Returns the targets that list a file among their sources
+ */
+export function targetsFor(filePath: string, targets: BazelTarget[]): BazelTarget[] {
+  const absolute = resolve(filePath)
+  return targets.filter(target => targetContains(target, absolute))
+}
+
+/**
+ * Looks up a target by label, accepting the `//pkg` shorthand for `//pkg:pkg`
+ */
+export function findBazelTarget(label: string, targets: BazelTarget[]): BazelTarget | undefined {
+  const normalized = label.includes(':') ? label : `${label}:${label.split('/').pop()}`
+  return targets.find(target => target.label === normalized)
+}
+
+/**
+ * Checks whether a file is one of a target's sources
+ */
+export function targetContains(target: BazelTarget, filePath: string): boolean {
+  if (target.srcs.includes(filePath)) return true
+  if (!filePath.startsWith(target.packageDir + sep)) return false
+
+  const relativePath = relative(target.packageDir, filePath).split(sep).join('/')
+  return target.globs.some(({ include, exclude }) =>
+    include.some(pattern => globToRegExp(pattern).test(relativePath))
+    && !exclude.some(pattern => globToRegExp(pattern).test(relativePath)),
+  )
+}
+
+function findBuildFiles(root: string): string[] {
+  const buildFiles: string[] = []
+
+  function search(dir: string) {
+    let entries: string[] = []
+    try {
+      entries = readdirSync(dir)
+    }
+    catch {
+      return
+    }
+
+    const buildFile = BUILD_FILE_NAMES.find(name => entries.includes(name))
+    if (buildFile && isFile(join(dir, buildFile))) {
+      buildFiles.push(join(dir, buildFile))
+    }
+
+    for (const entry of entries) {
+      if (entry.startsWith('.') || entry.startsWith('bazel-') || GLOBAL_IGNORE_DIRS.has(entry)) continue
+      const fullPath = join(dir, entry)
+      if (isDirectory(fullPath)) {
+        search(fullPath)
+      }
+    }
+  }
+
+  search(root)
+  return buildFiles
+}
+
+function extractRuleCalls(content: string): RuleCall[] {
+  const calls: RuleCall[] = []
+  const callPattern = /^([A-Za-z_][\w.]*)\s*\(/gm
+  let match
+
+  while ((match = callPattern.exec(content)) !== null) {
+    const start = match.index + match[0].length
+    const end = findClosingParen(content, start)
+    if (end === -1) break
+
+    calls.push({ rule: match[1]!, args: splitKeywordArgs(content.substring(start, end)) })
+    callPattern.lastIndex = end + 1
+  }
+
+  return calls
+}
+
+function findClosingParen(content: string, start: number): number {
+  let depth = 1
+  let quote: string | null = null
+
+  for (let i = start; i < content.length; i++) {
+    const char = content[i]!
+    if (quote) {
+      if (char === '\\') i++
+      else if (char === quote) quote = null
+      continue
+    }
+    if (char === '"' || char === '\'') quote = char
+    else if (char === '(' || char === '[' || char === '{') depth++
+    else if (char === ')' || char === ']' || char === '}') {
+      depth--
+      if (depth === 0) return i
+    }
+  }
+
+  return -1
+}
+
+function splitKeywordArgs(body: string): Map<string, string> {
+  const args = new Map<string, string>()
+  for (const part of splitTopLevel(body, ',')) {
+    const match = part.match(/^\s*(\w+)\s*=([\s\S]*)$/)
+    if (match) {
+      args.set(match[1]!, match[2]!.trim())
+    }
+  }
+  return args
+}
+
+function splitSourceValue(value: string): { literals: string[], globCalls: { include: string[], exclude: string[] }[] } {
+  const literals: string[] = []
+  const globCalls: { include: string[], exclude: string[] }[] = []
+
+  for (const operand of splitTopLevel(value, '+')) {
+    const trimmed = operand.trim()
+    if (trimmed.startsWith('glob(')) {
+      const globBody = trimmed.substring(5, trimmed.lastIndexOf(')'))
+      const keywordArgs = splitKeywordArgs(globBody)
+      const positional = splitTopLevel(globBody, ',').find(arg => !/^\s*\w+\s*=/.test(arg)) ?? ''
+      globCalls.push({
+        include: extractStrings(keywordArgs.get('include') ?? positional),
+        exclude: extractStrings(keywordArgs.get('exclude') ?? ''),
+      })
+    }
+    else if (trimmed.startsWith('[') || trimmed.startsWith('"') || trimmed.startsWith('\'')) {
+      literals.push(...extractStrings(trimmed))
+    }
+  }
+
+  return { literals, globCalls }
+}
+
+function extractStrings(value: string): string[] {
+  return Array.from(value.matchAll(/"((?:[^"\\]|\\.)*)"|'((?:[^'\\]|\\.)*)'/g), match => match[1] ?? match[2] ?? '')
+}
+
+function stripComments(content: string): string {
+  return content
+    .split('\n')
+    .map((line) => {
+      let quote: string | null = null
+      for (let i = 0; i < line.length; i++) {
+        const char = line[i]!
+        if (quote) {
+          if (char === '\\') i++
+          else if (char === quote) quote = null
+        }
+        else if (char === '"' || char === '\'') quote = char
+        else if (char === '#') return line.substring(0, i)
+      }
+      return line
+    })
+    .join('\n')
+}
+
+function readText(filePath: string): string {
+  try {
+    return readFileSync(filePath, 'utf-8')
+  }
+  catch {
+    return ''
+  }
+}
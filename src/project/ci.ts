@@ -0,0 +1,448 @@
+/**
+ * CI pipeline discovery - reads GitHub Actions workflows and GitLab CI pipelines into jobs and
+ * the commands their steps run, linking each command to the package.json script, task runner
+ * target or local script it invokes, so a failing job can be reproduced locally
+ */
+
+import { readFileSync, readdirSync } from 'fs'
+import { dirname, join, relative, resolve, sep } from 'path'
+import { CI_PIPELINE_PATTERNS } from '../constants/index.js'
+import { parseYaml, getYamlKeyLine } from '../utils/yaml.js'
+import { isFile } from '../utils/helpers.js'
+import { extractTasks, type TaskRunner } from './tasks.js'
+import type { TreeNode } from '../types/core.js'
+
+export type CIProvider = 'github-actions' | 'gitlab-ci'
+
+export interface CIInvocation {
+  kind: 'npm-script' | TaskRunner | 'script'
+  name: string
+  file: string
+  line?: number
+  commands: string[] // What the script or target runs in turn
+}
+
+export interface CICommand {
+  command: string
+  line: number
+  workingDirectory: string
+  invokes: CIInvocation[]
+}
+
+export interface CIStep {
+  name?: string
+  line: number
+  uses?: string // Action the step runs instead of commands
+  commands: CICommand[]
+}
+
+export interface CIJob {
+  id: string
+  name: string
+  provider: CIProvider
+  file: string
+  line: number
+  workflow?: string // Workflow name, or the GitLab stage
+  runsOn?: string
+  image?: string
+  needs: string[]
+  matrix?: Record<string, unknown[]>
+  env: Record<string, string>
+  steps: CIStep[]
+}
+
+// Top-level GitLab keys that configure the pipeline rather than declare a job
+const GITLAB_RESERVED_KEYS = new Set([
+  'stages', 'variables', 'default', 'include', 'workflow', 'image', 'services', 'cache',
+  'before_script', 'after_script', 'types',
+])
+
+const GITLAB_SCRIPT_KEYS = ['before_script', 'script', 'after_script'] as const
+
+const SCRIPT_INTERPRETERS = new Set(['bash', 'sh', 'zsh', 'pwsh', 'python', 'python3', 'node', 'ruby', 'perl', 'tsx', 'ts-node'])
+
+const PACKAGE_MANAGERS = new Set(['npm', 'yarn', 'pnpm', 'bun'])
+
+// npm commands that run the script of the same name
+const NPM_SCRIPT_COMMANDS: Record<string, string> = { test: 'test', t: 'test', start: 'start', stop: 'stop', restart: 'restart' }
+
+const DIRECTORY_FLAGS = new Set(['--prefix', '--cwd', '--dir', '-C', '-d', '--directory', '--working-directory'])
+
+const TASK_RUNNER_FILES: Record<TaskRunner, string[]> = {
+  make: ['GNUmakefile', 'makefile', 'Makefile'],
+  task: ['Taskfile.yml', 'Taskfile.yaml', 'taskfile.yml', 'taskfile.yaml', 'Taskfile.dist.yml'],
+  just: ['justfile', 'Justfile', '.justfile'],
+}
+
+/**
+ * Finds the CI provider of a pipeline file by its path
+ */
+export function getCIProvider(filePath: string): CIProvider | null {
+  if (CI_PIPELINE_PATTERNS.GITHUB_ACTIONS.some(pattern => pattern.test(filePath))) return 'github-actions'
+  if (CI_PIPELINE_PATTERNS.GITLAB_CI.some(pattern => pattern.test(filePath))) return 'gitlab-ci'
+  return null
+}
+
+/**
+ * Lists the jobs of every CI pipeline of a project. Pipelines come from the given file nodes, or
+ * from disk under `root` when they were not indexed. Scripts, Makefiles, Taskfiles and justfiles
+ * the commands invoke are read the same way.
+ */
+export function extractCIJobs(fileNodes: TreeNode[], root: string): CIJob[] {
+  const contents = new Map(fileNodes.map(node => [node.path, node.content]))
+  const readContent = (path: string) => contents.get(path) ?? (isFile(path) ? readText(path) : undefined)
+  const linker = createLinker(root, readContent)
+
+  const pipelines = new Set([
+    ...fileNodes.filter(node => getCIProvider(node.path)).map(node => node.path),
+    ...findPipelineFiles(root),
+  ])
+
+  const jobs: CIJob[] = []
+  for (const file of pipelines) {
+    const content = readContent(file)
+    if (!content) continue
+    jobs.push(...(getCIProvider(file) === 'github-actions'
+      ? parseGitHubWorkflow(content, file, root)
+      : parseGitLabPipeline(content, file, root)))
+  }
+
+  for (const command of jobs.flatMap(job => job.steps.flatMap(step => step.commands))) {
+    command.invokes = linker(command)
+  }
+  return jobs
+}
+
+/**
+ * Reads the jobs of a GitHub Actions workflow. Commands run from the repository root unless the
+ * step, job or workflow sets a `working-directory`.
+ */
+export function parseGitHubWorkflow(content: string, file: string, root: string): CIJob[] {
+  const workflow = parseYaml(content)
+  if (!isMapping(workflow) || !isMapping(workflow.jobs)) return []
+
+  const jobs = workflow.jobs
+  const lines = content.split('\n')
+  const workflowName = typeof workflow.name === 'string' ? workflow.name : relative(root, file)
+
+  return Object.entries(jobs).filter((entry): entry is [string, Record<string, unknown>] => isMapping(entry[1])).map(([id, job]) => {
+    const line = getYamlKeyLine(jobs, id) ?? 1
+    const jobDirectory = defaultWorkingDirectory(job) ?? defaultWorkingDirectory(workflow) ?? '.'
+    const strategy = isMapping(job.strategy) ? job.strategy : {}
+    const container = isMapping(job.container) ? job.container.image : job.container
+
+    return {
+      id,
+      name: typeof job.name === 'string' ? job.name : id,
+      provider: 'github-actions' as const,
+      file,
+      line,
+      workflow: workflowName,
+      runsOn: Array.isArray(job['runs-on']) ? job['runs-on'].join(', ') : stringValue(job['runs-on']),
+      image: stringValue(container),
+      needs: stringList(job.needs),
+      matrix: readMatrix(strategy.matrix),
+      env: { ...stringMap(workflow.env), ...stringMap(job.env) },
+      steps: (Array.isArray(job.steps) ? job.steps.filter(isMapping) : []).map((step) => {
+        const stepLine = Math.min(...Object.keys(step).map(key => getYamlKeyLine(step, key) ?? Infinity))
+        const directory = resolve(root, stringValue(step['working-directory']) ?? jobDirectory)
+        return {
+          name: stringValue(step.name),
+          line: Number.isFinite(stepLine) ? stepLine : line,
+          uses: stringValue(step.uses),
+          commands: typeof step.run === 'string'
+            ? readCommands([step.run], lines, getYamlKeyLine(step, 'run') ?? line, directory)
+            : [],
+        }
+      }),
+    }
+  })
+}
+
+/**
+ * Reads the jobs of a GitLab CI pipeline, one step per `before_script`, `script` and
+ * `after_script`. Hidden `.template` jobs are only used through `extends`, and commands run from
+ * the repository root.
+ */
+export function parseGitLabPipeline(content: string, file: string, root: string): CIJob[] {
+  const pipeline = parseYaml(content)
+  if (!isMapping(pipeline)) return []
+
+  const lines = content.split('\n')
+  const defaults = isMapping(pipeline.default) ? pipeline.default : {}
+
+  // The mapping declaring a key for a job: the job itself, a template it extends, or the defaults
+  const owner = (job: Record<string, unknown>, key: string, depth = 0): Record<string, unknown> | undefined => {
+    if (key in job) return job
+    if (depth > 8) return undefined
+    for (const parent of stringList(job.extends)) {
+      const template = pipeline[parent]
+      const found = isMapping(template) ? owner(template, key, depth + 1) : undefined
+      if (found) return found
+    }
+    return undefined
+  }
+  const field = (job: Record<string, unknown>, key: string, inherited = true) => {
+    const mapping = owner(job, key) ?? (inherited ? [defaults, pipeline].find(candidate => key in candidate) : undefined)
+    return mapping ? { value: mapping[key], line: getYamlKeyLine(mapping, key) } : undefined
+  }
+
+  return Object.entries(pipeline)
+    .filter((entry): entry is [string, Record<string, unknown>] =>
+      !GITLAB_RESERVED_KEYS.has(entry[0]) && !entry[0].startsWith('.') && isMapping(entry[1]))
+    .filter(([, job]) => field(job, 'script', false) || job.trigger)
+    .map(([id, job]) => {
+      const line = getYamlKeyLine(pipeline, id) ?? 1
+      const image = field(job, 'image')?.value
+      return {
+        id,
+        name: id,
+        provider: 'gitlab-ci' as const,
+        file,
+        line,
+        workflow: stringValue(field(job, 'stage', false)?.value) ?? 'test',
+        runsOn: stringList(field(job, 'tags')?.value).join(', ') || undefined,
+        image: stringValue(isMapping(image) ? image.name : image),
+        needs: (Array.isArray(job.needs) ? job.needs : [])
+          .map(need => isMapping(need) ? need.job : need)
+          .filter((need): need is string => typeof need === 'string'),
+        env: { ...stringMap(pipeline.variables), ...stringMap(field(job, 'variables', false)?.value) },
+        steps: GITLAB_SCRIPT_KEYS.flatMap((key) => {
+          const script = field(job, key)
+          if (!script) return []
+          const scriptLine = script.line ?? line
+          return [{ name: key, line: scriptLine, commands: readCommands(stringList(script.value), lines, scriptLine, root) }]
+        }),
+      }
+    })
+}
+
+/**
+ * Splits step scripts into commands, joining `\` continuations and skipping comments. Each
+ * command is located on the first line at or after `fromLine` holding its text, and a `cd`
+ * moves the working directory of the commands after it.
+ */
+function readCommands(scripts: string[], lines: string[], fromLine: number, workingDirectory: string): CICommand[] {
+  const commands: CICommand[] = []
+  let line = fromLine
+  let searchFrom = fromLine - 1
+  let directory = workingDirectory
+
+  for (const script of scripts) {
+    const physical = script.split('\n')
+    for (let i = 0; i < physical.length; i++) {
+      const first = physical[i]!.trim()
+      let command = first
+      while (command.endsWith('\\') && i + 1 < physical.length) {
+        command = `${command.slice(0, -1).trimEnd()} ${physical[++i]!.trim()}`
+      }
+      if (!command || command.startsWith('#')) continue
+
+      const found = lines.findIndex((text, index) => index >= searchFrom && text.includes(first))
+      if (found !== -1) {
+        line = found + 1
+        searchFrom = found + 1
+      }
+      commands.push({ command, line, workingDirectory: directory, invokes: [] })
+
+      for (const segment of splitSegments(command)) {
+        const cd = segment.match(/^cd\s+(['"]?)([^'"\s]+)\1$/)
+        if (cd && !cd[2]!.includes('$')) directory = resolve(directory, cd[2]!)
+      }
+    }
+  }
+  return commands
+}
+
+/**
+ * Builds the function resolving what a command invokes. Package scripts come from the nearest
+ * package.json, task runner targets from the runner's file in the working directory, and local
+ * scripts from their path.
+ */
+function createLinker(root: string, readContent: (path: string) => string | undefined): (command: CICommand) => CIInvocation[] {
+  const scripts = new Map<string, { file: string, scripts: Record<string, string> } | undefined>()
+
+  const packageScripts = (directory: string) => {
+    for (let current = directory; ; current = dirname(current)) {
+      if (!scripts.has(current)) scripts.set(current, readPackageScripts(join(current, 'package.json'), readContent))
+      const found = scripts.get(current)
+      if (found || current === root || !current.startsWith(root + sep)) return found
+    }
+  }
+
+  return (command) => {
+    const invocations: CIInvocation[] = []
+    let directory = command.workingDirectory
+
+    for (const segment of splitSegments(command.command)) {
+      const words = segment.split(/\s+/)
+      while (words.length > 0 && (/^\w+=/.test(words[0]!) || words[0] === 'sudo' || words[0] === 'time')) words.shift()
+      const [program, ...args] = words.map(unquote)
+      if (!program) continue
+
+      if (program === 'cd' && args[0] && !args[0].includes('$')) {
+        directory = resolve(directory, args[0])
+        continue
+      }
+
+      const { directory: target, positional } = readArguments(args, directory)
+      if (PACKAGE_MANAGERS.has(program)) {
+        const found = packageScripts(target)
+        const [subcommand, script] = positional
+        const name = subcommand === 'run' || subcommand === 'run-script'
+          ? script
+          : program === 'npm' ? NPM_SCRIPT_COMMANDS[subcommand ?? ''] : subcommand
+        const body = name ? found?.scripts[name] : undefined
+        if (found && name && body !== undefined) {
+          invocations.push({ kind: 'npm-script', name, file: found.file, line: lineOfKey(readContent(found.file) ?? '', name), commands: [body] })
+        }
+      }
+      else if (program === 'make' || program === 'task' || program === 'just') {
+        invocations.push(...linkTasks(program, positional.filter(word => !word.includes('=')), args, target, readContent))
+      }
+      else {
+        const path = SCRIPT_INTERPRETERS.has(program) ? positional[0] : program
+        if (path && /[/\\.]/.test(path) && !path.includes('$')) {
+          const file = resolve(target, path)
+          if (file.startsWith(root + sep) && readContent(file) !== undefined) {
+            invocations.push({ kind: 'script', name: relative(root, file), file, commands: [] })
+          }
+        }
+      }
+    }
+    return invocations
+  }
+}
+
+function linkTasks(
+  runner: TaskRunner,
+  targets: string[],
+  args: string[],
+  directory: string,
+  readContent: (path: string) => string | undefined,
+): CIInvocation[] {
+  const fileFlag = args.findIndex(arg => arg === '-f' || arg === '--file' || arg === '--justfile' || arg === '-t' || arg === '--taskfile')
+  const candidates = fileFlag !== -1 && args[fileFlag + 1] ? [args[fileFlag + 1]!] : TASK_RUNNER_FILES[runner]
+  const file = candidates.map(name => resolve(directory, name)).find(path => readContent(path) !== undefined)
+  if (!file) return []
+
+  const tasks = extractTasks([{ id: file, type: 'file', path: file, content: readContent(file) }])
+    .filter(task => task.runner === runner)
+  // Without targets, make and just run the first one and task runs `default`
+  const names = targets.length > 0 ? targets : runner === 'task' ? ['default'] : tasks.slice(0, 1).map(task => task.name)
+
+  return names.flatMap((name) => {
+    const task = tasks.find(candidate => candidate.name === name)
+    return task ? [{ kind: runner, name, file, line: task.line, commands: task.commands }] : []
+  })
+}
+
+/**
+ * Separates the directory flags of a command (`-C dir`, `--prefix dir`) from its positional
+ * arguments, skipping other flags
+ */
+function readArguments(args: string[], directory: string): { directory: string, positional: string[] } {
+  const positional: string[] = []
+  let target = directory
+  for (let i = 0; i < args.length; i++) {
+    const arg = args[i]!
+    const [flag, inline] = arg.split('=', 2) as [string, string | undefined]
+    if (DIRECTORY_FLAGS.has(flag)) {
+      const value = inline ?? args[++i]
+      if (value && !value.includes('$')) target = resolve(directory, value)
+    }
+    else if (arg === '-f' || arg === '--file' || arg === '-t' || arg === '--taskfile' || arg === '--justfile') {
+      i++
+    }
+    else if (arg === '--') {
+      break
+    }
+    else if (!arg.startsWith('-')) {
+      positional.push(arg)
+    }
+  }
+  return { directory: target, positional }
+}
+
+function splitSegments(command: string): string[] {
+  return command.split(/\s*(?:&&|\|\||;|\|)\s*/).map(segment => segment.trim()).filter(Boolean)
+}
+
+function findPipelineFiles(root: string): string[] {
+  const workflows = join(root, '.github', 'workflows')
+  return [
+    ...listFiles(workflows).filter(name => /\.ya?ml$/.test(name)).map(name => join(workflows, name)),
+    ...['.gitlab-ci.yml', '.gitlab-ci.yaml'].map(name => join(root, name)).filter(isFile),
+  ]
+}
+
+function readPackageScripts(path: string, readContent: (path: string) => string | undefined): { file: string, scripts: Record<string, string> } | undefined {
+  const content = readContent(path)
+  if (content === undefined) return undefined
+  try {
+    const scripts: unknown = JSON.parse(content).scripts
+    return { file: path, scripts: isMapping(scripts) ? stringMap(scripts) : {} }
+  }
+  catch {
+    // An unparsable package.json has no scripts to link
+    return { file: path, scripts: {} }
+  }
+}
+
+function defaultWorkingDirectory(mapping: Record<string, unknown>): string | undefined {
+  const defaults = isMapping(mapping.defaults) ? mapping.defaults : {}
+  return isMapping(defaults.run) ? stringValue(defaults.run['working-directory']) : undefined
+}
+
+function readMatrix(matrix: unknown): Record<string, unknown[]> | undefined {
+  if (!isMapping(matrix)) return undefined
+  const axes = Object.entries(matrix).filter(([key, values]) => key !== 'include' && key !== 'exclude' && Array.isArray(values))
+  return axes.length > 0 ? Object.fromEntries(axes) as Record<string, unknown[]> : undefined
+}
+
+function lineOfKey(content: string, key: string): number | undefined {
+  const index = content.indexOf(`"${key}"`)
+  return index === -1 ? undefined : content.substring(0, index).split('\n').length
+}
+
+function listFiles(directory: string): string[] {
+  try {
+    return readdirSync(directory)
+  }
+  catch {
+    // Projects without the directory have no pipelines in it
+    return []
+  }
+}
+
+function readText(path: string): string | undefined {
+  try {
+    return readFileSync(path, 'utf-8')
+  }
+  catch {
+    // Unreadable files are treated as missing
+    return undefined
+  }
+}
+
+function unquote(word: string): string {
+  return word.replace(/^(["'])(.*)\1$/, '$2')
+}
+
+function stringValue(value: unknown): string | undefined {
+  return typeof value === 'string' ? value : typeof value === 'number' ? String(value) : undefined
+}
+
+function stringList(value: unknown): string[] {
+  if (typeof value === 'string') return [value]
+  return Array.isArray(value) ? value.filter((item): item is string => typeof item === 'string') : []
+}
+
+function stringMap(value: unknown): Record<string, string> {
+  if (!isMapping(value)) return {}
+  return Object.fromEntries(Object.entries(value).map(([key, entry]) => [key, isMapping(entry) ? String(entry.value ?? '') : String(entry)]))
+}
+
+function isMapping(value: unknown): value is Record<string, unknown> {
+  return Boolean(value) && typeof value === 'object' && !Array.isArray(value)
+}
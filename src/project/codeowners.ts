@@ -0,0 +1,114 @@
+/**
+ * CODEOWNERS support - reads the ownership rules of GitHub/GitLab CODEOWNERS files so code
+ * locations can be reported with the team that owns them
+ */
+
+import { join, relative, resolve, sep } from 'path'
+import { readFileSync } from 'fs'
+import { PROJECT_FILES } from '../constants/index.js'
+import { globToRegExp, isFile } from '../utils/helpers.js'
+
+export interface CodeOwnersRule {
+  pattern: string
+  owners: string[] // Empty when the rule explicitly leaves paths unowned
+  line: number
+}
+
+export interface CodeOwners {
+  directory: string
+  file: string
+  rules: CompiledRule[]
+}
+
+export interface OwnerMatch {
+  owners: string[]
+  rule?: CodeOwnersRule
+}
+
+export interface CompiledRule extends CodeOwnersRule {
+  regex: RegExp
+  directoryOnly: boolean // Trailing slash: matches what is below a directory, not a file of that name
+  shallow: boolean // Last segment is `*`: matches direct children only
+}
+
+/**
+ * Loads the first CODEOWNERS file found in the usual locations, or undefined when the
+ * project has none
+ */
+export function loadCodeOwners(directory: string): CodeOwners | undefined {
+  const file = PROJECT_FILES.CODEOWNERS.map(location => join(directory, location)).find(isFile)
+  if (!file) return undefined
+
+  return { directory, file, rules: parseCodeOwners(readFileSync(file, 'utf-8')) }
+}
+
+/**
+ * Parses CODEOWNERS content. GitLab section headers (`[Section]`) are skipped; their rules
+ * apply like any other.
+ */
+export function parseCodeOwners(content: string): CompiledRule[] {
+  const rules: CompiledRule[] = []
+
+  content.split('\n').forEach((rawLine, index) => {
+    const line = rawLine.replace(/(^|\s)#.*$/, '').trim()
+    if (!line || /^\^?\[[^\]]+\](?:\[\d+\])?(?:\s|$)/.test(line)) return
+
+    const [pattern, ...owners] = line.split(/\s+/)
+    if (!pattern) return
+
+    rules.push({ pattern, owners, line: index + 1, ...compilePattern(pattern.replace(/\\#/g, '#')) })
+  })
+
+  return rules
+}
+
+/**
+ * Returns the owners of a path (absolute, or relative to the project directory). The last
+ * matching rule wins, as on GitHub.
+ */
+export function findOwners(codeOwners: CodeOwners, path: string): OwnerMatch {
+  const relativePath = relative(codeOwners.directory, resolve(codeOwners.directory, path)).split(sep).join('/')
+  if (relativePath.startsWith('..')) return { owners: [] }
+
+  for (let i = codeOwners.rules.length - 1; i >= 0; i--) {
+    const rule = codeOwners.rules[i]!
+    if (matchesRule(rule, relativePath)) {
+      return { owners: rule.owners, rule: { pattern: rule.pattern, owners: rule.owners, line: rule.line } }
+    }
+  }
+
+  return { owners: [] }
+}
+
+/**
+ * Owners of a finding or result location (`path` or `path:line`), or undefined when the
+ * project has no CODEOWNERS file
+ */
+export function ownersOf(codeOwners: CodeOwners | undefined, location: string): string[] | undefined {
+  return codeOwners ? findOwners(codeOwners, location.replace(/:\d+$/, '')).owners : undefined
+}
+
+function compilePattern(pattern: string): Pick<CompiledRule, 'regex' | 'directoryOnly' | 'shallow'> {
+  const directoryOnly = pattern.endsWith('/')
+  const body = pattern.replace(/^\/+/, '').replace(/\/+$/, '')
+  // Like .gitignore, a pattern with a slash before its end is relative to the root
+  const anchored = pattern.startsWith('/') || body.includes('/')
+
+  return {
+    regex: globToRegExp(anchored ? body : `**/${body}`),
+    directoryOnly,
+    shallow: body === '*' || body.endsWith('/*'),
+  }
+}
+
+function matchesRule(rule: CompiledRule, relativePath: string): boolean {
+  if (!rule.directoryOnly && rule.regex.test(relativePath)) return true
+  if (rule.shallow) return false
+
+  // A matching directory owns everything below it
+  const segments = relativePath.split('/')
+  for (let i = 1; i < segments.length; i++) {
+    if (rule.regex.test(segments.slice(0, i).join('/'))) return true
+  }
+  return false
+}
@@ -0,0 +1,182 @@
+/**
+ * Dependency source resolution - locates a third-party package's sources on disk so it can be
+ * indexed on demand (node_modules, the Go module cache, or Python site-packages)
+ */
+
+import { dirname, join, resolve } from 'path'
+import { homedir } from 'os'
+import { readdirSync, readFileSync } from 'fs'
+import { execFile } from 'child_process'
+import { promisify } from 'util'
+import { isDirectory, isFile } from '../utils/helpers.js'
+
+const execFileAsync = promisify(execFile)
+
+const NPM_PACKAGE_NAME = /^(@[a-z0-9-~][\w.-]*\/)?[a-z0-9-~][\w.-]*$/
+
+export type DependencyEcosystem = 'npm' | 'go' | 'python'
+
+export interface DependencySource {
+  name: string
+  ecosystem: DependencyEcosystem
+  directory: string
+  version?: string
+  file?: string // A single-file module, indexed without the rest of its directory
+}
+
+/**
+ * Resolves a dependency's source directory relative to a project. When no ecosystem is
+ * given, npm, Go and Python are tried in that order.
+ */
+export async function resolveDependencySource(
+  projectDirectory: string,
+  name: string,
+  ecosystem?: DependencyEcosystem,
+): Promise<DependencySource | null> {
+  const root = resolve(projectDirectory)
+  const ecosystems: DependencyEcosystem[] = ecosystem ? [ecosystem] : ['npm', 'go', 'python']
+
+  for (const candidate of ecosystems) {
+    const source = candidate === 'npm'
+      ? resolveNodeModule(root, name)
+      : candidate === 'go'
+        ? await resolveGoModule(root, name)
+        : await resolvePythonPackage(root, name)
+    if (source) return source
+  }
+
+  return null
+}
+
+/**
+ * Finds a package in the nearest node_modules, walking up like Node's resolver
+ */
+export function resolveNodeModule(projectDirectory: string, name: string): DependencySource | null {
+  // A package name, optionally scoped; no segment may start with a dot, so it cannot climb out of node_modules
+  if (!NPM_PACKAGE_NAME.test(name)) return null
+  let current = resolve(projectDirectory)
+
+  while (true) {
+    const candidate = join(current, 'node_modules', ...name.split('/'))
+    if (isDirectory(candidate)) {
+      return { name, ecosystem: 'npm', directory: candidate, version: readPackageVersion(candidate) }
+    }
+
+    const parent = dirname(current)
+    if (parent === current) return null
+    current = parent
+  }
+}
+
+/**
+ * Finds a required Go module in the module cache using the version pinned by go.mod
+ */
+export async function resolveGoModule(projectDirectory: string, name: string): Promise<DependencySource | null> {
+  const goMod = readText(join(projectDirectory, 'go.mod'))
+  const version = findGoRequireVersion(goMod, name)
+  if (!version) return null
+
+  const cacheDir = await getGoModCache()
+  const directory = join(cacheDir, `${escapeGoModulePath(name)}@${version}`)
+  return isDirectory(directory) ? { name, ecosystem: 'go', directory, version } : null
+}
+
+/**
+ * Finds an installed Python package in the project's virtualenv or the interpreter's
+ * site-packages, as a `name/` package or a `name.py` module there, dots naming subpackages.
+ * Nothing is imported, so none of the package's code runs.
+ */
+export async function resolvePythonPackage(projectDirectory: string, name: string): Promise<DependencySource | null> {
+  const moduleName = name.replace(/-/g, '_')
+  if (!/^[A-Za-z_]\w*(?:\.[A-Za-z_]\w*)*$/.test(moduleName)) return null
+  const envDirs = [process.env.VIRTUAL_ENV, join(projectDirectory, '.venv'), join(projectDirectory, 'venv')]
+    .filter((dir): dir is string => Boolean(dir) && isDirectory(dir!))
+
+  for (const sitePackages of [...envDirs.flatMap(findSitePackages), ...await getInterpreterSitePackages()]) {
+    const path = join(sitePackages, ...moduleName.split('.'))
+    if (isDirectory(path)) {
+      return { name, ecosystem: 'python', directory: path }
+    }
+    if (isFile(`${path}.py`)) {
+      return { name, ecosystem: 'python', directory: dirname(path), file: `${path}.py` }
+    }
+  }
+  return null
+}
+
+/**
+ * Reads the version a go.mod requires for a module, from single-line or block form
+ */
+export function findGoRequireVersion(goMod: string, name: string): string | null {
+  const escaped = name.replace(/[.*+?^${}()|[\]\\]/g, '\\$&')
+  const match = goMod.match(new RegExp(`^\\s*(?:require\\s+)?${escaped}\\s+(v[^\\s]+)`, 'm'))
+  return match ? match[1]! : null
+}
+
+/**
+ * Applies the module cache's case encoding, where each upper-case letter becomes `!` + lower-case
+ */
+export function escapeGoModulePath(modulePath: string): string {
+  return modulePath.replace(/[A-Z]/g, letter => `!${letter.toLowerCase()}`)
+}
+
+async function getGoModCache(): Promise<string> {
+  if (process.env.GOMODCACHE) return process.env.GOMODCACHE
+
+  try {
+    const { stdout } = await execFileAsync('go', ['env', 'GOMODCACHE'], { timeout: 10000 })
+    if (stdout.trim()) return stdout.trim()
+  }
+  catch {
+    /* go toolchain not installed, fall back to the default location */
+  }
+
+  return join(process.env.GOPATH || join(homedir(), 'go'), 'pkg', 'mod')
+}
+
+async function getInterpreterSitePackages(): Promise<string[]> {
+  try {
+    // -I keeps the working directory off sys.path and -S skips site, whose .pth files run code
+    const { stdout } = await execFileAsync('python3', ['-I', '-S', '-c', 'import sysconfig; print(sysconfig.get_path("purelib")); print(sysconfig.get_path("platlib"))'], { timeout: 10000 })
+    return [...new Set(stdout.split('\n').map(line => line.trim()).filter(line => line && isDirectory(line)))]
+  }
+  catch {
+    return []
+  }
+}
+
+function findSitePackages(envDir: string): string[] {
+  const windowsSitePackages = join(envDir, 'Lib', 'site-packages')
+  if (isDirectory(windowsSitePackages)) return [windowsSitePackages]
+
+  try {
+    return readdirSync(join(envDir, 'lib'))
+      .filter(entry => entry.startsWith('python'))
+      .map(entry => join(envDir, 'lib', entry, 'site-packages'))
+      .filter(isDirectory)
+  }
+  catch {
+    return []
+  }
+}
+
+function readPackageVersion(directory: string): string | undefined {
+  const packageJsonPath = join(directory, 'package.json')
+  if (!isFile(packageJsonPath)) return undefined
+
+  try {
+    return JSON.parse(readText(packageJsonPath)).version
+  }
+  catch {
+    return undefined
+  }
+}
+
+function readText(filePath: string): string {
+  try {
+    return readFileSync(filePath, 'utf-8')
+  }
+  catch {
+    return ''
+  }
+}
@@ -0,0 +1,109 @@
+/**
+ * Edit journal - the edits applied to a project, with the content each file had before, kept
+ * outside the project so a bad edit can be rolled back without relying on git
+ */
+
+import { createHash } from 'crypto'
+import { mkdirSync, readFileSync, writeFileSync } from 'fs'
+import { homedir } from 'os'
+import { join, relative, sep } from 'path'
+import { EDIT_JOURNAL_CONFIG } from '../constants/persistence.js'
+import { isFile } from '../utils/helpers.js'
+
+export interface JournalFile {
+  path: string // Absolute path
+  before: string | null // Pre-image; null for a file the edit created
+  afterHash: string | null // sha256 of the content the edit wrote; null for a file it deleted
+  additions: number
+  deletions: number
+}
+
+export interface JournalEntry {
+  id: string // Id of the plan that was applied
+  tool: string
+  appliedAt: string // ISO timestamp
+  files: JournalFile[]
+}
+
+export interface EditSummary {
+  id: string
+  tool: string
+  appliedAt: string
+  files: { path: string, additions: number, deletions: number, created: boolean, deleted: boolean }[]
+}
+
+interface Journal {
+  directory: string
+  entries: JournalEntry[] // Oldest first
+}
+
+/**
+ * Appends an applied edit to the project's journal, dropping the oldest past the limit
+ */
+export function recordEdit(directory: string, entry: JournalEntry): void {
+  const journal = readJournal(directory)
+  journal.entries.push(entry)
+  journal.entries.splice(0, Math.max(0, journal.entries.length - EDIT_JOURNAL_CONFIG.MAX_ENTRIES))
+  writeJournal(journal)
+}
+
+/**
+ * Removes an edit from the journal once it has been undone
+ */
+export function removeEdit(directory: string, id: string): void {
+  const journal = readJournal(directory)
+  journal.entries = journal.entries.filter(entry => entry.id !== id)
+  writeJournal(journal)
+}
+
+/**
+ * The most recent edit that has not been undone
+ */
+export function lastEdit(directory: string): JournalEntry | undefined {
+  const { entries } = readJournal(directory)
+  return entries[entries.length - 1]
+}
+
+/**
+ * The applied edits of a project, the most recent first, without their pre-images
+ */
+export function listEdits(directory: string): EditSummary[] {
+  return readJournal(directory).entries.slice().reverse().map(entry => ({
+    id: entry.id,
+    tool: entry.tool,
+    appliedAt: entry.appliedAt,
+    files: entry.files.map(file => ({
+      path: relative(directory, file.path).split(sep).join('/'),
+      additions: file.additions,
+      deletions: file.deletions,
+      created: file.before === null,
+      deleted: file.afterHash === null,
+    })),
+  }))
+}
+
+function readJournal(directory: string): Journal {
+  const path = journalPath(directory)
+  if (!isFile(path)) return { directory, entries: [] }
+  try {
+    return JSON.parse(readFileSync(path, 'utf-8')) as Journal
+  }
+  catch {
+    // A journal that cannot be read records nothing that could still be undone safely
+    return { directory, entries: [] }
+  }
+}
+
+function writeJournal(journal: Journal): void {
+  mkdirSync(journalRoot(), { recursive: true })
+  writeFileSync(journalPath(journal.directory), JSON.stringify(journal))
+}
+
+function journalPath(directory: string): string {
+  const hash = createHash('sha256').update(directory).digest('hex').substring(0, 16)
+  return join(journalRoot(), `${hash}.json`)
+}
+
+function journalRoot(): string {
+  return process.env[EDIT_JOURNAL_CONFIG.DIR_ENV] || join(homedir(), EDIT_JOURNAL_CONFIG.DEFAULT_SUBDIR)
+}
@@ -0,0 +1,297 @@
+/**
+ * Edit plans - the files a mutating tool would write, previewed as a unified diff by a dry run
+ * and applied later only if none of them changed in the meantime. Applied plans are journaled
+ * so the last one can be undone.
+ */
+
+import { createHash, randomBytes } from 'crypto'
+import { mkdirSync, readFileSync, rmSync, writeFileSync } from 'fs'
+import { dirname, relative, sep } from 'path'
+import { createError } from '../utils/errors.js'
+import { isFile } from '../utils/helpers.js'
+import { unifiedDiff } from '../utils/diff.js'
+import { lastEdit, recordEdit, removeEdit } from './edit-journal.js'
+import { formatContent } from './formatters.js'
+import { parseContent } from '../core/parser.js'
+import { getLanguageForFile } from '../core/languages.js'
+import { extractActionableErrors, type ActionableError } from '../analysis/errors.js'
+import { findSymbolCandidates, findSymbolsById, isSymbolId } from '../core/symbol-ids.js'
+import type { FileChange, JsonObject, Project, TreeNode } from '../types/core.js'
+
+export const EDIT_POSITIONS = ['replace', 'before', 'after'] as const
+export type EditPosition = typeof EDIT_POSITIONS[number]
+
+export interface FileEdit {
+  path: string // Absolute path
+  content: string | null // The whole new content of the file; null deletes it
+}
+
+export interface PlannedFile {
+  path: string // Relative to the project directory
+  hash: string | null // sha256 of the content the plan was made from; null for a file it creates
+  additions: number
+  deletions: number
+  deleted?: boolean
+  formatter?: string // Formatter the edited content was run through
+  formatError?: string // Why the project's formatter left it as written
+  syntaxErrors?: EditSyntaxError[] // Parse errors the edit introduces
+}
+
+export type EditSyntaxError = {
+  line: number
+  column: number
+  type: ActionableError['type']
+  text: string
+  suggestion: string
+}
+
+export interface PlanOptions {
+  format?: boolean // Run edited files through the project's formatter
+  undoes?: string // Journal entry the plan reverts
+}
+
+export interface ApplyOptions {
+  allowSyntaxErrors?: boolean // Write files the edit leaves with new parse errors
+}
+
+export interface EditPlan {
+  id: string
+  tool: string // Tool that made the plan
+  projectId: string
+  directory: string
+  files: PlannedFile[]
+  diff: string
+  edits: FileEdit[]
+  undoes?: string // Journal entry the plan reverts, removed from the journal once applied
+}
+
+// Plans kept for a later apply_edit; the oldest is dropped past this
+const MAX_PLANS = 50
+const plans = new Map<string, EditPlan>()
+
+/**
+ * Plans writing `edits` from the files as they are now, formatted first if `format` is set so
+ * the diff shows what will be written. Edits that change nothing are dropped.
+ */
+export function planEdits(tool: string, projectId: string, directory: string, edits: FileEdit[], options: PlanOptions = {}): EditPlan {
+  const { format = false, undoes } = options
+  const files: PlannedFile[] = []
+  const diffs: string[] = []
+  const changed: FileEdit[] = []
+
+  for (const requested of edits) {
+    const formatted = format && requested.content !== null ? formatContent(requested.path, requested.content, directory) : undefined
+    const edit = formatted ? { ...requested, content: formatted.content } : requested
+    const before = isFile(edit.path) ? readFileSync(edit.path, 'utf-8') : undefined
+    if (before === (edit.content ?? undefined)) continue
+
+    const path = relative(directory, edit.path).split(sep).join('/')
+    const { diff, additions, deletions } = unifiedDiff(path, before, edit.content ?? undefined)
+    const syntaxErrors = edit.content === null ? [] : newSyntaxErrors(edit.path, before, edit.content)
+    files.push({
+      path,
+      hash: before === undefined ? null : contentHash(before),
+      additions,
+      deletions,
+      ...(edit.content === null ? { deleted: true } : {}),
+      ...(formatted?.formatter ? { formatter: formatted.formatter } : {}),
+      ...(formatted?.error ? { formatError: formatted.error } : {}),
+      ...(syntaxErrors.length > 0 ? { syntaxErrors } : {}),
+    })
+    diffs.push(diff)
+    changed.push(edit)
+  }
+
+  // Unguessable, as the id is all apply_edit needs
+  const plan: EditPlan = { id: randomBytes(16).toString('hex'), tool, projectId, directory, files, diff: diffs.join(''), edits: changed, ...(undoes ? { undoes } : {}) }
+  plans.set(plan.id, plan)
+  if (plans.size > MAX_PLANS) plans.delete(plans.keys().next().value!)
+  return plan
+}
+
+export function getEditPlan(id: string): EditPlan {
+  const plan = plans.get(id)
+  if (!plan) {
+    throw createError('FILE_ERROR', `Unknown or expired edit plan: ${id}. Run the tool again with dryRun to plan the edit`)
+  }
+  return plan
+}
+
+/**
+ * Writes the files of a plan, refusing if any of them changed since it was planned - a
+ * concurrent edit would otherwise be overwritten - or if the edit introduces parse errors,
+ * unless `allowSyntaxErrors` is set. An undo restores what was there and is never refused for
+ * its syntax. A plan is applied once, and journaled with the files' previous content; applying
+ * an undo removes the edit it reverts from the journal.
+ */
+export function applyEditPlan(plan: EditPlan, options: ApplyOptions = {}): FileChange[] {
+  const before = plan.edits.map(edit => isFile(edit.path) ? readFileSync(edit.path, 'utf-8') : null)
+  const stale = plan.files.filter((file, index) => (before[index] === null ? null : contentHash(before[index]!)) !== file.hash)
+  if (stale.length > 0) {
+    throw createError('FILE_ERROR', `Files changed since the edit was planned: ${stale.map(file => file.path).join(', ')}. Run the tool again with dryRun to plan the edit`, {
+      files: stale.map(file => file.path),
+    })
+  }
+
+  const broken = plan.files.filter(file => file.syntaxErrors)
+  if (broken.length > 0 && !options.allowSyntaxErrors && !plan.undoes) {
+    const first = broken[0]!.syntaxErrors![0]!
+    throw createError('PARSE_ERROR', `Edit introduces syntax errors in ${broken.map(file => file.path).join(', ')} (${broken[0]!.path}:${first.line}: ${first.suggestion}). Fix the text, or pass allowSyntaxErrors to apply it anyway`, {
+      files: broken.map(file => ({ path: file.path, syntaxErrors: file.syntaxErrors!.length })),
+    })
+  }
+
+  const timestamp = Date.now()
+  const changes = plan.edits.map((edit, index): FileChange => {
+    if (edit.content === null) {
+      rmSync(edit.path, { force: true })
+      return { type: 'deleted', path: edit.path, timestamp }
+    }
+    mkdirSync(dirname(edit.path), { recursive: true })
+    writeFileSync(edit.path, edit.content)
+    return { type: before[index] === null ? 'created' : 'modified', path: edit.path, timestamp }
+  })
+  plans.delete(plan.id)
+
+  if (plan.undoes) {
+    removeEdit(plan.directory, plan.undoes)
+  }
+  else {
+    recordEdit(plan.directory, {
+      id: plan.id,
+      tool: plan.tool,
+      appliedAt: new Date(timestamp).toISOString(),
+      files: plan.edits.map((edit, index) => ({
+        path: edit.path,
+        before: before[index] ?? null,
+        afterHash: edit.content === null ? null : contentHash(edit.content),
+        additions: plan.files[index]!.additions,
+        deletions: plan.files[index]!.deletions,
+      })),
+    })
+  }
+  return changes
+}
+
+/**
+ * Plans restoring the files of the project's last applied edit. Refused if one of them changed
+ * since, as the undo would discard that change, unless `force` is set.
+ */
+export function planUndo(projectId: string, directory: string, force = false): EditPlan {
+  const entry = lastEdit(directory)
+  if (!entry) {
+    throw createError('FILE_ERROR', 'No applied edits to undo')
+  }
+
+  if (!force) {
+    const changed = entry.files.filter(file => (isFile(file.path) ? contentHash(readFileSync(file.path, 'utf-8')) : null) !== file.afterHash)
+    if (changed.length > 0) {
+      const paths = changed.map(file => relative(directory, file.path).split(sep).join('/'))
+      throw createError('FILE_ERROR', `Files changed since edit ${entry.id} was applied: ${paths.join(', ')}. Undoing it would discard those changes; pass force to undo anyway`, {
+        files: paths,
+      })
+    }
+  }
+
+  return planEdits('undo_last_edit', projectId, directory, entry.files.map(file => ({ path: file.path, content: file.before })), { undoes: entry.id })
+}
+
+/**
+ * The declaration a symbol name or id names, which must be exactly one
+ */
+export function resolveEditTarget(project: Project, symbol: string): TreeNode {
+  const ids = isSymbolId(symbol) ? [symbol] : [...new Set(findSymbolCandidates(project, symbol).map(candidate => candidate.id))]
+  if (ids.length === 0) {
+    throw new Error(`Unknown symbol: ${symbol}`)
+  }
+  if (ids.length > 1) {
+    throw new Error(`Ambiguous symbol ${symbol}; pass one of these ids: ${ids.join(', ')}`)
+  }
+
+  const declarations = findSymbolsById(project, ids[0]!)
+  if (declarations.length === 0) {
+    throw new Error(`Unknown symbol id: ${symbol}`)
+  }
+  if (declarations.length > 1) {
+    throw new Error(`${symbol} has ${declarations.length} declarations (overloads); edit the file by its lines instead`)
+  }
+  return declarations[0]!
+}
+
+/**
+ * The file with `text` replacing the lines of a declaration, or inserted before or after them.
+ * Replacing with empty text deletes the declaration.
+ */
+export function editAtSymbol(node: TreeNode, text: string, position: EditPosition): FileEdit {
+  const content = readFileSync(node.path, 'utf-8')
+  const lines = content.split('\n')
+  const start = node.startLine! - 1
+  const end = (node.endLine ?? node.startLine!) - 1
+
+  // The index is behind the file if the declaration is no longer where it was parsed
+  const indexed = node.content?.split('\n')[0]?.trim()
+  if (indexed && !lines[start]?.includes(indexed.substring(0, 200))) {
+    throw createError('FILE_ERROR', `${node.path} changed since it was indexed; retry once the index has caught up`)
+  }
+
+  const inserted = text === '' && position === 'replace' ? [] : text.replace(/\n$/, '').split('\n')
+  if (position === 'replace') lines.splice(start, end - start + 1, ...inserted)
+  else lines.splice(position === 'before' ? start : end + 1, 0, ...inserted)
+  return { path: node.path, content: lines.join('\n') }
+}
+
+/**
+ * The part of a plan a dry run reports
+ */
+export function describeEditPlan(plan: EditPlan): JsonObject {
+  return {
+    planId: plan.id,
+    tool: plan.tool,
+    projectId: plan.projectId,
+    files: plan.files.map(file => ({ ...file })),
+    introducesSyntaxErrors: plan.files.some(file => file.syntaxErrors),
+    diff: plan.diff,
+    ...(plan.undoes ? { undoes: plan.undoes } : {}),
+  }
+}
+
+/**
+ * Parse errors in the edited content of a file that its current content does not have. Files
+ * without a grammar have none; errors are told apart by kind and text, as lines shift.
+ */
+function newSyntaxErrors(path: string, before: string | undefined, after: string): EditSyntaxError[] {
+  const language = getLanguageForFile(path)
+  if (!language) return []
+
+  const existing = new Map<string, number>()
+  for (const error of syntaxErrorsOf(path, before)) {
+    const key = `${error.type}:${error.nodeType}:${error.text}`
+    existing.set(key, (existing.get(key) ?? 0) + 1)
+  }
+
+  return syntaxErrorsOf(path, after).flatMap((error) => {
+    const key = `${error.type}:${error.nodeType}:${error.text}`
+    const remaining = existing.get(key) ?? 0
+    if (remaining > 0) {
+      existing.set(key, remaining - 1)
+      return []
+    }
+    return [{ line: error.line, column: error.column, type: error.type, text: error.text, suggestion: error.suggestion }]
+  })
+}
+
+function syntaxErrorsOf(path: string, content: string | undefined): ActionableError[] {
+  if (content === undefined) return []
+  try {
+    const fileNode = parseContent(content, path)
+    return fileNode.rawNode ? extractActionableErrors(fileNode.rawNode, path) : []
+  }
+  catch {
+    // Without a working parser the edit cannot be checked
+    return []
+  }
+}
+
+export function contentHash(content: string): string {
+  return createHash('sha256').update(content).digest('hex')
+}
@@ -0,0 +1,154 @@
+/**
+ * Formatters - the formatter a project uses for a file (gofmt, prettier, black, rustfmt),
+ * discovered from its configuration, run on edited content before it is written
+ */
+
+import { execFileSync } from 'child_process'
+import { readdirSync, readFileSync } from 'fs'
+import { createRequire } from 'module'
+import { dirname, extname, join, relative } from 'path'
+import { isFile } from '../utils/helpers.js'
+
+export interface FormatResult {
+  content: string
+  formatter?: string // Formatter that produced the content
+  error?: string // Why a discovered formatter did not
+}
+
+interface FormatCommand {
+  file: string
+  args: string[]
+}
+
+interface Formatter {
+  name: string
+  extensions: readonly string[]
+  // How to format stdin to stdout for the file, when the project uses this formatter
+  command: (filePath: string, directory: string) => FormatCommand | undefined
+}
+
+const FORMAT_TIMEOUT_MS = 10000
+const PRETTIER_CONFIGS = /^(?:\.prettierrc(?:\.(?:json|ya?ml|json5|toml|[cm]?js|ts))?|prettier\.config\.(?:[cm]?js|ts))$/
+const BLACK_REQUIREMENT = /^\s*black\b/m
+
+const require = createRequire(import.meta.url)
+
+const FORMATTERS: Formatter[] = [
+  {
+    // gofmt is the one Go style, so every Go file is formatted
+    name: 'gofmt',
+    extensions: ['.go'],
+    command: () => ({ file: 'gofmt', args: [] }),
+  },
+  {
+    name: 'prettier',
+    extensions: ['.js', '.jsx', '.mjs', '.cjs', '.ts', '.tsx', '.vue', '.css', '.scss', '.less', '.html', '.json', '.md', '.yaml', '.yml'],
+    command: (filePath, directory) => {
+      const configured = ancestors(filePath, directory).some(dir => hasPrettierConfig(dir))
+      const script = configured ? serverPrettier() : undefined
+      return script ? { file: process.execPath, args: [script, '--stdin-filepath', filePath] } : undefined
+    },
+  },
+  {
+    name: 'black',
+    extensions: ['.py', '.pyi'],
+    command: (filePath, directory) => {
+      const configured = ancestors(filePath, directory).some(dir => usesBlack(dir))
+      return configured ? { file: 'black', args: ['--quiet', '--stdin-filename', filePath, '-'] } : undefined
+    },
+  },
+  {
+    name: 'rustfmt',
+    extensions: ['.rs'],
+    command: (filePath, directory) => {
+      const configured = ancestors(filePath, directory).some(dir => ['Cargo.toml', 'rustfmt.toml', '.rustfmt.toml'].some(name => isFile(join(dir, name))))
+      return configured ? { file: 'rustfmt', args: ['--emit', 'stdout', '--quiet'] } : undefined
+    },
+  },
+]
+
+/**
+ * `content` formatted as the project formats the file at `filePath`. Content comes back
+ * unchanged for files no formatter is configured for, or whose formatter is not installed;
+ * a formatter that fails, e.g. on a syntax error, is reported in `error`.
+ */
+export function formatContent(filePath: string, content: string, directory: string): FormatResult {
+  const extension = extname(filePath).toLowerCase()
+  for (const formatter of FORMATTERS.filter(candidate => candidate.extensions.includes(extension))) {
+    const command = formatter.command(filePath, directory)
+    if (!command) continue
+
+    try {
+      const formatted = execFileSync(command.file, command.args, {
+        input: content,
+        cwd: dirname(filePath),
+        encoding: 'utf-8',
+        timeout: FORMAT_TIMEOUT_MS,
+        stdio: ['pipe', 'pipe', 'pipe'],
+      })
+      return { content: formatted, formatter: formatter.name }
+    }
+    catch (error) {
+      if ((error as NodeJS.ErrnoException).code === 'ENOENT') continue
+      const stderr = String((error as { stderr?: string }).stderr ?? '').trim()
+      return { content, error: `${formatter.name} failed: ${stderr.split('\n')[0] || String(error)}` }
+    }
+  }
+  return { content }
+}
+
+/**
+ * Directories from the file's up to the project directory
+ */
+function ancestors(filePath: string, directory: string): string[] {
+  const dirs: string[] = []
+  let current = dirname(filePath)
+  while (!relative(directory, current).startsWith('..')) {
+    dirs.push(current)
+    if (current === directory || dirname(current) === current) break
+    current = dirname(current)
+  }
+  return dirs
+}
+
+/**
+ * The Prettier CLI installed with the server. A project's `node_modules/.bin/prettier` is never
+ * run, as the checkout decides what that file is.
+ */
+function serverPrettier(): string | undefined {
+  try {
+    const manifest = require.resolve('prettier/package.json')
+    const { bin } = JSON.parse(readFileSync(manifest, 'utf-8'))
+    const script = typeof bin === 'string' ? bin : bin?.prettier
+    return typeof script === 'string' ? join(dirname(manifest), script) : undefined
+  }
+  catch {
+    return undefined
+  }
+}
+
+function hasPrettierConfig(dir: string): boolean {
+  try {
+    if (readdirSync(dir).some(name => PRETTIER_CONFIGS.test(name))) return true
+  }
+  catch {
+    return false
+  }
+  const packageJson = join(dir, 'package.json')
+  if (!isFile(packageJson)) return false
+  try {
+    const manifest = JSON.parse(readFileSync(packageJson, 'utf-8'))
+    return Boolean(manifest.prettier || manifest.devDependencies?.prettier || manifest.dependencies?.prettier)
+  }
+  catch {
+    return false
+  }
+}
+
+function usesBlack(dir: string): boolean {
+  const pyproject = join(dir, 'pyproject.toml')
+  if (isFile(pyproject) && /^\[tool\.black\]|["']black\b/m.test(readFileSync(pyproject, 'utf-8'))) return true
+  return ['requirements-dev.txt', 'requirements.txt', 'dev-requirements.txt']
+    .map(name => join(dir, name))
+    .some(path => isFile(path) && BLACK_REQUIREMENT.test(readFileSync(path, 'utf-8')))
+}
@@ -0,0 +1,146 @@
+/**
+ * Framework detection - recognizes the frameworks and major libraries a project is built
+ * with from its dependency manifests and the imports of its sources, so framework-specific
+ * conventions can be applied only where they hold
+ */
+
+import { basename, dirname, join } from 'path'
+import { readFileSync } from 'fs'
+import { escapeRegExp } from '../constants/index.js'
+import { isFile } from '../utils/helpers.js'
+import type { DetectedFramework, Project, TreeNode } from '../types/core.js'
+
+export interface FrameworkMarker {
+  id: string
+  name: string
+  manifests: string[]
+  pattern: RegExp // Tested against the manifest text
+  imports?: RegExp // Tested against source files
+}
+
+const NPM_MANIFESTS = ['package.json']
+const PYTHON_MANIFESTS = ['requirements.txt', 'pyproject.toml', 'Pipfile', 'setup.py']
+const JVM_MANIFESTS = ['pom.xml', 'build.gradle', 'build.gradle.kts']
+
+const npmDependency = (name: string) => new RegExp(`"${escapeRegExp(name)}"\\s*:`)
+const scriptImport = (...modules: string[]) =>
+  new RegExp(`(?:\\bfrom\\s+|\\brequire\\(\\s*|^\\s*import\\s+)['"](?:${modules.map(escapeRegExp).join('|')})(?:/[\\w./-]*)?['"]`, 'm')
+const pythonImport = (module: string) => new RegExp(`^\\s*(?:from|import)\\s+${module}\\b`, 'm')
+const goImport = (module: string) => new RegExp(`"${escapeRegExp(module)}(?:/v\\d+)?"`)
+
+export const FRAMEWORK_MARKERS: FrameworkMarker[] = [
+  { id: 'nextjs', name: 'Next.js', manifests: NPM_MANIFESTS, pattern: npmDependency('next'), imports: scriptImport('next') },
+  { id: 'react', name: 'React', manifests: NPM_MANIFESTS, pattern: npmDependency('react'), imports: scriptImport('react') },
+  { id: 'react-native', name: 'React Native', manifests: NPM_MANIFESTS, pattern: npmDependency('react-native'), imports: scriptImport('react-native') },
+  { id: 'vue', name: 'Vue', manifests: NPM_MANIFESTS, pattern: npmDependency('vue'), imports: scriptImport('vue') },
+  { id: 'nuxt', name: 'Nuxt', manifests: NPM_MANIFESTS, pattern: npmDependency('nuxt'), imports: scriptImport('nuxt', '#app') },
+  { id: 'svelte', name: 'Svelte', manifests: NPM_MANIFESTS, pattern: /"(?:svelte|@sveltejs\/kit)"\s*:/, imports: scriptImport('svelte', '@sveltejs/kit') },
+  { id: 'angular', name: 'Angular', manifests: NPM_MANIFESTS, pattern: npmDependency('@angular/core'), imports: scriptImport('@angular/core') },
+  { id: 'express', name: 'Express', manifests: NPM_MANIFESTS, pattern: npmDependency('express'), imports: scriptImport('express') },
+  { id: 'fastify', name: 'Fastify', manifests: NPM_MANIFESTS, pattern: npmDependency('fastify'), imports: scriptImport('fastify') },
+  { id: 'koa', name: 'Koa', manifests: NPM_MANIFESTS, pattern: npmDependency('koa'), imports: scriptImport('koa') },
+  { id: 'hono', name: 'Hono', manifests: NPM_MANIFESTS, pattern: npmDependency('hono'), imports: scriptImport('hono') },
+  { id: 'nestjs', name: 'NestJS', manifests: NPM_MANIFESTS, pattern: npmDependency('@nestjs/core'), imports: scriptImport('@nestjs/core', '@nestjs/common') },
+  { id: 'electron', name: 'Electron', manifests: NPM_MANIFESTS, pattern: npmDependency('electron'), imports: scriptImport('electron') },
+  { id: 'django', name: 'Django', manifests: PYTHON_MANIFESTS, pattern: /^\s*["']?django\b/im, imports: pythonImport('django') },
+  { id: 'flask', name: 'Flask', manifests: PYTHON_MANIFESTS, pattern: /^\s*["']?flask\b/im, imports: pythonImport('flask') },
+  { id: 'fastapi', name: 'FastAPI', manifests: PYTHON_MANIFESTS, pattern: /^\s*["']?fastapi\b/im, imports: pythonImport('fastapi') },
+  { id: 'gin', name: 'Gin', manifests: ['go.mod'], pattern: /github\.com\/gin-gonic\/gin\b/, imports: goImport('github.com/gin-gonic/gin') },
+  { id: 'echo', name: 'Echo', manifests: ['go.mod'], pattern: /github\.com\/labstack\/echo\b/, imports: goImport('github.com/labstack/echo') },
+  { id: 'chi', name: 'chi', manifests: ['go.mod'], pattern: /github\.com\/go-chi\/chi\b/, imports: goImport('github.com/go-chi/chi') },
+  { id: 'fiber', name: 'Fiber', manifests: ['go.mod'], pattern: /github\.com\/gofiber\/fiber\b/, imports: goImport('github.com/gofiber/fiber') },
+  { id: 'cobra', name: 'Cobra', manifests: ['go.mod'], pattern: /github\.com\/spf13\/cobra\b/, imports: goImport('github.com/spf13/cobra') },
+  { id: 'actix-web', name: 'Actix Web', manifests: ['Cargo.toml'], pattern: /^\s*actix-web\s*=/m, imports: /^\s*use\s+actix_web\b/m },
+  { id: 'axum', name: 'Axum', manifests: ['Cargo.toml'], pattern: /^\s*axum\s*=/m, imports: /^\s*use\s+axum\b/m },
+  { id: 'rocket', name: 'Rocket', manifests: ['Cargo.toml'], pattern: /^\s*rocket\s*=/m, imports: /^\s*(?:use\s+rocket\b|#\[macro_use\]\s*extern\s+crate\s+rocket\b)/m },
+  { id: 'spring-boot', name: 'Spring Boot', manifests: JVM_MANIFESTS, pattern: /spring-boot/, imports: /^\s*import\s+org\.springframework\.boot\b/m },
+  { id: 'quarkus', name: 'Quarkus', manifests: JVM_MANIFESTS, pattern: /io\.quarkus/, imports: /^\s*import\s+io\.quarkus\b/m },
+  { id: 'rails', name: 'Rails', manifests: ['Gemfile'], pattern: /^\s*gem\s+['"]rails['"]/m, imports: /<\s*(?:ApplicationController|ApplicationRecord)\b|\bRails\.application\b/ },
+  { id: 'sinatra', name: 'Sinatra', manifests: ['Gemfile'], pattern: /^\s*gem\s+['"]sinatra['"]/m, imports: /^\s*require\s+['"]sinatra(?:\/base)?['"]/m },
+  { id: 'phoenix', name: 'Phoenix', manifests: ['mix.exs'], pattern: /\{\s*:phoenix\s*,/, imports: /^\s*use\s+(?:Phoenix\.(?:Router|Controller|LiveView)\b|[A-Z][\w.]*Web\s*,\s*:(?:router|controller|live_view)\b)/m },
+  { id: 'laravel', name: 'Laravel', manifests: ['composer.json'], pattern: npmDependency('laravel/framework'), imports: /^\s*use\s+Illuminate\\/m },
+  { id: 'symfony', name: 'Symfony', manifests: ['composer.json'], pattern: npmDependency('symfony/framework-bundle'), imports: /^\s*use\s+Symfony\\/m },
+]
+
+export const FRAMEWORK_MANIFESTS = [...new Set(FRAMEWORK_MARKERS.flatMap(marker => marker.manifests))]
+
+/**
+ * Detects the frameworks of a directory from the manifests of `manifestDirectories` (the
+ * directory itself by default) and the imports of its source files. A framework found in a
+ * manifest is not reported again for its imports.
+ */
+export function detectFrameworks(directory: string, fileNodes: TreeNode[] = [], manifestDirectories: string[] = [directory]): DetectedFramework[] {
+  const frameworks: DetectedFramework[] = []
+
+  for (const manifestDirectory of new Set(manifestDirectories)) {
+    const manifests = new Map<string, string>()
+    for (const name of FRAMEWORK_MANIFESTS) {
+      const content = readManifest(join(manifestDirectory, name))
+      if (content !== undefined) manifests.set(name, content)
+    }
+    for (const marker of FRAMEWORK_MARKERS) {
+      const source = marker.manifests.find(name => manifests.has(name) && marker.pattern.test(manifests.get(name)!))
+      if (source) frameworks.push({ id: marker.id, name: marker.name, directory: manifestDirectory, source })
+    }
+  }
+
+  const found = new Set(frameworks.map(framework => framework.id))
+  for (const file of fileNodes) {
+    for (const marker of findImportedFrameworks(file)) {
+      if (found.has(marker.id)) continue
+      found.add(marker.id)
+      frameworks.push({ id: marker.id, name: marker.name, directory: dirname(file.path), source: 'imports' })
+    }
+  }
+
+  return frameworks
+}
+
+/**
+ * Frameworks whose modules a source file imports
+ */
+export function findImportedFrameworks(fileNode: TreeNode): FrameworkMarker[] {
+  const content = fileNode.content
+  if (!content || fileNode.type !== 'file') return []
+  return FRAMEWORK_MARKERS.filter(marker => marker.imports?.test(content))
+}
+
+/**
+ * Every framework of a project and its sub-projects
+ */
+export function listProjectFrameworks(project: Project): DetectedFramework[] {
+  return [...(project.frameworks ?? []), ...(project.subProjects ?? []).flatMap(listProjectFrameworks)]
+}
+
+/**
+ * Whether a project or one of its sub-projects uses a framework. Undefined when frameworks
+ * have not been detected, e.g. for a project that was never parsed.
+ */
+export function usesFramework(project: Project, id: string): boolean | undefined {
+  if (project.frameworks === undefined && !project.subProjects?.some(subProject => subProject.frameworks !== undefined)) return undefined
+  return listProjectFrameworks(project).some(framework => framework.id === id)
+}
+
+/**
+ * Re-detects the frameworks of a project after file changes, when a manifest changed or a
+ * changed file imports a framework not yet detected
+ */
+export function refreshFrameworks(project: Project, changedPaths: string[], changedFiles: TreeNode[]): void {
+  if (project.frameworks === undefined) return
+  const known = new Set(project.frameworks.map(framework => framework.id))
+  const manifestChanged = changedPaths.some(path => FRAMEWORK_MANIFESTS.includes(basename(path)))
+  if (!manifestChanged && !changedFiles.some(file => findImportedFrameworks(file).some(marker => !known.has(marker.id)))) return
+
+  project.frameworks = detectFrameworks(project.config.directory, [...project.files.values()])
+}
+
+function readManifest(path: string): string | undefined {
+  if (!isFile(path)) return undefined
+  try {
+    return readFileSync(path, 'utf-8')
+  }
+  catch {
+    // An unreadable manifest reveals no frameworks
+    return undefined
+  }
+}
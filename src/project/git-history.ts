@@ -0,0 +1,266 @@
+/**
+ * Git history - indexes the tree of a commit straight from the object database, without
+ * touching the worktree, so symbols can be searched as they existed at any ref; lists the
+ * commits that changed a symbol's line range; and counts recent commits per file
+ */
+
+import { basename, dirname, join } from 'path'
+import { execFile, spawn } from 'child_process'
+import { promisify } from 'util'
+import { checkFileLimit, createDiagnostic, createProject, extractAllNodes } from './manager.js'
+import { parseContent } from '../core/parser.js'
+import { getLanguageForFile } from '../core/languages.js'
+import { GIT_HISTORY_CONFIG, GLOBAL_IGNORE_DIRS, INDEXED_HIDDEN_ENTRIES, MEMORY_LIMITS, isTestFile } from '../constants/index.js'
+import { getLogger } from '../utils/logger.js'
+import type { Project, TreeNode } from '../types/core.js'
+
+const execFileAsync = promisify(execFile)
+
+export interface RefSnapshot {
+  ref: string
+  commit: string
+  project: Project
+}
+
+export interface SymbolCommit {
+  commit: string
+  author: string
+  date: string
+  subject: string
+  body?: string
+  diff?: string // Changes within the tracked range only
+  diffTruncated?: boolean
+}
+
+export interface SymbolHistoryOptions {
+  maxCommits?: number
+  includeDiffs?: boolean
+  maxDiffLines?: number
+}
+
+interface TreeEntry {
+  path: string // Relative to the project directory, `/`-separated
+  object: string
+  size: number
+}
+
+const snapshots = new Map<string, Project>()
+
+const RECORD_SEPARATOR = '\x1e'
+const FIELD_SEPARATOR = '\x1f'
+
+/**
+ * Indexes `directory` as it was at `ref` (a branch, tag or commit). Files are selected with
+ * the same rules as the worktree walker, and a commit with more than `maxFiles` of them is
+ * refused before any is parsed. Parsed commits are cached.
+ */
+export async function loadProjectAtRef(directory: string, ref: string, projectId?: string, maxFiles?: number): Promise<RefSnapshot> {
+  if (ref.startsWith('-')) {
+    throw new Error(`Invalid git ref: ${ref}`)
+  }
+
+  let commit: string
+  try {
+    commit = (await git(directory, ['rev-parse', '--verify', '--quiet', `${ref}^{commit}`])).trim()
+  }
+  catch {
+    throw new Error(`Unknown git ref: ${ref} (in ${directory})`)
+  }
+
+  const key = `${directory}\0${commit}`
+  let project = snapshots.get(key)
+  if (project) {
+    // Re-insert to keep the map in least-recently-used order
+    snapshots.delete(key)
+  }
+  else {
+    project = await parseCommit(directory, commit, maxFiles)
+    while (snapshots.size >= GIT_HISTORY_CONFIG.CACHED_SNAPSHOTS) {
+      snapshots.delete(snapshots.keys().next().value!)
+    }
+  }
+  snapshots.set(key, project)
+
+  return {
+    ref,
+    commit,
+    project: { ...project, id: `${projectId ?? basename(directory)}@${commit.substring(0, 12)}` },
+  }
+}
+
+/**
+ * Lists the commits that modified a symbol, newest first, following its line range back
+ * through history like `git log -L`. The range is taken from the working tree, so uncommitted
+ * edits above the symbol shift it.
+ */
+export async function getSymbolHistory(node: TreeNode, options: SymbolHistoryOptions = {}): Promise<SymbolCommit[]> {
+  const { maxCommits = 10, includeDiffs = true, maxDiffLines = 80 } = options
+  if (node.startLine === undefined || node.endLine === undefined) {
+    throw new Error(`${node.name ?? node.path} has no line range to track`)
+  }
+
+  const range = `${node.startLine},${node.endLine}:${basename(node.path)}`
+  let output: string
+  try {
+    output = await git(dirname(node.path), [
+      'log', '-L', range, '-n', String(maxCommits), '--no-color',
+      `--format=${RECORD_SEPARATOR}%H${FIELD_SEPARATOR}%an${FIELD_SEPARATOR}%aI${FIELD_SEPARATOR}%s${FIELD_SEPARATOR}%b${FIELD_SEPARATOR}`,
+      ...(includeDiffs ? [] : ['--no-patch']),
+    ])
+  }
+  catch (error) {
+    const stderr = (error as { stderr?: string }).stderr?.trim().split('\n')[0]
+    throw new Error(`No git history for ${node.path}${stderr ? `: ${stderr}` : ''}`)
+  }
+
+  return output.split(RECORD_SEPARATOR).filter(record => record.trim()).map((record) => {
+    const [commit = '', author = '', date = '', subject = '', body = '', patch = ''] = record.split(FIELD_SEPARATOR)
+    const entry: SymbolCommit = { commit, author, date, subject }
+    if (body.trim()) entry.body = body.trim()
+
+    if (includeDiffs) {
+      const lines = patch.trim().split('\n')
+      entry.diff = lines.slice(0, maxDiffLines).join('\n')
+      if (lines.length > maxDiffLines) entry.diffTruncated = true
+    }
+    return entry
+  })
+}
+
+/**
+ * Number of commits in the last `days` days touching each file below `directory`, keyed by
+ * absolute path. Empty outside a git repository.
+ */
+export async function getFileChurn(directory: string, days: number): Promise<Map<string, number>> {
+  const churn = new Map<string, number>()
+  let output: string
+  try {
+    output = await git(directory, ['log', `--since=${Math.max(1, Math.round(days))}.days`, '--no-renames', '--name-only', '--relative', `--format=${RECORD_SEPARATOR}`])
+  }
+  catch {
+    return churn
+  }
+
+  for (const record of output.split(RECORD_SEPARATOR)) {
+    for (const path of new Set(record.split('\n').map(line => line.trim()).filter(Boolean))) {
+      const file = join(directory, path)
+      churn.set(file, (churn.get(file) ?? 0) + 1)
+    }
+  }
+  return churn
+}
+
+async function parseCommit(directory: string, commit: string, maxFiles?: number): Promise<Project> {
+  const logger = getLogger()
+  const entries = (await listTree(directory, commit)).filter(isIndexable)
+  logger.info(`Parsing ${entries.length} files at ${commit.substring(0, 12)}`)
+
+  const project = createProject({ directory }, true)
+  if (maxFiles !== undefined) checkFileLimit(project, entries.length, maxFiles)
+  const blobs = await readBlobs(directory, entries.map(entry => entry.object))
+
+  entries.forEach((entry, index) => {
+    const filePath = join(project.config.directory, entry.path)
+    try {
+      const fileNode = parseContent(blobs[index]!.toString('utf-8'), filePath)
+      project.files.set(filePath, fileNode)
+      project.nodes.set(filePath, extractAllNodes(fileNode))
+    }
+    catch (error) {
+      logger.debug(`Failed to parse ${entry.path} at ${commit}:`, error)
+      project.diagnostics?.push(createDiagnostic(filePath, 'parse', error))
+    }
+  })
+
+  return project
+}
+
+/**
+ * Lists the blobs below the working directory at a commit; git reports paths relative to it
+ */
+async function listTree(directory: string, commit: string): Promise<TreeEntry[]> {
+  const output = await git(directory, ['ls-tree', '-r', '-z', '--long', commit])
+  const entries: TreeEntry[] = []
+
+  for (const line of output.split('\0')) {
+    const match = line.match(/^\d+ blob ([0-9a-f]+)\s+(\d+)\t(.+)$/s)
+    if (match) {
+      entries.push({ object: match[1]!, size: Number(match[2]), path: match[3]! })
+    }
+  }
+
+  return entries
+}
+
+function isIndexable(entry: TreeEntry): boolean {
+  const segments = entry.path.split('/')
+  const fileName = segments.at(-1)!
+
+  return entry.size <= MEMORY_LIMITS.MAX_FILE_SIZE_BYTES
+    && !segments.some(segment => segment.startsWith('.') && !INDEXED_HIDDEN_ENTRIES.has(segment))
+    && !segments.slice(0, -1).some(segment => GLOBAL_IGNORE_DIRS.has(segment))
+    && !isTestFile(fileName)
+    && getLanguageForFile(fileName) !== undefined
+}
+
+/**
+ * Reads blob contents through a single `git cat-file --batch` process
+ */
+function readBlobs(directory: string, objects: string[]): Promise<Buffer[]> {
+  if (objects.length === 0) return Promise.resolve([])
+
+  return new Promise((resolveBlobs, reject) => {
+    const child = spawn('git', ['cat-file', '--batch'], { cwd: directory })
+    const chunks: Buffer[] = []
+
+    child.stdout.on('data', (chunk: Buffer) => chunks.push(chunk))
+    child.on('error', reject)
+    child.on('close', (code) => {
+      if (code !== 0) {
+        reject(new Error(`git cat-file exited with code ${code}`))
+        return
+      }
+      try {
+        resolveBlobs(splitBatchOutput(Buffer.concat(chunks), objects.length))
+      }
+      catch (error) {
+        reject(error)
+      }
+    })
+
+    child.stdin.end(objects.map(object => `${object}\n`).join(''))
+  })
+}
+
+/**
+ * Splits `<object> <type> <size>\n<content>\n` records, in request order
+ */
+function splitBatchOutput(output: Buffer, count: number): Buffer[] {
+  const blobs: Buffer[] = []
+  let offset = 0
+
+  while (blobs.length < count) {
+    const headerEnd = output.indexOf(0x0A, offset)
+    if (headerEnd < 0) throw new Error('Truncated git cat-file output')
+
+    const header = output.subarray(offset, headerEnd).toString('utf-8').split(' ')
+    if (header[1] === 'missing' || header.length < 3) {
+      throw new Error(`Missing git object: ${header[0]}`)
+    }
+
+    const size = Number(header[2])
+    blobs.push(output.subarray(headerEnd + 1, headerEnd + 1 + size))
+    offset = headerEnd + 1 + size + 1
+  }
+
+  return blobs
+}
+
+async function git(cwd: string, args: string[]): Promise<string> {
+  const { stdout } = await execFileAsync('git', args, {
+    cwd,
+    timeout: GIT_HISTORY_CONFIG.TIMEOUT_MS,
+    maxBuffer: GIT_HISTORY_CONFIG.MAX_BUFFER_BYTES,
+  })
+  return stdout
+}
@@ -0,0 +1,205 @@
+/**
+ * Go workspace detection - maps go.work and nested go.mod files to module roots
+ */
+
+import { join, resolve, sep } from 'path'
+import { readFileSync, readdirSync } from 'fs'
+import { isDirectory, isFile } from '../utils/helpers.js'
+import { GLOBAL_IGNORE_DIRS } from '../constants/index.js'
+import type { GoModule } from '../types/core.js'
+
+/**
+ * Finds every Go module belonging to a directory, preferring go.work `use`
+ * directives and falling back to nested go.mod discovery
+ */
+export function detectGoModules(directory: string, maxDepth = 4): GoModule[] {
+  const root = resolve(directory)
+  const workFile = join(root, 'go.work')
+  const moduleDirs = isFile(workFile)
+    ? parseGoWorkUses(readText(workFile)).map(use => resolve(root, use))
+    : findGoModDirs(root, maxDepth)
+
+  const modules: GoModule[] = []
+  for (const dir of moduleDirs) {
+    const modulePath = parseGoModulePath(readText(join(dir, 'go.mod')))
+    if (modulePath) {
+      modules.push({ path: modulePath, directory: dir })
+    }
+  }
+
+  return modules
+}
+
+/**
+ * Extracts the directories listed by `use` directives, both single-line and block form
+ */
+export function parseGoWorkUses(content: string): string[] {
+  const uses: string[] = []
+  let inBlock = false
+
+  for (const rawLine of content.split('\n')) {
+    const line = rawLine.replace(/\/\/.*$/, '').trim()
+    if (!line) continue
+
+    if (inBlock) {
+      if (line === ')') {
+        inBlock = false
+      }
+      else {
+        uses.push(unquote(line))
+      }
+      continue
+    }
+
+    if (line === 'use (') {
+      inBlock = true
+    }
+    else if (line.startsWith('use ')) {
+      uses.push(unquote(line.substring(4).trim()))
+    }
+  }
+
+  return uses
+}
+
+/**
+ * Modules that `replace` directives of the given modules point at a local directory, e.g.
+ * `replace example.com/lib => ../lib`. Paths already provided by a module are skipped.
+ */
+export function detectGoReplaces(modules: GoModule[]): GoModule[] {
+  const replaces: GoModule[] = []
+
+  for (const module of modules) {
+    for (const replace of parseGoReplaces(readText(join(module.directory, 'go.mod')), module.directory)) {
+      if (modules.some(existing => existing.path === replace.path) || replaces.some(existing => existing.path === replace.path)) continue
+      replaces.push(replace)
+    }
+  }
+
+  return replaces
+}
+
+/**
+ * Extracts the local-path `replace` directives of a go.mod, single-line and block form.
+ * Replacements by another module version are not files of the project and are skipped.
+ */
+export function parseGoReplaces(content: string, moduleDir: string): GoModule[] {
+  const replaces: GoModule[] = []
+  let inBlock = false
+
+  for (const rawLine of content.split('\n')) {
+    let line = rawLine.replace(/\/\/.*$/, '').trim()
+    if (!line) continue
+
+    if (inBlock) {
+      if (line === ')') {
+        inBlock = false
+        continue
+      }
+    }
+    else if (line === 'replace (') {
+      inBlock = true
+      continue
+    }
+    else if (line.startsWith('replace ')) {
+      line = line.substring(8).trim()
+    }
+    else {
+      continue
+    }
+
+    const match = line.match(/^("?)([^\s"]+)\1(?:\s+\S+)?\s*=>\s*("?)([^\s"]+)\3\s*$/)
+    if (match && /^(?:\.{1,2}\/|\/)/.test(match[4]!)) {
+      replaces.push({ path: match[2]!, directory: resolve(moduleDir, match[4]!) })
+    }
+  }
+
+  return replaces
+}
+
+/**
+ * Reads the module path declared by a go.mod file
+ */
+export function parseGoModulePath(content: string): string | null {
+  const match = content.match(/^\s*module\s+("?)([^\s"]+)\1/m)
+  return match ? match[2]! : null
+}
+
+/**
+ * Resolves a Go import path to a package directory inside one of the workspace modules.
+ * The longest matching module path wins so nested modules shadow their parents.
+ */
+export function resolveGoImport(importPath: string, modules: GoModule[]): { module: GoModule, directory: string } | null {
+  let best: GoModule | null = null
+
+  for (const module of modules) {
+    const matches = importPath === module.path || importPath.startsWith(module.path + '/')
+    if (matches && (!best || module.path.length > best.path.length)) {
+      best = module
+    }
+  }
+
+  if (!best) return null
+
+  const relative = importPath.substring(best.path.length).replace(/^\//, '')
+  return { module: best, directory: relative ? join(best.directory, ...relative.split('/')) : best.directory }
+}
+
+/**
+ * Returns the workspace module that owns a file, if any
+ */
+export function findOwningGoModule(filePath: string, modules: GoModule[]): GoModule | null {
+  let best: GoModule | null = null
+
+  for (const module of modules) {
+    const inside = filePath === module.directory || filePath.startsWith(module.directory + sep)
+    if (inside && (!best || module.directory.length > best.directory.length)) {
+      best = module
+    }
+  }
+
+  return best
+}
+
+function findGoModDirs(directory: string, maxDepth: number): string[] {
+  const dirs: string[] = []
+
+  function search(dir: string, depth: number) {
+    if (depth > maxDepth) return
+    if (isFile(join(dir, 'go.mod'))) {
+      dirs.push(dir)
+    }
+
+    let entries: string[] = []
+    try {
+      entries = readdirSync(dir)
+    }
+    catch {
+      return
+    }
+
+    for (const entry of entries) {
+      if (entry.startsWith('.') || GLOBAL_IGNORE_DIRS.has(entry) || entry === 'vendor') continue
+      const fullPath = join(dir, entry)
+      if (isDirectory(fullPath)) {
+        search(fullPath, depth + 1)
+      }
+    }
+  }
+
+  search(directory, 0)
+  return dirs
+}
+
+function unquote(value: string): string {
+  return value.replace(/^["`]|["`]$/g, '')
+}
+
+function readText(filePath: string): string {
+  try {
+    return readFileSync(filePath, 'utf-8')
+  }
+  catch {
+    return ''
+  }
+}
@@ -0,0 +1,211 @@
+/**
+ * Index archives - serializes a parsed project to a gzip file so CI can build the index
+ * once and developers or agents load it without re-parsing. Entries are keyed by content
+ * hash, so files changed since the export are re-parsed on load.
+ */
+
+import { createHash } from 'crypto'
+import { existsSync, readFileSync } from 'fs'
+import { readFile, writeFile } from 'fs/promises'
+import { relative, resolve, sep } from 'path'
+import { gunzipSync, gzipSync } from 'zlib'
+import { createProject, parseProject } from './manager.js'
+import { countErrorNodes } from '../analysis/errors.js'
+import { elementShape } from '../analysis/similarity.js'
+import { getVersion } from '../utils/version.js'
+import { INDEX_ARCHIVE_CONFIG } from '../constants/persistence.js'
+import type { Project, ProjectConfig, TreeNode } from '../types/core.js'
+
+type ArchivedNode = Omit<TreeNode, 'path' | 'parent' | 'rawNode' | 'children' | 'parameters'> & {
+  path?: string
+  children?: ArchivedNode[]
+  parameters?: ArchivedNode[]
+}
+
+interface ArchivedFile {
+  path: string // Relative to the project root, POSIX separators
+  hash: string
+  hasErrors: boolean // Restored nodes carry no syntax tree, so these files are always re-parsed
+  node: ArchivedNode
+}
+
+interface IndexArchive {
+  format: string
+  formatVersion: number
+  toolVersion: string
+  createdAt: string
+  config: Pick<ProjectConfig, 'languages' | 'ignoreDirs' | 'maxDepth'>
+  files: ArchivedFile[]
+}
+
+export interface IndexExportSummary {
+  file: string
+  files: number
+  bytes: number
+}
+
+export interface IndexImportReport {
+  restored: number // Unchanged since the export, loaded as-is
+  reparsed: number // Changed since the export, or re-parsed to recover their syntax errors
+  added: number // Not in the archive
+  removed: number // In the archive but no longer on disk
+  toolVersion: string // Version that wrote the archive; a mismatch re-parses everything
+  toolVersionMatched: boolean
+}
+
+/**
+ * Writes every file of a parsed project (including sub-projects) to a gzip archive
+ */
+export async function exportIndex(project: Project, file: string): Promise<IndexExportSummary> {
+  const root = project.config.directory
+  const files: ArchivedFile[] = []
+
+  for (const fileNode of collectFileNodes(project)) {
+    if (!existsSync(fileNode.path)) continue
+    files.push({
+      path: relative(root, fileNode.path).split(sep).join('/'),
+      hash: hashFile(fileNode.path),
+      hasErrors: fileNode.rawNode ? countErrorNodes(fileNode.rawNode) > 0 : false,
+      node: archiveNode(fileNode, fileNode.path, fileNode.rawNode),
+    })
+  }
+
+  const archive: IndexArchive = {
+    format: INDEX_ARCHIVE_CONFIG.FORMAT,
+    formatVersion: INDEX_ARCHIVE_CONFIG.FORMAT_VERSION,
+    toolVersion: getVersion(),
+    createdAt: new Date().toISOString(),
+    config: {
+      languages: project.config.languages,
+      ignoreDirs: project.config.ignoreDirs,
+      maxDepth: project.config.maxDepth,
+    },
+    files: files.sort((a, b) => a.path.localeCompare(b.path)),
+  }
+
+  const outputPath = resolve(file)
+  const data = gzipSync(JSON.stringify(archive))
+  await writeFile(outputPath, data)
+
+  return { file: outputPath, files: files.length, bytes: data.length }
+}
+
+/**
+ * Builds a project for a directory from an archive. Files whose content hash still matches
+ * are restored from the archive, everything else is parsed as usual. The archive's
+ * languages, ignore list and depth apply unless the config overrides them.
+ */
+export async function importIndex(
+  file: string,
+  config: ProjectConfig,
+): Promise<{ project: Project, report: IndexImportReport }> {
+  const archive = await readIndexArchive(file)
+  const project = createProject({
+    ...config,
+    languages: config.languages?.length ? config.languages : archive.config.languages,
+    ignoreDirs: config.ignoreDirs?.length ? config.ignoreDirs : archive.config.ignoreDirs,
+    maxDepth: config.maxDepth ?? archive.config.maxDepth,
+  })
+
+  const root = project.config.directory
+  const entries = new Map(archive.files.map(entry => [resolve(root, entry.path), entry]))
+  const toolVersionMatched = archive.toolVersion === getVersion()
+  const seen = new Set<string>()
+  const report: IndexImportReport = {
+    restored: 0,
+    reparsed: 0,
+    added: 0,
+    removed: 0,
+    toolVersion: archive.toolVersion,
+    toolVersionMatched,
+  }
+
+  await parseProject(project, (filePath) => {
+    const entry = entries.get(filePath)
+    seen.add(filePath)
+
+    if (!entry) {
+      report.added++
+      return undefined
+    }
+    // Node extraction may differ between versions, so an older archive only saves the file walk
+    if (!toolVersionMatched || entry.hasErrors || hashFile(filePath) !== entry.hash) {
+      report.reparsed++
+      return undefined
+    }
+
+    report.restored++
+    return restoreNode(entry.node, filePath)
+  })
+
+  report.removed = Array.from(entries.keys()).filter(path => !seen.has(path)).length
+  return { project, report }
+}
+
+async function readIndexArchive(file: string): Promise<IndexArchive> {
+  const path = resolve(file)
+  let archive: IndexArchive
+
+  try {
+    const data = await readFile(path)
+    // Accept plain JSON too so archives can be inspected and edited by hand
+    const text = data[0] === 0x1f && data[1] === 0x8b ? gunzipSync(data).toString('utf-8') : data.toString('utf-8')
+    archive = JSON.parse(text) as IndexArchive
+  }
+  catch (error) {
+    throw new Error(`Cannot read index archive ${path}: ${error instanceof Error ? error.message : String(error)}`)
+  }
+
+  if (archive?.format !== INDEX_ARCHIVE_CONFIG.FORMAT || !Array.isArray(archive.files)) {
+    throw new Error(`Not a tree-sitter-mcp index archive: ${path}`)
+  }
+  if (archive.formatVersion !== INDEX_ARCHIVE_CONFIG.FORMAT_VERSION) {
+    throw new Error(
+      `Unsupported index format version ${archive.formatVersion} in ${path} `
+      + `(expected ${INDEX_ARCHIVE_CONFIG.FORMAT_VERSION}). Re-export it with tree-sitter-mcp ${getVersion()}`,
+    )
+  }
+
+  return archive
+}
+
+function collectFileNodes(project: Project): TreeNode[] {
+  const fileNodes = Array.from(project.files.values())
+  for (const subProject of project.subProjects ?? []) {
+    fileNodes.push(...collectFileNodes(subProject))
+  }
+  return fileNodes
+}
+
+function hashFile(filePath: string): string {
+  try {
+    return createHash('sha256').update(readFileSync(filePath)).digest('hex')
+  }
+  catch {
+    return ''
+  }
+}
+
+function archiveNode(node: TreeNode, filePath: string, tree: TreeNode['rawNode']): ArchivedNode {
+  const { path, parent: _parent, rawNode: _rawNode, children, parameters, ...rest } = node
+  // find_similar compares elements by the shape of their syntax tree, which isn't archived
+  const syntaxShape = node.type !== 'file' && node.content ? node.syntaxShape ?? elementShape(tree, node) : undefined
+  return {
+    ...rest,
+    // Nodes normally share the file's absolute path, which differs between machines
+    ...(path !== filePath ? { path } : {}),
+    ...(syntaxShape ? { syntaxShape } : {}),
+    ...(children ? { children: children.map(child => archiveNode(child, filePath, tree)) } : {}),
+    ...(parameters ? { parameters: parameters.map(parameter => archiveNode(parameter, filePath, tree)) } : {}),
+  }
+}
+
+function restoreNode(node: ArchivedNode, filePath: string): TreeNode {
+  const { path, children, parameters, ...rest } = node
+  return {
+    ...rest,
+    path: path ?? filePath,
+    ...(children ? { children: children.map(child => restoreNode(child, filePath)) } : {}),
+    ...(parameters ? { parameters: parameters.map(parameter => restoreNode(parameter, filePath)) } : {}),
+  }
+}
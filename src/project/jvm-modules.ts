@@ -0,0 +1,102 @@
+/**
+ * Gradle and sbt multi-project builds - maps settings.gradle includes and build.sbt project
+ * definitions to subproject directories with their declared dependencies
+ */
+
+import { basename, join, resolve, sep } from 'path'
+import { readFileSync } from 'fs'
+import { isFile } from '../utils/helpers.js'
+import { parseGradleDependencies, parseGradleSettings, parseSbtBuild } from '../core/jvm-build.js'
+import type { JvmModule } from '../types/core.js'
+
+const GRADLE_SETTINGS_FILES = ['settings.gradle.kts', 'settings.gradle']
+const GRADLE_BUILD_FILES = ['build.gradle.kts', 'build.gradle']
+
+/**
+ * Finds the subprojects of the Gradle or sbt build rooted at a directory. A Gradle build
+ * without a settings script is a single project.
+ */
+export function detectJvmModules(directory: string): JvmModule[] {
+  const root = resolve(directory)
+  return [...detectGradleModules(root), ...detectSbtModules(root)]
+}
+
+/**
+ * Returns the subproject that owns a file, if any. The deepest directory wins so nested
+ * subprojects shadow the root project.
+ */
+export function findOwningJvmModule(filePath: string, modules: JvmModule[]): JvmModule | null {
+  let best: JvmModule | null = null
+
+  for (const module of modules) {
+    const inside = filePath === module.directory || filePath.startsWith(module.directory + sep)
+    if (inside && (!best || module.directory.length > best.directory.length)) {
+      best = module
+    }
+  }
+
+  return best
+}
+
+function detectGradleModules(root: string): JvmModule[] {
+  const settingsFile = GRADLE_SETTINGS_FILES.map(name => join(root, name)).find(isFile)
+  if (!settingsFile && !findGradleBuildFile(root)) return []
+
+  const settings = parseGradleSettings(settingsFile ? readText(settingsFile) : '')
+  const paths = [':', ...settings.includes.map(include => include.path)]
+
+  return [...new Set(paths)].map((path) => {
+    const relativeDir = settings.projectDirs.get(path) ?? path.split(':').filter(Boolean).join('/')
+    const moduleDir = resolve(root, relativeDir || '.')
+    const buildFile = findGradleBuildFile(moduleDir)
+    const { dependencies, projects } = parseGradleDependencies(buildFile ? readText(buildFile) : '')
+
+    return {
+      name: path,
+      tool: 'gradle' as const,
+      directory: moduleDir,
+      ...(buildFile ? { buildFile } : {}),
+      dependsOn: [...new Set(projects.map(project => project.coordinate))],
+      dependencies,
+    }
+  })
+}
+
+function detectSbtModules(root: string): JvmModule[] {
+  const buildFile = join(root, 'build.sbt')
+  if (!isFile(buildFile)) return []
+
+  const build = parseSbtBuild(readText(buildFile))
+  const modules: JvmModule[] = build.projects.map(project => ({
+    name: project.name,
+    tool: 'sbt',
+    directory: resolve(root, project.directory),
+    buildFile,
+    dependsOn: project.dependsOn,
+    dependencies: project.dependencies,
+  }))
+
+  // Settings outside any project apply to the root project, which sbt creates when none is defined
+  const rootModule = modules.find(module => module.directory === root)
+  if (rootModule) {
+    rootModule.dependencies.push(...build.dependencies)
+  }
+  else if (modules.length === 0 || build.dependencies.length > 0) {
+    modules.unshift({ name: basename(root), tool: 'sbt', directory: root, buildFile, dependsOn: [], dependencies: build.dependencies })
+  }
+
+  return modules
+}
+
+function findGradleBuildFile(directory: string): string | undefined {
+  return GRADLE_BUILD_FILES.map(name => join(directory, name)).find(isFile)
+}
+
+function readText(filePath: string): string {
+  try {
+    return readFileSync(filePath, 'utf-8')
+  }
+  catch {
+    return ''
+  }
+}
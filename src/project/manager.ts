@@ -2,15 +2,23 @@
  * Simplified project management - streamlined from complex TreeManager class
  */
 
-import { resolve } from 'path'
+import { basename, resolve, sep } from 'path'
 import { parseFile } from '../core/parser.js'
 import { findProjectFiles } from '../core/file-walker.js'
 import { createFileWatcher } from '../core/watcher.js'
 import { generateId } from '../utils/helpers.js'
 import { getLogger } from '../utils/logger.js'
-import { handleError } from '../utils/errors.js'
-import type { Project, ProjectConfig, TreeNode, FileChange } from '../types/core.js'
+import { createError, handleError, isTreeSitterError } from '../utils/errors.js'
+import { MEMORY_LIMITS } from '../constants/persistence.js'
+import type { Project, ProjectConfig, TreeNode, FileChange, IndexDiagnostic } from '../types/core.js'
 import { detectMonorepo } from './monorepo.js'
+import { isBazelWorkspace, loadBazelTargets } from './bazel.js'
+import { detectGoReplaces } from './go-workspace.js'
+import { detectJvmModules } from './jvm-modules.js'
+import { isJvmBuildFile } from '../core/jvm-build.js'
+import { isPathAliasConfig, loadPathAliases } from './path-aliases.js'
+import { loadPackageEntryPoints } from './package-exports.js'
+import { detectFrameworks, listProjectFrameworks, refreshFrameworks } from './frameworks.js'
 
 export function createProject(config: ProjectConfig, isSubProject = false): Project {
   const project: Project = {
@@ -24,10 +32,28 @@ export function createProject(config: ProjectConfig, isSubProject = false): Proj
     },
     files: new Map(),
     nodes: new Map(),
+    diagnostics: [],
   }
 
   if (!isSubProject) {
     const monorepoInfo = detectMonorepo(project.config.directory)
+    if (monorepoInfo.goModules && monorepoInfo.goModules.length > 0) {
+      project.goModules = [...monorepoInfo.goModules, ...detectGoReplaces(monorepoInfo.goModules)]
+    }
+    if (monorepoInfo.jvmModules && monorepoInfo.jvmModules.length > 0) {
+      project.jvmModules = monorepoInfo.jvmModules
+    }
+    const pathAliases = loadPathAliases(project.config.directory)
+    if (pathAliases.length > 0) {
+      project.pathAliases = pathAliases
+    }
+    const packages = loadPackageEntryPoints(project.config.directory)
+    if (packages.length > 0) {
+      project.packages = packages
+    }
+    if (isBazelWorkspace(project.config.directory)) {
+      project.bazelTargets = loadBazelTargets(project.config.directory)
+    }
     if (monorepoInfo.isMonorepo) {
       project.isMonorepo = true
       project.subProjects = monorepoInfo.subProjects.map(subPath =>
@@ -39,50 +65,106 @@ export function createProject(config: ProjectConfig, isSubProject = false): Proj
   return project
 }
 
-export async function parseProject(project: Project): Promise<Project> {
+const activeBuilds = new WeakMap<Project, number>()
+// The newest update burst to have claimed each path, per project
+const pathClaims = new WeakMap<Project, Map<string, number>>()
+let lastUpdateToken = 0
+const BUILD_FILE_PATTERN = /^(BUILD(\.bazel)?|BUCK)$/
+
+/**
+ * Returns a still-valid file node from an earlier index (e.g. an imported archive),
+ * or undefined when the file has to be parsed again
+ */
+export type CachedFileLookup = (filePath: string) => TreeNode | undefined
+
+export function checkFileLimit(project: Project, files: number, maxFiles: number): void {
+  if (files > maxFiles) {
+    throw createError('THROTTLED', `Throttled: ${project.config.directory} has ${files} files to index; a call may index ${maxFiles}`, {
+      reason: 'files',
+      limit: maxFiles,
+      files,
+    })
+  }
+}
+
+// Files the leaf sub-projects of a project would parse
+async function countProjectFiles(project: Project): Promise<number> {
+  if (project.subProjects && project.subProjects.length > 0) {
+    const counts = await Promise.all(project.subProjects.map(countProjectFiles))
+    return counts.reduce((total, count) => total + count, 0)
+  }
+  const { directory, languages, ignoreDirs, files } = project.config
+  return (files ?? await findProjectFiles(directory, languages, ignoreDirs)).length
+}
+
+export async function parseProject(project: Project, lookup?: CachedFileLookup): Promise<Project> {
   const logger = getLogger()
+  const buildToken = (activeBuilds.get(project) ?? 0) + 1
+  activeBuilds.set(project, buildToken)
 
   try {
     logger.info(`Parsing project: ${project.config.directory}`)
 
-    // Clear existing files and nodes before reparsing
-    project.files.clear()
-    project.nodes.clear()
+    // Build the replacement index off to the side so concurrent searches keep seeing the
+    // previous snapshot until the new one is complete
+    const files = new Map<string, TreeNode>()
+    const nodes = new Map<string, TreeNode[]>()
+    const diagnostics: IndexDiagnostic[] = []
 
     if (project.subProjects && project.subProjects.length > 0) {
       logger.info(`Parsing ${project.subProjects.length} sub-projects`)
+      // The limit is on the whole index, so every sub-project is counted before any is parsed
+      const { maxFiles } = project.config
+      if (maxFiles !== undefined) {
+        checkFileLimit(project, await countProjectFiles(project), maxFiles)
+      }
       for (const subProject of project.subProjects) {
         try {
-          await parseProject(subProject)
+          await parseProject(subProject, lookup)
         }
         catch (error) {
+          if (isTreeSitterError(error) && error.code === 'THROTTLED') throw error
           logger.error(`Failed to parse sub-project ${subProject.config.directory}:`, error)
         }
       }
     }
     else {
-      const files = await findProjectFiles(
+      const filePaths = project.config.files ?? await findProjectFiles(
         project.config.directory,
         project.config.languages,
         project.config.ignoreDirs,
       )
 
-      logger.info(`Found ${files.length} files to parse`)
+      logger.info(`Found ${filePaths.length} files to parse`)
+      const { maxFiles } = project.config
+      if (maxFiles !== undefined) {
+        checkFileLimit(project, filePaths.length, maxFiles)
+      }
 
-      for (const filePath of files) {
+      for (const filePath of filePaths) {
         try {
-          const fileNode = await parseFile(filePath)
-          project.files.set(filePath, fileNode)
-
-          const allNodes = extractAllNodes(fileNode)
-          project.nodes.set(filePath, allNodes)
+          const fileNode = lookup?.(filePath) ?? await parseFile(filePath)
+          files.set(filePath, fileNode)
+          nodes.set(filePath, extractAllNodes(fileNode))
         }
         catch (error) {
           logger.warn(`Failed to parse ${filePath}:`, error)
+          diagnostics.push(createDiagnostic(filePath, 'parse', error))
         }
       }
     }
 
+    if (activeBuilds.get(project) !== buildToken) {
+      logger.debug(`Discarding superseded parse of ${project.config.directory}`)
+      return project
+    }
+
+    project.files = files
+    project.nodes = nodes
+    project.diagnostics = diagnostics
+    project.frameworks = detectFrameworks(project.config.directory, [...files.values()])
+    project.generation = (project.generation ?? 0) + 1
+
     logger.info(`Project parsed successfully: ${project.files.size} files`)
     return project
   }
@@ -91,41 +173,107 @@ export async function parseProject(project: Project): Promise<Project> {
   }
 }
 
-export async function updateProject(project: Project, changes: FileChange[]): Promise<void> {
+export async function updateProject(project: Project, changes: FileChange[], isSubProject = false): Promise<void> {
   const logger = getLogger()
 
+  if (project.bazelTargets && changes.some(change => BUILD_FILE_PATTERN.test(basename(change.path)))) {
+    project.bazelTargets = loadBazelTargets(project.config.directory)
+  }
+  if (!isSubProject && changes.some(change => isJvmBuildFile(basename(change.path)))) {
+    const jvmModules = detectJvmModules(project.config.directory)
+    project.jvmModules = jvmModules.length > 0 ? jvmModules : undefined
+  }
+  if (!isSubProject && changes.some(change => isPathAliasConfig(change.path))) {
+    const pathAliases = loadPathAliases(project.config.directory)
+    project.pathAliases = pathAliases.length > 0 ? pathAliases : undefined
+    const packages = loadPackageEntryPoints(project.config.directory)
+    project.packages = packages.length > 0 ? packages : undefined
+  }
+
+  // Route changes to the sub-project that indexed them so scoped modules stay authoritative
+  if (project.subProjects && project.subProjects.length > 0) {
+    const rootChanges: FileChange[] = []
+    const routed = new Map<Project, FileChange[]>()
+    for (const change of changes) {
+      const owner = findOwningSubProject(project, change.path)
+      if (owner) routed.set(owner, [...(routed.get(owner) ?? []), change])
+      else rootChanges.push(change)
+    }
+    for (const [owner, ownerChanges] of routed) {
+      await updateProject(owner, ownerChanges, true)
+    }
+    if (rootChanges.length === 0) return
+    changes = rootChanges
+  }
+
+  // Collapse bursts so each path is parsed once, using its most recent change
+  const latestChanges = new Map<string, FileChange>()
   for (const change of changes) {
-    switch (change.type) {
-      case 'created':
-      case 'modified':
-        try {
-          const fileNode = await parseFile(change.path)
-          project.files.set(change.path, fileNode)
+    latestChanges.set(change.path, change)
+  }
 
-          const allNodes = extractAllNodes(fileNode)
-          project.nodes.set(change.path, allNodes)
+  // Claim the paths so an earlier burst that is still parsing them can't overwrite this one
+  const updateToken = ++lastUpdateToken
+  const claims = pathClaims.get(project) ?? new Map<string, number>()
+  pathClaims.set(project, claims)
+  for (const path of latestChanges.keys()) {
+    claims.set(path, updateToken)
+  }
 
-          logger.debug(`Updated file: ${change.path}`)
-        }
-        catch (error) {
-          logger.warn(`Failed to update ${change.path}:`, error)
-        }
-        break
+  const parsed = new Map<string, TreeNode>()
+  const failed = new Map<string, unknown>()
 
-      case 'deleted':
-        project.files.delete(change.path)
-        project.nodes.delete(change.path)
-        logger.debug(`Removed file: ${change.path}`)
-        break
+  for (const change of latestChanges.values()) {
+    if (change.type === 'deleted') continue
+    try {
+      parsed.set(change.path, await parseFile(change.path))
+    }
+    catch (error) {
+      logger.warn(`Failed to update ${change.path}:`, error)
+      failed.set(change.path, error)
     }
   }
+
+  // Apply every staged change synchronously so searches never observe a half-applied burst
+  const applied: string[] = []
+  for (const change of latestChanges.values()) {
+    if (claims.get(change.path) !== updateToken) {
+      logger.debug(`Discarding superseded update of ${change.path}`)
+      continue
+    }
+    claims.delete(change.path)
+    applied.push(change.path)
+    const fileNode = parsed.get(change.path)
+
+    if (fileNode) {
+      project.files.set(change.path, fileNode)
+      project.nodes.set(change.path, extractAllNodes(fileNode))
+      clearDiagnostics(project, change.path)
+      logger.debug(`Updated file: ${change.path}`)
+    }
+    else if (failed.has(change.path)) {
+      recordDiagnostic(project, change.path, 'update', failed.get(change.path))
+    }
+    else {
+      project.files.delete(change.path)
+      project.nodes.delete(change.path)
+      clearDiagnostics(project, change.path)
+      logger.debug(`Removed file: ${change.path}`)
+    }
+  }
+
+  if (applied.length === 0) return
+  refreshFrameworks(project, applied, applied.flatMap(path => parsed.get(path) ?? []))
+  project.generation = (project.generation ?? 0) + 1
 }
 
 export function watchProject(project: Project, onUpdate?: (changes: FileChange[]) => void): () => void {
   const watcher = createFileWatcher(
     project.config.directory,
     (changes) => {
-      updateProject(project, changes)
+      updateProject(project, changes).catch((error) => {
+        getLogger().error(`Failed to apply file changes for ${project.config.directory}:`, error)
+      })
       onUpdate?.(changes)
     },
   )
@@ -135,6 +283,59 @@ export function watchProject(project: Project, onUpdate?: (changes: FileChange[]
   return () => watcher.stop()
 }
 
+function findOwningSubProject(project: Project, filePath: string): Project | undefined {
+  let owner: Project | undefined
+  for (const subProject of project.subProjects ?? []) {
+    const directory = subProject.config.directory
+    if (filePath.startsWith(directory + sep) && (!owner || directory.length > owner.config.directory.length)) {
+      owner = subProject
+    }
+  }
+  return owner
+}
+
+/**
+ * Records a per-file indexing failure on the project, keeping the newest entries
+ */
+export function recordDiagnostic(project: Project, path: string, stage: IndexDiagnostic['stage'], error: unknown): void {
+  clearDiagnostics(project, path)
+  const diagnostics = project.diagnostics ?? (project.diagnostics = [])
+  diagnostics.push(createDiagnostic(path, stage, error))
+
+  if (diagnostics.length > MEMORY_LIMITS.MAX_DIAGNOSTICS_PER_PROJECT) {
+    diagnostics.splice(0, diagnostics.length - MEMORY_LIMITS.MAX_DIAGNOSTICS_PER_PROJECT)
+  }
+}
+
+/**
+ * Records why a file could not be indexed, preferring the parser's own message
+ */
+export function createDiagnostic(path: string, stage: IndexDiagnostic['stage'], error: unknown): IndexDiagnostic {
+  const context = (error as { context?: { error?: unknown } })?.context?.error
+  const message = typeof context === 'string' ? context : error instanceof Error ? error.message : String(error)
+  return { path, stage, message, timestamp: Date.now() }
+}
+
+function clearDiagnostics(project: Project, path: string): void {
+  if (!project.diagnostics) return
+  project.diagnostics = project.diagnostics.filter(diagnostic => diagnostic.path !== path)
+}
+
+/**
+ * Collects indexing diagnostics from a project and its sub-projects
+ */
+export function getProjectDiagnostics(project: Project): IndexDiagnostic[] {
+  const diagnostics = [...(project.diagnostics ?? [])]
+
+  if (project.subProjects) {
+    for (const subProject of project.subProjects) {
+      diagnostics.push(...getProjectDiagnostics(subProject))
+    }
+  }
+
+  return diagnostics
+}
+
 export function getAllNodes(project: Project): TreeNode[] {
   const allNodes: TreeNode[] = []
 
@@ -160,6 +361,7 @@ export function getProjectStats(project: Project): {
   totalNodes: number
   languages: string[]
   directories: string[]
+  frameworks: string[]
 } {
   const stats = {
     totalFiles: project.files.size,
@@ -180,10 +382,14 @@ export function getProjectStats(project: Project): {
     ...stats,
     languages: Array.from(stats.languages),
     directories: Array.from(stats.directories),
+    frameworks: [...new Set(listProjectFrameworks(project).map(framework => framework.name))],
   }
 }
 
-function extractAllNodes(fileNode: TreeNode): TreeNode[] {
+/**
+ * Flattens a parsed file into the node list stored per file: the file itself and every element
+ */
+export function extractAllNodes(fileNode: TreeNode): TreeNode[] {
   const nodes: TreeNode[] = []
 
   function traverse(node: TreeNode) {
@@ -80,8 +80,7 @@ export function getMemoryStats(manager: MemoryManager): {
 
   let memoryUsage = 0
   for (const project of manager.projects.values()) {
-    memoryUsage += project.files.size * 1000 // Rough estimate: 1KB per file
-    memoryUsage += Array.from(project.nodes.values()).reduce((sum, nodes) => sum + nodes.length, 0) * 100 // 100 bytes per node
+    memoryUsage += estimateProjectMemory(project)
   }
 
   return {
@@ -93,6 +92,14 @@ export function getMemoryStats(manager: MemoryManager): {
   }
 }
 
+/**
+ * Rough size in bytes of a project's index: 1KB per file and 100 bytes per node
+ */
+export function estimateProjectMemory(project: Project): number {
+  const nodes = Array.from(project.nodes.values()).reduce((sum, fileNodes) => sum + fileNodes.length, 0)
+  return project.files.size * 1000 + nodes * 100
+}
+
 export function clearMemory(manager: MemoryManager): void {
   const logger = getLogger()
   const projectCount = manager.projects.size
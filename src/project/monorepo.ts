@@ -8,11 +8,13 @@ import { isDirectory, isFile } from '../utils/helpers.js'
 import { getLogger } from '../utils/logger.js'
 import { GLOBAL_IGNORE_DIRS } from '../constants/index.js'
 import type { MonorepoInfo } from '../types/analysis.js'
+import { detectGoModules } from './go-workspace.js'
+import { detectJvmModules } from './jvm-modules.js'
 
 const PROJECT_INDICATORS = [
   'package.json', 'package-lock.json', 'yarn.lock', 'pnpm-lock.yaml',
   'Cargo.toml', 'go.mod', 'pyproject.toml', 'requirements.txt', 'Pipfile',
-  'composer.json', 'pom.xml', 'build.gradle', 'tsconfig.json',
+  'composer.json', 'pom.xml', 'build.gradle', 'build.gradle.kts', 'build.sbt', 'mix.exs', 'tsconfig.json',
 ]
 
 export function detectMonorepo(directory: string): MonorepoInfo {
@@ -22,6 +24,23 @@ export function detectMonorepo(directory: string): MonorepoInfo {
     const subProjects = findSubProjects(directory)
     const workspaces = detectWorkspaces(directory)
     const rootProject = findRootProject(directory)
+    const goModules = detectGoModules(directory)
+    const jvmModules = detectJvmModules(directory)
+
+    // go.work and settings.gradle may point at modules deeper than the generic indicator scan
+    // reaches, and sbt subprojects often have no build file of their own
+    for (const module of [...goModules, ...jvmModules]) {
+      if (!subProjects.includes(module.directory)) {
+        subProjects.push(module.directory)
+      }
+    }
+
+    // A bare go.work root only holds modules, so drop the fallback entry for it
+    const root = resolve(directory)
+    if (goModules.length > 0 && !PROJECT_INDICATORS.some(indicator => isFile(join(root, indicator)))) {
+      const rootIndex = subProjects.findIndex(subProject => resolve(subProject) === root)
+      if (rootIndex !== -1) subProjects.splice(rootIndex, 1)
+    }
 
     const isMonorepo = subProjects.length > 1
 
@@ -34,6 +53,8 @@ export function detectMonorepo(directory: string): MonorepoInfo {
       subProjects,
       workspaces,
       rootProject,
+      goModules,
+      jvmModules,
     }
   }
   catch (error) {
@@ -317,6 +338,9 @@ function detectProjectType(projectPath: string): string {
   if (isFile(join(projectPath, 'go.mod'))) return 'go'
   if (isFile(join(projectPath, 'pyproject.toml'))) return 'python'
   if (isFile(join(projectPath, 'pom.xml'))) return 'java'
+  if (isFile(join(projectPath, 'build.gradle')) || isFile(join(projectPath, 'build.gradle.kts'))) return 'gradle'
+  if (isFile(join(projectPath, 'build.sbt'))) return 'sbt'
+  if (isFile(join(projectPath, 'mix.exs'))) return 'elixir'
   return 'unknown'
 }
 
@@ -0,0 +1,162 @@
+/**
+ * Package entry points - resolves bare specifiers naming a package of the project through its
+ * package.json `exports` (with conditions), `imports`, `module` and `main`, the way Node does
+ * for `import` and for `require`, so CJS/ESM dual packages resolve to the file each kind of
+ * import actually loads
+ */
+
+import { readFileSync } from 'fs'
+import { join, relative, resolve, sep } from 'path'
+import { PROJECT_FILES } from '../constants/index.js'
+import { isFile } from '../utils/helpers.js'
+import { findConfigDirs } from './path-aliases.js'
+import type { PackageEntryPoints } from '../types/core.js'
+
+export type ImportKind = 'import' | 'require'
+
+// Conditions Node sets; an exports object is matched in its own key order
+const CONDITIONS: Record<ImportKind, Set<string>> = {
+  import: new Set(['node', 'import', 'default']),
+  require: new Set(['node', 'require', 'default']),
+}
+
+// Build output directories, tried as `src` when the built file is not part of the sources
+const BUILD_DIRECTORIES = new Set(['dist', 'build', 'lib', 'out'])
+const FORMAT_DIRECTORIES = new Set(['esm', 'cjs', 'mjs', 'es', 'commonjs', 'module'])
+
+/**
+ * Reads the package.json of every named package below the directory
+ */
+export function loadPackageEntryPoints(directory: string, maxDepth = 4): PackageEntryPoints[] {
+  const packages: PackageEntryPoints[] = []
+  for (const dir of findConfigDirs(resolve(directory), maxDepth)) {
+    const source = join(dir, PROJECT_FILES.PACKAGE_MANAGERS.NPM)
+    if (!isFile(source)) continue
+
+    let manifest: Record<string, unknown>
+    try {
+      manifest = JSON.parse(readFileSync(source, 'utf-8'))
+    }
+    catch {
+      continue
+    }
+    if (typeof manifest.name !== 'string' || !manifest.name) continue
+
+    packages.push({
+      name: manifest.name,
+      directory: dir,
+      source,
+      ...(manifest.exports !== undefined ? { exports: manifest.exports } : {}),
+      ...(manifest.imports !== undefined ? { imports: manifest.imports } : {}),
+      ...(typeof manifest.main === 'string' ? { main: manifest.main } : {}),
+      ...(typeof manifest.module === 'string' ? { module: manifest.module } : {}),
+    })
+  }
+  return packages
+}
+
+/**
+ * Candidate paths a specifier loads, for an `import` or a `require` from a file: a package's
+ * name or subpath (`@app/core`, `@app/core/utils`), or a `#internal` import of the file's own
+ * package. The file an entry point names comes first, then the sources it is built from.
+ */
+export function resolvePackageSpecifier(specifier: string, fromFile: string, packages: PackageEntryPoints[], kind: ImportKind): string[] {
+  if (specifier.startsWith('#')) {
+    const owner = packages
+      .filter(candidate => fromFile.startsWith(candidate.directory + sep))
+      .sort((a, b) => b.directory.length - a.directory.length)[0]
+    const target = owner?.imports !== undefined ? resolveSubpath(owner.imports, specifier, kind) : undefined
+    return owner && target ? withSources(owner, target) : []
+  }
+
+  const pkg = packages
+    .filter(candidate => specifier === candidate.name || specifier.startsWith(`${candidate.name}/`))
+    .sort((a, b) => b.name.length - a.name.length)[0]
+  if (!pkg) return []
+
+  const subpath = `.${specifier.substring(pkg.name.length)}`
+  const target = resolvePackageTarget(pkg, subpath, kind)
+  return target ? withSources(pkg, target) : []
+}
+
+/**
+ * The target, relative to the package, a subpath (`.`, `./utils`) resolves to. Without
+ * `exports`, the root is `module` for imports (as bundlers read it) or `main`, and other
+ * subpaths are files of the package.
+ */
+export function resolvePackageTarget(pkg: PackageEntryPoints, subpath: string, kind: ImportKind): string | undefined {
+  if (pkg.exports !== undefined) {
+    const exports = typeof pkg.exports === 'string' || Array.isArray(pkg.exports) || !hasSubpathKeys(pkg.exports)
+      ? { '.': pkg.exports }
+      : pkg.exports
+    return resolveSubpath(exports, subpath, kind)
+  }
+  if (subpath !== '.') return subpath
+  return (kind === 'import' ? pkg.module : undefined) ?? pkg.main ?? './index.js'
+}
+
+/**
+ * Matches a subpath against the keys of an `exports` or `imports` map, exact keys first and
+ * then the `*` pattern with the longest prefix
+ */
+function resolveSubpath(map: unknown, subpath: string, kind: ImportKind): string | undefined {
+  if (!isRecord(map)) return undefined
+  if (subpath in map) return resolveTarget(map[subpath], kind, '')
+
+  const patterns = Object.keys(map)
+    .filter(key => key.includes('*'))
+    .sort((a, b) => b.indexOf('*') - a.indexOf('*') || b.length - a.length)
+  for (const pattern of patterns) {
+    const [prefix, suffix = ''] = pattern.split('*')
+    if (subpath.length >= prefix!.length + suffix.length && subpath.startsWith(prefix!) && subpath.endsWith(suffix)) {
+      return resolveTarget(map[pattern], kind, subpath.substring(prefix!.length, subpath.length - suffix.length))
+    }
+  }
+  return undefined
+}
+
+/**
+ * Picks a target: strings as written, arrays by their first resolvable entry, and condition
+ * objects by their first key the import kind sets. `null` excludes the subpath.
+ */
+function resolveTarget(target: unknown, kind: ImportKind, captured: string): string | undefined {
+  if (typeof target === 'string') return target.replace(/\*/g, captured)
+  if (Array.isArray(target)) {
+    for (const entry of target) {
+      const resolved = resolveTarget(entry, kind, captured)
+      if (resolved) return resolved
+    }
+    return undefined
+  }
+  if (!isRecord(target)) return undefined
+  for (const [condition, value] of Object.entries(target)) {
+    if (CONDITIONS[kind].has(condition)) {
+      const resolved = resolveTarget(value, kind, captured)
+      if (resolved) return resolved
+    }
+  }
+  return undefined
+}
+
+/**
+ * The target's path, then the same path under `src` when it lies in a build directory:
+ * `./dist/esm/index.mjs` may be built from `src/index.ts`
+ */
+function withSources(pkg: PackageEntryPoints, target: string): string[] {
+  const path = resolve(pkg.directory, target)
+  const segments = relative(pkg.directory, path).split(sep)
+  if (!BUILD_DIRECTORIES.has(segments[0] ?? '')) return [path]
+
+  const rest = segments.slice(1)
+  const sources = [join(pkg.directory, 'src', ...rest)]
+  if (FORMAT_DIRECTORIES.has(rest[0] ?? '')) sources.push(join(pkg.directory, 'src', ...rest.slice(1)))
+  return [path, ...sources.map(source => source.replace(/(?:\.d)?\.[cm]?[jt]s$/, ''))]
+}
+
+function hasSubpathKeys(exports: unknown): boolean {
+  return isRecord(exports) && Object.keys(exports).some(key => key.startsWith('.'))
+}
+
+function isRecord(value: unknown): value is Record<string, unknown> {
+  return typeof value === 'object' && value !== null && !Array.isArray(value)
+}
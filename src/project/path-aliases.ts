@@ -0,0 +1,316 @@
+/**
+ * Path aliases - reads tsconfig/jsconfig `paths`, jest `moduleNameMapper` and webpack/vite
+ * `resolve.alias` so bare specifiers like `@app/shared/foo` resolve to files of the project
+ */
+
+import { basename, dirname, join, resolve, sep } from 'path'
+import { readdirSync, readFileSync } from 'fs'
+import { GLOBAL_IGNORE_DIRS, PROJECT_FILES } from '../constants/index.js'
+import { isDirectory, isFile, readCallArguments, splitTopLevel } from '../utils/helpers.js'
+import type { PathAlias } from '../types/core.js'
+
+const { TSCONFIG, JEST, BUNDLER } = PROJECT_FILES.PATH_ALIAS_CONFIGS
+const MAX_EXTENDS_DEPTH = 8
+
+const PATH_ALIAS_FILES = new Set<string>([...TSCONFIG, ...JEST, ...BUNDLER, PROJECT_FILES.PACKAGE_MANAGERS.NPM])
+
+/**
+ * Reads the aliases of every config file in a directory tree, so each package of a
+ * monorepo keeps its own
+ */
+export function loadPathAliases(directory: string, maxDepth = 4): PathAlias[] {
+  const aliases: PathAlias[] = []
+
+  for (const dir of findConfigDirs(resolve(directory), maxDepth)) {
+    const tsconfig = TSCONFIG.map(name => join(dir, name)).find(isFile)
+    if (tsconfig) aliases.push(...readTsconfigPaths(tsconfig))
+
+    for (const name of JEST) {
+      const file = join(dir, name)
+      if (isFile(file)) aliases.push(...parseModuleNameMapper(readText(file), dir, file))
+    }
+
+    const packageJson = join(dir, PROJECT_FILES.PACKAGE_MANAGERS.NPM)
+    if (isFile(packageJson)) {
+      const jest = parseJsonc(readText(packageJson))?.jest
+      if (isRecord(jest) && isRecord(jest.moduleNameMapper)) {
+        aliases.push(...mapperAliases(jest.moduleNameMapper, dir, packageJson))
+      }
+    }
+
+    for (const name of BUNDLER) {
+      const file = join(dir, name)
+      if (isFile(file)) aliases.push(...parseBundlerAliases(readText(file), dir, file))
+    }
+  }
+
+  return aliases
+}
+
+/**
+ * Whether changing a file can add or remove aliases
+ */
+export function isPathAliasConfig(filePath: string): boolean {
+  return PATH_ALIAS_FILES.has(basename(filePath))
+}
+
+/**
+ * Reads `compilerOptions.paths` of a tsconfig, following relative `extends`. Targets are
+ * relative to `baseUrl`, or to the config that declares `paths` when there is none.
+ */
+export function readTsconfigPaths(file: string): PathAlias[] {
+  const options = readCompilerOptions(file, 0)
+  if (!options.paths) return []
+
+  const root = options.baseUrl ?? options.pathsDir!
+  return Object.entries(options.paths)
+    .filter((entry): entry is [string, string[]] => Array.isArray(entry[1]))
+    .map(([pattern, targets]) => ({
+      pattern,
+      targets: targets.filter(target => typeof target === 'string').map(target => resolve(root, target)),
+      scope: dirname(file),
+      source: file,
+    }))
+}
+
+/**
+ * Reads `moduleNameMapper` from a jest config. Only mappings a path pattern can express
+ * (`^@app/(.*)$` → `<rootDir>/src/$1`) are kept; mocks for styles and assets point at no
+ * source file and are skipped.
+ */
+export function parseModuleNameMapper(content: string, rootDir: string, source: string): PathAlias[] {
+  if (source.endsWith('.json')) {
+    const config = parseJsonc(content)
+    return isRecord(config) && isRecord(config.moduleNameMapper) ? mapperAliases(config.moduleNameMapper, rootDir, source) : []
+  }
+
+  const entries = readObjectsAfter(content, /\bmoduleNameMapper\s*:\s*\{/g)
+  return mapperAliases(Object.fromEntries(entries.map(([key, value]) => [key, readStrings(value)])), rootDir, source)
+}
+
+/**
+ * Reads `resolve.alias` of a webpack or vite config, in object (`'@app': path.resolve(__dirname,
+ * 'src')`) or vite's array (`{ find: '@', replacement: './src' }`) form
+ */
+export function parseBundlerAliases(content: string, directory: string, source: string): PathAlias[] {
+  const aliases: PathAlias[] = []
+  const add = (key: string, value: string) => {
+    const target = targetOf(value, directory)
+    if (!target) return
+    // webpack: a trailing `$` restricts the alias to the exact specifier
+    if (key.endsWith('$')) {
+      aliases.push({ pattern: key.slice(0, -1), targets: [target], scope: directory, source })
+      return
+    }
+    aliases.push({ pattern: key, targets: [target], scope: directory, source })
+    aliases.push({ pattern: `${key.replace(/\/$/, '')}/*`, targets: [`${target}/*`], scope: directory, source })
+  }
+
+  for (const [key, value] of readObjectsAfter(content, /\balias\s*:\s*\{/g)) add(key, value)
+
+  for (const match of content.matchAll(/\balias\s*:\s*\[/g)) {
+    const list = readCallArguments(content, match.index! + match[0].length - 1)
+    for (const item of list?.args ?? []) {
+      const entry = Object.fromEntries(readObject(item, 0))
+      const find = entry.find ? readStrings(entry.find)[0] : undefined
+      if (find && entry.replacement) add(find, entry.replacement)
+    }
+  }
+
+  return aliases
+}
+
+/**
+ * Candidate paths, without extension, a bare specifier maps to for a file. Configs nearer
+ * the file come first; within one config, exact patterns and then longer prefixes do.
+ */
+export function expandPathAlias(specifier: string, fromFile: string, aliases: PathAlias[]): string[] {
+  const candidates: { path: string, scope: number, specificity: number }[] = []
+
+  for (const alias of aliases) {
+    if (fromFile !== alias.scope && !fromFile.startsWith(alias.scope + sep)) continue
+
+    const star = alias.pattern.indexOf('*')
+    let captured: string | undefined
+    if (star === -1) {
+      if (specifier === alias.pattern) captured = ''
+    }
+    else {
+      const prefix = alias.pattern.substring(0, star)
+      const suffix = alias.pattern.substring(star + 1)
+      if (specifier.length >= prefix.length + suffix.length && specifier.startsWith(prefix) && specifier.endsWith(suffix)) {
+        captured = specifier.substring(prefix.length, specifier.length - suffix.length)
+      }
+    }
+    if (captured === undefined) continue
+
+    const specificity = star === -1 ? Infinity : star
+    for (const target of alias.targets) {
+      candidates.push({ path: target.replace('*', captured), scope: alias.scope.length, specificity })
+    }
+  }
+
+  const paths = candidates
+    .sort((a, b) => b.scope - a.scope || b.specificity - a.specificity)
+    .map(candidate => candidate.path)
+  return [...new Set(paths)]
+}
+
+interface CompilerPaths {
+  baseUrl?: string
+  paths?: Record<string, unknown>
+  pathsDir?: string
+}
+
+function readCompilerOptions(file: string, depth: number): CompilerPaths {
+  const config = parseJsonc(readText(file))
+  if (!isRecord(config)) return {}
+
+  const inherited: CompilerPaths = {}
+  const parents = typeof config.extends === 'string' ? [config.extends] : Array.isArray(config.extends) ? config.extends : []
+  for (const parent of parents) {
+    // Package configs (`@tsconfig/node20`) never declare project paths
+    if (typeof parent !== 'string' || !parent.startsWith('.') || depth >= MAX_EXTENDS_DEPTH) continue
+    const parentFile = resolve(dirname(file), parent.endsWith('.json') ? parent : `${parent}.json`)
+    Object.assign(inherited, readCompilerOptions(parentFile, depth + 1))
+  }
+
+  const options = isRecord(config.compilerOptions) ? config.compilerOptions : {}
+  return {
+    ...inherited,
+    ...(typeof options.baseUrl === 'string' ? { baseUrl: resolve(dirname(file), options.baseUrl) } : {}),
+    ...(isRecord(options.paths) ? { paths: options.paths, pathsDir: dirname(file) } : {}),
+  }
+}
+
+function mapperAliases(mapper: Record<string, unknown>, rootDir: string, source: string): PathAlias[] {
+  const aliases: PathAlias[] = []
+
+  for (const [regex, value] of Object.entries(mapper)) {
+    const pattern = regexToPattern(regex)
+    const targets = (Array.isArray(value) ? value : [value])
+      .filter((target): target is string => typeof target === 'string')
+      .filter(target => /^(?:<rootDir>|\.{1,2}\/|\/)/.test(target))
+      .map(target => resolve(rootDir, target.replace(/^<rootDir>\/?/, '').replace(/\$1/, '*')))
+    if (pattern && targets.length > 0) aliases.push({ pattern, targets, scope: rootDir, source })
+  }
+
+  return aliases
+}
+
+/**
+ * `^@app/(.*)$` → `@app/*`; returns undefined for regexes a path pattern cannot express
+ */
+function regexToPattern(regex: string): string | undefined {
+  const body = regex.replace(/^\^/, '').replace(/\$$/, '').replace(/\((?:\.\*|\.\+)\)/, '*').replace(/\\([./@-])/g, '$1')
+  if (/[()[\]{}|+?^$\\]/.test(body) || body.includes('.*') || (body.match(/\*/g) ?? []).length > 1) return undefined
+  return body
+}
+
+function targetOf(value: string, directory: string): string | undefined {
+  const parts = readStrings(value)
+  if (parts.length === 0) return undefined
+  // `path.resolve(__dirname, 'src')`, `new URL('./src', import.meta.url)` and plain
+  // relative strings all resolve against the config's directory
+  if (!/__dirname|import\.meta|process\.cwd\(\)|\bresolve\(/.test(value) && !/^\.{0,2}\//.test(parts[0]!)) return undefined
+  return resolve(directory, ...parts)
+}
+
+function readObjectsAfter(content: string, opening: RegExp): [string, string][] {
+  return [...content.matchAll(opening)].flatMap(match => readObject(content, match.index! + match[0].length - 1))
+}
+
+/**
+ * Reads the `key: value` entries of an object literal whose opening brace is at `open`
+ */
+function readObject(content: string, open: number): [string, string][] {
+  const start = content.indexOf('{', open)
+  const entries = start === -1 ? [] : readCallArguments(content, start)?.args ?? []
+  return entries.flatMap((entry) => {
+    const [key, ...value] = splitTopLevel(entry, ':')
+    if (!key || value.length === 0) return []
+    return [[key.trim().replace(/^(['"`])(.*)\1$/, '$2'), value.join(':').trim()] as [string, string]]
+  })
+}
+
+function readStrings(expression: string): string[] {
+  return [...expression.matchAll(/(['"`])((?:\\.|(?!\1).)*)\1/g)].map(match => match[2]!)
+}
+
+/**
+ * JSON with the comments and trailing commas tsconfig files allow
+ */
+function parseJsonc(content: string): Record<string, unknown> | undefined {
+  let output = ''
+  for (let i = 0; i < content.length; i++) {
+    const char = content[i]!
+    if (char === '"') {
+      const start = i
+      for (i++; i < content.length && content[i] !== '"'; i++) {
+        if (content[i] === '\\') i++
+      }
+      output += content.substring(start, i + 1)
+    }
+    else if (char === '/' && content[i + 1] === '/') {
+      while (i < content.length && content[i] !== '\n') i++
+      output += '\n'
+    }
+    else if (char === '/' && content[i + 1] === '*') {
+      i = content.indexOf('*/', i + 2)
+      if (i === -1) break
+      i++
+    }
+    else {
+      output += char
+    }
+  }
+
+  try {
+    const parsed: unknown = JSON.parse(output.replace(/,(\s*[}\]])/g, '$1'))
+    return isRecord(parsed) ? parsed : undefined
+  }
+  catch {
+    return undefined
+  }
+}
+
+/**
+ * Directories below the root, up to the depth, holding any file that can declare aliases
+ */
+export function findConfigDirs(root: string, maxDepth: number): string[] {
+  const dirs: string[] = []
+
+  function search(dir: string, depth: number) {
+    if (depth > maxDepth) return
+    let entries: string[] = []
+    try {
+      entries = readdirSync(dir)
+    }
+    catch {
+      return
+    }
+
+    if (entries.some(entry => PATH_ALIAS_FILES.has(entry))) dirs.push(dir)
+    for (const entry of entries) {
+      if (entry.startsWith('.') || GLOBAL_IGNORE_DIRS.has(entry)) continue
+      const fullPath = join(dir, entry)
+      if (isDirectory(fullPath)) search(fullPath, depth + 1)
+    }
+  }
+
+  search(root, 0)
+  return dirs
+}
+
+function isRecord(value: unknown): value is Record<string, unknown> {
+  return typeof value === 'object' && value !== null && !Array.isArray(value)
+}
+
+function readText(filePath: string): string {
+  try {
+    return readFileSync(filePath, 'utf-8')
+  }
+  catch {
+    return ''
+  }
+}
@@ -7,6 +7,7 @@ import { createHash } from 'crypto'
 import { access, constants } from 'fs/promises'
 import { createMemoryManager, addProject, getProject, removeProject, type MemoryManager } from './memory.js'
 import { createProject, parseProject, watchProject, updateProject } from './manager.js'
+import { importIndex, type IndexImportReport } from './index-archive.js'
 import { getLogger } from '../utils/logger.js'
 import { PROJECT_ID_PATTERNS } from '../constants/persistence.js'
 import type { Project, ProjectConfig, FileChange } from '../types/core.js'
@@ -47,6 +48,10 @@ function hasConfigChanged(oldConfig: ProjectConfig, newConfig: ProjectConfig): b
     return true
   }
 
+  if ((oldConfig.files ?? []).join('\n') !== (newConfig.files ?? []).join('\n')) {
+    return true
+  }
+
   // Check languages array
   const oldLanguages = oldConfig.languages || []
   const newLanguages = newConfig.languages || []
@@ -99,6 +104,47 @@ export async function getOrCreateProject(
   project.id = finalProjectId
 
   await parseProject(project)
+  await registerProject(manager, project, config.autoWatch === true)
+
+  return project
+}
+
+/**
+ * Loads a project from an index archive, re-parsing only files changed since the export.
+ * Replaces any project already registered under the same ID.
+ */
+export async function loadProjectFromIndex(
+  manager: PersistentProjectManager,
+  file: string,
+  config: ProjectConfig,
+  projectId?: string,
+): Promise<{ project: Project, report: IndexImportReport }> {
+  const directory = resolve(config.directory)
+
+  try {
+    await access(directory, constants.R_OK)
+  }
+  catch {
+    throw new Error(`Directory does not exist or is not accessible: ${directory}`)
+  }
+
+  const finalProjectId = sanitizeProjectId(projectId || generateProjectId(manager, directory))
+  const { project, report } = await importIndex(file, { ...config, directory })
+  project.id = finalProjectId
+
+  removeProjectFromManager(manager, finalProjectId)
+  await registerProject(manager, project, config.autoWatch === true)
+
+  return { project, report }
+}
+
+async function registerProject(
+  manager: PersistentProjectManager,
+  project: Project,
+  autoWatch: boolean,
+): Promise<void> {
+  const logger = getLogger()
+  const directory = project.config.directory
 
   // Handle eviction before adding new project
   if (manager.memory.projects.size >= manager.memory.maxProjects) {
@@ -111,14 +157,12 @@ export async function getOrCreateProject(
   }
 
   addProject(manager.memory, project)
-  manager.directoryToProject.set(directory, finalProjectId)
-  manager.projectToDirectory.set(finalProjectId, directory)
+  manager.directoryToProject.set(directory, project.id)
+  manager.projectToDirectory.set(project.id, directory)
 
-  if (config.autoWatch === true) {
+  if (autoWatch) {
     startWatching(manager, project)
   }
-
-  return project
 }
 
 export function generateProjectId(
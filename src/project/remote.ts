@@ -0,0 +1,123 @@
+/**
+ * Remote repository support - shallow-clones git URLs into a local cache so they can be indexed
+ */
+
+import { join } from 'path'
+import { homedir } from 'os'
+import { createHash } from 'crypto'
+import { execFile } from 'child_process'
+import { promisify } from 'util'
+import { readdirSync } from 'fs'
+import { mkdir, rm } from 'fs/promises'
+import { isDirectory } from '../utils/helpers.js'
+import { getLogger } from '../utils/logger.js'
+import { REMOTE_REPO_CONFIG } from '../constants/persistence.js'
+
+const execFileAsync = promisify(execFile)
+
+const GIT_URL_PATTERN = /^(?:https?:\/\/|ssh:\/\/|git:\/\/|git@[\w.-]+:)/
+
+// Set by `--allow-file-remotes`. A file:// URL reaches any repository on the server's disk,
+// past the checks a local directory gets, so it is not a git URL by default
+let fileRemotesAllowed = false
+
+export interface RemoteCheckout {
+  url: string
+  ref: string
+  directory: string
+  commit: string
+}
+
+/**
+ * Checks whether a string looks like a git remote rather than a local path
+ */
+export function isGitUrl(value: string): boolean {
+  return GIT_URL_PATTERN.test(value) || (fileRemotesAllowed && value.startsWith('file://'))
+}
+
+/**
+ * Enables or disables cloning file:// URLs
+ */
+export function setFileRemotesAllowed(enabled: boolean): void {
+  fileRemotesAllowed = enabled
+}
+
+/**
+ * Returns the cache directory a remote checkout lives in. Each url/ref pair gets its own
+ * directory so different refs of the same repository can be indexed side by side.
+ */
+export function getRemoteCacheDir(url: string, ref: string = REMOTE_REPO_CONFIG.DEFAULT_REF): string {
+  const cacheRoot = getRemoteCacheRoot()
+  const repoName = url.replace(/\.git$/, '').split(/[/:]/).filter(Boolean).pop() || 'repo'
+  const hash = createHash('sha256').update(`${url}#${ref}`).digest('hex').substring(0, 12)
+  return join(cacheRoot, `${repoName.replace(/[^\w.-]/g, '_')}-${hash}`)
+}
+
+/**
+ * Lists the checkouts in the remote repository cache. Their directory names are the
+ * project IDs register_project assigns when no ID is given.
+ */
+export function listCachedCheckouts(): string[] {
+  try {
+    const cacheRoot = getRemoteCacheRoot()
+    return readdirSync(cacheRoot)
+      .map(name => join(cacheRoot, name))
+      .filter(directory => isDirectory(join(directory, '.git')))
+  }
+  catch {
+    return []
+  }
+}
+
+function getRemoteCacheRoot(): string {
+  return process.env[REMOTE_REPO_CONFIG.CACHE_DIR_ENV] || join(homedir(), REMOTE_REPO_CONFIG.DEFAULT_CACHE_SUBDIR)
+}
+
+/**
+ * Shallow-clones a repository at a ref, reusing an existing checkout unless a refresh is requested.
+ * Fetching the ref directly works for branches, tags and commit SHAs alike.
+ */
+export async function checkoutRemoteRepository(url: string, ref?: string, refresh = false): Promise<RemoteCheckout> {
+  const logger = getLogger()
+  const actualRef = ref || REMOTE_REPO_CONFIG.DEFAULT_REF
+  // git would read a ref starting with a dash, such as --upload-pack=<cmd>, as an option
+  if (actualRef.startsWith('-')) {
+    throw new Error(`Invalid git ref: ${actualRef}`)
+  }
+  const directory = getRemoteCacheDir(url, actualRef)
+  const hasCheckout = isDirectory(join(directory, '.git'))
+
+  if (!hasCheckout || refresh) {
+    logger.info(`Fetching ${url}@${actualRef} into ${directory}`)
+
+    try {
+      if (!hasCheckout) {
+        await rm(directory, { recursive: true, force: true })
+        await mkdir(directory, { recursive: true })
+        await git(directory, ['init', '--quiet'])
+        await git(directory, ['remote', 'add', 'origin', url])
+      }
+      await git(directory, ['fetch', '--depth', '1', '--quiet', 'origin', actualRef])
+      await git(directory, ['checkout', '--quiet', '--force', 'FETCH_HEAD'])
+    }
+    catch (error) {
+      if (!hasCheckout) {
+        await rm(directory, { recursive: true, force: true })
+      }
+      const detail = (error as { stderr?: string }).stderr?.trim() || (error instanceof Error ? error.message : String(error))
+      throw new Error(`Failed to fetch ${url}@${actualRef}: ${detail}`)
+    }
+  }
+
+  const commit = (await git(directory, ['rev-parse', 'HEAD'])).trim()
+  return { url, ref: actualRef, directory, commit }
+}
+
+async function git(cwd: string, args: string[]): Promise<string> {
+  const { stdout } = await execFileAsync('git', args, {
+    cwd,
+    timeout: REMOTE_REPO_CONFIG.CLONE_TIMEOUT_MS,
+    env: { ...process.env, GIT_TERMINAL_PROMPT: '0' },
+  })
+  return stdout
+}
@@ -0,0 +1,39 @@
+/**
+ * Saved queries - search queries named once under `queries` in `.tree-sitter-mcp.json`
+ * (e.g. "endpoints": "kind:function name:~Handler -path:vendor") and referenced as `@endpoints`
+ */
+
+import { loadProjectSettings } from './settings.js'
+import { PROJECT_FILES } from '../constants/index.js'
+
+// `@name` where a term may start: at the beginning, after a space, `(` or a negating `-`
+const REFERENCE = /(^|[\s(-])@([\w.-]+)(?=$|[\s)])/g
+
+/**
+ * Replaces each `@name` in a query with the saved query of that name, in parentheses, so
+ * saved queries combine with other terms and with each other. Queries are returned unchanged
+ * when the project saves none, as `@` may then be part of a name.
+ */
+export function expandSavedQueries(directory: string, query: string): string {
+  const queries = loadProjectSettings(directory).queries ?? {}
+  if (Object.keys(queries).length === 0) return query
+  return expand(query, queries, [])
+}
+
+function expand(query: string, queries: Record<string, string>, expanding: string[]): string {
+  // A query that is only a reference stays as saved, plain names included
+  const whole = /^\s*@([\w.-]+)\s*$/.exec(query)
+  if (whole) return savedQuery(whole[1]!, queries, expanding)
+  return query.replace(REFERENCE, (_match, prefix: string, name: string) => `${prefix}(${savedQuery(name, queries, expanding)})`)
+}
+
+function savedQuery(name: string, queries: Record<string, string>, expanding: string[]): string {
+  const saved = queries[name]
+  if (typeof saved !== 'string') {
+    throw new Error(`Unknown saved query: @${name}. Queries defined in ${PROJECT_FILES.SETTINGS}: ${Object.keys(queries).map(defined => `@${defined}`).join(', ')}`)
+  }
+  if (expanding.includes(name)) {
+    throw new Error(`Saved query @${name} refers to itself through ${[...expanding, name].map(step => `@${step}`).join(' -> ')}`)
+  }
+  return expand(saved, queries, [...expanding, name])
+}
@@ -0,0 +1,72 @@
+/**
+ * Named scopes - globs declared once under `scopes` in `.tree-sitter-mcp.json`
+ * (e.g. "backend": "services/**") and referenced by name from tools and commands
+ */
+
+import { relative, sep } from 'path'
+import { loadProjectSettings } from './settings.js'
+import { PROJECT_FILES } from '../constants/index.js'
+import { globToRegExp } from '../utils/helpers.js'
+import type { Project } from '../types/core.js'
+
+export interface ScopeMatcher {
+  name: string
+  include: RegExp[]
+  exclude: RegExp[]
+}
+
+/**
+ * Resolves a scope name against the project's settings. Globs are relative to the project
+ * directory; a glob starting with `!` excludes paths.
+ */
+export function resolveScope(directory: string, name: string): ScopeMatcher {
+  const scopes = loadProjectSettings(directory).scopes ?? {}
+  const globs = scopes[name]
+
+  if (globs === undefined) {
+    const defined = Object.keys(scopes)
+    throw new Error(defined.length > 0
+      ? `Unknown scope: ${name}. Defined scopes: ${defined.join(', ')}`
+      : `Unknown scope: ${name}. No scopes are defined in ${PROJECT_FILES.SETTINGS}`)
+  }
+
+  const patterns = (Array.isArray(globs) ? globs : [globs]).filter((glob): glob is string => typeof glob === 'string')
+  return {
+    name,
+    include: patterns.filter(glob => !glob.startsWith('!')).flatMap(toRegExps),
+    exclude: patterns.filter(glob => glob.startsWith('!')).flatMap(glob => toRegExps(glob.substring(1))),
+  }
+}
+
+/**
+ * Returns a view of the project, and its sub-projects, restricted to the files of a scope.
+ * The project itself is returned when no scope is given.
+ */
+export function scopeProject(project: Project, scope?: string): Project {
+  if (!scope) return project
+
+  const root = project.config.directory
+  const matcher = resolveScope(root, scope)
+  const inScope = (path: string) => matchesScope(matcher, relative(root, path).split(sep).join('/'))
+
+  const restrict = (current: Project): Project => ({
+    ...current,
+    files: new Map([...current.files].filter(([path]) => inScope(path))),
+    nodes: new Map([...current.nodes].filter(([path]) => inScope(path))),
+    diagnostics: current.diagnostics?.filter(diagnostic => inScope(diagnostic.path)),
+    subProjects: current.subProjects?.map(restrict),
+  })
+
+  return restrict(project)
+}
+
+export function matchesScope(matcher: ScopeMatcher, relativePath: string): boolean {
+  return matcher.include.some(pattern => pattern.test(relativePath))
+    && !matcher.exclude.some(pattern => pattern.test(relativePath))
+}
+
+function toRegExps(glob: string): RegExp[] {
+  const trimmed = glob.replace(/^\.\//, '').replace(/\/+$/, '')
+  // A plain path names a file or everything below a directory
+  return /[*?]/.test(trimmed) ? [globToRegExp(trimmed)] : [globToRegExp(trimmed), globToRegExp(`${trimmed}/**`)]
+}
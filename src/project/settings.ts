@@ -0,0 +1,50 @@
+/**
+ * Project settings - optional `.tree-sitter-mcp.json` at the project root holding the
+ * user-supplied patterns some analyses need (e.g. a homegrown feature flag API)
+ */
+
+import { join } from 'path'
+import { readFileSync } from 'fs'
+import { PROJECT_FILES } from '../constants/index.js'
+import { isFile } from '../utils/helpers.js'
+import { getLogger } from '../utils/logger.js'
+
+export interface FeatureFlagSettings {
+  functions?: string[] // Calls whose first string argument is a flag key, e.g. `isFeatureOn` or `flags.enabled`
+  patterns?: string[] // Regular expressions whose first capture group is the flag key
+  sdks?: string[] // Built-in SDK presets to match; all of them when omitted
+}
+
+export interface EditSettings {
+  allowSyntaxErrors?: boolean // Apply edits that introduce parse errors instead of refusing them
+}
+
+export interface LanguageServerSettings {
+  enabled?: boolean // Ask installed language servers (gopls, tsserver, pyright) for precise answers; on by default
+  timeoutMs?: number // How long to wait for an answer before falling back to the index
+}
+
+export interface ProjectSettings {
+  featureFlags?: FeatureFlagSettings
+  edits?: EditSettings
+  languageServers?: LanguageServerSettings
+  scopes?: Record<string, string | string[]> // Named globs tools accept as `scope`, e.g. { "backend": "services/**" }
+  queries?: Record<string, string> // Named search queries, used as `@name`, e.g. { "endpoints": "kind:function name:~Handler" }
+}
+
+/**
+ * Reads the settings file of a project directory. A missing or malformed file yields empty settings.
+ */
+export function loadProjectSettings(directory: string): ProjectSettings {
+  const settingsPath = join(directory, PROJECT_FILES.SETTINGS)
+  if (!isFile(settingsPath)) return {}
+
+  try {
+    const settings = JSON.parse(readFileSync(settingsPath, 'utf-8'))
+    return settings && typeof settings === 'object' ? settings as ProjectSettings : {}
+  }
+  catch (error) {
+    getLogger().warn(`Ignoring unreadable ${PROJECT_FILES.SETTINGS} in ${directory}: ${error}`)
+    return {}
+  }
+}
@@ -0,0 +1,214 @@
+/**
+ * Task runner discovery - reads Makefile targets, Taskfile tasks and justfile recipes so
+ * the project's own build/test automation can be listed
+ */
+
+import { basename } from 'path'
+import { INFRASTRUCTURE_FILE_PATTERNS } from '../constants/index.js'
+import { parseYaml, getYamlKeyLine } from '../utils/yaml.js'
+import type { TreeNode } from '../types/core.js'
+
+export type TaskRunner = 'make' | 'task' | 'just'
+
+export interface TaskDefinition {
+  name: string
+  runner: TaskRunner
+  file: string
+  line: number
+  description?: string
+  dependencies: string[]
+  commands: string[]
+}
+
+/**
+ * Finds the task runner for a file by its name
+ */
+export function getTaskRunner(filePath: string): TaskRunner | null {
+  const fileName = basename(filePath)
+  if (INFRASTRUCTURE_FILE_PATTERNS.MAKEFILE.some(pattern => pattern.test(fileName)) || fileName.endsWith('.mk')) return 'make'
+  if (INFRASTRUCTURE_FILE_PATTERNS.TASKFILE.some(pattern => pattern.test(fileName))) return 'task'
+  if (INFRASTRUCTURE_FILE_PATTERNS.JUSTFILE.some(pattern => pattern.test(fileName))) return 'just'
+  return null
+}
+
+/**
+ * Lists the tasks declared by every Makefile, Taskfile and justfile among the given file nodes
+ */
+export function extractTasks(fileNodes: TreeNode[]): TaskDefinition[] {
+  const tasks: TaskDefinition[] = []
+
+  for (const fileNode of fileNodes) {
+    const runner = getTaskRunner(fileNode.path)
+    if (!runner || !fileNode.content) continue
+
+    tasks.push(...(runner === 'make'
+      ? parseMakefileTargets(fileNode.content, fileNode.path)
+      : runner === 'task'
+        ? parseTaskfile(fileNode.content, fileNode.path)
+        : parseJustfile(fileNode.content, fileNode.path)))
+  }
+
+  return tasks
+}
+
+/**
+ * Extracts explicit targets from a Makefile. Pattern rules and special targets (`.PHONY`, ...) are
+ * skipped. A `## text` comment after the prerequisites, or comments directly above, describe the target.
+ */
+export function parseMakefileTargets(content: string, file: string): TaskDefinition[] {
+  const lines = content.split('\n')
+  const tasks: TaskDefinition[] = []
+  let current: TaskDefinition[] = []
+
+  for (let i = 0; i < lines.length; i++) {
+    const line = lines[i]!
+
+    if (line.startsWith('\t')) {
+      const command = joinContinuations(lines, i, '\t')
+      i = command.end
+      if (command.text) current.forEach(task => task.commands.push(command.text))
+      continue
+    }
+
+    const rule = line.match(/^([^\s:#=][^:=]*?)\s*:(?![=:])\s*(.*)$/)
+    if (!rule) {
+      if (line.trim() && !line.trim().startsWith('#')) current = []
+      continue
+    }
+
+    const [prerequisites = '', inlineComment] = rule[2]!.split(/\s*##\s*/)
+    const [dependencyText = '', inlineRecipe] = prerequisites.split(/\s*;\s*/)
+    const description = inlineComment?.trim() || readCommentAbove(lines, i)
+    const dependencies = dependencyText.split(/\s+/).filter(word => word && word !== '|')
+
+    current = rule[1]!.split(/\s+/)
+      .filter(name => name && !name.startsWith('.') && !name.includes('%') && !name.includes('$'))
+      .map(name => ({ name, runner: 'make' as const, file, line: i + 1, description, dependencies, commands: [] }))
+    if (inlineRecipe) current.forEach(task => task.commands.push(inlineRecipe.trim()))
+    tasks.push(...current)
+  }
+
+  return tasks
+}
+
+/**
+ * Extracts tasks from a Taskfile (go-task). Tasks may be a single command string, a list of
+ * commands, or a mapping with `desc`, `deps` and `cmds`.
+ */
+export function parseTaskfile(content: string, file: string): TaskDefinition[] {
+  const document = parseYaml(content) as { tasks?: Record<string, unknown> } | null
+  const tasks = document?.tasks
+  if (!tasks || typeof tasks !== 'object') return []
+
+  return Object.entries(tasks).map(([name, definition]) => {
+    const task = (typeof definition === 'string' || Array.isArray(definition) ? { cmds: definition } : definition ?? {}) as {
+      desc?: string
+      summary?: string
+      deps?: unknown[]
+      cmds?: unknown
+      cmd?: string
+    }
+    const commands = task.cmd ? [task.cmd] : Array.isArray(task.cmds) ? task.cmds : task.cmds ? [task.cmds] : []
+
+    return {
+      name,
+      runner: 'task' as const,
+      file,
+      line: getYamlKeyLine(tasks, name) ?? 1,
+      description: task.desc ?? task.summary?.split('\n')[0],
+      dependencies: (task.deps ?? []).map(readTaskReference).filter((dep): dep is string => Boolean(dep)),
+      commands: commands
+        .map(command => typeof command === 'string' ? command : readTaskCommand(command))
+        .filter((command): command is string => Boolean(command)),
+    }
+  })
+}
+
+/**
+ * Extracts recipes from a justfile. Settings, aliases, variables and `[private]` or
+ * underscore-prefixed recipes are skipped.
+ */
+export function parseJustfile(content: string, file: string): TaskDefinition[] {
+  const lines = content.split('\n')
+  const tasks: TaskDefinition[] = []
+  let current: TaskDefinition | null = null
+  let isPrivate = false
+
+  for (let i = 0; i < lines.length; i++) {
+    const line = lines[i]!
+
+    if (current && /^\s+\S/.test(line)) {
+      const command = joinContinuations(lines, i, '')
+      i = command.end
+      current.commands.push(command.text.replace(/^[@-]+/, ''))
+      continue
+    }
+
+    const trimmed = line.trim()
+    if (!trimmed || trimmed.startsWith('#')) continue
+    current = null
+
+    if (/^\[.*\bprivate\b.*\]$/.test(trimmed)) {
+      isPrivate = true
+      continue
+    }
+    if (trimmed.startsWith('[') || /^(set|alias|export|import|mod)\s/.test(trimmed) || /^[\w-]+\s*:=/.test(trimmed)) continue
+
+    const recipe = line.match(/^@?([A-Za-z_][\w-]*)((?:\s+[^:\s]+)*)\s*:(?!=)\s*(.*)$/)
+    if (!recipe) continue
+
+    const name = recipe[1]!
+    // Dependencies may pass arguments, `(build "release")`; only the recipe names are kept
+    const dependencyText = recipe[3]!.split('#')[0]!.replace(/"[^"]*"|'[^']*'/g, '')
+    if (!isPrivate && !name.startsWith('_')) {
+      current = {
+        name,
+        runner: 'just',
+        file,
+        line: i + 1,
+        description: readCommentAbove(lines, i),
+        dependencies: dependencyText.match(/\(\s*[\w-]+|[\w-]+/g)?.map(dep => dep.replace(/^\(\s*/, '')) ?? [],
+        commands: [],
+      }
+      tasks.push(current)
+    }
+    isPrivate = false
+  }
+
+  return tasks
+}
+
+function readTaskReference(dep: unknown): string | undefined {
+  if (typeof dep === 'string') return dep
+  return dep && typeof dep === 'object' ? (dep as { task?: string }).task : undefined
+}
+
+function readTaskCommand(command: unknown): string | undefined {
+  if (!command || typeof command !== 'object') return undefined
+  const { cmd, task } = command as { cmd?: string, task?: string }
+  return cmd ?? (task ? `task ${task}` : undefined)
+}
+
+function joinContinuations(lines: string[], start: number, prefix: string): { text: string, end: number } {
+  let text = lines[start]!.substring(prefix.length).trim()
+  let end = start
+
+  while (text.endsWith('\\') && end + 1 < lines.length) {
+    end++
+    text = text.slice(0, -1).trimEnd() + ' ' + lines[end]!.trim()
+  }
+
+  return { text: prefix === '\t' ? text.replace(/^[@+-]+/, '') : text, end }
+}
+
+function readCommentAbove(lines: string[], index: number): string | undefined {
+  const comments: string[] = []
+
+  for (let i = index - 1; i >= 0; i--) {
+    const line = lines[i]!.trim()
+    if (!line.startsWith('#')) break
+    comments.unshift(line.replace(/^#+\s?/, ''))
+  }
+
+  return comments.length > 0 ? comments.join(' ').trim() : undefined
+}
@@ -0,0 +1,54 @@
+/**
+ * Test files - walks a project for tests and test doubles, including the test directories
+ * indexing skips, for tools that learn from or reuse what the project's tests already do
+ */
+
+import { readdirSync } from 'fs'
+import { basename, join, relative } from 'path'
+import { GLOBAL_IGNORE_DIRS, isTestFile } from '../constants/index.js'
+import { isFile } from '../utils/helpers.js'
+
+const TEST_DIRECTORIES = new Set(['test', 'tests', '__tests__', 'spec', 'specs', '__test__'])
+const MAX_DEPTH = 8
+
+/**
+ * Files under the root accepted by the predicate, given their `/`-separated path relative to
+ * the root, in path order and up to the limit
+ */
+export function findTestFiles(root: string, accept: (path: string) => boolean, limit: number): string[] {
+  const found: string[] = []
+  const walk = (directory: string, depth: number) => {
+    if (depth > MAX_DEPTH || found.length >= limit) return
+    let entries: string[]
+    try {
+      entries = readdirSync(directory)
+    }
+    catch {
+      return
+    }
+    for (const entry of entries.sort()) {
+      if (found.length >= limit) return
+      if (entry.startsWith('.') || (GLOBAL_IGNORE_DIRS.has(entry) && !TEST_DIRECTORIES.has(entry))) continue
+      const path = join(directory, entry)
+      if (isFile(path)) {
+        if (accept(relative(root, path).replace(/\\/g, '/'))) found.push(path)
+      }
+      else {
+        walk(path, depth + 1)
+      }
+    }
+  }
+  walk(root, 0)
+  return found
+}
+
+/**
+ * Whether a root-relative path is a test or test support file: `.test`/`.spec` files and test
+ * directories, Go `_test.go`, pytest `test_*.py`, `*_test.py` and `conftest.py`, and Jest
+ * `__mocks__`
+ */
+export function isTestPath(path: string): boolean {
+  const name = basename(path)
+  return isTestFile(`/${path}`) || name.endsWith('_test.go') || /^test_.*\.py$|_test\.py$|^conftest\.py$/.test(name)
+    || path.split('/').includes('__mocks__')
+}
@@ -0,0 +1,13 @@
+from flask import Flask, jsonify
+
+app = Flask(__name__)
+
+
+@app.route('/reports/<int:report_id>')
+def get_report(report_id):
+    return jsonify({'id': report_id})
+
+
+@app.route('/reports/<int:report_id>/export', methods=['POST'])
+def export_report(report_id):
+    return jsonify({'queued': True})
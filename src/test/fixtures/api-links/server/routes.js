@@ -0,0 +1,11 @@
+const express = require('express')
+const { listUsers, getUser, createUser, deleteUser } = require('./users')
+
+const router = express.Router()
+
+router.get('/api/users', listUsers)
+router.get('/api/users/:id', getUser)
+router.post('/api/users', createUser)
+router.delete('/api/users/:id', deleteUser)
+
+module.exports = router
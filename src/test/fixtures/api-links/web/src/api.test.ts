@@ -0,0 +1,5 @@
+import { it } from 'vitest'
+
+it('reaches the health endpoint', async () => {
+  await fetch('/api/health')
+})
@@ -0,0 +1,36 @@
+import axios from 'axios'
+import { api } from './client'
+
+const API_BASE = import.meta.env.VITE_API_BASE
+
+export async function loadUsers() {
+  const response = await fetch(`${API_BASE}/api/users`)
+  return response.json()
+}
+
+export async function loadUser(id: string) {
+  return fetch(`/api/users/${id}?expand=teams`).then(response => response.json())
+}
+
+export async function saveUser(user: { name: string }) {
+  return fetch('/api/users', {
+    method: 'POST',
+    body: JSON.stringify(user),
+  })
+}
+
+export async function loadReport(id: number) {
+  return axios.get('/reports/' + id)
+}
+
+export async function exportReport(id: number) {
+  return axios({ url: '/reports/' + id + '/export', method: 'post' })
+}
+
+export async function loadOrders() {
+  return api.get<unknown[]>('/api/orders')
+}
+
+export async function follow(url: string) {
+  return fetch(url)
+}
@@ -0,0 +1,7 @@
+const http = require('http')
+
+function startServer(port) {
+  return http.createServer((req, res) => res.end('ok')).listen(port)
+}
+
+startServer(8080)
@@ -0,0 +1,3 @@
+#!/usr/bin/env bash
+export WORKER_COUNT=8
+node src/config.js
@@ -0,0 +1,14 @@
+const { DATABASE_URL, SESSION_SECRET = 'dev' } = process.env
+
+function loadConfig() {
+  return {
+    databaseUrl: DATABASE_URL,
+    sessionSecret: SESSION_SECRET,
+    port: Number(process.env.APP_PORT ?? 3000),
+    redisUrl: process.env['REDIS_URL'],
+    sentryDsn: process.env.SENTRY_DSN,
+    home: process.env.HOME,
+  }
+}
+
+module.exports = { loadConfig }
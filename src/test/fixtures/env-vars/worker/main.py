@@ -0,0 +1,5 @@
+import os
+
+QUEUE = os.environ["QUEUE_NAME"]
+LEVEL = os.getenv("LOG_LEVEL", "info")
+WORKERS = int(os.environ.get("WORKER_COUNT", "4"))
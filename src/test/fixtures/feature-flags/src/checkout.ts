@@ -0,0 +1,10 @@
+import { ldClient } from './clients'
+import { isFeatureOn } from './flags'
+
+export async function renderCheckout(user: { key: string }) {
+  const newFlow = await ldClient.variation('checkout-v2', user, false)
+  if (isFeatureOn('express-pay')) {
+    return 'express'
+  }
+  return newFlow ? 'v2' : 'v1'
+}
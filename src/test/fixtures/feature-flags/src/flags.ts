@@ -0,0 +1,5 @@
+const enabled = new Set(['express-pay'])
+
+export function isFeatureOn(key: string): boolean {
+  return enabled.has(key)
+}
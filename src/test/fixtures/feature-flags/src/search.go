@@ -0,0 +1,11 @@
+package search
+
+import "github.com/Unleash/unleash-client-go/v4"
+
+func useRanking() bool {
+	return unleash.IsEnabled("search.ranking-v3")
+}
+
+func useCheckoutFlow(ctx Context) bool {
+	return ldClient.BoolVariation("checkout-v2", ctx, false)
+}
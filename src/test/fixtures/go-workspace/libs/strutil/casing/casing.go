@@ -0,0 +1,12 @@
+package casing
+
+import "strings"
+
+// ToTitle upper-cases the first letter of every word
+func ToTitle(value string) string {
+	words := strings.Fields(value)
+	for i, word := range words {
+		words[i] = strings.ToUpper(word[:1]) + word[1:]
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,3 @@
+module example.com/libs/strutil
+
+go 1.22
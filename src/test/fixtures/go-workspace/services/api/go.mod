@@ -0,0 +1,5 @@
+module example.com/services/api
+
+go 1.22
+
+require example.com/libs/strutil v0.0.0
@@ -0,0 +1,11 @@
+package main
+
+import (
+	"fmt"
+
+	"example.com/libs/strutil/casing"
+)
+
+func main() {
+	fmt.Println(casing.ToTitle("hello workspace"))
+}
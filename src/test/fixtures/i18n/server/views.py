@@ -0,0 +1,5 @@
+from django.utils.translation import gettext as _
+
+
+def dashboard(request):
+    return {"title": _("Welcome back"), "logout": _("Log out")}
@@ -0,0 +1,20 @@
+import { useTranslation } from 'react-i18next'
+
+export function App({ name, count, code }: { name: string, count: number, code?: string }) {
+  const { t } = useTranslation()
+
+  return (
+    <main>
+      <nav>
+        <a href="/">{t('nav.home')}</a>
+        <a href="/settings">{t('common:nav.settings')}</a>
+      </nav>
+      <h1>{t('greeting', { name })}</h1>
+      <p>{t('items', { count })}</p>
+      <h2>{t('checkout.title')}</h2>
+      {code && <p role="alert">{t(`errors.${code}`)}</p>}
+      <input placeholder="Search products" />
+      <button type="submit">Save draft</button>
+    </main>
+  )
+}
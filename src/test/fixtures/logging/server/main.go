@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+func main() {
+	fmt.Println("starting server")
+	db, err := connect(os.Getenv("DATABASE_URL"))
+	if err != nil {
+		slog.Error("database connection failed", "url", maskedURL())
+		os.Exit(1)
+	}
+	slog.Info("server ready", "port", 8080, "db", db.Name)
+
+	if err := serve(db); err != nil {
+		slog.Error("server stopped", "err", err)
+	}
+}
@@ -0,0 +1,14 @@
+import { logger } from './logger'
+import { charge } from './payments'
+
+export async function createOrder(orderId: string, user: { id: string }) {
+  logger.info({ orderId, userId: user.id }, 'order created')
+
+  try {
+    await charge(orderId)
+  }
+  catch (error) {
+    logger.error(`payment failed for ${orderId}`)
+    console.error('charge failed', error)
+  }
+}
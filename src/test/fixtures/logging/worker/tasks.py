@@ -0,0 +1,14 @@
+import logging
+
+logger = logging.getLogger(__name__)
+
+
+def run(job):
+    logger.info("job started", extra={"job_id": job.id})
+    try:
+        job.execute()
+    except ValueError:
+        logger.exception("invalid job input")
+    except Exception as exc:
+        logger.error("job %s failed", job.id)
+    print("job finished")
@@ -0,0 +1,24 @@
+"""create invoices
+
+Revision ID: a1b2c3d4e5f6
+Revises:
+"""
+from alembic import op
+import sqlalchemy as sa
+
+revision = 'a1b2c3d4e5f6'
+down_revision = None
+
+
+def upgrade():
+    op.create_table(
+        'invoices',
+        sa.Column('id', sa.Integer(), nullable=False),
+        sa.Column('account_id', sa.Integer(), sa.ForeignKey('accounts.id'), nullable=True),
+        sa.Column('amount', sa.Integer()),
+        sa.PrimaryKeyConstraint('id'),
+    )
+
+
+def downgrade():
+    op.drop_table('invoices')
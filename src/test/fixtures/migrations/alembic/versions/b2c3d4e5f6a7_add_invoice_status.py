@@ -0,0 +1,21 @@
+"""add invoice status
+
+Revision ID: b2c3d4e5f6a7
+Revises: a1b2c3d4e5f6
+"""
+from alembic import op
+import sqlalchemy as sa
+
+revision = 'b2c3d4e5f6a7'
+down_revision = 'a1b2c3d4e5f6'
+
+
+def upgrade():
+    op.add_column('invoices', sa.Column('status', sa.String(length=20), nullable=False, server_default='draft'))
+    with op.batch_alter_table('invoices') as batch_op:
+        batch_op.alter_column('amount', new_column_name='amount_cents', type_=sa.BigInteger())
+    op.drop_table('legacy_invoices')
+
+
+def downgrade():
+    op.drop_column('invoices', 'status')
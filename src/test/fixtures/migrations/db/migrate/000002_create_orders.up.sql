@@ -0,0 +1,11 @@
+-- Orders belong to a user
+CREATE TABLE orders (
+  id BIGSERIAL,
+  user_id BIGINT NOT NULL,
+  total NUMERIC(10, 2),
+  note TEXT DEFAULT 'none; yet',
+  PRIMARY KEY (id),
+  CONSTRAINT fk_orders_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+
+CREATE INDEX idx_orders_user ON orders (user_id);
@@ -0,0 +1,2 @@
+ALTER TABLE users RENAME COLUMN display_name TO name;
+ALTER TABLE users DROP COLUMN phone;
@@ -0,0 +1,4 @@
+ALTER TABLE users RENAME COLUMN name TO display_name;
+ALTER TABLE users ADD COLUMN phone VARCHAR(32), ALTER COLUMN email TYPE CITEXT;
+ALTER TABLE orders ALTER COLUMN total SET NOT NULL;
+ALTER TABLE orders DROP COLUMN note;
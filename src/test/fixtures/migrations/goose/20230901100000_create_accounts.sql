@@ -0,0 +1,8 @@
+-- +goose Up
+CREATE TABLE accounts (
+  id INTEGER PRIMARY KEY,
+  owner TEXT NOT NULL
+);
+
+-- +goose Down
+DROP TABLE accounts;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE accounts ADD plan TEXT NOT NULL DEFAULT 'free';
+-- +goose StatementEnd
+
+-- +goose Down
+ALTER TABLE accounts DROP COLUMN plan;
@@ -0,0 +1,26 @@
+const express = require('express')
+
+const router = express.Router()
+
+function listUsers(req, res) {
+  res.json([])
+}
+
+function createUser(req, res) {
+  res.status(201).json(req.body)
+}
+
+function getUser(req, res) {
+  res.json({ id: req.params.userId })
+}
+
+function healthCheck(req, res) {
+  res.send('ok')
+}
+
+router.get('/api/v1/users', listUsers)
+router.post('/api/v1/users', createUser)
+router.get('/api/v1/users/:userId', getUser)
+router.get('/health', healthCheck)
+
+module.exports = router
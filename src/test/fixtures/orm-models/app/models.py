@@ -0,0 +1,31 @@
+from typing import List, Optional
+
+from sqlalchemy import ForeignKey, Integer, String, Column
+from sqlalchemy.orm import DeclarativeBase, Mapped, mapped_column, relationship
+
+
+class Base(DeclarativeBase):
+    pass
+
+
+class Account(Base):
+    __tablename__ = "accounts"
+
+    id: Mapped[int] = mapped_column(primary_key=True)
+    owner: Mapped[Optional[str]] = mapped_column(String(100))
+    invoices: Mapped[List["Invoice"]] = relationship(back_populates="account")
+
+    def display_name(self) -> str:
+        return self.owner or "unknown"
+
+
+class Invoice(Base):
+    __tablename__ = "invoices"
+
+    id = Column(Integer, primary_key=True)
+    number = Column("invoice_number", String(20), nullable=False)
+    account_id = Column(
+        Integer,
+        ForeignKey("accounts.id"),
+    )
+    account = relationship("Account", back_populates="invoices")
@@ -0,0 +1,33 @@
+package models
+
+import "gorm.io/gorm"
+
+type Company struct {
+	gorm.Model
+	Name      string     `gorm:"size:255;not null"`
+	Employees []Employee
+}
+
+type Employee struct {
+	ID        uint   `gorm:"primaryKey"`
+	Email     string `gorm:"column:email_address;uniqueIndex"`
+	CompanyID uint
+	Company   Company
+	Manager   *string
+	Skills    []Skill `gorm:"many2many:employee_skills;"`
+	cache     string
+}
+
+func (Employee) TableName() string {
+	return "staff"
+}
+
+type Skill struct {
+	ID   uint   `gorm:"primaryKey"`
+	Name string `gorm:"-"`
+}
+
+// Not a model: no gorm tags or embedded gorm.Model
+type Config struct {
+	Debug bool
+}
@@ -0,0 +1,29 @@
+import { Column, CreateDateColumn, Entity, ManyToOne, OneToMany, PrimaryGeneratedColumn } from 'typeorm'
+import { Customer } from './customer'
+import { OrderLine } from './order-line'
+
+@Entity('orders')
+export class Order {
+  @PrimaryGeneratedColumn('uuid')
+  id!: string
+
+  @Column({ name: 'total_cents', type: 'integer' })
+  totalCents!: number
+
+  @Column({ nullable: true })
+  note?: string
+
+  @CreateDateColumn() createdAt!: Date
+
+  @ManyToOne(() => Customer, customer => customer.orders, {
+    onDelete: 'CASCADE',
+  })
+  customer!: Customer
+
+  @OneToMany(() => OrderLine, line => line.order)
+  lines!: OrderLine[]
+
+  get isEmpty(): boolean {
+    return this.lines.length === 0
+  }
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"context"
+
+	usersv1 "example.com/acme/gen/usersv1"
+)
+
+type userServer struct {
+	usersv1.UnimplementedUserServiceServer
+}
+
+func (s *userServer) GetUser(ctx context.Context, req *usersv1.GetUserRequest) (*usersv1.User, error) {
+	return &usersv1.User{Id: req.Id}, nil
+}
+
+func main() {
+	usersv1.RegisterUserServiceServer(nil, &userServer{})
+}
@@ -0,0 +1,17 @@
+#!/usr/bin/env bash
+set -euo pipefail
+
+export APP_ENV="${APP_ENV:-development}"
+DATA_DIR="$(pwd)/data"
+
+install_deps() {
+  local requirements="requirements.txt"
+  pip install -r "$requirements"
+}
+
+function prepare_data {
+  mkdir -p "$DATA_DIR"
+}
+
+install_deps
+prepare_data
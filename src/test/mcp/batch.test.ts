@@ -0,0 +1,71 @@
+/**
+ * MCP batch tool tests
+ */
+
+import { describe, it, expect } from 'vitest'
+import { resolve } from 'path'
+import { handleToolRequest } from '../../mcp/handlers.js'
+import type { JsonObject } from '../../types/core.js'
+
+describe('MCP batch Tool', () => {
+  const positiveFixture = resolve(import.meta.dirname, '../fixtures/minimal-positive')
+
+  async function callBatch(args: JsonObject) {
+    const result = await handleToolRequest({
+      params: {
+        name: 'batch',
+        arguments: args,
+      },
+    })
+    return JSON.parse(result.content[0]!.text)
+  }
+
+  it('should key results by call id', async () => {
+    const content = await callBatch({
+      calls: [
+        { id: 'user', tool: 'search_code', arguments: { query: 'TestUser', directory: positiveFixture } },
+        { id: 'usage', tool: 'find_usage', arguments: { identifier: 'TestUser', directory: positiveFixture } },
+      ],
+    })
+
+    expect(content.succeeded).toBe(2)
+    expect(content.results.user.ok).toBe(true)
+    expect(content.results.user.result.totalResults).toBeGreaterThan(0)
+    expect(content.results.usage.tool).toBe('find_usage')
+  })
+
+  it('should run calls in parallel and default ids to the call index', async () => {
+    const queries = ['TestUser', 'TestUserService', 'XyzNonexistentElement']
+    const content = await callBatch({
+      calls: queries.map(query => ({ tool: 'search_code', arguments: { query, directory: positiveFixture } })),
+      parallel: true,
+    })
+
+    expect(Object.keys(content.results)).toEqual(['0', '1', '2'])
+    expect(content.results['2'].result.totalResults).toBe(0)
+  })
+
+  it('should report failing calls without stopping the others', async () => {
+    const content = await callBatch({
+      calls: [
+        { tool: 'find_usage', arguments: { directory: positiveFixture } },
+        { tool: 'no_such_tool' },
+        { tool: 'search_code', arguments: { query: 'TestUser', directory: positiveFixture } },
+      ],
+    })
+
+    expect(content.results['0']).toMatchObject({ ok: false, error: 'Identifier must be a string' })
+    expect(content.results['1']).toMatchObject({ ok: false, error: 'Unknown tool: no_such_tool' })
+    expect(content.results['2'].ok).toBe(true)
+    expect(content.failed).toBe(2)
+  })
+
+  it('should reject malformed batches', async () => {
+    await expect(callBatch({ calls: [] })).rejects.toThrow('Calls must be a non-empty array')
+    await expect(callBatch({ calls: [{ tool: 'batch' }] })).rejects.toThrow('Batches cannot be nested')
+    await expect(callBatch({ calls: [{ id: 'a', tool: 'search_code' }, { id: 'a', tool: 'search_code' }] }))
+      .rejects.toThrow('Duplicate call id: a')
+    await expect(callBatch({ calls: Array.from({ length: 21 }, () => ({ tool: 'search_code' })) }))
+      .rejects.toThrow('at most 20 calls')
+  })
+})
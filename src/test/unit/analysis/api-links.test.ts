@@ -0,0 +1,50 @@
+/**
+ * Frontend HTTP call to backend route linking
+ */
+
+import { describe, it, expect } from 'vitest'
+import { resolve } from 'path'
+import { readFileSync } from 'fs'
+import { buildApiLinks, extractHttpCalls } from '../../../analysis/api-links.js'
+import type { TreeNode } from '../../../types/core.js'
+
+describe('API client/server linking', () => {
+  const fixture = resolve(import.meta.dirname, '../../fixtures/api-links')
+  const files: TreeNode[] = ['server/routes.js', 'server/reports.py', 'web/src/api.ts', 'web/src/api.test.ts'].map(file => ({
+    id: file,
+    type: 'file',
+    path: file,
+    content: readFileSync(resolve(fixture, file), 'utf-8'),
+  }))
+  const report = buildApiLinks(files)
+
+  it('should link calls to routes in another language', () => {
+    expect(report.links.map(link => [link.call.method, link.call.path, link.routes.map(route => `${route.method} ${route.path}`)])).toEqual([
+      ['GET', '/api/users', ['GET /api/users']],
+      ['GET', '/api/users/{}', ['GET /api/users/:id']],
+      ['POST', '/api/users', ['POST /api/users']],
+      ['GET', '/reports/{}', ['GET /reports/<int:report_id>']],
+      ['POST', '/reports/{}/export', ['POST /reports/<int:report_id>/export']],
+    ])
+  })
+
+  it('should report calls without routes and routes without callers', () => {
+    expect(report.unmatchedCalls.map(call => `${call.client} ${call.method} ${call.url}:${call.line}`)).toEqual(['api GET /api/orders:31'])
+    expect(report.unmatchedRoutes.map(route => `${route.method} ${route.path}`)).toEqual(['DELETE /api/users/:id'])
+    expect(report.dynamicCalls.map(call => call.line)).toEqual([35])
+  })
+
+  it('should read URLs from templates, concatenation and config objects', () => {
+    const [call] = extractHttpCalls([{
+      id: 'client.js',
+      type: 'file',
+      path: 'client.js',
+      content: 'axios.request({ method: verb, url: `https://api.example.com/v1/items/${id}#top` })',
+    }])
+    expect(call).toMatchObject({ method: 'ANY', path: '/v1/items/{}', client: 'axios' })
+  })
+
+  it('should not report routes when the project makes no calls', () => {
+    expect(buildApiLinks(files.filter(file => file.path.startsWith('server/'))).unmatchedRoutes).toEqual([])
+  })
+})
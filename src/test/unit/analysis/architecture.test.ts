@@ -0,0 +1,92 @@
+/**
+ * Architecture summaries: structure, frameworks, entry points, key types and dependencies
+ */
+
+import { describe, it, expect, afterEach } from 'vitest'
+import { mkdirSync, mkdtempSync, rmSync, writeFileSync } from 'fs'
+import { tmpdir } from 'os'
+import { dirname, join } from 'path'
+import { summarizeArchitecture } from '../../../analysis/architecture.js'
+import { createProject } from '../../../project/manager.js'
+import type { Project, TreeNode } from '../../../types/core.js'
+
+function addFile(project: Project, path: string, content: string, symbols: [string, string, number][] = []): void {
+  const file = join(project.config.directory, path)
+  mkdirSync(dirname(file), { recursive: true })
+  writeFileSync(file, content)
+
+  const children: TreeNode[] = symbols.map(([name, kind, line]) => ({
+    id: `${file}:${name}`,
+    type: kind === 'method' ? 'function' : kind,
+    name,
+    path: file,
+    startLine: line,
+    endLine: line,
+    symbol: { kind: kind as 'class', visibility: 'public', signature: name },
+  }))
+  project.files.set(file, { id: file, type: 'file', path: file, content, children })
+  project.nodes.set(file, children)
+}
+
+describe('Architecture summary', () => {
+  let root: string | undefined
+
+  afterEach(() => {
+    if (root) rmSync(root, { recursive: true, force: true })
+    root = undefined
+  })
+
+  it('should map directories, frameworks, entry points and key types', () => {
+    root = mkdtempSync(join(tmpdir(), 'architecture-'))
+    writeFileSync(join(root, 'package.json'), JSON.stringify({ name: 'shop', bin: { shop: 'bin/shop.js' }, dependencies: { express: '^4.0.0' } }))
+    const project = createProject({ directory: root })
+
+    addFile(project, 'src/models/order.ts', 'export interface Order { id: string }\nexport class Invoice {}\n', [['Order', 'interface', 1], ['Invoice', 'class', 2]])
+    addFile(project, 'src/api/orders.ts', `import { Order } from '../models/order'
+import express from 'express'
+
+const router = express.Router()
+router.get('/orders/:id', getOrder)
+router.post('/orders', createOrder)
+
+export function getOrder(req): Order {}
+export function createOrder(req): Order {}
+`, [['getOrder', 'function', 8], ['createOrder', 'function', 9]])
+    addFile(project, 'src/api/orders.test.ts', 'import { Invoice } from \'../models/order\'\nnew Invoice()\n')
+    addFile(project, 'bin/shop.js', '#!/usr/bin/env node\nrequire(\'../src/api/orders\')\n')
+    addFile(project, 'tools/seed.py', 'def main():\n    pass\n\nif __name__ == "__main__":\n    main()\n', [['main', 'function', 1]])
+
+    const summary = summarizeArchitecture(project)
+
+    expect(summary.files).toBe(5)
+    expect(summary.directories.map(dir => [dir.path, dir.files, dir.symbols, dir.frameworks])).toEqual([
+      ['bin', 1, 0, []],
+      ['src/api', 2, 2, ['Express']],
+      ['src/models', 1, 2, []],
+      ['tools', 1, 1, []],
+    ])
+    expect(summary.frameworks).toEqual([
+      { id: 'express', name: 'Express', directory: '.', source: 'package.json' },
+      { id: 'express', name: 'Express', directory: 'src/api', source: 'routes' },
+    ])
+    expect(summary.entryPoints.map(entry => [entry.kind, entry.path.slice(root!.length + 1), entry.line, entry.routes])).toEqual([
+      ['bin', 'bin/shop.js', undefined, undefined],
+      ['script', 'bin/shop.js', 1, undefined],
+      ['routes', 'src/api/orders.ts', 5, 2],
+      ['main', 'tools/seed.py', 4, undefined],
+    ])
+    // The test's use of Invoice doesn't make it key
+    expect(summary.keyTypes.map(type => [type.id, type.references])).toEqual([['src/models/order.ts#Order', 3]])
+    expect(summary.dependencies).toEqual([{ from: 'src/api', to: 'src/models', imports: 2 }])
+  })
+
+  it('should group by fewer levels on request', () => {
+    root = mkdtempSync(join(tmpdir(), 'architecture-'))
+    const project = createProject({ directory: root })
+    addFile(project, 'src/a/one.ts', 'export const one = 1\n')
+    addFile(project, 'src/b/two.ts', 'export const two = 2\n')
+    addFile(project, 'index.ts', 'export {}\n')
+
+    expect(summarizeArchitecture(project, { depth: 1 }).directories.map(dir => [dir.path, dir.files])).toEqual([['.', 1], ['src', 2]])
+  })
+})
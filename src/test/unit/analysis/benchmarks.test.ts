@@ -0,0 +1,125 @@
+/**
+ * Benchmark discovery, runner output parsing and the comparison of two refs' measurements
+ */
+
+import { describe, it, expect } from 'vitest'
+import { compareMeasurements, parseBenchmarkOutput, readBenchmarks } from '../../../analysis/benchmarks.js'
+
+const GO_BENCH = `package parser
+
+// BenchmarkParse in a comment: func BenchmarkNope(b *testing.B) {}
+func BenchmarkParse(b *testing.B) {
+	for _, size := range sizes {
+		b.Run("small", func(b *testing.B) {})
+		b.Run("large", func(b *testing.B) {})
+	}
+}
+
+func Benchmarked(b *testing.B) {}
+
+func BenchmarkTokenize(bb *testing.B) {
+	for i := 0; i < bb.N; i++ {
+		tokenize(input)
+	}
+}
+`
+
+const VITEST_BENCH = `import { bench, describe } from 'vitest'
+
+describe('sort', () => {
+  bench('native', () => {
+    [...data].sort()
+  })
+  bench('quick', () => quickSort(data))
+})
+
+bench('standalone', () => {})
+`
+
+const BENCHMARK_JS = `const Benchmark = require('benchmark')
+
+new Benchmark.Suite()
+  .add('RegExp#test', () => /o/.test('Hello World!'))
+  .add('String#indexOf', () => 'Hello World!'.indexOf('o') > -1)
+  .run()
+`
+
+describe('benchmarks', () => {
+  it('should read Go benchmarks with their sub-benchmarks and a command running each', () => {
+    expect(readBenchmarks('svc/parser/parse_test.go', GO_BENCH, 'svc')).toEqual([
+      {
+        name: 'BenchmarkParse',
+        file: 'svc/parser/parse_test.go',
+        line: 4,
+        framework: 'go',
+        cases: ['small', 'large'],
+        command: 'cd svc && go test ./parser -run \'^$\' -bench \'^BenchmarkParse$\' -benchmem',
+      },
+      {
+        name: 'BenchmarkTokenize',
+        file: 'svc/parser/parse_test.go',
+        line: 13,
+        framework: 'go',
+        command: 'cd svc && go test ./parser -run \'^$\' -bench \'^BenchmarkTokenize$\' -benchmem',
+      },
+    ])
+  })
+
+  it('should read Vitest and Benchmark.js suites', () => {
+    expect(readBenchmarks('src/sort.bench.ts', VITEST_BENCH).map(benchmark => [benchmark.name, benchmark.group, benchmark.line])).toEqual([
+      ['native', 'sort', 4],
+      ['quick', 'sort', 7],
+      ['standalone', undefined, 10],
+    ])
+    expect(readBenchmarks('src/sort.bench.ts', VITEST_BENCH)[0]!.command).toBe('npx vitest bench --run src/sort.bench.ts')
+
+    const suite = readBenchmarks('bench/strings.js', BENCHMARK_JS)
+    expect(suite.map(benchmark => [benchmark.name, benchmark.framework, benchmark.command])).toEqual([
+      ['RegExp#test', 'benchmark.js', 'node bench/strings.js'],
+      ['String#indexOf', 'benchmark.js', 'node bench/strings.js'],
+    ])
+    expect(readBenchmarks('bench/plain.js', 'console.log(1)')).toEqual([])
+  })
+
+  it('should take the median of repeated Go runs and convert JavaScript rates to time per operation', () => {
+    const go = parseBenchmarkOutput('go', `goos: linux
+BenchmarkParse/small-8         	  100000	      1200 ns/op	     512 B/op	       8 allocs/op
+BenchmarkParse/small-8         	  100000	      1000 ns/op	     512 B/op	       8 allocs/op
+BenchmarkParse/small-8         	  100000	      1100 ns/op	     480 B/op	       7 allocs/op
+BenchmarkTokenize-8            	 5000000	       250.5 ns/op
+PASS
+`)
+    expect(Object.fromEntries(go)).toEqual({
+      'BenchmarkParse/small': { nsPerOp: 1100, bytesPerOp: 512, allocsPerOp: 8, runs: 3 },
+      'BenchmarkTokenize': { nsPerOp: 250.5, runs: 1 },
+    })
+
+    const js = parseBenchmarkOutput('benchmark.js', 'RegExp#test x 4,000,000 ops/sec ±1.10% (90 runs sampled)\n')
+    expect(js.get('RegExp#test')).toEqual({ nsPerOp: 250, runs: 1 })
+
+    const vitest = parseBenchmarkOutput('vitest', JSON.stringify({
+      files: [{ groups: [{ fullName: 'src/sort.bench.ts > sort', benchmarks: [{ name: 'native', mean: 0.002 }] }] }],
+    }))
+    expect(vitest.get('sort > native')).toEqual({ nsPerOp: 2000, runs: 1 })
+  })
+
+  it('should classify deltas against the threshold and report benchmarks only one ref has', () => {
+    const benchmarks = readBenchmarks('svc/parser/parse_test.go', GO_BENCH, 'svc')
+    const base = new Map([
+      ['BenchmarkParse/small', { nsPerOp: 1000, allocsPerOp: 8, runs: 5 }],
+      ['BenchmarkParse/large', { nsPerOp: 5000, runs: 5 }],
+      ['BenchmarkTokenize', { nsPerOp: 200, runs: 5 }],
+    ])
+    const head = new Map([
+      ['BenchmarkParse/small', { nsPerOp: 800, allocsPerOp: 4, runs: 5 }],
+      ['BenchmarkParse/large', { nsPerOp: 5100, runs: 5 }],
+      ['BenchmarkLex', { nsPerOp: 50, runs: 5 }],
+    ])
+    expect(compareMeasurements(base, head, benchmarks, 5).map(delta => [delta.name, delta.verdict, delta.deltaPercent, delta.allocsDeltaPercent, delta.file])).toEqual([
+      ['BenchmarkLex', 'added', undefined, undefined, undefined],
+      ['BenchmarkParse/large', 'unchanged', 2, undefined, 'svc/parser/parse_test.go'],
+      ['BenchmarkParse/small', 'faster', -20, -50, 'svc/parser/parse_test.go'],
+      ['BenchmarkTokenize', 'removed', undefined, undefined, 'svc/parser/parse_test.go'],
+    ])
+  })
+})
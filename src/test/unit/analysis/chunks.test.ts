@@ -0,0 +1,99 @@
+/**
+ * Symbol-aligned chunk export
+ */
+
+import { describe, it, expect } from 'vitest'
+import { chunkFile, formatChunksAsJsonl } from '../../../analysis/chunks.js'
+import type { TreeNode } from '../../../types/core.js'
+
+const CONFIG_SOURCE = `import { readFile } from 'fs/promises'
+import path, { join as joinPath } from 'path'
+import type { Config } from './types'
+
+const DEFAULT_NAME = 'app'
+
+/**
+ * Loads the config file
+ * from disk
+ */
+export async function loadConfig(dir: string): Promise<Config> {
+  const raw = await readFile(joinPath(dir, 'config.json'), 'utf-8')
+  return JSON.parse(raw)
+}
+
+// Caches loaded configs
+export class ConfigCache {
+  private entries = new Map<string, Config>()
+
+  get(dir: string): Config | undefined {
+    return this.entries.get(dir)
+  }
+
+  set(dir: string, config: Config): void {
+    this.entries.set(path.resolve(dir), config)
+  }
+}
+`
+
+describe('Chunk export', () => {
+  const file: TreeNode = {
+    id: 'file-1',
+    type: 'file',
+    path: 'src/config.ts',
+    content: CONFIG_SOURCE,
+    children: [
+      { id: 'fn-1', type: 'function', name: 'loadConfig', path: 'src/config.ts', startLine: 11, endLine: 14 },
+      { id: 'class-1', type: 'class', name: 'ConfigCache', path: 'src/config.ts', startLine: 17, endLine: 27 },
+      { id: 'fn-2', type: 'function', name: 'get', path: 'src/config.ts', startLine: 20, endLine: 22 },
+      { id: 'fn-3', type: 'function', name: 'set', path: 'src/config.ts', startLine: 24, endLine: 26 },
+    ],
+  }
+
+  it('should emit one chunk per top-level symbol with its doc comment', () => {
+    const chunks = chunkFile(file)
+    expect(chunks.map(chunk => [chunk.kind, chunk.symbol, chunk.startLine, chunk.endLine])).toEqual([
+      ['module', undefined, 1, 5],
+      ['function', 'loadConfig', 7, 14],
+      ['class', 'ConfigCache', 16, 27],
+    ])
+    expect(chunks[1]).toMatchObject({ language: 'typescript', docComment: 'Loads the config file\nfrom disk' })
+    expect(chunks[1]!.content.startsWith('/**')).toBe(true)
+    expect(chunks[2]!.docComment).toBe('Caches loaded configs')
+  })
+
+  it('should list only the imports a chunk uses', () => {
+    const [, loadConfig, cache] = chunkFile(file)
+    expect(loadConfig!.imports).toEqual(['fs/promises', 'path', './types'])
+    expect(cache!.imports).toEqual(['path', './types'])
+  })
+
+  it('should split oversized classes into members and functions into parts', () => {
+    const chunks = chunkFile(file, { maxTokens: 40, includeModuleCode: false })
+    expect(chunks.map(chunk => `${chunk.kind}:${chunk.symbol}:${chunk.startLine}-${chunk.endLine}${chunk.part ? `#${chunk.part}/${chunk.parts}` : ''}`)).toEqual([
+      'function:loadConfig:7-11#1/2',
+      'function:loadConfig:12-14#2/2',
+      'class:ConfigCache:16-18',
+      'method:get:20-22',
+      'method:set:24-26',
+    ])
+    expect(chunks[3]!.parent).toBe('ConfigCache')
+    expect(chunks.every(chunk => chunk.tokens <= 40)).toBe(true)
+  })
+
+  it('should read Python docstrings and imports', () => {
+    const [, read] = chunkFile({
+      id: 'file-2',
+      type: 'file',
+      path: 'util.py',
+      content: 'import os\nfrom typing import Optional\n\n\ndef read(path: str) -> Optional[str]:\n    """Read a file if it exists."""\n    return open(path).read() if os.path.exists(path) else None\n',
+      children: [{ id: 'fn-4', type: 'function', name: 'read', path: 'util.py', startLine: 5, endLine: 7 }],
+    })
+    expect(read).toMatchObject({ symbol: 'read', docComment: 'Read a file if it exists.', imports: ['typing', 'os'] })
+  })
+
+  it('should write one JSON object per line', () => {
+    const lines = formatChunksAsJsonl(chunkFile(file)).trimEnd().split('\n')
+    expect(lines).toHaveLength(3)
+    expect(JSON.parse(lines[1]!).id).toBe('src/config.ts:7-14')
+  })
+})
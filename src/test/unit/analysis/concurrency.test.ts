@@ -0,0 +1,117 @@
+/**
+ * Go concurrency analysis
+ */
+
+import { describe, it, expect, beforeAll, afterAll } from 'vitest'
+import { mkdtempSync, rmSync, writeFileSync } from 'fs'
+import { tmpdir } from 'os'
+import { join } from 'path'
+import { analyzeConcurrency } from '../../../analysis/concurrency.js'
+import type { TreeNode } from '../../../types/core.js'
+
+const WORKER = `package worker
+
+import (
+	"sync"
+	"time"
+)
+
+type Pool struct {
+	mu      sync.Mutex
+	cache   map[string]int
+	results chan int
+	events  chan string
+}
+
+func (p *Pool) Start(jobs []string) {
+	for _, job := range jobs {
+		go func() {
+			p.process(job)
+		}()
+	}
+	for i, job := range jobs {
+		job := job
+		go func(n int) {
+			p.results <- n
+			p.process(job)
+		}(i)
+	}
+	time.Sleep(time.Second)
+}
+
+func (p *Pool) Get(key string) (int, bool) {
+	p.mu.Lock()
+	value, ok := p.cache[key]
+	if !ok {
+		return 0, false
+	}
+	p.mu.Unlock()
+	return value, true
+}
+
+func (p *Pool) Set(key string, value int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cache[key] = value
+	p.events <- key // "events <- x" in a comment is not a send
+}
+
+func (p *Pool) Drain() {
+	p.mu.Lock()
+	p.cache = nil
+}
+
+func (p *Pool) Collect() []int {
+	var out []int
+	for n := range p.results {
+		out = append(out, n)
+	}
+	return out
+}
+
+func (p *Pool) process(job string) {}
+`
+
+describe('Go concurrency analysis', () => {
+  let root: string
+
+  beforeAll(() => {
+    root = mkdtempSync(join(tmpdir(), 'ts-mcp-concurrency-'))
+  })
+
+  afterAll(() => {
+    rmSync(root, { recursive: true, force: true })
+  })
+
+  const analyze = (goMod?: string) => {
+    const directory = mkdtempSync(join(root, 'module-'))
+    if (goMod) writeFileSync(join(directory, 'go.mod'), goMod)
+    const file: TreeNode = { id: 'pool.go', type: 'file', path: join(directory, 'pool.go'), content: WORKER }
+    return analyzeConcurrency([file])
+  }
+
+  it('should report each smell at its line', () => {
+    const { findings, metrics } = analyze('module example.com/worker\n\ngo 1.21\n')
+    expect(findings.map(finding => [finding.category, Number(finding.location.split(':').pop())])).toEqual([
+      ['loop_variable_capture', 17],
+      ['sleep_synchronization', 28],
+      ['unreleased_lock', 32],
+      ['unreleased_lock', 49],
+      ['unread_channel', 45],
+    ])
+    expect(findings[0]!.description).toBe('Start: goroutine captures loop variable job, shared by all iterations before Go 1.22; pass it as an argument or copy it')
+    expect(findings[2]!.description).toContain('returns while holding p.mu')
+    expect(findings[4]!.description).toContain('Channel events')
+    expect(metrics).toEqual({
+      analyzedFiles: 1,
+      analyzedFunctions: 6,
+      issuesByCategory: { loop_variable_capture: 1, sleep_synchronization: 1, unreleased_lock: 2, unread_channel: 1 },
+    })
+  })
+
+  it('should skip loop variable capture from Go 1.22 on', () => {
+    const { findings } = analyze('module example.com/worker\n\ngo 1.22.3\n')
+    expect(findings.map(finding => finding.category)).not.toContain('loop_variable_capture')
+    expect(findings).toHaveLength(4)
+  })
+})
@@ -0,0 +1,137 @@
+/**
+ * Constant and enum value resolution
+ */
+
+import { describe, it, expect } from 'vitest'
+import { evaluateConstant, extractConstants, findConstantValues, withConstantUsages } from '../../../analysis/constants.js'
+import type { TreeNode } from '../../../types/core.js'
+
+const valuesOf = (content: string, file: string) =>
+  extractConstants(content, file).map(constant => [constant.group ? `${constant.group}.${constant.name}` : constant.name, constant.value])
+
+describe('Constant values', () => {
+  it('should follow Go iota sequences and repeated expressions', () => {
+    const content = `package orders
+
+type Status int
+
+const (
+	Pending Status = iota // first
+	Paid
+	_
+	Shipped
+)
+
+const (
+	KB = 1 << (10 * (iota + 1))
+	MB
+)
+
+const Retries = 3
+`
+
+    expect(valuesOf(content, '/p/orders/status.go')).toEqual([
+      ['Status.Pending', 0],
+      ['Status.Paid', 1],
+      ['Status.Shipped', 3],
+      ['KB', 1024],
+      ['MB', 1048576],
+      ['Retries', 3],
+    ])
+  })
+
+  it('should auto-increment TS enums and read as-const objects', () => {
+    const content = `export enum Level {
+  Debug = 1,
+  Info,
+  // Warn is reserved
+  Error = Info << 2,
+}
+
+export const enum Color { Red = 'red', Blue = 'blue' }
+
+export const Codes = {
+  NotFound: 404,
+  Gone: 410,
+} as const
+
+export const TIMEOUT_MS = 30 * 1000
+const endpoint = process.env.API_URL
+`
+
+    expect(valuesOf(content, '/p/src/levels.ts')).toEqual([
+      ['Level.Debug', 1],
+      ['Level.Info', 2],
+      ['Level.Error', 8],
+      ['Color.Red', 'red'],
+      ['Color.Blue', 'blue'],
+      ['Codes.NotFound', 404],
+      ['Codes.Gone', 410],
+      ['TIMEOUT_MS', 30000],
+    ])
+    expect(extractConstants(content, '/p/src/levels.ts')[2]).toMatchObject({ line: 5, expression: 'Info << 2' })
+  })
+
+  it('should resolve Python auto() for enums and flags', () => {
+    const content = `from enum import Enum, Flag, auto
+
+class State(Enum):
+    """Order states"""
+    OPEN = auto()
+    CLOSED = auto()
+
+    def label(self):
+        return self.name
+
+class Perm(Flag):
+    READ = auto()
+    WRITE = auto()
+    EXEC = auto()
+
+MAX_SIZE = 2 ** 10
+`
+
+    expect(valuesOf(content, '/p/perms.py')).toEqual([
+      ['State.OPEN', 1],
+      ['State.CLOSED', 2],
+      ['Perm.READ', 1],
+      ['Perm.WRITE', 2],
+      ['Perm.EXEC', 4],
+      ['MAX_SIZE', 1024],
+    ])
+  })
+
+  it('should read Rust discriminants and Java enum arguments', () => {
+    expect(valuesOf('pub enum Code {\n    Ok = 0,\n    #[deprecated]\n    Retry,\n    Custom(u16),\n    Other,\n}\n\npub const LIMIT: u32 = 0xFF;\n', '/p/src/code.rs')).toEqual([
+      ['Code.Ok', 0],
+      ['Code.Retry', 1],
+      ['Code.Custom', undefined],
+      ['Code.Other', undefined],
+      ['LIMIT', 255],
+    ])
+    expect(valuesOf('public enum Status {\n  ACTIVE(3), @Deprecated INACTIVE(4);\n  Status(int code) {}\n}\n', '/p/Status.java')).toEqual([
+      ['Status.ACTIVE', 3],
+      ['Status.INACTIVE', 4],
+    ])
+  })
+
+  it('should filter by value and find qualified usages', () => {
+    const files: TreeNode[] = [
+      ['/p/src/status.ts', 'export enum Status { Draft, Live, Archived }\n'],
+      ['/p/src/view.ts', `import { Status } from './status'\n\nif (item.status === Status.Archived) hide()\n// Status.Archived in a comment\n`],
+      ['/p/app.py', 'Archived = 2\n'],
+    ].map(([path, content]) => ({ id: path!, type: 'file', path: path!, content }))
+
+    const [archived, ...rest] = findConstantValues(files, { name: 'status', value: '0x2' })
+    expect(rest).toEqual([])
+    expect(archived).toMatchObject({ name: 'Archived', group: 'Status', value: 2 })
+    expect(withConstantUsages([archived!], files)[0]?.usages).toEqual([{ file: '/p/src/view.ts', line: 3 }])
+  })
+
+  it('should not evaluate expressions with unknown names', () => {
+    expect(evaluateConstant('Base + 1')).toBeUndefined()
+    expect(evaluateConstant('Base + 1', new Map([['Base', 4]]))).toBe(5)
+    expect(evaluateConstant('7 / 2', new Map(), true)).toBe(3)
+    expect(evaluateConstant(`'v' + 2`)).toBe('v2')
+  })
+})
@@ -0,0 +1,89 @@
+/**
+ * Token-budgeted context packs around a symbol
+ */
+
+import { describe, it, expect } from 'vitest'
+import { buildContextPack } from '../../../analysis/context-pack.js'
+import { createProject } from '../../../project/manager.js'
+import type { Project, TreeNode } from '../../../types/core.js'
+
+function addFile(project: Project, path: string, content: string, symbols: [string, string, number, number][]): void {
+  const lines = content.split('\n')
+  const children: TreeNode[] = symbols.map(([name, type, startLine, endLine]) => ({
+    id: `${path}:${name}`,
+    type,
+    name,
+    path,
+    startLine,
+    endLine,
+    content: lines.slice(startLine - 1, endLine).join('\n'),
+  }))
+  project.files.set(path, { id: path, type: 'file', path, content, children })
+  project.nodes.set(path, children)
+}
+
+function createOrdersProject(): Project {
+  const project = createProject({ directory: '/p' })
+  addFile(project, '/p/src/orders.ts', `import { sendMail, formatDate } from './mail'
+import { db } from './db'
+
+export interface Order {
+  id: string
+  total: number
+}
+
+export function shipOrder(order: Order) {
+  const saved = db.save(order)
+  notify(order)
+  return saved
+}
+
+function notify(order: Order) {
+  sendMail(order.id)
+}
+`, [['Order', 'interface', 4, 7], ['shipOrder', 'function', 9, 13], ['notify', 'function', 15, 17]])
+  addFile(project, '/p/src/checkout.ts', `import { shipOrder } from './orders'
+
+export function checkout(cart) {
+  return shipOrder(cart.order)
+}
+`, [['checkout', 'function', 3, 5]])
+  addFile(project, '/p/src/mail.ts', `export function sendMail(to) {}
+`, [['sendMail', 'function', 1, 1]])
+  return project
+}
+
+describe('Context packs', () => {
+  it('should bundle the definition with its callees, types, callers and used imports', () => {
+    const pack = buildContextPack(createOrdersProject(), 'shipOrder')
+
+    expect(pack.target).toMatchObject({ id: 'src/orders.ts#shipOrder', startLine: 9 })
+    expect(pack.target.content).toContain('notify(order)')
+    expect(pack.callees.map(entry => entry.id)).toEqual(['src/orders.ts#notify'])
+    expect(pack.types.map(entry => entry.id)).toEqual(['src/orders.ts#Order'])
+    expect(pack.callers.map(entry => [entry.id, entry.content])).toEqual([['src/checkout.ts#checkout', 'export function checkout(cart) {\n  return shipOrder(cart.order)\n}']])
+    expect(pack.imports).toEqual([{ module: './db', names: ['db'] }])
+    expect(pack.omitted).toBe(0)
+  })
+
+  it('should fall back to signatures and truncate the definition to fit the budget', () => {
+    const project = createOrdersProject()
+    const pack = buildContextPack(project, 'src/orders.ts#shipOrder', { maxTokens: 100 })
+    expect(pack.tokens).toBeLessThanOrEqual(100)
+
+    const body = Array.from({ length: 80 }, (_, i) => `  step${i}(order)`).join('\n')
+    addFile(project, '/p/src/long.ts', `export function process(order) {\n${body}\n}\n`, [['process', 'function', 1, 82]])
+    const long = buildContextPack(project, 'process', { maxTokens: 100 })
+    expect(long.target.truncated).toBe(true)
+    expect(long.tokens).toBeLessThanOrEqual(100)
+  })
+
+  it('should ask for an id when a name is ambiguous', () => {
+    const project = createOrdersProject()
+    addFile(project, '/p/lib/notify.ts', 'export function notify(user) {}\n', [['notify', 'function', 1, 1]])
+
+    expect(() => buildContextPack(project, 'notify')).toThrow('pass one of these ids: lib/notify.ts#notify, src/orders.ts#notify')
+    expect(() => buildContextPack(project, 'missing')).toThrow('Unknown symbol: missing')
+    expect(buildContextPack(project, 'src/orders.ts#notify').callers.map(entry => entry.name)).toEqual(['shipOrder'])
+  })
+})
@@ -0,0 +1,68 @@
+/**
+ * Documentation sections mentioning a symbol
+ */
+
+import { describe, it, expect } from 'vitest'
+import { findDocsFor } from '../../../analysis/docs.js'
+import { parseContent } from '../../../core/parser.js'
+import { createProject, extractAllNodes } from '../../../project/manager.js'
+import type { Project } from '../../../types/core.js'
+
+function addFile(project: Project, path: string, content: string): void {
+  const fileNode = parseContent(content, path)
+  project.files.set(path, fileNode)
+  project.nodes.set(path, extractAllNodes(fileNode))
+}
+
+describe('findDocsFor', () => {
+  it('should rank sections naming the symbol in headings and code above prose', () => {
+    const project = createProject({ directory: '/p' })
+    addFile(project, '/p/src/parser.zig', `pub fn parseFile(path: []const u8) !Tree {
+    return Tree.init(path);
+}
+`)
+    addFile(project, '/p/docs/guide.md', `# Guide
+
+Everything starts when parseFile runs.
+
+## API
+
+### parseFile
+
+Reads one file.
+
+\`\`\`zig
+const tree = try parseFile("src/main.zig");
+\`\`\`
+
+## Internals
+
+The walker calls \`parseFile\` for each path, unlike parseFiles.
+`)
+
+    const { definitions, sections } = findDocsFor(project, 'parseFile')
+
+    expect(definitions).toHaveLength(1)
+    expect(definitions[0]).toMatchObject({ path: '/p/src/parser.zig', line: 1, kind: 'function' })
+    expect(sections.map(section => section.heading)).toEqual(['parseFile', 'Internals', 'Guide'])
+    expect(sections[0]).toMatchObject({ headings: ['Guide', 'API', 'parseFile'], line: 7, endLine: 13 })
+    expect(sections[0]!.mentions.map(mention => [mention.line, mention.kind])).toEqual([[7, 'heading'], [12, 'example']])
+    expect(sections[1]!.mentions.map(mention => mention.kind)).toEqual(['code'])
+    expect(sections[2]!.mentions.map(mention => mention.kind)).toEqual(['text'])
+  })
+
+  it('should mark sections whose examples declare the symbol', () => {
+    const project = createProject({ directory: '/p' })
+    addFile(project, '/p/README.md', `# Plugins
+
+\`\`\`zig
+pub fn registerPlugin(name: []const u8) void {}
+\`\`\`
+`)
+
+    const { definitions, sections } = findDocsFor(project, 'registerPlugin')
+    expect(definitions).toEqual([])
+    expect(sections).toHaveLength(1)
+    expect(sections[0]).toMatchObject({ heading: 'Plugins', defines: true })
+  })
+})
@@ -0,0 +1,118 @@
+/**
+ * Entry point detection from source and deployment configuration
+ */
+
+import { describe, it, expect, afterEach } from 'vitest'
+import { mkdirSync, mkdtempSync, rmSync, writeFileSync } from 'fs'
+import { tmpdir } from 'os'
+import { dirname, join } from 'path'
+import { findSourceEntryPoints, listEntryPoints } from '../../../analysis/entry-points.js'
+import { createProject } from '../../../project/manager.js'
+import type { TreeNode } from '../../../types/core.js'
+
+const file = (path: string, content: string): TreeNode => ({ id: path, type: 'file', path, content })
+const summary = (entries: ReturnType<typeof findSourceEntryPoints>) =>
+  entries.map(entry => [entry.kind, entry.line, entry.name ?? entry.schedule, entry.framework])
+
+describe('Entry points', () => {
+  let root: string | undefined
+
+  afterEach(() => {
+    if (root) rmSync(root, { recursive: true, force: true })
+    root = undefined
+  })
+
+  it('should find main packages and CLI command registrations', () => {
+    const main = file('/p/cmd/app/main.go', `package main
+
+var serveCmd = &cobra.Command{
+	Use:   "serve [flags]",
+	Short: "Start the server",
+}
+
+func main() {
+	c := cron.New()
+	c.AddFunc("0 3 * * *", cleanup)
+	lambda.Start(handle)
+}
+`)
+    const library = file('/p/pkg/util/main.go', 'package util\n\nfunc main() {}\n')
+    const cli = file('/p/tools/cli.py', `import click
+
+@click.group()
+def cli():
+    pass
+
+@cli.command(name="sync")
+@click.option("--dry-run")
+def sync_all(dry_run):
+    pass
+
+if __name__ == '__main__':
+    cli()
+`)
+
+    expect(summary(findSourceEntryPoints([main, library, cli]))).toEqual([
+      ['command', 3, 'serve', 'cobra'],
+      ['main', 8, 'main', undefined],
+      ['cron', 10, '0 3 * * *', 'robfig/cron'],
+      ['serverless', 11, 'handle', 'aws-lambda'],
+      ['command', 3, 'cli', 'click'],
+      ['command', 7, 'sync_all', 'click'],
+      ['main', 12, '__main__', undefined],
+    ])
+  })
+
+  it('should find handlers and schedules in JavaScript and skip tests', () => {
+    const handler = file('/p/src/jobs.ts', `import cron from 'node-cron'
+import { Command } from 'commander'
+
+export const handler = async (event) => ({ statusCode: 200 })
+cron.schedule('*/5 * * * *', poll)
+new Command().command('import <file>')
+`)
+
+    expect(summary(findSourceEntryPoints([handler, file('/p/src/jobs.test.ts', handler.content!)]))).toEqual([
+      ['serverless', 4, 'handler', 'aws-lambda'],
+      ['cron', 5, '*/5 * * * *', 'node-cron'],
+      ['command', 6, 'import', 'commander'],
+    ])
+  })
+
+  it('should read npm, serverless, workflow, crontab and CronJob configuration', () => {
+    root = mkdtempSync(join(tmpdir(), 'entry-points-'))
+    const write = (path: string, content: string) => {
+      mkdirSync(dirname(join(root!, path)), { recursive: true })
+      writeFileSync(join(root!, path), content)
+    }
+    write('package.json', JSON.stringify({ name: 'shop', bin: 'bin/shop.js', scripts: { start: 'node server.js' } }, null, 2))
+    write('serverless.yml', `service: reports
+
+functions:
+  nightly:
+    handler: src/report.run
+    events:
+      - schedule: rate(1 day)
+  api:
+    handler: src/api.handler
+`)
+    write('.github/workflows/cleanup.yml', 'name: Cleanup\non:\n  schedule:\n    - cron: \'0 0 * * 0\'\n')
+    write('deploy/crontab', '# m h dom mon dow command\nMAILTO=ops@example.com\n15 2 * * * /usr/bin/backup --all\n')
+    write('k8s/jobs.yaml', 'apiVersion: v1\nkind: ConfigMap\n---\napiVersion: batch/v1\nkind: CronJob\nmetadata:\n  name: digest\nspec:\n  schedule: "0 8 * * 1"\n')
+
+    const project = createProject({ directory: root })
+    const entries = listEntryPoints(project).map(entry => [entry.kind, entry.path.slice(root!.length + 1), entry.line, entry.name, entry.schedule ?? entry.command])
+
+    expect(entries).toEqual([
+      ['cron', '.github/workflows/cleanup.yml', 4, 'Cleanup', '0 0 * * 0'],
+      ['bin', 'bin/shop.js', undefined, 'shop', 'bin/shop.js'],
+      ['cron', 'deploy/crontab', 3, undefined, '15 2 * * *'],
+      ['cron', 'k8s/jobs.yaml', 9, 'digest', '0 8 * * 1'],
+      ['npm-script', 'package.json', 5, 'start', 'node server.js'],
+      ['serverless', 'serverless.yml', 5, 'nightly', 'src/report.run'],
+      ['cron', 'serverless.yml', 7, 'nightly', 'rate(1 day)'],
+      ['serverless', 'serverless.yml', 9, 'api', 'src/api.handler'],
+    ])
+    expect(listEntryPoints(project, ['cron']).map(entry => entry.framework)).toEqual(['github-actions', 'crontab', 'kubernetes', 'serverless'])
+  })
+})
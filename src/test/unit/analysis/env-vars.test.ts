@@ -0,0 +1,49 @@
+/**
+ * Environment variable read/definition cross-referencing
+ */
+
+import { describe, it, expect } from 'vitest'
+import { resolve } from 'path'
+import { buildEnvVarMap, mapEnvironmentVariables, parseDotenv } from '../../../analysis/env-vars.js'
+import { createProject, parseProject } from '../../../project/manager.js'
+import type { TreeNode } from '../../../types/core.js'
+
+describe('Environment variable map', () => {
+  const fixture = resolve(import.meta.dirname, '../../fixtures/env-vars')
+
+  it('should read names from dotenv content', () => {
+    expect(parseDotenv('# comment\nexport A=1\nB = 2\n\nC=\n')).toEqual([
+      { name: 'A', line: 2 },
+      { name: 'B', line: 3 },
+      { name: 'C', line: 5 },
+    ])
+  })
+
+  it('should detect reads across languages', () => {
+    const file = (path: string, content: string): TreeNode => ({ id: path, type: 'file', path: resolve(fixture, path), content })
+    const map = buildEnvVarMap([
+      file('main.go', 'port := os.Getenv("GO_PORT")\nos.Setenv("GO_PORT", "1")'),
+      file('App.java', 'String url = System.getenv("JAVA_URL");'),
+      file('app.rb', 'ENV.fetch("RUBY_KEY")'),
+    ], resolve(fixture, 'missing'))
+
+    expect(map.variables.find(variable => variable.name === 'GO_PORT')?.status).toBe('defined')
+    expect(map.undefined).toEqual(['JAVA_URL', 'RUBY_KEY'])
+  })
+
+  it('should flag undefined and unused variables in a project', async () => {
+    const project = createProject({ directory: fixture })
+    await parseProject(project)
+
+    const map = mapEnvironmentVariables(project)
+    const byName = new Map(map.variables.map(variable => [variable.name, variable]))
+
+    expect(map.undefined).toEqual(['SENTRY_DSN'])
+    expect(map.unused).toContain('LEGACY_API_KEY')
+    expect(byName.get('HOME')?.status).toBe('external')
+    expect(byName.get('APP_PORT')?.definitions[0]?.source).toBe('dockerfile')
+    expect(byName.get('QUEUE_NAME')?.definitions[0]?.source).toBe('compose')
+    expect(byName.get('WORKER_COUNT')?.definitions[0]?.source).toBe('shell')
+    expect(byName.get('DATABASE_URL')?.definitions.some(definition => definition.source === 'dotenv')).toBe(true)
+  })
+})
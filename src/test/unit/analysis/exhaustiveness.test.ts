@@ -0,0 +1,122 @@
+/**
+ * Switch and match statements over Go const enums, TypeScript unions and enums, and Rust enums
+ */
+
+import { describe, it, expect } from 'vitest'
+import { analyzeExhaustiveness } from '../../../analysis/exhaustiveness.js'
+import type { TreeNode } from '../../../types/core.js'
+
+const GO_SOURCE = `package orders
+
+type Status int
+
+const (
+	_ Status = iota
+	Pending
+	Paid
+	Shipped // Not yet handled everywhere
+)
+
+func label(s Status) string {
+	switch s {
+	case Pending, Paid:
+		return "open"
+	}
+	return ""
+}
+
+func done(s Status) bool {
+	switch s {
+	case Shipped:
+		return true
+	default:
+		return false
+	}
+}
+`
+
+const TS_SOURCE = `export type Theme =
+  | 'light'
+  | 'dark'
+  | 'system'
+
+export enum Role { Admin, Editor = 'editor', Viewer }
+
+export function apply(theme: Theme, role: Role) {
+  switch (theme) {
+    case 'light':
+      break
+    case 'dark': {
+      switch (role) {
+        case Role.Admin:
+        case Role.Editor:
+        case Role.Viewer:
+          break
+      }
+      break
+    }
+  }
+  switch (role) {
+    case Role.Admin:
+      return 'all'
+  }
+}
+`
+
+const RUST_SOURCE = `pub enum Shape {
+    Circle(f64),
+    Square { side: f64 },
+    Triangle(f64, f64),
+}
+
+fn area(shape: &Shape) -> f64 {
+    match shape {
+        Shape::Circle(r) => r * r,
+        Shape::Square { side } => side * side,
+    }
+}
+
+fn sides(shape: &Shape) -> u32 {
+    match shape {
+        Shape::Circle(_) => 0,
+        _ => 3,
+    }
+}
+`
+
+describe('enum exhaustiveness', () => {
+  const files: TreeNode[] = [['orders/status.go', GO_SOURCE], ['web/theme.ts', TS_SOURCE], ['src/shape.rs', RUST_SOURCE]].map(([path, content]) => ({
+    id: path!,
+    type: 'file',
+    path: path!,
+    content,
+  }))
+  const report = analyzeExhaustiveness(files)
+
+  it('should read enum-like types in every language', () => {
+    expect(report.enums.map(type => [type.name, type.kind, type.members])).toEqual([
+      ['Status', 'const', ['Pending', 'Paid', 'Shipped']],
+      ['Theme', 'union', ['\'light\'', '\'dark\'', '\'system\'']],
+      ['Role', 'enum', ['Admin', 'Editor', 'Viewer']],
+      ['Shape', 'enum', ['Circle', 'Square', 'Triangle']],
+    ])
+  })
+
+  it('should report the variants a switch misses', () => {
+    expect(report.findings.map(finding => [finding.location, finding.description])).toEqual([
+      ['orders/status.go:13', 'Switch over Status doesn\'t handle Shipped'],
+      ['web/theme.ts:9', 'Switch over Theme doesn\'t handle \'system\''],
+      ['web/theme.ts:22', 'Switch over Role doesn\'t handle Editor, Viewer'],
+      ['src/shape.rs:8', 'Match on Shape doesn\'t handle Triangle'],
+    ])
+    expect(report.findings[0]!.metrics).toEqual({ enum: 'Status', missing: ['Shipped'] })
+  })
+
+  it('should accept default arms and complete nested switches', () => {
+    const checks = report.switches.map(check => [check.file, check.line, check.hasDefault, check.missing.length])
+    expect(checks).toContainEqual(['orders/status.go', 21, true, 2])
+    expect(checks).toContainEqual(['web/theme.ts', 13, false, 0])
+    expect(checks).toContainEqual(['src/shape.rs', 15, true, 2])
+    expect(report.findings.some(finding => finding.location === 'web/theme.ts:13')).toBe(false)
+  })
+})
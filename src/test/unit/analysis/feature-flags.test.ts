@@ -0,0 +1,44 @@
+/**
+ * Feature flag key extraction from SDK and configured calls
+ */
+
+import { describe, it, expect } from 'vitest'
+import { resolve } from 'path'
+import { readFileSync } from 'fs'
+import { findFeatureFlags } from '../../../analysis/feature-flags.js'
+import { loadProjectSettings } from '../../../project/settings.js'
+import type { TreeNode } from '../../../types/core.js'
+
+describe('Feature flag tracking', () => {
+  const fixture = resolve(import.meta.dirname, '../../fixtures/feature-flags')
+  const files: TreeNode[] = ['src/checkout.ts', 'src/flags.ts', 'src/search.go'].map(file => ({
+    id: file,
+    type: 'file',
+    path: resolve(fixture, file),
+    content: readFileSync(resolve(fixture, file), 'utf-8'),
+  }))
+
+  it('should find flag keys evaluated through built-in SDKs', () => {
+    const flags = findFeatureFlags(files)
+
+    expect(flags.map(flag => flag.key)).toEqual(['checkout-v2', 'search.ranking-v3'])
+    expect(flags[0]?.files).toBe(2)
+    expect(flags[1]?.references[0]).toMatchObject({ line: 6, source: 'unleash' })
+  })
+
+  it('should include functions configured in project settings', () => {
+    const settings = loadProjectSettings(fixture)
+    const flags = findFeatureFlags(files, settings.featureFlags)
+
+    expect(settings.featureFlags?.functions).toEqual(['isFeatureOn'])
+    expect(flags.find(flag => flag.key === 'express-pay')?.references).toEqual([
+      expect.objectContaining({ line: 6, source: 'custom' }),
+    ])
+  })
+
+  it('should accept regular expressions and limit SDK presets', () => {
+    const flags = findFeatureFlags(files, { patterns: ['enabled\\.has\\((\\w+)\\)'], sdks: ['unleash'] })
+
+    expect(flags.map(flag => flag.key)).toEqual(['key', 'search.ranking-v3'])
+  })
+})
@@ -0,0 +1,47 @@
+/**
+ * Diffing findings between analysis runs for analyze --watch
+ */
+
+import { describe, it, expect } from 'vitest'
+import { diffFindings } from '../../../analysis/index.js'
+import type { Finding } from '../../../types/analysis.js'
+
+function finding(location: string, description: string, severity: Finding['severity'] = 'warning'): Finding {
+  return { type: 'quality', category: 'high_complexity', severity, location, description }
+}
+
+describe('diffFindings', () => {
+  it('should report new and resolved findings', () => {
+    const previous = [finding('/p/a.ts:10', 'load: reduce complexity (12)'), finding('/p/b.ts:3', 'save: reduce complexity (11)')]
+    const current = [finding('/p/a.ts:10', 'load: reduce complexity (12)'), finding('/p/c.ts:7', 'parse: reduce complexity (14)')]
+
+    const diff = diffFindings(previous, current)
+
+    expect(diff.added.map(f => f.location)).toEqual(['/p/c.ts:7'])
+    expect(diff.resolved.map(f => f.location)).toEqual(['/p/b.ts:3'])
+  })
+
+  it('should ignore moved lines and changed measurements', () => {
+    const previous = [finding('/p/a.ts:10', 'load: reduce complexity (12)')]
+    const current = [finding('/p/a.ts:24', 'load: reduce complexity (11)')]
+
+    expect(diffFindings(previous, current)).toEqual({ added: [], resolved: [] })
+  })
+
+  it('should treat a severity change as a new finding', () => {
+    const previous = [finding('/p/a.ts:10', 'load: reduce complexity (12)')]
+    const current = [finding('/p/a.ts:10', 'load: reduce complexity (25)', 'critical')]
+
+    const diff = diffFindings(previous, current)
+
+    expect(diff.added).toHaveLength(1)
+    expect(diff.resolved).toHaveLength(1)
+  })
+
+  it('should count duplicates separately', () => {
+    const previous = [finding('/p/a.ts:1', 'anonymous: reduce complexity (12)')]
+    const current = [finding('/p/a.ts:1', 'anonymous: reduce complexity (12)'), finding('/p/a.ts:40', 'anonymous: reduce complexity (13)')]
+
+    expect(diffFindings(previous, current).added.map(f => f.location)).toEqual(['/p/a.ts:40'])
+  })
+})
@@ -0,0 +1,102 @@
+/**
+ * Dart definitions and Flutter widget mapping
+ */
+
+import { describe, it, expect } from 'vitest'
+import { extractDartDefinitions } from '../../../core/dart.js'
+import { extractFlutterWidgets } from '../../../analysis/flutter.js'
+import type { TreeNode } from '../../../types/core.js'
+
+const APP = `import 'package:flutter/material.dart';
+
+/// The root of the app.
+class App extends StatelessWidget {
+  const App({super.key});
+
+  @override
+  Widget build(BuildContext context) {
+    return MaterialApp(home: CounterPage(title: 'Home'));
+  }
+}
+
+void main() => runApp(const App());
+`
+
+const COUNTER = `import 'package:flutter/material.dart';
+
+class CounterPage extends StatefulWidget {
+  final String title;
+  const CounterPage({super.key, required this.title});
+
+  @override
+  State<CounterPage> createState() => _CounterPageState();
+}
+
+class _CounterPageState extends State<CounterPage> {
+  int _count = 0;
+
+  void _increment() => setState(() => _count++);
+
+  @override
+  Widget build(BuildContext context) {
+    return Scaffold(
+      // CounterPage() in a comment is not a child
+      body: CounterLabel(count: _count),
+      floatingActionButton: FloatingActionButton(onPressed: _increment),
+    );
+  }
+}
+
+class CounterLabel extends StatelessWidget {
+  final int count;
+  const CounterLabel({super.key, required this.count});
+
+  @override
+  Widget build(BuildContext context) => Text('$count');
+}
+`
+
+describe('Dart', () => {
+  it('should index classes, members and functions', () => {
+    const nodes = extractDartDefinitions(APP, '/p/lib/app.dart')
+    expect(nodes.map(node => [node.type, node.name, node.startLine, node.endLine, node.symbol?.container, node.symbol?.visibility])).toEqual([
+      ['class', 'App', 4, 11, undefined, 'public'],
+      ['method', 'App', 5, 5, 'App', 'public'],
+      ['method', 'build', 8, 10, 'App', 'public'],
+      ['function', 'main', 13, 13, undefined, 'public'],
+    ])
+    expect(nodes[0]!.symbol).toMatchObject({ kind: 'class', doc: 'The root of the app.', signature: 'class App extends StatelessWidget' })
+
+    const counter = extractDartDefinitions(COUNTER, '/p/lib/counter.dart')
+    expect(counter.find(node => node.name === '_CounterPageState')!.symbol?.visibility).toBe('private')
+    expect(counter.filter(node => node.symbol?.container === '_CounterPageState').map(node => node.name)).toEqual(['_increment', 'build'])
+  })
+
+  it('should not take a doc block from before a plain comment', () => {
+    const nodes = extractDartDefinitions('/** Formats dates. */\n/* Generated, do not edit. */\nclass Formatter {}\n', '/p/lib/formatter.dart')
+    expect(nodes[0]!.name).toBe('Formatter')
+    expect(nodes[0]!.symbol?.doc).toBeUndefined()
+  })
+})
+
+describe('Flutter widgets', () => {
+  it('should pair widgets with their State and build methods and follow the widgets they create', () => {
+    const files: TreeNode[] = [
+      { id: 'app', type: 'file', path: '/p/lib/app.dart', content: APP },
+      { id: 'counter', type: 'file', path: '/p/lib/counter.dart', content: COUNTER },
+    ]
+    const widgets = extractFlutterWidgets(files)
+
+    expect(widgets.map(widget => [widget.name, widget.kind, widget.line, widget.children, widget.usedBy])).toEqual([
+      ['App', 'stateless', 4, ['CounterPage'], []],
+      ['CounterPage', 'stateful', 3, ['CounterLabel'], ['App']],
+      ['CounterLabel', 'stateless', 26, [], ['CounterPage']],
+    ])
+    expect(widgets[1]).toMatchObject({
+      base: 'StatefulWidget',
+      state: { name: '_CounterPageState', path: '/p/lib/counter.dart', line: 11, endLine: 24 },
+      build: { path: '/p/lib/counter.dart', line: 17, endLine: 23 },
+    })
+    expect(widgets[2]!.build).toEqual({ path: '/p/lib/counter.dart', line: 31, endLine: 31 })
+  })
+})
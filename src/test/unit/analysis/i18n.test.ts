@@ -0,0 +1,56 @@
+/**
+ * Translation key cross-referencing against locale catalogs
+ */
+
+import { describe, it, expect } from 'vitest'
+import { resolve } from 'path'
+import { readFileSync } from 'fs'
+import { buildI18nReport, findTranslationCalls, parseLocaleFile } from '../../../analysis/i18n.js'
+import type { TreeNode } from '../../../types/core.js'
+
+describe('Translation checks', () => {
+  const fixture = resolve(import.meta.dirname, '../../fixtures/i18n')
+  const read = (file: string) => readFileSync(resolve(fixture, file), 'utf-8')
+  const catalogs = ['locales/en/common.json', 'locales/fr/common.json', 'server/locale/fr/LC_MESSAGES/django.po']
+    .flatMap(file => parseLocaleFile(read(file), file))
+  const files: TreeNode[] = ['src/App.tsx', 'server/views.py'].map(file => ({ id: file, type: 'file', path: file, content: read(file) }))
+
+  it('should read locales and namespaces from locale file paths', () => {
+    expect(catalogs.map(catalog => [catalog.locale, catalog.namespace])).toEqual([['en', 'common'], ['fr', 'common'], ['fr', undefined]])
+    expect(catalogs[2]?.entries).toEqual([
+      { key: 'Welcome back', translated: true },
+      { key: 'Log out', translated: false },
+      { key: 'Archived reports', translated: true },
+    ])
+  })
+
+  it('should report undefined and unused keys', () => {
+    const report = buildI18nReport(files, catalogs)
+
+    expect(report.undefined.map(entry => entry.key)).toEqual(['checkout.title'])
+    // errors.* is reached through t(`errors.${code}`)
+    expect(report.unused.map(entry => entry.key)).toEqual(['Archived reports', 'legacy.banner'])
+  })
+
+  it('should report keys missing from a locale of the same catalog', () => {
+    expect(buildI18nReport(files, catalogs).missingTranslations).toEqual({ fr: ['Log out', 'greeting', 'nav.settings'] })
+  })
+
+  it('should list hardcoded strings in markup', () => {
+    expect(buildI18nReport(files, catalogs).hardcodedStrings).toEqual([
+      { text: 'Search products', file: 'src/App.tsx', line: 16, attribute: 'placeholder' },
+      { text: 'Save draft', file: 'src/App.tsx', line: 17 },
+    ])
+  })
+
+  it('should read keys from common translation APIs', () => {
+    const content = [
+      '<FormattedMessage id="cart.empty" />',
+      'intl.formatMessage({ id: \'cart.total\' })',
+      'this.$t(\'menu.open\')',
+      'pgettext("month", "May")',
+    ].join('\n')
+
+    expect(findTranslationCalls(content, 'a.js').map(usage => usage.key)).toEqual(['cart.empty', 'cart.total', 'menu.open', 'May'])
+  })
+})
@@ -0,0 +1,130 @@
+/**
+ * Kubernetes manifests and Helm templates linked to images and code
+ */
+
+import { describe, it, expect } from 'vitest'
+import { parseKubernetesManifests, renderHelmTemplate, kubernetesToNodes } from '../../../core/kubernetes.js'
+import { mapKubernetes } from '../../../analysis/kubernetes.js'
+import { buildEnvVarMap } from '../../../analysis/env-vars.js'
+import { createProject } from '../../../project/manager.js'
+import type { TreeNode } from '../../../types/core.js'
+
+const file = (path: string, content: string): TreeNode => ({ id: path, type: 'file', path, content })
+
+const MANIFEST = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+spec:
+  selector:
+    matchLabels:
+      app: api
+  template:
+    metadata:
+      labels:
+        app: api
+    spec:
+      containers:
+        - name: api
+          image: ghcr.io/acme/api:1.4.0
+          env:
+            - name: DATABASE_URL
+              valueFrom:
+                secretKeyRef:
+                  name: api-db
+                  key: url
+          envFrom:
+            - configMapRef:
+                name: api-config
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: api
+spec:
+  selector:
+    app: api
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: api-config
+data:
+  LOG_LEVEL: info
+`
+
+describe('Kubernetes', () => {
+  it('should extract workloads, services and config keys', () => {
+    const resources = parseKubernetesManifests(MANIFEST)
+
+    expect(resources.map(resource => [resource.kind, resource.name, resource.line, resource.endLine])).toEqual([
+      ['Deployment', 'api', 1, 26],
+      ['Service', 'api', 27, 34],
+      ['ConfigMap', 'api-config', 35, 41],
+    ])
+    expect(resources[0]!.containers).toEqual([{
+      name: 'api',
+      image: 'ghcr.io/acme/api:1.4.0',
+      line: 16,
+      env: [{ name: 'DATABASE_URL', line: 18, from: { kind: 'Secret', name: 'api-db', key: 'url' } }],
+      envFrom: [{ kind: 'ConfigMap', name: 'api-config' }],
+    }])
+    expect(resources[2]!.keys).toEqual([{ name: 'LOG_LEVEL', line: 40 }])
+    expect(kubernetesToNodes(MANIFEST, '/p/k8s/api.yaml').map(node => [node.type, node.name])).toEqual([
+      ['deployment', 'api'],
+      ['image', 'ghcr.io/acme/api:1.4.0'],
+      ['service', 'api'],
+      ['configmap', 'api-config'],
+    ])
+  })
+
+  it('should render Helm templates with chart values', () => {
+    const template = `{{- if .Values.enabled }}
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ include "web.fullname" . }}
+spec:
+  template:
+    spec:
+      containers:
+        - name: web
+          image: "{{ .Values.image.repository }}:{{ .Values.image.tag }}"
+{{- end }}
+`
+    const rendered = renderHelmTemplate(template, { image: { repository: 'acme/web', tag: '2.0' } }, 'web')
+
+    expect(rendered.split('\n')[0]).toBe('')
+    expect(parseKubernetesManifests(rendered).map(resource => [resource.name, resource.containers[0]?.image])).toEqual([
+      ['web', 'acme/web:2.0'],
+    ])
+  })
+
+  it('should link images to their builds and env vars to the code reading them', () => {
+    const project = createProject({ directory: '/p' })
+    const files = [
+      file('/p/k8s/api.yaml', MANIFEST),
+      file('/p/Makefile', 'image:\n\tdocker build -t ghcr.io/acme/api:$(VERSION) -f services/api/Dockerfile services/api\n'),
+      file('/p/services/api/Dockerfile', 'FROM golang:1.22\n'),
+      file('/p/services/api/main.go', 'package main\n\nvar db = os.Getenv("DATABASE_URL")\nvar level = os.Getenv("LOG_LEVEL")\n'),
+      file('/p/tools/seed.go', 'package main\n\nvar db = os.Getenv("DATABASE_URL")\n'),
+    ]
+    for (const node of files) project.files.set(node.path, node)
+
+    const map = mapKubernetes(project)
+    const [container] = map.workloads[0]!.containers
+
+    expect(container!.builds).toEqual([
+      { dockerfile: '/p/services/api/Dockerfile', context: '/p/services/api', via: 'build-command' },
+    ])
+    expect(container!.env.map(env => [env.name, env.from, env.reads.map(read => `${read.file}:${read.line}`)])).toEqual([
+      ['DATABASE_URL', 'Secret/api-db', ['/p/services/api/main.go:3']],
+      ['LOG_LEVEL', 'ConfigMap/api-config', ['/p/services/api/main.go:4']],
+    ])
+    expect(map.services.map(service => [service.name, service.workloads])).toEqual([['api', ['api']]])
+    expect(map.configs.map(config => [config.name, config.keys, config.usedBy])).toEqual([['api-config', ['LOG_LEVEL'], ['api']]])
+
+    const envMap = buildEnvVarMap(files, '/p/missing')
+    expect(envMap.variables.find(variable => variable.name === 'LOG_LEVEL')?.status).toBe('defined')
+  })
+})
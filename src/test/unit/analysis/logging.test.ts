@@ -0,0 +1,60 @@
+/**
+ * Log statement extraction and logging consistency findings
+ */
+
+import { describe, it, expect } from 'vitest'
+import { resolve } from 'path'
+import { readFileSync } from 'fs'
+import { analyzeLogging, extractLogStatements } from '../../../analysis/logging.js'
+import type { TreeNode } from '../../../types/core.js'
+
+describe('Logging analysis', () => {
+  const fixture = resolve(import.meta.dirname, '../../fixtures/logging')
+  // Relative paths, since everything under src/test counts as test code
+  const files: TreeNode[] = ['server/main.go', 'src/orders.ts', 'worker/tasks.py', 'scripts/seed.js'].map(file => ({
+    id: file,
+    type: 'file',
+    path: file,
+    content: readFileSync(resolve(fixture, file), 'utf-8'),
+  }))
+
+  it('should extract levels, messages and structured fields', () => {
+    const { statements } = analyzeLogging(files)
+
+    expect(statements.find(statement => statement.file === 'server/main.go' && statement.line === 16)).toMatchObject({
+      logger: 'slog.Info',
+      level: 'info',
+      message: 'server ready',
+      fields: ['port', 'db'],
+    })
+    expect(statements.find(statement => statement.file === 'src/orders.ts' && statement.line === 5)?.fields).toEqual(['orderId', 'userId'])
+    expect(statements.find(statement => statement.file === 'worker/tasks.py' && statement.line === 7)?.fields).toEqual(['job_id'])
+  })
+
+  it('should flag print statements in production code only', () => {
+    const findings = analyzeLogging(files).findings.filter(finding => finding.category === 'print_logging')
+
+    expect(findings.map(finding => finding.location)).toEqual(['server/main.go:10', 'worker/tasks.py:14'])
+    expect(findings[0]?.description).toContain('slog')
+  })
+
+  it('should flag error logs that drop the handled error', () => {
+    const findings = analyzeLogging(files).findings.filter(finding => finding.category === 'error_log_without_error')
+
+    expect(findings.map(finding => finding.location)).toEqual(['server/main.go:13', 'src/orders.ts:11', 'worker/tasks.py:13'])
+  })
+
+  it('should read zerolog and logrus chains', () => {
+    const statements = extractLogStatements([
+      'if err != nil {',
+      '  log.Error().Err(err).Str("user", id).Msg("run failed")',
+      '  log.WithField("job", name).Error("job died")',
+      '}',
+    ].join('\n'), 'worker.go')
+
+    expect(statements).toEqual([
+      expect.objectContaining({ line: 2, message: 'run failed', fields: ['error', 'user'], includesError: true }),
+      expect.objectContaining({ line: 3, message: 'job died', fields: ['job'], includesError: false }),
+    ])
+  })
+})
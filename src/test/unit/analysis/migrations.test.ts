@@ -0,0 +1,111 @@
+/**
+ * Migration parsing and table replay across golang-migrate, goose, Prisma and Alembic
+ */
+
+import { describe, it, expect } from 'vitest'
+import { resolve } from 'path'
+import { readFileSync } from 'fs'
+import { describeTable, findMigrations, parseSqlMigration } from '../../../analysis/migrations.js'
+import type { TreeNode } from '../../../types/core.js'
+
+describe('Migration analysis', () => {
+  const fixture = resolve(import.meta.dirname, '../../fixtures/migrations')
+  const files: TreeNode[] = [
+    'db/migrate/000002_create_orders.up.sql',
+    'db/migrate/000001_create_users.up.sql',
+    'db/migrate/000001_create_users.down.sql',
+    'db/migrate/000003_user_profile.up.sql',
+    'goose/20230901100000_create_accounts.sql',
+    'goose/20230915100000_account_plan.sql',
+    'prisma/migrations/20240101120000_init/migration.sql',
+    'prisma/migrations/20240215090000_add_posts/migration.sql',
+    'alembic/versions/b2c3d4e5f6a7_add_invoice_status.py',
+    'alembic/versions/a1b2c3d4e5f6_create_invoices.py',
+  ].map(file => ({
+    id: file,
+    type: 'file',
+    path: file,
+    content: readFileSync(resolve(fixture, file), 'utf-8'),
+  }))
+  const migrations = findMigrations(files)
+
+  it('should detect each tool and order migrations by version and revision chain', () => {
+    expect(migrations.map(migration => `${migration.tool}:${migration.id}`)).toEqual([
+      'golang-migrate:000001',
+      'golang-migrate:000002',
+      'golang-migrate:000003',
+      'goose:20230901100000',
+      'goose:20230915100000',
+      'prisma:20240101120000_init',
+      'prisma:20240215090000_add_posts',
+      'alembic:a1b2c3d4e5f6',
+      'alembic:b2c3d4e5f6a7',
+    ])
+  })
+
+  it('should replay renames, type changes and drops into the current shape', () => {
+    const users = describeTable(migrations, 'USERS')!
+    expect(users.columns.map(column => `${column.name} ${column.type}`)).toEqual([
+      'id BIGSERIAL',
+      'email CITEXT',
+      'display_name TEXT',
+      'created_at TIMESTAMP WITH TIME ZONE',
+      'phone VARCHAR(32)',
+    ])
+    expect(users).toMatchObject({ createdIn: '000001', lastTouchedIn: '000003' })
+    expect(users.columns.find(column => column.name === 'created_at')).toMatchObject({ nullable: false, default: 'now()' })
+
+    const orders = describeTable(migrations, 'orders')!
+    expect(orders.columns.map(column => column.name)).toEqual(['id', 'user_id', 'total'])
+    expect(orders.columns[0]).toMatchObject({ primaryKey: true })
+    expect(orders.columns[1]).toMatchObject({ references: 'users.id' })
+    expect(orders.columns[2]).toMatchObject({ nullable: false, addedIn: '000002', changedIn: '000003' })
+  })
+
+  it('should only read the goose Up section', () => {
+    const accounts = describeTable(migrations, 'accounts')!
+    expect(accounts.columns.map(column => column.name)).toEqual(['id', 'owner', 'plan'])
+    expect(accounts.history.map(entry => entry.line)).toEqual([2, 3])
+  })
+
+  it('should keep history across a Prisma SQLite table rebuild', () => {
+    const user = describeTable(migrations, 'User')!
+    expect(user.createdIn).toBe('20240101120000_init')
+    expect(user.droppedIn).toBeUndefined()
+    expect(user.columns.map(column => `${column.name}@${column.addedIn}`)).toEqual([
+      'id@20240101120000_init',
+      'email@20240101120000_init',
+      'name@20240215090000_add_posts',
+    ])
+    expect(describeTable(migrations, 'new_User')).toBeUndefined()
+    expect(describeTable(migrations, 'Post')!.columns.find(column => column.name === 'authorId')?.references).toBe('User.id')
+  })
+
+  it('should read Alembic create_table, batch operations and renames', () => {
+    const invoices = describeTable(migrations, 'invoices')!
+    expect(invoices.columns.map(column => `${column.name} ${column.type}`)).toEqual([
+      'id Integer',
+      'account_id Integer',
+      'amount_cents BigInteger',
+      'status String(length=20)',
+    ])
+    expect(invoices.columns[0]).toMatchObject({ primaryKey: true, nullable: false })
+    expect(invoices.columns[1]).toMatchObject({ references: 'accounts.id' })
+    expect(invoices.lastTouchedIn).toBe('b2c3d4e5f6a7')
+    expect(describeTable(migrations, 'legacy_invoices')?.droppedIn).toBe('b2c3d4e5f6a7')
+  })
+
+  it('should parse MySQL column changes and table renames', () => {
+    const changes = parseSqlMigration([
+      'ALTER TABLE `shop`.`items` CHANGE `title` `name` VARCHAR(100) NOT NULL, MODIFY price DECIMAL(8,2);',
+      'RENAME TABLE items TO products;',
+    ].join('\n'))
+    expect(changes.map(change => `${change.kind}:${change.table}.${change.column ?? ''}${change.to ? `->${change.to}` : ''}`)).toEqual([
+      'rename_column:items.title->name',
+      'alter_column:items.name',
+      'alter_column:items.price',
+      'rename_table:items.->products',
+    ])
+    expect(changes[1]!.definition).toMatchObject({ type: 'VARCHAR(100)', nullable: false })
+  })
+})
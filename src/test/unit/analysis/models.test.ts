@@ -0,0 +1,63 @@
+/**
+ * ORM model extraction across GORM, Prisma, SQLAlchemy and TypeORM
+ */
+
+import { describe, it, expect } from 'vitest'
+import { resolve } from 'path'
+import { readFileSync } from 'fs'
+import { extractModels } from '../../../analysis/models.js'
+import type { TreeNode } from '../../../types/core.js'
+
+describe('ORM model extraction', () => {
+  const fixture = resolve(import.meta.dirname, '../../fixtures/orm-models')
+  const files: TreeNode[] = ['prisma/schema.prisma', 'internal/models/company.go', 'app/models.py', 'src/entities/order.ts'].map(file => ({
+    id: file,
+    type: 'file',
+    path: file,
+    content: readFileSync(resolve(fixture, file), 'utf-8'),
+  }))
+  const models = extractModels(files)
+  const model = (name: string) => models.find(candidate => candidate.name === name)!
+
+  it('should find models of every ORM and skip plain structs', () => {
+    expect(models.map(candidate => `${candidate.orm}:${candidate.name}`)).toEqual([
+      'sqlalchemy:Account',
+      'gorm:Company',
+      'gorm:Employee',
+      'sqlalchemy:Invoice',
+      'typeorm:Order',
+      'prisma:Post',
+      'prisma:Profile',
+      'gorm:Skill',
+      'prisma:Tag',
+      'prisma:User',
+    ])
+  })
+
+  it('should read Prisma fields, tables and relation kinds', () => {
+    expect(model('User')).toMatchObject({ table: 'users', line: 6, endLine: 15 })
+    expect(model('User').fields.find(field => field.name === 'createdAt')).toMatchObject({ column: 'created_at' })
+    expect(model('User').relations.map(relation => [relation.name, relation.kind])).toEqual([['posts', 'hasMany'], ['profile', 'hasOne']])
+    expect(model('Post').relations.map(relation => [relation.name, relation.kind])).toEqual([['author', 'belongsTo'], ['tags', 'manyToMany']])
+  })
+
+  it('should read GORM tags, embedded gorm.Model and TableName', () => {
+    expect(model('Company').fields.map(field => field.name)).toEqual(['ID', 'CreatedAt', 'UpdatedAt', 'DeletedAt', 'Name'])
+    expect(model('Employee')).toMatchObject({ table: 'staff' })
+    expect(model('Employee').fields.find(field => field.name === 'Email')).toMatchObject({ column: 'email_address' })
+    expect(model('Employee').relations.map(relation => [relation.name, relation.kind])).toEqual([['Company', 'belongsTo'], ['Skills', 'manyToMany']])
+  })
+
+  it('should read SQLAlchemy columns and foreign keys', () => {
+    expect(model('Account').fields.find(field => field.name === 'owner')).toMatchObject({ type: 'str', nullable: true })
+    expect(model('Invoice').fields.find(field => field.name === 'account_id')).toMatchObject({ line: 27, references: 'accounts.id' })
+    expect(model('Invoice').relations).toEqual([{ name: 'account', kind: 'belongsTo', target: 'Account', line: 31 }])
+    expect(model('Account').relations[0]).toMatchObject({ kind: 'hasMany', target: 'Invoice' })
+  })
+
+  it('should read TypeORM decorators', () => {
+    expect(model('Order')).toMatchObject({ table: 'orders' })
+    expect(model('Order').fields.map(field => field.name)).toEqual(['id', 'totalCents', 'note', 'createdAt'])
+    expect(model('Order').relations.map(relation => [relation.target, relation.kind])).toEqual([['Customer', 'belongsTo'], ['OrderLine', 'hasMany']])
+  })
+})
@@ -0,0 +1,99 @@
+/**
+ * Nil and None dereferences in Go and Python functions, by confidence
+ */
+
+import { describe, it, expect } from 'vitest'
+import { analyzeNullability } from '../../../analysis/nullability.js'
+import type { TreeNode } from '../../../types/core.js'
+
+const GO_SOURCE = `package users
+
+func Describe(u *User) string {
+	if u == nil {
+		log.Print("no user")
+	}
+	return u.Name
+}
+
+func Notify(u *User) {
+	if u != nil {
+		u.Touch()
+	}
+	send(u.Email)
+}
+
+func Greet(u *User) string {
+	if u == nil {
+		return "hello"
+	}
+	return "hello " + u.Name
+}
+
+func Fetch(url string) int {
+	resp, err := http.Get(url)
+	defer resp.Body.Close()
+	if err != nil {
+		return 0
+	}
+	return resp.StatusCode
+}
+`
+
+const PY_SOURCE = `import re
+
+def version(text):
+    match = re.match(r"v(\\d+)", text)
+    return match.group(1)
+
+def checked(text):
+    match = re.search(r"\\d+", text)
+    if match is None:
+        return None
+    return match.group(0)
+
+def owner(repo):
+    if repo is not None:
+        log(repo.name)
+    return repo.owner
+
+def region(config):
+    return config.get("region").lower()
+
+def fallback(user):
+    if user is None:
+        print("anonymous")
+    return user.name
+`
+
+const files: TreeNode[] = [['users/users.go', GO_SOURCE], ['app/utils.py', PY_SOURCE]].map(([path, content]) => ({
+  id: path!,
+  type: 'file',
+  path: path!,
+  content,
+}))
+
+describe('nullability analysis', () => {
+  it('should report medium and high confidence dereferences by default', () => {
+    const { findings, metrics } = analyzeNullability(files)
+    expect(findings.map(finding => [finding.location, finding.category, finding.metrics?.confidence])).toEqual([
+      ['users/users.go:7', 'nil_dereference', 'high'],
+      ['users/users.go:14', 'nil_dereference', 'medium'],
+      ['app/utils.py:5', 'unchecked_result', 'high'],
+      ['app/utils.py:16', 'nil_dereference', 'medium'],
+      ['app/utils.py:24', 'nil_dereference', 'high'],
+    ])
+    expect(findings[0]!.description).toBe('Describe: u is dereferenced after `if u == nil` lets execution continue with it nil')
+    expect(findings[0]!.severity).toBe('warning')
+    expect(findings[1]!.severity).toBe('info')
+    expect(metrics).toEqual({ analyzedFiles: 2, analyzedFunctions: 9, issuesByCategory: { nil_dereference: 4, unchecked_result: 1 } })
+  })
+
+  it('should add low confidence findings on request and drop them above', () => {
+    const low = analyzeNullability(files, 'low').findings
+    expect(low.filter(finding => finding.metrics?.confidence === 'low').map(finding => [finding.location, finding.description])).toEqual([
+      ['users/users.go:26', 'Fetch: resp is dereferenced before err is checked; it is usually nil when err is set'],
+      ['app/utils.py:19', 'region: the result of config.get() can be None and is used without a check'],
+    ])
+    expect(analyzeNullability(files, 'high').findings.map(finding => finding.location)).toEqual(['users/users.go:7', 'app/utils.py:5', 'app/utils.py:24'])
+  })
+})
@@ -0,0 +1,49 @@
+/**
+ * OpenAPI spec to route handler cross-referencing
+ */
+
+import { describe, it, expect } from 'vitest'
+import { resolve } from 'path'
+import { checkOpenApi } from '../../../analysis/openapi.js'
+import { extractRoutes, normalizeRoutePath, routePathsMatch } from '../../../analysis/routes.js'
+import { createProject, parseProject } from '../../../project/manager.js'
+import type { TreeNode } from '../../../types/core.js'
+
+describe('OpenAPI cross-referencing', () => {
+  const fixture = resolve(import.meta.dirname, '../../fixtures/openapi-express')
+
+  it('should normalize path parameters across frameworks', () => {
+    expect(normalizeRoutePath('/users/:id/')).toBe('/users/{}')
+    expect(normalizeRoutePath('/users/<int:id>')).toBe('/users/{}')
+    expect(normalizeRoutePath('/users/{userId}')).toBe('/users/{}')
+    expect(routePathsMatch('/api/v1/users/:id', '/users/{id}')).toBe(true)
+    expect(routePathsMatch('/api/v1/accounts/:id', '/users/{id}')).toBe(false)
+  })
+
+  it('should extract decorator routes with their handlers', () => {
+    const file: TreeNode = {
+      id: 'file-1',
+      type: 'file',
+      path: 'app.py',
+      content: '@app.route("/items/<int:item_id>", methods=["GET", "PUT"])\ndef item(item_id):\n    pass\n',
+      children: [{ id: 'fn-1', type: 'function', name: 'item', path: 'app.py', startLine: 2, endLine: 3 }],
+    }
+
+    expect(extractRoutes([file]).map(route => [route.method, route.path, route.handler])).toEqual([
+      ['GET', '/items/<int:item_id>', 'item'],
+      ['PUT', '/items/<int:item_id>', 'item'],
+    ])
+  })
+
+  it('should report implemented, unimplemented and undocumented endpoints', async () => {
+    const project = createProject({ directory: fixture })
+    await parseProject(project)
+
+    const report = await checkOpenApi(project)
+
+    expect(report.specFiles).toEqual(['openapi.yaml'])
+    expect(report.operations.find(op => op.operationId === 'getUser')?.handlers[0]?.handler).toBe('getUser')
+    expect(report.unimplemented.map(op => op.operationId)).toEqual(['deleteUser'])
+    expect(report.undocumented.map(route => route.path)).toEqual(['/health'])
+  })
+})
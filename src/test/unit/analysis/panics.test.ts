@@ -0,0 +1,117 @@
+/**
+ * Panic sites and the functions that reach them through their calls
+ */
+
+import { describe, it, expect } from 'vitest'
+import { findPanicPaths } from '../../../analysis/panics.js'
+import type { TreeNode } from '../../../types/core.js'
+
+const GO_SOURCE = `package config
+
+func Load(path string) Config {
+	raw := readRaw(path)
+	return parse(raw)
+}
+
+func parse(raw any) Config {
+	if raw == nil {
+		panic("no config") // panic( in a comment is not a site
+	}
+	cfg := raw.(Config)
+	if name, ok := raw.(Named); ok {
+		cfg.Name = name.Name()
+	}
+	return cfg
+}
+
+func readRaw(path string) any {
+	return nil
+}
+
+func Safe(path string) (cfg Config) {
+	defer func() {
+		recover()
+	}()
+	return Load(path)
+}
+`
+
+const TS_SOURCE = `export function start(argv: string[]) {
+  const options = configure(argv)
+  try {
+    validate(options)
+  }
+  catch (error) {
+    console.error(error)
+  }
+}
+
+function configure(argv: string[]) {
+  return check(argv)
+}
+
+function check(argv: string[]) {
+  if (argv.length === 0) throw new Error('no arguments')
+  return argv
+}
+
+function validate(options: string[]) {
+  throw new Error('invalid')
+}
+`
+
+// Function nodes as the parser indexes them, with their lines in the file
+function functions(path: string, content: string): TreeNode[] {
+  const lines = content.split('\n')
+  const nodes: TreeNode[] = []
+  lines.forEach((line, index) => {
+    const declaration = /^(?:export )?func(?:tion)?\s+(\w+)/.exec(line)
+    if (!declaration) return
+    const end = lines.findIndex((next, after) => after > index && next === '}')
+    nodes.push({
+      id: `${path}#${declaration[1]}`,
+      type: 'function',
+      name: declaration[1],
+      path,
+      startLine: index + 1,
+      endLine: end + 1,
+      content: lines.slice(index, end + 1).join('\n'),
+    })
+  })
+  return nodes
+}
+
+describe('panic paths', () => {
+  const nodes = [...functions('config/load.go', GO_SOURCE), ...functions('src/cli.ts', TS_SOURCE)]
+
+  it('should list the sites each language can panic or throw at', () => {
+    const { sites } = findPanicPaths(nodes)
+    expect(sites.map(site => [site.kind, site.function, site.line, site.handled ?? false])).toEqual([
+      ['panic', 'parse', 10, false],
+      ['type_assertion', 'parse', 12, false],
+      ['throw', 'check', 16, false],
+      ['throw', 'validate', 21, false],
+    ])
+    expect(sites[1]!.code).toBe('cfg := raw.(Config)')
+  })
+
+  it('should mark callers up to the depth, skipping recovered and caught calls', () => {
+    const { functions: marked } = findPanicPaths(nodes)
+    expect(marked.map(fn => [fn.name, fn.depth, fn.via ?? []])).toEqual([
+      ['parse', 0, []],
+      ['check', 0, []],
+      ['validate', 0, []],
+      ['Load', 1, ['parse']],
+      ['configure', 1, ['check']],
+      ['start', 2, ['configure', 'check']],
+    ])
+    expect(findPanicPaths(nodes, { depth: 1 }).functions.map(fn => fn.name)).not.toContain('start')
+  })
+
+  it('should report the sites a symbol can reach', () => {
+    const report = findPanicPaths(nodes, { symbol: 'start' })
+    expect(report.functions).toEqual([{ name: 'start', file: 'src/cli.ts', line: 1, depth: 2, sites: 0, via: ['configure', 'check'] }])
+    expect(report.sites.map(site => site.function)).toEqual(['check'])
+    expect(() => findPanicPaths(nodes, { symbol: 'missing' })).toThrow('Unknown function: missing')
+  })
+})
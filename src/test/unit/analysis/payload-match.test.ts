@@ -0,0 +1,111 @@
+/**
+ * Matching JSON/YAML payloads and key paths to Go, TypeScript and Python types
+ */
+
+import { describe, it, expect } from 'vitest'
+import { extractDataShapes, matchKeyPath, matchPayload, parsePayload } from '../../../analysis/payload-match.js'
+import type { TreeNode } from '../../../types/core.js'
+
+const GO_SOURCE = `package api
+
+type Base struct {
+	ID int64 \`json:"id"\`
+}
+
+type Order struct {
+	Base
+	Customer Customer \`json:"customer"\`
+	Items    []Item   \`json:"items"\`
+	Note     string   \`json:"note,omitempty"\`
+	Secret   string   \`json:"-"\`
+}
+
+type Item struct {
+	SKU      string \`json:"sku"\`
+	Quantity int    \`json:"qty"\`
+}
+
+type Customer struct {
+	Name  string
+	Email string \`json:"email"\`
+}
+`
+
+const TS_SOURCE = `export interface Customer {
+  name: string
+  email: string
+  phone?: string
+}
+
+export type Item = {
+  sku: string
+  quantity: number
+}
+`
+
+const PY_SOURCE = `from pydantic import BaseModel, Field
+
+
+class Customer(BaseModel):
+    """A buyer. Note: names are not unique."""
+
+    full_name: str = Field(..., alias="name")
+    email: str
+
+    def display(self) -> str:
+        return self.full_name
+`
+
+describe('payload type matching', () => {
+  const files: TreeNode[] = [['api/order.go', GO_SOURCE], ['web/types.ts', TS_SOURCE], ['app/models.py', PY_SOURCE]].map(([path, content]) => ({
+    id: path!,
+    type: 'file',
+    path: path!,
+    content,
+  }))
+  const shapes = extractDataShapes(files)
+
+  it('should read types with their serialized names and promoted fields', () => {
+    expect(shapes.map(shape => `${shape.language}:${shape.name}`)).toEqual([
+      'go:Base', 'go:Order', 'go:Item', 'go:Customer', 'typescript:Customer', 'typescript:Item', 'python:Customer',
+    ])
+    const order = shapes.find(shape => shape.name === 'Order')!
+    expect(order.fields.map(field => [field.name, field.keys])).toEqual([
+      ['ID', ['id']], ['Customer', ['customer']], ['Items', ['items']], ['Note', ['note']],
+    ])
+    expect(shapes.find(shape => shape.language === 'python')!.fields.map(field => field.keys)).toEqual([['name', 'full_name'], ['email']])
+  })
+
+  it('should rank the type a payload decodes into first', () => {
+    const payload = parsePayload('{"id": 7, "customer": {"name": "Ada", "email": "a@b.c"}, "items": [{"sku": "X1", "qty": 2}]}')
+    const [best] = matchPayload(shapes, payload)
+    expect(best!.name).toBe('Order')
+    expect(best!.score).toBe(1)
+    expect(best!.nested).toEqual([{ key: 'customer', type: 'Customer', score: 1 }, { key: 'items', type: 'Item', score: 1 }])
+  })
+
+  it('should report unknown keys, missing fields and loose matches', () => {
+    const candidates = matchPayload(shapes, parsePayload('name: Ada\nemail: a@b.c\nloyalty: gold\n'), { maxResults: 3 })
+    expect(candidates.map(candidate => `${candidate.language}:${candidate.name}`)).toEqual(['go:Customer', 'typescript:Customer', 'python:Customer'])
+    expect(candidates[0]!.unknownKeys).toEqual(['loyalty'])
+    expect(candidates[0]!.missingFields).toEqual([])
+
+    const item = matchPayload(shapes, { SKU: 'X1', quantity: 2 }, { language: 'typescript' })[0]!
+    expect(item.name).toBe('Item')
+    expect(item.matched).toEqual([{ key: 'SKU', field: 'sku', line: 8, exact: false }, { key: 'quantity', field: 'quantity', line: 9, exact: true }])
+    expect(matchPayload(shapes, { unrelated: true })).toEqual([])
+  })
+
+  it('should resolve key paths through field types', () => {
+    const [best] = matchKeyPath(shapes, 'data.items[0].qty')
+    expect(best!.name).toBe('Order')
+    expect(best!.unresolved).toEqual(['data'])
+    expect(best!.steps.map(step => `${step.type}.${step.field}`)).toEqual(['Order.Items', 'Item.Quantity'])
+    expect(best!.steps[1]!.line).toBe(17)
+
+    expect(matchKeyPath(shapes, 'customer.email', { language: 'go' }).map(candidate => [candidate.name, candidate.score])).toEqual([
+      ['Order', 1],
+      ['Customer', 0.5],
+    ])
+  })
+})
@@ -0,0 +1,117 @@
+/**
+ * Resources left open on some path in Go and Python functions
+ */
+
+import { describe, it, expect } from 'vitest'
+import { analyzeResources } from '../../../analysis/resources.js'
+import type { TreeNode } from '../../../types/core.js'
+
+const GO_SOURCE = `package store
+
+import (
+	"net/http"
+	"os"
+)
+
+func Load(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return read(f)
+}
+
+func Fetch(url string) (int, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return 0, err
+	}
+	if resp.StatusCode != 200 {
+		return resp.StatusCode, nil
+	}
+	resp.Body.Close()
+	return 200, nil
+}
+
+func Append(path string) error {
+	f, err := os.OpenFile(path, os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	f.Write([]byte("os.Open(x) in a string is not a call"))
+	return nil
+}
+
+func Create(path string) (*os.File, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func Probe(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		resp.Body.Close()
+	}()
+	return nil
+}
+`
+
+const PY_SOURCE = `import json
+
+def load(path):
+    with open(path) as handle:
+        return json.load(handle)
+
+def first_line(path):
+    handle = open(path)
+    line = handle.readline()
+    if not line:
+        raise ValueError("empty")
+    handle.close()
+    return line
+
+def count(path):
+    handle = open(path)  # handle = open(other) in a comment
+    return len(handle.read())
+
+def guarded(path):
+    handle = open(path)
+    try:
+        return handle.read()
+    finally:
+        handle.close()
+
+def opener(path):
+    handle = open(path, "rb")
+    return handle
+`
+
+const file = (path: string, content: string): TreeNode => ({ id: path, type: 'file', path, content })
+
+describe('resource leak analysis', () => {
+  it('should report Go resources a return path leaves open', () => {
+    const { findings, metrics } = analyzeResources([file('store/store.go', GO_SOURCE)])
+    expect(findings.map(finding => [finding.category, finding.location])).toEqual([
+      ['leak_on_return', 'store/store.go:23'],
+      ['unclosed_resource', 'store/store.go:30'],
+    ])
+    expect(findings[0]!.description).toBe('Fetch: returns without closing resp from http.Get; defer resp.Body.Close() after opening it')
+    expect(findings[1]!.metrics).toEqual({ function: 'Append', resource: 'f', opener: 'os.OpenFile' })
+    expect(metrics).toEqual({ analyzedFiles: 1, analyzedFunctions: 5, issuesByCategory: { leak_on_return: 1, unclosed_resource: 1 } })
+  })
+
+  it('should report Python handles opened outside with blocks', () => {
+    const { findings } = analyzeResources([file('app/files.py', PY_SOURCE)])
+    expect(findings.map(finding => [finding.category, finding.location, finding.description])).toEqual([
+      ['leak_on_return', 'app/files.py:11', 'first_line: raises without closing handle from open(); open it in a with block'],
+      ['unclosed_resource', 'app/files.py:16', 'count: handle from open() is never closed; open it in a with block'],
+    ])
+  })
+})
@@ -0,0 +1,85 @@
+/**
+ * Rolling analysis findings up per owner or top-level directory, with trends
+ */
+
+import { describe, it, expect } from 'vitest'
+import { applyRollupTrends, buildRollup, formatRollupTable, readRollupBaseline, rollupFindings } from '../../../analysis/rollup.js'
+import { parseCodeOwners, type CodeOwners } from '../../../project/codeowners.js'
+import type { Finding } from '../../../types/analysis.js'
+
+function finding(location: string, severity: Finding['severity'] = 'warning', category = 'high_complexity'): Finding {
+  return { type: 'quality', category, severity, location, description: 'reduce complexity' }
+}
+
+const codeOwners: CodeOwners = {
+  directory: '/p',
+  file: '/p/CODEOWNERS',
+  rules: parseCodeOwners('/services/ @acme/backend\n/shared/ @acme/backend @acme/web\n/apps/web/ @acme/web\n'),
+}
+
+const findings = [
+  finding('/p/services/api.ts:10', 'critical'),
+  finding('/p/services/db.ts:4', 'warning', 'long_method'),
+  finding('/p/apps/web/App.tsx:22'),
+  finding('/p/shared/format.ts:1', 'info'),
+  finding('/p/index.ts:3'),
+]
+
+describe('rollupFindings', () => {
+  it('should count findings per owner, most critical first', () => {
+    const groups = rollupFindings(findings, { groupBy: 'owner', directory: '/p', codeOwners })
+
+    expect(groups.map(group => group.group)).toEqual(['@acme/backend', '@acme/web', '(unowned)'])
+    expect(groups[0]).toMatchObject({ total: 3, critical: 1, warning: 1, info: 1 })
+    expect(groups[0]?.categories).toEqual({ high_complexity: 2, long_method: 1 })
+    expect(groups[1]).toMatchObject({ total: 2, warning: 1, info: 1 })
+  })
+
+  it('should count findings per top-level directory', () => {
+    const groups = rollupFindings(findings, { groupBy: 'directory', directory: '/p' })
+
+    expect(groups.map(group => [group.group, group.total])).toEqual([
+      ['services', 2],
+      ['(root)', 1],
+      ['apps', 1],
+      ['shared', 1],
+    ])
+  })
+
+  it('should need a CODEOWNERS file to group by owner', () => {
+    expect(() => rollupFindings(findings, { groupBy: 'owner', directory: '/p' })).toThrow('Grouping by owner needs a CODEOWNERS file')
+  })
+})
+
+describe('applyRollupTrends', () => {
+  it('should report the change per group and keep cleared groups', () => {
+    const baseline = rollupFindings([...findings, finding('/p/services/queue.ts:8'), finding('/p/tools/gen.ts:2')], { groupBy: 'directory', directory: '/p' })
+    const groups = applyRollupTrends(rollupFindings(findings, { groupBy: 'directory', directory: '/p' }), baseline)
+
+    expect(groups.find(group => group.group === 'services')?.trend).toEqual({ total: -1, critical: 0, warning: -1, info: 0 })
+    expect(groups.find(group => group.group === 'apps')?.trend).toEqual({ total: 0, critical: 0, warning: 0, info: 0 })
+    expect(groups.find(group => group.group === 'tools')).toMatchObject({ total: 0, trend: { total: -1, warning: -1 } })
+  })
+
+  it('should render the trend in the markdown table', () => {
+    const baseline = buildRollup(findings.slice(0, 1), { groupBy: 'directory', directory: '/p' })
+    const table = formatRollupTable(buildRollup(findings.slice(0, 2), { groupBy: 'directory', directory: '/p' }, baseline))
+
+    expect(table).toContain(`## Findings by directory (trend since ${baseline.generatedAt})`)
+    expect(table).toContain('| services | 2 (+1) | 1 (0) | 1 (+1) | 0 (0) |')
+  })
+})
+
+describe('readRollupBaseline', () => {
+  it('should read the rollup of a previous report', () => {
+    const rollup = buildRollup(findings, { groupBy: 'owner', directory: '/p', codeOwners })
+    expect(readRollupBaseline(JSON.parse(JSON.stringify({ findings, rollup })), 'owner')).toEqual(rollup)
+  })
+
+  it('should reject reports without a matching rollup', () => {
+    const rollup = buildRollup(findings, { groupBy: 'directory', directory: '/p' })
+
+    expect(() => readRollupBaseline({ findings }, 'owner')).toThrow('Baseline report has no rollup')
+    expect(() => readRollupBaseline({ rollup }, 'owner')).toThrow('Baseline report is grouped by directory, not owner')
+  })
+})
@@ -0,0 +1,123 @@
+/**
+ * Solidity definitions and security analysis
+ */
+
+import { describe, it, expect } from 'vitest'
+import { extractSolidityDefinitions } from '../../../core/solidity.js'
+import { analyzeSecurity } from '../../../analysis/security.js'
+import type { TreeNode } from '../../../types/core.js'
+
+const VAULT = `// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.20;
+
+/// @title A simple vault
+/// @notice Holds ether for its depositors.
+contract Vault is Ownable {
+    mapping(address => uint256) public balances;
+    uint256 public constant FEE = 10;
+    address owner;
+
+    event Withdrawn(address indexed to, uint256 amount);
+
+    struct Deposit { uint256 amount; uint64 at; }
+
+    modifier onlyOwner() {
+        require(tx.origin == owner, "not owner");
+        _;
+    }
+
+    /**
+     * @notice Sends the caller their balance.
+     * @param amount wei to withdraw
+     */
+    function withdraw(uint256 amount) external {
+        require(balances[msg.sender] >= amount);
+        (bool ok, ) = msg.sender.call{value: amount}("");
+        require(ok);
+        balances[msg.sender] -= amount;
+        emit Withdrawn(msg.sender, amount);
+    }
+
+    function safeWithdraw(uint256 amount) external nonReentrant {
+        (bool ok, ) = msg.sender.call{value: amount}("");
+        require(ok);
+        balances[msg.sender] -= amount;
+    }
+
+    function sweep(address payable to) public onlyOwner {
+        to.send(address(this).balance);
+    }
+
+    function upgrade(address impl, bytes calldata data) internal {
+        (bool ok, ) = impl.delegatecall(data);
+        require(ok, "function f() {");
+    }
+
+    function kill() public onlyOwner {
+        selfdestruct(payable(owner));
+    }
+}
+
+interface IVault {
+    function deposit() external payable;
+}
+
+library Math {
+    function max(uint a, uint b) internal pure returns (uint) {
+        return a > b ? a : b;
+    }
+}
+`
+
+describe('Solidity', () => {
+  it('should index contracts, functions, modifiers and events', () => {
+    const nodes = extractSolidityDefinitions(VAULT, '/p/contracts/Vault.sol')
+    expect(nodes.map(node => [node.type, node.name, node.startLine, node.endLine, node.symbol?.kind, node.symbol?.container, node.symbol?.visibility])).toEqual([
+      ['class', 'Vault', 6, 50, 'class', undefined, 'public'],
+      ['event', 'Withdrawn', 11, 11, 'type', 'Vault', 'public'],
+      ['class', 'Deposit', 13, 13, 'struct', 'Vault', 'public'],
+      ['modifier', 'onlyOwner', 15, 18, 'function', 'Vault', 'internal'],
+      ['function', 'withdraw', 24, 30, 'method', 'Vault', 'public'],
+      ['function', 'safeWithdraw', 32, 36, 'method', 'Vault', 'public'],
+      ['function', 'sweep', 38, 40, 'method', 'Vault', 'public'],
+      ['function', 'upgrade', 42, 45, 'method', 'Vault', 'internal'],
+      ['function', 'kill', 47, 49, 'method', 'Vault', 'public'],
+      ['class', 'IVault', 52, 54, 'interface', undefined, 'public'],
+      ['function', 'deposit', 53, 53, 'method', 'IVault', 'public'],
+      ['class', 'Math', 56, 60, 'module', undefined, 'public'],
+      ['function', 'max', 57, 59, 'method', 'Math', 'internal'],
+    ])
+
+    const byName = (name: string) => nodes.find(node => node.name === name)!.symbol
+    expect(byName('Vault')).toMatchObject({ doc: 'Holds ether for its depositors.', signature: 'contract Vault is Ownable' })
+    expect(byName('withdraw')).toMatchObject({ doc: 'Sends the caller their balance.', signature: 'function withdraw(uint256 amount) external' })
+    expect(byName('Withdrawn')?.signature).toBe('event Withdrawn(address indexed to, uint256 amount)')
+  })
+
+  it('should not take a doc block from before a plain comment', () => {
+    const nodes = extractSolidityDefinitions('/** Holds ether. */\n/* Audited in 2024. */\ncontract Vault {}\n', '/p/contracts/Vault.sol')
+    expect(nodes[0]!.name).toBe('Vault')
+    expect(nodes[0]!.symbol?.doc).toBeUndefined()
+  })
+})
+
+describe('Security analysis', () => {
+  it('should report exploit-prone patterns in Solidity functions', () => {
+    const file: TreeNode = { id: 'vault', type: 'file', path: '/p/contracts/Vault.sol', content: VAULT }
+    const { findings, metrics } = analyzeSecurity([file, { id: 'app', type: 'file', path: '/p/app.ts', content: 'tx.origin == owner' }])
+
+    expect(findings.map(finding => [finding.category, finding.severity, finding.location, finding.metrics?.function])).toEqual([
+      ['tx_origin', 'critical', '/p/contracts/Vault.sol:16', 'Vault.onlyOwner'],
+      ['reentrancy', 'critical', '/p/contracts/Vault.sol:28', 'Vault.withdraw'],
+      ['unchecked_call', 'warning', '/p/contracts/Vault.sol:39', 'Vault.sweep'],
+      ['delegatecall', 'warning', '/p/contracts/Vault.sol:43', 'Vault.upgrade'],
+      ['selfdestruct', 'warning', '/p/contracts/Vault.sol:48', 'Vault.kill'],
+    ])
+    expect(findings[1]!.description).toContain('writes balances after an external call')
+    expect(metrics).toEqual({
+      analyzedFiles: 1,
+      analyzedFunctions: 7,
+      issuesByCategory: { tx_origin: 1, reentrancy: 1, unchecked_call: 1, delegatecall: 1, selfdestruct: 1 },
+    })
+  })
+})
@@ -0,0 +1,56 @@
+/**
+ * Structural similarity search over project functions
+ */
+
+import { describe, it, expect } from 'vitest'
+import { findSimilarCode, normalizedTokens } from '../../../analysis/similarity.js'
+import { createProject } from '../../../project/manager.js'
+import type { TreeNode } from '../../../types/core.js'
+
+const fn = (name: string, path: string, content: string): TreeNode => ({ id: `${path}#${name}`, type: 'function', name, path, content, startLine: 1, endLine: content.split('\n').length })
+
+const SNIPPET = `function total(items) {
+  let sum = 0
+  for (const item of items) {
+    if (item.active) sum += item.price * item.quantity
+  }
+  return sum
+}`
+
+describe('Similar code search', () => {
+  const project = createProject({ directory: '/p' })
+  project.nodes.set('/p/src/cart.ts', [
+    fn('cartTotal', '/p/src/cart.ts', `function cartTotal(lines) {
+  // Skips removed lines
+  let acc = 10
+  for (const line of lines) {
+    if (line.visible) acc += line.cost * line.count
+  }
+  return acc
+}`),
+    fn('formatName', '/p/src/cart.ts', 'function formatName(user) {\n  return `${user.first} ${user.last}`.trim()\n}'),
+  ])
+  project.nodes.set('/p/lib/report.py', [
+    fn('report_total', '/p/lib/report.py', 'def report_total(rows):\n    for row in rows:\n        print(row)\n'),
+  ])
+
+  it('should ignore names, literals and comments', () => {
+    expect(normalizedTokens('let n = 42 // answer')).toEqual(normalizedTokens('let total = 7'))
+    expect(normalizedTokens('if (a) return "x"')).toEqual(['if', '(', 'id', ')', 'return', 'str'])
+  })
+
+  it('should rank a renamed copy as an exact match', () => {
+    const [match, ...rest] = findSimilarCode(project, SNIPPET)
+
+    expect(rest).toEqual([])
+    expect(match?.node.name).toBe('cartTotal')
+    expect(match?.similarity).toBe(100)
+  })
+
+  it('should apply the similarity threshold and language filter', () => {
+    expect(findSimilarCode(project, SNIPPET, { minSimilarity: 0 }).map(result => result.node.name)).toEqual(['cartTotal', 'formatName', 'report_total'])
+    expect(findSimilarCode(project, SNIPPET, { language: 'python', minSimilarity: 0 }).map(result => result.node.name)).toEqual(['report_total'])
+    expect(() => findSimilarCode(project, SNIPPET, { language: 'cobol' })).toThrow('Unknown language: cobol')
+    expect(() => findSimilarCode(project, '  ')).toThrow('Snippet must not be empty')
+  })
+})
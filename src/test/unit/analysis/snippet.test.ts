@@ -0,0 +1,64 @@
+/**
+ * Snippet analysis for stdin and the parse_snippet tool
+ */
+
+import { describe, it, expect } from 'vitest'
+import { analyzeSnippet } from '../../../analysis/snippet.js'
+import { resolveLanguage } from '../../../core/languages.js'
+
+const SERVICE_SOURCE = `export class UserService {
+  find(id: string) {
+    return this.users.get(id)
+  }
+
+  save(user: User, overwrite: boolean) {
+    if (overwrite || !this.users.has(user.id)) {
+      this.users.set(user.id, user)
+    }
+  }
+}
+
+export function createService() {
+  return new UserService()
+}
+`
+
+describe('resolveLanguage', () => {
+  it('should accept names, aliases, extensions and file names', () => {
+    expect(resolveLanguage('python')?.name).toBe('python')
+    expect(resolveLanguage('TS')?.name).toBe('typescript')
+    expect(resolveLanguage('.go')?.name).toBe('go')
+    expect(resolveLanguage('handler.rs')?.name).toBe('rust')
+  })
+
+  it('should return undefined for unknown hints', () => {
+    expect(resolveLanguage('cobol')).toBeUndefined()
+  })
+})
+
+describe('analyzeSnippet', () => {
+  it('should require a known language', () => {
+    expect(() => analyzeSnippet('x = 1')).toThrow('A language is required')
+    expect(() => analyzeSnippet('x = 1', { language: 'cobol' })).toThrow('Unknown language: cobol')
+  })
+
+  it('should outline symbols, nesting methods under their class', () => {
+    const analysis = analyzeSnippet(SERVICE_SOURCE, { language: 'ts' })
+
+    expect(analysis.language).toBe('typescript')
+    expect(analysis.path).toBe('snippet.ts')
+    expect(analysis.outline).toContain('class UserService (1-11)')
+    expect(analysis.outline).toContain('\n  function save')
+    expect(analysis.symbols.find(symbol => symbol.name === 'save')).toMatchObject({ parent: 'UserService', parameters: 2 })
+    expect(analysis.symbols.find(symbol => symbol.name === 'createService')?.parent).toBeUndefined()
+    expect(analysis.syntaxErrors).toEqual([])
+  })
+
+  it('should report syntax errors under the given file name', () => {
+    const analysis = analyzeSnippet('function broken( {\n  return 1\n', { filename: 'broken.js' })
+
+    expect(analysis.language).toBe('javascript')
+    expect(analysis.syntaxErrors.length).toBeGreaterThan(0)
+    expect(analysis.syntaxErrors[0]?.file).toBe('broken.js')
+  })
+})
@@ -0,0 +1,149 @@
+/**
+ * Spring beans and the dependencies injected into them
+ */
+
+import { describe, it, expect } from 'vitest'
+import { extractSpringBeans } from '../../../analysis/spring.js'
+import type { TreeNode } from '../../../types/core.js'
+
+const file = (path: string, content: string): TreeNode => ({ id: path, type: 'file', path, content })
+
+const GATEWAYS = `package shop.payments;
+
+public interface PaymentGateway {
+    void charge(long cents);
+}
+
+@Component("stripe")
+@Primary
+class StripeGateway implements PaymentGateway {
+    public void charge(long cents) {}
+}
+
+@Component
+class PaypalGateway implements PaymentGateway {
+    public void charge(long cents) {}
+}
+`
+
+const SERVICE = `package shop.orders;
+
+import lombok.RequiredArgsConstructor;
+
+@Service
+@RequiredArgsConstructor
+public class OrderService {
+    private static final Logger LOG = LoggerFactory.getLogger(OrderService.class);
+    private final OrderRepository orders;
+    private final PaymentGateway gateway;
+    @Qualifier("paypalGateway")
+    private final PaymentGateway fallback;
+    private final List<PaymentGateway> allGateways;
+    private int retries = 3;
+
+    @Autowired
+    private Clock clock;
+
+    public Order place(Order order) {
+        gateway.charge(order.total());
+        return orders.save(order);
+    }
+}
+`
+
+const CONTROLLER = `package shop.web;
+
+@RestController
+@RequestMapping("/orders")
+public class OrderController {
+    private final OrderService service;
+    private Metrics metrics;
+
+    public OrderController(OrderService service, @Value("\${shop.region}") String region) {
+        this.service = service;
+    }
+
+    @Autowired
+    public void setMetrics(Metrics metrics) {
+        this.metrics = metrics;
+    }
+
+    @GetMapping("/{id}")
+    public Order get(@PathVariable long id) {
+        return null;
+    }
+
+    @PostMapping
+    public Order create(@RequestBody Order order) {
+        return service.place(order);
+    }
+}
+
+interface OrderRepository extends JpaRepository<Order, Long> {
+    List<Order> findByStatus(String status);
+}
+
+@Configuration
+class ClockConfig {
+    @Bean
+    public Clock clock() {
+        return Clock.systemUTC();
+    }
+
+    @Bean(name = "metrics")
+    Metrics metrics(Clock clock, Optional<Tracer> tracer) {
+        return new Metrics(clock);
+    }
+}
+`
+
+describe('Spring beans', () => {
+  const beans = extractSpringBeans([
+    file('/p/src/main/java/shop/payments/PaymentGateway.java', GATEWAYS),
+    file('/p/src/main/java/shop/orders/OrderService.java', SERVICE),
+    file('/p/src/main/java/shop/web/OrderController.java', CONTROLLER),
+    file('/p/README.md', '@Service class Nope {}'),
+  ])
+
+  it('should find stereotype classes, repositories and @Bean methods', () => {
+    expect(beans.map(bean => [bean.name, bean.type, bean.stereotype, bean.line, bean.declaredBy, bean.primary])).toEqual([
+      ['stripe', 'StripeGateway', 'Component', 7, undefined, true],
+      ['paypalGateway', 'PaypalGateway', 'Component', 13, undefined, undefined],
+      ['orderService', 'OrderService', 'Service', 5, undefined, undefined],
+      ['orderController', 'OrderController', 'RestController', 3, undefined, undefined],
+      ['orderRepository', 'OrderRepository', 'Repository', 29, undefined, undefined],
+      ['clockConfig', 'ClockConfig', 'Configuration', 33, undefined, undefined],
+      ['clock', 'Clock', 'Bean', 35, 'ClockConfig', undefined],
+      ['metrics', 'Metrics', 'Bean', 40, 'ClockConfig', undefined],
+    ])
+  })
+
+  it('should resolve injected dependencies to the beans satisfying them', () => {
+    const dependencies = (name: string) => beans.find(bean => bean.name === name)!.dependencies
+      .map(dependency => [dependency.name, dependency.type, dependency.via, dependency.line, dependency.qualifier, dependency.beans])
+
+    expect(dependencies('orderService')).toEqual([
+      ['clock', 'Clock', 'field', 16, undefined, ['clock']],
+      ['orders', 'OrderRepository', 'constructor', 9, undefined, ['orderRepository']],
+      ['gateway', 'PaymentGateway', 'constructor', 10, undefined, ['stripe']],
+      ['fallback', 'PaymentGateway', 'constructor', 11, 'paypalGateway', ['paypalGateway']],
+      ['allGateways', 'List<PaymentGateway>', 'constructor', 13, undefined, ['stripe', 'paypalGateway']],
+    ])
+    expect(dependencies('orderController')).toEqual([
+      ['metrics', 'Metrics', 'setter', 13, undefined, ['metrics']],
+      ['service', 'OrderService', 'constructor', 9, undefined, ['orderService']],
+    ])
+    expect(dependencies('metrics')).toEqual([
+      ['clock', 'Clock', 'parameter', 41, undefined, ['clock']],
+      ['tracer', 'Optional<Tracer>', 'parameter', 41, undefined, []],
+    ])
+  })
+
+  it('should list the endpoints of controllers', () => {
+    expect(beans.find(bean => bean.name === 'orderController')!.endpoints).toEqual([
+      { method: 'GET', path: '/orders/{id}', line: 18, handler: undefined },
+      { method: 'POST', path: '/orders', line: 23, handler: undefined },
+    ])
+    expect(beans.find(bean => bean.name === 'orderService')!.endpoints).toBeUndefined()
+  })
+})
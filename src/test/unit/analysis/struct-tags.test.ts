@@ -0,0 +1,86 @@
+/**
+ * Go struct tags: tagged structs, serialized names and malformed or colliding tags
+ */
+
+import { describe, it, expect } from 'vitest'
+import { findSerializedField, findTagProblems, parseGoStructs, structsTaggedWith } from '../../../analysis/struct-tags.js'
+
+const SOURCE = `package api
+
+type User struct {
+	ID        int64  \`json:"id" db:"user_id"\`
+	Email     string \`json:"email,omitempty"\`
+	CreatedAt string \`json:",omitempty" yaml:"created"\`
+	Password  string \`json:"-"\`
+	Nickname  string
+	internal  string
+	Address   struct {
+		Street string \`json:"street"\`
+	} \`json:"address"\`
+}
+
+type Broken struct {
+	Name  string \`json:"name" json:"title"\`
+	Alias string \`json:name\`
+	Label string \`json:"label"db:"label"\`
+	Title string \`json:"name"\`
+}
+
+type Plain struct {
+	X, Y int
+}
+`
+
+describe('Go struct tags', () => {
+  const structs = parseGoStructs(SOURCE, 'api/user.go')
+  const struct = (name: string) => structs.find(candidate => candidate.name === name)!
+
+  it('should read fields and their tags, leaving nested struct fields out', () => {
+    expect(structs.map(candidate => candidate.name)).toEqual(['User', 'Broken', 'Plain'])
+    expect(struct('User').fields.map(field => field.name)).toEqual(['ID', 'Email', 'CreatedAt', 'Password', 'Nickname', 'internal', 'Address'])
+    expect(struct('User').fields[0]!.tags).toEqual({ json: { name: 'id', options: [] }, db: { name: 'user_id', options: [] } })
+    expect(struct('User').fields[2]!.tags.json).toEqual({ name: 'CreatedAt', options: ['omitempty'] })
+    expect(struct('User').fields[6]!.tags.json?.name).toBe('address')
+    expect(struct('Plain').fields.map(field => [field.name, field.type])).toEqual([['X', 'int'], ['Y', 'int']])
+  })
+
+  it('should list the structs using a tag key', () => {
+    expect(structsTaggedWith(structs, 'db').map(candidate => candidate.name)).toEqual(['User'])
+    expect(structsTaggedWith(structs, 'yaml').map(candidate => candidate.name)).toEqual(['User'])
+    expect(structsTaggedWith(structs).map(candidate => candidate.name)).toEqual(['User', 'Broken'])
+  })
+
+  it('should find the field serialized under a name', () => {
+    expect(findSerializedField(structs, 'user_id', 'db').map(match => match.field)).toEqual(['ID'])
+    expect(findSerializedField(structs, 'Password')).toEqual([])
+
+    const nickname = findSerializedField(structs, 'nickname')
+    expect(nickname.map(match => [match.struct, match.field, match.tagged, match.exact])).toEqual([['User', 'Nickname', false, false]])
+    expect(findSerializedField(structs, 'internal')).toEqual([])
+    expect(findSerializedField(structs, 'x', 'yaml').map(match => match.field)).toEqual(['X'])
+  })
+
+  it('should report malformed tags and fields sharing a name', () => {
+    const problems = findTagProblems(structs)
+    expect(problems.map(problem => [problem.kind, problem.fields, problem.line])).toEqual([
+      ['malformed', ['Name'], 16],
+      ['malformed', ['Alias'], 17],
+      ['malformed', ['Label'], 18],
+      ['duplicate', ['Name', 'Title'], 19],
+    ])
+    expect(problems[0]!.message).toContain('repeats the json key')
+    expect(problems[2]!.message).toContain('not separated by spaces')
+
+    const duplicates = findTagProblems(parseGoStructs('type Event struct {\n\tName string `json:"name"`\n\tTitle string `json:"name"`\n}\n', 'event.go'))
+    expect(duplicates).toEqual([{
+      kind: 'duplicate',
+      struct: 'Event',
+      file: 'event.go',
+      line: 3,
+      fields: ['Name', 'Title'],
+      key: 'json',
+      name: 'name',
+      message: 'Name and Title are both serialized as json "name"',
+    }])
+  })
+})
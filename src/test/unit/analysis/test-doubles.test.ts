@@ -0,0 +1,176 @@
+/**
+ * Interface boundaries of a type and the mocks and fakes that already stand in for them
+ */
+
+import { describe, it, expect } from 'vitest'
+import { findTestBoundaries } from '../../../analysis/test-doubles.js'
+
+const ROOT = '/repo'
+
+const GO_FILES = {
+  'store/store.go': `package store
+
+type UserStore interface {
+	Get(id string) (*User, error)
+	Save(u *User) error
+}
+
+type Closer interface {
+	Close() error
+}
+
+type PostgresStore struct {
+	db *sql.DB
+}
+
+var _ UserStore = (*PostgresStore)(nil)
+
+func (s *PostgresStore) Get(id string) (*User, error) { return nil, nil }
+func (s *PostgresStore) Save(u *User) error { return nil }
+func (s *PostgresStore) Close() error { return nil }
+`,
+  'service/signup.go': `package service
+
+func NewSignup(users store.UserStore, mailer Mailer) *Signup {
+	return &Signup{users: users, mailer: mailer}
+}
+`,
+  'mocks/user_store.go': `package mocks
+
+// MockUserStore is a mock of UserStore interface.
+type MockUserStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserStoreMockRecorder
+}
+
+func NewMockUserStore(ctrl *gomock.Controller) *MockUserStore {
+	return &MockUserStore{ctrl: ctrl}
+}
+`,
+  'service/signup_test.go': `package service
+
+type fakeStore struct {
+	users map[string]*User
+}
+
+func (f *fakeStore) Get(id string) (*User, error) { return f.users[id], nil }
+func (f *fakeStore) Save(u *User) error { return nil }
+
+type CloserMock struct {
+	mock.Mock
+}
+
+func (m *CloserMock) Close() error { return m.Called().Error(0) }
+`,
+}
+
+const TS_FILES = {
+  'src/billing/gateway.ts': `export interface PaymentGateway {
+  charge(amount: number): Promise<Receipt>
+  refund: (id: string) => Promise<void>
+}
+
+export class StripeGateway implements PaymentGateway {
+  constructor(private readonly client: Stripe) {}
+
+  async charge(amount: number) {
+    if (amount <= 0) {
+      throw new Error('invalid')
+    }
+    return this.client.charge(amount)
+  }
+
+  refund = async (id: string) => {
+    await this.client.refund(id)
+  }
+}
+`,
+  'src/billing/checkout.ts': `export function checkout(cart: Cart, gateway: PaymentGateway) {
+  return gateway.charge(cart.total)
+}
+`,
+  'src/billing/checkout.test.ts': `import { vi } from 'vitest'
+import { mock } from 'vitest-mock-extended'
+
+vi.mock('./gateway.js')
+
+const gateway = mock<PaymentGateway>()
+// vi.mock('./gateway.js') again in a comment
+const stubGateway: PaymentGateway = { charge: async () => receipt, refund: async () => {} }
+`,
+}
+
+const PY_FILES = {
+  'app/repo.py': `from typing import Protocol
+
+
+class Repository(Protocol):
+    def fetch(self, key): ...
+
+
+class SqlRepository:
+    def __init__(self, engine):
+        self.engine = engine
+
+    def fetch(self, key):
+        return self.engine.get(key)
+`,
+  'tests/test_service.py': `from unittest.mock import create_autospec, patch
+
+repo = create_autospec(SqlRepository)
+
+
+@patch("app.repo.SqlRepository.fetch")
+def test_fetch(fetch):
+    pass
+
+
+class InMemoryRepository(Repository):
+    def fetch(self, key):
+        return None
+`,
+}
+
+function sources(files: Record<string, string>) {
+  return Object.entries(files).map(([path, content]) => ({ path: `${ROOT}/${path}`, content }))
+}
+
+describe('test doubles', () => {
+  it('should find a Go type\'s interfaces, consumers and gomock, testify and hand-written doubles', () => {
+    const boundaries = findTestBoundaries(sources(GO_FILES), ROOT, 'PostgresStore')
+    expect(boundaries.type).toEqual({ name: 'PostgresStore', kind: 'struct', file: 'store/store.go', line: 12, methods: ['Close', 'Get', 'Save'] })
+    expect(boundaries.interfaces.map(boundary => [boundary.name, boundary.via, boundary.consumers])).toEqual([
+      ['UserStore', 'assertion', [{ function: 'NewSignup', file: 'service/signup.go', line: 3 }]],
+      ['Closer', 'structural', []],
+    ])
+    expect(boundaries.doubles).toEqual([
+      { kind: 'gomock', of: 'UserStore', name: 'MockUserStore', constructor: 'NewMockUserStore', file: 'mocks/user_store.go', line: 4 },
+      { kind: 'fake', of: 'UserStore', name: 'fakeStore', file: 'service/signup_test.go', line: 3 },
+      { kind: 'testify', of: 'Closer', name: 'CloserMock', file: 'service/signup_test.go', line: 10 },
+    ])
+  })
+
+  it('should find Vitest module mocks, typed mock factories and object-literal fakes', () => {
+    const boundaries = findTestBoundaries(sources(TS_FILES), ROOT, 'StripeGateway')
+    expect(boundaries.type.methods).toEqual(['charge', 'refund'])
+    expect(boundaries.interfaces.map(boundary => [boundary.name, boundary.via, boundary.methods, boundary.consumers.map(consumer => consumer.function)])).toEqual([
+      ['PaymentGateway', 'declared', ['charge', 'refund'], ['checkout']],
+    ])
+    expect(boundaries.doubles).toEqual([
+      { kind: 'vitest', of: 'StripeGateway', usage: 'vi.mock(\'./gateway.js\')', file: 'src/billing/checkout.test.ts', line: 4 },
+      { kind: 'vitest', of: 'PaymentGateway', name: 'gateway', usage: 'mock<PaymentGateway>()', file: 'src/billing/checkout.test.ts', line: 6 },
+      { kind: 'fake', of: 'PaymentGateway', name: 'stubGateway', file: 'src/billing/checkout.test.ts', line: 8 },
+    ])
+  })
+
+  it('should find protocols and unittest.mock specs, patches and fakes for a Python class', () => {
+    const boundaries = findTestBoundaries(sources(PY_FILES), ROOT, 'SqlRepository')
+    expect(boundaries.interfaces.map(boundary => [boundary.name, boundary.via])).toEqual([['Repository', 'structural']])
+    expect(boundaries.doubles).toEqual([
+      { kind: 'unittest.mock', of: 'SqlRepository', name: 'repo', usage: 'create_autospec(SqlRepository)', file: 'tests/test_service.py', line: 3 },
+      { kind: 'unittest.mock', of: 'SqlRepository', usage: 'patch("app.repo.SqlRepository.fetch")', file: 'tests/test_service.py', line: 6 },
+      { kind: 'fake', of: 'Repository', name: 'InMemoryRepository', file: 'tests/test_service.py', line: 11 },
+    ])
+    expect(() => findTestBoundaries(sources(PY_FILES), ROOT, 'Missing')).toThrow('Unknown type: Missing')
+  })
+})
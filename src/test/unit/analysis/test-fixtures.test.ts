@@ -0,0 +1,102 @@
+/**
+ * Fixture and golden file references of tests, from the literal paths in their code
+ */
+
+import { describe, it, expect } from 'vitest'
+import { mapTestFixtures } from '../../../analysis/test-fixtures.js'
+
+const TESTS = [
+  {
+    path: 'pkg/render/render_test.go',
+    content: `package render
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+func TestRender(t *testing.T) {
+	input, _ := os.ReadFile("testdata/input.json")
+	golden := filepath.Join("testdata", t.Name()+".golden")
+	out := filepath.Join(t.TempDir(), "out.txt")
+}
+`,
+  },
+  {
+    path: 'src/__tests__/format.test.ts',
+    content: `import { format } from '../format'
+const fixtures = path.join(__dirname, 'fixtures')
+
+it('formats', () => {
+  const input = readFileSync(path.join(fixtures, 'order.json'), 'utf-8')
+  expect(format(input)).toMatchSnapshot()
+})
+`,
+  },
+  {
+    path: 'tests/test_parser.py',
+    content: `from pathlib import Path
+
+DATA = Path(__file__).parent / "data"
+
+
+def test_parse(snapshot):
+    text = (DATA / "sample.csv").read_text()
+    assert parse(text) == snapshot
+`,
+  },
+]
+
+const FILES = [
+  'pkg/render/render.go',
+  'pkg/render/render_test.go',
+  'pkg/render/testdata/input.json',
+  'pkg/render/testdata/TestRender.golden',
+  'src/format.ts',
+  'src/__tests__/format.test.ts',
+  'src/__tests__/fixtures/order.json',
+  'src/__tests__/__snapshots__/format.test.ts.snap',
+  'tests/test_parser.py',
+  'tests/data/sample.csv',
+]
+
+describe('test fixtures', () => {
+  it('should map each test to its fixtures and goldens with the command updating them', () => {
+    const { tests, fixtureDirectories } = mapTestFixtures(TESTS, FILES)
+    expect(tests).toEqual([
+      {
+        test: 'pkg/render/render_test.go',
+        fixtures: [
+          { path: 'pkg/render/testdata/input.json', kind: 'file', line: 6, golden: false, exists: true },
+          { path: 'pkg/render/testdata/*.golden', kind: 'pattern', line: 7, golden: true, exists: true, matches: ['pkg/render/testdata/TestRender.golden'] },
+        ],
+        update: 'go test ./pkg/render -update',
+      },
+      {
+        test: 'src/__tests__/format.test.ts',
+        fixtures: [
+          { path: 'src/__tests__/fixtures', kind: 'directory', line: 2, golden: false, exists: true },
+          { path: 'src/__tests__/fixtures/order.json', kind: 'file', line: 5, golden: false, exists: true },
+          { path: 'src/__tests__/__snapshots__/format.test.ts.snap', kind: 'file', line: 6, golden: true, exists: true },
+        ],
+        update: 'npx jest src/__tests__/format.test.ts -u',
+      },
+      {
+        test: 'tests/test_parser.py',
+        fixtures: [
+          { path: 'tests/data', kind: 'directory', line: 3, golden: false, exists: true },
+          { path: 'tests/__snapshots__/test_parser.ambr', kind: 'file', line: 6, golden: true, exists: false },
+          { path: 'tests/data/sample.csv', kind: 'file', line: 7, golden: false, exists: true },
+        ],
+        update: 'pytest tests/test_parser.py --snapshot-update',
+      },
+    ])
+    expect(fixtureDirectories).toEqual([
+      { path: 'pkg/render/testdata', files: 2, tests: 1 },
+      { path: 'src/__tests__/__snapshots__', files: 1, tests: 1 },
+      { path: 'src/__tests__/fixtures', files: 1, tests: 1 },
+    ])
+  })
+
+  it('should keep only the tests depending on files under a path', () => {
+    expect(mapTestFixtures(TESTS, FILES, 'src/__tests__/fixtures').tests.map(test => test.test)).toEqual(['src/__tests__/format.test.ts'])
+    expect(mapTestFixtures(TESTS, FILES, 'pkg/render/testdata/TestRender.golden').tests.map(test => test.test)).toEqual(['pkg/render/render_test.go'])
+  })
+})
@@ -0,0 +1,124 @@
+/**
+ * Test skeletons following the conventions of a project's existing tests
+ */
+
+import { describe, it, expect } from 'vitest'
+import { buildTestStub } from '../../../analysis/test-stubs.js'
+import type { TreeNode } from '../../../types/core.js'
+
+const ROOT = '/repo'
+
+const GO_SOURCE = `package config
+
+func Parse(input string, strict bool) (*Config, error) {
+	return decode(input, strict)
+}
+
+func (s *Store) Lookup(keys ...string) []Entry {
+	return s.find(keys)
+}
+`
+
+function functionNode(path: string, name: string, content: string, startLine: number, container?: string): TreeNode {
+  return {
+    id: `${path}#${name}`,
+    type: 'function',
+    name,
+    path: `${ROOT}/${path}`,
+    startLine,
+    content,
+    symbol: { kind: container ? 'method' : 'function', container },
+  }
+}
+
+describe('test stubs', () => {
+  it('should write a table-driven Go test per function or method', () => {
+    const parse = buildTestStub(functionNode('config/parse.go', 'Parse', GO_SOURCE.split('\n').slice(2, 5).join('\n'), 3), GO_SOURCE, ROOT, [], [])
+    expect(parse.testFile).toBe('config/parse_test.go')
+    expect(parse.imports).toEqual(['"reflect"', '"testing"'])
+    expect(parse.content).toBe(`package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	type args struct {
+		input  string
+		strict bool
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *Config
+		wantErr bool
+	}{
+		// TODO: Add test cases.
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.args.input, tt.args.strict)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+`)
+
+    const testify = [{ path: 'config/store_test.go', content: 'package config\n\nimport "github.com/stretchr/testify/assert"\n' }]
+    const lookup = buildTestStub(functionNode('config/store.go', 'Lookup', GO_SOURCE.split('\n').slice(6, 9).join('\n'), 7, 'Store'), GO_SOURCE, ROOT, testify, [])
+    expect(lookup.imports).toEqual(['"testing"', '"github.com/stretchr/testify/assert"'])
+    expect(lookup.content).toContain('func TestStore_Lookup(t *testing.T) {\n\ttype args struct {\n\t\tkeys []string\n\t}')
+    expect(lookup.content).toContain('\t\t\ts := &Store{}\n\t\t\tgot := s.Lookup(tt.args.keys...)\n\t\t\tassert.Equal(t, tt.want, got)')
+  })
+
+  it('should place and style a describe block like the existing tests', () => {
+    const tests = [
+      { path: 'src/test/unit/core/paths.test.ts', content: 'import { describe, it, expect } from \'vitest\'\nimport { normalize } from \'../../../core/paths.js\'\n\ndescribe(\'paths\', () => {\n  it(\'should work\', () => {\n    expect(normalize(\'a\')).toBe(\'a\')\n  })\n})\n' },
+      { path: 'src/test/unit/utils/retry.test.ts', content: 'import { describe, it, expect } from \'vitest\'\nimport { retry } from \'../../../utils/retry.js\'\n' },
+    ]
+    const sources = ['src/core/paths.ts', 'src/utils/retry.ts', 'src/analysis/pricing.ts']
+    const content = 'export async function price(items: Map<string, Item>, coupon?: string) {\n  return total(items, coupon)\n}'
+    const stub = buildTestStub(functionNode('src/analysis/pricing.ts', 'price', content, 1), content, ROOT, tests, sources)
+
+    expect(stub.conventions).toEqual({ framework: 'vitest', placement: 'mirrored', testRoot: 'src/test/unit', sourceRoot: 'src', examined: 2 })
+    expect(stub.testFile).toBe('src/test/unit/analysis/pricing.test.ts')
+    expect(stub.content).toBe(`import { describe, it, expect } from 'vitest'
+import { price } from '../../../analysis/pricing.js'
+
+describe('price', () => {
+  it.each([
+    // TODO: add cases as [items, coupon, expected]
+  ])('price(%o, %o) returns %o', async (items, coupon, expected) => {
+    expect(await price(items, coupon)).toEqual(expected)
+  })
+})
+`)
+  })
+
+  it('should write a parametrized pytest test in a tests directory by default', () => {
+    const content = 'def apply(self, order, rate=0.1, *extra):\n    return order.total * rate'
+    const stub = buildTestStub(functionNode('src/shop/discounts.py', 'apply', content, 4, 'Discount'), content, ROOT, [], [])
+    expect(stub.testFile).toBe('tests/test_discounts.py')
+    expect(stub.content).toBe(`import pytest
+
+from shop.discounts import Discount
+
+
+@pytest.mark.parametrize(
+    ("order", "rate", "expected"),
+    [
+        # TODO: add cases
+    ],
+)
+def test_discount_apply(order, rate, expected):
+    assert Discount().apply(order, rate) == expected
+`)
+  })
+})
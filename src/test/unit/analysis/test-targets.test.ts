@@ -0,0 +1,90 @@
+/**
+ * Test target ranking from coverage reports, complexity and churn
+ */
+
+import { describe, it, expect } from 'vitest'
+import { parseCoverageReport, suggestTestTargets } from '../../../analysis/test-targets.js'
+import type { TreeNode } from '../../../types/core.js'
+
+const ROOT = '/repo'
+
+const PRICING = `export function price(items: Item[], coupon?: string) {
+  let total = 0
+  for (const item of items) {
+    total += item.price
+  }
+  if (coupon) {
+    total *= 0.9
+  }
+  return total
+}
+
+export function currency(value: number) {
+  return value.toFixed(2)
+}
+`
+
+const PARSER = `func Parse(input string) (Config, error) {
+	if input == "" {
+		return Config{}, ErrEmpty
+	}
+	return decode(input)
+}
+`
+
+const LCOV = `TN:
+SF:src/pricing.ts
+DA:1,3
+DA:2,3
+DA:3,3
+DA:4,6
+DA:6,3
+DA:7,0
+DA:9,3
+BRDA:6,0,0,0
+BRDA:6,0,1,3
+DA:12,2
+DA:13,2
+end_of_record
+`
+
+function functionNode(path: string, name: string, content: string, startLine: number, endLine: number): TreeNode {
+  return { id: `${path}#${name}`, type: 'function', name, path, startLine, endLine, content }
+}
+
+describe('test target suggestions', () => {
+  const nodes = [
+    functionNode(`${ROOT}/src/pricing.ts`, 'price', PRICING.split('\n').slice(0, 10).join('\n'), 1, 10),
+    functionNode(`${ROOT}/src/pricing.ts`, 'currency', PRICING.split('\n').slice(11, 14).join('\n'), 12, 14),
+    functionNode(`${ROOT}/src/legacy.ts`, 'migrate', 'export function migrate(rows: Row[]) {\n  if (rows.length === 0) return []\n  return rows.map(upgrade)\n}', 1, 4),
+    functionNode(`${ROOT}/config/parse.go`, 'Parse', PARSER, 1, 6),
+  ]
+
+  it('should read lcov and Go cover profiles', () => {
+    const lcov = parseCoverageReport(LCOV, `${ROOT}/coverage/lcov.info`)
+    expect(lcov.format).toBe('lcov')
+    expect(lcov.files.get('src/pricing.ts')!.branches.get(6)).toEqual({ taken: 1, total: 2 })
+
+    const profile = parseCoverageReport('mode: set\nexample.com/app/config/parse.go:1.44,2.17 1 1\nexample.com/app/config/parse.go:2.17,4.3 1 0\n', `${ROOT}/coverage.out`)
+    expect(profile.format).toBe('go')
+    expect([...profile.files.get('example.com/app/config/parse.go')!.lines]).toEqual([[1, 1], [2, 1], [3, 0], [4, 0]])
+  })
+
+  it('should rank partly covered and unreported functions, weighted by churn', () => {
+    const coverage = parseCoverageReport(LCOV, `${ROOT}/coverage/lcov.info`)
+    const churn = new Map([[`${ROOT}/src/legacy.ts`, 3]])
+    const targets = suggestTestTargets(nodes, ROOT, { coverage, churn })
+
+    // Parse is not in the report, but no Go file is, so its coverage is unknown rather than zero
+    expect(targets.map(target => [target.name, target.score, target.coverage, target.commits])).toEqual([
+      ['migrate', 6, 0, 3],
+      ['Parse', 2, undefined, 0],
+      ['price', 1.33, 0.67, 0],
+    ])
+    expect(targets[2]!.targets).toEqual([
+      { line: 6, code: 'if (coupon) {', reason: 'branch not taken' },
+      { line: 7, code: 'total *= 0.9', reason: 'not run' },
+    ])
+    expect(targets[0]!.targets).toEqual([{ line: 2, code: 'if (rows.length === 0) return []', reason: 'branch not reached' }])
+  })
+})
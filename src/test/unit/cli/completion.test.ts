@@ -0,0 +1,50 @@
+/**
+ * Shell completion candidates derived from the commander program
+ */
+
+import { describe, it, expect } from 'vitest'
+import { createCLI } from '../../../cli/index.js'
+import { COMPLETION_SHELLS, completeWords, formatCompletionResult, generateCompletionScript } from '../../../cli/completion.js'
+
+describe('Shell completion', () => {
+  const program = createCLI()
+  const values = (words: string[]) => completeWords(program, words).candidates.map(candidate => candidate.value)
+
+  it('should complete visible subcommands only', () => {
+    expect(values(['an'])).toEqual(['analyze'])
+    expect(values([''])).toContain('index')
+    expect(values([''])).not.toContain('__complete')
+  })
+
+  it('should complete nested subcommands and their options', () => {
+    expect(values(['index', ''])).toEqual(['export', 'import'])
+    expect(values(['index', 'import', 'index.tsz', '--up'])).toEqual(['--update'])
+  })
+
+  it('should complete option values, including after variadic values', () => {
+    expect(values(['analyze', '--output', ''])).toEqual(['json', 'text', 'markdown'])
+    expect(values(['analyze', '-a', 'quality', 'd'])).toEqual(['deadcode'])
+    expect(values(['analyze', '-a', 'quality', '--max'])).toEqual(['--max-results'])
+  })
+
+  it('should fall back to the shell for paths', () => {
+    expect(completeWords(program, ['search', 'x', '-d', '']).fallback).toBe('dirs')
+    expect(completeWords(program, ['index', 'export', '']).fallback).toBe('files')
+    expect(formatCompletionResult(completeWords(program, ['index', 'export', '']))).toBe(':files\n')
+  })
+
+  it('should offer the default project ID of the given directory', () => {
+    expect(values(['analyze', '-d', '/work/my app', '-p', ''])).toContain('my-app')
+  })
+
+  it('should complete MCP tool names', () => {
+    expect(values(['tools', 'check_'])).toEqual(expect.arrayContaining(['check_errors', 'check_openapi']))
+    expect(values(['tools', 'check_errors', ''])).toEqual([])
+  })
+
+  it('should generate a script for every shell that calls back into the CLI', () => {
+    for (const shell of COMPLETION_SHELLS) {
+      expect(generateCompletionScript(shell)).toContain('tree-sitter-mcp __complete --')
+    }
+  })
+})
@@ -0,0 +1,127 @@
+/**
+ * Assembly labels and linker script symbols read from the source text
+ */
+
+import { describe, it, expect } from 'vitest'
+import { extractAssemblyDefinitions, extractLinkerScriptDefinitions } from '../../../core/assembly.js'
+import { getLanguageForFile } from '../../../core/languages.js'
+
+describe('extractAssemblyDefinitions', () => {
+  it('should read GNU assembler labels with their visibility, kind and extent', () => {
+    const source = `#include "asm.h"
+  .text
+/* Copy n bytes */
+  .globl memcpy
+  .type memcpy, @function
+memcpy:
+  mov %rdx, %rcx
+.Lloop:
+  rep movsb
+  jnz .Lloop
+  ret
+  .size memcpy, .-memcpy
+
+helper:
+  ret
+
+  .section .data
+counter:
+  .long 0
+
+  .equ BUFFER_SIZE, 64
+  .macro SAVE reg
+  push \\reg
+  .endm
+`
+    const nodes = extractAssemblyDefinitions(source, '/p/mem.S')
+    const byName = new Map(nodes.map(node => [node.name, node]))
+
+    expect(nodes.map(node => `${node.type}:${node.name}`)).toEqual([
+      'label:memcpy', 'label:.Lloop', 'label:helper', 'label:counter', 'constant:BUFFER_SIZE', 'macro:SAVE',
+    ])
+    expect(byName.get('memcpy')!).toMatchObject({ startLine: 6, endLine: 12 })
+    expect(byName.get('memcpy')!.symbol).toMatchObject({ kind: 'function', visibility: 'public', doc: 'Copy n bytes' })
+    expect(byName.get('.Lloop')!).toMatchObject({ startLine: 8, endLine: 11 })
+    expect(byName.get('.Lloop')!.symbol).toMatchObject({ container: 'memcpy', visibility: 'private' })
+    expect(byName.get('helper')!).toMatchObject({ startLine: 14, endLine: 15 })
+    expect(byName.get('helper')!.symbol.visibility).toBe('private')
+    expect(byName.get('counter')!.symbol.kind).toBe('variable')
+    expect(byName.get('counter')!).toMatchObject({ startLine: 18, endLine: 21 })
+    expect(byName.get('SAVE')!).toMatchObject({ startLine: 22, endLine: 24 })
+  })
+
+  it('should read NASM, MASM and kernel entry macros', () => {
+    const nasm = `global _start
+section .bss
+buf resb 16
+section .text
+; Program entry
+_start:
+  call print
+.done:
+  ret
+%macro PRINT 1
+  nop
+%endmacro
+`
+    const nodes = extractAssemblyDefinitions(nasm, '/p/start.asm')
+    expect(nodes.find(node => node.name === '_start')!.symbol).toMatchObject({ visibility: 'public', doc: 'Program entry' })
+    expect(nodes.find(node => node.name === '.done')!.symbol.container).toBe('_start')
+    expect(nodes.find(node => node.name === 'PRINT')!.type).toBe('macro')
+
+    const masm = extractAssemblyDefinitions(`PUBLIC Add2\nAdd2 PROC\n  add rcx, rdx\n  ret\nAdd2 ENDP\n`, '/p/add.asm')
+    expect(masm).toHaveLength(1)
+    expect(masm[0]).toMatchObject({ type: 'function', name: 'Add2', startLine: 2, endLine: 5 })
+    expect(masm[0]!.symbol.visibility).toBe('public')
+
+    const kernel = extractAssemblyDefinitions(`SYM_FUNC_START(clear_page)\n  rep stosq\n  RET\nSYM_FUNC_END(clear_page)\n`, '/p/clear_page.S')
+    expect(kernel[0]).toMatchObject({ name: 'clear_page', startLine: 1, endLine: 4 })
+    expect(kernel[0]!.symbol.visibility).toBe('public')
+  })
+})
+
+describe('extractLinkerScriptDefinitions', () => {
+  it('should read memory regions, output sections and assigned symbols', () => {
+    const source = `ENTRY(Reset_Handler)
+
+MEMORY
+{
+  FLASH (rx)  : ORIGIN = 0x08000000, LENGTH = 512K
+  RAM (xrw)   : ORIGIN = 0x20000000, LENGTH = 128K
+}
+
+/* Top of the stack */
+_estack = ORIGIN(RAM) + LENGTH(RAM);
+
+SECTIONS
+{
+  .text :
+  {
+    . = ALIGN(4);
+    *(.text*)
+    _etext = .;
+  } > FLASH
+
+  .bss (NOLOAD) : {
+    PROVIDE_HIDDEN(__bss_start = .);
+    *(.bss*)
+  } > RAM
+}
+`
+    const nodes = extractLinkerScriptDefinitions(source, '/p/stm32.ld')
+
+    expect(nodes.map(node => `${node.type}:${node.name}`)).toEqual([
+      'region:FLASH', 'region:RAM', 'symbol:_estack', 'section:.text', 'symbol:_etext', 'section:.bss', 'symbol:__bss_start',
+    ])
+    expect(nodes.find(node => node.name === '_estack')!.symbol).toMatchObject({ kind: 'variable', visibility: 'public', doc: 'Top of the stack' })
+    expect(nodes.find(node => node.name === '.text')!).toMatchObject({ startLine: 14, endLine: 19 })
+    expect(nodes.find(node => node.name === '_etext')!.symbol.container).toBe('.text')
+    expect(nodes.find(node => node.name === '__bss_start')!.symbol).toMatchObject({ container: '.bss', visibility: 'internal' })
+  })
+
+  it('should recognize assembly and linker script files', () => {
+    expect(getLanguageForFile('/p/boot.S')?.name).toBe('assembly')
+    expect(getLanguageForFile('/p/start.asm')?.name).toBe('assembly')
+    expect(getLanguageForFile('/p/kernel.lds')?.name).toBe('linker_script')
+  })
+})
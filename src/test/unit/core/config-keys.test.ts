@@ -0,0 +1,102 @@
+/**
+ * Configuration file key indexing
+ */
+
+import { describe, it, expect } from 'vitest'
+import { readConfigKeys, isKeyPath, keyPathAccessors } from '../../../core/config-keys.js'
+import { parseContent } from '../../../core/parser.js'
+import { searchCode, findConfigKeyUsage } from '../../../core/search.js'
+import type { TreeNode } from '../../../types/core.js'
+
+const keys = (content: string, format: 'json' | 'yaml' | 'toml') =>
+  readConfigKeys(content, format).map(key => [key.path, key.line])
+
+describe('Config keys', () => {
+  it('should read JSON keys with their paths, tolerating comments', () => {
+    const content = `{
+  // connection settings
+  "database": {
+    "pool_size": 10,
+    "replicas": [{ "host": "a" }, { "host": "b" }],
+  },
+  "debug": false
+}`
+
+    expect(keys(content, 'json')).toEqual([
+      ['database', 3],
+      ['database.pool_size', 4],
+      ['database.replicas', 5],
+      ['database.replicas[0].host', 5],
+      ['database.replicas[1].host', 5],
+      ['debug', 7],
+    ])
+  })
+
+  it('should read YAML and TOML keys', () => {
+    const yaml = `database:
+  pool_size: 10 # per worker
+servers:
+  - host: a
+    port: 80
+`
+    expect(keys(yaml, 'yaml')).toEqual([
+      ['database', 1],
+      ['database.pool_size', 2],
+      ['servers', 3],
+      ['servers[0].host', 4],
+      ['servers[0].port', 5],
+    ])
+
+    const toml = `title = "app"
+
+[database]
+pool_size = 10
+hosts = [
+  "a",
+]
+options = { timeout = 5, "retry.count" = 3 }
+
+[[workers]]
+name = "one"
+`
+    expect(keys(toml, 'toml')).toEqual([
+      ['title', 1],
+      ['database', 3],
+      ['database.pool_size', 4],
+      ['database.hosts', 5],
+      ['database.options', 8],
+      ['database.options.timeout', 8],
+      ['database.options.retry.count', 8],
+      ['workers[0]', 10],
+      ['workers[0].name', 11],
+    ])
+  })
+
+  it('should recognize key paths and their subscript spellings', () => {
+    expect(isKeyPath('database.pool_size')).toBe(true)
+    expect(isKeyPath('servers[0].host')).toBe(true)
+    expect(isKeyPath('poolSize')).toBe(false)
+    expect(keyPathAccessors('servers[0].host')).toEqual([
+      '[\'servers\'][0][\'host\']',
+      '["servers"][0]["host"]',
+      '[:servers][0][:host]',
+    ])
+  })
+
+  it('should find a key path in config files and in the code reading it', () => {
+    const config = parseContent('database:\n  pool_size: 10\n', '/p/config/app.yaml')
+    const code: TreeNode = {
+      id: 'settings',
+      type: 'file',
+      path: '/p/app/settings.py',
+      content: 'import yaml\n\nsize = config["database"]["pool_size"]\n',
+    }
+
+    const [best] = searchCode('database.pool_size', [config])
+    expect([best?.node.type, best?.node.startLine, best?.score]).toEqual(['key', 2, 100])
+    expect(findConfigKeyUsage('database.pool_size', [config, code]).map(usage => [usage.node.path, usage.startLine, usage.via])).toEqual([
+      ['/p/config/app.yaml', 2, undefined],
+      ['/p/app/settings.py', 3, '["database"]["pool_size"]'],
+    ])
+  })
+})
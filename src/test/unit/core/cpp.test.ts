@@ -0,0 +1,87 @@
+/**
+ * C/C++ templates and declarations generated by macros
+ */
+
+import { describe, it, expect, afterEach } from 'vitest'
+import { mkdtempSync, rmSync, writeFileSync } from 'fs'
+import { tmpdir } from 'os'
+import { join } from 'path'
+import { expandMacros, extractMacroDeclarations, parseTemplateParameters, readDeclarations, readMacroDefinitions } from '../../../core/cpp.js'
+
+describe('C/C++ macros and templates', () => {
+  let root: string | undefined
+
+  afterEach(() => {
+    if (root) rmSync(root, { recursive: true, force: true })
+    root = undefined
+  })
+
+  it('should read template parameters with their constraints', () => {
+    expect(parseTemplateParameters('typename T, int N = 4, std::integral U, typename... Rest')).toEqual([
+      { name: 'T', constraint: 'typename' },
+      { name: 'N', constraint: 'int' },
+      { name: 'U', constraint: 'std::integral' },
+      { name: 'Rest', constraint: 'typename...' },
+    ])
+    expect(parseTemplateParameters('template <typename> class Container, typename Alloc = std::allocator<int>')).toEqual([
+      { name: 'Container', constraint: 'template <typename> class' },
+      { name: 'Alloc', constraint: 'typename' },
+    ])
+  })
+
+  it('should expand stringizing, token pasting and nested macros', () => {
+    const macros = new Map(readMacroDefinitions(`#define NAME(x) #x
+#define GETTER(type, field) \\
+  type get_##field(const struct config *c) { return c->field; }
+#define FIELDS GETTER(int, width) GETTER(int, height)
+`).map(macro => [macro.name, macro]))
+
+    expect(expandMacros('NAME(width)', macros)).toBe('"width"')
+    expect(readDeclarations(expandMacros('FIELDS', macros)).map(declaration => [declaration.kind, declaration.name, declaration.signature])).toEqual([
+      ['function', 'get_width', 'int get_width(const struct config *c)'],
+      ['function', 'get_height', 'int get_height(const struct config *c)'],
+    ])
+  })
+
+  it('should read the declarations of expanded code', () => {
+    const code = `typedef struct { int x; } point_t;
+struct list_node { struct list_node *next; };
+static const struct command cmd_run = { "run", do_run };
+register_command(&cmd_run);
+int handle_run(int argc, char **argv);
+enum class Mode { On, Off };`
+
+    expect(readDeclarations(code).map(declaration => [declaration.kind, declaration.name])).toEqual([
+      ['type', 'point_t'],
+      ['struct', 'list_node'],
+      ['variable', 'cmd_run'],
+      ['function', 'handle_run'],
+      ['enum', 'Mode'],
+    ])
+  })
+
+  it('should index macro definitions and the declarations invocations generate', () => {
+    root = mkdtempSync(join(tmpdir(), 'ts-mcp-cpp-'))
+    writeFileSync(join(root, 'commands.h'), '#define COMMAND(name) int cmd_##name(int argc, char **argv)\n')
+    const content = `#include "commands.h"
+#define DECLARE_COUNTER(name) static int name##_count = 0;
+
+DECLARE_COUNTER(requests)
+
+COMMAND(status) {
+  return 0;
+}
+
+// COMMAND(disabled)
+`
+    const nodes = extractMacroDeclarations(content, join(root, 'main.c'))
+
+    expect(nodes.map(node => [node.type, node.name, node.startLine, node.endLine, node.symbol?.kind, node.symbol?.macro])).toEqual([
+      ['macro', 'DECLARE_COUNTER', 2, 2, 'macro', undefined],
+      ['variable', 'requests_count', 4, 4, 'variable', 'DECLARE_COUNTER'],
+      ['function', 'cmd_status', 6, 8, 'function', 'COMMAND'],
+    ])
+    expect(nodes[1]!.symbol?.visibility).toBe('internal')
+    expect(nodes[2]!.symbol?.signature).toBe('int cmd_status(int argc, char **argv)')
+  })
+})
@@ -0,0 +1,111 @@
+/**
+ * Go-to-definition through a language server, falling back to the index
+ */
+
+import { describe, it, expect, beforeAll, afterAll } from 'vitest'
+import { mkdtempSync, rmSync, writeFileSync } from 'fs'
+import { tmpdir } from 'os'
+import { join } from 'path'
+import { findDefinitions, identifierAt } from '../../../core/definitions.js'
+import { hoverAt, stopLanguageServers } from '../../../lsp/client.js'
+import { parseContent } from '../../../core/parser.js'
+import { createProject, extractAllNodes } from '../../../project/manager.js'
+import type { Project } from '../../../types/core.js'
+
+// Answers every definition request with line 2, column 6 of the file asked about
+const FAKE_SERVER = `
+let buffer = Buffer.alloc(0)
+const send = (message) => {
+  const body = Buffer.from(JSON.stringify(message))
+  process.stdout.write('Content-Length: ' + body.length + '\\r\\n\\r\\n')
+  process.stdout.write(body)
+}
+process.stdin.on('data', (chunk) => {
+  buffer = Buffer.concat([buffer, chunk])
+  for (;;) {
+    const end = buffer.indexOf('\\r\\n\\r\\n')
+    if (end < 0) return
+    const length = Number(/Content-Length: (\\d+)/.exec(buffer.subarray(0, end).toString())[1])
+    if (buffer.length < end + 4 + length) return
+    const message = JSON.parse(buffer.subarray(end + 4, end + 4 + length).toString())
+    buffer = buffer.subarray(end + 4 + length)
+    if (message.method === 'initialize') send({ jsonrpc: '2.0', id: message.id, result: { capabilities: {} } })
+    if (message.method === 'textDocument/definition') {
+      const start = { line: 1, character: 5 }
+      send({ jsonrpc: '2.0', id: message.id, result: [{ uri: message.params.textDocument.uri, range: { start, end: start } }] })
+    }
+    if (message.method === 'textDocument/hover') {
+      send({ jsonrpc: '2.0', id: message.id, result: { contents: { kind: 'markdown', value: 'func greet(name string)' } } })
+    }
+  }
+})
+`
+
+const ZIG_SOURCE = `const std = @import("std");
+
+pub fn greet(name: []const u8) void {
+    std.debug.print("hi {s}\\n", .{name});
+}
+
+pub fn main() void {
+    greet("zig");
+}
+`
+
+describe('findDefinitions', () => {
+  let root: string
+  let project: Project
+  let server: string
+
+  beforeAll(() => {
+    root = mkdtempSync(join(tmpdir(), 'ts-mcp-definitions-'))
+    server = join(root, 'fake-server.cjs')
+    writeFileSync(server, FAKE_SERVER)
+    writeFileSync(join(root, 'main.go'), 'package main\nfunc greet(name string) {}\nfunc main() { greet("go") }\n')
+
+    const zig = join(root, 'main.zig')
+    writeFileSync(zig, ZIG_SOURCE)
+    project = createProject({ directory: root })
+    const fileNode = parseContent(ZIG_SOURCE, zig)
+    project.files.set(zig, fileNode)
+    project.nodes.set(zig, extractAllNodes(fileNode))
+  })
+
+  afterAll(() => {
+    stopLanguageServers()
+    rmSync(root, { recursive: true, force: true })
+  })
+
+  it('should find the identifier around a column', () => {
+    expect(identifierAt('    greet("zig");', 6)).toBe('greet')
+    expect(identifierAt('    greet("zig");', 0)).toBeUndefined()
+  })
+
+  it('should match names in the index when no language server applies', async () => {
+    const lookup = await findDefinitions(project, join(root, 'main.zig'), 8, 6)
+    expect(lookup.name).toBe('greet')
+    expect(lookup.resolvedBy).toBe('tree-sitter')
+    expect(lookup.languageServer.status).toBe('unavailable')
+    expect(lookup.definitions.map(definition => [definition.startLine, definition.resolvedBy])).toEqual([[3, 'tree-sitter']])
+  })
+
+  it('should prefer the language server and mark what it resolved', async () => {
+    const settings = { commands: { gopls: [process.execPath, server] } }
+    const lookup = await findDefinitions(project, join(root, 'main.go'), 3, 16, { settings })
+    expect(lookup.languageServer).toEqual({ server: 'gopls', status: 'resolved' })
+    expect(lookup.resolvedBy).toBe('gopls')
+    expect(lookup.definitions).toEqual([{ name: 'greet', path: join(root, 'main.go'), startLine: 2, column: 6, resolvedBy: 'gopls' }])
+
+    const hover = await hoverAt(root, join(root, 'main.go'), 3, 16, settings)
+    expect(hover.result).toBe('func greet(name string)')
+  })
+
+  it('should fall back to the index when the server is missing or turned off', async () => {
+    const missing = await findDefinitions(project, join(root, 'main.go'), 3, 16, { settings: { commands: { gopls: [join(root, 'no-gopls')] } } })
+    expect(missing.languageServer.status).toBe('unavailable')
+    expect(missing.resolvedBy).toBe('tree-sitter')
+
+    const disabled = await findDefinitions(project, join(root, 'main.go'), 3, 16, { settings: { enabled: false } })
+    expect(disabled.languageServer).toEqual({ server: 'gopls', status: 'disabled' })
+  })
+})
@@ -0,0 +1,55 @@
+/**
+ * Dockerfile and compose file indexing
+ */
+
+import { describe, it, expect } from 'vitest'
+import { resolve } from 'path'
+import { readFileSync } from 'fs'
+import { parseDockerfile, parseComposeFile } from '../../../core/docker.js'
+import { searchCode } from '../../../core/search.js'
+import { analyzeStructure } from '../../../analysis/structure.js'
+import { createProject, parseProject, getAllNodes } from '../../../project/manager.js'
+
+describe('Container build files', () => {
+  const fixture = resolve(import.meta.dirname, '../../fixtures/docker-compose')
+
+  it('should extract stages and COPY sources from a Dockerfile', () => {
+    const { stages, copies } = parseDockerfile(readFileSync(resolve(fixture, 'api/Dockerfile'), 'utf-8'))
+
+    expect(stages.map(stage => [stage.name, stage.baseImage])).toEqual([
+      ['builder', 'node:20-alpine'],
+      [undefined, 'node:20-alpine'],
+    ])
+    expect(copies.map(copy => copy.sources[0])).toEqual(['api/package.json', 'api/src', '/app'])
+    expect(copies[2]?.from).toBe('builder')
+  })
+
+  it('should extract compose services with their build contexts', () => {
+    const services = parseComposeFile(readFileSync(resolve(fixture, 'docker-compose.yml'), 'utf-8'))
+
+    expect(services.map(service => service.name)).toEqual(['api', 'worker', 'cache'])
+    expect(services[0]?.build).toEqual({ context: '.', dockerfile: 'api/Dockerfile' })
+    expect(services[0]?.dependsOn).toEqual(['cache'])
+  })
+
+  it('should index infrastructure files so searches find services and copied paths', async () => {
+    const project = createProject({ directory: fixture })
+    await parseProject(project)
+    const nodes = getAllNodes(project)
+
+    expect(searchCode('worker', nodes, { exactMatch: true }).some(result => result.node.type === 'service')).toBe(true)
+    expect(searchCode('api/src', nodes, { exactMatch: true }).some(result => result.node.path.endsWith('api/Dockerfile'))).toBe(true)
+  })
+
+  it('should flag COPY sources missing from the build context', async () => {
+    const project = createProject({ directory: fixture })
+    await parseProject(project)
+
+    const { findings } = analyzeStructure(getAllNodes(project))
+    const missing = findings.filter(finding => finding.category === 'missing_copy_source')
+
+    // api/Dockerfile resolves its sources against the compose context, so only the worker is reported
+    expect(missing).toHaveLength(1)
+    expect(missing[0]?.location).toMatch(/worker\/Dockerfile:4$/)
+  })
+})
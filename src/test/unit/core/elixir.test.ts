@@ -0,0 +1,128 @@
+/**
+ * Elixir definitions and Phoenix routes
+ */
+
+import { describe, it, expect } from 'vitest'
+import { extractElixirDefinitions, extractPhoenixRoutes } from '../../../core/elixir.js'
+import { extractRoutes } from '../../../analysis/routes.js'
+
+const ACCOUNTS = `defmodule MyApp.Accounts do
+  @moduledoc """
+  The accounts context.
+  """
+
+  alias MyApp.Accounts.User
+
+  @doc "Fetches a user, raising if missing"
+  @spec get_user!(integer) :: User.t()
+  def get_user!(id), do: Repo.get!(User, id)
+
+  @doc false
+  def change_user(%User{} = user, attrs \\\\ %{}) do
+    # end of the world
+    User.changeset(user, attrs)
+  end
+
+  def greet(%{name: name}), do: "hi #{name} do"
+  def greet(_), do: fn -> "end" end
+
+  defp normalize(email) when is_binary(email) do
+    Enum.map([1, 2], fn x ->
+      x * 2
+    end)
+  end
+
+  defmacro with_user(do: block) do
+    quote do: unquote(block)
+  end
+
+  defmodule Policy do
+    def allowed?(%{range: range}), do: range.end > 0
+  end
+end
+
+defprotocol MyApp.Printable do
+  def print(data)
+end
+
+defimpl MyApp.Printable, for: MyApp.Accounts.User do
+  def print(user), do: user.email
+end
+`
+
+const ROUTER = `defmodule MyAppWeb.Router do
+  use MyAppWeb, :router
+
+  pipeline :browser do
+    plug :accepts, ["html"]
+  end
+
+  scope "/", MyAppWeb do
+    pipe_through :browser
+
+    get "/", PageController, :home
+    live "/dashboard", DashboardLive, :index
+    resources "/users", UserController, only: [:index, :show] do
+      resources "/posts", PostController, except: [:new, :edit, :update, :delete, :index]
+    end
+  end
+
+  scope "/api", MyAppWeb.Api, as: :api do
+    post "/login", SessionController, :create
+    resources "/profile", ProfileController, singleton: true, only: [:show]
+  end
+end
+`
+
+describe('Elixir', () => {
+  it('should index modules, functions and macros with their containers and docs', () => {
+    const nodes = extractElixirDefinitions(ACCOUNTS, '/p/lib/my_app/accounts.ex')
+    expect(nodes.map(node => [node.type, node.name, node.startLine, node.endLine, node.symbol?.container, node.symbol?.visibility])).toEqual([
+      ['class', 'MyApp.Accounts', 1, 34, undefined, 'public'],
+      ['function', 'get_user!', 10, 10, 'MyApp.Accounts', 'public'],
+      ['function', 'change_user', 13, 16, 'MyApp.Accounts', 'public'],
+      ['function', 'greet', 18, 19, 'MyApp.Accounts', 'public'],
+      ['function', 'normalize', 21, 25, 'MyApp.Accounts', 'private'],
+      ['macro', 'with_user', 27, 29, 'MyApp.Accounts', 'public'],
+      ['class', 'MyApp.Accounts.Policy', 31, 33, 'MyApp.Accounts', 'public'],
+      ['function', 'allowed?', 32, 32, 'MyApp.Accounts.Policy', 'public'],
+      ['class', 'MyApp.Printable', 36, 38, undefined, 'public'],
+      ['function', 'print', 37, 37, 'MyApp.Printable', 'public'],
+      ['class', 'MyApp.Printable.MyApp.Accounts.User', 40, 42, undefined, 'public'],
+      ['function', 'print', 41, 41, 'MyApp.Printable.MyApp.Accounts.User', 'public'],
+    ])
+
+    const byName = (name: string) => nodes.find(node => node.name === name)!.symbol
+    expect(byName('MyApp.Accounts')?.doc).toBe('The accounts context.')
+    expect(byName('get_user!')?.doc).toBe('Fetches a user, raising if missing')
+    expect(byName('get_user!')?.signature).toBe('def get_user!(id)')
+    expect(byName('change_user')?.doc).toBeUndefined()
+    expect(byName('normalize')?.signature).toBe('defp normalize(email) when is_binary(email)')
+    expect(byName('MyApp.Printable')?.kind).toBe('interface')
+  })
+
+  it('should resolve Phoenix routes through scopes and resources', () => {
+    expect(extractPhoenixRoutes(ROUTER).map(route => [route.method, route.path, route.line, `${route.controller}.${route.action}`])).toEqual([
+      ['GET', '/', 11, 'MyAppWeb.PageController.home'],
+      ['GET', '/dashboard', 12, 'MyAppWeb.DashboardLive.index'],
+      ['GET', '/users', 13, 'MyAppWeb.UserController.index'],
+      ['GET', '/users/:id', 13, 'MyAppWeb.UserController.show'],
+      ['GET', '/users/:user_id/posts/:id', 14, 'MyAppWeb.PostController.show'],
+      ['POST', '/users/:user_id/posts', 14, 'MyAppWeb.PostController.create'],
+      ['POST', '/api/login', 19, 'MyAppWeb.Api.SessionController.create'],
+      ['GET', '/api/profile', 20, 'MyAppWeb.Api.ProfileController.show'],
+    ])
+  })
+
+  it('should index router routes by path and report them as Phoenix routes', () => {
+    const routes = extractElixirDefinitions(ROUTER, '/p/lib/my_app_web/router.ex').filter(node => node.type === 'route')
+    expect(routes[0]).toMatchObject({ name: '/', startLine: 11, symbol: { signature: 'GET /', handler: 'MyAppWeb.PageController.home' } })
+
+    const definitions = extractRoutes([
+      { id: 'router', type: 'file', path: '/p/lib/my_app_web/router.ex', content: ROUTER },
+      { id: 'accounts', type: 'file', path: '/p/lib/my_app/accounts.ex', content: 'defmodule A do\n  def get(conn), do: conn\nend\n  get "/not-a-route", X, :y\n' },
+    ])
+    expect(definitions).toHaveLength(8)
+    expect(definitions[0]).toEqual({ method: 'GET', path: '/', file: '/p/lib/my_app_web/router.ex', line: 11, handler: 'MyAppWeb.PageController.home', framework: 'phoenix' })
+  })
+})
@@ -0,0 +1,92 @@
+/**
+ * Low-confidence indexing of files no language claims
+ */
+
+import { describe, it, expect } from 'vitest'
+import { extractFallbackDefinitions } from '../../../core/fallback.js'
+import { parseContent } from '../../../core/parser.js'
+import { searchCode } from '../../../core/search.js'
+
+describe('extractFallbackDefinitions', () => {
+  it('should guess routines and types with indentation blocks', () => {
+    const source = `# Tokenizer for the config language
+class Lexer
+  def initialize(text)
+    @text = text
+  end
+
+  private def advance
+    @pos += 1
+  end
+end
+
+# Reads one token
+function readToken(stream)
+  return stream.next()
+end
+`
+    const nodes = extractFallbackDefinitions(source, '/p/lexer.foo')
+
+    expect(nodes.map(node => [node.type, node.name, node.startLine, node.endLine])).toEqual([
+      ['class', 'Lexer', 2, 10],
+      ['method', 'initialize', 3, 5],
+      ['method', 'advance', 7, 9],
+      ['function', 'readToken', 13, 15],
+    ])
+    expect(nodes[0]!.symbol).toMatchObject({ kind: 'class', confidence: 'low', doc: 'Tokenizer for the config language' })
+    expect(nodes[1]!.symbol!.container).toBe('Lexer')
+    expect(nodes[2]!.symbol!.visibility).toBe('private')
+    expect(nodes[3]!.symbol!.signature).toBe('function readToken(stream)')
+  })
+
+  it('should read C-like headers that open a block, but not calls or control flow', () => {
+    const source = `static int Parser::parse(const char *text)
+{
+    if (text == NULL) {
+        return -1;
+    }
+    log_message("parse");
+    return 0;
+}
+
+int prototype(int value);
+`
+    const nodes = extractFallbackDefinitions(source, '/p/parser.cxx2')
+
+    expect(nodes).toHaveLength(1)
+    expect(nodes[0]).toMatchObject({ type: 'function', name: 'parse', startLine: 1, endLine: 8 })
+    expect(nodes[0]!.symbol).toMatchObject({ container: 'Parser', confidence: 'low' })
+  })
+
+  it('should read Lisp definition forms', () => {
+    const nodes = extractFallbackDefinitions(`(defun square (x)
+  (* x x))
+
+(defmacro unless-nil (form) form)
+`, '/p/math.lsp2')
+
+    expect(nodes.map(node => [node.type, node.name, node.startLine, node.endLine])).toEqual([
+      ['function', 'square', 1, 2],
+      ['macro', 'unless-nil', 4, 4],
+    ])
+  })
+})
+
+describe('fallback indexing', () => {
+  it('should index unknown languages but leave data and binary files as text', () => {
+    expect(parseContent('fn main() {\n  run()\n}\n', '/p/main.xyz').children?.map(node => node.name)).toEqual(['main'])
+    expect(parseContent('function,count\nparse,3\n', '/p/data.csv').children).toBeUndefined()
+    expect(parseContent('function run\u0000()', '/p/blob.bin').children).toBeUndefined()
+  })
+
+  it('should rank guessed elements below parsed ones', () => {
+    const guessed = parseContent('fn loadConfig() {\n}\n', '/p/config.xyz')
+    const parsed = parseContent('pub fn loadConfig() void {\n}\n', '/p/config.zig')
+
+    const results = searchCode('loadConfig', [guessed, parsed])
+    expect(results.map(result => [result.node.path, result.score])).toEqual([
+      ['/p/config.zig', 100],
+      ['/p/config.xyz', 80],
+    ])
+  })
+})
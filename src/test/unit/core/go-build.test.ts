@@ -0,0 +1,72 @@
+/**
+ * Go build constraints from directives and file names, and the platform variants of symbols
+ */
+
+import { describe, it, expect } from 'vitest'
+import { createGoBuildIndex, matchesBuildTags, readBuildConstraint } from '../../../core/go-build.js'
+import type { TreeNode } from '../../../types/core.js'
+
+function file(path: string, content: string): TreeNode {
+  return { id: path, type: 'file', path, content }
+}
+
+function declaration(path: string, name: string, type = 'function'): TreeNode {
+  return { id: `${path}:${name}`, type, name, path, startLine: 3 }
+}
+
+describe('Go build constraints', () => {
+  it('should combine go:build lines, legacy +build lines and file name suffixes', () => {
+    expect(readBuildConstraint('poll/fd_linux.go', 'package poll\n')).toEqual({ expression: 'linux', suffix: '_linux' })
+    expect(readBuildConstraint('poll/fd_windows_arm64_test.go', 'package poll\n')).toEqual({ expression: 'windows && arm64', suffix: '_windows_arm64' })
+    expect(readBuildConstraint('poll/fd_posix.go', '// Copyright\n\n//go:build unix || (js && wasm)\n\npackage poll\n')).toEqual({
+      expression: 'unix || (js && wasm)',
+      directive: 'unix || (js && wasm)',
+    })
+    expect(readBuildConstraint('poll/fd_unix.go', '// +build linux,cgo darwin\n// +build !race\n\npackage poll\n')?.expression).toBe('((linux && cgo) || darwin) && !race')
+    expect(readBuildConstraint('poll/sock_linux.go', '//go:build !android\n\npackage poll\n')?.expression).toBe('linux && !android')
+    expect(readBuildConstraint('linux.go', 'package poll\n')).toBeUndefined()
+    expect(readBuildConstraint('poll/fd.go', 'package poll\n\n//go:build ignore\n')).toBeUndefined()
+  })
+
+  it('should match tags, treating an operating system or architecture left out as any', () => {
+    const constraint = (path: string, content = 'package x\n') => readBuildConstraint(path, content)
+    expect(matchesBuildTags(constraint('fd_linux_arm64.go'), 'linux')).toBe(true)
+    expect(matchesBuildTags(constraint('fd_linux_arm64.go'), 'linux/amd64')).toBe(false)
+    expect(matchesBuildTags(constraint('fd_windows.go'), 'linux')).toBe(false)
+    expect(matchesBuildTags(constraint('fd_unix.go', '//go:build unix\npackage x\n'), 'darwin')).toBe(true)
+    expect(matchesBuildTags(constraint('fd_other.go', '//go:build !linux && !windows\npackage x\n'), 'linux')).toBe(false)
+    expect(matchesBuildTags(constraint('e2e_test.go', '//go:build integration && go1.21\npackage x\n'), 'linux')).toBe(false)
+    expect(matchesBuildTags(constraint('e2e_test.go', '//go:build integration && go1.21\npackage x\n'), 'linux,integration')).toBe(true)
+    expect(matchesBuildTags(undefined, 'windows')).toBe(true)
+  })
+
+  it('should list the other build files of the package declaring the same symbol', () => {
+    const nodes = [
+      file('/app/poll/fd_linux.go', 'package poll\n'),
+      file('/app/poll/fd_windows.go', 'package poll\n'),
+      file('/app/poll/fd_other.go', '//go:build !linux && !windows\n\npackage poll\n'),
+      file('/app/poll/fd.go', 'package poll\n'),
+      file('/app/net/fd_linux.go', 'package net\n'),
+      declaration('/app/poll/fd_linux.go', 'open'),
+      declaration('/app/poll/fd_windows.go', 'open'),
+      declaration('/app/poll/fd_other.go', 'open'),
+      declaration('/app/poll/fd.go', 'Close'),
+      declaration('/app/net/fd_linux.go', 'open'),
+    ]
+    const index = createGoBuildIndex(nodes)
+    expect(index.describe(nodes[5]!)).toEqual({
+      constraint: 'linux',
+      variants: [
+        { path: '/app/poll/fd_windows.go', constraint: 'windows' },
+        { path: '/app/poll/fd_other.go', constraint: '!linux && !windows' },
+      ],
+    })
+    expect(index.describe(nodes[8]!)).toBeUndefined()
+    expect(nodes.filter(node => index.matches(node.path, 'windows')).map(node => node.path)).toEqual([
+      '/app/poll/fd_windows.go',
+      '/app/poll/fd.go',
+      '/app/poll/fd_windows.go',
+      '/app/poll/fd.go',
+    ])
+  })
+})
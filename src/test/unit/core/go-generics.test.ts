@@ -0,0 +1,56 @@
+/**
+ * Go type parameters and instantiations
+ */
+
+import { describe, it, expect } from 'vitest'
+import { readGoTypeParameters, parseTypeParameterList, readGoTypeArguments } from '../../../core/go-generics.js'
+import { findUsage } from '../../../core/search.js'
+import type { TreeNode } from '../../../types/core.js'
+
+describe('Go generics', () => {
+  it('should read type parameters with shared and composite constraints', () => {
+    expect(readGoTypeParameters('func Map[T, U any](xs []T, f func(T) U) []U', 'Map')).toEqual([
+      { name: 'T', constraint: 'any' },
+      { name: 'U', constraint: 'any' },
+    ])
+    expect(readGoTypeParameters('type Tree[K cmp.Ordered, V interface{ Clone() V }] struct', 'Tree')).toEqual([
+      { name: 'K', constraint: 'cmp.Ordered' },
+      { name: 'V', constraint: 'interface{ Clone() V }' },
+    ])
+    expect(parseTypeParameterList('S ~[]E, E comparable')).toEqual([
+      { name: 'S', constraint: '~[]E' },
+      { name: 'E', constraint: 'comparable' },
+    ])
+    expect(readGoTypeParameters('type Digest [32]byte', 'Digest')).toEqual([])
+    expect(parseTypeParameterList('N')).toEqual([])
+  })
+
+  it('should tell type arguments from index and slice expressions', () => {
+    const argumentsAt = (text: string) => readGoTypeArguments(text, text.indexOf('['))
+
+    expect(argumentsAt('Max[float64](a, b)')).toEqual(['float64'])
+    expect(argumentsAt('NewCache[string, map[string]*User]()')).toEqual(['string', 'map[string]*User'])
+    expect(argumentsAt('Pair[pkg.ID, []byte]{}')).toEqual(['pkg.ID', '[]byte'])
+    expect(argumentsAt('xs[0]')).toBeUndefined()
+    expect(argumentsAt('xs[1:]')).toBeUndefined()
+    expect(argumentsAt('m["key"]')).toBeUndefined()
+    expect(argumentsAt('xs[i*2]')).toBeUndefined()
+    expect(argumentsAt('Max(a, b)')).toBeUndefined()
+  })
+
+  it('should attach type arguments to usages of a generic', () => {
+    const file: TreeNode = {
+      id: 'main',
+      type: 'file',
+      path: '/p/main.go',
+      content: 'package main\n\nvar a = Max[int](1, 2)\nvar b = Max(1.5, 2.5)\nvar c = Max[float64](1, 2)\n',
+    }
+
+    expect(findUsage('Max', [file], { generic: true }).map(usage => [usage.startLine, usage.typeArguments])).toEqual([
+      [3, ['int']],
+      [4, undefined],
+      [5, ['float64']],
+    ])
+    expect(findUsage('Max', [file]).every(usage => usage.typeArguments === undefined)).toBe(true)
+  })
+})
@@ -0,0 +1,94 @@
+/**
+ * Groovy sources, Jenkins pipelines and Gradle Groovy DSL builds
+ */
+
+import { describe, it, expect } from 'vitest'
+import { extractGroovyDefinitions } from '../../../core/groovy.js'
+import { getLanguageForFile } from '../../../core/languages.js'
+
+describe('extractGroovyDefinitions', () => {
+  it('should name Jenkins pipeline stages, including nested parallel ones', () => {
+    const source = `@Library('shared') _
+
+pipeline {
+  agent any
+  stages {
+    stage('Build') {
+      steps {
+        sh 'make build'
+      }
+    }
+    stage("Test") {
+      parallel {
+        stage('Unit') { steps { sh 'make test' } }
+        stage('Lint') { steps { sh "make lint // not a comment" } }
+      }
+    }
+  }
+}
+
+def notify(String status) {
+  echo "Build \${status}"
+}
+`
+    const nodes = extractGroovyDefinitions(source, '/p/Jenkinsfile')
+
+    expect(nodes.map(node => [node.type, node.name, node.startLine, node.endLine, node.symbol?.container])).toEqual([
+      ['stage', 'Build', 6, 10, undefined],
+      ['stage', 'Test', 11, 16, undefined],
+      ['stage', 'Unit', 13, 13, 'Test'],
+      ['stage', 'Lint', 14, 14, 'Test'],
+      ['function', 'notify', 20, 22, undefined],
+    ])
+    expect(nodes[0]!.symbol?.signature).toBe(`stage('Build')`)
+  })
+
+  it('should read classes, methods and Gradle tasks', () => {
+    const source = `/** Copies generated docs. */
+task copyDocs(type: Copy) {
+  from 'build/docs'
+}
+
+tasks.register('integrationTest', Test) {
+  useJUnitPlatform()
+}
+
+class Versions {
+  static String current() {
+    return '1.0'
+  }
+
+  private def bump(int part) {
+    if (part > 0) {
+      return part
+    }
+  }
+}
+`
+    const nodes = extractGroovyDefinitions(source, '/p/publish.gradle')
+
+    expect(nodes.map(node => [node.type, node.name, node.startLine, node.endLine, node.symbol?.visibility])).toEqual([
+      ['task', 'copyDocs', 2, 4, 'public'],
+      ['task', 'integrationTest', 6, 8, 'public'],
+      ['class', 'Versions', 10, 20, 'public'],
+      ['method', 'current', 11, 13, 'public'],
+      ['method', 'bump', 15, 19, 'private'],
+    ])
+    expect(nodes[0]!.symbol?.doc).toBe('Copies generated docs.')
+    expect(nodes[0]!.symbol?.signature).toBe('task copyDocs(type: Copy)')
+    expect(nodes[3]!.symbol?.container).toBe('Versions')
+  })
+
+  it('should index Jenkinsfiles as Groovy and keep build.gradle a Gradle build', () => {
+    expect(getLanguageForFile('/p/Jenkinsfile')?.name).toBe('groovy')
+    expect(getLanguageForFile('/p/ci/release.jenkinsfile')?.name).toBe('groovy')
+    expect(getLanguageForFile('/p/src/Util.groovy')?.name).toBe('groovy')
+    expect(getLanguageForFile('/p/build.gradle')?.name).toBe('gradle')
+
+    const nodes = getLanguageForFile('/p/build.gradle')!.extractElements!(`dependencies {\n  implementation 'com.google.guava:guava:33.0.0-jre'\n}\n\ntask hello {\n  doLast { println 'hi' }\n}\n`, '/p/build.gradle')
+    expect(nodes.map(node => [node.type, node.name])).toEqual([
+      ['dependency', 'com.google.guava:guava'],
+      ['task', 'hello'],
+    ])
+  })
+})
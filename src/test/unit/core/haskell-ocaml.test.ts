@@ -0,0 +1,176 @@
+/**
+ * Haskell and OCaml definitions
+ */
+
+import { describe, it, expect } from 'vitest'
+import { extractHaskellDefinitions } from '../../../core/haskell.js'
+import { extractOCamlDefinitions } from '../../../core/ocaml.js'
+import { readImports } from '../../../analysis/chunks.js'
+
+const HASKELL = `{-# LANGUAGE GADTs #-}
+-- | Shapes and their areas.
+module Data.Shape
+  ( Shape(..)
+  , Named (name)
+  , area
+  , (<+>)
+  ) where
+
+import qualified Data.Map as M
+import Data.List (sortBy, nub)
+
+-- | A plane figure.
+data Shape
+  = Circle Double
+  | Rect { width :: Double, height :: Double }
+  deriving (Show, Eq)
+
+type Point = (Double, Double)
+
+class Named a where
+  -- | The display name.
+  name :: a -> String
+  label
+    :: a
+    -> String
+
+-- | The area of a shape.
+area :: Shape -> Double
+area (Circle r) = pi * r * r
+area (Rect w h) = w * h
+
+(<+>) :: Point -> Point -> Point
+(x1, y1) <+> (x2, y2) = (x1 + x2, y1 + y2)
+
+origin :: Point
+origin = (0, 0)
+
+helper x
+  | x > 0 = x
+  | otherwise = foldl' (+) 0 [x] -- not a declaration: area = 1
+
+data Expr a where
+  IntE :: Int -> Expr Int
+  Add :: Expr Int
+      -> Expr Int -> Expr Int
+`
+
+const OCAML = `open Printf
+module SMap = Map.Make (String)
+
+(** A plane figure. *)
+type shape =
+  | Circle of float
+  | Rect of { width : float; height : float }
+
+exception Invalid_shape of string
+
+(** The area of a shape. *)
+let area = function
+  | Circle r -> Float.pi *. r *. r
+  | Rect { width; height } -> width *. height
+
+let origin = (0., 0.)
+
+let rec size t =
+  let sub = function [] -> 0 | _ :: r -> size r + 1 in
+  sub t
+and depth ?(acc = 0) t = acc
+
+module type SHAPE = sig
+  type t
+  val area : t -> float
+end
+
+module Make (S : SHAPE) = struct
+  let describe s = sprintf "%s: %f (* no comment *)" "shape" (S.area s)
+
+  module Inner = struct
+    let twice x = x * 2
+  end
+end
+
+class counter init = object
+  val mutable count = init
+  method incr = count <- count + 1
+end
+
+let () =
+  print_endline (string_of_float (area (Circle 1.)))
+`
+
+describe('Haskell', () => {
+  it('should index functions, types, classes and constructors with export visibility', () => {
+    const nodes = extractHaskellDefinitions(HASKELL, '/p/src/Data/Shape.hs')
+    expect(nodes.map(node => [node.type, node.name, node.startLine, node.endLine, node.symbol?.container, node.symbol?.visibility])).toEqual([
+      ['module', 'Data.Shape', 3, 8, undefined, 'public'],
+      ['class', 'Shape', 14, 17, undefined, 'public'],
+      ['variant', 'Circle', 15, 15, 'Shape', 'public'],
+      ['variant', 'Rect', 16, 16, 'Shape', 'public'],
+      ['class', 'Point', 19, 19, undefined, 'private'],
+      ['class', 'Named', 21, 26, undefined, 'public'],
+      ['method', 'name', 23, 23, 'Named', 'public'],
+      ['method', 'label', 24, 26, 'Named', 'private'],
+      ['function', 'area', 29, 31, undefined, 'public'],
+      ['function', '(<+>)', 33, 34, undefined, 'public'],
+      ['variable', 'origin', 36, 37, undefined, 'private'],
+      ['function', 'helper', 39, 41, undefined, 'private'],
+      ['class', 'Expr', 43, 46, undefined, 'private'],
+      ['variant', 'IntE', 44, 44, 'Expr', 'private'],
+      ['variant', 'Add', 45, 46, 'Expr', 'private'],
+    ])
+
+    const byName = (name: string) => nodes.find(node => node.name === name)!.symbol
+    expect(byName('Data.Shape')?.doc).toBe('Shapes and their areas.')
+    expect(byName('Shape')).toMatchObject({ kind: 'type', signature: 'data Shape', doc: 'A plane figure.' })
+    expect(byName('Named')?.kind).toBe('trait')
+    expect(byName('name')?.doc).toBe('The display name.')
+    expect(byName('Point')?.signature).toBe('type Point = (Double, Double)')
+    expect(byName('area')).toMatchObject({ signature: 'area :: Shape -> Double', doc: 'The area of a shape.' })
+    expect(byName('Add')?.signature).toBe('Add :: Expr Int -> Expr Int -> Expr Int')
+  })
+
+  it('should read qualified and listed imports', () => {
+    expect(readImports(HASKELL, 'haskell')).toEqual([
+      { module: 'Data.Map', names: ['M'] },
+      { module: 'Data.List', names: ['sortBy', 'nub'] },
+    ])
+  })
+})
+
+describe('OCaml', () => {
+  it('should index structure items with their enclosing modules and classes', () => {
+    const nodes = extractOCamlDefinitions(OCAML, '/p/lib/shape.ml')
+    expect(nodes.map(node => [node.type, node.name, node.startLine, node.endLine, node.symbol?.kind, node.symbol?.container])).toEqual([
+      ['module', 'SMap', 2, 2, 'module', undefined],
+      ['class', 'shape', 5, 7, 'enum', undefined],
+      ['variant', 'Circle', 6, 6, 'variant', 'shape'],
+      ['variant', 'Rect', 7, 7, 'variant', 'shape'],
+      ['class', 'Invalid_shape', 9, 9, 'type', undefined],
+      ['function', 'area', 12, 14, 'function', undefined],
+      ['variable', 'origin', 16, 16, 'variable', undefined],
+      ['function', 'size', 18, 20, 'function', undefined],
+      ['function', 'depth', 21, 21, 'function', undefined],
+      ['module', 'SHAPE', 23, 26, 'interface', undefined],
+      ['class', 't', 24, 24, 'type', 'SHAPE'],
+      ['function', 'area', 25, 25, 'function', 'SHAPE'],
+      ['module', 'Make', 28, 34, 'module', undefined],
+      ['function', 'describe', 29, 29, 'function', 'Make'],
+      ['module', 'Inner', 31, 33, 'module', 'Make'],
+      ['function', 'twice', 32, 32, 'function', 'Make.Inner'],
+      ['class', 'counter', 36, 39, 'class', undefined],
+      ['method', 'incr', 38, 38, 'method', 'counter'],
+    ])
+
+    const byName = (name: string) => nodes.find(node => node.name === name)!.symbol
+    expect(byName('area')).toMatchObject({ signature: 'let area', doc: 'The area of a shape.' })
+    expect(byName('depth')?.signature).toBe('and depth ?(acc = 0) t')
+    expect(byName('Make')?.signature).toBe('module Make (S : SHAPE)')
+  })
+
+  it('should read opened modules and module aliases', () => {
+    expect(readImports(OCAML, 'ocaml')).toEqual([
+      { module: 'Printf', names: ['Printf'] },
+    ])
+  })
+})
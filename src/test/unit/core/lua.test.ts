@@ -0,0 +1,113 @@
+/**
+ * Lua definitions and Neovim plugin conventions
+ */
+
+import { describe, it, expect } from 'vitest'
+import { extractLuaDefinitions, luaModuleName, maskLua } from '../../../core/lua.js'
+
+const PLUGIN = `local M = {}
+local H = {}
+
+local config = require('myplugin.config')
+
+--- Configures the plugin.
+---@param opts table|nil
+function M.setup(opts)
+  config.apply(opts or {}) -- end of setup? no
+  if opts and opts.keymaps then
+    for _, map in ipairs(opts.keymaps) do
+      M.bind(map)
+    end
+  end
+end
+
+function M:toggle()
+  local s = [[ function not_a_function() end ]]
+  repeat
+    self.count = (self.count or 0) + 1
+  until self.count > 1
+end
+
+local function normalize(path)
+  return (path:gsub('\\\\', '/'))
+end
+
+H.format = function(buf)
+  --[==[ end ]==]
+  return buf
+end
+
+return M
+`
+
+const CONFIG = `local map = vim.keymap.set
+local group = vim.api.nvim_create_augroup('FormatOnSave', { clear = true })
+
+map('n', '<leader>ff', require('telescope.builtin').find_files, { desc = 'Find files' })
+vim.keymap.set({ 'n', 'v' }, '<leader>y', '"+y')
+vim.api.nvim_set_keymap('i', 'jk', '<Esc>', { noremap = true })
+vim.api.nvim_buf_set_keymap(0, 'n', 'K', '<cmd>lua vim.lsp.buf.hover()<CR>', {})
+
+vim.api.nvim_create_autocmd({ 'BufWritePre', 'InsertLeave' }, {
+  group = group,
+  pattern = '*.go',
+  desc = 'Format Go files',
+  callback = function(args)
+    vim.lsp.buf.format({ bufnr = args.buf })
+  end,
+})
+
+vim.api.nvim_create_autocmd('TextYankPost', { command = 'silent! lua vim.highlight.on_yank()' })
+
+vim.api.nvim_create_user_command('Scratch', function(opts)
+  vim.cmd('enew')
+end, { desc = 'Open a scratch buffer', nargs = '?' })
+`
+
+describe('Lua', () => {
+  it('should derive require names from paths below lua/', () => {
+    expect(luaModuleName('/p/lua/myplugin/init.lua')).toBe('myplugin')
+    expect(luaModuleName('/p/lua/myplugin/util/path.lua')).toBe('myplugin.util.path')
+    expect(luaModuleName('/p/plugin/myplugin.lua')).toBeUndefined()
+  })
+
+  it('should mask comments and strings without moving offsets', () => {
+    const code = 'local s = "end" -- end\nlocal t = [==[ end ]==]\n'
+    const masked = maskLua(code)
+    expect(masked).toHaveLength(code.length)
+    expect(masked).not.toMatch(/\bend\b/)
+    expect(masked.split('\n')).toHaveLength(3)
+  })
+
+  it('should index functions under the module they are defined on', () => {
+    const nodes = extractLuaDefinitions(PLUGIN, '/p/lua/myplugin/init.lua')
+    expect(nodes.map(node => [node.name, node.startLine, node.endLine, node.symbol?.kind, node.symbol?.container, node.symbol?.visibility])).toEqual([
+      ['setup', 8, 15, 'function', 'myplugin', 'public'],
+      ['toggle', 17, 22, 'method', 'myplugin', 'public'],
+      ['normalize', 24, 26, 'function', undefined, 'private'],
+      ['format', 28, 31, 'function', 'H', 'private'],
+    ])
+    expect(nodes[0]!.symbol?.doc).toBe('Configures the plugin.')
+    expect(nodes[0]!.symbol?.signature).toBe('function M.setup(opts)')
+    expect(nodes[0]!.path).toBe('/p/lua/myplugin/init.lua')
+  })
+
+  it('should index keymaps, autocommands and user commands', () => {
+    const nodes = extractLuaDefinitions(CONFIG, '/p/init.lua')
+    expect(nodes.map(node => [node.type, node.name, node.startLine, node.symbol?.signature, node.symbol?.handler])).toEqual([
+      ['keymap', '<leader>ff', 4, 'n <leader>ff', undefined],
+      ['keymap', '<leader>y', 5, 'n,v <leader>y', '"+y'],
+      ['keymap', 'jk', 6, 'i jk', '<Esc>'],
+      ['keymap', 'K', 7, 'n K', '<cmd>lua vim.lsp.buf.hover()<CR>'],
+      ['autocmd', 'BufWritePre', 9, 'autocmd BufWritePre *.go', undefined],
+      ['autocmd', 'InsertLeave', 9, 'autocmd InsertLeave *.go', undefined],
+      ['autocmd', 'TextYankPost', 18, 'autocmd TextYankPost', 'silent! lua vim.highlight.on_yank()'],
+      ['command', 'Scratch', 20, ':Scratch', undefined],
+    ])
+
+    const byName = (name: string) => nodes.find(node => node.name === name)!
+    expect(byName('<leader>ff').symbol?.doc).toBe('Find files')
+    expect(byName('BufWritePre')).toMatchObject({ endLine: 16, symbol: { container: 'FormatOnSave', doc: 'Format Go files' } })
+    expect(byName('Scratch')).toMatchObject({ endLine: 22, symbol: { doc: 'Open a scratch buffer' } })
+  })
+})
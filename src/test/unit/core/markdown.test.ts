@@ -0,0 +1,96 @@
+/**
+ * Markdown and LaTeX structure, and the code examples they embed
+ */
+
+import { describe, it, expect } from 'vitest'
+import { extractMarkdownStructure } from '../../../core/markdown.js'
+import { extractLatexStructure } from '../../../core/latex.js'
+import { getLanguageForFile } from '../../../core/languages.js'
+
+const GUIDE = `---
+title: Guide
+---
+# Parser guide
+
+Parsing files into trees.
+
+## Usage
+
+\`\`\`zig
+pub fn loadTree(path: []const u8) !Tree {
+    return parseFile(path);
+}
+\`\`\`
+
+\`\`\`json
+{ "name": "config" }
+\`\`\`
+
+Options
+-------
+
+Use \`maxDepth\` to limit the walk.
+`
+
+describe('extractMarkdownStructure', () => {
+  it('should read headings with their sections and nesting', () => {
+    const nodes = extractMarkdownStructure(GUIDE, '/p/docs/guide.md')
+    const headings = nodes.filter(node => node.type === 'heading')
+
+    expect(headings.map(node => node.name)).toEqual(['Parser guide', 'Usage', 'Options'])
+    expect(headings[0]).toMatchObject({ startLine: 4, endLine: 23 })
+    expect(headings[0]!.symbol).toMatchObject({ kind: 'module', doc: 'Parsing files into trees.' })
+    expect(headings[1]).toMatchObject({ startLine: 8, endLine: 18 })
+    expect(headings[1]!.symbol!.container).toBe('Parser guide')
+    expect(headings[2]).toMatchObject({ startLine: 20, endLine: 23 })
+    expect(headings[2]!.symbol!.container).toBe('Parser guide')
+  })
+
+  it('should index code blocks and parse the examples in their language', () => {
+    const nodes = extractMarkdownStructure(GUIDE, '/p/docs/guide.md')
+
+    expect(nodes.filter(node => node.type === 'code_block').map(node => [node.name, node.startLine, node.endLine])).toEqual([
+      ['zig', 10, 14],
+      ['json', 16, 18],
+    ])
+    const example = nodes.find(node => node.name === 'loadTree')
+    expect(example).toMatchObject({ type: 'function', path: '/p/docs/guide.md', startLine: 11, endLine: 13 })
+    // Data formats are shown, not declared
+    expect(nodes.some(node => node.type === 'key')).toBe(false)
+  })
+
+  it('should recognize documentation files', () => {
+    expect(getLanguageForFile('/p/README.md')?.name).toBe('markdown')
+    expect(getLanguageForFile('/p/paper.tex')?.name).toBe('latex')
+  })
+})
+
+describe('extractLatexStructure', () => {
+  it('should read sections, labels, macros and listings', () => {
+    const source = `\\documentclass{article}
+\\newcommand{\\norm}[1]{\\left\\lVert#1\\right\\rVert}
+\\begin{document}
+\\section{Introduction}\\label{sec:intro}
+% \\section{Commented out}
+\\subsection{The \\texttt{solve} routine}
+\\begin{lstlisting}[language=Lua]
+function solve(matrix)
+  return matrix -- 100% correct
+end
+\\end{lstlisting}
+\\section*{Appendix}
+\\end{document}
+`
+    const nodes = extractLatexStructure(source, '/p/paper.tex')
+
+    expect(nodes.filter(node => node.type === 'heading').map(node => [node.name, node.startLine, node.endLine])).toEqual([
+      ['Introduction', 4, 11],
+      ['The solve routine', 6, 11],
+      ['Appendix', 12, 13],
+    ])
+    expect(nodes.find(node => node.type === 'label')).toMatchObject({ name: 'sec:intro', symbol: { container: 'Introduction' } })
+    expect(nodes.find(node => node.type === 'macro')).toMatchObject({ name: 'norm', startLine: 2, endLine: 2 })
+    expect(nodes.find(node => node.type === 'code_block')).toMatchObject({ name: 'lua', startLine: 7, endLine: 11 })
+    expect(nodes.find(node => node.name === 'solve' && node.type === 'function')?.startLine).toBe(8)
+  })
+})
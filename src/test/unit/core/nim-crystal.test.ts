@@ -0,0 +1,164 @@
+/**
+ * Nim and Crystal declarations read from the source text
+ */
+
+import { describe, it, expect } from 'vitest'
+import { extractNimDefinitions } from '../../../core/nim.js'
+import { extractCrystalDefinitions } from '../../../core/crystal.js'
+import { LANGUAGE_CONFIGS, getLanguageForFile } from '../../../core/languages.js'
+import { ALL_LOGIC_EXTENSIONS, OPTIONAL_GRAMMAR_PACKAGES, PARSER_NAMES } from '../../../constants/index.js'
+
+describe('extractNimDefinitions', () => {
+  it('should read routines, type sections and export markers', () => {
+    const source = `import std/[strutils, os]
+
+type
+  Shape* = ref object of RootObj
+    name*: string
+  Color = enum
+    red, green,
+    blue = "b"
+  Meters* = distinct float
+
+proc area*(s: Shape,
+           scale = 1.0): float =
+  ## The area of a shape.
+  proc helper(): int = 1
+  result = 0.0
+
+method draw(s: var Shape) {.base.} =
+  echo "# not a comment"
+
+template twice*(body: untyped) =
+  body
+  body
+
+func forward(x: int): int
+`
+    const nodes = extractNimDefinitions(source, '/p/shapes.nim')
+
+    expect(nodes.map(node => [node.type, node.name, node.startLine, node.endLine, node.symbol?.visibility])).toEqual([
+      ['class', 'Shape', 4, 5, 'public'],
+      ['class', 'Color', 6, 8, 'internal'],
+      ['variant', 'red', 7, 7, 'internal'],
+      ['variant', 'green', 7, 7, 'internal'],
+      ['variant', 'blue', 8, 8, 'internal'],
+      ['type', 'Meters', 9, 9, 'public'],
+      ['function', 'area', 11, 15, 'public'],
+      ['method', 'draw', 17, 18, 'internal'],
+      ['macro', 'twice', 20, 22, 'public'],
+      ['function', 'forward', 24, 24, 'internal'],
+    ])
+    expect(nodes[0]!.symbol?.kind).toBe('class')
+    expect(nodes[6]!.symbol?.signature).toBe('proc area*(s: Shape, scale = 1.0): float')
+    expect(nodes[6]!.symbol?.doc).toBe('The area of a shape.')
+    expect(nodes[7]!.symbol?.container).toBe('Shape')
+    expect(nodes[9]!.symbol?.signature).toBe('func forward(x: int): int')
+  })
+
+  it('should read a type declared on the type line', () => {
+    const nodes = extractNimDefinitions('type Point* = object\n  x, y: float\n', '/p/point.nim')
+
+    expect(nodes.map(node => [node.name, node.symbol?.kind, node.startLine, node.endLine, node.symbol?.signature])).toEqual([
+      ['Point', 'struct', 1, 2, 'Point* = object'],
+    ])
+  })
+})
+
+describe('extractCrystalDefinitions', () => {
+  it('should read nested types, methods, enums and libs', () => {
+    const source = `require "http/server"
+
+module Shapes
+  # A drawable shape.
+  abstract class Shape
+    abstract def area : Float64
+
+    def describe(io : IO) : Nil
+      return unless io
+      io << "shape" if true
+      [1, 2].each do |value|
+        io << value
+      end
+    end
+
+    private def select
+      true
+    end
+  end
+
+  enum Color
+    Red
+    Green = 2
+
+    def hot?
+      self == Red
+    end
+  end
+end
+
+class Shapes::Circle < Shapes::Shape
+  def area : Float64
+    if @radius > 0
+      3.14
+    else
+      0.0
+    end
+  end
+end
+
+lib LibC
+  fun strlen(s : UInt8*) : Int32
+end
+
+macro define_getter(name)
+  def {{name}}
+    @{{name}}
+  end
+end
+`
+    const nodes = extractCrystalDefinitions(source, '/p/shapes.cr')
+
+    expect(nodes.map(node => [node.type, node.name, node.startLine, node.endLine, node.symbol?.container])).toEqual([
+      ['module', 'Shapes', 3, 29, undefined],
+      ['class', 'Shape', 5, 19, 'Shapes'],
+      ['method', 'area', 6, 6, 'Shapes::Shape'],
+      ['method', 'describe', 8, 14, 'Shapes::Shape'],
+      ['method', 'select', 16, 18, 'Shapes::Shape'],
+      ['class', 'Color', 21, 28, 'Shapes'],
+      ['variant', 'Red', 22, 22, 'Shapes::Color'],
+      ['variant', 'Green', 23, 23, 'Shapes::Color'],
+      ['method', 'hot?', 25, 27, 'Shapes::Color'],
+      ['class', 'Circle', 31, 39, 'Shapes'],
+      ['method', 'area', 32, 38, 'Shapes::Circle'],
+      ['module', 'LibC', 41, 43, undefined],
+      ['function', 'strlen', 42, 42, 'LibC'],
+      ['macro', 'define_getter', 45, 49, undefined],
+    ])
+    expect(nodes[1]!.symbol?.doc).toBe('A drawable shape.')
+    expect(nodes[1]!.symbol?.signature).toBe('abstract class Shape')
+    expect(nodes[3]!.symbol?.signature).toBe('def describe(io : IO) : Nil')
+    expect(nodes[4]!.symbol?.visibility).toBe('private')
+  })
+})
+
+describe('language registry', () => {
+  it('should give every optional programming language a grammar package and its own extensions', () => {
+    expect(getLanguageForFile('/p/a.nim')?.name).toBe('nim')
+    expect(getLanguageForFile('/p/a.cr')?.name).toBe('crystal')
+
+    const logicExtensions: readonly string[] = ALL_LOGIC_EXTENSIONS
+    // Assembly and linker scripts have no grammar and are only ever read as text
+    const textOnly: string[] = [PARSER_NAMES.ASSEMBLY, PARSER_NAMES.LINKER_SCRIPT]
+    for (const config of LANGUAGE_CONFIGS.filter(candidate => candidate.optional && !textOnly.includes(candidate.name) && candidate.extensions.some(extension => logicExtensions.includes(extension)))) {
+      expect(OPTIONAL_GRAMMAR_PACKAGES[config.parserName as keyof typeof OPTIONAL_GRAMMAR_PACKAGES], config.name).toBeDefined()
+    }
+    const owners = new Map<string, string>()
+    for (const config of LANGUAGE_CONFIGS) {
+      for (const extension of config.extensions) {
+        expect(owners.get(extension) ?? config.name, extension).toBe(config.name)
+        owners.set(extension, config.name)
+      }
+    }
+  })
+})
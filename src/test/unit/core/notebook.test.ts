@@ -0,0 +1,42 @@
+/**
+ * Jupyter notebook parsing with cell-aware locations
+ */
+
+import { describe, it, expect } from 'vitest'
+import { resolve } from 'path'
+import { readFileSync } from 'fs'
+import { parseFile } from '../../../core/parser.js'
+import { extractNotebookScript, getNotebookOutline } from '../../../core/notebook.js'
+
+describe('Jupyter notebooks', () => {
+  const notebookPath = resolve(import.meta.dirname, '../../fixtures/notebooks/analysis.ipynb')
+
+  it('should extract only code cells and comment out magics', () => {
+    const { language, script, cells } = extractNotebookScript(readFileSync(notebookPath, 'utf-8'))
+
+    expect(language).toBe('python')
+    expect(cells.map(cell => cell.index)).toEqual([1, 2, 3])
+    expect(script).toContain('# In[1]:')
+    expect(script).toContain('# %matplotlib inline')
+    expect(script).not.toContain('Sales analysis')
+  })
+
+  it('should report element lines relative to their cell', async () => {
+    const fileNode = await parseFile(notebookPath)
+    const summarize = fileNode.children?.find(child => child.name === 'summarize')
+
+    expect(summarize?.cell).toBe(2)
+    expect(summarize?.startLine).toBe(5)
+  })
+
+  it('should outline the definitions of each cell', async () => {
+    const fileNode = await parseFile(notebookPath)
+    const outline = getNotebookOutline(fileNode)
+
+    expect(outline).toHaveLength(3)
+    expect(outline[0]?.functions).toEqual(['load_sales'])
+    expect(outline[1]?.classes).toEqual(['Summary'])
+    expect(outline[1]?.functions).toContain('summarize')
+    expect(outline[2]?.functions).toEqual([])
+  })
+})
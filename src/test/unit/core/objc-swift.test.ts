@@ -0,0 +1,149 @@
+/**
+ * Objective-C and Swift declarations read from the source text
+ */
+
+import { describe, it, expect } from 'vitest'
+import { extractObjCDefinitions, extractObjCHeaderDefinitions, objcSelector } from '../../../core/objc.js'
+import { extractSwiftDefinitions } from '../../../core/swift.js'
+
+const header = `#import <Foundation/Foundation.h>
+
+typedef NS_ENUM(NSInteger, LoadState) {
+  LoadStateIdle,
+  LoadStateBusy,
+};
+
+/** Loads remote documents. */
+@interface DocumentLoader : NSObject <NSCopying>
+@property (nonatomic, copy) NSString *name;
+@property (nonatomic, copy, nullable) void (^completion)(NSError *error);
+- (instancetype)initWithName:(NSString *)name;
+- (void)loadDataWithURL:(NSURL *)url completion:(void (^)(NSData *data))completion NS_SWIFT_NAME(fetch(from:completion:));
++ (instancetype)sharedLoader;
+@end
+
+@interface DocumentLoader (Caching)
+- (void)clearCache;
+@end
+
+@protocol DocumentLoaderDelegate;
+@protocol DocumentSource <NSObject>
+- (NSData *)dataForPath:(NSString *)path;
+@end
+`
+
+describe('extractObjCDefinitions', () => {
+  it('should read interfaces, categories, protocols, methods and properties', () => {
+    const nodes = extractObjCHeaderDefinitions(header, '/p/DocumentLoader.h')
+    const summary = nodes.map(node => [node.type, node.name, node.symbol?.container])
+
+    expect(summary).toEqual([
+      ['class', 'LoadState', undefined],
+      ['class', 'DocumentLoader', undefined],
+      ['property', 'name', 'DocumentLoader'],
+      ['property', 'completion', 'DocumentLoader'],
+      ['method', 'initWithName', 'DocumentLoader'],
+      ['method', 'loadDataWithURL', 'DocumentLoader'],
+      ['method', 'sharedLoader', 'DocumentLoader'],
+      ['class', 'DocumentLoader', undefined],
+      ['method', 'clearCache', 'DocumentLoader'],
+      ['class', 'DocumentSource', undefined],
+      ['method', 'dataForPath', 'DocumentSource'],
+    ])
+    expect(nodes[1]!.symbol?.doc).toBe('Loads remote documents.')
+    expect(nodes[1]!.endLine).toBe(15)
+    expect(nodes[7]!.symbol?.signature).toBe('@interface DocumentLoader (Caching)')
+    expect(nodes[9]!.symbol?.kind).toBe('interface')
+    expect(nodes[5]!.symbol?.signature).toContain('NS_SWIFT_NAME(fetch(from:completion:))')
+    expect(objcSelector(nodes[5]!.symbol!.signature)).toBe('loadDataWithURL:completion:')
+  })
+
+  it('should read method bodies and C functions of implementation files', () => {
+    const source = `#import "DocumentLoader.h"
+
+static NSString *Describe(NSInteger state) {
+  if (state) { return @"busy"; }
+  return @"idle";
+}
+
+@implementation DocumentLoader {
+  NSCache *_cache;
+}
+
+- (void)clearCache {
+  [_cache removeAllObjects];
+}
+@end
+`
+    const nodes = extractObjCDefinitions(source, '/p/DocumentLoader.m')
+
+    expect(nodes.map(node => [node.type, node.name, node.startLine, node.endLine])).toEqual([
+      ['function', 'Describe', 3, 6],
+      ['class', 'DocumentLoader', 8, 15],
+      ['method', 'clearCache', 12, 14],
+    ])
+  })
+
+  it('should leave plain C headers alone', () => {
+    expect(extractObjCHeaderDefinitions('int add(int a, int b);\n', '/p/math.h')).toEqual([])
+  })
+})
+
+describe('extractSwiftDefinitions', () => {
+  it('should read types, members, cases and access levels', () => {
+    const source = `import UIKit
+
+/// Shows a document.
+@objcMembers public class DocumentView: UIView {
+  public var title: String = ""
+  private let loader = DocumentLoader(name: "docs")
+
+  @objc(reloadWithAnimation:)
+  func reload(animated: Bool) {
+    let local = 1
+    print(local)
+  }
+
+  init(frame: CGRect, style: Int) {
+    super.init(frame: frame)
+  }
+}
+
+enum Mode: String {
+  case light, dark
+  case custom(name: String, level: Int)
+}
+
+public extension DocumentView {
+  func scroll(_ offset: CGFloat) {}
+}
+
+func makeView() -> DocumentView {
+  return DocumentView(frame: .zero, style: 0)
+}
+`
+    const nodes = extractSwiftDefinitions(source, '/p/DocumentView.swift')
+    const summary = nodes.map(node => [node.type, node.name, node.symbol?.container, node.symbol?.visibility])
+
+    expect(summary).toEqual([
+      ['class', 'DocumentView', undefined, 'public'],
+      ['property', 'title', 'DocumentView', 'public'],
+      ['property', 'loader', 'DocumentView', 'private'],
+      ['method', 'reload', 'DocumentView', 'internal'],
+      ['method', 'init', 'DocumentView', 'internal'],
+      ['class', 'Mode', undefined, 'internal'],
+      ['variant', 'light', 'Mode', 'internal'],
+      ['variant', 'dark', 'Mode', 'internal'],
+      ['variant', 'custom', 'Mode', 'internal'],
+      ['class', 'DocumentView', undefined, 'public'],
+      ['method', 'scroll', 'DocumentView', 'public'],
+      ['function', 'makeView', undefined, 'internal'],
+    ])
+    expect(nodes[0]!.symbol?.doc).toBe('Shows a document.')
+    expect(nodes[0]!.symbol?.annotations).toEqual(['@objcMembers'])
+    expect(nodes[3]!.symbol?.annotations).toEqual(['@objc(reloadWithAnimation:)'])
+    expect(nodes[3]!.symbol?.signature).toBe('@objc(reloadWithAnimation:) func reload(animated: Bool)')
+    expect([nodes[3]!.startLine, nodes[3]!.endLine]).toEqual([8, 12])
+    expect(nodes[5]!.symbol?.kind).toBe('enum')
+  })
+})
@@ -0,0 +1,72 @@
+/**
+ * Preprocessor condition regions, their evaluation with defines, and guarded declarations
+ */
+
+import { describe, it, expect } from 'vitest'
+import { createPreprocessorIndex, evaluateCondition, guardAt, parseDefines, readConditionalRegions } from '../../../core/preprocessor.js'
+import type { TreeNode } from '../../../types/core.js'
+
+const PLATFORM = `#ifndef PLATFORM_H
+#define PLATFORM_H
+
+#ifdef _WIN32
+int platform_init(void);
+#elif defined(__APPLE__) && \\
+      TARGET_OS_IPHONE
+int platform_init(void);
+#else
+int platform_init(void);
+# if DEBUG_LEVEL > 1
+void trace(const char *message);
+# endif
+#endif
+
+/* #if NEVER */
+int shared(void);
+
+#endif
+`
+
+describe('preprocessor conditions', () => {
+  it('should read branch conditions, skipping the include guard and commented directives', () => {
+    const regions = readConditionalRegions(PLATFORM, 'platform.h')
+    expect(regions).toEqual([
+      { condition: 'defined(_WIN32)', line: 4, endLine: 5 },
+      { condition: '!defined(_WIN32) && defined(__APPLE__) && TARGET_OS_IPHONE', line: 6, endLine: 8 },
+      { condition: '!defined(_WIN32) && !(defined(__APPLE__) && TARGET_OS_IPHONE)', line: 9, endLine: 13 },
+      { condition: 'DEBUG_LEVEL > 1', line: 11, endLine: 12 },
+    ])
+    expect(guardAt(regions, 12)).toBe('!defined(_WIN32) && !(defined(__APPLE__) && TARGET_OS_IPHONE) && DEBUG_LEVEL > 1')
+    expect(guardAt(regions, 17)).toBeUndefined()
+  })
+
+  it('should evaluate conditions with the defines a compiler was given', () => {
+    const defines = parseDefines('-D_WIN32 -DDEBUG_LEVEL=2 VERSION=0x0201')
+    expect(evaluateCondition('defined(_WIN32)', defines)).toBe(true)
+    expect(evaluateCondition('!defined _WIN32 || __APPLE__', defines)).toBe(false)
+    expect(evaluateCondition('DEBUG_LEVEL > 1 && VERSION >= 0x0200', defines)).toBe(true)
+    expect(evaluateCondition('(VERSION >> 8) == 2 ? DEBUG_LEVEL % 2 : 1', defines)).toBe(false)
+    expect(evaluateCondition('__has_include(<threads.h>)', defines)).toBeUndefined()
+  })
+
+  it('should mark guarded declarations with their variants and filter by defines', () => {
+    const nodes: TreeNode[] = [
+      { id: 'file', type: 'file', path: '/app/platform.h', content: PLATFORM },
+      { id: 'win', type: 'function', name: 'platform_init', path: '/app/platform.h', startLine: 5 },
+      { id: 'ios', type: 'function', name: 'platform_init', path: '/app/platform.h', startLine: 7 },
+      { id: 'other', type: 'function', name: 'platform_init', path: '/app/platform.h', startLine: 10 },
+      { id: 'shared', type: 'function', name: 'shared', path: '/app/platform.h', startLine: 17 },
+    ]
+    const index = createPreprocessorIndex(nodes)
+    expect(index.describe(nodes[1]!)).toEqual({
+      guard: 'defined(_WIN32)',
+      variants: [
+        { path: '/app/platform.h', line: 7, guard: '!defined(_WIN32) && defined(__APPLE__) && TARGET_OS_IPHONE' },
+        { path: '/app/platform.h', line: 10, guard: '!defined(_WIN32) && !(defined(__APPLE__) && TARGET_OS_IPHONE)' },
+      ],
+    })
+    expect(index.describe(nodes[4]!)).toBeUndefined()
+    expect(nodes.filter(node => index.matches(node, parseDefines('_WIN32'))).map(node => node.id)).toEqual(['file', 'win', 'shared'])
+    expect(nodes.filter(node => index.matches(node, parseDefines(''))).map(node => node.id)).toEqual(['file', 'other', 'shared'])
+  })
+})
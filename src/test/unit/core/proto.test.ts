@@ -0,0 +1,38 @@
+/**
+ * Protobuf definition extraction and generated-code linking
+ */
+
+import { describe, it, expect } from 'vitest'
+import { resolve } from 'path'
+import { readFileSync } from 'fs'
+import { parseProtoDefinitions } from '../../../core/proto.js'
+import { listProtoDefinitions } from '../../../analysis/protobuf.js'
+import { createProject, parseProject } from '../../../project/manager.js'
+
+describe('Protobuf support', () => {
+  const fixture = resolve(import.meta.dirname, '../../fixtures/proto-grpc')
+
+  it('should extract services, rpcs and nested types', () => {
+    const definitions = parseProtoDefinitions(readFileSync(resolve(fixture, 'proto/users.proto'), 'utf-8'))
+
+    expect(definitions.package).toBe('acme.users.v1')
+    expect(definitions.services).toHaveLength(1)
+    expect(definitions.services[0]?.rpcs.map(rpc => [rpc.name, rpc.serverStreaming])).toEqual([
+      ['GetUser', false],
+      ['WatchUsers', true],
+    ])
+    expect(definitions.types.map(type => type.fullName)).toContain('acme.users.v1.User.Address')
+    expect(definitions.types.find(type => type.name === 'Role')?.kind).toBe('enum')
+  })
+
+  it('should link services to generated stubs and handwritten usages', async () => {
+    const project = createProject({ directory: fixture })
+    await parseProject(project)
+
+    const [protoFile] = listProtoDefinitions(project)
+    const service = protoFile?.services[0]
+
+    expect(service?.links?.generatedFiles.some(path => path.endsWith('users_grpc.pb.go'))).toBe(true)
+    expect(service?.links?.usages.some(usage => usage.path.endsWith('server/main.go'))).toBe(true)
+  })
+})
@@ -0,0 +1,139 @@
+/**
+ * Routes, tasks and commands registered through Python decorators
+ */
+
+import { describe, it, expect, beforeEach, afterEach } from 'vitest'
+import { mkdirSync, mkdtempSync, rmSync, writeFileSync } from 'fs'
+import { tmpdir } from 'os'
+import { join } from 'path'
+import { celeryTaskName, extractDecoratedRegistrations } from '../../../core/python-decorators.js'
+
+const describeNodes = (content: string, filePath = '/p/app/views.py') => extractDecoratedRegistrations(content, filePath)
+  .map(node => [node.type, node.name, node.startLine, node.endLine, node.symbol?.handler, node.symbol?.container])
+
+describe('Python decorator registrations', () => {
+  let root: string
+
+  beforeEach(() => {
+    root = mkdtempSync(join(tmpdir(), 'ts-mcp-decorators-'))
+  })
+
+  afterEach(() => {
+    rmSync(root, { recursive: true, force: true })
+  })
+
+  it('should index Flask and FastAPI routes under their paths, with router prefixes', () => {
+    const content = `from fastapi import APIRouter
+from flask import Blueprint
+
+router = APIRouter(prefix="/users", tags=["users"])
+admin = Blueprint("admin", __name__, url_prefix="/admin/")
+
+
+@router.get("/{user_id}")
+async def get_user(user_id: int):
+    """Fetch one user"""
+    return await load(user_id)
+
+
+@admin.route("/stats", methods=["GET", "POST"])
+@login_required
+def stats():
+    return render()
+
+# @app.get("/commented")
+@cache.memoize(60)
+def cached():
+    pass
+`
+    expect(describeNodes(content)).toEqual([
+      ['route', '/users/{user_id}', 8, 11, 'get_user', undefined],
+      ['route', '/admin/stats', 14, 17, 'stats', undefined],
+    ])
+
+    const [route] = extractDecoratedRegistrations(content, '/p/app/views.py')
+    expect(route!.symbol).toEqual({
+      kind: 'function',
+      visibility: 'public',
+      signature: '@router.get("/{user_id}") async def get_user(user_id: int)',
+      doc: 'Fetch one user',
+      handler: 'get_user',
+    })
+    expect(route!.content!.split('\n')[0]).toBe('@router.get("/{user_id}")')
+  })
+
+  it('should index Celery tasks under explicit or module-derived names', () => {
+    mkdirSync(join(root, 'billing'))
+    writeFileSync(join(root, 'billing', '__init__.py'), '')
+    const content = `from celery import shared_task
+from .worker import app
+
+
+@app.task(bind=True, name="billing.charge_card")
+def charge(self, order_id):
+    pass
+
+
+@shared_task
+def send_receipt(order_id):
+    pass
+`
+    expect(describeNodes(content, join(root, 'billing', 'tasks.py')).map(node => node.slice(0, 2))).toEqual([
+      ['task', 'billing.charge_card'],
+      ['task', 'billing.tasks.send_receipt'],
+    ])
+    expect(celeryTaskName('/p/scripts/jobs.py', 'cleanup')).toBe('jobs.cleanup')
+  })
+
+  it('should index click commands and groups under their command names', () => {
+    const content = `import click
+
+
+@click.group()
+def cli():
+    pass
+
+
+@cli.command()
+@click.option("--force", is_flag=True)
+def sync_all(force):
+    """Sync every mirror"""
+
+
+@cli.command("db-migrate")
+def migrate():
+    pass
+
+
+@cli.command(name="serve", help="Run the server")
+def run_server(): pass
+`
+    expect(describeNodes(content, '/p/app/cli.py')).toEqual([
+      ['command', 'cli', 4, 6, 'cli', undefined],
+      ['command', 'sync-all', 9, 12, 'sync_all', 'cli'],
+      ['command', 'db-migrate', 15, 17, 'migrate', 'cli'],
+      ['command', 'serve', 20, 21, 'run_server', 'cli'],
+    ])
+  })
+
+  it('should ignore task and command decorators from other libraries', () => {
+    const content = `from prefect import flow, task
+
+
+@task
+def extract():
+    pass
+
+
+@pipeline.task()
+def load():
+    pass
+
+
+@app.command()
+def other():
+    pass
+`
+    expect(describeNodes(content)).toEqual([])
+  })
+})
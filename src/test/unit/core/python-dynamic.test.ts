@@ -0,0 +1,127 @@
+/**
+ * Python attributes defined dynamically or as model fields
+ */
+
+import { describe, it, expect } from 'vitest'
+import { extractDynamicAttributes } from '../../../core/python-dynamic.js'
+
+const describeNodes = (content: string) => extractDynamicAttributes(content, '/p/app/models.py')
+  .map(node => [node.name, node.symbol?.container, node.startLine, node.symbol?.visibility, node.symbol?.signature])
+
+describe('Python dynamic attributes', () => {
+  it('should index dataclass and pydantic fields with their docs', () => {
+    const content = `from dataclasses import dataclass, field
+from typing import ClassVar
+from pydantic import BaseModel, Field
+
+
+@dataclass(frozen=True)
+class Point:
+    """A point"""
+    x: int
+    y: int = 0
+    """Vertical offset"""
+    registry: ClassVar[dict] = {}
+    tags: list[str] = field(default_factory=list)
+
+    def norm(self) -> float:
+        total: float = 0
+        return total
+
+
+class User(BaseModel):
+    email: str = Field(..., description="Login address, lower-cased")
+    _secret: str = "x: int = 1"
+
+
+class Admin(User):
+    level: int
+
+
+class Plain:
+    label: str
+`
+    expect(describeNodes(content)).toEqual([
+      ['x', 'Point', 9, 'public', 'x: int'],
+      ['y', 'Point', 10, 'public', 'y: int'],
+      ['tags', 'Point', 13, 'public', 'tags: list[str]'],
+      ['email', 'User', 21, 'public', 'email: str'],
+      ['_secret', 'User', 22, 'internal', '_secret: str'],
+      ['level', 'Admin', 26, 'public', 'level: int'],
+    ])
+
+    const nodes = extractDynamicAttributes(content, '/p/app/models.py')
+    expect(nodes.map(node => [node.name, node.symbol?.doc])).toEqual([
+      ['x', undefined],
+      ['y', 'Vertical offset'],
+      ['tags', undefined],
+      ['email', 'Login address, lower-cased'],
+      ['_secret', undefined],
+      ['level', undefined],
+    ])
+    expect(nodes[0]!.type).toBe('variable')
+  })
+
+  it('should index attributes assigned to self in __init__', () => {
+    const content = `class Client:
+    def __init__(self, url, retries=3):
+        """Connect later"""
+        self.url = url
+        self.retries: int = retries
+        self.__token = None
+        self.a, self.b = 1, 2
+        self.first = self.last = None
+        self.retries += 1
+        self.cache[url] = True
+        if retries > 1:
+            self.backoff = (
+                0.5
+            )
+
+    def reset(self):
+        self.stale = True
+`
+    expect(describeNodes(content)).toEqual([
+      ['url', 'Client', 4, 'public', 'self.url'],
+      ['retries', 'Client', 5, 'public', 'self.retries: int'],
+      ['__token', 'Client', 6, 'private', 'self.__token'],
+      ['a', 'Client', 7, 'public', 'self.a'],
+      ['b', 'Client', 7, 'public', 'self.b'],
+      ['first', 'Client', 8, 'public', 'self.first'],
+      ['last', 'Client', 8, 'public', 'self.last'],
+      ['backoff', 'Client', 12, 'public', 'self.backoff'],
+    ])
+    expect(extractDynamicAttributes(content, '/p/app/models.py').at(-1)!.endLine).toBe(14)
+  })
+
+  it('should index setattr calls with literal names', () => {
+    const content = `import sys
+
+
+class Settings:
+    def load(self, values):
+        setattr(self, "timeout", values.get("timeout"))
+        setattr(self, name, 1)
+
+    @classmethod
+    def register(cls):
+        setattr(cls, 'DEFAULTS', {})
+
+
+setattr(sys.modules[__name__], "VERSION", "1.0")
+setattr(Settings, "debug", False)
+# setattr(Settings, "commented", True)
+
+
+def configure(app):
+    setattr(app, "ready", True)
+`
+    expect(describeNodes(content)).toEqual([
+      ['timeout', 'Settings', 6, 'public', 'setattr(self, "timeout", values.get("timeout"))'],
+      ['DEFAULTS', 'Settings', 11, 'public', 'setattr(cls, \'DEFAULTS\', {})'],
+      ['VERSION', undefined, 14, 'public', 'setattr(sys.modules[__name__], "VERSION", "1.0")'],
+      ['debug', 'Settings', 15, 'public', 'setattr(Settings, "debug", False)'],
+      ['ready', 'app', 20, 'public', 'setattr(app, "ready", True)'],
+    ])
+  })
+})
@@ -0,0 +1,79 @@
+/**
+ * Search query language: field terms, boolean operators and how they narrow declarations
+ */
+
+import { describe, it, expect } from 'vitest'
+import { applySearchQuery, isStructuredQuery, parseSearchQuery } from '../../../core/query.js'
+import type { TreeNode } from '../../../types/core.js'
+
+function declaration(path: string, name: string, type: string, content?: string): TreeNode {
+  return { id: `${path}#${name}`, type, name, path, content }
+}
+
+const NODES: TreeNode[] = [
+  { id: '/app/server/main.go', type: 'file', name: 'main.go', path: '/app/server/main.go' },
+  declaration('/app/server/main.go', 'main', 'function', 'func main() {\n  router := NewRouter()\n  ServeHTTP(router)\n}'),
+  declaration('/app/server/handlers.go', 'UserHandler', 'function'),
+  declaration('/app/server/handlers.go', 'HandlerConfig', 'struct'),
+  declaration('/app/vendor/lib/handler.go', 'VendorHandler', 'function'),
+  declaration('/app/server/router.go', 'NewRouter', 'function'),
+  declaration('/app/web/handler.ts', 'useHandler', 'function'),
+]
+
+function names(query: string): string[] {
+  return applySearchQuery(query, NODES, '/app').nodes.map(node => node.name!)
+}
+
+describe('search query language', () => {
+  it('should parse terms, OR, negation and parentheses', () => {
+    expect(parseSearchQuery('kind:function -path:vendor')).toEqual({
+      expression: {
+        op: 'and',
+        terms: [
+          { op: 'term', field: 'kind', value: 'function' },
+          { op: 'not', term: { op: 'term', field: 'path', value: 'vendor' } },
+        ],
+      },
+      text: '',
+    })
+    expect(parseSearchQuery('(kind:class OR kind:struct) NOT Config user').expression).toEqual({
+      op: 'and',
+      terms: [
+        { op: 'or', terms: [{ op: 'term', field: 'kind', value: 'class' }, { op: 'term', field: 'kind', value: 'struct' }] },
+        { op: 'not', term: { op: 'term', field: 'text', value: 'Config' } },
+        { op: 'term', field: 'text', value: 'user' },
+      ],
+    })
+    expect(parseSearchQuery('path:"my dir" handler -skipped').text).toBe('handler')
+  })
+
+  it('should reject malformed queries', () => {
+    expect(() => parseSearchQuery('(kind:function')).toThrow('Unbalanced parentheses')
+    expect(() => parseSearchQuery('kind:function)')).toThrow('Unbalanced parentheses')
+    expect(() => parseSearchQuery('kind:function OR')).toThrow('at the end of the query')
+    expect(() => parseSearchQuery('name:')).toThrow('name: needs a value')
+    expect(() => parseSearchQuery('path:"open')).toThrow('Unterminated quote')
+  })
+
+  it('should narrow declarations by each field', () => {
+    expect(names('kind:function lang:go name:~Handler -path:vendor')).toEqual(['UserHandler'])
+    expect(names('name:userhandler')).toEqual(['UserHandler'])
+    expect(names('name:*Handler kind:function')).toEqual(['UserHandler', 'VendorHandler', 'useHandler'])
+    expect(names('lang:ts')).toEqual(['useHandler'])
+    expect(names('kind:struct OR path:web')).toEqual(['HandlerConfig', 'useHandler'])
+    expect(names('caller:main')).toEqual(['NewRouter'])
+    // Paths are relative to the project, so /app itself matches nothing
+    expect(names('path:app')).toEqual([])
+    expect(names('kind:function -path:app')).toEqual(['main', 'UserHandler', 'VendorHandler', 'NewRouter', 'useHandler'])
+    expect(names('path:server/handlers')).toEqual(['UserHandler', 'HandlerConfig'])
+    expect(names('lang:go handler -kind:struct')).toEqual(['UserHandler', 'VendorHandler'])
+  })
+
+  it('should leave plain names to the ordinary search', () => {
+    expect(isStructuredQuery('UserHandler')).toBe(false)
+    expect(isStructuredQuery('utils.FormatDate')).toBe(false)
+    expect(isStructuredQuery('(kind:function)')).toBe(true)
+    expect(applySearchQuery('UserHandler', NODES, '/app')).toEqual({ query: 'UserHandler', nodes: NODES })
+    expect(applySearchQuery('lang:go name:~Handler', NODES, '/app').query).toBe('')
+  })
+})
@@ -0,0 +1,160 @@
+/**
+ * R and Julia definitions and exported names
+ */
+
+import { describe, it, expect, beforeEach, afterEach } from 'vitest'
+import { mkdirSync, mkdtempSync, rmSync, writeFileSync } from 'fs'
+import { tmpdir } from 'os'
+import { join } from 'path'
+import { extractRDefinitions, readNamespaceExports } from '../../../core/r.js'
+import { extractJuliaDefinitions } from '../../../core/julia.js'
+import { readImports } from '../../../analysis/chunks.js'
+
+const R = `library(dplyr)
+
+#' Compute the mean of x
+#'
+#' @param x A numeric vector
+#' @export
+safe_mean <- function(x, na.rm = TRUE) {
+  helper <- function(y) y + 1
+  mean(x, na.rm = na.rm)
+}
+
+internal_helper = function(a) a * 2
+
+square <- \\(x) x^2
+
+lapply(1:3, f = function(i) i)
+
+Person <- R6::R6Class("Person",
+  public = list(
+    initialize = function(name) {
+      self$name <- name
+    },
+    greet = function() cat("Hello { ", self$name, "\\n")
+  ),
+  private = list(
+    secret = function() "hidden"
+  )
+)
+
+setClass("Account", representation(balance = "numeric"))
+setMethod("deposit", "Account", function(acc, amount) {
+  acc@balance <- acc@balance + amount
+  acc
+})
+`
+
+const JULIA = `module Shapes
+
+using LinearAlgebra, Statistics
+import Base: show
+
+export Shape, Circle, area, @shout
+
+abstract type Shape end
+
+"""
+    Circle(r)
+
+A circle of radius \`r\`.
+"""
+struct Circle <: Shape
+    r::Float64
+    Circle(r) = r < 0 ? error("negative") : new(r)
+end
+
+"Area of a shape."
+area(c::Circle) = pi * c.r^2
+
+function perimeter(c::Circle)::Float64
+    xs = [i for i in 1:10 if i > 2]
+    last = xs[end]
+    inner(x) = x + 1
+    2pi * c.r
+end
+
+function Base.show(io::IO, c::Circle)
+    print(io, "Circle(", c.r, ") end")
+end
+
+_helper(x) = x'
+
+macro shout(ex)
+    :(uppercase($(esc(ex))))
+end
+
+end # module
+`
+
+describe('R', () => {
+  let root: string
+
+  beforeEach(() => {
+    root = mkdtempSync(join(tmpdir(), 'ts-mcp-r-'))
+  })
+
+  afterEach(() => {
+    rmSync(root, { recursive: true, force: true })
+  })
+
+  it('should index top-level functions and class methods, following roxygen exports', () => {
+    const nodes = extractRDefinitions(R, join(root, 'analysis.R'))
+    expect(nodes.map(node => [node.type, node.name, node.startLine, node.endLine, node.symbol?.container, node.symbol?.visibility])).toEqual([
+      ['function', 'safe_mean', 7, 10, undefined, 'public'],
+      ['function', 'internal_helper', 12, 12, undefined, 'internal'],
+      ['function', 'square', 14, 14, undefined, 'internal'],
+      ['class', 'Person', 18, 28, undefined, 'internal'],
+      ['method', 'initialize', 20, 22, 'Person', 'public'],
+      ['method', 'greet', 23, 23, 'Person', 'public'],
+      ['method', 'secret', 26, 26, 'Person', 'private'],
+      ['class', 'Account', 30, 30, undefined, 'internal'],
+      ['method', 'deposit', 31, 34, 'Account', 'internal'],
+    ])
+    expect(nodes[0]!.symbol).toMatchObject({ signature: 'safe_mean <- function(x, na.rm = TRUE)', doc: 'Compute the mean of x' })
+    expect(nodes[3]!.symbol?.signature).toBe('Person <- R6::R6Class("Person")')
+  })
+
+  it('should take exports from the package NAMESPACE', () => {
+    mkdirSync(join(root, 'R'))
+    writeFileSync(join(root, 'NAMESPACE'), '# Generated by roxygen2\nexport(internal_helper)\nexportPattern("^sq")\nS3method(print, person)\n')
+    const nodes = extractRDefinitions(R, join(root, 'R', 'analysis.R'))
+    const visibility = Object.fromEntries(nodes.filter(node => node.type !== 'method').map(node => [node.name, node.symbol?.visibility]))
+    expect(visibility).toEqual({ safe_mean: 'internal', internal_helper: 'public', square: 'public', Person: 'internal', Account: 'internal' })
+
+    const exports = readNamespaceExports('export(a, "b")\nexportClasses(Account)\nS3method(print, person)\n')
+    expect([...exports.names]).toEqual(['a', 'b', 'Account', 'print.person'])
+  })
+})
+
+describe('Julia', () => {
+  it('should index modules, functions, macros and types with their exports', () => {
+    const nodes = extractJuliaDefinitions(JULIA, '/p/src/Shapes.jl')
+    expect(nodes.map(node => [node.type, node.name, node.startLine, node.endLine, node.symbol?.kind, node.symbol?.container, node.symbol?.visibility])).toEqual([
+      ['module', 'Shapes', 1, 40, 'module', undefined, 'public'],
+      ['class', 'Shape', 8, 8, 'type', 'Shapes', 'public'],
+      ['class', 'Circle', 15, 18, 'struct', 'Shapes', 'public'],
+      ['method', 'Circle', 17, 17, 'method', 'Shapes.Circle', 'public'],
+      ['function', 'area', 21, 21, 'function', 'Shapes', 'public'],
+      ['function', 'perimeter', 23, 28, 'function', 'Shapes', 'internal'],
+      ['function', 'show', 30, 32, 'function', 'Shapes', 'internal'],
+      ['function', '_helper', 34, 34, 'function', 'Shapes', 'private'],
+      ['macro', '@shout', 36, 38, 'macro', 'Shapes', 'public'],
+    ])
+
+    const byName = (name: string) => nodes.find(node => node.name === name)!.symbol
+    expect(byName('Circle')).toMatchObject({ signature: 'struct Circle <: Shape', doc: 'A circle of radius `r`.' })
+    expect(byName('area')).toMatchObject({ signature: 'area(c::Circle)', doc: 'Area of a shape.' })
+    expect(byName('perimeter')?.signature).toBe('function perimeter(c::Circle)::Float64')
+  })
+
+  it('should read R and Julia imports', () => {
+    expect(readImports(R, 'r')).toEqual([{ module: 'dplyr', names: ['dplyr'] }])
+    expect(readImports(JULIA, 'julia')).toEqual([
+      { module: 'LinearAlgebra', names: ['LinearAlgebra'] },
+      { module: 'Statistics', names: ['Statistics'] },
+      { module: 'Base', names: ['show'] },
+    ])
+  })
+})
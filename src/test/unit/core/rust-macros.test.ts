@@ -0,0 +1,125 @@
+/**
+ * Symbols declared through common Rust macros and derives
+ */
+
+import { describe, it, expect } from 'vitest'
+import { extractRustMacroDeclarations } from '../../../core/rust-macros.js'
+
+const describeNodes = (content: string) => extractRustMacroDeclarations(content, '/p/src/lib.rs')
+  .map(node => [node.type, node.name, node.startLine, node.endLine, node.symbol?.kind, node.symbol?.visibility, node.symbol?.container, node.symbol?.macro])
+
+describe('Rust macro declarations', () => {
+  it('should index macro_rules definitions and the statics of lazy_static and thread_local', () => {
+    const content = `#[macro_export]
+macro_rules! ensure {
+    ($cond:expr) => { if !$cond { return Err(()) } };
+}
+
+macro_rules! local_only { () => {} }
+
+lazy_static! {
+    /// Parsed once at startup
+    pub static ref CONFIG: Config = {
+        let text = "a; b { c";
+        Config::parse(text)
+    };
+    static ref CACHE: Mutex<HashMap<String, u32>> = Mutex::new(HashMap::new());
+}
+
+thread_local! {
+    pub(crate) static DEPTH: Cell<u32> = Cell::new(0);
+}
+`
+    expect(describeNodes(content)).toEqual([
+      ['macro', 'ensure', 1, 4, 'macro', 'public', undefined, undefined],
+      ['macro', 'local_only', 6, 6, 'macro', 'private', undefined, undefined],
+      ['variable', 'CONFIG', 10, 13, 'variable', 'public', undefined, 'lazy_static'],
+      ['variable', 'CACHE', 14, 14, 'variable', 'private', undefined, 'lazy_static'],
+      ['variable', 'DEPTH', 18, 18, 'variable', 'internal', undefined, 'thread_local'],
+    ])
+
+    const nodes = extractRustMacroDeclarations(content, '/p/src/lib.rs')
+    expect(nodes[2]!.symbol).toEqual({
+      kind: 'variable',
+      visibility: 'public',
+      signature: 'pub static ref CONFIG: Config',
+      doc: 'Parsed once at startup',
+      macro: 'lazy_static',
+    })
+    expect(nodes[3]!.symbol?.signature).toBe('static ref CACHE: Mutex<HashMap<String, u32>>')
+  })
+
+  it('should index bitflags types with their flags', () => {
+    const content = `bitflags! {
+    #[derive(Debug, Clone, Copy)]
+    pub struct Permissions: u32 {
+        const READ = 0b001;
+        const WRITE = 0b010;
+        const ALL = Self::READ.bits() | Self::WRITE.bits();
+    }
+}
+`
+    expect(describeNodes(content)).toEqual([
+      ['class', 'Permissions', 3, 7, 'struct', 'public', undefined, 'bitflags'],
+      ['variable', 'READ', 4, 4, 'variable', 'public', 'Permissions', 'bitflags'],
+      ['variable', 'WRITE', 5, 5, 'variable', 'public', 'Permissions', 'bitflags'],
+      ['variable', 'ALL', 6, 6, 'variable', 'public', 'Permissions', 'bitflags'],
+    ])
+    expect(extractRustMacroDeclarations(content, '/p/src/lib.rs').map(node => node.symbol?.signature)).toEqual([
+      'pub struct Permissions: u32',
+      'pub const READ: Permissions',
+      'pub const WRITE: Permissions',
+      'pub const ALL: Permissions',
+    ])
+  })
+
+  it('should index builder types and thiserror variants generated by derives', () => {
+    const content = `use thiserror::Error;
+
+#[derive(Debug, Error)]
+pub enum StoreError {
+    /// The key was never written
+    #[error("key not found: {0}")]
+    NotFound(String),
+    #[error("io failure { path }")]
+    Io { path: PathBuf, #[source] source: std::io::Error },
+    #[error(transparent)]
+    Other(#[from] anyhow::Error),
+}
+
+#[derive(Debug, derive_builder::Builder)]
+#[builder(name = "ServerOptions")]
+struct ServerConfig {
+    port: u16,
+}
+
+#[derive(Error)]
+enum Plain { A }
+`
+    expect(describeNodes(content)).toEqual([
+      ['variant', 'NotFound', 6, 7, 'variant', 'public', 'StoreError', 'thiserror::Error'],
+      ['variant', 'Io', 8, 9, 'variant', 'public', 'StoreError', 'thiserror::Error'],
+      ['variant', 'Other', 10, 11, 'variant', 'public', 'StoreError', 'thiserror::Error'],
+      ['class', 'ServerOptions', 14, 18, 'struct', 'private', undefined, 'derive_builder::Builder'],
+      ['variant', 'A', 21, 21, 'variant', 'private', 'Plain', 'thiserror::Error'],
+    ])
+
+    const [notFound, io] = extractRustMacroDeclarations(content, '/p/src/lib.rs')
+    expect(notFound!.content).toBe('#[error("key not found: {0}")]\n    NotFound(String)')
+    expect(notFound!.symbol?.doc).toBe('The key was never written')
+    expect(io!.symbol?.signature).toBe('Io { path: PathBuf, #[source] source: std::io::Error }')
+  })
+
+  it('should ignore derives without generated symbols and macros inside comments or strings', () => {
+    const content = `// lazy_static! { static ref GONE: u8 = 0; }
+const DOC: &str = "macro_rules! fake {}";
+
+#[derive(Debug, Clone)]
+pub struct Point { x: i32, y: i32 }
+
+#[derive(Debug)]
+pub enum Error { Broken }
+`
+    expect(describeNodes(content)).toEqual([])
+  })
+})
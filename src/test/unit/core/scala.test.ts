@@ -0,0 +1,78 @@
+/**
+ * Scala sources: templates and methods read from the text, braced and indented
+ */
+
+import { describe, it, expect } from 'vitest'
+import { extractScalaDefinitions } from '../../../core/scala.js'
+import { parseContent } from '../../../core/parser.js'
+
+describe('extractScalaDefinitions', () => {
+  it('should read classes, objects, traits and their methods', () => {
+    const source = `package com.example.shop
+
+/** Prices an order. */
+trait Pricing {
+  def total(order: Order): BigDecimal
+}
+
+final case class Order(id: String, items: List[Item])
+
+object Checkout extends App with Pricing {
+  private val rate = "{ not a brace"
+
+  override def total(order: Order): BigDecimal = {
+    order.items.map(_.price).sum
+  }
+
+  private[shop] def tax(amount: BigDecimal) = amount * 0.2
+}
+`
+    const nodes = extractScalaDefinitions(source, '/p/Checkout.scala')
+
+    expect(nodes.map(node => [node.type, node.name, node.startLine, node.endLine, node.symbol?.kind, node.symbol?.container, node.symbol?.visibility])).toEqual([
+      ['class', 'Pricing', 4, 6, 'trait', undefined, 'public'],
+      ['method', 'total', 5, 5, 'method', 'Pricing', 'public'],
+      ['class', 'Order', 8, 8, 'class', undefined, 'public'],
+      ['class', 'Checkout', 10, 18, 'class', undefined, 'public'],
+      ['method', 'total', 13, 15, 'method', 'Checkout', 'public'],
+      ['method', 'tax', 17, 17, 'method', 'Checkout', 'internal'],
+    ])
+    expect(nodes[0]!.symbol?.doc).toBe('Prices an order.')
+    expect(nodes[2]!.symbol?.signature).toBe('final case class Order(id: String, items: List[Item])')
+    expect(nodes[4]!.symbol?.signature).toBe('override def total(order: Order): BigDecimal')
+  })
+
+  it('should end Scala 3 indented bodies where the indentation does', () => {
+    const source = `enum Color:
+  case Red, Green
+
+class Greeter(name: String):
+  def greet(): String =
+    val greeting = s"Hello, $name"
+
+    greeting
+
+  def shout(): String = greet().toUpperCase
+
+def main(): Unit =
+  println(Greeter("scala").greet())
+`
+    const nodes = extractScalaDefinitions(source, '/p/Main.scala')
+
+    expect(nodes.map(node => [node.type, node.name, node.startLine, node.endLine, node.symbol?.container])).toEqual([
+      ['class', 'Color', 1, 2, undefined],
+      ['class', 'Greeter', 4, 10, undefined],
+      ['method', 'greet', 5, 8, 'Greeter'],
+      ['method', 'shout', 10, 10, 'Greeter'],
+      ['function', 'main', 12, 13, undefined],
+    ])
+  })
+
+  it('should index symbols without the tree-sitter-scala grammar', () => {
+    const file = parseContent('object Main {\n  def run(): Unit = ()\n}\n', '/p/Main.scala')
+    expect(file.children?.map(node => [node.type, node.name])).toEqual([
+      ['class', 'Main'],
+      ['method', 'run'],
+    ])
+  })
+})
@@ -0,0 +1,55 @@
+/**
+ * Empty search diagnosis: file counts per filter stage, other spellings and near names
+ */
+
+import { describe, it, expect } from 'vitest'
+import { diagnoseEmptySearch, nameWords, nearestNames } from '../../../core/search-diagnosis.js'
+import type { TreeNode } from '../../../types/core.js'
+
+function file(path: string, symbols: Array<[string, string]>): TreeNode[] {
+  return [
+    { id: path, type: 'file', name: path.split('/').pop(), path },
+    ...symbols.map(([name, type]) => ({ id: `${path}#${name}`, type, name, path })),
+  ]
+}
+
+const NODES = [
+  ...file('/app/api/users.ts', [['UserService', 'class'], ['getUser', 'function']]),
+  ...file('/app/web/App.tsx', [['App', 'function']]),
+  ...file('/app/scripts/seed.py', [['seed_users', 'function']]),
+]
+
+describe('empty search diagnosis', () => {
+  it('should count files through the filters, by language', () => {
+    const diagnosis = diagnoseEmptySearch('Missing', { indexed: NODES, searched: NODES, pathPattern: 'api/' })
+    expect(diagnosis).toMatchObject({ filesIndexed: 3, filesSearched: 3, filesMatchingPath: 1, languages: { typescript: 1 } })
+
+    const unmatched = diagnoseEmptySearch('Missing', { indexed: NODES, searched: NODES, pathPattern: 'server/' })
+    expect(unmatched.filesMatchingPath).toBe(0)
+    expect(unmatched.hints).toContain('No searched file has "server/" in its path')
+
+    const unindexed = diagnoseEmptySearch('App', { indexed: [], searched: [] })
+    expect(unindexed.hints[0]).toContain('No files are indexed')
+  })
+
+  it('should point at the same name in another case or type', () => {
+    const diagnosis = diagnoseEmptySearch('userservice', { indexed: NODES, searched: NODES, types: ['function'] })
+    expect(diagnosis.caseInsensitiveMatches).toEqual([{ name: 'UserService', type: 'class', path: '/app/api/users.ts' }])
+    expect(diagnosis.hints).toEqual(['UserService of type class, which types leaves out, is declared in /app/api/users.ts; the filters or exactMatch may exclude it'])
+  })
+
+  it('should suggest the nearest symbol names', () => {
+    expect(diagnoseEmptySearch('getUsr', { indexed: NODES, searched: NODES }).suggestions).toEqual(['getUser'])
+    expect(nearestNames('seed_user', ['seed_users', 'seed', 'need_users', 'unrelated'])).toEqual(['seed_users', 'need_users', 'seed'])
+    expect(diagnoseEmptySearch('getUsr', { indexed: NODES, searched: NODES, symbols: false }).suggestions).toEqual([])
+  })
+
+  it('should suggest names by spelling and by shared words', () => {
+    const names = ['ChatProvider', 'ChatProviderProps', 'ProviderChat', 'useChatProvider', 'MessageList']
+    expect(nearestNames('ChatProvidor', names)[0]).toBe('ChatProvider')
+    expect(nearestNames('ProviderChat', ['ChatProvider', 'MessageList'])).toEqual(['ChatProvider'])
+    expect(nearestNames('chat_provider', names)).toContain('useChatProvider')
+    expect(nearestNames('Message', ['Massage', 'MessageList', 'Other'])).toEqual(['Massage', 'MessageList'])
+    expect(nameWords('parseHTTPResponse_v2')).toEqual(['parse', 'http', 'response', 'v2'])
+  })
+})
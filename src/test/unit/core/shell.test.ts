@@ -0,0 +1,43 @@
+/**
+ * Shell script and Makefile indexing
+ */
+
+import { describe, it, expect } from 'vitest'
+import { resolve } from 'path'
+import { readFileSync } from 'fs'
+import { parseFile } from '../../../core/parser.js'
+import { makefileToShell } from '../../../core/shell.js'
+
+describe('Shell support', () => {
+  const fixture = resolve(import.meta.dirname, '../../fixtures/shell-scripts')
+
+  it('should index functions and variable definitions in shell scripts', async () => {
+    const fileNode = await parseFile(resolve(fixture, 'scripts/setup.sh'))
+    const children = fileNode.children ?? []
+
+    expect(children.filter(child => child.type === 'function').map(child => child.name)).toEqual(['install_deps', 'prepare_data'])
+    expect(children.filter(child => child.type === 'variable').map(child => child.name)).toEqual(
+      expect.arrayContaining(['APP_ENV', 'DATA_DIR', 'requirements']),
+    )
+  })
+
+  it('should rewrite Makefiles into shell with the same line numbers', () => {
+    const content = readFileSync(resolve(fixture, 'Makefile'), 'utf-8')
+    const script = makefileToShell(content)
+    const lines = script.split('\n')
+
+    expect(lines).toHaveLength(content.split('\n').length)
+    expect(lines[1]).toBe('export GOFLAGS=\'-mod=mod\'')
+    expect(lines[6]).toBe('echo "building ${BIN}"')
+    expect(lines[4]).toBe('')
+  })
+
+  it('should index variables set in Makefile recipes', async () => {
+    const fileNode = await parseFile(resolve(fixture, 'Makefile'))
+    const variable = fileNode.children?.find(child => child.name === 'DATABASE_URL')
+
+    expect(variable?.type).toBe('variable')
+    expect(variable?.startLine).toBe(11)
+    expect(fileNode.children?.some(child => child.name === 'BIN')).toBe(true)
+  })
+})
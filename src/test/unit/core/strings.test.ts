@@ -0,0 +1,112 @@
+/**
+ * Searching the text of string literals, user-facing markup and comments
+ */
+
+import { describe, it, expect } from 'vitest'
+import { extractComments, extractStringLiterals, searchStrings, type CommentFilter } from '../../../core/strings.js'
+import { findUsage } from '../../../core/search.js'
+import type { TreeNode } from '../../../types/core.js'
+
+const file = (path: string, content: string, children: TreeNode[] = []): TreeNode => ({ id: path, type: 'file', path, content, children })
+
+describe('String literal search', () => {
+  const files = [
+    file('/app/src/orders.ts', `// Order could not be shipped: legacy message
+export function shipOrder(id: string) {
+  throw new Error(\`Order \${id} could not be shipped\`)
+}
+
+const label = 'Ship order'
+`, [{ id: 'shipOrder', type: 'function', name: 'shipOrder', path: '/app/src/orders.ts', startLine: 2, endLine: 4 }]),
+    file('/app/src/Checkout.tsx', `export const Checkout = () => (
+  <form>
+    <p>Don't close this page</p>
+    <input placeholder="Card number" />
+    <button type="submit">Pay now</button>
+  </form>
+)
+`),
+    file('/app/worker.py', `def retry(job):
+    # Retrying is silent
+    log.warning("Job %s failed after %d attempts", job.id, 3)
+    return f"retry {job.id}"
+`),
+    file('/app/main.go', 'package main\n\nvar sep = \'/\'\nvar msg = `raw "text"`\n'),
+  ]
+
+  it('should find the template behind a rendered message', () => {
+    const [match, ...rest] = searchStrings('Order 1234 could not be shipped', files)
+
+    expect(rest).toEqual([])
+    expect(match).toMatchObject({ text: 'Order ${id} could not be shipped', kind: 'template', file: '/app/src/orders.ts', line: 3, column: 19, symbol: 'shipOrder' })
+  })
+
+  it('should match printf placeholders and rank exact text first', () => {
+    expect(searchStrings('Job 42 failed after 3 attempts', files).map(match => match.line)).toEqual([3])
+    expect(searchStrings('ship order', files).map(match => match.text)).toEqual(['Ship order'])
+    expect(searchStrings('order', files).map(match => match.text)).toEqual(['Ship order', 'Order ${id} could not be shipped'])
+  })
+
+  it('should only search user-facing text in ui mode', () => {
+    expect(searchStrings('submit', files, { mode: 'ui' })).toEqual([])
+    expect(searchStrings('card', files, { mode: 'ui' }).map(match => [match.text, match.attribute, match.line])).toEqual([['Card number', 'placeholder', 4]])
+    expect(searchStrings('close this page', files, { mode: 'ui' })[0]).toMatchObject({ kind: 'markup', line: 3 })
+  })
+
+  it('should lex strings per language', () => {
+    const [checkout] = files.filter(node => node.path.endsWith('.tsx'))
+    expect(extractStringLiterals(checkout!.content!, checkout!.path).map(literal => [literal.kind, literal.text])).toEqual([
+      ['markup', 'Don\'t close this page'],
+      ['attribute', 'Card number'],
+      ['string', 'submit'],
+      ['markup', 'Pay now'],
+    ])
+
+    const [go] = files.filter(node => node.path.endsWith('.go'))
+    expect(extractStringLiterals(go!.content!, go!.path).map(literal => literal.text)).toEqual(['raw "text"'])
+
+    const [python] = files.filter(node => node.path.endsWith('.py'))
+    expect(extractStringLiterals(python!.content!, python!.path).map(literal => literal.kind)).toEqual(['string', 'template'])
+  })
+})
+
+describe('Comment search', () => {
+  const service = file('/app/src/session.ts', `/**
+ * Refreshes the session token.
+ * Called by the legacyLogin flow.
+ */
+export function refresh() {
+  // TODO: drop legacyLogin once
+  // every client has migrated
+  return legacyLogin(url('// not a comment'))
+}
+`)
+  const python = file('/app/auth.py', `def login():
+    """Log in through legacyLogin."""
+    message = """legacyLogin is gone"""  # legacyLogin
+    return legacyLogin()
+`)
+
+  it('should strip markers and join consecutive line comments', () => {
+    expect(extractComments(service.content!, service.path).map(comment => [comment.kind, comment.text, comment.line, comment.endLine])).toEqual([
+      ['docstring', 'Refreshes the session token.\nCalled by the legacyLogin flow.', 1, 4],
+      ['comment', 'TODO: drop legacyLogin once\nevery client has migrated', 6, 7],
+    ])
+    expect(extractComments(python.content!, python.path).map(comment => [comment.kind, comment.text])).toEqual([
+      ['docstring', 'Log in through legacyLogin.'],
+      ['comment', 'legacyLogin'],
+    ])
+  })
+
+  it('should match text wrapped over several comment lines', () => {
+    expect(searchStrings('legacyLogin once every client', [service], { mode: 'comments' }).map(match => match.line)).toEqual([6])
+  })
+
+  it('should exclude or keep only usages inside comments', () => {
+    const lines = (comments: CommentFilter) => findUsage('legacyLogin', [service, python], { comments }).map(usage => `${usage.node.path.slice(5)}:${usage.startLine}`)
+
+    expect(lines('exclude')).toEqual(['src/session.ts:8', 'auth.py:3', 'auth.py:4'])
+    expect(lines('only')).toEqual(['src/session.ts:3', 'src/session.ts:6', 'auth.py:2', 'auth.py:3'])
+    expect(lines('include')).toHaveLength(7)
+  })
+})
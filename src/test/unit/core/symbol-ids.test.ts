@@ -0,0 +1,81 @@
+/**
+ * Qualified symbol ids and disambiguation of names defined in several packages
+ */
+
+import { describe, it, expect, beforeEach, afterEach } from 'vitest'
+import { mkdirSync, mkdtempSync, rmSync, writeFileSync } from 'fs'
+import { tmpdir } from 'os'
+import { dirname, join } from 'path'
+import { createProject } from '../../../project/manager.js'
+import { findSymbolCandidates, findSymbolsById, isSymbolId, symbolId } from '../../../core/symbol-ids.js'
+import { findDependentFiles } from '../../../import/aliases.js'
+import type { Project, SymbolInfo, TreeNode } from '../../../types/core.js'
+
+describe('Symbol ids', () => {
+  let root: string
+  let project: Project
+
+  function addFile(path: string, content: string, declarations: [string, SymbolInfo][] = []) {
+    const filePath = join(root, path)
+    mkdirSync(dirname(filePath), { recursive: true })
+    writeFileSync(filePath, content)
+    const children: TreeNode[] = declarations.map(([name, symbol]) => ({ id: `${path}#${name}`, type: symbol.kind === 'method' ? 'function' : symbol.kind, name, path: filePath, startLine: 1, symbol }))
+    const file: TreeNode = { id: path, type: 'file', path: filePath, content, children }
+    project.files.set(filePath, file)
+    project.nodes.set(filePath, [file, ...children])
+  }
+
+  beforeEach(() => {
+    root = mkdtempSync(join(tmpdir(), 'ts-mcp-ids-'))
+    writeFileSync(join(root, 'package.json'), '{ "name": "acme" }')
+    mkdirSync(join(root, 'packages/web'), { recursive: true })
+    writeFileSync(join(root, 'packages/web/package.json'), '{ "name": "@acme/web" }')
+    project = createProject({ directory: root })
+    project.goModules = [{ path: 'example.com/api', directory: join(root, 'api') }]
+
+    addFile('packages/web/src/config.ts', 'export interface Config {}\n', [['Config', { kind: 'interface', visibility: 'public', signature: 'export interface Config' }]])
+    addFile('api/internal/config.go', 'package internal\n\ntype Config struct{}\n', [['Config', { kind: 'struct', visibility: 'public', signature: 'type Config struct' }]])
+    addFile('lib/store.ts', 'export class Store { load() {} }\n', [
+      ['Store', { kind: 'class', visibility: 'public', signature: 'export class Store' }],
+      ['load', { kind: 'method', visibility: 'public', signature: 'load()', container: 'Store' }],
+    ])
+    addFile('packages/web/src/app.ts', `import { Config } from './config'\n`)
+    addFile('lib/unrelated.ts', 'const Config = 1\n')
+  })
+
+  afterEach(() => {
+    rmSync(root, { recursive: true, force: true })
+  })
+
+  it('should qualify ids by file and container', () => {
+    const load = project.nodes.get(join(root, 'lib/store.ts'))!.find(node => node.name === 'load')!
+
+    expect(symbolId(root, load)).toBe('lib/store.ts#Store.load')
+    expect(isSymbolId('lib/store.ts#Store.load')).toBe(true)
+    expect(isSymbolId('Store.load')).toBe(false)
+    expect(findSymbolsById(project, 'lib/store.ts#Store.load')).toEqual([load])
+    expect(findSymbolsById(project, 'lib/store.ts#load')).toEqual([])
+  })
+
+  it('should list each definition of a name with its package', () => {
+    const candidates = findSymbolCandidates(project, 'Config')
+
+    expect(candidates.map(candidate => [candidate.id, candidate.kind, candidate.package])).toEqual([
+      ['api/internal/config.go#Config', 'struct', 'example.com/api/internal'],
+      ['packages/web/src/config.ts#Config', 'interface', '@acme/web'],
+    ])
+    expect(candidates[1]).toMatchObject({ signature: 'export interface Config', path: join(root, 'packages/web/src/config.ts') })
+  })
+
+  it('should filter candidates by container and kind', () => {
+    expect(findSymbolCandidates(project, 'Store.load').map(candidate => candidate.id)).toEqual(['lib/store.ts#Store.load'])
+    expect(findSymbolCandidates(project, 'Config', ['struct']).map(candidate => candidate.package)).toEqual(['example.com/api/internal'])
+  })
+
+  it('should limit dependents to files importing the declaration', () => {
+    const [config] = findSymbolsById(project, 'packages/web/src/config.ts#Config')
+    const files = findDependentFiles(project, config!)
+
+    expect([...files!].sort()).toEqual([join(root, 'packages/web/src/app.ts'), join(root, 'packages/web/src/config.ts')])
+  })
+})
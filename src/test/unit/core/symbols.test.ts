@@ -0,0 +1,183 @@
+/**
+ * Normalized symbol descriptions across languages
+ */
+
+import { describe, it, expect } from 'vitest'
+import { parseContent } from '../../../core/parser.js'
+import { parseProtoDefinitions, protoDefinitionsToNodes } from '../../../core/proto.js'
+import type { TreeNode } from '../../../types/core.js'
+
+function symbolsOf(content: string, filePath: string) {
+  const children = parseContent(content, filePath).children ?? []
+  return (name: string) => children.find((node: TreeNode) => node.name === name)?.symbol
+}
+
+describe('Symbol normalization', () => {
+  it('should describe TypeScript declarations', () => {
+    const symbol = symbolsOf(`/**
+ * Loads users
+ */
+export async function loadUsers(limit: number): Promise<User[]> {
+  return []
+}
+
+function helper() {}
+
+export class UserStore {
+  private cache = new Map();
+
+  // Saves a user
+  async save(user: User): Promise<void> {}
+
+  protected reset() {}
+}
+
+export interface User { id: string }
+`, 'store.ts')
+
+    expect(symbol('loadUsers')).toEqual({
+      kind: 'function',
+      visibility: 'public',
+      signature: 'async function loadUsers(limit: number): Promise<User[]>',
+      doc: 'Loads users',
+    })
+    expect(symbol('helper')).toMatchObject({ kind: 'function', visibility: 'internal' })
+    expect(symbol('UserStore')).toMatchObject({ kind: 'class', visibility: 'public' })
+    expect(symbol('save')).toMatchObject({ kind: 'method', visibility: 'public', container: 'UserStore', doc: 'Saves a user' })
+    expect(symbol('reset')).toMatchObject({ kind: 'method', visibility: 'protected' })
+    expect(symbol('User')).toMatchObject({ kind: 'interface', visibility: 'public' })
+  })
+
+  it('should follow Python naming conventions and read docstrings', () => {
+    const symbol = symbolsOf(`class Repo:
+    """Stores rows."""
+
+    def _load(self):
+        pass
+
+    def __flush(self):
+        pass
+
+
+def public_api(x: int) -> int:
+    """Doubles x."""
+    return x * 2
+`, 'repo.py')
+
+    expect(symbol('Repo')).toMatchObject({ kind: 'class', visibility: 'public', doc: 'Stores rows.' })
+    expect(symbol('_load')).toMatchObject({ kind: 'method', visibility: 'internal', container: 'Repo' })
+    expect(symbol('__flush')).toMatchObject({ visibility: 'private' })
+    expect(symbol('public_api')).toMatchObject({ kind: 'function', signature: 'def public_api(x: int) -> int', doc: 'Doubles x.' })
+  })
+
+  it('should attach Go methods to their receiver type', () => {
+    const symbol = symbolsOf(`package store
+
+// Server handles requests
+type Server struct {
+	addr string
+}
+
+func (s *Server) Start() error {
+	return nil
+}
+
+func helper() {}
+`, 'server.go')
+
+    expect(symbol('Server')).toEqual({ kind: 'struct', visibility: 'public', signature: 'type Server struct', doc: 'Server handles requests' })
+    expect(symbol('Start')).toMatchObject({ kind: 'method', visibility: 'public', container: 'Server', signature: 'func (s *Server) Start() error' })
+    expect(symbol('helper')).toMatchObject({ kind: 'function', visibility: 'internal' })
+  })
+
+  it('should capture Go type parameters and their constraints', () => {
+    const symbol = symbolsOf(`package collections
+
+type Set[K comparable] map[K]struct{}
+
+type Digest [32]byte
+
+func Sum[T ~int | ~float64](values []T) T {
+	var total T
+	return total
+}
+
+func (s Set[K]) Has(key K) bool {
+	_, ok := s[key]
+	return ok
+}
+`, 'set.go')
+
+    expect(symbol('Set')?.typeParameters).toEqual([{ name: 'K', constraint: 'comparable' }])
+    expect(symbol('Sum')?.typeParameters).toEqual([{ name: 'T', constraint: '~int | ~float64' }])
+    expect(symbol('Digest')?.typeParameters).toBeUndefined()
+    expect(symbol('Has')).toMatchObject({ kind: 'method', container: 'Set' })
+    expect(symbol('Has')?.typeParameters).toBeUndefined()
+  })
+
+  it('should describe C++ templates and out-of-class members', () => {
+    const symbol = symbolsOf(`// Bounded buffer
+template <typename T, int N = 16>
+class Ring {
+public:
+  void push(T value);
+};
+
+template <typename T, int N>
+void Ring<T, N>::push(T value) {}
+`, 'ring.hpp')
+
+    expect(symbol('Ring')).toMatchObject({
+      kind: 'class',
+      signature: 'template <typename T, int N = 16> class Ring',
+      doc: 'Bounded buffer',
+      typeParameters: [{ name: 'T', constraint: 'typename' }, { name: 'N', constraint: 'int' }],
+    })
+    expect(symbol('push')).toMatchObject({ kind: 'method', container: 'Ring' })
+  })
+
+  it('should read Rust visibility modifiers and impl blocks', () => {
+    const symbol = symbolsOf(`/// A point
+#[derive(Debug)]
+pub struct Point {
+    x: i32,
+}
+
+impl Point {
+    pub fn new() -> Self { Point { x: 0 } }
+    fn secret(&self) {}
+}
+
+pub(crate) trait Shape {
+    fn area(&self) -> f64;
+}
+`, 'point.rs')
+
+    expect(symbol('Point')).toMatchObject({ kind: 'struct', visibility: 'public', doc: 'A point' })
+    expect(symbol('new')).toMatchObject({ kind: 'method', visibility: 'public', container: 'Point' })
+    expect(symbol('secret')).toMatchObject({ kind: 'method', visibility: 'private' })
+    expect(symbol('Shape')).toMatchObject({ kind: 'trait', visibility: 'internal' })
+  })
+
+  it('should map proto definitions onto the same model', () => {
+    const content = `syntax = "proto3";
+package acme.v1;
+
+service Users {
+  rpc Watch(WatchRequest) returns (stream User);
+}
+
+message User {
+  message Address {}
+}
+`
+    const nodes = protoDefinitionsToNodes(parseProtoDefinitions(content), content, 'users.proto')
+    const symbol = (name: string) => nodes.find(node => node.name === name)?.symbol
+
+    expect(symbol('Users')).toMatchObject({ kind: 'interface', signature: 'service Users' })
+    expect(symbol('Watch')).toEqual({ kind: 'method', visibility: 'public', signature: 'rpc Watch(WatchRequest) returns (stream User)', container: 'Users' })
+    expect(symbol('User')).toMatchObject({ kind: 'struct' })
+    expect(symbol('User')?.container).toBeUndefined()
+    expect(symbol('Address')).toMatchObject({ kind: 'struct', container: 'User' })
+  })
+})
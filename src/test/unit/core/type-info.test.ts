@@ -0,0 +1,124 @@
+/**
+ * Declared types read from the source, and the declarations of those types
+ */
+
+import { describe, it, expect, beforeAll, afterAll } from 'vitest'
+import { mkdtempSync, rmSync, writeFileSync } from 'fs'
+import { tmpdir } from 'os'
+import { join } from 'path'
+import { getTypeInfo } from '../../../core/type-info.js'
+import { createProject } from '../../../project/manager.js'
+import type { Project, TreeNode } from '../../../types/core.js'
+
+const GO_SOURCE = `package main
+
+type User struct {
+	Name  string \`json:"name"\`
+	Email string
+}
+
+func greet(u *User, count int) string {
+	var label string
+	other := &User{}
+	return u.Name + label + other.Email
+}
+`
+
+const TS_SOURCE = `export class UserService {
+  private repo: UserRepository
+
+  load(id: string, retries = 3) {
+    return this.repo.find(id)
+  }
+}
+
+export interface UserRepository {
+  find(id: string): User
+}
+`
+
+function declaration(path: string, content: string, name: string, kind: string, startLine: number, endLine: number, signature: string, container?: string): TreeNode {
+  const lines = content.split('\n').slice(startLine - 1, endLine)
+  return {
+    id: `${name}-${startLine}`,
+    type: kind,
+    name,
+    path,
+    startLine,
+    endLine,
+    content: lines.join('\n'),
+    symbol: { kind: kind as 'function', visibility: 'public', signature, ...(container ? { container } : {}) },
+  }
+}
+
+describe('getTypeInfo', () => {
+  let root: string
+  let project: Project
+  let goFile: string
+  let tsFile: string
+
+  beforeAll(() => {
+    root = mkdtempSync(join(tmpdir(), 'ts-mcp-type-info-'))
+    goFile = join(root, 'main.go')
+    tsFile = join(root, 'service.ts')
+    writeFileSync(goFile, GO_SOURCE)
+    writeFileSync(tsFile, TS_SOURCE)
+
+    project = createProject({ directory: root })
+    project.nodes.set(goFile, [
+      declaration(goFile, GO_SOURCE, 'User', 'struct', 3, 6, 'type User struct'),
+      declaration(goFile, GO_SOURCE, 'greet', 'function', 8, 12, 'func greet(u *User, count int) string'),
+    ])
+    project.nodes.set(tsFile, [
+      declaration(tsFile, TS_SOURCE, 'UserService', 'class', 1, 7, 'export class UserService'),
+      declaration(tsFile, TS_SOURCE, 'load', 'method', 4, 6, 'load(id: string, retries = 3)', 'UserService'),
+      declaration(tsFile, TS_SOURCE, 'UserRepository', 'interface', 9, 11, 'export interface UserRepository'),
+    ])
+  })
+
+  afterAll(() => {
+    rmSync(root, { recursive: true, force: true })
+  })
+
+  it('should read parameter types and find the type declaration', async () => {
+    const info = await getTypeInfo(project, goFile, 11, 9, { precise: false })
+    expect(info.name).toBe('u')
+    expect(info.declaredType).toEqual({ type: '*User', role: 'parameter', path: goFile, line: 8, resolvedBy: 'tree-sitter' })
+    expect(info.typeDefinitions.map(definition => definition.name)).toEqual(['User'])
+    expect(info.typeDefinitions[0]!.content).toContain('Email string')
+  })
+
+  it('should read local declarations and composite literals', async () => {
+    const label = await getTypeInfo(project, goFile, 11, 19, { precise: false })
+    expect(label.declaredType?.type).toBe('string')
+    expect(label.declaredType?.line).toBe(9)
+    expect(label.typeDefinitions).toEqual([])
+
+    const other = await getTypeInfo(project, goFile, 10, 2, { precise: false })
+    expect(other.declaredType?.type).toBe('*User')
+  })
+
+  it('should resolve fields through the receiver type', async () => {
+    const name = await getTypeInfo(project, goFile, 11, 12, { precise: false })
+    expect(name.declaredType).toEqual({ type: 'string', role: 'field', path: goFile, line: 4, resolvedBy: 'tree-sitter' })
+
+    const email = await getTypeInfo(project, goFile, 11, 35, { precise: false })
+    expect(email.declaredType?.line).toBe(5)
+  })
+
+  it('should resolve this-members and annotated parameters', async () => {
+    const repo = await getTypeInfo(project, tsFile, 5, 17, { precise: false })
+    expect(repo.declaredType).toEqual({ type: 'UserRepository', role: 'field', path: tsFile, line: 2, resolvedBy: 'tree-sitter' })
+    expect(repo.typeDefinitions[0]!.kind).toBe('interface')
+
+    const id = await getTypeInfo(project, tsFile, 5, 27, { precise: false })
+    expect(id.declaredType?.type).toBe('string')
+    expect(id.declaredType?.role).toBe('parameter')
+  })
+
+  it('should report no type for names it cannot resolve', async () => {
+    const info = await getTypeInfo(project, tsFile, 1, 3, { precise: false })
+    expect(info.declaredType).toBeUndefined()
+    expect(info.languageServer.status).toBe('disabled')
+  })
+})
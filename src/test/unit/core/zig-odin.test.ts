@@ -0,0 +1,133 @@
+/**
+ * Zig and Odin definitions
+ */
+
+import { describe, it, expect } from 'vitest'
+import { extractZigDefinitions } from '../../../core/zig.js'
+import { extractOdinDefinitions } from '../../../core/odin.js'
+
+const ZIG = `const std = @import("std");
+
+/// A point in 2D space.
+pub const Point = struct {
+    x: f32,
+    y: f32,
+
+    /// Distance from the origin.
+    pub fn length(self: Point) f32 {
+        return @sqrt(self.x * self.x + self.y * self.y);
+    }
+
+    fn origin() Point {
+        return .{ .x = 0, .y = 0 };
+    }
+};
+
+pub const Color = enum(u8) { red, green, blue };
+const ParseError = error{ InvalidChar, Overflow };
+
+pub fn List(comptime T: type) type {
+    return struct {
+        items: []T,
+        pub fn append(self: *@This(), item: T) !void {
+            _ = item; // fn fake() {
+        }
+    };
+}
+
+extern "c" fn write(fd: c_int, buf: [*]const u8, len: usize) isize;
+
+export fn add(a: i32, b: i32) i32 {
+    const msg = "} unbalanced {";
+    _ = msg;
+    return a + b;
+}
+`
+
+const ODIN = `package geometry
+
+import "core:fmt"
+
+// A point in 2D space.
+Point :: struct {
+    x, y: f32,
+}
+
+Shape :: union { Point, Circle }
+Direction :: enum u8 { North, East, South, West }
+Flags :: bit_set[Direction; u8]
+Meters :: distinct f32
+Callback :: #type proc(p: Point) -> bool
+
+// Distance from the origin.
+@(require_results)
+length :: proc(p: Point) -> f32 {
+    helper :: proc() {}
+    fmt.println("}")
+    return p.x
+}
+
+@(private="file")
+scale :: proc "contextless" (p: ^Point, by: f32) {
+    p.x *= by
+}
+
+area :: proc{area_circle, area_rect}
+
+foreign libc {
+    puts :: proc "c" (s: cstring) -> i32 ---
+}
+`
+
+describe('Zig', () => {
+  it('should index containers and functions with their containers', () => {
+    const nodes = extractZigDefinitions(ZIG, '/p/src/geometry.zig')
+    expect(nodes.map(node => [node.type, node.name, node.startLine, node.endLine, node.symbol?.kind, node.symbol?.container, node.symbol?.visibility])).toEqual([
+      ['class', 'Point', 4, 16, 'struct', undefined, 'public'],
+      ['function', 'length', 9, 11, 'method', 'Point', 'public'],
+      ['function', 'origin', 13, 15, 'function', 'Point', 'private'],
+      ['class', 'Color', 18, 18, 'enum', undefined, 'public'],
+      ['class', 'ParseError', 19, 19, 'enum', undefined, 'private'],
+      ['function', 'List', 21, 28, 'function', undefined, 'public'],
+      ['function', 'append', 24, 26, 'method', 'List', 'public'],
+      ['function', 'write', 30, 30, 'function', undefined, 'private'],
+      ['function', 'add', 32, 36, 'function', undefined, 'public'],
+    ])
+
+    const byName = (name: string) => nodes.find(node => node.name === name)!.symbol
+    expect(byName('Point')).toMatchObject({ doc: 'A point in 2D space.', signature: 'pub const Point = struct' })
+    expect(byName('length')).toMatchObject({ doc: 'Distance from the origin.', signature: 'pub fn length(self: Point) f32' })
+    expect(byName('Color')?.signature).toBe('pub const Color = enum(u8)')
+  })
+})
+
+describe('Odin', () => {
+  it('should index procedures and types with their visibility', () => {
+    const nodes = extractOdinDefinitions(ODIN, '/p/geometry/point.odin')
+    expect(nodes.map(node => [node.type, node.name, node.startLine, node.endLine, node.symbol?.kind, node.symbol?.container, node.symbol?.visibility])).toEqual([
+      ['class', 'Point', 6, 8, 'struct', undefined, 'public'],
+      ['class', 'Shape', 10, 10, 'type', undefined, 'public'],
+      ['class', 'Direction', 11, 11, 'enum', undefined, 'public'],
+      ['class', 'Flags', 12, 12, 'type', undefined, 'public'],
+      ['class', 'Meters', 13, 13, 'type', undefined, 'public'],
+      ['class', 'Callback', 14, 14, 'type', undefined, 'public'],
+      ['function', 'length', 18, 22, 'function', undefined, 'public'],
+      ['function', 'helper', 19, 19, 'function', 'length', 'public'],
+      ['function', 'scale', 25, 27, 'function', undefined, 'private'],
+      ['function', 'area', 29, 29, 'function', undefined, 'public'],
+      ['function', 'puts', 32, 32, 'function', 'libc', 'public'],
+    ])
+
+    const byName = (name: string) => nodes.find(node => node.name === name)!.symbol
+    expect(byName('Point')?.doc).toBe('A point in 2D space.')
+    expect(byName('length')).toMatchObject({ doc: 'Distance from the origin.', signature: 'length :: proc(p: Point) -> f32' })
+    expect(byName('scale')?.signature).toBe('scale :: proc "contextless" (p: ^Point, by: f32)')
+    expect(byName('puts')?.signature).toBe('puts :: proc "c" (s: cstring) -> i32')
+    expect(byName('Flags')?.signature).toBe('Flags :: bit_set[Direction; u8]')
+  })
+
+  it('should make every declaration of a #+private file private', () => {
+    const nodes = extractOdinDefinitions(`#+private\npackage geometry\n\nhelper :: proc() {}\n`, '/p/geometry/internal.odin')
+    expect(nodes.map(node => [node.name, node.symbol?.visibility])).toEqual([['helper', 'private']])
+  })
+})
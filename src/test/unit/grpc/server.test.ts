@@ -0,0 +1,76 @@
+/**
+ * gRPC API: the published service against its implementation, and the tool calls behind it
+ */
+
+import { describe, it, expect } from 'vitest'
+import { readFileSync } from 'fs'
+import { createGRPCMethods, fromStruct, GRPC_SERVICE, PROTO_PATH, toStruct, type ToolCaller } from '../../../grpc/server.js'
+import { parseProtoDefinitions } from '../../../core/proto.js'
+import { MCP_TOOLS } from '../../../mcp/schemas.js'
+import type { JsonObject } from '../../../types/core.js'
+
+function recordingCaller(responses: Record<string, JsonObject>) {
+  const calls: Array<[string, JsonObject]> = []
+  const callTool: ToolCaller = async (name, args) => {
+    calls.push([name, args])
+    if (!responses[name]) throw new Error(`Unknown tool: ${name}`)
+    return responses[name]
+  }
+  return { calls, callTool }
+}
+
+describe('gRPC API', () => {
+  it('should implement every RPC the published service declares', () => {
+    const { services } = parseProtoDefinitions(readFileSync(PROTO_PATH, 'utf-8'))
+    const service = services.find(candidate => candidate.fullName === GRPC_SERVICE)
+    expect(service).toBeDefined()
+    expect(service!.rpcs.map(rpc => rpc.name).sort()).toEqual(Object.keys(createGRPCMethods(async () => ({}))).sort())
+  })
+
+  it('should convert JSON to google.protobuf.Struct and back', () => {
+    const json: JsonObject = { query: 'parse', maxResults: 5, exactMatch: true, types: ['function', 'method'], nested: { ref: null } }
+    expect(toStruct({ types: ['function'], ref: null }).fields).toEqual({
+      types: { listValue: { values: [{ stringValue: 'function' }] } },
+      ref: { nullValue: 'NULL_VALUE' },
+    })
+    expect(fromStruct(toStruct(json))).toEqual(json)
+    expect(fromStruct(undefined)).toEqual({})
+  })
+
+  it('should run the MCP tools behind typed and generic RPCs', async () => {
+    const { calls, callTool } = recordingCaller({
+      search_code: { projectId: 'app', query: 'parse', results: [{ name: 'parse', path: '/app/parser.ts', startLine: 3 }], totalResults: 1 },
+      analyze_code: {
+        analysis: {
+          projectId: 'app',
+          findings: [{ type: 'quality', category: 'long_method', severity: 'warning', location: 'a.ts:1', description: 'Long', metrics: { lines: 80 } }],
+          summary: { totalFindings: 1, criticalFindings: 0, warningFindings: 1, infoFindings: 0 },
+          totalFindings: 1,
+        },
+      },
+    })
+    const methods = createGRPCMethods(callTool)
+
+    expect(await methods.SearchCode!({ projectId: 'app', query: 'parse', types: ['function'] })).toMatchObject({ totalResults: 1 })
+    expect(calls[0]).toEqual(['search_code', { projectId: 'app', query: 'parse', types: ['function'], mode: 'symbols' }])
+
+    const analysis = await methods.AnalyzeCode!({ directory: '/app', analysisTypes: ['quality'] })
+    expect(analysis.findings).toEqual([{
+      type: 'quality',
+      category: 'long_method',
+      severity: 'warning',
+      location: 'a.ts:1',
+      description: 'Long',
+      metrics: { fields: { lines: { numberValue: 80 } } },
+    }])
+    expect(analysis.summary).toMatchObject({ warningFindings: 1 })
+
+    const generic = await methods.CallTool!({ name: 'search_code', arguments: toStruct({ query: 'parse', mode: 'strings' }) as unknown as JsonObject })
+    expect(calls[2]).toEqual(['search_code', { query: 'parse', mode: 'strings' }])
+    expect(fromStruct(generic.result as ReturnType<typeof toStruct>)).toMatchObject({ totalResults: 1 })
+    await expect(methods.CallTool!({ name: 'rename_everything' })).rejects.toThrow('Unknown tool: rename_everything')
+
+    const { tools } = await methods.ListTools!({}) as { tools: Array<{ name: string, readOnly: boolean }> }
+    expect(tools.map(tool => tool.name)).toEqual(MCP_TOOLS.map(tool => tool.name))
+  })
+})
@@ -0,0 +1,161 @@
+/**
+ * Following import aliases and re-exports
+ */
+
+import { describe, it, expect } from 'vitest'
+import { createProject } from '../../../project/manager.js'
+import { buildAliasIndex, findAliasedDefinitions, findAliasExpressions, findDependentFiles, resolveQualifiedName, resolveReExportChains } from '../../../import/aliases.js'
+import { findUsage, searchCode } from '../../../core/search.js'
+import type { Project, TreeNode } from '../../../types/core.js'
+
+function projectWith(files: Record<string, { content: string, defines?: string[] }>): Project {
+  const project = createProject({ directory: '/p' })
+  for (const [path, { content, defines = [] }] of Object.entries(files)) {
+    const filePath = `/p/${path}`
+    const children: TreeNode[] = defines.map(name => ({ id: `${path}#${name}`, type: 'function', name, path: filePath, startLine: 1, endLine: 3 }))
+    const file: TreeNode = { id: path, type: 'file', name: path, path: filePath, content, children }
+    project.files.set(filePath, file)
+    project.nodes.set(filePath, [file, ...children])
+  }
+  return project
+}
+
+const scriptProject = () => projectWith({
+  'src/format.ts': { content: 'export function Date(value: number) {\n  return String(value)\n}\n', defines: ['Date'] },
+  'src/utils/index.ts': { content: `export { Date as FormatDate } from '../format.js'\nexport * from './strings'\n` },
+  'src/utils/strings.ts': { content: 'export function pad(value: string) {\n  return value\n}\n', defines: ['pad'] },
+  'src/app.ts': { content: `import * as utils from './utils'\n\nconst label = utils.FormatDate(Date.now())\nutils.pad(label)\n` },
+  'src/view.ts': { content: `import { FormatDate as fd } from './utils/index.js'\n\nexport const when = fd(0)\n` },
+})
+
+describe('resolveQualifiedName', () => {
+  it('should follow namespace imports and re-exports to the definition', () => {
+    const index = buildAliasIndex(scriptProject())
+
+    expect(resolveQualifiedName(index, 'utils.FormatDate')).toEqual([{ module: '/p/src/format.ts', name: 'Date' }])
+    expect(resolveQualifiedName(index, 'fd')).toEqual([{ module: '/p/src/format.ts', name: 'Date' }])
+    expect(resolveQualifiedName(index, 'utils.pad')).toEqual([{ module: '/p/src/utils/strings.ts', name: 'pad' }])
+  })
+
+  it('should not resolve names that are neither qualified nor aliased', () => {
+    const index = buildAliasIndex(scriptProject())
+
+    expect(resolveQualifiedName(index, 'Date')).toEqual([])
+    expect(resolveQualifiedName(index, 'utils.missing')).toEqual([])
+  })
+
+  it('should resolve Go package aliases', () => {
+    const project = projectWith({
+      'format/date.go': { content: 'package format\n\nfunc Date() string { return "" }\n', defines: ['Date'] },
+      'utils/utils.go': { content: 'package utils\n\nimport f "example.com/app/format"\n\nvar FormatDate = f.Date\n' },
+      'main.go': { content: 'package main\n\nimport "example.com/app/utils"\n\nfunc main() { utils.FormatDate() }\n' },
+    })
+    project.goModules = [{ path: 'example.com/app', directory: '/p' }]
+
+    expect(resolveQualifiedName(buildAliasIndex(project), 'utils.FormatDate')).toEqual([{ module: '/p/format', name: 'Date' }])
+  })
+})
+
+describe('barrel re-exports', () => {
+  const barrelProject = () => projectWith({
+    'src/format/date.ts': { content: 'export function Date() {}\n', defines: ['Date'] },
+    'src/format/index.ts': { content: `export * from './date'\n` },
+    'src/index.ts': { content: `export { Date as FormatDate } from './format/index.js'\nexport * from './format'\n` },
+    'src/app.ts': { content: `import * as lib from './index'\n` },
+  })
+
+  it('should follow export-from and star re-exports through nested barrels', () => {
+    const chains = resolveReExportChains(buildAliasIndex(barrelProject()), 'lib.FormatDate')
+
+    expect(chains).toEqual([[
+      { module: '/p/src/index.ts', name: 'FormatDate' },
+      { module: '/p/src/format/index.ts', name: 'Date' },
+      { module: '/p/src/format/date.ts', name: 'Date' },
+    ]])
+  })
+
+  it('should point plain names exported by a barrel at the defining file', () => {
+    const matches = findAliasedDefinitions(barrelProject(), 'Date')
+
+    expect(matches.map(match => match.node.path)).toEqual(['/p/src/format/date.ts'])
+    expect(matches[0]?.reExports).toEqual([{ path: '/p/src/format/index.ts', name: 'Date' }])
+  })
+
+  it('should include the chain in search results', () => {
+    const project = barrelProject()
+    const nodes = Array.from(project.nodes.values()).flat()
+    const [result] = searchCode('FormatDate', nodes, { aliasMatches: findAliasedDefinitions(project, 'FormatDate') })
+
+    expect(result?.node.path).toBe('/p/src/format/date.ts')
+    expect(result?.reExports).toEqual([
+      { path: '/p/src/index.ts', name: 'FormatDate' },
+      { path: '/p/src/format/index.ts', name: 'Date' },
+    ])
+  })
+})
+
+describe('findAliasExpressions', () => {
+  it('should list every name the definition is used by, per file', () => {
+    const expressions = findAliasExpressions(scriptProject(), 'Date')
+
+    expect(expressions.get('/p/src/app.ts')).toEqual(['utils.FormatDate'])
+    expect(expressions.get('/p/src/view.ts')).toEqual(['fd'])
+    expect(expressions.get('/p/src/utils/index.ts')).toEqual(['FormatDate'])
+  })
+})
+
+describe('alias-aware search', () => {
+  it('should rank the underlying definition first for a qualified query', () => {
+    const project = scriptProject()
+    const nodes = Array.from(project.nodes.values()).flat()
+    const results = searchCode('utils.FormatDate', nodes, { aliasMatches: findAliasedDefinitions(project, 'utils.FormatDate') })
+
+    expect(results[0]?.node.name).toBe('Date')
+    expect(results[0]?.matches).toContain('alias')
+  })
+
+  it('should report aliased usages with the expression they were found by', () => {
+    const project = scriptProject()
+    const usages = findUsage('Date', [...project.files.values()], { aliases: findAliasExpressions(project, 'Date') })
+
+    expect(usages.find(usage => usage.node.path === '/p/src/app.ts' && usage.via)).toMatchObject({ via: 'utils.FormatDate', startLine: 3 })
+    expect(usages.filter(usage => usage.node.path === '/p/src/view.ts').map(usage => [usage.startLine, usage.via])).toEqual([[1, 'fd'], [3, 'fd']])
+  })
+})
+
+describe('package entry points', () => {
+  const dualProject = () => {
+    const project = projectWith({
+      'packages/core/esm/index.mjs': { content: 'export function parse() {}\n', defines: ['parse'] },
+      'packages/core/cjs/index.cjs': { content: 'function parse() {}\nmodule.exports = { parse }\n', defines: ['parse'] },
+      'packages/core/src/utils.ts': { content: 'export function slugify() {}\n', defines: ['slugify'] },
+      'apps/web/main.ts': { content: `import { parse } from '@app/core'\nimport { slugify as slug } from '@app/core/utils'\n` },
+      'apps/cli/main.js': { content: `const { parse: read } = require('@app/core')\n` },
+    })
+    project.packages = [{
+      name: '@app/core',
+      directory: '/p/packages/core',
+      source: '/p/packages/core/package.json',
+      exports: {
+        '.': { import: './esm/index.mjs', require: './cjs/index.cjs' },
+        './*': { types: './dist/*.d.ts', default: './dist/*.js' },
+      },
+    }]
+    return project
+  }
+
+  it('should resolve imports and requires of a dual package to the file each loads', () => {
+    const project = dualProject()
+    const index = buildAliasIndex(project)
+
+    expect(index.imports.get('/p/apps/web/main.ts')).toEqual([
+      { local: 'parse', module: '/p/packages/core/esm/index.mjs', imported: 'parse' },
+      { local: 'slug', module: '/p/packages/core/src/utils.ts', imported: 'slugify' },
+    ])
+    expect(index.imports.get('/p/apps/cli/main.js')).toEqual([{ local: 'read', module: '/p/packages/core/cjs/index.cjs', imported: 'parse' }])
+
+    const definition = (path: string) => project.files.get(path)!.children![0]!
+    expect([...findDependentFiles(project, definition('/p/packages/core/cjs/index.cjs'))!]).toEqual(['/p/packages/core/cjs/index.cjs', '/p/apps/cli/main.js'])
+    expect([...findDependentFiles(project, definition('/p/packages/core/esm/index.mjs'))!]).toEqual(['/p/packages/core/esm/index.mjs', '/p/apps/web/main.ts'])
+  })
+})
@@ -0,0 +1,120 @@
+/**
+ * Following Objective-C and Swift bridging
+ */
+
+import { describe, it, expect } from 'vitest'
+import { createProject } from '../../../project/manager.js'
+import { findBridgedExpressions, objcNameOf, swiftNameOf } from '../../../import/bridging.js'
+import { findAliasExpressions } from '../../../import/aliases.js'
+import { findUsage } from '../../../core/search.js'
+import { extractObjCDefinitions, extractObjCHeaderDefinitions } from '../../../core/objc.js'
+import { extractSwiftDefinitions } from '../../../core/swift.js'
+import type { Project, TreeNode } from '../../../types/core.js'
+
+function projectWith(files: Record<string, string>): Project {
+  const project = createProject({ directory: '/nonexistent/app' })
+  for (const [path, content] of Object.entries(files)) {
+    const filePath = `/nonexistent/app/${path}`
+    const children = path.endsWith('.swift')
+      ? extractSwiftDefinitions(content, filePath)
+      : path.endsWith('.h') ? extractObjCHeaderDefinitions(content, filePath) : extractObjCDefinitions(content, filePath)
+    const file: TreeNode = { id: path, type: 'file', name: path, path: filePath, content, children }
+    project.files.set(filePath, file)
+    project.nodes.set(filePath, [file, ...children])
+  }
+  return project
+}
+
+const mixedProject = () => projectWith({
+  'App/App-Bridging-Header.h': '#import "Legacy/Includes.h"\n',
+  'App/Legacy/Includes.h': '#import "DocumentLoader.h"\n',
+  'App/Legacy/DocumentLoader.h': `@interface DocumentLoader : NSObject
+- (void)loadDataWithURL:(NSURL *)url;
+- (void)cancelAll NS_SWIFT_NAME(stop());
+@end
+`,
+  'App/Legacy/DocumentLoader.m': `#import "DocumentLoader.h"
+#import "App-Swift.h"
+
+@implementation DocumentLoader
+- (void)loadDataWithURL:(NSURL *)url {
+  [[[DocumentView alloc] init] reloadWithAnimation:YES];
+}
+- (void)cancelAll {
+}
+@end
+`,
+  'App/Legacy/Unbridged.h': `@interface Unbridged : NSObject
+- (void)loadDataWithURL:(NSURL *)url;
+@end
+`,
+  'App/DocumentView.swift': `class DocumentView: UIView {
+  @objc(reloadWithAnimation:)
+  func reload(animated: Bool) {
+    loader.loadData(URL(string: "x")!)
+    loader.stop()
+  }
+
+  @objc func load(url: URL) {}
+}
+`,
+})
+
+describe('bridged names', () => {
+  it('should translate Objective-C selectors to their Swift names', () => {
+    const nodes = extractObjCHeaderDefinitions(`@interface A : NSObject
+- (void)loadDataWithURL:(NSURL *)url;
+- (void)cancelAll NS_SWIFT_NAME(stop());
+- (instancetype)initWithName:(NSString *)name;
+- (void)refresh;
+@end
+`, '/a.h')
+
+    expect(nodes.slice(1).map(swiftNameOf)).toEqual(['loadData', 'stop', undefined, 'refresh'])
+  })
+
+  it('should translate Swift declarations to their Objective-C names', () => {
+    const nodes = extractSwiftDefinitions(`class A {
+  @objc func load(url: URL) {}
+  @objc func show(_ view: UIView) {}
+  @objc(fetchWithPath:) func fetch(path: String) {}
+  @objc init(name: String) {}
+}
+`, '/a.swift')
+
+    expect(nodes.slice(1).map(objcNameOf)).toEqual(['loadWithUrl', 'show', 'fetchWithPath', 'initWithName'])
+  })
+})
+
+describe('findBridgedExpressions', () => {
+  it('should search Swift files for Objective-C declarations reachable from the bridging header', () => {
+    const expressions = findBridgedExpressions(mixedProject(), 'loadDataWithURL')
+
+    expect(expressions).toEqual(new Map([['/nonexistent/app/App/DocumentView.swift', ['loadData']]]))
+  })
+
+  it('should search Objective-C files importing the generated header for @objc declarations', () => {
+    const project = mixedProject()
+
+    expect(findBridgedExpressions(project, 'reload')).toEqual(new Map([['/nonexistent/app/App/Legacy/DocumentLoader.m', ['reloadWithAnimation']]]))
+    expect(findBridgedExpressions(project, 'load')).toEqual(new Map([['/nonexistent/app/App/Legacy/DocumentLoader.m', ['loadWithUrl']]]))
+  })
+
+  it('should find usages across the language boundary', () => {
+    const project = mixedProject()
+    const files = [...project.files.values()]
+    const usages = findUsage('cancelAll', files, { aliases: findAliasExpressions(project, 'cancelAll') })
+
+    expect(usages.map(usage => [usage.node.path.replace('/nonexistent/app/', ''), usage.startLine, usage.via])).toContainEqual(['App/DocumentView.swift', 5, 'stop'])
+  })
+
+  it('should not bridge headers outside the bridging header closure', () => {
+    const project = projectWith({
+      'App/App-Bridging-Header.h': '',
+      'App/Legacy/Unbridged.h': '@interface Unbridged : NSObject\n- (void)loadDataWithURL:(NSURL *)url;\n@end\n',
+      'App/View.swift': 'func show() {}\n',
+    })
+
+    expect(findBridgedExpressions(project, 'loadDataWithURL').size).toBe(0)
+  })
+})
@@ -0,0 +1,45 @@
+/**
+ * Library API: opening an index and keeping it current
+ */
+
+import { describe, it, expect, beforeEach, afterEach } from 'vitest'
+import { mkdirSync, mkdtempSync, rmSync, writeFileSync } from 'fs'
+import { tmpdir } from 'os'
+import { join } from 'path'
+import { openIndex } from '../../library.js'
+
+describe('library API', () => {
+  let root: string
+
+  beforeEach(() => {
+    root = mkdtempSync(join(tmpdir(), 'ts-mcp-library-'))
+    mkdirSync(join(root, 'lua'))
+    writeFileSync(join(root, 'lua/greet.lua'), 'local function greet(name)\n  return "hi " .. name\nend\n')
+    writeFileSync(join(root, 'README.md'), '# Greeter\n\nCall greet with a name.\n')
+  })
+
+  afterEach(() => {
+    rmSync(root, { recursive: true, force: true })
+  })
+
+  it('should search and find usages in the directory it opens', async () => {
+    const index = await openIndex(root)
+
+    expect(index.stats()).toMatchObject({ totalFiles: 2 })
+    expect(index.search('greet', { types: ['function'] }).map(result => result.node.path)).toEqual([join(root, 'lua/greet.lua')])
+    expect([...new Set(index.findUsage('greet').map(result => result.node.path))].sort()).toEqual([join(root, 'README.md'), join(root, 'lua/greet.lua')])
+    expect(index.diagnostics()).toEqual([])
+  })
+
+  it('should apply changed and removed paths relative to the project', async () => {
+    const index = await openIndex({ directory: root })
+
+    writeFileSync(join(root, 'lua/greet.lua'), 'local function welcome(name)\n  return name\nend\n')
+    rmSync(join(root, 'README.md'))
+    await index.update(['lua/greet.lua', 'README.md'])
+
+    expect(index.search('welcome', { types: ['function'] }).map(result => result.node.name)).toEqual(['welcome'])
+    expect(index.search('greet', { types: ['function'] })).toEqual([])
+    expect([...index.project.files.keys()]).toEqual([join(root, 'lua/greet.lua')])
+  })
+})
@@ -0,0 +1,109 @@
+/**
+ * LSP bridge: framing and the definition, reference and symbol requests
+ */
+
+import { describe, it, expect, beforeAll, afterAll } from 'vitest'
+import { mkdtempSync, rmSync, writeFileSync } from 'fs'
+import { tmpdir } from 'os'
+import { join } from 'path'
+import { PassThrough } from 'stream'
+import { pathToFileURL } from 'url'
+import { createLSPSession, handleLSPMessage, serveLSP, type LSPSession } from '../../../lsp/server.js'
+import type { LSPMessage } from '../../../lsp/protocol.js'
+import { clearMCPMemory } from '../../../mcp/handlers.js'
+
+const SOURCE = `const std = @import("std");
+
+pub fn greet(name: []const u8) void {
+    std.debug.print("hi {s}\\n", .{name});
+}
+
+pub fn main() void {
+    greet("zig");
+}
+`
+
+describe('LSP bridge', () => {
+  let root: string
+  let uri: string
+  let session: LSPSession
+  let nextId = 1
+
+  const request = async (method: string, params: object) => {
+    const response = await handleLSPMessage(session, { jsonrpc: '2.0', id: nextId++, method, params: params as LSPMessage['params'] })
+    return response!
+  }
+
+  beforeAll(async () => {
+    root = mkdtempSync(join(tmpdir(), 'ts-mcp-lsp-'))
+    const file = join(root, 'main.zig')
+    writeFileSync(file, SOURCE)
+    uri = pathToFileURL(file).href
+    session = createLSPSession()
+    await request('initialize', { rootUri: pathToFileURL(root).href, capabilities: {} })
+  })
+
+  afterAll(() => {
+    clearMCPMemory()
+    rmSync(root, { recursive: true, force: true })
+  })
+
+  it('should refuse requests before initialize', async () => {
+    const response = await handleLSPMessage(createLSPSession(), { jsonrpc: '2.0', id: 1, method: 'workspace/symbol', params: { query: 'greet' } })
+    expect(response?.error?.code).toBe(-32002)
+  })
+
+  it('should find the declaration of the word under the cursor', async () => {
+    const { result } = await request('textDocument/definition', { textDocument: { uri }, position: { line: 7, character: 6 } })
+    const locations = result as { uri: string, range: { start: { line: number } } }[]
+    expect(locations).toHaveLength(1)
+    expect(locations[0]!.uri).toBe(uri)
+    expect(locations[0]!.range.start.line).toBe(2)
+  })
+
+  it('should find references with and without the declaration', async () => {
+    const params = { textDocument: { uri }, position: { line: 2, character: 9 } }
+    const all = (await request('textDocument/references', { ...params, context: { includeDeclaration: true } })).result as { range: { start: { line: number, character: number } } }[]
+    expect(all.map(location => location.range.start)).toEqual([{ line: 2, character: 7 }, { line: 7, character: 4 }])
+
+    const usages = (await request('textDocument/references', { ...params, context: { includeDeclaration: false } })).result as unknown[]
+    expect(usages).toHaveLength(1)
+  })
+
+  it('should answer from the open document rather than the file on disk', async () => {
+    await handleLSPMessage(session, { jsonrpc: '2.0', method: 'textDocument/didOpen', params: { textDocument: { uri, text: '\n\n\n\n\n\n\nmain();\n' } } })
+    const { result } = await request('textDocument/definition', { textDocument: { uri }, position: { line: 7, character: 1 } })
+    expect((result as { range: { start: { line: number } } }[])[0]!.range.start.line).toBe(6)
+    await handleLSPMessage(session, { jsonrpc: '2.0', method: 'textDocument/didClose', params: { textDocument: { uri } } })
+  })
+
+  it('should list document and workspace symbols', async () => {
+    const symbols = (await request('textDocument/documentSymbol', { textDocument: { uri } })).result as { name: string, kind: number }[]
+    expect(symbols.map(symbol => symbol.name)).toContain('greet')
+    expect(symbols.map(symbol => symbol.name)).toContain('main')
+    expect(symbols.find(symbol => symbol.name === 'greet')!.kind).toBe(12)
+
+    const found = (await request('workspace/symbol', { query: 'greet' })).result as { name: string }[]
+    expect(found[0]!.name).toBe('greet')
+  })
+
+  it('should report unsupported methods', async () => {
+    const response = await request('textDocument/hover', { textDocument: { uri }, position: { line: 0, character: 0 } })
+    expect(response.error?.code).toBe(-32601)
+  })
+
+  it('should frame responses with Content-Length', async () => {
+    const input = new PassThrough()
+    const output = new PassThrough()
+    serveLSP(input, output, () => undefined)
+
+    const body = JSON.stringify({ jsonrpc: '2.0', id: 1, method: 'shutdown' })
+    input.write(`Content-Length: ${Buffer.byteLength(body)}\r\n\r\n${body.substring(0, 10)}`)
+    input.write(body.substring(10))
+
+    const written = await new Promise<string>(resolve => output.on('data', (chunk: Buffer) => {
+      if (!chunk.toString().startsWith('Content-Length')) resolve(chunk.toString())
+    }))
+    expect(written).toContain('Server not initialized')
+  })
+})
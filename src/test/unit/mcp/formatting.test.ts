@@ -0,0 +1,68 @@
+/**
+ * When edits run a formatter: the server's `--format-edits` decides the default, never the
+ * indexed project, and read-only servers and tenants run none
+ */
+
+import { describe, it, expect, beforeAll, afterAll, afterEach } from 'vitest'
+import { chmodSync, existsSync, mkdirSync, mkdtempSync, rmSync, writeFileSync } from 'fs'
+import { tmpdir } from 'os'
+import { delimiter, join } from 'path'
+import { clearMCPMemory, handleToolRequest, setFormatEdits, setReadOnlyMode } from '../../../mcp/handlers.js'
+import { parseTenants, authenticate, authorizeCall } from '../../../mcp/tenants.js'
+
+describe('formatting edits', () => {
+  let root: string
+  let marker: string
+  const path = process.env.PATH
+
+  beforeAll(() => {
+    root = mkdtempSync(join(tmpdir(), 'ts-mcp-formatting-'))
+    marker = join(root, 'formatted')
+    // A gofmt that leaves a mark when run and passes its input through
+    mkdirSync(join(root, 'bin'))
+    writeFileSync(join(root, 'bin', 'gofmt'), `#!/bin/sh\necho run > ${JSON.stringify(marker)}\ncat\n`)
+    chmodSync(join(root, 'bin', 'gofmt'), 0o755)
+    process.env.PATH = `${join(root, 'bin')}${delimiter}${path}`
+    writeFileSync(join(root, 'main.go'), 'package main\n\nfunc greet() {}\n')
+    writeFileSync(join(root, '.tree-sitter-mcp.json'), JSON.stringify({ edits: { format: true } }))
+  })
+
+  afterEach(() => {
+    setFormatEdits(false)
+    setReadOnlyMode(false)
+    rmSync(marker, { force: true })
+  })
+
+  afterAll(() => {
+    process.env.PATH = path
+    clearMCPMemory()
+    rmSync(root, { recursive: true, force: true })
+  })
+
+  async function dryRun(format?: boolean) {
+    const result = await handleToolRequest({ params: { name: 'edit_at_symbol', arguments: {
+      directory: root, symbol: 'greet', text: 'func greet() { println("hi") }', dryRun: true, ...(format === undefined ? {} : { format }),
+    } } })
+    return JSON.parse(result.content[0]!.text)
+  }
+
+  it('should take the default from the server, not the project settings', async () => {
+    expect((await dryRun()).files[0].formatter).toBeUndefined()
+    expect(existsSync(marker)).toBe(false)
+
+    setFormatEdits(true)
+    expect((await dryRun()).files[0].formatter).toBe('gofmt')
+    expect(existsSync(marker)).toBe(true)
+  })
+
+  it('should run no formatter in read-only mode or for tenants', async () => {
+    setFormatEdits(true)
+    setReadOnlyMode(true)
+    expect((await dryRun(true)).files[0].formatter).toBeUndefined()
+    expect(existsSync(marker)).toBe(false)
+
+    const registry = parseTenants({ projects: [{ id: 'app', directory: root }], tenants: [{ name: 't', token: 'token', projects: ['app'] }] }, '/')
+    const args = { symbol: 'greet', text: 'func greet() {}', dryRun: true, format: true }
+    expect(authorizeCall(registry, authenticate(registry, 'token'), 'edit_at_symbol', args)).toMatchObject({ format: false })
+  })
+})
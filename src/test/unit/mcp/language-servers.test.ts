@@ -0,0 +1,75 @@
+/**
+ * Which language server command a lookup may start: the server's options decide, never the
+ * indexed project's settings
+ */
+
+import { describe, it, expect, beforeAll, afterAll, afterEach } from 'vitest'
+import { existsSync, mkdtempSync, rmSync, writeFileSync } from 'fs'
+import { tmpdir } from 'os'
+import { join } from 'path'
+import { clearMCPMemory, handleToolRequest, setLanguageServerCommands, setReadOnlyMode, setResultCache } from '../../../mcp/handlers.js'
+import { createResultCache } from '../../../mcp/result-cache.js'
+import { stopLanguageServers } from '../../../lsp/client.js'
+import { parseTenants, authenticate, authorizeCall } from '../../../mcp/tenants.js'
+
+describe('language server commands', () => {
+  let root: string
+  let marker: string
+  let script: string
+
+  beforeAll(() => {
+    // Each call has to reach the lookup rather than the answer of the one before
+    setResultCache(undefined)
+    root = mkdtempSync(join(tmpdir(), 'ts-mcp-lsp-'))
+    marker = join(root, 'spawned')
+    script = join(root, 'server.cjs')
+    // Leaves a mark when started, then exits without answering
+    writeFileSync(script, `require('fs').writeFileSync(${JSON.stringify(marker)}, 'started')\n`)
+    writeFileSync(join(root, 'main.go'), 'package main\n\nfunc greet() {}\n\nfunc main() { greet() }\n')
+    writeFileSync(join(root, '.tree-sitter-mcp.json'), JSON.stringify({
+      languageServers: { commands: { gopls: [process.execPath, script] } },
+    }))
+  })
+
+  afterEach(() => {
+    setLanguageServerCommands({})
+    setReadOnlyMode(false)
+    stopLanguageServers()
+    rmSync(marker, { force: true })
+  })
+
+  afterAll(() => {
+    setResultCache(createResultCache())
+    clearMCPMemory()
+    rmSync(root, { recursive: true, force: true })
+  })
+
+  async function definition() {
+    const result = await handleToolRequest({ params: { name: 'find_definition', arguments: { directory: root, file: 'main.go', line: 5, column: 16 } } })
+    return JSON.parse(result.content[0]!.text)
+  }
+
+  it('should not start the command a project setting names', async () => {
+    const lookup = await definition()
+    expect(lookup.name).toBe('greet')
+    expect(existsSync(marker)).toBe(false)
+  })
+
+  it('should start the command the server is given', async () => {
+    setLanguageServerCommands({ gopls: [process.execPath, script] })
+    const lookup = await definition()
+    expect(lookup.languageServer).toMatchObject({ server: 'gopls', status: 'failed' })
+    expect(existsSync(marker)).toBe(true)
+  })
+
+  it('should start no server in read-only mode or for tenants', async () => {
+    setLanguageServerCommands({ gopls: [process.execPath, script] })
+    setReadOnlyMode(true)
+    const lookup = await definition()
+    expect(lookup.languageServer).toEqual({ status: 'disabled' })
+    expect(existsSync(marker)).toBe(false)
+
+    const registry = parseTenants({ projects: [{ id: 'app', directory: root }], tenants: [{ name: 't', token: 'token', projects: ['app'] }] }, '/')
+    expect(authorizeCall(registry, authenticate(registry, 'token'), 'get_type_info', { file: 'main.go', line: 5, column: 16 })).toMatchObject({ precise: false })
+  })
+})
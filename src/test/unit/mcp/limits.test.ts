@@ -0,0 +1,114 @@
+/**
+ * Per-session call limits: concurrency, per-minute budget, and the throttled result clients see
+ */
+
+import { describe, it, expect } from 'vitest'
+import { execFileSync } from 'child_process'
+import { mkdirSync, mkdtempSync, rmSync, writeFileSync } from 'fs'
+import { tmpdir } from 'os'
+import { join } from 'path'
+import { createCallSession, isIdleSession, isThrottled, runLimitedCall, throttled, throttledResult } from '../../../mcp/limits.js'
+import { clearMCPMemory, handleToolRequest, setIndexFileLimit } from '../../../mcp/handlers.js'
+import { createProject, parseProject } from '../../../project/manager.js'
+
+describe('call limits', () => {
+  it('should refuse calls over the concurrency cap until one finishes', async () => {
+    const session = createCallSession({ maxConcurrentCalls: 1 })
+    let finish: () => void = () => {}
+    const running = runLimitedCall(session, 'search_code', {}, () => new Promise<string>((resolveCall) => {
+      finish = () => resolveCall('done')
+    }))
+
+    const refused = await runLimitedCall(session, 'search_code', {}, async () => 'second').catch(error => error)
+    expect(isThrottled(refused)).toBe(true)
+    expect(refused.context).toEqual({ reason: 'concurrency', limit: 1 })
+
+    finish()
+    expect(await running).toBe('done')
+    expect(await runLimitedCall(session, 'search_code', {}, async () => 'third')).toBe('third')
+    expect(session.active).toBe(0)
+  })
+
+  it('should budget calls per minute, counting each call of a batch', async () => {
+    const session = createCallSession({ maxCallsPerMinute: 3 })
+    await runLimitedCall(session, 'search_code', {}, async () => 1, 1_000)
+    await runLimitedCall(session, 'batch', { calls: [{}, {}] }, async () => 2, 2_000)
+
+    const refused = await runLimitedCall(session, 'find_usage', {}, async () => 3, 10_000).catch(error => error)
+    expect(refused.context).toEqual({ reason: 'rate', limit: 3, retryAfterMs: 51_000 })
+
+    const oversized = await runLimitedCall(session, 'batch', { calls: [{}, {}, {}, {}] }, async () => 4, 70_000).catch(error => error)
+    expect(oversized.context).toEqual({ reason: 'rate', limit: 3 })
+
+    expect(await runLimitedCall(session, 'find_usage', {}, async () => 5, 61_500)).toBe(5)
+    expect(isIdleSession(session, 100_000)).toBe(false)
+    expect(isIdleSession(session, 121_500)).toBe(true)
+  })
+
+  it('should report throttled calls as JSON tool errors', () => {
+    const result = throttledResult(throttled('files', 100, 'The project has 250 files to index'))
+    expect(result.isError).toBe(true)
+    expect(JSON.parse(result.content[0]!.text)).toEqual({
+      error: 'throttled',
+      message: 'Throttled: The project has 250 files to index',
+      reason: 'files',
+      limit: 100,
+    })
+    expect(isThrottled(new Error('Throttled'))).toBe(false)
+  })
+
+  it('should hold a monorepo to the file limit as a whole', async () => {
+    const root = mkdtempSync(join(tmpdir(), 'ts-mcp-limits-'))
+    try {
+      for (const name of ['api', 'web']) {
+        mkdirSync(join(root, 'packages', name), { recursive: true })
+        writeFileSync(join(root, 'packages', name, 'package.json'), JSON.stringify({ name }))
+        writeFileSync(join(root, 'packages', name, 'a.ts'), 'export const a = 1\n')
+        writeFileSync(join(root, 'packages', name, 'b.ts'), 'export const b = 2\n')
+      }
+
+      // Each package is under the limit on its own
+      const project = createProject({ directory: root, maxFiles: 3 })
+      expect(project.subProjects?.length).toBeGreaterThan(1)
+      const refused = await parseProject(project).catch(error => error)
+      expect(isThrottled(refused)).toBe(true)
+      expect(refused.context).toMatchObject({ reason: 'files', limit: 3 })
+      expect(refused.context.files).toBeGreaterThanOrEqual(4)
+      expect(project.files.size).toBe(0)
+
+      await expect(parseProject(createProject({ directory: root, maxFiles: 100 }))).resolves.toBeDefined()
+    }
+    finally {
+      rmSync(root, { recursive: true, force: true })
+    }
+  })
+
+  it('should hold dependencies and snapshots at a ref to the file limit', async () => {
+    const root = mkdtempSync(join(tmpdir(), 'ts-mcp-limits-'))
+    const git = (...args: string[]) => execFileSync('git', ['-c', 'user.name=t', '-c', 'user.email=t@example.com', ...args], { cwd: root })
+    try {
+      mkdirSync(join(root, 'node_modules', 'big'), { recursive: true })
+      for (const name of ['a', 'b', 'c']) {
+        writeFileSync(join(root, 'node_modules', 'big', `${name}.ts`), `export const ${name} = 1\n`)
+        writeFileSync(join(root, `${name}.ts`), `export const ${name} = 1\n`)
+      }
+      git('init', '-q')
+      git('add', '-A', '--', '*.ts', ':!node_modules')
+      git('commit', '-q', '-m', 'init')
+      setIndexFileLimit(2)
+
+      const dependency = await handleToolRequest({ params: { name: 'index_dependency', arguments: { directory: root, name: 'big', ecosystem: 'npm' } } }).catch(error => error)
+      expect(isThrottled(dependency)).toBe(true)
+      expect(dependency.context).toMatchObject({ reason: 'files', limit: 2, files: 3 })
+
+      const snapshot = await handleToolRequest({ params: { name: 'search_code', arguments: { directory: root, query: 'a', searchAtRef: 'HEAD' } } }).catch(error => error)
+      expect(isThrottled(snapshot)).toBe(true)
+      expect(snapshot.context).toMatchObject({ reason: 'files', limit: 2, files: 3 })
+    }
+    finally {
+      setIndexFileLimit(undefined)
+      clearMCPMemory()
+      rmSync(root, { recursive: true, force: true })
+    }
+  })
+})
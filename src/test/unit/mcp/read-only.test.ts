@@ -0,0 +1,49 @@
+/**
+ * Tool annotations and read-only mode
+ */
+
+import { describe, it, expect, afterEach } from 'vitest'
+import { handleToolRequest, isMutatingCall, setReadOnlyMode } from '../../../mcp/handlers.js'
+import { MCP_TOOLS } from '../../../mcp/schemas.js'
+
+describe('tool annotations', () => {
+  it('should mark every tool as read-only or not', () => {
+    for (const tool of MCP_TOOLS) {
+      expect(typeof tool.annotations.readOnlyHint).toBe('boolean')
+    }
+    expect(MCP_TOOLS.filter(tool => !tool.annotations.readOnlyHint).map(tool => tool.name)).toEqual(['register_project', 'export_chunks', 'export_index', 'edit_at_symbol', 'apply_edit', 'undo_last_edit', 'batch'])
+  })
+
+  it('should tell mutating calls from their arguments', () => {
+    expect(isMutatingCall('export_index', { file: 'index.tsz' })).toBe(true)
+    expect(isMutatingCall('export_chunks', {})).toBe(false)
+    expect(isMutatingCall('export_chunks', { outputFile: 'chunks.jsonl' })).toBe(true)
+    expect(isMutatingCall('search_code', { query: 'parse' })).toBe(false)
+    expect(isMutatingCall('edit_at_symbol', { symbol: 'parse', text: '', dryRun: true })).toBe(false)
+    expect(isMutatingCall('edit_at_symbol', { symbol: 'parse', text: '' })).toBe(true)
+    expect(isMutatingCall('undo_last_edit', { dryRun: true })).toBe(false)
+    expect(isMutatingCall('list_edits')).toBe(false)
+    expect(isMutatingCall('batch', { calls: [] })).toBe(false)
+    expect(isMutatingCall('register_project', { directory: '/srv/api' })).toBe(false)
+    expect(isMutatingCall('register_project', { url: 'https://github.com/acme/api.git' })).toBe(true)
+    expect(isMutatingCall('register_project', { directory: 'git@github.com:acme/api.git' })).toBe(true)
+  })
+})
+
+describe('read-only mode', () => {
+  afterEach(() => setReadOnlyMode(false))
+
+  it('should refuse mutating calls, including inside a batch', async () => {
+    setReadOnlyMode(true)
+
+    await expect(handleToolRequest({ params: { name: 'export_index', arguments: { file: 'index.tsz' } } }))
+      .rejects.toThrow('read-only mode')
+    await expect(handleToolRequest({ params: { name: 'register_project', arguments: { url: 'https://github.com/acme/api.git' } } }))
+      .rejects.toThrow('read-only mode')
+
+    const result = await handleToolRequest({ params: { name: 'batch', arguments: { calls: [{ id: 'write', tool: 'export_index', arguments: { file: 'index.tsz' } }] } } })
+    const { results } = JSON.parse(result.content[0]!.text)
+    expect(results.write).toMatchObject({ ok: false })
+    expect(results.write.error).toContain('read-only mode')
+  })
+})
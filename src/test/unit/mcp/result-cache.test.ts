@@ -0,0 +1,73 @@
+/**
+ * Tool result cache: hits for repeated calls, misses once the index moves on
+ */
+
+import { describe, it, expect } from 'vitest'
+import { cachedToolCall, createResultCache, isCacheableCall } from '../../../mcp/result-cache.js'
+import type { Project } from '../../../types/core.js'
+
+function project(generation: number, subProjects: Project[] = []): Project {
+  return { id: 'app', config: { directory: '/app' }, files: new Map(), nodes: new Map(), generation, subProjects }
+}
+
+function countingCall() {
+  const counter = { calls: 0 }
+  const call = async () => ({ content: [{ type: 'text' as const, text: `result ${++counter.calls}` }] })
+  return { counter, call }
+}
+
+describe('result cache', () => {
+  it('should answer identical calls from cache until a generation changes', async () => {
+    const cache = createResultCache()
+    const sub = project(1)
+    const app = project(3, [sub])
+    const { counter, call } = countingCall()
+
+    const first = await cachedToolCall(cache, () => [app], 'search_code', { query: 'App', maxResults: 5 }, call)
+    const repeated = await cachedToolCall(cache, () => [app], 'search_code', { maxResults: 5, query: 'App' }, call)
+    expect(repeated).toEqual(first)
+    expect(counter.calls).toBe(1)
+
+    await cachedToolCall(cache, () => [app], 'search_code', { query: 'Other' }, call)
+    expect(counter.calls).toBe(2)
+
+    sub.generation = 2
+    expect((await cachedToolCall(cache, () => [app], 'search_code', { query: 'App', maxResults: 5 }, call)).content[0]!.text).toBe('result 3')
+    expect(cache).toMatchObject({ hits: 1, misses: 3 })
+  })
+
+  it('should store the result of a call that indexed its project, but not one raced by an update', async () => {
+    const cache = createResultCache()
+    const app = project(1)
+    let loaded: Project[] = []
+    const { counter, call } = countingCall()
+
+    await cachedToolCall(cache, () => loaded, 'find_usage', { identifier: 'run' }, async () => {
+      loaded = [app]
+      return call()
+    })
+    await cachedToolCall(cache, () => loaded, 'find_usage', { identifier: 'run' }, call)
+    expect(counter.calls).toBe(1)
+
+    await cachedToolCall(cache, () => loaded, 'resolve_symbol', { name: 'run' }, async () => {
+      app.generation = 2
+      return call()
+    })
+    await cachedToolCall(cache, () => loaded, 'resolve_symbol', { name: 'run' }, call)
+    expect(counter.calls).toBe(3)
+  })
+
+  it('should expire entries and only cache calls that depend on the index alone', async () => {
+    const cache = createResultCache({ maxAgeMs: 1_000 })
+    const app = project(1)
+    const { counter, call } = countingCall()
+    await cachedToolCall(cache, () => [app], 'search_code', { query: 'App' }, call, 0)
+    await cachedToolCall(cache, () => [app], 'search_code', { query: 'App' }, call, 1_500)
+    expect(counter.calls).toBe(2)
+
+    expect(isCacheableCall('search_code', { query: 'App' })).toBe(true)
+    expect(isCacheableCall('search_code', { query: 'App', searchAtRef: 'main' })).toBe(false)
+    expect(isCacheableCall('analyze_code', { analysisTypes: ['quality'] })).toBe(false)
+    expect(isCacheableCall('apply_edit', {})).toBe(false)
+  })
+})
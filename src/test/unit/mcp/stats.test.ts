@@ -0,0 +1,40 @@
+/**
+ * Query stats attached to responses with includeStats
+ */
+
+import { describe, it, expect } from 'vitest'
+import { resolve } from 'path'
+import { handleToolRequest } from '../../../mcp/handlers.js'
+import type { JsonObject } from '../../../types/core.js'
+
+const fixture = resolve(import.meta.dirname, '../../fixtures/minimal-positive')
+
+async function call(name: string, args: JsonObject) {
+  const result = await handleToolRequest({ params: { name, arguments: args } })
+  return JSON.parse(result.content[0]!.text)
+}
+
+describe('query stats', () => {
+  it('should report timing, files, cache hits and filters of a search', async () => {
+    const first = await call('search_code', { query: 'TestUser', directory: fixture, pathPattern: 'src', includeStats: true })
+    expect(first.stats.durationMs).toBeGreaterThanOrEqual(0)
+    expect(first.stats.filesIndexed).toBeGreaterThan(0)
+    expect(first.stats.filesScanned).toBeGreaterThan(0)
+    expect(first.stats.filters).toEqual({ pathPattern: 'src' })
+    expect(first.stats.cache.hit).toBe(false)
+
+    const repeated = await call('search_code', { query: 'TestUser', directory: fixture, pathPattern: 'src', includeStats: true })
+    expect(repeated.results).toEqual(first.results)
+    expect(repeated.stats.cache.hit).toBe(true)
+    expect(repeated.stats.cache.hitRatio).toBeGreaterThan(0)
+  })
+
+  it('should leave responses alone without the flag', async () => {
+    const usage = await call('find_usage', { identifier: 'TestUser', directory: fixture })
+    expect(usage.stats).toBeUndefined()
+
+    const withStats = await call('find_usage', { identifier: 'TestUser', directory: fixture, comments: 'exclude', includeStats: true })
+    expect(withStats.stats).toMatchObject({ filters: { comments: 'exclude' } })
+    expect(withStats.stats.filesScanned).toBeLessThanOrEqual(withStats.stats.filesIndexed)
+  })
+})
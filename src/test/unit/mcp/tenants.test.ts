@@ -0,0 +1,105 @@
+/**
+ * Tenants of a shared server: tokens, the projects they grant, and quotas
+ */
+
+import { describe, it, expect } from 'vitest'
+import { mkdtempSync, readFileSync, rmSync, writeFileSync } from 'fs'
+import { tmpdir } from 'os'
+import { join } from 'path'
+import { authenticate, authorizeCall, parseTenants, runTenantCall } from '../../../mcp/tenants.js'
+import { clearMCPMemory, handleToolRequest } from '../../../mcp/handlers.js'
+import type { JsonObject } from '../../../types/core.js'
+
+const TENANTS = {
+  projects: [
+    { id: 'web', directory: 'repos/web' },
+    { id: 'billing', directory: '/srv/billing' },
+  ],
+  tenants: [
+    { name: 'frontend', token: 'front-token', projects: ['web'], quota: { maxConcurrentCalls: 1 } },
+    { name: 'ci', tokenSha256: '9F86D081884C7D659A2FEAA0C55AD015A3BF4F1B2B0B822CD15D6C15B0F00A08', projects: ['web', 'billing'], readOnly: true },
+  ],
+}
+
+describe('tenants', () => {
+  it('should authenticate bearer tokens against plain and hashed tokens', () => {
+    const registry = parseTenants(TENANTS, '/etc/tree-sitter-mcp')
+    expect(registry.projects.get('web')?.directory).toBe('/etc/tree-sitter-mcp/repos/web')
+    expect(authenticate(registry, 'Bearer front-token').name).toBe('frontend')
+    expect(authenticate(registry, 'test').name).toBe('ci')
+    expect(() => authenticate(registry, 'Bearer guess')).toThrow('A valid token is required')
+    expect(() => authenticate(registry, undefined)).toThrow('A valid token is required')
+
+    expect(() => parseTenants({ ...TENANTS, tenants: [{ name: 'x', token: 't', projects: ['other'] }] }, '/')).toThrow('grants a project the file does not list')
+    expect(() => parseTenants({ projects: [], tenants: [{ name: 'x', projects: [] }] }, '/')).toThrow('needs a token')
+  })
+
+  it('should pin calls to a granted project and refuse everything else', () => {
+    const registry = parseTenants(TENANTS, '/etc/tree-sitter-mcp')
+    const frontend = authenticate(registry, 'front-token')
+    const ci = authenticate(registry, 'test')
+
+    expect(authorizeCall(registry, frontend, 'search_code', { query: 'App' })).toEqual({ query: 'App', projectId: 'web', directory: '/etc/tree-sitter-mcp/repos/web' })
+    expect(authorizeCall(registry, ci, 'find_usage', { identifier: 'charge', directory: '/srv/billing' })).toMatchObject({ projectId: 'billing' })
+    expect(() => authorizeCall(registry, frontend, 'search_code', { query: 'x', projectId: 'billing' })).toThrow('no access to project billing')
+    expect(() => authorizeCall(registry, frontend, 'search_code', { query: 'x', directory: '/srv/billing' })).toThrow('no access to project /srv/billing')
+    expect(() => authorizeCall(registry, ci, 'search_code', { query: 'x' })).toThrow('projectId is required')
+    expect(() => authorizeCall(registry, ci, 'search_code', { query: 'x', projectId: 'web', includeProjects: ['billing', 'payroll'] })).toThrow('no access to project payroll')
+    expect(() => authorizeCall(registry, frontend, 'find_definition', { file: '../../billing/secrets.ts', line: 1 })).toThrow('file must be inside project web')
+    expect(() => authorizeCall(registry, frontend, 'register_project', { directory: '/' })).toThrow('not available on a shared server')
+    expect(() => authorizeCall(registry, frontend, 'index_dependency', { name: 'left-pad' })).toThrow('not available on a shared server')
+    expect(() => authorizeCall(registry, frontend, 'batch', { calls: [{ tool: 'index_dependency', arguments: { name: 'left-pad' } }] })).toThrow('not available on a shared server')
+    expect(() => authorizeCall(registry, ci, 'apply_edit', { projectId: 'web' })).toThrow('tenant ci is read-only')
+
+    const batch = authorizeCall(registry, frontend, 'batch', { calls: [{ tool: 'search_code', arguments: { query: 'App' } }] })
+    expect((batch.calls as JsonObject[])[0]!.arguments).toMatchObject({ projectId: 'web' })
+    expect(() => authorizeCall(registry, frontend, 'batch', { calls: [{ tool: 'search_code', arguments: { query: 'x', projectId: 'billing' } }] })).toThrow('no access')
+  })
+
+  it('should refuse calls over the concurrent call quota until one finishes', async () => {
+    const registry = parseTenants(TENANTS, '/etc/tree-sitter-mcp')
+    const frontend = authenticate(registry, 'front-token')
+    let finish: () => void = () => {}
+    const running = runTenantCall(registry, frontend, 'search_code', { query: 'App' }, () => new Promise<string>((resolveCall) => {
+      finish = () => resolveCall('done')
+    }))
+
+    await expect(runTenantCall(registry, frontend, 'search_code', { query: 'App' }, async () => 'second')).rejects.toThrow('already runs 1 calls')
+    finish()
+    expect(await running).toBe('done')
+    expect(await runTenantCall(registry, frontend, 'search_code', { query: 'App' }, async (_name, args) => args.projectId)).toBe('web')
+  })
+
+  it('should apply a plan only for the tenant of its project', async () => {
+    const root = mkdtempSync(join(tmpdir(), 'ts-mcp-tenant-plans-'))
+    process.env.TREE_SITTER_MCP_EDIT_JOURNAL_DIR = join(root, '.journal')
+    const source = 'pub fn greet() void {}\n'
+    try {
+      const [a, b] = ['a', 'b'].map((name) => {
+        const directory = mkdtempSync(join(root, `${name}-`))
+        writeFileSync(join(directory, 'main.zig'), source)
+        return directory
+      })
+      const registry = parseTenants({
+        projects: [{ id: 'a', directory: a }, { id: 'b', directory: b }],
+        tenants: [{ name: 'alice', token: 'a-token', projects: ['a'] }, { name: 'bob', token: 'b-token', projects: ['b'] }],
+      }, '/')
+      const call = (token: string, name: string, args: JsonObject) => runTenantCall(registry, authenticate(registry, token), name, args, (tool, authorized) => handleToolRequest({ params: { name: tool, arguments: authorized } }))
+
+      const preview = await call('a-token', 'edit_at_symbol', { symbol: 'greet', text: 'pub fn greet() void { }', dryRun: true })
+      const { planId } = JSON.parse(preview.content[0]!.text)
+      expect(planId).toMatch(/^[0-9a-f]{32}$/)
+
+      await expect(call('b-token', 'apply_edit', { planId })).rejects.toThrow('was made for another project')
+      expect(readFileSync(join(a!, 'main.zig'), 'utf-8')).toBe(source)
+
+      await call('a-token', 'apply_edit', { planId })
+      expect(readFileSync(join(a!, 'main.zig'), 'utf-8')).toBe('pub fn greet() void { }\n')
+    }
+    finally {
+      clearMCPMemory()
+      delete process.env.TREE_SITTER_MCP_EDIT_JOURNAL_DIR
+      rmSync(root, { recursive: true, force: true })
+    }
+  })
+})
@@ -0,0 +1,79 @@
+/**
+ * Atomic index swaps during reindex
+ */
+
+import { describe, it, expect } from 'vitest'
+import { mkdtempSync, rmSync, unlinkSync, writeFileSync } from 'fs'
+import { tmpdir } from 'os'
+import { join, resolve } from 'path'
+import { createProject, parseProject, updateProject } from '../../../project/manager.js'
+
+describe('Atomic reindex', () => {
+  const fixture = resolve(import.meta.dirname, '../../fixtures/simple-ts')
+
+  it('should keep serving the previous snapshot while a reparse is running', async () => {
+    const project = createProject({ directory: fixture, languages: ['typescript'] })
+    await parseProject(project)
+    const previousFiles = project.files
+    const fileCount = previousFiles.size
+
+    const reparse = parseProject(project)
+    expect(project.files).toBe(previousFiles)
+    expect(project.files.size).toBe(fileCount)
+
+    await reparse
+    expect(project.files).not.toBe(previousFiles)
+    expect(project.files.size).toBe(fileCount)
+  })
+
+  it('should publish only the latest of overlapping reparses', async () => {
+    const project = createProject({ directory: fixture, languages: ['typescript'] })
+    await Promise.all([parseProject(project), parseProject(project)])
+
+    expect(project.generation).toBe(1)
+    expect(project.files.size).toBeGreaterThan(0)
+  })
+
+  it('should bump the generation once per applied update burst', async () => {
+    const project = createProject({ directory: fixture, languages: ['typescript'] })
+    await parseProject(project)
+    const [firstPath] = Array.from(project.files.keys())
+    const generation = project.generation ?? 0
+
+    await updateProject(project, [
+      { type: 'modified', path: firstPath!, timestamp: Date.now() },
+      { type: 'modified', path: firstPath!, timestamp: Date.now() },
+    ])
+
+    expect(project.generation).toBe(generation + 1)
+    expect(project.files.has(firstPath!)).toBe(true)
+  })
+
+  it('should not let an earlier, slower burst overwrite a later one', async () => {
+    const directory = mkdtempSync(join(tmpdir(), 'tree-sitter-mcp-bursts-'))
+    try {
+      const others = Array.from({ length: 40 }, (_, index) => join(directory, `other${index}.zig`))
+      for (const path of others) writeFileSync(path, 'pub fn other() void {}\n')
+      const shared = join(directory, 'shared.zig')
+      writeFileSync(shared, 'pub fn first() void {}\n')
+      const project = createProject({ directory, languages: ['zig'] })
+      await parseProject(project)
+
+      // The first burst deletes the file but is still parsing the others when it comes back
+      unlinkSync(shared)
+      const slow = updateProject(project, [
+        ...others.map(path => ({ type: 'modified' as const, path, timestamp: Date.now() })),
+        { type: 'deleted', path: shared, timestamp: Date.now() },
+      ])
+      writeFileSync(shared, 'pub fn second() void {}\n')
+      await updateProject(project, [{ type: 'modified', path: shared, timestamp: Date.now() }])
+      await slow
+
+      expect(project.files.has(shared)).toBe(true)
+      expect(project.nodes.get(shared)?.some(node => node.name === 'second')).toBe(true)
+    }
+    finally {
+      rmSync(directory, { recursive: true, force: true })
+    }
+  })
+})
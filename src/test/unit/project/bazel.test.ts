@@ -0,0 +1,57 @@
+/**
+ * Bazel/Buck BUILD file parsing and target scoping
+ */
+
+import { describe, it, expect } from 'vitest'
+import { parseBuildFile, targetsFor, findBazelTarget } from '../../../project/bazel.js'
+
+describe('Bazel build graph', () => {
+  const packageDir = '/ws/services/api'
+  const content = `
+load("@io_bazel_rules_go//go:def.bzl", "go_library", "go_test")
+
+package(default_visibility = ["//services:__subpackages__"])
+
+go_library(
+    name = "api",
+    srcs = [
+        "handler.go",
+        "router.go",  # routing table
+    ] + glob(["internal/**/*.go"], exclude = ["internal/**/*_test.go"]),
+    deps = ["//libs/strutil"],
+)
+
+go_test(
+    name = "api_test",
+    srcs = glob(["*_test.go"]),
+    visibility = ["//visibility:private"],
+    deps = [":api"],
+)
+`
+
+  const targets = parseBuildFile(content, packageDir, 'services/api')
+
+  it('should extract named targets with labels and rules', () => {
+    expect(targets.map(target => [target.label, target.rule])).toEqual([
+      ['//services/api:api', 'go_library'],
+      ['//services/api:api_test', 'go_test'],
+    ])
+  })
+
+  it('should apply package default visibility unless overridden', () => {
+    expect(targets[0]?.visibility).toEqual(['//services:__subpackages__'])
+    expect(targets[1]?.visibility).toEqual(['//visibility:private'])
+  })
+
+  it('should map files to the targets that own them', () => {
+    expect(targetsFor('/ws/services/api/handler.go', targets).map(target => target.name)).toEqual(['api'])
+    expect(targetsFor('/ws/services/api/internal/auth/token.go', targets).map(target => target.name)).toEqual(['api'])
+    expect(targetsFor('/ws/services/api/internal/auth/token_test.go', targets)).toEqual([])
+    expect(targetsFor('/ws/services/api/handler_test.go', targets).map(target => target.name)).toEqual(['api_test'])
+  })
+
+  it('should resolve the //pkg shorthand label', () => {
+    expect(findBazelTarget('//services/api', targets)?.name).toBe('api')
+    expect(findBazelTarget('//services/api:missing', targets)).toBeUndefined()
+  })
+})
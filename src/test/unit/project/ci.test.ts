@@ -0,0 +1,133 @@
+/**
+ * CI pipeline jobs and the project commands they invoke
+ */
+
+import { describe, it, expect } from 'vitest'
+import { extractCIJobs, getCIProvider } from '../../../project/ci.js'
+import type { TreeNode } from '../../../types/core.js'
+
+const file = (path: string, content: string): TreeNode => ({ id: path, type: 'file', path, content })
+
+const WORKFLOW = `name: CI
+on: [push]
+defaults:
+  run:
+    working-directory: web
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    strategy:
+      matrix:
+        node: [18, 20]
+    env:
+      CI: true
+    steps:
+      - uses: actions/checkout@v4
+      - name: Install
+        run: npm ci
+      - name: Test
+        run: |
+          npm run lint
+          npm test -- --coverage
+  build:
+    needs: test
+    runs-on: ubuntu-latest
+    steps:
+      - run: make -C .. build
+      - run: ./scripts/release.sh
+        working-directory: .
+`
+
+describe('CI jobs', () => {
+  it('should recognize pipeline files by path', () => {
+    expect(getCIProvider('/p/.github/workflows/ci.yml')).toBe('github-actions')
+    expect(getCIProvider('/p/.gitlab-ci.yml')).toBe('gitlab-ci')
+    expect(getCIProvider('/p/ci.yml')).toBeNull()
+  })
+
+  it('should list GitHub Actions jobs with their commands and what they invoke', () => {
+    const jobs = extractCIJobs([
+      file('/p/.github/workflows/ci.yml', WORKFLOW),
+      file('/p/web/package.json', '{\n  "scripts": {\n    "lint": "eslint .",\n    "test": "vitest run"\n  }\n}\n'),
+      file('/p/Makefile', 'build: deps\n\tgo build ./...\n'),
+      file('/p/scripts/release.sh', '#!/bin/sh\n'),
+    ], '/p/missing')
+
+    expect(jobs.map(job => [job.id, job.workflow, job.runsOn, job.needs, job.matrix])).toEqual([
+      ['test', 'CI', 'ubuntu-latest', [], { node: [18, 20] }],
+      ['build', 'CI', 'ubuntu-latest', ['test'], undefined],
+    ])
+    expect(jobs[0]!.env).toEqual({ CI: 'true' })
+    expect(jobs[0]!.steps.map(step => [step.name, step.line, step.uses])).toEqual([
+      [undefined, 15, 'actions/checkout@v4'],
+      ['Install', 16, undefined],
+      ['Test', 18, undefined],
+    ])
+
+    const commands = jobs.flatMap(job => job.steps.flatMap(step => step.commands))
+    expect(commands.map(command => [command.command, command.line, command.workingDirectory])).toEqual([
+      ['npm ci', 17, '/p/missing/web'],
+      ['npm run lint', 20, '/p/missing/web'],
+      ['npm test -- --coverage', 21, '/p/missing/web'],
+      ['make -C .. build', 26, '/p/missing/web'],
+      ['./scripts/release.sh', 27, '/p/missing'],
+    ])
+  })
+
+  it('should link commands to package scripts, task runner targets and local scripts', () => {
+    const jobs = extractCIJobs([
+      file('/p/.github/workflows/ci.yml', WORKFLOW),
+      file('/p/web/package.json', '{\n  "scripts": {\n    "lint": "eslint .",\n    "test": "vitest run"\n  }\n}\n'),
+      file('/p/Makefile', 'build: deps\n\tgo build ./...\n'),
+      file('/p/scripts/release.sh', '#!/bin/sh\n'),
+    ], '/p')
+
+    const invokes = jobs.flatMap(job => job.steps.flatMap(step => step.commands))
+      .map(command => [command.command, command.invokes.map(invocation => [invocation.kind, invocation.name, invocation.file, invocation.line, invocation.commands])])
+    expect(invokes).toEqual([
+      ['npm ci', []],
+      ['npm run lint', [['npm-script', 'lint', '/p/web/package.json', 3, ['eslint .']]]],
+      ['npm test -- --coverage', [['npm-script', 'test', '/p/web/package.json', 4, ['vitest run']]]],
+      ['make -C .. build', [['make', 'build', '/p/Makefile', 1, ['go build ./...']]]],
+      ['./scripts/release.sh', [['script', 'scripts/release.sh', '/p/scripts/release.sh', undefined, []]]],
+    ])
+  })
+
+  it('should read GitLab jobs with inherited scripts and cd changing the directory', () => {
+    const pipeline = `stages: [test]
+default:
+  before_script:
+    - npm ci
+.node:
+  image: node:20
+  script:
+    - cd api && npm test
+variables:
+  NODE_ENV: test
+unit:
+  extends: .node
+  stage: test
+deploy:
+  stage: deploy
+  script:
+    - just deploy
+`
+    const jobs = extractCIJobs([
+      file('/p/.gitlab-ci.yml', pipeline),
+      file('/p/api/package.json', '{ "scripts": { "test": "jest" } }'),
+      file('/p/justfile', 'deploy:\n    ./deploy.sh\n'),
+    ], '/p')
+
+    expect(jobs.map(job => [job.id, job.workflow, job.image, job.env])).toEqual([
+      ['unit', 'test', 'node:20', { NODE_ENV: 'test' }],
+      ['deploy', 'deploy', undefined, { NODE_ENV: 'test' }],
+    ])
+    expect(jobs[0]!.steps.map(step => [step.name, step.line, step.commands.map(command => [command.command, command.line, command.invokes.map(invocation => invocation.name)])])).toEqual([
+      ['before_script', 3, [['npm ci', 4, []]]],
+      ['script', 7, [['cd api && npm test', 8, ['test']]]],
+    ])
+    expect(jobs[1]!.steps.flatMap(step => step.commands.flatMap(command => command.invokes.map(invocation => [invocation.kind, invocation.name, invocation.commands])))).toEqual([
+      ['just', 'deploy', ['./deploy.sh']],
+    ])
+  })
+})
@@ -0,0 +1,76 @@
+/**
+ * CODEOWNERS parsing and ownership lookup
+ */
+
+import { describe, it, expect, afterEach } from 'vitest'
+import { mkdirSync, mkdtempSync, rmSync, writeFileSync } from 'fs'
+import { tmpdir } from 'os'
+import { join } from 'path'
+import { findOwners, loadCodeOwners, ownersOf, parseCodeOwners, type CodeOwners } from '../../../project/codeowners.js'
+
+const CODEOWNERS = `# Default owners
+*                       @acme/platform
+
+*.md                    @acme/docs
+/services/              @acme/backend
+/services/billing/      @acme/billing alice@acme.com
+apps/web/*              @acme/web
+assets/
+
+[Mobile]
+/apps/mobile/ @acme/mobile
+`
+
+describe('CODEOWNERS', () => {
+  const codeOwners: CodeOwners = { directory: '/repo', file: '/repo/.github/CODEOWNERS', rules: parseCodeOwners(CODEOWNERS) }
+  const owners = (path: string) => findOwners(codeOwners, path).owners
+  let root: string | undefined
+
+  afterEach(() => {
+    if (root) rmSync(root, { recursive: true, force: true })
+    root = undefined
+  })
+
+  it('should let the last matching rule win', () => {
+    expect(owners('services/api/server.ts')).toEqual(['@acme/backend'])
+    expect(owners('services/billing/invoice.ts')).toEqual(['@acme/billing', 'alice@acme.com'])
+    expect(owners('scripts/seed.ts')).toEqual(['@acme/platform'])
+    expect(findOwners(codeOwners, 'services/api/server.ts').rule).toMatchObject({ pattern: '/services/', line: 5 })
+  })
+
+  it('should match unanchored patterns at any depth', () => {
+    expect(owners('docs/guide/setup.md')).toEqual(['@acme/docs'])
+    expect(owners('services/api/README.md')).toEqual(['@acme/backend'])
+    expect(owners('packages/ui/assets/logo.svg')).toEqual([])
+  })
+
+  it('should match direct children only for a trailing wildcard', () => {
+    expect(owners('apps/web/index.ts')).toEqual(['@acme/web'])
+    expect(owners('apps/web/src/App.tsx')).toEqual(['@acme/platform'])
+  })
+
+  it('should apply rules inside GitLab sections', () => {
+    expect(owners('apps/mobile/App.kt')).toEqual(['@acme/mobile'])
+  })
+
+  it('should accept absolute paths and finding locations', () => {
+    expect(owners('/repo/services/api/server.ts')).toEqual(['@acme/backend'])
+    expect(owners('/elsewhere/server.ts')).toEqual([])
+    expect(ownersOf(codeOwners, '/repo/services/api/server.ts:42')).toEqual(['@acme/backend'])
+    expect(ownersOf(undefined, '/repo/services/api/server.ts:42')).toBeUndefined()
+  })
+
+  it('should find the file in the usual locations', () => {
+    root = mkdtempSync(join(tmpdir(), 'ts-mcp-owners-'))
+    expect(loadCodeOwners(root)).toBeUndefined()
+
+    mkdirSync(join(root, 'docs'))
+    writeFileSync(join(root, 'docs/CODEOWNERS'), '* @acme/docs-fallback\n')
+    mkdirSync(join(root, '.github'))
+    writeFileSync(join(root, '.github/CODEOWNERS'), '* @acme/platform\n')
+
+    const loaded = loadCodeOwners(root)
+    expect(loaded?.file).toBe(join(root, '.github/CODEOWNERS'))
+    expect(findOwners(loaded!, 'src/index.ts').owners).toEqual(['@acme/platform'])
+  })
+})
@@ -0,0 +1,81 @@
+/**
+ * Dependency source resolution
+ */
+
+import { describe, it, expect, beforeAll, afterAll } from 'vitest'
+import { mkdtempSync, mkdirSync, writeFileSync, rmSync } from 'fs'
+import { tmpdir } from 'os'
+import { join } from 'path'
+import { resolveNodeModule, resolvePythonPackage, findGoRequireVersion, escapeGoModulePath } from '../../../project/dependencies.js'
+import { createProject, parseProject } from '../../../project/manager.js'
+
+describe('Dependency source resolution', () => {
+  let root: string
+
+  beforeAll(() => {
+    root = mkdtempSync(join(tmpdir(), 'ts-mcp-deps-'))
+    mkdirSync(join(root, 'node_modules', '@scope', 'lib'), { recursive: true })
+    writeFileSync(join(root, 'node_modules', '@scope', 'lib', 'package.json'), JSON.stringify({ name: '@scope/lib', version: '1.2.3' }))
+    mkdirSync(join(root, 'packages', 'app'), { recursive: true })
+    const sitePackages = join(root, '.venv', 'lib', 'python3.12', 'site-packages')
+    mkdirSync(join(sitePackages, 'tsmcp_ns', 'inner'), { recursive: true })
+    writeFileSync(join(sitePackages, 'tsmcp_ns', '__init__.py'), '')
+    writeFileSync(join(sitePackages, 'tsmcp_ns', 'inner', '__init__.py'), '')
+    writeFileSync(join(sitePackages, 'tsmcp_ns', 'helpers.py'), 'def helper(): pass\n')
+    writeFileSync(join(sitePackages, 'tsmcp_single.py'), 'def single(): pass\n')
+  })
+
+  afterAll(() => {
+    rmSync(root, { recursive: true, force: true })
+  })
+
+  it('should walk up to the nearest node_modules', () => {
+    const source = resolveNodeModule(join(root, 'packages', 'app'), '@scope/lib')
+    expect(source?.directory).toBe(join(root, 'node_modules', '@scope', 'lib'))
+    expect(source?.version).toBe('1.2.3')
+    expect(resolveNodeModule(root, 'missing-package')).toBeNull()
+  })
+
+  it('should refuse names that are not npm package names', () => {
+    // Joined onto node_modules, each of these names the directory itself or one above it
+    for (const name of ['../../../etc', '..', '.', '@scope/..', '@scope/../../etc', 'lib/../..', '/etc']) {
+      expect(resolveNodeModule(join(root, 'packages', 'app'), name)).toBeNull()
+    }
+    expect(resolveNodeModule(root, '@scope/lib')).not.toBeNull()
+  })
+
+  it('should find Python packages, subpackages and single-file modules on disk', async () => {
+    const sitePackages = join(root, '.venv', 'lib', 'python3.12', 'site-packages')
+    expect(await resolvePythonPackage(root, 'tsmcp-ns')).toEqual({ name: 'tsmcp-ns', ecosystem: 'python', directory: join(sitePackages, 'tsmcp_ns') })
+    expect(await resolvePythonPackage(root, 'tsmcp_ns.inner')).toMatchObject({ directory: join(sitePackages, 'tsmcp_ns', 'inner') })
+    // A module is its file alone, not the directory it sits in
+    expect(await resolvePythonPackage(root, 'tsmcp_single')).toEqual({ name: 'tsmcp_single', ecosystem: 'python', directory: sitePackages, file: join(sitePackages, 'tsmcp_single.py') })
+    expect(await resolvePythonPackage(root, 'tsmcp_ns.helpers')).toMatchObject({ directory: join(sitePackages, 'tsmcp_ns'), file: join(sitePackages, 'tsmcp_ns', 'helpers.py') })
+    expect(await resolvePythonPackage(root, 'tsmcp_ns..inner')).toBeNull()
+    expect(await resolvePythonPackage(root, 'tsmcp_missing')).toBeNull()
+  })
+
+  it('should index only the listed files of a directory', async () => {
+    const directory = mkdtempSync(join(tmpdir(), 'ts-mcp-deps-files-'))
+    try {
+      writeFileSync(join(directory, 'one.zig'), 'pub fn one() void {}\n')
+      writeFileSync(join(directory, 'two.zig'), 'pub fn two() void {}\n')
+      const project = await parseProject(createProject({ directory, files: [join(directory, 'one.zig')] }))
+      expect([...project.files.keys()]).toEqual([join(directory, 'one.zig')])
+    }
+    finally {
+      rmSync(directory, { recursive: true, force: true })
+    }
+  })
+
+  it('should read pinned versions from go.mod require directives', () => {
+    const goMod = 'module example.com/app\n\nrequire github.com/google/uuid v1.6.0\n\nrequire (\n\tgithub.com/spf13/cobra v1.8.0\n\tgolang.org/x/sync v0.7.0 // indirect\n)\n'
+    expect(findGoRequireVersion(goMod, 'github.com/google/uuid')).toBe('v1.6.0')
+    expect(findGoRequireVersion(goMod, 'github.com/spf13/cobra')).toBe('v1.8.0')
+    expect(findGoRequireVersion(goMod, 'github.com/spf13')).toBeNull()
+  })
+
+  it('should case-encode module paths like the Go module cache', () => {
+    expect(escapeGoModulePath('github.com/BurntSushi/toml')).toBe('github.com/!burnt!sushi/toml')
+  })
+})
@@ -0,0 +1,46 @@
+/**
+ * Per-file failure isolation during indexing
+ */
+
+import { describe, it, expect } from 'vitest'
+import { resolve } from 'path'
+import { createProject, parseProject, recordDiagnostic, getProjectDiagnostics, updateProject } from '../../../project/manager.js'
+
+describe('Indexing diagnostics', () => {
+  const fixture = resolve(import.meta.dirname, '../../fixtures/simple-ts')
+
+  it('should start with no diagnostics for a clean project', async () => {
+    const project = createProject({ directory: fixture, languages: ['typescript'] })
+    await parseProject(project)
+
+    expect(project.files.size).toBeGreaterThan(0)
+    expect(getProjectDiagnostics(project)).toEqual([])
+  })
+
+  it('should record a failed update without dropping the rest of the index', async () => {
+    const project = createProject({ directory: fixture, languages: ['typescript'] })
+    await parseProject(project)
+    const fileCount = project.files.size
+
+    const missingPath = resolve(fixture, 'src/does-not-exist.ts')
+    await updateProject(project, [{ type: 'modified', path: missingPath, timestamp: Date.now() }])
+
+    const diagnostics = getProjectDiagnostics(project)
+    expect(diagnostics).toHaveLength(1)
+    expect(diagnostics[0]?.path).toBe(missingPath)
+    expect(diagnostics[0]?.stage).toBe('update')
+    expect(project.files.size).toBe(fileCount)
+  })
+
+  it('should keep a single entry per file and clear it on delete', async () => {
+    const project = createProject({ directory: fixture, languages: ['typescript'] })
+    const path = resolve(fixture, 'src/broken.ts')
+
+    recordDiagnostic(project, path, 'parse', new Error('first'))
+    recordDiagnostic(project, path, 'parse', new Error('second'))
+    expect(getProjectDiagnostics(project).map(d => d.message)).toEqual(['second'])
+
+    await updateProject(project, [{ type: 'deleted', path, timestamp: Date.now() }])
+    expect(getProjectDiagnostics(project)).toEqual([])
+  })
+})
@@ -0,0 +1,112 @@
+/**
+ * Edit plans: dry-run diffs and hash-checked apply
+ */
+
+import { describe, it, expect, beforeEach, afterEach } from 'vitest'
+import { mkdtempSync, readFileSync, rmSync, writeFileSync } from 'fs'
+import { tmpdir } from 'os'
+import { join } from 'path'
+import { applyEditPlan, editAtSymbol, getEditPlan, planEdits, planUndo, resolveEditTarget } from '../../../project/edits.js'
+import { listEdits } from '../../../project/edit-journal.js'
+import { parseContent } from '../../../core/parser.js'
+import { createProject, extractAllNodes } from '../../../project/manager.js'
+import { isFile } from '../../../utils/helpers.js'
+import type { Project } from '../../../types/core.js'
+
+const SOURCE = `const std = @import("std");
+
+pub fn greet(name: []const u8) void {
+    std.debug.print("hi {s}\\n", .{name});
+}
+
+pub fn main() void {
+    greet("zig");
+}
+`
+
+describe('edit plans', () => {
+  let root: string
+  let file: string
+  let project: Project
+
+  beforeEach(() => {
+    root = mkdtempSync(join(tmpdir(), 'ts-mcp-edits-'))
+    process.env.TREE_SITTER_MCP_EDIT_JOURNAL_DIR = join(root, '.journal')
+    file = join(root, 'main.zig')
+    writeFileSync(file, SOURCE)
+    project = createProject({ directory: root })
+    const fileNode = parseContent(SOURCE, file)
+    project.files.set(file, fileNode)
+    project.nodes.set(file, extractAllNodes(fileNode))
+  })
+
+  afterEach(() => {
+    rmSync(root, { recursive: true, force: true })
+    delete process.env.TREE_SITTER_MCP_EDIT_JOURNAL_DIR
+  })
+
+  it('should preview an edit at a symbol as a diff without writing', () => {
+    const node = resolveEditTarget(project, 'greet')
+    const plan = planEdits('edit_at_symbol', project.id, root, [editAtSymbol(node, 'pub fn greet() void {}', 'replace')])
+
+    expect(plan.files).toMatchObject([{ path: 'main.zig', additions: 1, deletions: 3 }])
+    expect(plan.diff).toContain('-pub fn greet(name: []const u8) void {')
+    expect(plan.diff).toContain('+pub fn greet() void {}')
+    expect(readFileSync(file, 'utf-8')).toBe(SOURCE)
+    expect(getEditPlan(plan.id)).toBe(plan)
+  })
+
+  it('should apply a plan once and only to unchanged files', () => {
+    const node = resolveEditTarget(project, 'main')
+    const plan = planEdits('edit_at_symbol', project.id, root, [editAtSymbol(node, '// entry point', 'before')])
+
+    const changes = applyEditPlan(plan)
+    expect(changes.map(change => [change.type, change.path])).toEqual([['modified', file]])
+    expect(readFileSync(file, 'utf-8')).toContain('// entry point\npub fn main() void {')
+    expect(() => getEditPlan(plan.id)).toThrow('Unknown or expired edit plan')
+
+    const stale = planEdits('edit_at_symbol', project.id, root, [{ path: file, content: '' }])
+    writeFileSync(file, SOURCE + '// concurrent edit\n')
+    expect(() => applyEditPlan(stale)).toThrow('changed since the edit was planned: main.zig')
+    expect(readFileSync(file, 'utf-8')).toContain('concurrent edit')
+  })
+
+  it('should journal applied edits and undo the last one', () => {
+    const created = join(root, 'notes.zig')
+    applyEditPlan(planEdits('edit_at_symbol', project.id, root, [editAtSymbol(resolveEditTarget(project, 'greet'), '', 'replace')]))
+    applyEditPlan(planEdits('edit_at_symbol', project.id, root, [{ path: created, content: '// notes\n' }]))
+
+    expect(listEdits(root).map(edit => edit.files)).toEqual([
+      [{ path: 'notes.zig', additions: 1, deletions: 0, created: true, deleted: false }],
+      [{ path: 'main.zig', additions: 0, deletions: 3, created: false, deleted: false }],
+    ])
+
+    const undo = planUndo(project.id, root)
+    expect(undo.files).toMatchObject([{ path: 'notes.zig', deleted: true }])
+    applyEditPlan(undo)
+    expect(isFile(created)).toBe(false)
+    expect(listEdits(root)).toHaveLength(1)
+
+    // A change made after the edit is not discarded unless forced
+    writeFileSync(file, readFileSync(file, 'utf-8') + '// later\n')
+    expect(() => planUndo(project.id, root)).toThrow('Files changed since edit')
+    applyEditPlan(planUndo(project.id, root, true))
+    expect(readFileSync(file, 'utf-8')).toBe(SOURCE)
+    expect(() => planUndo(project.id, root)).toThrow('No applied edits to undo')
+  })
+
+  it('should refuse edits that introduce syntax errors unless allowed', () => {
+    const source = join(root, 'math.ts')
+    writeFileSync(source, 'export function add(a: number, b: number) {\n  return a + b\n}\n')
+    const plan = planEdits('edit_at_symbol', project.id, root, [{ path: source, content: 'export function add(a: number, b: number {\n  return a + b\n}\n' }])
+
+    expect(plan.files[0]!.syntaxErrors?.length).toBeGreaterThan(0)
+    expect(() => applyEditPlan(plan)).toThrow('introduces syntax errors in math.ts')
+    applyEditPlan(plan, { allowSyntaxErrors: true })
+    expect(readFileSync(source, 'utf-8')).toContain('b: number {')
+  })
+
+  it('should refuse unknown symbols', () => {
+    expect(() => resolveEditTarget(project, 'missing')).toThrow('Unknown symbol: missing')
+  })
+})
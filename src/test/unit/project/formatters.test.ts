@@ -0,0 +1,67 @@
+/**
+ * Formatting edited content with the project's formatter
+ */
+
+import { describe, it, expect, beforeEach, afterEach } from 'vitest'
+import { spawnSync } from 'child_process'
+import { chmodSync, existsSync, mkdirSync, mkdtempSync, rmSync, writeFileSync } from 'fs'
+import { tmpdir } from 'os'
+import { join } from 'path'
+import { formatContent } from '../../../project/formatters.js'
+import { planEdits } from '../../../project/edits.js'
+
+const hasGofmt = !spawnSync('gofmt', ['-l'], { input: '' }).error
+
+describe('formatContent', () => {
+  let root: string
+
+  beforeEach(() => {
+    root = mkdtempSync(join(tmpdir(), 'ts-mcp-format-'))
+  })
+
+  afterEach(() => {
+    rmSync(root, { recursive: true, force: true })
+  })
+
+  it('should format Go with gofmt and report its failures', () => {
+    if (!hasGofmt) return
+
+    expect(formatContent(join(root, 'main.go'), 'package main\nfunc main(){\nx:=1\n_ = x}\n', root)).toEqual({
+      content: 'package main\n\nfunc main() {\n\tx := 1\n\t_ = x\n}\n',
+      formatter: 'gofmt',
+    })
+    const broken = formatContent(join(root, 'main.go'), 'package main\nfunc main( {\n', root)
+    expect(broken.content).toBe('package main\nfunc main( {\n')
+    expect(broken.error).toContain('gofmt failed')
+  })
+
+  it('should leave files alone when the project configures no formatter', () => {
+    writeFileSync(join(root, 'requirements.txt'), 'requests\n')
+    expect(formatContent(join(root, 'app.py'), 'x=1\n', root)).toEqual({ content: 'x=1\n' })
+    expect(formatContent(join(root, 'src', 'app.ts'), 'const x=1\n', root)).toEqual({ content: 'const x=1\n' })
+  })
+
+  it('should not run the prettier a project installs', () => {
+    const marker = join(root, 'ran')
+    writeFileSync(join(root, '.prettierrc'), '{}\n')
+    mkdirSync(join(root, 'node_modules', '.bin'), { recursive: true })
+    writeFileSync(join(root, 'node_modules', '.bin', 'prettier'), `#!/bin/sh\necho ran > ${JSON.stringify(marker)}\n`)
+    chmodSync(join(root, 'node_modules', '.bin', 'prettier'), 0o755)
+
+    const result = formatContent(join(root, 'app.ts'), 'const x=1\n', root)
+    expect(result.content).toBe('const x=1\n')
+    expect(existsSync(marker)).toBe(false)
+  })
+
+  it('should show formatted content in the planned diff', () => {
+    if (!hasGofmt) return
+
+    const file = join(root, 'main.go')
+    writeFileSync(file, 'package main\n')
+    const plan = planEdits('edit_at_symbol', 'p', root, [{ path: file, content: 'package main\nfunc run(){}\n' }], { format: true })
+
+    expect(plan.files).toMatchObject([{ path: 'main.go', formatter: 'gofmt' }])
+    expect(plan.edits[0]!.content).toBe('package main\n\nfunc run() {}\n')
+    expect(plan.diff).toContain('+func run() {}')
+  })
+})
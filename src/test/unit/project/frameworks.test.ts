@@ -0,0 +1,79 @@
+/**
+ * Framework detection from manifests and imports
+ */
+
+import { describe, it, expect, afterEach } from 'vitest'
+import { mkdirSync, mkdtempSync, rmSync, writeFileSync } from 'fs'
+import { tmpdir } from 'os'
+import { join } from 'path'
+import { detectFrameworks, refreshFrameworks, usesFramework } from '../../../project/frameworks.js'
+import { createProject } from '../../../project/manager.js'
+import type { TreeNode } from '../../../types/core.js'
+
+const file = (path: string, content: string): TreeNode => ({ id: path, type: 'file', path, content })
+
+describe('Framework detection', () => {
+  let root: string | undefined
+
+  afterEach(() => {
+    if (root) rmSync(root, { recursive: true, force: true })
+    root = undefined
+  })
+
+  it('should detect frameworks from manifests of each directory', () => {
+    root = mkdtempSync(join(tmpdir(), 'frameworks-'))
+    mkdirSync(join(root, 'web'))
+    mkdirSync(join(root, 'api'))
+    mkdirSync(join(root, 'ml'))
+    writeFileSync(join(root, 'web', 'package.json'), '{ "dependencies": { "next": "14.0.0", "react": "18.2.0" } }')
+    writeFileSync(join(root, 'api', 'go.mod'), 'module example.com/api\n\nrequire github.com/gin-gonic/gin v1.9.1\n')
+    writeFileSync(join(root, 'ml', 'requirements.txt'), 'numpy==1.26\nDjango>=4.2\n')
+
+    const frameworks = detectFrameworks(root, [], ['web', 'api', 'ml'].map(directory => join(root!, directory)))
+
+    expect(frameworks.map(framework => [framework.id, framework.directory, framework.source])).toEqual([
+      ['nextjs', join(root, 'web'), 'package.json'],
+      ['react', join(root, 'web'), 'package.json'],
+      ['gin', join(root, 'api'), 'go.mod'],
+      ['django', join(root, 'ml'), 'requirements.txt'],
+    ])
+  })
+
+  it('should fall back to imports for frameworks missing from manifests', () => {
+    root = mkdtempSync(join(tmpdir(), 'frameworks-'))
+    writeFileSync(join(root, 'package.json'), '{ "dependencies": { "express": "4.18.0" } }')
+    const files = [
+      file(join(root, 'src', 'server.ts'), "import express from 'express'\n"),
+      file(join(root, 'src', 'App.java'), 'import org.springframework.boot.SpringApplication;\n'),
+      file(join(root, 'src', 'util.ts'), "const next = require('./next')\n"),
+    ]
+
+    const frameworks = detectFrameworks(root, files)
+
+    expect(frameworks.map(framework => [framework.id, framework.source])).toEqual([
+      ['express', 'package.json'],
+      ['spring-boot', 'imports'],
+    ])
+  })
+
+  it('should report framework use and refresh it after changes', () => {
+    root = mkdtempSync(join(tmpdir(), 'frameworks-'))
+    const project = createProject({ directory: root })
+    expect(usesFramework(project, 'nextjs')).toBeUndefined()
+
+    project.frameworks = detectFrameworks(root)
+    expect(usesFramework(project, 'nextjs')).toBe(false)
+
+    const page = file(join(root, 'pages', 'index.tsx'), "import Link from 'next/link'\n")
+    project.files.set(page.path, page)
+    refreshFrameworks(project, [page.path], [page])
+    expect(usesFramework(project, 'nextjs')).toBe(true)
+
+    writeFileSync(join(root, 'package.json'), '{ "dependencies": { "vue": "3.4.0" } }')
+    refreshFrameworks(project, [join(root, 'package.json')], [])
+    expect(project.frameworks.map(framework => [framework.id, framework.source])).toEqual([
+      ['vue', 'package.json'],
+      ['nextjs', 'imports'],
+    ])
+  })
+})
@@ -0,0 +1,88 @@
+/**
+ * Indexing a project as it was at a git ref, and the commits that changed a symbol
+ */
+
+import { describe, it, expect, beforeAll, afterAll } from 'vitest'
+import { execFileSync } from 'child_process'
+import { mkdirSync, mkdtempSync, rmSync, writeFileSync } from 'fs'
+import { tmpdir } from 'os'
+import { join } from 'path'
+import { getSymbolHistory, loadProjectAtRef } from '../../../project/git-history.js'
+import { getAllNodes } from '../../../project/manager.js'
+import { searchCode } from '../../../core/search.js'
+import type { TreeNode } from '../../../types/core.js'
+
+describe('Git history snapshots', () => {
+  let root: string
+
+  const git = (...args: string[]) => execFileSync('git', args, { cwd: root, encoding: 'utf-8' })
+  const commit = (message: string) => git('-c', 'user.name=test', '-c', 'user.email=test@example.com', 'commit', '-qam', message)
+
+  beforeAll(() => {
+    root = mkdtempSync(join(tmpdir(), 'ts-mcp-history-'))
+    mkdirSync(join(root, 'src'))
+    git('init', '-q')
+    writeFileSync(join(root, 'src/auth.ts'), 'export function legacyLogin(user: string) {\n  return user\n}\n')
+    writeFileSync(join(root, 'src/auth.test.ts'), 'legacyLogin("x")\n')
+    git('add', '.')
+    commit('v2.1')
+    git('tag', 'v2.1')
+
+    writeFileSync(join(root, 'src/auth.ts'), 'export function login(user: string, token: string) {\n  return user + token\n}\n')
+    commit('Rename login')
+  })
+
+  afterAll(() => {
+    rmSync(root, { recursive: true, force: true })
+  })
+
+  it('should index the files of the ref, not the worktree', async () => {
+    const snapshot = await loadProjectAtRef(root, 'v2.1')
+    const nodes = getAllNodes(snapshot.project)
+
+    expect(snapshot.commit).toBe(git('rev-parse', 'v2.1').trim())
+    expect([...snapshot.project.files.keys()]).toEqual([join(root, 'src/auth.ts')])
+    expect(searchCode('legacyLogin', nodes, { exactMatch: true })).toHaveLength(1)
+    expect(searchCode('login', nodes, { exactMatch: true })).toHaveLength(0)
+  })
+
+  it('should keep snapshots of different refs apart', async () => {
+    const head = await loadProjectAtRef(root, 'HEAD', 'app')
+    const result = searchCode('login', getAllNodes(head.project), { exactMatch: true })
+
+    expect(head.project.id).toBe(`app@${head.commit.substring(0, 12)}`)
+    expect(result[0]?.node.content).toContain('token: string')
+  })
+
+  it('should reject unknown refs', async () => {
+    await expect(loadProjectAtRef(root, 'v9.9')).rejects.toThrow('Unknown git ref: v9.9')
+  })
+
+  it('should list the commits that changed a symbol, newest first', async () => {
+    const login: TreeNode = { id: 'login', type: 'function', name: 'login', path: join(root, 'src/auth.ts'), startLine: 1, endLine: 3 }
+    const commits = await getSymbolHistory(login)
+
+    expect(commits.map(entry => entry.subject)).toEqual(['Rename login', 'v2.1'])
+    expect(commits[0]?.diff).toContain('+export function login(user: string, token: string) {')
+    expect(commits[0]?.author).toBe('test')
+  })
+
+  it('should limit commits and diff lines', async () => {
+    const login: TreeNode = { id: 'login', type: 'function', name: 'login', path: join(root, 'src/auth.ts'), startLine: 1, endLine: 3 }
+
+    const [latest, ...rest] = await getSymbolHistory(login, { maxCommits: 1, maxDiffLines: 2 })
+    expect(rest).toHaveLength(0)
+    expect(latest?.diff?.split('\n')).toHaveLength(2)
+    expect(latest?.diffTruncated).toBe(true)
+
+    const withoutDiffs = await getSymbolHistory(login, { includeDiffs: false })
+    expect(withoutDiffs.every(entry => entry.diff === undefined)).toBe(true)
+  })
+
+  it('should explain files without history', async () => {
+    writeFileSync(join(root, 'src/draft.ts'), 'export function draft() {}\n')
+    const draft: TreeNode = { id: 'draft', type: 'function', name: 'draft', path: join(root, 'src/draft.ts'), startLine: 1, endLine: 1 }
+
+    await expect(getSymbolHistory(draft)).rejects.toThrow(`No git history for ${join(root, 'src/draft.ts')}`)
+  })
+})
@@ -0,0 +1,71 @@
+/**
+ * Go workspace and multi-module detection
+ */
+
+import { describe, it, expect } from 'vitest'
+import { resolve } from 'path'
+import { detectGoModules, parseGoWorkUses, parseGoModulePath, parseGoReplaces, resolveGoImport, findOwningGoModule } from '../../../project/go-workspace.js'
+import { createProject, parseProject, getAllNodes } from '../../../project/manager.js'
+import { findUsage } from '../../../core/search.js'
+
+describe('Go workspaces', () => {
+  const fixture = resolve(import.meta.dirname, '../../fixtures/go-workspace')
+
+  it('should parse single-line and block use directives', () => {
+    const uses = parseGoWorkUses('go 1.22\n\nuse ./tools\nuse (\n\t./a\n\t"./b" // quoted\n)\n')
+    expect(uses).toEqual(['./tools', './a', './b'])
+  })
+
+  it('should read the module path from go.mod', () => {
+    expect(parseGoModulePath('module example.com/foo\n\ngo 1.22\n')).toBe('example.com/foo')
+    expect(parseGoModulePath('go 1.22\n')).toBeNull()
+  })
+
+  it('should detect every module listed in go.work', () => {
+    const modules = detectGoModules(fixture)
+    expect(modules.map(module => module.path).sort()).toEqual([
+      'example.com/libs/strutil',
+      'example.com/services/api',
+    ])
+  })
+
+  it('should read local replace directives as modules', () => {
+    const replaces = parseGoReplaces([
+      'module example.com/app',
+      'replace example.com/lib => ../lib',
+      'replace (',
+      '\texample.com/util v1.2.0 => ./third_party/util // vendored fork',
+      '\texample.com/remote => example.com/fork v1.0.0',
+      ')',
+    ].join('\n'), '/ws/app')
+
+    expect(replaces).toEqual([
+      { path: 'example.com/lib', directory: '/ws/lib' },
+      { path: 'example.com/util', directory: '/ws/app/third_party/util' },
+    ])
+    expect(resolveGoImport('example.com/lib/strings', replaces)?.directory).toBe('/ws/lib/strings')
+  })
+
+  it('should resolve imports to the longest matching module', () => {
+    const modules = [
+      { path: 'example.com/libs', directory: '/ws/libs' },
+      { path: 'example.com/libs/strutil', directory: '/ws/libs/strutil' },
+    ]
+
+    expect(resolveGoImport('example.com/libs/strutil/casing', modules)?.directory).toBe('/ws/libs/strutil/casing')
+    expect(resolveGoImport('example.com/libsx', modules)).toBeNull()
+    expect(findOwningGoModule('/ws/libs/strutil/casing/casing.go', modules)?.path).toBe('example.com/libs/strutil')
+  })
+
+  it('should find usages across workspace modules', async () => {
+    const project = createProject({ directory: fixture, languages: ['go'] })
+    await parseProject(project)
+
+    expect(project.subProjects).toHaveLength(2)
+
+    const usages = findUsage('ToTitle', getAllNodes(project))
+    const paths = new Set(usages.map(usage => usage.node.path))
+    expect([...paths].some(path => path.includes('services/api'))).toBe(true)
+    expect([...paths].some(path => path.includes('libs/strutil'))).toBe(true)
+  })
+})
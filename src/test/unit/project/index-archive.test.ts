@@ -0,0 +1,108 @@
+/**
+ * Index archive export/import with stale-entry detection
+ */
+
+import { describe, it, expect, beforeEach, afterEach } from 'vitest'
+import { appendFileSync, cpSync, mkdtempSync, readFileSync, rmSync, writeFileSync } from 'fs'
+import { tmpdir } from 'os'
+import { join, resolve } from 'path'
+import { gunzipSync } from 'zlib'
+import { createProject, parseProject } from '../../../project/manager.js'
+import { exportIndex, importIndex } from '../../../project/index-archive.js'
+import { findSimilarCode } from '../../../analysis/similarity.js'
+import type { TreeNode } from '../../../types/core.js'
+
+describe('Index archives', () => {
+  const fixture = resolve(import.meta.dirname, '../../fixtures/simple-ts')
+  let root: string
+  let archive: string
+
+  beforeEach(() => {
+    root = mkdtempSync(join(tmpdir(), 'ts-mcp-index-'))
+    cpSync(fixture, join(root, 'project'), { recursive: true })
+    archive = join(root, 'index.tsz')
+  })
+
+  afterEach(() => {
+    rmSync(root, { recursive: true, force: true })
+  })
+
+  async function exportFixture() {
+    const project = createProject({ directory: join(root, 'project'), languages: ['typescript'] })
+    await parseProject(project)
+    await exportIndex(project, archive)
+    return project
+  }
+
+  it('should write a gzip archive with relative paths', async () => {
+    const project = await exportFixture()
+    const contents = JSON.parse(gunzipSync(readFileSync(archive)).toString('utf-8'))
+
+    expect(contents.format).toBe('tree-sitter-mcp-index')
+    expect(contents.files).toHaveLength(project.files.size)
+    expect(contents.files.every((file: { path: string }) => !file.path.startsWith('/'))).toBe(true)
+  })
+
+  it('should restore unchanged files without re-parsing them', async () => {
+    const exported = await exportFixture()
+    const { project, report } = await importIndex(archive, { directory: join(root, 'project') })
+
+    expect(report.restored).toBe(exported.files.size)
+    expect(report.reparsed).toBe(0)
+    expect(report.toolVersionMatched).toBe(true)
+
+    const [filePath] = Array.from(exported.files.keys())
+    const names = (node: TreeNode) => (node.children ?? []).map(child => `${child.type}:${child.name}:${child.startLine}`)
+    expect(names(project.files.get(filePath!)!)).toEqual(names(exported.files.get(filePath!)!))
+    expect(project.files.get(filePath!)!.path).toBe(filePath)
+  })
+
+  it('should rank similar code the same after a restore', async () => {
+    const exported = await exportFixture()
+    const { project } = await importIndex(archive, { directory: join(root, 'project') })
+    const snippet = 'function check(value: string): boolean {\n  return value.trim().length > 2\n}'
+    const ranking = (source: typeof project) => findSimilarCode(source, snippet, { language: 'typescript', minSimilarity: 0, maxResults: 50 })
+      .map(result => `${result.node.name}:${result.similarity}`)
+
+    const restored = Array.from(project.nodes.values()).flat().filter(node => node.type === 'function')
+    expect(restored.length).toBeGreaterThan(0)
+    expect(restored.every(node => node.syntaxShape && !node.rawNode)).toBe(true)
+    expect(ranking(project)).toEqual(ranking(exported))
+  })
+
+  it('should re-parse changed files and pick up added and removed ones', async () => {
+    const exported = await exportFixture()
+    const [changed, removed] = Array.from(exported.files.keys())
+    appendFileSync(changed!, '\nexport function addedLater(): void {}\n')
+    rmSync(removed!)
+    writeFileSync(join(root, 'project', 'src', 'fresh.ts'), 'export const fresh = true\n')
+
+    const { project, report } = await importIndex(archive, { directory: join(root, 'project') })
+
+    expect(report.reparsed).toBe(1)
+    expect(report.added).toBe(1)
+    expect(report.removed).toBe(1)
+    expect(project.files.has(removed!)).toBe(false)
+    expect(project.files.get(changed!)!.children?.some(child => child.name === 'addedLater')).toBe(true)
+  })
+
+  it('should re-parse everything when the archive comes from another version', async () => {
+    const exported = await exportFixture()
+    const contents = JSON.parse(gunzipSync(readFileSync(archive)).toString('utf-8'))
+    writeFileSync(archive, JSON.stringify({ ...contents, toolVersion: '0.0.1' }))
+
+    const { report } = await importIndex(archive, { directory: join(root, 'project') })
+
+    expect(report.toolVersionMatched).toBe(false)
+    expect(report.restored).toBe(0)
+    expect(report.reparsed).toBe(exported.files.size)
+  })
+
+  it('should reject unknown archive formats', async () => {
+    writeFileSync(archive, JSON.stringify({ format: 'tree-sitter-mcp-index', formatVersion: 99, files: [] }))
+    await expect(importIndex(archive, { directory: join(root, 'project') })).rejects.toThrow('Unsupported index format version 99')
+
+    writeFileSync(archive, 'not an archive')
+    await expect(importIndex(archive, { directory: join(root, 'project') })).rejects.toThrow('Cannot read index archive')
+  })
+})
@@ -0,0 +1,155 @@
+/**
+ * Gradle and sbt multi-project builds
+ */
+
+import { describe, it, expect, beforeEach, afterEach } from 'vitest'
+import { mkdirSync, mkdtempSync, rmSync, writeFileSync } from 'fs'
+import { tmpdir } from 'os'
+import { join } from 'path'
+import { jvmBuildToNodes, parseGradleDependencies, parseGradleSettings, parseSbtBuild } from '../../../core/jvm-build.js'
+import { detectJvmModules, findOwningJvmModule } from '../../../project/jvm-modules.js'
+
+const SETTINGS = `rootProject.name = "shop"
+
+include(":core", ":web")
+include 'libs:json'   // nested
+// include ':legacy'
+project(':libs:json').projectDir = file('third_party/json')
+`
+
+const BUILD = `buildscript {
+    dependencies {
+        classpath 'com.android.tools.build:gradle:8.2.0'
+    }
+}
+
+dependencies {
+    implementation("com.google.guava:guava:33.0-jre")
+    api(project(":core"))
+    testImplementation(platform("org.junit:junit-bom:5.10.0"))
+    runtimeOnly group: 'org.postgresql', name: 'postgresql', version: '42.7.1'
+    implementation("org.slf4j:slf4j-api:\${slf4jVersion}") {
+        exclude(group = "org.example")
+    }
+    implementation(libs.jackson.databind)
+    /* compileOnly("org.projectlombok:lombok:1.18.30") */
+}
+
+tasks.withType<Test> {
+    useJUnitPlatform()
+}
+`
+
+const SBT = `ThisBuild / scalaVersion := "3.3.1"
+
+val circeVersion = "0.14.6"
+val sharedDeps = Seq("org.typelevel" %% "cats-core" % "2.10.0")
+
+libraryDependencies += "ch.qos.logback" % "logback-classic" % "1.4.14"
+
+lazy val core = project
+  .settings(
+    libraryDependencies ++= Seq(
+      "io.circe" %% "circe-core" % circeVersion,
+      "org.scalameta" %% "munit" % "1.0.0" % Test,
+    )
+  )
+
+lazy val api = (project in file("services/api"))
+  .dependsOn(core % "compile->compile;test->test")
+  .settings(libraryDependencies += "com.softwaremill.sttp.tapir" %% "tapir-core" % "1.9.0")
+`
+
+describe('JVM builds', () => {
+  let root: string
+
+  beforeEach(() => {
+    root = mkdtempSync(join(tmpdir(), 'ts-mcp-jvm-'))
+  })
+
+  afterEach(() => {
+    rmSync(root, { recursive: true, force: true })
+  })
+
+  it('should read Gradle includes and project directories', () => {
+    const settings = parseGradleSettings(SETTINGS)
+    expect(settings.rootName).toBe('shop')
+    expect(settings.includes).toEqual([
+      { path: ':core', line: 3 },
+      { path: ':web', line: 3 },
+      { path: ':libs:json', line: 4 },
+    ])
+    expect([...settings.projectDirs]).toEqual([[':libs:json', 'third_party/json']])
+  })
+
+  it('should read Gradle dependencies in string, map and project notation', () => {
+    const { dependencies, projects } = parseGradleDependencies(BUILD)
+    expect(dependencies).toEqual([
+      { coordinate: 'com.google.guava:guava', version: '33.0-jre', configuration: 'implementation', line: 8 },
+      { coordinate: 'org.junit:junit-bom', version: '5.10.0', configuration: 'testImplementation', line: 10 },
+      { coordinate: 'org.postgresql:postgresql', version: '42.7.1', configuration: 'runtimeOnly', line: 11 },
+      { coordinate: 'org.slf4j:slf4j-api', version: '${slf4jVersion}', configuration: 'implementation', line: 12 },
+    ])
+    expect(projects).toEqual([{ coordinate: ':core', configuration: 'api', line: 9 }])
+  })
+
+  it('should read sbt projects with their directories and dependencies', () => {
+    const build = parseSbtBuild(SBT)
+    expect(build.projects.map(project => [project.name, project.directory, project.line, project.endLine, project.dependsOn])).toEqual([
+      ['core', 'core', 8, 14, []],
+      ['api', 'services/api', 16, 18, ['core']],
+    ])
+    expect(build.projects[0]!.dependencies).toEqual([
+      { coordinate: 'io.circe:circe-core', version: 'circeVersion', configuration: 'compile', line: 11 },
+      { coordinate: 'org.scalameta:munit', version: '1.0.0', configuration: 'test', line: 12 },
+    ])
+    expect(build.dependencies.map(dependency => dependency.coordinate)).toEqual(['ch.qos.logback:logback-classic'])
+  })
+
+  it('should index build files as project and dependency nodes', () => {
+    expect(jvmBuildToNodes(SETTINGS, '/p/settings.gradle').map(node => [node.type, node.name, node.startLine])).toEqual([
+      ['project', ':core', 3],
+      ['project', ':web', 3],
+      ['project', ':libs:json', 4],
+    ])
+    expect(jvmBuildToNodes(SBT, '/p/build.sbt').map(node => [node.type, node.name, node.symbol?.container])).toEqual([
+      ['dependency', 'ch.qos.logback:logback-classic', undefined],
+      ['project', 'core', undefined],
+      ['dependency', 'io.circe:circe-core', 'core'],
+      ['dependency', 'org.scalameta:munit', 'core'],
+      ['project', 'api', undefined],
+      ['dependency', 'com.softwaremill.sttp.tapir:tapir-core', 'api'],
+    ])
+    const [guava] = jvmBuildToNodes(BUILD, '/p/web/build.gradle.kts')
+    expect(guava!.symbol?.signature).toBe('implementation com.google.guava:guava:33.0-jre')
+  })
+
+  it('should map a Gradle build to its subproject directories', () => {
+    writeFileSync(join(root, 'settings.gradle'), SETTINGS)
+    writeFileSync(join(root, 'build.gradle'), 'plugins { id "java" }\n')
+    mkdirSync(join(root, 'web'))
+    writeFileSync(join(root, 'web', 'build.gradle.kts'), BUILD)
+
+    const modules = detectJvmModules(root)
+    expect(modules.map(module => [module.name, module.directory, module.dependsOn, module.dependencies.length])).toEqual([
+      [':', root, [], 0],
+      [':core', join(root, 'core'), [], 0],
+      [':web', join(root, 'web'), [':core'], 4],
+      [':libs:json', join(root, 'third_party', 'json'), [], 0],
+    ])
+    expect(findOwningJvmModule(join(root, 'web', 'src', 'App.kt'), modules)?.name).toBe(':web')
+    expect(findOwningJvmModule(join(root, 'docs', 'notes.md'), modules)?.name).toBe(':')
+  })
+
+  it('should give sbt settings outside any project to the root project', () => {
+    writeFileSync(join(root, 'build.sbt'), SBT)
+
+    const modules = detectJvmModules(root)
+    expect(modules.map(module => [module.name, module.tool, module.directory, module.dependsOn])).toEqual([
+      [root.split('/').pop(), 'sbt', root, []],
+      ['core', 'sbt', join(root, 'core'), []],
+      ['api', 'sbt', join(root, 'services', 'api'), ['core']],
+    ])
+    expect(modules[0]!.dependencies.map(dependency => dependency.coordinate)).toEqual(['ch.qos.logback:logback-classic'])
+  })
+})
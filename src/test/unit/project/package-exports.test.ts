@@ -0,0 +1,80 @@
+/**
+ * Package entry points from package.json exports, imports, module and main
+ */
+
+import { describe, it, expect, beforeEach, afterEach } from 'vitest'
+import { mkdirSync, mkdtempSync, rmSync, writeFileSync } from 'fs'
+import { tmpdir } from 'os'
+import { dirname, join } from 'path'
+import { loadPackageEntryPoints, resolvePackageSpecifier, resolvePackageTarget } from '../../../project/package-exports.js'
+import type { PackageEntryPoints } from '../../../types/core.js'
+
+const PACKAGE: PackageEntryPoints = {
+  name: 'parser',
+  directory: '/repo/packages/parser',
+  source: '/repo/packages/parser/package.json',
+  exports: {
+    '.': {
+      node: { import: './dist/esm/index.mjs', require: './dist/cjs/index.cjs' },
+      default: './dist/browser.js',
+    },
+    './plugins/*': ['./plugins/*.js'],
+    './plugins/internal/*': null,
+    './package.json': './package.json',
+  },
+  imports: { '#platform': { require: './src/platform-cjs.js', default: './src/platform.js' } },
+}
+
+describe('package entry points', () => {
+  it('should pick the first condition in exports order that the kind of import sets', () => {
+    expect(resolvePackageTarget(PACKAGE, '.', 'import')).toBe('./dist/esm/index.mjs')
+    expect(resolvePackageTarget(PACKAGE, '.', 'require')).toBe('./dist/cjs/index.cjs')
+    expect(resolvePackageTarget(PACKAGE, './plugins/json', 'import')).toBe('./plugins/json.js')
+    expect(resolvePackageTarget(PACKAGE, './plugins/internal/cache', 'import')).toBeUndefined()
+    expect(resolvePackageTarget(PACKAGE, './missing', 'import')).toBeUndefined()
+    expect(resolvePackageTarget({ ...PACKAGE, exports: undefined, main: './index.cjs', module: './index.mjs' }, '.', 'require')).toBe('./index.cjs')
+    expect(resolvePackageTarget({ ...PACKAGE, exports: { import: './a.mjs', require: './a.cjs' } }, '.', 'import')).toBe('./a.mjs')
+  })
+
+  it('should offer the sources of built entry points and resolve #imports within the package', () => {
+    expect(resolvePackageSpecifier('parser', '/repo/apps/cli/main.js', [PACKAGE], 'require')).toEqual([
+      '/repo/packages/parser/dist/cjs/index.cjs',
+      '/repo/packages/parser/src/cjs/index',
+      '/repo/packages/parser/src/index',
+    ])
+    expect(resolvePackageSpecifier('#platform', '/repo/packages/parser/src/read.js', [PACKAGE], 'require')).toEqual(['/repo/packages/parser/src/platform-cjs.js'])
+    expect(resolvePackageSpecifier('#platform', '/repo/apps/cli/main.js', [PACKAGE], 'require')).toEqual([])
+    expect(resolvePackageSpecifier('parser-utils', '/repo/apps/cli/main.js', [PACKAGE], 'import')).toEqual([])
+  })
+
+  describe('loading', () => {
+    let root: string
+
+    beforeEach(() => {
+      root = mkdtempSync(join(tmpdir(), 'ts-mcp-packages-'))
+    })
+
+    afterEach(() => {
+      rmSync(root, { recursive: true, force: true })
+    })
+
+    function write(path: string, content: string) {
+      mkdirSync(dirname(join(root, path)), { recursive: true })
+      writeFileSync(join(root, path), content)
+    }
+
+    it('should read named packages and skip unnamed or unreadable manifests', () => {
+      write('package.json', JSON.stringify({ private: true, workspaces: ['packages/*'] }))
+      write('packages/core/package.json', JSON.stringify({ name: '@app/core', main: './lib/index.js', exports: { '.': './lib/index.js' } }))
+      write('packages/broken/package.json', '{ "name": ')
+
+      expect(loadPackageEntryPoints(root)).toEqual([{
+        name: '@app/core',
+        directory: join(root, 'packages/core'),
+        source: join(root, 'packages/core/package.json'),
+        exports: { '.': './lib/index.js' },
+        main: './lib/index.js',
+      }])
+    })
+  })
+})
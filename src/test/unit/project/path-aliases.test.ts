@@ -0,0 +1,107 @@
+/**
+ * Path aliases from tsconfig paths, jest moduleNameMapper and bundler configs
+ */
+
+import { describe, it, expect, beforeEach, afterEach } from 'vitest'
+import { mkdirSync, mkdtempSync, rmSync, writeFileSync } from 'fs'
+import { tmpdir } from 'os'
+import { dirname, join } from 'path'
+import { expandPathAlias, loadPathAliases, parseBundlerAliases, parseModuleNameMapper } from '../../../project/path-aliases.js'
+import { createProject } from '../../../project/manager.js'
+import { findAliasExpressions } from '../../../import/aliases.js'
+import type { TreeNode } from '../../../types/core.js'
+
+describe('Path aliases', () => {
+  let root: string
+
+  beforeEach(() => {
+    root = mkdtempSync(join(tmpdir(), 'ts-mcp-paths-'))
+  })
+
+  afterEach(() => {
+    rmSync(root, { recursive: true, force: true })
+  })
+
+  function write(path: string, content: string) {
+    mkdirSync(dirname(join(root, path)), { recursive: true })
+    writeFileSync(join(root, path), content)
+  }
+
+  it('should read tsconfig paths through extends, relative to the declaring baseUrl', () => {
+    write('tsconfig.base.json', `{
+  // shared by every package
+  "compilerOptions": {
+    "baseUrl": ".",
+    "paths": { "@app/shared/*": ["packages/shared/src/*"], "@app/config": ["config/index.ts"], },
+  },
+}`)
+    write('packages/web/tsconfig.json', '{ "extends": "../../tsconfig.base", "compilerOptions": { "jsx": "react" } }')
+
+    const aliases = loadPathAliases(root).filter(alias => alias.scope === join(root, 'packages/web'))
+    expect(aliases).toEqual([
+      { pattern: '@app/shared/*', targets: [join(root, 'packages/shared/src/*')], scope: join(root, 'packages/web'), source: join(root, 'packages/web/tsconfig.json') },
+      { pattern: '@app/config', targets: [join(root, 'config/index.ts')], scope: join(root, 'packages/web'), source: join(root, 'packages/web/tsconfig.json') },
+    ])
+  })
+
+  it('should keep jest mappings a path pattern can express', () => {
+    const aliases = parseModuleNameMapper(`module.exports = {
+  moduleNameMapper: {
+    '^@/(.*)$': '<rootDir>/src/$1',
+    '\\\\.(css|less)$': 'identity-obj-proxy',
+  },
+}`, '/p', '/p/jest.config.js')
+
+    expect(aliases).toEqual([{ pattern: '@/*', targets: ['/p/src/*'], scope: '/p', source: '/p/jest.config.js' }])
+  })
+
+  it('should read webpack and vite aliases', () => {
+    const webpack = parseBundlerAliases(`module.exports = {
+  resolve: {
+    alias: {
+      '@components': path.resolve(__dirname, 'src/components'),
+      config$: path.join(__dirname, 'config', 'prod.js'),
+      lodash: 'lodash-es',
+    },
+  },
+}`, '/p', '/p/webpack.config.js')
+    expect(webpack.map(alias => [alias.pattern, alias.targets[0]])).toEqual([
+      ['@components', '/p/src/components'],
+      ['@components/*', '/p/src/components/*'],
+      ['config', '/p/config/prod.js'],
+    ])
+
+    const vite = parseBundlerAliases(`export default defineConfig({
+  resolve: { alias: [{ find: '~', replacement: fileURLToPath(new URL('./src', import.meta.url)) }] },
+})`, '/p', '/p/vite.config.ts')
+    expect(vite.map(alias => [alias.pattern, alias.targets[0]])).toEqual([['~', '/p/src'], ['~/*', '/p/src/*']])
+  })
+
+  it('should prefer the nearest config and the most specific pattern', () => {
+    const aliases = [
+      { pattern: '@app/*', targets: ['/p/src/*'], scope: '/p', source: '/p/tsconfig.json' },
+      { pattern: '@app/ui/*', targets: ['/p/ui/*'], scope: '/p', source: '/p/tsconfig.json' },
+      { pattern: '@app/*', targets: ['/p/web/src/*'], scope: '/p/web', source: '/p/web/tsconfig.json' },
+    ]
+
+    expect(expandPathAlias('@app/ui/button', '/p/server.ts', aliases)).toEqual(['/p/ui/button', '/p/src/ui/button'])
+    expect(expandPathAlias('@app/ui/button', '/p/web/main.ts', aliases)).toEqual(['/p/web/src/ui/button', '/p/ui/button', '/p/src/ui/button'])
+    expect(expandPathAlias('react', '/p/web/main.ts', aliases)).toEqual([])
+  })
+
+  it('should resolve aliased imports when finding usages', () => {
+    write('tsconfig.json', '{ "compilerOptions": { "paths": { "@shared/*": ["./packages/shared/*"] } } }')
+    const project = createProject({ directory: root })
+    const files: Record<string, [string, string[]]> = {
+      'packages/shared/format.ts': ['export function formatDate() {}\n', ['formatDate']],
+      'apps/web/main.ts': [`import { formatDate as fmt } from '@shared/format'\nfmt()\n`, []],
+    }
+    for (const [path, [content, defines]] of Object.entries(files)) {
+      const filePath = join(root, path)
+      const children: TreeNode[] = defines.map(name => ({ id: name, type: 'function', name, path: filePath }))
+      project.files.set(filePath, { id: path, type: 'file', path: filePath, content, children })
+    }
+
+    expect(findAliasExpressions(project, 'formatDate').get(join(root, 'apps/web/main.ts'))).toEqual(['fmt'])
+  })
+})
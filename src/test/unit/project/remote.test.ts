@@ -0,0 +1,106 @@
+/**
+ * Remote repositories: git URL detection, cache directories, and shallow checkouts
+ */
+
+import { describe, it, expect, beforeAll, afterAll } from 'vitest'
+import { execFileSync } from 'child_process'
+import { existsSync, mkdtempSync, rmSync, writeFileSync } from 'fs'
+import { tmpdir } from 'os'
+import { join } from 'path'
+import { checkoutRemoteRepository, getRemoteCacheDir, isGitUrl, setFileRemotesAllowed } from '../../../project/remote.js'
+import { clearMCPMemory, handleToolRequest } from '../../../mcp/handlers.js'
+import { REMOTE_REPO_CONFIG } from '../../../constants/persistence.js'
+
+describe('Remote repositories', () => {
+  let root: string
+  let work: string
+  let bare: string
+  let cache: string
+  let previousCache: string | undefined
+
+  const git = (cwd: string, ...args: string[]) => execFileSync('git', args, { cwd, encoding: 'utf-8' })
+  const commit = (message: string) => git(work, '-c', 'user.name=test', '-c', 'user.email=test@example.com', 'commit', '-qam', message)
+
+  beforeAll(() => {
+    root = mkdtempSync(join(tmpdir(), 'ts-mcp-remote-'))
+    work = join(root, 'work')
+    bare = join(root, 'origin.git')
+    cache = join(root, 'cache')
+    previousCache = process.env[REMOTE_REPO_CONFIG.CACHE_DIR_ENV]
+    process.env[REMOTE_REPO_CONFIG.CACHE_DIR_ENV] = cache
+
+    git(root, 'init', '-q', work)
+    writeFileSync(join(work, 'main.ts'), 'export function first() {}\n')
+    git(work, 'add', '.')
+    commit('first')
+    git(root, 'clone', '-q', '--bare', work, bare)
+  })
+
+  afterAll(() => {
+    if (previousCache === undefined) delete process.env[REMOTE_REPO_CONFIG.CACHE_DIR_ENV]
+    else process.env[REMOTE_REPO_CONFIG.CACHE_DIR_ENV] = previousCache
+    setFileRemotesAllowed(false)
+    clearMCPMemory()
+    rmSync(root, { recursive: true, force: true })
+  })
+
+  it('should tell git URLs from local paths', () => {
+    expect(isGitUrl('https://github.com/acme/api.git')).toBe(true)
+    expect(isGitUrl('git@github.com:acme/api.git')).toBe(true)
+    expect(isGitUrl('ssh://git@host/acme/api')).toBe(true)
+    expect(isGitUrl('/home/me/api')).toBe(false)
+    expect(isGitUrl('../api')).toBe(false)
+    expect(isGitUrl('--upload-pack=touch /tmp/x')).toBe(false)
+  })
+
+  it('should refuse file:// URLs unless the server allows them', async () => {
+    expect(isGitUrl('file:///srv/git/api.git')).toBe(false)
+    await expect(handleToolRequest({ params: { name: 'register_project', arguments: { url: `file://${bare}`, projectId: 'local-remote' } } }))
+      .rejects.toThrow('--allow-file-remotes')
+    expect(existsSync(getRemoteCacheDir(`file://${bare}`))).toBe(false)
+
+    setFileRemotesAllowed(true)
+    expect(isGitUrl('file:///srv/git/api.git')).toBe(true)
+  })
+
+  it('should give each url and ref a cache directory of its own', () => {
+    const main = getRemoteCacheDir('https://github.com/acme/api.git', 'main')
+    expect(main.startsWith(cache)).toBe(true)
+    expect(main).toMatch(/\/api-[0-9a-f]{12}$/)
+    expect(getRemoteCacheDir('https://github.com/acme/api.git', 'main')).toBe(main)
+    expect(getRemoteCacheDir('https://github.com/acme/api.git', 'v2')).not.toBe(main)
+    expect(getRemoteCacheDir('git@github.com:acme/api.git')).toBe(getRemoteCacheDir('git@github.com:acme/api.git', 'HEAD'))
+  })
+
+  it('should refuse refs git would read as options', async () => {
+    setFileRemotesAllowed(true)
+    const marker = join(root, 'pwned')
+    await expect(checkoutRemoteRepository(`file://${bare}`, `--upload-pack=touch ${marker}`)).rejects.toThrow('Invalid git ref')
+    expect(existsSync(marker)).toBe(false)
+  })
+
+  it('should clone a repository through register_project and fetch it again on refresh', async () => {
+    setFileRemotesAllowed(true)
+    const url = `file://${bare}`
+    const register = async (refresh: boolean) => {
+      const result = await handleToolRequest({ params: { name: 'register_project', arguments: { url, projectId: 'remote-test', refresh } } })
+      return JSON.parse(result.content[0]!.text)
+    }
+
+    const first = await register(false)
+    expect(first.remote).toEqual({ url, ref: 'HEAD', commit: git(bare, 'rev-parse', 'HEAD').trim() })
+    expect(first.directory).toBe(getRemoteCacheDir(url))
+    expect(existsSync(join(first.directory, 'main.ts'))).toBe(true)
+
+    writeFileSync(join(work, 'second.ts'), 'export function second() {}\n')
+    git(work, 'add', '.')
+    commit('second')
+    git(work, 'push', '-q', bare, 'HEAD')
+
+    expect((await register(false)).remote.commit).toBe(first.remote.commit)
+    const refreshed = await register(true)
+    expect(refreshed.remote.commit).toBe(git(bare, 'rev-parse', 'HEAD').trim())
+    expect(refreshed.remote.commit).not.toBe(first.remote.commit)
+    expect(existsSync(join(refreshed.directory, 'second.ts'))).toBe(true)
+  })
+})
@@ -0,0 +1,65 @@
+/**
+ * Saved queries from .tree-sitter-mcp.json, referenced as @name
+ */
+
+import { describe, it, expect, beforeEach, afterEach } from 'vitest'
+import { mkdtempSync, rmSync, writeFileSync } from 'fs'
+import { tmpdir } from 'os'
+import { join } from 'path'
+import { expandSavedQueries } from '../../../project/saved-queries.js'
+import { applySearchQuery } from '../../../core/query.js'
+import type { TreeNode } from '../../../types/core.js'
+
+describe('Saved queries', () => {
+  let root: string
+
+  beforeEach(() => {
+    root = mkdtempSync(join(tmpdir(), 'ts-mcp-queries-'))
+    writeFileSync(join(root, '.tree-sitter-mcp.json'), JSON.stringify({
+      queries: {
+        'endpoints': 'kind:function name:~Handler',
+        'first-party': '-path:vendor',
+        'api': '@endpoints @first-party',
+        'auth': 'AuthService',
+        'loop': '@cycle',
+        'cycle': '@loop',
+      },
+    }))
+  })
+
+  afterEach(() => {
+    rmSync(root, { recursive: true, force: true })
+  })
+
+  it('should expand references in parentheses, nested ones included', () => {
+    expect(expandSavedQueries(root, '@endpoints')).toBe('kind:function name:~Handler')
+    expect(expandSavedQueries(root, '@endpoints lang:go -@auth')).toBe('(kind:function name:~Handler) lang:go -(AuthService)')
+    expect(expandSavedQueries(root, '@api')).toBe('(kind:function name:~Handler) (-path:vendor)')
+    expect(expandSavedQueries(root, '@auth')).toBe('AuthService')
+    expect(expandSavedQueries(root, 'user@example.com')).toBe('user@example.com')
+  })
+
+  it('should select what the expanded query does', () => {
+    const nodes: TreeNode[] = [
+      { id: '1', type: 'function', name: 'UserHandler', path: join(root, 'api/users.go') },
+      { id: '2', type: 'function', name: 'VendorHandler', path: join(root, 'vendor/lib.go') },
+      { id: '3', type: 'struct', name: 'HandlerConfig', path: join(root, 'api/config.go') },
+    ]
+    expect(applySearchQuery(expandSavedQueries(root, '@api'), nodes, root).nodes.map(node => node.name)).toEqual(['UserHandler'])
+  })
+
+  it('should report unknown and circular references', () => {
+    expect(() => expandSavedQueries(root, '@endpoint')).toThrow('Unknown saved query: @endpoint. Queries defined in .tree-sitter-mcp.json: @endpoints, @first-party')
+    expect(() => expandSavedQueries(root, '@loop')).toThrow('Saved query @loop refers to itself through @loop -> @cycle -> @loop')
+  })
+
+  it('should leave queries alone in projects without saved queries', () => {
+    const plain = mkdtempSync(join(tmpdir(), 'ts-mcp-queries-'))
+    try {
+      expect(expandSavedQueries(plain, '@Override')).toBe('@Override')
+    }
+    finally {
+      rmSync(plain, { recursive: true, force: true })
+    }
+  })
+})
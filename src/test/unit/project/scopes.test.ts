@@ -0,0 +1,68 @@
+/**
+ * Named scopes from .tree-sitter-mcp.json
+ */
+
+import { describe, it, expect, beforeEach, afterEach } from 'vitest'
+import { mkdtempSync, rmSync, writeFileSync } from 'fs'
+import { tmpdir } from 'os'
+import { join } from 'path'
+import { createProject } from '../../../project/manager.js'
+import { matchesScope, resolveScope, scopeProject } from '../../../project/scopes.js'
+import type { Project, TreeNode } from '../../../types/core.js'
+
+describe('Named scopes', () => {
+  let root: string
+
+  beforeEach(() => {
+    root = mkdtempSync(join(tmpdir(), 'ts-mcp-scopes-'))
+    writeFileSync(join(root, '.tree-sitter-mcp.json'), JSON.stringify({
+      scopes: {
+        backend: 'services/**',
+        ui: ['apps/web/**', 'packages/ui', '!**/*.stories.tsx'],
+      },
+    }))
+  })
+
+  afterEach(() => {
+    rmSync(root, { recursive: true, force: true })
+  })
+
+  function projectWith(paths: string[]): Project {
+    const project = createProject({ directory: root })
+    for (const path of paths) {
+      const file: TreeNode = { id: path, type: 'file', path: join(root, path) }
+      project.files.set(file.path, file)
+      project.nodes.set(file.path, [{ id: `${path}-fn`, type: 'function', name: 'main', path: file.path }])
+    }
+    return project
+  }
+
+  it('should match globs, plain directories and exclusions', () => {
+    const ui = resolveScope(root, 'ui')
+
+    expect(matchesScope(ui, 'apps/web/src/App.tsx')).toBe(true)
+    expect(matchesScope(ui, 'packages/ui/Button.tsx')).toBe(true)
+    expect(matchesScope(ui, 'packages/ui-kit/Button.tsx')).toBe(false)
+    expect(matchesScope(ui, 'apps/web/src/Button.stories.tsx')).toBe(false)
+  })
+
+  it('should restrict files and nodes to the scope', () => {
+    const project = projectWith(['services/api/server.ts', 'apps/web/src/App.tsx', 'scripts/seed.ts'])
+    const scoped = scopeProject(project, 'backend')
+
+    expect([...scoped.files.keys()]).toEqual([join(root, 'services/api/server.ts')])
+    expect([...scoped.nodes.keys()]).toEqual([join(root, 'services/api/server.ts')])
+    expect(project.files.size).toBe(3)
+  })
+
+  it('should return the project itself without a scope', () => {
+    const project = projectWith(['scripts/seed.ts'])
+    expect(scopeProject(project)).toBe(project)
+  })
+
+  it('should list the defined scopes for an unknown name', () => {
+    expect(() => resolveScope(root, 'mobile')).toThrow('Unknown scope: mobile. Defined scopes: backend, ui')
+    rmSync(join(root, '.tree-sitter-mcp.json'))
+    expect(() => resolveScope(root, 'mobile')).toThrow('No scopes are defined in .tree-sitter-mcp.json')
+  })
+})
@@ -0,0 +1,56 @@
+/**
+ * Task runner discovery for Makefiles, Taskfiles and justfiles
+ */
+
+import { describe, it, expect } from 'vitest'
+import { resolve } from 'path'
+import { readFileSync } from 'fs'
+import { extractTasks, parseJustfile, parseMakefileTargets, parseTaskfile } from '../../../project/tasks.js'
+import type { TreeNode } from '../../../types/core.js'
+
+describe('Task discovery', () => {
+  const fixture = resolve(import.meta.dirname, '../../fixtures/shell-scripts')
+  const read = (file: string) => readFileSync(resolve(fixture, file), 'utf-8')
+
+  it('should read Makefile targets with dependencies and recipes', () => {
+    const tasks = parseMakefileTargets(read('Makefile'), 'Makefile')
+
+    expect(tasks.map(task => task.name)).toEqual(['build', 'test'])
+    expect(tasks[1]?.dependencies).toEqual(['build'])
+    expect(tasks[0]?.commands).toEqual(['echo "building $(BIN)"', 'go build -o $(BIN) ./cmd/service'])
+  })
+
+  it('should use self-documenting Make comments as descriptions', () => {
+    const [task] = parseMakefileTargets('help: ## Show this help\n\t@grep -E "^[a-z]+:" Makefile\n%.o: %.c\n\tcc -c $<\n', 'Makefile')
+
+    expect(task?.description).toBe('Show this help')
+    expect(task?.commands).toEqual(['grep -E "^[a-z]+:" Makefile'])
+  })
+
+  it('should read Taskfile tasks including task references', () => {
+    const tasks = parseTaskfile(read('Taskfile.yml'), 'Taskfile.yml')
+
+    expect(tasks.map(task => [task.name, task.description])).toEqual([['lint', 'Run static checks'], ['ci', undefined]])
+    expect(tasks[1]?.dependencies).toEqual(['lint'])
+    expect(tasks[1]?.commands).toEqual(['task test'])
+  })
+
+  it('should read justfile recipes and skip settings', () => {
+    const tasks = parseJustfile(read('justfile'), 'justfile')
+
+    expect(tasks.map(task => task.name)).toEqual(['setup', 'fetch'])
+    expect(tasks[0]?.description).toBe('Prepare a local environment')
+    expect(tasks[0]?.dependencies).toEqual(['fetch'])
+  })
+
+  it('should pick the runner from the file name', () => {
+    const files: TreeNode[] = ['Makefile', 'Taskfile.yml', 'justfile', 'scripts/setup.sh'].map(file => ({
+      id: file,
+      type: 'file',
+      path: resolve(fixture, file),
+      content: read(file),
+    }))
+
+    expect(new Set(extractTasks(files).map(task => task.runner))).toEqual(new Set(['make', 'task', 'just']))
+  })
+})
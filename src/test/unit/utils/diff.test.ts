@@ -0,0 +1,36 @@
+/**
+ * Unified diffs between file versions
+ */
+
+import { describe, it, expect } from 'vitest'
+import { unifiedDiff } from '../../../utils/diff.js'
+
+describe('unifiedDiff', () => {
+  it('should write hunks with context around each change', () => {
+    const before = ['a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l'].join('\n') + '\n'
+    const after = ['a', 'B', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l', 'm'].join('\n') + '\n'
+
+    const { diff, additions, deletions } = unifiedDiff('src/x.txt', before, after)
+    expect(diff).toBe(`--- a/src/x.txt
++++ b/src/x.txt
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -10,3 +10,4 @@
+ j
+ k
+ l
++m
+`)
+    expect([additions, deletions]).toEqual([2, 1])
+  })
+
+  it('should describe created files and unchanged ones', () => {
+    expect(unifiedDiff('new.txt', undefined, 'one\ntwo\n').diff).toBe('--- /dev/null\n+++ b/new.txt\n@@ -0,0 +1,2 @@\n+one\n+two\n')
+    expect(unifiedDiff('same.txt', 'x\n', 'x\n')).toEqual({ diff: '', additions: 0, deletions: 0 })
+  })
+})
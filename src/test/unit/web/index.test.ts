@@ -0,0 +1,65 @@
+/**
+ * Browser entry: grammar registration, the in-memory index and the POSIX path shim
+ */
+
+import { describe, it, expect, vi } from 'vitest'
+import { posix } from 'path'
+import { createWebIndex, loadGrammars, type WebTreeSitter } from '../../../web/index.js'
+import { getParser } from '../../../core/languages.js'
+import * as shim from '../../../web/shims/path.js'
+
+describe('web build', () => {
+  it('should register a web-tree-sitter parser per grammar after initializing the runtime', async () => {
+    const init = vi.fn(async () => {})
+    const load = vi.fn(async (input: string | Uint8Array) => ({ grammar: input }))
+    class WebParser {
+      language: unknown
+      setLanguage(language: unknown) {
+        this.language = language
+      }
+
+      parse(): unknown {
+        return undefined
+      }
+    }
+    const treeSitter: WebTreeSitter = { Parser: Object.assign(WebParser, { init }), Language: { load } }
+
+    await expect(loadGrammars(treeSitter, { klingon: '/grammars/klingon.wasm' })).rejects.toThrow('Unknown language: klingon')
+    expect(init).not.toHaveBeenCalled()
+
+    expect(await loadGrammars(treeSitter, { go: '/grammars/tree-sitter-go.wasm' })).toEqual(['go'])
+    expect(init).toHaveBeenCalledTimes(1)
+    expect(getParser('go')).toBeInstanceOf(WebParser)
+    expect((getParser('go') as unknown as WebParser).language).toEqual({ grammar: '/grammars/tree-sitter-go.wasm' })
+  })
+
+  it('should index supplied files, keeping those it cannot parse by content', () => {
+    const index = createWebIndex()
+    index.addFile('/docs/guide.md', '# Guide\n\n## Install\n\nCall loadGrammars before indexing.\n')
+    index.addFile('/src/greet.lua', 'local function greet(name)\n  return name\nend\n')
+    index.addFile('/src/unknown.xyz', 'loadGrammars\n')
+
+    expect(index.search('greet').map(result => [result.node.name, result.node.path])).toEqual([['greet', '/src/greet.lua']])
+    expect(index.search('Install').map(result => result.node.path)).toContain('/docs/guide.md')
+    expect(new Set(index.findUsage('loadGrammars').map(result => result.node.path))).toEqual(new Set(['/docs/guide.md', '/src/unknown.xyz']))
+
+    expect(index.removeFile('/src/greet.lua')).toBe(true)
+    expect(index.files()).toEqual(['/docs/guide.md', '/src/unknown.xyz'])
+    expect(index.search('greet')).toEqual([])
+  })
+
+  it('should resolve paths like path.posix', () => {
+    const cases: string[][] = [['/a/b', '../c'], ['a', './b/', '..'], ['/a', '/b', 'c'], ['', 'a'], ['/'], ['a/../..', 'b']]
+    for (const parts of cases) {
+      expect(shim.join(...parts)).toBe(posix.join(...parts))
+      expect(shim.resolve('/', ...parts)).toBe(posix.resolve('/', ...parts))
+    }
+    for (const path of ['/a/b/c.test.ts', 'a/b/', '/', 'file', '.hidden', 'a/b.c/d']) {
+      expect([shim.dirname(path), shim.basename(path), shim.extname(path), shim.normalize(path)])
+        .toEqual([posix.dirname(path), posix.basename(path), posix.extname(path), posix.normalize(path)])
+    }
+    expect(shim.basename('/a/b.ts', '.ts')).toBe('b')
+    expect(shim.relative('/a/b/c', '/a/d')).toBe(posix.relative('/a/b/c', '/a/d'))
+    expect(shim.relative('/a', '/a')).toBe('')
+  })
+})
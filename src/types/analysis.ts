@@ -2,7 +2,7 @@
  * Analysis-specific type definitions
  */
 
-import type { TreeNode, JsonObject } from './core.js'
+import type { TreeNode, JsonObject, GoModule, JvmModule } from './core.js'
 
 export interface AnalysisResult {
   findings: Finding[]
@@ -11,12 +11,49 @@ export interface AnalysisResult {
 }
 
 export interface Finding {
-  type: 'quality' | 'deadcode' | 'structure' | 'syntax'
+  type: 'quality' | 'deadcode' | 'structure' | 'syntax' | 'security' | 'concurrency' | 'resources' | 'nullability'
   category: string
   severity: 'critical' | 'warning' | 'info'
   location: string
   description: string
   metrics?: JsonObject
+  owners?: string[] // From CODEOWNERS, when the project has one
+}
+
+/**
+ * Findings that appeared or disappeared between two analysis runs
+ */
+export interface FindingsDiff {
+  added: Finding[]
+  resolved: Finding[]
+}
+
+/**
+ * Finding counts for one CODEOWNERS team or top-level directory. `trend` holds the change
+ * in each count since the baseline report, when one was given.
+ */
+export interface FindingRollup {
+  group: string
+  total: number
+  critical: number
+  warning: number
+  info: number
+  categories: Record<string, number>
+  trend?: RollupTrend
+}
+
+export interface RollupTrend {
+  total: number
+  critical: number
+  warning: number
+  info: number
+}
+
+export interface AnalysisRollup {
+  groupBy: 'owner' | 'directory'
+  generatedAt: string
+  groups: FindingRollup[]
+  baselineAt?: string // When the baseline the trends compare against was produced
 }
 
 export interface AnalysisMetrics {
@@ -24,6 +61,10 @@ export interface AnalysisMetrics {
   deadcode?: DeadcodeMetrics
   structure?: StructureMetrics
   syntax?: SyntaxMetrics
+  security?: SecurityMetrics
+  concurrency?: ConcurrencyMetrics
+  resources?: ResourceMetrics
+  nullability?: NullabilityMetrics
 }
 
 export interface AnalysisSummary {
@@ -70,6 +111,11 @@ export interface AnalysisOptions {
   includeDeadcode?: boolean
   includeStructure?: boolean
   includeSyntax?: boolean
+  includeSecurity?: boolean
+  includeConcurrency?: boolean
+  includeResources?: boolean
+  includeNullability?: boolean
+  minConfidence?: Confidence // For analyses grading their findings, currently nullability
   target?: string
   scope?: 'project' | 'file' | 'method'
   excludePaths?: string[]
@@ -83,9 +129,37 @@ export interface SyntaxMetrics {
   errorsByType: Record<string, number>
 }
 
+export interface SecurityMetrics {
+  analyzedFiles: number
+  analyzedFunctions: number
+  issuesByCategory: Record<string, number>
+}
+
+export interface ConcurrencyMetrics {
+  analyzedFiles: number
+  analyzedFunctions: number
+  issuesByCategory: Record<string, number>
+}
+
+export interface NullabilityMetrics {
+  analyzedFiles: number
+  analyzedFunctions: number
+  issuesByCategory: Record<string, number>
+}
+
+export type Confidence = 'low' | 'medium' | 'high'
+
+export interface ResourceMetrics {
+  analyzedFiles: number
+  analyzedFunctions: number
+  issuesByCategory: Record<string, number>
+}
+
 export interface MonorepoInfo {
   isMonorepo: boolean
   subProjects: string[]
   workspaces: string[]
   rootProject: string
+  goModules?: GoModule[]
+  jvmModules?: JvmModule[]
 }
\ No newline at end of file
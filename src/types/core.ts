@@ -8,6 +8,31 @@ export type JsonArray = JsonValue[]
 
 export type TreeSitterLanguage = unknown
 
+export type SymbolKind = 'function' | 'method' | 'class' | 'interface' | 'struct' | 'enum' | 'trait' | 'module' | 'type' | 'variable' | 'macro' | 'variant'
+export type SymbolVisibility = 'public' | 'protected' | 'internal' | 'private'
+
+/**
+ * Language-independent description of a declared symbol, filled in by every extractor.
+ * `internal` covers module, package and crate visibility.
+ */
+export interface SymbolInfo {
+  kind: SymbolKind
+  visibility: SymbolVisibility
+  signature: string // Declaration header without its body
+  container?: string // Enclosing class, struct, trait or module
+  doc?: string // Doc comment or docstring, without comment markers
+  typeParameters?: TypeParameter[] // Generic declarations only
+  macro?: string // Macro or derive whose expansion declares the symbol
+  handler?: string // Function a route, task or command registration is bound to
+  annotations?: string[] // Java annotations as written, e.g. `@Service`, `@GetMapping("/{id}")`
+  confidence?: 'low' // Guessed from line patterns in a file no language claims
+}
+
+export interface TypeParameter {
+  name: string
+  constraint: string // e.g. `comparable`, `~int | ~float64`, `interface{ String() string }`
+}
+
 export interface TreeNode {
   id: string
   type: string
@@ -23,7 +48,10 @@ export interface TreeNode {
   parent?: TreeNode
   skipped?: boolean
   skipReason?: string
+  cell?: number // Notebook cell index; lines are then relative to the cell
+  symbol?: SymbolInfo // Set on declarations; structural nodes such as files and cells have none
   rawNode?: any // Raw tree-sitter node for error detection
+  syntaxShape?: string[] // Named node types under an element, kept by index archives in place of `rawNode`
 }
 
 /**
@@ -79,6 +107,8 @@ export function createLightweightTreeNode(node: TreeNode): TreeNode {
     content: node.content,
     skipped: node.skipped,
     skipReason: node.skipReason,
+    cell: node.cell,
+    symbol: node.symbol,
     // Deliberately exclude reference properties to break memory chains
     parameters: undefined,
     children: undefined,
@@ -93,6 +123,8 @@ export interface ProjectConfig {
   ignoreDirs?: string[]
   maxDepth?: number
   autoWatch?: boolean
+  maxFiles?: number // Refuse to index a project or sub-project with more files than this
+  files?: string[] // Index only these files of the directory, e.g. a single-file dependency
 }
 
 export interface Project {
@@ -102,6 +134,125 @@ export interface Project {
   nodes: Map<string, TreeNode[]>
   isMonorepo?: boolean
   subProjects?: Project[]
+  diagnostics?: IndexDiagnostic[]
+  generation?: number // Incremented every time a new index snapshot is published
+  goModules?: GoModule[] // Modules of a Go workspace, used to resolve imports across sub-projects
+  pathAliases?: PathAlias[] // Bare specifiers like `@app/shared` that map to project files
+  packages?: PackageEntryPoints[] // Named packages of the project, imported by name from each other
+  bazelTargets?: BazelTarget[] // Targets declared by BUILD files when the project is a Bazel/Buck workspace
+  jvmModules?: JvmModule[] // Subprojects of a Gradle or sbt build, with the dependencies they declare
+  frameworks?: DetectedFramework[] // Frameworks and major libraries found when the project was parsed
+}
+
+/**
+ * A framework or major library a project uses, with the manifest or import that revealed it
+ */
+export interface DetectedFramework {
+  id: string // Stable identifier such as `nextjs`, `gin`, `django` or `spring-boot`
+  name: string
+  directory: string // Directory of the manifest, or of the first file importing it
+  source: string // Manifest file name, or `imports`
+}
+
+/**
+ * A Go module rooted at a go.mod file, identified by its declared module path
+ */
+export interface GoModule {
+  path: string
+  directory: string
+}
+
+/**
+ * A Gradle or sbt subproject. Gradle names are project paths such as `:core:api`, sbt names
+ * are the project ids of the build definition.
+ */
+export interface JvmModule {
+  name: string
+  tool: 'gradle' | 'sbt'
+  directory: string
+  buildFile?: string
+  dependsOn: string[] // Other subprojects of the same build
+  dependencies: JvmDependency[]
+}
+
+/**
+ * An artifact declared in a build file. `coordinate` is `group:artifact`, or the project path
+ * when the dependency is on another subproject.
+ */
+export interface JvmDependency {
+  coordinate: string
+  version?: string
+  configuration: string // `implementation`, `testImplementation`, or the sbt configuration such as `test`
+  line: number
+}
+
+/**
+ * A module specifier alias from tsconfig/jsconfig `paths`, jest `moduleNameMapper` or a
+ * bundler's `resolve.alias`. `pattern` and `targets` may hold one `*` wildcard; targets
+ * are absolute.
+ */
+export interface PathAlias {
+  pattern: string
+  targets: string[]
+  scope: string // Directory of the config file; the alias applies to files below it
+  source: string // Config file the alias was read from
+}
+
+/**
+ * The entry points a package.json declares: `exports` and `imports` as written, which may
+ * map one subpath to different files per condition (`import`, `require`, `node`, ...), and
+ * the legacy `main` and `module` fields
+ */
+export interface PackageEntryPoints {
+  name: string
+  directory: string
+  source: string // The package.json
+  exports?: unknown
+  imports?: unknown // `#internal` specifiers private to the package
+  main?: string
+  module?: string
+}
+
+/**
+ * A Bazel/Buck target read from a BUILD file. Explicit sources are stored as absolute
+ * paths, glob patterns stay relative to the package directory.
+ */
+export interface BazelTarget {
+  label: string
+  name: string
+  rule: string
+  packageDir: string
+  buildFile: string
+  srcs: string[]
+  globs: { include: string[], exclude: string[] }[]
+  visibility: string[]
+}
+
+/**
+ * A file that failed during indexing. Recorded instead of aborting the whole parse
+ * so one pathological file or grammar bug cannot take the server down.
+ */
+export interface IndexDiagnostic {
+  path: string
+  stage: 'parse' | 'update'
+  message: string
+  timestamp: number
+}
+
+/**
+ * One file a symbol is re-exported through, under the name it has there
+ */
+export interface ReExportHop {
+  path: string // Re-exporting file, or package directory for Go
+  name: string
+}
+
+/**
+ * A definition a query resolves to through import aliases or re-exports
+ */
+export interface AliasMatch {
+  node: TreeNode
+  reExports: ReExportHop[] // From the module the query named to the one before the definition
 }
 
 export interface SearchOptions {
@@ -110,6 +261,7 @@ export interface SearchOptions {
   exactMatch?: boolean
   types?: string[]
   pathPattern?: string
+  aliasMatches?: AliasMatch[] // Definitions the query resolves to through aliases; ranked as exact matches
 
   // Content inclusion options
   forceContentInclusion?: boolean
@@ -122,6 +274,7 @@ export interface SearchResult {
   score: number
   matches: string[]
   context?: string
+  reExports?: ReExportHop[]
 
   // Content inclusion fields
   contentIncluded: boolean
@@ -137,6 +290,8 @@ export interface FindUsageResult {
   endLine: number
   startColumn: number
   endColumn: number
+  via?: string // Alias the identifier was found under, e.g. `utils.FormatDate`
+  typeArguments?: string[] // Explicit type arguments of a generic instantiation, e.g. `Max[int]`
 }
 
 export interface FileChange {
@@ -148,15 +303,23 @@ export interface FileChange {
 export interface LanguageConfig {
   name: string
   extensions: string[]
+  filePatterns?: readonly RegExp[] // Matched against the base name, for files like Dockerfile that have no extension
   parserName: string
   functionTypes: string[]
   classTypes: string[]
+  variableTypes?: string[]
+  optional?: boolean // Grammar is an optional dependency; files are still indexed without it
+  extractElements?: (content: string, filePath: string) => TreeNode[] // Declarations of a file, in place of the syntax tree walk
+  extraElements?: (content: string, filePath: string) => TreeNode[] // Text-based extraction added to what the syntax tree yields
+  preprocess?: (content: string) => string // Rewrites content into source the grammar accepts, keeping line numbers
 }
 
 export interface ImportContext {
   aliases?: Record<string, string>
   framework?: string
   basePath?: string
+  goModules?: GoModule[]
+  pathAliases?: PathAlias[]
 }
 
 export interface ResolutionResult {
@@ -0,0 +1,121 @@
+/**
+ * Line diffs - unified diffs between two versions of a file
+ */
+
+export interface FileDiff {
+  diff: string // Empty when the versions have the same lines
+  additions: number
+  deletions: number
+}
+
+interface DiffLine {
+  type: ' ' | '-' | '+'
+  text: string
+  oldBefore: number // Lines of the old version before this one
+  newBefore: number
+}
+
+// Beyond this many line pairs the changed region is shown as removed and re-added whole
+const MAX_LCS_CELLS = 4_000_000
+
+/**
+ * A unified diff from `before` to `after`, with `context` unchanged lines around each change.
+ * `before` is undefined for a file the change creates, `after` for one it deletes.
+ */
+export function unifiedDiff(path: string, before: string | undefined, after: string | undefined, context = 3): FileDiff {
+  const lines = diffLines(splitLines(before ?? ''), splitLines(after ?? ''))
+  const changes = lines.flatMap((line, index) => line.type === ' ' ? [] : [index])
+  if (changes.length === 0) return { diff: '', additions: 0, deletions: 0 }
+
+  const output = [before === undefined ? '--- /dev/null' : `--- a/${path}`, after === undefined ? '+++ /dev/null' : `+++ b/${path}`]
+  let position = 0
+  while (position < changes.length) {
+    const start = Math.max(0, changes[position]! - context)
+    let last = changes[position]!
+    while (position + 1 < changes.length && changes[position + 1]! - last <= context * 2) last = changes[++position]!
+    position++
+    const hunk = lines.slice(start, Math.min(lines.length, last + context + 1))
+
+    const oldCount = hunk.filter(line => line.type !== '+').length
+    const newCount = hunk.filter(line => line.type !== '-').length
+    const oldStart = hunk[0]!.oldBefore + (oldCount > 0 ? 1 : 0)
+    const newStart = hunk[0]!.newBefore + (newCount > 0 ? 1 : 0)
+    output.push(`@@ -${oldStart},${oldCount} +${newStart},${newCount} @@`, ...hunk.map(line => `${line.type}${line.text}`))
+  }
+
+  return {
+    diff: `${output.join('\n')}\n`,
+    additions: lines.filter(line => line.type === '+').length,
+    deletions: lines.filter(line => line.type === '-').length,
+  }
+}
+
+function splitLines(content: string): string[] {
+  return content === '' ? [] : content.replace(/\n$/, '').split('\n')
+}
+
+/**
+ * The lines of both versions in order, the unchanged ones a longest common subsequence
+ */
+function diffLines(before: string[], after: string[]): DiffLine[] {
+  let prefix = 0
+  while (prefix < before.length && prefix < after.length && before[prefix] === after[prefix]) prefix++
+  let suffix = 0
+  while (suffix < before.length - prefix && suffix < after.length - prefix
+    && before[before.length - 1 - suffix] === after[after.length - 1 - suffix]) suffix++
+
+  const removed = before.slice(prefix, before.length - suffix)
+  const added = after.slice(prefix, after.length - suffix)
+  const types: DiffLine['type'][] = [
+    ...Array<' '>(prefix).fill(' '),
+    ...middleTypes(removed, added),
+    ...Array<' '>(suffix).fill(' '),
+  ]
+
+  const lines: DiffLine[] = []
+  let oldIndex = 0
+  let newIndex = 0
+  for (const type of types) {
+    lines.push({ type, text: type === '+' ? after[newIndex]! : before[oldIndex]!, oldBefore: oldIndex, newBefore: newIndex })
+    if (type !== '+') oldIndex++
+    if (type !== '-') newIndex++
+  }
+  return lines
+}
+
+function middleTypes(removed: string[], added: string[]): DiffLine['type'][] {
+  const width = added.length + 1
+  if (removed.length * added.length > MAX_LCS_CELLS) {
+    return [...Array<'-'>(removed.length).fill('-'), ...Array<'+'>(added.length).fill('+')]
+  }
+
+  // common[i * width + j] is the longest common subsequence of removed[i..] and added[j..]
+  const common = new Uint32Array((removed.length + 1) * width)
+  for (let i = removed.length - 1; i >= 0; i--) {
+    for (let j = added.length - 1; j >= 0; j--) {
+      common[i * width + j] = removed[i] === added[j]
+        ? common[(i + 1) * width + j + 1]! + 1
+        : Math.max(common[(i + 1) * width + j]!, common[i * width + j + 1]!)
+    }
+  }
+
+  const types: DiffLine['type'][] = []
+  let i = 0
+  let j = 0
+  while (i < removed.length || j < added.length) {
+    if (i < removed.length && j < added.length && removed[i] === added[j]) {
+      types.push(' ')
+      i++
+      j++
+    }
+    else if (j >= added.length || (i < removed.length && common[(i + 1) * width + j]! >= common[i * width + j + 1]!)) {
+      types.push('-')
+      i++
+    }
+    else {
+      types.push('+')
+      j++
+    }
+  }
+  return types
+}
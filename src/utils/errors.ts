@@ -30,6 +30,11 @@ export const ERROR_CODES = {
   PARSE_ERROR: 'PARSE_ERROR',
   FILE_ERROR: 'FILE_ERROR',
   SEARCH_ERROR: 'SEARCH_ERROR',
+  // Refusals of a shared server, named after the gRPC status they are reported with
+  UNAUTHENTICATED: 'UNAUTHENTICATED',
+  PERMISSION_DENIED: 'PERMISSION_DENIED',
+  RESOURCE_EXHAUSTED: 'RESOURCE_EXHAUSTED',
+  THROTTLED: 'THROTTLED', // A call limit was hit; the context says which and when to retry
 } as const
 
 export type ErrorCode = typeof ERROR_CODES[keyof typeof ERROR_CODES]
@@ -95,4 +95,116 @@ export function debounce<T extends (...args: never[]) => unknown>(
     if (timeout) clearTimeout(timeout)
     timeout = setTimeout(() => func(...args), wait)
   }
-}
\ No newline at end of file
+}
+
+/**
+ * Converts a glob (`*`, `**`, `?`) into an anchored regular expression over `/`-separated paths
+ */
+export function globToRegExp(glob: string): RegExp {
+  let pattern = ''
+
+  for (let i = 0; i < glob.length; i++) {
+    const char = glob[i]!
+    if (char === '*') {
+      if (glob[i + 1] === '*') {
+        const slash = glob[i + 2] === '/'
+        pattern += slash ? '(?:.*/)?' : '.*'
+        i += slash ? 2 : 1
+      }
+      else {
+        pattern += '[^/]*'
+      }
+    }
+    else if (char === '?') {
+      pattern += '[^/]'
+    }
+    else {
+      pattern += char.replace(/[.+^${}()|[\]\\]/g, '\\$&')
+    }
+  }
+
+  return new RegExp(`^${pattern}$`)
+}
+
+/**
+ * Splits text at a separator that isn't nested in brackets or quotes, e.g. call arguments
+ */
+export function splitTopLevel(body: string, separator = ','): string[] {
+  const parts: string[] = []
+  let depth = 0
+  let quote: string | null = null
+  let current = ''
+
+  for (let i = 0; i < body.length; i++) {
+    const char = body[i]!
+    if (quote) {
+      if (char === '\\') {
+        current += char + (body[i + 1] ?? '')
+        i++
+        continue
+      }
+      if (char === quote) quote = null
+    }
+    else if (char === '"' || char === '\'' || char === '`') quote = char
+    else if (char === '(' || char === '[' || char === '{') depth++
+    else if (char === ')' || char === ']' || char === '}') depth--
+    else if (char === separator && depth === 0) {
+      parts.push(current)
+      current = ''
+      continue
+    }
+    current += char
+  }
+
+  if (current.trim()) parts.push(current)
+  return parts
+}
+
+export interface CallArguments {
+  args: string[] // Trimmed argument source text
+  end: number // Index just past the closing parenthesis
+}
+
+/**
+ * Reads the arguments of a call whose opening parenthesis is at `open`, skipping nested
+ * brackets and string literals. Returns null when the call isn't closed within 5000 characters.
+ */
+export function readCallArguments(content: string, open: number): CallArguments | null {
+  const args: string[] = []
+  let depth = 0
+  let start = open + 1
+
+  for (let i = open; i < content.length && i < open + 5000; i++) {
+    const char = content[i]!
+    if (char === '"' || char === '\'' || char === '`') {
+      i = skipString(content, i)
+    }
+    else if (char === '(' || char === '[' || char === '{') {
+      depth++
+    }
+    else if (char === ')' || char === ']' || char === '}') {
+      depth--
+      if (depth === 0) {
+        const last = content.substring(start, i).trim()
+        if (last || args.length > 0) args.push(last)
+        return { args, end: i + 1 }
+      }
+    }
+    else if (char === ',' && depth === 1) {
+      args.push(content.substring(start, i).trim())
+      start = i + 1
+    }
+  }
+
+  return null
+}
+
+function skipString(content: string, start: number): number {
+  const quote = content[start]
+  for (let i = start + 1; i < content.length; i++) {
+    if (content[i] === '\\') i++
+    else if (content[i] === quote) return i
+    else if (content[i] === '\n' && quote !== '`') return i
+  }
+  return content.length
+}
@@ -0,0 +1,371 @@
+/**
+ * Minimal YAML reader - the subset used by OpenAPI specs, compose files, CI workflows and task runners.
+ * Supports block mappings and sequences, flow collections, quoted and block scalars, anchors,
+ * aliases, merge keys and multiple documents. Line numbers of mapping keys are kept for lookups.
+ */
+
+interface YamlLine {
+  indent: number
+  text: string
+  line: number
+}
+
+const keyLines = new WeakMap<object, Record<string, number>>()
+
+/**
+ * Parses every document in a YAML stream
+ */
+export function parseYamlDocuments(content: string): unknown[] {
+  const documents: YamlLine[][] = [[]]
+
+  content.split(/\r?\n/).forEach((raw, index) => {
+    if (/^---(\s|$)/.test(raw)) {
+      documents.push([])
+      const rest = raw.substring(3).trim()
+      if (rest) documents[documents.length - 1]!.push({ indent: 4, text: stripComment(rest), line: index + 1 })
+      return
+    }
+    if (/^\.\.\.(\s|$)/.test(raw)) return
+
+    const text = stripComment(raw)
+    if (!text.trim()) return
+    documents[documents.length - 1]!.push({ indent: text.length - text.trimStart().length, text: text.trim(), line: index + 1 })
+  })
+
+  return documents
+    .filter(lines => lines.length > 0)
+    .map(parseDocumentLines)
+}
+
+/**
+ * Parses a single-document YAML string
+ */
+export function parseYaml(content: string): unknown {
+  return parseYamlDocuments(content)[0] ?? null
+}
+
+/**
+ * Returns the 1-based line a mapping key was declared on, if the mapping came from this parser
+ */
+export function getYamlKeyLine(mapping: unknown, key: string): number | undefined {
+  return mapping && typeof mapping === 'object' ? keyLines.get(mapping)?.[key] : undefined
+}
+
+function parseDocumentLines(lines: YamlLine[]): unknown {
+  let index = 0
+  const anchors = new Map<string, unknown>()
+
+  function parseBlock(indent: number): unknown {
+    const line = lines[index]
+    if (!line || line.indent < indent) return null
+
+    if (line.text === '-' || line.text.startsWith('- ')) {
+      return parseSequence(line.indent)
+    }
+    if (findKeySeparator(line.text) !== -1) {
+      return parseMapping(line.indent)
+    }
+
+    index++
+    return parseScalar(collectContinuation(line.text, line.indent))
+  }
+
+  function parseMapping(indent: number): Record<string, unknown> {
+    const mapping: Record<string, unknown> = {}
+    const declaredLines: Record<string, number> = {}
+    keyLines.set(mapping, declaredLines)
+
+    while (index < lines.length) {
+      const line = lines[index]!
+      if (line.indent !== indent) break
+
+      const separator = findKeySeparator(line.text)
+      if (separator === -1) break
+
+      const key = unquote(line.text.substring(0, separator).trim())
+      const rest = line.text.substring(separator + 1).trim()
+      index++
+
+      const value = parseValue(rest, indent, true)
+      if (key === '<<') {
+        for (const source of Array.isArray(value) ? value : [value]) {
+          if (source && typeof source === 'object') {
+            for (const [mergedKey, mergedValue] of Object.entries(source)) {
+              if (!(mergedKey in mapping)) mapping[mergedKey] = mergedValue
+            }
+          }
+        }
+        continue
+      }
+
+      mapping[key] = value
+      declaredLines[key] = line.line
+    }
+
+    return mapping
+  }
+
+  function parseSequence(indent: number): unknown[] {
+    const sequence: unknown[] = []
+
+    while (index < lines.length) {
+      const line = lines[index]!
+      if (line.indent !== indent || !(line.text === '-' || line.text.startsWith('- '))) break
+
+      const itemText = line.text.substring(1).trim()
+      if (itemText && findKeySeparator(itemText) !== -1 && !/^[[{]/.test(itemText)) {
+        // "- key: value" starts a mapping whose keys align with the first one
+        const itemIndent = indent + (line.text.length - itemText.length)
+        lines[index] = { indent: itemIndent, text: itemText, line: line.line }
+        sequence.push(parseMapping(itemIndent))
+        continue
+      }
+
+      index++
+      sequence.push(parseValue(itemText, indent, false))
+    }
+
+    return sequence
+  }
+
+  function parseValue(rest: string, indent: number, allowSameIndentSequence: boolean): unknown {
+    let text = rest
+    let anchor: string | undefined
+
+    const anchorMatch = text.match(/^&(\S+)\s*/)
+    if (anchorMatch) {
+      anchor = anchorMatch[1]
+      text = text.substring(anchorMatch[0].length)
+    }
+    text = text.replace(/^!!?[\w/:.-]*\s*/, '')
+
+    let value: unknown
+    if (!text) {
+      const next = lines[index]
+      if (next && next.indent > indent) {
+        value = parseBlock(next.indent)
+      }
+      else if (next && allowSameIndentSequence && next.indent === indent && (next.text === '-' || next.text.startsWith('- '))) {
+        value = parseSequence(indent)
+      }
+      else {
+        value = null
+      }
+    }
+    else if (/^[|>][+-]?\d*$/.test(text)) {
+      value = parseBlockScalar(text, indent)
+    }
+    else if (text.startsWith('*')) {
+      value = anchors.get(text.substring(1).trim()) ?? null
+    }
+    else if (text.startsWith('[') || text.startsWith('{')) {
+      value = parseFlow(collectFlow(text))
+    }
+    else {
+      value = parseScalar(collectContinuation(text, indent))
+    }
+
+    if (anchor) anchors.set(anchor, value)
+    return value
+  }
+
+  function parseBlockScalar(header: string, indent: number): string {
+    const folded = header.startsWith('>')
+    const chomp = header.includes('-') ? 'strip' : header.includes('+') ? 'keep' : 'clip'
+    const collected: YamlLine[] = []
+
+    while (index < lines.length && lines[index]!.indent > indent) {
+      collected.push(lines[index]!)
+      index++
+    }
+
+    const baseIndent = collected.length > 0 ? Math.min(...collected.map(line => line.indent)) : 0
+    const body = collected.map(line => ' '.repeat(line.indent - baseIndent) + line.text)
+    const text = folded ? body.join(' ') : body.join('\n')
+
+    if (chomp === 'strip') return text
+    return text + '\n'
+  }
+
+  function collectContinuation(text: string, indent: number): string {
+    if (/^["']/.test(text) && isClosedQuote(text)) return text
+
+    let result = text
+    while (index < lines.length) {
+      const next = lines[index]!
+      if (next.indent <= indent || findKeySeparator(next.text) !== -1 || next.text.startsWith('- ')) break
+      result += ' ' + next.text
+      index++
+    }
+    return result
+  }
+
+  function collectFlow(text: string): string {
+    let result = text
+    while (!isBalanced(result) && index < lines.length) {
+      result += ' ' + lines[index]!.text
+      index++
+    }
+    return result
+  }
+
+  const first = lines[0]
+  return first ? parseBlock(first.indent) : null
+}
+
+function findKeySeparator(text: string): number {
+  if (/^[[{]/.test(text) || text.startsWith('- ')) return -1
+
+  if (text.startsWith('"') || text.startsWith('\'')) {
+    const quote = text[0]!
+    let i = 1
+    while (i < text.length) {
+      if (text[i] === '\\' && quote === '"') i++
+      else if (text[i] === quote) {
+        if (quote === '\'' && text[i + 1] === '\'') i++
+        else break
+      }
+      i++
+    }
+    return text[i + 1] === ':' && (i + 2 === text.length || /\s/.test(text[i + 2]!)) ? i + 1 : -1
+  }
+
+  const match = text.match(/:(\s|$)/)
+  return match?.index ?? -1
+}
+
+function stripComment(line: string): string {
+  let quote: string | null = null
+
+  for (let i = 0; i < line.length; i++) {
+    const char = line[i]!
+    if (quote) {
+      if (char === '\\' && quote === '"') i++
+      else if (char === quote) quote = null
+    }
+    else if (char === '"' || char === '\'') {
+      if (i === 0 || /[\s:[{,-]/.test(line[i - 1]!)) quote = char
+    }
+    else if (char === '#' && (i === 0 || /\s/.test(line[i - 1]!))) {
+      return line.substring(0, i).trimEnd()
+    }
+  }
+
+  return line.trimEnd()
+}
+
+function unquote(text: string): string {
+  if (text.startsWith('"') && text.endsWith('"') && text.length >= 2) {
+    try {
+      return JSON.parse(text)
+    }
+    catch {
+      return text.slice(1, -1)
+    }
+  }
+  if (text.startsWith('\'') && text.endsWith('\'') && text.length >= 2) {
+    return text.slice(1, -1).replace(/''/g, '\'')
+  }
+  return text
+}
+
+function isClosedQuote(text: string): boolean {
+  const quote = text[0]!
+  return text.length > 1 && text.endsWith(quote)
+}
+
+function isBalanced(text: string): boolean {
+  let depth = 0
+  let quote: string | null = null
+
+  for (let i = 0; i < text.length; i++) {
+    const char = text[i]!
+    if (quote) {
+      if (char === '\\' && quote === '"') i++
+      else if (char === quote) quote = null
+    }
+    else if (char === '"' || char === '\'') quote = char
+    else if (char === '[' || char === '{') depth++
+    else if (char === ']' || char === '}') depth--
+  }
+
+  return depth <= 0
+}
+
+function parseScalar(text: string): unknown {
+  const value = text.trim()
+  if (value.startsWith('"') || value.startsWith('\'')) return unquote(value)
+  if (value === '' || value === '~' || value === 'null' || value === 'Null' || value === 'NULL') return null
+  if (/^(true|True|TRUE)$/.test(value)) return true
+  if (/^(false|False|FALSE)$/.test(value)) return false
+  if (/^[-+]?(\d[\d_]*)$/.test(value)) return Number(value.replace(/_/g, ''))
+  if (/^0x[0-9a-fA-F]+$/.test(value)) return parseInt(value, 16)
+  if (/^[-+]?(\d+\.\d*|\.\d+|\d+)([eE][-+]?\d+)?$/.test(value)) return Number(value)
+  return value
+}
+
+function parseFlow(text: string): unknown {
+  let i = 0
+
+  function skipSpace() {
+    while (i < text.length && /\s/.test(text[i]!)) i++
+  }
+
+  function parseItem(terminators: string): unknown {
+    skipSpace()
+    const char = text[i]
+    if (char === '[') return parseList()
+    if (char === '{') return parseMap()
+
+    const start = i
+    if (char === '"' || char === '\'') {
+      i++
+      while (i < text.length && text[i] !== char) {
+        if (text[i] === '\\' && char === '"') i++
+        i++
+      }
+      i++
+      return unquote(text.substring(start, i))
+    }
+
+    while (i < text.length && !terminators.includes(text[i]!)) i++
+    return parseScalar(text.substring(start, i))
+  }
+
+  function parseList(): unknown[] {
+    const list: unknown[] = []
+    i++
+    skipSpace()
+    while (i < text.length && text[i] !== ']') {
+      list.push(parseItem(',]'))
+      skipSpace()
+      if (text[i] === ',') i++
+      skipSpace()
+    }
+    i++
+    return list
+  }
+
+  function parseMap(): Record<string, unknown> {
+    const map: Record<string, unknown> = {}
+    i++
+    skipSpace()
+    while (i < text.length && text[i] !== '}') {
+      const key = String(parseItem(':,}'))
+      skipSpace()
+      let value: unknown = null
+      if (text[i] === ':') {
+        i++
+        value = parseItem(',}')
+      }
+      map[key] = value
+      skipSpace()
+      if (text[i] === ',') i++
+      skipSpace()
+    }
+    i++
+    return map
+  }
+
+  return parseItem('')
+}
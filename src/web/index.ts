@@ -0,0 +1,102 @@
+/**
+ * Browser entry - the parsing and search core without the MCP server, the CLI or the file
+ * system. Grammars run on web-tree-sitter's WebAssembly runtime; files are added as strings by
+ * the host (a browser tool, a VS Code web extension) instead of being read from disk.
+ */
+
+import type Parser from 'tree-sitter'
+import { getLanguageByName, registerParser } from '../core/languages.js'
+import { parseContent } from '../core/parser.js'
+import { findUsage, searchCode } from '../core/search.js'
+import { createError } from '../utils/errors.js'
+import type { FindUsageResult, SearchOptions, SearchResult, TreeNode } from '../types/core.js'
+
+export { searchCode, findUsage } from '../core/search.js'
+export { parseContent } from '../core/parser.js'
+export { getLanguageForFile, LANGUAGE_CONFIGS } from '../core/languages.js'
+export type { FindUsageResult, SearchOptions, SearchResult, TreeNode } from '../types/core.js'
+
+// The parts of web-tree-sitter used here, so the package is the host's dependency and not ours
+export interface WebTreeSitterParser {
+  setLanguage(language: unknown): unknown
+  parse(source: string): unknown
+}
+
+export interface WebTreeSitter {
+  Parser: { init(options?: object): Promise<void>, new (): WebTreeSitterParser }
+  Language: { load(input: string | Uint8Array): Promise<unknown> }
+}
+
+export interface WebIndex {
+  addFile(path: string, content: string): TreeNode
+  removeFile(path: string): boolean
+  files(): string[]
+  nodes(): TreeNode[]
+  search(query: string, options?: SearchOptions): SearchResult[]
+  findUsage(identifier: string, options?: Parameters<typeof findUsage>[2]): FindUsageResult[]
+}
+
+/**
+ * Initializes web-tree-sitter and registers a parser per language from its grammar, given as
+ * the URL of its `.wasm` file or its bytes and keyed by language name (`typescript`, `go`).
+ * Returns the languages registered; languages left out still index through their fallbacks.
+ */
+export async function loadGrammars(
+  treeSitter: WebTreeSitter,
+  grammars: Record<string, string | Uint8Array>,
+  initOptions?: object,
+): Promise<string[]> {
+  for (const name of Object.keys(grammars)) {
+    if (!getLanguageByName(name)) {
+      throw createError('PARSE_ERROR', `Unknown language: ${name}`, { language: name })
+    }
+  }
+
+  await treeSitter.Parser.init(initOptions)
+  const loaded: string[] = []
+  for (const [name, input] of Object.entries(grammars)) {
+    const parser = new treeSitter.Parser()
+    parser.setLanguage(await treeSitter.Language.load(input))
+    registerParser(name, parser as unknown as Parser)
+    loaded.push(name)
+  }
+  return loaded
+}
+
+/**
+ * An in-memory index of files the host supplies. A file whose grammar was not loaded is kept
+ * with its content only, so usages are still found in it.
+ */
+export function createWebIndex(): WebIndex {
+  const files = new Map<string, TreeNode[]>()
+
+  function parse(path: string, content: string): TreeNode {
+    try {
+      return parseContent(content, path)
+    }
+    catch {
+      return { id: `file-${Date.now()}`, type: 'file', path, content }
+    }
+  }
+
+  function allNodes(): TreeNode[] {
+    return [...files.values()].flat()
+  }
+
+  return {
+    addFile(path, content) {
+      const fileNode = parse(path, content)
+      files.set(path, flatten(fileNode))
+      return fileNode
+    },
+    removeFile: path => files.delete(path),
+    files: () => [...files.keys()],
+    nodes: allNodes,
+    search: (query, options) => searchCode(query, allNodes(), options),
+    findUsage: (identifier, options) => findUsage(identifier, allNodes(), options),
+  }
+}
+
+function flatten(node: TreeNode): TreeNode[] {
+  return [node, ...(node.children ?? []).flatMap(flatten)]
+}
@@ -0,0 +1,52 @@
+/**
+ * Stand-ins for the Node modules (`fs`, `child_process`, `chokidar`, ...) the core imports for
+ * features the browser build has no use for. Importing them is harmless; calling them throws.
+ */
+
+function unavailable(name: string) {
+  return (..._args: unknown[]): never => {
+    throw new Error(`${name} is not available in the browser`)
+  }
+}
+
+export const readFileSync = unavailable('readFileSync')
+export const writeFileSync = unavailable('writeFileSync')
+export const existsSync = (_path: string) => false
+export const statSync = unavailable('statSync')
+export const readdirSync = unavailable('readdirSync')
+export const mkdirSync = unavailable('mkdirSync')
+export const mkdtempSync = unavailable('mkdtempSync')
+export const rmSync = unavailable('rmSync')
+export const symlinkSync = unavailable('symlinkSync')
+
+export const readFile = unavailable('readFile')
+export const writeFile = unavailable('writeFile')
+export const readdir = unavailable('readdir')
+export const stat = unavailable('stat')
+export const access = unavailable('access')
+export const mkdir = unavailable('mkdir')
+export const rm = unavailable('rm')
+export const constants = { F_OK: 0, R_OK: 4, W_OK: 2, X_OK: 1 }
+
+export const execFile = unavailable('execFile')
+export const execFileSync = unavailable('execFileSync')
+export const execSync = unavailable('execSync')
+export const spawn = unavailable('spawn')
+
+export const watch = unavailable('watch')
+export class FSWatcher {}
+
+export const createHash = unavailable('createHash')
+export const createServer = unavailable('createServer')
+export const gzipSync = unavailable('gzipSync')
+export const gunzipSync = unavailable('gunzipSync')
+export const homedir = () => '/'
+export const tmpdir = () => '/tmp'
+export const fileURLToPath = (url: string | URL) => new URL(url).pathname
+export const pathToFileURL = (path: string) => new URL(`file://${path}`)
+
+// Called at module load: optional grammars are then simply not installed
+export const createRequire = (_url: string) => unavailable('require')
+export const promisify = (fn: (...args: unknown[]) => unknown) => async (...args: unknown[]) => fn(...args)
+
+export default {}
@@ -0,0 +1,69 @@
+/**
+ * POSIX `path` for the browser build - paths the host supplies are slash-separated and the
+ * working directory is `/`
+ */
+
+export const sep = '/'
+export const delimiter = ':'
+
+export function isAbsolute(path: string): boolean {
+  return path.startsWith('/')
+}
+
+export function normalize(path: string): string {
+  if (!path) return '.'
+  const absolute = isAbsolute(path)
+  const segments: string[] = []
+  for (const segment of path.split('/')) {
+    if (!segment || segment === '.') continue
+    if (segment === '..' && segments.length > 0 && segments[segments.length - 1] !== '..') segments.pop()
+    else if (segment !== '..' || !absolute) segments.push(segment)
+  }
+  const joined = segments.join('/')
+  const trailing = path.endsWith('/') && joined ? '/' : ''
+  return absolute ? `/${joined}${trailing}` : (joined ? `${joined}${trailing}` : '.')
+}
+
+export function join(...paths: string[]): string {
+  const joined = paths.filter(Boolean).join('/')
+  return joined ? normalize(joined) : '.'
+}
+
+export function resolve(...paths: string[]): string {
+  let resolved = ''
+  for (let i = paths.length - 1; i >= 0 && !isAbsolute(resolved); i--) {
+    if (paths[i]) resolved = resolved ? `${paths[i]}/${resolved}` : paths[i]!
+  }
+  const normalized = normalize(`/${resolved}`)
+  return normalized.length > 1 && normalized.endsWith('/') ? normalized.slice(0, -1) : normalized
+}
+
+export function dirname(path: string): string {
+  const trimmed = path.length > 1 ? path.replace(/\/+$/, '') : path
+  const index = trimmed.lastIndexOf('/')
+  if (index < 0) return '.'
+  return index === 0 ? '/' : trimmed.substring(0, index)
+}
+
+export function basename(path: string, suffix?: string): string {
+  const base = path.replace(/\/+$/, '').split('/').pop() ?? ''
+  return suffix && base !== suffix && base.endsWith(suffix) ? base.slice(0, -suffix.length) : base
+}
+
+export function extname(path: string): string {
+  const base = basename(path)
+  const index = base.lastIndexOf('.')
+  return index > 0 ? base.substring(index) : ''
+}
+
+export function relative(from: string, to: string): string {
+  const fromSegments = resolve(from).split('/').filter(Boolean)
+  const toSegments = resolve(to).split('/').filter(Boolean)
+  let common = 0
+  while (common < fromSegments.length && fromSegments[common] === toSegments[common]) common++
+  return [...fromSegments.slice(common).map(() => '..'), ...toSegments.slice(common)].join('/')
+}
+
+export const posix = { sep, delimiter, isAbsolute, normalize, join, resolve, dirname, basename, extname, relative }
+
+export default posix